@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// defaultGuardInterval is how often each symbol is checked when no interval is given
+// defaultGuardInterval 未指定间隔时每个交易对的检查频率
+const defaultGuardInterval = 15 * time.Second
+
+// Guard is a lightweight watchdog meant to run next to the main bot as a second process.
+// It has no LLM and no scheduler: it only watches the symbol leases in the shared database,
+// and while the main bot's lease on a symbol has expired it keeps that symbol's position
+// protected (reconciliation, stop-loss order presence, partial take-profit).
+// As soon as the main bot renews its lease again the guard stands down for that symbol.
+// Guard 是与主程序并行运行的轻量守护进程。
+// 不使用 LLM 和调度器：只观察共享数据库中的交易对租约，当主程序对某交易对的租约过期时，
+// 接管该交易对持仓的保护（对账、止损单检查、分批止盈）。主程序恢复续约后立即退出接管。
+func main() {
+	interval := defaultGuardInterval
+	if len(os.Args) >= 2 {
+		if os.Args[1] == "-h" || os.Args[1] == "--help" {
+			printUsage()
+			return
+		}
+		seconds, err := strconv.Atoi(os.Args[1])
+		if err != nil || seconds <= 0 {
+			printUsage()
+			os.Exit(1)
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger.Init(cfg.DebugMode)
+	log := logger.Global
+
+	log.Header("加密货币交易机器人 - 止损守护进程", '=', 80)
+	log.Info(fmt.Sprintf("交易对: %v", cfg.CryptoSymbols))
+	log.Info(fmt.Sprintf("检查间隔: %v", interval))
+
+	if err := os.MkdirAll(filepath.Dir(cfg.DatabasePath), 0755); err != nil {
+		log.Error(fmt.Sprintf("创建数据库目录失败: %v", err))
+		os.Exit(1)
+	}
+	db, err := storage.NewStorage(cfg.DatabasePath)
+	if err != nil {
+		log.Error(fmt.Sprintf("初始化数据库失败: %v", err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := executors.NewBinanceExecutor(cfg, log)
+	if err := executor.DetectPositionMode(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  检测持仓模式失败，使用配置值: %v", err))
+	}
+
+	// The guard takes no leases, so a restarted main bot can always acquire its symbols again
+	// 守护进程不获取租约，因此重启的主程序总能重新获取交易对
+	sm := executors.NewStopLossManager(cfg, executor, log, db)
+	notifier := notify.NewFromConfig(cfg, log)
+	sm.SetNotifier(notifier)
+	go notifier.Run(ctx)
+	go sm.MonitorPartialTakeProfitRealtime(interval)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Success("🛡️ 守护进程已启动，等待主程序租约过期后接管")
+	guardCycle(ctx, cfg, log, db, sm, notifier)
+	for {
+		select {
+		case <-sigChan:
+			log.Warning("收到停止信号，守护进程退出")
+			sm.Stop()
+			notifier.Close()
+			return
+		case <-ticker.C:
+			guardCycle(ctx, cfg, log, db, sm, notifier)
+		}
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: guard [INTERVAL_SECONDS]")
+	fmt.Println()
+	fmt.Println("Runs stop-loss and take-profit protection only (no LLM, no scheduler).")
+	fmt.Println("A symbol is protected only while the main bot's lease on it has expired,")
+	fmt.Println("so the guard can run permanently next to the main bot.")
+	fmt.Println()
+	fmt.Println("Run it with the same .env (DATABASE_PATH, BOT_INSTANCE_ID) as the main bot.")
+	fmt.Printf("INTERVAL_SECONDS defaults to %d.\n", int(defaultGuardInterval.Seconds()))
+}
+
+// guardCycle takes over symbols whose main bot lease expired and hands back those whose lease is alive again
+// guardCycle 接管主程序租约已过期的交易对，并交还租约已恢复的交易对
+func guardCycle(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, db *storage.Storage, sm *executors.StopLossManager, notifier *notify.Notifier) {
+	for _, symbol := range cfg.CryptoSymbols {
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+
+		lease, err := db.GetSymbolLease(binanceSymbol)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️ 【%s】读取租约失败: %v", binanceSymbol, err))
+			continue
+		}
+		if executors.LeaseAlive(lease, time.Now()) {
+			if sm.HasPosition(binanceSymbol) {
+				log.Success(fmt.Sprintf("✅【%s】主程序已恢复（主机 %s, PID %d），守护进程交还管理", binanceSymbol, lease.Hostname, lease.PID))
+				sm.RemovePosition(binanceSymbol)
+			}
+			continue
+		}
+
+		if !sm.HasPosition(binanceSymbol) {
+			pos, err := loadActivePosition(db, cfg, binanceSymbol)
+			if err != nil {
+				log.Warning(fmt.Sprintf("⚠️ 【%s】加载持仓失败: %v", binanceSymbol, err))
+				continue
+			}
+			if pos == nil {
+				continue
+			}
+			log.Warning(fmt.Sprintf("🛡️【%s】主程序租约已过期，守护进程接管持仓保护", binanceSymbol))
+			notifier.Notify(notify.SeverityCritical, binanceSymbol, "🛡️ 主程序租约已过期，守护进程接管持仓保护")
+			sm.RestorePosition(pos)
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		if err := sm.GuardSymbol(checkCtx, binanceSymbol); err != nil {
+			log.Error(fmt.Sprintf("❌【%s】守护检查失败: %v", binanceSymbol, err))
+		}
+		cancel()
+	}
+}
+
+// loadActivePosition returns the open position record of a symbol as a managed position, or nil when there is none
+// loadActivePosition 将交易对的未平仓记录转换为受管持仓，没有时返回 nil
+func loadActivePosition(db *storage.Storage, cfg *config.Config, binanceSymbol string) (*executors.Position, error) {
+	records, err := db.GetActivePositions()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if cfg.GetBinanceSymbolFor(r.Symbol) != binanceSymbol {
+			continue
+		}
+		return executors.PositionFromRecord(r, binanceSymbol), nil
+	}
+	return nil, nil
+}
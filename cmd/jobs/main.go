@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/jobs"
+)
+
+// apiURLEnv overrides the address of the web server the CLI talks to
+// apiURLEnv 覆盖命令行连接的 Web 服务器地址
+const apiURLEnv = "JOBS_API_URL"
+
+// pollInterval is how often wait checks a job
+// pollInterval 是 wait 查询任务状态的间隔
+const pollInterval = 2 * time.Second
+
+// jobView is a job as returned by the API
+// jobView 是 API 返回的任务
+type jobView struct {
+	jobs.Job
+	Percent float64 `json:"percent"`
+}
+
+// client calls the jobs API of a running web server with a logged-in session
+// client 使用已登录会话调用运行中 Web 服务器的任务接口
+type client struct {
+	base string
+	http *http.Client
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	base := os.Getenv(apiURLEnv)
+	if base == "" {
+		base = fmt.Sprintf("http://localhost:%d", cfg.WebPort)
+	}
+	c, err := login(strings.TrimRight(base, "/"), cfg.WebUsername, cfg.WebPassword)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to log in to %s: %v\n", base, err)
+		os.Exit(1)
+	}
+
+	command := os.Args[1]
+
+	switch command {
+	case "list":
+		err = handleList(c)
+	case "submit":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: jobs submit <KIND> [key=value ...]")
+			os.Exit(1)
+		}
+		err = handleSubmit(c, os.Args[2], os.Args[3:])
+	case "status", "wait", "cancel":
+		if len(os.Args) < 3 {
+			fmt.Printf("Usage: jobs %s <ID>\n", command)
+			os.Exit(1)
+		}
+		id, perr := strconv.ParseInt(os.Args[2], 10, 64)
+		if perr != nil {
+			fmt.Fprintf(os.Stderr, "Invalid job ID: %s\n", os.Args[2])
+			os.Exit(1)
+		}
+		switch command {
+		case "status":
+			err = handleStatus(c, id)
+		case "wait":
+			err = handleWait(c, id)
+		default:
+			err = handleCancel(c, id)
+		}
+	default:
+		fmt.Printf("Unknown command: %s\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: jobs <command> [args]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  list                          - List recent jobs and the kinds that can be submitted")
+	fmt.Println("  submit KIND [key=value ...]   - Start a background job and print its ID")
+	fmt.Println("  status ID                     - Show a job's status, progress and result")
+	fmt.Println("  wait ID                       - Follow a job's progress until it finishes")
+	fmt.Println("  cancel ID                     - Cancel a queued or running job")
+	fmt.Println()
+	fmt.Println("Jobs run inside the web server; the CLI logs in with WEB_USERNAME and WEB_PASSWORD.")
+	fmt.Printf("The server is http://localhost:WEB_PORT unless %s is set.\n", apiURLEnv)
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  jobs submit memory-seed days=365")
+	fmt.Println("  jobs wait 3")
+	fmt.Println("  jobs cancel 3")
+}
+
+// login opens a web session; the login form answers with a redirect that carries the session cookie
+// login 建立 Web 会话；登录表单以携带会话 cookie 的重定向作为响应
+func login(base, username, password string) (*client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	c := &client{
+		base: base,
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: 30 * time.Second,
+			// Protected routes redirect to /login without a session; that must surface as an error, not a login page
+			// 未登录时受保护路由会重定向到 /login，需要作为错误返回而不是跟随到登录页
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+
+	resp, err := c.http.PostForm(base+"/login", url.Values{"username": {username}, "password": {password}})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range jar.Cookies(u) {
+		if cookie.Name == "session_id" && cookie.Value != "" {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid credentials (status %d)", resp.StatusCode)
+}
+
+// call sends a request to the API and decodes a JSON answer into out
+// call 向 API 发送请求并将 JSON 响应解码到 out
+func (c *client) call(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("%s %s: %s (status %d)", method, path, apiErr.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("%s %s: status %d", method, path, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func handleList(c *client) error {
+	var resp struct {
+		Jobs  []jobView   `json:"jobs"`
+		Kinds []jobs.Kind `json:"kinds"`
+	}
+	if err := c.call(http.MethodGet, "/api/jobs", nil, &resp); err != nil {
+		return err
+	}
+
+	fmt.Println("Kinds:")
+	for _, k := range resp.Kinds {
+		fmt.Printf("  %-16s %s\n", k.Name, k.Description)
+	}
+	fmt.Println()
+
+	if len(resp.Jobs) == 0 {
+		fmt.Println("No jobs. Run: jobs submit KIND")
+		return nil
+	}
+	for _, j := range resp.Jobs {
+		fmt.Printf("#%-5d %-16s %-10s %5.1f%%  %s  %s\n",
+			j.ID, j.Kind, j.Status, j.Percent, j.CreatedAt.Local().Format("2006-01-02 15:04:05"), j.Message)
+	}
+	return nil
+}
+
+func handleSubmit(c *client, kind string, args []string) error {
+	params := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid parameter %q, expected key=value", arg)
+		}
+		params[key] = value
+	}
+
+	var resp struct {
+		JobID int64 `json:"job_id"`
+	}
+	if err := c.call(http.MethodPost, "/api/jobs", map[string]any{"kind": kind, "params": params}, &resp); err != nil {
+		return err
+	}
+	fmt.Printf("Submitted job #%d (%s)\n", resp.JobID, kind)
+	fmt.Printf("Follow it with: jobs wait %d\n", resp.JobID)
+	return nil
+}
+
+func handleStatus(c *client, id int64) error {
+	var j jobView
+	if err := c.call(http.MethodGet, fmt.Sprintf("/api/jobs/%d", id), nil, &j); err != nil {
+		return err
+	}
+	printJob(j)
+	return nil
+}
+
+func handleWait(c *client, id int64) error {
+	last := ""
+	for {
+		var j jobView
+		if err := c.call(http.MethodGet, fmt.Sprintf("/api/jobs/%d", id), nil, &j); err != nil {
+			return err
+		}
+		if !j.Status.Finished() {
+			line := fmt.Sprintf("[%s] %5.1f%%  %s", j.Status, j.Percent, j.Message)
+			if line != last {
+				fmt.Println(line)
+				last = line
+			}
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		printJob(j)
+		if j.Status != jobs.StatusSucceeded {
+			return fmt.Errorf("job #%d %s", id, j.Status)
+		}
+		return nil
+	}
+}
+
+func handleCancel(c *client, id int64) error {
+	if err := c.call(http.MethodDelete, fmt.Sprintf("/api/jobs/%d", id), nil, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Cancelling job #%d\n", id)
+	return nil
+}
+
+// printJob prints a job's details and its result as indented JSON
+// printJob 打印任务详情，结果以缩进 JSON 输出
+func printJob(j jobView) {
+	fmt.Printf("Job #%d (%s)\n", j.ID, j.Kind)
+	fmt.Printf("Status:     %s\n", j.Status)
+	fmt.Printf("Progress:   %.1f%% (%d/%d) %s\n", j.Percent, j.Done, j.Total, j.Message)
+	fmt.Printf("Requested:  %s by %s\n", j.CreatedAt.Local().Format("2006-01-02 15:04:05"), j.RequestedBy)
+	if j.FinishedAt != nil {
+		fmt.Printf("Finished:   %s\n", j.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	if j.Error != "" {
+		fmt.Printf("Error:      %s\n", j.Error)
+	}
+	if j.Result != nil {
+		data, _ := json.MarshalIndent(j.Result, "", "  ")
+		fmt.Printf("Result:\n%s\n", data)
+	}
+}
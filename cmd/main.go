@@ -16,6 +16,7 @@ import (
 	"github.com/oak/crypto-trading-bot/internal/constant"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
@@ -38,7 +39,9 @@ func main() {
 	log.Info(fmt.Sprintf("回看天数: %d", cfg.CryptoLookbackDays))
 	log.Info(fmt.Sprintf("杠杆倍数: %dx", cfg.BinanceLeverage))
 
-	if cfg.BinanceTestMode {
+	if cfg.PaperTrading {
+		log.Success(fmt.Sprintf("📝 运行模式: 模拟盘（实盘价格，虚拟资金 %.2f USDT）", cfg.PaperInitialBalance))
+	} else if cfg.BinanceTestMode {
 		log.Success("🟢 运行模式: 测试模式（模拟交易）")
 	} else {
 		log.Warning("🔴 运行模式: 实盘模式（真实交易！）")
@@ -180,7 +183,32 @@ func main() {
 	// 初始化止损管理器（用于交易图的持仓信息）
 	stopLossManager := executors.NewStopLossManager(cfg, executor, log, db)
 
+	// A single run has no digest timer, so whatever was batched is sent on exit
+	// 单次运行没有汇总定时器，批量的通知在退出时发送
+	notifier := notify.NewFromConfig(cfg, log)
+	stopLossManager.SetNotifier(notifier)
+	defer notifier.Close()
+
+	// Paused symbols without a position are left out of this run
+	// 没有持仓的已暂停交易对不参与本次分析
+	executor.SetSymbolPauses(executors.NewSymbolPauseRegistry(db, cfg, log))
+	symbols, skipped := executor.FilterPausedSymbols(cfg.CryptoSymbols, stopLossManager.HasPosition)
+	if len(skipped) > 0 {
+		log.Warning(fmt.Sprintf("⏸️  已暂停，跳过分析: %v", skipped))
+	}
+	if len(symbols) == 0 {
+		log.Info("所有交易对均已暂停，退出")
+		return
+	}
+
 	tradingGraph := agents.NewSimpleTradingGraph(cfg, log, executor, stopLossManager)
+	tradingGraph.SetSymbols(symbols)
+	if cfg.UseMemory {
+		tradingGraph.SetMemoryStore(db)
+	}
+	tradingGraph.SetLessonStore(db)
+	tradingGraph.SetDecisionStore(db)
+	tradingGraph.SetTraderConfigStore(db)
 
 	// ! 启动交易员分析流程
 	result, err := tradingGraph.Run(ctx)
@@ -230,7 +258,9 @@ func main() {
 		// Get symbol-specific decision text
 		// 获取该交易对的专属决策文本
 		symbolDecision := decision // Default to full decision
+		symbolAction := ""
 		if parsedDecision, ok := symbolDecisions[symbol]; ok && parsedDecision.Valid {
+			symbolAction = string(parsedDecision.Action)
 			// Format symbol-specific decision for display
 			// 格式化该交易对的专属决策用于显示
 			symbolDecision = fmt.Sprintf(`【%s】
@@ -254,8 +284,11 @@ func main() {
 			SentimentReport: reports.SentimentReport,
 			PositionInfo:    reports.PositionInfo,
 			Decision:        symbolDecision, // ✅ Symbol-specific decision instead of full text
+			Action:          symbolAction,
 			Executed:        false,
 			ExecutionResult: "",
+			ErrorCategory:   reports.ErrorCategory,
+			AnalystStatus:   state.AnalystStatus(symbol),
 		}
 
 		sessionID, err := db.SaveSession(session)
@@ -284,6 +317,12 @@ func main() {
 			log.Error(fmt.Sprintf("获取账户余额失败: %v", err))
 		}
 
+		// Close or flag residual positions below the minimum order quantity before trading
+		// 交易前平掉或告警低于最小下单量的残余持仓
+		if cfg.DustCleanupEnabled {
+			executors.NewResidualSweeper(stopLossManager, log).Sweep(ctx, cfg.CryptoSymbols)
+		}
+
 		// Update positions for all symbols
 		// 更新所有交易对的持仓信息
 		for _, symbol := range cfg.CryptoSymbols {
@@ -298,6 +337,39 @@ func main() {
 		// 初始化交易协调器（传入止损管理器）
 		coordinator := executors.NewTradeCoordinator(cfg, executor, log, stopLossManager)
 
+		// Clamp the LLM's proposed sizes with the deterministic sizing engine (POSITION_SIZING_MODE)
+		// 用确定性仓位计算引擎限制 LLM 建议的仓位（POSITION_SIZING_MODE）
+		sizer := executors.NewPositionSizer(cfg)
+		if sizer.Mode() == executors.SizingModeKelly {
+			if closed, err := db.GetClosedPositions(time.Now().AddDate(0, 0, -portfolio.KellyLookbackDays)); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  获取已平仓交易失败，凯利仓位回退为固定比例: %v", err))
+			} else {
+				stats := portfolio.CalculateKellyStats(closed)
+				sizer.SetKellyStats(stats)
+				log.Info(fmt.Sprintf("🧮 凯利统计: %d 笔交易，胜率 %.1f%%，盈亏比 %.2f，凯利比例 %.3f",
+					stats.Trades, stats.WinRate*100, stats.PayoffRatio, stats.Fraction()))
+			}
+		}
+		coordinator.SetPositionSizer(sizer)
+		coordinator.SetExposureLimits(executors.NewExposureLimits(cfg))
+		if deleverage, err := executors.NewDeleveragePolicy(cfg); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  DELEVERAGE_WINDOWS 解析失败: %v，不启用计划降杠杆", err))
+		} else {
+			coordinator.SetDeleveragePolicy(deleverage)
+		}
+
+		// Hard-rule check of every entry after the trader (RISK_GATE_ENABLED)
+		// 交易员之后用硬性规则复核每个开仓（RISK_GATE_ENABLED）
+		riskGate := agents.NewRiskGate(cfg, db, log)
+
+		// Record each actionable decision's path to a position
+		// 记录每条可执行决策形成持仓的过程
+		intents := executors.NewIntentTracker(db, log)
+
+		// Record the slippage against the decision price and the fees of every executed order
+		// 记录每笔已执行订单相对决策价格的滑点与手续费
+		executionCosts := executors.NewExecutionCostTracker(executor, db, log)
+
 		// Note: Local monitoring disabled - relying on Binance server-side stop-loss orders
 		// 注意：已禁用本地监控 - 完全依赖币安服务器端止损单
 		// 原因：
@@ -310,13 +382,24 @@ func main() {
 		// Execute trades for each symbol
 		// 为每个交易对执行交易
 		executionResults := make(map[string]string)
+		executionErrors := make(map[string]string) // 失败交易对的错误类别 / Error category of each failed symbol
+		riskOverrides := make(map[string]string)   // 风控闸门对已执行决策的调整 / Risk gate adjustments of the executed decisions
+
+		// Closes run first to free position slots, entries queue by confidence for MAX_OPEN_POSITIONS
+		// 先执行平仓以释放持仓名额，开仓按置信度排队以分配 MAX_OPEN_POSITIONS 名额
+		order := agents.ExecutionOrder(decisions)
+		if cfg.MaxOpenPositions > 0 {
+			log.Info(fmt.Sprintf("📋 执行顺序（持仓上限 %d）: %s", cfg.MaxOpenPositions, strings.Join(order, " → ")))
+		}
 
-		for symbol, symbolDecision := range decisions {
+		for _, symbol := range order {
+			symbolDecision := decisions[symbol]
 			log.Subheader(fmt.Sprintf("处理 %s 交易决策", symbol), '-', 60)
 
 			if !symbolDecision.Valid {
 				log.Warning(fmt.Sprintf("⚠️  %s 决策无效: %s", symbol, symbolDecision.Reason))
 				executionResults[symbol] = fmt.Sprintf("决策无效: %s", symbolDecision.Reason)
+				executionErrors[symbol] = storage.ErrorCategoryLLM
 				continue
 			}
 
@@ -330,6 +413,14 @@ func main() {
 			if symbolDecision.Action == executors.ActionHold {
 				log.Info("💤 观望决策，不执行交易")
 
+				// Funding cost is checked on top of HOLD: a position that is expensive to keep gets an early-close suggestion
+				// 在 HOLD 之上检查资金费成本：继续持有成本过高的持仓会收到提前平仓建议
+				if advice, ok := coordinator.FundingCostAdvice(ctx, symbol); ok {
+					log.Warning(advice)
+					executionResults[symbol] = advice
+					stopLossManager.Notifier().Notify(notify.SeverityInfo, symbol, advice)
+				}
+
 				// Update stop-loss if LLM provides new stop-loss price
 				// 如果 LLM 提供了新的止损价格，则更新止损
 				//if symbolDecision.StopLoss > 0 {
@@ -363,6 +454,8 @@ func main() {
 				continue
 			}
 
+			intent := intents.Begin("", symbol, symbolDecision.Action, symbolDecision.Reason)
+
 			// Update position info for this symbol
 			// 更新该交易对的持仓信息
 			if err := portfolioMgr.UpdatePosition(ctx, symbol); err != nil {
@@ -381,24 +474,61 @@ func main() {
 			if err := agents.ValidateDecision(symbolDecision, currentPosition); err != nil {
 				log.Error(fmt.Sprintf("❌ %s 决策验证失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("决策验证失败: %v", err)
+				executionErrors[symbol] = storage.ErrorCategoryRisk
+				intents.Fail(intent, storage.IntentRejected, storage.ErrorCategoryRisk, fmt.Sprintf("决策验证失败: %v", err))
 				continue
 			}
+			intents.Advance(intent, storage.IntentApproved, "决策验证通过")
+
+			// The risk gate has the last word on entries: it can veto them or lower their leverage and size
+			// 风控闸门对开仓拥有最终决定权：可以否决，或降低杠杆和仓位
+			if riskGate != nil && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell) {
+				overrides := riskGate.Review(symbolDecision, riskGate.Input(ctx, coordinator, symbol, symbolDecision.Action, state.LatestPrice(symbol)))
+				if len(overrides) > 0 {
+					note := "风控闸门: " + strings.Join(overrides, "；")
+					log.Warning(fmt.Sprintf("🛡️  %s %s", symbol, note))
+					if symbolDecision.Action == executors.ActionHold {
+						executionResults[symbol] = note
+						executionErrors[symbol] = storage.ErrorCategoryRisk
+						intents.Fail(intent, storage.IntentRejected, storage.ErrorCategoryRisk, note)
+						continue
+					}
+					riskOverrides[symbol] = note
+				}
+			}
+
+			// Regime leverage ceilings apply before the order is placed; the leverage is then always set explicitly
+			// so a ceiling from an earlier cycle does not stay on the exchange
+			// 市场状态杠杆上限在下单前生效；此时总是显式设置杠杆，避免之前周期的上限残留在交易所
+			orderLeverage := symbolDecision.Leverage
+			if len(cfg.LeverageRegimeCaps) > 0 && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell) {
+				var capped bool
+				orderLeverage, capped = agents.SelectLeverage(cfg, symbolDecision.Leverage, state.Regime(symbol))
+				if capped {
+					log.Warning(fmt.Sprintf("🛡️  %s 处于%s状态，杠杆下调为 %dx", symbol, state.Regime(symbol), orderLeverage))
+				}
+			}
 
 			// Execute the trade using coordinator
 			// 使用协调器执行交易
-			result, err := coordinator.ExecuteDecisionWithParams(
+			result, err := coordinator.ExecuteDecisionWithHints(
 				ctx,
 				symbol,
 				symbolDecision.Action,
 				symbolDecision.Reason,
-				symbolDecision.Leverage,
+				orderLeverage,
 				symbolDecision.PositionSizePercent,
+				executors.SizingHints{StopLoss: symbolDecision.StopLoss, ATR: state.LatestATR(symbol)},
 			)
 			if err != nil {
 				log.Error(fmt.Sprintf("❌ %s 交易执行失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("执行失败: %v", err)
+				executionErrors[symbol] = executors.ErrorCategoryOf(err)
+				intents.Fail(intent, storage.IntentRejected, executionErrors[symbol], fmt.Sprintf("执行前检查未通过: %v", err))
 				continue
 			}
+			intents.RecordExecution(intent, result)
+			executionCosts.Record(ctx, result, state.LatestPrice(symbol))
 
 			// Display execution summary
 			// 显示执行摘要
@@ -406,18 +536,21 @@ func main() {
 
 			if result.Success {
 				executionResults[symbol] = fmt.Sprintf("✅ 成功执行 %s", result.Action)
+				if result.ReferencePrice > 0 {
+					executionResults[symbol] += fmt.Sprintf("（成交 %.2f，滑点 %+.1f bps）", result.Price, result.SlippageBps)
+				}
 
 				// Register position for stop-loss management (only for opening positions)
 				// 注册持仓到止损管理器（仅开仓时）
 				if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell {
 					// Validate and get leverage to use
 					// 验证并获取要使用的杠杆
-					leverageToUse := agents.ValidateLeverage(
-						symbolDecision.Leverage,
-						cfg.BinanceLeverageMin,
-						cfg.BinanceLeverageMax,
-						cfg.BinanceLeverageDynamic,
-					)
+					leverageToUse, _ := agents.SelectLeverage(cfg, symbolDecision.Leverage, state.Regime(symbol))
+					if symbolDecision.PositionSizePercent <= 0 && result.Leverage > 0 {
+						// An entry sized from its stop distance picks its own leverage
+						// 按止损距离计算的仓位自行选择杠杆
+						leverageToUse = result.Leverage
+					}
 
 					if cfg.BinanceLeverageDynamic {
 						log.Info(fmt.Sprintf("💡 LLM 选择杠杆: %dx (范围: %d-%d)", leverageToUse, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax))
@@ -429,14 +562,14 @@ func main() {
 					// 如果 LLM 未提供止损价格，则计算初始止损
 					initialStopLoss := symbolDecision.StopLoss
 					if initialStopLoss == 0 {
-						// Use 2.5% default stop-loss
-						// 使用 2.5% 默认止损
-						if symbolDecision.Action == executors.ActionBuy {
-							initialStopLoss = result.Price * 0.975 // -2.5%
-						} else {
-							initialStopLoss = result.Price * 1.025 // +2.5%
+						// Use the 2.5% default stop-loss the entry was risk-capped with
+						// 使用开仓风险上限所依据的 2.5% 默认止损
+						defaultSide := "long"
+						if symbolDecision.Action == executors.ActionSell {
+							defaultSide = "short"
 						}
-						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 2.5%% 止损: %.2f", initialStopLoss))
+						initialStopLoss = executors.DefaultInitialStop(defaultSide, result.Price)
+						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 %.1f%% 止损: %.2f", executors.DefaultStopLossPercent, initialStopLoss))
 					}
 
 					// Get ATR value from indicators for dynamic trailing stop
@@ -471,7 +604,7 @@ func main() {
 						Side:            positionSide,
 						EntryPrice:      result.Price,
 						EntryTime:       time.Now(),
-						Quantity:        result.Amount,
+						Quantity:        result.FilledQuantity(),
 						Leverage:        leverageToUse,
 						InitialStopLoss: initialStopLoss,
 						CurrentStopLoss: initialStopLoss,
@@ -513,14 +646,18 @@ func main() {
 
 					// Place initial stop-loss order
 					// 下初始止损单
-					if err := stopLossManager.PlaceInitialStopLoss(ctx, position); err != nil {
-						log.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", err))
+					stopErr := stopLossManager.PlaceInitialStopLoss(ctx, position)
+					if stopErr != nil {
+						log.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", stopErr))
+						executionErrors[symbol] = storage.ErrorCategoryExchange
 					} else {
 						log.Success(fmt.Sprintf("✅ 初始止损单已下达: %.2f", initialStopLoss))
 					}
+					intents.RecordPosition(intent, position.ID, stopErr)
 				}
 			} else {
 				executionResults[symbol] = fmt.Sprintf("❌ 执行失败: %s", result.Message)
+				executionErrors[symbol] = storage.ErrorCategoryExchange
 			}
 		}
 
@@ -541,6 +678,12 @@ func main() {
 
 		log.Info(portfolioMgr.GetPortfolioSummary())
 
+		// Resized entries keep the reason next to their result
+		// 被调整的开仓在执行结果旁保留调整原因
+		for symbol, note := range riskOverrides {
+			executionResults[symbol] = fmt.Sprintf("%s（%s）", executionResults[symbol], note)
+		}
+
 		// Display execution summary
 		// 显示执行摘要
 		log.Subheader("执行结果摘要", '─', 80)
@@ -564,6 +707,11 @@ func main() {
 				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
 			}
 		}
+		for symbol, category := range executionErrors {
+			if err := db.UpdateLatestSessionErrorCategory(symbol, cfg.CryptoTimeframe, category); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  更新 %s 错误类别失败: %v", symbol, err))
+			}
+		}
 
 		log.Success("✅ 自动执行流程完成")
 	} else {
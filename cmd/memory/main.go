@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/jobs"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// lessonSource marks lessons added from this CLI
+// lessonSource 标记通过本命令行添加的规则
+const lessonSource = "cli"
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.DatabasePath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create database directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Open database
+	db, err := storage.NewStorage(cfg.DatabasePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	command := os.Args[1]
+
+	switch command {
+	case "seed":
+		days := 180
+		if len(os.Args) >= 3 {
+			days, _ = strconv.Atoi(os.Args[2])
+		}
+		handleSeed(db, cfg, days)
+	case "stats":
+		handleStats(db, cfg)
+	case "lesson":
+		handleLesson(db, cfg, os.Args[2:])
+	default:
+		fmt.Printf("Unknown command: %s\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: memory <command> [args]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  seed [DAYS]   - Scan DAYS of history (default: 180) for breakouts, failed breakouts")
+	fmt.Println("                  and range bounces, simulate a 2R trade on each and store the outcomes")
+	fmt.Println("  stats         - Show stored situations per setup")
+	fmt.Println("  lesson add [SYMBOL] TEXT      - Add a trading rule, for all symbols or only SYMBOL")
+	fmt.Println("  lesson list                   - List trading rules")
+	fmt.Println("  lesson enable|disable|delete ID - Toggle or remove a trading rule")
+	fmt.Println()
+	fmt.Println("Symbols and timeframe come from CRYPTO_SYMBOLS and CRYPTO_TIMEFRAME.")
+	fmt.Println("Seeding is idempotent: situations already stored are skipped.")
+	fmt.Println("To seed without blocking, run it as a background job of the web server: jobs submit memory-seed days=365")
+	fmt.Println("Enabled trading rules are included in every trader prompt.")
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Println("  memory seed 365")
+	fmt.Println("  memory stats")
+	fmt.Println("  memory lesson add \"Never add to a losing position\"")
+	fmt.Println("  memory lesson add SOL/USDT \"Skip entries in the first hour of the US session\"")
+	fmt.Println("  memory lesson disable 3")
+}
+
+func handleLesson(db *storage.Storage, cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "add":
+		var symbol, text string
+		switch len(args) {
+		case 2:
+			text = args[1]
+		case 3:
+			symbol, text = strings.ToUpper(cfg.GetBinanceSymbolFor(args[1])), args[2]
+		default:
+			fmt.Fprintf(os.Stderr, "Usage: memory lesson add [SYMBOL] TEXT\n")
+			os.Exit(1)
+		}
+		if strings.TrimSpace(text) == "" {
+			fmt.Fprintf(os.Stderr, "Lesson text must not be empty\n")
+			os.Exit(1)
+		}
+		id, err := db.SaveLesson(&storage.TradingLesson{Symbol: symbol, Lesson: strings.TrimSpace(text), Enabled: true, CreatedBy: lessonSource})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to add lesson: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Added lesson #%d\n", id)
+
+	case "list":
+		lessons, err := db.GetLessons(false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to list lessons: %v\n", err)
+			os.Exit(1)
+		}
+		if len(lessons) == 0 {
+			fmt.Println("No lessons stored. Run: memory lesson add TEXT")
+			return
+		}
+		for _, l := range lessons {
+			scope := "ALL"
+			if l.Symbol != "" {
+				scope = l.Symbol
+			}
+			state := "on"
+			if !l.Enabled {
+				state = "off"
+			}
+			fmt.Printf("#%-4d %-3s %-10s %s  (%s, %s)\n", l.ID, state, scope, l.Lesson, l.CreatedBy, l.UpdatedAt.Format("2006-01-02"))
+		}
+
+	case "enable", "disable", "delete":
+		if len(args) != 2 {
+			fmt.Fprintf(os.Stderr, "Usage: memory lesson %s ID\n", args[0])
+			os.Exit(1)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid lesson ID: %s\n", args[1])
+			os.Exit(1)
+		}
+		if args[0] == "delete" {
+			err = db.DeleteLesson(id)
+		} else {
+			var lesson *storage.TradingLesson
+			lesson, err = db.GetLesson(id)
+			if err == nil && lesson == nil {
+				err = fmt.Errorf("lesson %d not found", id)
+			}
+			if err == nil {
+				lesson.Enabled = args[0] == "enable"
+				err = db.UpdateLesson(lesson)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to %s lesson: %v\n", args[0], err)
+			os.Exit(1)
+		}
+		fmt.Printf("Lesson #%d: %sd\n", id, args[0])
+
+	default:
+		fmt.Printf("Unknown lesson command: %s\n", args[0])
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+func handleSeed(db *storage.Storage, cfg *config.Config, days int) {
+	if days <= 0 {
+		fmt.Fprintf(os.Stderr, "DAYS must be positive\n")
+		os.Exit(1)
+	}
+
+	results, err := jobs.SeedMemory(context.Background(), db, cfg, days, func(done, total int, message string) {
+		if done < total {
+			fmt.Printf("=== %s ===\n", message)
+		}
+	})
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", r.Symbol, r.Error)
+			continue
+		}
+		fmt.Printf("%-12s klines=%-6d setups=%-5d stored=%d (already present: %d)\n", r.Symbol, r.Klines, r.Setups, r.Inserted, r.Skipped)
+	}
+	fmt.Println()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Seed failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	handleStats(db, cfg)
+}
+
+// setupStats aggregates stored situations of one setup type and direction
+// setupStats 汇总同一形态和方向的已存储情境
+type setupStats struct {
+	count  int
+	wins   int
+	totalR float64
+}
+
+func handleStats(db *storage.Storage, cfg *config.Config) {
+	for _, symbol := range cfg.CryptoSymbols {
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+		memories, err := db.GetSituationMemories(binanceSymbol, cfg.CryptoTimeframe)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get situations: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("=== Situation Memory: %s %s ===\n", binanceSymbol, cfg.CryptoTimeframe)
+		if len(memories) == 0 {
+			fmt.Println("No situations stored. Run: memory seed")
+			fmt.Println()
+			continue
+		}
+
+		stats := make(map[string]*setupStats)
+		for _, m := range memories {
+			key := dataflows.SetupTypeLabel(m.Setup, m.Direction)
+			if stats[key] == nil {
+				stats[key] = &setupStats{}
+			}
+			stats[key].count++
+			stats[key].totalR += m.RMultiple
+			if m.Outcome == dataflows.OutcomeWin {
+				stats[key].wins++
+			}
+		}
+
+		keys := make([]string, 0, len(stats))
+		for key := range stats {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := stats[key]
+			fmt.Printf("%-20s count=%-5d win rate=%5.1f%%  avg=%+.2fR\n",
+				key, s.count, float64(s.wins)/float64(s.count)*100, s.totalR/float64(s.count))
+		}
+		fmt.Println()
+	}
+}
@@ -98,6 +98,13 @@ func handleStats(db *storage.Storage, cfg *config.Config) {
 		fmt.Printf("First Session:    %s\n", stats["first_session"].(string))
 		fmt.Printf("Last Session:     %s\n", stats["last_session"].(string))
 	}
+
+	if counts, ok := stats["error_categories"].([]storage.ErrorCategoryCount); ok {
+		fmt.Println("\n=== Failures by Category (sessions / orders) ===")
+		for _, c := range counts {
+			fmt.Printf("%-17s %d / %d\n", c.Category+":", c.Sessions, c.Orders)
+		}
+	}
 }
 
 func handleLatest(db *storage.Storage, limit int) {
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// passphraseEnv is the environment variable holding the archive passphrase
+// passphraseEnv 是保存归档口令的环境变量
+const passphraseEnv = "STATE_PASSPHRASE"
+
+func main() {
+	if len(os.Args) < 3 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	passphrase := os.Getenv(passphraseEnv)
+	if passphrase == "" {
+		fmt.Fprintf(os.Stderr, "%s is not set\n", passphraseEnv)
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(constant.BlankStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.DatabasePath), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create database directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Open database
+	db, err := storage.NewStorage(cfg.DatabasePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	command := os.Args[1]
+	archivePath := os.Args[2]
+
+	switch command {
+	case "export":
+		envFile := ".env"
+		if len(os.Args) >= 4 {
+			envFile = os.Args[3]
+		}
+		err = handleExport(db, archivePath, envFile, passphrase)
+	case "import":
+		force := len(os.Args) >= 4 && os.Args[3] == "--force"
+		err = handleImport(db, archivePath, passphrase, force)
+	default:
+		fmt.Printf("Unknown command: %s\n", command)
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", command, err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: state <command> <archive> [args]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  export <FILE> [ENV]      - Export database state and config (default: .env) to an encrypted archive")
+	fmt.Println("  import <FILE> [--force]  - Restore state from an archive; --force overwrites a database with open positions")
+	fmt.Println()
+	fmt.Printf("The archive passphrase is read from %s.\n", passphraseEnv)
+	fmt.Println()
+	fmt.Println("Examples:")
+	fmt.Printf("  %s=... state export bot-state.ctb\n", passphraseEnv)
+	fmt.Printf("  %s=... state import bot-state.ctb\n", passphraseEnv)
+}
+
+// handleExport writes the database state and the .env file into an encrypted archive
+// handleExport 将数据库状态和 .env 文件写入加密归档
+func handleExport(db *storage.Storage, archivePath, envFile, passphrase string) error {
+	snapshot, err := db.ExportState()
+	if err != nil {
+		return err
+	}
+
+	if envData, err := os.ReadFile(envFile); err == nil {
+		snapshot.Config = string(envData)
+	} else {
+		fmt.Printf("Warning: config file %s not readable, exporting without config: %v\n", envFile, err)
+	}
+	snapshot.Hostname, _ = os.Hostname()
+
+	// The archive contains API keys, so keep it private to the owner
+	// 归档包含 API 密钥，仅允许所有者访问
+	file, err := os.OpenFile(archivePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer file.Close()
+
+	if err := storage.WriteStateArchive(file, snapshot, passphrase); err != nil {
+		return err
+	}
+
+	fmt.Printf("=== State exported to %s ===\n", archivePath)
+	printSnapshotSummary(snapshot)
+	return nil
+}
+
+// handleImport restores the database state and writes the archived config next to the local one
+// handleImport 恢复数据库状态，并将归档中的配置写到本地配置旁
+func handleImport(db *storage.Storage, archivePath, passphrase string, force bool) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	snapshot, err := storage.ReadStateArchive(file, passphrase)
+	if err != nil {
+		return err
+	}
+
+	// Never silently discard positions the local bot is still managing
+	// 不要悄悄丢弃本地机器人仍在管理的持仓
+	active, err := db.GetActivePositions()
+	if err != nil {
+		return err
+	}
+	if len(active) > 0 && !force {
+		return fmt.Errorf("local database has %d open positions, rerun with --force to overwrite", len(active))
+	}
+
+	if err := db.ImportState(snapshot); err != nil {
+		return err
+	}
+
+	fmt.Printf("=== State imported from %s (exported %s on %s) ===\n",
+		archivePath, snapshot.ExportedAt.Format("2006-01-02 15:04:05"), snapshot.Hostname)
+	printSnapshotSummary(snapshot)
+
+	if snapshot.Config != "" {
+		// Keep an existing .env untouched; the operator can diff and merge
+		// 保留已有的 .env 不变，由操作者对比后合并
+		envFile := ".env"
+		if _, err := os.Stat(envFile); err == nil {
+			envFile = ".env.imported"
+		}
+		if err := os.WriteFile(envFile, []byte(snapshot.Config), 0600); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		fmt.Printf("Config written to %s\n", envFile)
+	}
+
+	return nil
+}
+
+func printSnapshotSummary(snapshot *storage.StateSnapshot) {
+	for table, rows := range snapshot.Tables {
+		fmt.Printf("  %-18s %d rows\n", table+":", len(rows))
+	}
+	fmt.Printf("  %-18s %v\n", "config:", snapshot.Config != "")
+}
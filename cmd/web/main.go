@@ -14,10 +14,14 @@ import (
 	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/schema"
 	"github.com/oak/crypto-trading-bot/internal/agents"
+	"github.com/oak/crypto-trading-bot/internal/capture"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/constant"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/jobs"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
 	"github.com/oak/crypto-trading-bot/internal/scheduler"
 	"github.com/oak/crypto-trading-bot/internal/storage"
@@ -28,6 +32,14 @@ import (
 // 全局止损管理器
 var globalStopLossManager *executors.StopLossManager
 
+// Global HOLD re-evaluation watcher, nil when HOLD_REEVAL_ENABLED is off
+// 全局 HOLD 重新评估监视器，未启用 HOLD_REEVAL_ENABLED 时为 nil
+var globalReevalWatcher *scheduler.ReevalWatcher
+
+// Global scheduled deleverage policy, nil when DELEVERAGE_WINDOWS is empty
+// 全局计划降杠杆策略，DELEVERAGE_WINDOWS 为空时为 nil
+var globalDeleverage *executors.DeleveragePolicy
+
 func main() {
 	// Load configuration
 	// 加载配置
@@ -49,7 +61,9 @@ func main() {
 	log.Info(fmt.Sprintf("杠杆倍数: %dx", cfg.BinanceLeverage))
 	log.Info(fmt.Sprintf("Web 端口: %d", cfg.WebPort))
 
-	if cfg.BinanceTestMode {
+	if cfg.PaperTrading {
+		log.Success(fmt.Sprintf("📝 运行模式: 模拟盘（实盘价格，虚拟资金 %.2f USDT）", cfg.PaperInitialBalance))
+	} else if cfg.BinanceTestMode {
 		log.Success("🟢 运行模式: 测试模式（模拟交易）")
 	} else {
 		log.Warning("🔴 运行模式: 实盘模式（真实交易！）")
@@ -94,6 +108,47 @@ func main() {
 
 	ctx := context.Background()
 
+	// Verify key permissions and account modes now, instead of failing on the first order
+	// 立即检查密钥权限和账户模式，而不是等到第一笔下单时才失败
+	log.Subheader("检查 API 密钥权限", '─', 80)
+	keyReport, err := executor.CheckKeyPermissions(ctx)
+	if err != nil {
+		log.Error(fmt.Sprintf("❌ API 密钥检查失败: %v", err))
+		log.Error("请检查 BINANCE_API_KEY / BINANCE_API_SECRET 是否正确，以及服务器 IP 是否在密钥白名单中")
+		os.Exit(1)
+	}
+	if keyReport != nil {
+		for _, warning := range keyReport.Warnings {
+			log.Warning(fmt.Sprintf("⚠️  %s", warning))
+		}
+		for _, problem := range keyReport.Errors {
+			log.Error(fmt.Sprintf("❌ %s", problem))
+		}
+		if !keyReport.OK() {
+			log.Error("❌ API 密钥或账户设置不满足交易要求，程序退出")
+			os.Exit(1)
+		}
+		log.Success("✅ API 密钥权限检查通过")
+	}
+
+	// Take a lease on each symbol so a second instance on the same account cannot manage it too
+	// 获取每个交易对的租约，防止同一账户上的第二个实例同时管理该交易对
+	log.Subheader("获取交易对租约", '─', 80)
+	leaseMgr := executors.NewSymbolLeaseManager(db, executor, log)
+	granted := leaseMgr.Acquire(ctx, cfg.CryptoSymbols)
+	if len(granted) == 0 {
+		log.Error("❌ 所有交易对都由其他实例管理，程序退出")
+		os.Exit(1)
+	}
+	if len(granted) < len(cfg.CryptoSymbols) {
+		log.Warning(fmt.Sprintf("⚠️  本实例只管理: %v", granted))
+		cfg.CryptoSymbols = granted
+	} else {
+		log.Success(fmt.Sprintf("✅ 已获取全部交易对租约: %v", granted))
+	}
+	go leaseMgr.Run(ctx)
+	executor.SetSymbolPauses(executors.NewSymbolPauseRegistry(db, cfg, log))
+
 	// Initialize and verify LLM service
 	// 初始化并验证 LLM 服务
 	log.Subheader("验证 LLM 服务", '─', 80)
@@ -146,6 +201,19 @@ func main() {
 		log.Success(fmt.Sprintf("✅ %s 交易所设置完成", symbol))
 	}
 
+	// Probe supported order types so execution can degrade gracefully
+	// 探测支持的订单类型，以便执行层自动降级
+	log.Subheader("探测订单能力", '─', 80)
+	if err := executor.ProbeCapabilities(ctx, cfg.CryptoSymbols); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  订单能力探测失败，使用默认能力: %v", err))
+	}
+
+	// Record the wallet balance baseline for per-cycle reconciliation
+	// 记录钱包余额基准，用于每个周期对账
+	if _, err := executor.ReconcileBalance(ctx); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  记录余额对账基准失败: %v", err))
+	}
+
 	// Check margin type and warn if using isolated margin with dynamic leverage
 	// 检查保证金类型，如果在逐仓模式下使用动态杠杆则发出警告
 	if cfg.BinanceLeverageDynamic && len(cfg.CryptoSymbols) > 0 {
@@ -183,6 +251,42 @@ func main() {
 	log.Subheader("初始化止损管理器", '─', 80)
 	globalStopLossManager = executors.NewStopLossManager(cfg, executor, log, db)
 
+	// Push stop-loss and take-profit events to Telegram, batching minor ones into a digest
+	// 将止损和止盈事件推送到 Telegram，次要事件合并为汇总
+	notifier := notify.NewFromConfig(cfg, log)
+	globalStopLossManager.SetNotifier(notifier)
+	notifyCtx, stopNotifier := context.WithCancel(ctx)
+	go notifier.Run(notifyCtx)
+
+	// Push yesterday's trading PnL and per-symbol funding at midnight in TIMEZONE
+	// 每天 TIMEZONE 零点推送前一天的交易盈亏和按交易对的资金费
+	if cfg.DailySummaryEnabled {
+		go runDailySummary(notifyCtx, cfg, log, executor, db, notifier)
+	}
+
+	// Have the deep model review the past week's trades every Monday at midnight in TIMEZONE
+	// 每周一 TIMEZONE 零点由深度模型复盘上一周的交易
+	if cfg.WeeklyReviewEnabled {
+		go runWeeklyReview(notifyCtx, cfg, log, db, notifier)
+	}
+
+	// Cancel entry orders (and optionally flatten) on shutdown, crash or a long exchange outage
+	// 在停止、崩溃或长时间与交易所失联时撤销开仓挂单（可选平仓）
+	deadMan := executors.NewDeadManSwitch(cfg, executor, globalStopLossManager, log)
+	deadManCtx, stopDeadMan := context.WithCancel(ctx)
+	go deadMan.Run(deadManCtx)
+
+	// Alert, and optionally reduce, when a position's mark price nears its liquidation price
+	// 持仓标记价格接近强平价时告警，并可自动减仓
+	go executors.NewLiquidationGuard(cfg, globalStopLossManager, log).Run(ctx)
+	defer func() {
+		if r := recover(); r != nil {
+			deadMan.Shutdown(context.Background(), fmt.Sprintf("程序崩溃: %v", r))
+			notifier.Close()
+			panic(r)
+		}
+	}()
+
 	// Load existing active positions from database
 	// 从数据库加载现有活跃持仓
 	activePositions, err := db.GetActivePositions()
@@ -224,32 +328,22 @@ func main() {
 		// Register deduplicated positions
 		// 注册去重后的持仓
 		for normalizedSymbol, posRecord := range posMap {
-			// Convert PositionRecord to Position
-			// 将 PositionRecord 转换为 Position
-			pos := &executors.Position{
-				ID:               posRecord.ID,
-				Symbol:           normalizedSymbol, // Use normalized symbol / 使用标准化符号
-				Side:             posRecord.Side,
-				EntryPrice:       posRecord.EntryPrice,
-				EntryTime:        posRecord.EntryTime,
-				Quantity:         posRecord.Quantity,
-				InitialStopLoss:  posRecord.InitialStopLoss,
-				CurrentStopLoss:  posRecord.CurrentStopLoss,
-				StopLossType:     posRecord.StopLossType,
-				TrailingDistance: posRecord.TrailingDistance,
-				HighestPrice:     posRecord.HighestPrice,
-				CurrentPrice:     posRecord.CurrentPrice,
-				OpenReason:       posRecord.OpenReason,
-				ATR:              posRecord.ATR,
-				StopLossOrderID:  posRecord.StopLossOrderID, // ✅ 恢复止损单 ID
-			}
-			globalStopLossManager.RegisterPosition(pos)
+			// Restore the position with its stop history, extreme price and fired take-profit levels
+			// 恢复持仓及其止损历史、极值价格和已触发的止盈级别
+			globalStopLossManager.RestorePosition(executors.PositionFromRecord(posRecord, normalizedSymbol))
 			log.Success(fmt.Sprintf("已恢复持仓: %s %s @ $%.2f", normalizedSymbol, posRecord.Side, posRecord.EntryPrice))
 		}
 	} else {
 		log.Info("暂无活跃持仓")
 	}
 
+	// Rebuild stop-loss state from what is actually on the exchange
+	// 以交易所的实际状态重建止损管理状态
+	if cfg.StartupReconcileEnabled {
+		log.Subheader("启动对账", '─', 80)
+		globalStopLossManager.ReconcileOnStartup(ctx, cfg.CryptoSymbols)
+	}
+
 	// Initialize portfolio manager for balance tracking
 	// 初始化投资组合管理器用于余额跟踪
 	portfolioMgr := portfolio.NewPortfolioManager(cfg, executor, log)
@@ -283,17 +377,16 @@ func main() {
 		}
 	}
 
-	// Note: Local monitoring disabled - relying on Binance server-side stop-loss orders
-	// 注意：已禁用本地监控 - 完全依赖币安服务器端止损单
-	// 原因：
-	//   1. 币安止损单 24/7 服务器端监控，触发速度更快（毫秒级）
-	//   2. 避免本地监控与币安止损单重复执行
-	//   3. 减少 API 调用开销
-	//   4. 即使本地程序崩溃，币安止损单仍会执行
-	// go func() {
-	// 	log.Success("🔍 启动持仓监控，间隔: 10 秒")
-	// 	globalStopLossManager.MonitorPositions(10 * time.Second)
-	// }()
+	// Local stop monitoring only runs for symbols with STOP_MONITORING_MODE local or both;
+	// exchange stops trigger on Binance server-side and need no polling
+	// 本地止损监控只为 STOP_MONITORING_MODE 为 local 或 both 的交易对运行；
+	// exchange 模式的止损由币安服务器端触发，无需轮询
+	if globalStopLossManager.UsesLocalStopMonitoring() {
+		go func() {
+			log.Success("🔍 启动本地止损监控，间隔: 10 秒")
+			globalStopLossManager.MonitorPositions(10 * time.Second)
+		}()
+	}
 
 	// Start real-time partial take-profit monitoring in background
 	// 在后台启动分批止盈实时监控（独立于交易分析周期）
@@ -309,6 +402,66 @@ func main() {
 		globalStopLossManager.MonitorPartialTakeProfitRealtime(monitorInterval)
 	}()
 
+	// Start retry queue for order actions that failed transiently (e.g. stop-loss replacement hit a rate limit)
+	// 启动失败动作重试队列（如止损单替换因限频失败）
+	go globalStopLossManager.RunTaskQueue(10 * time.Second)
+
+	// Receive stop-loss fills, liquidations and position changes as they happen instead of on the next cycle
+	// 实时接收止损成交、强平和持仓变化，而不是等到下一个周期
+	if cfg.UserDataStreamEnabled {
+		go executors.NewUserDataStream(executor, globalStopLossManager, log).Run(ctx)
+	}
+
+	// Share one streamed price cache between stop-loss, take-profit, reports and the web UI instead of separate REST calls
+	// 止损、止盈、报告和 Web 界面共享同一个流式价格缓存，而不是各自调用 REST
+	if cfg.PriceStreamEnabled {
+		priceService := executors.NewPriceService(executor, cfg.CryptoSymbols, log)
+		go priceService.Run(ctx)
+		go globalStopLossManager.FollowPrices(ctx, priceService)
+	}
+
+	// Analysts and the stop monitor read candles from rolling in-memory buffers fed by the kline stream
+	// 分析师和止损监控从 K 线流维护的内存滚动缓冲读取 K 线
+	if cfg.KlineStreamEnabled {
+		klineStream := dataflows.NewKlineStream(cfg, cfg.CryptoSymbols, dataflows.StreamTimeframes(cfg), log)
+		go klineStream.Run(ctx)
+	}
+
+	// Keep stop-loss/take-profit brackets linked: once one leg fills, cancel the other
+	// 保持止损/止盈括号单关联：一条腿成交后撤销另一条
+	go executor.MonitorBrackets(ctx, 5*time.Second)
+
+	// Merge the fills of resting DCA ladder rungs into their positions and resize the stop; entries are only split while this runs
+	// 将 DCA 阶梯挂单的成交并入持仓并调整止损数量；只有此监控运行时才会拆分开仓
+	if cfg.EntryLadderLevels > 1 {
+		go globalStopLossManager.MonitorEntryLadders(ctx, 5*time.Second)
+	}
+
+	// Lower leverage and size during scheduled windows such as weekends, reducing open positions once per window
+	// 在周末等计划时段内降低杠杆和仓位，每个时段对已有持仓减仓一次
+	if policy, err := executors.NewDeleveragePolicy(cfg); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  DELEVERAGE_WINDOWS 解析失败: %v，不启用计划降杠杆", err))
+	} else if policy != nil {
+		globalDeleverage = policy
+		go globalStopLossManager.MonitorDeleverage(ctx, policy, time.Minute)
+	}
+
+	// Reduce the largest losing positions step by step while the margin ratio is high, well before liquidation
+	// 保证金率过高时逐步减仓亏损最大的持仓，远在强平之前
+	if cfg.MarginDeleverageRatioPercent > 0 {
+		go globalStopLossManager.MonitorMarginRatio(ctx, time.Minute)
+	}
+
+	// Between trading cycles, let the quick model tighten stops and move take-profits of open positions (it never opens positions)
+	// 在交易周期之间由快速模型收紧持仓止损并调整止盈（不会开仓）
+	if cfg.StopReviewEnabled {
+		if interval, err := time.ParseDuration(cfg.StopReviewInterval); err != nil || interval <= 0 {
+			log.Warning(fmt.Sprintf("⚠️  止损复查间隔无效（%s），未启动快速止损复查", cfg.StopReviewInterval))
+		} else {
+			go agents.NewStopReviewer(cfg, log, globalStopLossManager).Run(ctx, interval)
+		}
+	}
+
 	// Start balance history recording in background
 	// 在后台启动余额历史记录
 	go func() {
@@ -356,11 +509,46 @@ func main() {
 		os.Exit(1)
 	}
 
+	tradingScheduler.SetLocation(cfg.Location())
+
 	log.Success(fmt.Sprintf("调度器已初始化 (运行间隔: %s, K线间隔: %s)", cfg.TradingInterval, cfg.CryptoTimeframe))
 
+	// A restart within a cycle that already saved its sessions must not analyze and trade that cycle again
+	// 在已保存会话的周期内重启时，不得再次分析和交易该周期
+	if latest, err := db.GetLatestSessions(1); err == nil && len(latest) > 0 {
+		if cycle := tradingScheduler.CycleStart(latest[0].CreatedAt); tradingScheduler.IsCurrentCycle(cycle, time.Now()) {
+			tradingScheduler.MarkProcessed(cycle)
+			log.Info(fmt.Sprintf("本周期（%s）已执行过分析，等待下一周期", cycle.Format("2006-01-02 15:04")))
+		}
+	}
+
+	// Adaptive interval: volatility is re-evaluated on every boundary of the shortest interval
+	// 自适应间隔：在每个最短间隔的边界重新评估波动率
+	var adaptiveClock *scheduler.TradingScheduler
+	marketData := dataflows.NewMarketData(cfg)
+	if cfg.AdaptiveIntervalEnabled {
+		if err := tradingScheduler.SetAdaptiveBounds(cfg.AdaptiveIntervalMin, cfg.AdaptiveIntervalMax); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  自适应运行间隔配置无效，保持固定间隔: %v", err))
+		} else if adaptiveClock, err = scheduler.NewTradingScheduler(cfg.AdaptiveIntervalMin); err == nil {
+			log.Info(fmt.Sprintf("⏱️  自适应运行间隔已启用: %s ~ %s（基准 %s）", cfg.AdaptiveIntervalMin, cfg.AdaptiveIntervalMax, cfg.TradingInterval))
+			adaptTradingInterval(ctx, cfg, log, marketData, tradingScheduler)
+		}
+	}
+
 	// Start web server (pass scheduler to enable config updates)
 	// 启动 Web 服务器（传递调度器以启用配置更新）
 	webServer := web.NewServer(cfg, log, db, globalStopLossManager, tradingScheduler)
+
+	// Analyses requested from the web UI are queued to the trading loop, so they never overlap a scheduled cycle
+	// Web 界面请求的即时分析排队交给交易循环执行，因此不会与定时周期重叠
+	analysisTrigger := scheduler.NewAnalysisTrigger(time.Duration(cfg.WebAnalyzeCooldownMinutes) * time.Minute)
+	webServer.SetAnalysisTrigger(analysisTrigger)
+
+	// Long operations run as background jobs beside the trading loop instead of inside HTTP requests
+	// 耗时操作作为后台任务在交易循环之外运行，而不是在 HTTP 请求中执行
+	jobManager := jobs.NewManager(cfg.WebJobMaxConcurrent, log)
+	jobManager.Register(jobs.KindMemorySeed, "Replay historical klines into situation memory (params: days, default 180)", jobs.MemorySeed(cfg, db))
+	webServer.SetJobManager(jobManager)
 	go func() {
 		if err := webServer.Start(); err != nil {
 			log.Error(fmt.Sprintf("Web 服务器启动失败: %v", err))
@@ -377,6 +565,27 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Held symbols are re-analyzed early when a HOLD trigger fires
+	// 持仓 HOLD 的交易对在触发条件满足时提前重新分析
+	if cfg.HoldReevalEnabled {
+		globalReevalWatcher = scheduler.NewReevalWatcher(scheduler.ReevalTriggers{
+			PriceMovePercent:     cfg.HoldReevalPriceMovePercent,
+			StopProximityPercent: cfg.HoldReevalStopProximityPercent,
+			FundingFlip:          cfg.HoldReevalFundingFlip,
+		})
+		if globalReevalWatcher != nil {
+			log.Info(fmt.Sprintf("🔔 HOLD 提前重新评估已启用: 价格变动 %.2f%%, 距止损 %.2f%%, 资金费率翻转 %v",
+				cfg.HoldReevalPriceMovePercent, cfg.HoldReevalStopProximityPercent, cfg.HoldReevalFundingFlip))
+		}
+	}
+
+	// Residual positions below the minimum order quantity are closed or flagged before each cycle
+	// 每个周期开始前平掉或告警低于最小下单量的残余持仓
+	var residualSweeper *executors.ResidualSweeper
+	if cfg.DustCleanupEnabled {
+		residualSweeper = executors.NewResidualSweeper(globalStopLossManager, log)
+	}
+
 	// Trading loop
 	// 交易循环
 	runCount := 0
@@ -387,23 +596,55 @@ func main() {
 		select {
 		case <-sigChan:
 			log.Warning("\n收到停止信号，正在关闭...")
+			stopDeadMan()
+			deadMan.Shutdown(ctx, "收到停止信号")
 			globalStopLossManager.Stop()
+			jobManager.Shutdown()
+			stopNotifier()
+			notifier.Close()
+			leaseMgr.Release()
 			if err := webServer.Stop(ctx); err != nil {
 				log.Warning(fmt.Sprintf("Web 服务器停止失败: %v", err))
 			}
 			return
 
+		case job := <-analysisTrigger.Jobs():
+			runRequestedAnalysis(ctx, cfg, log, executor, db, tradingScheduler, analysisTrigger, job)
+
 		case <-ticker.C:
-			// Check if it's time to run
-			// 检查是否到达执行时间
-			if tradingScheduler.IsOnTimeframe() {
+			// Retune the interval first, so a tightened interval already applies at this boundary
+			// 先调整运行间隔，使收紧后的间隔在本次边界即生效
+			if adaptiveClock != nil && adaptiveClock.IsOnTimeframe() {
+				adaptTradingInterval(ctx, cfg, log, marketData, tradingScheduler)
+			}
+
+			// Check if it's time to run; each cycle runs once, and a late tick catches up on the latest cycle only
+			// 检查是否到达执行时间；每个周期只运行一次，延迟的触发只补跑最新周期
+			if cycle, missed, ok := tradingScheduler.ClaimCycle(time.Now()); ok {
 				runCount++
 				log.Header(fmt.Sprintf("第 %d 次执行", runCount), '=', 80)
 				log.Info(fmt.Sprintf("执行时间: %s", time.Now().Format("2006-01-02 15:04:05")))
+				if missed > 0 {
+					log.Warning(fmt.Sprintf("⏭️  错过了 %d 个周期，不再补跑，只处理最新周期 %s", missed, cycle.Format("15:04")))
+				}
+				if late := time.Since(cycle); late > time.Minute {
+					log.Warning(fmt.Sprintf("⏰ 补跑周期 %s（延迟 %s）", cycle.Format("15:04"), late.Round(time.Second)))
+				}
+
+				// Reconcile the wallet balance before trading; a large unexplained change halts new entries
+				// 交易前对账钱包余额；无法解释的大额变动会暂停开仓
+				if result, err := executor.ReconcileBalance(ctx); err != nil {
+					log.Warning(fmt.Sprintf("⚠️  余额对账失败: %v", err))
+				} else if result != nil && !result.EntriesHalted {
+					log.Info(fmt.Sprintf("💰 余额对账通过: 实际 %.2f USDT, 预期 %.2f USDT", result.Actual, result.Expected))
+				}
+				if residualSweeper != nil {
+					residualSweeper.Sweep(ctx, cfg.CryptoSymbols)
+				}
 
 				// Run trading analysis with auto-execution
 				// 运行交易分析并自动执行
-				if err := runTradingAnalysis(ctx, cfg, log, executor, db); err != nil {
+				if _, err := runTradingAnalysis(ctx, cfg, log, executor, db, tradingScheduler, cycle, cfg.CryptoSymbols); err != nil {
 					log.Error(fmt.Sprintf("交易分析失败: %v", err))
 				}
 
@@ -412,12 +653,209 @@ func main() {
 				nextTime := tradingScheduler.GetNextTimeframeTime()
 				log.Info(fmt.Sprintf("下次执行时间: %s", nextTime.Format("2006-01-02 15:04:05")))
 				log.Header("等待下一次执行", '=', 80)
+			} else if fired := checkHoldReevals(ctx, log, executor); len(fired) > 0 {
+				// Between cycles, re-analyze only the held symbols whose HOLD triggers fired
+				// 周期之间只重新分析 HOLD 触发条件已满足的持仓交易对
+				log.Header(fmt.Sprintf("提前重新评估: %v", fired), '=', 80)
+				if _, err := runTradingAnalysis(ctx, cfg, log, executor, db, tradingScheduler, tradingScheduler.CycleStart(time.Now()), fired); err != nil {
+					log.Error(fmt.Sprintf("提前重新评估失败: %v", err))
+				}
+			}
+		}
+	}
+}
+
+// adaptTradingInterval measures the volatility of every symbol and moves the scheduler between the adaptive bounds
+// adaptTradingInterval 测量各交易对的波动率，并在自适应上下限之间调整调度间隔
+// The most volatile symbol decides: one spiking symbol tightens the interval, all symbols must be quiet to relax it
+// 由波动最大的交易对决定：任一交易对波动放大即收紧，所有交易对都平静才放宽
+func adaptTradingInterval(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, marketData *dataflows.MarketData, sched *scheduler.TradingScheduler) {
+	barDuration, err := scheduler.TimeframeDuration(cfg.CryptoTimeframe)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  无法评估波动率: %v", err))
+		return
+	}
+	// Enough bars to warm up the indicators and fill the ranking window
+	// 足够预热指标并填满排名窗口的 K 线
+	end := time.Now()
+	start := end.Add(-barDuration * time.Duration(dataflows.DefaultVolatilityWindow+30))
+
+	level := scheduler.VolatilityLow
+	var details []string
+	for _, symbol := range cfg.CryptoSymbols {
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+		data, err := marketData.GetOHLCVRange(ctx, binanceSymbol, cfg.CryptoTimeframe, start, end)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  【%s】获取 K 线失败，跳过波动率评估: %v", binanceSymbol, err))
+			continue
+		}
+		atrPercentile, realizedPercentile, ok := dataflows.VolatilityPercentiles(data, dataflows.DefaultVolatilityWindow)
+		if !ok {
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s ATR P%.0f / 已实现波动 P%.0f", binanceSymbol, atrPercentile, realizedPercentile))
+
+		switch scheduler.ClassifyVolatility(atrPercentile, realizedPercentile, cfg.AdaptiveHighPercentile, cfg.AdaptiveLowPercentile) {
+		case scheduler.VolatilityHigh:
+			level = scheduler.VolatilityHigh
+		case scheduler.VolatilityNormal:
+			if level == scheduler.VolatilityLow {
+				level = scheduler.VolatilityNormal
 			}
 		}
 	}
+
+	// Without any measurement the current interval is kept
+	// 没有任何测量结果时保持当前间隔
+	if len(details) == 0 {
+		return
+	}
+
+	interval, changed := sched.Adapt(level)
+	if changed {
+		log.Warning(fmt.Sprintf("⏱️  %s（%s），运行间隔调整为 %s（基准 %s），下次分析: %s",
+			level, strings.Join(details, "，"), interval, sched.GetBaseTimeframe(),
+			sched.GetNextTimeframeTime().Format("2006-01-02 15:04:05")))
+	}
 }
 
-func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage) error {
+// armHoldReeval records the market state of a held symbol so a later trigger can re-analyze it early
+// armHoldReeval 记录持仓交易对的市场状态，以便后续触发时提前重新分析
+func armHoldReeval(ctx context.Context, log *logger.ColorLogger, executor *executors.BinanceExecutor, symbol string) {
+	if globalReevalWatcher == nil || !globalStopLossManager.HasPosition(symbol) {
+		return
+	}
+	price, fundingRate, err := executor.GetMarkPriceAndFunding(ctx, symbol)
+	if err != nil {
+		log.Warning(fmt.Sprintf("⚠️  【%s】获取标记价格失败，无法设置 HOLD 重新评估: %v", symbol, err))
+		return
+	}
+	globalReevalWatcher.Arm(scheduler.HoldSnapshot{Symbol: symbol, Price: price, FundingRate: fundingRate, HeldAt: time.Now()})
+	log.Info(fmt.Sprintf("🔔 【%s】已设置 HOLD 重新评估触发（价格 %.4f，资金费率 %.4f%%）", symbol, price, fundingRate*100))
+}
+
+// checkHoldReevals checks every armed HOLD and returns the symbols whose triggers fired
+// checkHoldReevals 检查所有已布防的 HOLD，返回触发条件已满足的交易对
+func checkHoldReevals(ctx context.Context, log *logger.ColorLogger, executor *executors.BinanceExecutor) []string {
+	var fired []string
+	for _, hold := range globalReevalWatcher.Armed() {
+		// A position closed by its stop or take-profit needs no re-evaluation
+		// 已被止损或止盈平掉的持仓无需重新评估
+		pos := globalStopLossManager.GetPosition(hold.Symbol)
+		if pos == nil {
+			globalReevalWatcher.Disarm(hold.Symbol)
+			continue
+		}
+		price, fundingRate, err := executor.GetMarkPriceAndFunding(ctx, hold.Symbol)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  【%s】获取标记价格失败，跳过 HOLD 触发检查: %v", hold.Symbol, err))
+			continue
+		}
+		if reason, ok := globalReevalWatcher.Check(hold.Symbol, price, pos.CurrentStopLoss, fundingRate); ok {
+			log.Warning(fmt.Sprintf("🔔 【%s】HOLD 触发提前重新评估: %s", hold.Symbol, reason))
+			fired = append(fired, hold.Symbol)
+		}
+	}
+	return fired
+}
+
+// runDailySummary sends the summary of the day that just ended every midnight in TIMEZONE until ctx is done
+// runDailySummary 在每天 TIMEZONE 零点发送刚结束一天的汇总，直到 ctx 结束
+func runDailySummary(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage, notifier *notify.Notifier) {
+	for {
+		now := time.Now().In(cfg.Location())
+		next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		dayStart := next.AddDate(0, 0, -1)
+		trades, err := db.GetClosedPositions(dayStart)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  每日汇总读取平仓记录失败: %v", err))
+			continue
+		}
+		// Funding is optional: without it the summary still covers trading PnL
+		// 资金费为可选项：获取失败时汇总仍包含交易盈亏
+		funding, err := executor.GetFundingPayments(ctx, "", dayStart, next)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  每日汇总获取资金费记录失败: %v", err))
+		}
+
+		summary := portfolio.BuildDailySummary(dayStart, trades, funding)
+		message := summary.Format()
+
+		// Stress scenarios only matter while positions are open
+		// 压力场景只在有持仓时才有意义
+		if snapshot, err := executor.AccountSnapshot(ctx); err != nil {
+			log.Warning(fmt.Sprintf("⚠️  每日汇总获取账户快照失败，跳过压力测试: %v", err))
+		} else if len(snapshot.Positions) > 0 {
+			message += "\n" + portfolio.BuildStressReport(ctx, cfg, snapshot).Format()
+		}
+		log.Info(message)
+		notifier.Notify(notify.SeverityInfo, "全部交易对", message)
+	}
+}
+
+// runWeeklyReview stores and sends a strategy review of the week that just ended every Monday at midnight in TIMEZONE until ctx is done
+// runWeeklyReview 在每周一 TIMEZONE 零点保存并发送刚结束一周的策略复盘，直到 ctx 结束
+func runWeeklyReview(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, db *storage.Storage, notifier *notify.Notifier) {
+	reviewer := agents.NewStrategyReviewer(cfg, db, log)
+	for {
+		now := time.Now().In(cfg.Location())
+		daysToMonday := (8 - int(now.Weekday())) % 7
+		if daysToMonday == 0 {
+			daysToMonday = 7
+		}
+		next := time.Date(now.Year(), now.Month(), now.Day()+daysToMonday, 0, 0, 0, 0, now.Location())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		weekStart := next.AddDate(0, 0, -7)
+		review, report, err := reviewer.Review(ctx, weekStart, next)
+		if err != nil {
+			log.Warning(fmt.Sprintf("⚠️  策略周复盘失败: %v", err))
+			continue
+		}
+		message := report.Format(review.PeriodStart, review.PeriodEnd, review.Trades, review.NetPnL)
+		log.Info(message)
+		notifier.Notify(notify.SeverityInfo, "全部交易对", message)
+	}
+}
+
+// runRequestedAnalysis runs an on-demand analysis queued from the web UI and records its outcome on the request
+// runRequestedAnalysis 运行 Web 界面排队的即时分析，并在请求上记录结果
+func runRequestedAnalysis(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage, sched *scheduler.TradingScheduler, trigger *scheduler.AnalysisTrigger, job scheduler.AnalysisJob) {
+	defer trigger.Release(job.Symbol)
+
+	log.Header(fmt.Sprintf("即时分析: %s（%s 请求 #%d）", job.Symbol, job.RequestedBy, job.RequestID), '=', 80)
+	if err := db.UpdateAnalysisRequest(job.RequestID, storage.AnalysisRequestRunning, "", ""); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新分析请求状态失败: %v", err))
+	}
+
+	status, errMsg := storage.AnalysisRequestDone, ""
+	batchID, err := runTradingAnalysis(ctx, cfg, log, executor, db, sched, sched.CycleStart(time.Now()), []string{job.Symbol})
+	if err != nil {
+		log.Error(fmt.Sprintf("即时分析失败: %v", err))
+		status, errMsg = storage.AnalysisRequestFailed, err.Error()
+	}
+	if err := db.UpdateAnalysisRequest(job.RequestID, status, batchID, errMsg); err != nil {
+		log.Warning(fmt.Sprintf("⚠️  更新分析请求状态失败: %v", err))
+	}
+}
+
+// runTradingAnalysis analyzes the symbols for a cycle and, with AUTO_EXECUTE, executes the decisions
+// runTradingAnalysis 分析某个周期的交易对，启用 AUTO_EXECUTE 时执行决策
+// Decisions are only executed while their cycle is still current; a run that outlasts its cycle only records them
+// 只有在所属周期仍为当前周期时才执行决策；运行超出周期时只记录决策
+// It returns the batch ID shared by the saved sessions, empty when nothing was analyzed
+// 返回已保存会话共享的批次 ID，未进行分析时为空
+func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, db *storage.Storage, sched *scheduler.TradingScheduler, cycle time.Time, symbols []string) (string, error) {
 	// Create trading graph
 	// 创建交易图工作流
 	log.Subheader("初始化 Eino Graph 工作流", '─', 80)
@@ -428,13 +866,41 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	log.Info("  • 交易员 (Trader)")
 	log.Info("")
 
+	// Paused symbols without a position are left out of this cycle
+	// 没有持仓的已暂停交易对不参与本轮分析
+	symbols, skipped := executor.FilterPausedSymbols(symbols, globalStopLossManager.HasPosition)
+	if len(skipped) > 0 {
+		log.Warning(fmt.Sprintf("⏸️  已暂停，跳过分析: %v", skipped))
+	}
+	if len(symbols) == 0 {
+		log.Info("所有交易对均已暂停，本轮跳过")
+		return "", nil
+	}
+
+	// Symbols armed for debug capture archive this cycle's artifacts; capture turns off after its last cycle
+	// 已开启调试采集的交易对归档本轮产物；最后一轮结束后自动关闭采集
+	recorder := capture.Shared(cfg)
+	recorder.BeginCycle(cycle)
+	defer func() {
+		for _, symbol := range recorder.EndCycle() {
+			log.Info(fmt.Sprintf("🗂️  【%s】调试采集已完成并自动关闭，文件位于 %s", symbol, filepath.Join(cfg.ResultsDir, "debug_capture", symbol)))
+		}
+	}()
+
 	tradingGraph := agents.NewSimpleTradingGraph(cfg, log, executor, globalStopLossManager)
+	tradingGraph.SetSymbols(symbols)
+	if cfg.UseMemory {
+		tradingGraph.SetMemoryStore(db)
+	}
+	tradingGraph.SetLessonStore(db)
+	tradingGraph.SetDecisionStore(db)
+	tradingGraph.SetTraderConfigStore(db)
 
 	// Run the graph workflow
 	// 运行工作流
 	result, err := tradingGraph.Run(ctx)
 	if err != nil {
-		return fmt.Errorf("工作流执行失败: %w", err)
+		return "", fmt.Errorf("工作流执行失败: %w", err)
 	}
 
 	// Display final results
@@ -452,7 +918,7 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	// 获取智能体状态
 	state := tradingGraph.GetState()
 	log.Subheader("分析师报告摘要", '─', 80)
-	for _, symbol := range cfg.CryptoSymbols {
+	for _, symbol := range symbols {
 		reports := state.GetSymbolReports(symbol)
 		if reports != nil {
 			log.Info(fmt.Sprintf("【%s】", symbol))
@@ -474,9 +940,9 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 	// Parse multi-currency decision to extract symbol-specific decisions
 	// 解析多币种决策以提取每个交易对的专属决策
-	symbolDecisions := agents.ParseMultiCurrencyDecision(decision, cfg.CryptoSymbols)
+	symbolDecisions := agents.ParseMultiCurrencyDecision(decision, symbols)
 
-	for _, symbol := range cfg.CryptoSymbols {
+	for _, symbol := range symbols {
 		reports := state.GetSymbolReports(symbol)
 		if reports == nil {
 			continue
@@ -485,7 +951,9 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 		// Get symbol-specific decision text
 		// 获取该交易对的专属决策文本
 		symbolDecision := decision // Default to full decision
+		symbolAction := ""
 		if parsedDecision, ok := symbolDecisions[symbol]; ok && parsedDecision.Valid {
+			symbolAction = string(parsedDecision.Action)
 			// Format symbol-specific decision for display
 			// 格式化该交易对的专属决策用于显示
 			symbolDecision = fmt.Sprintf(`【%s】
@@ -511,8 +979,11 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			PositionInfo:    reports.PositionInfo,
 			Decision:        symbolDecision, // ✅ Symbol-specific decision
 			FullDecision:    decision,       // ✅ Full LLM decision (all symbols)
+			Action:          symbolAction,
 			Executed:        false,
 			ExecutionResult: "",
+			ErrorCategory:   reports.ErrorCategory,
+			AnalystStatus:   state.AnalystStatus(symbol),
 		}
 
 		sessionID, err := db.SaveSession(session)
@@ -532,7 +1003,7 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 		// Parse multi-currency decision
 		// 解析多币种决策
-		decisions := agents.ParseMultiCurrencyDecision(decision, cfg.CryptoSymbols)
+		decisions := agents.ParseMultiCurrencyDecision(decision, symbols)
 
 		// Initialize portfolio manager
 		// 初始化投资组合管理器
@@ -555,16 +1026,61 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 		// 初始化交易协调器（传入止损管理器）
 		coordinator := executors.NewTradeCoordinator(cfg, executor, log, globalStopLossManager)
 
+		// Clamp the LLM's proposed sizes with the deterministic sizing engine (POSITION_SIZING_MODE)
+		// 用确定性仓位计算引擎限制 LLM 建议的仓位（POSITION_SIZING_MODE）
+		sizer := executors.NewPositionSizer(cfg)
+		if sizer.Mode() == executors.SizingModeKelly {
+			if closed, err := db.GetClosedPositions(time.Now().AddDate(0, 0, -portfolio.KellyLookbackDays)); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  获取已平仓交易失败，凯利仓位回退为固定比例: %v", err))
+			} else {
+				stats := portfolio.CalculateKellyStats(closed)
+				sizer.SetKellyStats(stats)
+				log.Info(fmt.Sprintf("🧮 凯利统计: %d 笔交易，胜率 %.1f%%，盈亏比 %.2f，凯利比例 %.3f",
+					stats.Trades, stats.WinRate*100, stats.PayoffRatio, stats.Fraction()))
+			}
+		}
+		coordinator.SetPositionSizer(sizer)
+		coordinator.SetExposureLimits(executors.NewExposureLimits(cfg))
+		coordinator.SetDeleveragePolicy(globalDeleverage)
+
+		// Hard-rule check of every entry after the trader (RISK_GATE_ENABLED)
+		// 交易员之后用硬性规则复核每个开仓（RISK_GATE_ENABLED）
+		riskGate := agents.NewRiskGate(cfg, db, log)
+
+		// Record each actionable decision's path to a position
+		// 记录每条可执行决策形成持仓的过程
+		intents := executors.NewIntentTracker(db, log)
+
+		// Record the slippage against the decision price and the fees of every executed order
+		// 记录每笔已执行订单相对决策价格的滑点与手续费
+		executionCosts := executors.NewExecutionCostTracker(executor, db, log)
+
 		// Execute trades for each symbol
 		// 为每个交易对执行交易
 		executionResults := make(map[string]string)
+		executionErrors := make(map[string]string) // 失败交易对的错误类别 / Error category of each failed symbol
+		riskOverrides := make(map[string]string)   // 风控闸门对已执行决策的调整 / Risk gate adjustments of the executed decisions
+		var staleDecisions []string
+
+		// Closes run first to free position slots, entries queue by confidence for MAX_OPEN_POSITIONS
+		// 先执行平仓以释放持仓名额，开仓按置信度排队以分配 MAX_OPEN_POSITIONS 名额
+		order := agents.ExecutionOrder(decisions)
+		if cfg.MaxOpenPositions > 0 {
+			log.Info(fmt.Sprintf("📋 执行顺序（持仓上限 %d）: %s", cfg.MaxOpenPositions, strings.Join(order, " → ")))
+		}
 
-		for symbol, symbolDecision := range decisions {
+		for _, symbol := range order {
+			symbolDecision := decisions[symbol]
 			log.Subheader(fmt.Sprintf("处理 %s 交易决策", symbol), '-', 60)
 
+			// Every new decision replaces the previous HOLD's triggers
+			// 每个新决策都会替换上一次 HOLD 的触发条件
+			globalReevalWatcher.Disarm(symbol)
+
 			if !symbolDecision.Valid {
 				log.Warning(fmt.Sprintf("⚠️  %s 决策无效: %s", symbol, symbolDecision.Reason))
 				executionResults[symbol] = fmt.Sprintf("决策无效: %s", symbolDecision.Reason)
+				executionErrors[symbol] = storage.ErrorCategoryLLM
 				continue
 			}
 
@@ -577,6 +1093,15 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			// 处理 HOLD 动作
 			if symbolDecision.Action == executors.ActionHold {
 				log.Info("💤 观望决策，不执行交易")
+				armHoldReeval(ctx, log, executor, symbol)
+
+				// Funding cost is checked on top of HOLD: a position that is expensive to keep gets an early-close suggestion
+				// 在 HOLD 之上检查资金费成本：继续持有成本过高的持仓会收到提前平仓建议
+				if advice, ok := coordinator.FundingCostAdvice(ctx, symbol); ok {
+					log.Warning(advice)
+					executionResults[symbol] = advice
+					globalStopLossManager.Notifier().Notify(notify.SeverityInfo, symbol, advice)
+				}
 
 				// Update stop-loss if LLM provides new stop-loss price
 				// 如果 LLM 提供了新的止损价格，则更新止损
@@ -611,15 +1136,26 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				continue
 			}
 
+			// A decision from a cycle that has already ended is history, not a signal to trade on
+			// 已结束周期的决策只是历史记录，不作为交易信号
+			if !sched.IsCurrentCycle(cycle, time.Now()) {
+				log.Warning(fmt.Sprintf("⏭️  周期 %s 已结束，%s 决策已过期，跳过执行", cycle.Format("15:04"), symbolDecision.Action))
+				executionResults[symbol] = fmt.Sprintf("周期 %s 已结束，决策过期未执行", cycle.Format("15:04"))
+				staleDecisions = append(staleDecisions, fmt.Sprintf("%s %s", symbol, symbolDecision.Action))
+				continue
+			}
+
+			intent := intents.Begin(batchID, symbol, symbolDecision.Action, symbolDecision.Reason)
+
 			// Update position info for this symbol
 			// 更新该交易对的持仓信息
 			if err := portfolioMgr.UpdatePosition(ctx, symbol); err != nil {
 				log.Warning(fmt.Sprintf("⚠️  获取 %s 持仓信息失败: %v", symbol, err))
 			}
 
-			// Get current position
-			// 获取当前持仓
-			currentPosition, err := executor.GetCurrentPosition(ctx, symbol)
+			// Get current position; with both hedge legs open, the leg the decision acts on
+			// 获取当前持仓；双向对冲两条腿都存在时取决策作用的一条
+			currentPosition, err := executor.PositionFor(ctx, symbol, symbolDecision.Action)
 			if err != nil {
 				log.Warning(fmt.Sprintf("⚠️  获取 %s 当前持仓失败: %v", symbol, err))
 			}
@@ -629,24 +1165,61 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			if err := agents.ValidateDecision(symbolDecision, currentPosition); err != nil {
 				log.Error(fmt.Sprintf("❌ %s 决策验证失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("决策验证失败: %v", err)
+				executionErrors[symbol] = storage.ErrorCategoryRisk
+				intents.Fail(intent, storage.IntentRejected, storage.ErrorCategoryRisk, fmt.Sprintf("决策验证失败: %v", err))
 				continue
 			}
+			intents.Advance(intent, storage.IntentApproved, "决策验证通过")
+
+			// The risk gate has the last word on entries: it can veto them or lower their leverage and size
+			// 风控闸门对开仓拥有最终决定权：可以否决，或降低杠杆和仓位
+			if riskGate != nil && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell) {
+				overrides := riskGate.Review(symbolDecision, riskGate.Input(ctx, coordinator, symbol, symbolDecision.Action, state.LatestPrice(symbol)))
+				if len(overrides) > 0 {
+					note := "风控闸门: " + strings.Join(overrides, "；")
+					log.Warning(fmt.Sprintf("🛡️  %s %s", symbol, note))
+					if symbolDecision.Action == executors.ActionHold {
+						executionResults[symbol] = note
+						executionErrors[symbol] = storage.ErrorCategoryRisk
+						intents.Fail(intent, storage.IntentRejected, storage.ErrorCategoryRisk, note)
+						continue
+					}
+					riskOverrides[symbol] = note
+				}
+			}
+
+			// Regime leverage ceilings apply before the order is placed; the leverage is then always set explicitly
+			// so a ceiling from an earlier cycle does not stay on the exchange
+			// 市场状态杠杆上限在下单前生效；此时总是显式设置杠杆，避免之前周期的上限残留在交易所
+			orderLeverage := symbolDecision.Leverage
+			if len(cfg.LeverageRegimeCaps) > 0 && (symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell) {
+				var capped bool
+				orderLeverage, capped = agents.SelectLeverage(cfg, symbolDecision.Leverage, state.Regime(symbol))
+				if capped {
+					log.Warning(fmt.Sprintf("🛡️  %s 处于%s状态，杠杆下调为 %dx", symbol, state.Regime(symbol), orderLeverage))
+				}
+			}
 
 			// Execute the trade using coordinator
 			// 使用协调器执行交易
-			result, err := coordinator.ExecuteDecisionWithParams(
+			result, err := coordinator.ExecuteDecisionWithHints(
 				ctx,
 				symbol,
 				symbolDecision.Action,
 				symbolDecision.Reason,
-				symbolDecision.Leverage,
+				orderLeverage,
 				symbolDecision.PositionSizePercent,
+				executors.SizingHints{StopLoss: symbolDecision.StopLoss, ATR: state.LatestATR(symbol)},
 			)
 			if err != nil {
 				log.Error(fmt.Sprintf("❌ %s 交易执行失败: %v", symbol, err))
 				executionResults[symbol] = fmt.Sprintf("执行失败: %v", err)
+				executionErrors[symbol] = executors.ErrorCategoryOf(err)
+				intents.Fail(intent, storage.IntentRejected, executionErrors[symbol], fmt.Sprintf("执行前检查未通过: %v", err))
 				continue
 			}
+			intents.RecordExecution(intent, result)
+			executionCosts.Record(ctx, result, state.LatestPrice(symbol))
 
 			// Display execution summary
 			// 显示执行摘要
@@ -658,6 +1231,9 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				tradingGraph.IncrementTradeCount()
 
 				executionResults[symbol] = fmt.Sprintf("✅ 成功执行 %s", result.Action)
+				if result.ReferencePrice > 0 {
+					executionResults[symbol] += fmt.Sprintf("（成交 %.2f，滑点 %+.1f bps）", result.Price, result.SlippageBps)
+				}
 
 				// Handle closing positions: cancel stop-loss and update database
 				// 处理平仓：取消止损单并更新数据库
@@ -672,8 +1248,14 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 					// Close position completely (cancel stop-loss, remove from memory, update database)
 					// 完整关闭持仓（取消止损单、从内存移除、更新数据库）
+					// With a hedge open, only the leg on the closed side goes
+					// 存在对冲腿时只关闭被平方向上的那条腿
+					closeSide := "long"
+					if symbolDecision.Action == executors.ActionCloseShort {
+						closeSide = "short"
+					}
 					closeReason := fmt.Sprintf("LLM决策平仓: %s", symbolDecision.Reason)
-					if err := globalStopLossManager.ClosePosition(ctx, symbol, closePrice, closeReason, realizedPnL); err != nil {
+					if err := globalStopLossManager.CloseSide(ctx, symbol, closeSide, closePrice, closeReason, realizedPnL); err != nil {
 						log.Warning(fmt.Sprintf("⚠️  关闭 %s 持仓失败: %v", symbol, err))
 					}
 				}
@@ -683,12 +1265,12 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 				if symbolDecision.Action == executors.ActionBuy || symbolDecision.Action == executors.ActionSell {
 					// Validate and get leverage to use
 					// 验证并获取要使用的杠杆
-					leverageToUse := agents.ValidateLeverage(
-						symbolDecision.Leverage,
-						cfg.BinanceLeverageMin,
-						cfg.BinanceLeverageMax,
-						cfg.BinanceLeverageDynamic,
-					)
+					leverageToUse, _ := agents.SelectLeverage(cfg, symbolDecision.Leverage, state.Regime(symbol))
+					if symbolDecision.PositionSizePercent <= 0 && result.Leverage > 0 {
+						// An entry sized from its stop distance picks its own leverage
+						// 按止损距离计算的仓位自行选择杠杆
+						leverageToUse = result.Leverage
+					}
 
 					if cfg.BinanceLeverageDynamic {
 						log.Info(fmt.Sprintf("💡 LLM 选择杠杆: %dx (范围: %d-%d)", leverageToUse, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax))
@@ -700,14 +1282,14 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 					// 如果 LLM 未提供止损价格，则计算初始止损
 					initialStopLoss := symbolDecision.StopLoss
 					if initialStopLoss == 0 {
-						// Use 2.5% default stop-loss
-						// 使用 2.5% 默认止损
-						if symbolDecision.Action == executors.ActionBuy {
-							initialStopLoss = result.Price * 0.975 // -2.5%
-						} else {
-							initialStopLoss = result.Price * 1.025 // +2.5%
+						// Use the 2.5% default stop-loss the entry was risk-capped with
+						// 使用开仓风险上限所依据的 2.5% 默认止损
+						defaultSide := "long"
+						if symbolDecision.Action == executors.ActionSell {
+							defaultSide = "short"
 						}
-						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 2.5%% 止损: %.2f", initialStopLoss))
+						initialStopLoss = executors.DefaultInitialStop(defaultSide, result.Price)
+						log.Info(fmt.Sprintf("LLM 未提供止损价格，使用默认 %.1f%% 止损: %.2f", executors.DefaultStopLossPercent, initialStopLoss))
 					}
 
 					// Get ATR value from indicators for dynamic trailing stop
@@ -743,7 +1325,7 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 						Side:            positionSide,
 						EntryPrice:      result.Price,
 						EntryTime:       time.Now(),
-						Quantity:        result.Amount,
+						Quantity:        result.FilledQuantity(),
 						Leverage:        leverageToUse,
 						InitialStopLoss: initialStopLoss,
 						CurrentStopLoss: initialStopLoss,
@@ -752,9 +1334,13 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 						ATR:             atrValue,
 					}
 
-					// Register to stop-loss manager
-					// 注册到止损管理器
-					globalStopLossManager.RegisterPosition(position)
+					// Register to stop-loss manager; a hedge leg sits next to the position it hedges
+					// 注册到止损管理器；对冲腿与被对冲的持仓并存
+					if result.Hedge {
+						globalStopLossManager.RegisterHedge(position)
+					} else {
+						globalStopLossManager.RegisterPosition(position)
+					}
 
 					// Save position to database
 					// 保存持仓到数据库
@@ -783,17 +1369,28 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 
 					// Place initial stop-loss order
 					// 下初始止损单
-					if err := globalStopLossManager.PlaceInitialStopLoss(ctx, position); err != nil {
-						log.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", err))
+					stopErr := globalStopLossManager.PlaceInitialStopLoss(ctx, position)
+					if stopErr != nil {
+						log.Warning(fmt.Sprintf("⚠️  下初始止损单失败: %v", stopErr))
+						executionErrors[symbol] = storage.ErrorCategoryExchange
 					} else {
 						log.Success(fmt.Sprintf("✅ 初始止损单已下达: %.2f", initialStopLoss))
 					}
+					intents.RecordPosition(intent, position.ID, stopErr)
 				}
 			} else {
 				executionResults[symbol] = fmt.Sprintf("❌ 执行失败: %s", result.Message)
+				executionErrors[symbol] = storage.ErrorCategoryExchange
 			}
 		}
 
+		// Stale decisions are reported once and labelled historical, so they cannot be mistaken for live signals
+		// 过期决策只汇总通知一次并标记为历史，避免被误认为实时信号
+		if len(staleDecisions) > 0 {
+			globalStopLossManager.Notifier().Notify(notify.SeverityInfo, "全部交易对",
+				fmt.Sprintf("🕰️ [历史周期 %s] 决策已过期，未执行: %s", cycle.Format("01-02 15:04"), strings.Join(staleDecisions, "，")))
+		}
+
 		// Update portfolio summary after execution
 		// 执行后更新投资组合摘要
 		log.Subheader("执行后投资组合状态", '─', 80)
@@ -824,6 +1421,12 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 			log.Warning(fmt.Sprintf("⚠️  保存余额历史失败: %v", err))
 		}
 
+		// Resized entries keep the reason next to their result
+		// 被调整的开仓在执行结果旁保留调整原因
+		for symbol, note := range riskOverrides {
+			executionResults[symbol] = fmt.Sprintf("%s（%s）", executionResults[symbol], note)
+		}
+
 		// Display execution summary
 		// 显示执行摘要
 		log.Subheader("执行结果摘要", '─', 80)
@@ -842,11 +1445,16 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 		// 更新数据库中的执行结果
 		log.Info("更新数据库执行记录...")
 		executionResultStr := resultBuilder.String()
-		for _, symbol := range cfg.CryptoSymbols {
+		for _, symbol := range symbols {
 			if err := db.UpdateLatestSessionExecution(symbol, cfg.CryptoTimeframe, true, executionResultStr); err != nil {
 				log.Warning(fmt.Sprintf("⚠️  更新 %s 执行记录失败: %v", symbol, err))
 			}
 		}
+		for symbol, category := range executionErrors {
+			if err := db.UpdateLatestSessionErrorCategory(symbol, cfg.CryptoTimeframe, category); err != nil {
+				log.Warning(fmt.Sprintf("⚠️  更新 %s 错误类别失败: %v", symbol, err))
+			}
+		}
 
 		log.Success("✅ 自动执行流程完成")
 	} else {
@@ -854,5 +1462,5 @@ func runTradingAnalysis(ctx context.Context, cfg *config.Config, log *logger.Col
 	}
 
 	log.Success("✅ 本次执行完成")
-	return nil
+	return batchID, nil
 }
@@ -0,0 +1,170 @@
+package agents
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// reversalMarker opens the reasoning of a decision that goes against the recent ones
+// reversalMarker 是与近期决策方向相反的决策在 reasoning 开头必须使用的标记
+const reversalMarker = "与近期决策相反："
+
+// maxHistoryReasonRunes bounds how much of a past reasoning is repeated in the prompt
+// maxHistoryReasonRunes 限制提示词中重复的历史理由长度
+const maxHistoryReasonRunes = 120
+
+// pastDecision is one earlier decision of a symbol, read back from its trading session
+// pastDecision 是交易对的一次历史决策，从交易会话中读取
+type pastDecision struct {
+	Time       time.Time // 决策时间 / When it was made
+	Action     string    // 决策动作 / Decided action
+	Confidence float64   // 置信度，未知时为 0 / Confidence, 0 when unknown
+	Reason     string    // 决策理由 / Reasoning
+}
+
+// actionStance is +1 for actions leaning long, -1 for those leaning short and 0 for HOLD or unknown actions
+// actionStance 偏多的动作返回 +1，偏空的动作返回 -1，HOLD 或未知动作返回 0
+// Closing a long leans short and closing a short leans long, so a BUY followed by CLOSE_LONG is a reversal
+// 平多偏空、平空偏多，因此 BUY 之后紧接 CLOSE_LONG 也算方向反转
+func actionStance(action string) int {
+	switch strings.ToUpper(strings.TrimSpace(action)) {
+	case "BUY", "CLOSE_SHORT":
+		return 1
+	case "SELL", "CLOSE_LONG":
+		return -1
+	}
+	return 0
+}
+
+// pastDecisionsFrom reads the decisions back from sessions, keeping the newest-first order
+// pastDecisionsFrom 从会话中读取历史决策，保持由新到旧的顺序
+func pastDecisionsFrom(sessions []*storage.TradingSession) []pastDecision {
+	past := make([]pastDecision, 0, len(sessions))
+	for _, s := range sessions {
+		d := pastDecision{Time: s.CreatedAt, Action: s.Action}
+		for _, line := range strings.Split(s.Decision, "\n") {
+			line = strings.TrimSpace(line)
+			if v, ok := strings.CutPrefix(line, "**置信度**:"); ok {
+				d.Confidence, _ = strconv.ParseFloat(strings.TrimSpace(v), 64)
+			} else if v, ok := strings.CutPrefix(line, "**理由**:"); ok {
+				d.Reason = strings.TrimSpace(v)
+			}
+		}
+		if d.Action == "" {
+			continue
+		}
+		past = append(past, d)
+	}
+	return past
+}
+
+// latestDirectional returns the newest past decision that leaned one way, nil when all of them held
+// latestDirectional 返回最近一次有方向的历史决策，全部为观望时返回 nil
+func latestDirectional(past []pastDecision) *pastDecision {
+	for i := range past {
+		if actionStance(past[i].Action) != 0 {
+			return &past[i]
+		}
+	}
+	return nil
+}
+
+// formatDecisionHistory renders the recent decisions of a symbol, oldest first, and points out the reversals among them
+// formatDecisionHistory 按时间顺序渲染交易对的近期决策，并指出其中的方向反转
+func formatDecisionHistory(symbol string, past []pastDecision) string {
+	if len(past) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**%s**（最近 %d 次）:\n", symbol, len(past)))
+	var flips []string
+	prev := 0
+	for i := len(past) - 1; i >= 0; i-- {
+		d := past[i]
+		reason := []rune(d.Reason)
+		if len(reason) > maxHistoryReasonRunes {
+			reason = append(reason[:maxHistoryReasonRunes], []rune("…")...)
+		}
+		sb.WriteString(fmt.Sprintf("- %s %s（置信度 %.2f）: %s\n", d.Time.Format("01-02 15:04"), d.Action, d.Confidence, string(reason)))
+
+		stance := actionStance(d.Action)
+		if stance != 0 && prev != 0 && stance != prev {
+			flips = append(flips, fmt.Sprintf("%s %s", d.Time.Format("01-02 15:04"), d.Action))
+		}
+		if stance != 0 {
+			prev = stance
+		}
+	}
+	if len(flips) > 0 {
+		sb.WriteString(fmt.Sprintf("⚠️ 近期已出现方向反复（%s），避免无新信息的来回反转\n", strings.Join(flips, "、")))
+	}
+	return sb.String()
+}
+
+// reversalIssue returns why a decision reversing the latest directional one is not acceptable as is, empty when it
+// does not reverse it or explains the reversal with reversalMarker
+// reversalIssue 返回与最近一次有方向决策相反的决策为何不能接受；未反转或已用 reversalMarker 说明时返回空
+func reversalIssue(d TradeDecision, last *pastDecision) string {
+	if last == nil || actionStance(d.Action)*actionStance(last.Action) >= 0 {
+		return ""
+	}
+	if strings.Contains(d.Reasoning, reversalMarker) {
+		return ""
+	}
+	return fmt.Sprintf("%s 与 %s 的 %s 决策方向相反，reasoning 必须以「%s」开头，说明是什么新信息改变了判断，否则应保持一致或 HOLD",
+		strings.ToUpper(d.Action), last.Time.Format("01-02 15:04"), last.Action, reversalMarker)
+}
+
+// decisionHistoryReport loads the previous DECISION_CONSISTENCY_WINDOW decisions of every symbol of the run,
+// returning the prompt section and the latest directional decision per Binance symbol
+// decisionHistoryReport 读取本轮每个交易对之前的 DECISION_CONSISTENCY_WINDOW 次决策，
+// 返回提示词段落以及每个币安交易对最近一次有方向的决策
+func (g *SimpleTradingGraph) decisionHistoryReport() (string, map[string]*pastDecision) {
+	if g.decisions == nil || g.config.DecisionConsistencyWindow <= 0 {
+		return "", nil
+	}
+	var sb strings.Builder
+	latest := make(map[string]*pastDecision)
+	for _, symbol := range g.state.Symbols {
+		sessions, _, err := g.decisions.QuerySessions(storage.SessionQuery{Symbol: symbol, Limit: g.config.DecisionConsistencyWindow})
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️ 【%s】读取历史决策失败: %v", symbol, err))
+			continue
+		}
+		past := pastDecisionsFrom(sessions)
+		sb.WriteString(formatDecisionHistory(symbol, past))
+		if last := latestDirectional(past); last != nil {
+			latest[g.config.GetBinanceSymbolFor(symbol)] = last
+		}
+	}
+	return sb.String(), latest
+}
+
+// reversalIssues checks every decision against the latest directional decision of its symbol
+// reversalIssues 将每个决策与其交易对最近一次有方向的决策进行比较
+func (g *SimpleTradingGraph) reversalIssues(decisions map[string]TradeDecision) map[string]string {
+	latest := g.state.LatestDirectionalDecisions()
+	issues := make(map[string]string)
+	for sym, d := range decisions {
+		if issue := reversalIssue(d, latest[g.config.GetBinanceSymbolFor(sym)]); issue != "" {
+			issues[sym] = issue
+		}
+	}
+	return issues
+}
+
+// logReversals warns about unexplained reversals when the guardrail is not there to send them back
+// logReversals 在决策护栏未启用、无法退回决策时，对未说明的方向反转发出警告
+func (g *SimpleTradingGraph) logReversals(decision string) {
+	decisions, ok := parseDecisionMap(decision)
+	if !ok {
+		return
+	}
+	for sym, issue := range g.reversalIssues(decisions) {
+		g.logger.Warning(fmt.Sprintf("🔁【%s】决策一致性: %s", sym, issue))
+	}
+}
@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestPastDecisionsFrom(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	sessions := []*storage.TradingSession{
+		{CreatedAt: now, Action: "SELL", Decision: "【BTC/USDT】\n**交易方向**: SELL\n**置信度**: 0.72\n**杠杆倍数**: 5倍\n**理由**: Breakdown below support"},
+		{CreatedAt: now.Add(-time.Hour), Action: "HOLD", Decision: "【BTC/USDT】\n**交易方向**: HOLD\n**置信度**: 0.50\n**理由**: Range"},
+		{CreatedAt: now.Add(-2 * time.Hour), Action: "", Decision: "unparsed"},
+		{CreatedAt: now.Add(-3 * time.Hour), Action: "BUY", Decision: "【BTC/USDT】\n**交易方向**: BUY\n**置信度**: 0.80\n**理由**: Breakout"},
+	}
+
+	past := pastDecisionsFrom(sessions)
+	if len(past) != 3 || past[0].Confidence != 0.72 || past[0].Reason != "Breakdown below support" {
+		t.Fatalf("Expected 3 parsed decisions, got %+v", past)
+	}
+	if last := latestDirectional(past); last == nil || last.Action != "SELL" {
+		t.Errorf("Expected SELL as the latest directional decision, got %+v", last)
+	}
+
+	report := formatDecisionHistory("BTC/USDT", past)
+	if !strings.Contains(report, "BUY（置信度 0.80）: Breakout") || strings.Index(report, "BUY") > strings.Index(report, "SELL") {
+		t.Errorf("Expected the decisions oldest first, got:\n%s", report)
+	}
+	if !strings.Contains(report, "方向反复") {
+		t.Errorf("Expected the BUY → SELL flip to be pointed out, got:\n%s", report)
+	}
+}
+
+func TestReversalIssue(t *testing.T) {
+	last := &pastDecision{Time: time.Now(), Action: "BUY"}
+	tests := []struct {
+		name      string
+		decision  TradeDecision
+		wantIssue bool
+	}{
+		{"same direction", TradeDecision{Action: "BUY", Reasoning: "Trend continues"}, false},
+		{"hold", TradeDecision{Action: "HOLD", Reasoning: "Wait"}, false},
+		{"unexplained reversal", TradeDecision{Action: "SELL", Reasoning: "Looks weak"}, true},
+		{"unexplained close", TradeDecision{Action: "CLOSE_LONG", Reasoning: "Take profit"}, true},
+		{"explained reversal", TradeDecision{Action: "SELL", Reasoning: reversalMarker + "Funding flipped and support broke"}, false},
+	}
+	for _, tt := range tests {
+		if issue := reversalIssue(tt.decision, last); (issue != "") != tt.wantIssue {
+			t.Errorf("%s: got issue %q, want issue %v", tt.name, issue, tt.wantIssue)
+		}
+	}
+	if issue := reversalIssue(TradeDecision{Action: "SELL"}, nil); issue != "" {
+		t.Errorf("Expected no issue without history, got %q", issue)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 )
 
@@ -632,3 +635,49 @@ func ValidateLeverage(llmLeverage int, minLeverage int, maxLeverage int, dynamic
 	// 使用 LLM 的选择
 	return llmLeverage
 }
+
+// SelectLeverage returns the leverage for an opening order: the LLM's choice validated against the configured range,
+// then lowered to the ceiling of the symbol's market regime; capped reports whether the ceiling applied
+// SelectLeverage 返回开仓使用的杠杆：先按配置范围校验 LLM 的选择，再降到交易对市场状态的上限；capped 表示上限是否生效
+func SelectLeverage(cfg *config.Config, llmLeverage int, regime dataflows.MarketRegime) (leverage int, capped bool) {
+	leverage = ValidateLeverage(llmLeverage, cfg.BinanceLeverageMin, cfg.BinanceLeverageMax, cfg.BinanceLeverageDynamic)
+	if ceiling := cfg.LeverageCapFor(string(regime)); ceiling > 0 && leverage > ceiling {
+		return ceiling, true
+	}
+	return leverage, false
+}
+
+// ExecutionOrder returns the symbols of a multi-currency decision in the order they should be executed:
+// closes first so they free position slots, then HOLD and invalid decisions, then entries by descending confidence
+// ExecutionOrder 返回多币种决策中各交易对的执行顺序：先平仓以释放持仓名额，再处理 HOLD 和无效决策，
+// 最后按置信度从高到低开仓，使 MAX_OPEN_POSITIONS 名额优先给最有把握的开仓
+func ExecutionOrder(decisions map[string]*TradingDecision) []string {
+	rank := func(d *TradingDecision) int {
+		switch {
+		case d == nil || !d.Valid:
+			return 1
+		case d.Action == executors.ActionCloseLong || d.Action == executors.ActionCloseShort:
+			return 0
+		case d.Action == executors.ActionBuy || d.Action == executors.ActionSell:
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	symbols := make([]string, 0, len(decisions))
+	for symbol := range decisions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := decisions[symbols[i]], decisions[symbols[j]]
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra < rb
+		}
+		if rank(a) == 2 && a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		return symbols[i] < symbols[j]
+	})
+	return symbols
+}
@@ -4,6 +4,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 )
 
@@ -513,3 +515,55 @@ func TestRealWorldDecision(t *testing.T) {
 	t.Logf("   Stop-Loss: %v", decision.StopLoss)
 	t.Logf("   Reason: %v", decision.Reason)
 }
+
+// TestSelectLeverage tests the regime ceiling on top of the configured leverage range
+// TestSelectLeverage 测试在配置杠杆范围之上应用市场状态上限
+func TestSelectLeverage(t *testing.T) {
+	cfg := &config.Config{
+		BinanceLeverageMin:     3,
+		BinanceLeverageMax:     20,
+		BinanceLeverageDynamic: true,
+		LeverageRegimeCaps:     map[string]int{"high_volatility": 5, "ranging": 2},
+	}
+
+	tests := []struct {
+		name     string
+		llm      int
+		regime   dataflows.MarketRegime
+		expected int
+		capped   bool
+	}{
+		{"trending keeps the LLM choice", 15, dataflows.RegimeTrending, 15, false},
+		{"high volatility caps", 15, dataflows.RegimeHighVolatility, 5, true},
+		{"below the ceiling is kept", 4, dataflows.RegimeHighVolatility, 4, false},
+		{"ceiling below the range minimum wins", 10, dataflows.RegimeRanging, 2, true},
+		{"unknown regime is not capped", 30, "", 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, capped := SelectLeverage(cfg, tt.llm, tt.regime)
+			if got != tt.expected || capped != tt.capped {
+				t.Errorf("SelectLeverage(%d, %s) = %d (capped=%v), expected %d (capped=%v)", tt.llm, tt.regime, got, capped, tt.expected, tt.capped)
+			}
+		})
+	}
+}
+
+// TestExecutionOrder tests that closes run first and entries queue by confidence
+// TestExecutionOrder 测试平仓优先执行、开仓按置信度排队
+func TestExecutionOrder(t *testing.T) {
+	decisions := map[string]*TradingDecision{
+		"BTC/USDT":  {Action: executors.ActionBuy, Confidence: 0.6, Valid: true},
+		"ETH/USDT":  {Action: executors.ActionSell, Confidence: 0.9, Valid: true},
+		"SOL/USDT":  {Action: executors.ActionCloseLong, Valid: true},
+		"BNB/USDT":  {Action: executors.ActionHold, Valid: true},
+		"DOGE/USDT": {Action: executors.ActionBuy, Confidence: 0.95},
+		"XRP/USDT":  {Action: executors.ActionBuy, Confidence: 0.6, Valid: true},
+	}
+
+	got := strings.Join(ExecutionOrder(decisions), ",")
+	want := "SOL/USDT,BNB/USDT,DOGE/USDT,ETH/USDT,BTC/USDT,XRP/USDT"
+	if got != want {
+		t.Errorf("ExecutionOrder() = %s, want %s", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package agents
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"regexp"
 	"strings"
@@ -14,10 +15,12 @@ import (
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/eino-contrib/jsonschema"
+	"github.com/oak/crypto-trading-bot/internal/capture"
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // SymbolReports holds reports for a single symbol
@@ -27,12 +30,23 @@ type SymbolReports struct {
 	MarketReport              string
 	CryptoReport              string
 	SentimentReport           string
+	FundingReport             string // 资金费率与持仓拥挤度分析（可选分析师）/ Funding and crowding analysis (optional analyst)
+	IntegrityIssue            string // K 线交叉校验发现的偏差，空表示正常 / Kline cross-check discrepancy, empty when clean
+	ContractSpec              string // 合约规格（最小变动、数量限制、杠杆分层、资金费间隔）/ Contract specs (tick, lot limits, leverage bracket, funding interval)
 	PositionInfo              string
 	OHLCVData                 []dataflows.OHLCV
 	TechnicalIndicators       *dataflows.TechnicalIndicators // 主时间周期的技术指标 / Primary timeframe indicators
 	LongerTechnicalIndicators *dataflows.TechnicalIndicators // 长期时间周期的技术指标 / Longer timeframe indicators
+	LongerOHLCVData           []dataflows.OHLCV              // 长期时间周期的 K 线 / Longer timeframe bars
+	Regime                    *dataflows.RegimeReading       // 主时间周期的市场状态，K 线不足时为 nil / Primary timeframe regime, nil when bars are too few
+	ErrorCategory             string                         // 分析阶段的失败类别（DATA/LLM），空表示正常 / Failure category during analysis (DATA/LLM), empty when clean
+	AnalystStatus             map[string]string              // 分析师 → 报告状态，未运行的分析师不在其中 / Analyst → report status, analysts that did not run are missing
 }
 
+// attributedAnalysts are the analysts whose report status is recorded with every session
+// attributedAnalysts 是每个会话都记录报告状态的分析师
+var attributedAnalysts = []string{"market", "crypto", "sentiment", "funding"}
+
 // TradeDecision represents a structured trading decision from LLM (for JSON Schema output)
 // TradeDecision 表示 LLM 的结构化交易决策（用于 JSON Schema 输出）
 type TradeDecision struct {
@@ -58,8 +72,22 @@ type AgentState struct {
 	Reports       map[string]*SymbolReports // 每个交易对的报告 / Reports for each symbol
 	AccountInfo   string                    // 账户总览信息 / Account overview
 	AllPositions  string                    // 所有持仓汇总 / All positions summary
+	Lessons       string                    // 用户维护的交易规则 / User-maintained trading rules
+	Decisions     string                    // 各交易对的近期决策 / Recent decisions of each symbol
 	FinalDecision string                    // 最终交易决策 / Final trading decision
 	mu            sync.RWMutex              // 读写锁 / Read-write mutex
+
+	// capture archives node outputs of symbols armed for debug capture, nil records nothing
+	// capture 归档已开启调试采集的交易对的节点输出，为 nil 时不记录
+	capture *capture.Recorder
+
+	// latestDirectional is the newest decision of each Binance symbol that leaned one way
+	// latestDirectional 是每个币安交易对最近一次有方向的决策
+	latestDirectional map[string]*pastDecision
+
+	// traderProfile is the model and prompt making this cycle's live decision, nil uses the configured ones
+	// traderProfile 是本轮做出实盘决策的模型和提示词，为 nil 时使用当前配置
+	traderProfile *traderProfile
 }
 
 // NewAgentState creates a new agent state for multiple symbols
@@ -81,6 +109,7 @@ func NewAgentState(symbols []string, timeframe string) *AgentState {
 // SetMarketReport sets the market analysis report for a symbol
 // SetMarketReport 设置某个交易对的市场分析报告
 func (s *AgentState) SetMarketReport(symbol, report string) {
+	s.capture.RecordText(symbol, "market_report", report)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if r, exists := s.Reports[symbol]; exists {
@@ -91,6 +120,7 @@ func (s *AgentState) SetMarketReport(symbol, report string) {
 // SetCryptoReport sets the crypto analysis report for a symbol
 // SetCryptoReport 设置某个交易对的加密货币分析报告
 func (s *AgentState) SetCryptoReport(symbol, report string) {
+	s.capture.RecordText(symbol, "crypto_report", report)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if r, exists := s.Reports[symbol]; exists {
@@ -101,6 +131,7 @@ func (s *AgentState) SetCryptoReport(symbol, report string) {
 // SetSentimentReport sets the sentiment analysis report for a symbol
 // SetSentimentReport 设置某个交易对的情绪分析报告
 func (s *AgentState) SetSentimentReport(symbol, report string) {
+	s.capture.RecordText(symbol, "sentiment_report", report)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if r, exists := s.Reports[symbol]; exists {
@@ -108,9 +139,82 @@ func (s *AgentState) SetSentimentReport(symbol, report string) {
 	}
 }
 
+// SetFundingReport sets the funding and crowding report for a symbol
+// SetFundingReport 设置某个交易对的资金费率与拥挤度报告
+func (s *AgentState) SetFundingReport(symbol, report string) {
+	s.capture.RecordText(symbol, "funding_report", report)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, exists := s.Reports[symbol]; exists {
+		r.FundingReport = report
+	}
+}
+
+// SetIntegrityIssue records the kline cross-check result for a symbol, empty when the data is clean
+// SetIntegrityIssue 记录某个交易对的 K 线交叉校验结果，数据正常时为空
+func (s *AgentState) SetIntegrityIssue(symbol, issue string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, exists := s.Reports[symbol]; exists {
+		r.IntegrityIssue = issue
+	}
+}
+
+// SetAnalystStatus records whether an analyst's report for a symbol is complete or degraded
+// SetAnalystStatus 记录某个分析师对交易对的报告是完整还是降级
+func (s *AgentState) SetAnalystStatus(symbol, analyst, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, exists := s.Reports[symbol]; exists {
+		if r.AnalystStatus == nil {
+			r.AnalystStatus = make(map[string]string)
+		}
+		r.AnalystStatus[analyst] = status
+	}
+}
+
+// AnalystStatus returns the report status of every attributed analyst for a symbol, absent for those that did not run
+// AnalystStatus 返回交易对所有归因分析师的报告状态，未运行的分析师为 absent
+func (s *AgentState) AnalystStatus(symbol string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := make(map[string]string, len(attributedAnalysts))
+	for _, analyst := range attributedAnalysts {
+		status[analyst] = storage.AnalystAbsent
+		if r, exists := s.Reports[symbol]; exists {
+			if st, ok := r.AnalystStatus[analyst]; ok {
+				status[analyst] = st
+			}
+		}
+	}
+	return status
+}
+
+// SetFailure records why the analysis of a symbol degraded; the first failure is kept
+// SetFailure 记录某个交易对分析降级的原因，只保留第一次失败
+func (s *AgentState) SetFailure(symbol, category string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, exists := s.Reports[symbol]; exists && r.ErrorCategory == "" {
+		r.ErrorCategory = category
+	}
+}
+
+// SetContractSpec sets the formatted contract specs for a symbol
+// SetContractSpec 设置某个交易对的合约规格
+func (s *AgentState) SetContractSpec(symbol, spec string) {
+	s.capture.RecordText(symbol, "contract_spec", spec)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, exists := s.Reports[symbol]; exists {
+		r.ContractSpec = spec
+	}
+}
+
 // SetPositionInfo sets the position information for a symbol
 // SetPositionInfo 设置某个交易对的持仓信息
 func (s *AgentState) SetPositionInfo(symbol, info string) {
+	s.capture.RecordText(symbol, "position_info", info)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if r, exists := s.Reports[symbol]; exists {
@@ -134,6 +238,47 @@ func (s *AgentState) SetAllPositions(info string) {
 	s.AllPositions = info
 }
 
+// SetLessons sets the user-maintained trading rules shown to the trader
+// SetLessons 设置提供给交易员的用户交易规则
+func (s *AgentState) SetLessons(lessons string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Lessons = lessons
+}
+
+// SetDecisionHistory sets the recent decisions shown to the trader and the latest directional one of each symbol
+// SetDecisionHistory 设置提供给交易员的近期决策以及每个交易对最近一次有方向的决策
+func (s *AgentState) SetDecisionHistory(history string, latest map[string]*pastDecision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Decisions = history
+	s.latestDirectional = latest
+}
+
+// LatestDirectionalDecisions returns the newest decision of each Binance symbol that leaned one way
+// LatestDirectionalDecisions 返回每个币安交易对最近一次有方向的决策
+func (s *AgentState) LatestDirectionalDecisions() map[string]*pastDecision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latestDirectional
+}
+
+// SetTraderProfile sets the model and prompt making this cycle's live decision
+// SetTraderProfile 设置本轮做出实盘决策的模型和提示词
+func (s *AgentState) SetTraderProfile(profile traderProfile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.traderProfile = &profile
+}
+
+// TraderProfile returns the model and prompt making this cycle's live decision, or nil before one was set
+// TraderProfile 返回本轮做出实盘决策的模型和提示词，尚未设置时返回 nil
+func (s *AgentState) TraderProfile() *traderProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.traderProfile
+}
+
 // SetFinalDecision sets the final trading decision
 // SetFinalDecision 设置最终交易决策
 func (s *AgentState) SetFinalDecision(decision string) {
@@ -150,6 +295,46 @@ func (s *AgentState) GetSymbolReports(symbol string) *SymbolReports {
 	return s.Reports[symbol]
 }
 
+// LatestPrice returns the close of the latest primary timeframe bar of a symbol, 0 when no bars were loaded
+// LatestPrice 返回交易对主时间周期最新 K 线的收盘价，未加载 K 线时返回 0
+func (s *AgentState) LatestPrice(symbol string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, exists := s.Reports[symbol]
+	if !exists || len(r.OHLCVData) == 0 {
+		return 0
+	}
+	return r.OHLCVData[len(r.OHLCVData)-1].Close
+}
+
+// LatestATR returns the latest ATR(14) of a symbol's primary timeframe, or 0 when indicators are missing
+// LatestATR 返回交易对主时间周期最新的 ATR(14)，缺少指标时返回 0
+func (s *AgentState) LatestATR(symbol string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, exists := s.Reports[symbol]
+	if !exists || r.TechnicalIndicators == nil || len(r.TechnicalIndicators.ATR_14) == 0 {
+		return 0
+	}
+	atr := r.TechnicalIndicators.ATR_14[len(r.TechnicalIndicators.ATR_14)-1]
+	if math.IsNaN(atr) {
+		return 0
+	}
+	return atr
+}
+
+// Regime returns the market regime of a symbol in this run, empty when it could not be measured
+// Regime 返回交易对本轮的市场状态，无法测量时为空
+func (s *AgentState) Regime(symbol string) dataflows.MarketRegime {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, exists := s.Reports[symbol]
+	if !exists || r.Regime == nil {
+		return ""
+	}
+	return r.Regime.Regime
+}
+
 // GetAllReports returns all reports as a formatted string
 // GetAllReports 返回所有报告的格式化字符串
 func (s *AgentState) GetAllReports() string {
@@ -172,6 +357,22 @@ func (s *AgentState) GetAllReports() string {
 		sb.WriteString("\n")
 	}
 
+	// 用户维护的交易规则手册 / User-maintained trading rulebook
+	if s.Lessons != "" {
+		sb.WriteString("=== 交易规则手册（用户维护，必须遵守）===\n")
+		sb.WriteString(s.Lessons)
+		sb.WriteString("\n")
+	}
+
+	// 近期决策，用于保持决策一致 / Recent decisions, to keep decisions consistent
+	if s.Decisions != "" {
+		sb.WriteString("=== 近期决策（一致性要求）===\n")
+		sb.WriteString(fmt.Sprintf("若本次决策与某交易对最近一次有方向的决策相反（BUY/CLOSE_SHORT 偏多，SELL/CLOSE_LONG 偏空），"+
+			"reasoning 必须以「%s」开头，说明是什么新信息改变了判断；没有新信息时保持一致或 HOLD。\n", reversalMarker))
+		sb.WriteString(s.Decisions)
+		sb.WriteString("\n")
+	}
+
 	// 最后为每个交易对生成市场分析报告（不包含持仓信息）/ Finally generate market analysis for each symbol (without position info)
 	for _, symbol := range s.Symbols {
 		reports := s.Reports[symbol]
@@ -180,6 +381,14 @@ func (s *AgentState) GetAllReports() string {
 		sb.WriteString(reports.MarketReport)
 		sb.WriteString("\n\n=== 加密货币专属分析 ===\n")
 		sb.WriteString(reports.CryptoReport)
+		if reports.FundingReport != "" {
+			sb.WriteString("\n\n=== 资金费率与持仓拥挤度 ===\n")
+			sb.WriteString(reports.FundingReport)
+		}
+		if reports.ContractSpec != "" {
+			sb.WriteString("\n\n=== 合约规格 ===\n")
+			sb.WriteString(reports.ContractSpec)
+		}
 		//sb.WriteString("\n\n=== 市场情绪分析 ===\n")
 		//sb.WriteString(reports.SentimentReport)
 		sb.WriteString("\n")
@@ -219,7 +428,7 @@ func loadPromptFromFile(promptPath string, log *logger.ColorLogger) string {
 **入场理由**: 为什么这是高确定性机会？（1-2 句话，说明趋势+确认信号）
 **初始止损**: $具体价格（基于支撑/阻力或 2×ATR，必须输出数字）
 **预期盈亏比**: ≥ 2:1（说明止损空间 vs 目标空间，但不设固定止盈）
-**仓位建议**: 如 "30% 资金" 或 "维持观望"
+**仓位建议**: 如 "30% 资金" 或 "维持观望"（不填时按止损距离与单笔风险自动计算仓位和杠杆）
 
 **止损设置要求**（Critical）：
 • 必须输出具体止损价格，如 "初始止损: $95000"
@@ -263,6 +472,44 @@ func loadPromptFromFile(promptPath string, log *logger.ColorLogger) string {
 	return promptContent
 }
 
+// decisionLanguageNames maps DECISION_LANGUAGE codes to the language name given to the LLM
+// decisionLanguageNames 将 DECISION_LANGUAGE 代码映射为提供给 LLM 的语言名称
+var decisionLanguageNames = map[string]string{
+	"en": "English",
+	"ja": "Japanese (日本語)",
+	"ko": "Korean (한국어)",
+	"es": "Spanish (Español)",
+	"fr": "French (Français)",
+	"de": "German (Deutsch)",
+	"ru": "Russian (Русский)",
+	"pt": "Portuguese (Português)",
+}
+
+// decisionLanguageInstruction returns the system prompt suffix that switches free-text fields to another language
+// decisionLanguageInstruction 返回将自由文本字段切换为其他语言的系统 Prompt 后缀
+// Chinese is the prompts' native language and needs no suffix; unknown codes are passed through as a language name
+// 中文是 Prompt 的原生语言，不需要后缀；未知代码原样作为语言名称使用
+func decisionLanguageInstruction(lang string) string {
+	lang = strings.TrimSpace(lang)
+	switch strings.ToLower(lang) {
+	case "", "zh", "zh-cn", "chinese", "中文":
+		return ""
+	}
+
+	name, ok := decisionLanguageNames[strings.ToLower(lang)]
+	if !ok {
+		name = lang
+	}
+
+	return fmt.Sprintf(`
+
+---
+
+**Output language (overrides any language instruction above)**:
+Write all free-text content — the "reasoning", "summary" and "stop_loss_reason" fields and any analysis outside them — in %s.
+Keep the machine-readable parts exactly as specified: JSON keys, symbol names, numbers, and the action values BUY / SELL / HOLD / CLOSE_LONG / CLOSE_SHORT must stay in English and follow the schema.`, name)
+}
+
 // SimpleTradingGraph creates a simplified trading workflow using Eino Graph
 type SimpleTradingGraph struct {
 	config          *config.Config
@@ -270,25 +517,62 @@ type SimpleTradingGraph struct {
 	executor        *executors.BinanceExecutor
 	state           *AgentState
 	stopLossManager *executors.StopLossManager
-	startTime       time.Time  // 交易开始时间 / Trading start time
-	tradeCount      int        // 已执行的交易次数 / Number of trades executed
-	mu              sync.Mutex // 保护 tradeCount / Protect tradeCount
+	startTime       time.Time        // 交易开始时间 / Trading start time
+	tradeCount      int              // 已执行的交易次数 / Number of trades executed
+	mu              sync.Mutex       // 保护 tradeCount / Protect tradeCount
+	memory          *storage.Storage // 历史情境记忆（USE_MEMORY）/ Situation memory store (USE_MEMORY)
+	lessons         *storage.Storage // 用户交易规则 / User trading lessons store
+	decisions       *storage.Storage // 历史决策（DECISION_CONSISTENCY_WINDOW）/ Past decisions store (DECISION_CONSISTENCY_WINDOW)
+	traderConfigs   *storage.Storage // 交易员配置版本与影子决策（SHADOW_CYCLES）/ Trader config versions and shadow decisions (SHADOW_CYCLES)
 }
 
 // NewSimpleTradingGraph creates a new simple trading graph
 // NewSimpleTradingGraph 创建新的简单交易图
 func NewSimpleTradingGraph(cfg *config.Config, log *logger.ColorLogger, executor *executors.BinanceExecutor, stopLossManager *executors.StopLossManager) *SimpleTradingGraph {
+	state := NewAgentState(cfg.CryptoSymbols, cfg.CryptoTimeframe)
+	state.capture = capture.Shared(cfg)
 	return &SimpleTradingGraph{
 		config:          cfg,
 		logger:          log,
 		executor:        executor,
-		state:           NewAgentState(cfg.CryptoSymbols, cfg.CryptoTimeframe),
+		state:           state,
 		stopLossManager: stopLossManager,
 		startTime:       time.Now(), // 初始化交易开始时间 / Initialize trading start time
 		tradeCount:      0,          // 初始化交易次数为 0 / Initialize trade count to 0
 	}
 }
 
+// SetSymbols restricts this run to a subset of the configured symbols, e.g. to skip paused ones
+// SetSymbols 将本次运行限定为配置交易对的子集，例如跳过已暂停的交易对
+func (g *SimpleTradingGraph) SetSymbols(symbols []string) {
+	g.state = NewAgentState(symbols, g.config.CryptoTimeframe)
+	g.state.capture = capture.Shared(g.config)
+}
+
+// SetMemoryStore enables recall of similar past situations in the market report
+// SetMemoryStore 启用在市场报告中召回历史相似情境
+func (g *SimpleTradingGraph) SetMemoryStore(db *storage.Storage) {
+	g.memory = db
+}
+
+// SetLessonStore enables the user-maintained trading rules in the trader prompt
+// SetLessonStore 启用交易员提示词中的用户交易规则
+func (g *SimpleTradingGraph) SetLessonStore(db *storage.Storage) {
+	g.lessons = db
+}
+
+// SetDecisionStore enables the recent decisions in the trader prompt and the check of unexplained reversals
+// SetDecisionStore 启用交易员提示词中的近期决策以及对未说明的方向反转的检查
+func (g *SimpleTradingGraph) SetDecisionStore(db *storage.Storage) {
+	g.decisions = db
+}
+
+// SetTraderConfigStore enables versioning of the trader's model and prompt, with shadow runs of changes
+// SetTraderConfigStore 启用交易员模型和提示词的版本管理，变更先影子运行
+func (g *SimpleTradingGraph) SetTraderConfigStore(db *storage.Storage) {
+	g.traderConfigs = db
+}
+
 // IncrementTradeCount increments the trade counter (thread-safe)
 // IncrementTradeCount 增加交易计数（线程安全）
 func (g *SimpleTradingGraph) IncrementTradeCount() {
@@ -333,14 +617,30 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 
 				binanceSymbol := g.config.GetBinanceSymbolFor(sym)
 
-				// Fetch OHLCV data for primary timeframe
-				// 获取主时间周期的 OHLCV 数据
-				ohlcvData, err := marketData.GetOHLCV(ctx, binanceSymbol, timeframe, lookbackDays)
+				// Fetch primary, longer-timeframe and multi-timeframe klines in parallel
+				// 并行获取主周期、长期周期和多时间框架 K 线
+				longerTimeframe := ""
+				if g.config.EnableMultiTimeframe {
+					longerTimeframe = g.config.CryptoLongerTimeframe
+					g.logger.Info(fmt.Sprintf("  🔄 正在获取 %s 更长期时间周期数据 (%s)...", sym, longerTimeframe))
+				}
+				klineSet := marketData.GetKlineSet(ctx, binanceSymbol, timeframe, lookbackDays, longerTimeframe, g.config.CryptoLongerLookbackDays)
+
+				ohlcvData, err := klineSet.Primary, klineSet.PrimaryErr
 				if err != nil {
 					g.logger.Warning(fmt.Sprintf("  ⚠️  %s OHLCV数据获取失败: %v", sym, err))
+					g.state.SetFailure(sym, storage.ErrorCategoryData)
+					g.state.SetAnalystStatus(sym, "market", storage.AnalystDegraded)
 					return
 				}
 
+				integrityIssue := g.crossCheckKlines(ctx, marketData, sym, binanceSymbol, timeframe, ohlcvData)
+				if integrityIssue != "" {
+					g.state.SetAnalystStatus(sym, "market", storage.AnalystDegraded)
+				} else {
+					g.state.SetAnalystStatus(sym, "market", storage.AnalystPresent)
+				}
+
 				// Calculate indicators for primary timeframe
 				// 计算主时间周期的指标
 				indicators := dataflows.CalculateIndicators(ohlcvData)
@@ -348,26 +648,41 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				// Generate primary timeframe report
 				// 生成主时间周期报告
 				report := dataflows.FormatIndicatorReport(sym, timeframe, ohlcvData, indicators)
+				regime, regimeOK := dataflows.DetectRegime(ohlcvData, indicators, dataflows.RegimeThresholds{
+					HighPercentile: g.config.RegimeHighPercentile,
+					LowPercentile:  g.config.RegimeLowPercentile,
+					TrendADX:       g.config.RegimeTrendADX,
+				})
+				if regimeOK {
+					report += fmt.Sprintf("\n市场状态: %s\n", regime)
+				}
+				// Structure-mode symbols take profit at support/resistance, so the levels are refreshed every cycle
+				// 结构位模式的交易对在支撑/阻力位止盈，因此每个周期刷新结构位
+				if g.stopLossManager != nil && g.config.TakeProfitModeFor(sym) == executors.TakeProfitModeStructure && len(ohlcvData) > 0 {
+					structureLevels := dataflows.DetectStructureLevels(ohlcvData)
+					g.stopLossManager.UpdateStructureLevels(sym, structureLevels)
+					report += dataflows.FormatStructureLevels(structureLevels, ohlcvData[len(ohlcvData)-1].Close, 3)
+				}
+				if integrityIssue != "" {
+					report = fmt.Sprintf("⚠️ 数据校验警告: 最近 K 线与%s价格不一致，以下价格可能有误: %s\n\n", g.config.KlineCrossCheckSource, integrityIssue) + report
+				}
 
 				// Multi-timeframe analysis (if enabled)
 				// 多时间周期分析（如果启用）
 				var longerIndicators *dataflows.TechnicalIndicators
-				if g.config.EnableMultiTimeframe {
-					g.logger.Info(fmt.Sprintf("  🔄 正在获取 %s 更长期时间周期数据 (%s)...", sym, g.config.CryptoLongerTimeframe))
-
-					// Fetch OHLCV data for longer timeframe
-					// 获取更长期时间周期的 OHLCV 数据
-					longerOHLCV, err := marketData.GetOHLCV(ctx, binanceSymbol, g.config.CryptoLongerTimeframe, g.config.CryptoLongerLookbackDays)
-					if err != nil {
-						g.logger.Warning(fmt.Sprintf("  ⚠️  %s 更长期时间周期数据获取失败: %v", sym, err))
+				var longerData []dataflows.OHLCV
+				if longerTimeframe != "" {
+					if klineSet.LongerErr != nil {
+						g.logger.Warning(fmt.Sprintf("  ⚠️  %s 更长期时间周期数据获取失败: %v", sym, klineSet.LongerErr))
 					} else {
 						// Calculate indicators for longer timeframe (with configurable ATR period for trailing stop)
 						// 计算更长期时间周期的指标（使用可配置的 ATR 周期用于追踪止损）
-						longerIndicators = dataflows.CalculateIndicators(longerOHLCV, g.config.TrailingStopATRPeriod)
+						longerIndicators = dataflows.CalculateIndicators(klineSet.Longer, g.config.TrailingStopATRPeriod)
+						longerData = klineSet.Longer
 
 						// Generate longer timeframe report
 						// 生成更长期时间周期报告
-						longerReport := dataflows.FormatLongerTimeframeReport(sym, g.config.CryptoLongerTimeframe, longerOHLCV, longerIndicators)
+						longerReport := dataflows.FormatLongerTimeframeReport(sym, longerTimeframe, klineSet.Longer, longerIndicators)
 
 						// Append longer timeframe report to main report
 						// 将更长期时间周期报告追加到主报告
@@ -379,10 +694,8 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 
 				// Multi-timeframe indicators analysis (always enabled)
 				// 多时间框架指标分析（默认启用）
-				g.logger.Info(fmt.Sprintf("  📈 正在获取 %s 多时间框架指标...", sym))
-				multiTimeframeIndicators := marketData.GetMultiTimeframeIndicators(ctx, binanceSymbol)
-				if len(multiTimeframeIndicators) > 0 {
-					multiTimeframeReport := dataflows.FormatMultiTimeframeReport(multiTimeframeIndicators)
+				if len(klineSet.MultiFrames) > 0 {
+					multiTimeframeReport := dataflows.FormatMultiTimeframeReport(klineSet.MultiFrames)
 					if multiTimeframeReport != "" {
 						// Append multi-timeframe indicators report to main report
 						// 将多时间框架指标报告追加到主报告
@@ -390,6 +703,12 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 						g.logger.Success(fmt.Sprintf("  ✅ %s 多时间框架指标分析完成", sym))
 					}
 				}
+				// Recall labeled situations similar to the current bar (seeded by cmd/memory)
+				// 召回与当前 K 线相似的带标签历史情境（由 cmd/memory 播种）
+				if memoryReport := g.memoryReport(binanceSymbol, timeframe, ohlcvData); memoryReport != "" {
+					report += "\n" + memoryReport
+				}
+				g.logger.Info(fmt.Sprintf("  ⏱️  %s K线数据组装耗时: %v", sym, klineSet.Elapsed.Round(time.Millisecond)))
 
 				// Save to state (thread-safe)
 				mu.Lock()
@@ -397,6 +716,10 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					reports.OHLCVData = ohlcvData
 					reports.TechnicalIndicators = indicators
 					reports.LongerTechnicalIndicators = longerIndicators // 保存长期时间周期指标 / Save longer timeframe indicators
+					reports.LongerOHLCVData = longerData
+					if regimeOK {
+						reports.Regime = &regime
+					}
 				}
 				mu.Unlock()
 
@@ -433,12 +756,15 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 
 				reportBuilder.WriteString(fmt.Sprintf("=== %s 加密货币数据 ===\n\n", sym))
 
+				// Fetch funding rate, open interest and 24h stats in parallel
+				// 并行获取资金费率、持仓量和 24h 统计
+				snapshot := marketData.GetCryptoSnapshot(ctx, binanceSymbol, "15m", 16)
+
 				// Funding rate
-				fundingRate, err := marketData.GetFundingRate(ctx, binanceSymbol)
-				if err != nil {
-					reportBuilder.WriteString(fmt.Sprintf("资金费率获取失败: %v\n\n", err))
+				if snapshot.FundingErr != nil {
+					reportBuilder.WriteString(fmt.Sprintf("资金费率获取失败: %v\n\n", snapshot.FundingErr))
 				} else {
-					reportBuilder.WriteString(fmt.Sprintf("💰 资金费率: %.6f (%.4f%%)\n\n", fundingRate, fundingRate*100))
+					reportBuilder.WriteString(fmt.Sprintf("💰 资金费率: %.6f (%.4f%%)\n\n", snapshot.FundingRate, snapshot.FundingRate*100))
 				}
 
 				// Order book - use enhanced format
@@ -457,10 +783,9 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				reportBuilder.WriteString("📊 持仓量统计 (4h, 15m间隔):\n")
 				reportBuilder.WriteString("注意：以下数据均为从旧到新，显示相对于上一个点的变化率\n")
 
-				oiSeries, err := marketData.GetOpenInterestChange(ctx, binanceSymbol, "15m", 16)
-				if err != nil {
-					reportBuilder.WriteString(fmt.Sprintf("  数据获取失败: %v\n\n", err))
-				} else if rawSeries, ok := oiSeries["series_values"].([]float64); ok && len(rawSeries) > 0 {
+				if snapshot.OpenInterestErr != nil {
+					reportBuilder.WriteString(fmt.Sprintf("  数据获取失败: %v\n\n", snapshot.OpenInterestErr))
+				} else if rawSeries, ok := snapshot.OpenInterest["series_values"].([]float64); ok && len(rawSeries) > 0 {
 					// 显示起始值和结束值（绝对值）
 					// Display start and end values (absolute values)
 
@@ -514,9 +839,9 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				//}
 
 				// 24h stats
-				stats, err := marketData.Get24HrStats(ctx, binanceSymbol)
-				if err != nil {
-					reportBuilder.WriteString(fmt.Sprintf("📅 24h统计获取失败: %v\n", err))
+				stats := snapshot.Stats24h
+				if snapshot.Stats24hErr != nil {
+					reportBuilder.WriteString(fmt.Sprintf("📅 24h统计获取失败: %v\n", snapshot.Stats24hErr))
 				} else {
 					reportBuilder.WriteString("📅 24h统计:\n")
 					reportBuilder.WriteString(fmt.Sprintf("- 价格变化: %s%%, 最高: $%s, 最低: $%s, 成交量: %s\n",
@@ -525,8 +850,13 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 
 				report := reportBuilder.String()
 				g.state.SetCryptoReport(sym, report)
+				if snapshot.FundingErr != nil || snapshot.OpenInterestErr != nil || snapshot.Stats24hErr != nil {
+					g.state.SetAnalystStatus(sym, "crypto", storage.AnalystDegraded)
+				} else {
+					g.state.SetAnalystStatus(sym, "crypto", storage.AnalystPresent)
+				}
 
-				g.logger.Success(fmt.Sprintf("  ✅ %s 加密货币分析完成", sym))
+				g.logger.Success(fmt.Sprintf("  ✅ %s 加密货币分析完成 (耗时 %v)", sym, snapshot.Elapsed.Round(time.Millisecond)))
 			}(symbol)
 		}
 
@@ -578,9 +908,11 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 					g.logger.Warning(fmt.Sprintf("  ⚠️  %s 市场情绪数据获取失败", sym))
 					report := dataflows.FormatSentimentReport(nil)
 					g.state.SetSentimentReport(sym, report)
+					g.state.SetAnalystStatus(sym, "sentiment", storage.AnalystDegraded)
 				} else {
 					report := dataflows.FormatSentimentReport(sentiment)
 					g.state.SetSentimentReport(sym, report)
+					g.state.SetAnalystStatus(sym, "sentiment", storage.AnalystPresent)
 					g.logger.Success(fmt.Sprintf("  ✅ %s 情绪分析完成", sym))
 				}
 			}(symbol)
@@ -592,6 +924,54 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 		return results, nil
 	})
 
+	// Funding Analyst Lambda - Assesses positioning crowdedness from funding, open interest and long/short ratio
+	// Funding Analyst Lambda - 根据资金费率、持仓量和多空比评估持仓拥挤度
+	fundingAnalyst := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		g.logger.Info("🔍 资金费率分析师：正在评估所有交易对的持仓拥挤度...")
+
+		var wg sync.WaitGroup
+		for _, symbol := range g.state.Symbols {
+			wg.Add(1)
+			go func(sym string) {
+				defer wg.Done()
+
+				binanceSymbol := g.config.GetBinanceSymbolFor(sym)
+
+				// A failed series is left out of the score rather than failing the analyst
+				// 获取失败的序列不计入评分，而不是让分析师整体失败
+				fundingRates, err := marketData.GetFundingRateHistory(ctx, binanceSymbol, dataflows.FundingHistoryLimit)
+				if err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  %s 资金费率历史获取失败: %v", sym, err))
+				}
+				var oiValues, ratios []float64
+				if oi, err := marketData.GetOpenInterestChange(ctx, binanceSymbol, "1h", 25); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  %s 持仓量统计获取失败: %v", sym, err))
+				} else {
+					oiValues, _ = oi["series_values"].([]float64)
+				}
+				if lsr, err := marketData.GetTopLongShortPositionRatio(ctx, binanceSymbol, "1h", 25); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  %s 大户多空比获取失败: %v", sym, err))
+				} else {
+					ratios, _ = lsr["series_ratios"].([]float64)
+				}
+
+				assessment, ok := dataflows.AssessCrowding(fundingRates, oiValues, ratios)
+				g.state.SetFundingReport(sym, dataflows.FormatCrowdingReport(sym, assessment, ok))
+				if ok {
+					g.state.SetAnalystStatus(sym, "funding", storage.AnalystPresent)
+				} else {
+					g.state.SetAnalystStatus(sym, "funding", storage.AnalystDegraded)
+				}
+				g.logger.Success(fmt.Sprintf("  ✅ %s 拥挤度评估完成: %s (评分 %+d)", sym, assessment.Crowding, assessment.Score))
+			}(symbol)
+		}
+
+		wg.Wait()
+		g.logger.Success("✅ 所有交易对的资金费率分析完成")
+
+		return map[string]any{}, nil
+	})
+
 	// Position Info Lambda - Gets current position for all symbols
 	// Position Info Lambda - 获取所有交易对的持仓信息
 	positionInfo := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
@@ -638,14 +1018,57 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				// Only process symbols with active positions
 				// 只处理有持仓的币种
 				if g.stopLossManager.HasPosition(sym) {
+					// Break-even runs before and independently of the ATR trailing stop
+					// 保本止损先于 ATR 追踪止损运行，且相互独立
+					if err := g.stopLossManager.CheckBreakEven(ctx, sym); err != nil {
+						g.logger.Warning(fmt.Sprintf("  ⚠️  %s 保本止损检查失败: %v", sym, err))
+					}
+
 					// Get ATR_3 from longer timeframe data (preferred) or fallback to primary timeframe
 					// 优先从长期时间周期数据获取 ATR_7，如果不可用则回退到主时间周期
 					g.state.mu.RLock()
 					symbolReport, exists := g.state.Reports[sym]
 					g.state.mu.RUnlock()
 
-					if !exists {
+					// ATR multipliers follow where the ATR sits in its recent range, measured on the bars the stop trails on
+					// ATR 倍数随 ATR 在近期区间中的位置调整，使用追踪止损所用的 K 线测量
+					if exists && g.config.ATRScalingEnabled {
+						bars := symbolReport.LongerOHLCVData
+						if len(bars) == 0 {
+							bars = symbolReport.OHLCVData
+						}
+						g.stopLossManager.UpdateATRScale(sym, bars)
+					}
+
+					if formula := g.stopLossManager.TrailingFormula(sym); formula == executors.TrailingFormulaPercent || formula == executors.TrailingFormulaStep {
+						// Percentage and step trailing only need the extreme price the manager tracks, not the market data
+						// 百分比与阶梯追踪只需要管理器跟踪的极值价格，不依赖市场数据
+						if err := g.stopLossManager.UpdatePercentStop(ctx, sym, formula == executors.TrailingFormulaStep); err != nil {
+							g.logger.Warning(fmt.Sprintf("  ⚠️  %s %s 追踪止损更新失败: %v", sym, formula, err))
+						} else {
+							g.logger.Info(fmt.Sprintf("  ✓ %s %s 追踪止损检查完成", sym, formula))
+						}
+					} else if !exists {
 						g.logger.Warning(fmt.Sprintf("  ⚠️  %s 有持仓但缺少市场数据，无法更新追踪止损", sym))
+					} else if formula != executors.TrailingFormulaATR {
+						// Chandelier exit, parabolic SAR and SuperTrend need the bars themselves, preferring the longer timeframe like the ATR formula
+						// 吊灯止损、抛物线 SAR 与 SuperTrend 需要 K 线本身，与 ATR 公式一样优先使用长期时间周期
+						bars, barSource := symbolReport.LongerOHLCVData, g.config.CryptoLongerTimeframe
+						if len(bars) == 0 {
+							bars, barSource = symbolReport.OHLCVData, g.config.CryptoTimeframe
+						}
+						update := g.stopLossManager.UpdateChandelierStop
+						switch formula {
+						case executors.TrailingFormulaPSAR:
+							update = g.stopLossManager.UpdatePSARStop
+						case executors.TrailingFormulaSuperTrend:
+							update = g.stopLossManager.UpdateSuperTrendStop
+						}
+						if err := update(ctx, sym, bars); err != nil {
+							g.logger.Warning(fmt.Sprintf("  ⚠️  %s %s 追踪止损更新失败: %v", sym, formula, err))
+						} else {
+							g.logger.Info(fmt.Sprintf("  ✓ %s %s 追踪止损检查完成 (来源:%s)", sym, formula, barSource))
+						}
 					} else {
 						var latestATR7 float64
 						var atrSource string // 用于日志显示 ATR 来源 / For logging ATR source
@@ -680,6 +1103,14 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 				// If no position exists, skip trailing stop update silently
 				// 如果无持仓，静默跳过追踪止损更新（不输出日志）
 
+				// Contract specs keep the trader's size and stop suggestions placeable on the actual contract
+				// 合约规格确保交易员的仓位与止损建议在实际合约上可以下单
+				if spec, err := g.executor.GetContractSpec(ctx, sym); err != nil {
+					g.logger.Warning(fmt.Sprintf("  ⚠️  获取 %s 合约规格失败: %v", sym, err))
+				} else {
+					g.state.SetContractSpec(sym, executors.FormatContractSpec(spec))
+				}
+
 				// 获取持仓信息（不包含账户信息）/ Get position info (without account info)
 				posInfo := g.executor.GetPositionOnly(ctx, sym, g.stopLossManager)
 
@@ -711,19 +1142,38 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	trader := compose.InvokableLambda(func(ctx context.Context, input map[string]any) (map[string]any, error) {
 		g.logger.Info("🤖 交易员：正在制定交易策略...")
 
+		g.state.SetLessons(g.lessonsReport())
+		g.state.SetDecisionHistory(g.decisionHistoryReport())
 		allReports := g.state.GetAllReports()
 
 		// Try to use LLM for decision, fall back to simple rules if LLM fails
 		var decision string
 		var err error
 
-		// Check if API key is configured
-		if g.config.APIKey != "" && g.config.APIKey != "your_openai_key" {
+		// Skip the cycle when the kline cross-check failed, then check if API key is configured
+		// K 线交叉校验失败时跳过本轮，否则检查是否配置了 API Key
+		if issues := g.integrityIssues(); len(issues) > 0 && g.config.KlineCrossCheckAction == "skip" {
+			decision = g.makeIntegrityHoldDecision(issues)
+		} else if g.config.APIKey != "" && g.config.APIKey != "your_openai_key" {
+			live, candidate := g.resolveTraderProfiles()
+			g.state.SetTraderProfile(live)
+
 			// ! Use LLM for decision
 			decision, err = g.makeLLMDecision(ctx)
 			if err != nil {
 				g.logger.Warning(fmt.Sprintf("LLM 决策失败: %v", err))
+				g.recordLLMFailure()
 				decision = g.makeSimpleDecision()
+			} else if g.config.GuardrailEnabled {
+				decision = g.guardDecision(ctx, decision, func(feedback string) (string, error) {
+					return g.makeLLMDecisionWithFeedback(ctx, feedback)
+				})
+			} else {
+				g.logReversals(decision)
+			}
+
+			if candidate != nil {
+				g.runShadowCycle(ctx, candidate, decision)
 			}
 		} else {
 			g.logger.Info("OpenAI API Key 未配置，使用简单规则决策")
@@ -753,6 +1203,12 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 	if err := graph.AddLambdaNode("position_info", positionInfo); err != nil {
 		return nil, err
 	}
+	fundingSelected := g.config.AnalystSelected("funding")
+	if fundingSelected {
+		if err := graph.AddLambdaNode("funding_analyst", fundingAnalyst); err != nil {
+			return nil, err
+		}
+	}
 	if err := graph.AddLambdaNode("trader", trader); err != nil {
 		return nil, err
 	}
@@ -783,6 +1239,17 @@ func (g *SimpleTradingGraph) BuildGraph(ctx context.Context) (compose.Runnable[m
 		return nil, err
 	}
 
+	// The funding analyst runs alongside the others and only joins at the trader
+	// 资金费率分析师与其他分析师并行运行，只在交易员节点汇合
+	if fundingSelected {
+		if err := graph.AddEdge(compose.START, "funding_analyst"); err != nil {
+			return nil, err
+		}
+		if err := graph.AddEdge("funding_analyst", "trader"); err != nil {
+			return nil, err
+		}
+	}
+
 	// Trader outputs to END
 	if err := graph.AddEdge("trader", compose.END); err != nil {
 		return nil, err
@@ -858,6 +1325,21 @@ func (g *SimpleTradingGraph) makeSimpleDecision() string {
 // makeLLMDecision uses LLM to generate trading decision with JSON structured output
 // makeLLMDecision 使用 LLM 生成交易决策，使用 JSON 结构化输出
 func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error) {
+	return g.makeLLMDecisionWithFeedback(ctx, "")
+}
+
+// recordLLMFailure marks every symbol of the cycle as degraded by an LLM failure
+// recordLLMFailure 将本轮所有交易对标记为因 LLM 失败而降级
+func (g *SimpleTradingGraph) recordLLMFailure() {
+	for _, symbol := range g.state.Symbols {
+		g.state.SetFailure(symbol, storage.ErrorCategoryLLM)
+	}
+}
+
+// traderChatConfig returns the structured-output chat config of the trader for model, and whether the backend only
+// supports JSON Object mode
+// traderChatConfig 返回交易员使用 model 的结构化输出配置，以及后端是否只支持 JSON Object 模式
+func (g *SimpleTradingGraph) traderChatConfig(model string) (*openaiComponent.ChatModelConfig, bool) {
 	// List of backend URLs that only support JSON Object mode (not JSON Schema)
 	// 仅支持 JSON Object 模式（不支持 JSON Schema）的后端 URL 列表
 	jsonObjectModeBackends := []string{
@@ -888,7 +1370,7 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		cfg = &openaiComponent.ChatModelConfig{
 			APIKey:  g.config.APIKey,
 			BaseURL: g.config.BackendURL,
-			Model:   g.config.QuickThinkLLM,
+			Model:   model,
 			// Enable basic JSON mode (compatible with DeepSeek, Qwen, etc.)
 			// 启用基础 JSON 模式（兼容 DeepSeek、Qwen 等）
 			ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
@@ -908,7 +1390,7 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		cfg = &openaiComponent.ChatModelConfig{
 			APIKey:  g.config.APIKey,
 			BaseURL: g.config.BackendURL,
-			Model:   g.config.QuickThinkLLM,
+			Model:   model,
 			// Enable JSON Schema structured output
 			// 启用 JSON Schema 结构化输出
 			ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
@@ -923,60 +1405,34 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 		}
 	}
 
+	return cfg, useJSONObjectMode
+}
+
+// makeLLMDecisionWithFeedback generates the decision with extra feedback appended to the prompt, used when the guardrail asks for a regeneration
+// makeLLMDecisionWithFeedback 生成决策时在提示词后附加反馈，用于决策护栏要求重新生成时
+func (g *SimpleTradingGraph) makeLLMDecisionWithFeedback(ctx context.Context, feedback string) (string, error) {
+	// The live profile is the approved model and prompt, which differs from the configured one while a change runs in shadow
+	// 实盘配置是已确认的模型和提示词；变更处于影子运行期间时与当前配置不同
+	profile := g.state.TraderProfile()
+	if profile == nil {
+		configured := g.configuredTraderProfile()
+		profile = &configured
+	}
+	cfg, useJSONObjectMode := g.traderChatConfig(profile.Model)
+
 	// Create ChatModel
 	// 创建 ChatModel
-	chatModel, err := openaiComponent.NewChatModel(ctx, cfg)
+	chatModel, model, err := newChatModel(ctx, g.config, g.logger, cfg)
 	if err != nil {
 		g.logger.Warning(fmt.Sprintf("LLM 初始化失败，使用简单规则决策: %v", err))
+		g.recordLLMFailure()
 		return g.makeSimpleDecision(), nil
 	}
 
-	// Prepare the prompt with all reports
-	// 准备包含所有报告的 Prompt
-	allReports := g.state.GetAllReports()
-
-	// Load system prompt from file or use default
-	// 从文件加载系统 Prompt 或使用默认值
-	systemPrompt := loadPromptFromFile(g.config.TraderPromptPath, g.logger)
-
-	// Build user prompt with leverage range info and K-line interval
-	// 构建包含杠杆范围信息和 K 线间隔的用户 Prompt
-	leverageInfo := ""
-	if g.config.BinanceLeverageDynamic {
-		leverageInfo = fmt.Sprintf(`
-**动态杠杆范围**: %d-%d 倍
-`, g.config.BinanceLeverageMin, g.config.BinanceLeverageMax)
-	} else {
-		leverageInfo = fmt.Sprintf(`
-**固定杠杆**: %d 倍（本次交易将使用固定杠杆）
-`, g.config.BinanceLeverage)
-	}
-
-	// Add K-line interval info
-	// 添加 K 线间隔信息
-	klineInfo := fmt.Sprintf(`
-**K 线数据间隔**: %s（市场报告中的技术指标基于此时间周期计算）
-**系统运行间隔**: %s（系统每隔此时间运行一次分析）
-`, g.config.CryptoTimeframe, g.config.TradingInterval)
-
-	// Calculate trading session context
-	// 计算交易会话上下文信息
-	minutesSinceStart := int(time.Since(g.startTime).Minutes())
-	currentTime := time.Now().Format("2006-01-02 15:04:05")
-	tradeCount := g.GetTradeCount()
-
-	// Build session context info
-	// 构建会话上下文信息
-	sessionContext := fmt.Sprintf(`
-- 这是你开始交易的第 %d 分钟,目前的时间是：%s,你已经参与了交易 %d 次，
-`, minutesSinceStart, currentTime, tradeCount)
-
-	userPrompt := fmt.Sprintf(`%s下方我们将为您提供各种市场技术分析、加密货币状态分析，助您发掘超额收益。再下方是您当前的当前持仓信息，包括价值、业绩和持仓情况。请分析以下各种数据并给出交易决策：
-%s
-%s
-%s
-
-请给出你的分析和最终决策。`, sessionContext, leverageInfo, klineInfo, allReports)
+	// System prompt from the profile, user prompt with all reports
+	// 系统 Prompt 来自实盘配置，用户 Prompt 包含所有报告
+	systemPrompt := profile.Prompt + decisionLanguageInstruction(g.config.DecisionLanguage)
+	userPrompt := g.traderUserPrompt(feedback)
 
 	// Create messages
 	// 创建消息
@@ -991,10 +1447,12 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 	if useJSONObjectMode {
 		modeStr = "JSON Object"
 	}
-	g.logger.Info(fmt.Sprintf("🤖 正在调用 LLM 生成交易决策 (%s 模式), 使用的模型:%v", modeStr, g.config.QuickThinkLLM))
+	g.logger.Info(fmt.Sprintf("🤖 正在调用 LLM 生成交易决策 (%s 模式), 使用的模型:%v", modeStr, model))
 	response, err := chatModel.Generate(ctx, messages)
+	g.captureTraderCall(systemPrompt, userPrompt, response, err)
 	if err != nil {
 		g.logger.Warning(fmt.Sprintf("LLM 调用失败，使用简单规则决策: %v", err))
+		g.recordLLMFailure()
 		return g.makeSimpleDecision(), nil
 	}
 
@@ -1044,6 +1502,7 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 	if !parsed {
 		g.logger.Warning(fmt.Sprintf("JSON 解析失败，原始响应: %s", response.Content))
 		g.logger.Warning("降级到简单规则决策")
+		g.recordLLMFailure()
 		return g.makeSimpleDecision(), nil
 	}
 
@@ -1051,6 +1510,7 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 	// 对示例决策验证必填字段
 	if strings.TrimSpace(sample.Action) == "" || strings.TrimSpace(sample.Symbol) == "" {
 		g.logger.Warning(fmt.Sprintf("LLM 返回的 JSON 缺少必填字段 (action或symbol为空)，示例: %+v", sample))
+		g.recordLLMFailure()
 		return g.makeSimpleDecision(), nil
 	}
 
@@ -1065,6 +1525,77 @@ func (g *SimpleTradingGraph) makeLLMDecision(ctx context.Context) (string, error
 	return response.Content, nil
 }
 
+// traderUserPrompt builds the trader's user prompt from this cycle's reports, with feedback appended
+// traderUserPrompt 用本轮报告构建交易员的用户 Prompt，并在末尾附加反馈
+func (g *SimpleTradingGraph) traderUserPrompt(feedback string) string {
+	// Prepare the prompt with all reports
+	// 准备包含所有报告的 Prompt
+	allReports := g.state.GetAllReports()
+
+	// Build user prompt with leverage range info and K-line interval
+	// 构建包含杠杆范围信息和 K 线间隔的用户 Prompt
+	leverageInfo := ""
+	if g.config.BinanceLeverageDynamic {
+		leverageInfo = fmt.Sprintf(`
+**动态杠杆范围**: %d-%d 倍
+`, g.config.BinanceLeverageMin, g.config.BinanceLeverageMax)
+	} else {
+		leverageInfo = fmt.Sprintf(`
+**固定杠杆**: %d 倍（本次交易将使用固定杠杆）
+`, g.config.BinanceLeverage)
+	}
+	for _, symbol := range g.state.Symbols {
+		regime := g.state.Regime(symbol)
+		if ceiling := g.config.LeverageCapFor(string(regime)); ceiling > 0 {
+			leverageInfo += fmt.Sprintf("**%s 杠杆上限**: %d 倍（当前市场状态为%s，超出部分将被下调）\n", symbol, ceiling, regime)
+		}
+	}
+
+	// Add K-line interval info
+	// 添加 K 线间隔信息
+	klineInfo := fmt.Sprintf(`
+**K 线数据间隔**: %s（市场报告中的技术指标基于此时间周期计算）
+**系统运行间隔**: %s（系统每隔此时间运行一次分析）
+`, g.config.CryptoTimeframe, g.config.TradingInterval)
+
+	// Calculate trading session context
+	// 计算交易会话上下文信息
+	minutesSinceStart := int(time.Since(g.startTime).Minutes())
+	currentTime := time.Now().Format("2006-01-02 15:04:05")
+	tradeCount := g.GetTradeCount()
+
+	// Build session context info
+	// 构建会话上下文信息
+	sessionContext := fmt.Sprintf(`
+- 这是你开始交易的第 %d 分钟,目前的时间是：%s,你已经参与了交易 %d 次，
+`, minutesSinceStart, currentTime, tradeCount)
+
+	return fmt.Sprintf(`%s下方我们将为您提供各种市场技术分析、加密货币状态分析，助您发掘超额收益。再下方是您当前的当前持仓信息，包括价值、业绩和持仓情况。请分析以下各种数据并给出交易决策：
+%s
+%s
+%s
+
+请给出你的分析和最终决策。%s`, sessionContext, leverageInfo, klineInfo, allReports, feedback)
+}
+
+// captureTraderCall archives the trader prompts and raw response for every symbol armed for debug capture
+// captureTraderCall 为每个已开启调试采集的交易对归档交易员提示词和原始响应
+func (g *SimpleTradingGraph) captureTraderCall(systemPrompt, userPrompt string, response *schema.Message, err error) {
+	recorder := g.state.capture
+	raw := fmt.Sprintf("error: %v", err)
+	if err == nil {
+		raw = response.Content
+	}
+	for _, symbol := range g.state.Symbols {
+		if !recorder.Active(symbol) {
+			continue
+		}
+		recorder.RecordText(symbol, "trader_system_prompt", systemPrompt)
+		recorder.RecordText(symbol, "trader_user_prompt", userPrompt)
+		recorder.RecordText(symbol, "trader_response", raw)
+	}
+}
+
 // Run executes the trading graph
 func (g *SimpleTradingGraph) Run(ctx context.Context) (map[string]any, error) {
 	g.logger.Header("启动交易分析工作流", '=', 80)
@@ -73,3 +73,38 @@ func TestMakeLLMDecision_FallbackToSimpleDecision(t *testing.T) {
 		t.Fatalf("expected fallback decision from makeSimpleDecision,\nwant:\n%s\n\ngot:\n%s", expected, decision)
 	}
 }
+
+func TestDecisionLanguageInstruction(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		wantNone bool
+		contains string
+	}{
+		{name: "Empty keeps prompt language", lang: "", wantNone: true},
+		{name: "Chinese keeps prompt language", lang: "zh", wantNone: true},
+		{name: "Chinese case-insensitive", lang: "ZH-CN", wantNone: true},
+		{name: "English", lang: "en", contains: "in English"},
+		{name: "Known code with spaces", lang: " ja ", contains: "Japanese"},
+		{name: "Unknown value passed through", lang: "Italiano", contains: "in Italiano"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := decisionLanguageInstruction(tt.lang)
+			if tt.wantNone {
+				if got != "" {
+					t.Errorf("Expected no instruction, got %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.contains) {
+				t.Errorf("Expected instruction to contain %q, got %q", tt.contains, got)
+			}
+			// 结构化字段必须保持英文
+			if !strings.Contains(got, "CLOSE_LONG") {
+				t.Error("Expected instruction to keep action values in English")
+			}
+		})
+	}
+}
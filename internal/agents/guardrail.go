@@ -0,0 +1,296 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+)
+
+// GuardrailLimits are the bounds a trade decision must stay within
+// GuardrailLimits 交易决策必须满足的边界
+type GuardrailLimits struct {
+	MaxRiskPercent float64 // 止损触发时单笔最大亏损占余额的比例（%），0 表示不检查 / Max loss at the stop as % of balance, 0 disables
+	MinLeverage    int     // 最小杠杆 / Minimum leverage
+	MaxLeverage    int     // 最大杠杆 / Maximum leverage
+}
+
+// guardrailReviewPrompt asks the review model to look only for contradictions, not to second-guess the trade
+// guardrailReviewPrompt 要求复核模型只查找自相矛盾之处，而不是质疑交易本身
+const guardrailReviewPrompt = `你是交易决策的一致性审核员。你只检查决策是否自相矛盾，不评价交易好坏。
+检查项：
+1. reasoning/summary 描述的方向是否与 action 一致（例如理由看空却给出 BUY）
+2. 理由中提到的止损、目标价、杠杆、仓位是否与结构化字段一致
+3. 字段是否明显不合理（例如 HOLD 却给出开仓仓位）
+只输出 JSON：{"consistent": true/false, "issues": ["问题描述", ...]}，issues 使用中文。`
+
+// guardrailReview is the review model's verdict
+// guardrailReview 复核模型的结论
+type guardrailReview struct {
+	Consistent bool     `json:"consistent"` // 是否一致 / Whether the decision is consistent
+	Issues     []string `json:"issues"`     // 发现的问题 / Issues found
+}
+
+// CheckDecisionConsistency returns the internal inconsistencies of one decision at the current price
+// CheckDecisionConsistency 返回单个决策在当前价格下的内部不一致之处
+//
+// Only BUY and SELL open risk, so the checks apply to them: the stop must sit on the losing side of the price,
+// the stated risk/reward must be positive (a target on the wrong side of entry makes it negative), leverage must be
+// within the configured range, and the loss at the stop must not exceed the risk cap.
+// 只有 BUY 和 SELL 会开启风险，因此只检查这两种动作：止损必须位于价格的亏损一侧，
+// 预期盈亏比必须为正（目标价在入场价错误一侧时为负），杠杆必须在配置范围内，止损亏损不得超过风险上限。
+func CheckDecisionConsistency(d TradeDecision, price float64, limits GuardrailLimits) []string {
+	var issues []string
+	if d.Confidence < 0 || d.Confidence > 1 {
+		issues = append(issues, fmt.Sprintf("置信度 %.2f 超出 0-1 范围", d.Confidence))
+	}
+
+	action := strings.ToUpper(strings.TrimSpace(d.Action))
+	if action != "BUY" && action != "SELL" {
+		return issues
+	}
+
+	if d.PositionSize <= 0 || d.PositionSize > 100 {
+		issues = append(issues, fmt.Sprintf("开仓仓位 %.1f%% 不在 0-100%% 范围内", d.PositionSize))
+	}
+	if d.StopLoss <= 0 {
+		issues = append(issues, "开仓决策缺少止损价")
+	} else if price > 0 {
+		if action == "BUY" && d.StopLoss >= price {
+			issues = append(issues, fmt.Sprintf("做多止损 %.4f 不低于当前价 %.4f，止损在错误一侧", d.StopLoss, price))
+		}
+		if action == "SELL" && d.StopLoss <= price {
+			issues = append(issues, fmt.Sprintf("做空止损 %.4f 不高于当前价 %.4f，止损在错误一侧", d.StopLoss, price))
+		}
+	}
+	if d.RiskRewardRatio <= 0 {
+		issues = append(issues, fmt.Sprintf("预期盈亏比 %.2f 不为正，目标价不在入场价的盈利一侧", d.RiskRewardRatio))
+	}
+
+	leverage := d.Leverage
+	if leverage > 0 && limits.MaxLeverage > 0 && (leverage < limits.MinLeverage || leverage > limits.MaxLeverage) {
+		issues = append(issues, fmt.Sprintf("杠杆 %dx 超出允许范围 %d-%dx", leverage, limits.MinLeverage, limits.MaxLeverage))
+	}
+	if leverage <= 0 {
+		leverage = limits.MinLeverage
+	}
+
+	// Loss at the stop as a share of balance: margin share × leverage × stop distance
+	// 止损亏损占余额比例：保证金占比 × 杠杆 × 止损距离
+	if limits.MaxRiskPercent > 0 && price > 0 && d.StopLoss > 0 && d.PositionSize > 0 {
+		stopDistance := math.Abs(price-d.StopLoss) / price
+		riskPercent := d.PositionSize * float64(leverage) * stopDistance
+		if riskPercent > limits.MaxRiskPercent {
+			issues = append(issues, fmt.Sprintf("止损触发将亏损余额的 %.2f%%（仓位 %.1f%% × %dx × 止损距离 %.2f%%），超过上限 %.2f%%",
+				riskPercent, d.PositionSize, leverage, stopDistance*100, limits.MaxRiskPercent))
+		}
+	}
+	return issues
+}
+
+// parseDecisionMap parses trader output into decisions keyed by symbol, accepting the single-object form too
+// parseDecisionMap 将交易员输出解析为按交易对索引的决策，也接受单对象格式
+func parseDecisionMap(content string) (map[string]TradeDecision, bool) {
+	trimmed := strings.TrimSpace(extractJSONPayload(content))
+
+	var multi map[string]TradeDecision
+	if err := json.Unmarshal([]byte(trimmed), &multi); err == nil && len(multi) > 0 {
+		for sym, d := range multi {
+			if d.Symbol == "" {
+				d.Symbol = sym
+				multi[sym] = d
+			}
+		}
+		return multi, true
+	}
+
+	var single TradeDecision
+	if err := json.Unmarshal([]byte(trimmed), &single); err == nil && single.Symbol != "" {
+		return map[string]TradeDecision{single.Symbol: single}, true
+	}
+	return nil, false
+}
+
+// rejectDecisions turns the rejected symbols into HOLD and returns the decisions as JSON
+// rejectDecisions 将被拒绝的交易对改为 HOLD，并以 JSON 返回全部决策
+func rejectDecisions(decisions map[string]TradeDecision, rejected map[string][]string) (string, error) {
+	for sym, issues := range rejected {
+		d := decisions[sym]
+		d.Action = "HOLD"
+		d.PositionSize = 0
+		d.NewStopLoss = nil
+		d.StopLossReason = nil
+		d.Reasoning = fmt.Sprintf("决策护栏拒绝了原决策（%s）: %s", decisions[sym].Action, strings.Join(issues, "；"))
+		decisions[sym] = d
+	}
+	out, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode guarded decision: %w", err)
+	}
+	return string(out), nil
+}
+
+// formatGuardrailFeedback tells the trader what was inconsistent so it can correct the decision
+// formatGuardrailFeedback 告诉交易员哪些地方不一致，以便修正决策
+func formatGuardrailFeedback(issues map[string][]string) string {
+	symbols := make([]string, 0, len(issues))
+	for sym := range issues {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+
+	var sb strings.Builder
+	sb.WriteString("\n\n**⚠️ 你上一次给出的决策未通过一致性校验，请修正以下问题后重新给出全部交易对的完整决策**:\n")
+	for _, sym := range symbols {
+		for _, issue := range issues[sym] {
+			sb.WriteString(fmt.Sprintf("- %s: %s\n", sym, issue))
+		}
+	}
+	return sb.String()
+}
+
+// guardDecision validates the trader's decision and has it regenerated, or rejects it, before the executor sees it
+// guardDecision 在执行器看到决策之前校验交易员的决策，不一致时要求重新生成或直接拒绝
+//
+// Rule checks run first; the review model, when configured, only sees decisions that pass them. Symbols that are
+// still inconsistent once the retries are used up are turned into HOLD.
+// 先进行规则校验；配置了复核模型时，只有通过规则校验的决策才交给它复核。重试用尽后仍不一致的交易对改为 HOLD。
+func (g *SimpleTradingGraph) guardDecision(ctx context.Context, decision string, regenerate func(feedback string) (string, error)) string {
+	for attempt := 0; ; attempt++ {
+		decisions, ok := parseDecisionMap(decision)
+		if !ok {
+			// Text and rule-based fallbacks carry no structured orders to check
+			// 文本和规则降级决策没有可校验的结构化订单
+			return decision
+		}
+
+		issues := g.checkDecisions(ctx, decisions)
+		if len(issues) == 0 {
+			if attempt > 0 {
+				g.logger.Success(fmt.Sprintf("🛡️ 决策护栏: 第 %d 次重新生成的决策通过校验", attempt))
+			}
+			return decision
+		}
+		for sym, list := range issues {
+			g.logger.Warning(fmt.Sprintf("🛡️ 决策护栏:【%s】决策不一致: %s", sym, strings.Join(list, "；")))
+		}
+
+		if attempt >= g.config.GuardrailMaxRetries {
+			guarded, err := rejectDecisions(decisions, issues)
+			if err != nil {
+				g.logger.Error(fmt.Sprintf("❌ 决策护栏: %v，全部改为规则决策", err))
+				return g.makeSimpleDecision()
+			}
+			g.logger.Warning(fmt.Sprintf("🛡️ 决策护栏: 重试 %d 次后仍不一致，%d 个交易对改为观望", attempt, len(issues)))
+			return guarded
+		}
+
+		g.logger.Info(fmt.Sprintf("🛡️ 决策护栏: 要求交易员重新生成决策（第 %d/%d 次）", attempt+1, g.config.GuardrailMaxRetries))
+		regenerated, err := regenerate(formatGuardrailFeedback(issues))
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️ 决策护栏: 重新生成失败: %v", err))
+			guarded, rejectErr := rejectDecisions(decisions, issues)
+			if rejectErr != nil {
+				return g.makeSimpleDecision()
+			}
+			return guarded
+		}
+		decision = regenerated
+	}
+}
+
+// checkDecisions runs the rule checks on every decision and, if they all pass, the review model
+// checkDecisions 对每个决策进行规则校验，全部通过后再交给复核模型
+func (g *SimpleTradingGraph) checkDecisions(ctx context.Context, decisions map[string]TradeDecision) map[string][]string {
+	limits := GuardrailLimits{
+		MaxRiskPercent: g.config.GuardrailMaxRiskPercent,
+		MinLeverage:    g.config.BinanceLeverageMin,
+		MaxLeverage:    g.config.BinanceLeverageMax,
+	}
+	if !g.config.BinanceLeverageDynamic {
+		limits.MinLeverage, limits.MaxLeverage = g.config.BinanceLeverage, g.config.BinanceLeverage
+	}
+
+	issues := make(map[string][]string)
+	for sym, d := range decisions {
+		if list := CheckDecisionConsistency(d, g.latestPrice(sym), limits); len(list) > 0 {
+			issues[sym] = list
+		}
+	}
+	for sym, issue := range g.reversalIssues(decisions) {
+		issues[sym] = append(issues[sym], issue)
+	}
+	if len(issues) > 0 || g.config.GuardrailLLM == "" {
+		return issues
+	}
+
+	review, err := g.reviewDecisions(ctx, decisions)
+	if err != nil {
+		// The rule checks passed, so a review outage does not block trading
+		// 规则校验已通过，复核模型不可用时不阻塞交易
+		g.logger.Warning(fmt.Sprintf("⚠️ 决策护栏: 复核模型调用失败，仅使用规则校验结果: %v", err))
+		return issues
+	}
+	for sym, list := range review {
+		issues[sym] = list
+	}
+	return issues
+}
+
+// reviewDecisions asks the cheap review model whether each decision contradicts itself
+// reviewDecisions 让廉价复核模型判断每个决策是否自相矛盾
+func (g *SimpleTradingGraph) reviewDecisions(ctx context.Context, decisions map[string]TradeDecision) (map[string][]string, error) {
+	chatModel, _, err := newChatModel(ctx, g.config, g.logger, &openaiComponent.ChatModelConfig{
+		APIKey:  g.config.APIKey,
+		BaseURL: g.config.BackendURL,
+		Model:   g.config.GuardrailLLM,
+		ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
+			Type: openaiComponent.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review model: %w", err)
+	}
+
+	issues := make(map[string][]string)
+	for sym, d := range decisions {
+		payload, err := json.Marshal(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode decision: %w", err)
+		}
+		response, err := chatModel.Generate(ctx, []*schema.Message{
+			schema.SystemMessage(guardrailReviewPrompt),
+			schema.UserMessage(fmt.Sprintf("交易对: %s\n当前价格: %.4f\n决策: %s", sym, g.latestPrice(sym), payload)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to review %s: %w", sym, err)
+		}
+
+		var review guardrailReview
+		if err := json.Unmarshal([]byte(extractJSONPayload(response.Content)), &review); err != nil {
+			return nil, fmt.Errorf("failed to parse review of %s: %w", sym, err)
+		}
+		if !review.Consistent {
+			if len(review.Issues) == 0 {
+				review.Issues = []string{"复核模型判定决策自相矛盾"}
+			}
+			issues[sym] = review.Issues
+		}
+	}
+	return issues, nil
+}
+
+// latestPrice returns the last close seen by the market analyst, or 0 when unknown
+// latestPrice 返回市场分析师获取的最新收盘价，未知时返回 0
+func (g *SimpleTradingGraph) latestPrice(symbol string) float64 {
+	reports := g.state.GetSymbolReports(symbol)
+	if reports == nil || len(reports.OHLCVData) == 0 {
+		return 0
+	}
+	return reports.OHLCVData[len(reports.OHLCVData)-1].Close
+}
@@ -0,0 +1,124 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCheckDecisionConsistency tests the rule checks of the decision guardrail
+// TestCheckDecisionConsistency 测试决策护栏的规则校验
+func TestCheckDecisionConsistency(t *testing.T) {
+	limits := GuardrailLimits{MaxRiskPercent: 3, MinLeverage: 5, MaxLeverage: 20}
+
+	tests := []struct {
+		name     string
+		decision TradeDecision
+		price    float64
+		want     []string // 期望出现在问题列表中的片段，空表示一致 / Fragments expected in the issues, empty means consistent
+	}{
+		{
+			name:     "Consistent long",
+			decision: TradeDecision{Action: "BUY", Confidence: 0.8, Leverage: 10, PositionSize: 10, StopLoss: 98, RiskRewardRatio: 2},
+			price:    100,
+		},
+		{
+			name:     "Long stop above price",
+			decision: TradeDecision{Action: "BUY", Confidence: 0.8, Leverage: 10, PositionSize: 10, StopLoss: 101, RiskRewardRatio: 2},
+			price:    100,
+			want:     []string{"错误一侧"},
+		},
+		{
+			name:     "Short stop below price",
+			decision: TradeDecision{Action: "SELL", Confidence: 0.8, Leverage: 10, PositionSize: 10, StopLoss: 99, RiskRewardRatio: 2},
+			price:    100,
+			want:     []string{"错误一侧"},
+		},
+		{
+			name:     "Target on the wrong side",
+			decision: TradeDecision{Action: "BUY", Confidence: 0.8, Leverage: 10, PositionSize: 10, StopLoss: 98, RiskRewardRatio: -1},
+			price:    100,
+			want:     []string{"盈亏比"},
+		},
+		{
+			name:     "Size beyond stated risk",
+			decision: TradeDecision{Action: "BUY", Confidence: 0.8, Leverage: 20, PositionSize: 30, StopLoss: 98, RiskRewardRatio: 2},
+			price:    100,
+			want:     []string{"超过上限"},
+		},
+		{
+			name:     "Leverage out of range and missing stop",
+			decision: TradeDecision{Action: "SELL", Confidence: 0.8, Leverage: 50, PositionSize: 10, RiskRewardRatio: 2},
+			price:    100,
+			want:     []string{"杠杆 50x", "缺少止损"},
+		},
+		{
+			name:     "HOLD is not checked for stops",
+			decision: TradeDecision{Action: "HOLD", Confidence: 0.5},
+			price:    100,
+		},
+		{
+			name:     "Confidence out of range",
+			decision: TradeDecision{Action: "HOLD", Confidence: 75},
+			price:    100,
+			want:     []string{"置信度"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := CheckDecisionConsistency(tt.decision, tt.price, limits)
+			if len(tt.want) == 0 {
+				if len(issues) != 0 {
+					t.Errorf("Expected no issues, got %v", issues)
+				}
+				return
+			}
+			joined := strings.Join(issues, "\n")
+			for _, want := range tt.want {
+				if !strings.Contains(joined, want) {
+					t.Errorf("Expected an issue containing %q, got %v", want, issues)
+				}
+			}
+		})
+	}
+}
+
+// TestRejectDecisions tests that rejected symbols become HOLD and the rest are kept
+// TestRejectDecisions 测试被拒绝的交易对改为 HOLD，其余保持不变
+func TestRejectDecisions(t *testing.T) {
+	content := "```json\n" + `{
+  "BTC/USDT": {"symbol": "BTC/USDT", "action": "BUY", "confidence": 0.8, "leverage": 10, "position_size": 10, "stop_loss": 101, "risk_reward_ratio": 2},
+  "ETH/USDT": {"symbol": "ETH/USDT", "action": "SELL", "confidence": 0.7, "leverage": 10, "position_size": 10, "stop_loss": 3100, "risk_reward_ratio": 2}
+}` + "\n```"
+
+	decisions, ok := parseDecisionMap(content)
+	if !ok || len(decisions) != 2 {
+		t.Fatalf("Expected two decisions, got %v (ok=%v)", decisions, ok)
+	}
+
+	guarded, err := rejectDecisions(decisions, map[string][]string{"BTC/USDT": {"止损在错误一侧"}})
+	if err != nil {
+		t.Fatalf("Reject failed: %v", err)
+	}
+
+	parsed := ParseMultiCurrencyDecision(guarded, []string{"BTC/USDT", "ETH/USDT"})
+	if btc := parsed["BTC/USDT"]; btc == nil || btc.Action != "HOLD" || !strings.Contains(btc.Reason, "护栏") {
+		t.Errorf("Expected BTC/USDT to be turned into HOLD, got %+v", btc)
+	}
+	if eth := parsed["ETH/USDT"]; eth == nil || eth.Action != "SELL" {
+		t.Errorf("Expected ETH/USDT to be kept, got %+v", eth)
+	}
+}
+
+// TestParseDecisionMapSingle tests that the single-object format is accepted
+// TestParseDecisionMapSingle 测试接受单对象格式
+func TestParseDecisionMapSingle(t *testing.T) {
+	decisions, ok := parseDecisionMap(`{"symbol": "SOL/USDT", "action": "HOLD", "confidence": 0.5}`)
+	if !ok || decisions["SOL/USDT"].Action != "HOLD" {
+		t.Errorf("Expected a single SOL/USDT decision, got %v (ok=%v)", decisions, ok)
+	}
+
+	if _, ok := parseDecisionMap("市场震荡，建议观望"); ok {
+		t.Error("Expected plain text to be rejected")
+	}
+}
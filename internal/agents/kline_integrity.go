@@ -0,0 +1,84 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// crossCheckKlines checks the latest candles against the reference source and records the result in the state
+// crossCheckKlines 用参考数据源校验最近的 K 线，并将结果记录到状态中
+//
+// It returns the discrepancy summary, or an empty string when the check is disabled, passes or cannot run.
+// 返回偏差摘要；校验未启用、通过或无法执行时返回空字符串。
+func (g *SimpleTradingGraph) crossCheckKlines(ctx context.Context, marketData *dataflows.MarketData, sym, binanceSymbol, timeframe string, ohlcvData []dataflows.OHLCV) string {
+	// Clear the previous cycle's result, the state outlives a single run in the web server
+	// 清除上一轮的结果，Web 服务中状态会跨多轮保留
+	g.state.SetIntegrityIssue(sym, "")
+	if !g.config.KlineCrossCheckEnabled {
+		return ""
+	}
+
+	discrepancies, missing, err := marketData.CrossCheckKlines(ctx, binanceSymbol, timeframe, ohlcvData)
+	if err != nil {
+		// A reference outage is not evidence of bad data
+		// 参考数据源不可用不代表数据有误
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s K线交叉校验失败，跳过校验: %v", sym, err))
+		return ""
+	}
+	if missing > 0 {
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s 参考数据源缺少 %d 根 K 线，无法对比", sym, missing))
+	}
+	if len(discrepancies) == 0 {
+		g.logger.Success(fmt.Sprintf("  ✅ %s K线交叉校验通过（%s，容差 %.2f%%）", sym, g.config.KlineCrossCheckSource, g.config.KlineCrossCheckTolerance))
+		return ""
+	}
+
+	issue := dataflows.FormatKlineDiscrepancies(g.config.KlineCrossCheckSource, discrepancies)
+	g.logger.Error(fmt.Sprintf("🚨【%s】K线数据与%s价格偏差超过 %.2f%%: %s", sym, g.config.KlineCrossCheckSource, g.config.KlineCrossCheckTolerance, issue))
+	g.state.SetIntegrityIssue(sym, issue)
+	g.stopLossManager.Notifier().Notify(notify.SeverityCritical, sym, fmt.Sprintf("🚨 K线数据与%s价格偏差超过 %.2f%%: %s", g.config.KlineCrossCheckSource, g.config.KlineCrossCheckTolerance, issue))
+	return issue
+}
+
+// integrityIssues returns the symbols whose klines failed the cross-check this cycle
+// integrityIssues 返回本轮 K 线交叉校验未通过的交易对
+func (g *SimpleTradingGraph) integrityIssues() map[string]string {
+	issues := make(map[string]string)
+	for _, sym := range g.state.Symbols {
+		if reports := g.state.GetSymbolReports(sym); reports != nil && reports.IntegrityIssue != "" {
+			issues[sym] = reports.IntegrityIssue
+		}
+	}
+	return issues
+}
+
+// makeIntegrityHoldDecision skips the cycle by holding every symbol, since bad prices can mislead decisions on all of them
+// makeIntegrityHoldDecision 通过让所有交易对观望来跳过本轮，因为错误价格可能误导所有交易对的决策
+func (g *SimpleTradingGraph) makeIntegrityHoldDecision(issues map[string]string) string {
+	g.logger.Warning(fmt.Sprintf("🚨 %d 个交易对的 K 线数据未通过交叉校验，跳过本轮决策，全部观望", len(issues)))
+
+	decisions := make(map[string]TradeDecision, len(g.state.Symbols))
+	for _, sym := range g.state.Symbols {
+		reasoning := "其他交易对的 K 线数据未通过交叉校验，本轮跳过"
+		if issue, ok := issues[sym]; ok {
+			reasoning = "K 线数据未通过交叉校验，本轮跳过: " + issue
+		}
+		decisions[sym] = TradeDecision{
+			Symbol:    sym,
+			Action:    "HOLD",
+			Reasoning: reasoning,
+			Summary:   "数据校验失败，跳过本轮",
+		}
+	}
+
+	out, err := json.MarshalIndent(decisions, "", "  ")
+	if err != nil {
+		g.logger.Error(fmt.Sprintf("❌ 编码跳过决策失败: %v", err))
+		return g.makeSimpleDecision()
+	}
+	return string(out)
+}
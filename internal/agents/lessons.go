@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// maxPromptLessons bounds how many user lessons are put into the trader prompt
+// maxPromptLessons 限制放入交易员提示词的用户规则数量
+const maxPromptLessons = 50
+
+// formatLessons renders the enabled lessons that apply to this run: global ones plus those of the run's symbols
+// formatLessons 渲染适用于本次运行的已启用规则：全局规则以及本次交易对的规则
+func formatLessons(lessons []*storage.TradingLesson, binanceSymbols []string) string {
+	inRun := make(map[string]bool, len(binanceSymbols))
+	for _, symbol := range binanceSymbols {
+		inRun[strings.ToUpper(symbol)] = true
+	}
+
+	var sb strings.Builder
+	count := 0
+	for _, l := range lessons {
+		if !l.Enabled || (l.Symbol != "" && !inRun[strings.ToUpper(l.Symbol)]) {
+			continue
+		}
+		if count >= maxPromptLessons {
+			break
+		}
+		count++
+		scope := "全部交易对"
+		if l.Symbol != "" {
+			scope = strings.ToUpper(l.Symbol)
+		}
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", count, scope, strings.TrimSpace(l.Lesson)))
+	}
+	return sb.String()
+}
+
+// lessonsReport loads the user lessons for this run; empty when the store is not set or nothing applies
+// lessonsReport 读取本次运行的用户规则；未设置存储或没有适用规则时返回空
+func (g *SimpleTradingGraph) lessonsReport() string {
+	if g.lessons == nil {
+		return ""
+	}
+	lessons, err := g.lessons.GetLessons(true)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  读取交易规则失败: %v", err))
+		return ""
+	}
+
+	symbols := make([]string, 0, len(g.state.Symbols))
+	for _, symbol := range g.state.Symbols {
+		symbols = append(symbols, g.config.GetBinanceSymbolFor(symbol))
+	}
+	return formatLessons(lessons, symbols)
+}
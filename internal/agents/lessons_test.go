@@ -0,0 +1,32 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestFormatLessons(t *testing.T) {
+	lessons := []*storage.TradingLesson{
+		{ID: 1, Lesson: "Never add to a losing position", Enabled: true},
+		{ID: 2, Symbol: "SOLUSDT", Lesson: "Skip entries during the US open", Enabled: true},
+		{ID: 3, Symbol: "ETHUSDT", Lesson: "ETH only", Enabled: true},
+		{ID: 4, Lesson: "Disabled rule", Enabled: false},
+	}
+
+	report := formatLessons(lessons, []string{"BTCUSDT", "SOLUSDT"})
+	if !strings.Contains(report, "1. [全部交易对] Never add to a losing position") {
+		t.Errorf("Expected the global lesson first, got:\n%s", report)
+	}
+	if !strings.Contains(report, "2. [SOLUSDT] Skip entries during the US open") {
+		t.Errorf("Expected the SOLUSDT lesson, got:\n%s", report)
+	}
+	if strings.Contains(report, "ETH only") || strings.Contains(report, "Disabled rule") {
+		t.Errorf("Expected other symbols and disabled lessons to be left out, got:\n%s", report)
+	}
+
+	if report := formatLessons(nil, []string{"BTCUSDT"}); report != "" {
+		t.Errorf("Expected an empty report without lessons, got %q", report)
+	}
+}
@@ -0,0 +1,284 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// Rate limit headers of OpenAI-compatible backends
+// OpenAI 兼容后端的限流响应头
+const (
+	headerLimitRequests     = "X-Ratelimit-Limit-Requests"
+	headerRemainingRequests = "X-Ratelimit-Remaining-Requests"
+	headerResetRequests     = "X-Ratelimit-Reset-Requests"
+	headerLimitTokens       = "X-Ratelimit-Limit-Tokens"
+	headerRemainingTokens   = "X-Ratelimit-Remaining-Tokens"
+	headerResetTokens       = "X-Ratelimit-Reset-Tokens"
+)
+
+// defaultQuotaBackoff is how long a 429 without Retry-After keeps a model marked as exhausted
+// defaultQuotaBackoff 是未带 Retry-After 的 429 使模型保持耗尽状态的时长
+const defaultQuotaBackoff = time.Minute
+
+// QuotaStats is the last known quota of one model, as reported by the backend
+// QuotaStats 是后端报告的某个模型最近一次已知配额
+type QuotaStats struct {
+	Model             string    `json:"model"`                        // 模型 / Model
+	LimitRequests     int64     `json:"limit_requests"`               // 请求数上限，0 表示未知 / Request limit, 0 when unknown
+	RemainingRequests int64     `json:"remaining_requests"`           // 剩余请求数 / Remaining requests
+	RequestsResetAt   time.Time `json:"requests_reset_at,omitempty"`  // 请求配额重置时间 / When the request quota resets
+	LimitTokens       int64     `json:"limit_tokens"`                 // token 上限，0 表示未知 / Token limit, 0 when unknown
+	RemainingTokens   int64     `json:"remaining_tokens"`             // 剩余 token / Remaining tokens
+	TokensResetAt     time.Time `json:"tokens_reset_at,omitempty"`    // token 配额重置时间 / When the token quota resets
+	RateLimitedUntil  time.Time `json:"rate_limited_until,omitempty"` // 收到 429 后的限制截止时间 / Blocked until after a 429
+	Calls             int64     `json:"calls"`                        // 调用次数 / Calls
+	RateLimited       int64     `json:"rate_limited"`                 // 收到 429 的次数 / 429 responses
+	Throttled         int64     `json:"throttled"`                    // 等待配额重置的次数 / Waits for a quota reset
+	SwitchedAway      int64     `json:"switched_away"`                // 因配额不足改用备用模型的次数 / Calls sent to the fallback model instead
+	UpdatedAt         time.Time `json:"updated_at"`                   // 最近一次响应时间 / Time of the latest response
+}
+
+// LLMQuota tracks the rate limit quota of every model from the backend's response headers and decides, before each
+// call, whether to wait for a reset or switch to the fallback model
+// LLMQuota 根据后端响应头跟踪每个模型的限流配额，并在每次调用前决定是否等待重置或切换备用模型
+//
+// One tracker is shared by every LLM client in the process (trader, guardrail, stop review), because the backend
+// counts quota per API key rather than per client.
+// 进程内所有 LLM 客户端（交易员、决策护栏、止损复查）共享一个跟踪器，因为后端按 API Key 而非按客户端统计配额。
+type LLMQuota struct {
+	minRemainingPercent float64       // 低于该比例时干预（%），0 表示只统计 / Share of the limit below which calls are held back (%), 0 only tracks
+	maxWait             time.Duration // 等待重置的最长时间 / Longest wait for a reset
+	models              map[string]*QuotaStats
+	now                 func() time.Time
+	mu                  sync.Mutex
+}
+
+// NewLLMQuota creates a tracker that holds calls back below minRemainingPercent of a model's limit
+// NewLLMQuota 创建在模型剩余配额低于 minRemainingPercent 时进行干预的跟踪器
+func NewLLMQuota(minRemainingPercent float64, maxWait time.Duration) *LLMQuota {
+	return &LLMQuota{
+		minRemainingPercent: minRemainingPercent,
+		maxWait:             maxWait,
+		models:              make(map[string]*QuotaStats),
+		now:                 time.Now,
+	}
+}
+
+var (
+	sharedLLMQuota     *LLMQuota
+	sharedLLMQuotaOnce sync.Once
+)
+
+// SharedLLMQuota returns the process-wide tracker configured by LLM_QUOTA_*
+// SharedLLMQuota 返回按 LLM_QUOTA_* 配置的进程级跟踪器
+func SharedLLMQuota(cfg *config.Config) *LLMQuota {
+	sharedLLMQuotaOnce.Do(func() {
+		sharedLLMQuota = NewLLMQuota(cfg.LLMQuotaMinRemainingPercent, time.Duration(cfg.LLMQuotaMaxWaitSeconds)*time.Second)
+	})
+	return sharedLLMQuota
+}
+
+// stats returns the entry of a model, creating it on first use; the caller holds q.mu
+// stats 返回模型的统计项，首次使用时创建；调用方需持有 q.mu
+func (q *LLMQuota) stats(model string) *QuotaStats {
+	s, ok := q.models[model]
+	if !ok {
+		s = &QuotaStats{Model: model}
+		q.models[model] = s
+	}
+	return s
+}
+
+// Observe records the quota reported by a response of model and marks the model exhausted on a 429
+// Observe 记录模型响应报告的配额，收到 429 时将模型标记为耗尽
+func (q *LLMQuota) Observe(model string, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	s := q.stats(model)
+	s.Calls++
+	s.UpdatedAt = now
+
+	h := resp.Header
+	if v, ok := headerInt(h, headerLimitRequests); ok {
+		s.LimitRequests = v
+	}
+	if v, ok := headerInt(h, headerRemainingRequests); ok {
+		s.RemainingRequests = v
+	}
+	if d, ok := headerReset(h, headerResetRequests); ok {
+		s.RequestsResetAt = now.Add(d)
+	}
+	if v, ok := headerInt(h, headerLimitTokens); ok {
+		s.LimitTokens = v
+	}
+	if v, ok := headerInt(h, headerRemainingTokens); ok {
+		s.RemainingTokens = v
+	}
+	if d, ok := headerReset(h, headerResetTokens); ok {
+		s.TokensResetAt = now.Add(d)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		s.RateLimited++
+		backoff := defaultQuotaBackoff
+		if seconds, err := strconv.Atoi(h.Get("Retry-After")); err == nil && seconds > 0 {
+			backoff = time.Duration(seconds) * time.Second
+		}
+		if until := now.Add(backoff); until.After(s.RateLimitedUntil) {
+			s.RateLimitedUntil = until
+		}
+	}
+}
+
+// lowUntil reports whether a model is below the threshold, and when that ends; the caller holds q.mu
+// lowUntil 判断模型是否低于阈值以及何时恢复；调用方需持有 q.mu
+// A quota whose reset has passed counts as replenished, since the backend reports nothing until the next call
+// 重置时间已过的配额视为已恢复，因为后端要到下一次调用才会报告
+func (q *LLMQuota) lowUntil(model string, now time.Time) (time.Time, bool) {
+	s, ok := q.models[model]
+	if !ok {
+		return time.Time{}, false
+	}
+	var until time.Time
+	if now.Before(s.RateLimitedUntil) {
+		until = s.RateLimitedUntil
+	}
+	below := func(remaining, limit int64) bool {
+		return limit > 0 && float64(remaining) < float64(limit)*q.minRemainingPercent/100
+	}
+	if below(s.RemainingRequests, s.LimitRequests) && now.Before(s.RequestsResetAt) && s.RequestsResetAt.After(until) {
+		until = s.RequestsResetAt
+	}
+	if below(s.RemainingTokens, s.LimitTokens) && now.Before(s.TokensResetAt) && s.TokensResetAt.After(until) {
+		until = s.TokensResetAt
+	}
+	return until, !until.IsZero()
+}
+
+// Select returns the model to call and how long to wait first: the model itself while it has quota, a wait when its
+// quota resets within LLM_QUOTA_MAX_WAIT_SECONDS, otherwise the fallback model when that one has quota
+// Select 返回应调用的模型及调用前的等待时间：配额充足时使用原模型，配额在 LLM_QUOTA_MAX_WAIT_SECONDS 内重置时等待，
+// 否则在备用模型配额充足时改用备用模型
+// With neither option the model is called as is, so a decision is still attempted
+// 两者都不可行时照常调用原模型，仍然尝试生成决策
+func (q *LLMQuota) Select(model, fallback string) (string, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.minRemainingPercent <= 0 {
+		return model, 0
+	}
+	now := q.now()
+	until, low := q.lowUntil(model, now)
+	if !low {
+		return model, 0
+	}
+	if wait := until.Sub(now); wait <= q.maxWait {
+		q.stats(model).Throttled++
+		return model, wait
+	}
+	if fallback != "" && fallback != model {
+		if _, fallbackLow := q.lowUntil(fallback, now); !fallbackLow {
+			q.stats(model).SwitchedAway++
+			return fallback, 0
+		}
+	}
+	return model, 0
+}
+
+// Snapshot returns the quota of every model seen, sorted by model name
+// Snapshot 返回所有已出现模型的配额，按模型名排序
+func (q *LLMQuota) Snapshot() []QuotaStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := make([]QuotaStats, 0, len(q.models))
+	for _, s := range q.models {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Model < stats[j].Model })
+	return stats
+}
+
+// headerInt parses an integer header
+// headerInt 解析整数响应头
+func headerInt(h http.Header, name string) (int64, bool) {
+	v, err := strconv.ParseInt(strings.TrimSpace(h.Get(name)), 10, 64)
+	return v, err == nil
+}
+
+// headerReset parses a reset header, either a Go-style duration ("6m0s", "20ms") or a number of seconds
+// headerReset 解析重置响应头，格式为 Go 风格时长（"6m0s"、"20ms"）或秒数
+func headerReset(h http.Header, name string) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get(name))
+	if v == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return d, true
+	}
+	if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// quotaTransport records the quota headers of every response of one model
+// quotaTransport 记录某个模型每个响应的配额响应头
+type quotaTransport struct {
+	base  http.RoundTripper
+	quota *LLMQuota
+	model string
+}
+
+func (t *quotaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.quota.Observe(t.model, resp)
+	}
+	return resp, err
+}
+
+// newChatModel creates a chat model whose model is picked by the shared quota tracker and whose responses feed it,
+// and returns the model actually used
+// newChatModel 创建聊天模型：模型由共享配额跟踪器选择，其响应也会计入跟踪器；同时返回实际使用的模型
+// The wait for a quota reset is cut short by ctx
+// 等待配额重置时会因 ctx 取消而提前结束
+func newChatModel(ctx context.Context, cfg *config.Config, log *logger.ColorLogger, modelCfg *openaiComponent.ChatModelConfig) (*openaiComponent.ChatModel, string, error) {
+	quota := SharedLLMQuota(cfg)
+	model, wait := quota.Select(modelCfg.Model, cfg.LLMFallbackModel)
+	if wait > 0 {
+		log.Warning(fmt.Sprintf("⏳ 模型 %s 配额不足，等待 %s 后重置", model, wait.Round(time.Second)))
+		select {
+		case <-ctx.Done():
+			return nil, model, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	if model != modelCfg.Model {
+		log.Warning(fmt.Sprintf("🔀 模型 %s 配额不足，本次改用备用模型 %s", modelCfg.Model, model))
+	}
+
+	withQuota := *modelCfg
+	withQuota.Model = model
+	withQuota.HTTPClient = &http.Client{
+		Transport: &quotaTransport{base: http.DefaultTransport, quota: quota, model: model},
+	}
+	chatModel, err := openaiComponent.NewChatModel(ctx, &withQuota)
+	return chatModel, model, err
+}
@@ -0,0 +1,122 @@
+package agents
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newTestQuota returns a tracker driven by a fake clock
+// newTestQuota 返回由模拟时钟驱动的配额跟踪器
+func newTestQuota(minRemainingPercent float64, maxWait time.Duration, start time.Time) (*LLMQuota, *time.Time) {
+	now := start
+	q := NewLLMQuota(minRemainingPercent, maxWait)
+	q.now = func() time.Time { return now }
+	return q, &now
+}
+
+func quotaResponse(status int, remainingRequests, reset string) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{
+		headerLimitRequests:     []string{"100"},
+		headerRemainingRequests: []string{remainingRequests},
+		headerResetRequests:     []string{reset},
+		headerLimitTokens:       []string{"200000"},
+		headerRemainingTokens:   []string{"150000"},
+		headerResetTokens:       []string{"20ms"},
+	}}
+}
+
+func TestLLMQuotaObserveParsesHeaders(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQuota(10, 30*time.Second, start)
+
+	q.Observe("gpt-4o-mini", quotaResponse(http.StatusOK, "42", "6m0s"))
+
+	stats := q.Snapshot()
+	if len(stats) != 1 {
+		t.Fatalf("Expected one model, got %d", len(stats))
+	}
+	s := stats[0]
+	if s.LimitRequests != 100 || s.RemainingRequests != 42 || s.LimitTokens != 200000 || s.RemainingTokens != 150000 {
+		t.Fatalf("Unexpected quota parsed: %+v", s)
+	}
+	if !s.RequestsResetAt.Equal(start.Add(6*time.Minute)) || !s.TokensResetAt.Equal(start.Add(20*time.Millisecond)) {
+		t.Fatalf("Unexpected reset times: requests %v, tokens %v", s.RequestsResetAt, s.TokensResetAt)
+	}
+	if s.Calls != 1 {
+		t.Fatalf("Expected 1 call, got %d", s.Calls)
+	}
+}
+
+func TestLLMQuotaSelectWaitsForShortReset(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQuota(10, 30*time.Second, start)
+
+	q.Observe("primary", quotaResponse(http.StatusOK, "5", "12s"))
+
+	model, wait := q.Select("primary", "fallback")
+	if model != "primary" || wait != 12*time.Second {
+		t.Fatalf("Expected to wait 12s for primary, got %s after %v", model, wait)
+	}
+	if s := q.Snapshot()[0]; s.Throttled != 1 {
+		t.Fatalf("Expected 1 throttled call, got %d", s.Throttled)
+	}
+}
+
+func TestLLMQuotaSelectFallsBackOnLongReset(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q, now := newTestQuota(10, 30*time.Second, start)
+
+	q.Observe("primary", quotaResponse(http.StatusOK, "5", "5m"))
+
+	model, wait := q.Select("primary", "fallback")
+	if model != "fallback" || wait != 0 {
+		t.Fatalf("Expected the fallback without waiting, got %s after %v", model, wait)
+	}
+
+	// Without a fallback the primary is still called, so a decision is attempted
+	// 没有备用模型时仍调用原模型，照常尝试生成决策
+	if model, wait := q.Select("primary", ""); model != "primary" || wait != 0 {
+		t.Fatalf("Expected primary without waiting, got %s after %v", model, wait)
+	}
+
+	// Once the reset passes the primary counts as replenished
+	// 重置时间过后原模型视为已恢复
+	*now = start.Add(5*time.Minute + time.Second)
+	if model, _ := q.Select("primary", "fallback"); model != "primary" {
+		t.Fatalf("Expected primary after the reset, got %s", model)
+	}
+}
+
+func TestLLMQuotaSelectHonoursRateLimit(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQuota(10, 30*time.Second, start)
+
+	// A 429 without quota headers, as DeepSeek sends
+	// 不带配额响应头的 429（DeepSeek 即如此）
+	q.Observe("primary", &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"20"}}})
+
+	model, wait := q.Select("primary", "fallback")
+	if model != "primary" || wait != 20*time.Second {
+		t.Fatalf("Expected to wait out Retry-After on primary, got %s after %v", model, wait)
+	}
+
+	q.Observe("primary", &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}})
+	if model, _ := q.Select("primary", "fallback"); model != "fallback" {
+		t.Fatalf("Expected the fallback during the default backoff, got %s", model)
+	}
+	if s := q.Snapshot()[0]; s.RateLimited != 2 || s.SwitchedAway != 1 {
+		t.Fatalf("Expected 2 rate-limited responses and 1 switch, got %+v", s)
+	}
+}
+
+func TestLLMQuotaSelectOnlyTracksWhenDisabled(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q, _ := newTestQuota(0, 30*time.Second, start)
+
+	q.Observe("primary", quotaResponse(http.StatusOK, "0", "5m"))
+
+	if model, wait := q.Select("primary", "fallback"); model != "primary" || wait != 0 {
+		t.Fatalf("Expected primary untouched when disabled, got %s after %v", model, wait)
+	}
+}
@@ -0,0 +1,111 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// recalledSituation is a stored situation with its distance to the current market
+// recalledSituation 是一条已存储的情境及其与当前市场的距离
+type recalledSituation struct {
+	Memory   *storage.SituationMemory
+	Distance float64
+}
+
+// recallSimilarSituations returns the k stored situations closest to the current features
+// recallSimilarSituations 返回与当前特征最接近的 k 条已存储情境
+// When a setup is forming on the current bar only situations of the same setup and direction are considered
+// 当前 K 线出现形态时，只考虑相同形态和方向的情境
+func recallSimilarSituations(memories []*storage.SituationMemory, current dataflows.SetupFeatures, setup *dataflows.Setup, k int) []recalledSituation {
+	var candidates []recalledSituation
+	for _, m := range memories {
+		if setup != nil && (m.Setup != setup.Type || m.Direction != setup.Direction) {
+			continue
+		}
+		var features dataflows.SetupFeatures
+		if err := json.Unmarshal([]byte(m.Features), &features); err != nil {
+			continue
+		}
+		candidates = append(candidates, recalledSituation{Memory: m, Distance: dataflows.SetupDistance(current, features)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Distance < candidates[j].Distance
+	})
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	return candidates
+}
+
+// formatMemoryReport renders recalled situations as a section of the market report
+// formatMemoryReport 将召回的情境渲染为市场报告的一个章节
+func formatMemoryReport(current dataflows.SetupFeatures, setup *dataflows.Setup, recalled []recalledSituation) string {
+	if len(recalled) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== 历史相似情境 (Similar Past Situations) ===\n")
+	if setup != nil {
+		sb.WriteString(fmt.Sprintf("当前形态: %s\n", dataflows.SetupTypeLabel(setup.Type, setup.Direction)))
+	}
+	sb.WriteString(fmt.Sprintf("当前特征: %s\n", dataflows.DescribeSetupFeatures(current)))
+
+	wins := 0
+	totalR := 0.0
+	for i, r := range recalled {
+		m := r.Memory
+		if m.Outcome == dataflows.OutcomeWin {
+			wins++
+		}
+		totalR += m.RMultiple
+		sb.WriteString(fmt.Sprintf("%d. %s %s → %s %+.2fR（%+.2f%%，%d 根 K 线）\n",
+			i+1, m.SituationTime.Format("2006-01-02 15:04"), dataflows.SetupTypeLabel(m.Setup, m.Direction),
+			outcomeLabel(m.Outcome), m.RMultiple, m.ReturnPct, m.BarsHeld))
+	}
+	sb.WriteString(fmt.Sprintf("小结: %d 个相似情境中 %d 个到达 2R 目标，平均 %+.2fR（历史统计，仅供参考）\n",
+		len(recalled), wins, totalR/float64(len(recalled))))
+	return sb.String()
+}
+
+func outcomeLabel(outcome string) string {
+	switch outcome {
+	case dataflows.OutcomeWin:
+		return "止盈"
+	case dataflows.OutcomeLoss:
+		return "止损"
+	default:
+		return "超时"
+	}
+}
+
+// memoryReport recalls situations similar to the latest bar of ohlcvData; empty when memory is off or nothing matches
+// memoryReport 召回与 ohlcvData 最新 K 线相似的情境；未启用记忆或无匹配时返回空
+func (g *SimpleTradingGraph) memoryReport(binanceSymbol, timeframe string, ohlcvData []dataflows.OHLCV) string {
+	if g.memory == nil {
+		return ""
+	}
+
+	current, ok := dataflows.CurrentSetupFeatures(ohlcvData, dataflows.DefaultSetupLookback)
+	if !ok {
+		return ""
+	}
+	memories, err := g.memory.GetSituationMemories(binanceSymbol, timeframe)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("  ⚠️  %s 读取历史情境失败: %v", binanceSymbol, err))
+		return ""
+	}
+
+	setup := dataflows.DetectLatestSetup(ohlcvData, dataflows.DefaultSetupLookback)
+	topK := g.config.MemoryTopK
+	if topK <= 0 {
+		topK = 3
+	}
+	return formatMemoryReport(current, setup, recallSimilarSituations(memories, current, setup, topK))
+}
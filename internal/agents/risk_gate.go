@@ -0,0 +1,198 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// RiskGateInput is the account state an entry decision is checked against
+// RiskGateInput 是复核开仓决策时使用的账户状态
+type RiskGateInput struct {
+	Equity       float64            // 账户权益（USDT）/ Account equity in USDT
+	Price        float64            // 当前价格，0 表示未知 / Current price, 0 when unknown
+	OpenNotional map[string]float64 // 各交易对已有名义价值（币安格式）/ Open notional per Binance symbol
+	LastCloseAt  time.Time          // 该交易对最近一次平仓时间，零值表示冷却期内没有 / Latest close of the symbol, zero when none in the cooldown
+	Now          time.Time          // 复核时间 / When the check runs
+}
+
+// RiskGate checks every entry decision of the trader against hard rules before execution, without the LLM:
+// a cooldown after each close, the leverage ceiling, the estimated distance to liquidation and the exposure caps
+// RiskGate 在执行前用硬性规则复核交易员的每个开仓决策，不依赖 LLM：平仓后的冷却期、杠杆上限、估算强平距离以及敞口上限
+//
+// A failed rule either downgrades the decision to HOLD or lowers its leverage and size in place; the caller records
+// the returned reasons in the session log. A nil gate (RISK_GATE_ENABLED=false) lets every decision through.
+// 规则不通过时将决策改为 HOLD，或直接降低其杠杆和仓位；调用方将返回的原因记录到会话日志。gate 为 nil（RISK_GATE_ENABLED=false）时全部放行。
+type RiskGate struct {
+	config  *config.Config
+	storage *storage.Storage
+	logger  *logger.ColorLogger
+}
+
+// NewRiskGate creates the gate configured by RISK_GATE_*, or nil when it is disabled
+// NewRiskGate 按 RISK_GATE_* 创建风控闸门；未启用时返回 nil
+func NewRiskGate(cfg *config.Config, db *storage.Storage, log *logger.ColorLogger) *RiskGate {
+	if !cfg.RiskGateEnabled {
+		return nil
+	}
+	return &RiskGate{config: cfg, storage: db, logger: log}
+}
+
+// Input gathers the account state an entry on symbol is checked against
+// Input 收集复核该交易对开仓所需的账户状态
+// What cannot be fetched is left empty, which skips the rules depending on it; the coordinator still enforces the exposure caps
+// 获取失败的数据保持为空，依赖它的规则将被跳过；协调器仍会执行敞口上限
+func (g *RiskGate) Input(ctx context.Context, coordinator *executors.TradeCoordinator, symbol string, action executors.TradeAction, price float64) RiskGateInput {
+	now := time.Now()
+	in := RiskGateInput{Price: price, Now: now}
+
+	side := "long"
+	if action == executors.ActionSell {
+		side = "short"
+	}
+	equity, open, err := coordinator.OpenExposure(ctx, symbol, side)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  风控闸门获取 %s 组合敞口失败: %v", symbol, err))
+	} else {
+		in.Equity, in.OpenNotional = equity, open
+	}
+
+	if in.LastCloseAt, err = g.lastCloseAt(symbol, now); err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️  风控闸门获取 %s 平仓记录失败: %v", symbol, err))
+	}
+	return in
+}
+
+// lastCloseAt returns when a position of symbol last closed within the cooldown, zero when none did or the cooldown is off
+// lastCloseAt 返回冷却期内该交易对最近一次平仓的时间；没有平仓或未设置冷却期时返回零值
+func (g *RiskGate) lastCloseAt(symbol string, now time.Time) (time.Time, error) {
+	if g.storage == nil || g.config.RiskGateCooldownMinutes <= 0 {
+		return time.Time{}, nil
+	}
+	closed, err := g.storage.GetClosedPositions(now.Add(-time.Duration(g.config.RiskGateCooldownMinutes) * time.Minute))
+	if err != nil {
+		return time.Time{}, err
+	}
+	target := g.config.GetBinanceSymbolFor(symbol)
+	var last time.Time
+	for _, p := range closed {
+		if p.CloseTime != nil && g.config.GetBinanceSymbolFor(p.Symbol) == target && p.CloseTime.After(last) {
+			last = *p.CloseTime
+		}
+	}
+	return last, nil
+}
+
+// Review checks an entry decision and returns the reason of every override it applied, nil when it passed untouched
+// Review 复核开仓决策，返回每项调整的原因；原样通过时返回 nil
+// Closes and HOLD always pass: the gate never stands between the bot and reducing risk
+// 平仓和 HOLD 总是放行：风控闸门不会阻止降低风险的操作
+func (g *RiskGate) Review(d *TradingDecision, in RiskGateInput) []string {
+	if g == nil || d == nil || !d.Valid || (d.Action != executors.ActionBuy && d.Action != executors.ActionSell) {
+		return nil
+	}
+	var overrides []string
+	veto := func(reason string) []string {
+		overrides = append(overrides, fmt.Sprintf("%s 改为 HOLD：%s", d.Action, reason))
+		d.Action = executors.ActionHold
+		return overrides
+	}
+
+	// Cooldown after a close, so a stop-out is not followed by an immediate re-entry
+	// 平仓后的冷却期，避免止损后立即再次入场
+	if minutes := g.config.RiskGateCooldownMinutes; minutes > 0 && !in.LastCloseAt.IsZero() {
+		if elapsed := in.Now.Sub(in.LastCloseAt); elapsed < time.Duration(minutes)*time.Minute {
+			return veto(fmt.Sprintf("距上次平仓仅 %.0f 分钟，冷却期 %d 分钟", elapsed.Minutes(), minutes))
+		}
+	}
+
+	// The leverage the order will actually use, after the configured range
+	// 按配置范围校验后订单实际使用的杠杆
+	leverage := ValidateLeverage(d.Leverage, g.config.BinanceLeverageMin, g.config.BinanceLeverageMax, g.config.BinanceLeverageDynamic)
+	if d.Leverage > leverage {
+		overrides = append(overrides, fmt.Sprintf("杠杆 %dx 超过上限，降为 %dx", d.Leverage, leverage))
+		d.Leverage = leverage
+	}
+
+	// Liquidation must sit beyond both the minimum distance and the stop, or the stop would never get to fire
+	// 强平价必须同时远于最小距离和止损价，否则止损来不及触发
+	required := g.config.RiskGateMinLiquidationDistancePercent
+	if d.StopLoss > 0 && in.Price > 0 {
+		required = math.Max(required, math.Abs(in.Price-d.StopLoss)/in.Price*100)
+	}
+	if required > 0 && leverage > 0 && executors.EstimatedLiquidationDistancePercent(leverage) <= required {
+		safe := maxLeverageForLiquidationDistance(required)
+		if safe < 1 || safe < g.config.BinanceLeverageMin {
+			return veto(fmt.Sprintf("%dx 估算强平距离 %.1f%% 不足 %.1f%%，最低杠杆 %dx 也无法满足",
+				leverage, executors.EstimatedLiquidationDistancePercent(leverage), required, g.config.BinanceLeverageMin))
+		}
+		overrides = append(overrides, fmt.Sprintf("%dx 估算强平距离 %.1f%% 不足 %.1f%%，杠杆降为 %dx",
+			leverage, executors.EstimatedLiquidationDistancePercent(leverage), required, safe))
+		leverage = safe
+		d.Leverage = safe
+	}
+
+	// Exposure caps: no room left vetoes the entry, too little room shrinks it
+	// 敞口上限：没有余量时否决开仓，余量不足时缩小仓位
+	room, detail := exposureRoom(g.config, d.Symbol, in)
+	if room <= 0 {
+		return veto(detail)
+	}
+	if d.PositionSizePercent > 0 && !math.IsInf(room, 1) {
+		notional := in.Equity * d.PositionSizePercent / 100 * float64(leverage)
+		if notional > room {
+			size := room / (in.Equity * float64(leverage)) * 100
+			overrides = append(overrides, fmt.Sprintf("仓位 %.1f%% 超出敞口余量（%s），缩小为 %.1f%%", d.PositionSizePercent, detail, size))
+			d.PositionSizePercent = size
+		}
+	}
+	return overrides
+}
+
+// maxLeverageForLiquidationDistance returns the highest leverage whose estimated liquidation lies beyond distance percent
+// maxLeverageForLiquidationDistance 返回估算强平距离大于 distance 百分比的最高杠杆
+func maxLeverageForLiquidationDistance(distance float64) int {
+	leverage := 125
+	for leverage > 0 && executors.EstimatedLiquidationDistancePercent(leverage) <= distance {
+		leverage--
+	}
+	return leverage
+}
+
+// exposureRoom returns the notional still allowed on symbol under EXPOSURE_MAX_TOTAL_PERCENT and
+// EXPOSURE_MAX_SYMBOL_PERCENT, +Inf when neither is set or the equity is unknown
+// exposureRoom 返回在 EXPOSURE_MAX_TOTAL_PERCENT 和 EXPOSURE_MAX_SYMBOL_PERCENT 下该交易对仍可开的名义价值；
+// 均未设置或权益未知时返回 +Inf
+func exposureRoom(cfg *config.Config, symbol string, in RiskGateInput) (float64, string) {
+	room := math.Inf(1)
+	detail := ""
+	if in.Equity <= 0 {
+		return room, detail
+	}
+	if cfg.ExposureMaxTotalPercent > 0 {
+		total := 0.0
+		for _, notional := range in.OpenNotional {
+			total += notional
+		}
+		limit := in.Equity * cfg.ExposureMaxTotalPercent / 100
+		room = limit - total
+		detail = fmt.Sprintf("总敞口 %.2f / %.2f USDT", total, limit)
+	}
+	if cfg.ExposureMaxSymbolPercent > 0 {
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+		open := in.OpenNotional[binanceSymbol]
+		limit := in.Equity * cfg.ExposureMaxSymbolPercent / 100
+		room = math.Min(room, limit-open)
+		if detail != "" {
+			detail += "，"
+		}
+		detail += fmt.Sprintf("%s 敞口 %.2f / %.2f USDT", binanceSymbol, open, limit)
+	}
+	return room, detail
+}
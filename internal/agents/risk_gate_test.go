@@ -0,0 +1,95 @@
+package agents
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+func TestRiskGateReview(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	newGate := func() *RiskGate {
+		return NewRiskGate(&config.Config{
+			RiskGateEnabled:                       true,
+			RiskGateMinLiquidationDistancePercent: 5,
+			RiskGateCooldownMinutes:               60,
+			BinanceLeverageMin:                    1,
+			BinanceLeverageMax:                    10,
+			BinanceLeverageDynamic:                true,
+			ExposureMaxTotalPercent:               100,
+		}, nil, nil)
+	}
+	entry := func() *TradingDecision {
+		return &TradingDecision{Symbol: "BTC/USDT", Action: executors.ActionBuy, Leverage: 5, PositionSizePercent: 10, Valid: true}
+	}
+
+	// An entry inside every limit passes untouched
+	// 所有限制之内的开仓原样通过
+	d := entry()
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, Price: 100, Now: now}); overrides != nil || d.Action != executors.ActionBuy {
+		t.Errorf("Expected the entry to pass, got %v", overrides)
+	}
+
+	// Re-entering 20 minutes after a close is vetoed
+	// 平仓 20 分钟后再次开仓被否决
+	d = entry()
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, LastCloseAt: now.Add(-20 * time.Minute), Now: now}); len(overrides) != 1 || d.Action != executors.ActionHold {
+		t.Errorf("Expected a cooldown veto, got %v / %s", overrides, d.Action)
+	}
+
+	// Leverage above the ceiling is lowered to it
+	// 超过上限的杠杆降到上限
+	d = entry()
+	d.Leverage = 20
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, Price: 100, Now: now}); len(overrides) != 1 || d.Leverage != 10 {
+		t.Errorf("Expected leverage 10, got %dx (%v)", d.Leverage, overrides)
+	}
+
+	// A stop 15% away needs liquidation beyond it: 6x liquidates about 16.3% away, 7x about 13.9%
+	// 止损距离 15% 时强平必须更远：6x 约 16.3%，7x 约 13.9%
+	d = entry()
+	d.Leverage = 10
+	d.StopLoss = 85
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, Price: 100, Now: now}); len(overrides) != 1 || d.Leverage != 6 || d.Action != executors.ActionBuy {
+		t.Errorf("Expected leverage 6, got %dx (%v)", d.Leverage, overrides)
+	}
+
+	// With fixed 10x leverage the same stop cannot be made safe, so the entry is vetoed
+	// 固定 10x 杠杆时同样的止损无法满足，开仓被否决
+	fixed := newGate()
+	fixed.config.BinanceLeverageMin, fixed.config.BinanceLeverageDynamic = 10, false
+	d = entry()
+	d.StopLoss = 85
+	if overrides := fixed.Review(d, RiskGateInput{Equity: 1000, Price: 100, Now: now}); d.Action != executors.ActionHold {
+		t.Errorf("Expected a liquidation veto, got %v", overrides)
+	}
+
+	// 200 USDT of room at 5x leaves 4% of equity instead of 10%
+	// 5x 杠杆下剩余 200 USDT 余量，仓位从 10% 缩小为 4%
+	d = entry()
+	open := map[string]float64{"ETHUSDT": 800}
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, Price: 100, OpenNotional: open, Now: now}); len(overrides) != 1 || math.Abs(d.PositionSizePercent-4) > 1e-9 {
+		t.Errorf("Expected a 4%% position, got %.2f%% (%v)", d.PositionSizePercent, overrides)
+	}
+
+	// No room left at all vetoes the entry
+	// 完全没有余量时否决开仓
+	d = entry()
+	open["BTCUSDT"] = 200
+	if overrides := newGate().Review(d, RiskGateInput{Equity: 1000, Price: 100, OpenNotional: open, Now: now}); d.Action != executors.ActionHold {
+		t.Errorf("Expected an exposure veto, got %v", overrides)
+	}
+
+	// Closes and a disabled gate always pass
+	// 平仓以及未启用的闸门总是放行
+	d = &TradingDecision{Symbol: "BTC/USDT", Action: executors.ActionCloseLong, Valid: true}
+	if overrides := newGate().Review(d, RiskGateInput{LastCloseAt: now, Now: now}); overrides != nil {
+		t.Errorf("Expected closes to pass, got %v", overrides)
+	}
+	if gate := NewRiskGate(&config.Config{}, nil, nil); gate != nil || gate.Review(entry(), RiskGateInput{}) != nil {
+		t.Errorf("Expected a nil gate to let entries through")
+	}
+}
@@ -0,0 +1,412 @@
+package agents
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// shadowMissingAction stands for a symbol one side left out of its decision
+// shadowMissingAction 表示一方决策中缺少的交易对
+const shadowMissingAction = "NONE"
+
+// shadowReportMaxSymbols bounds how many symbols the diff report lists
+// shadowReportMaxSymbols 限制差异报告中列出的交易对数量
+const shadowReportMaxSymbols = 5
+
+// traderProfile is the model and system prompt the trader decides with
+// traderProfile 是交易员做决策使用的模型和系统提示词
+type traderProfile struct {
+	Model  string
+	Prompt string
+}
+
+// fingerprint identifies the profile by a hash of its model and prompt
+// fingerprint 以模型和提示词的哈希标识该配置
+func (p traderProfile) fingerprint() string {
+	sum := sha256.Sum256([]byte(p.Model + "\x00" + p.Prompt))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// configuredTraderProfile returns the model and prompt currently set in the configuration
+// configuredTraderProfile 返回当前配置中的模型和提示词
+func (g *SimpleTradingGraph) configuredTraderProfile() traderProfile {
+	return traderProfile{
+		Model:  g.config.QuickThinkLLM,
+		Prompt: loadPromptFromFile(g.config.TraderPromptPath, g.logger),
+	}
+}
+
+// resolveTraderProfiles returns the profile making live decisions this cycle, and the candidate config to run in
+// shadow, if any
+// resolveTraderProfiles 返回本轮做出实盘决策的配置，以及需要影子运行的候选配置（如有）
+//
+// Without SHADOW_CYCLES a changed model or prompt goes live at once. With it, the approved config stays live while
+// the change runs in shadow, and after the shadow run it waits for the user to promote it.
+// 未设置 SHADOW_CYCLES 时变更立即生效；设置后已确认的配置继续实盘，变更先影子运行，完成后等待用户切换。
+func (g *SimpleTradingGraph) resolveTraderProfiles() (traderProfile, *storage.TraderConfig) {
+	configured := g.configuredTraderProfile()
+	if g.traderConfigs == nil {
+		return configured, nil
+	}
+	fingerprint := configured.fingerprint()
+
+	live, err := g.traderConfigs.GetLiveTraderConfig()
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 读取实盘交易员配置失败，使用当前配置: %v", err))
+		return configured, nil
+	}
+	if live != nil && live.Fingerprint == fingerprint {
+		return configured, nil
+	}
+	if live == nil || g.config.ShadowCycles <= 0 {
+		// The first recorded config, or changes without shadow runs, go live at once
+		// 首个记录的配置，或未启用影子运行时的变更，立即生效
+		g.promoteTraderProfile(configured, fingerprint, live)
+		return configured, nil
+	}
+
+	liveProfile := traderProfile{Model: live.Model, Prompt: live.Prompt}
+	candidate, err := g.traderConfigs.GetTraderConfig(fingerprint)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 读取候选交易员配置失败，本轮不做影子运行: %v", err))
+		return liveProfile, nil
+	}
+	if candidate == nil {
+		candidate = &storage.TraderConfig{
+			Fingerprint: fingerprint,
+			Model:       configured.Model,
+			Prompt:      configured.Prompt,
+			Status:      storage.TraderConfigShadow,
+			CreatedAt:   time.Now(),
+		}
+		if err := g.traderConfigs.SaveTraderConfig(candidate); err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️ 保存候选交易员配置失败，本轮不做影子运行: %v", err))
+			return liveProfile, nil
+		}
+		msg := fmt.Sprintf("🧪 交易员配置已变更（%s @ %s → %s @ %s），新配置先影子运行 %d 轮，实盘继续使用原配置",
+			live.Model, live.Fingerprint, configured.Model, fingerprint, g.config.ShadowCycles)
+		g.logger.Warning(msg)
+		g.stopLossManager.Notifier().Notify(notify.SeverityInfo, "全部交易对", msg)
+	}
+
+	if candidate.Status != storage.TraderConfigShadow {
+		g.logger.Info(fmt.Sprintf("🧪 候选交易员配置 %s 等待切换，实盘继续使用 %s @ %s", fingerprint, live.Model, live.Fingerprint))
+		return liveProfile, nil
+	}
+	return liveProfile, candidate
+}
+
+// promoteTraderProfile records the configured profile and makes it live, replacing live when there is one
+// promoteTraderProfile 记录当前配置并切换为实盘配置，替换现有的实盘配置（如有）
+func (g *SimpleTradingGraph) promoteTraderProfile(profile traderProfile, fingerprint string, live *storage.TraderConfig) {
+	err := g.traderConfigs.SaveTraderConfig(&storage.TraderConfig{
+		Fingerprint: fingerprint,
+		Model:       profile.Model,
+		Prompt:      profile.Prompt,
+		Status:      storage.TraderConfigReady,
+		CreatedAt:   time.Now(),
+	})
+	if err == nil {
+		err = g.traderConfigs.PromoteTraderConfig(fingerprint)
+	}
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 记录交易员配置 %s 失败: %v", fingerprint, err))
+		return
+	}
+	if live != nil {
+		g.logger.Info(fmt.Sprintf("🔁 交易员配置已变更并立即生效: %s @ %s → %s @ %s", live.Model, live.Fingerprint, profile.Model, fingerprint))
+	}
+}
+
+// runShadowCycle has the candidate decide on this cycle's input and records how it differs from the live decision;
+// after SHADOW_CYCLES cycles it sends the diff report
+// runShadowCycle 让候选配置基于本轮输入做出决策，并记录与实盘决策的差异；满 SHADOW_CYCLES 轮后发送差异报告
+//
+// The live side is the final decision after the guardrail, the shadow side the candidate's raw decision. Cycles the
+// live side fell back to rules, or the candidate failed, are not counted.
+// 实盘一侧为经过护栏后的最终决策，影子一侧为候选配置的原始决策。实盘降级为规则决策或候选配置调用失败的轮次不计入。
+func (g *SimpleTradingGraph) runShadowCycle(ctx context.Context, candidate *storage.TraderConfig, liveDecision string) {
+	liveDecisions, ok := parseDecisionMap(liveDecision)
+	if !ok {
+		g.logger.Info("🧪 影子运行: 本轮实盘为规则决策，跳过对比")
+		return
+	}
+
+	content, err := g.generateShadowDecision(ctx, traderProfile{Model: candidate.Model, Prompt: candidate.Prompt})
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("🧪 影子运行: 候选配置生成决策失败，本轮不计入: %v", err))
+		return
+	}
+	shadowDecisions, ok := parseDecisionMap(content)
+	if !ok {
+		g.logger.Warning("🧪 影子运行: 候选配置的决策无法解析，本轮不计入")
+		return
+	}
+
+	cycle := candidate.ShadowCycles + 1
+	now := time.Now()
+	rows := compareDecisions(liveDecisions, shadowDecisions)
+	differ := 0
+	for _, row := range rows {
+		row.Fingerprint = candidate.Fingerprint
+		row.Cycle = cycle
+		row.CreatedAt = now
+		if row.LiveAction != row.ShadowAction {
+			differ++
+			g.logger.Info(fmt.Sprintf("🧪【%s】影子决策不同: 实盘 %s → 候选 %s", row.Symbol, row.LiveAction, row.ShadowAction))
+		}
+		if _, err := g.traderConfigs.SaveShadowDecision(row); err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️ 保存影子决策失败: %v", err))
+			return
+		}
+	}
+
+	cycles, err := g.traderConfigs.FinishShadowCycle(candidate.Fingerprint)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 记录影子运行轮数失败: %v", err))
+		return
+	}
+	g.logger.Info(fmt.Sprintf("🧪 影子运行 %d/%d: %d 个交易对中 %d 个决策不同", cycles, g.config.ShadowCycles, len(rows), differ))
+	if cycles < g.config.ShadowCycles {
+		return
+	}
+
+	all, err := g.traderConfigs.GetShadowDecisions(candidate.Fingerprint)
+	if err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 读取影子决策失败: %v", err))
+		return
+	}
+	if err := g.traderConfigs.SetTraderConfigStatus(candidate.Fingerprint, storage.TraderConfigReady); err != nil {
+		g.logger.Warning(fmt.Sprintf("⚠️ 更新候选交易员配置状态失败: %v", err))
+		return
+	}
+	live, _ := g.traderConfigs.GetLiveTraderConfig()
+	report := BuildShadowDiffReport(all).Format(live, candidate)
+	g.logger.Success(report)
+	g.stopLossManager.Notifier().Notify(notify.SeverityInfo, "全部交易对", report)
+}
+
+// generateShadowDecision asks the candidate profile for a decision on this cycle's reports
+// generateShadowDecision 让候选配置基于本轮报告生成决策
+func (g *SimpleTradingGraph) generateShadowDecision(ctx context.Context, profile traderProfile) (string, error) {
+	cfg, _ := g.traderChatConfig(profile.Model)
+	chatModel, model, err := newChatModel(ctx, g.config, g.logger, cfg)
+	if err != nil {
+		return "", err
+	}
+	if model != profile.Model {
+		// A quota fallback would compare another model than the candidate
+		// 配额降级后对比的将不是候选模型
+		return "", fmt.Errorf("model %s replaced by fallback %s", profile.Model, model)
+	}
+
+	g.logger.Info(fmt.Sprintf("🧪 影子运行: 正在用候选配置生成决策, 使用的模型:%v", model))
+	response, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(profile.Prompt + decisionLanguageInstruction(g.config.DecisionLanguage)),
+		schema.UserMessage(g.traderUserPrompt("")),
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.Content, nil
+}
+
+// compareDecisions pairs the live and shadow decision of every symbol either side decided on, sorted by symbol
+// compareDecisions 按交易对配对实盘决策与影子决策（任一方有决策的交易对均列出），按交易对排序
+func compareDecisions(live, shadow map[string]TradeDecision) []*storage.ShadowDecision {
+	bySymbol := make(map[string]*storage.ShadowDecision)
+	row := func(key string) *storage.ShadowDecision {
+		symbol := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(key), "/", ""))
+		r, ok := bySymbol[symbol]
+		if !ok {
+			r = &storage.ShadowDecision{Symbol: symbol, LiveAction: shadowMissingAction, ShadowAction: shadowMissingAction}
+			bySymbol[symbol] = r
+		}
+		return r
+	}
+	for key, d := range live {
+		r := row(key)
+		r.LiveAction = normalizeShadowAction(d.Action)
+		r.LiveConfidence = d.Confidence
+		r.LiveLeverage = d.Leverage
+	}
+	for key, d := range shadow {
+		r := row(key)
+		r.ShadowAction = normalizeShadowAction(d.Action)
+		r.ShadowConfidence = d.Confidence
+		r.ShadowLeverage = d.Leverage
+	}
+
+	rows := make([]*storage.ShadowDecision, 0, len(bySymbol))
+	for _, r := range bySymbol {
+		rows = append(rows, r)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Symbol < rows[j].Symbol })
+	return rows
+}
+
+// normalizeShadowAction upper-cases an action, treating an empty one as missing
+// normalizeShadowAction 将动作转为大写，空动作视为缺失
+func normalizeShadowAction(action string) string {
+	action = strings.ToUpper(strings.TrimSpace(action))
+	if action == "" {
+		return shadowMissingAction
+	}
+	return action
+}
+
+// ActionChange counts how often the candidate turned one live action into another
+// ActionChange 统计候选配置将某个实盘动作改为另一动作的次数
+type ActionChange struct {
+	From  string `json:"from"`  // 实盘动作 / Live action
+	To    string `json:"to"`    // 候选动作 / Candidate action
+	Count int    `json:"count"` // 次数 / Occurrences
+}
+
+// SymbolDisagreement counts the decisions of one symbol where live and candidate differed
+// SymbolDisagreement 统计单个交易对上实盘与候选配置动作不同的决策数
+type SymbolDisagreement struct {
+	Symbol        string `json:"symbol"`        // 交易对 / Symbol
+	Decisions     int    `json:"decisions"`     // 对比的决策数 / Decisions compared
+	Disagreements int    `json:"disagreements"` // 动作不同的决策数 / Decisions with another action
+}
+
+// ShadowDiffReport summarizes how a candidate's decisions differed from the live ones over its shadow run
+// ShadowDiffReport 汇总候选配置在影子运行期间的决策与实盘决策的差异
+type ShadowDiffReport struct {
+	Cycles              int                  `json:"cycles"`                // 影子运行轮数 / Shadow cycles
+	Decisions           int                  `json:"decisions"`             // 对比的交易对决策数 / Symbol decisions compared
+	Agreements          int                  `json:"agreements"`            // 动作一致的决策数 / Decisions with the same action
+	LiveEntries         int                  `json:"live_entries"`          // 实盘开仓决策数 / Live BUY/SELL decisions
+	ShadowEntries       int                  `json:"shadow_entries"`        // 候选开仓决策数 / Candidate BUY/SELL decisions
+	LiveAvgConfidence   float64              `json:"live_avg_confidence"`   // 实盘平均置信度 / Live average confidence
+	ShadowAvgConfidence float64              `json:"shadow_avg_confidence"` // 候选平均置信度 / Candidate average confidence
+	LeverageChanges     int                  `json:"leverage_changes"`      // 双方同向开仓但杠杆不同的次数 / Same entry with another leverage
+	ActionChanges       []ActionChange       `json:"action_changes"`        // 动作变化，次数多的在前 / Action changes, most frequent first
+	Symbols             []SymbolDisagreement `json:"symbols"`               // 各交易对分歧，分歧多的在前 / Disagreements per symbol, most first
+}
+
+// AgreementRate returns the share of decisions with the same action, in percent
+// AgreementRate 返回动作一致的决策占比（%）
+func (r ShadowDiffReport) AgreementRate() float64 {
+	if r.Decisions == 0 {
+		return 0
+	}
+	return float64(r.Agreements) / float64(r.Decisions) * 100
+}
+
+// BuildShadowDiffReport summarizes the recorded shadow decisions of a candidate
+// BuildShadowDiffReport 汇总候选配置已记录的影子决策
+func BuildShadowDiffReport(rows []*storage.ShadowDecision) ShadowDiffReport {
+	var r ShadowDiffReport
+	changes := make(map[[2]string]int)
+	symbols := make(map[string]*SymbolDisagreement)
+	var liveConfidence, shadowConfidence float64
+	isEntry := func(action string) bool { return action == "BUY" || action == "SELL" }
+
+	for _, row := range rows {
+		if row.Cycle > r.Cycles {
+			r.Cycles = row.Cycle
+		}
+		r.Decisions++
+		liveConfidence += row.LiveConfidence
+		shadowConfidence += row.ShadowConfidence
+		if isEntry(row.LiveAction) {
+			r.LiveEntries++
+		}
+		if isEntry(row.ShadowAction) {
+			r.ShadowEntries++
+		}
+
+		sym, ok := symbols[row.Symbol]
+		if !ok {
+			sym = &SymbolDisagreement{Symbol: row.Symbol}
+			symbols[row.Symbol] = sym
+		}
+		sym.Decisions++
+
+		if row.LiveAction == row.ShadowAction {
+			r.Agreements++
+			if isEntry(row.LiveAction) && row.LiveLeverage != row.ShadowLeverage {
+				r.LeverageChanges++
+			}
+			continue
+		}
+		sym.Disagreements++
+		changes[[2]string{row.LiveAction, row.ShadowAction}]++
+	}
+	if r.Decisions > 0 {
+		r.LiveAvgConfidence = liveConfidence / float64(r.Decisions)
+		r.ShadowAvgConfidence = shadowConfidence / float64(r.Decisions)
+	}
+
+	for change, count := range changes {
+		r.ActionChanges = append(r.ActionChanges, ActionChange{From: change[0], To: change[1], Count: count})
+	}
+	sort.Slice(r.ActionChanges, func(i, j int) bool {
+		a, b := r.ActionChanges[i], r.ActionChanges[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.From+a.To < b.From+b.To
+	})
+	for _, sym := range symbols {
+		r.Symbols = append(r.Symbols, *sym)
+	}
+	sort.Slice(r.Symbols, func(i, j int) bool {
+		a, b := r.Symbols[i], r.Symbols[j]
+		if a.Disagreements != b.Disagreements {
+			return a.Disagreements > b.Disagreements
+		}
+		return a.Symbol < b.Symbol
+	})
+	return r
+}
+
+// Format renders the report as a notification message; live may be nil
+// Format 将报告渲染为通知消息；live 可以为 nil
+func (r ShadowDiffReport) Format(live, candidate *storage.TraderConfig) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🧪 交易员配置影子运行报告（%d 轮）\n", r.Cycles))
+	if live != nil {
+		sb.WriteString(fmt.Sprintf("实盘: %s @ %s\n", live.Model, live.Fingerprint))
+	}
+	sb.WriteString(fmt.Sprintf("候选: %s @ %s\n", candidate.Model, candidate.Fingerprint))
+	sb.WriteString(fmt.Sprintf("对比 %d 个交易对决策，动作一致 %d 个（%.1f%%）\n", r.Decisions, r.Agreements, r.AgreementRate()))
+	sb.WriteString(fmt.Sprintf("开仓决策: 实盘 %d 次，候选 %d 次\n", r.LiveEntries, r.ShadowEntries))
+	sb.WriteString(fmt.Sprintf("平均置信度: 实盘 %.2f → 候选 %.2f\n", r.LiveAvgConfidence, r.ShadowAvgConfidence))
+	if r.LeverageChanges > 0 {
+		sb.WriteString(fmt.Sprintf("同向开仓但杠杆不同: %d 次\n", r.LeverageChanges))
+	}
+
+	if len(r.ActionChanges) > 0 {
+		sb.WriteString("\n动作变化（实盘 → 候选）:\n")
+		for _, c := range r.ActionChanges {
+			sb.WriteString(fmt.Sprintf("  %s → %s: %d 次\n", c.From, c.To, c.Count))
+		}
+	}
+	listed := 0
+	for _, sym := range r.Symbols {
+		if sym.Disagreements == 0 || listed == shadowReportMaxSymbols {
+			break
+		}
+		if listed == 0 {
+			sb.WriteString("\n分歧最多的交易对:\n")
+		}
+		sb.WriteString(fmt.Sprintf("  %s: %d/%d\n", sym.Symbol, sym.Disagreements, sym.Decisions))
+		listed++
+	}
+
+	sb.WriteString(fmt.Sprintf("\n确认后切换实盘: POST /api/trader-configs/%s/promote", candidate.Fingerprint))
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package agents
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestCompareDecisions(t *testing.T) {
+	live := map[string]TradeDecision{
+		"BTC/USDT": {Action: "HOLD", Confidence: 0.5},
+		"ETH/USDT": {Action: "BUY", Confidence: 0.8, Leverage: 5},
+	}
+	shadow := map[string]TradeDecision{
+		"BTCUSDT":  {Action: "buy", Confidence: 0.7, Leverage: 10},
+		"ETH/USDT": {Action: "BUY", Confidence: 0.9, Leverage: 8},
+		"SOL/USDT": {Action: "SELL", Confidence: 0.6},
+	}
+
+	rows := compareDecisions(live, shadow)
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 symbols, got %+v", rows)
+	}
+	// Symbols in either format are paired, actions are upper-cased
+	// 两种格式的交易对都能配对，动作统一为大写
+	if r := rows[0]; r.Symbol != "BTCUSDT" || r.LiveAction != "HOLD" || r.ShadowAction != "BUY" || r.ShadowLeverage != 10 {
+		t.Errorf("Unexpected BTCUSDT row: %+v", r)
+	}
+	if r := rows[1]; r.Symbol != "ETHUSDT" || r.LiveLeverage != 5 || r.ShadowLeverage != 8 {
+		t.Errorf("Unexpected ETHUSDT row: %+v", r)
+	}
+	if r := rows[2]; r.Symbol != "SOLUSDT" || r.LiveAction != shadowMissingAction || r.ShadowAction != "SELL" {
+		t.Errorf("Expected SOLUSDT missing on the live side, got %+v", r)
+	}
+}
+
+func TestBuildShadowDiffReport(t *testing.T) {
+	rows := []*storage.ShadowDecision{
+		{Cycle: 1, Symbol: "BTCUSDT", LiveAction: "HOLD", ShadowAction: "BUY", LiveConfidence: 0.4, ShadowConfidence: 0.8},
+		{Cycle: 1, Symbol: "ETHUSDT", LiveAction: "BUY", ShadowAction: "BUY", LiveConfidence: 0.8, ShadowConfidence: 0.8, LiveLeverage: 5, ShadowLeverage: 10},
+		{Cycle: 2, Symbol: "BTCUSDT", LiveAction: "HOLD", ShadowAction: "BUY", LiveConfidence: 0.4, ShadowConfidence: 0.6},
+		{Cycle: 2, Symbol: "ETHUSDT", LiveAction: "SELL", ShadowAction: "HOLD", LiveConfidence: 0.8, ShadowConfidence: 0.2},
+	}
+
+	r := BuildShadowDiffReport(rows)
+	if r.Cycles != 2 || r.Decisions != 4 || r.Agreements != 1 || r.AgreementRate() != 25 {
+		t.Fatalf("Unexpected totals: %+v", r)
+	}
+	if r.LiveEntries != 2 || r.ShadowEntries != 3 || r.LeverageChanges != 1 {
+		t.Errorf("Expected 2 live and 3 shadow entries with 1 leverage change, got %+v", r)
+	}
+	if math.Abs(r.LiveAvgConfidence-0.6) > 1e-9 || math.Abs(r.ShadowAvgConfidence-0.6) > 1e-9 {
+		t.Errorf("Expected average confidence 0.6 on both sides, got %.2f and %.2f", r.LiveAvgConfidence, r.ShadowAvgConfidence)
+	}
+	if len(r.ActionChanges) != 2 || r.ActionChanges[0] != (ActionChange{From: "HOLD", To: "BUY", Count: 2}) {
+		t.Errorf("Expected HOLD → BUY twice first, got %+v", r.ActionChanges)
+	}
+	if len(r.Symbols) != 2 || r.Symbols[0].Symbol != "BTCUSDT" || r.Symbols[0].Disagreements != 2 {
+		t.Errorf("Expected BTCUSDT to disagree most, got %+v", r.Symbols)
+	}
+
+	msg := r.Format(&storage.TraderConfig{Model: "gpt-4o-mini", Fingerprint: "aaa"}, &storage.TraderConfig{Model: "gpt-4o", Fingerprint: "bbb"})
+	for _, want := range []string{"2 轮", "25.0%", "HOLD → BUY: 2 次", "BTCUSDT: 2/2", "/api/trader-configs/bbb/promote"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("Expected the report to contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestResolveTraderProfiles(t *testing.T) {
+	dir := t.TempDir()
+	promptPath := filepath.Join(dir, "trader.txt")
+	if err := os.WriteFile(promptPath, []byte("prompt v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.NewStorage(filepath.Join(dir, "trader.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{QuickThinkLLM: "gpt-4o-mini", TraderPromptPath: promptPath, ShadowCycles: 3}
+	g := &SimpleTradingGraph{
+		config:          cfg,
+		logger:          logger.NewColorLogger(false),
+		stopLossManager: &executors.StopLossManager{},
+		traderConfigs:   db,
+	}
+
+	// The first config seen goes live at once
+	// 首次出现的配置立即生效
+	live, candidate := g.resolveTraderProfiles()
+	if live.Prompt != "prompt v1" || candidate != nil {
+		t.Fatalf("Expected v1 live without a candidate, got %+v, %+v", live, candidate)
+	}
+	v1 := live.fingerprint()
+
+	// A changed prompt runs in shadow while v1 stays live
+	// 提示词变更后进入影子运行，v1 继续实盘
+	if err := os.WriteFile(promptPath, []byte("prompt v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	live, candidate = g.resolveTraderProfiles()
+	if live.Prompt != "prompt v1" || candidate == nil || candidate.Prompt != "prompt v2" || candidate.Status != storage.TraderConfigShadow {
+		t.Fatalf("Expected v1 live with v2 in shadow, got %+v, %+v", live, candidate)
+	}
+
+	// Once the shadow run is done the candidate waits for promotion
+	// 影子运行完成后候选配置等待切换
+	if err := db.SetTraderConfigStatus(candidate.Fingerprint, storage.TraderConfigReady); err != nil {
+		t.Fatal(err)
+	}
+	if live, candidate = g.resolveTraderProfiles(); live.Prompt != "prompt v1" || candidate != nil {
+		t.Fatalf("Expected v1 live while v2 waits, got %+v, %+v", live, candidate)
+	}
+
+	// Without shadow cycles a change goes live at once
+	// 未启用影子运行时变更立即生效
+	cfg.ShadowCycles = 0
+	if live, candidate = g.resolveTraderProfiles(); live.Prompt != "prompt v2" || candidate != nil {
+		t.Fatalf("Expected v2 live at once, got %+v, %+v", live, candidate)
+	}
+	if old, _ := db.GetTraderConfig(v1); old == nil || old.Status != storage.TraderConfigRetired {
+		t.Errorf("Expected v1 retired, got %+v", old)
+	}
+}
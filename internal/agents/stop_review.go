@@ -0,0 +1,235 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// stopReviewCandles is the number of recent candles shown to the review model
+// stopReviewCandles 是提供给复查模型的最近 K 线数量
+const stopReviewCandles = 12
+
+// stopReviewPrompt limits the quick model to managing the exits of an open position
+// stopReviewPrompt 将快速模型限定为只管理已有持仓的离场价格
+const stopReviewPrompt = `你是持仓风控助手，在两次完整分析之间快速复查已有持仓的止损和止盈。
+你不能开仓、加仓、反手或平仓，只能：
+1. 收紧止损（多仓只能上移，空仓只能下移），用于锁定利润或应对走势转弱
+2. 调整下一个止盈目标价（必须位于当前价的盈利一侧）
+没有明确理由时保持不变。
+只输出 JSON：{"new_stop_loss": 数字或0, "new_take_profit": 数字或0, "reason": "中文理由"}，0 表示不调整。`
+
+// stopReview is the review model's verdict for one position
+// stopReview 复查模型对单个持仓的结论
+type stopReview struct {
+	NewStopLoss   float64 `json:"new_stop_loss"`   // 新止损价，0 表示不调整 / New stop price, 0 keeps it
+	NewTakeProfit float64 `json:"new_take_profit"` // 下一个止盈目标价，0 表示不调整 / Next take-profit target, 0 keeps it
+	Reason        string  `json:"reason"`          // 理由 / Reason
+}
+
+// StopReviewer runs the quick intraday LLM review that may only adjust stops and take-profits of open positions
+// StopReviewer 执行快速的盘中 LLM 复查，只能调整已有持仓的止损和止盈
+type StopReviewer struct {
+	config     *config.Config
+	logger     *logger.ColorLogger
+	stopLoss   *executors.StopLossManager
+	marketData *dataflows.MarketData
+}
+
+// NewStopReviewer creates a stop reviewer for the positions held by the stop-loss manager
+// NewStopReviewer 为止损管理器中的持仓创建止损复查器
+func NewStopReviewer(cfg *config.Config, log *logger.ColorLogger, sm *executors.StopLossManager) *StopReviewer {
+	return &StopReviewer{
+		config:     cfg,
+		logger:     log,
+		stopLoss:   sm,
+		marketData: dataflows.NewMarketData(cfg),
+	}
+}
+
+// Run reviews the open positions every interval until ctx is done
+// Run 每隔 interval 复查一次持仓，直到 ctx 结束
+func (r *StopReviewer) Run(ctx context.Context, interval time.Duration) {
+	r.logger.Success(fmt.Sprintf("🔍 启动快速止损复查，间隔: %s，模型: %s", interval, r.config.QuickThinkLLM))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.ReviewOnce(ctx)
+		}
+	}
+}
+
+// ReviewOnce reviews every open position once and returns the adjustments applied and the stop moves skipped as below the update threshold
+// ReviewOnce 对每个持仓复查一次，返回实际生效的调整次数和因低于更新阈值而跳过的止损调整次数
+func (r *StopReviewer) ReviewOnce(ctx context.Context) (applied, skipped int) {
+	positions := r.stopLoss.GetAllPositions()
+	if len(positions) == 0 {
+		return 0, 0
+	}
+
+	chatModel, _, err := newChatModel(ctx, r.config, r.logger, &openaiComponent.ChatModelConfig{
+		APIKey:  r.config.APIKey,
+		BaseURL: r.config.BackendURL,
+		Model:   r.config.QuickThinkLLM,
+		ResponseFormat: &openaiComponent.ChatCompletionResponseFormat{
+			Type: openaiComponent.ChatCompletionResponseFormatTypeJSONObject,
+		},
+	})
+	if err != nil {
+		r.logger.Warning(fmt.Sprintf("⚠️ 止损复查: 创建模型失败: %v", err))
+		return 0, 0
+	}
+
+	for _, pos := range positions {
+		review, err := r.reviewPosition(ctx, chatModel, pos)
+		if err != nil {
+			r.logger.Warning(fmt.Sprintf("⚠️ 止损复查:【%s】%v", pos.Symbol, err))
+			continue
+		}
+		a, s := r.applyReview(ctx, pos, review)
+		applied += a
+		skipped += s
+	}
+	if applied > 0 || skipped > 0 {
+		r.logger.Info(fmt.Sprintf("🔍 止损复查完成: 生效 %d 项，低于阈值跳过 %d 项", applied, skipped))
+	}
+	return applied, skipped
+}
+
+// reviewPosition asks the quick model for new exit prices of one position
+// reviewPosition 让快速模型为单个持仓给出新的离场价格
+func (r *StopReviewer) reviewPosition(ctx context.Context, chatModel *openaiComponent.ChatModel, pos *executors.Position) (stopReview, error) {
+	ohlcv, err := r.marketData.GetOHLCV(ctx, pos.Symbol, r.config.CryptoTimeframe, r.config.CryptoLookbackDays)
+	if err != nil {
+		return stopReview{}, fmt.Errorf("failed to get klines: %w", err)
+	}
+
+	response, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(stopReviewPrompt),
+		schema.UserMessage(formatStopReviewInput(pos, ohlcv, r.config.CryptoTimeframe)),
+	})
+	if err != nil {
+		return stopReview{}, fmt.Errorf("failed to call review model: %w", err)
+	}
+	return parseStopReview(response.Content)
+}
+
+// applyReview applies the review through the stop-loss manager, which only lets stops move in the favorable direction;
+// it returns the adjustments applied and the stop moves skipped as below the update threshold
+// applyReview 通过止损管理器应用复查结论，止损只能朝有利方向移动；返回生效的调整次数和低于更新阈值而跳过的止损调整次数
+func (r *StopReviewer) applyReview(ctx context.Context, pos *executors.Position, review stopReview) (applied, skipped int) {
+	reason := fmt.Sprintf("快速复查: %s", review.Reason)
+	if review.NewStopLoss > 0 {
+		// The stop is read under the manager lock, since the monitor loops move it concurrently
+		// 止损在管理器锁内读取，因为监控循环会并发移动它
+		current, significant, exists := r.stopLoss.ReviewStopLoss(pos.Symbol, review.NewStopLoss)
+		switch {
+		case !exists || review.NewStopLoss == current:
+		case !significant:
+			skipped++
+			r.logger.Info(fmt.Sprintf("🔍 止损复查:【%s】止损调整 %.4f → %.4f 低于更新阈值，跳过", pos.Symbol, current, review.NewStopLoss))
+		default:
+			if err := r.stopLoss.UpdateStopLoss(ctx, pos.Symbol, review.NewStopLoss, reason); err != nil {
+				r.logger.Warning(fmt.Sprintf("⚠️ 止损复查:【%s】止损未更新: %v", pos.Symbol, err))
+			} else {
+				applied++
+			}
+		}
+	}
+	if review.NewTakeProfit > 0 {
+		if err := r.stopLoss.UpdateTakeProfitTarget(ctx, pos.Symbol, review.NewTakeProfit, reason); err != nil {
+			r.logger.Warning(fmt.Sprintf("⚠️ 止损复查:【%s】止盈未更新: %v", pos.Symbol, err))
+		} else {
+			applied++
+		}
+	}
+
+	note := fmt.Sprintf("%s 止损 %.4f 止盈 %.4f: %s", time.Now().Format("01-02 15:04"), review.NewStopLoss, review.NewTakeProfit, review.Reason)
+	r.stopLoss.RecordLLMReview(pos.Symbol, note)
+	if review.NewStopLoss == 0 && review.NewTakeProfit == 0 {
+		r.logger.Info(fmt.Sprintf("🔍 止损复查:【%s】保持不变（%s）", pos.Symbol, review.Reason))
+	}
+	return applied, skipped
+}
+
+// formatStopReviewInput describes a position, its exits and the recent candles to the review model
+// formatStopReviewInput 向复查模型描述持仓、离场价格和最近的 K 线
+func formatStopReviewInput(pos *executors.Position, ohlcv []dataflows.OHLCV, timeframe string) string {
+	var sb strings.Builder
+	side := "多仓"
+	if pos.Side == "short" {
+		side = "空仓"
+	}
+	sb.WriteString(fmt.Sprintf("交易对: %s\n方向: %s\n入场价: %.4f\n当前止损: %.4f（初始止损 %.4f）\n",
+		pos.Symbol, side, pos.EntryPrice, pos.CurrentStopLoss, pos.InitialStopLoss))
+
+	if pos.TakeProfitConfig != nil && pos.TakeProfitConfig.Enabled {
+		for _, level := range pos.TakeProfitConfig.Levels {
+			if !level.Executed {
+				sb.WriteString(fmt.Sprintf("下一个止盈: 级别 %d，目标价 %.4f（平仓 %.0f%%）\n", level.Level, level.TargetPrice, level.Percentage*100))
+				break
+			}
+		}
+	} else {
+		sb.WriteString("止盈: 未启用分批止盈\n")
+	}
+
+	if len(ohlcv) == 0 {
+		return sb.String()
+	}
+	last := ohlcv[len(ohlcv)-1]
+	if pos.EntryPrice > 0 {
+		pnl := (last.Close - pos.EntryPrice) / pos.EntryPrice * 100
+		if pos.Side == "short" {
+			pnl = -pnl
+		}
+		sb.WriteString(fmt.Sprintf("当前价: %.4f（浮动盈亏 %+.2f%%）\n", last.Close, pnl))
+	}
+
+	indicators := dataflows.CalculateIndicators(ohlcv)
+	if n := len(indicators.ATR_14); n > 0 && !math.IsNaN(indicators.ATR_14[n-1]) {
+		sb.WriteString(fmt.Sprintf("ATR(14): %.4f\n", indicators.ATR_14[n-1]))
+	}
+	if n := len(indicators.RSI); n > 0 && !math.IsNaN(indicators.RSI[n-1]) {
+		sb.WriteString(fmt.Sprintf("RSI(14): %.1f\n", indicators.RSI[n-1]))
+	}
+
+	start := len(ohlcv) - stopReviewCandles
+	if start < 0 {
+		start = 0
+	}
+	sb.WriteString(fmt.Sprintf("\n最近 %d 根 %s K线（开/高/低/收）:\n", len(ohlcv)-start, timeframe))
+	for _, c := range ohlcv[start:] {
+		sb.WriteString(fmt.Sprintf("%s %.4f / %.4f / %.4f / %.4f\n", c.Timestamp.Format("01-02 15:04"), c.Open, c.High, c.Low, c.Close))
+	}
+	return sb.String()
+}
+
+// parseStopReview parses the review model's JSON verdict
+// parseStopReview 解析复查模型的 JSON 结论
+func parseStopReview(content string) (stopReview, error) {
+	var review stopReview
+	if err := json.Unmarshal([]byte(extractJSONPayload(content)), &review); err != nil {
+		return stopReview{}, fmt.Errorf("failed to parse stop review: %w", err)
+	}
+	if review.NewStopLoss < 0 || review.NewTakeProfit < 0 {
+		return stopReview{}, fmt.Errorf("negative price in stop review: stop %.4f, take-profit %.4f", review.NewStopLoss, review.NewTakeProfit)
+	}
+	return review, nil
+}
@@ -0,0 +1,52 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+func TestParseStopReview(t *testing.T) {
+	review, err := parseStopReview("```json\n{\"new_stop_loss\": 101.5, \"new_take_profit\": 0, \"reason\": \"锁定利润\"}\n```")
+	if err != nil || review.NewStopLoss != 101.5 || review.NewTakeProfit != 0 || review.Reason != "锁定利润" {
+		t.Errorf("Unexpected review %+v err=%v", review, err)
+	}
+	if _, err := parseStopReview(`{"new_stop_loss": -1}`); err == nil {
+		t.Error("Expected a negative stop to be rejected")
+	}
+	if _, err := parseStopReview("保持不变"); err == nil {
+		t.Error("Expected non-JSON output to be rejected")
+	}
+}
+
+func TestFormatStopReviewInput(t *testing.T) {
+	pos := &executors.Position{
+		Symbol:          "BTCUSDT",
+		Side:            "short",
+		EntryPrice:      100,
+		InitialStopLoss: 105,
+		CurrentStopLoss: 103,
+		TakeProfitConfig: &executors.TakeProfitConfig{
+			Enabled: true,
+			Levels: []*executors.TakeProfitLevel{
+				{Level: 1, TargetPrice: 95, Percentage: 0.3, Executed: true},
+				{Level: 2, TargetPrice: 90, Percentage: 0.3},
+			},
+		},
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ohlcv []dataflows.OHLCV
+	for i := 0; i < 20; i++ {
+		ohlcv = append(ohlcv, dataflows.OHLCV{Timestamp: start.Add(time.Duration(i) * time.Hour), Open: 99, High: 100, Low: 97, Close: 98})
+	}
+
+	input := formatStopReviewInput(pos, ohlcv, "1h")
+	for _, want := range []string{"空仓", "当前止损: 103.0000", "级别 2，目标价 90.0000", "浮动盈亏 +2.00%", "最近 12 根 1h K线"} {
+		if !strings.Contains(input, want) {
+			t.Errorf("Expected %q in review input:\n%s", want, input)
+		}
+	}
+}
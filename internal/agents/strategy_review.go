@@ -0,0 +1,348 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	openaiComponent "github.com/cloudwego/eino-ext/components/model/openai"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// strategyReviewMaxTrades bounds how many trades are listed one by one to the review model
+// strategyReviewMaxTrades 限制逐笔列给复盘模型的交易数量
+const strategyReviewMaxTrades = 60
+
+// strategyReviewPrompt asks the deep model for a structured review of the period
+// strategyReviewPrompt 要求深度模型对该区间给出结构化复盘
+const strategyReviewPrompt = `你是加密货币合约交易系统的策略复盘顾问。下面是自动交易机器人一个周期内的平仓交易、绩效统计、执行成本以及用户维护的交易规则。
+请找出真正由数据支撑的规律，不要泛泛而谈：
+1. what_worked: 做得好的地方（引用具体交易对、方向或数据）
+2. what_to_change: 需要改进的地方（同样引用数据）
+3. parameter_tweaks: 建议调整的配置参数，只建议"当前参数"中列出的参数，给出当前值、建议值和理由；数据不足时宁可不建议
+交易笔数很少时，请在 summary 中说明结论的可信度有限。
+只输出 JSON：{"summary": "一段话总结", "what_worked": ["..."], "what_to_change": ["..."], "parameter_tweaks": [{"parameter": "参数名", "current": "当前值", "proposed": "建议值", "reason": "理由"}]}`
+
+// reviewValue is a parameter value the model may write as a string or a bare number
+// reviewValue 是模型可能写成字符串或数字的参数值
+type reviewValue string
+
+func (v *reviewValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = reviewValue(s)
+		return nil
+	}
+	*v = reviewValue(strings.TrimSpace(string(data)))
+	return nil
+}
+
+// ParameterTweak is a configuration change proposed by the review
+// ParameterTweak 是复盘建议的配置调整
+type ParameterTweak struct {
+	Parameter string      `json:"parameter"` // 参数名（.env 键）/ Parameter (.env key)
+	Current   reviewValue `json:"current"`   // 当前值 / Current value
+	Proposed  reviewValue `json:"proposed"`  // 建议值 / Proposed value
+	Reason    string      `json:"reason"`    // 理由 / Reason
+}
+
+// StrategyReviewReport is the structured review the deep model writes for a period
+// StrategyReviewReport 是深度模型为某个区间撰写的结构化复盘
+type StrategyReviewReport struct {
+	Summary         string           `json:"summary"`          // 总结 / Summary
+	WhatWorked      []string         `json:"what_worked"`      // 做得好的地方 / What worked
+	WhatToChange    []string         `json:"what_to_change"`   // 需要改进的地方 / What to change
+	ParameterTweaks []ParameterTweak `json:"parameter_tweaks"` // 参数调整建议 / Proposed parameter tweaks
+}
+
+// Format renders the review as a notification message
+// Format 将复盘渲染为通知消息
+func (r *StrategyReviewReport) Format(start, end time.Time, trades int, netPnL float64) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📝 策略周复盘 %s ~ %s\n", start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02")))
+	sb.WriteString(fmt.Sprintf("平仓 %d 笔，净盈亏 %+.2f USDT\n", trades, netPnL))
+	if r.Summary != "" {
+		sb.WriteString("\n" + r.Summary + "\n")
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString("\n" + title + "\n")
+		for _, item := range items {
+			sb.WriteString("• " + item + "\n")
+		}
+	}
+	list("✅ 做得好的地方", r.WhatWorked)
+	list("⚠️ 需要改进", r.WhatToChange)
+	if len(r.ParameterTweaks) > 0 {
+		sb.WriteString("\n🔧 参数建议（需人工确认后修改 .env）\n")
+		for _, t := range r.ParameterTweaks {
+			sb.WriteString(fmt.Sprintf("• %s: %s → %s（%s）\n", t.Parameter, t.Current, t.Proposed, t.Reason))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// parseStrategyReview parses the review model's JSON answer
+// parseStrategyReview 解析复盘模型的 JSON 回答
+func parseStrategyReview(content string) (*StrategyReviewReport, error) {
+	var report StrategyReviewReport
+	if err := json.Unmarshal([]byte(extractJSONPayload(content)), &report); err != nil {
+		return nil, fmt.Errorf("failed to parse strategy review: %w", err)
+	}
+	if report.Summary == "" && len(report.WhatWorked) == 0 && len(report.WhatToChange) == 0 && len(report.ParameterTweaks) == 0 {
+		return nil, fmt.Errorf("strategy review is empty")
+	}
+	return &report, nil
+}
+
+// StrategyReviewer has the deep model review the trades of a period and stores the result
+// StrategyReviewer 让深度模型复盘某个区间的交易并保存结果
+//
+// The review only proposes parameter changes; nothing is applied automatically.
+// 复盘只给出参数建议，不会自动修改任何配置。
+type StrategyReviewer struct {
+	config  *config.Config
+	storage *storage.Storage
+	logger  *logger.ColorLogger
+}
+
+// NewStrategyReviewer creates a reviewer reading trades and analytics from db
+// NewStrategyReviewer 创建从 db 读取交易与分析数据的复盘器
+func NewStrategyReviewer(cfg *config.Config, db *storage.Storage, log *logger.ColorLogger) *StrategyReviewer {
+	return &StrategyReviewer{config: cfg, storage: db, logger: log}
+}
+
+// strategyReviewInput is everything the review model sees about a period
+// strategyReviewInput 是复盘模型看到的某个区间的全部数据
+type strategyReviewInput struct {
+	Start, End   time.Time
+	Trades       []*storage.PositionRecord
+	RMultiples   *portfolio.RMultipleDistribution
+	Costs        *portfolio.ExecutionCostReport
+	StopSlippage *portfolio.StopSlippageReport
+	Lessons      []*storage.TradingLesson
+	NetPnL       float64
+	Parameters   [][2]string // 当前参数（键、值）/ Current parameters as key and value
+}
+
+// Review reviews the trades closed in [start, end), stores the review and returns it
+// Review 复盘 [start, end) 内平仓的交易，保存复盘并返回
+func (r *StrategyReviewer) Review(ctx context.Context, start, end time.Time) (*storage.StrategyReview, *StrategyReviewReport, error) {
+	in, err := r.gather(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chatModel, model, err := newChatModel(ctx, r.config, r.logger, &openaiComponent.ChatModelConfig{
+		APIKey:  r.config.APIKey,
+		BaseURL: r.config.BackendURL,
+		Model:   r.config.DeepThinkLLM,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create review model: %w", err)
+	}
+	r.logger.Info(fmt.Sprintf("📝 正在由 %s 生成策略复盘（%d 笔交易）", model, len(in.Trades)))
+
+	response, err := chatModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage(strategyReviewPrompt),
+		schema.UserMessage(formatStrategyReviewInput(in)),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to call review model: %w", err)
+	}
+	report, err := parseStrategyReview(response.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode strategy review: %w", err)
+	}
+	review := &storage.StrategyReview{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Model:       model,
+		Trades:      len(in.Trades),
+		NetPnL:      in.NetPnL,
+		Report:      string(reportJSON),
+		CreatedAt:   time.Now(),
+	}
+	if review.ID, err = r.storage.SaveStrategyReview(review); err != nil {
+		return nil, nil, err
+	}
+	return review, report, nil
+}
+
+// gather loads the trades, analytics and lessons of [start, end)
+// gather 读取 [start, end) 的交易、分析数据和交易规则
+// Analytics that fail to load are left out rather than failing the review
+// 分析数据读取失败时不纳入复盘，而不是让复盘失败
+func (r *StrategyReviewer) gather(start, end time.Time) (strategyReviewInput, error) {
+	in := strategyReviewInput{Start: start, End: end, Parameters: strategyReviewParameters(r.config)}
+
+	closed, err := r.storage.GetClosedPositions(start)
+	if err != nil {
+		return in, fmt.Errorf("failed to load closed positions: %w", err)
+	}
+	for _, p := range closed {
+		if p.CloseTime != nil && p.CloseTime.Before(end) {
+			in.Trades = append(in.Trades, p)
+			in.NetPnL += p.NetPnL()
+		}
+	}
+	in.RMultiples = portfolio.CalculateRMultiples(in.Trades, 0)
+
+	if costs, err := r.storage.GetExecutionCosts(start); err != nil {
+		r.logger.Warning(fmt.Sprintf("⚠️  策略复盘读取执行成本失败: %v", err))
+	} else {
+		in.Costs = portfolio.CalculateExecutionCosts(costs)
+	}
+	if slips, err := r.storage.GetStopSlippages(start); err != nil {
+		r.logger.Warning(fmt.Sprintf("⚠️  策略复盘读取止损滑点失败: %v", err))
+	} else {
+		in.StopSlippage = portfolio.CalculateStopSlippage(slips)
+	}
+	if in.Lessons, err = r.storage.GetLessons(true); err != nil {
+		r.logger.Warning(fmt.Sprintf("⚠️  策略复盘读取交易规则失败: %v", err))
+	}
+	return in, nil
+}
+
+// strategyReviewParameters lists the settings the review may propose to tweak, as .env keys and current values
+// strategyReviewParameters 列出复盘可以建议调整的配置，以 .env 键和当前值表示
+func strategyReviewParameters(cfg *config.Config) [][2]string {
+	leverage := fmt.Sprintf("%d", cfg.BinanceLeverageMin)
+	if cfg.BinanceLeverageDynamic {
+		leverage = fmt.Sprintf("%d-%d", cfg.BinanceLeverageMin, cfg.BinanceLeverageMax)
+	}
+	return [][2]string{
+		{"CRYPTO_SYMBOLS", strings.Join(cfg.CryptoSymbols, ",")},
+		{"CRYPTO_TIMEFRAME", cfg.CryptoTimeframe},
+		{"BINANCE_LEVERAGE", leverage},
+		{"POSITION_SIZING_MODE", cfg.PositionSizingMode},
+		{"EXPOSURE_MAX_TOTAL_PERCENT", fmt.Sprintf("%g", cfg.ExposureMaxTotalPercent)},
+		{"EXPOSURE_MAX_SYMBOL_PERCENT", fmt.Sprintf("%g", cfg.ExposureMaxSymbolPercent)},
+		{"TRAILING_STOP_MODE", cfg.TrailingStopMode},
+		{"TRAILING_STOP_FORMULA", cfg.TrailingStopFormula},
+		{"STOP_MONITORING_MODE", cfg.StopMonitoringMode},
+		{"RISK_GATE_ENABLED", fmt.Sprintf("%t", cfg.RiskGateEnabled)},
+		{"RISK_GATE_COOLDOWN_MINUTES", fmt.Sprintf("%d", cfg.RiskGateCooldownMinutes)},
+		{"RISK_GATE_MIN_LIQUIDATION_DISTANCE_PERCENT", fmt.Sprintf("%g", cfg.RiskGateMinLiquidationDistancePercent)},
+	}
+}
+
+// formatStrategyReviewInput describes the period's trades, analytics, lessons and parameters to the review model
+// formatStrategyReviewInput 向复盘模型描述区间内的交易、分析数据、交易规则和当前参数
+func formatStrategyReviewInput(in strategyReviewInput) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("复盘区间: %s ~ %s\n", in.Start.Format("2006-01-02 15:04"), in.End.Format("2006-01-02 15:04")))
+	sb.WriteString(fmt.Sprintf("平仓交易: %d 笔，净盈亏 %+.2f USDT（已扣手续费、计入资金费）\n", len(in.Trades), in.NetPnL))
+
+	// Per-symbol and per-side results
+	// 按交易对和方向的结果
+	type group struct {
+		trades, wins int
+		pnl          float64
+	}
+	groups := make(map[string]*group)
+	for _, p := range in.Trades {
+		key := p.Symbol + " " + p.Side
+		g, ok := groups[key]
+		if !ok {
+			g = &group{}
+			groups[key] = g
+		}
+		g.trades++
+		g.pnl += p.NetPnL()
+		if p.NetPnL() > 0 {
+			g.wins++
+		}
+	}
+	if len(groups) > 0 {
+		keys := make([]string, 0, len(groups))
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		sb.WriteString("\n## 按交易对与方向\n")
+		for _, key := range keys {
+			g := groups[key]
+			sb.WriteString(fmt.Sprintf("- %s: %d 笔，胜 %d，净盈亏 %+.2f USDT\n", key, g.trades, g.wins, g.pnl))
+		}
+	}
+
+	if d := in.RMultiples; d != nil && d.Trades > 0 {
+		sb.WriteString("\n## R 倍数\n")
+		sb.WriteString(fmt.Sprintf("- 期望 %.2fR，中位数 %.2fR，胜率 %.0f%%，平均盈利 %.2fR，平均亏损 %.2fR，SQN %.2f（%d 笔有初始止损）\n",
+			d.Expectancy, d.MedianR, d.WinRate*100, d.AvgWinR, d.AvgLossR, d.SQN, d.Trades))
+	}
+	if c := in.Costs; c != nil && c.Total.Orders > 0 {
+		sb.WriteString("\n## 执行成本\n")
+		sb.WriteString(fmt.Sprintf("- %d 笔订单，平均滑点 %.1f bps，平均手续费 %.1f bps，总成本 %.2f USDT\n",
+			c.Total.Orders, c.Total.AvgSlippageBps, c.Total.AvgFeeBps, c.Total.TotalCost))
+	}
+	if s := in.StopSlippage; s != nil && s.Total.Stops > 0 {
+		sb.WriteString("\n## 止损滑点\n")
+		sb.WriteString(fmt.Sprintf("- %d 次止损，平均滑点 %.1f bps，平均止损距离 %.2f%%，滑点占止损距离 %.1f%%\n",
+			s.Total.Stops, s.Total.AvgSlippageBps, s.Total.AvgStopDistancePercent, s.SlippageToStopPct))
+	}
+
+	if len(in.Trades) > 0 {
+		sb.WriteString("\n## 逐笔交易（开仓理由 / 平仓原因）\n")
+		trades := in.Trades
+		if len(trades) > strategyReviewMaxTrades {
+			sb.WriteString(fmt.Sprintf("（仅列出最近 %d 笔）\n", strategyReviewMaxTrades))
+			trades = trades[len(trades)-strategyReviewMaxTrades:]
+		}
+		for _, p := range trades {
+			r := "-"
+			if value, _, ok := portfolio.TradeRMultiple(p); ok {
+				r = fmt.Sprintf("%+.2fR", value)
+			}
+			sb.WriteString(fmt.Sprintf("- %s %s %s %dx 入场 %.4f 平仓 %.4f 净盈亏 %+.2f (%s) | %s / %s\n",
+				p.CloseTime.Format("01-02 15:04"), p.Symbol, p.Side, p.Leverage, p.EntryPrice, p.ClosePrice, p.NetPnL(), r,
+				truncateReviewText(p.OpenReason, 120), truncateReviewText(p.CloseReason, 60)))
+		}
+	}
+
+	if len(in.Lessons) > 0 {
+		sb.WriteString("\n## 用户交易规则\n")
+		for _, l := range in.Lessons {
+			scope := "全部交易对"
+			if l.Symbol != "" {
+				scope = l.Symbol
+			}
+			sb.WriteString(fmt.Sprintf("- [%s] %s\n", scope, strings.TrimSpace(l.Lesson)))
+		}
+	}
+
+	sb.WriteString("\n## 当前参数\n")
+	for _, p := range in.Parameters {
+		sb.WriteString(fmt.Sprintf("- %s=%s\n", p[0], p[1]))
+	}
+	return sb.String()
+}
+
+// truncateReviewText flattens text to one line of at most n runes
+// truncateReviewText 将文本压成一行，最多 n 个字符
+func truncateReviewText(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	if text == "" {
+		return "-"
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/portfolio"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestParseStrategyReview(t *testing.T) {
+	content := "```json\n" + `{
+		"summary": "本周趋势行情下多单表现较好",
+		"what_worked": ["BTCUSDT 多单 3 胜 0 负"],
+		"what_to_change": ["SOLUSDT 空单止损过紧"],
+		"parameter_tweaks": [{"parameter": "RISK_GATE_COOLDOWN_MINUTES", "current": 60, "proposed": "120", "reason": "止损后立即再入场亏损"}]
+	}` + "\n```"
+
+	report, err := parseStrategyReview(content)
+	if err != nil {
+		t.Fatalf("parseStrategyReview failed: %v", err)
+	}
+	if len(report.WhatWorked) != 1 || len(report.WhatToChange) != 1 || len(report.ParameterTweaks) != 1 {
+		t.Fatalf("Unexpected review: %+v", report)
+	}
+	// A bare number is accepted as a parameter value
+	// 参数值可以是不带引号的数字
+	if tweak := report.ParameterTweaks[0]; tweak.Current != "60" || tweak.Proposed != "120" {
+		t.Errorf("Expected 60 → 120, got %s → %s", tweak.Current, tweak.Proposed)
+	}
+
+	if _, err := parseStrategyReview(`{}`); err == nil {
+		t.Error("Expected an empty review to fail")
+	}
+	if _, err := parseStrategyReview("not json"); err == nil {
+		t.Error("Expected invalid JSON to fail")
+	}
+}
+
+func TestFormatStrategyReviewInput(t *testing.T) {
+	start := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	closeAt := start.Add(30 * time.Hour)
+	trades := []*storage.PositionRecord{
+		{Symbol: "BTCUSDT", Side: "long", Leverage: 5, EntryPrice: 100, Quantity: 1, InitialStopLoss: 98, ClosePrice: 104, CloseTime: &closeAt,
+			RealizedPnL: 4, OpenReason: "突破前高\n成交量放大", CloseReason: "止盈"},
+		{Symbol: "BTCUSDT", Side: "long", Leverage: 5, EntryPrice: 100, Quantity: 1, InitialStopLoss: 98, ClosePrice: 98, CloseTime: &closeAt,
+			RealizedPnL: -2, CloseReason: "止损"},
+	}
+	in := strategyReviewInput{
+		Start:      start,
+		End:        start.AddDate(0, 0, 7),
+		Trades:     trades,
+		RMultiples: portfolio.CalculateRMultiples(trades, 0),
+		Lessons:    []*storage.TradingLesson{{Symbol: "SOLUSDT", Lesson: "美盘开盘不开仓", Enabled: true}},
+		NetPnL:     2,
+		Parameters: [][2]string{{"TRAILING_STOP_FORMULA", "atr"}},
+	}
+
+	text := formatStrategyReviewInput(in)
+	for _, want := range []string{
+		"平仓交易: 2 笔，净盈亏 +2.00 USDT",
+		"BTCUSDT long: 2 笔，胜 1，净盈亏 +2.00 USDT",
+		"突破前高 成交量放大 / 止盈",
+		"+2.00R",
+		"[SOLUSDT] 美盘开盘不开仓",
+		"TRAILING_STOP_FORMULA=atr",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected review input to contain %q, got:\n%s", want, text)
+		}
+	}
+}
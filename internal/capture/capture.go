@@ -0,0 +1,218 @@
+// Package capture archives the intermediate artifacts of a few analysis cycles for selected symbols
+// Package capture 为选定交易对归档若干个分析周期的中间产物
+//
+// A symbol armed for N cycles has its raw API payloads, prompts and node outputs written under
+// RESULTS_DIR/debug_capture/<SYMBOL>/<cycle>/, after which capture turns itself off. This replaces
+// turning on DEBUG_MODE for the whole process when reporting an issue with one symbol.
+// 为交易对开启 N 个周期的采集后，其原始 API 响应、提示词和节点输出会写入
+// RESULTS_DIR/debug_capture/<交易对>/<周期>/，N 个周期后自动关闭。
+// 用于替代为排查单个交易对的问题而对整个进程开启 DEBUG_MODE。
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// unsafeNameChars are replaced in artifact file names
+// unsafeNameChars 是产物文件名中需要替换的字符
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Recorder writes artifacts of armed symbols into per-cycle directories
+// Recorder 将已开启采集的交易对的产物写入按周期划分的目录
+type Recorder struct {
+	dir          string // 采集根目录 / Capture root directory
+	maxCycleSize int64  // 每个交易对每个周期的字节上限，0 表示不限 / Byte budget per symbol per cycle, 0 is unlimited
+
+	mu        sync.Mutex
+	remaining map[string]int    // 交易对 → 剩余周期数 / Symbol → cycles left
+	current   map[string]string // 交易对 → 本周期目录 / Symbol → directory of the running cycle
+	written   map[string]int64  // 交易对 → 本周期已写字节数 / Symbol → bytes written this cycle
+	sequence  int               // 文件序号，保持写入顺序 / File sequence, keeps the write order
+}
+
+// NewRecorder creates a recorder writing under dir with a per-cycle byte budget for each symbol
+// NewRecorder 创建写入 dir 的采集器，每个交易对每个周期有字节上限
+func NewRecorder(dir string, maxCycleSize int64) *Recorder {
+	return &Recorder{
+		dir:          dir,
+		maxCycleSize: maxCycleSize,
+		remaining:    make(map[string]int),
+		current:      make(map[string]string),
+		written:      make(map[string]int64),
+	}
+}
+
+var (
+	shared     *Recorder
+	sharedOnce sync.Once
+)
+
+// Shared returns the process-wide recorder, arming DEBUG_CAPTURE_SYMBOLS on first use
+// Shared 返回进程级共享的采集器，首次使用时为 DEBUG_CAPTURE_SYMBOLS 开启采集
+func Shared(cfg *config.Config) *Recorder {
+	sharedOnce.Do(func() {
+		shared = NewRecorder(filepath.Join(cfg.ResultsDir, "debug_capture"), int64(cfg.DebugCaptureMaxMB)*1024*1024)
+		for _, symbol := range cfg.DebugCaptureSymbols {
+			shared.Arm(symbol, cfg.DebugCaptureCycles)
+		}
+	})
+	return shared
+}
+
+// normalize converts BTC/USDT and btcusdt to BTCUSDT
+// normalize 将 BTC/USDT 和 btcusdt 统一为 BTCUSDT
+func normalize(symbol string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(symbol), "/", ""))
+}
+
+// Arm captures the next cycles of a symbol; cycles <= 0 disarms it
+// Arm 为交易对开启接下来 cycles 个周期的采集；cycles <= 0 表示关闭
+func (r *Recorder) Arm(symbol string, cycles int) {
+	if r == nil {
+		return
+	}
+	symbol = normalize(symbol)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cycles <= 0 {
+		delete(r.remaining, symbol)
+		delete(r.current, symbol)
+		return
+	}
+	r.remaining[symbol] = cycles
+}
+
+// Armed returns the armed symbols and their remaining cycles
+// Armed 返回已开启采集的交易对及其剩余周期数
+func (r *Recorder) Armed() map[string]int {
+	armed := make(map[string]int)
+	if r == nil {
+		return armed
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for symbol, cycles := range r.remaining {
+		armed[symbol] = cycles
+	}
+	return armed
+}
+
+// Active reports whether a symbol is being captured in the running cycle
+// Active 判断交易对在当前周期是否正在采集
+func (r *Recorder) Active(symbol string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.current[normalize(symbol)]
+	return ok
+}
+
+// BeginCycle opens a capture directory for every armed symbol
+// BeginCycle 为每个已开启采集的交易对创建本周期的采集目录
+func (r *Recorder) BeginCycle(cycle time.Time) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for symbol := range r.remaining {
+		r.current[symbol] = filepath.Join(r.dir, symbol, cycle.Format("20060102-150405"))
+		r.written[symbol] = 0
+	}
+}
+
+// EndCycle closes the running cycle and returns the symbols whose capture just ran out
+// EndCycle 结束当前周期，返回采集次数刚好用完的交易对
+func (r *Recorder) EndCycle() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var finished []string
+	for symbol := range r.current {
+		if r.remaining[symbol]--; r.remaining[symbol] <= 0 {
+			delete(r.remaining, symbol)
+			finished = append(finished, symbol)
+		}
+	}
+	r.current = make(map[string]string)
+	sort.Strings(finished)
+	return finished
+}
+
+// Record writes one artifact of a symbol when it is being captured
+// Record 在交易对正在采集时写入一个产物
+//
+// Artifacts past the cycle's byte budget are dropped, and the first one dropped leaves a marker file.
+// 超出本周期字节上限的产物会被丢弃，第一个被丢弃的产物会留下标记文件。
+func (r *Recorder) Record(symbol, name string, data []byte) error {
+	if r == nil {
+		return nil
+	}
+	symbol = normalize(symbol)
+	r.mu.Lock()
+	dir, ok := r.current[symbol]
+	if !ok {
+		r.mu.Unlock()
+		return nil
+	}
+	if r.maxCycleSize > 0 && r.written[symbol]+int64(len(data)) > r.maxCycleSize {
+		first := r.written[symbol] <= r.maxCycleSize
+		r.written[symbol] = r.maxCycleSize + 1
+		r.mu.Unlock()
+		if first {
+			return writeArtifact(dir, "TRUNCATED.txt", []byte(fmt.Sprintf("capture budget of %d bytes reached at %s\n", r.maxCycleSize, name)))
+		}
+		return nil
+	}
+	r.written[symbol] += int64(len(data))
+	r.sequence++
+	fileName := fmt.Sprintf("%04d_%s", r.sequence, unsafeNameChars.ReplaceAllString(name, "_"))
+	r.mu.Unlock()
+
+	return writeArtifact(dir, fileName, data)
+}
+
+// RecordText writes a text artifact
+// RecordText 写入文本产物
+func (r *Recorder) RecordText(symbol, name, text string) error {
+	return r.Record(symbol, name+".txt", []byte(text))
+}
+
+// RecordJSON writes a value as an indented JSON artifact
+// RecordJSON 将值写为缩进格式的 JSON 产物
+func (r *Recorder) RecordJSON(symbol, name string, v interface{}) error {
+	if !r.Active(symbol) {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode capture %s: %w", name, err)
+	}
+	return r.Record(symbol, name+".json", data)
+}
+
+// writeArtifact creates the cycle directory on demand and writes one file into it
+// writeArtifact 按需创建周期目录并写入一个文件
+func writeArtifact(dir, name string, data []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create capture directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write capture %s: %w", name, err)
+	}
+	return nil
+}
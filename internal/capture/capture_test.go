@@ -0,0 +1,99 @@
+package capture
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderCapturesArmedCyclesThenDisables(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, 0)
+	r.Arm("BTC/USDT", 2)
+
+	cycle := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		r.BeginCycle(cycle.Add(time.Duration(i) * time.Hour))
+		if !r.Active("BTCUSDT") || r.Active("ETH/USDT") {
+			t.Fatalf("Expected only BTCUSDT active in cycle %d", i)
+		}
+		if err := r.RecordText("BTC/USDT", "market_report", "report"); err != nil {
+			t.Fatal(err)
+		}
+		r.RecordText("ETH/USDT", "market_report", "ignored")
+		finished := r.EndCycle()
+		if i == 0 && len(finished) != 0 {
+			t.Fatalf("Expected capture to continue after the first cycle, got %v", finished)
+		}
+		if i == 1 && (len(finished) != 1 || finished[0] != "BTCUSDT") {
+			t.Fatalf("Expected BTCUSDT to finish after the second cycle, got %v", finished)
+		}
+	}
+
+	if len(r.Armed()) != 0 {
+		t.Errorf("Expected capture disabled, still armed: %v", r.Armed())
+	}
+	cycles, _ := os.ReadDir(filepath.Join(dir, "BTCUSDT"))
+	if len(cycles) != 2 {
+		t.Errorf("Expected 2 cycle directories, got %d", len(cycles))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ETHUSDT")); !os.IsNotExist(err) {
+		t.Error("Expected nothing captured for an unarmed symbol")
+	}
+}
+
+func TestRecorderBudgetDropsExcess(t *testing.T) {
+	dir := t.TempDir()
+	r := NewRecorder(dir, 10)
+	r.Arm("BTCUSDT", 1)
+	r.BeginCycle(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+
+	r.Record("BTCUSDT", "a.txt", []byte("12345678"))
+	r.Record("BTCUSDT", "b.txt", []byte("12345678"))
+	r.Record("BTCUSDT", "c.txt", []byte("1"))
+
+	files, _ := os.ReadDir(filepath.Join(dir, "BTCUSDT", "20250310-080000"))
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if len(names) != 2 || names[0] != "0001_a.txt" || names[1] != "TRUNCATED.txt" {
+		t.Errorf("Expected the first artifact and a truncation marker, got %v", names)
+	}
+}
+
+func TestWithCaptureRecordsRawPayload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`[[1,"100"]]`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	r := NewRecorder(dir, 0)
+	r.Arm("BTCUSDT", 1)
+	r.BeginCycle(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	client := WithCapture(r, server.Client())
+
+	resp, err := client.Get(server.URL + "/fapi/v1/klines?symbol=BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `[[1,"100"]]` {
+		t.Fatalf("Expected the response body to reach the caller, got %q", body)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "BTCUSDT", "20250310-080000", "0001_api_fapi_v1_klines.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `[[1,"100"]]`) {
+		t.Errorf("Expected the raw payload archived, got %s", data)
+	}
+}
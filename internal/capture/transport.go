@@ -0,0 +1,102 @@
+package capture
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// capturingTransport archives the request and raw response of Binance calls for symbols being captured
+// capturingTransport 为正在采集的交易对归档币安调用的请求与原始响应
+type capturingTransport struct {
+	base     http.RoundTripper
+	recorder *Recorder
+}
+
+// WithCapture wraps a Binance HTTP client's transport so calls carrying a captured symbol are archived
+// WithCapture 包装币安 HTTP 客户端的传输层，使携带正在采集交易对的调用被归档
+func WithCapture(recorder *Recorder, client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, wrapped := base.(*capturingTransport); wrapped {
+		return client
+	}
+
+	captured := *client
+	captured.Transport = &capturingTransport{base: base, recorder: recorder}
+	return &captured
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *capturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	symbol, body := requestSymbol(req)
+	if symbol == "" || !t.recorder.Active(symbol) {
+		return t.base.RoundTrip(req)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	// Signatures are kept, the API key header is not
+	// 保留签名参数，但不记录 API Key 请求头
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", req.Method, req.URL.Path)
+	fmt.Fprintf(&b, "query: %s\n", req.URL.RawQuery)
+	if len(body) > 0 {
+		fmt.Fprintf(&b, "body: %s\n", body)
+	}
+	if err != nil {
+		fmt.Fprintf(&b, "\nerror: %v\n", err)
+		t.recorder.Record(symbol, "api_"+endpointName(req.URL.Path)+".txt", []byte(b.String()))
+		return resp, err
+	}
+
+	payload, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(payload))
+	fmt.Fprintf(&b, "\nstatus: %s\n\n", resp.Status)
+	b.Write(payload)
+	if readErr != nil {
+		fmt.Fprintf(&b, "\nread error: %v\n", readErr)
+	}
+	t.recorder.Record(symbol, "api_"+endpointName(req.URL.Path)+".txt", []byte(b.String()))
+	return resp, nil
+}
+
+// requestSymbol returns the symbol parameter of a request and its form body, read through GetBody
+// requestSymbol 返回请求的 symbol 参数及表单请求体，通过 GetBody 读取以免消耗原请求体
+func requestSymbol(req *http.Request) (string, []byte) {
+	if symbol := req.URL.Query().Get("symbol"); symbol != "" {
+		return symbol, nil
+	}
+	if req.GetBody == nil {
+		return "", nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", nil
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", nil
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return "", data
+	}
+	return form.Get("symbol"), data
+}
+
+// endpointName turns /fapi/v1/klines into fapi_v1_klines
+// endpointName 将 /fapi/v1/klines 转为 fapi_v1_klines
+func endpointName(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+}
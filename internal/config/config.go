@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/viper"
 	"os"
 	"strings"
+	"time"
+	_ "time/tzdata" // 内置时区数据，容器内无 zoneinfo 时也能解析 TIMEZONE / Embedded zone data so TIMEZONE resolves without system zoneinfo
 )
 
 // Config holds all configuration for the crypto trading bot
@@ -25,12 +27,39 @@ type Config struct {
 	BackendURL       string
 	APIKey           string
 	TraderPromptPath string // 交易策略 Prompt 文件路径 / Path to trader strategy prompt file
+	DecisionLanguage string // 决策理由输出语言（JSON 结构不变）/ Language of decision reasoning (JSON schema unchanged)
+
+	// LLM quota management
+	// LLM 配额管理
+	LLMFallbackModel            string  // 配额不足时切换的备用模型，留空不切换 / Model switched to when quota runs low, empty never switches
+	LLMQuotaMinRemainingPercent float64 // 剩余请求或 token 低于上限的该比例时限流或切换（%），0 表示只统计 / Throttle or switch below this share of the request or token limit (%), 0 only tracks
+	LLMQuotaMaxWaitSeconds      int     // 配额即将重置时最多等待的秒数 / Longest wait for a quota reset before switching models
 
 	// Agent behavior
 	MaxDebateRounds      int
 	MaxRiskDiscussRounds int
 	MaxRecurLimit        int
 
+	// Decision guardrail
+	// 决策护栏
+	GuardrailEnabled        bool    // 执行前校验交易决策的内部一致性 / Check the trader's decision for internal consistency before execution
+	GuardrailLLM            string  // 复核用的廉价模型，留空只做规则校验 / Cheap model for the review pass, empty runs the rule checks only
+	GuardrailMaxRetries     int     // 不一致时要求交易员重新生成的次数 / Times the trader is asked to regenerate an inconsistent decision
+	GuardrailMaxRiskPercent float64 // 止损触发时单笔最大亏损占余额的比例（%）/ Max loss of one trade at its stop, as % of balance
+
+	DecisionConsistencyWindow int // 提示词中展示的每个交易对的历史决策数，0 表示不启用 / Past decisions per symbol shown in the prompt, 0 disables the consistency check
+
+	ShadowCycles int // 交易员模型或提示词变更后影子运行的轮数，0 表示立即生效 / Cycles a changed trader model or prompt runs in shadow before it can go live, 0 applies changes at once
+
+	// Deterministic risk gate
+	// 确定性风控闸门
+	RiskGateEnabled                       bool    // 执行前用硬性规则复核每个开仓决策 / Check every entry decision against hard rules before execution
+	RiskGateMinLiquidationDistancePercent float64 // 估算强平价距入场价的最小距离（%）/ Minimum estimated distance from entry to liquidation (%)
+	RiskGateCooldownMinutes               int     // 同一交易对平仓后再次开仓的最短间隔（分钟）/ Minimum minutes between a close and the next entry on a symbol
+
+	StopReviewEnabled  bool   // 在交易周期之间用快速模型复查持仓止损/止盈 / Review open stops/TPs with the quick model between trading cycles
+	StopReviewInterval string // 止损复查间隔 / Interval of the stop review
+
 	// Data vendors
 	DataVendorStock      string
 	DataVendorIndicators string
@@ -41,6 +70,9 @@ type Config struct {
 	// 币安交易配置
 	BinanceAPIKey               string
 	BinanceAPISecret            string
+	BinanceAPIKeyBackup         string // 备用 API 密钥，主密钥被拒绝时自动切换（空表示不启用）/ Backup API key switched to when the primary is rejected (empty disables)
+	BinanceAPISecretBackup      string // 备用 API 密钥的 Secret / Secret of the backup API key
+	BinanceKeyCheckStrict       bool   // 启动检查时将提现权限和未限制 IP 视为错误 / Treat withdrawal permission and no IP restriction as errors in the startup key check
 	BinanceProxy                string
 	BinanceProxyInsecureSkipTLS bool // 是否跳过代理 TLS 验证（某些代理需要）/ Skip TLS verification for proxy (required by some proxies)
 	BinanceLeverage             int  // 固定杠杆（向后兼容）/ Fixed leverage (backward compatible)
@@ -49,6 +81,49 @@ type Config struct {
 	BinanceLeverageDynamic      bool // 是否启用动态杠杆 / Enable dynamic leverage
 	BinanceTestMode             bool
 	BinancePositionMode         string
+	HedgeOnReversal             bool              // 反转信号时保留原持仓并开反向对冲（需双向持仓模式）/ Keep the position and open an opposite hedge on reversal signals (hedge mode only)
+	BinanceWsProxy              string            // WebSocket 代理，为空时同 BINANCE_PROXY，direct 表示不走代理 / WebSocket proxy, BINANCE_PROXY when empty, "direct" bypasses it
+	PaperTrading                bool              // 模拟盘：按实盘价格模拟成交，不向交易所下单；独立于连接测试网的 BINANCE_TEST_MODE / Paper trading: simulate fills at live prices without sending orders; separate from BINANCE_TEST_MODE, which selects the testnet
+	PaperInitialBalance         float64           // 模拟盘初始 USDT 余额 / Initial virtual USDT balance for paper trading
+	PaperFeeRate                float64           // 模拟盘手续费率（吃单）/ Taker fee rate applied to simulated fills
+	BinanceMarginType           string            // 默认保证金类型：cross/isolated，留空保持交易所当前设置 / Default margin type: cross/isolated, empty keeps the exchange setting
+	BinanceMarginTypes          map[string]string // 按交易对覆盖的保证金类型（键为币安格式）/ Per-symbol margin type overrides keyed by Binance symbol
+	BinanceMaxWeightPerMinute   int    // 每分钟请求权重上限（所有币安调用共享），0 表示不限流 / Request weight budget per minute shared by all Binance calls, 0 disables
+	ExecutorSlowCallMs          int               // 交易所调用耗时超过该值（毫秒）记录警告，0 表示不告警 / Exchange calls slower than this (ms) log a warning, 0 disables
+	ExecutorErrorRateAlert      float64           // 端点近期错误率达到该值时告警（0.2 = 20%），0 表示不告警 / Alert when an endpoint's recent error rate reaches this (0.2 = 20%), 0 disables
+
+	// Market regime
+	// 市场状态
+	RegimeHighPercentile float64        // 波动率百分位达到该值为高波动 / Volatility percentile at which the regime is high volatility
+	RegimeLowPercentile  float64        // 波动率百分位不高于该值为低波动 / Volatility percentile at or below which the regime is low volatility
+	RegimeTrendADX       float64        // ADX 达到该值为趋势 / ADX at which the regime is trending
+	LeverageRegimeCaps   map[string]int // 按市场状态的杠杆上限（键为状态名）/ Leverage ceilings keyed by market regime
+
+	// Order execution
+	// 下单执行配置
+	OrderExecutionMode       string  // 开仓下单方式：market/limit / Entry order type: market or limit
+	LimitOrderOffsetPercent  float64 // 限价单相对标记价格的偏移（%）/ Limit price offset from mark price (%)
+	LimitOrderTimeoutSeconds int     // 限价单等待成交超时（秒）/ Limit order fill timeout (seconds)
+	LimitOrderFallback       string  // 限价单超时后的处理：market/chase/cancel / Action after limit order timeout: market, chase or cancel
+	PartialFillPolicy        string  // 市价单部分成交后剩余数量的处理：market/chase/cancel / Remainder handling after a partial market fill: market, chase or cancel
+	PartialFillChaseAttempts int     // 追单最多重新挂单次数 / Maximum re-posts when chasing a remainder
+	IcebergThresholdNotional float64 // 超过该名义价值（USDT）的开仓拆分为冰山单，0 表示禁用 / Entries above this notional (USDT) are split into iceberg slices, 0 disables
+	IcebergSliceNotional     float64 // 冰山单每个可见分片的名义价值（USDT）/ Notional (USDT) of each visible iceberg slice
+	IcebergSliceDelaySeconds int     // 冰山单分片之间的间隔（秒）/ Delay between iceberg slices (seconds)
+	BracketEntryEnabled      bool    // 开仓成交后同时下达止损单和第一级止盈单 / Place the stop-loss and first take-profit orders together right after an entry fills
+	MaxSlippageBps           float64 // 市价单允许的最大预计滑点（基点），0 表示不检查 / Maximum expected slippage (bps) for market orders, 0 disables the check
+	SlippageGuardAction      string  // 预计滑点超限时的处理：abort/limit / Action when expected slippage is too high: abort or limit
+	OrderValidationOnly      bool    // 仅按交易所过滤规则校验订单，不实际下单 / Only validate orders against exchange filters, never send them
+	UserDataStreamEnabled    bool    // 订阅用户数据流，实时接收成交和持仓变化 / Subscribe to the user data stream for real-time fills and position changes
+	PriceStreamEnabled       bool    // 订阅成交价格流并共享价格缓存 / Subscribe to the trade price stream and share a price cache
+	KlineStreamEnabled       bool    // 订阅 K 线流并在内存中维护滚动 K 线 / Subscribe to the kline stream and keep rolling candles in memory
+
+	// DCA entry ladder, a single level disables it
+	// DCA 分批挂单开仓，层数为 1 表示禁用
+	EntryLadderLevels         int       // 开仓拆分的层数（第一层立即成交，其余为限价挂单）/ Rungs an entry is split into: the first fills now, the rest rest as limit orders
+	EntryLadderSpacingPercent float64   // 相邻两层之间的价格间距（%）/ Price spacing between adjacent rungs (%)
+	EntryLadderWeights        []float64 // 各层数量权重，未设置时等权 / Quantity weight of each rung, equal when unset
+	EntryLadderTimeoutMinutes int       // 未成交挂单的撤单时间（分钟）/ Minutes before unfilled rungs are cancelled
 
 	// Trading parameters
 	// 交易参数
@@ -56,15 +131,74 @@ type Config struct {
 	CryptoTimeframe    string   // K线数据时间间隔 / K-line data timeframe
 	TradingInterval    string   // 系统运行间隔（独立于K线间隔）/ System execution interval (independent from K-line timeframe)
 	CryptoLookbackDays int
+
+	// Adaptive analysis frequency
+	// 自适应分析频率
+	AdaptiveIntervalEnabled bool    // 根据波动率自动调整运行间隔 / Adjust the execution interval to volatility
+	AdaptiveIntervalMin     string  // 高波动时的最短运行间隔 / Shortest interval in high volatility
+	AdaptiveIntervalMax     string  // 低波动时的最长运行间隔 / Longest interval in low volatility
+	AdaptiveHighPercentile  float64 // 波动率百分位达到该值时收紧间隔 / Volatility percentile that tightens the interval
+	AdaptiveLowPercentile   float64 // 波动率百分位不高于该值时放宽间隔 / Volatility percentile at or below which the interval relaxes
 	// PositionSize removed - now uses LLM's position size recommendation
 	// 移除 PositionSize - 现在使用 LLM 的仓位建议
 
+	// Position sizing engine, clamps the LLM's proposed size
+	// 仓位计算引擎，限制 LLM 建议的仓位
+	PositionSizingMode       string  // 仓位计算模式：llm/fixed_fractional/volatility_target/kelly / Sizing mode: llm, fixed_fractional, volatility_target or kelly
+	PositionRiskPercent      float64 // 固定比例模式下止损触发时的亏损占权益比例（%）/ Loss at the stop as % of equity in fixed-fractional mode
+	PositionVolTargetPercent float64 // 波动目标模式下一个 ATR 波动对应的权益比例（%）/ Equity % one ATR move may cost in volatility-target mode
+	PositionKellyFraction    float64 // 凯利比例缩放系数（0.5 为半凯利）/ Scale applied to the Kelly fraction (0.5 is half Kelly)
+	PositionKellyMaxPercent  float64 // 凯利模式单笔风险上限（%）/ Cap on the Kelly risk per trade (%)
+	PositionKellyMinTrades   int     // 凯利模式所需的最少已平仓交易数 / Closed trades required before Kelly sizing applies
+
+	// Risk-based default size, used when the LLM gives no position size
+	// 基于风险的默认仓位，LLM 未给出仓位建议时使用
+	RiskSizingRiskPercent   float64 // 止损触发时的亏损占权益比例（%）/ Loss at the stop as % of equity
+	RiskSizingMarginPercent float64 // 单笔保证金占权益的上限（%），据此选择杠杆 / Cap on one entry's margin as % of equity, leverage is chosen from it
+
+	// Hard cap on one trade's loss at its stop, whatever size and stop the LLM proposed
+	// 单笔交易止损亏损硬上限，不受 LLM 建议的仓位和止损影响
+	MaxTradeRiskPercent float64 // 止损触发时单笔亏损占权益的上限（%），0 表示不限制 / Cap on one trade's loss at its stop as % of equity, 0 disables it
+
+	// Portfolio exposure limits, 0 disables a cap
+	// 组合敞口上限，0 表示不限制
+	ExposureMaxTotalPercent  float64 // 所有交易对名义价值合计占权益的上限（%）/ Cap on total notional across all symbols as % of equity
+	ExposureMaxSymbolPercent float64 // 单个交易对名义价值占权益的上限（%）/ Cap on one symbol's notional as % of equity
+
+	// Concurrent position limit, 0 disables it
+	// 同时持仓数量上限，0 表示不限制
+	MaxOpenPositions int // 同时持有仓位的交易对数量上限 / Most symbols holding a position at once
+
+	// Scheduled deleveraging before weekends and holidays
+	// 周末和节假日前的计划降杠杆
+	DeleverageWindows     string  // 降杠杆时段（UTC），如 "Fri 20:00/Mon 00:00"，空表示不启用 / Deleverage windows in UTC, e.g. "Fri 20:00/Mon 00:00", empty disables
+	DeleverageMaxLeverage int     // 时段内杠杆上限，0 表示不限制 / Leverage ceiling inside a window, 0 imposes none
+	DeleverageSizePercent float64 // 时段内仓位为正常的百分比 / Position size inside a window as % of normal
+
+	// Funding-cost-aware holding
+	// 资金费成本感知持仓
+	FundingCostMaxPercent   float64 // 持有期内预计资金费成本上限（名义价值的 %），0 表示不检查 / Cap on the expected funding cost over the horizon as % of notional, 0 disables the check
+	FundingCostHorizonHours int     // 预估资金费成本的持有期（小时）/ Holding horizon the funding cost is projected over, in hours
+
+	// Auto-deleveraging when the account margin ratio is high
+	// 账户保证金率过高时自动减仓
+	MarginDeleverageRatioPercent float64 // 触发自动减仓的保证金率（%），0 表示不启用 / Margin ratio (%) that triggers reductions, 0 disables them
+	MarginDeleverageStepPercent  float64 // 每步减仓的持仓比例（%）/ Share of the position closed per step (%)
+
 	// Multi-timeframe analysis
 	// 多时间周期分析
 	EnableMultiTimeframe     bool   // 是否启用多时间周期分析 / Enable multi-timeframe analysis
 	CryptoLongerTimeframe    string // 更长期的时间周期（如 4h）/ Longer timeframe (e.g., 4h)
 	CryptoLongerLookbackDays int    // 更长期时间周期的回看天数 / Lookback days for longer timeframe
 
+	// Kline integrity cross-check
+	// K 线完整性交叉校验
+	KlineCrossCheckEnabled   bool    // 是否用第二数据源校验最近的 K 线 / Cross-check the latest candles against a second source
+	KlineCrossCheckSource    string  // 参考数据源：index 或 mark / Reference source: index or mark
+	KlineCrossCheckCandles   int     // 校验最近多少根已收盘 K 线 / Number of latest closed candles to check
+	KlineCrossCheckTolerance float64 // 允许的最大价差（%）/ Maximum allowed price difference (%)
+	KlineCrossCheckAction    string  // 超出容差时的处理：alert 或 skip / What to do on a discrepancy: alert or skip
+
 	// Analysis options
 	// 分析选项
 	EnableSentimentAnalysis bool // 是否启用市场情绪分析 / Enable sentiment analysis (CryptoOracle API)
@@ -74,24 +208,126 @@ type Config struct {
 	// Note: Trailing stop parameters (update threshold, ATR multiplier, etc.) are configured
 	// in internal/executors/trailing_stop_calculator.go for each symbol
 	// 注意：追踪止损参数（更新阈值、ATR倍数等）在 internal/executors/trailing_stop_calculator.go 中为每个币种配置
-	EnableStopLoss               bool // 是否启用止损管理 / Enable stop-loss management
-	TrailingStopATRPeriod        int  // 追踪止损的 ATR 周期（从长期时间周期计算，推荐 3/7/14）/ ATR period for trailing stop (calculated from longer timeframe, recommended 3/7/14)
-	TakeProfitMonitoringInterval int  // 分批止盈监控间隔（秒），默认 10 秒 / Partial take-profit monitoring interval (seconds), default 10
+	EnableStopLoss               bool   // 是否启用止损管理 / Enable stop-loss management
+	TrailingStopATRPeriod        int    // 追踪止损的 ATR 周期（从长期时间周期计算，推荐 3/7/14）/ ATR period for trailing stop (calculated from longer timeframe, recommended 3/7/14)
+	TrailingStopMode             string // 追踪止损方式：local/native / Trailing stop mode: local or native (exchange TRAILING_STOP_MARKET)
+	TakeProfitMonitoringInterval int    // 分批止盈监控间隔（秒），默认 10 秒 / Partial take-profit monitoring interval (seconds), default 10
+
+	// Trailing stop formula (chandelier, PSAR, SuperTrend and percentage parameters are per symbol in trailing_stop_calculator.go)
+	// 追踪止损公式（吊灯止损、抛物线 SAR、SuperTrend 与百分比参数在 trailing_stop_calculator.go 中按交易对配置）
+	TrailingStopFormula  string            // 默认追踪公式：atr/chandelier/psar/supertrend/percent/step / Default trailing formula: atr, chandelier, psar, supertrend, percent or step
+	TrailingStopFormulas map[string]string // 按交易对覆盖的追踪公式（键为币安格式）/ Per-symbol formula overrides keyed by Binance symbol
+
+	// Stop monitoring: where stops are enforced
+	// 止损监控：止损由谁执行
+	StopMonitoringMode  string            // 默认止损监控方式：exchange/local/both / Default stop monitoring: exchange, local or both
+	StopMonitoringModes map[string]string // 按交易对覆盖的止损监控方式（键为币安格式）/ Per-symbol stop monitoring overrides keyed by Binance symbol
+
+	// Take-profit ladder: R multiples or structural levels
+	// 分批止盈阶梯：按 R 倍数或结构位
+	TakeProfitMode          string            // 默认止盈阶梯：r_multiple/structure / Default ladder: r_multiple or structure
+	TakeProfitModes         map[string]string // 按交易对覆盖的止盈阶梯（键为币安格式）/ Per-symbol ladder overrides keyed by Binance symbol
+	TakeProfitStructureMinR float64           // 结构位止盈目标距入场的最小 R 倍数 / Nearest a structural target may sit to the entry, in R
+
+	// Trailing stop update threshold auto-tuning
+	// 追踪止损更新阈值自动调节
+	TrailingThresholdAutoTune bool    // 根据止损单变更频率自动调节更新阈值 / Auto-tune the update threshold from observed order churn
+	TrailingThresholdMin      float64 // 阈值下限（%）/ Lower bound of the threshold (%)
+	TrailingThresholdMax      float64 // 阈值上限（%）/ Upper bound of the threshold (%)
+	TrailingThresholdWindow   int     // 每多少次候选更新评估一次 / Candidate updates per evaluation window
+
+	// Volatility-adaptive ATR multipliers
+	// 随波动率自适应的 ATR 倍数
+	ATRScalingEnabled  bool    // 按 ATR 百分位缩放初始/追踪止损 ATR 倍数 / Scale initial/trailing ATR multipliers by the ATR percentile
+	ATRScalingMinScale float64 // ATR 处于 P0 时的缩放系数 / Factor at the ATR's P0
+	ATRScalingMaxScale float64 // ATR 处于 P100 时的缩放系数 / Factor at the ATR's P100
+
+	// Break-even stop
+	// 保本止损
+	BreakEvenEnabled        bool    // 盈利达到阈值后将止损移至开仓价 / Move the stop to entry once profit reaches the trigger
+	BreakEvenTriggerPercent float64 // 价格朝有利方向移动该百分比后触发，0 表示不按百分比触发 / Trigger after price moves this % in favour, 0 disables
+	BreakEvenTriggerR       float64 // 盈利达到该倍数的初始风险后触发，0 表示不按 R 触发 / Trigger once profit reaches this multiple of initial risk, 0 disables
+	BreakEvenBufferPercent  float64 // 保本价相对开仓价的缓冲（%），用于覆盖手续费 / Buffer beyond entry (%) covering fees
+
+	// Profit-lock tiers
+	// 利润锁定阶梯
+	ProfitLockTiers map[float64]float64 // 最大浮盈（%）达到键值后至少锁定该比例（%）的最大浮盈，为空表示不启用 / Once the best open profit reaches the key (%), lock at least the value (%) of it; empty disables
+
+	// HOLD re-evaluation triggers
+	// HOLD 提前重新评估触发条件
+	HoldReevalEnabled              bool    // 持仓 HOLD 后满足触发条件时提前重新分析该交易对 / Re-analyze a held symbol early when a trigger fires
+	HoldReevalPriceMovePercent     float64 // 价格相对 HOLD 时变动该百分比即触发，0 表示不启用 / Trigger when price moves this % from the HOLD, 0 disables
+	HoldReevalStopProximityPercent float64 // 价格距离止损不足该百分比即触发，0 表示不启用 / Trigger when price is within this % of the stop, 0 disables
+	HoldReevalFundingFlip          bool    // 资金费率正负翻转即触发 / Trigger when the funding rate changes sign
 
 	// Memory system
-	UseMemory  bool
-	MemoryTopK int
+	UseMemory  bool // 在市场报告中召回历史相似情境 / Recall similar past situations in the market report
+	MemoryTopK int  // 召回的情境数量 / Number of situations recalled
 
 	// Debug options
 	DebugMode        bool
 	SelectedAnalysts []string
 	AutoExecute      bool
 
+	// Debug capture
+	// 调试采集
+	DebugCaptureSymbols []string // 启动时开启采集的交易对 / Symbols armed for capture at startup
+	DebugCaptureCycles  int      // 每次开启采集的周期数 / Cycles captured per arming
+	DebugCaptureMaxMB   int      // 每个交易对每个周期的采集上限（MB），0 表示不限 / Capture budget per symbol per cycle (MB), 0 is unlimited
+
 	// Web monitoring
 	// Web 监控配置
 	WebPort     int
 	WebUsername string // Web 登录用户名 / Web login username
 	WebPassword string // Web 登录密码 / Web login password
+
+	WebAnalyzeCooldownMinutes int // 同一交易对两次即时分析请求的最短间隔（分钟）/ Minimum minutes between on-demand analyses of one symbol
+	WebJobMaxConcurrent       int // 同时运行的后台任务数上限 / Maximum background jobs running at once
+
+	// Display timezone
+	// 显示时区
+	Timezone string // IANA 时区名，用于显示、报告和日切，为空使用服务器本地时区 / IANA zone for display, reports and daily rollover, server local when empty
+	location *time.Location // 解析后的时区，由 LoadConfig 设置 / Resolved zone, set by LoadConfig
+
+	// Performance analytics
+	// 绩效分析
+	RiskFreeRate       float64 // 年化无风险利率（%），用于夏普/索提诺比率 / Annual risk-free rate (%) for Sharpe/Sortino ratios
+	LeaderboardKeyPath string  // 排行榜导出签名私钥路径，不存在时自动生成 / Signing key for leaderboard exports, generated when missing
+
+	// Account safety
+	// 账户安全
+	BalanceDiscrepancyThreshold float64 // 余额异常变动阈值（USDT），0 表示禁用 / Unexplained balance change threshold (USDT), 0 disables
+	BotInstanceID               string  // 实例标识，为空时使用主机名 / Instance identifier, hostname when empty
+
+	// Notifications
+	// 通知
+	TelegramBotToken    string // Telegram Bot Token，为空时不发送通知 / Telegram bot token, notifications are off when empty
+	TelegramChatID      string // 接收通知的 Telegram 聊天 ID / Telegram chat receiving notifications
+	NotifyDigestMinutes int    // 低优先级通知的汇总间隔（分钟），0 表示逐条发送 / Digest interval for low-severity notifications (minutes), 0 sends each one
+	DailySummaryEnabled bool   // 每日零点推送交易盈亏与资金费汇总 / Send the daily trading PnL and funding summary at midnight
+	WeeklyReviewEnabled bool   // 每周一零点由深度模型生成策略复盘并推送 / Have the deep model write and push a strategy review every Monday at midnight
+
+	// Dead man's switch
+	// 死人开关
+	DeadManSwitchEnabled        bool // 停止、崩溃或失联时撤销开仓挂单 / Cancel entry orders on shutdown, crash or lost connectivity
+	DeadManSwitchFlatten        bool // 触发时同时市价平仓 / Also flatten positions at market when tripped
+	DeadManSwitchTimeoutSeconds int  // 与交易所失联多久后触发（秒）/ Seconds without exchange contact before tripping
+	DeadManSwitchCountdown      bool // 启用币安 countdownCancelAll 作为兜底 / Arm Binance countdownCancelAll as a backstop
+
+	// Liquidation monitoring
+	// 强平监控
+	LiquidationMonitorEnabled    bool    // 监控标记价格与强平价的距离 / Watch the distance between mark price and liquidation price
+	LiquidationBufferPercent     float64 // 标记价格距强平价小于该百分比时告警 / Alert when mark price is within this percent of liquidation
+	LiquidationAutoReducePercent float64 // 进入缓冲区时自动减仓的比例（%），0 表示只告警 / Percent of the position reduced on entering the buffer, 0 only alerts
+
+	// Startup reconciliation
+	// 启动对账
+	StartupReconcileEnabled bool // 启动时用交易所持仓和挂单重建止损状态 / Rebuild stop-loss state from exchange positions and orders on startup
+	StartupAdoptPositions   bool // 启动时接管无止损单的未知持仓并重新计算止损 / Adopt unknown positions without a stop on startup and compute a fresh stop
+
+	// Residual position cleanup
+	// 残余持仓清理
+	DustCleanupEnabled bool // 每个周期清理低于最小下单量的残余持仓 / Clean up positions below the minimum order quantity every cycle
 }
 
 // LoadConfig loads configuration from .env file or a custom path
@@ -132,12 +368,36 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		BackendURL:       viper.GetString("LLM_BACKEND_URL"),
 		APIKey:           viper.GetString("OPENAI_API_KEY"),
 		TraderPromptPath: viper.GetString("TRADER_PROMPT_PATH"),
+		DecisionLanguage: viper.GetString("DECISION_LANGUAGE"),
+
+		// LLM quota management
+		LLMFallbackModel:            viper.GetString("LLM_FALLBACK_MODEL"),
+		LLMQuotaMinRemainingPercent: viper.GetFloat64("LLM_QUOTA_MIN_REMAINING_PERCENT"),
+		LLMQuotaMaxWaitSeconds:      viper.GetInt("LLM_QUOTA_MAX_WAIT_SECONDS"),
 
 		// Agent behavior
 		MaxDebateRounds:      viper.GetInt("MAX_DEBATE_ROUNDS"),
 		MaxRiskDiscussRounds: viper.GetInt("MAX_RISK_DISCUSS_ROUNDS"),
 		MaxRecurLimit:        viper.GetInt("MAX_RECUR_LIMIT"),
 
+		// Decision guardrail
+		GuardrailEnabled:        viper.GetBool("GUARDRAIL_ENABLED"),
+		GuardrailLLM:            viper.GetString("GUARDRAIL_LLM"),
+		GuardrailMaxRetries:     viper.GetInt("GUARDRAIL_MAX_RETRIES"),
+		GuardrailMaxRiskPercent: viper.GetFloat64("GUARDRAIL_MAX_RISK_PERCENT"),
+
+		DecisionConsistencyWindow: viper.GetInt("DECISION_CONSISTENCY_WINDOW"),
+
+		ShadowCycles: viper.GetInt("SHADOW_CYCLES"),
+
+		// Deterministic risk gate
+		RiskGateEnabled:                       viper.GetBool("RISK_GATE_ENABLED"),
+		RiskGateMinLiquidationDistancePercent: viper.GetFloat64("RISK_GATE_MIN_LIQUIDATION_DISTANCE_PERCENT"),
+		RiskGateCooldownMinutes:               viper.GetInt("RISK_GATE_COOLDOWN_MINUTES"),
+
+		StopReviewEnabled:  viper.GetBool("STOP_REVIEW_ENABLED"),
+		StopReviewInterval: viper.GetString("STOP_REVIEW_INTERVAL"),
+
 		// Data vendors
 		DataVendorStock:      viper.GetString("DATA_VENDOR_STOCK"),
 		DataVendorIndicators: viper.GetString("DATA_VENDOR_INDICATORS"),
@@ -147,24 +407,124 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		// Binance trading configuration
 		BinanceAPIKey:               viper.GetString("BINANCE_API_KEY"),
 		BinanceAPISecret:            viper.GetString("BINANCE_API_SECRET"),
+		BinanceAPIKeyBackup:         viper.GetString("BINANCE_API_KEY_BACKUP"),
+		BinanceAPISecretBackup:      viper.GetString("BINANCE_API_SECRET_BACKUP"),
+		BinanceKeyCheckStrict:       viper.GetBool("BINANCE_KEY_CHECK_STRICT"),
 		BinanceProxy:                viper.GetString("BINANCE_PROXY"),
 		BinanceProxyInsecureSkipTLS: viper.GetBool("BINANCE_PROXY_INSECURE_SKIP_TLS"),
+		BinanceWsProxy:              viper.GetString("BINANCE_WS_PROXY"),
 		BinanceLeverage:             viper.GetInt("BINANCE_LEVERAGE"),
 		BinanceTestMode:             viper.GetBool("BINANCE_TEST_MODE"),
+		PaperTrading:                viper.GetBool("PAPER_TRADING"),
+		PaperInitialBalance:         viper.GetFloat64("PAPER_INITIAL_BALANCE"),
+		PaperFeeRate:                viper.GetFloat64("PAPER_FEE_RATE"),
 		BinancePositionMode:         viper.GetString("BINANCE_POSITION_MODE"),
+		HedgeOnReversal:             viper.GetBool("HEDGE_ON_REVERSAL"),
+		BinanceMaxWeightPerMinute:   viper.GetInt("BINANCE_MAX_WEIGHT_PER_MINUTE"),
+		ExecutorSlowCallMs:          viper.GetInt("EXECUTOR_SLOW_CALL_MS"),
+		ExecutorErrorRateAlert:      viper.GetFloat64("EXECUTOR_ERROR_RATE_ALERT"),
+
+		// Market regime
+		RegimeHighPercentile: viper.GetFloat64("REGIME_HIGH_PERCENTILE"),
+		RegimeLowPercentile:  viper.GetFloat64("REGIME_LOW_PERCENTILE"),
+		RegimeTrendADX:       viper.GetFloat64("REGIME_TREND_ADX"),
+
+		// Order execution
+		// 下单执行配置
+		OrderExecutionMode:       viper.GetString("ORDER_EXECUTION_MODE"),
+		LimitOrderOffsetPercent:  viper.GetFloat64("LIMIT_ORDER_OFFSET_PERCENT"),
+		LimitOrderTimeoutSeconds: viper.GetInt("LIMIT_ORDER_TIMEOUT_SECONDS"),
+		LimitOrderFallback:       viper.GetString("LIMIT_ORDER_FALLBACK"),
+		PartialFillPolicy:        viper.GetString("PARTIAL_FILL_POLICY"),
+		PartialFillChaseAttempts: viper.GetInt("PARTIAL_FILL_CHASE_ATTEMPTS"),
+		IcebergThresholdNotional: viper.GetFloat64("ICEBERG_THRESHOLD_NOTIONAL"),
+		IcebergSliceNotional:     viper.GetFloat64("ICEBERG_SLICE_NOTIONAL"),
+		IcebergSliceDelaySeconds: viper.GetInt("ICEBERG_SLICE_DELAY_SECONDS"),
+		BracketEntryEnabled:      viper.GetBool("BRACKET_ENTRY_ENABLED"),
+		MaxSlippageBps:           viper.GetFloat64("MAX_SLIPPAGE_BPS"),
+		SlippageGuardAction:      viper.GetString("SLIPPAGE_GUARD_ACTION"),
+		OrderValidationOnly:      viper.GetBool("ORDER_VALIDATION_ONLY"),
+		UserDataStreamEnabled:    viper.GetBool("USER_DATA_STREAM_ENABLED"),
+		PriceStreamEnabled:       viper.GetBool("PRICE_STREAM_ENABLED"),
+		KlineStreamEnabled:       viper.GetBool("KLINE_STREAM_ENABLED"),
+
+		// DCA entry ladder
+		// DCA 分批挂单开仓
+		EntryLadderLevels:         viper.GetInt("ENTRY_LADDER_LEVELS"),
+		EntryLadderSpacingPercent: viper.GetFloat64("ENTRY_LADDER_SPACING_PERCENT"),
+		EntryLadderWeights:        parseEntryLadderWeights(viper.GetString("ENTRY_LADDER_WEIGHTS")),
+		EntryLadderTimeoutMinutes: viper.GetInt("ENTRY_LADDER_TIMEOUT_MINUTES"),
 
 		// Trading parameters
 		CryptoTimeframe:    viper.GetString("CRYPTO_TIMEFRAME"),
 		TradingInterval:    viper.GetString("TRADING_INTERVAL"),
 		CryptoLookbackDays: viper.GetInt("CRYPTO_LOOKBACK_DAYS"),
+
+		// Adaptive analysis frequency
+		// 自适应分析频率
+		AdaptiveIntervalEnabled: viper.GetBool("ADAPTIVE_INTERVAL_ENABLED"),
+		AdaptiveIntervalMin:     viper.GetString("ADAPTIVE_INTERVAL_MIN"),
+		AdaptiveIntervalMax:     viper.GetString("ADAPTIVE_INTERVAL_MAX"),
+		AdaptiveHighPercentile:  viper.GetFloat64("ADAPTIVE_HIGH_PERCENTILE"),
+		AdaptiveLowPercentile:   viper.GetFloat64("ADAPTIVE_LOW_PERCENTILE"),
 		// PositionSize removed - now uses LLM's position size recommendation
 
+		// Position sizing engine
+		// 仓位计算引擎
+		PositionRiskPercent:      viper.GetFloat64("POSITION_RISK_PERCENT"),
+		PositionVolTargetPercent: viper.GetFloat64("POSITION_VOL_TARGET_PERCENT"),
+		PositionKellyFraction:    viper.GetFloat64("POSITION_KELLY_FRACTION"),
+		PositionKellyMaxPercent:  viper.GetFloat64("POSITION_KELLY_MAX_PERCENT"),
+		PositionKellyMinTrades:   viper.GetInt("POSITION_KELLY_MIN_TRADES"),
+
+		// Risk-based default size
+		// 基于风险的默认仓位
+		RiskSizingRiskPercent:   viper.GetFloat64("RISK_SIZING_RISK_PERCENT"),
+		RiskSizingMarginPercent: viper.GetFloat64("RISK_SIZING_MARGIN_PERCENT"),
+
+		// Per-trade risk hard cap
+		// 单笔风险硬上限
+		MaxTradeRiskPercent: viper.GetFloat64("MAX_TRADE_RISK_PERCENT"),
+
+		// Portfolio exposure limits
+		// 组合敞口上限
+		ExposureMaxTotalPercent:  viper.GetFloat64("EXPOSURE_MAX_TOTAL_PERCENT"),
+		ExposureMaxSymbolPercent: viper.GetFloat64("EXPOSURE_MAX_SYMBOL_PERCENT"),
+
+		// Concurrent position limit
+		// 同时持仓数量上限
+		MaxOpenPositions: viper.GetInt("MAX_OPEN_POSITIONS"),
+
+		// Scheduled deleveraging
+		// 计划降杠杆
+		DeleverageWindows:     viper.GetString("DELEVERAGE_WINDOWS"),
+		DeleverageMaxLeverage: viper.GetInt("DELEVERAGE_MAX_LEVERAGE"),
+		DeleverageSizePercent: viper.GetFloat64("DELEVERAGE_SIZE_PERCENT"),
+
+		// Funding-cost-aware holding
+		// 资金费成本感知持仓
+		FundingCostMaxPercent:   viper.GetFloat64("FUNDING_COST_MAX_PERCENT"),
+		FundingCostHorizonHours: viper.GetInt("FUNDING_COST_HORIZON_HOURS"),
+
+		// Margin ratio auto-deleveraging
+		// 保证金率自动减仓
+		MarginDeleverageRatioPercent: viper.GetFloat64("MARGIN_DELEVERAGE_RATIO_PERCENT"),
+		MarginDeleverageStepPercent:  viper.GetFloat64("MARGIN_DELEVERAGE_STEP_PERCENT"),
+
 		// Multi-timeframe analysis
 		// 多时间周期分析
 		EnableMultiTimeframe:     viper.GetBool("ENABLE_MULTI_TIMEFRAME"),
 		CryptoLongerTimeframe:    viper.GetString("CRYPTO_LONGER_TIMEFRAME"),
 		CryptoLongerLookbackDays: viper.GetInt("CRYPTO_LONGER_LOOKBACK_DAYS"),
 
+		// Kline integrity cross-check
+		// K 线完整性交叉校验
+		KlineCrossCheckEnabled:   viper.GetBool("KLINE_CROSSCHECK_ENABLED"),
+		KlineCrossCheckSource:    viper.GetString("KLINE_CROSSCHECK_SOURCE"),
+		KlineCrossCheckCandles:   viper.GetInt("KLINE_CROSSCHECK_CANDLES"),
+		KlineCrossCheckTolerance: viper.GetFloat64("KLINE_CROSSCHECK_TOLERANCE"),
+		KlineCrossCheckAction:    viper.GetString("KLINE_CROSSCHECK_ACTION"),
+
 		// Analysis options
 		EnableSentimentAnalysis: viper.GetBool("ENABLE_SENTIMENT_ANALYSIS"),
 
@@ -173,6 +533,34 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		// 追踪止损参数在 internal/executors/trailing_stop_calculator.go 中配置
 		EnableStopLoss:        viper.GetBool("ENABLE_STOPLOSS"),
 		TrailingStopATRPeriod: viper.GetInt("TRAILING_STOP_ATR_PERIOD"),
+		TrailingStopMode:      viper.GetString("TRAILING_STOP_MODE"),
+
+		// Trailing stop update threshold auto-tuning
+		// 追踪止损更新阈值自动调节
+		TrailingThresholdAutoTune: viper.GetBool("TRAILING_THRESHOLD_AUTOTUNE"),
+		TrailingThresholdMin:      viper.GetFloat64("TRAILING_THRESHOLD_MIN"),
+		TrailingThresholdMax:      viper.GetFloat64("TRAILING_THRESHOLD_MAX"),
+		TrailingThresholdWindow:   viper.GetInt("TRAILING_THRESHOLD_WINDOW"),
+
+		// Volatility-adaptive ATR multipliers
+		// 随波动率自适应的 ATR 倍数
+		ATRScalingEnabled:  viper.GetBool("ATR_SCALING_ENABLED"),
+		ATRScalingMinScale: viper.GetFloat64("ATR_SCALING_MIN_SCALE"),
+		ATRScalingMaxScale: viper.GetFloat64("ATR_SCALING_MAX_SCALE"),
+
+		// Break-even stop
+		// 保本止损
+		BreakEvenEnabled:        viper.GetBool("BREAKEVEN_ENABLED"),
+		BreakEvenTriggerPercent: viper.GetFloat64("BREAKEVEN_TRIGGER_PERCENT"),
+		BreakEvenTriggerR:       viper.GetFloat64("BREAKEVEN_TRIGGER_R"),
+		BreakEvenBufferPercent:  viper.GetFloat64("BREAKEVEN_BUFFER_PERCENT"),
+
+		// HOLD re-evaluation triggers
+		// HOLD 提前重新评估触发条件
+		HoldReevalEnabled:              viper.GetBool("HOLD_REEVAL_ENABLED"),
+		HoldReevalPriceMovePercent:     viper.GetFloat64("HOLD_REEVAL_PRICE_MOVE_PERCENT"),
+		HoldReevalStopProximityPercent: viper.GetFloat64("HOLD_REEVAL_STOP_PROXIMITY_PERCENT"),
+		HoldReevalFundingFlip:          viper.GetBool("HOLD_REEVAL_FUNDING_FLIP"),
 
 		// Memory system
 		UseMemory:  viper.GetBool("USE_MEMORY"),
@@ -183,11 +571,62 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		SelectedAnalysts: strings.Split(viper.GetString("SELECTED_ANALYSTS"), ","),
 		AutoExecute:      viper.GetBool("AUTO_EXECUTE"),
 
+		// Debug capture
+		DebugCaptureCycles: viper.GetInt("DEBUG_CAPTURE_CYCLES"),
+		DebugCaptureMaxMB:  viper.GetInt("DEBUG_CAPTURE_MAX_MB"),
+
 		// Web monitoring
 		// Web 监控配置
 		WebPort:     viper.GetInt("WEB_PORT"),
 		WebUsername: viper.GetString("WEB_USERNAME"),
 		WebPassword: viper.GetString("WEB_PASSWORD"),
+
+		WebAnalyzeCooldownMinutes: viper.GetInt("WEB_ANALYZE_COOLDOWN_MINUTES"),
+		WebJobMaxConcurrent:       viper.GetInt("WEB_JOB_MAX_CONCURRENT"),
+
+		// Display timezone
+		// 显示时区
+		Timezone: viper.GetString("TIMEZONE"),
+
+		// Performance analytics
+		// 绩效分析
+		RiskFreeRate:       viper.GetFloat64("RISK_FREE_RATE"),
+		LeaderboardKeyPath: viper.GetString("LEADERBOARD_KEY_PATH"),
+
+		// Account safety
+		// 账户安全
+		BalanceDiscrepancyThreshold: viper.GetFloat64("BALANCE_DISCREPANCY_THRESHOLD"),
+		BotInstanceID:               viper.GetString("BOT_INSTANCE_ID"),
+
+		// Notifications
+		// 通知
+		TelegramBotToken:    viper.GetString("TELEGRAM_BOT_TOKEN"),
+		TelegramChatID:      viper.GetString("TELEGRAM_CHAT_ID"),
+		NotifyDigestMinutes: viper.GetInt("NOTIFY_DIGEST_MINUTES"),
+		DailySummaryEnabled: viper.GetBool("DAILY_SUMMARY_ENABLED"),
+		WeeklyReviewEnabled: viper.GetBool("WEEKLY_REVIEW_ENABLED"),
+
+		// Dead man's switch
+		// 死人开关
+		DeadManSwitchEnabled:        viper.GetBool("DEAD_MAN_SWITCH_ENABLED"),
+		DeadManSwitchFlatten:        viper.GetBool("DEAD_MAN_SWITCH_FLATTEN"),
+		DeadManSwitchTimeoutSeconds: viper.GetInt("DEAD_MAN_SWITCH_TIMEOUT_SECONDS"),
+		DeadManSwitchCountdown:      viper.GetBool("DEAD_MAN_SWITCH_COUNTDOWN"),
+
+		// Liquidation monitoring
+		// 强平监控
+		LiquidationMonitorEnabled:    viper.GetBool("LIQUIDATION_MONITOR_ENABLED"),
+		LiquidationBufferPercent:     viper.GetFloat64("LIQUIDATION_BUFFER_PERCENT"),
+		LiquidationAutoReducePercent: viper.GetFloat64("LIQUIDATION_AUTO_REDUCE_PERCENT"),
+
+		// Startup reconciliation
+		// 启动对账
+		StartupReconcileEnabled: viper.GetBool("STARTUP_RECONCILE_ENABLED"),
+		StartupAdoptPositions:   viper.GetBool("STARTUP_ADOPT_POSITIONS"),
+
+		// Residual position cleanup
+		// 残余持仓清理
+		DustCleanupEnabled: viper.GetBool("DUST_CLEANUP_ENABLED"),
 	}
 
 	// Auto-calculate lookback days if not set
@@ -228,6 +667,45 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		cfg.CryptoSymbols = []string{"BTC/USDT"}
 	}
 
+	// Parse margin types ("isolated" or per symbol "BTC/USDT:isolated,ETH/USDT:cross")
+	// 解析保证金类型（"isolated" 或按交易对 "BTC/USDT:isolated,ETH/USDT:cross"）
+	cfg.BinanceMarginType = normalizeMarginType(viper.GetString("BINANCE_MARGIN_TYPE"))
+	cfg.BinanceMarginTypes = parseMarginTypes(viper.GetString("BINANCE_MARGIN_TYPES"))
+
+	// Parse the position sizing mode, unknown modes fall back to the LLM's size
+	// 解析仓位计算模式，无法识别时使用 LLM 建议的仓位
+	cfg.PositionSizingMode = normalizePositionSizingMode(viper.GetString("POSITION_SIZING_MODE"))
+
+	// Parse trailing formulas ("chandelier" or per symbol "SOL/USDT:chandelier,BTC/USDT:atr")
+	// 解析追踪公式（"chandelier" 或按交易对 "SOL/USDT:chandelier,BTC/USDT:atr"）
+	cfg.TrailingStopFormula = normalizeTrailingFormula(viper.GetString("TRAILING_STOP_FORMULA"))
+	cfg.TrailingStopFormulas = parseTrailingFormulas(viper.GetString("TRAILING_STOP_FORMULAS"))
+
+	// Parse stop monitoring ("local" or per symbol "SOL/USDT:local,BTC/USDT:both")
+	// 解析止损监控方式（"local" 或按交易对 "SOL/USDT:local,BTC/USDT:both"）
+	cfg.StopMonitoringMode = normalizeStopMonitoringMode(viper.GetString("STOP_MONITORING_MODE"))
+	if cfg.StopMonitoringMode == "" {
+		cfg.StopMonitoringMode = "exchange"
+	}
+	cfg.StopMonitoringModes = parseStopMonitoringModes(viper.GetString("STOP_MONITORING_MODES"))
+
+	// Parse the take-profit ladder ("structure" or per symbol "SOL/USDT:structure,BTC/USDT:r_multiple")
+	// 解析止盈阶梯（"structure" 或按交易对 "SOL/USDT:structure,BTC/USDT:r_multiple"）
+	cfg.TakeProfitMode = normalizeTakeProfitMode(viper.GetString("TAKE_PROFIT_MODE"))
+	if cfg.TakeProfitMode == "" {
+		cfg.TakeProfitMode = "r_multiple"
+	}
+	cfg.TakeProfitModes = parseTakeProfitModes(viper.GetString("TAKE_PROFIT_MODES"))
+	cfg.TakeProfitStructureMinR = viper.GetFloat64("TAKE_PROFIT_STRUCTURE_MIN_R")
+
+	// Parse the symbols armed for debug capture at startup ("BTC/USDT,SOL/USDT")
+	// 解析启动时开启调试采集的交易对（"BTC/USDT,SOL/USDT"）
+	for _, symbol := range strings.Split(viper.GetString("DEBUG_CAPTURE_SYMBOLS"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			cfg.DebugCaptureSymbols = append(cfg.DebugCaptureSymbols, symbol)
+		}
+	}
+
 	// Parse leverage range (support "10-20" format)
 	// 解析杠杆范围（支持 "10-20" 格式）
 	leverageStr := viper.GetString("BINANCE_LEVERAGE")
@@ -263,15 +741,41 @@ func LoadConfig(pathToEnv string) (*Config, error) {
 		cfg.BinanceLeverageDynamic = false
 	}
 
+	// Parse leverage ceilings per market regime ("high_volatility:5,ranging:10")
+	// 解析按市场状态的杠杆上限（"high_volatility:5,ranging:10"）
+	cfg.LeverageRegimeCaps = parseLeverageRegimeCaps(viper.GetString("LEVERAGE_REGIME_CAPS"))
+	cfg.ProfitLockTiers = parseProfitLockTiers(viper.GetString("PROFIT_LOCK_TIERS"))
+
 	// Setup TradingInterval default (use CRYPTO_TIMEFRAME if not set)
 	// 设置 TradingInterval 默认值（如果未设置，使用 CRYPTO_TIMEFRAME）
 	if cfg.TradingInterval == "" {
 		cfg.TradingInterval = cfg.CryptoTimeframe
 	}
 
+	// Resolve the timezone once; it is applied explicitly where times are shown or days roll over
+	// 只解析一次时区；在显示时间和日切处显式使用
+	loc, err := loadTimezone(cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	cfg.location = loc
+
 	return cfg, nil
 }
 
+// loadTimezone resolves an IANA zone name; an empty name is the server's local time
+// loadTimezone 解析 IANA 时区名；名称为空时为服务器本地时区
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TIMEZONE %q: %w", name, err)
+	}
+	return loc, nil
+}
+
 func setDefaults() {
 	viper.SetDefault("RESULTS_DIR", "./crypto_results")
 	viper.SetDefault("DATA_CACHE_DIR", "./internal/dataflows/data_cache")
@@ -282,28 +786,125 @@ func setDefaults() {
 	viper.SetDefault("QUICK_THINK_LLM", "gpt-4o-mini")
 	viper.SetDefault("LLM_BACKEND_URL", "https://api.openai.com/v1")
 	viper.SetDefault("TRADER_PROMPT_PATH", "prompts/trader_system.txt")
+	viper.SetDefault("DECISION_LANGUAGE", "zh") // 默认中文，与 Prompt 原文一致 / Chinese by default, matching the prompts
+	viper.SetDefault("LLM_FALLBACK_MODEL", "")
+	viper.SetDefault("LLM_QUOTA_MIN_REMAINING_PERCENT", 10.0)
+	viper.SetDefault("LLM_QUOTA_MAX_WAIT_SECONDS", 30)
 
 	viper.SetDefault("MAX_DEBATE_ROUNDS", 2)
 	viper.SetDefault("MAX_RISK_DISCUSS_ROUNDS", 2)
 	viper.SetDefault("MAX_RECUR_LIMIT", 100)
 
+	viper.SetDefault("GUARDRAIL_ENABLED", true)
+	viper.SetDefault("GUARDRAIL_LLM", "")
+	viper.SetDefault("GUARDRAIL_MAX_RETRIES", 1)
+	viper.SetDefault("GUARDRAIL_MAX_RISK_PERCENT", 3.0) // 与 Prompt 中单笔 1%-3% 的亏损上限一致 / Matches the 1%-3% per-trade loss cap in the prompts
+	viper.SetDefault("DECISION_CONSISTENCY_WINDOW", 0)  // 默认不启用 / Disabled by default
+	viper.SetDefault("SHADOW_CYCLES", 0)                // 默认立即生效 / Changes apply at once by default
+	viper.SetDefault("RISK_GATE_ENABLED", false)
+	viper.SetDefault("RISK_GATE_MIN_LIQUIDATION_DISTANCE_PERCENT", 10.0)
+	viper.SetDefault("RISK_GATE_COOLDOWN_MINUTES", 60)
+	viper.SetDefault("STOP_REVIEW_ENABLED", false)      // 默认关闭，避免额外的 LLM 调用 / Off by default to avoid extra LLM calls
+	viper.SetDefault("STOP_REVIEW_INTERVAL", "15m")
+
 	viper.SetDefault("DATA_VENDOR_STOCK", "ccxt")
 	viper.SetDefault("DATA_VENDOR_INDICATORS", "ccxt")
 	viper.SetDefault("DATA_VENDOR_NEWS", "alpha_vantage")
 	viper.SetDefault("DATA_VENDOR_CRYPTO", "ccxt")
 
 	viper.SetDefault("BINANCE_LEVERAGE", 10)
+	viper.SetDefault("LEVERAGE_REGIME_CAPS", "") // 不按市场状态限制 / No regime ceilings
+	viper.SetDefault("REGIME_HIGH_PERCENTILE", 80)
+	viper.SetDefault("REGIME_LOW_PERCENTILE", 20)
+	viper.SetDefault("REGIME_TREND_ADX", 25) // ADX > 25 为强趋势 / ADX above 25 is a strong trend
 	viper.SetDefault("BINANCE_TEST_MODE", true)
+	viper.SetDefault("PAPER_TRADING", false)
+	viper.SetDefault("PAPER_INITIAL_BALANCE", 10000.0)
+	viper.SetDefault("PAPER_FEE_RATE", 0.0005) // 币安 USDT 合约普通用户吃单费率 / Binance USDT-M taker fee for regular users
 	viper.SetDefault("BINANCE_POSITION_MODE", "auto")
+	viper.SetDefault("BINANCE_KEY_CHECK_STRICT", false)
+	viper.SetDefault("HEDGE_ON_REVERSAL", false)
+	viper.SetDefault("BINANCE_MARGIN_TYPE", "")             // 留空保持交易所当前设置 / Empty keeps the exchange setting
+	viper.SetDefault("BINANCE_MARGIN_TYPES", "")            // 按交易对覆盖，如 BTC/USDT:isolated / Per-symbol overrides, e.g. BTC/USDT:isolated
+	viper.SetDefault("BINANCE_MAX_WEIGHT_PER_MINUTE", 1800) // 币安合约上限 2400，预留 25% 余量 / Binance futures allows 2400, keep 25% headroom
+	viper.SetDefault("EXECUTOR_SLOW_CALL_MS", 2000)         // 单次调用超过 2 秒告警 / Warn on calls slower than 2s
+	viper.SetDefault("EXECUTOR_ERROR_RATE_ALERT", 0.2)      // 近期 20% 调用失败时告警 / Warn when 20% of recent calls fail
+
+	// Order execution defaults
+	// 下单执行默认值
+	viper.SetDefault("ORDER_EXECUTION_MODE", "market")   // 默认市价开仓 / Market entry by default
+	viper.SetDefault("LIMIT_ORDER_OFFSET_PERCENT", 0.02) // 限价偏移 0.02% / Limit price offset 0.02%
+	viper.SetDefault("LIMIT_ORDER_TIMEOUT_SECONDS", 15)  // 等待成交 15 秒 / Wait 15 seconds for fill
+	viper.SetDefault("LIMIT_ORDER_FALLBACK", "market")   // 超时后转市价 / Fall back to market after timeout
+	viper.SetDefault("PARTIAL_FILL_POLICY", "market")    // 剩余数量转市价 / Fill the remainder at market
+	viper.SetDefault("PARTIAL_FILL_CHASE_ATTEMPTS", 3)   // 最多追单 3 次 / Chase at most 3 times
+	viper.SetDefault("ICEBERG_THRESHOLD_NOTIONAL", 0)    // 默认禁用冰山单 / Iceberg orders disabled by default
+	viper.SetDefault("ICEBERG_SLICE_NOTIONAL", 1000)     // 每片 1000 USDT / 1000 USDT per slice
+	viper.SetDefault("ICEBERG_SLICE_DELAY_SECONDS", 2)   // 分片间隔 2 秒 / 2 seconds between slices
+	viper.SetDefault("BRACKET_ENTRY_ENABLED", false)     // 默认由止损管理器单独下止损 / Stop manager places the stop alone by default
+	viper.SetDefault("MAX_SLIPPAGE_BPS", 50)             // 预计滑点上限 0.5% / Expected slippage capped at 0.5%
+	viper.SetDefault("SLIPPAGE_GUARD_ACTION", "limit")   // 超限时改用 IOC 限价单 / Switch to an IOC limit order when exceeded
+	viper.SetDefault("ORDER_VALIDATION_ONLY", false)     // 默认正常下单 / Orders are sent by default
+	viper.SetDefault("USER_DATA_STREAM_ENABLED", true)   // 默认启用实时推送 / Real-time push enabled by default
+	viper.SetDefault("PRICE_STREAM_ENABLED", true)       // 默认启用共享价格缓存 / Shared price cache enabled by default
+	viper.SetDefault("KLINE_STREAM_ENABLED", true)       // 默认从 K 线流读取 K 线 / Candles read from the kline stream by default
+
+	// DCA entry ladder defaults
+	// DCA 分批挂单开仓默认值
+	viper.SetDefault("ENTRY_LADDER_LEVELS", 1)            // 默认一次性开仓 / Whole entry at once by default
+	viper.SetDefault("ENTRY_LADDER_SPACING_PERCENT", 0.5) // 每层间隔 0.5% / 0.5% between rungs
+	viper.SetDefault("ENTRY_LADDER_WEIGHTS", "")          // 默认等权 / Equal weights by default
+	viper.SetDefault("ENTRY_LADDER_TIMEOUT_MINUTES", 240) // 4 小时后撤销未成交挂单 / Cancel unfilled rungs after 4 hours
 
 	viper.SetDefault("CRYPTO_SYMBOL", "BTC/USDT")
 	viper.SetDefault("CRYPTO_TIMEFRAME", "1h")
+	viper.SetDefault("ADAPTIVE_INTERVAL_ENABLED", false) // 默认固定运行间隔 / Fixed interval by default
+	viper.SetDefault("ADAPTIVE_INTERVAL_MIN", "15m")     // 最短 15 分钟 / At most every 15 minutes
+	viper.SetDefault("ADAPTIVE_INTERVAL_MAX", "4h")      // 最长 4 小时 / At least every 4 hours
+	viper.SetDefault("ADAPTIVE_HIGH_PERCENTILE", 80)     // 波动率处于前 20% 时收紧 / Tighten in the top 20% of volatility
+	viper.SetDefault("ADAPTIVE_LOW_PERCENTILE", 20)      // 波动率处于后 20% 时放宽 / Relax in the bottom 20% of volatility
 	// POSITION_SIZE removed - now uses LLM's position size recommendation
 	// 移除 POSITION_SIZE - 现在使用 LLM 的仓位建议
+	viper.SetDefault("POSITION_SIZING_MODE", "llm")      // 默认直接使用 LLM 建议 / Use the LLM's size by default
+	viper.SetDefault("POSITION_RISK_PERCENT", 1.0)       // 单笔承担 1% 权益风险 / Risk 1% of equity per trade
+	viper.SetDefault("POSITION_VOL_TARGET_PERCENT", 1.0) // 一个 ATR 波动对应 1% 权益 / One ATR move costs 1% of equity
+	viper.SetDefault("POSITION_KELLY_FRACTION", 0.5)     // 半凯利 / Half Kelly
+	viper.SetDefault("POSITION_KELLY_MAX_PERCENT", 2.0)  // 单笔风险不超过 2% / Never risk more than 2% per trade
+	viper.SetDefault("POSITION_KELLY_MIN_TRADES", 20)    // 至少 20 笔已平仓交易 / At least 20 closed trades
+	viper.SetDefault("RISK_SIZING_RISK_PERCENT", 0.5)    // 未给出仓位时单笔承担 0.5% 权益风险 / Risk 0.5% of equity when no size is given
+	viper.SetDefault("RISK_SIZING_MARGIN_PERCENT", 30.0) // 单笔保证金不超过 30% 权益 / At most 30% of equity as margin per entry
+	viper.SetDefault("MAX_TRADE_RISK_PERCENT", 3.0)      // 与 GUARDRAIL_MAX_RISK_PERCENT 一致 / Matches GUARDRAIL_MAX_RISK_PERCENT
+	viper.SetDefault("EXPOSURE_MAX_TOTAL_PERCENT", 0.0)  // 默认不限制总敞口 / No total exposure cap by default
+	viper.SetDefault("EXPOSURE_MAX_SYMBOL_PERCENT", 0.0) // 默认不限制单币敞口 / No per-symbol exposure cap by default
+
+	// Concurrent position limit defaults
+	// 同时持仓数量上限默认值
+	viper.SetDefault("MAX_OPEN_POSITIONS", 0) // 默认不限制持仓数量 / No position count limit by default
+
+	// Scheduled deleveraging defaults
+	// 计划降杠杆默认值
+	viper.SetDefault("DELEVERAGE_WINDOWS", "")        // 默认不启用 / Disabled by default
+	viper.SetDefault("DELEVERAGE_MAX_LEVERAGE", 0)    // 默认不限制杠杆 / No leverage ceiling by default
+	viper.SetDefault("DELEVERAGE_SIZE_PERCENT", 50.0) // 时段内仓位减半 / Half size inside a window
+
+	// Funding-cost-aware holding defaults
+	// 资金费成本感知持仓默认值
+	viper.SetDefault("FUNDING_COST_MAX_PERCENT", 0.0)  // 默认不检查 / Disabled by default
+	viper.SetDefault("FUNDING_COST_HORIZON_HOURS", 24) // 按持有 24 小时预估 / Project over 24 hours of holding
+
+	// Margin ratio auto-deleveraging defaults
+	// 保证金率自动减仓默认值
+	viper.SetDefault("MARGIN_DELEVERAGE_RATIO_PERCENT", 0.0) // 默认不启用 / Disabled by default
+	viper.SetDefault("MARGIN_DELEVERAGE_STEP_PERCENT", 25.0) // 每步减仓 25% / Close 25% per step
 
 	// Analysis defaults
 	// 分析选项默认值
 	viper.SetDefault("ENABLE_SENTIMENT_ANALYSIS", true) // 默认启用情绪分析 / Enable sentiment analysis by default
+	viper.SetDefault("KLINE_CROSSCHECK_ENABLED", false)
+	viper.SetDefault("KLINE_CROSSCHECK_SOURCE", "index")
+	viper.SetDefault("KLINE_CROSSCHECK_CANDLES", 5)
+	viper.SetDefault("KLINE_CROSSCHECK_TOLERANCE", 1.0) // 永续与指数的正常基差远小于 1% / The normal perp-to-index basis is far below 1%
+	viper.SetDefault("KLINE_CROSSCHECK_ACTION", "skip")
 
 	// Stop-loss management defaults
 	// 止损管理默认值
@@ -311,7 +912,34 @@ func setDefaults() {
 	// 追踪止损参数在 internal/executors/trailing_stop_calculator.go 中配置
 	viper.SetDefault("ENABLE_STOPLOSS", true)                      // 启用止损管理 / Enable stop-loss management
 	viper.SetDefault("TRAILING_STOP_ATR_PERIOD", 7)                // 追踪止损 ATR 周期，推荐 3（短期）/7（平衡）/14（长期）/ Trailing stop ATR period, recommended 3 (short) / 7 (balanced) / 14 (long)
+	viper.SetDefault("TRAILING_STOP_MODE", "local")                // 默认本地追踪止损 / Local trailing stop by default
 	viper.SetDefault("TAKE_PROFIT_MONITORING_INTERVAL", 10)        // 分批止盈监控间隔（秒），默认 10 秒 / Partial take-profit monitoring interval (seconds), default 10
+	viper.SetDefault("TRAILING_STOP_FORMULA", "atr")               // 默认 ATR 追踪公式 / ATR trailing formula by default
+	viper.SetDefault("TRAILING_STOP_FORMULAS", "")                 // 按交易对覆盖，如 SOL/USDT:chandelier / Per-symbol overrides, e.g. SOL/USDT:chandelier
+	viper.SetDefault("STOP_MONITORING_MODE", "exchange")           // 默认止损挂在交易所 / Stops rest on the exchange by default
+	viper.SetDefault("STOP_MONITORING_MODES", "")                  // 按交易对覆盖，如 SOL/USDT:local / Per-symbol overrides, e.g. SOL/USDT:local
+	viper.SetDefault("TAKE_PROFIT_MODE", "r_multiple")             // 默认按 1R/2R/3R 止盈 / Take profit at 1R/2R/3R by default
+	viper.SetDefault("TAKE_PROFIT_MODES", "")                      // 按交易对覆盖，如 SOL/USDT:structure / Per-symbol overrides, e.g. SOL/USDT:structure
+	viper.SetDefault("TAKE_PROFIT_STRUCTURE_MIN_R", 1.0)           // 结构位目标至少 1R / Structural targets at least 1R away
+
+	viper.SetDefault("TRAILING_THRESHOLD_AUTOTUNE", false)
+	viper.SetDefault("TRAILING_THRESHOLD_MIN", 0.1)
+	viper.SetDefault("TRAILING_THRESHOLD_MAX", 1.0)
+	viper.SetDefault("TRAILING_THRESHOLD_WINDOW", 10)
+
+	viper.SetDefault("ATR_SCALING_ENABLED", false)
+	viper.SetDefault("ATR_SCALING_MIN_SCALE", 0.8) // 波动最低时收紧到 0.8 倍 / Tighten to 0.8x at the lowest volatility
+	viper.SetDefault("ATR_SCALING_MAX_SCALE", 1.5) // 波动最高时放宽到 1.5 倍 / Widen to 1.5x at the highest volatility
+
+	viper.SetDefault("BREAKEVEN_ENABLED", false)
+	viper.SetDefault("BREAKEVEN_TRIGGER_PERCENT", 0.0) // 默认只按 R 触发 / Trigger on R only by default
+	viper.SetDefault("BREAKEVEN_TRIGGER_R", 1.0)       // 盈利 1R 时保本 / Break even at 1R
+	viper.SetDefault("BREAKEVEN_BUFFER_PERCENT", 0.1)  // 覆盖开平仓两次吃单手续费（2 × 0.05%）/ Covers taker fees on entry and exit (2 × 0.05%)
+	viper.SetDefault("PROFIT_LOCK_TIERS", "")          // 不锁定利润 / No profit lock
+	viper.SetDefault("HOLD_REEVAL_ENABLED", false)
+	viper.SetDefault("HOLD_REEVAL_PRICE_MOVE_PERCENT", 2.0)     // 价格变动 2% / 2% price move
+	viper.SetDefault("HOLD_REEVAL_STOP_PROXIMITY_PERCENT", 0.5) // 距离止损 0.5% 以内 / Within 0.5% of the stop
+	viper.SetDefault("HOLD_REEVAL_FUNDING_FLIP", true)
 
 	viper.SetDefault("USE_MEMORY", true)
 	viper.SetDefault("MEMORY_TOP_K", 3)
@@ -319,10 +947,53 @@ func setDefaults() {
 	viper.SetDefault("DEBUG_MODE", false)
 	viper.SetDefault("SELECTED_ANALYSTS", "market,crypto,sentiment")
 	viper.SetDefault("AUTO_EXECUTE", false)
+	viper.SetDefault("DEBUG_CAPTURE_SYMBOLS", "")
+	viper.SetDefault("DEBUG_CAPTURE_CYCLES", 3)
+	viper.SetDefault("DEBUG_CAPTURE_MAX_MB", 20)
 
 	viper.SetDefault("WEB_PORT", 8080)
 	viper.SetDefault("WEB_USERNAME", "admin")
 	viper.SetDefault("WEB_PASSWORD", "changeme")
+	viper.SetDefault("WEB_ANALYZE_COOLDOWN_MINUTES", 5) // 每个交易对 5 分钟内最多一次即时分析 / At most one on-demand analysis per symbol every 5 minutes
+	viper.SetDefault("WEB_JOB_MAX_CONCURRENT", 1)       // 后台任务逐个运行 / Background jobs run one at a time
+
+	viper.SetDefault("TIMEZONE", "") // 为空使用服务器本地时区 / Server local time when empty
+
+	viper.SetDefault("RISK_FREE_RATE", 0.0)                                    // 默认不扣除无风险收益 / No risk-free deduction by default
+	viper.SetDefault("LEADERBOARD_KEY_PATH", "./data/leaderboard_ed25519.pem") // 与数据库放在同一目录 / Next to the database
+
+	viper.SetDefault("BALANCE_DISCREPANCY_THRESHOLD", 10.0) // 余额无法解释的变动超过 10 USDT 时暂停开仓 / Halt entries on unexplained balance change above 10 USDT
+	viper.SetDefault("BOT_INSTANCE_ID", "")                 // 为空时使用主机名 / Hostname when empty
+
+	// Notification defaults
+	// 通知默认值
+	viper.SetDefault("TELEGRAM_BOT_TOKEN", "")
+	viper.SetDefault("TELEGRAM_CHAT_ID", "")
+	viper.SetDefault("NOTIFY_DIGEST_MINUTES", 0) // 默认逐条发送 / Send each notification by default
+	viper.SetDefault("DAILY_SUMMARY_ENABLED", true)
+	viper.SetDefault("WEEKLY_REVIEW_ENABLED", false) // 调用深度模型有成本，默认关闭 / Off by default since it calls the deep model
+
+	// Dead man's switch defaults
+	// 死人开关默认值
+	viper.SetDefault("DEAD_MAN_SWITCH_ENABLED", false)
+	viper.SetDefault("DEAD_MAN_SWITCH_FLATTEN", false)       // 默认保留持仓和止损单 / Keep positions and their stops by default
+	viper.SetDefault("DEAD_MAN_SWITCH_TIMEOUT_SECONDS", 120) // 失联 2 分钟后触发 / Trip after 2 minutes without contact
+	viper.SetDefault("DEAD_MAN_SWITCH_COUNTDOWN", false)
+
+	// Liquidation monitoring defaults
+	// 强平监控默认值
+	viper.SetDefault("LIQUIDATION_MONITOR_ENABLED", true)
+	viper.SetDefault("LIQUIDATION_BUFFER_PERCENT", 10.0)     // 距强平价 10% 以内告警 / Alert within 10% of liquidation
+	viper.SetDefault("LIQUIDATION_AUTO_REDUCE_PERCENT", 0.0) // 默认只告警不减仓 / Alert only by default
+
+	// Startup reconciliation defaults
+	// 启动对账默认值
+	viper.SetDefault("STARTUP_RECONCILE_ENABLED", true)
+	viper.SetDefault("STARTUP_ADOPT_POSITIONS", true)
+
+	// Residual position cleanup defaults
+	// 残余持仓清理默认值
+	viper.SetDefault("DUST_CLEANUP_ENABLED", true)
 }
 
 func getProjectDir() string {
@@ -357,6 +1028,285 @@ func (c *Config) GetBinanceSymbolFor(symbol string) string {
 	return strings.ReplaceAll(symbol, "/", "")
 }
 
+// AnalystSelected reports whether an analyst is listed in SELECTED_ANALYSTS
+// AnalystSelected 判断分析师是否列在 SELECTED_ANALYSTS 中
+func (c *Config) AnalystSelected(name string) bool {
+	for _, analyst := range c.SelectedAnalysts {
+		if strings.EqualFold(strings.TrimSpace(analyst), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Location returns the TIMEZONE zone used for display, reports and daily rollover, or the server's local time
+// Location 返回用于显示、报告和日切的 TIMEZONE 时区，未设置或无效时为服务器本地时区
+func (c *Config) Location() *time.Location {
+	if c.location != nil {
+		return c.location
+	}
+	if loc, err := loadTimezone(c.Timezone); err == nil {
+		return loc
+	}
+	return time.Local
+}
+
+// UseBinanceTestnet reports whether Binance clients should connect to the testnet
+// UseBinanceTestnet 判断币安客户端是否连接测试网
+//
+// Paper trading always reads live prices, so it overrides BINANCE_TEST_MODE for market data.
+// 模拟盘始终读取实盘价格，因此行情数据不受 BINANCE_TEST_MODE 影响。
+func (c *Config) UseBinanceTestnet() bool {
+	return c.BinanceTestMode && !c.PaperTrading
+}
+
+// MarginTypeFor returns the configured margin type of a symbol, or "" to keep the exchange setting
+// MarginTypeFor 返回交易对配置的保证金类型，返回 "" 表示保持交易所当前设置
+func (c *Config) MarginTypeFor(symbol string) string {
+	if marginType, ok := c.BinanceMarginTypes[strings.ToUpper(c.GetBinanceSymbolFor(symbol))]; ok {
+		return marginType
+	}
+	return c.BinanceMarginType
+}
+
+// normalizeMarginType lowercases a margin type and returns "" for anything but cross or isolated
+// normalizeMarginType 将保证金类型转为小写，非 cross/isolated 的值返回 ""
+func normalizeMarginType(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "cross", "crossed":
+		return "cross"
+	case "isolated":
+		return "isolated"
+	}
+	return ""
+}
+
+// parseMarginTypes parses "BTC/USDT:isolated,ETH/USDT:cross" into a map keyed by Binance symbol; invalid entries are skipped
+// parseMarginTypes 将 "BTC/USDT:isolated,ETH/USDT:cross" 解析为以币安格式为键的映射，无效条目被跳过
+func parseMarginTypes(value string) map[string]string {
+	marginTypes := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		symbol := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(parts[0]), "/", ""))
+		marginType := normalizeMarginType(parts[1])
+		if symbol == "" || marginType == "" {
+			continue
+		}
+		marginTypes[symbol] = marginType
+	}
+	return marginTypes
+}
+
+// normalizeTrailingFormula maps a trailing formula name to "atr", "chandelier", "psar", "supertrend", "percent" or "step"; unknown names return ""
+// normalizeTrailingFormula 将追踪公式名称规范为 "atr"、"chandelier"、"psar"、"supertrend"、"percent" 或 "step"；无法识别时返回 ""
+func normalizeTrailingFormula(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "atr":
+		return "atr"
+	case "chandelier":
+		return "chandelier"
+	case "psar", "sar":
+		return "psar"
+	case "supertrend", "super_trend", "st":
+		return "supertrend"
+	case "percent", "percentage", "pct":
+		return "percent"
+	case "step", "steps":
+		return "step"
+	}
+	return ""
+}
+
+// normalizePositionSizingMode maps a sizing mode name to "fixed_fractional", "volatility_target" or "kelly"; anything else is "llm"
+// normalizePositionSizingMode 将仓位计算模式规范为 "fixed_fractional"、"volatility_target" 或 "kelly"；其他值均为 "llm"
+func normalizePositionSizingMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "fixed_fractional", "fixed", "fractional":
+		return "fixed_fractional"
+	case "volatility_target", "volatility", "vol_target":
+		return "volatility_target"
+	case "kelly":
+		return "kelly"
+	}
+	return "llm"
+}
+
+// parseTrailingFormulas parses "SOL/USDT:chandelier,BTC/USDT:atr" into a map keyed by Binance symbol; invalid entries are skipped
+// parseTrailingFormulas 将 "SOL/USDT:chandelier,BTC/USDT:atr" 解析为以币安格式为键的映射，无效条目被跳过
+func parseTrailingFormulas(value string) map[string]string {
+	formulas := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		symbol := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(parts[0]), "/", ""))
+		formula := normalizeTrailingFormula(parts[1])
+		if symbol == "" || formula == "" {
+			continue
+		}
+		formulas[symbol] = formula
+	}
+	return formulas
+}
+
+// normalizeStopMonitoringMode maps a stop monitoring name to "exchange", "local" or "both"; unknown names return ""
+// normalizeStopMonitoringMode 将止损监控方式规范为 "exchange"、"local" 或 "both"；无法识别时返回 ""
+func normalizeStopMonitoringMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "exchange", "binance":
+		return "exchange"
+	case "local":
+		return "local"
+	case "both":
+		return "both"
+	}
+	return ""
+}
+
+// parseStopMonitoringModes parses "SOL/USDT:local,BTC/USDT:both" into a map keyed by Binance symbol; invalid entries are skipped
+// parseStopMonitoringModes 将 "SOL/USDT:local,BTC/USDT:both" 解析为以币安格式为键的映射，无效条目被跳过
+func parseStopMonitoringModes(value string) map[string]string {
+	modes := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		symbol := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(parts[0]), "/", ""))
+		mode := normalizeStopMonitoringMode(parts[1])
+		if symbol == "" || mode == "" {
+			continue
+		}
+		modes[symbol] = mode
+	}
+	return modes
+}
+
+// StopMonitoringModeFor returns how a symbol's stop is enforced: "exchange", "local" or "both"
+// StopMonitoringModeFor 返回交易对止损的执行方式："exchange"、"local" 或 "both"
+func (c *Config) StopMonitoringModeFor(symbol string) string {
+	if mode, ok := c.StopMonitoringModes[strings.ToUpper(c.GetBinanceSymbolFor(symbol))]; ok {
+		return mode
+	}
+	if c.StopMonitoringMode == "" {
+		return "exchange"
+	}
+	return c.StopMonitoringMode
+}
+
+// normalizeTakeProfitMode maps a take-profit ladder name to "r_multiple" or "structure"; unknown names return ""
+// normalizeTakeProfitMode 将止盈阶梯名称规范为 "r_multiple" 或 "structure"；无法识别时返回 ""
+func normalizeTakeProfitMode(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "r_multiple", "r":
+		return "r_multiple"
+	case "structure":
+		return "structure"
+	}
+	return ""
+}
+
+// parseTakeProfitModes parses "SOL/USDT:structure,BTC/USDT:r_multiple" into a map keyed by Binance symbol; invalid entries are skipped
+// parseTakeProfitModes 将 "SOL/USDT:structure,BTC/USDT:r_multiple" 解析为以币安格式为键的映射，无效条目被跳过
+func parseTakeProfitModes(value string) map[string]string {
+	modes := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		symbol := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(parts[0]), "/", ""))
+		mode := normalizeTakeProfitMode(parts[1])
+		if symbol == "" || mode == "" {
+			continue
+		}
+		modes[symbol] = mode
+	}
+	return modes
+}
+
+// TakeProfitModeFor returns how a symbol's take-profit ladder is placed: "r_multiple" or "structure"
+// TakeProfitModeFor 返回交易对分批止盈阶梯的设置方式："r_multiple" 或 "structure"
+func (c *Config) TakeProfitModeFor(symbol string) string {
+	if mode, ok := c.TakeProfitModes[strings.ToUpper(c.GetBinanceSymbolFor(symbol))]; ok {
+		return mode
+	}
+	if c.TakeProfitMode == "" {
+		return "r_multiple"
+	}
+	return c.TakeProfitMode
+}
+
+// leverageRegimes are the regime names accepted in LEVERAGE_REGIME_CAPS
+// leverageRegimes 是 LEVERAGE_REGIME_CAPS 中可用的市场状态名称
+var leverageRegimes = map[string]bool{"high_volatility": true, "trending": true, "ranging": true, "low_volatility": true}
+
+// parseLeverageRegimeCaps parses "high_volatility:5,ranging:10", skipping unknown regimes and non-positive ceilings
+// parseLeverageRegimeCaps 解析 "high_volatility:5,ranging:10"，跳过未知的状态和非正数上限
+func parseLeverageRegimeCaps(value string) map[string]int {
+	caps := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		regime := strings.ToLower(strings.TrimSpace(parts[0]))
+		ceiling := 0
+		fmt.Sscanf(strings.TrimSpace(parts[1]), "%d", &ceiling)
+		if ceiling <= 0 || !leverageRegimes[regime] {
+			continue
+		}
+		caps[regime] = ceiling
+	}
+	return caps
+}
+
+// parseProfitLockTiers parses "4:50,8:70" into lock percentages keyed by trigger profit, skipping non-positive
+// triggers and locks outside (0, 100]
+// parseProfitLockTiers 将 "4:50,8:70" 解析为以触发盈利为键的锁定比例，跳过非正数触发值和 (0, 100] 之外的锁定比例
+func parseProfitLockTiers(value string) map[float64]float64 {
+	tiers := make(map[float64]float64)
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		trigger, lock := 0.0, 0.0
+		fmt.Sscanf(strings.TrimSpace(parts[0]), "%g", &trigger)
+		fmt.Sscanf(strings.TrimSpace(parts[1]), "%g", &lock)
+		if trigger <= 0 || lock <= 0 || lock > 100 {
+			continue
+		}
+		tiers[trigger] = lock
+	}
+	return tiers
+}
+
+// parseEntryLadderWeights parses "1,1,2" into rung weights, stopping at the first entry that is not a positive number
+// parseEntryLadderWeights 将 "1,1,2" 解析为各层权重，遇到第一个非正数条目即停止
+func parseEntryLadderWeights(value string) []float64 {
+	var weights []float64
+	for _, entry := range strings.Split(value, ",") {
+		weight := 0.0
+		if _, err := fmt.Sscanf(strings.TrimSpace(entry), "%g", &weight); err != nil || weight <= 0 {
+			break
+		}
+		weights = append(weights, weight)
+	}
+	return weights
+}
+
+// LeverageCapFor returns the leverage ceiling of a market regime, 0 when the regime is not capped
+// LeverageCapFor 返回市场状态的杠杆上限，未设置上限时返回 0
+func (c *Config) LeverageCapFor(regime string) int {
+	return c.LeverageRegimeCaps[regime]
+}
+
 // GetAllBinanceSymbols returns all trading pairs in Binance format
 // GetAllBinanceSymbols 返回所有交易对的币安格式
 func (c *Config) GetAllBinanceSymbols() []string {
@@ -377,6 +1327,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("BINANCE_API_KEY and BINANCE_API_SECRET are required")
 	}
 
+	if (c.BinanceAPIKeyBackup == "") != (c.BinanceAPISecretBackup == "") {
+		return fmt.Errorf("BINANCE_API_KEY_BACKUP and BINANCE_API_SECRET_BACKUP must be set together")
+	}
+
+	if c.MarginDeleverageRatioPercent > 0 && (c.MarginDeleverageStepPercent <= 0 || c.MarginDeleverageStepPercent >= 100) {
+		return fmt.Errorf("MARGIN_DELEVERAGE_STEP_PERCENT must be between 0 and 100 (exclusive)")
+	}
+
+	if c.LLMQuotaMinRemainingPercent < 0 || c.LLMQuotaMinRemainingPercent >= 100 || c.LLMQuotaMaxWaitSeconds < 0 {
+		return fmt.Errorf("LLM_QUOTA_MIN_REMAINING_PERCENT must be between 0 and 100 and LLM_QUOTA_MAX_WAIT_SECONDS must not be negative")
+	}
+
+	if c.RiskGateEnabled && (c.RiskGateMinLiquidationDistancePercent < 0 || c.RiskGateMinLiquidationDistancePercent >= 100 || c.RiskGateCooldownMinutes < 0) {
+		return fmt.Errorf("RISK_GATE_MIN_LIQUIDATION_DISTANCE_PERCENT must be between 0 and 100 and RISK_GATE_COOLDOWN_MINUTES must not be negative")
+	}
+
+	if c.MaxTradeRiskPercent < 0 || c.MaxTradeRiskPercent >= 100 {
+		return fmt.Errorf("MAX_TRADE_RISK_PERCENT must be between 0 and 100")
+	}
+
 	// PositionSize validation removed - now relies on LLM's position size recommendation
 	// 移除 PositionSize 验证 - 现在依赖 LLM 的仓位建议
 
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadConfig(t *testing.T) {
@@ -44,3 +46,141 @@ func TestCalculateLookbackDays(t *testing.T) {
 		})
 	}
 }
+
+func TestMarginTypeFor(t *testing.T) {
+	cfg := &Config{
+		BinanceMarginType:  normalizeMarginType(" Cross "),
+		BinanceMarginTypes: parseMarginTypes("SOL/USDT:isolated, ETHUSDT:CROSSED,DOGE/USDT:bogus,broken"),
+	}
+
+	tests := []struct {
+		symbol   string
+		expected string
+	}{
+		{"SOL/USDT", "isolated"},
+		{"ETH/USDT", "cross"},
+		{"DOGE/USDT", "cross"},
+		{"BTC/USDT", "cross"},
+	}
+
+	for _, tt := range tests {
+		if got := cfg.MarginTypeFor(tt.symbol); got != tt.expected {
+			t.Errorf("MarginTypeFor(%s) = %s, expected %s", tt.symbol, got, tt.expected)
+		}
+	}
+
+	// An empty default keeps the exchange setting for unlisted symbols
+	// 默认值为空时，未列出的交易对保持交易所当前设置
+	cfg.BinanceMarginType = normalizeMarginType("")
+	if got := cfg.MarginTypeFor("BTC/USDT"); got != "" {
+		t.Errorf("MarginTypeFor(BTC/USDT) = %q, expected empty", got)
+	}
+}
+
+func TestAnalystSelected(t *testing.T) {
+	cfg := &Config{SelectedAnalysts: strings.Split("market, crypto,Funding", ",")}
+
+	if !cfg.AnalystSelected("funding") {
+		t.Error("Expected funding to be selected")
+	}
+	if cfg.AnalystSelected("sentiment") {
+		t.Error("Expected sentiment not to be selected")
+	}
+}
+
+func TestLocation(t *testing.T) {
+	if loc := (&Config{}).Location(); loc != time.Local {
+		t.Errorf("Expected an empty TIMEZONE to use the server zone, got %s", loc)
+	}
+	if loc := (&Config{Timezone: "Asia/Shanghai"}).Location(); loc.String() != "Asia/Shanghai" {
+		t.Errorf("Expected Asia/Shanghai, got %s", loc)
+	}
+	if _, err := loadTimezone("Mars/Olympus"); err == nil {
+		t.Error("Expected an unknown zone to be rejected")
+	}
+}
+
+func TestStopMonitoringModeFor(t *testing.T) {
+	cfg := &Config{
+		StopMonitoringMode:  normalizeStopMonitoringMode("Both"),
+		StopMonitoringModes: parseStopMonitoringModes("SOL/USDT:local, ETHUSDT:Binance,DOGE/USDT:bogus"),
+	}
+
+	tests := []struct {
+		symbol   string
+		expected string
+	}{
+		{"SOL/USDT", "local"},
+		{"ETH/USDT", "exchange"},
+		{"DOGE/USDT", "both"},
+		{"BTCUSDT", "both"},
+	}
+	for _, tt := range tests {
+		if got := cfg.StopMonitoringModeFor(tt.symbol); got != tt.expected {
+			t.Errorf("StopMonitoringModeFor(%s) = %s, expected %s", tt.symbol, got, tt.expected)
+		}
+	}
+
+	if got := (&Config{}).StopMonitoringModeFor("BTC/USDT"); got != "exchange" {
+		t.Errorf("Expected exchange by default, got %s", got)
+	}
+}
+
+func TestTakeProfitModeFor(t *testing.T) {
+	cfg := &Config{
+		TakeProfitMode:  normalizeTakeProfitMode("r_multiple"),
+		TakeProfitModes: parseTakeProfitModes("SOL/USDT:Structure, DOGE/USDT:bogus"),
+	}
+	if got := cfg.TakeProfitModeFor("SOLUSDT"); got != "structure" {
+		t.Errorf("Expected structure for SOLUSDT, got %s", got)
+	}
+	if got := cfg.TakeProfitModeFor("DOGE/USDT"); got != "r_multiple" {
+		t.Errorf("Expected an invalid override to be skipped, got %s", got)
+	}
+	if got := (&Config{}).TakeProfitModeFor("BTC/USDT"); got != "r_multiple" {
+		t.Errorf("Expected r_multiple by default, got %s", got)
+	}
+}
+
+func TestLeverageCapFor(t *testing.T) {
+	cfg := &Config{LeverageRegimeCaps: parseLeverageRegimeCaps("High_Volatility:5, ranging:10,calm:3,trending:0")}
+
+	tests := []struct {
+		regime   string
+		expected int
+	}{
+		{"high_volatility", 5},
+		{"ranging", 10},
+		{"trending", 0},
+		{"calm", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := cfg.LeverageCapFor(tt.regime); got != tt.expected {
+			t.Errorf("LeverageCapFor(%q) = %d, expected %d", tt.regime, got, tt.expected)
+		}
+	}
+}
+
+func TestParseEntryLadderWeights(t *testing.T) {
+	weights := parseEntryLadderWeights(" 1, 1.5 ,2")
+	if len(weights) != 3 || weights[0] != 1 || weights[1] != 1.5 || weights[2] != 2 {
+		t.Errorf("Expected [1 1.5 2], got %v", weights)
+	}
+	if weights := parseEntryLadderWeights("1,0,2"); len(weights) != 1 {
+		t.Errorf("Expected parsing to stop at a non-positive weight, got %v", weights)
+	}
+	if weights := parseEntryLadderWeights(""); weights != nil {
+		t.Errorf("Expected no weights when unset, got %v", weights)
+	}
+}
+
+func TestParseProfitLockTiers(t *testing.T) {
+	tiers := parseProfitLockTiers(" 4:50, 8:70 ,0:30,5:0,6:120,bad")
+	if len(tiers) != 2 || tiers[4] != 50 || tiers[8] != 70 {
+		t.Errorf("Expected {4:50 8:70}, got %v", tiers)
+	}
+	if tiers := parseProfitLockTiers(""); len(tiers) != 0 {
+		t.Errorf("Expected no tiers when unset, got %v", tiers)
+	}
+}
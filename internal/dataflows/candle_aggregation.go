@@ -0,0 +1,119 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/scheduler"
+)
+
+// aggregationBases are the native Binance intervals a custom timeframe can be built from, longest first
+// aggregationBases 是可用于合成自定义时间周期的币安原生周期，按从长到短排列
+var aggregationBases = []struct {
+	timeframe string
+	length    time.Duration
+}{
+	{"12h", 12 * time.Hour},
+	{"8h", 8 * time.Hour},
+	{"6h", 6 * time.Hour},
+	{"4h", 4 * time.Hour},
+	{"2h", 2 * time.Hour},
+	{"1h", time.Hour},
+	{"30m", 30 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"5m", 5 * time.Minute},
+	{"3m", 3 * time.Minute},
+	{"1m", time.Minute},
+}
+
+// maxAggregatedCandles caps an aggregated fetch at the 1000 candles a native request returns
+// maxAggregatedCandles 将合成周期的获取数量限制为原生请求返回的 1000 根
+const maxAggregatedCandles = 1000
+
+// aggregationBase returns the native interval a timeframe Binance does not offer is built from, with both lengths
+// aggregationBase 返回币安不提供的时间周期所基于的原生周期及两者的长度
+// It is the longest native interval that divides the timeframe, so every aggregated candle holds whole base candles.
+// Native and unparsable timeframes return ok=false.
+// 取能整除该时间周期的最长原生周期，使每根合成 K 线都由完整的基础 K 线组成。原生或无法解析的时间周期返回 ok=false。
+func aggregationBase(timeframe string) (base string, baseLength, period time.Duration, ok bool) {
+	if convertTimeframe(timeframe) == timeframe {
+		return "", 0, 0, false
+	}
+	period, err := scheduler.TimeframeDuration(timeframe)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	for _, b := range aggregationBases {
+		if b.length < period && period%b.length == 0 {
+			return b.timeframe, b.length, period, true
+		}
+	}
+	return "", 0, 0, false
+}
+
+// NativeInterval returns the Binance interval to request for a timeframe: itself when native, else its aggregation base
+// NativeInterval 返回某时间周期应向币安请求的周期：原生周期返回自身，否则返回其合成基础周期
+func NativeInterval(timeframe string) string {
+	if base, _, _, ok := aggregationBase(timeframe); ok {
+		return base
+	}
+	return convertTimeframe(timeframe)
+}
+
+// AggregateOHLCV merges consecutive base candles into candles of period, aligned to UTC like exchange klines
+// AggregateOHLCV 将连续的基础 K 线合并为 period 长度的 K 线，与交易所 K 线一样按 UTC 对齐
+//
+// A leading bucket missing some of its base candles is dropped so the first candle is not a partial one; the last
+// bucket is kept even while forming, as the exchange returns the forming candle too.
+// 缺少部分基础 K 线的首个区间会被丢弃，避免第一根为不完整 K 线；最后一个区间即使尚未收盘也保留，与交易所返回未收盘 K 线一致。
+func AggregateOHLCV(bars []OHLCV, baseLength, period time.Duration) []OHLCV {
+	if len(bars) == 0 || baseLength <= 0 || period < baseLength {
+		return nil
+	}
+	perBucket := int(period / baseLength)
+	periodMs := period.Milliseconds()
+
+	var (
+		out    []OHLCV
+		counts []int
+	)
+	for _, bar := range bars {
+		open := time.UnixMilli(bar.Timestamp.UnixMilli() / periodMs * periodMs)
+		if n := len(out); n > 0 && out[n-1].Timestamp.Equal(open) {
+			candle := &out[n-1]
+			if bar.High > candle.High {
+				candle.High = bar.High
+			}
+			if bar.Low < candle.Low {
+				candle.Low = bar.Low
+			}
+			candle.Close = bar.Close
+			candle.Volume += bar.Volume
+			counts[n-1]++
+			continue
+		}
+		out = append(out, OHLCV{Timestamp: open, Open: bar.Open, High: bar.High, Low: bar.Low, Close: bar.Close, Volume: bar.Volume})
+		counts = append(counts, 1)
+	}
+
+	if len(out) > 1 && counts[0] < perBucket {
+		out = out[1:]
+	}
+	return out
+}
+
+// aggregatedOHLCV fetches the base candles between start and end and aggregates them into period candles
+// aggregatedOHLCV 获取 start 到 end 之间的基础 K 线并合成为 period 长度的 K 线
+func (m *MarketData) aggregatedOHLCV(ctx context.Context, symbol, base string, baseLength, period time.Duration, start, end time.Time) ([]OHLCV, error) {
+	// Start on a period boundary so the first candle is complete
+	// 从周期边界开始，使第一根 K 线完整
+	periodMs := period.Milliseconds()
+	start = time.UnixMilli(start.UnixMilli() / periodMs * periodMs)
+
+	bars, err := m.GetOHLCVRange(ctx, symbol, base, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s klines for aggregation: %w", base, err)
+	}
+	return AggregateOHLCV(bars, baseLength, period), nil
+}
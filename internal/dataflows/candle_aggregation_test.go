@@ -0,0 +1,62 @@
+package dataflows
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregationBase(t *testing.T) {
+	tests := []struct {
+		timeframe string
+		base      string
+		ok        bool
+	}{
+		{"90m", "30m", true},
+		{"45m", "15m", true},
+		{"20m", "5m", true},
+		{"1h", "", false},  // 原生周期 / Native interval
+		{"8h", "", false},  // 原生周期 / Native interval
+		{"7m", "", false},  // 不能整除一天 / Does not divide a day
+		{"bad", "", false}, // 无法解析 / Unparsable
+	}
+	for _, tt := range tests {
+		base, _, _, ok := aggregationBase(tt.timeframe)
+		if base != tt.base || ok != tt.ok {
+			t.Errorf("aggregationBase(%q) = %q, %v; want %q, %v", tt.timeframe, base, ok, tt.base, tt.ok)
+		}
+	}
+	if got := NativeInterval("90m"); got != "30m" {
+		t.Errorf("Expected 90m to be requested as 30m, got %s", got)
+	}
+}
+
+func TestAggregateOHLCV(t *testing.T) {
+	// 30m candles from 00:30 to 03:00 UTC: the 00:00 bucket is missing its first candle and is dropped
+	// 00:30 到 03:00 UTC 的 30m K 线：00:00 区间缺少第一根，被丢弃
+	start := time.Date(2026, 3, 2, 0, 30, 0, 0, time.UTC)
+	var bars []OHLCV
+	for i := 0; i < 6; i++ {
+		price := 100 + float64(i)
+		bars = append(bars, OHLCV{Timestamp: start.Add(time.Duration(i) * 30 * time.Minute),
+			Open: price, High: price + 2, Low: price - 1, Close: price + 1, Volume: 10})
+	}
+
+	candles := AggregateOHLCV(bars, 30*time.Minute, 90*time.Minute)
+	if len(candles) != 2 {
+		t.Fatalf("Expected 2 candles, got %d: %+v", len(candles), candles)
+	}
+
+	first := candles[0]
+	if !first.Timestamp.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the first candle to open at 01:30, got %s", first.Timestamp.UTC())
+	}
+	if first.Open != 102 || first.High != 106 || first.Low != 101 || first.Close != 105 || first.Volume != 30 {
+		t.Errorf("Unexpected first candle: %+v", first)
+	}
+
+	// The last bucket is kept while forming
+	// 最后一个区间尚未收盘也保留
+	if last := candles[1]; last.Open != 105 || last.Volume != 10 {
+		t.Errorf("Unexpected forming candle: %+v", last)
+	}
+}
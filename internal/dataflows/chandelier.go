@@ -0,0 +1,35 @@
+package dataflows
+
+import (
+	"math"
+)
+
+// ChandelierExit returns the chandelier exit levels at the latest bar
+// ChandelierExit 返回最新 K 线处的吊灯止损价位
+// The long stop hangs multiplier × ATR(period) below the highest high of the last period bars, the short stop
+// the same distance above the lowest low; ok is false when there are not enough bars to warm up the ATR
+// 多仓止损位于最近 period 根 K 线最高价下方 multiplier × ATR(period) 处，空仓止损位于最低价上方相同距离处；
+// K 线不足以预热 ATR 时 ok 为 false
+func ChandelierExit(data []OHLCV, period int, multiplier float64) (longStop, shortStop, atr float64, ok bool) {
+	if period <= 0 || len(data) < period+1 {
+		return 0, 0, 0, false
+	}
+
+	highs := make([]float64, len(data))
+	lows := make([]float64, len(data))
+	closes := make([]float64, len(data))
+	for i, d := range data {
+		highs[i], lows[i], closes[i] = d.High, d.Low, d.Close
+	}
+	atr = calculateATR(highs, lows, closes, period)[len(data)-1]
+	if math.IsNaN(atr) || atr <= 0 {
+		return 0, 0, 0, false
+	}
+
+	highest, lowest := highs[len(data)-period], lows[len(data)-period]
+	for i := len(data) - period + 1; i < len(data); i++ {
+		highest = math.Max(highest, highs[i])
+		lowest = math.Min(lowest, lows[i])
+	}
+	return highest - multiplier*atr, lowest + multiplier*atr, atr, true
+}
@@ -0,0 +1,37 @@
+package dataflows
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChandelierExit(t *testing.T) {
+	// Every bar spans 4 around a rising close, so the true range and the ATR are 4
+	// 每根 K 线围绕上涨的收盘价振幅为 4，因此真实波幅和 ATR 都是 4
+	data := make([]OHLCV, 30)
+	for i := range data {
+		c := 100 + float64(i)
+		data[i] = OHLCV{Open: c, High: c + 2, Low: c - 2, Close: c}
+	}
+	// A spike on the first bar is outside the highest-high window, and the first bar has no previous
+	// close so its true range never enters the ATR either
+	// 第一根 K 线的尖峰位于最高价窗口之外，且第一根 K 线没有前收盘价，其真实波幅也不计入 ATR
+	data[0].High = 500
+
+	longStop, shortStop, atr, ok := ChandelierExit(data, 5, 3)
+	if !ok {
+		t.Fatal("Expected a chandelier exit")
+	}
+	if math.Abs(atr-4) > 1e-9 {
+		t.Errorf("Expected ATR 4, got %.4f", atr)
+	}
+	// Highest high of the last 5 bars is 131, lowest low 123
+	// 最近 5 根 K 线最高价 131，最低价 123
+	if math.Abs(longStop-119) > 1e-9 || math.Abs(shortStop-135) > 1e-9 {
+		t.Errorf("Expected stops 119/135, got %.4f/%.4f", longStop, shortStop)
+	}
+
+	if _, _, _, ok := ChandelierExit(data[:5], 5, 3); ok {
+		t.Error("Expected too few bars to fail")
+	}
+}
@@ -0,0 +1,157 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Funding analyst parameters
+// 资金费率分析师参数
+const (
+	FundingHistoryLimit       = 90  // 资金费率历史条数（每 8 小时一次，约 30 天）/ Funding events kept (every 8h, about 30 days)
+	minFundingSamples         = 10  // 计算 z 分数所需的最少样本 / Minimum samples for a z-score
+	crowdingZScoreThreshold   = 2.0 // 资金费率 z 分数的极端阈值 / Funding z-score treated as extreme
+	crowdingOIChangeThreshold = 5.0 // 持仓量显著变化阈值（%）/ Open interest change treated as significant (%)
+	crowdingRatioTrendPercent = 10  // 多空比显著变化阈值（%）/ Long/short ratio change treated as significant (%)
+	crowdingScoreThreshold    = 2   // 判定拥挤所需的分数 / Score needed to call positioning crowded
+)
+
+// Crowding labels
+// 拥挤度标签
+const (
+	CrowdingLong    = "crowded_long"  // 多头拥挤 / Longs crowded
+	CrowdingShort   = "crowded_short" // 空头拥挤 / Shorts crowded
+	CrowdingNeutral = "neutral"       // 无明显拥挤 / No clear crowding
+)
+
+// CrowdingAssessment describes how crowded futures positioning is on one side
+// CrowdingAssessment 描述合约持仓在某一方向的拥挤程度
+type CrowdingAssessment struct {
+	FundingRate       float64 // 最新资金费率 / Latest funding rate
+	FundingMean       float64 // 历史资金费率均值 / Mean of the funding history
+	FundingZScore     float64 // 最新资金费率的 z 分数 / Z-score of the latest funding rate
+	HasFunding        bool    // 资金费率样本是否足够 / Whether enough funding samples were available
+	OIChangePercent   float64 // 窗口内持仓量变化（%）/ Open interest change over the window (%)
+	HasOpenInterest   bool    // 是否有持仓量数据 / Whether open interest data was available
+	LongShortRatio    float64 // 最新大户多空比 / Latest top trader long/short ratio
+	LongShortTrendPct float64 // 窗口内多空比变化（%）/ Long/short ratio change over the window (%)
+	HasLongShort      bool    // 是否有多空比数据 / Whether long/short ratio data was available
+	Score             int     // 正数偏多头拥挤，负数偏空头拥挤 / Positive leans crowded long, negative crowded short
+	Crowding          string  // 拥挤度标签 / Crowding label
+}
+
+// AssessCrowding scores positioning crowdedness from funding history, open interest and long/short ratio series
+// AssessCrowding 根据资金费率历史、持仓量和多空比序列评估持仓拥挤度
+//
+// All series are oldest to newest. Extreme funding counts once, open interest building up in the direction
+// funding leans counts once, and a rising or falling long/short ratio counts once. ok is false when no series is usable.
+// 所有序列均从旧到新。资金费率极端计 1 分，持仓量沿资金费率倾向方向增加计 1 分，多空比明显上升或下降计 1 分。
+// 没有可用序列时 ok 为 false。
+func AssessCrowding(fundingRates, oiValues, longShortRatios []float64) (CrowdingAssessment, bool) {
+	a := CrowdingAssessment{Crowding: CrowdingNeutral}
+
+	if len(fundingRates) >= minFundingSamples {
+		a.HasFunding = true
+		a.FundingRate = fundingRates[len(fundingRates)-1]
+		mean, std := meanStd(fundingRates)
+		a.FundingMean = mean
+		if std > 0 {
+			a.FundingZScore = (a.FundingRate - mean) / std
+		}
+		if a.FundingZScore >= crowdingZScoreThreshold {
+			a.Score++
+		} else if a.FundingZScore <= -crowdingZScoreThreshold {
+			a.Score--
+		}
+	}
+
+	if len(oiValues) >= 2 && oiValues[0] > 0 {
+		a.HasOpenInterest = true
+		a.OIChangePercent = (oiValues[len(oiValues)-1] - oiValues[0]) / oiValues[0] * 100
+		// New positions are being opened on the side that pays funding
+		// 支付资金费率的一方仍在加仓
+		if a.HasFunding && a.OIChangePercent >= crowdingOIChangeThreshold {
+			if a.FundingRate > 0 {
+				a.Score++
+			} else if a.FundingRate < 0 {
+				a.Score--
+			}
+		}
+	}
+
+	if len(longShortRatios) >= 2 && longShortRatios[0] > 0 {
+		a.HasLongShort = true
+		a.LongShortRatio = longShortRatios[len(longShortRatios)-1]
+		a.LongShortTrendPct = (a.LongShortRatio - longShortRatios[0]) / longShortRatios[0] * 100
+		if a.LongShortTrendPct >= crowdingRatioTrendPercent {
+			a.Score++
+		} else if a.LongShortTrendPct <= -crowdingRatioTrendPercent {
+			a.Score--
+		}
+	}
+
+	if !a.HasFunding && !a.HasOpenInterest && !a.HasLongShort {
+		return a, false
+	}
+	if a.Score >= crowdingScoreThreshold {
+		a.Crowding = CrowdingLong
+	} else if a.Score <= -crowdingScoreThreshold {
+		a.Crowding = CrowdingShort
+	}
+	return a, true
+}
+
+// FormatCrowdingReport formats a crowding assessment as the funding analyst's report section
+// FormatCrowdingReport 将拥挤度评估格式化为资金费率分析师的报告
+func FormatCrowdingReport(symbol string, a CrowdingAssessment, ok bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("=== %s 资金费率与持仓拥挤度 ===\n\n", symbol))
+	if !ok {
+		sb.WriteString("数据不足，无法评估持仓拥挤度\n")
+		return sb.String()
+	}
+
+	if a.HasFunding {
+		sb.WriteString(fmt.Sprintf("💰 资金费率: 最新 %.4f%%, 均值 %.4f%%, z 分数 %+.2f\n",
+			a.FundingRate*100, a.FundingMean*100, a.FundingZScore))
+	} else {
+		sb.WriteString("💰 资金费率: 历史样本不足\n")
+	}
+	if a.HasOpenInterest {
+		sb.WriteString(fmt.Sprintf("📊 持仓量变化(24h): %+.2f%%\n", a.OIChangePercent))
+	} else {
+		sb.WriteString("📊 持仓量变化: 数据获取失败\n")
+	}
+	if a.HasLongShort {
+		sb.WriteString(fmt.Sprintf("🐋 大户多空比: %.2f (24h 变化 %+.1f%%)\n", a.LongShortRatio, a.LongShortTrendPct))
+	} else {
+		sb.WriteString("🐋 大户多空比: 数据获取失败\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n拥挤度评分: %+d（正数偏多头拥挤，负数偏空头拥挤）\n", a.Score))
+	switch a.Crowding {
+	case CrowdingLong:
+		sb.WriteString("结论: 多头拥挤 — 多头持续支付高资金费率，警惕多头挤压（long squeeze），追多风险高\n")
+	case CrowdingShort:
+		sb.WriteString("结论: 空头拥挤 — 空头持续支付资金费率，警惕轧空（short squeeze），追空风险高\n")
+	default:
+		sb.WriteString("结论: 持仓无明显拥挤\n")
+	}
+	return sb.String()
+}
+
+// meanStd returns the mean and population standard deviation of values
+// meanStd 返回数值的均值和总体标准差
+func meanStd(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,111 @@
+package dataflows
+
+import (
+	"strings"
+	"testing"
+)
+
+// flatFunding returns n funding rates alternating around base, followed by last
+// flatFunding 返回围绕 base 交替的 n 个资金费率，最后追加 last
+func flatFunding(n int, base, last float64) []float64 {
+	rates := make([]float64, 0, n+1)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			rates = append(rates, base+0.00001)
+		} else {
+			rates = append(rates, base-0.00001)
+		}
+	}
+	return append(rates, last)
+}
+
+func TestAssessCrowding(t *testing.T) {
+	tests := []struct {
+		name     string
+		funding  []float64
+		oi       []float64
+		ratios   []float64
+		want     string
+		wantOK   bool
+		minScore int
+		maxScore int
+	}{
+		{
+			name:     "Extreme funding with rising OI and ratio",
+			funding:  flatFunding(30, 0.0001, 0.001),
+			oi:       []float64{100, 104, 110},
+			ratios:   []float64{1.5, 1.7, 1.8},
+			want:     CrowdingLong,
+			wantOK:   true,
+			minScore: 3, maxScore: 3,
+		},
+		{
+			name:     "Negative funding with rising OI and falling ratio",
+			funding:  flatFunding(30, 0.0001, -0.001),
+			oi:       []float64{100, 108},
+			ratios:   []float64{1.2, 1.0},
+			want:     CrowdingShort,
+			wantOK:   true,
+			minScore: -3, maxScore: -3,
+		},
+		{
+			name:     "Normal funding and flat positioning",
+			funding:  flatFunding(30, 0.0001, 0.0001),
+			oi:       []float64{100, 101},
+			ratios:   []float64{1.5, 1.52},
+			want:     CrowdingNeutral,
+			wantOK:   true,
+			minScore: 0, maxScore: 0,
+		},
+		{
+			name:     "Ratio trend alone is not crowded",
+			funding:  nil,
+			oi:       nil,
+			ratios:   []float64{1.0, 1.5},
+			want:     CrowdingNeutral,
+			wantOK:   true,
+			minScore: 1, maxScore: 1,
+		},
+		{
+			name:   "No data",
+			want:   CrowdingNeutral,
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AssessCrowding(tt.funding, tt.oi, tt.ratios)
+			if ok != tt.wantOK {
+				t.Fatalf("Expected ok=%v, got %v", tt.wantOK, ok)
+			}
+			if got.Crowding != tt.want {
+				t.Errorf("Expected %s, got %s (score %d)", tt.want, got.Crowding, got.Score)
+			}
+			if got.Score < tt.minScore || got.Score > tt.maxScore {
+				t.Errorf("Expected score in [%d, %d], got %d", tt.minScore, tt.maxScore, got.Score)
+			}
+		})
+	}
+}
+
+func TestAssessCrowdingNeedsEnoughFundingSamples(t *testing.T) {
+	got, ok := AssessCrowding([]float64{0.0001, 0.01}, nil, nil)
+	if ok || got.HasFunding {
+		t.Errorf("Expected two funding samples to be ignored, got ok=%v %+v", ok, got)
+	}
+}
+
+func TestFormatCrowdingReport(t *testing.T) {
+	a, ok := AssessCrowding(flatFunding(30, 0.0001, 0.001), []float64{100, 110}, []float64{1.5, 1.8})
+	report := FormatCrowdingReport("BTC/USDT", a, ok)
+	for _, want := range []string{"BTC/USDT", "z 分数", "多头拥挤"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q:\n%s", want, report)
+		}
+	}
+
+	if report := FormatCrowdingReport("BTC/USDT", CrowdingAssessment{}, false); !strings.Contains(report, "数据不足") {
+		t.Errorf("Expected a no-data report, got:\n%s", report)
+	}
+}
@@ -0,0 +1,74 @@
+package dataflows
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/capture"
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// Keep-alive tuning for Binance REST calls
+// 币安 REST 调用的长连接调优参数
+const (
+	httpMaxIdleConns        = 100              // 全局最大空闲连接数 / Max idle connections overall
+	httpMaxIdleConnsPerHost = 32               // 每个主机的最大空闲连接数 / Max idle connections per host
+	httpIdleConnTimeout     = 90 * time.Second // 空闲连接保持时间 / How long idle connections are kept
+	httpDialTimeout         = 5 * time.Second  // 建立连接超时 / TCP dial timeout
+	httpDialKeepAlive       = 30 * time.Second // TCP keep-alive 探测间隔 / TCP keep-alive probe interval
+	httpTLSHandshakeTimeout = 5 * time.Second  // TLS 握手超时 / TLS handshake timeout
+	httpRequestTimeout      = 30 * time.Second // 单次请求总超时 / Overall request timeout
+)
+
+var (
+	sharedHTTPClient     *http.Client
+	sharedHTTPClientOnce sync.Once
+)
+
+// getSharedHTTPClient returns a process-wide HTTP client so that TCP/TLS connections are reused across analysis cycles
+// getSharedHTTPClient 返回进程级共享的 HTTP 客户端，使 TCP/TLS 连接在多个分析周期之间复用
+func getSharedHTTPClient(cfg *config.Config) *http.Client {
+	sharedHTTPClientOnce.Do(func() {
+		// Debug capture sits inside the weight limiter so archiving a payload is not counted as waiting
+		// 调试采集位于权重限流器内层，归档响应不会计入限流等待
+		sharedHTTPClient = WithWeightLimit(cfg, capture.WithCapture(capture.Shared(cfg), newKeepAliveHTTPClient(cfg)))
+	})
+	return sharedHTTPClient
+}
+
+// newKeepAliveHTTPClient builds an HTTP client tuned for many small parallel requests to the same host
+// newKeepAliveHTTPClient 构建针对同一主机大量小型并行请求优化的 HTTP 客户端
+func newKeepAliveHTTPClient(cfg *config.Config) *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   httpDialTimeout,
+			KeepAlive: httpDialKeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          httpMaxIdleConns,
+		MaxIdleConnsPerHost:   httpMaxIdleConnsPerHost,
+		IdleConnTimeout:       httpIdleConnTimeout,
+		TLSHandshakeTimeout:   httpTLSHandshakeTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	// Set proxy if configured (http, https, socks5 or socks5h)
+	// 如果配置了代理，则设置代理（http、https、socks5 或 socks5h）
+	if cfg.BinanceProxy != "" {
+		if proxy, err := RESTProxy(cfg); err == nil {
+			transport.Proxy = proxy
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: cfg.BinanceProxyInsecureSkipTLS,
+			}
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   httpRequestTimeout,
+	}
+}
@@ -0,0 +1,34 @@
+package dataflows
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// TestNewKeepAliveHTTPClient tests keep-alive tuning and proxy wiring of the shared client
+// TestNewKeepAliveHTTPClient 测试共享客户端的长连接参数与代理设置
+func TestNewKeepAliveHTTPClient(t *testing.T) {
+	client := newKeepAliveHTTPClient(&config.Config{BinanceProxy: "http://127.0.0.1:7890"})
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected *http.Transport, got %T", client.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != httpMaxIdleConnsPerHost {
+		t.Errorf("Expected MaxIdleConnsPerHost %d, got %d", httpMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+	if transport.DisableKeepAlives {
+		t.Error("Keep-alives should be enabled")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://fapi.binance.com/fapi/v1/ping", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy func failed: %v", err)
+	}
+	if proxyURL == nil || proxyURL.Host != "127.0.0.1:7890" {
+		t.Errorf("Expected proxy 127.0.0.1:7890, got %v", proxyURL)
+	}
+}
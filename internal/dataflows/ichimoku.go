@@ -0,0 +1,137 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Ichimoku periods: Tenkan-sen, Kijun-sen, Senkou Span B, and how far ahead the spans are plotted
+// 一目均衡表周期：转换线、基准线、先行带 B，以及先行带向前平移的 K 线数
+const (
+	ichimokuTenkanPeriod  = 9
+	ichimokuKijunPeriod   = 26
+	ichimokuSenkouBPeriod = 52
+	ichimokuDisplacement  = 26
+)
+
+// CloudPosition is where a close sits relative to the Ichimoku cloud under its bar
+// CloudPosition 是收盘价相对其所在 K 线下方云层的位置
+type CloudPosition string
+
+const (
+	CloudAbove  CloudPosition = "above"  // 云层上方，看涨 / Above the cloud, bullish
+	CloudBelow  CloudPosition = "below"  // 云层下方，看跌 / Below the cloud, bearish
+	CloudInside CloudPosition = "inside" // 云层内部，趋势不明 / Inside the cloud, no clear trend
+)
+
+// Label returns the Chinese description of the position used in reports
+// Label 返回报告中使用的中文描述
+func (p CloudPosition) Label() string {
+	switch p {
+	case CloudAbove:
+		return "价格位于云层上方（看涨）"
+	case CloudBelow:
+		return "价格位于云层下方（看跌）"
+	case CloudInside:
+		return "价格位于云层内部（震荡/趋势不明）"
+	}
+	return "云层数据不足"
+}
+
+// calculateIchimoku returns the Tenkan-sen, Kijun-sen and both Senkou spans of every bar
+// calculateIchimoku 返回每根 K 线的转换线、基准线和两条先行带
+// The spans are the values computed at each bar, which the chart plots 26 bars ahead: the cloud under bar i is
+// spanA[i-26] / spanB[i-26], and the latest values are the cloud still to come. Values before warm-up are NaN
+// 先行带为每根 K 线处计算的值，图上向前平移 26 根绘制：第 i 根 K 线下方的云层为 spanA[i-26] / spanB[i-26]，
+// 最新值即未来的云层。预热期内的值为 NaN
+func calculateIchimoku(highs, lows []float64) (tenkan, kijun, spanA, spanB []float64) {
+	tenkan = midpointOfRange(highs, lows, ichimokuTenkanPeriod)
+	kijun = midpointOfRange(highs, lows, ichimokuKijunPeriod)
+	spanB = midpointOfRange(highs, lows, ichimokuSenkouBPeriod)
+	spanA = make([]float64, len(highs))
+	for i := range spanA {
+		spanA[i] = (tenkan[i] + kijun[i]) / 2 // NaN 会自然传播 / NaN propagates
+	}
+	return tenkan, kijun, spanA, spanB
+}
+
+// midpointOfRange returns (highest high + lowest low) / 2 over the period bars ending at each bar
+// midpointOfRange 返回截至每根 K 线的 period 根 K 线内（最高价 + 最低价）/ 2
+func midpointOfRange(highs, lows []float64, period int) []float64 {
+	result := make([]float64, len(highs))
+	for i := range highs {
+		if i < period-1 {
+			result[i] = math.NaN()
+			continue
+		}
+		high, low := highs[i], lows[i]
+		for j := i - period + 1; j < i; j++ {
+			high = math.Max(high, highs[j])
+			low = math.Min(low, lows[j])
+		}
+		result[i] = (high + low) / 2
+	}
+	return result
+}
+
+// cloudPositions returns the position of every close relative to the cloud under its bar, empty before warm-up
+// cloudPositions 返回每根 K 线收盘价相对其下方云层的位置，预热期内为空
+func cloudPositions(closes, spanA, spanB []float64) []CloudPosition {
+	result := make([]CloudPosition, len(closes))
+	for i := ichimokuDisplacement; i < len(closes); i++ {
+		result[i] = IchimokuCloudPosition(closes[i], spanA[i-ichimokuDisplacement], spanB[i-ichimokuDisplacement])
+	}
+	return result
+}
+
+// IchimokuCloudPosition places price relative to the cloud between two span values; it is empty when a span is NaN
+// IchimokuCloudPosition 判断价格相对两条先行带之间云层的位置；任一先行带为 NaN 时返回空
+func IchimokuCloudPosition(price, spanA, spanB float64) CloudPosition {
+	if math.IsNaN(spanA) || math.IsNaN(spanB) {
+		return ""
+	}
+	switch {
+	case price > math.Max(spanA, spanB):
+		return CloudAbove
+	case price < math.Min(spanA, spanB):
+		return CloudBelow
+	default:
+		return CloudInside
+	}
+}
+
+// formatIchimokuSummary describes the Ichimoku lines, the cloud under the latest bar and the cloud ahead, or returns
+// an empty string before the indicator has warmed up
+// formatIchimokuSummary 描述一目均衡表各线、最新 K 线下方的云层以及未来云层；指标未完成预热时返回空字符串
+func formatIchimokuSummary(price float64, indicators *TechnicalIndicators, lastIdx int) string {
+	cloudIdx := lastIdx - ichimokuDisplacement
+	if cloudIdx < 0 || len(indicators.Ichimoku_Tenkan) <= lastIdx || len(indicators.Ichimoku_SpanB) <= lastIdx {
+		return ""
+	}
+	tenkan, kijun := indicators.Ichimoku_Tenkan[lastIdx], indicators.Ichimoku_Kijun[lastIdx]
+	spanA, spanB := indicators.Ichimoku_SpanA[cloudIdx], indicators.Ichimoku_SpanB[cloudIdx]
+	leadA, leadB := indicators.Ichimoku_SpanA[lastIdx], indicators.Ichimoku_SpanB[lastIdx]
+	for _, v := range []float64{tenkan, kijun, spanA, spanB, leadA, leadB} {
+		if math.IsNaN(v) {
+			return ""
+		}
+	}
+
+	var sb strings.Builder
+	tk := "转换线在基准线上方（短期偏多）"
+	if tenkan < kijun {
+		tk = "转换线在基准线下方（短期偏空）"
+	} else if tenkan == kijun {
+		tk = "转换线与基准线持平"
+	}
+	sb.WriteString(fmt.Sprintf("一目均衡表(9/26/52): 转换线 = %.1f, 基准线 = %.1f, %s\n", tenkan, kijun, tk))
+	sb.WriteString(fmt.Sprintf("当前云层: 先行带A = %.1f, 先行带B = %.1f, %s\n",
+		spanA, spanB, IchimokuCloudPosition(price, spanA, spanB).Label()))
+	color := "绿云（先行带A在B上方，看涨）"
+	if leadA < leadB {
+		color = "红云（先行带A在B下方，看跌）"
+	}
+	sb.WriteString(fmt.Sprintf("未来云层(%d期后): 先行带A = %.1f, 先行带B = %.1f, %s\n", ichimokuDisplacement, leadA, leadB, color))
+	return sb.String()
+}
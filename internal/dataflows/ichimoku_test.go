@@ -0,0 +1,79 @@
+package dataflows
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// risingBars returns n bars with low i, high i+1 and close i+0.5
+// risingBars 返回 n 根最低价 i、最高价 i+1、收盘价 i+0.5 的 K 线
+func risingBars(n int) []OHLCV {
+	bars := make([]OHLCV, n)
+	for i := range bars {
+		f := float64(i)
+		bars[i] = OHLCV{Open: f, High: f + 1, Low: f, Close: f + 0.5}
+	}
+	return bars
+}
+
+func TestCalculateIchimoku(t *testing.T) {
+	ind := CalculateIndicators(risingBars(100))
+
+	// Tenkan warms up after 9 bars, Span B after 52
+	// 转换线 9 根 K 线后完成预热，先行带 B 需要 52 根
+	if !math.IsNaN(ind.Ichimoku_Tenkan[7]) || ind.Ichimoku_Tenkan[8] != 4.5 {
+		t.Errorf("Expected Tenkan NaN at bar 7 and 4.5 at bar 8, got %v and %v", ind.Ichimoku_Tenkan[7], ind.Ichimoku_Tenkan[8])
+	}
+	if !math.IsNaN(ind.Ichimoku_SpanB[50]) || ind.Ichimoku_SpanB[51] != 26 {
+		t.Errorf("Expected Span B NaN at bar 50 and 26 at bar 51, got %v and %v", ind.Ichimoku_SpanB[50], ind.Ichimoku_SpanB[51])
+	}
+
+	// On a steady rise: Tenkan = i-3.5, Kijun = i-12, Span A = i-7.75, Span B = i-25
+	// 稳定上涨时：转换线 = i-3.5，基准线 = i-12，先行带 A = i-7.75，先行带 B = i-25
+	if got := []float64{ind.Ichimoku_Tenkan[99], ind.Ichimoku_Kijun[99], ind.Ichimoku_SpanA[99], ind.Ichimoku_SpanB[99]}; got[0] != 95.5 || got[1] != 87 || got[2] != 91.25 || got[3] != 74 {
+		t.Errorf("Unexpected Ichimoku lines at bar 99: %v", got)
+	}
+
+	// The cloud under bar i comes from bar i-26, so Span B's warm-up delays the position until bar 77
+	// 第 i 根 K 线下方的云层来自第 i-26 根，因此先行带 B 的预热使位置从第 77 根开始才有值
+	if ind.CloudPosition[76] != "" || ind.CloudPosition[77] != CloudAbove || ind.CloudPosition[99] != CloudAbove {
+		t.Errorf("Expected no position at bar 76 and above from bar 77, got %q, %q, %q",
+			ind.CloudPosition[76], ind.CloudPosition[77], ind.CloudPosition[99])
+	}
+}
+
+func TestIchimokuCloudPosition(t *testing.T) {
+	tests := []struct {
+		price, spanA, spanB float64
+		want                CloudPosition
+	}{
+		{110, 100, 105, CloudAbove},
+		{90, 100, 105, CloudBelow},
+		{102, 105, 100, CloudInside},
+		{100, 100, 105, CloudInside},
+		{100, math.NaN(), 105, ""},
+	}
+	for _, tt := range tests {
+		if got := IchimokuCloudPosition(tt.price, tt.spanA, tt.spanB); got != tt.want {
+			t.Errorf("IchimokuCloudPosition(%v, %v, %v) = %q, want %q", tt.price, tt.spanA, tt.spanB, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIndicatorReportIchimoku(t *testing.T) {
+	bars := risingBars(100)
+	report := FormatIndicatorReport("BTCUSDT", "1h", bars, CalculateIndicators(bars))
+	for _, want := range []string{"一目均衡表(9/26/52): 转换线 = 95.5, 基准线 = 87.0", "价格位于云层上方（看涨）", "绿云"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q:\n%s", want, report)
+		}
+	}
+
+	// Too little history leaves the section out
+	// 历史数据不足时不输出该部分
+	short := risingBars(40)
+	if report := FormatIndicatorReport("BTCUSDT", "1h", short, CalculateIndicators(short)); strings.Contains(report, "一目均衡表") {
+		t.Errorf("Expected no Ichimoku section before warm-up:\n%s", report)
+	}
+}
@@ -0,0 +1,143 @@
+package dataflows
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Reference sources for the kline cross-check
+// K 线交叉校验的参考数据源
+const (
+	KlineSourceIndex = "index" // 指数价格 K 线 / Index price klines
+	KlineSourceMark  = "mark"  // 标记价格 K 线 / Mark price klines
+)
+
+// KlineDiscrepancy is one price that differs from the reference source beyond the tolerance
+// KlineDiscrepancy 表示一个与参考数据源偏差超过容差的价格
+type KlineDiscrepancy struct {
+	Timestamp   time.Time // K 线开盘时间 / Candle open time
+	Field       string    // open 或 close / open or close
+	Primary     float64   // 主数据源价格 / Primary source price
+	Reference   float64   // 参考数据源价格 / Reference source price
+	DiffPercent float64   // 偏差（%）/ Difference (%)
+}
+
+// CompareKlines compares the open and close of the last n closed primary candles with the reference candles
+// CompareKlines 将主数据源最近 n 根已收盘 K 线的开盘价和收盘价与参考 K 线对比
+//
+// The last primary candle is still forming and is left out. Candles are matched by open time; a candle missing
+// from the reference is counted in missing rather than reported as a discrepancy.
+// 主数据源最后一根 K 线尚未收盘，不参与对比。K 线按开盘时间匹配，参考数据源中缺失的 K 线计入 missing，而不是视为偏差。
+func CompareKlines(primary, reference []OHLCV, n int, tolerancePercent float64) (discrepancies []KlineDiscrepancy, missing int) {
+	if len(primary) < 2 || n <= 0 {
+		return nil, 0
+	}
+	closed := primary[:len(primary)-1]
+	if len(closed) > n {
+		closed = closed[len(closed)-n:]
+	}
+
+	byTime := make(map[int64]OHLCV, len(reference))
+	for _, k := range reference {
+		byTime[k.Timestamp.Unix()] = k
+	}
+
+	for _, k := range closed {
+		ref, ok := byTime[k.Timestamp.Unix()]
+		if !ok {
+			missing++
+			continue
+		}
+		for _, pair := range []struct {
+			field              string
+			primary, reference float64
+		}{
+			{"open", k.Open, ref.Open},
+			{"close", k.Close, ref.Close},
+		} {
+			if pair.reference <= 0 {
+				continue
+			}
+			diff := math.Abs(pair.primary-pair.reference) / pair.reference * 100
+			if diff > tolerancePercent {
+				discrepancies = append(discrepancies, KlineDiscrepancy{
+					Timestamp:   k.Timestamp,
+					Field:       pair.field,
+					Primary:     pair.primary,
+					Reference:   pair.reference,
+					DiffPercent: diff,
+				})
+			}
+		}
+	}
+	return discrepancies, missing
+}
+
+// GetReferenceOHLCV fetches the latest limit candles from the reference source (index or mark price)
+// GetReferenceOHLCV 从参考数据源（指数价格或标记价格）获取最近 limit 根 K 线
+func (m *MarketData) GetReferenceOHLCV(ctx context.Context, symbol, timeframe, source string, limit int) ([]OHLCV, error) {
+	// Aggregated timeframes fetch enough base candles for limit candles (the endpoints return at most 1500)
+	// 合成周期获取足够合成 limit 根 K 线的基础 K 线（接口最多返回 1500 根）
+	if base, baseLength, period, ok := aggregationBase(timeframe); ok {
+		perCandle := int(period / baseLength)
+		baseLimit := (limit + 1) * perCandle
+		if baseLimit > 1500 {
+			baseLimit = 1500
+		}
+		bars, err := m.GetReferenceOHLCV(ctx, symbol, base, source, baseLimit)
+		if err != nil {
+			return nil, err
+		}
+		candles := AggregateOHLCV(bars, baseLength, period)
+		if len(candles) > limit {
+			candles = candles[len(candles)-limit:]
+		}
+		return candles, nil
+	}
+	interval := convertTimeframe(timeframe)
+
+	switch source {
+	case KlineSourceMark:
+		klines, err := m.client.NewMarkPriceKlinesService().Symbol(symbol).Interval(interval).Limit(limit).Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch mark price klines: %w", err)
+		}
+		return klinesToOHLCV(klines), nil
+	case KlineSourceIndex, "":
+		klines, err := m.client.NewIndexPriceKlinesService().Pair(symbol).Interval(interval).Limit(limit).Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch index price klines: %w", err)
+		}
+		return klinesToOHLCV(klines), nil
+	default:
+		return nil, fmt.Errorf("unknown kline cross-check source: %s", source)
+	}
+}
+
+// CrossCheckKlines checks the latest closed candles of primary against the configured reference source
+// CrossCheckKlines 用配置的参考数据源校验 primary 最近的已收盘 K 线
+func (m *MarketData) CrossCheckKlines(ctx context.Context, symbol, timeframe string, primary []OHLCV) ([]KlineDiscrepancy, int, error) {
+	n := m.config.KlineCrossCheckCandles
+	// One extra candle covers the forming one on both sides
+	// 多取一根以覆盖双方尚未收盘的 K 线
+	reference, err := m.GetReferenceOHLCV(ctx, symbol, timeframe, m.config.KlineCrossCheckSource, n+1)
+	if err != nil {
+		return nil, 0, err
+	}
+	discrepancies, missing := CompareKlines(primary, reference, n, m.config.KlineCrossCheckTolerance)
+	return discrepancies, missing, nil
+}
+
+// FormatKlineDiscrepancies summarizes the discrepancies for logs, alerts and the market report
+// FormatKlineDiscrepancies 汇总偏差，用于日志、告警和市场报告
+func FormatKlineDiscrepancies(source string, discrepancies []KlineDiscrepancy) string {
+	parts := make([]string, 0, len(discrepancies))
+	for _, d := range discrepancies {
+		parts = append(parts, fmt.Sprintf("%s %s %.4f vs %s %.4f (偏差 %.2f%%)",
+			d.Timestamp.Format("01-02 15:04"), d.Field, d.Primary, source, d.Reference, d.DiffPercent))
+	}
+	return strings.Join(parts, "；")
+}
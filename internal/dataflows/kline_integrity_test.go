@@ -0,0 +1,76 @@
+package dataflows
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// hourlyCandles returns one hourly candle per close price, opening at the previous close
+// hourlyCandles 为每个收盘价生成一根小时 K 线，开盘价为上一根的收盘价
+func hourlyCandles(start time.Time, closes ...float64) []OHLCV {
+	candles := make([]OHLCV, 0, len(closes))
+	open := closes[0]
+	for i, c := range closes {
+		candles = append(candles, OHLCV{Timestamp: start.Add(time.Duration(i) * time.Hour), Open: open, High: c, Low: c, Close: c})
+		open = c
+	}
+	return candles
+}
+
+func TestCompareKlines(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	reference := hourlyCandles(start, 100, 101, 102, 103, 104)
+
+	t.Run("Basis within tolerance", func(t *testing.T) {
+		primary := hourlyCandles(start, 100.1, 101.1, 102.1, 103.1, 104.1)
+		if got, missing := CompareKlines(primary, reference, 3, 1); len(got) != 0 || missing != 0 {
+			t.Errorf("Expected no discrepancies, got %+v (missing %d)", got, missing)
+		}
+	})
+
+	t.Run("Bad close is reported", func(t *testing.T) {
+		primary := hourlyCandles(start, 100, 101, 110, 103, 104)
+		got, _ := CompareKlines(primary, reference, 3, 1)
+		// The bad close also becomes the next candle's open
+		// 错误的收盘价同时也是下一根的开盘价
+		if len(got) != 2 || got[0].Field != "close" || got[1].Field != "open" {
+			t.Fatalf("Expected the bad close and the following open, got %+v", got)
+		}
+		if got[0].Primary != 110 || got[0].Reference != 102 {
+			t.Errorf("Unexpected discrepancy: %+v", got[0])
+		}
+	})
+
+	t.Run("Forming candle is ignored", func(t *testing.T) {
+		primary := hourlyCandles(start, 100, 101, 102, 103, 150)
+		if got, _ := CompareKlines(primary, reference, 3, 1); len(got) != 0 {
+			t.Errorf("Expected the forming candle to be skipped, got %+v", got)
+		}
+	})
+
+	t.Run("Only the last n candles are checked", func(t *testing.T) {
+		primary := hourlyCandles(start, 150, 101, 102, 103, 104)
+		if got, _ := CompareKlines(primary, reference, 2, 1); len(got) != 0 {
+			t.Errorf("Expected old candles to be skipped, got %+v", got)
+		}
+	})
+
+	t.Run("Missing reference candles are counted", func(t *testing.T) {
+		primary := hourlyCandles(start, 100, 101, 102, 103, 104)
+		if got, missing := CompareKlines(primary, reference[:2], 3, 1); len(got) != 0 || missing != 2 {
+			t.Errorf("Expected 2 missing candles, got %+v (missing %d)", got, missing)
+		}
+	})
+}
+
+func TestFormatKlineDiscrepancies(t *testing.T) {
+	text := FormatKlineDiscrepancies(KlineSourceIndex, []KlineDiscrepancy{{
+		Timestamp: time.Date(2026, 1, 1, 3, 0, 0, 0, time.Local), Field: "close", Primary: 110, Reference: 103, DiffPercent: 6.8,
+	}})
+	for _, want := range []string{"01-01 03:00", "close", "index", "6.80%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in %q", want, text)
+		}
+	}
+}
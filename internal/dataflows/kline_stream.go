@@ -0,0 +1,354 @@
+package dataflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/scheduler"
+)
+
+// Kline stream tuning
+// K 线流参数
+const (
+	klineBufferSize           = 3000            // 每个交易对/周期保留的 K 线数 / Candles kept per symbol and interval
+	klineRequestLimit         = 1000            // 单次原生请求返回的 K 线上限 / Candles a native request returns at most
+	klineStaleAfter           = 2 * time.Minute // 超过该时间未收到推送的缓冲视为过期 / Buffers without an update for this long are stale
+	klineStreamReconnectDelay = 5 * time.Second // 断线重连间隔 / Delay before reconnecting
+)
+
+// multiTimeframes are the fixed timeframes of the multi-timeframe indicator table
+// multiTimeframes 是多时间框架指标表使用的固定时间周期
+var multiTimeframes = []string{"5m", "15m", "1h", "4h"}
+
+// sharedKlineStream is the process-wide kline stream read by every MarketData, nil when streaming is off
+// sharedKlineStream 是所有 MarketData 读取的进程级 K 线流，未启用时为 nil
+var sharedKlineStream atomic.Pointer[KlineStream]
+
+// klineBuffer is the rolling candle window of one symbol and native interval
+// klineBuffer 是单个交易对和原生周期的滚动 K 线窗口
+type klineBuffer struct {
+	length      time.Duration // K 线长度 / Candle length
+	candles     []OHLCV       // 按时间升序 / Oldest first
+	synced      bool          // 连接后已用 REST 补齐且没有缺口 / Backfilled over REST since connecting, without gaps
+	coveredFrom time.Time     // 缓冲完整覆盖的起始时间 / Start of the span the buffer fully covers
+	updated     time.Time     // 最近一次推送时间 / Last pushed update
+}
+
+// KlineStream keeps rolling candle buffers of the traded symbols up to date from the Binance kline websocket
+// KlineStream 通过币安 K 线 websocket 维护交易对的滚动 K 线缓冲
+//
+// Analysts and the stop monitor read candles from the buffers instead of polling REST. A buffer is backfilled over
+// REST on first use, after a reconnect and after a missed candle; while the stream is down or a buffer has gone
+// quiet, reads fall back to REST.
+// 分析师和止损监控从缓冲读取 K 线，而不是轮询 REST。缓冲在首次使用、重连后以及漏掉 K 线后通过 REST 补齐；
+// 数据流断开或缓冲长时间未更新时，读取回退到 REST。
+type KlineStream struct {
+	streams map[string][]string                                                                                                                              // 交易对 -> 订阅的原生周期 / Symbol -> subscribed native intervals
+	logger  *logger.ColorLogger                                                                                                                              // 日志 / Logger
+	serve   func(symbolIntervals map[string][]string, handler futures.WsKlineHandler, errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error) // 建立连接（测试可替换）/ Connects the stream (replaceable in tests)
+	fetch   func(ctx context.Context, symbol, interval string, start, end time.Time) ([]OHLCV, error)                                                        // REST 补齐（测试可替换）/ REST backfill (replaceable in tests)
+	now     func() time.Time                                                                                                                                 // 时钟（测试可替换）/ Clock (replaceable in tests)
+
+	mu        sync.RWMutex
+	connected bool
+	buffers   map[string]*klineBuffer // symbol@interval -> 缓冲 / Buffer
+}
+
+// StreamTimeframes returns the timeframes worth streaming: the analysis and longer timeframes, the stop monitor's
+// interval and the multi-timeframe table
+// StreamTimeframes 返回需要订阅的时间周期：分析周期、更长周期、止损监控周期以及多时间框架指标表
+func StreamTimeframes(cfg *config.Config) []string {
+	timeframes := []string{cfg.CryptoTimeframe, cfg.CryptoLongerTimeframe, cfg.TradingInterval}
+	return append(timeframes, multiTimeframes...)
+}
+
+// NewKlineStream creates a kline stream for the symbols and timeframes and makes it the one MarketData reads from
+// NewKlineStream 为指定交易对和时间周期创建 K 线流，并设为 MarketData 读取的共享数据流
+// Timeframes Binance does not offer are streamed as their aggregation base
+// 币安不提供的时间周期订阅其合成基础周期
+func NewKlineStream(cfg *config.Config, symbols, timeframes []string, log *logger.ColorLogger) *KlineStream {
+	ks := &KlineStream{
+		streams: make(map[string][]string),
+		logger:  log,
+		serve:   futures.WsCombinedKlineServeMultiInterval,
+		now:     time.Now,
+		buffers: make(map[string]*klineBuffer),
+	}
+	market := NewMarketData(cfg)
+	ks.fetch = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]OHLCV, error) {
+		return market.GetOHLCVRange(ctx, symbol, interval, start, end)
+	}
+
+	for _, symbol := range symbols {
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+		for _, tf := range timeframes {
+			if tf == "" {
+				continue
+			}
+			interval := NativeInterval(tf)
+			length, err := scheduler.TimeframeDuration(interval)
+			if err != nil {
+				continue
+			}
+			key := klineKey(binanceSymbol, interval)
+			if _, ok := ks.buffers[key]; ok {
+				continue
+			}
+			ks.buffers[key] = &klineBuffer{length: length}
+			ks.streams[binanceSymbol] = append(ks.streams[binanceSymbol], interval)
+		}
+	}
+
+	sharedKlineStream.Store(ks)
+	return ks
+}
+
+// klineKey identifies the buffer of a symbol and native interval
+// klineKey 标识交易对和原生周期对应的缓冲
+func klineKey(symbol, interval string) string {
+	return symbol + "@" + interval
+}
+
+// Run keeps the kline stream connected until ctx is cancelled
+// Run 保持 K 线流连接，直到 ctx 被取消
+func (ks *KlineStream) Run(ctx context.Context) {
+	if len(ks.streams) == 0 {
+		return
+	}
+	for {
+		err := ks.connect(ctx)
+		ks.setConnected(false)
+		if ctx.Err() != nil {
+			ks.logger.Info("K 线流已停止")
+			return
+		}
+		ks.logger.Warning(fmt.Sprintf("⚠️  K 线流中断: %v，%v 后重连（期间使用 REST 获取 K 线）", err, klineStreamReconnectDelay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(klineStreamReconnectDelay):
+		}
+	}
+}
+
+// connect opens one websocket session and blocks until it ends
+// connect 建立一次 websocket 会话并阻塞直到会话结束
+func (ks *KlineStream) connect(ctx context.Context) error {
+	handler := func(event *futures.WsKlineEvent) {
+		ks.apply(event.Symbol, event.Kline)
+	}
+	errHandler := func(err error) {
+		ks.logger.Warning(fmt.Sprintf("⚠️  K 线流错误: %v", err))
+	}
+
+	doneC, stopC, err := ks.serve(ks.streams, handler, errHandler)
+	if err != nil {
+		return fmt.Errorf("failed to connect kline stream: %w", err)
+	}
+	ks.setConnected(true)
+	ks.logger.Success(fmt.Sprintf("✅ 已连接币安 K 线流: %v", ks.streams))
+
+	select {
+	case <-ctx.Done():
+		close(stopC)
+		<-doneC
+		return ctx.Err()
+	case <-doneC:
+		return errors.New("连接已断开")
+	}
+}
+
+// setConnected records the connection state; every buffer needs a backfill after a (re)connect, as candles may have
+// been missed while down
+// setConnected 记录连接状态；（重新）连接后所有缓冲都需要重新补齐，因为断开期间可能漏掉 K 线
+func (ks *KlineStream) setConnected(connected bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.connected = connected
+	for _, buf := range ks.buffers {
+		buf.synced = false
+	}
+}
+
+// apply merges a pushed candle into its buffer: the forming candle is replaced, a new one appended
+// apply 将推送的 K 线并入缓冲：未收盘的 K 线被替换，新 K 线追加到末尾
+// A candle arriving after a gap marks the buffer for a backfill
+// 出现缺口后到达的 K 线会使缓冲需要重新补齐
+func (ks *KlineStream) apply(symbol string, k futures.WsKline) {
+	candle, ok := wsKlineToOHLCV(k)
+	if !ok {
+		return
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	buf, ok := ks.buffers[klineKey(symbol, k.Interval)]
+	if !ok {
+		return
+	}
+	buf.updated = ks.now()
+
+	n := len(buf.candles)
+	switch {
+	case n > 0 && candle.Timestamp.Equal(buf.candles[n-1].Timestamp):
+		buf.candles[n-1] = candle
+	case n > 0 && candle.Timestamp.Before(buf.candles[n-1].Timestamp):
+		return
+	default:
+		if n > 0 && candle.Timestamp.Sub(buf.candles[n-1].Timestamp) > buf.length {
+			buf.synced = false
+		}
+		buf.candles = append(buf.candles, candle)
+		buf.trim()
+	}
+}
+
+// trim drops the oldest candles beyond klineBufferSize
+// trim 删除超出 klineBufferSize 的最早 K 线
+func (buf *klineBuffer) trim() {
+	if extra := len(buf.candles) - klineBufferSize; extra > 0 {
+		buf.candles = append([]OHLCV(nil), buf.candles[extra:]...)
+		buf.coveredFrom = buf.candles[0].Timestamp
+	}
+}
+
+// wsKlineToOHLCV converts a pushed kline to an OHLCV data point
+// wsKlineToOHLCV 将推送的 K 线转换为 OHLCV 数据
+func wsKlineToOHLCV(k futures.WsKline) (OHLCV, bool) {
+	open, err1 := strconv.ParseFloat(k.Open, 64)
+	high, err2 := strconv.ParseFloat(k.High, 64)
+	low, err3 := strconv.ParseFloat(k.Low, 64)
+	closePrice, err4 := strconv.ParseFloat(k.Close, 64)
+	volume, err5 := strconv.ParseFloat(k.Volume, 64)
+	if err := errors.Join(err1, err2, err3, err4, err5); err != nil {
+		return OHLCV{}, false
+	}
+	return OHLCV{Timestamp: time.UnixMilli(k.StartTime), Open: open, High: high, Low: low, Close: closePrice, Volume: volume}, true
+}
+
+// candles returns the native-interval candles of a symbol from start to now
+// candles 返回交易对从 start 到现在的原生周期 K 线
+//
+// served=false means the symbol and interval are not streamed or the stream is down, and the caller uses REST.
+// A buffer that is not synced, does not reach back to start or has gone quiet is backfilled over REST first.
+// served=false 表示该交易对和周期未订阅或数据流已断开，由调用方使用 REST。缓冲未补齐、未覆盖到 start 或长时间未更新时先通过 REST 补齐。
+func (ks *KlineStream) candles(ctx context.Context, symbol, interval string, start time.Time) (bars []OHLCV, served bool, err error) {
+	key := klineKey(symbol, interval)
+
+	ks.mu.RLock()
+	buf, ok := ks.buffers[key]
+	if !ok || !ks.connected {
+		ks.mu.RUnlock()
+		return nil, false, nil
+	}
+	now := ks.now()
+	if buf.synced && !buf.coveredFrom.After(start) && now.Sub(buf.updated) <= klineStaleAfter {
+		bars = candlesSince(buf.candles, start)
+		ks.mu.RUnlock()
+		return bars, true, nil
+	}
+	ks.mu.RUnlock()
+
+	fetched, err := ks.fetch(ctx, symbol, interval, start, now)
+	if err != nil {
+		return nil, true, err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	buf.merge(fetched, start)
+	if ks.connected {
+		buf.synced = true
+		if buf.updated.IsZero() {
+			buf.updated = now
+		}
+	}
+	return candlesSince(buf.candles, start), true, nil
+}
+
+// merge replaces the buffer up to the end of a REST backfill, keeping pushed candles newer than it
+// merge 用 REST 补齐结果替换缓冲中截至其末尾的部分，保留比它更新的推送 K 线
+func (buf *klineBuffer) merge(fetched []OHLCV, start time.Time) {
+	merged := append([]OHLCV(nil), fetched...)
+	if len(fetched) > 0 {
+		last := fetched[len(fetched)-1].Timestamp
+		for _, c := range buf.candles {
+			if c.Timestamp.After(last) {
+				merged = append(merged, c)
+			}
+		}
+	} else {
+		merged = append(merged, buf.candles...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.Before(merged[j].Timestamp) })
+	buf.candles = merged
+	buf.coveredFrom = start
+	buf.trim()
+}
+
+// candlesSince copies the candles opening at or after start
+// candlesSince 复制开盘时间不早于 start 的 K 线
+func candlesSince(candles []OHLCV, start time.Time) []OHLCV {
+	i := sort.Search(len(candles), func(i int) bool { return !candles[i].Timestamp.Before(start) })
+	return append([]OHLCV(nil), candles[i:]...)
+}
+
+// latest returns the newest pushed candle of a symbol and native interval while the stream is up and fresh
+// latest 在数据流连接且未过期时返回交易对和原生周期的最新推送 K 线
+func (ks *KlineStream) latest(symbol, interval string) (OHLCV, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	buf, ok := ks.buffers[klineKey(symbol, interval)]
+	if !ok || !ks.connected || len(buf.candles) == 0 || ks.now().Sub(buf.updated) > klineStaleAfter {
+		return OHLCV{}, false
+	}
+	return buf.candles[len(buf.candles)-1], true
+}
+
+// LatestStreamedCandle returns the newest streamed candle of a symbol and native interval, ok=false when the
+// candle is not streamed or the stream is down
+// LatestStreamedCandle 返回交易对和原生周期的最新流式 K 线，未订阅或数据流断开时 ok=false
+func LatestStreamedCandle(symbol, interval string) (OHLCV, bool) {
+	ks := sharedKlineStream.Load()
+	if ks == nil {
+		return OHLCV{}, false
+	}
+	return ks.latest(symbol, interval)
+}
+
+// streamedOHLCV serves a GetOHLCV window from the shared kline stream, aggregating when the timeframe is not native
+// streamedOHLCV 从共享 K 线流提供 GetOHLCV 的数据窗口，非原生周期时进行合成
+func streamedOHLCV(ctx context.Context, symbol, timeframe string, start time.Time) ([]OHLCV, bool, error) {
+	ks := sharedKlineStream.Load()
+	if ks == nil {
+		return nil, false, nil
+	}
+	base, baseLength, period, ok := aggregationBase(timeframe)
+	if !ok {
+		// Keep to the newest candles a native request would return
+		// 与原生请求一样最多返回最新的 klineRequestLimit 根
+		bars, served, err := ks.candles(ctx, symbol, convertTimeframe(timeframe), start)
+		if len(bars) > klineRequestLimit {
+			bars = bars[len(bars)-klineRequestLimit:]
+		}
+		return bars, served, err
+	}
+
+	periodMs := period.Milliseconds()
+	start = time.UnixMilli(start.UnixMilli() / periodMs * periodMs)
+	bars, served, err := ks.candles(ctx, symbol, base, start)
+	if !served || err != nil {
+		return nil, served, err
+	}
+	return AggregateOHLCV(bars, baseLength, period), true, nil
+}
@@ -0,0 +1,106 @@
+package dataflows
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// newTestKlineStream returns a connected 1h stream for BTCUSDT whose REST backfill serves bars and counts calls
+// newTestKlineStream 返回已连接的 BTCUSDT 1h K 线流，其 REST 补齐返回 bars 并记录调用次数
+func newTestKlineStream(now time.Time, bars []OHLCV, fetches *int) *KlineStream {
+	ks := &KlineStream{
+		streams: map[string][]string{"BTCUSDT": {"1h"}},
+		logger:  logger.NewColorLogger(false),
+		now:     func() time.Time { return now },
+		buffers: map[string]*klineBuffer{klineKey("BTCUSDT", "1h"): {length: time.Hour}},
+	}
+	ks.fetch = func(ctx context.Context, symbol, interval string, start, end time.Time) ([]OHLCV, error) {
+		*fetches++
+		return candlesSince(bars, start), nil
+	}
+	return ks
+}
+
+// wsKline builds a pushed 1h kline opening at start
+// wsKline 构造开盘时间为 start 的 1h 推送 K 线
+func wsKline(start time.Time, close float64) futures.WsKline {
+	c := strconv.FormatFloat(close, 'f', 2, 64)
+	return futures.WsKline{StartTime: start.UnixMilli(), Interval: "1h", Open: c, High: c, Low: c, Close: c, Volume: "1"}
+}
+
+func TestKlineStreamCandles(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	var bars []OHLCV
+	for i := 0; i < 10; i++ {
+		ts := now.Truncate(time.Hour).Add(time.Duration(i-9) * time.Hour)
+		bars = append(bars, OHLCV{Timestamp: ts, Close: 100 + float64(i)})
+	}
+	fetches := 0
+	ks := newTestKlineStream(now, bars, &fetches)
+	ctx := context.Background()
+	start := now.Add(-5 * time.Hour)
+
+	// Not connected yet: the caller uses REST
+	// 尚未连接：由调用方使用 REST
+	if _, served, _ := ks.candles(ctx, "BTCUSDT", "1h", start); served {
+		t.Fatal("Expected a disconnected stream not to serve candles")
+	}
+
+	ks.setConnected(true)
+	got, served, err := ks.candles(ctx, "BTCUSDT", "1h", start)
+	if err != nil || !served || fetches != 1 || len(got) != 5 {
+		t.Fatalf("Expected the first read to backfill 5 candles over REST, got %d candles, %d fetches, err %v", len(got), fetches, err)
+	}
+
+	// The forming candle is replaced in place and a new candle is appended, without touching REST
+	// 未收盘 K 线原地更新，新 K 线追加到末尾，不访问 REST
+	ks.apply("BTCUSDT", wsKline(now.Truncate(time.Hour), 120))
+	ks.apply("BTCUSDT", wsKline(now.Truncate(time.Hour).Add(time.Hour), 121))
+	got, _, _ = ks.candles(ctx, "BTCUSDT", "1h", start)
+	if fetches != 1 || len(got) != 6 || got[4].Close != 120 || got[5].Close != 121 {
+		t.Fatalf("Expected 6 buffered candles ending 120, 121 without a fetch, got %d fetches and %+v", fetches, got)
+	}
+	if latest, ok := ks.latest("BTCUSDT", "1h"); !ok || latest.Close != 121 {
+		t.Errorf("Expected the latest streamed candle to close at 121, got %+v, %v", latest, ok)
+	}
+
+	// A read further back than the buffer covers is backfilled again
+	// 读取超出缓冲覆盖范围的更早数据时重新补齐
+	if got, _, _ = ks.candles(ctx, "BTCUSDT", "1h", now.Add(-8*time.Hour)); fetches != 2 || len(got) != 9 {
+		t.Errorf("Expected a longer read to backfill, got %d fetches and %d candles", fetches, len(got))
+	}
+
+	// A skipped candle marks the buffer for a backfill
+	// 漏掉 K 线后缓冲需要重新补齐
+	ks.apply("BTCUSDT", wsKline(now.Truncate(time.Hour).Add(3*time.Hour), 123))
+	if _, _, _ = ks.candles(ctx, "BTCUSDT", "1h", start); fetches != 3 {
+		t.Errorf("Expected a gap to force a backfill, got %d fetches", fetches)
+	}
+
+	// Unknown intervals are left to REST
+	// 未订阅的周期由 REST 处理
+	if _, served, _ := ks.candles(ctx, "BTCUSDT", "4h", start); served {
+		t.Error("Expected an unsubscribed interval not to be served")
+	}
+}
+
+func TestKlineStreamStale(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+	fetches := 0
+	ks := newTestKlineStream(now, []OHLCV{{Timestamp: now.Truncate(time.Hour), Close: 100}}, &fetches)
+	ks.setConnected(true)
+	ks.apply("BTCUSDT", wsKline(now.Truncate(time.Hour), 101))
+
+	ks.now = func() time.Time { return now.Add(klineStaleAfter + time.Second) }
+	if _, ok := ks.latest("BTCUSDT", "1h"); ok {
+		t.Error("Expected a quiet buffer not to report a latest candle")
+	}
+	if _, _, _ = ks.candles(context.Background(), "BTCUSDT", "1h", now.Add(-time.Hour)); fetches != 1 {
+		t.Errorf("Expected a quiet buffer to be backfilled, got %d fetches", fetches)
+	}
+}
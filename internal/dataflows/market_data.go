@@ -2,11 +2,8 @@ package dataflows
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"math"
-	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -53,6 +50,20 @@ type TechnicalIndicators struct {
 	DI_Plus     []float64 // +DI - 上升趋向指标
 	DI_Minus    []float64 // -DI - 下降趋向指标
 	VolumeRatio []float64 // Volume Ratio - 成交量比率
+
+	// Momentum oscillators for overbought/oversold timing
+	// 动量震荡指标，用于判断超买超卖时机
+	StochRSI_K []float64 // StochRSI(14,14,3,3) %K，0-100
+	StochRSI_D []float64 // StochRSI(14,14,3,3) %D，0-100
+	WilliamsR  []float64 // Williams %R(14)，-100 到 0 / -100 to 0
+
+	// Ichimoku cloud (9/26/52); the spans are computed at each bar and plotted 26 bars ahead
+	// 一目均衡表（9/26/52）；先行带为每根 K 线处计算的值，图上向前平移 26 根
+	Ichimoku_Tenkan []float64       // 转换线 / Tenkan-sen
+	Ichimoku_Kijun  []float64       // 基准线 / Kijun-sen
+	Ichimoku_SpanA  []float64       // 先行带 A / Senkou Span A
+	Ichimoku_SpanB  []float64       // 先行带 B / Senkou Span B
+	CloudPosition   []CloudPosition // 收盘价相对所在 K 线下方云层的位置 / Close relative to the cloud under its bar
 }
 
 // MultiTimeframeIndicator holds key indicators for a single timeframe
@@ -64,6 +75,12 @@ type MultiTimeframeIndicator struct {
 	MACD      float64 // MACD - 动量指标
 	RSI7      float64 // RSI(7) - 7期相对强弱指数
 	RSI14     float64 // RSI(14) - 14期相对强弱指数
+	ADX       float64 // ADX(14) - 趋势强度
+	DIPlus    float64 // +DI(14) - 上升趋向指标
+	DIMinus   float64 // -DI(14) - 下降趋向指标
+	StochK    float64 // StochRSI %K - 随机 RSI 快线
+	StochD    float64 // StochRSI %D - 随机 RSI 慢线
+	WilliamsR float64 // Williams %R(14) - 威廉指标
 }
 
 // MarketData handles crypto market data fetching
@@ -75,7 +92,7 @@ type MarketData struct {
 // NewMarketData creates a new MarketData instance
 // Note: For public endpoints (klines, orderbook, etc.), API key is not required
 func NewMarketData(cfg *config.Config) *MarketData {
-	futures.UseTestnet = cfg.BinanceTestMode
+	futures.UseTestnet = cfg.UseBinanceTestnet()
 
 	// For public data endpoints, we can use empty API credentials
 	// Only private endpoints (account info, trading) require valid credentials
@@ -90,23 +107,9 @@ func NewMarketData(cfg *config.Config) *MarketData {
 
 	client := futures.NewClient(apiKey, apiSecret)
 
-	// Set proxy if configured
-	if cfg.BinanceProxy != "" {
-		proxyURL, err := url.Parse(cfg.BinanceProxy)
-		if err == nil {
-			// Create custom HTTP client with proxy
-			httpClient := &http.Client{
-				Transport: &http.Transport{
-					Proxy: http.ProxyURL(proxyURL),
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: false,
-					},
-				},
-				Timeout: 30 * time.Second,
-			}
-			client.HTTPClient = httpClient
-		}
-	}
+	// Reuse a shared keep-alive HTTP client (proxy-aware) so each cycle skips TCP/TLS handshakes
+	// 复用共享的长连接 HTTP 客户端（支持代理），避免每个周期重复 TCP/TLS 握手
+	client.HTTPClient = getSharedHTTPClient(cfg)
 
 	return &MarketData{
 		client: client,
@@ -115,12 +118,37 @@ func NewMarketData(cfg *config.Config) *MarketData {
 }
 
 // GetOHLCV fetches OHLCV data for a symbol
+// Timeframes Binance does not offer (e.g. 90m) are aggregated from a lower native interval
+// 币安不提供的时间周期（如 90m）由更短的原生周期合成
+// Streamed symbols and timeframes are read from the kline stream's buffer; the rest, and everything while the
+// stream is down, is fetched over REST
+// 已订阅的交易对和周期从 K 线流缓冲读取；其余情况以及数据流断开期间通过 REST 获取
 func (m *MarketData) GetOHLCV(ctx context.Context, symbol string, timeframe string, lookbackDays int) ([]OHLCV, error) {
 	interval := convertTimeframe(timeframe)
 
 	startTime := time.Now().AddDate(0, 0, -lookbackDays)
 	endTime := time.Now()
 
+	base, baseLength, period, aggregated := aggregationBase(timeframe)
+	if aggregated {
+		// Keep to the candle count of a native request, which bounds the base candles fetched
+		// 与原生请求的 K 线数量保持一致，同时限制需要获取的基础 K 线数量
+		if earliest := endTime.Add(-maxAggregatedCandles * period); startTime.Before(earliest) {
+			startTime = earliest
+		}
+	}
+
+	if bars, served, err := streamedOHLCV(ctx, symbol, timeframe, startTime); served {
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch klines: %w", err)
+		}
+		return bars, nil
+	}
+
+	if aggregated {
+		return m.aggregatedOHLCV(ctx, symbol, base, baseLength, period, startTime, endTime)
+	}
+
 	klines, err := m.client.NewKlinesService().
 		Symbol(symbol).
 		Interval(interval).
@@ -133,6 +161,44 @@ func (m *MarketData) GetOHLCV(ctx context.Context, symbol string, timeframe stri
 		return nil, fmt.Errorf("failed to fetch klines: %w", err)
 	}
 
+	return klinesToOHLCV(klines), nil
+}
+
+// GetOHLCVRange fetches every kline between start and end, paging past the 1000-kline request limit
+// GetOHLCVRange 获取 start 到 end 之间的全部 K 线，分页突破单次 1000 根的限制
+func (m *MarketData) GetOHLCVRange(ctx context.Context, symbol string, timeframe string, start, end time.Time) ([]OHLCV, error) {
+	if base, baseLength, period, ok := aggregationBase(timeframe); ok {
+		return m.aggregatedOHLCV(ctx, symbol, base, baseLength, period, start, end)
+	}
+	interval := convertTimeframe(timeframe)
+
+	var ohlcvData []OHLCV
+	from := start.UnixMilli()
+	for from < end.UnixMilli() {
+		klines, err := m.client.NewKlinesService().
+			Symbol(symbol).
+			Interval(interval).
+			StartTime(from).
+			EndTime(end.UnixMilli()).
+			Limit(1000).
+			Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch klines: %w", err)
+		}
+		if len(klines) == 0 {
+			break
+		}
+
+		ohlcvData = append(ohlcvData, klinesToOHLCV(klines)...)
+		from = klines[len(klines)-1].CloseTime + 1
+	}
+
+	return ohlcvData, nil
+}
+
+// klinesToOHLCV converts Binance klines to OHLCV data points
+// klinesToOHLCV 将币安 K 线转换为 OHLCV 数据
+func klinesToOHLCV(klines []*futures.Kline) []OHLCV {
 	ohlcvData := make([]OHLCV, 0, len(klines))
 	for _, k := range klines {
 		open, _ := strconv.ParseFloat(k.Open, 64)
@@ -151,7 +217,7 @@ func (m *MarketData) GetOHLCV(ctx context.Context, symbol string, timeframe stri
 		})
 	}
 
-	return ohlcvData, nil
+	return ohlcvData
 }
 
 // CalculateIndicators calculates technical indicators from OHLCV data
@@ -202,6 +268,8 @@ func CalculateIndicators(ohlcvData []OHLCV, atrPeriod ...int) *TechnicalIndicato
 	// 新增指标：趋势强度和成交量确认
 	adx, diPlus, diMinus := calculateADX(highs, lows, closes, 14)
 	volumeRatio := calculateVolumeRatio(volumes, 20)
+	tenkan, kijun, spanA, spanB := calculateIchimoku(highs, lows)
+	stochK, stochD := calculateStochRSI(closes)
 
 	return &TechnicalIndicators{
 		RSI:       rsi,
@@ -229,6 +297,16 @@ func CalculateIndicators(ohlcvData []OHLCV, atrPeriod ...int) *TechnicalIndicato
 		DI_Plus:     diPlus,
 		DI_Minus:    diMinus,
 		VolumeRatio: volumeRatio,
+
+		StochRSI_K: stochK,
+		StochRSI_D: stochD,
+		WilliamsR:  calculateWilliamsR(highs, lows, closes, williamsRPeriod),
+
+		Ichimoku_Tenkan: tenkan,
+		Ichimoku_Kijun:  kijun,
+		Ichimoku_SpanA:  spanA,
+		Ichimoku_SpanB:  spanB,
+		CloudPosition:   cloudPositions(closes, spanA, spanB),
 	}
 }
 
@@ -552,9 +630,12 @@ func calculateADX(highs, lows, closes []float64, period int) (adx, diPlus, diMin
 		}
 	}
 
-	// Calculate ADX (smoothed DX)
-	// 计算 ADX（平滑的 DX）
+	// Calculate ADX (smoothed DX); it needs a full period of DX values before the first reading
+	// 计算 ADX（平滑的 DX）；首个值需要一个完整周期的 DX
 	adxPeriod := period // Use same period as DI (Wilder's standard method)
+	for i := period; i < period+adxPeriod-1 && i < n; i++ {
+		adx[i] = math.NaN()
+	}
 	for i := period + adxPeriod - 1; i < n; i++ {
 		if i == period+adxPeriod-1 {
 			// Initial ADX is average of first period DX values
@@ -572,6 +653,39 @@ func calculateADX(highs, lows, closes []float64, period int) (adx, diPlus, diMin
 	return adx, diPlus, diMinus
 }
 
+// DescribeTrendStrength interprets ADX and the directional indicators, or returns an empty string when ADX is not
+// available yet
+// DescribeTrendStrength 解读 ADX 与趋向指标；ADX 尚不可用时返回空字符串
+// ADX below 20 means no trend, 20-25 a trend forming, 25-40 a trend and 40 or more a strong trend; the larger DI
+// tells which side leads
+// ADX 低于 20 表示无趋势，20-25 趋势形成中，25-40 有趋势，40 及以上为强趋势；较大的 DI 表示主导方向
+func DescribeTrendStrength(adx, diPlus, diMinus float64) string {
+	if math.IsNaN(adx) {
+		return ""
+	}
+	var strength string
+	switch {
+	case adx >= 40:
+		strength = "强趋势"
+	case adx >= 25:
+		strength = "趋势行情"
+	case adx >= 20:
+		strength = "趋势形成中"
+	default:
+		strength = "无明显趋势（震荡）"
+	}
+	if math.IsNaN(diPlus) || math.IsNaN(diMinus) || adx < 20 {
+		return strength
+	}
+	if diPlus > diMinus {
+		return strength + "，+DI > -DI 多头主导"
+	}
+	if diMinus > diPlus {
+		return strength + "，-DI > +DI 空头主导"
+	}
+	return strength + "，多空力量均衡"
+}
+
 // calculateVolumeRatio calculates volume ratio compared to average
 // calculateVolumeRatio 计算成交量比率（相对于平均值）
 // Ratio > 1.5: 放量 / High volume
@@ -697,8 +811,35 @@ func FormatIndicatorReport(symbol string, timeframe string, ohlcvData []OHLCV, i
 		currentADX = indicators.ADX[lastIdx]
 	}
 
+	currentDIPlus := 0.0
+	if len(indicators.DI_Plus) > lastIdx && !math.IsNaN(indicators.DI_Plus[lastIdx]) {
+		currentDIPlus = indicators.DI_Plus[lastIdx]
+	}
+
+	currentDIMinus := 0.0
+	if len(indicators.DI_Minus) > lastIdx && !math.IsNaN(indicators.DI_Minus[lastIdx]) {
+		currentDIMinus = indicators.DI_Minus[lastIdx]
+	}
+
+	// The oscillators stay NaN until warmed up so the summary can leave them out
+	// 震荡指标预热前保持 NaN，摘要中据此省略
+	currentStochK, currentStochD, currentWilliamsR := math.NaN(), math.NaN(), math.NaN()
+	if len(indicators.StochRSI_K) > lastIdx && len(indicators.StochRSI_D) > lastIdx && len(indicators.WilliamsR) > lastIdx {
+		currentStochK, currentStochD, currentWilliamsR = indicators.StochRSI_K[lastIdx], indicators.StochRSI_D[lastIdx], indicators.WilliamsR[lastIdx]
+	}
+
 	sb.WriteString(fmt.Sprintf("当前价格 = %.1f, EMA(12) = %.1f, EMA(26) = %.1f\n", latestClosePrice, currentEMA12, currentEMA26))
-	sb.WriteString(fmt.Sprintf("MACD = %.1f,  RSI(7) = %.1f, RSI(14) = %.1f, ADX = %.1f\n\n", currentMACD, currentRSI7, currentRSI14, currentADX))
+	sb.WriteString(fmt.Sprintf("MACD = %.1f,  RSI(7) = %.1f, RSI(14) = %.1f, ADX(14) = %.1f, +DI = %.1f, -DI = %.1f\n", currentMACD, currentRSI7, currentRSI14, currentADX, currentDIPlus, currentDIMinus))
+	if len(indicators.ADX) > lastIdx {
+		if strength := DescribeTrendStrength(indicators.ADX[lastIdx], currentDIPlus, currentDIMinus); strength != "" {
+			sb.WriteString(fmt.Sprintf("趋势强度: %s\n", strength))
+		}
+	}
+	if oscillators := DescribeOscillators(currentStochK, currentStochD, currentWilliamsR); oscillators != "" {
+		sb.WriteString(fmt.Sprintf("StochRSI(14,14,3,3): %%K = %.1f, %%D = %.1f, Williams %%R(14) = %.1f\n", currentStochK, currentStochD, currentWilliamsR))
+		sb.WriteString(fmt.Sprintf("超买超卖: %s\n", oscillators))
+	}
+	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("下述所有价格或信号数据均按时间从旧到新排列。\n\n"))
 
 	// === 日内数据（最近10期）===
@@ -772,11 +913,37 @@ func FormatIndicatorReport(symbol string, timeframe string, ohlcvData []OHLCV, i
 		sb.WriteString(fmt.Sprintf("RSI(14): %s\n\n", formatSeries(indicators.RSI, startIdx, lastIdx, 1)))
 	}
 
-	// 6. ADX 趋势强度过滤器
-	// ADX Trend Strength Filter
+	// 6. ADX + DMI 趋势强度与方向
+	// ADX + DMI Trend Strength and Direction
 	if len(indicators.ADX) > lastIdx {
 		sb.WriteString(fmt.Sprintf("ADX: %s\n\n", formatSeries(indicators.ADX, startIdx, lastIdx, 1)))
 	}
+	if len(indicators.DI_Plus) > lastIdx {
+		sb.WriteString(fmt.Sprintf("+DI: %s\n\n", formatSeries(indicators.DI_Plus, startIdx, lastIdx, 1)))
+	}
+	if len(indicators.DI_Minus) > lastIdx {
+		sb.WriteString(fmt.Sprintf("-DI: %s\n\n", formatSeries(indicators.DI_Minus, startIdx, lastIdx, 1)))
+	}
+
+	// 7. Ichimoku 云图趋势背景
+	// Ichimoku Cloud Trend Context
+	if cloud := formatIchimokuSummary(latestClosePrice, indicators, lastIdx); cloud != "" {
+		sb.WriteString(cloud)
+		sb.WriteString("\n")
+	}
+
+	// 8. StochRSI + Williams %R 超买超卖时机
+	// StochRSI + Williams %R Overbought/Oversold Timing
+	// StochRSI ≥ 80 / Williams %R ≥ -20 超买，StochRSI ≤ 20 / Williams %R ≤ -80 超卖
+	if len(indicators.StochRSI_K) > lastIdx {
+		sb.WriteString(fmt.Sprintf("StochRSI %%K: %s\n\n", formatSeries(indicators.StochRSI_K, startIdx, lastIdx, 1)))
+	}
+	if len(indicators.StochRSI_D) > lastIdx {
+		sb.WriteString(fmt.Sprintf("StochRSI %%D: %s\n\n", formatSeries(indicators.StochRSI_D, startIdx, lastIdx, 1)))
+	}
+	if len(indicators.WilliamsR) > lastIdx {
+		sb.WriteString(fmt.Sprintf("Williams %%R: %s\n\n", formatSeries(indicators.WilliamsR, startIdx, lastIdx, 1)))
+	}
 
 	return sb.String()
 }
@@ -800,6 +967,29 @@ func (m *MarketData) GetFundingRate(ctx context.Context, symbol string) (float64
 	return fundingRate, nil
 }
 
+// GetFundingRateHistory fetches up to limit past funding rates, oldest first
+// GetFundingRateHistory 获取最多 limit 条历史资金费率，按从旧到新排列
+func (m *MarketData) GetFundingRateHistory(ctx context.Context, symbol string, limit int) ([]float64, error) {
+	rates, err := m.client.NewFundingRateService().
+		Symbol(symbol).
+		Limit(limit).
+		Do(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch funding rate history: %w", err)
+	}
+
+	history := make([]float64, 0, len(rates))
+	for _, rate := range rates {
+		value, err := strconv.ParseFloat(rate.FundingRate, 64)
+		if err != nil {
+			continue
+		}
+		history = append(history, value)
+	}
+	return history, nil
+}
+
 // GetOrderBook fetches the order book depth
 func (m *MarketData) GetOrderBook(ctx context.Context, symbol string, limit int) (map[string]interface{}, error) {
 	depth, err := m.client.NewDepthService().
@@ -1188,7 +1378,7 @@ func FormatLongerTimeframeReport(symbol string, timeframe string, ohlcvData []OH
 func (m *MarketData) GetMultiTimeframeIndicators(ctx context.Context, symbol string) []MultiTimeframeIndicator {
 	// Define fixed timeframes for multi-timeframe analysis
 	// 定义固定的多时间框架列表（经典的多周期分析组合）
-	timeframes := []string{"5m", "15m", "1h", "4h"}
+	timeframes := multiTimeframes
 
 	// Calculate lookback days for each timeframe
 	// 为每个时间框架计算回看天数
@@ -1224,6 +1414,12 @@ func (m *MarketData) GetMultiTimeframeIndicators(ctx context.Context, symbol str
 					MACD:      math.NaN(),
 					RSI7:      math.NaN(),
 					RSI14:     math.NaN(),
+					ADX:       math.NaN(),
+					DIPlus:    math.NaN(),
+					DIMinus:   math.NaN(),
+					StochK:    math.NaN(),
+					StochD:    math.NaN(),
+					WilliamsR: math.NaN(),
 				}
 				return
 			}
@@ -1242,6 +1438,12 @@ func (m *MarketData) GetMultiTimeframeIndicators(ctx context.Context, symbol str
 				MACD:      math.NaN(),
 				RSI7:      math.NaN(),
 				RSI14:     math.NaN(),
+				ADX:       math.NaN(),
+				DIPlus:    math.NaN(),
+				DIMinus:   math.NaN(),
+				StochK:    math.NaN(),
+				StochD:    math.NaN(),
+				WilliamsR: math.NaN(),
 			}
 
 			if len(indicators.EMA_20) > lastIdx && !math.IsNaN(indicators.EMA_20[lastIdx]) {
@@ -1259,6 +1461,18 @@ func (m *MarketData) GetMultiTimeframeIndicators(ctx context.Context, symbol str
 			if len(indicators.RSI) > lastIdx && !math.IsNaN(indicators.RSI[lastIdx]) {
 				result.RSI14 = indicators.RSI[lastIdx]
 			}
+			if len(indicators.ADX) > lastIdx && !math.IsNaN(indicators.ADX[lastIdx]) {
+				result.ADX = indicators.ADX[lastIdx]
+				result.DIPlus = indicators.DI_Plus[lastIdx]
+				result.DIMinus = indicators.DI_Minus[lastIdx]
+			}
+			if len(indicators.StochRSI_K) > lastIdx && !math.IsNaN(indicators.StochRSI_D[lastIdx]) {
+				result.StochK = indicators.StochRSI_K[lastIdx]
+				result.StochD = indicators.StochRSI_D[lastIdx]
+			}
+			if len(indicators.WilliamsR) > lastIdx && !math.IsNaN(indicators.WilliamsR[lastIdx]) {
+				result.WilliamsR = indicators.WilliamsR[lastIdx]
+			}
 
 			results[index] = result
 		}(i, tf)
@@ -1282,6 +1496,7 @@ func FormatMultiTimeframeReport(indicators []MultiTimeframeIndicator) string {
 	// Define display names for timeframes (Chinese)
 	// 定义时间框架的显示名称（中文）
 	displayNames := map[string]string{
+		"3m":  "3分钟",
 		"5m":  "5分钟",
 		"15m": "15分钟",
 		"1h":  "1小时",
@@ -1321,9 +1536,24 @@ func FormatMultiTimeframeReport(indicators []MultiTimeframeIndicator) string {
 			rsi14Str = fmt.Sprintf("%.2f", ind.RSI14)
 		}
 
-		// Format: "3分钟:  EMA20=86014.071, EMA50=86066.329, MACD=-28.439, RSI7=48.63, RSI14=51.64"
-		sb.WriteString(fmt.Sprintf("%-6s  EMA20=%s, EMA50=%s, MACD=%s, RSI7=%s, RSI14=%s\n",
-			displayName+":", ema20Str, ema50Str, macdStr, rsi7Str, rsi14Str))
+		adxStr := "N/A"
+		if !math.IsNaN(ind.ADX) {
+			adxStr = fmt.Sprintf("%.2f(+DI=%.2f, -DI=%.2f)", ind.ADX, ind.DIPlus, ind.DIMinus)
+		}
+
+		stochStr := "N/A"
+		if !math.IsNaN(ind.StochK) && !math.IsNaN(ind.StochD) {
+			stochStr = fmt.Sprintf("%.2f/%.2f", ind.StochK, ind.StochD)
+		}
+
+		williamsRStr := "N/A"
+		if !math.IsNaN(ind.WilliamsR) {
+			williamsRStr = fmt.Sprintf("%.2f", ind.WilliamsR)
+		}
+
+		// Format: "3分钟:  EMA20=86014.071, EMA50=86066.329, MACD=-28.439, RSI7=48.63, RSI14=51.64, ADX=27.40(+DI=30.12, -DI=18.55), StochRSI=85.20/78.41, WR=-12.35"
+		sb.WriteString(fmt.Sprintf("%-6s  EMA20=%s, EMA50=%s, MACD=%s, RSI7=%s, RSI14=%s, ADX=%s, StochRSI=%s, WR=%s\n",
+			displayName+":", ema20Str, ema50Str, macdStr, rsi7Str, rsi14Str, adxStr, stochStr, williamsRStr))
 	}
 
 	return sb.String()
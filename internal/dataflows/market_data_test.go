@@ -310,8 +310,8 @@ func TestCalculateIndicators(t *testing.T) {
 		if len(indicators.EMA_26) != dataPoints {
 			t.Errorf("EMA_26 length: expected %d, got %d", dataPoints, len(indicators.EMA_26))
 		}
-		if len(indicators.ATR) != dataPoints {
-			t.Errorf("ATR length: expected %d, got %d", dataPoints, len(indicators.ATR))
+		if len(indicators.ATR_14) != dataPoints {
+			t.Errorf("ATR length: expected %d, got %d", dataPoints, len(indicators.ATR_14))
 		}
 		if len(indicators.ATR_3) != dataPoints {
 			t.Errorf("ATR_3 length: expected %d, got %d", dataPoints, len(indicators.ATR_3))
@@ -380,8 +380,8 @@ func TestCalculateIndicators(t *testing.T) {
 
 		// 验证 ATR 为正值
 		// Verify ATR is positive
-		if !math.IsNaN(indicators.ATR[lastIdx]) && indicators.ATR[lastIdx] <= 0 {
-			t.Errorf("ATR should be positive, got: %f", indicators.ATR[lastIdx])
+		if !math.IsNaN(indicators.ATR_14[lastIdx]) && indicators.ATR_14[lastIdx] <= 0 {
+			t.Errorf("ATR should be positive, got: %f", indicators.ATR_14[lastIdx])
 		}
 		if !math.IsNaN(indicators.ATR_3[lastIdx]) && indicators.ATR_3[lastIdx] <= 0 {
 			t.Errorf("ATR_3 should be positive, got: %f", indicators.ATR_3[lastIdx])
@@ -443,6 +443,55 @@ func TestCalculateIndicators(t *testing.T) {
 	})
 }
 
+// TestCalculateADX tests ADX(14) warm-up and the direction of the DI lines
+// TestCalculateADX 测试 ADX(14) 的预热期以及 DI 线的方向
+func TestCalculateADX(t *testing.T) {
+	n := 60
+	highs, lows, closes := make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		price := 100.0 + float64(i)
+		highs[i], lows[i], closes[i] = price+0.5, price-0.5, price
+	}
+
+	adx, diPlus, diMinus := calculateADX(highs, lows, closes, 14)
+
+	// DI is available from bar 14, ADX only after a full period of DX values (bar 27)
+	// DI 从第 14 根开始可用，ADX 需要一个完整周期的 DX（第 27 根）
+	if !math.IsNaN(diPlus[13]) || math.IsNaN(diPlus[14]) {
+		t.Errorf("Expected +DI to start at bar 14, got %v and %v", diPlus[13], diPlus[14])
+	}
+	if !math.IsNaN(adx[14]) || !math.IsNaN(adx[26]) || math.IsNaN(adx[27]) {
+		t.Errorf("Expected ADX NaN until bar 26 and a value at bar 27, got %v, %v, %v", adx[14], adx[26], adx[27])
+	}
+
+	// A steady rise is a strong uptrend
+	// 稳定上涨是强上升趋势
+	last := n - 1
+	if adx[last] < 40 || diPlus[last] <= diMinus[last] {
+		t.Errorf("Expected a strong uptrend, got ADX %.1f, +DI %.1f, -DI %.1f", adx[last], diPlus[last], diMinus[last])
+	}
+}
+
+// TestDescribeTrendStrength tests the interpretation of ADX and DMI
+// TestDescribeTrendStrength 测试 ADX 与 DMI 的解读
+func TestDescribeTrendStrength(t *testing.T) {
+	tests := []struct {
+		adx, diPlus, diMinus float64
+		want                 string
+	}{
+		{math.NaN(), 20, 10, ""},
+		{15, 30, 10, "无明显趋势（震荡）"},
+		{22, 30, 10, "趋势形成中，+DI > -DI 多头主导"},
+		{30, 10, 30, "趋势行情，-DI > +DI 空头主导"},
+		{45, 35, 12, "强趋势，+DI > -DI 多头主导"},
+	}
+	for _, tt := range tests {
+		if got := DescribeTrendStrength(tt.adx, tt.diPlus, tt.diMinus); got != tt.want {
+			t.Errorf("DescribeTrendStrength(%v, %v, %v) = %q, want %q", tt.adx, tt.diPlus, tt.diMinus, got, tt.want)
+		}
+	}
+}
+
 // TestConvertTimeframe tests the convertTimeframe helper function
 // TestConvertTimeframe 测试时间周期转换辅助函数
 func TestConvertTimeframe(t *testing.T) {
@@ -0,0 +1,98 @@
+package dataflows
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CryptoSnapshot bundles the derivatives data used by the crypto analyst for one symbol
+// CryptoSnapshot 汇总加密货币分析师所需的单个交易对衍生品数据
+type CryptoSnapshot struct {
+	Symbol          string
+	FundingRate     float64                // 当前资金费率 / Current funding rate
+	FundingErr      error                  // 资金费率获取错误 / Funding rate fetch error
+	OpenInterest    map[string]interface{} // 持仓量变化统计 / Open interest change statistics
+	OpenInterestErr error                  // 持仓量获取错误 / Open interest fetch error
+	Stats24h        map[string]string      // 24 小时统计 / 24-hour statistics
+	Stats24hErr     error                  // 24 小时统计获取错误 / 24-hour stats fetch error
+	Elapsed         time.Duration          // 数据组装耗时 / Time spent assembling the snapshot
+}
+
+// GetCryptoSnapshot fetches funding rate, open interest and 24h stats concurrently instead of one after another
+// GetCryptoSnapshot 并发获取资金费率、持仓量和 24 小时统计，而不是逐个串行请求
+func (m *MarketData) GetCryptoSnapshot(ctx context.Context, symbol string, oiPeriod string, oiLimit int) *CryptoSnapshot {
+	start := time.Now()
+	snapshot := &CryptoSnapshot{Symbol: symbol}
+
+	// Each goroutine writes to its own fields, so no lock is needed
+	// 每个 goroutine 只写入自己的字段，因此无需加锁
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	go func() {
+		defer wg.Done()
+		snapshot.FundingRate, snapshot.FundingErr = m.GetFundingRate(ctx, symbol)
+	}()
+
+	go func() {
+		defer wg.Done()
+		snapshot.OpenInterest, snapshot.OpenInterestErr = m.GetOpenInterestChange(ctx, symbol, oiPeriod, oiLimit)
+	}()
+
+	go func() {
+		defer wg.Done()
+		snapshot.Stats24h, snapshot.Stats24hErr = m.Get24HrStats(ctx, symbol)
+	}()
+
+	wg.Wait()
+	snapshot.Elapsed = time.Since(start)
+
+	return snapshot
+}
+
+// KlineSet holds candles for the primary timeframe and, optionally, the longer timeframe
+// KlineSet 保存主时间周期以及（可选的）长期时间周期的 K 线
+type KlineSet struct {
+	Primary     []OHLCV
+	PrimaryErr  error
+	Longer      []OHLCV
+	LongerErr   error
+	MultiFrames []MultiTimeframeIndicator
+	Elapsed     time.Duration
+}
+
+// GetKlineSet fetches the primary klines, longer-timeframe klines and multi-timeframe indicators in parallel
+// GetKlineSet 并行获取主周期 K 线、长期周期 K 线和多时间框架指标
+// Pass an empty longerTimeframe to skip the longer-timeframe request
+// 传入空的 longerTimeframe 可跳过长期周期请求
+func (m *MarketData) GetKlineSet(ctx context.Context, symbol, timeframe string, lookbackDays int, longerTimeframe string, longerLookbackDays int) *KlineSet {
+	start := time.Now()
+	set := &KlineSet{}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		set.Primary, set.PrimaryErr = m.GetOHLCV(ctx, symbol, timeframe, lookbackDays)
+	}()
+
+	go func() {
+		defer wg.Done()
+		set.MultiFrames = m.GetMultiTimeframeIndicators(ctx, symbol)
+	}()
+
+	if longerTimeframe != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set.Longer, set.LongerErr = m.GetOHLCV(ctx, symbol, longerTimeframe, longerLookbackDays)
+		}()
+	}
+
+	wg.Wait()
+	set.Elapsed = time.Since(start)
+
+	return set
+}
@@ -62,6 +62,12 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 					MACD:      -50.0,
 					RSI7:      48.5,
 					RSI14:     52.0,
+					ADX:       27.4,
+					DIPlus:    30.12,
+					DIMinus:   18.55,
+					StochK:    85.2,
+					StochD:    78.41,
+					WilliamsR: -12.35,
 				},
 			},
 			wantEmpty: false,
@@ -73,6 +79,9 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 				"MACD=-50.000",
 				"RSI7=48.50",
 				"RSI14=52.00",
+				"ADX=27.40(+DI=30.12, -DI=18.55)",
+				"StochRSI=85.20/78.41",
+				"WR=-12.35",
 			},
 		},
 		{
@@ -85,6 +94,9 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 					MACD:      -28.439,
 					RSI7:      48.63,
 					RSI14:     51.64,
+					StochK:    64.1,
+					StochD:    58.7,
+					WilliamsR: -35.6,
 				},
 				{
 					Timeframe: "5m",
@@ -93,6 +105,9 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 					MACD:      -41.399,
 					RSI7:      38.35,
 					RSI14:     41.86,
+					StochK:    12.5,
+					StochD:    18.25,
+					WilliamsR: -88.4,
 				},
 				{
 					Timeframe: "15m",
@@ -109,6 +124,8 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 				"3分钟",
 				"5分钟",
 				"15分钟",
+				"StochRSI=64.10/58.70, WR=-35.60",
+				"StochRSI=12.50/18.25, WR=-88.40",
 			},
 		},
 		{
@@ -121,6 +138,10 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 					MACD:      math.NaN(),
 					RSI7:      48.5,
 					RSI14:     math.NaN(),
+					ADX:       math.NaN(),
+					StochK:    math.NaN(),
+					StochD:    math.NaN(),
+					WilliamsR: math.NaN(),
 				},
 			},
 			wantEmpty: false,
@@ -132,6 +153,9 @@ func TestFormatMultiTimeframeReport(t *testing.T) {
 				"MACD=N/A",
 				"RSI7=48.50",
 				"RSI14=N/A",
+				"ADX=N/A",
+				"StochRSI=N/A",
+				"WR=N/A",
 			},
 		},
 	}
@@ -173,6 +197,9 @@ func TestFormatMultiTimeframeReportFormat(t *testing.T) {
 			MACD:      -28.439,
 			RSI7:      48.63,
 			RSI14:     51.64,
+			StochK:    22.4,
+			StochD:    31.75,
+			WilliamsR: -83.1,
 		},
 	}
 
@@ -191,7 +218,7 @@ func TestFormatMultiTimeframeReportFormat(t *testing.T) {
 
 	// Check format of indicator line
 	indicatorLine := lines[1]
-	expectedParts := []string{"3分钟:", "EMA20=", "EMA50=", "MACD=", "RSI7=", "RSI14="}
+	expectedParts := []string{"3分钟:", "EMA20=", "EMA50=", "MACD=", "RSI7=", "RSI14=", "ADX=", "StochRSI=22.40/31.75", "WR=-83.10"}
 	for _, part := range expectedParts {
 		if !strings.Contains(indicatorLine, part) {
 			t.Errorf("Expected indicator line to contain '%s', got: %s", part, indicatorLine)
@@ -0,0 +1,109 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stochastic RSI (14, 14, 3, 3) and Williams %R (14) periods
+// 随机 RSI（14, 14, 3, 3）与威廉指标（14）的周期
+const (
+	stochRSIPeriod      = 14 // RSI 周期 / RSI period
+	stochRSIStochPeriod = 14 // 在 RSI 上取随机值的回看周期 / Lookback of the stochastic taken over the RSI
+	stochRSISmoothK     = 3  // %K 平滑周期 / %K smoothing
+	stochRSISmoothD     = 3  // %D 平滑周期 / %D smoothing
+	williamsRPeriod     = 14
+)
+
+// Overbought and oversold bands: StochRSI runs 0 to 100, Williams %R runs -100 to 0
+// 超买超卖区间：StochRSI 取值 0 到 100，威廉指标取值 -100 到 0
+const (
+	stochRSIOverbought  = 80.0
+	stochRSIOversold    = 20.0
+	williamsROverbought = -20.0
+	williamsROversold   = -80.0
+)
+
+// calculateStochRSI returns the smoothed %K and %D of the stochastic taken over RSI(14), both 0 to 100
+// calculateStochRSI 返回在 RSI(14) 上计算的随机指标平滑后的 %K 与 %D，取值 0 到 100
+// When the RSI has not moved over the lookback the previous reading is kept (50 at the start). Values before
+// warm-up are NaN: %K starts at bar 29 and %D at bar 31
+// 回看期内 RSI 没有变化时沿用上一个读数（起始时为 50）。预热期内的值为 NaN：%K 从第 29 根开始，%D 从第 31 根开始
+func calculateStochRSI(closes []float64) (k, d []float64) {
+	rsi := calculateRSI(closes, stochRSIPeriod)
+	raw := make([]float64, len(closes))
+	prev := 50.0
+	for i := range raw {
+		raw[i] = math.NaN()
+		if i < stochRSIPeriod+stochRSIStochPeriod-1 {
+			continue
+		}
+		high, low := rsi[i], rsi[i]
+		for j := i - stochRSIStochPeriod + 1; j < i; j++ {
+			high = math.Max(high, rsi[j])
+			low = math.Min(low, rsi[j])
+		}
+		if high > low {
+			prev = (rsi[i] - low) / (high - low) * 100
+		}
+		raw[i] = prev
+	}
+	k = calculateSMA(raw, stochRSISmoothK)
+	d = calculateSMA(k, stochRSISmoothD) // NaN 会自然传播 / NaN propagates
+	return k, d
+}
+
+// calculateWilliamsR returns where each close sits in the period's high-low range, from -100 at the low to 0 at the high
+// calculateWilliamsR 返回每根收盘价在周期高低区间中的位置，最低价为 -100，最高价为 0
+// A range with no movement reads -50; values before warm-up are NaN
+// 区间没有波动时为 -50；预热期内的值为 NaN
+func calculateWilliamsR(highs, lows, closes []float64, period int) []float64 {
+	result := make([]float64, len(closes))
+	for i := range closes {
+		if i < period-1 {
+			result[i] = math.NaN()
+			continue
+		}
+		high, low := highs[i], lows[i]
+		for j := i - period + 1; j < i; j++ {
+			high = math.Max(high, highs[j])
+			low = math.Min(low, lows[j])
+		}
+		if high == low {
+			result[i] = -50
+			continue
+		}
+		result[i] = (high - closes[i]) / (high - low) * -100
+	}
+	return result
+}
+
+// DescribeOscillators interprets StochRSI %K/%D and Williams %R, or returns an empty string when any is not yet available
+// DescribeOscillators 解读 StochRSI %K/%D 与威廉指标；任一指标尚不可用时返回空字符串
+func DescribeOscillators(k, d, williamsR float64) string {
+	if math.IsNaN(k) || math.IsNaN(d) || math.IsNaN(williamsR) {
+		return ""
+	}
+
+	stoch := "StochRSI 中性区间"
+	switch {
+	case k >= stochRSIOverbought:
+		stoch = "StochRSI 超买"
+	case k <= stochRSIOversold:
+		stoch = "StochRSI 超卖"
+	}
+	if k > d {
+		stoch += "，%K 在 %D 上方（动能向上）"
+	} else if k < d {
+		stoch += "，%K 在 %D 下方（动能向下）"
+	}
+
+	wr := "Williams %R 中性区间"
+	switch {
+	case williamsR >= williamsROverbought:
+		wr = "Williams %R 超买（收盘接近周期高点）"
+	case williamsR <= williamsROversold:
+		wr = "Williams %R 超卖（收盘接近周期低点）"
+	}
+	return fmt.Sprintf("%s；%s", stoch, wr)
+}
@@ -0,0 +1,106 @@
+package dataflows
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// zigzagBars returns n bars oscillating around 100 with a slow upward drift, so the RSI keeps moving
+// zigzagBars 返回 n 根围绕 100 缓慢上行震荡的 K 线，使 RSI 持续变化
+func zigzagBars(n int) []OHLCV {
+	bars := make([]OHLCV, n)
+	for i := range bars {
+		c := 100 + 5*math.Sin(float64(i)/3) + float64(i)/10
+		bars[i] = OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+	return bars
+}
+
+func TestCalculateStochRSI(t *testing.T) {
+	ind := CalculateIndicators(zigzagBars(80))
+
+	// %K warms up at bar 29 and %D at bar 31
+	// %K 从第 29 根完成预热，%D 从第 31 根开始
+	if !math.IsNaN(ind.StochRSI_K[28]) || math.IsNaN(ind.StochRSI_K[29]) {
+		t.Errorf("Expected %%K NaN at bar 28 and a value at bar 29, got %v and %v", ind.StochRSI_K[28], ind.StochRSI_K[29])
+	}
+	if !math.IsNaN(ind.StochRSI_D[30]) || math.IsNaN(ind.StochRSI_D[31]) {
+		t.Errorf("Expected %%D NaN at bar 30 and a value at bar 31, got %v and %v", ind.StochRSI_D[30], ind.StochRSI_D[31])
+	}
+	for i := 31; i < 80; i++ {
+		if k, d := ind.StochRSI_K[i], ind.StochRSI_D[i]; k < 0 || k > 100 || d < 0 || d > 100 {
+			t.Fatalf("Expected StochRSI within 0-100 at bar %d, got %%K %v, %%D %v", i, k, d)
+		}
+	}
+
+	// A flat RSI keeps the last reading instead of dividing by zero
+	// RSI 不变时沿用上一个读数，而不是除以零
+	k, _ := calculateStochRSI(closesOf(risingBars(40)))
+	if k[39] != 50 {
+		t.Errorf("Expected a constant RSI to read 50, got %v", k[39])
+	}
+}
+
+func TestCalculateWilliamsR(t *testing.T) {
+	highs := []float64{10, 12, 14, 13}
+	lows := []float64{8, 9, 11, 10}
+	closes := []float64{9, 11, 14, 11}
+
+	wr := calculateWilliamsR(highs, lows, closes, 3)
+	if !math.IsNaN(wr[1]) {
+		t.Errorf("Expected NaN before warm-up, got %v", wr[1])
+	}
+	// Bar 2 closes at the range high, bar 3 at a third of the way down from it
+	// 第 2 根收于区间最高点，第 3 根收于从高点向下三分之一处
+	if wr[2] != 0 || math.Abs(wr[3]-(-60)) > 1e-9 {
+		t.Errorf("Expected 0 and -60, got %v and %v", wr[2], wr[3])
+	}
+	if flat := calculateWilliamsR([]float64{5, 5}, []float64{5, 5}, []float64{5, 5}, 2); flat[1] != -50 {
+		t.Errorf("Expected a flat range to read -50, got %v", flat[1])
+	}
+}
+
+func TestDescribeOscillators(t *testing.T) {
+	tests := []struct {
+		k, d, wr float64
+		want     string
+	}{
+		{math.NaN(), 50, -50, ""},
+		{85, 78, -10, "StochRSI 超买，%K 在 %D 上方（动能向上）；Williams %R 超买（收盘接近周期高点）"},
+		{15, 22, -90, "StochRSI 超卖，%K 在 %D 下方（动能向下）；Williams %R 超卖（收盘接近周期低点）"},
+		{50, 50, -50, "StochRSI 中性区间；Williams %R 中性区间"},
+	}
+	for _, tt := range tests {
+		if got := DescribeOscillators(tt.k, tt.d, tt.wr); got != tt.want {
+			t.Errorf("DescribeOscillators(%v, %v, %v) = %q, want %q", tt.k, tt.d, tt.wr, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIndicatorReportOscillators(t *testing.T) {
+	bars := zigzagBars(80)
+	report := FormatIndicatorReport("BTCUSDT", "1h", bars, CalculateIndicators(bars))
+	for _, want := range []string{"StochRSI(14,14,3,3): %K = ", "Williams %R(14) = ", "超买超卖: ", "StochRSI %K: [", "StochRSI %D: [", "Williams %R: ["} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q:\n%s", want, report)
+		}
+	}
+
+	// Before warm-up the summary leaves the oscillators out
+	// 预热完成前摘要中不输出震荡指标
+	short := zigzagBars(20)
+	if report := FormatIndicatorReport("BTCUSDT", "1h", short, CalculateIndicators(short)); strings.Contains(report, "StochRSI(14,14,3,3)") {
+		t.Errorf("Expected no oscillator summary before warm-up:\n%s", report)
+	}
+}
+
+// closesOf returns the closing prices of bars
+// closesOf 返回 K 线的收盘价
+func closesOf(bars []OHLCV) []float64 {
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	return closes
+}
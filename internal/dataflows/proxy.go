@@ -0,0 +1,71 @@
+package dataflows
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// ProxyDirect disables BINANCE_PROXY for WebSocket streams when set as BINANCE_WS_PROXY
+// ProxyDirect 作为 BINANCE_WS_PROXY 时表示 WebSocket 不使用 BINANCE_PROXY
+const ProxyDirect = "direct"
+
+// ParseProxyURL validates a proxy address; http, https, socks5 and socks5h are supported
+// ParseProxyURL 校验代理地址，支持 http、https、socks5 和 socks5h
+// socks5h resolves hostnames on the proxy, which matters when local DNS is blocked or poisoned
+// socks5h 在代理端解析域名，适用于本地 DNS 被屏蔽或污染的情况
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy url %q has no host", raw)
+	}
+	return proxyURL, nil
+}
+
+// RESTProxy returns the proxy function for Binance REST calls, falling back to the environment when BINANCE_PROXY is unset
+// RESTProxy 返回币安 REST 调用的代理函数，未设置 BINANCE_PROXY 时使用环境变量
+func RESTProxy(cfg *config.Config) (func(*http.Request) (*url.URL, error), error) {
+	if cfg.BinanceProxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	proxyURL, err := ParseProxyURL(cfg.BinanceProxy)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(proxyURL), nil
+}
+
+// WebSocketProxy returns the proxy address for Binance WebSocket streams, or "" to use the environment
+// WebSocketProxy 返回币安 WebSocket 流使用的代理地址，返回空字符串表示使用环境变量
+//
+// BINANCE_WS_PROXY overrides BINANCE_PROXY, and "direct" keeps streams off the REST proxy. The WebSocket
+// dialer resolves hostnames on SOCKS5 proxies already, so socks5h is passed on as socks5.
+// BINANCE_WS_PROXY 优先于 BINANCE_PROXY，"direct" 表示流连接不走 REST 代理。WebSocket 拨号器本身就在 SOCKS5 代理端解析域名，因此 socks5h 按 socks5 传递。
+func WebSocketProxy(cfg *config.Config) (string, error) {
+	raw := cfg.BinanceWsProxy
+	if raw == "" {
+		raw = cfg.BinanceProxy
+	}
+	if raw == "" || strings.EqualFold(raw, ProxyDirect) {
+		return "", nil
+	}
+	proxyURL, err := ParseProxyURL(raw)
+	if err != nil {
+		return "", err
+	}
+	if proxyURL.Scheme == "socks5h" {
+		proxyURL.Scheme = "socks5"
+	}
+	return proxyURL.String(), nil
+}
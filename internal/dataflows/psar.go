@@ -0,0 +1,53 @@
+package dataflows
+
+import (
+	"math"
+)
+
+// ParabolicSAR returns Wilder's parabolic stop-and-reverse level for the bar after the latest one
+// ParabolicSAR 返回 Wilder 抛物线止损转向指标在最新 K 线之后一根 K 线的价位
+// step is the acceleration factor increment (and its start), max its cap; long reports whether the SAR is below price
+// (an uptrend). ok is false with fewer than three bars
+// step 为加速因子的步长（也是初始值），max 为其上限；long 表示 SAR 位于价格下方（上升趋势）。K 线少于三根时 ok 为 false
+func ParabolicSAR(data []OHLCV, step, max float64) (sar float64, long, ok bool) {
+	if len(data) < 3 || step <= 0 || max < step {
+		return 0, false, false
+	}
+
+	// Seed the trend from the first two closes
+	// 用前两根 K 线的收盘价确定初始趋势
+	long = data[1].Close >= data[0].Close
+	var ep float64
+	if long {
+		sar, ep = math.Min(data[0].Low, data[1].Low), math.Max(data[0].High, data[1].High)
+	} else {
+		sar, ep = math.Max(data[0].High, data[1].High), math.Min(data[0].Low, data[1].Low)
+	}
+	af := step
+
+	// next advances the SAR by one bar; it may not move into the range of the two bars before it
+	// next 将 SAR 推进一根 K 线；SAR 不得进入其前两根 K 线的价格区间
+	next := func(i int) float64 {
+		s := sar + af*(ep-sar)
+		if long {
+			return math.Min(s, math.Min(data[i-1].Low, data[i-2].Low))
+		}
+		return math.Max(s, math.Max(data[i-1].High, data[i-2].High))
+	}
+
+	for i := 2; i < len(data); i++ {
+		sar = next(i)
+		bar := data[i]
+		switch {
+		case long && bar.Low < sar:
+			long, sar, ep, af = false, ep, bar.Low, step
+		case !long && bar.High > sar:
+			long, sar, ep, af = true, ep, bar.High, step
+		case long && bar.High > ep:
+			ep, af = bar.High, math.Min(af+step, max)
+		case !long && bar.Low < ep:
+			ep, af = bar.Low, math.Min(af+step, max)
+		}
+	}
+	return next(len(data)), long, true
+}
@@ -0,0 +1,37 @@
+package dataflows
+
+import (
+	"testing"
+)
+
+func TestParabolicSAR(t *testing.T) {
+	// A steady uptrend keeps the SAR below price and closing in on it
+	// 稳定上涨时 SAR 始终位于价格下方并逐渐逼近
+	data := make([]OHLCV, 40)
+	for i := range data {
+		c := 100 + float64(i)
+		data[i] = OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+	sar, long, ok := ParabolicSAR(data, 0.02, 0.2)
+	if !ok || !long {
+		t.Fatalf("Expected an uptrend SAR, got %.4f long=%v ok=%v", sar, long, ok)
+	}
+	if sar >= data[len(data)-1].Low || sar < data[len(data)-3].Low-10 {
+		t.Errorf("Expected the SAR just below the recent lows, got %.4f", sar)
+	}
+
+	// A sharp decline flips the SAR above price
+	// 急跌使 SAR 翻转到价格上方
+	for i := 0; i < 10; i++ {
+		c := 130 - 3*float64(i)
+		data = append(data, OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c})
+	}
+	sar, long, ok = ParabolicSAR(data, 0.02, 0.2)
+	if !ok || long || sar <= data[len(data)-1].High {
+		t.Errorf("Expected a downtrend SAR above price, got %.4f long=%v ok=%v", sar, long, ok)
+	}
+
+	if _, _, ok := ParabolicSAR(data[:2], 0.02, 0.2); ok {
+		t.Error("Expected too few bars to fail")
+	}
+}
@@ -0,0 +1,186 @@
+package dataflows
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// usedWeightHeader is the header Binance futures uses to report the IP's request weight in the current minute
+// usedWeightHeader 是币安合约报告当前分钟内该 IP 已用请求权重的响应头
+const usedWeightHeader = "X-Mbx-Used-Weight-1m"
+
+// weightWindow is the length of Binance's request weight window
+// weightWindow 是币安请求权重的统计窗口长度
+const weightWindow = time.Minute
+
+// WeightLimiter throttles Binance REST requests by the used weight reported in response headers
+// WeightLimiter 根据响应头报告的已用权重对币安 REST 请求进行限流
+//
+// One limiter is shared by every Binance client in the process (stop-loss monitor, analysts, web handlers),
+// because Binance counts weight per IP rather than per client.
+// 进程内所有币安客户端（止损监控、分析师、Web 接口）共享一个限流器，因为币安按 IP 而非按客户端统计权重。
+type WeightLimiter struct {
+	maxWeight   int       // 每分钟允许使用的权重上限 / Weight budget per minute
+	usedWeight  int       // 当前窗口已用权重（含估算）/ Weight used in the current window (including estimates)
+	windowStart time.Time // 当前窗口开始时间 / Start of the current window
+	bannedUntil time.Time // 收到 429/418 后的禁止请求截止时间 / No requests before this time after a 429/418
+	now         func() time.Time
+	sleep       func(time.Duration)
+	mu          sync.Mutex
+}
+
+// NewWeightLimiter creates a limiter that keeps each minute's weight at or below maxWeight
+// NewWeightLimiter 创建一个将每分钟权重控制在 maxWeight 以内的限流器
+func NewWeightLimiter(maxWeight int) *WeightLimiter {
+	return &WeightLimiter{
+		maxWeight: maxWeight,
+		now:       time.Now,
+		sleep:     time.Sleep,
+	}
+}
+
+// Wait blocks until a request can be sent without exceeding the weight budget, then reserves one unit
+// Wait 阻塞直到发送请求不会超出权重预算，然后预留 1 个单位
+// The reservation is an estimate; the next response header replaces it with the exchange's count
+// 预留值只是估算，下一次响应头会用交易所的真实计数覆盖
+func (l *WeightLimiter) Wait() {
+	for {
+		l.mu.Lock()
+		now := l.now()
+		l.rollWindow(now)
+
+		var wait time.Duration
+		switch {
+		case now.Before(l.bannedUntil):
+			wait = l.bannedUntil.Sub(now)
+		case l.usedWeight >= l.maxWeight:
+			wait = l.windowStart.Add(weightWindow).Sub(now)
+		default:
+			l.usedWeight++
+			l.mu.Unlock()
+			return
+		}
+		l.mu.Unlock()
+
+		l.sleep(wait)
+	}
+}
+
+// Observe records the weight reported by a response and backs off on 429/418
+// Observe 记录响应报告的权重，并在收到 429/418 时退避
+func (l *WeightLimiter) Observe(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.rollWindow(now)
+
+	if used, err := strconv.Atoi(resp.Header.Get(usedWeightHeader)); err == nil {
+		l.usedWeight = used
+	}
+
+	// 429 means the limit was hit, 418 means the IP is already banned; both carry Retry-After in seconds
+	// 429 表示触发限制，418 表示 IP 已被封禁；两者都带有以秒为单位的 Retry-After
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		retryAfter := l.windowStart.Add(weightWindow).Sub(now)
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		if until := now.Add(retryAfter); until.After(l.bannedUntil) {
+			l.bannedUntil = until
+		}
+	}
+}
+
+// Usage returns the weight used in the current window and the budget
+// Usage 返回当前窗口已用权重和预算
+func (l *WeightLimiter) Usage() (used, max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollWindow(l.now())
+	return l.usedWeight, l.maxWeight
+}
+
+// rollWindow starts a new window when the wall-clock minute changes, matching Binance's minute buckets
+// rollWindow 在自然分钟变化时开启新窗口，与币安的分钟统计保持一致
+func (l *WeightLimiter) rollWindow(now time.Time) {
+	start := now.Truncate(weightWindow)
+	if start.After(l.windowStart) {
+		l.windowStart = start
+		l.usedWeight = 0
+	}
+}
+
+// weightLimitedTransport wraps an http.RoundTripper with a WeightLimiter
+// weightLimitedTransport 用 WeightLimiter 包装 http.RoundTripper
+type weightLimitedTransport struct {
+	base    http.RoundTripper
+	limiter *WeightLimiter
+}
+
+func (t *weightLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.limiter.Wait()
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.limiter.Observe(resp)
+	}
+	return resp, err
+}
+
+var (
+	sharedWeightLimiters   = map[int]*WeightLimiter{}
+	sharedWeightLimitersMu sync.Mutex
+)
+
+// SharedWeightLimiter returns the limiter shared by all callers with the same budget, or nil when BINANCE_MAX_WEIGHT_PER_MINUTE is 0
+// SharedWeightLimiter 返回相同权重预算的调用方共享的限流器；BINANCE_MAX_WEIGHT_PER_MINUTE 为 0 时返回 nil
+// Limiters are keyed by the budget, so the result does not depend on which caller asked first
+// 限流器按预算区分，结果与调用先后无关
+func SharedWeightLimiter(cfg *config.Config) *WeightLimiter {
+	if cfg.BinanceMaxWeightPerMinute <= 0 {
+		return nil
+	}
+
+	sharedWeightLimitersMu.Lock()
+	defer sharedWeightLimitersMu.Unlock()
+	limiter, ok := sharedWeightLimiters[cfg.BinanceMaxWeightPerMinute]
+	if !ok {
+		limiter = NewWeightLimiter(cfg.BinanceMaxWeightPerMinute)
+		sharedWeightLimiters[cfg.BinanceMaxWeightPerMinute] = limiter
+	}
+	return limiter
+}
+
+// WithWeightLimit wraps a Binance HTTP client's transport with the shared weight limiter
+// WithWeightLimit 用共享权重限流器包装币安 HTTP 客户端的传输层
+// A nil client gets a new one based on http.DefaultTransport; the client is returned unchanged when limiting is disabled
+// 传入 nil 时基于 http.DefaultTransport 新建客户端；限流关闭时原样返回
+func WithWeightLimit(cfg *config.Config, client *http.Client) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	limiter := SharedWeightLimiter(cfg)
+	if limiter == nil {
+		return client
+	}
+
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, wrapped := base.(*weightLimitedTransport); wrapped {
+		return client
+	}
+
+	limited := *client
+	limited.Transport = &weightLimitedTransport{base: base, limiter: limiter}
+	return &limited
+}
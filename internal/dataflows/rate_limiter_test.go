@@ -0,0 +1,101 @@
+package dataflows
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// newTestLimiter returns a limiter driven by a fake clock; sleeping advances the clock
+// newTestLimiter 返回由模拟时钟驱动的限流器；休眠会推进时钟
+func newTestLimiter(maxWeight int, start time.Time) (*WeightLimiter, *time.Time, *[]time.Duration) {
+	now := start
+	var sleeps []time.Duration
+	l := NewWeightLimiter(maxWeight)
+	l.now = func() time.Time { return now }
+	l.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return l, &now, &sleeps
+}
+
+func TestWeightLimiterThrottlesOnHeader(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 10, 0, time.UTC)
+	l, _, sleeps := newTestLimiter(100, start)
+
+	l.Wait()
+	l.Observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{usedWeightHeader: []string{"100"}}})
+
+	if used, _ := l.Usage(); used != 100 {
+		t.Fatalf("Expected used weight 100 from header, got %d", used)
+	}
+
+	// The budget is spent, so the next request waits for the next minute
+	// 预算已用完，下一次请求需等待到下一分钟
+	l.Wait()
+	if len(*sleeps) != 1 || (*sleeps)[0] != 50*time.Second {
+		t.Fatalf("Expected a single 50s wait, got %v", *sleeps)
+	}
+	if used, _ := l.Usage(); used != 1 {
+		t.Errorf("Expected a fresh window with 1 reserved unit, got %d", used)
+	}
+}
+
+func TestWeightLimiterRetryAfter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l, _, sleeps := newTestLimiter(2400, start)
+
+	l.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"120"}}})
+	l.Wait()
+
+	if len(*sleeps) == 0 || (*sleeps)[0] != 120*time.Second {
+		t.Errorf("Expected to wait 120s after 429, got %v", *sleeps)
+	}
+}
+
+func TestWeightLimiterReservesBetweenHeaders(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l, _, sleeps := newTestLimiter(3, start)
+
+	for i := 0; i < 3; i++ {
+		l.Wait()
+	}
+	if len(*sleeps) != 0 {
+		t.Fatalf("Expected no waits within budget, got %v", *sleeps)
+	}
+	l.Wait()
+	if len(*sleeps) != 1 {
+		t.Errorf("Expected the fourth request to wait, got %v", *sleeps)
+	}
+}
+
+func TestWithWeightLimit(t *testing.T) {
+	cfg := &config.Config{BinanceMaxWeightPerMinute: 1800}
+	client := WithWeightLimit(cfg, nil)
+	if _, ok := client.Transport.(*weightLimitedTransport); !ok {
+		t.Fatalf("Expected weight limited transport, got %T", client.Transport)
+	}
+
+	// Wrapping twice must not stack limiters
+	// 重复包装不能叠加限流器
+	again := WithWeightLimit(cfg, client)
+	if again.Transport != client.Transport {
+		t.Error("Expected the already limited client to be returned unchanged")
+	}
+}
+
+func TestSharedWeightLimiterIndependentOfCallOrder(t *testing.T) {
+	if SharedWeightLimiter(&config.Config{}) != nil {
+		t.Fatal("Expected no limiter for a zero budget")
+	}
+	limiter := SharedWeightLimiter(&config.Config{BinanceMaxWeightPerMinute: 600})
+	if limiter == nil {
+		t.Fatal("Expected a limiter after an earlier zero-budget call")
+	}
+	if again := SharedWeightLimiter(&config.Config{BinanceMaxWeightPerMinute: 600}); again != limiter {
+		t.Error("Expected callers with the same budget to share one limiter")
+	}
+}
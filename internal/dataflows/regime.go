@@ -0,0 +1,122 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+)
+
+// MarketRegime is the volatility and trend state of a symbol on its primary timeframe
+// MarketRegime 是交易对在主时间周期上的波动与趋势状态
+type MarketRegime string
+
+// Market regimes, named as in LEVERAGE_REGIME_CAPS
+// 市场状态，名称与 LEVERAGE_REGIME_CAPS 中一致
+const (
+	RegimeHighVolatility MarketRegime = "high_volatility" // 波动放大 / Volatility spiking
+	RegimeTrending       MarketRegime = "trending"        // 趋势明确 / Clear trend
+	RegimeRanging        MarketRegime = "ranging"         // 震荡 / Range-bound
+	RegimeLowVolatility  MarketRegime = "low_volatility"  // 波动收敛 / Volatility compressed
+)
+
+// minRegimeWindow is the fewest bars volatility is ranked against before a regime is reported
+// minRegimeWindow 是给出市场状态前波动率排名所需的最少 K 线数
+const minRegimeWindow = 30
+
+// String returns the Chinese name of the regime
+// String 返回市场状态的中文名称
+func (r MarketRegime) String() string {
+	switch r {
+	case RegimeHighVolatility:
+		return "高波动"
+	case RegimeTrending:
+		return "趋势"
+	case RegimeRanging:
+		return "震荡"
+	case RegimeLowVolatility:
+		return "低波动"
+	default:
+		return "未知"
+	}
+}
+
+// RegimeThresholds decide how a symbol's measurements map to a regime
+// RegimeThresholds 决定交易对的测量值如何对应到市场状态
+type RegimeThresholds struct {
+	HighPercentile float64 // ATR 或已实现波动百分位达到该值即为高波动 / Either percentile at or above this is high volatility
+	LowPercentile  float64 // 两个百分位都不高于该值即为低波动 / Both percentiles at or below this is low volatility
+	TrendADX       float64 // ADX 达到该值即为趋势 / ADX at or above this is trending
+}
+
+// RegimeReading is a symbol's regime with the measurements behind it
+// RegimeReading 是交易对的市场状态及其依据的测量值
+type RegimeReading struct {
+	Regime             MarketRegime
+	ATRPercentile      float64 // ATR（占价格比例）百分位 0-100 / ATR as a share of price, percentile 0-100
+	RealizedPercentile float64 // 已实现波动率百分位 0-100 / Realized volatility percentile 0-100
+	ADX                float64 // 最新 ADX，未知时为 0 / Latest ADX, 0 when unknown
+}
+
+// String formats the reading for reports and logs
+// String 将测量结果格式化用于报告和日志
+func (r RegimeReading) String() string {
+	return fmt.Sprintf("%s（ATR P%.0f / 已实现波动 P%.0f / ADX %.1f）", r.Regime, r.ATRPercentile, r.RealizedPercentile, r.ADX)
+}
+
+// DetectRegime classifies the latest bar of data, taking ADX from the indicators of the same bars
+// DetectRegime 判断最新 K 线的市场状态，ADX 取自同一组 K 线的指标
+//
+// High volatility wins over a trend so the safer limits apply when both hold; the ranking window shrinks to the
+// bars available down to minRegimeWindow, and ok is false below that.
+// 高波动优先于趋势，两者同时成立时适用更保守的限制；排名窗口会缩小到可用 K 线数，低于 minRegimeWindow 时 ok 为 false。
+func DetectRegime(data []OHLCV, indicators *TechnicalIndicators, thresholds RegimeThresholds) (RegimeReading, bool) {
+	atrPercentile, realizedPercentile, ok := regimePercentiles(data)
+	if !ok {
+		return RegimeReading{}, false
+	}
+
+	reading := RegimeReading{ATRPercentile: atrPercentile, RealizedPercentile: realizedPercentile}
+	if indicators != nil && len(indicators.ADX) > 0 {
+		if adx := indicators.ADX[len(indicators.ADX)-1]; !math.IsNaN(adx) {
+			reading.ADX = adx
+		}
+	}
+
+	switch {
+	case atrPercentile >= thresholds.HighPercentile || realizedPercentile >= thresholds.HighPercentile:
+		reading.Regime = RegimeHighVolatility
+	case thresholds.TrendADX > 0 && reading.ADX >= thresholds.TrendADX:
+		reading.Regime = RegimeTrending
+	case atrPercentile <= thresholds.LowPercentile && realizedPercentile <= thresholds.LowPercentile:
+		reading.Regime = RegimeLowVolatility
+	default:
+		reading.Regime = RegimeRanging
+	}
+	return reading, true
+}
+
+// regimePercentiles ranks the latest volatility against up to DefaultVolatilityWindow bars, at least minRegimeWindow
+// regimePercentiles 将最新波动率与最多 DefaultVolatilityWindow 根、至少 minRegimeWindow 根 K 线比较排名
+func regimePercentiles(data []OHLCV) (atrPercentile, realizedPercentile float64, ok bool) {
+	window := min(DefaultVolatilityWindow, len(data)-volatilityPeriod-1)
+	if window < minRegimeWindow {
+		return 0, 0, false
+	}
+	return VolatilityPercentiles(data, window)
+}
+
+// ATRMultiplierScale maps the ATR percentile of data to a factor for ATR stop multipliers
+// ATRMultiplierScale 将 K 线的 ATR 百分位映射为 ATR 止损倍数的缩放系数
+//
+// The factor is 1 at the median, falls linearly to minScale at P0 and rises to maxScale at P100, so stops widen
+// when volatility is high for its range and tighten when it is compressed; ok is false with too few bars.
+// 中位数时系数为 1，P0 时线性降到 minScale，P100 时升到 maxScale：波动相对偏高时放宽止损，收敛时收紧；K 线不足时 ok 为 false。
+func ATRMultiplierScale(data []OHLCV, minScale, maxScale float64) (scale, atrPercentile float64, ok bool) {
+	atrPercentile, _, ok = regimePercentiles(data)
+	if !ok {
+		return 1, 0, false
+	}
+	if atrPercentile < 50 {
+		return 1 - (1-minScale)*(50-atrPercentile)/50, atrPercentile, true
+	}
+	return 1 + (maxScale-1)*(atrPercentile-50)/50, atrPercentile, true
+}
@@ -0,0 +1,81 @@
+package dataflows
+
+import "testing"
+
+func TestDetectRegime(t *testing.T) {
+	thresholds := RegimeThresholds{HighPercentile: 80, LowPercentile: 20, TrendADX: 25}
+	n := DefaultVolatilityWindow + volatilityPeriod + 10
+
+	spike := volatilityBars(n, func(i int) float64 {
+		if i >= n-5 {
+			return 5
+		}
+		return 0.5
+	})
+	trending := &TechnicalIndicators{ADX: []float64{40}}
+	reading, ok := DetectRegime(spike, trending, thresholds)
+	if !ok || reading.Regime != RegimeHighVolatility {
+		t.Errorf("a volatility spike should win over a trend, got %+v (ok=%v)", reading, ok)
+	}
+
+	// Volatility back to the middle of its range
+	// 波动率回到区间中部
+	steady := volatilityBars(n, func(i int) float64 {
+		switch {
+		case i < n/2:
+			return 0.5
+		case i < n-30:
+			return 2
+		default:
+			return 1
+		}
+	})
+	if reading, _ := DetectRegime(steady, trending, thresholds); reading.Regime != RegimeTrending {
+		t.Errorf("expected trending with ADX 40, got %s", reading.Regime)
+	}
+	if reading, _ := DetectRegime(steady, &TechnicalIndicators{ADX: []float64{12}}, thresholds); reading.Regime != RegimeRanging {
+		t.Errorf("expected ranging with ADX 12, got %s", reading.Regime)
+	}
+
+	calm := volatilityBars(n, func(i int) float64 {
+		if i >= n-volatilityPeriod-2 {
+			return 0.1
+		}
+		return 2
+	})
+	if reading, _ := DetectRegime(calm, nil, thresholds); reading.Regime != RegimeLowVolatility {
+		t.Errorf("expected low volatility, got %s", reading.Regime)
+	}
+
+	if _, ok := DetectRegime(spike[:minRegimeWindow], trending, thresholds); ok {
+		t.Error("expected ok=false for too few bars")
+	}
+}
+
+func TestATRMultiplierScale(t *testing.T) {
+	n := DefaultVolatilityWindow + volatilityPeriod + 10
+	spike := volatilityBars(n, func(i int) float64 {
+		if i >= n-5 {
+			return 5
+		}
+		return 0.5
+	})
+	scale, percentile, ok := ATRMultiplierScale(spike, 0.8, 1.5)
+	if !ok || percentile < 95 || scale < 1.45 {
+		t.Errorf("a spike should widen close to 1.5x, got %.2f at P%.0f (ok=%v)", scale, percentile, ok)
+	}
+
+	calm := volatilityBars(n, func(i int) float64 {
+		if i >= n-volatilityPeriod-2 {
+			return 0.1
+		}
+		return 2
+	})
+	if scale, _, _ := ATRMultiplierScale(calm, 0.8, 1.5); scale > 0.85 || scale < 0.8 {
+		t.Errorf("a calm market should tighten close to 0.8x, got %.2f", scale)
+	}
+
+	if scale, _, ok := ATRMultiplierScale(spike[:minRegimeWindow], 0.8, 1.5); ok || scale != 1 {
+		t.Errorf("expected 1x and ok=false for too few bars, got %.2f (ok=%v)", scale, ok)
+	}
+}
@@ -0,0 +1,322 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+)
+
+// Classic setup types recognised by the scanner
+// 扫描器识别的经典形态类型
+const (
+	SetupBreakout       = "breakout"        // 区间突破 / Close outside the range
+	SetupFailedBreakout = "failed_breakout" // 假突破 / Wick outside the range, close back inside
+	SetupRangeBounce    = "range_bounce"    // 区间边界反弹 / Rejection at a range edge
+)
+
+// Outcome labels of a simulated setup
+// 模拟结果标签
+const (
+	OutcomeWin     = "win"     // 先到达目标 / Target hit first
+	OutcomeLoss    = "loss"    // 先触发止损 / Stop hit first
+	OutcomeTimeout = "timeout" // 持有期内都未触发 / Neither hit within the holding period
+)
+
+// Defaults shared by memory seeding and recall, so recalled situations are measured the same way
+// 记忆播种与召回共用的默认参数，保证召回的情境用相同方式度量
+const (
+	DefaultSetupLookback = 20 // 区间回看 K 线数 / Bars forming the range
+	DefaultSetupHoldBars = 48 // 模拟最长持有 K 线数 / Maximum bars held in simulation
+)
+
+// Scanner parameters
+// 扫描参数
+const (
+	setupRewardRisk    = 2.0 // 目标 = 2R / Target at 2R
+	setupMaxRangeATR   = 8.0 // 区间宽度不超过 8 倍 ATR 才算盘整 / A range wider than 8 ATR is a trend, not a range
+	setupMinRangeATR   = 1.5 // 区间宽度至少 1.5 倍 ATR / Ranges narrower than 1.5 ATR are noise
+	setupEdgeFraction  = 0.1 // 距边界 10% 区间宽度内算触及边界 / Within 10% of the width counts as touching an edge
+	setupIndicatorBars = 20  // 指标预热所需 K 线数 / Bars needed to warm up indicators
+)
+
+// SetupFeatures describes the market around a setup; similar features mean a similar situation
+// SetupFeatures 描述形态出现时的市场状态；特征相近即情境相似
+type SetupFeatures struct {
+	RangeWidthATR   float64 `json:"range_width_atr"`   // 区间宽度 / ATR / Range width in ATRs
+	PositionInRange float64 `json:"position_in_range"` // 收盘价在区间中的位置（0=下沿，1=上沿）/ Close position in the range (0 = low, 1 = high)
+	RSI             float64 `json:"rsi"`               // RSI(14)
+	VolumeRatio     float64 `json:"volume_ratio"`      // 量比（20 期）/ Volume ratio (20 periods)
+	TrendPct        float64 `json:"trend_pct"`         // 回看期内涨跌幅（%）/ Change over the lookback (%)
+	ATRPct          float64 `json:"atr_pct"`           // ATR 占价格百分比 / ATR as a percentage of price
+}
+
+// Vector scales the features to comparable magnitudes for distance calculations
+// Vector 将特征缩放到可比较的量级，用于距离计算
+func (f SetupFeatures) Vector() []float64 {
+	return []float64{
+		f.RangeWidthATR / setupMaxRangeATR,
+		f.PositionInRange,
+		f.RSI / 100,
+		f.VolumeRatio / 3,
+		f.TrendPct / 10,
+		f.ATRPct / 2,
+	}
+}
+
+// SetupDistance returns the Euclidean distance between two feature sets; smaller is more similar
+// SetupDistance 返回两组特征的欧氏距离，越小越相似
+func SetupDistance(a, b SetupFeatures) float64 {
+	va, vb := a.Vector(), b.Vector()
+	sum := 0.0
+	for i := range va {
+		d := va[i] - vb[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// Setup is a classic chart setup found at one bar, with the canonical trade it implies
+// Setup 是在某根 K 线上发现的经典形态，以及该形态对应的标准交易
+type Setup struct {
+	Index     int           // K 线索引 / Bar index
+	Type      string        // 形态类型 / Setup type
+	Direction string        // long 或 short / long or short
+	Entry     float64       // 入场价（收盘价）/ Entry (bar close)
+	Stop      float64       // 止损价 / Stop price
+	Target    float64       // 目标价（2R）/ Target price (2R)
+	Features  SetupFeatures // 市场特征 / Market features
+}
+
+// SetupOutcome is the result of walking a setup's canonical trade forward
+// SetupOutcome 是按标准交易向后推演的结果
+type SetupOutcome struct {
+	Result    string  // win / loss / timeout
+	ReturnPct float64 // 收益率（%）/ Return (%)
+	RMultiple float64 // 以风险 R 计的收益 / Return in multiples of risk
+	BarsHeld  int     // 持有 K 线数 / Bars held
+}
+
+// setupSeries holds the indicator series shared by setup detection
+// setupSeries 保存形态检测共用的指标序列
+type setupSeries struct {
+	atr    []float64
+	rsi    []float64
+	volume []float64
+}
+
+func newSetupSeries(data []OHLCV) *setupSeries {
+	highs := make([]float64, len(data))
+	lows := make([]float64, len(data))
+	closes := make([]float64, len(data))
+	volumes := make([]float64, len(data))
+	for i, d := range data {
+		highs[i], lows[i], closes[i], volumes[i] = d.High, d.Low, d.Close, d.Volume
+	}
+	return &setupSeries{
+		atr:    calculateATR(highs, lows, closes, 14),
+		rsi:    calculateRSI(closes, 14),
+		volume: calculateVolumeRatio(volumes, 20),
+	}
+}
+
+// ScanSetups finds every classic setup in the data, using the previous lookback bars as the range
+// ScanSetups 找出数据中的所有经典形态，以之前 lookback 根 K 线作为区间
+// After a setup is found the next lookback/2 bars are skipped, so one move is not counted many times
+// 发现形态后跳过之后 lookback/2 根 K 线，避免同一段行情被重复计数
+func ScanSetups(data []OHLCV, lookback int) []Setup {
+	series := newSetupSeries(data)
+	cooldown := lookback / 2
+	if cooldown < 1 {
+		cooldown = 1
+	}
+
+	var setups []Setup
+	for i := setupStart(lookback); i < len(data); i++ {
+		if setup := detectSetup(data, series, i, lookback); setup != nil {
+			setups = append(setups, *setup)
+			i += cooldown
+		}
+	}
+	return setups
+}
+
+// DetectLatestSetup returns the setup on the last bar, or nil
+// DetectLatestSetup 返回最后一根 K 线上的形态，没有则返回 nil
+func DetectLatestSetup(data []OHLCV, lookback int) *Setup {
+	if len(data) <= setupStart(lookback) {
+		return nil
+	}
+	return detectSetup(data, newSetupSeries(data), len(data)-1, lookback)
+}
+
+// CurrentSetupFeatures returns the features at the last bar, whether or not a setup is present
+// CurrentSetupFeatures 返回最后一根 K 线的特征，无论是否出现形态
+func CurrentSetupFeatures(data []OHLCV, lookback int) (SetupFeatures, bool) {
+	if len(data) <= setupStart(lookback) {
+		return SetupFeatures{}, false
+	}
+	i := len(data) - 1
+	rangeHigh, rangeLow := priorRange(data, i, lookback)
+	return setupFeaturesAt(data, newSetupSeries(data), i, lookback, rangeHigh, rangeLow)
+}
+
+func setupStart(lookback int) int {
+	if lookback > setupIndicatorBars {
+		return lookback
+	}
+	return setupIndicatorBars
+}
+
+// priorRange returns the high and low of the lookback bars before i
+// priorRange 返回 i 之前 lookback 根 K 线的最高价和最低价
+func priorRange(data []OHLCV, i, lookback int) (float64, float64) {
+	high, low := data[i-lookback].High, data[i-lookback].Low
+	for j := i - lookback + 1; j < i; j++ {
+		high = math.Max(high, data[j].High)
+		low = math.Min(low, data[j].Low)
+	}
+	return high, low
+}
+
+func setupFeaturesAt(data []OHLCV, series *setupSeries, i, lookback int, rangeHigh, rangeLow float64) (SetupFeatures, bool) {
+	atr, rsi, volume := series.atr[i], series.rsi[i], series.volume[i]
+	width := rangeHigh - rangeLow
+	if math.IsNaN(atr) || math.IsNaN(rsi) || math.IsNaN(volume) || atr <= 0 || width <= 0 {
+		return SetupFeatures{}, false
+	}
+
+	c := data[i].Close
+	return SetupFeatures{
+		RangeWidthATR:   width / atr,
+		PositionInRange: (c - rangeLow) / width,
+		RSI:             rsi,
+		VolumeRatio:     volume,
+		TrendPct:        (c/data[i-lookback].Close - 1) * 100,
+		ATRPct:          atr / c * 100,
+	}, true
+}
+
+// detectSetup checks bar i against the range of the previous lookback bars
+// detectSetup 用之前 lookback 根 K 线的区间检查第 i 根 K 线
+func detectSetup(data []OHLCV, series *setupSeries, i, lookback int) *Setup {
+	rangeHigh, rangeLow := priorRange(data, i, lookback)
+	features, ok := setupFeaturesAt(data, series, i, lookback, rangeHigh, rangeLow)
+	if !ok || features.RangeWidthATR < setupMinRangeATR || features.RangeWidthATR > setupMaxRangeATR {
+		return nil
+	}
+
+	bar := data[i]
+	atr := series.atr[i]
+	edge := (rangeHigh - rangeLow) * setupEdgeFraction
+
+	var setupType, direction string
+	var stop float64
+	switch {
+	case bar.Close > rangeHigh:
+		setupType, direction, stop = SetupBreakout, "long", rangeHigh-atr
+	case bar.Close < rangeLow:
+		setupType, direction, stop = SetupBreakout, "short", rangeLow+atr
+	case bar.High > rangeHigh:
+		setupType, direction, stop = SetupFailedBreakout, "short", bar.High+0.25*atr
+	case bar.Low < rangeLow:
+		setupType, direction, stop = SetupFailedBreakout, "long", bar.Low-0.25*atr
+	case bar.Low <= rangeLow+edge && bar.Close > bar.Open:
+		setupType, direction, stop = SetupRangeBounce, "long", rangeLow-0.5*atr
+	case bar.High >= rangeHigh-edge && bar.Close < bar.Open:
+		setupType, direction, stop = SetupRangeBounce, "short", rangeHigh+0.5*atr
+	default:
+		return nil
+	}
+
+	risk := math.Abs(bar.Close - stop)
+	if risk <= 0 {
+		return nil
+	}
+	target := bar.Close + setupRewardRisk*risk
+	if direction == "short" {
+		target = bar.Close - setupRewardRisk*risk
+	}
+
+	return &Setup{
+		Index:     i,
+		Type:      setupType,
+		Direction: direction,
+		Entry:     bar.Close,
+		Stop:      stop,
+		Target:    target,
+		Features:  features,
+	}
+}
+
+// SimulateOutcome walks the setup's trade forward for at most maxBars bars
+// SimulateOutcome 将形态对应的交易向后推演最多 maxBars 根 K 线
+// When stop and target are both inside one bar the stop is assumed to fill first (conservative)
+// 同一根 K 线同时触及止损和目标时，保守地假设止损先成交
+func SimulateOutcome(data []OHLCV, setup Setup, maxBars int) (SetupOutcome, bool) {
+	if setup.Index+1 >= len(data) {
+		return SetupOutcome{}, false
+	}
+
+	risk := math.Abs(setup.Entry - setup.Stop)
+	long := setup.Direction == "long"
+	outcome := func(result string, exit float64, bars int) SetupOutcome {
+		move := exit - setup.Entry
+		if !long {
+			move = -move
+		}
+		return SetupOutcome{
+			Result:    result,
+			ReturnPct: move / setup.Entry * 100,
+			RMultiple: move / risk,
+			BarsHeld:  bars,
+		}
+	}
+
+	last := setup.Index + maxBars
+	if last >= len(data) {
+		last = len(data) - 1
+	}
+	for j := setup.Index + 1; j <= last; j++ {
+		bar := data[j]
+		stopHit := (long && bar.Low <= setup.Stop) || (!long && bar.High >= setup.Stop)
+		targetHit := (long && bar.High >= setup.Target) || (!long && bar.Low <= setup.Target)
+		switch {
+		case stopHit:
+			return outcome(OutcomeLoss, setup.Stop, j-setup.Index), true
+		case targetHit:
+			return outcome(OutcomeWin, setup.Target, j-setup.Index), true
+		}
+	}
+
+	// An unfinished window at the end of the data is not a real timeout
+	// 数据末尾不足一个完整持有期的不算超时
+	if last-setup.Index < maxBars {
+		return SetupOutcome{}, false
+	}
+	return outcome(OutcomeTimeout, data[last].Close, maxBars), true
+}
+
+// SetupTypeLabel returns the Chinese label of a setup type and direction
+// SetupTypeLabel 返回形态类型和方向的中文标签
+func SetupTypeLabel(setupType, direction string) string {
+	labels := map[string]string{
+		SetupBreakout:       "区间突破",
+		SetupFailedBreakout: "假突破",
+		SetupRangeBounce:    "区间反弹",
+	}
+	side := "做多"
+	if direction == "short" {
+		side = "做空"
+	}
+	label, ok := labels[setupType]
+	if !ok {
+		label = setupType
+	}
+	return fmt.Sprintf("%s（%s）", label, side)
+}
+
+// DescribeSetupFeatures formats features as a one-line summary
+// DescribeSetupFeatures 将特征格式化为单行摘要
+func DescribeSetupFeatures(f SetupFeatures) string {
+	return fmt.Sprintf("区间宽度 %.1f×ATR，收盘位于区间 %.0f%%，RSI %.0f，量比 %.2f，回看涨跌 %+.2f%%，ATR %.2f%%",
+		f.RangeWidthATR, f.PositionInRange*100, f.RSI, f.VolumeRatio, f.TrendPct, f.ATRPct)
+}
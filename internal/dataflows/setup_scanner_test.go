@@ -0,0 +1,173 @@
+package dataflows
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// rangeBars builds n bars oscillating between 99 and 101
+// rangeBars 构造 n 根在 99 到 101 之间震荡的 K 线
+func rangeBars(n int) []OHLCV {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mids := []float64{99.5, 100, 100.5, 100}
+	data := make([]OHLCV, n)
+	for i := range data {
+		mid := mids[i%len(mids)]
+		data[i] = OHLCV{
+			Timestamp: start.Add(time.Duration(i) * time.Hour),
+			Open:      mid - 0.2,
+			High:      mid + 0.5,
+			Low:       mid - 0.5,
+			Close:     mid + 0.2,
+			Volume:    1000,
+		}
+	}
+	return data
+}
+
+func TestDetectLatestSetup(t *testing.T) {
+	tests := []struct {
+		name          string
+		bar           OHLCV
+		wantType      string
+		wantDirection string
+	}{
+		{
+			name:          "Close above range is a long breakout",
+			bar:           OHLCV{Open: 100.5, High: 102.5, Low: 100.4, Close: 102.2, Volume: 3000},
+			wantType:      SetupBreakout,
+			wantDirection: "long",
+		},
+		{
+			name:          "Wick above range closing inside is a failed breakout",
+			bar:           OHLCV{Open: 100.5, High: 101.8, Low: 100.2, Close: 100.3, Volume: 2000},
+			wantType:      SetupFailedBreakout,
+			wantDirection: "short",
+		},
+		{
+			name:          "Bullish bar at the range low is a bounce",
+			bar:           OHLCV{Open: 99.3, High: 99.9, Low: 99.05, Close: 99.8, Volume: 1000},
+			wantType:      SetupRangeBounce,
+			wantDirection: "long",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(rangeBars(40), tt.bar)
+			setup := DetectLatestSetup(data, DefaultSetupLookback)
+			if setup == nil {
+				t.Fatal("expected a setup, got nil")
+			}
+			if setup.Type != tt.wantType || setup.Direction != tt.wantDirection {
+				t.Errorf("got %s/%s, want %s/%s", setup.Type, setup.Direction, tt.wantType, tt.wantDirection)
+			}
+
+			// 目标价必须在 2R 处
+			risk := math.Abs(setup.Entry - setup.Stop)
+			if math.Abs(math.Abs(setup.Target-setup.Entry)-setupRewardRisk*risk) > 1e-9 {
+				t.Errorf("target %.4f is not at 2R from entry %.4f (stop %.4f)", setup.Target, setup.Entry, setup.Stop)
+			}
+		})
+	}
+}
+
+func TestDetectLatestSetupMidRange(t *testing.T) {
+	// 区间中部的 K 线不构成形态
+	data := append(rangeBars(40), OHLCV{Open: 99.9, High: 100.3, Low: 99.7, Close: 100.1, Volume: 1000})
+	if setup := DetectLatestSetup(data, DefaultSetupLookback); setup != nil {
+		t.Errorf("expected no setup, got %s/%s", setup.Type, setup.Direction)
+	}
+}
+
+func TestSimulateOutcome(t *testing.T) {
+	setup := Setup{Index: 0, Direction: "long", Entry: 100, Stop: 98, Target: 104}
+	bar := func(high, low, close float64) OHLCV {
+		return OHLCV{Open: 100, High: high, Low: low, Close: close}
+	}
+
+	tests := []struct {
+		name       string
+		bars       []OHLCV
+		maxBars    int
+		wantOK     bool
+		wantResult string
+		wantR      float64
+		wantBars   int
+	}{
+		{
+			name:       "Target hit",
+			bars:       []OHLCV{bar(101, 99, 100.5), bar(104.5, 100, 104)},
+			maxBars:    5,
+			wantOK:     true,
+			wantResult: OutcomeWin,
+			wantR:      2,
+			wantBars:   2,
+		},
+		{
+			name:       "Stop hit",
+			bars:       []OHLCV{bar(101, 97.5, 98)},
+			maxBars:    5,
+			wantOK:     true,
+			wantResult: OutcomeLoss,
+			wantR:      -1,
+			wantBars:   1,
+		},
+		{
+			name:       "Stop and target in one bar counts as a loss",
+			bars:       []OHLCV{bar(105, 97, 101)},
+			maxBars:    5,
+			wantOK:     true,
+			wantResult: OutcomeLoss,
+			wantR:      -1,
+			wantBars:   1,
+		},
+		{
+			name:       "Neither hit within the holding period",
+			bars:       []OHLCV{bar(101, 99, 100.5), bar(101, 99, 101)},
+			maxBars:    2,
+			wantOK:     true,
+			wantResult: OutcomeTimeout,
+			wantR:      0.5,
+			wantBars:   2,
+		},
+		{
+			name:    "Incomplete window at the end of the data",
+			bars:    []OHLCV{bar(101, 99, 100.5)},
+			maxBars: 5,
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append([]OHLCV{{Close: 100}}, tt.bars...)
+			got, ok := SimulateOutcome(data, setup, tt.maxBars)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.Result != tt.wantResult || math.Abs(got.RMultiple-tt.wantR) > 1e-9 || got.BarsHeld != tt.wantBars {
+				t.Errorf("got %s %.2fR in %d bars, want %s %.2fR in %d bars",
+					got.Result, got.RMultiple, got.BarsHeld, tt.wantResult, tt.wantR, tt.wantBars)
+			}
+		})
+	}
+}
+
+func TestSetupDistance(t *testing.T) {
+	a := SetupFeatures{RangeWidthATR: 4, PositionInRange: 0.9, RSI: 65, VolumeRatio: 1.5, TrendPct: 2, ATRPct: 1}
+	near := a
+	near.RSI = 62
+	far := SetupFeatures{RangeWidthATR: 2, PositionInRange: 0.1, RSI: 30, VolumeRatio: 0.5, TrendPct: -5, ATRPct: 3}
+
+	if d := SetupDistance(a, a); d != 0 {
+		t.Errorf("distance to itself = %f, want 0", d)
+	}
+	if SetupDistance(a, near) >= SetupDistance(a, far) {
+		t.Errorf("expected near features to be closer than far ones")
+	}
+}
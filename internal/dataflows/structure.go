@@ -0,0 +1,153 @@
+package dataflows
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// StructureKind is what produced a support/resistance level
+// StructureKind 是支撑/阻力位的来源
+type StructureKind string
+
+// Structure level kinds
+// 结构位类型
+const (
+	StructureSwingHigh  StructureKind = "swing_high"  // 前期摆动高点 / Prior swing high
+	StructureSwingLow   StructureKind = "swing_low"   // 前期摆动低点 / Prior swing low
+	StructureVolumeNode StructureKind = "volume_node" // 成交量密集区 / High-volume price node
+)
+
+const (
+	structurePivotWidth     = 3   // 摆动点两侧各需的 K 线数 / Bars required on each side of a swing point
+	structureVolumeBins     = 40  // 成交量分布的价格分箱数 / Price bins of the volume profile
+	structureVolumeNodeLift = 1.5 // 成交量密集区至少为平均分箱成交量的倍数 / A node holds at least this multiple of the average bin volume
+)
+
+// String returns the Chinese name of the kind
+// String 返回结构位类型的中文名称
+func (k StructureKind) String() string {
+	switch k {
+	case StructureSwingHigh:
+		return "摆动高点"
+	case StructureSwingLow:
+		return "摆动低点"
+	case StructureVolumeNode:
+		return "成交量密集区"
+	default:
+		return "未知"
+	}
+}
+
+// StructureLevel is one support/resistance price
+// StructureLevel 是一个支撑/阻力价位
+type StructureLevel struct {
+	Price float64
+	Kind  StructureKind
+}
+
+// DetectStructureLevels finds the swing highs, swing lows and volume nodes of data, sorted by price
+// DetectStructureLevels 找出 K 线中的摆动高低点与成交量密集区，按价格排序
+//
+// A swing point is a bar whose high (low) beats structurePivotWidth bars on both sides, so the latest bars are not
+// yet confirmed; a volume node is a local peak of the volume profile, binned by each bar's typical price.
+// 摆动点是最高价（最低价）超过两侧各 structurePivotWidth 根 K 线的 K 线，因此最新几根尚未确认；
+// 成交量密集区是按典型价格分箱的成交量分布中的局部峰值。
+func DetectStructureLevels(data []OHLCV) []StructureLevel {
+	var levels []StructureLevel
+
+	for i := structurePivotWidth; i < len(data)-structurePivotWidth; i++ {
+		isHigh, isLow := true, true
+		for j := i - structurePivotWidth; j <= i+structurePivotWidth; j++ {
+			if j == i {
+				continue
+			}
+			if data[j].High >= data[i].High {
+				isHigh = false
+			}
+			if data[j].Low <= data[i].Low {
+				isLow = false
+			}
+		}
+		if isHigh {
+			levels = append(levels, StructureLevel{Price: data[i].High, Kind: StructureSwingHigh})
+		}
+		if isLow {
+			levels = append(levels, StructureLevel{Price: data[i].Low, Kind: StructureSwingLow})
+		}
+	}
+
+	levels = append(levels, volumeNodes(data)...)
+	sort.Slice(levels, func(a, b int) bool { return levels[a].Price < levels[b].Price })
+	return levels
+}
+
+// volumeNodes returns the centers of the volume profile bins that peak above structureVolumeNodeLift × the average
+// volumeNodes 返回成交量分布中高于平均值 structureVolumeNodeLift 倍的峰值分箱中心价
+func volumeNodes(data []OHLCV) []StructureLevel {
+	if len(data) == 0 {
+		return nil
+	}
+	low, high := data[0].Low, data[0].High
+	for _, d := range data {
+		low, high = math.Min(low, d.Low), math.Max(high, d.High)
+	}
+	if high <= low {
+		return nil
+	}
+
+	width := (high - low) / structureVolumeBins
+	bins := make([]float64, structureVolumeBins)
+	var total float64
+	for _, d := range data {
+		typical := (d.High + d.Low + d.Close) / 3
+		bin := min(int((typical-low)/width), structureVolumeBins-1)
+		bins[bin] += d.Volume
+		total += d.Volume
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	threshold := total / structureVolumeBins * structureVolumeNodeLift
+	var nodes []StructureLevel
+	for i, v := range bins {
+		if v < threshold || (i > 0 && bins[i-1] > v) || (i < len(bins)-1 && bins[i+1] >= v) {
+			continue
+		}
+		nodes = append(nodes, StructureLevel{Price: low + width*(float64(i)+0.5), Kind: StructureVolumeNode})
+	}
+	return nodes
+}
+
+// FormatStructureLevels lists the nearest levels above and below price for the market report
+// FormatStructureLevels 列出价格上方与下方最近的结构位，用于市场报告
+func FormatStructureLevels(levels []StructureLevel, price float64, perSide int) string {
+	var above, below []string
+	for _, l := range levels {
+		if l.Price > price {
+			if len(above) < perSide {
+				above = append(above, fmt.Sprintf("%.4f（%s）", l.Price, l.Kind))
+			}
+		} else if l.Price < price {
+			below = append([]string{fmt.Sprintf("%.4f（%s）", l.Price, l.Kind)}, below...)
+		}
+	}
+	if len(below) > perSide {
+		below = below[:perSide]
+	}
+	if len(above) == 0 && len(below) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n关键结构位: 上方 %s；下方 %s\n", joinOrNone(above), joinOrNone(below))
+}
+
+// joinOrNone joins items with "、", or returns "无" for an empty list
+// joinOrNone 用 "、" 连接各项，列表为空时返回 "无"
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "无"
+	}
+	return strings.Join(items, "、")
+}
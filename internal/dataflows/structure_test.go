@@ -0,0 +1,42 @@
+package dataflows
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectStructureLevels(t *testing.T) {
+	// Flat bars around 100 carry all the volume; one spike up and one spike down are the only swing points
+	// 围绕 100 的平稳 K 线承载全部成交量；一次向上尖峰和一次向下尖峰是仅有的摆动点
+	data := make([]OHLCV, 21)
+	for i := range data {
+		data[i] = OHLCV{High: 101, Low: 99, Close: 100, Volume: 10}
+	}
+	data[5] = OHLCV{High: 110, Low: 99, Close: 100}
+	data[12] = OHLCV{High: 101, Low: 90, Close: 100}
+
+	levels := DetectStructureLevels(data)
+	want := []StructureLevel{
+		{Price: 90, Kind: StructureSwingLow},
+		{Price: 100.25, Kind: StructureVolumeNode},
+		{Price: 110, Kind: StructureSwingHigh},
+	}
+	if len(levels) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, levels)
+	}
+	for i := range want {
+		if levels[i] != want[i] {
+			t.Errorf("Level %d: expected %v, got %v", i, want[i], levels[i])
+		}
+	}
+
+	report := FormatStructureLevels(levels, 100, 3)
+	if !strings.Contains(report, "上方 100.2500（成交量密集区）、110.0000（摆动高点）") || !strings.Contains(report, "下方 90.0000（摆动低点）") {
+		t.Errorf("Unexpected report: %q", report)
+	}
+	// Five flat bars confirm no swing point, leaving only their volume node
+	// 五根平稳 K 线无法确认摆动点，只剩成交量密集区
+	if few := DetectStructureLevels(data[:5]); len(few) != 1 || few[0].Kind != StructureVolumeNode {
+		t.Errorf("Expected only a volume node from five flat bars, got %v", few)
+	}
+}
@@ -0,0 +1,61 @@
+package dataflows
+
+import (
+	"math"
+)
+
+// SuperTrend returns the SuperTrend line at the latest bar
+// SuperTrend 返回最新 K 线处的 SuperTrend 线
+// The bands sit multiplier × ATR(period) around the bar midpoint and only tighten while the trend holds; a close through
+// the active band flips the trend. long reports whether the line is the lower band (an uptrend). ok is false when there
+// are not enough bars to warm up the ATR
+// 上下轨位于 K 线中点 ± multiplier × ATR(period) 处，趋势延续时只收紧不放宽；收盘价突破当前轨道即翻转趋势。
+// long 表示该线为下轨（上升趋势）。K 线不足以预热 ATR 时 ok 为 false
+func SuperTrend(data []OHLCV, period int, multiplier float64) (line float64, long, ok bool) {
+	if period <= 0 || multiplier <= 0 || len(data) < period+2 {
+		return 0, false, false
+	}
+
+	highs := make([]float64, len(data))
+	lows := make([]float64, len(data))
+	closes := make([]float64, len(data))
+	for i, d := range data {
+		highs[i], lows[i], closes[i] = d.High, d.Low, d.Close
+	}
+	atr := calculateATR(highs, lows, closes, period)
+
+	// Seed the bands and the trend on the first bar with an ATR
+	// 在第一根有 ATR 的 K 线上初始化轨道与趋势
+	mid := (highs[period] + lows[period]) / 2
+	upper, lower := mid+multiplier*atr[period], mid-multiplier*atr[period]
+	long = closes[period] >= mid
+
+	for i := period + 1; i < len(data); i++ {
+		if math.IsNaN(atr[i]) {
+			return 0, false, false
+		}
+		mid := (highs[i] + lows[i]) / 2
+		basicUpper, basicLower := mid+multiplier*atr[i], mid-multiplier*atr[i]
+
+		// A band only moves against the trend once the previous close has broken through it
+		// 只有上一根收盘价突破轨道后，轨道才会反向移动
+		if basicUpper < upper || closes[i-1] > upper {
+			upper = basicUpper
+		}
+		if basicLower > lower || closes[i-1] < lower {
+			lower = basicLower
+		}
+
+		switch {
+		case long && closes[i] < lower:
+			long = false
+		case !long && closes[i] > upper:
+			long = true
+		}
+	}
+
+	if long {
+		return lower, true, true
+	}
+	return upper, false, true
+}
@@ -0,0 +1,39 @@
+package dataflows
+
+import (
+	"testing"
+)
+
+func TestSuperTrend(t *testing.T) {
+	// A steady uptrend keeps the line on the lower band, below price
+	// 稳定上涨时该线位于下轨，低于价格
+	data := make([]OHLCV, 40)
+	for i := range data {
+		c := 100 + float64(i)
+		data[i] = OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+	line, long, ok := SuperTrend(data, 10, 3)
+	if !ok || !long {
+		t.Fatalf("Expected an uptrend SuperTrend, got %.4f long=%v ok=%v", line, long, ok)
+	}
+	// Midpoint 139 minus 3 × ATR of 2
+	// 中点 139 减去 3 倍 ATR（2）
+	if line < 132.9 || line > 133.1 {
+		t.Errorf("Expected the line near 133, got %.4f", line)
+	}
+
+	// A sharp decline flips the line above price
+	// 急跌使该线翻转到价格上方
+	for i := 0; i < 10; i++ {
+		c := 130 - 3*float64(i)
+		data = append(data, OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c})
+	}
+	line, long, ok = SuperTrend(data, 10, 3)
+	if !ok || long || line <= data[len(data)-1].High {
+		t.Errorf("Expected a downtrend SuperTrend above price, got %.4f long=%v ok=%v", line, long, ok)
+	}
+
+	if _, _, ok := SuperTrend(data[:11], 10, 3); ok {
+		t.Error("Expected too few bars to fail")
+	}
+}
@@ -0,0 +1,110 @@
+package dataflows
+
+import (
+	"math"
+)
+
+// Volatility measurement parameters
+// 波动率测量参数
+const (
+	DefaultVolatilityWindow = 100 // 百分位排名的回看 K 线数 / Bars the latest value is ranked against
+	volatilityPeriod        = 14  // ATR 与已实现波动率的周期 / Period of ATR and realized volatility
+)
+
+// VolatilityPercentiles ranks the latest ATR (as a share of price) and realized volatility against the previous window bars
+// VolatilityPercentiles 将最新的 ATR（占价格比例）和已实现波动率与之前 window 根 K 线进行百分位排名
+// Both percentiles are 0-100; ok is false when there are not enough bars to fill the window
+// 两个百分位取值 0-100；K 线不足以填满窗口时 ok 为 false
+func VolatilityPercentiles(data []OHLCV, window int) (atrPercentile, realizedPercentile float64, ok bool) {
+	if window < 2 || len(data) < window+volatilityPeriod+1 {
+		return 0, 0, false
+	}
+
+	highs := make([]float64, len(data))
+	lows := make([]float64, len(data))
+	closes := make([]float64, len(data))
+	for i, d := range data {
+		highs[i], lows[i], closes[i] = d.High, d.Low, d.Close
+	}
+
+	// ATR is normalised by price so a trending market does not look more volatile just because price rose
+	// ATR 按价格归一化，避免价格上涨本身被误判为波动放大
+	atr := calculateATR(highs, lows, closes, volatilityPeriod)
+	atrPct := make([]float64, len(atr))
+	for i := range atr {
+		atrPct[i] = math.NaN()
+		if closes[i] > 0 {
+			atrPct[i] = atr[i] / closes[i]
+		}
+	}
+
+	atrPercentile, ok = percentileRank(atrPct[len(atrPct)-window:])
+	if !ok {
+		return 0, 0, false
+	}
+	realized := calculateRealizedVolatility(closes, volatilityPeriod)
+	realizedPercentile, ok = percentileRank(realized[len(realized)-window:])
+	if !ok {
+		return 0, 0, false
+	}
+	return atrPercentile, realizedPercentile, true
+}
+
+// calculateRealizedVolatility returns the standard deviation of log returns over the previous period bars
+// calculateRealizedVolatility 计算之前 period 根 K 线对数收益率的标准差
+func calculateRealizedVolatility(closes []float64, period int) []float64 {
+	result := make([]float64, len(closes))
+	for i := range closes {
+		if i < period {
+			result[i] = math.NaN()
+			continue
+		}
+
+		returns := make([]float64, 0, period)
+		for j := i - period + 1; j <= i; j++ {
+			if closes[j-1] > 0 && closes[j] > 0 {
+				returns = append(returns, math.Log(closes[j]/closes[j-1]))
+			}
+		}
+		if len(returns) < 2 {
+			result[i] = math.NaN()
+			continue
+		}
+
+		mean := 0.0
+		for _, r := range returns {
+			mean += r
+		}
+		mean /= float64(len(returns))
+		variance := 0.0
+		for _, r := range returns {
+			variance += (r - mean) * (r - mean)
+		}
+		result[i] = math.Sqrt(variance / float64(len(returns)-1))
+	}
+	return result
+}
+
+// percentileRank returns the share (0-100) of values in the series at or below its last value, ignoring NaN
+// percentileRank 返回序列中不高于最后一个值的比例（0-100），忽略 NaN
+func percentileRank(series []float64) (float64, bool) {
+	if len(series) == 0 {
+		return 0, false
+	}
+	current := series[len(series)-1]
+	if math.IsNaN(current) {
+		return 0, false
+	}
+
+	count, below := 0, 0
+	for _, v := range series {
+		if math.IsNaN(v) {
+			continue
+		}
+		count++
+		if v <= current {
+			below++
+		}
+	}
+	return float64(below) / float64(count) * 100, true
+}
@@ -0,0 +1,68 @@
+package dataflows
+
+import (
+	"math"
+	"testing"
+)
+
+// volatilityBars builds n bars whose range and close-to-close moves are scaled by swing
+// volatilityBars 构造 n 根 K 线，振幅和涨跌幅由 swing 决定
+func volatilityBars(n int, swing func(i int) float64) []OHLCV {
+	data := make([]OHLCV, n)
+	for i := range data {
+		s := swing(i)
+		c := 100.0
+		if i%2 == 1 {
+			c += s
+		}
+		data[i] = OHLCV{Open: 100, High: c + s, Low: c - s, Close: c}
+	}
+	return data
+}
+
+func TestVolatilityPercentiles(t *testing.T) {
+	window := 50
+	n := window + volatilityPeriod + 10
+
+	spike := volatilityBars(n, func(i int) float64 {
+		if i >= n-5 {
+			return 5
+		}
+		return 0.5
+	})
+	atrP, rvP, ok := VolatilityPercentiles(spike, window)
+	if !ok {
+		t.Fatal("expected percentiles")
+	}
+	if atrP < 95 || rvP < 95 {
+		t.Errorf("spike should rank at the top, got ATR P%.0f / RV P%.0f", atrP, rvP)
+	}
+
+	calm := volatilityBars(n, func(i int) float64 {
+		if i >= n-volatilityPeriod-2 {
+			return 0.1
+		}
+		return 2
+	})
+	atrP, rvP, ok = VolatilityPercentiles(calm, window)
+	if !ok {
+		t.Fatal("expected percentiles")
+	}
+	if atrP > 10 || rvP > 10 {
+		t.Errorf("calm market should rank at the bottom, got ATR P%.0f / RV P%.0f", atrP, rvP)
+	}
+
+	if _, _, ok := VolatilityPercentiles(spike[:window], window); ok {
+		t.Error("expected ok=false for too few bars")
+	}
+}
+
+func TestPercentileRank(t *testing.T) {
+	got, ok := percentileRank([]float64{math.NaN(), 1, 2, 3, 2})
+	if !ok || got != 75 {
+		t.Errorf("percentileRank = %.2f (ok=%v), want 75", got, ok)
+	}
+	if _, ok := percentileRank([]float64{1, math.NaN()}); ok {
+		t.Error("expected ok=false when the last value is NaN")
+	}
+}
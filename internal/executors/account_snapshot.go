@@ -0,0 +1,168 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// accountSnapshotTTL is how long a snapshot is served from memory before the account is fetched again
+// accountSnapshotTTL 是快照在重新获取账户前从内存返回的时长
+const accountSnapshotTTL = 5 * time.Second
+
+// AccountPositionSnapshot is one open position in an account snapshot
+// AccountPositionSnapshot 是账户快照中的一个持仓
+type AccountPositionSnapshot struct {
+	Symbol        string  `json:"symbol"`         // 交易对 / Trading pair
+	Side          string  `json:"side"`           // long/short
+	Size          float64 `json:"size"`           // 持仓数量 / Quantity
+	EntryPrice    float64 `json:"entry_price"`    // 开仓均价 / Entry price
+	Price         float64 `json:"price"`          // 当前价格 / Current price
+	Notional      float64 `json:"notional"`       // 名义价值 / Notional value
+	Leverage      int     `json:"leverage"`       // 杠杆 / Leverage
+	UnrealizedPnL float64 `json:"unrealized_pnl"` // 未实现盈亏 / Unrealized PnL
+	LivePrice     bool    `json:"live_price"`     // 价格来自实时缓存 / Price comes from the live price cache
+}
+
+// AccountSnapshot is the wallet breakdown shown on the dashboard
+// AccountSnapshot 是仪表盘展示的钱包明细
+type AccountSnapshot struct {
+	FetchedAt        time.Time                 `json:"fetched_at"`         // 账户数据获取时间 / When the account was fetched
+	WalletBalance    float64                   `json:"wallet_balance"`     // 钱包余额 / Wallet balance
+	AvailableBalance float64                   `json:"available_balance"`  // 可用保证金 / Available margin
+	MarginBalance    float64                   `json:"margin_balance"`     // 保证金余额（含未实现盈亏）/ Margin balance including unrealized PnL
+	MaintMargin      float64                   `json:"maint_margin"`       // 维持保证金 / Maintenance margin
+	MarginRatio      float64                   `json:"margin_ratio"`       // 保证金率（%）：维持保证金 / 保证金余额 / Margin ratio (%): maintenance margin / margin balance
+	UnrealizedPnL    float64                   `json:"unrealized_pnl"`     // 未实现盈亏合计 / Total unrealized PnL
+	TodayRealizedPnL float64                   `json:"today_realized_pnl"` // 今日已实现净盈亏 / Net PnL realized today
+	Positions        []AccountPositionSnapshot `json:"positions"`          // 持仓明细 / Open positions
+}
+
+// accountSnapshotCache holds the last snapshot so dashboard refreshes do not each call the exchange
+// accountSnapshotCache 保存最近一次快照，避免仪表盘每次刷新都调用交易所
+type accountSnapshotCache struct {
+	mu       sync.Mutex
+	snapshot *AccountSnapshot
+}
+
+// AccountSnapshot returns the wallet breakdown, fetching the account at most once per accountSnapshotTTL
+// AccountSnapshot 返回钱包明细，每个 accountSnapshotTTL 内最多获取一次账户
+// Position prices and unrealized PnL are refreshed from the shared price cache on every call
+// 每次调用都会用共享价格缓存刷新持仓价格和未实现盈亏
+func (e *BinanceExecutor) AccountSnapshot(ctx context.Context) (*AccountSnapshot, error) {
+	e.account.mu.Lock()
+	defer e.account.mu.Unlock()
+
+	if e.account.snapshot == nil || time.Since(e.account.snapshot.FetchedAt) > accountSnapshotTTL {
+		account, err := e.GetAccountInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get account info: %w", err)
+		}
+		snapshot := buildAccountSnapshot(account, time.Now())
+		if e.paper != nil {
+			snapshot.Positions = e.paperPositionSnapshots()
+		}
+		e.account.snapshot = snapshot
+	}
+
+	snapshot := *e.account.snapshot
+	snapshot.Positions = append([]AccountPositionSnapshot(nil), e.account.snapshot.Positions...)
+	e.applyLivePrices(&snapshot)
+	return &snapshot, nil
+}
+
+// buildAccountSnapshot converts the exchange account into a snapshot
+// buildAccountSnapshot 将交易所账户转换为快照
+func buildAccountSnapshot(account *futures.Account, at time.Time) *AccountSnapshot {
+	snapshot := &AccountSnapshot{FetchedAt: at, Positions: []AccountPositionSnapshot{}}
+	snapshot.WalletBalance, _ = parseFloat(account.TotalWalletBalance)
+	snapshot.AvailableBalance, _ = parseFloat(account.AvailableBalance)
+	snapshot.MarginBalance, _ = parseFloat(account.TotalMarginBalance)
+	snapshot.MaintMargin, _ = parseFloat(account.TotalMaintMargin)
+	snapshot.UnrealizedPnL, _ = parseFloat(account.TotalUnrealizedProfit)
+
+	for _, p := range account.Positions {
+		amt, _ := parseFloat(p.PositionAmt)
+		if amt == 0 {
+			continue
+		}
+		entry, _ := parseFloat(p.EntryPrice)
+		notional, _ := parseFloat(p.Notional)
+		pnl, _ := parseFloat(p.UnrealizedProfit)
+		leverage, _ := parseFloat(p.Leverage)
+
+		pos := AccountPositionSnapshot{
+			Symbol:        p.Symbol,
+			Side:          "long",
+			Size:          amt,
+			EntryPrice:    entry,
+			Leverage:      int(leverage),
+			UnrealizedPnL: pnl,
+		}
+		if amt < 0 {
+			pos.Side, pos.Size = "short", -amt
+		}
+		if notional < 0 {
+			notional = -notional
+		}
+		pos.Notional = notional
+		if pos.Size > 0 {
+			pos.Price = notional / pos.Size
+		}
+		snapshot.Positions = append(snapshot.Positions, pos)
+	}
+	sort.Slice(snapshot.Positions, func(i, j int) bool { return snapshot.Positions[i].Symbol < snapshot.Positions[j].Symbol })
+	return snapshot
+}
+
+// paperPositionSnapshots lists the simulated positions, which the paper account does not carry
+// paperPositionSnapshots 列出模拟持仓（模拟盘账户不包含持仓）
+func (e *BinanceExecutor) paperPositionSnapshots() []AccountPositionSnapshot {
+	positions := []AccountPositionSnapshot{}
+	for _, symbol := range e.config.CryptoSymbols {
+		pos := e.paper.Position(e.config.GetBinanceSymbolFor(symbol))
+		if pos == nil || pos.Size == 0 {
+			continue
+		}
+		positions = append(positions, AccountPositionSnapshot{
+			Symbol:        pos.Symbol,
+			Side:          pos.Side,
+			Size:          pos.Size,
+			EntryPrice:    pos.EntryPrice,
+			Price:         pos.CurrentPrice,
+			Notional:      pos.CurrentPrice * pos.Size,
+			Leverage:      pos.Leverage,
+			UnrealizedPnL: pos.UnrealizedPnL,
+		})
+	}
+	return positions
+}
+
+// applyLivePrices revalues positions at fresh cached prices and recomputes the account totals that depend on them
+// applyLivePrices 按未过期的缓存价格重新估值持仓，并重算依赖于此的账户合计
+func (e *BinanceExecutor) applyLivePrices(s *AccountSnapshot) {
+	var delta float64
+	for i := range s.Positions {
+		pos := &s.Positions[i]
+		price, ok := e.cachedPrice(pos.Symbol)
+		if !ok {
+			continue
+		}
+		pnl := (price - pos.EntryPrice) * pos.Size
+		if pos.Side == "short" {
+			pnl = -pnl
+		}
+		delta += pnl - pos.UnrealizedPnL
+		pos.Price, pos.Notional, pos.UnrealizedPnL, pos.LivePrice = price, price*pos.Size, pnl, true
+	}
+	s.UnrealizedPnL += delta
+	s.MarginBalance += delta
+	s.MarginRatio = 0
+	if s.MarginBalance > 0 {
+		s.MarginRatio = s.MaintMargin / s.MarginBalance * 100
+	}
+}
@@ -0,0 +1,62 @@
+package executors
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestAccountSnapshot(t *testing.T) {
+	account := &futures.Account{
+		TotalWalletBalance:    "1000",
+		AvailableBalance:      "700",
+		TotalMarginBalance:    "1030",
+		TotalMaintMargin:      "10.3",
+		TotalUnrealizedProfit: "30",
+		Positions: []*futures.AccountPosition{
+			{Symbol: "ETHUSDT", PositionAmt: "-2", EntryPrice: "2000", Notional: "-3980", UnrealizedProfit: "20", Leverage: "5"},
+			{Symbol: "BTCUSDT", PositionAmt: "0.1", EntryPrice: "50000", Notional: "5010", UnrealizedProfit: "10", Leverage: "10"},
+			{Symbol: "SOLUSDT", PositionAmt: "0", Leverage: "20"},
+		},
+	}
+
+	snapshot := buildAccountSnapshot(account, time.Now())
+	if len(snapshot.Positions) != 2 || snapshot.Positions[0].Symbol != "BTCUSDT" {
+		t.Fatalf("Expected the two open positions sorted by symbol, got %+v", snapshot.Positions)
+	}
+	eth := snapshot.Positions[1]
+	if eth.Side != "short" || eth.Size != 2 || eth.Notional != 3980 || eth.Price != 1990 || eth.Leverage != 5 {
+		t.Errorf("Unexpected short position: %+v", eth)
+	}
+
+	// Without a fresh cached price the exchange values stand
+	// 没有未过期的缓存价格时保留交易所的数值
+	cfg := &config.Config{CryptoSymbols: []string{"BTC/USDT", "ETH/USDT"}}
+	e := &BinanceExecutor{config: cfg}
+	plain := *snapshot
+	e.applyLivePrices(&plain)
+	if plain.UnrealizedPnL != 30 || math.Abs(plain.MarginRatio-1) > 1e-9 {
+		t.Errorf("Expected exchange totals and a 1%% margin ratio, got %+v", plain)
+	}
+
+	// A live ETH price revalues the short and the account totals with it
+	// 实时 ETH 价格会重新估值空仓及账户合计
+	ps := NewPriceService(e, cfg.CryptoSymbols, logger.NewColorLogger(false))
+	ps.Publish("ETHUSDT", 1950, time.Now())
+	live := *snapshot
+	live.Positions = append([]AccountPositionSnapshot(nil), snapshot.Positions...)
+	e.applyLivePrices(&live)
+	if p := live.Positions[1]; !p.LivePrice || p.UnrealizedPnL != 100 || p.Price != 1950 {
+		t.Errorf("Expected ETH revalued at 1950, got %+v", p)
+	}
+	if live.Positions[0].LivePrice || live.UnrealizedPnL != 110 || live.MarginBalance != 1110 {
+		t.Errorf("Unexpected totals after revaluation: %+v", live)
+	}
+	if snapshot.Positions[1].UnrealizedPnL != 20 {
+		t.Error("Expected revaluation not to modify the cached snapshot")
+	}
+}
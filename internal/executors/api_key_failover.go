@@ -0,0 +1,226 @@
+package executors
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// apiKeyHeader is the header Binance reads the API key from
+// apiKeyHeader 是币安读取 API 密钥的请求头
+const apiKeyHeader = "X-MBX-APIKEY"
+
+// apiKeyFailoverCooldown is the minimum time between two key switches, so two rejected keys do not flip on every request
+// apiKeyFailoverCooldown 是两次切换密钥的最小间隔，避免两个密钥都被拒绝时每个请求都来回切换
+const apiKeyFailoverCooldown = time.Minute
+
+// apiKeyRejectCodes are Binance error codes meaning the key itself was refused, not the request
+// apiKeyRejectCodes 是表示密钥本身被拒绝（而非请求有误）的币安错误码
+var apiKeyRejectCodes = map[int64]string{
+	-1002: "未授权",                // UNAUTHORIZED
+	-1022: "签名无效",               // INVALID_SIGNATURE
+	-2014: "API 密钥格式无效",         // BAD_API_KEY_FMT
+	-2015: "API 密钥、IP 白名单或权限无效", // REJECTED_MBX_KEY
+}
+
+// apiKeyPair is one Binance API key and its secret
+// apiKeyPair 是一组币安 API 密钥及其密钥
+type apiKeyPair struct {
+	label  string // 日志中显示的名称 / Name shown in logs
+	key    string
+	secret string
+}
+
+// apiKeyFailover sends signed requests with the active key and switches to the next key when the exchange rejects it
+// apiKeyFailover 使用当前密钥发送签名请求，交易所拒绝该密钥时切换到下一组密钥
+//
+// The go-binance client always signs with the primary key; while another key is active the request is re-signed here,
+// so the client's fields are never written while other goroutines sign with them. The rejected request is sent once
+// more with the new key: a refused key means the exchange did not act on it.
+// go-binance 客户端始终用主密钥签名；其他密钥生效时在这里重新签名，因此不会在其他协程签名时改写客户端字段。
+// 被拒绝的请求会用新密钥再发送一次：密钥被拒绝意味着交易所没有执行该请求。
+type apiKeyFailover struct {
+	base     http.RoundTripper
+	keys     []apiKeyPair
+	logger   *logger.ColorLogger
+	mu       sync.Mutex
+	active   int              // 当前密钥的下标，0 为主密钥 / Index of the active key, 0 is the primary
+	switched time.Time        // 上次切换时间 / When the key last switched
+	notifier *notify.Notifier // 切换告警，可为 nil / Switch alerts, may be nil
+}
+
+// withAPIKeyFailover wraps a Binance HTTP client so it fails over between keys; with a single key the client is returned as is
+// withAPIKeyFailover 包装币安 HTTP 客户端使其在多组密钥间故障切换；只有一组密钥时原样返回
+func withAPIKeyFailover(client *http.Client, keys []apiKeyPair, log *logger.ColorLogger) (*http.Client, *apiKeyFailover) {
+	if len(keys) < 2 {
+		return client, nil
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport := &apiKeyFailover{base: base, keys: keys, logger: log}
+	wrapped := *client
+	wrapped.Transport = transport
+	return &wrapped, transport
+}
+
+// setNotifier sends key switch alerts to n
+// setNotifier 将密钥切换告警发送到 n
+func (t *apiKeyFailover) setNotifier(n *notify.Notifier) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notifier = n
+}
+
+// activeKey returns the index of the key requests are currently sent with
+// activeKey 返回当前发送请求所用密钥的下标
+func (t *apiKeyFailover) activeKey() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.active
+}
+
+func (t *apiKeyFailover) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Public endpoints carry no key
+	// 公共接口不带密钥
+	if req.Header.Get(apiKeyHeader) == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	active := t.activeKey()
+	out, err := t.withKey(req, active)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		return resp, err
+	}
+	reason, rejected := keyRejection(resp)
+	if !rejected {
+		return resp, nil
+	}
+
+	next, ok := t.failover(active, reason)
+	if !ok {
+		return resp, nil
+	}
+	resp.Body.Close()
+	retry, err := t.withKey(req, next)
+	if err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(retry)
+}
+
+// failover moves from the rejected key to the next one and returns the key to retry with; ok is false inside the cooldown
+// failover 从被拒绝的密钥切换到下一组并返回重试所用的密钥；冷却期内 ok 为 false
+func (t *apiKeyFailover) failover(rejected int, reason string) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Another request already switched away from this key
+	// 其他请求已经切换离开该密钥
+	if t.active != rejected {
+		return t.active, true
+	}
+	if !t.switched.IsZero() && time.Since(t.switched) < apiKeyFailoverCooldown {
+		return rejected, false
+	}
+
+	t.active = (rejected + 1) % len(t.keys)
+	t.switched = time.Now()
+	from, to := t.keys[rejected].label, t.keys[t.active].label
+	if t.logger != nil {
+		t.logger.Error(fmt.Sprintf("🔑 币安%s被拒绝（%s），已切换到%s", from, reason, to))
+	}
+	t.notifier.Notify(notify.SeverityCritical, "全部交易对", fmt.Sprintf("🔑 币安%s被拒绝（%s），交易和止损管理已切换到%s，请尽快检查密钥", from, reason, to))
+	return t.active, true
+}
+
+// withKey returns req as sent with key i: the primary-signed request itself, or a copy carrying and signed with another key
+// withKey 返回以第 i 组密钥发送的请求：主密钥时为原请求，其他密钥时为换上该密钥并重新签名的副本
+func (t *apiKeyFailover) withKey(req *http.Request, i int) (*http.Request, error) {
+	if i == 0 {
+		return req, nil
+	}
+	pair := t.keys[i]
+
+	out := req.Clone(req.Context())
+	out.Header.Set(apiKeyHeader, pair.key)
+
+	var body []byte
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		body, err = io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// go-binance appends the signature as the last query parameter over query + body
+	// go-binance 将签名作为最后一个查询参数追加，签名内容为查询串 + 请求体
+	query := out.URL.RawQuery
+	if idx := strings.LastIndex(query, "signature="); idx >= 0 {
+		unsigned := strings.TrimSuffix(query[:idx], "&")
+		mac := hmac.New(sha256.New, []byte(pair.secret))
+		mac.Write([]byte(unsigned + string(body)))
+		signature := "signature=" + hex.EncodeToString(mac.Sum(nil))
+		if unsigned == "" {
+			out.URL.RawQuery = signature
+		} else {
+			out.URL.RawQuery = unsigned + "&" + signature
+		}
+	}
+	return out, nil
+}
+
+// keyRejection reports whether a response refused the API key, restoring its body for the client
+// keyRejection 判断响应是否拒绝了 API 密钥，并为客户端还原响应体
+func keyRejection(resp *http.Response) (string, bool) {
+	if resp.StatusCode < http.StatusBadRequest {
+		return "", false
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var apiErr struct {
+		Code int64 `json:"code"`
+	}
+	if json.Unmarshal(body, &apiErr) != nil {
+		return "", false
+	}
+	reason, ok := apiKeyRejectCodes[apiErr.Code]
+	return reason, ok
+}
+
+// ActiveAPIKey returns the name of the API key the executor is trading with
+// ActiveAPIKey 返回执行器当前交易所用 API 密钥的名称
+func (e *BinanceExecutor) ActiveAPIKey() string {
+	if e.keys == nil {
+		return "主密钥"
+	}
+	return e.keys.keys[e.keys.activeKey()].label
+}
@@ -0,0 +1,109 @@
+package executors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// keyServer accepts requests signed by one of secrets (keyed by API key) and rejects other keys with -2015
+func keyServer(t *testing.T, secrets map[string]string, rejected *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret, ok := secrets[r.Header.Get(apiKeyHeader)]
+		if !ok {
+			atomic.AddInt32(rejected, 1)
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		query := r.URL.RawQuery
+		idx := strings.LastIndex(query, "signature=")
+		if idx < 0 {
+			t.Errorf("Expected a signed request, got %q", query)
+			return
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(strings.TrimSuffix(query[:idx], "&") + string(body)))
+		if query[idx+len("signature="):] != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code":-1022,"msg":"Signature for this request is not valid."}`)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			io.WriteString(w, `{"orderId":42,"symbol":"BTCUSDT","status":"NEW"}`)
+			return
+		}
+		io.WriteString(w, `[]`)
+	}))
+}
+
+func TestAPIKeyFailover(t *testing.T) {
+	var rejected int32
+	server := keyServer(t, map[string]string{"backup": "backup-secret"}, &rejected)
+	defer server.Close()
+
+	client := futures.NewClient("primary", "primary-secret")
+	client.BaseURL = server.URL
+	var keys *apiKeyFailover
+	client.HTTPClient, keys = withAPIKeyFailover(client.HTTPClient, []apiKeyPair{
+		{label: "主密钥", key: "primary", secret: "primary-secret"},
+		{label: "备用密钥", key: "backup", secret: "backup-secret"},
+	}, nil)
+
+	// The rejected request is re-signed and sent again with the backup key
+	if _, err := client.NewGetBalanceService().Do(context.Background()); err != nil {
+		t.Fatalf("Expected the balance request to succeed with the backup key, got %v", err)
+	}
+	if keys.activeKey() != 1 || rejected != 1 {
+		t.Fatalf("Expected one rejection and the backup key active, got key %d after %d rejections", keys.activeKey(), rejected)
+	}
+
+	// Later requests go straight to the backup key, including form bodies
+	order, err := client.NewCreateOrderService().Symbol("BTCUSDT").Side(futures.SideTypeBuy).
+		Type(futures.OrderTypeLimit).TimeInForce(futures.TimeInForceTypeGTC).
+		Price("50000").Quantity("0.001").Do(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the order to be signed with the backup key, got %v", err)
+	}
+	if order.OrderID != 42 || rejected != 1 {
+		t.Errorf("Expected order 42 without another rejection, got %d after %d rejections", order.OrderID, rejected)
+	}
+}
+
+func TestAPIKeyFailoverCooldown(t *testing.T) {
+	var rejected int32
+	server := keyServer(t, map[string]string{}, &rejected)
+	defer server.Close()
+
+	client := futures.NewClient("primary", "primary-secret")
+	client.BaseURL = server.URL
+	var keys *apiKeyFailover
+	client.HTTPClient, keys = withAPIKeyFailover(client.HTTPClient, []apiKeyPair{
+		{label: "主密钥", key: "primary", secret: "primary-secret"},
+		{label: "备用密钥", key: "backup", secret: "backup-secret"},
+	}, nil)
+
+	if _, err := client.NewGetBalanceService().Do(context.Background()); err == nil {
+		t.Fatal("Expected an error when both keys are rejected")
+	}
+	if _, err := client.NewGetBalanceService().Do(context.Background()); err == nil {
+		t.Fatal("Expected an error when both keys are rejected")
+	}
+
+	// The first request switched once; within the cooldown the backup stays active and no request is resent
+	if keys.activeKey() != 1 || rejected != 3 {
+		t.Errorf("Expected the backup key to stay active after 3 rejections, got key %d after %d", keys.activeKey(), rejected)
+	}
+}
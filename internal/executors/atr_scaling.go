@@ -0,0 +1,71 @@
+package executors
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// atrScaler keeps the ATR multiplier factor of every symbol, measured from the bars its stops trail on
+// atrScaler 保存每个交易对的 ATR 倍数缩放系数，由其追踪止损所用的 K 线测得
+type atrScaler struct {
+	minScale float64            // ATR 处于 P0 时的系数 / Factor at the ATR's P0
+	maxScale float64            // ATR 处于 P100 时的系数 / Factor at the ATR's P100
+	scales   map[string]float64 // 交易对 → 当前系数 / Symbol → current factor
+	mu       sync.RWMutex
+}
+
+// EnableATRScaling scales the initial and trailing ATR multipliers by the ATR percentile, from minScale to maxScale
+// EnableATRScaling 按 ATR 百分位在 minScale 到 maxScale 之间缩放初始与追踪止损的 ATR 倍数
+func (calc *TrailingStopCalculator) EnableATRScaling(minScale, maxScale float64) {
+	if minScale <= 0 || minScale > 1 {
+		minScale = 1
+	}
+	if maxScale < 1 {
+		maxScale = 1
+	}
+	calc.scaler = &atrScaler{minScale: minScale, maxScale: maxScale, scales: make(map[string]float64)}
+}
+
+// UpdateATRScale measures a symbol's ATR percentile on bars and stores the factor used by GetConfig
+// UpdateATRScale 用 K 线测量交易对的 ATR 百分位，并保存 GetConfig 使用的缩放系数
+//
+// With too few bars the previous factor is kept; scale is 1 when scaling is disabled.
+// K 线不足时保留之前的系数；未启用缩放时 scale 为 1。
+func (calc *TrailingStopCalculator) UpdateATRScale(symbol string, bars []dataflows.OHLCV) (scale, atrPercentile float64, ok bool) {
+	if calc.scaler == nil {
+		return 1, 0, false
+	}
+	scale, atrPercentile, ok = dataflows.ATRMultiplierScale(bars, calc.scaler.minScale, calc.scaler.maxScale)
+	if !ok {
+		return calc.scaler.scale(normalizeCalculatorSymbol(symbol)), 0, false
+	}
+	calc.scaler.mu.Lock()
+	calc.scaler.scales[normalizeCalculatorSymbol(symbol)] = scale
+	calc.scaler.mu.Unlock()
+	return scale, atrPercentile, true
+}
+
+// scale returns a symbol's factor, 1 until it has been measured
+// scale 返回交易对的缩放系数，测量之前为 1
+func (s *atrScaler) scale(normalizedSymbol string) float64 {
+	if s == nil {
+		return 1
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if scale, ok := s.scales[normalizedSymbol]; ok {
+		return scale
+	}
+	return 1
+}
+
+// UpdateATRScale refreshes the ATR multiplier factor of a symbol from the bars its stop trails on
+// UpdateATRScale 用交易对追踪止损所用的 K 线刷新其 ATR 倍数缩放系数
+func (sm *StopLossManager) UpdateATRScale(symbol string, bars []dataflows.OHLCV) {
+	scale, atrPercentile, ok := sm.calculator.UpdateATRScale(symbol, bars)
+	if ok {
+		sm.logger.Info(fmt.Sprintf("【%s】📐 ATR 处于 P%.0f，止损 ATR 倍数缩放 %.2f 倍", symbol, atrPercentile, scale))
+	}
+}
@@ -0,0 +1,42 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+func TestUpdateATRScaleWidensMultipliers(t *testing.T) {
+	// Quiet bars followed by a volatility spike put the ATR at the top of its range
+	// 平静 K 线之后波动放大，使 ATR 位于区间顶部
+	bars := make([]dataflows.OHLCV, 140)
+	for i := range bars {
+		swing := 0.5
+		if i >= len(bars)-5 {
+			swing = 5
+		}
+		bars[i] = dataflows.OHLCV{Open: 100, High: 100 + swing, Low: 100 - swing, Close: 100}
+	}
+
+	calc := NewTrailingStopCalculator(nil)
+	base := calc.GetConfig("BTCUSDT")
+	if _, _, ok := calc.UpdateATRScale("BTCUSDT", bars); ok {
+		t.Fatal("Expected no scaling while it is disabled")
+	}
+
+	calc.EnableATRScaling(0.8, 1.5)
+	scale, _, ok := calc.UpdateATRScale("BTC/USDT", bars)
+	if !ok || scale <= 1 {
+		t.Fatalf("Expected a widening factor, got %.2f (ok=%v)", scale, ok)
+	}
+
+	scaled := calc.GetConfig("BTCUSDT")
+	if math.Abs(scaled.TrailingATRMultiplier-base.TrailingATRMultiplier*scale) > 1e-9 ||
+		math.Abs(scaled.InitialATRMultiplier-base.InitialATRMultiplier*scale) > 1e-9 {
+		t.Errorf("Expected multipliers scaled by %.2f, got %+v", scale, scaled)
+	}
+	if other := calc.GetConfig("ETHUSDT"); other.TrailingATRMultiplier != base.TrailingATRMultiplier {
+		t.Errorf("Expected an unmeasured symbol unscaled, got %.2f", other.TrailingATRMultiplier)
+	}
+}
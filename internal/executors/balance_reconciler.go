@@ -0,0 +1,172 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// botIncomeTypes are income types produced by the bot's own trading; anything else moving the wallet is unexplained
+// botIncomeTypes 是由机器人自身交易产生的收益类型；其他导致钱包变化的记录都视为无法解释
+var botIncomeTypes = map[string]bool{
+	"REALIZED_PNL": true, // 已实现盈亏 / Realized PnL
+	"FUNDING_FEE":  true, // 资金费 / Funding fee
+	"COMMISSION":   true, // 手续费 / Trading commission
+}
+
+// BalanceReconciliation is the result of one expected-vs-actual wallet balance check
+// BalanceReconciliation 是一次预期与实际钱包余额对账的结果
+type BalanceReconciliation struct {
+	Time          time.Time // 对账时间 / Check time
+	Baseline      float64   // 上次确认的钱包余额 / Last confirmed wallet balance
+	ExplainedPnL  float64   // 机器人交易解释的变动 / Change explained by the bot's trading
+	Expected      float64   // 预期钱包余额 / Expected wallet balance
+	Actual        float64   // 实际钱包余额 / Actual wallet balance
+	Discrepancy   float64   // 实际 - 预期 / Actual minus expected
+	Unexplained   []string  // 无法解释的收益记录摘要 / Summary of unexplained income records
+	EntriesHalted bool      // 是否已暂停开仓 / Whether new entries are halted
+}
+
+// balanceGuard holds the reconciliation baseline and the entry halt flag
+// balanceGuard 保存对账基准和开仓暂停标志
+type balanceGuard struct {
+	baseline     float64   // 上次确认的钱包余额 / Last confirmed wallet balance
+	baselineTime time.Time // 基准时间 / Baseline time
+	halted       bool      // 是否暂停开仓 / Whether entries are halted
+	haltReason   string    // 暂停原因 / Halt reason
+	mu           sync.RWMutex
+}
+
+// incomeRecord is the subset of a Binance income record used for reconciliation
+// incomeRecord 是对账所用的币安收益记录子集
+type incomeRecord struct {
+	IncomeType string  // 收益类型 / Income type
+	Symbol     string  // 交易对 / Trading pair
+	Asset      string  // 资产 / Asset
+	Amount     float64 // 金额 / Amount
+}
+
+// explainedIncome sums the income records the bot accounts for: its own trading income in USDT on configured symbols
+// explainedIncome 汇总机器人可解释的收益记录：已配置交易对上以 USDT 结算的自身交易收益
+// It also returns a short description of every other record, which points at the cause of a discrepancy
+// 同时返回其余记录的简短描述，用于定位差异原因
+func explainedIncome(records []incomeRecord, symbols map[string]bool) (float64, []string) {
+	explained := 0.0
+	var unexplained []string
+	for _, r := range records {
+		if r.Asset == "USDT" && botIncomeTypes[r.IncomeType] && symbols[r.Symbol] {
+			explained += r.Amount
+			continue
+		}
+		unexplained = append(unexplained, fmt.Sprintf("%s %s %+.4f %s", r.IncomeType, r.Symbol, r.Amount, r.Asset))
+	}
+	return explained, unexplained
+}
+
+// ReconcileBalance compares the actual USDT wallet balance with the balance expected from the bot's own trading
+// ReconcileBalance 将实际 USDT 钱包余额与由机器人自身交易推算的预期余额进行比较
+//
+// The first call only records a baseline. When the discrepancy exceeds BALANCE_DISCREPANCY_THRESHOLD,
+// new entries are halted until the process is restarted; closing trades remain allowed.
+// 首次调用只记录基准。差异超过 BALANCE_DISCREPANCY_THRESHOLD 时暂停开仓直到程序重启，平仓仍然允许。
+func (e *BinanceExecutor) ReconcileBalance(ctx context.Context) (*BalanceReconciliation, error) {
+	threshold := e.config.BalanceDiscrepancyThreshold
+	if threshold <= 0 {
+		return nil, nil
+	}
+	// The paper wallet only changes through its own fills and fees, while the income history is the real account's
+	// 模拟盘钱包只随自身成交和手续费变化，而收益记录来自真实账户，两者无法对账
+	if e.paper != nil {
+		return nil, nil
+	}
+
+	now := time.Now()
+	account, err := e.GetAccountInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	actual := 0.0
+	for _, asset := range account.Assets {
+		if asset.Asset == "USDT" {
+			actual, _ = parseFloat(asset.WalletBalance)
+			break
+		}
+	}
+
+	g := e.balanceGuard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.baselineTime.IsZero() {
+		g.baseline = actual
+		g.baselineTime = now
+		e.logger.Info(fmt.Sprintf("💰 余额对账基准: %.2f USDT", actual))
+		return nil, nil
+	}
+
+	records, err := e.getIncomeHistory(ctx, "", "", g.baselineTime, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get income history: %w", err)
+	}
+
+	incomes := make([]incomeRecord, 0, len(records))
+	for _, r := range records {
+		amount, err := parseFloat(r.Income)
+		if err != nil {
+			continue
+		}
+		incomes = append(incomes, incomeRecord{IncomeType: r.IncomeType, Symbol: r.Symbol, Asset: r.Asset, Amount: amount})
+	}
+
+	symbols := make(map[string]bool, len(e.config.CryptoSymbols))
+	for _, symbol := range e.config.CryptoSymbols {
+		symbols[e.config.GetBinanceSymbolFor(symbol)] = true
+	}
+	explained, unexplained := explainedIncome(incomes, symbols)
+
+	result := &BalanceReconciliation{
+		Time:         now,
+		Baseline:     g.baseline,
+		ExplainedPnL: explained,
+		Expected:     g.baseline + explained,
+		Actual:       actual,
+		Unexplained:  unexplained,
+	}
+	result.Discrepancy = result.Actual - result.Expected
+
+	if math.Abs(result.Discrepancy) > threshold {
+		if !g.halted {
+			g.halted = true
+			g.haltReason = fmt.Sprintf("钱包余额异常变动 %+.2f USDT（预期 %.2f，实际 %.2f）",
+				result.Discrepancy, result.Expected, result.Actual)
+			e.logger.Error(fmt.Sprintf("🚨 %s，超过阈值 %.2f USDT，已暂停开仓", g.haltReason, threshold))
+			if len(unexplained) > 0 {
+				e.logger.Error(fmt.Sprintf("🚨 无法解释的账户记录: %s", strings.Join(unexplained, "; ")))
+			} else {
+				e.logger.Error("🚨 未找到对应的账户记录，可能是提现、划转或交易所外部操作")
+			}
+			e.logger.Warning("⚠️  平仓不受影响；确认账户状态后重启程序以恢复开仓")
+		}
+		result.EntriesHalted = true
+		return result, nil
+	}
+
+	// Roll the baseline forward so small rounding differences never accumulate
+	// 向前滚动基准，避免微小的舍入误差累积
+	g.baseline = actual
+	g.baselineTime = now
+	result.EntriesHalted = g.halted
+	return result, nil
+}
+
+// EntriesHalted reports whether new entries are halted by the balance guard, and why
+// EntriesHalted 返回余额守护是否已暂停开仓及原因
+func (e *BinanceExecutor) EntriesHalted() (bool, string) {
+	e.balanceGuard.mu.RLock()
+	defer e.balanceGuard.mu.RUnlock()
+	return e.balanceGuard.halted, e.balanceGuard.haltReason
+}
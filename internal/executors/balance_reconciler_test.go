@@ -0,0 +1,95 @@
+package executors
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestExplainedIncome(t *testing.T) {
+	symbols := map[string]bool{"BTCUSDT": true, "ETHUSDT": true}
+
+	tests := []struct {
+		name            string
+		records         []incomeRecord
+		wantExplained   float64
+		wantUnexplained int
+	}{
+		{
+			name:            "No records",
+			records:         nil,
+			wantExplained:   0,
+			wantUnexplained: 0,
+		},
+		{
+			name: "Bot trading only",
+			records: []incomeRecord{
+				{IncomeType: "REALIZED_PNL", Symbol: "BTCUSDT", Asset: "USDT", Amount: 25.5},
+				{IncomeType: "COMMISSION", Symbol: "BTCUSDT", Asset: "USDT", Amount: -0.8},
+				{IncomeType: "FUNDING_FEE", Symbol: "ETHUSDT", Asset: "USDT", Amount: -0.2},
+			},
+			wantExplained:   24.5,
+			wantUnexplained: 0,
+		},
+		{
+			name: "Withdrawal transfer",
+			records: []incomeRecord{
+				{IncomeType: "REALIZED_PNL", Symbol: "BTCUSDT", Asset: "USDT", Amount: 10},
+				{IncomeType: "TRANSFER", Symbol: "", Asset: "USDT", Amount: -500},
+			},
+			wantExplained:   10,
+			wantUnexplained: 1,
+		},
+		{
+			name: "Manual trade on another symbol",
+			records: []incomeRecord{
+				{IncomeType: "REALIZED_PNL", Symbol: "SOLUSDT", Asset: "USDT", Amount: -40},
+				{IncomeType: "COMMISSION", Symbol: "SOLUSDT", Asset: "USDT", Amount: -1},
+			},
+			wantExplained:   0,
+			wantUnexplained: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explained, unexplained := explainedIncome(tt.records, symbols)
+			if math.Abs(explained-tt.wantExplained) > 1e-9 {
+				t.Errorf("Expected explained %.4f, got %.4f", tt.wantExplained, explained)
+			}
+			if len(unexplained) != tt.wantUnexplained {
+				t.Errorf("Expected %d unexplained records, got %d: %v", tt.wantUnexplained, len(unexplained), unexplained)
+			}
+		})
+	}
+}
+
+func TestEntriesHaltedDefault(t *testing.T) {
+	executor := &BinanceExecutor{balanceGuard: &balanceGuard{}}
+	if halted, _ := executor.EntriesHalted(); halted {
+		t.Errorf("Expected entries not halted by default")
+	}
+}
+
+func TestReconcileBalanceSkipsPaper(t *testing.T) {
+	log := logger.NewColorLogger(false)
+	e := &BinanceExecutor{
+		config:       &config.Config{BalanceDiscrepancyThreshold: 10},
+		logger:       log,
+		balanceGuard: &balanceGuard{},
+		paper:        NewPaperExecutor(10000, 0.0004, 10, log),
+	}
+
+	// Without the skip the call would reach for the real exchange and fail
+	// 若不跳过，调用会访问真实交易所并失败
+	result, err := e.ReconcileBalance(context.Background())
+	if result != nil || err != nil {
+		t.Errorf("Expected paper trading to skip reconciliation, got %+v, %v", result, err)
+	}
+	if halted, _ := e.EntriesHalted(); halted {
+		t.Error("Expected entries to stay open under paper trading")
+	}
+}
@@ -3,16 +3,18 @@ package executors
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
-	"net/url"
 	"strings"
 	"time"
 
+	"github.com/adshao/go-binance/v2/common"
 	"github.com/adshao/go-binance/v2/futures"
-	"github.com/jpillora/backoff"
+	"github.com/oak/crypto-trading-bot/internal/capture"
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/logger"
 )
 
@@ -48,19 +50,24 @@ const (
 type Position struct {
 	// Basic position info
 	// 基础持仓信息
-	ID               string    // 持仓 ID / Position ID
-	Symbol           string    // 交易对 / Trading pair
-	Side             string    // long/short
-	Size             float64   // 持仓大小 / Position size (same as Quantity)
-	EntryPrice       float64   // 入场价格 / Entry price
-	EntryTime        time.Time // 入场时间 / Entry time
-	CurrentPrice     float64   // 当前价格 / Current price
-	HighestPrice     float64   // 最高价（多仓）或最低价（空仓）/ Highest/lowest price
-	Quantity         float64   // 持仓数量 / Quantity (same as Size)
-	UnrealizedPnL    float64   // 未实现盈亏 / Unrealized PnL
-	PositionAmt      float64   // 仓位金额 / Position amount
-	Leverage         int       // 杠杆倍数 / Leverage
-	LiquidationPrice float64   // 强平价格 / Liquidation price
+	ID               string     // 持仓 ID / Position ID
+	Symbol           string     // 交易对 / Trading pair
+	Side             string     // long/short
+	Size             float64    // 持仓大小 / Position size (same as Quantity)
+	EntryPrice       float64    // 入场价格 / Entry price
+	EntryTime        time.Time  // 入场时间 / Entry time
+	CurrentPrice     float64    // 当前价格 / Current price
+	HighestPrice     float64    // 最高价（多仓）或最低价（空仓）/ Highest/lowest price
+	Quantity         float64    // 持仓数量 / Quantity (same as Size)
+	UnrealizedPnL    float64    // 未实现盈亏 / Unrealized PnL
+	PositionAmt      float64    // 仓位金额 / Position amount
+	Leverage         int        // 杠杆倍数 / Leverage
+	LiquidationPrice float64    // 强平价格 / Liquidation price
+	MarginType       MarginType // 保证金类型 / Margin type (cross/isolated)
+
+	// Lifecycle
+	// 生命周期
+	State LifecycleState // 生命周期状态，由止损/止盈管理器转换 / Lifecycle state, moved by the stop-loss and take-profit managers
 
 	// Stop-loss management
 	// 止损管理
@@ -79,6 +86,11 @@ type Position struct {
 	// 订单管理
 	StopLossOrderID string // 当前止损单 ID / Stop-loss order ID
 
+	// Funding accounting
+	// 资金费核算
+	FundingFees     float64   // 持仓期间累计资金费（正数为收入）/ Funding accumulated while open (positive is income)
+	LastFundingSync time.Time // 上次同步资金费时间 / Last funding sync
+
 	// History and context
 	// 历史和上下文
 	StopLossHistory []StopLossEvent // 止损变更历史 / Stop-loss history
@@ -119,6 +131,20 @@ type TradeResult struct {
 	Filled      float64
 	Message     string
 	NewPosition *Position
+
+	ReferencePrice float64 // 下单前的标记价格 / Mark price before the entry order
+	SlippageBps    float64 // 成交滑点（基点，正数为不利）/ Fill slippage in bps (positive is adverse)
+	Leverage       int     // 开仓使用的杠杆，0 表示未知 / Leverage the entry was sized with, 0 if unknown
+	Hedge          bool    // 开仓为保留反向持仓的对冲腿 / The entry is a hedge leg opened alongside the opposite position
+}
+
+// FilledQuantity returns the quantity actually filled, or the requested amount when the fill is unknown
+// FilledQuantity 返回实际成交数量，成交未知时返回请求数量
+func (r *TradeResult) FilledQuantity() float64 {
+	if r.Filled > 0 {
+		return r.Filled
+	}
+	return r.Amount
 }
 
 // BinanceExecutor handles Binance futures trading
@@ -129,19 +155,32 @@ type BinanceExecutor struct {
 	positionMode PositionMode
 	logger       *logger.ColorLogger
 	tradeHistory []TradeResult
+	brackets     *bracketRegistry     // 活跃的止损/止盈括号单 / Active stop-loss/take-profit brackets
+	ladders      *entryLadderRegistry // 活跃的 DCA 分批开仓阶梯 / Active DCA entry ladders
+	capabilities *capabilityRegistry  // 各交易对支持的订单能力 / Order capabilities per symbol
+	balanceGuard *balanceGuard        // 余额对账与开仓暂停 / Balance reconciliation and entry halt
+	instanceID   string               // 实例标识 / Instance identifier
+	instanceTag  string               // 嵌入客户端订单 ID 的实例标签 / Instance tag embedded in client order IDs
+	leases       *SymbolLeaseManager  // 交易对租约（多实例互斥）/ Symbol leases (mutual exclusion between instances)
+	paper        *PaperExecutor       // 模拟盘，启用时代替交易所成交 / Paper executor filling trades instead of the exchange when enabled
+	prices       *PriceService        // 共享价格缓存，未启用时为 nil / Shared price cache, nil when disabled
+	pauses       *SymbolPauseRegistry // 按交易对暂停开仓，未启用时为 nil / Per-symbol entry pauses, nil when disabled
+	account      accountSnapshotCache // 仪表盘账户快照缓存 / Cached account snapshot for the dashboard
+	contracts    contractSpecCache    // 杠杆分层与资金费间隔缓存 / Cached leverage brackets and funding intervals
+	keys         *apiKeyFailover      // 备用 API 密钥故障切换，未配置时为 nil / Backup API key failover, nil when not configured
 }
 
 // NewBinanceExecutor creates a new BinanceExecutor
 // NewBinanceExecutor 创建一个新的 BinanceExecutor
 func NewBinanceExecutor(cfg *config.Config, log *logger.ColorLogger) *BinanceExecutor {
-	futures.UseTestnet = cfg.BinanceTestMode
+	futures.UseTestnet = cfg.UseBinanceTestnet()
 
 	client := futures.NewClient(cfg.BinanceAPIKey, cfg.BinanceAPISecret)
 
-	// Set proxy if configured
-	// 如果配置了代理，则设置代理
+	// Set proxy if configured (http, https, socks5 or socks5h)
+	// 如果配置了代理，则设置代理（http、https、socks5 或 socks5h）
 	if cfg.BinanceProxy != "" {
-		proxyURL, err := url.Parse(cfg.BinanceProxy)
+		proxy, err := dataflows.RESTProxy(cfg)
 		if err != nil {
 			log.Warning(fmt.Sprintf("代理 URL 解析失败: %v，将不使用代理", err))
 		} else {
@@ -149,7 +188,7 @@ func NewBinanceExecutor(cfg *config.Config, log *logger.ColorLogger) *BinanceExe
 			// 创建带代理的自定义 HTTP 客户端
 			httpClient := &http.Client{
 				Transport: &http.Transport{
-					Proxy: http.ProxyURL(proxyURL),
+					Proxy: proxy,
 					TLSClientConfig: &tls.Config{
 						InsecureSkipVerify: cfg.BinanceProxyInsecureSkipTLS, // 是否跳过 TLS 验证 / Skip TLS verification
 					},
@@ -162,12 +201,53 @@ func NewBinanceExecutor(cfg *config.Config, log *logger.ColorLogger) *BinanceExe
 		}
 	}
 
+	// WebSocket streams (user data) take their own proxy; the setting is package-wide like UseTestnet
+	// WebSocket 流（用户数据流）使用单独的代理；该设置与 UseTestnet 一样是包级全局的
+	if wsProxy, err := dataflows.WebSocketProxy(cfg); err != nil {
+		log.Warning(fmt.Sprintf("WebSocket 代理 URL 解析失败: %v，将不使用代理", err))
+	} else {
+		futures.ProxyUrl = wsProxy
+	}
+
+	// Fail over to the backup API key when the exchange rejects the primary; innermost so metrics see the final response
+	// 交易所拒绝主密钥时切换到备用密钥；包在最内层，使调用统计看到最终响应
+	var keys *apiKeyFailover
+	if cfg.BinanceAPIKeyBackup != "" && cfg.BinanceAPISecretBackup != "" {
+		client.HTTPClient, keys = withAPIKeyFailover(client.HTTPClient, []apiKeyPair{
+			{label: "主密钥", key: cfg.BinanceAPIKey, secret: cfg.BinanceAPISecret},
+			{label: "备用密钥", key: cfg.BinanceAPIKeyBackup, secret: cfg.BinanceAPISecretBackup},
+		}, log)
+	}
+
+	// Archive raw payloads of symbols armed for debug capture
+	// 归档已开启调试采集的交易对的原始响应
+	client.HTTPClient = capture.WithCapture(capture.Shared(cfg), client.HTTPClient)
+
+	// Record latency and errors of every exchange call; the weight limiter wraps outside so its waits are not counted
+	// 记录每次交易所调用的耗时和错误；权重限流器包在外层，其等待时间不计入耗时
+	client.HTTPClient = withCallMetrics(client.HTTPClient, SharedCallMetrics(), log,
+		time.Duration(cfg.ExecutorSlowCallMs)*time.Millisecond, cfg.ExecutorErrorRateAlert)
+
+	// Share the per-IP request weight budget with every other Binance client in the process
+	// 与进程内其他币安客户端共享按 IP 统计的请求权重预算
+	client.HTTPClient = dataflows.WithWeightLimit(cfg, client.HTTPClient)
+
 	executor := &BinanceExecutor{
 		client:       client,
 		config:       cfg,
-		testMode:     cfg.BinanceTestMode,
+		testMode:     cfg.UseBinanceTestnet(),
 		logger:       log,
 		tradeHistory: make([]TradeResult, 0),
+		brackets:     &bracketRegistry{brackets: make(map[string]*BracketOrder)},
+		ladders:      &entryLadderRegistry{ladders: make(map[string]*EntryLadder)},
+		capabilities: &capabilityRegistry{symbols: make(map[string]SymbolCapabilities), filters: make(map[string]SymbolFilters)},
+		balanceGuard: &balanceGuard{},
+		keys:         keys,
+	}
+	executor.instanceID = resolveInstanceID(cfg.BotInstanceID)
+	executor.instanceTag = instanceTag(executor.instanceID)
+	if cfg.PaperTrading {
+		executor.paper = NewPaperExecutor(cfg.PaperInitialBalance, cfg.PaperFeeRate, cfg.BinanceLeverage, log)
 	}
 
 	// Mode logging removed from constructor to avoid repetitive logs
@@ -183,6 +263,13 @@ func (e *BinanceExecutor) DetectPositionMode(ctx context.Context) error {
 		return nil
 	}
 
+	// The paper executor nets positions per symbol like one-way mode
+	// 模拟盘按交易对净额持仓，等同单向模式
+	if e.paper != nil {
+		e.positionMode = PositionModeOneWay
+		return nil
+	}
+
 	// Check user configuration first
 	configMode := e.config.BinancePositionMode
 	if configMode == "oneway" || configMode == "hedge" {
@@ -233,16 +320,7 @@ func (e *BinanceExecutor) DetectMarginType(ctx context.Context, symbol string) (
 		// Check margin type from position risk info
 		// 从持仓风险信息中获取保证金类型
 		if len(positions) > 0 {
-			marginTypeStr := strings.ToLower(positions[0].MarginType)
-			if marginTypeStr == "cross" {
-				marginType = MarginTypeCross
-			} else if marginTypeStr == "isolated" {
-				marginType = MarginTypeIsolated
-			} else {
-				// Default to cross if unknown
-				// 未知类型默认为全仓
-				marginType = MarginTypeCross
-			}
+			marginType = parseMarginType(positions[0].MarginType)
 		} else {
 			// No position data, default to cross
 			// 无持仓数据，默认为全仓
@@ -260,13 +338,82 @@ func (e *BinanceExecutor) DetectMarginType(ctx context.Context, symbol string) (
 	return marginType, nil
 }
 
+// parseMarginType converts Binance's margin type string to MarginType, defaulting to cross
+// parseMarginType 将币安返回的保证金类型字符串转换为 MarginType，未知类型默认为全仓
+func parseMarginType(value string) MarginType {
+	if strings.ToLower(value) == "isolated" {
+		return MarginTypeIsolated
+	}
+	return MarginTypeCross
+}
+
+// Label returns the display name of the margin type
+// Label 返回保证金类型的显示名称
+func (m MarginType) Label() string {
+	if m == MarginTypeIsolated {
+		return "逐仓"
+	}
+	return "全仓"
+}
+
+// errCodeMarginTypeUnchanged is returned when a symbol already uses the requested margin type
+// errCodeMarginTypeUnchanged 在交易对已是目标保证金类型时返回
+const errCodeMarginTypeUnchanged = -4046
+
+// SetMarginType switches a symbol to the given margin type
+// SetMarginType 将交易对切换为指定的保证金类型
+//
+// A symbol already on that type counts as success. Binance refuses the change while the symbol has
+// open orders or a position (-4047/-4048); that error is returned and the current type stays in effect.
+// 交易对已是该类型时视为成功。交易对有挂单或持仓时币安拒绝修改（-4047/-4048），此时返回错误并保持当前类型。
+func (e *BinanceExecutor) SetMarginType(ctx context.Context, symbol string, marginType MarginType) error {
+	target := futures.MarginTypeCrossed
+	if marginType == MarginTypeIsolated {
+		target = futures.MarginTypeIsolated
+	}
+
+	err := e.withRetry(func() error {
+		return e.client.NewChangeMarginTypeService().
+			Symbol(e.config.GetBinanceSymbolFor(symbol)).
+			MarginType(target).
+			Do(ctx)
+	})
+
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == errCodeMarginTypeUnchanged {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to change margin type: %w", err)
+	}
+	return nil
+}
+
 // SetupExchange sets up exchange parameters
 func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leverage int) error {
+	if e.paper != nil {
+		e.paper.SetLeverage(e.config.GetBinanceSymbolFor(symbol), leverage)
+		balance, _ := e.GetBalance(ctx)
+		e.logger.Success(fmt.Sprintf("📝 [模拟盘] 设置杠杆倍数: %dx，虚拟 USDT 余额: %.2f", leverage, balance))
+		return nil
+	}
+
 	// Detect position mode
 	if err := e.DetectPositionMode(ctx); err != nil {
 		return fmt.Errorf("failed to detect position mode: %w", err)
 	}
 
+	// Apply the configured margin type before leverage, since it decides how liquidation works
+	// 在设置杠杆前应用配置的保证金类型，它决定了强平方式
+	if configured := e.config.MarginTypeFor(symbol); configured != "" {
+		marginType := MarginType(configured)
+		if err := e.SetMarginType(ctx, symbol, marginType); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  【%s】无法切换为%s模式，保持当前保证金模式: %v", symbol, marginType.Label(), err))
+		} else {
+			e.logger.Info(fmt.Sprintf("✓ 保证金模式: %s（%s）", marginType.Label(), marginType))
+		}
+	}
+
 	// Check current position to avoid leverage reduction error (-4161)
 	// 检查当前持仓，避免杠杆降低错误 (-4161)
 	currentPosition, err := e.GetCurrentPosition(ctx, symbol)
@@ -309,7 +456,7 @@ func (e *BinanceExecutor) SetupExchange(ctx context.Context, symbol string, leve
 
 checkBalance:
 	// Get balance
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get account info: %w", err)
 	}
@@ -326,10 +473,33 @@ checkBalance:
 }
 
 // GetCurrentPosition gets the current position for a symbol
+// In hedge mode with both legs open this is the first leg; use PositionFor or GetPositionLeg to pick one
+// 双向持仓模式下两条腿都存在时返回第一条；需要指定方向时使用 PositionFor 或 GetPositionLeg
 func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string) (*Position, error) {
-	var position *Position
+	legs, err := e.positionLegs(ctx, symbol)
+	if err != nil || len(legs) == 0 {
+		return nil, err
+	}
+	return legs[0], nil
+}
+
+// positionLegs returns every open leg of a symbol: one in one-way mode, up to two in hedge mode
+// positionLegs 返回交易对所有未平的持仓腿：单向持仓最多一条，双向持仓最多两条
+func (e *BinanceExecutor) positionLegs(ctx context.Context, symbol string) ([]*Position, error) {
+	if e.paper != nil {
+		// Refresh the price first so the position is valued, and stops or liquidation are applied, at the live price
+		// 先刷新价格，使持仓按实盘价格估值，并处理止损和强平
+		e.GetCurrentPrice(ctx, symbol)
+		if position := e.paper.Position(e.config.GetBinanceSymbolFor(symbol)); position != nil {
+			return []*Position{position}, nil
+		}
+		return nil, nil
+	}
+
+	var legs []*Position
 
 	err := e.withRetry(func() error {
+		legs = nil
 		positions, err := e.client.NewGetPositionRiskService().
 			Symbol(e.config.GetBinanceSymbolFor(symbol)).
 			Do(ctx)
@@ -351,7 +521,7 @@ func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string)
 					side = "short"
 				}
 
-				position = &Position{
+				legs = append(legs, &Position{
 					Side:             side,
 					Size:             math.Abs(posAmt),
 					EntryPrice:       entryPrice,
@@ -360,8 +530,8 @@ func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string)
 					Symbol:           pos.Symbol,
 					Leverage:         leverage,
 					LiquidationPrice: liquidationPrice,
-				}
-				break
+					MarginType:       parseMarginType(pos.MarginType),
+				})
 			}
 		}
 
@@ -372,7 +542,7 @@ func (e *BinanceExecutor) GetCurrentPosition(ctx context.Context, symbol string)
 		return nil, fmt.Errorf("failed to get position: %w", err)
 	}
 
-	return position, nil
+	return legs, nil
 }
 
 // ExecuteTrade executes a trade
@@ -387,8 +557,13 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 		TestMode:  e.testMode,
 	}
 
-	// Get current position
-	currentPosition, _ := e.GetCurrentPosition(ctx, symbol)
+	if e.paper != nil {
+		return e.executePaperTrade(ctx, symbol, action, amount, result)
+	}
+
+	// Get current position; with both hedge legs open, the leg the action acts on
+	// 获取当前持仓；双向对冲两条腿都存在时取该动作作用的一条
+	currentPosition, _ := e.PositionFor(ctx, symbol, action)
 
 	// Log trade execution
 	// 记录交易执行
@@ -415,6 +590,20 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 	// Detect position mode
 	e.DetectPositionMode(ctx)
 
+	// Pre-flight: reject orders that contradict the position mode, the current position or the available margin
+	// 预检：拒绝与持仓模式、当前持仓或可用保证金矛盾的订单
+	if err := e.preflightTrade(ctx, symbol, action, amount, currentPosition); err != nil {
+		result.Message = fmt.Sprintf("预检未通过: %v", err)
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】%s", symbol, result.Message))
+		return result
+	}
+
+	// Validation-only mode: check the orders against exchange filters and stop before sending
+	// 仅校验模式：按交易所过滤规则检查订单，在发送前停止
+	if e.config.OrderValidationOnly && action != ActionHold {
+		return e.validateOnly(ctx, symbol, action, amount, currentPosition, result)
+	}
+
 	// Execute trade based on action
 	var err error
 	switch action {
@@ -457,8 +646,16 @@ func (e *BinanceExecutor) ExecuteTrade(ctx context.Context, symbol string, actio
 func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, currentPosition *Position, amount float64, result *TradeResult) error {
 	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
 
+	// With hedge-on-reversal the short stays open and the long opened below becomes a hedge leg
+	// 启用反转对冲时保留空仓，下面开的多仓作为对冲腿
+	hedge := currentPosition != nil && currentPosition.Side == "short" && e.hedgeOnReversal()
+	if hedge {
+		e.logger.Info(fmt.Sprintf("🛡️ 保留空仓 %.4f，开多仓对冲", currentPosition.Size))
+		result.Hedge = true
+	}
+
 	// Close short position if exists
-	if currentPosition != nil && currentPosition.Side == "short" {
+	if currentPosition != nil && currentPosition.Side == "short" && !hedge {
 		modeLabel := ""
 		if e.testMode {
 			modeLabel = "🧪 [测试网] "
@@ -469,13 +666,12 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		_, err := e.client.NewCreateOrderService().
+		_, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeBuy).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
-			Do(ctx)
+			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)))
 
 		if err != nil {
 			return err
@@ -495,21 +691,16 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		order, err := e.client.NewCreateOrderService().
-			Symbol(binanceSymbol).
-			Side(futures.SideTypeBuy).
-			PositionSide(positionSide).
-			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", amount)).
-			Do(ctx)
+		// The mark price before the order is the reference for the fill's slippage
+		// 下单前的标记价格作为成交滑点的参考
+		referencePrice, _ := e.getMarkPrice(ctx, binanceSymbol)
 
+		// Place the entry order (market, or maker limit with timeout fallback)
+		// 下开仓单（市价，或带超时回退的 maker 限价单）
+		orderID, filledQty, fillPrice, err := e.placeEntryOrder(ctx, symbol, futures.SideTypeBuy, positionSide, amount)
 		if err != nil {
 			return err
 		}
-
-		// Get fill price from order
-		// 从订单获取成交价格
-		fillPrice, _ := parseFloat(order.AvgPrice)
 		if fillPrice == 0 {
 			// Fallback: query current market price
 			// 回退：查询当前市价
@@ -517,17 +708,26 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 			if err == nil {
 				fillPrice = currentPrice
 			}
+		} else {
+			e.recordEntrySlippage(result, futures.SideTypeBuy, referencePrice, fillPrice)
 		}
 
 		result.Success = true
-		result.OrderID = fmt.Sprintf("%d", order.OrderID)
+		result.OrderID = fmt.Sprintf("%d", orderID)
 		result.Price = fillPrice
+		result.Filled = filledQty
 		result.Message = "订单执行成功"
+		if ladder := e.EntryLadder(binanceSymbol); ladder != nil {
+			result.Message = entryLadderMessage(ladder, filledQty)
+		} else if filledQty < amount-filterEpsilon {
+			result.Message = fmt.Sprintf("订单部分成交: %.4f / %.4f", filledQty, amount)
+			e.logger.Warning(fmt.Sprintf("⚠️ 开仓部分成交: %.4f / %.4f，均价 %.2f", filledQty, amount, fillPrice))
+		}
 		modeLabelSuccess := ""
 		if e.testMode {
 			modeLabelSuccess = "🧪 [测试网] "
 		}
-		e.logger.Success(fmt.Sprintf("%s✅ 订单执行成功，订单ID: %d, 成交价: %.2f", modeLabelSuccess, order.OrderID, fillPrice))
+		e.logger.Success(fmt.Sprintf("%s✅ 订单执行成功，订单ID: %d, 成交价: %.2f", modeLabelSuccess, orderID, fillPrice))
 	} else {
 		result.Message = "已有多仓，不重复开仓（系统保护：防止意外加仓）"
 		e.logger.Warning("⚠️ 已有多仓，不重复开仓")
@@ -539,8 +739,16 @@ func (e *BinanceExecutor) executeBuy(ctx context.Context, symbol string, current
 func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, currentPosition *Position, amount float64, result *TradeResult) error {
 	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
 
+	// With hedge-on-reversal the long stays open and the short opened below becomes a hedge leg
+	// 启用反转对冲时保留多仓，下面开的空仓作为对冲腿
+	hedge := currentPosition != nil && currentPosition.Side == "long" && e.hedgeOnReversal()
+	if hedge {
+		e.logger.Info(fmt.Sprintf("🛡️ 保留多仓 %.4f，开空仓对冲", currentPosition.Size))
+		result.Hedge = true
+	}
+
 	// Close long position if exists
-	if currentPosition != nil && currentPosition.Side == "long" {
+	if currentPosition != nil && currentPosition.Side == "long" && !hedge {
 		modeLabel := ""
 		if e.testMode {
 			modeLabel = "🧪 [测试网] "
@@ -551,13 +759,12 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		_, err := e.client.NewCreateOrderService().
+		_, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
 			Symbol(binanceSymbol).
 			Side(futures.SideTypeSell).
 			PositionSide(positionSide).
 			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
-			Do(ctx)
+			Quantity(fmt.Sprintf("%.4f", currentPosition.Size)))
 
 		if err != nil {
 			return err
@@ -577,21 +784,16 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 			positionSide = futures.PositionSideTypeBoth
 		}
 
-		order, err := e.client.NewCreateOrderService().
-			Symbol(binanceSymbol).
-			Side(futures.SideTypeSell).
-			PositionSide(positionSide).
-			Type(futures.OrderTypeMarket).
-			Quantity(fmt.Sprintf("%.4f", amount)).
-			Do(ctx)
+		// The mark price before the order is the reference for the fill's slippage
+		// 下单前的标记价格作为成交滑点的参考
+		referencePrice, _ := e.getMarkPrice(ctx, binanceSymbol)
 
+		// Place the entry order (market, or maker limit with timeout fallback)
+		// 下开仓单（市价，或带超时回退的 maker 限价单）
+		orderID, filledQty, fillPrice, err := e.placeEntryOrder(ctx, symbol, futures.SideTypeSell, positionSide, amount)
 		if err != nil {
 			return err
 		}
-
-		// Get fill price from order
-		// 从订单获取成交价格
-		fillPrice, _ := parseFloat(order.AvgPrice)
 		if fillPrice == 0 {
 			// Fallback: query current market price
 			// 回退：查询当前市价
@@ -599,17 +801,26 @@ func (e *BinanceExecutor) executeSell(ctx context.Context, symbol string, curren
 			if err == nil {
 				fillPrice = currentPrice
 			}
+		} else {
+			e.recordEntrySlippage(result, futures.SideTypeSell, referencePrice, fillPrice)
 		}
 
 		result.Success = true
-		result.OrderID = fmt.Sprintf("%d", order.OrderID)
+		result.OrderID = fmt.Sprintf("%d", orderID)
 		result.Price = fillPrice
+		result.Filled = filledQty
 		result.Message = "订单执行成功"
+		if ladder := e.EntryLadder(binanceSymbol); ladder != nil {
+			result.Message = entryLadderMessage(ladder, filledQty)
+		} else if filledQty < amount-filterEpsilon {
+			result.Message = fmt.Sprintf("订单部分成交: %.4f / %.4f", filledQty, amount)
+			e.logger.Warning(fmt.Sprintf("⚠️ 开仓部分成交: %.4f / %.4f，均价 %.2f", filledQty, amount, fillPrice))
+		}
 		modeLabelSuccess := ""
 		if e.testMode {
 			modeLabelSuccess = "🧪 [测试网] "
 		}
-		e.logger.Success(fmt.Sprintf("%s✅ 订单执行成功，订单ID: %d, 成交价: %.2f", modeLabelSuccess, order.OrderID, fillPrice))
+		e.logger.Success(fmt.Sprintf("%s✅ 订单执行成功，订单ID: %d, 成交价: %.2f", modeLabelSuccess, orderID, fillPrice))
 	} else {
 		result.Message = "已有空仓，不重复开仓（系统保护：防止意外加仓）"
 		e.logger.Warning("⚠️ 已有空仓，不重复开仓")
@@ -629,8 +840,9 @@ func (e *BinanceExecutor) executeCloseLong(ctx context.Context, symbol string, c
 	if e.testMode {
 		modeLabel = "🧪 [测试网] "
 	}
-	e.logger.Info(fmt.Sprintf("%s📤 平多仓...", modeLabel))
 	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.logger.Info(fmt.Sprintf("%s📤 平多仓...", modeLabel))
 	positionSide := futures.PositionSideTypeLong
 	if e.positionMode == PositionModeOneWay {
 		positionSide = futures.PositionSideTypeBoth
@@ -643,23 +855,40 @@ func (e *BinanceExecutor) executeCloseLong(ctx context.Context, symbol string, c
 		Side(futures.SideTypeSell).
 		PositionSide(positionSide).
 		Type(futures.OrderTypeMarket).
-		Quantity(fmt.Sprintf("%.4f", currentPosition.Size))
+		Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT)
 
-	// Only use ReduceOnly in Hedge mode, not in One-way mode
-	// 只在双向持仓模式使用 ReduceOnly，单向模式不使用
-	if e.positionMode == PositionModeHedge {
+	// Hedge mode closes by position side; one-way mode needs ReduceOnly instead
+	// 双向持仓通过持仓方向平仓；单向持仓需要使用 ReduceOnly
+	if closeReduceOnly(e.positionMode) {
 		orderService = orderService.ReduceOnly(true)
 	}
 
-	order, err := orderService.Do(ctx)
+	order, err := e.createOrder(ctx, binanceSymbol, orderService)
 
 	if err != nil {
 		return err
 	}
 
+	// Report what actually filled, so the close price is the real average and a partial close is visible
+	// 报告实际成交，使平仓价为真实均价，部分平仓也能被发现
+	filledQty, _ := parseFloat(order.ExecutedQuantity)
+	result.Price, _ = parseFloat(order.AvgPrice)
+	if !isOrderDone(order.Status) {
+		filledQty, result.Price, _ = e.waitForOrderFill(ctx, binanceSymbol, order.OrderID, marketOrderFillTimeout)
+	}
+	if filledQty <= 0 {
+		return fmt.Errorf("close order %d did not fill (status %s)", order.OrderID, order.Status)
+	}
+
 	result.Success = true
 	result.OrderID = fmt.Sprintf("%d", order.OrderID)
+	result.Filled = filledQty
 	result.Message = "订单执行成功"
+	if filledQty < currentPosition.Size-filterEpsilon {
+		result.Message = fmt.Sprintf("平仓部分成交: %.4f / %.4f", filledQty, currentPosition.Size)
+		e.logger.Warning(fmt.Sprintf("⚠️ 平仓部分成交: %.4f / %.4f，剩余持仓将在下次分析时处理", filledQty, currentPosition.Size))
+	}
 	modeLabelSuccess := ""
 	if e.testMode {
 		modeLabelSuccess = "🧪 [测试网] "
@@ -679,8 +908,9 @@ func (e *BinanceExecutor) executeCloseShort(ctx context.Context, symbol string,
 	if e.testMode {
 		modeLabel = "🧪 [测试网] "
 	}
-	e.logger.Info(fmt.Sprintf("%s📤 平空仓...", modeLabel))
 	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.logger.Info(fmt.Sprintf("%s📤 平空仓...", modeLabel))
 	positionSide := futures.PositionSideTypeShort
 	if e.positionMode == PositionModeOneWay {
 		positionSide = futures.PositionSideTypeBoth
@@ -693,23 +923,40 @@ func (e *BinanceExecutor) executeCloseShort(ctx context.Context, symbol string,
 		Side(futures.SideTypeBuy).
 		PositionSide(positionSide).
 		Type(futures.OrderTypeMarket).
-		Quantity(fmt.Sprintf("%.4f", currentPosition.Size))
+		Quantity(fmt.Sprintf("%.4f", currentPosition.Size)).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT)
 
-	// Only use ReduceOnly in Hedge mode, not in One-way mode
-	// 只在双向持仓模式使用 ReduceOnly，单向模式不使用
-	if e.positionMode == PositionModeHedge {
+	// Hedge mode closes by position side; one-way mode needs ReduceOnly instead
+	// 双向持仓通过持仓方向平仓；单向持仓需要使用 ReduceOnly
+	if closeReduceOnly(e.positionMode) {
 		orderService = orderService.ReduceOnly(true)
 	}
 
-	order, err := orderService.Do(ctx)
+	order, err := e.createOrder(ctx, binanceSymbol, orderService)
 
 	if err != nil {
 		return err
 	}
 
+	// Report what actually filled, so the close price is the real average and a partial close is visible
+	// 报告实际成交，使平仓价为真实均价，部分平仓也能被发现
+	filledQty, _ := parseFloat(order.ExecutedQuantity)
+	result.Price, _ = parseFloat(order.AvgPrice)
+	if !isOrderDone(order.Status) {
+		filledQty, result.Price, _ = e.waitForOrderFill(ctx, binanceSymbol, order.OrderID, marketOrderFillTimeout)
+	}
+	if filledQty <= 0 {
+		return fmt.Errorf("close order %d did not fill (status %s)", order.OrderID, order.Status)
+	}
+
 	result.Success = true
 	result.OrderID = fmt.Sprintf("%d", order.OrderID)
+	result.Filled = filledQty
 	result.Message = "订单执行成功"
+	if filledQty < currentPosition.Size-filterEpsilon {
+		result.Message = fmt.Sprintf("平仓部分成交: %.4f / %.4f", filledQty, currentPosition.Size)
+		e.logger.Warning(fmt.Sprintf("⚠️ 平仓部分成交: %.4f / %.4f，剩余持仓将在下次分析时处理", filledQty, currentPosition.Size))
+	}
 	modeLabelSuccess := ""
 	if e.testMode {
 		modeLabelSuccess = "🧪 [测试网] "
@@ -725,7 +972,7 @@ func (e *BinanceExecutor) GetAccountSummary(ctx context.Context) string {
 
 	// Get account balance
 	// 获取账户余额
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Sprintf("**获取账户信息失败**: %v", err)
 	}
@@ -782,6 +1029,11 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 	var managedPos *Position // Position from StopLossManager (has HighestPrice)
 
 	if stopLossManager != nil {
+		// Refresh accumulated funding so the report reflects the latest settlements
+		// 刷新累计资金费，使报告反映最新的结算
+		if _, err := stopLossManager.SyncFunding(ctx, symbol, false); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  %v", err))
+		}
 		managedPos = stopLossManager.GetPosition(symbol)
 	}
 
@@ -796,6 +1048,8 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 		position.CurrentPrice = managedPos.CurrentPrice
 		position.InitialStopLoss = managedPos.InitialStopLoss
 		position.CurrentStopLoss = managedPos.CurrentStopLoss
+		position.FundingFees = managedPos.FundingFees
+		position.LastFundingSync = managedPos.LastFundingSync
 	} else if position == nil && managedPos != nil {
 		// If Binance API failed, use managed position
 		// 如果币安 API 失败，使用托管持仓
@@ -810,10 +1064,9 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 
 		// Get current price
 		// 获取当前价格
-		ticker, _ := e.client.NewListPriceChangeStatsService().Symbol(e.config.GetBinanceSymbolFor(symbol)).Do(ctx)
 		currentPrice := position.EntryPrice
-		if len(ticker) > 0 {
-			currentPrice, _ = parseFloat(ticker[0].LastPrice)
+		if price, err := e.GetCurrentPrice(ctx, symbol); err == nil {
+			currentPrice = price
 		}
 
 		// Calculate ROE (Return on Equity) using Binance official formula
@@ -830,6 +1083,9 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 		summary.WriteString(fmt.Sprintf("- 数量: %.4f\n", position.Size))
 		summary.WriteString(fmt.Sprintf("- 开仓价格: $%.2f\n", position.EntryPrice))
 		summary.WriteString(fmt.Sprintf("- 杠杆倍数: %dx\n", position.Leverage))
+		if position.MarginType != "" {
+			summary.WriteString(fmt.Sprintf("- 保证金模式: %s\n", position.MarginType.Label()))
+		}
 		summary.WriteString(fmt.Sprintf("- 当前价格: $%.2f\n", currentPrice))
 
 		// Display highest/lowest price since position entry
@@ -855,6 +1111,9 @@ func (e *BinanceExecutor) GetPositionOnly(ctx context.Context, symbol string, st
 		}
 
 		summary.WriteString(fmt.Sprintf("- 未实现盈亏: %+.2f USDT (%+.2f%%)\n", position.UnrealizedPnL, pnlPct))
+		if !position.LastFundingSync.IsZero() {
+			summary.WriteString(fmt.Sprintf("- 累计资金费: %+.4f USDT（含资金费未实现盈亏: %+.2f USDT）\n", position.FundingFees, position.NetUnrealizedPnL()))
+		}
 
 		// Display stop-loss information if available
 		// 显示止损信息（如果可用）
@@ -885,7 +1144,7 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	var summary strings.Builder
 
 	// Get account balance
-	account, err := e.client.NewGetAccountService().Do(ctx)
+	account, err := e.GetAccountInfo(ctx)
 	if err != nil {
 		return fmt.Sprintf("**获取账户信息失败**: %v", err)
 	}
@@ -932,6 +1191,11 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	var managedPos *Position // Position from StopLossManager (has HighestPrice)
 
 	if stopLossManager != nil {
+		// Refresh accumulated funding so the report reflects the latest settlements
+		// 刷新累计资金费，使报告反映最新的结算
+		if _, err := stopLossManager.SyncFunding(ctx, symbol, false); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️  %v", err))
+		}
 		managedPos = stopLossManager.GetPosition(symbol)
 	}
 
@@ -946,6 +1210,8 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 		position.CurrentPrice = managedPos.CurrentPrice
 		position.InitialStopLoss = managedPos.InitialStopLoss
 		position.CurrentStopLoss = managedPos.CurrentStopLoss
+		position.FundingFees = managedPos.FundingFees
+		position.LastFundingSync = managedPos.LastFundingSync
 	} else if position == nil && managedPos != nil {
 		// If Binance API failed, use managed position
 		// 如果币安 API 失败，使用托管持仓
@@ -959,10 +1225,9 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 		}
 
 		// Get current price
-		ticker, _ := e.client.NewListPriceChangeStatsService().Symbol(e.config.GetBinanceSymbolFor(symbol)).Do(ctx)
 		currentPrice := position.EntryPrice
-		if len(ticker) > 0 {
-			currentPrice, _ = parseFloat(ticker[0].LastPrice)
+		if price, err := e.GetCurrentPrice(ctx, symbol); err == nil {
+			currentPrice = price
 		}
 
 		// Calculate ROE (Return on Equity) using Binance official formula
@@ -986,6 +1251,9 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 		summary.WriteString(fmt.Sprintf("- 数量: %.4f\n", position.Size))
 		summary.WriteString(fmt.Sprintf("- 开仓价格: $%.2f\n", position.EntryPrice))
 		summary.WriteString(fmt.Sprintf("- 杠杆倍数: %dx\n", position.Leverage))
+		if position.MarginType != "" {
+			summary.WriteString(fmt.Sprintf("- 保证金模式: %s\n", position.MarginType.Label()))
+		}
 		summary.WriteString(fmt.Sprintf("- 当前价格: $%.2f\n", currentPrice))
 
 		// Display highest/lowest price since position entry
@@ -1017,6 +1285,9 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 		}
 
 		summary.WriteString(fmt.Sprintf("- 未实现盈亏: %+.2f USDT (%+.2f%%)\n", position.UnrealizedPnL, pnlPct))
+		if !position.LastFundingSync.IsZero() {
+			summary.WriteString(fmt.Sprintf("- 累计资金费: %+.4f USDT（含资金费未实现盈亏: %+.2f USDT）\n", position.FundingFees, position.NetUnrealizedPnL()))
+		}
 
 		// Display stop-loss information if available
 		// 显示止损信息（如果可用）
@@ -1048,38 +1319,12 @@ func (e *BinanceExecutor) GetPositionSummary(ctx context.Context, symbol string,
 	return summary.String()
 }
 
-// withRetry executes a function with exponential backoff retry
-func (e *BinanceExecutor) withRetry(fn func() error) error {
-	b := &backoff.Backoff{
-		Min:    2 * time.Second,
-		Max:    10 * time.Second,
-		Factor: 2,
-		Jitter: true,
-	}
-
-	maxRetries := 3
-	for i := 0; i <= maxRetries; i++ {
-		err := fn()
-		if err == nil {
-			return nil
-		}
-
-		if i == maxRetries {
-			return fmt.Errorf("max retries reached: %w", err)
-		}
-
-		duration := b.Duration()
-		e.logger.Warning(fmt.Sprintf("操作失败 (尝试 %d/%d): %v，等待 %.1f 秒后重试...",
-			i+1, maxRetries, err, duration.Seconds()))
-		time.Sleep(duration)
-	}
-
-	return nil
-}
-
 // GetAccountInfo gets account information from Binance
 // GetAccountInfo 从币安获取账户信息
 func (e *BinanceExecutor) GetAccountInfo(ctx context.Context) (*futures.Account, error) {
+	if e.paper != nil {
+		return e.paper.Account(), nil
+	}
 	return e.client.NewGetAccountService().Do(ctx)
 }
 
@@ -1110,10 +1355,18 @@ func (e *BinanceExecutor) GetBalance(ctx context.Context) (float64, error) {
 // GetCurrentPrice 返回交易对的当前市场价格
 func (e *BinanceExecutor) GetCurrentPrice(ctx context.Context, symbol string) (float64, error) {
 	binanceSymbol := strings.ReplaceAll(symbol, "/", "")
+	if price, ok := e.cachedPrice(binanceSymbol); ok {
+		return price, nil
+	}
 
 	// Get latest price from ticker
 	// 从行情数据获取最新价格
-	prices, err := e.client.NewListPricesService().Symbol(binanceSymbol).Do(ctx)
+	var prices []*futures.SymbolPrice
+	err := e.withRetry(func() error {
+		var err error
+		prices, err = e.client.NewListPricesService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get price: %w", err)
 	}
@@ -1126,10 +1379,21 @@ func (e *BinanceExecutor) GetCurrentPrice(ctx context.Context, symbol string) (f
 	if err != nil {
 		return 0, fmt.Errorf("failed to parse price: %w", err)
 	}
+	e.observePrice(binanceSymbol, price)
 
 	return price, nil
 }
 
+// observePrice records a REST price in the shared cache, or feeds it to the paper executor when there is no cache
+// observePrice 将 REST 获取的价格写入共享缓存；没有缓存时直接提供给模拟盘
+func (e *BinanceExecutor) observePrice(binanceSymbol string, price float64) {
+	if e.prices != nil {
+		e.prices.Publish(binanceSymbol, price, time.Now())
+		return
+	}
+	e.paper.OnPrice(binanceSymbol, price)
+}
+
 // Helper functions
 func parseFloat(s string) (float64, error) {
 	var f float64
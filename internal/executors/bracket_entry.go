@@ -0,0 +1,145 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// useBracketEntry reports whether a new position's first take-profit should rest on the exchange next to its stop
+// useBracketEntry 判断新持仓的第一级止盈是否应与止损一起挂在交易所
+func (sm *StopLossManager) useBracketEntry(pos *Position) bool {
+	// The paper executor only simulates stop orders, and a native trailing stop already owns the exit
+	// 模拟盘只模拟止损单，原生追踪止损已接管离场
+	if !sm.config.BracketEntryEnabled || sm.executor.paper != nil || sm.useNativeTrailing(pos) || !sm.exchangeStop(pos.Symbol) {
+		return false
+	}
+	return sm.executor.Capabilities(pos.Symbol).TakeProfitMarket
+}
+
+// bracketTakeProfitLevel returns the first pending take-profit level and the quantity its order should close
+// bracketTakeProfitLevel 返回第一个待执行的止盈级别及其订单应平仓的数量
+func bracketTakeProfitLevel(pos *Position) (*TakeProfitLevel, float64) {
+	if pos.TakeProfitConfig == nil || !pos.TakeProfitConfig.Enabled {
+		return nil, 0
+	}
+	for _, level := range pos.TakeProfitConfig.Levels {
+		if !level.Executed {
+			return level, pos.Quantity * level.Percentage
+		}
+	}
+	return nil, 0
+}
+
+// placeBracketEntry places the initial stop-loss and the first take-profit order concurrently
+// placeBracketEntry 并发下达初始止损单和第一级止盈单
+//
+// The stop decides the outcome: if it fails the take-profit is cancelled and the error returned, so the
+// caller's retry path handles it as before. A failed take-profit only leaves level 1 to local monitoring.
+// 止损单决定结果：止损失败时撤销止盈单并返回错误，由调用方原有的重试流程处理；止盈单失败只会让第一级回到本地监控。
+func (sm *StopLossManager) placeBracketEntry(ctx context.Context, pos *Position) error {
+	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	level, quantity := bracketTakeProfitLevel(pos)
+	if level == nil {
+		return sm.placeStopLossOrder(ctx, pos, pos.InitialStopLoss)
+	}
+
+	// A level too small to trade stays with local monitoring
+	// 数量低于最小下单量的级别仍由本地监控
+	quantity, ok := floorQuantity(binanceSymbol, quantity)
+	if !ok {
+		sm.logger.Info(fmt.Sprintf("ℹ️ 【%s】止盈级别 %d 数量低于最小下单量，仅下止损单", pos.Symbol, level.Level))
+		return sm.placeStopLossOrder(ctx, pos, pos.InitialStopLoss)
+	}
+
+	var tpOrderID int64
+	var tpErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tpOrderID, tpErr = sm.executor.placeBracketLeg(ctx, binanceSymbol, pos.Side, futures.OrderTypeTakeProfitMarket, level.TargetPrice, quantity)
+	}()
+	stopErr := sm.placeStopLossOrder(ctx, pos, pos.InitialStopLoss)
+	wg.Wait()
+
+	if stopErr != nil {
+		if tpErr == nil {
+			if err := sm.executor.cancelOrderByID(ctx, binanceSymbol, tpOrderID); err != nil {
+				sm.logger.Error(fmt.Sprintf("❌【%s】止损失败后撤销止盈单 %d 失败，请手动处理: %v", pos.Symbol, tpOrderID, err))
+			}
+		}
+		return stopErr
+	}
+	if tpErr != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】括号单止盈下单失败，第一级止盈改为本地监控: %v", pos.Symbol, tpErr))
+		return nil
+	}
+
+	level.OrderID = tpOrderID
+	sm.saveTakeProfitLevels(pos)
+	sm.logger.Success(fmt.Sprintf("【%s】括号单开仓保护已就位: 止损 %.2f / 止盈级别 %d %.4f @ %.2f (订单ID: %d)",
+		pos.Symbol, pos.InitialStopLoss, level.Level, quantity, level.TargetPrice, tpOrderID))
+	return nil
+}
+
+// syncTakeProfitOrder checks the exchange order of a level; resting means the exchange still owns it
+// syncTakeProfitOrder 检查级别的交易所订单；resting 表示订单仍由交易所负责
+//
+// A filled order returns its average price and executed quantity. An order that can no longer fill is
+// forgotten, handing the level back to local monitoring; any part of it that did fill is taken off the position.
+// 已成交的订单返回成交均价和成交数量；不可能再成交的订单会被清除，该级别交还给本地监控，其中已成交的部分从持仓中扣除。
+func (tm *TakeProfitManager) syncTakeProfitOrder(ctx context.Context, pos *Position, level *TakeProfitLevel) (price, quantity float64, resting bool, err error) {
+	order, err := tm.executor.getOrder(ctx, tm.config.GetBinanceSymbolFor(pos.Symbol), level.OrderID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	price, _ = parseFloat(order.AvgPrice)
+	if price == 0 {
+		price = level.TargetPrice
+	}
+	executed, _ := parseFloat(order.ExecutedQuantity)
+
+	switch {
+	case order.Status == futures.OrderStatusTypeFilled:
+		return price, executed, false, nil
+	case isOrderCancelled(order.Status):
+		tm.logger.Warning(fmt.Sprintf("⚠️【%s】止盈级别 %d 的交易所订单已失效 (%s)，改为本地监控", pos.Symbol, level.Level, order.Status))
+		if executed > 0 {
+			finishReduce(tm.logger, pos, executed, fmt.Sprintf("止盈级别 %d 失效前部分成交", level.Level))
+			tm.logger.Info(fmt.Sprintf("【%s】失效前已部分成交 %.4f @ $%.2f，剩余仓位: %.4f", pos.Symbol, executed, price, pos.Quantity))
+		}
+		level.OrderID = 0
+		return 0, 0, false, nil
+	}
+	return 0, 0, true, nil
+}
+
+// releaseTakeProfitOrder cancels a level's resting exchange order so the level is monitored locally again
+// releaseTakeProfitOrder 撤销级别挂在交易所的止盈单，使其重新由本地监控
+func (sm *StopLossManager) releaseTakeProfitOrder(ctx context.Context, pos *Position, level *TakeProfitLevel) error {
+	if level.OrderID == 0 || level.Executed {
+		return nil
+	}
+	if err := sm.executor.cancelOrderByID(ctx, sm.config.GetBinanceSymbolFor(pos.Symbol), level.OrderID); err != nil {
+		return fmt.Errorf("撤销止盈单 %d 失败: %w", level.OrderID, err)
+	}
+	level.OrderID = 0
+	return nil
+}
+
+// cancelTakeProfitOrders cancels every resting take-profit order of a position
+// cancelTakeProfitOrders 撤销持仓所有挂在交易所的止盈单
+func (sm *StopLossManager) cancelTakeProfitOrders(ctx context.Context, pos *Position) {
+	if pos.TakeProfitConfig == nil {
+		return
+	}
+	for _, level := range pos.TakeProfitConfig.Levels {
+		if err := sm.releaseTakeProfitOrder(ctx, pos, level); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  %s 止盈级别 %d: %v", pos.Symbol, level.Level, err))
+		}
+	}
+}
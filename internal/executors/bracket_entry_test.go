@@ -0,0 +1,201 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// bracketExchange serves the order endpoints a bracket entry uses and can reject one order type
+// bracketExchange 提供括号单开仓用到的下单接口，可拒绝某一种订单类型
+type bracketExchange struct {
+	mu        sync.Mutex
+	reject    string           // 拒绝的订单类型 / Order type to reject
+	nextID    int64            // 下一个订单 ID / Next order ID
+	placed    map[int64]string // 订单 ID → 订单类型 / Order ID → order type
+	cancelled []int64          // 已撤销的订单 / Cancelled orders
+	query     string           // GET /fapi/v1/order 的响应 / Response to GET /fapi/v1/order
+}
+
+func (x *bracketExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	// ParseForm skips DELETE bodies, so the signed parameters are read by hand
+	// ParseForm 不解析 DELETE 请求体，因此手动读取签名参数
+	raw, _ := io.ReadAll(r.Body)
+	r.Form, _ = url.ParseQuery(string(raw))
+	for k, v := range r.URL.Query() {
+		r.Form[k] = v
+	}
+	switch {
+	case r.URL.Path == "/fapi/v2/ticker/price":
+		fmt.Fprint(w, `{"symbol":"BTCUSDT","price":"100","time":0}`)
+	case r.URL.Path == "/fapi/v1/order" && r.Method == http.MethodPost:
+		orderType := r.Form.Get("type")
+		if orderType == x.reject {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"code":-2021,"msg":"Order would immediately trigger."}`)
+			return
+		}
+		x.nextID++
+		x.placed[x.nextID] = orderType
+		fmt.Fprintf(w, `{"symbol":"BTCUSDT","orderId":%d,"status":"NEW","type":"%s"}`, x.nextID, orderType)
+	case r.URL.Path == "/fapi/v1/order" && r.Method == http.MethodDelete:
+		var id int64
+		fmt.Sscanf(r.Form.Get("orderId"), "%d", &id)
+		x.cancelled = append(x.cancelled, id)
+		fmt.Fprintf(w, `{"symbol":"BTCUSDT","orderId":%d,"status":"CANCELED"}`, id)
+	case r.URL.Path == "/fapi/v1/order":
+		fmt.Fprint(w, x.query)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"code":-5000,"msg":"unexpected path %s"}`, r.URL.Path)
+	}
+}
+
+func newBracketTestManager(t *testing.T, exchange *bracketExchange) *StopLossManager {
+	srv := httptest.NewServer(exchange)
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{CryptoSymbols: []string{"BTC/USDT"}, BracketEntryEnabled: true}
+	executor := NewBinanceExecutor(cfg, logger.NewColorLogger(false))
+	executor.client.BaseURL = srv.URL
+	executor.positionMode = PositionModeOneWay
+	return NewStopLossManager(cfg, executor, logger.NewColorLogger(false), nil)
+}
+
+func newBracketTestPosition() *Position {
+	return &Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 95, CurrentStopLoss: 95,
+		TakeProfitConfig: &TakeProfitConfig{Enabled: true, Levels: []*TakeProfitLevel{
+			{Level: 1, Percentage: 0.3, TargetPrice: 105, NewStopLoss: 100},
+			{Level: 2, Percentage: 0.7, TargetPrice: 110, NewStopLoss: 105},
+		}}}
+}
+
+func TestBracketTakeProfitLevel(t *testing.T) {
+	pos := &Position{Quantity: 2, TakeProfitConfig: &TakeProfitConfig{Enabled: true, Levels: []*TakeProfitLevel{
+		{Level: 1, Percentage: 0.3, Executed: true},
+		{Level: 2, Percentage: 0.3},
+		{Level: 3, Percentage: 0.4},
+	}}}
+
+	level, quantity := bracketTakeProfitLevel(pos)
+	if level == nil || level.Level != 2 || quantity != 0.6 {
+		t.Fatalf("Expected level 2 closing 0.6, got %+v / %g", level, quantity)
+	}
+
+	pos.TakeProfitConfig.Enabled = false
+	if level, _ := bracketTakeProfitLevel(pos); level != nil {
+		t.Errorf("Expected no level when take-profit is disabled, got %+v", level)
+	}
+
+	pos.TakeProfitConfig = nil
+	if level, _ := bracketTakeProfitLevel(pos); level != nil {
+		t.Errorf("Expected no level without take-profit config, got %+v", level)
+	}
+}
+
+func TestPlaceBracketEntry(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	pos := newBracketTestPosition()
+
+	if err := sm.placeBracketEntry(context.Background(), pos); err != nil {
+		t.Fatalf("placeBracketEntry failed: %v", err)
+	}
+	level := pos.TakeProfitConfig.Levels[0]
+	if pos.StopLossOrderID == "" || level.OrderID == 0 {
+		t.Fatalf("Expected both legs placed, got stop %q / take-profit %d", pos.StopLossOrderID, level.OrderID)
+	}
+	if exchange.placed[level.OrderID] != "TAKE_PROFIT_MARKET" {
+		t.Errorf("Expected a TAKE_PROFIT_MARKET leg, got %q", exchange.placed[level.OrderID])
+	}
+}
+
+func TestPlaceBracketEntryRollsBackOnStopFailure(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string), reject: "STOP_MARKET"}
+	sm := newBracketTestManager(t, exchange)
+	pos := newBracketTestPosition()
+
+	if err := sm.placeBracketEntry(context.Background(), pos); err == nil {
+		t.Fatal("Expected the rejected stop to fail the bracket")
+	}
+	if len(exchange.placed) != 1 || len(exchange.cancelled) != 1 || exchange.placed[exchange.cancelled[0]] != "TAKE_PROFIT_MARKET" {
+		t.Errorf("Expected the take-profit leg to be cancelled, placed %v cancelled %v", exchange.placed, exchange.cancelled)
+	}
+	if pos.TakeProfitConfig.Levels[0].OrderID != 0 {
+		t.Error("Expected no take-profit order recorded after rollback")
+	}
+}
+
+func TestPlaceBracketEntryKeepsStopOnTakeProfitFailure(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string), reject: "TAKE_PROFIT_MARKET"}
+	sm := newBracketTestManager(t, exchange)
+	pos := newBracketTestPosition()
+
+	if err := sm.placeBracketEntry(context.Background(), pos); err != nil {
+		t.Fatalf("Expected the stop alone to succeed, got %v", err)
+	}
+	if pos.StopLossOrderID == "" || len(exchange.cancelled) != 0 {
+		t.Errorf("Expected the stop kept, got stop %q cancelled %v", pos.StopLossOrderID, exchange.cancelled)
+	}
+	if pos.TakeProfitConfig.Levels[0].OrderID != 0 {
+		t.Error("Expected level 1 to fall back to local monitoring")
+	}
+}
+
+func TestMonitorAndExecuteSyncsExchangeTakeProfit(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	pos := newBracketTestPosition()
+	level := pos.TakeProfitConfig.Levels[0]
+	level.OrderID = 7
+
+	// Still resting: nothing is executed locally even though the price is past the target
+	// 仍在挂单：即使价格越过目标价也不在本地执行
+	exchange.query = `{"symbol":"BTCUSDT","orderId":7,"status":"NEW","executedQty":"0","avgPrice":"0"}`
+	if n, err := sm.takeProfitMgr.MonitorAndExecute(context.Background(), pos, 106); err != nil || n != 0 {
+		t.Fatalf("Expected no execution while resting, got %d (%v)", n, err)
+	}
+	if pos.Quantity != 1 {
+		t.Fatalf("Expected quantity unchanged, got %g", pos.Quantity)
+	}
+
+	// Filled on the exchange: the level completes at the fill price without a second close
+	// 交易所已成交：按成交价完成该级别，不再重复平仓
+	exchange.query = `{"symbol":"BTCUSDT","orderId":7,"status":"FILLED","executedQty":"0.3","avgPrice":"105.2"}`
+	if n, err := sm.takeProfitMgr.MonitorAndExecute(context.Background(), pos, 104); err != nil || n != 1 {
+		t.Fatalf("Expected the exchange fill to execute level 1, got %d (%v)", n, err)
+	}
+	if !level.Executed || level.ExecutedPrice != 105.2 || pos.Quantity < 0.7-1e-9 || pos.Quantity > 0.7+1e-9 {
+		t.Errorf("Unexpected state after fill: level %+v quantity %g", level, pos.Quantity)
+	}
+	if len(exchange.placed) != 0 {
+		t.Errorf("Expected no local close order, got %v", exchange.placed)
+	}
+}
+
+func TestSyncTakeProfitOrderCreditsPartialFillBeforeCancel(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	pos := newBracketTestPosition()
+	level := pos.TakeProfitConfig.Levels[0]
+	level.OrderID = 7
+
+	exchange.query = `{"symbol":"BTCUSDT","orderId":7,"status":"EXPIRED","executedQty":"0.1","avgPrice":"105"}`
+	_, quantity, resting, err := sm.takeProfitMgr.syncTakeProfitOrder(context.Background(), pos, level)
+	if err != nil || resting || quantity != 0 {
+		t.Fatalf("Expected the expired order to be released, got quantity %g resting %v (%v)", quantity, resting, err)
+	}
+	if level.OrderID != 0 || pos.Quantity < 0.9-1e-9 || pos.Quantity > 0.9+1e-9 {
+		t.Errorf("Expected the partial fill credited and the order forgotten, got order %d quantity %g", level.OrderID, pos.Quantity)
+	}
+}
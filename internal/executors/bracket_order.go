@@ -0,0 +1,302 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Bracket statuses
+// 括号单状态
+const (
+	BracketStatusActive           = "active"             // 两条腿都在挂单 / Both legs resting
+	BracketStatusStopFilled       = "stop_filled"        // 止损腿成交，止盈腿已撤 / Stop leg filled, take-profit leg cancelled
+	BracketStatusTakeProfitFilled = "take_profit_filled" // 止盈腿成交，止损腿已撤 / Take-profit leg filled, stop leg cancelled
+	BracketStatusCancelled        = "cancelled"          // 两条腿都已撤销 / Both legs cancelled
+)
+
+// BracketOrder links a stop-loss and a take-profit order so that exactly one of them survives
+// BracketOrder 将止损单与止盈单关联，确保最终只有一个成交
+//
+// Binance futures has no native OCO, so the link is kept locally: when one leg fills,
+// SyncBracket cancels the other. Both legs are reduce-only, so even if they trigger at
+// the same moment the position cannot be over-closed.
+// 币安合约没有原生 OCO，因此关联关系在本地维护：一条腿成交后 SyncBracket 撤销另一条。
+// 两条腿都是只减仓单，即使同时触发也不会超额平仓。
+type BracketOrder struct {
+	Symbol            string    // 交易对 / Trading pair
+	Side              string    // 持仓方向 long/short / Position side
+	Quantity          float64   // 数量 / Quantity
+	StopLossPrice     float64   // 止损触发价 / Stop-loss trigger price
+	TakeProfitPrice   float64   // 止盈触发价 / Take-profit trigger price
+	StopLossOrderID   int64     // 止损单 ID / Stop-loss order ID
+	TakeProfitOrderID int64     // 止盈单 ID / Take-profit order ID
+	Status            string    // 状态 / Status
+	CreatedAt         time.Time // 创建时间 / Creation time
+}
+
+// bracketRegistry holds the active brackets per symbol
+// bracketRegistry 保存每个交易对的活跃括号单
+type bracketRegistry struct {
+	brackets map[string]*BracketOrder
+	mu       sync.Mutex
+}
+
+// validateBracketPrices checks that stop and take-profit sit on the correct sides of the reference price
+// validateBracketPrices 检查止损与止盈价格是否位于参考价的正确一侧
+func validateBracketPrices(side string, referencePrice, stopPrice, takeProfitPrice float64) error {
+	if stopPrice <= 0 || takeProfitPrice <= 0 {
+		return fmt.Errorf("stop-loss and take-profit prices must be positive")
+	}
+	if side == "long" {
+		if stopPrice >= referencePrice || takeProfitPrice <= referencePrice {
+			return fmt.Errorf("long bracket requires stop %.2f < price %.2f < take-profit %.2f",
+				stopPrice, referencePrice, takeProfitPrice)
+		}
+		return nil
+	}
+	if stopPrice <= referencePrice || takeProfitPrice >= referencePrice {
+		return fmt.Errorf("short bracket requires take-profit %.2f < price %.2f < stop %.2f",
+			takeProfitPrice, referencePrice, stopPrice)
+	}
+	return nil
+}
+
+// PlaceBracket places a linked stop-loss / take-profit pair for an open position
+// PlaceBracket 为已开持仓下达关联的止损/止盈单对
+//
+// The placement is all-or-nothing: if the second leg fails, the first leg is cancelled
+// and an error is returned, so the caller never ends up with an unlinked half bracket.
+// Any existing bracket for the symbol is cancelled first.
+// 下单是全有或全无的：第二条腿失败时会撤销第一条腿并返回错误，调用方不会留下未关联的半个括号单。
+// 该交易对已有的括号单会先被撤销。
+func (e *BinanceExecutor) PlaceBracket(ctx context.Context, symbol, side string, quantity, stopPrice, takeProfitPrice float64) (*BracketOrder, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	// Refuse before placing anything if the symbol cannot carry both conditional legs
+	// 若交易对无法承载两条条件单，则在下单前直接拒绝
+	if caps := e.Capabilities(symbol); !caps.StopMarket || !caps.TakeProfitMarket {
+		return nil, fmt.Errorf("symbol %s does not support STOP_MARKET/TAKE_PROFIT_MARKET orders", binanceSymbol)
+	}
+
+	currentPrice, err := e.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price for bracket: %w", err)
+	}
+	if err := validateBracketPrices(side, currentPrice, stopPrice, takeProfitPrice); err != nil {
+		return nil, err
+	}
+
+	if existing := e.GetBracket(symbol); existing != nil && existing.Status == BracketStatusActive {
+		if err := e.CancelBracket(ctx, symbol); err != nil {
+			return nil, fmt.Errorf("failed to cancel existing bracket: %w", err)
+		}
+	}
+
+	stopOrderID, err := e.placeBracketLeg(ctx, binanceSymbol, side, futures.OrderTypeStopMarket, stopPrice, quantity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to place bracket stop-loss: %w", err)
+	}
+
+	tpOrderID, err := e.placeBracketLeg(ctx, binanceSymbol, side, futures.OrderTypeTakeProfitMarket, takeProfitPrice, quantity)
+	if err != nil {
+		// Roll back the stop leg so the bracket stays atomic
+		// 回滚止损腿，保持括号单的原子性
+		if cancelErr := e.cancelOrderByID(ctx, binanceSymbol, stopOrderID); cancelErr != nil {
+			e.logger.Error(fmt.Sprintf("❌【%s】括号单回滚失败，止损单 %d 仍在挂单: %v", symbol, stopOrderID, cancelErr))
+		}
+		return nil, fmt.Errorf("failed to place bracket take-profit: %w", err)
+	}
+
+	bracket := &BracketOrder{
+		Symbol:            binanceSymbol,
+		Side:              side,
+		Quantity:          quantity,
+		StopLossPrice:     stopPrice,
+		TakeProfitPrice:   takeProfitPrice,
+		StopLossOrderID:   stopOrderID,
+		TakeProfitOrderID: tpOrderID,
+		Status:            BracketStatusActive,
+		CreatedAt:         time.Now(),
+	}
+
+	e.brackets.mu.Lock()
+	e.brackets.brackets[binanceSymbol] = bracket
+	e.brackets.mu.Unlock()
+
+	modeLabel := ""
+	if e.testMode {
+		modeLabel = "🧪 [测试网] "
+	}
+	e.logger.Success(fmt.Sprintf("%s【%s】括号单已下达: 止损 %.2f (ID: %d) / 止盈 %.2f (ID: %d)",
+		modeLabel, symbol, stopPrice, stopOrderID, takeProfitPrice, tpOrderID))
+
+	return bracket, nil
+}
+
+// GetBracket returns the bracket registered for a symbol, or nil
+// GetBracket 返回交易对登记的括号单，没有则返回 nil
+func (e *BinanceExecutor) GetBracket(symbol string) *BracketOrder {
+	e.brackets.mu.Lock()
+	defer e.brackets.mu.Unlock()
+	return e.brackets.brackets[e.config.GetBinanceSymbolFor(symbol)]
+}
+
+// CancelBracket cancels both legs of a symbol's bracket
+// CancelBracket 撤销交易对括号单的两条腿
+func (e *BinanceExecutor) CancelBracket(ctx context.Context, symbol string) error {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.brackets.mu.Lock()
+	defer e.brackets.mu.Unlock()
+
+	bracket, ok := e.brackets.brackets[binanceSymbol]
+	if !ok || bracket.Status != BracketStatusActive {
+		return nil
+	}
+
+	stopErr := e.cancelOrderByID(ctx, binanceSymbol, bracket.StopLossOrderID)
+	tpErr := e.cancelOrderByID(ctx, binanceSymbol, bracket.TakeProfitOrderID)
+	if stopErr != nil || tpErr != nil {
+		return fmt.Errorf("failed to cancel bracket (stop: %v, take-profit: %v)", stopErr, tpErr)
+	}
+
+	bracket.Status = BracketStatusCancelled
+	delete(e.brackets.brackets, binanceSymbol)
+	return nil
+}
+
+// SyncBracket checks both legs and cancels the survivor once one leg has filled
+// SyncBracket 检查两条腿，一旦有一条成交就撤销另一条
+func (e *BinanceExecutor) SyncBracket(ctx context.Context, symbol string) (*BracketOrder, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.brackets.mu.Lock()
+	defer e.brackets.mu.Unlock()
+
+	bracket, ok := e.brackets.brackets[binanceSymbol]
+	if !ok || bracket.Status != BracketStatusActive {
+		return bracket, nil
+	}
+
+	stopStatus, err := e.getOrderStatus(ctx, binanceSymbol, bracket.StopLossOrderID)
+	if err != nil {
+		return bracket, err
+	}
+	tpStatus, err := e.getOrderStatus(ctx, binanceSymbol, bracket.TakeProfitOrderID)
+	if err != nil {
+		return bracket, err
+	}
+
+	switch {
+	case stopStatus == futures.OrderStatusTypeFilled:
+		if err := e.cancelOrderByID(ctx, binanceSymbol, bracket.TakeProfitOrderID); err != nil && tpStatus != futures.OrderStatusTypeFilled {
+			return bracket, fmt.Errorf("failed to cancel take-profit leg: %w", err)
+		}
+		bracket.Status = BracketStatusStopFilled
+		e.logger.Warning(fmt.Sprintf("🔔【%s】括号单止损腿成交 @ %.2f，已撤销止盈腿", binanceSymbol, bracket.StopLossPrice))
+	case tpStatus == futures.OrderStatusTypeFilled:
+		if err := e.cancelOrderByID(ctx, binanceSymbol, bracket.StopLossOrderID); err != nil {
+			return bracket, fmt.Errorf("failed to cancel stop-loss leg: %w", err)
+		}
+		bracket.Status = BracketStatusTakeProfitFilled
+		e.logger.Success(fmt.Sprintf("🎯【%s】括号单止盈腿成交 @ %.2f，已撤销止损腿", binanceSymbol, bracket.TakeProfitPrice))
+	case isOrderCancelled(stopStatus) && isOrderCancelled(tpStatus):
+		bracket.Status = BracketStatusCancelled
+		e.logger.Warning(fmt.Sprintf("⚠️【%s】括号单两条腿均已失效", binanceSymbol))
+	default:
+		return bracket, nil
+	}
+
+	delete(e.brackets.brackets, binanceSymbol)
+	return bracket, nil
+}
+
+// MonitorBrackets periodically syncs all active brackets until ctx is cancelled
+// MonitorBrackets 周期性同步所有活跃括号单，直到 ctx 被取消
+func (e *BinanceExecutor) MonitorBrackets(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.brackets.mu.Lock()
+			symbols := make([]string, 0, len(e.brackets.brackets))
+			for symbol := range e.brackets.brackets {
+				symbols = append(symbols, symbol)
+			}
+			e.brackets.mu.Unlock()
+
+			for _, symbol := range symbols {
+				if _, err := e.SyncBracket(ctx, symbol); err != nil {
+					e.logger.Warning(fmt.Sprintf("⚠️  同步 %s 括号单失败: %v", symbol, err))
+				}
+			}
+		}
+	}
+}
+
+// placeBracketLeg places one reduce-only conditional leg of a bracket
+// placeBracketLeg 下达括号单的一条只减仓条件单
+func (e *BinanceExecutor) placeBracketLeg(ctx context.Context, binanceSymbol, side string, orderType futures.OrderType, triggerPrice, quantity float64) (int64, error) {
+	orderSide := futures.SideTypeSell
+	positionSide := futures.PositionSideTypeLong
+	if side == "short" {
+		orderSide = futures.SideTypeBuy
+		positionSide = futures.PositionSideTypeShort
+	}
+
+	orderService := e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(orderSide).
+		Type(orderType).
+		StopPrice(fmt.Sprintf("%.2f", triggerPrice)).
+		Quantity(fmt.Sprintf("%.4f", quantity)).
+		WorkingType(futures.WorkingTypeMarkPrice)
+
+	// Hedge mode closes by position side; one-way mode needs ReduceOnly instead
+	// 双向持仓通过持仓方向平仓；单向持仓需要使用 ReduceOnly
+	if e.positionMode == PositionModeHedge {
+		orderService = orderService.PositionSide(positionSide)
+	} else {
+		orderService = orderService.ReduceOnly(true)
+	}
+
+	order, err := e.createOrder(ctx, binanceSymbol, orderService)
+	if err != nil {
+		return 0, err
+	}
+	return order.OrderID, nil
+}
+
+// cancelOrderByID cancels an order, treating an already-gone order as success
+// cancelOrderByID 撤销订单，订单已不存在时视为成功
+func (e *BinanceExecutor) cancelOrderByID(ctx context.Context, binanceSymbol string, orderID int64) error {
+	if orderID == 0 {
+		return nil
+	}
+	_, err := e.cancelOrder(ctx, binanceSymbol, orderID)
+	if err != nil {
+		if status, statusErr := e.getOrderStatus(ctx, binanceSymbol, orderID); statusErr == nil && isOrderCancelled(status) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// getOrderStatus returns the current status of an order
+// getOrderStatus 返回订单当前状态
+func (e *BinanceExecutor) getOrderStatus(ctx context.Context, binanceSymbol string, orderID int64) (futures.OrderStatusType, error) {
+	order, err := e.getOrder(ctx, binanceSymbol, orderID)
+	if err != nil {
+		return "", err
+	}
+	return order.Status, nil
+}
@@ -0,0 +1,30 @@
+package executors
+
+import "testing"
+
+func TestValidateBracketPrices(t *testing.T) {
+	tests := []struct {
+		name       string
+		side       string
+		price      float64
+		stop       float64
+		takeProfit float64
+		wantErr    bool
+	}{
+		{name: "Valid long bracket", side: "long", price: 50000, stop: 49000, takeProfit: 52000},
+		{name: "Valid short bracket", side: "short", price: 50000, stop: 51000, takeProfit: 48000},
+		{name: "Long stop above price", side: "long", price: 50000, stop: 50500, takeProfit: 52000, wantErr: true},
+		{name: "Long take-profit below price", side: "long", price: 50000, stop: 49000, takeProfit: 49500, wantErr: true},
+		{name: "Short stop below price", side: "short", price: 50000, stop: 49000, takeProfit: 48000, wantErr: true},
+		{name: "Missing take-profit", side: "long", price: 50000, stop: 49000, takeProfit: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBracketPrices(tt.side, tt.price, tt.stop, tt.takeProfit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBracketPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+)
+
+// BreakEvenConfig decides when a position's stop moves to break-even
+// BreakEvenConfig 决定持仓止损何时移至保本
+type BreakEvenConfig struct {
+	TriggerPercent float64 // 价格朝有利方向移动的百分比，0 表示不启用 / Favourable price move in %, 0 disables
+	TriggerR       float64 // 初始风险的倍数，0 表示不启用 / Multiple of initial risk, 0 disables
+	BufferPercent  float64 // 保本价相对开仓价的缓冲（%）/ Buffer beyond entry (%)
+}
+
+// BreakEvenStop returns the break-even stop for a position and whether the profit has reached a trigger
+// BreakEvenStop 返回持仓的保本止损价以及盈利是否已达到触发条件
+// The R trigger needs an initial stop on the losing side of entry; the percent trigger works without one
+// R 触发需要位于开仓价亏损一侧的初始止损；百分比触发不需要
+func BreakEvenStop(cfg BreakEvenConfig, side string, entry, initialStop, price float64) (stop float64, reason string, triggered bool) {
+	if entry <= 0 || price <= 0 {
+		return 0, "", false
+	}
+	move := price - entry
+	risk := entry - initialStop
+	stop = entry * (1 + cfg.BufferPercent/100)
+	if side == "short" {
+		move, risk = -move, -risk
+		stop = entry * (1 - cfg.BufferPercent/100)
+	}
+	// The stop must stay on the protective side of the price, so a trigger below the buffer waits for more profit
+	// 止损必须位于价格的保护一侧，因此低于缓冲的触发条件需要等待更多盈利
+	if move <= 0 || (side == "short" && price >= stop) || (side != "short" && price <= stop) {
+		return stop, "", false
+	}
+
+	movePercent := move / entry * 100
+	if cfg.TriggerPercent > 0 && movePercent >= cfg.TriggerPercent {
+		return stop, fmt.Sprintf("盈利 %.2f%% ≥ %.2f%%", movePercent, cfg.TriggerPercent), true
+	}
+	if cfg.TriggerR > 0 && initialStop > 0 && risk > 0 && move/risk >= cfg.TriggerR {
+		return stop, fmt.Sprintf("盈利 %.2fR ≥ %.2fR", move/risk, cfg.TriggerR), true
+	}
+	return stop, "", false
+}
+
+// CheckBreakEven moves a position's stop to break-even once its profit reaches BREAKEVEN_TRIGGER_PERCENT or BREAKEVEN_TRIGGER_R
+// CheckBreakEven 在持仓盈利达到 BREAKEVEN_TRIGGER_PERCENT 或 BREAKEVEN_TRIGGER_R 后将止损移至保本
+//
+// It runs independently of the ATR trailing stop: a stop already at or beyond break-even is left alone,
+// and later trailing updates can still move it further.
+// 它独立于 ATR 追踪止损运行：已在保本价或更优位置的止损保持不变，之后的追踪更新仍可继续移动止损。
+func (sm *StopLossManager) CheckBreakEven(ctx context.Context, symbol string) error {
+	if !sm.config.BreakEvenEnabled {
+		return nil
+	}
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		sm.mu.RUnlock()
+		return nil
+	}
+	side, entry, initialStop, currentStop, stopLossType := pos.Side, pos.EntryPrice, pos.InitialStopLoss, pos.CurrentStopLoss, pos.StopLossType
+	sm.mu.RUnlock()
+
+	// Replacing a native trailing stop with a fixed one would stop the exchange from trailing it
+	// 用固定止损替换原生追踪止损会让交易所停止追踪
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
+	price, err := sm.getCurrentPrice(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("获取当前价格失败: %w", err)
+	}
+
+	cfg := BreakEvenConfig{
+		TriggerPercent: sm.config.BreakEvenTriggerPercent,
+		TriggerR:       sm.config.BreakEvenTriggerR,
+		BufferPercent:  sm.config.BreakEvenBufferPercent,
+	}
+	stop, reason, triggered := BreakEvenStop(cfg, side, entry, initialStop, price)
+	if !triggered || !sm.calculator.IsValidUpdate(side, currentStop, stop) {
+		return nil
+	}
+
+	sm.logger.Info(fmt.Sprintf("【%s】🛡️ %s，止损移至保本价 %.2f（开仓价 %.2f，缓冲 %.2f%%）",
+		normalizedSymbol, reason, stop, entry, cfg.BufferPercent))
+	if err := sm.moveStopLoss(ctx, symbol, stop, fmt.Sprintf("保本止损（%s）", reason), "program"); err != nil {
+		return fmt.Errorf("移动保本止损失败: %w", err)
+	}
+	return nil
+}
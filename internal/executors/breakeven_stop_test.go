@@ -0,0 +1,41 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBreakEvenStop(t *testing.T) {
+	rOnly := BreakEvenConfig{TriggerR: 1, BufferPercent: 0.1}
+	percentOnly := BreakEvenConfig{TriggerPercent: 2, BufferPercent: 0.1}
+
+	tests := []struct {
+		name        string
+		cfg         BreakEvenConfig
+		side        string
+		initialStop float64
+		price       float64
+		wantStop    float64
+		triggered   bool
+	}{
+		{"long below 1R", rOnly, "long", 95, 104, 100.1, false},
+		{"long at 1R", rOnly, "long", 95, 105, 100.1, true},
+		{"short at 1R", rOnly, "short", 105, 95, 99.9, true},
+		{"short losing", rOnly, "short", 105, 101, 99.9, false},
+		{"R trigger needs an initial stop", rOnly, "long", 0, 150, 100.1, false},
+		{"long percent trigger", percentOnly, "long", 0, 102, 100.1, true},
+		{"short percent below trigger", percentOnly, "short", 0, 98.5, 99.9, false},
+		{"either trigger", BreakEvenConfig{TriggerPercent: 10, TriggerR: 1, BufferPercent: 0.1}, "long", 98, 102, 100.1, true},
+		{"price inside the buffer", BreakEvenConfig{TriggerPercent: 0.05, BufferPercent: 0.1}, "long", 0, 100.08, 100.1, false},
+	}
+
+	for _, tt := range tests {
+		stop, reason, triggered := BreakEvenStop(tt.cfg, tt.side, 100, tt.initialStop, tt.price)
+		if triggered != tt.triggered || math.Abs(stop-tt.wantStop) > 1e-9 {
+			t.Errorf("%s: expected %.2f/%v, got %.2f/%v", tt.name, tt.wantStop, tt.triggered, stop, triggered)
+		}
+		if triggered && reason == "" {
+			t.Errorf("%s: expected a reason", tt.name)
+		}
+	}
+}
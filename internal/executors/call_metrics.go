@@ -0,0 +1,320 @@
+package executors
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// Call metrics tuning
+// 调用指标参数
+const (
+	callMetricsWindow     = 200             // 每个序列保留的最近调用数（用于分位数和近期错误率）/ Recent calls kept per series for percentiles and recent error rate
+	callErrorRateMinCalls = 20              // 近期调用数达到该值才计算错误率告警 / Recent calls needed before the error rate can alert
+	callAlertCooldown     = 5 * time.Minute // 同一序列两次错误率告警的最小间隔 / Minimum gap between error-rate alerts of one series
+)
+
+// CallStats summarizes the exchange calls of one endpoint, overall or for one symbol
+// CallStats 汇总某个端点的交易所调用情况（全部交易对或单个交易对）
+type CallStats struct {
+	Endpoint        string    `json:"endpoint"`             // 方法与路径，如 POST /fapi/v1/order / Method and path, e.g. POST /fapi/v1/order
+	Symbol          string    `json:"symbol,omitempty"`     // 交易对，空表示该端点的全部调用 / Symbol, empty for all calls of the endpoint
+	Calls           int64     `json:"calls"`                // 累计调用次数 / Total calls
+	Errors          int64     `json:"errors"`               // 累计失败次数 / Total failures
+	RecentErrorRate float64   `json:"recent_error_rate"`    // 最近调用的错误率 / Error rate over recent calls
+	AvgLatencyMs    float64   `json:"avg_latency_ms"`       // 平均耗时 / Average latency
+	P50LatencyMs    float64   `json:"p50_latency_ms"`       // 最近调用耗时中位数 / Median latency of recent calls
+	P95LatencyMs    float64   `json:"p95_latency_ms"`       // 最近调用耗时 95 分位 / 95th percentile latency of recent calls
+	MaxLatencyMs    float64   `json:"max_latency_ms"`       // 最大耗时 / Maximum latency
+	LastError       string    `json:"last_error,omitempty"` // 最近一次错误 / Most recent error
+	LastCall        time.Time `json:"last_call"`            // 最近一次调用时间 / Time of the most recent call
+}
+
+// callSample is one recorded call
+// callSample 是一次调用记录
+type callSample struct {
+	latency time.Duration
+	failed  bool
+}
+
+// callSeries accumulates the calls of one endpoint/symbol pair
+// callSeries 累计某个端点/交易对组合的调用
+type callSeries struct {
+	calls, errors int64
+	total, max    time.Duration
+	recent        []callSample // 最近调用的环形缓冲 / Ring buffer of recent calls
+	next          int
+	lastError     string
+	lastCall      time.Time
+	lastAlert     time.Time
+}
+
+// recentErrorRate returns the error rate of the recent calls and how many there are
+// recentErrorRate 返回最近调用的错误率及调用数
+func (s *callSeries) recentErrorRate() (float64, int) {
+	if len(s.recent) == 0 {
+		return 0, 0
+	}
+	failed := 0
+	for _, c := range s.recent {
+		if c.failed {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(s.recent)), len(s.recent)
+}
+
+type callKey struct {
+	endpoint string
+	symbol   string
+}
+
+// CallMetrics is the registry of exchange call latency and errors, per endpoint and per symbol
+// CallMetrics 是交易所调用耗时和错误的指标注册表，按端点和交易对统计
+type CallMetrics struct {
+	mu     sync.Mutex
+	series map[callKey]*callSeries
+}
+
+// NewCallMetrics creates an empty registry
+// NewCallMetrics 创建空的指标注册表
+func NewCallMetrics() *CallMetrics {
+	return &CallMetrics{series: make(map[callKey]*callSeries)}
+}
+
+var (
+	sharedCallMetrics     *CallMetrics
+	sharedCallMetricsOnce sync.Once
+)
+
+// SharedCallMetrics returns the process-wide registry that every executor records into
+// SharedCallMetrics 返回所有执行器共同记录的进程级指标注册表
+func SharedCallMetrics() *CallMetrics {
+	sharedCallMetricsOnce.Do(func() {
+		sharedCallMetrics = NewCallMetrics()
+	})
+	return sharedCallMetrics
+}
+
+// Record adds one call to the endpoint totals and, when the call names a symbol, to that symbol's series
+// Record 将一次调用计入端点合计；调用带有交易对时同时计入该交易对的序列
+// errMsg is empty for a successful call
+// 调用成功时 errMsg 为空
+func (m *CallMetrics) Record(endpoint, symbol string, latency time.Duration, errMsg string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := []callKey{{endpoint: endpoint}}
+	if symbol != "" {
+		keys = append(keys, callKey{endpoint: endpoint, symbol: symbol})
+	}
+	for _, key := range keys {
+		s, ok := m.series[key]
+		if !ok {
+			s = &callSeries{}
+			m.series[key] = s
+		}
+		s.calls++
+		s.total += latency
+		if latency > s.max {
+			s.max = latency
+		}
+		sample := callSample{latency: latency, failed: errMsg != ""}
+		if len(s.recent) < callMetricsWindow {
+			s.recent = append(s.recent, sample)
+		} else {
+			s.recent[s.next] = sample
+			s.next = (s.next + 1) % callMetricsWindow
+		}
+		if errMsg != "" {
+			s.errors++
+			s.lastError = errMsg
+		}
+		s.lastCall = at
+	}
+}
+
+// errorRateAlert reports whether an endpoint's recent error rate has reached the threshold and no alert was raised recently
+// errorRateAlert 判断端点近期错误率是否达到阈值且近期未告警
+func (m *CallMetrics) errorRateAlert(endpoint string, threshold float64, now time.Time) (float64, bool) {
+	if threshold <= 0 {
+		return 0, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.series[callKey{endpoint: endpoint}]
+	if !ok {
+		return 0, false
+	}
+	rate, n := s.recentErrorRate()
+	if n < callErrorRateMinCalls || rate < threshold || now.Sub(s.lastAlert) < callAlertCooldown {
+		return rate, false
+	}
+	s.lastAlert = now
+	return rate, true
+}
+
+// Snapshot returns the stats of every series, endpoint totals first and then symbols, slowest p95 first within each
+// Snapshot 返回所有序列的统计：先端点合计后交易对，各组内按 p95 耗时从慢到快排序
+func (m *CallMetrics) Snapshot() []CallStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]CallStats, 0, len(m.series))
+	for key, s := range m.series {
+		latencies := make([]time.Duration, len(s.recent))
+		for i, c := range s.recent {
+			latencies[i] = c.latency
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		rate, _ := s.recentErrorRate()
+
+		stats = append(stats, CallStats{
+			Endpoint:        key.endpoint,
+			Symbol:          key.symbol,
+			Calls:           s.calls,
+			Errors:          s.errors,
+			RecentErrorRate: rate,
+			AvgLatencyMs:    durationMs(s.total) / float64(s.calls),
+			P50LatencyMs:    durationMs(latencyPercentile(latencies, 0.50)),
+			P95LatencyMs:    durationMs(latencyPercentile(latencies, 0.95)),
+			MaxLatencyMs:    durationMs(s.max),
+			LastError:       s.lastError,
+			LastCall:        s.lastCall,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].Symbol == "") != (stats[j].Symbol == "") {
+			return stats[i].Symbol == ""
+		}
+		if stats[i].P95LatencyMs != stats[j].P95LatencyMs {
+			return stats[i].P95LatencyMs > stats[j].P95LatencyMs
+		}
+		return stats[i].Endpoint+stats[i].Symbol < stats[j].Endpoint+stats[j].Symbol
+	})
+	return stats
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted latencies
+// latencyPercentile 返回已排序耗时的最近秩分位数
+func latencyPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// instrumentedTransport records the latency and outcome of every exchange request
+// instrumentedTransport 记录每个交易所请求的耗时和结果
+type instrumentedTransport struct {
+	base           http.RoundTripper
+	metrics        *CallMetrics
+	logger         *logger.ColorLogger
+	slowCall       time.Duration // 超过该耗时的调用记录警告，0 表示不告警 / Calls slower than this log a warning, 0 disables
+	errorRateAlert float64       // 端点近期错误率告警阈值，0 表示不告警 / Recent error rate that alerts for an endpoint, 0 disables
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := req.Method + " " + req.URL.Path
+	symbol := requestSymbol(req)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	errMsg := ""
+	switch {
+	case err != nil:
+		errMsg = err.Error()
+	case resp.StatusCode >= http.StatusBadRequest:
+		// Keep the exchange's error code; the body is restored for the client
+		// 保留交易所返回的错误码；响应体会还原给客户端
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		errMsg = fmt.Sprintf("HTTP %d %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	t.metrics.Record(endpoint, symbol, latency, errMsg, start)
+
+	if t.logger != nil {
+		if t.slowCall > 0 && latency >= t.slowCall {
+			t.logger.Warning(fmt.Sprintf("🐢 交易所调用缓慢: %s %s 耗时 %dms", endpoint, symbol, latency.Milliseconds()))
+		}
+		if rate, alert := t.metrics.errorRateAlert(endpoint, t.errorRateAlert, start); alert {
+			t.logger.Warning(fmt.Sprintf("⚠️  交易所调用错误率升高: %s 最近 %.0f%% 失败（最近错误: %s）", endpoint, rate*100, errMsg))
+		}
+	}
+	return resp, err
+}
+
+// requestSymbol extracts the symbol parameter from the query string or the form body
+// requestSymbol 从查询参数或表单请求体中提取 symbol 参数
+func requestSymbol(req *http.Request) string {
+	if symbol := req.URL.Query().Get("symbol"); symbol != "" {
+		return symbol
+	}
+	if req.GetBody == nil {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return ""
+	}
+	return form.Get("symbol")
+}
+
+// withCallMetrics wraps a Binance HTTP client so every request is recorded in the metrics registry
+// withCallMetrics 包装币安 HTTP 客户端，使每个请求都记录到指标注册表
+// A nil client gets a new one based on http.DefaultTransport; the given client is never modified
+// 传入 nil 时基于 http.DefaultTransport 新建客户端；不会修改传入的客户端
+func withCallMetrics(client *http.Client, metrics *CallMetrics, log *logger.ColorLogger, slowCall time.Duration, errorRateAlert float64) *http.Client {
+	if client == nil {
+		client = &http.Client{}
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, wrapped := base.(*instrumentedTransport); wrapped {
+		return client
+	}
+
+	instrumented := *client
+	instrumented.Transport = &instrumentedTransport{
+		base:           base,
+		metrics:        metrics,
+		logger:         log,
+		slowCall:       slowCall,
+		errorRateAlert: errorRateAlert,
+	}
+	return &instrumented
+}
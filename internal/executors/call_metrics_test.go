@@ -0,0 +1,119 @@
+package executors
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallMetricsRecord(t *testing.T) {
+	m := NewCallMetrics()
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		errMsg := ""
+		if i%5 == 0 {
+			errMsg = "HTTP 400 {\"code\":-2019}"
+		}
+		m.Record("POST /fapi/v1/order", "BTCUSDT", time.Duration(i)*10*time.Millisecond, errMsg, now)
+	}
+	m.Record("GET /fapi/v2/balance", "", 5*time.Millisecond, "", now)
+
+	stats := m.Snapshot()
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 series (2 endpoint totals, 1 symbol), got %d: %+v", len(stats), stats)
+	}
+	if stats[0].Endpoint != "POST /fapi/v1/order" || stats[0].Symbol != "" {
+		t.Errorf("Expected the slowest endpoint total first, got %+v", stats[0])
+	}
+	if stats[2].Symbol != "BTCUSDT" {
+		t.Errorf("Expected symbol series last, got %+v", stats[2])
+	}
+
+	order := stats[0]
+	if order.Calls != 10 || order.Errors != 2 || order.RecentErrorRate != 0.2 {
+		t.Errorf("Unexpected counts: %+v", order)
+	}
+	if order.P50LatencyMs != 50 || order.P95LatencyMs != 100 || order.MaxLatencyMs != 100 || order.AvgLatencyMs != 55 {
+		t.Errorf("Unexpected latencies: %+v", order)
+	}
+	if !strings.Contains(order.LastError, "-2019") {
+		t.Errorf("Expected last error to keep the exchange code, got %q", order.LastError)
+	}
+}
+
+func TestCallMetricsWindowAndAlert(t *testing.T) {
+	m := NewCallMetrics()
+	now := time.Now()
+	for i := 0; i < callMetricsWindow; i++ {
+		m.Record("GET /fapi/v1/order", "", time.Millisecond, "timeout", now)
+	}
+	if rate, alert := m.errorRateAlert("GET /fapi/v1/order", 0.5, now); !alert || rate != 1 {
+		t.Errorf("Expected an alert at 100%% errors, got %v %v", rate, alert)
+	}
+	if _, alert := m.errorRateAlert("GET /fapi/v1/order", 0.5, now.Add(time.Minute)); alert {
+		t.Error("Expected the cooldown to suppress a repeated alert")
+	}
+
+	// Successful calls push the failures out of the recent window
+	// 成功调用将失败记录挤出近期窗口
+	for i := 0; i < callMetricsWindow; i++ {
+		m.Record("GET /fapi/v1/order", "", time.Millisecond, "", now)
+	}
+	stats := m.Snapshot()
+	if stats[0].Calls != 2*callMetricsWindow || stats[0].RecentErrorRate != 0 {
+		t.Errorf("Expected recent error rate to recover, got %+v", stats[0])
+	}
+	if _, alert := m.errorRateAlert("GET /fapi/v1/order", 0.5, now.Add(time.Hour)); alert {
+		t.Error("Expected no alert once the error rate recovered")
+	}
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"code":-2019,"msg":"Margin is insufficient."}`)
+			return
+		}
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	m := NewCallMetrics()
+	client := withCallMetrics(nil, m, nil, 0, 0)
+	if again := withCallMetrics(client, m, nil, 0, 0); again.Transport != client.Transport {
+		t.Error("Expected an instrumented client not to be wrapped twice")
+	}
+
+	resp, err := client.Get(srv.URL + "/fapi/v1/premiumIndex?symbol=ETHUSDT")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	form := url.Values{"symbol": {"BTCUSDT"}, "side": {"BUY"}}
+	resp, err = client.Post(srv.URL+"/fapi/v1/order", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "-2019") {
+		t.Errorf("Expected the error body to reach the client, got %q", body)
+	}
+
+	seen := make(map[string]CallStats)
+	for _, s := range m.Snapshot() {
+		seen[s.Endpoint+"|"+s.Symbol] = s
+	}
+	if s, ok := seen["GET /fapi/v1/premiumIndex|ETHUSDT"]; !ok || s.Errors != 0 {
+		t.Errorf("Expected a successful ETHUSDT premium index call, got %+v", seen)
+	}
+	if s, ok := seen["POST /fapi/v1/order|BTCUSDT"]; !ok || s.Errors != 1 || !strings.Contains(s.LastError, "HTTP 400") {
+		t.Errorf("Expected a failed BTCUSDT order call, got %+v", seen)
+	}
+}
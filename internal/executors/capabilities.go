@@ -0,0 +1,164 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// SymbolCapabilities records which order types and flags the exchange accepts for a symbol
+// SymbolCapabilities 记录交易所对某交易对支持的订单类型和参数
+type SymbolCapabilities struct {
+	Symbol           string    // 交易对 / Trading pair
+	StopMarket       bool      // 支持 STOP_MARKET / Supports STOP_MARKET
+	TakeProfitMarket bool      // 支持 TAKE_PROFIT_MARKET / Supports TAKE_PROFIT_MARKET
+	TrailingStop     bool      // 支持 TRAILING_STOP_MARKET / Supports TRAILING_STOP_MARKET
+	PostOnly         bool      // 支持 GTX 只做 maker / Supports GTX post-only
+	OCO              bool      // 支持原生 OCO（币安合约没有）/ Supports native OCO (Binance futures has none)
+	Probed           bool      // 是否来自交易所探测结果 / Whether the values come from an exchange probe
+	ProbedAt         time.Time // 探测时间 / Probe time
+}
+
+// capabilityRegistry holds the probed capability matrix and filter rules per symbol
+// capabilityRegistry 保存每个交易对探测到的能力矩阵和过滤规则
+type capabilityRegistry struct {
+	symbols map[string]SymbolCapabilities
+	filters map[string]SymbolFilters
+	mu      sync.RWMutex
+}
+
+// defaultCapabilities is assumed for symbols that have not been probed, matching the behaviour before probing existed
+// defaultCapabilities 用于尚未探测的交易对，与引入探测前的行为一致
+func defaultCapabilities(symbol string) SymbolCapabilities {
+	return SymbolCapabilities{
+		Symbol:           symbol,
+		StopMarket:       true,
+		TakeProfitMarket: true,
+		TrailingStop:     true,
+		PostOnly:         true,
+	}
+}
+
+// capabilitiesFromSymbol derives the capabilities of a symbol from its exchange info entry
+// capabilitiesFromSymbol 根据交易所信息中的交易对条目推导其能力
+func capabilitiesFromSymbol(info futures.Symbol) SymbolCapabilities {
+	caps := SymbolCapabilities{
+		Symbol:   info.Symbol,
+		Probed:   true,
+		ProbedAt: time.Now(),
+	}
+
+	for _, orderType := range info.OrderType {
+		switch orderType {
+		case futures.OrderTypeStopMarket:
+			caps.StopMarket = true
+		case futures.OrderTypeTakeProfitMarket:
+			caps.TakeProfitMarket = true
+		case futures.OrderTypeTrailingStopMarket:
+			caps.TrailingStop = true
+		}
+	}
+
+	for _, tif := range info.TimeInForce {
+		if tif == futures.TimeInForceTypeGTX {
+			caps.PostOnly = true
+		}
+	}
+
+	return caps
+}
+
+// ProbeCapabilities queries exchange info once and records the capability matrix for the given symbols
+// ProbeCapabilities 查询一次交易所信息，并记录给定交易对的能力矩阵
+// Symbols missing from exchange info keep the default capabilities; a failed probe is not fatal
+// 交易所信息中缺失的交易对保留默认能力；探测失败不会中断启动
+func (e *BinanceExecutor) ProbeCapabilities(ctx context.Context, symbols []string) error {
+	var info *futures.ExchangeInfo
+	err := e.withRetry(func() error {
+		var err error
+		info, err = e.client.NewExchangeInfoService().Do(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get exchange info: %w", err)
+	}
+
+	bySymbol := make(map[string]futures.Symbol, len(info.Symbols))
+	for _, s := range info.Symbols {
+		bySymbol[s.Symbol] = s
+	}
+
+	e.capabilities.mu.Lock()
+	for _, symbol := range symbols {
+		binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+		if s, ok := bySymbol[binanceSymbol]; ok {
+			e.capabilities.symbols[binanceSymbol] = capabilitiesFromSymbol(s)
+			e.capabilities.filters[binanceSymbol] = filtersFromSymbol(s)
+		} else {
+			e.logger.Warning(fmt.Sprintf("⚠️  交易所信息中未找到 %s，使用默认能力", binanceSymbol))
+		}
+	}
+	e.capabilities.mu.Unlock()
+
+	e.logCapabilityMatrix()
+	return nil
+}
+
+// Capabilities returns the capability matrix entry for a symbol, or the defaults if it was never probed
+// Capabilities 返回交易对的能力矩阵条目；未探测过则返回默认值
+func (e *BinanceExecutor) Capabilities(symbol string) SymbolCapabilities {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.capabilities.mu.RLock()
+	defer e.capabilities.mu.RUnlock()
+
+	if caps, ok := e.capabilities.symbols[binanceSymbol]; ok {
+		return caps
+	}
+	return defaultCapabilities(binanceSymbol)
+}
+
+// CapabilityMatrix returns a copy of all probed capabilities, sorted by symbol
+// CapabilityMatrix 返回所有已探测能力的副本，按交易对排序
+func (e *BinanceExecutor) CapabilityMatrix() []SymbolCapabilities {
+	e.capabilities.mu.RLock()
+	defer e.capabilities.mu.RUnlock()
+
+	matrix := make([]SymbolCapabilities, 0, len(e.capabilities.symbols))
+	for _, caps := range e.capabilities.symbols {
+		matrix = append(matrix, caps)
+	}
+	sort.Slice(matrix, func(i, j int) bool { return matrix[i].Symbol < matrix[j].Symbol })
+	return matrix
+}
+
+// logCapabilityMatrix prints the probed capabilities and the degradations that will apply
+// logCapabilityMatrix 打印探测到的能力以及将会生效的降级策略
+func (e *BinanceExecutor) logCapabilityMatrix() {
+	mark := func(ok bool) string {
+		if ok {
+			return "✓"
+		}
+		return "✗"
+	}
+
+	for _, caps := range e.CapabilityMatrix() {
+		e.logger.Info(fmt.Sprintf("【%s】能力: 止损市价 %s | 止盈市价 %s | 追踪止损 %s | 只做maker %s | OCO %s",
+			caps.Symbol, mark(caps.StopMarket), mark(caps.TakeProfitMarket), mark(caps.TrailingStop),
+			mark(caps.PostOnly), mark(caps.OCO)))
+
+		if !caps.PostOnly {
+			e.logger.Warning(fmt.Sprintf("【%s】不支持只做 maker，限价开仓将降级为市价单", caps.Symbol))
+		}
+		if !caps.TrailingStop {
+			e.logger.Warning(fmt.Sprintf("【%s】不支持原生追踪止损，将使用本地追踪止损", caps.Symbol))
+		}
+		if !caps.StopMarket || !caps.TakeProfitMarket {
+			e.logger.Warning(fmt.Sprintf("【%s】不支持完整的条件市价单，括号单不可用", caps.Symbol))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestCapabilitiesFromSymbol(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     futures.Symbol
+		expected SymbolCapabilities
+	}{
+		{
+			name: "Full support",
+			info: futures.Symbol{
+				Symbol: "BTCUSDT",
+				OrderType: []futures.OrderType{futures.OrderTypeLimit, futures.OrderTypeMarket,
+					futures.OrderTypeStopMarket, futures.OrderTypeTakeProfitMarket, futures.OrderTypeTrailingStopMarket},
+				TimeInForce: []futures.TimeInForceType{futures.TimeInForceTypeGTC, futures.TimeInForceTypeGTX},
+			},
+			expected: SymbolCapabilities{Symbol: "BTCUSDT", StopMarket: true, TakeProfitMarket: true, TrailingStop: true, PostOnly: true},
+		},
+		{
+			name: "No trailing stop or post-only",
+			info: futures.Symbol{
+				Symbol:      "NEWUSDT",
+				OrderType:   []futures.OrderType{futures.OrderTypeLimit, futures.OrderTypeMarket, futures.OrderTypeStopMarket},
+				TimeInForce: []futures.TimeInForceType{futures.TimeInForceTypeGTC, futures.TimeInForceTypeIOC},
+			},
+			expected: SymbolCapabilities{Symbol: "NEWUSDT", StopMarket: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := capabilitiesFromSymbol(tt.info)
+			if !result.Probed {
+				t.Errorf("Expected probed capabilities")
+			}
+			result.Probed = false
+			result.ProbedAt = tt.expected.ProbedAt
+			if result != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, result)
+			}
+		})
+	}
+}
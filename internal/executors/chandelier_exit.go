@@ -0,0 +1,111 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// Trailing stop formulas
+// 追踪止损公式
+const (
+	TrailingFormulaATR        = "atr"        // 入场后最高/最低价 ∓ N×ATR / Highest/lowest price since entry ∓ N×ATR
+	TrailingFormulaChandelier = "chandelier" // 最近 N 根 K 线最高/最低价 ∓ k×ATR(N) / Highest high/lowest low of the last N bars ∓ k×ATR(N)
+	TrailingFormulaPSAR       = "psar"       // 抛物线 SAR / Parabolic SAR
+	TrailingFormulaSuperTrend = "supertrend" // K 线中点 ∓ k×ATR(N) 的 SuperTrend 线 / SuperTrend line at the bar midpoint ∓ k×ATR(N)
+	TrailingFormulaPercent    = "percent"    // 入场后最高/最低价 ∓ 固定百分比 / Highest/lowest price since entry ∓ a fixed percentage
+	TrailingFormulaStep       = "step"       // 百分比追踪，按固定阶梯移动 / Percentage trailing that moves in fixed steps
+)
+
+// Chandelier exit defaults (Chuck LeBeau's 22 bars and 3 ATR)
+// 吊灯止损默认参数（Chuck LeBeau 的 22 根 K 线与 3 倍 ATR）
+const (
+	defaultChandelierPeriod     = 22
+	defaultChandelierMultiplier = 3.0
+)
+
+// SetFormulas selects the trailing formula: overrides keyed by Binance symbol first, then defaultFormula
+// SetFormulas 设置追踪公式：优先使用以币安格式为键的按交易对覆盖，其次使用 defaultFormula
+func (calc *TrailingStopCalculator) SetFormulas(defaultFormula string, overrides map[string]string) {
+	calc.formula = defaultFormula
+	calc.formulas = overrides
+}
+
+// Formula returns the trailing formula of a symbol
+// Formula 返回交易对的追踪公式
+func (calc *TrailingStopCalculator) Formula(symbol string) string {
+	if formula, ok := calc.formulas[normalizeCalculatorSymbol(symbol)]; ok {
+		return formula
+	}
+	if calc.formula == "" {
+		return TrailingFormulaATR
+	}
+	return calc.formula
+}
+
+// CalculateChandelierStop calculates the chandelier exit of a position from recent bars
+// CalculateChandelierStop 根据近期 K 线计算持仓的吊灯止损价
+//
+// Unlike CalculateTrailingStop, the reference is the extreme of the last N bars rather than since entry,
+// so the stop loosens when an old high rolls out of the window; IsValidUpdate keeps it from moving back.
+// 与 CalculateTrailingStop 不同，参考价是最近 N 根 K 线的极值而非入场以来的极值，旧高点移出窗口时止损会放宽；
+// IsValidUpdate 保证止损不会回退。
+func (calc *TrailingStopCalculator) CalculateChandelierStop(symbol string, bars []dataflows.OHLCV, side string) (stop, atr float64, err error) {
+	config := calc.GetConfig(symbol)
+	period, multiplier := config.ChandelierPeriod, config.ChandelierMultiplier
+	if period <= 0 {
+		period = defaultChandelierPeriod
+	}
+	if multiplier <= 0 {
+		multiplier = defaultChandelierMultiplier
+	}
+
+	longStop, shortStop, atr, ok := dataflows.ChandelierExit(bars, period, multiplier)
+	if !ok {
+		return 0, 0, fmt.Errorf("need at least %d bars for chandelier exit, got %d", period+1, len(bars))
+	}
+	stop = longStop
+	if side == "short" {
+		stop = shortStop
+	}
+
+	if calc.logger != nil {
+		calc.logger.Info(fmt.Sprintf("【%s】计算吊灯止损: 周期=%d, ATR=%.2f, 倍数=%.1f, 止损价=%.2f",
+			symbol, period, atr, multiplier, stop))
+	}
+	return stop, atr, nil
+}
+
+// UpdateChandelierStop trails a position's stop with the chandelier exit of the given bars
+// UpdateChandelierStop 使用给定 K 线的吊灯止损价追踪持仓止损
+func (sm *StopLossManager) UpdateChandelierStop(ctx context.Context, symbol string, bars []dataflows.OHLCV) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		sm.mu.RUnlock()
+		return nil
+	}
+	side, currentStopLoss, stopLossType := pos.Side, pos.CurrentStopLoss, pos.StopLossType
+	sm.mu.RUnlock()
+
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
+	newStopLoss, atr, err := sm.calculator.CalculateChandelierStop(symbol, bars, side)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("【%s】⚠️ 无法计算吊灯止损，跳过追踪止损更新: %v", symbol, err))
+		return nil
+	}
+	return sm.applyTrailingStop(ctx, symbol, side, currentStopLoss, newStopLoss,
+		fmt.Sprintf("吊灯止损自动调整（ATR=%.2f）", atr))
+}
+
+// TrailingFormula returns the trailing formula the manager uses for a symbol
+// TrailingFormula 返回管理器对交易对使用的追踪公式
+func (sm *StopLossManager) TrailingFormula(symbol string) string {
+	return sm.calculator.Formula(symbol)
+}
@@ -0,0 +1,44 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+func TestTrailingFormulaSelection(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	if calc.Formula("BTC/USDT") != TrailingFormulaATR {
+		t.Error("Expected the ATR formula by default")
+	}
+
+	calc.SetFormulas(TrailingFormulaChandelier, map[string]string{"BTCUSDT": TrailingFormulaATR})
+	if calc.Formula("BTC/USDT") != TrailingFormulaATR || calc.Formula("sol/usdt") != TrailingFormulaChandelier {
+		t.Errorf("Expected the override for BTC and the default for SOL, got %s/%s", calc.Formula("BTC/USDT"), calc.Formula("sol/usdt"))
+	}
+}
+
+func TestCalculateChandelierStop(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	bars := make([]dataflows.OHLCV, 40)
+	for i := range bars {
+		c := 100 + float64(i)
+		bars[i] = dataflows.OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+
+	// Defaults: 22 bars and 3 ATR; the ATR of these bars is 2
+	// 默认参数：22 根 K 线与 3 倍 ATR；这些 K 线的 ATR 为 2
+	stop, atr, err := calc.CalculateChandelierStop("BTCUSDT", bars, "long")
+	if err != nil || math.Abs(atr-2) > 1e-9 || math.Abs(stop-(140-6)) > 1e-9 {
+		t.Errorf("Expected long stop 134 with ATR 2, got %.4f/%.4f (%v)", stop, atr, err)
+	}
+	stop, _, err = calc.CalculateChandelierStop("BTCUSDT", bars, "short")
+	if err != nil || math.Abs(stop-(117+6)) > 1e-9 {
+		t.Errorf("Expected short stop 123, got %.4f (%v)", stop, err)
+	}
+
+	if _, _, err := calc.CalculateChandelierStop("BTCUSDT", bars[:10], "long"); err == nil {
+		t.Error("Expected an error with too few bars")
+	}
+}
@@ -0,0 +1,184 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// contractSpecTTL is how long leverage brackets and funding intervals are reused before being fetched again
+// contractSpecTTL 是杠杆分层和资金费间隔在重新获取前的复用时长
+const contractSpecTTL = time.Hour
+
+// defaultFundingIntervalHours applies to symbols that /fapi/v1/fundingInfo does not list
+// defaultFundingIntervalHours 用于 /fapi/v1/fundingInfo 未列出的交易对
+const defaultFundingIntervalHours = 8
+
+// ContractSpec is what the trader needs to know about a contract for its size and stop suggestions to be placeable
+// ContractSpec 是交易员需要了解的合约规格，确保其仓位与止损建议可以实际下单
+type ContractSpec struct {
+	Symbol               string  // 交易对 / Trading pair
+	TickSize             float64 // 价格最小变动 / Price tick size
+	MinQty               float64 // 最小数量 / Minimum quantity
+	StepSize             float64 // 数量步长 / Quantity step size
+	MinNotional          float64 // 最小名义价值 / Minimum notional
+	Leverage             int     // 当前杠杆，0 表示未知 / Current leverage, 0 when unknown
+	MaxLeverage          int     // 合约允许的最高杠杆，0 表示未知 / Highest leverage of the contract, 0 when unknown
+	MaxNotional          float64 // 当前杠杆下允许的最大持仓名义价值，0 表示未知 / Largest position notional at the current leverage, 0 when unknown
+	FundingIntervalHours int     // 资金费结算间隔（小时）/ Funding interval in hours
+}
+
+// contractSpecCache holds the slow-changing parts of the contract specs
+// contractSpecCache 保存合约规格中变化缓慢的部分
+type contractSpecCache struct {
+	mu               sync.Mutex
+	brackets         map[string][]futures.Bracket
+	bracketsAt       map[string]time.Time
+	fundingIntervals map[string]int
+	fundingAt        time.Time
+}
+
+// GetContractSpec returns the tick size, lot limits, leverage bracket limit and funding interval of a symbol
+// GetContractSpec 返回交易对的最小价格变动、数量限制、杠杆分层上限和资金费间隔
+// Parts that cannot be fetched are left at zero (or the default funding interval); only missing filters are an error
+// 无法获取的部分保持为 0（资金费间隔使用默认值）；只有缺少过滤规则才返回错误
+func (e *BinanceExecutor) GetContractSpec(ctx context.Context, symbol string) (ContractSpec, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	filters, err := e.GetSymbolFilters(ctx, symbol)
+	if err != nil {
+		return ContractSpec{}, fmt.Errorf("failed to get symbol filters: %w", err)
+	}
+
+	spec := ContractSpec{
+		Symbol:               binanceSymbol,
+		TickSize:             filters.TickSize,
+		MinQty:               filters.MinQty,
+		StepSize:             filters.StepSize,
+		MinNotional:          filters.MinNotional,
+		FundingIntervalHours: e.fundingIntervalHours(ctx, binanceSymbol),
+	}
+
+	if e.paper != nil {
+		spec.Leverage = e.paper.Leverage(binanceSymbol)
+	} else if leverage, err := e.symbolLeverage(ctx, binanceSymbol); err == nil {
+		spec.Leverage = leverage
+	}
+	if brackets, err := e.leverageBrackets(ctx, binanceSymbol); err == nil {
+		spec.MaxNotional, spec.MaxLeverage = maxNotionalForLeverage(brackets, spec.Leverage)
+	} else {
+		e.logger.Warning(fmt.Sprintf("【%s】⚠️ 获取杠杆分层失败: %v", binanceSymbol, err))
+	}
+	return spec, nil
+}
+
+// leverageBrackets returns the cached leverage brackets of a symbol, fetching them when stale
+// leverageBrackets 返回缓存的交易对杠杆分层，过期时重新获取
+func (e *BinanceExecutor) leverageBrackets(ctx context.Context, binanceSymbol string) ([]futures.Bracket, error) {
+	e.contracts.mu.Lock()
+	defer e.contracts.mu.Unlock()
+
+	if at, ok := e.contracts.bracketsAt[binanceSymbol]; ok && time.Since(at) < contractSpecTTL {
+		return e.contracts.brackets[binanceSymbol], nil
+	}
+
+	var res []*futures.LeverageBracket
+	err := e.withRetry(func() error {
+		var err error
+		res, err = e.client.NewGetLeverageBracketService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leverage brackets: %w", err)
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no leverage brackets for %s", binanceSymbol)
+	}
+
+	if e.contracts.brackets == nil {
+		e.contracts.brackets = make(map[string][]futures.Bracket)
+		e.contracts.bracketsAt = make(map[string]time.Time)
+	}
+	e.contracts.brackets[binanceSymbol] = res[0].Brackets
+	e.contracts.bracketsAt[binanceSymbol] = time.Now()
+	return res[0].Brackets, nil
+}
+
+// fundingIntervalHours returns the funding interval of a symbol, falling back to the default when it cannot be fetched
+// fundingIntervalHours 返回交易对的资金费结算间隔，无法获取时使用默认值
+func (e *BinanceExecutor) fundingIntervalHours(ctx context.Context, binanceSymbol string) int {
+	e.contracts.mu.Lock()
+	defer e.contracts.mu.Unlock()
+
+	if e.contracts.fundingIntervals == nil || time.Since(e.contracts.fundingAt) >= contractSpecTTL {
+		var infos []*futures.FundingRateInfo
+		err := e.withRetry(func() error {
+			var err error
+			infos, err = e.client.NewFundingRateInfoService().Do(ctx)
+			return err
+		})
+		if err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️ 获取资金费间隔失败，使用默认 %d 小时: %v", defaultFundingIntervalHours, err))
+			return defaultFundingIntervalHours
+		}
+		intervals := make(map[string]int, len(infos))
+		for _, info := range infos {
+			if info.FundingIntervalHours > 0 {
+				intervals[info.Symbol] = int(info.FundingIntervalHours)
+			}
+		}
+		e.contracts.fundingIntervals, e.contracts.fundingAt = intervals, time.Now()
+	}
+
+	if hours, ok := e.contracts.fundingIntervals[binanceSymbol]; ok {
+		return hours
+	}
+	return defaultFundingIntervalHours
+}
+
+// maxNotionalForLeverage returns the largest position notional allowed at leverage and the highest leverage of any bracket
+// maxNotionalForLeverage 返回在 leverage 杠杆下允许的最大持仓名义价值，以及所有分层中的最高杠杆
+// A bracket allows leverage up to its initial leverage, so the limit is the widest cap among the brackets that still allow it
+// 每个分层允许的杠杆不超过其初始杠杆，因此上限取仍允许该杠杆的分层中最大的名义价值上限
+func maxNotionalForLeverage(brackets []futures.Bracket, leverage int) (maxNotional float64, maxLeverage int) {
+	for _, b := range brackets {
+		if b.InitialLeverage > maxLeverage {
+			maxLeverage = b.InitialLeverage
+		}
+		if leverage > 0 && b.InitialLeverage >= leverage && b.NotionalCap > maxNotional {
+			maxNotional = b.NotionalCap
+		}
+	}
+	return maxNotional, maxLeverage
+}
+
+// FormatContractSpec renders a contract spec for the trader prompt, omitting the parts that are unknown
+// FormatContractSpec 将合约规格格式化为交易员提示词，省略未知部分
+func FormatContractSpec(spec ContractSpec) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("价格最小变动: %g | 最小下单数量: %g（步长 %g）", spec.TickSize, spec.MinQty, spec.StepSize))
+	if spec.MinNotional > 0 {
+		sb.WriteString(fmt.Sprintf(" | 最小名义价值: %g USDT", spec.MinNotional))
+	}
+	sb.WriteString("\n")
+
+	if spec.Leverage > 0 {
+		sb.WriteString(fmt.Sprintf("当前杠杆: %dx", spec.Leverage))
+		if spec.MaxLeverage > 0 {
+			sb.WriteString(fmt.Sprintf("（合约最高 %dx）", spec.MaxLeverage))
+		}
+		if spec.MaxNotional > 0 {
+			sb.WriteString(fmt.Sprintf("，该杠杆下最大持仓名义价值: %.0f USDT", spec.MaxNotional))
+		}
+		sb.WriteString("\n")
+	} else if spec.MaxLeverage > 0 {
+		sb.WriteString(fmt.Sprintf("合约最高杠杆: %dx\n", spec.MaxLeverage))
+	}
+
+	sb.WriteString(fmt.Sprintf("资金费结算间隔: 每 %d 小时\n", spec.FundingIntervalHours))
+	sb.WriteString("注意: 止损价须为价格最小变动的整数倍，仓位数量须不低于最小下单数量且为步长的整数倍")
+	return sb.String()
+}
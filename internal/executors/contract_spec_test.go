@@ -0,0 +1,53 @@
+package executors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestMaxNotionalForLeverage(t *testing.T) {
+	brackets := []futures.Bracket{
+		{Bracket: 1, InitialLeverage: 125, NotionalCap: 50000},
+		{Bracket: 2, InitialLeverage: 100, NotionalCap: 250000},
+		{Bracket: 3, InitialLeverage: 50, NotionalCap: 3000000},
+		{Bracket: 4, InitialLeverage: 20, NotionalCap: 12000000},
+	}
+
+	tests := []struct {
+		leverage    int
+		maxNotional float64
+	}{
+		{125, 50000},
+		{100, 250000},
+		{60, 250000},
+		{50, 3000000},
+		{10, 12000000},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		maxNotional, maxLeverage := maxNotionalForLeverage(brackets, tt.leverage)
+		if maxNotional != tt.maxNotional || maxLeverage != 125 {
+			t.Errorf("leverage %d: got (%v, %d), want (%v, 125)", tt.leverage, maxNotional, maxLeverage, tt.maxNotional)
+		}
+	}
+}
+
+func TestFormatContractSpec(t *testing.T) {
+	text := FormatContractSpec(ContractSpec{
+		Symbol: "BTCUSDT", TickSize: 0.1, MinQty: 0.001, StepSize: 0.001, MinNotional: 100,
+		Leverage: 10, MaxLeverage: 125, MaxNotional: 12000000, FundingIntervalHours: 4,
+	})
+	for _, want := range []string{"价格最小变动: 0.1", "最小下单数量: 0.001", "当前杠杆: 10x（合约最高 125x）", "12000000 USDT", "每 4 小时"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+
+	// Unknown leverage drops the bracket line instead of showing zeros
+	// 杠杆未知时省略分层信息而不是显示 0
+	if text := FormatContractSpec(ContractSpec{TickSize: 0.01, FundingIntervalHours: 8}); strings.Contains(text, "杠杆") {
+		t.Errorf("Expected no leverage line, got:\n%s", text)
+	}
+}
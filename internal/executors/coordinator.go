@@ -3,10 +3,12 @@ package executors
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/oak/crypto-trading-bot/internal/config"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
 // TradeCoordinator coordinates the entire trading flow from decision to execution
@@ -16,6 +18,16 @@ type TradeCoordinator struct {
 	executor        *BinanceExecutor
 	logger          *logger.ColorLogger
 	stopLossManager *StopLossManager
+	sizer           *PositionSizer    // 仓位计算引擎，nil 表示使用 LLM 建议 / Sizing engine, nil uses the LLM's size
+	exposure        *ExposureLimits   // 组合敞口上限，nil 表示不限制 / Portfolio exposure caps, nil imposes none
+	deleverage      *DeleveragePolicy // 计划降杠杆时段，nil 表示不启用 / Scheduled deleverage windows, nil disables them
+}
+
+// SizingHints are the decision details the sizing engine needs besides the account state
+// SizingHints 是仓位计算引擎在账户状态之外所需的决策信息
+type SizingHints struct {
+	StopLoss float64 // 决策止损价，0 表示未提供 / Decision stop price, 0 if not provided
+	ATR      float64 // 主时间周期 ATR，0 表示未知 / ATR of the primary timeframe, 0 if unknown
 }
 
 // NewTradeCoordinator creates a new TradeCoordinator
@@ -29,6 +41,24 @@ func NewTradeCoordinator(cfg *config.Config, executor *BinanceExecutor, log *log
 	}
 }
 
+// SetPositionSizer sets the sizing engine that clamps the LLM's proposed size
+// SetPositionSizer 设置用于限制 LLM 建议仓位的仓位计算引擎
+func (tc *TradeCoordinator) SetPositionSizer(sizer *PositionSizer) {
+	tc.sizer = sizer
+}
+
+// SetExposureLimits sets the portfolio caps that new entries are downsized or rejected against
+// SetExposureLimits 设置组合敞口上限，新开仓超限时会被削减或拒绝
+func (tc *TradeCoordinator) SetExposureLimits(limits *ExposureLimits) {
+	tc.exposure = limits
+}
+
+// SetDeleveragePolicy sets the scheduled windows in which new entries get lower leverage and size
+// SetDeleveragePolicy 设置计划降杠杆时段，时段内新开仓使用更低的杠杆和仓位
+func (tc *TradeCoordinator) SetDeleveragePolicy(policy *DeleveragePolicy) {
+	tc.deleverage = policy
+}
+
 // ExecuteDecision executes a trading decision with full safety checks
 // ExecuteDecision 执行交易决策并进行完整的安全检查
 func (tc *TradeCoordinator) ExecuteDecision(ctx context.Context, symbol string, action TradeAction, reason string) (*TradeResult, error) {
@@ -40,6 +70,12 @@ func (tc *TradeCoordinator) ExecuteDecision(ctx context.Context, symbol string,
 // ExecuteDecisionWithParams executes a trading decision with custom leverage and position size
 // ExecuteDecisionWithParams 使用自定义杠杆和仓位大小执行交易决策
 func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbol string, action TradeAction, reason string, leverage int, positionSizePercent float64) (*TradeResult, error) {
+	return tc.ExecuteDecisionWithHints(ctx, symbol, action, reason, leverage, positionSizePercent, SizingHints{})
+}
+
+// ExecuteDecisionWithHints executes a trading decision, letting the sizing engine clamp the size with the decision's stop and ATR
+// ExecuteDecisionWithHints 执行交易决策，由仓位计算引擎结合决策止损与 ATR 限制仓位
+func (tc *TradeCoordinator) ExecuteDecisionWithHints(ctx context.Context, symbol string, action TradeAction, reason string, leverage int, positionSizePercent float64, hints SizingHints) (*TradeResult, error) {
 	tc.logger.Header("交易执行协调器", '=', 80)
 	tc.logger.Info(fmt.Sprintf("交易对: %s", symbol))
 	tc.logger.Info(fmt.Sprintf("决策动作: %s", action))
@@ -81,10 +117,21 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	tc.logger.Info("\n[步骤 3/5] 验证交易动作...")
 	if err := tc.validateAction(action, currentPosition); err != nil {
 		tc.logger.Error(fmt.Sprintf("❌ 动作验证失败: %v", err))
-		return nil, fmt.Errorf("action validation failed: %w", err)
+		return nil, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("action validation failed: %w", err))
 	}
 	tc.logger.Success("✅ 动作验证通过")
 
+	// Inside a scheduled deleverage window entries are held to the window's leverage ceiling
+	// 处于计划降杠杆时段时，开仓杠杆不超过时段上限
+	if action == ActionBuy || action == ActionSell {
+		if window, _, active := tc.deleverage.Active(time.Now()); active {
+			if capped, ok := tc.deleverage.CapLeverage(leverage, tc.config.BinanceLeverage); ok {
+				tc.logger.Warning(fmt.Sprintf("🛡️  降杠杆时段 %s: 杠杆下调为 %dx", window.Label, capped))
+				leverage = capped
+			}
+		}
+	}
+
 	// Step 4: Update leverage if LLM provided recommendation
 	// 步骤 4: 如果 LLM 提供了杠杆建议，更新杠杆设置
 	if leverage > 0 {
@@ -101,7 +148,7 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	// Step 5: Calculate position size
 	// 步骤 5: 计算仓位大小
 	tc.logger.Info("\n[步骤 5/7] 计算仓位大小...")
-	positionSize, err := tc.calculatePositionSize(ctx, symbol, action, currentPosition, leverage, positionSizePercent)
+	positionSize, orderLeverage, err := tc.calculatePositionSize(ctx, symbol, action, currentPosition, leverage, positionSizePercent, hints)
 	if err != nil {
 		tc.logger.Error(fmt.Sprintf("❌ 仓位计算失败: %v", err))
 		return nil, fmt.Errorf("position size calculation failed: %w", err)
@@ -127,6 +174,7 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 	}
 
 	result := tc.executor.ExecuteTrade(ctx, symbol, action, positionSize, reason)
+	result.Leverage = orderLeverage
 
 	// Step 7: Post-execution verification
 	// 步骤 7: 执行后验证
@@ -145,11 +193,60 @@ func (tc *TradeCoordinator) ExecuteDecisionWithParams(ctx context.Context, symbo
 // preExecutionChecks performs safety checks before executing a trade
 // preExecutionChecks 在执行交易前进行安全检查
 func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol string, action TradeAction) error {
+	// Check 0a: Only the instance holding the symbol lease may trade it
+	// 检查 0a: 只有持有交易对租约的实例才能交易该交易对
+	if !tc.executor.ManagesSymbol(symbol) {
+		return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("【%s】由其他实例管理", symbol))
+	}
+
+	// Check 0: Refuse new entries while the balance guard is tripped or the symbol is paused; closes still go through
+	// 检查 0: 余额守护触发或交易对已暂停时拒绝开仓，平仓仍然放行
+	if action == ActionBuy || action == ActionSell {
+		if halted, reason := tc.executor.EntriesHalted(); halted {
+			return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("开仓已暂停: %s", reason))
+		}
+		if paused, reason := tc.executor.SymbolPaused(symbol); paused {
+			return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("【%s】交易已暂停: %s", symbol, reason))
+		}
+	}
+
+	// Check 0b: Keep the number of symbols holding a position within MAX_OPEN_POSITIONS
+	// 检查 0b: 持有仓位的交易对数量不超过 MAX_OPEN_POSITIONS
+	if (action == ActionBuy || action == ActionSell) && tc.config.MaxOpenPositions > 0 {
+		open, err := tc.otherOpenPositions(ctx, symbol)
+		if err != nil {
+			return Categorize(storage.ErrorCategoryExchange, fmt.Errorf("无法统计持仓数量: %w", err))
+		}
+		if len(open) >= tc.config.MaxOpenPositions {
+			return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("持仓数已达上限 %d/%d（%s），跳过【%s】开仓",
+				len(open), tc.config.MaxOpenPositions, strings.Join(open, "、"), symbol))
+		}
+		tc.logger.Info(fmt.Sprintf("  ✓ 持仓数: %d/%d", len(open), tc.config.MaxOpenPositions))
+	}
+
+	// Check 0c: Refuse entries whose expected funding cost over the holding horizon exceeds FUNDING_COST_MAX_PERCENT
+	// 检查 0c: 持有期内预计资金费成本超过 FUNDING_COST_MAX_PERCENT 时拒绝开仓
+	if (action == ActionBuy || action == ActionSell) && tc.config.FundingCostMaxPercent > 0 {
+		side := "long"
+		if action == ActionSell {
+			side = "short"
+		}
+		estimate, err := tc.executor.FundingCost(ctx, symbol, side, tc.fundingHorizon())
+		if err != nil {
+			tc.logger.Warning(fmt.Sprintf("  ⚠️  无法预估资金费成本，跳过检查: %v", err))
+		} else if estimate.CostPercent > tc.config.FundingCostMaxPercent {
+			return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("资金费成本过高（%s，上限 %.3f%%），否决【%s】开%s",
+				estimate, tc.config.FundingCostMaxPercent, symbol, sideLabel(side)))
+		} else {
+			tc.logger.Info(fmt.Sprintf("  ✓ 资金费成本: %s", estimate))
+		}
+	}
+
 	// Check 1: Verify balance
 	// 检查 1: 验证余额
-	account, err := tc.executor.client.NewGetAccountService().Do(ctx)
+	account, err := tc.executor.GetAccountInfo(ctx)
 	if err != nil {
-		return fmt.Errorf("无法获取账户信息: %w", err)
+		return Categorize(storage.ErrorCategoryExchange, fmt.Errorf("无法获取账户信息: %w", err))
 	}
 
 	var availableBalance float64
@@ -161,7 +258,7 @@ func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol strin
 	}
 
 	if availableBalance < 10.0 { // Minimum balance check
-		return fmt.Errorf("可用余额不足: %.2f USDT < 10 USDT", availableBalance)
+		return Categorize(storage.ErrorCategoryRisk, fmt.Errorf("可用余额不足: %.2f USDT < 10 USDT", availableBalance))
 	}
 
 	tc.logger.Info(fmt.Sprintf("  ✓ 账户余额: %.2f USDT", availableBalance))
@@ -171,7 +268,7 @@ func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol strin
 	binanceSymbol := tc.config.GetBinanceSymbolFor(symbol)
 	ticker, err := tc.executor.client.NewListPriceChangeStatsService().Symbol(binanceSymbol).Do(ctx)
 	if err != nil {
-		return fmt.Errorf("无法获取交易对价格: %w", err)
+		return Categorize(storage.ErrorCategoryExchange, fmt.Errorf("无法获取交易对价格: %w", err))
 	}
 
 	if len(ticker) == 0 {
@@ -183,6 +280,37 @@ func (tc *TradeCoordinator) preExecutionChecks(ctx context.Context, symbol strin
 	return nil
 }
 
+// fundingHorizon returns the holding horizon funding costs are projected over
+// fundingHorizon 返回预估资金费成本所用的持有期
+func (tc *TradeCoordinator) fundingHorizon() time.Duration {
+	return time.Duration(tc.config.FundingCostHorizonHours) * time.Hour
+}
+
+// FundingCostAdvice checks a held position against FUNDING_COST_MAX_PERCENT and returns advice to close it early
+// when holding it over the horizon is expected to cost more; ok is false when there is nothing to advise
+// FundingCostAdvice 按 FUNDING_COST_MAX_PERCENT 检查已有持仓，持有期内预计资金费成本超过上限时返回提前平仓建议；
+// 无建议时 ok 为 false
+func (tc *TradeCoordinator) FundingCostAdvice(ctx context.Context, symbol string) (string, bool) {
+	if tc.config.FundingCostMaxPercent <= 0 {
+		return "", false
+	}
+	pos, err := tc.executor.GetCurrentPosition(ctx, symbol)
+	if err != nil || pos == nil || pos.Size == 0 {
+		return "", false
+	}
+	estimate, err := tc.executor.FundingCost(ctx, symbol, pos.Side, tc.fundingHorizon())
+	if err != nil {
+		tc.logger.Warning(fmt.Sprintf("⚠️  【%s】无法预估持仓资金费成本: %v", symbol, err))
+		return "", false
+	}
+	if estimate.CostPercent <= tc.config.FundingCostMaxPercent {
+		return "", false
+	}
+	return fmt.Sprintf("💸【%s】继续持有%s %dh 的资金费成本过高（%s，上限 %.3f%%），建议提前平仓",
+		symbol, sideLabel(pos.Side), tc.config.FundingCostHorizonHours,
+		estimate, tc.config.FundingCostMaxPercent), true
+}
+
 // validateAction validates the action against current position
 // validateAction 验证动作与当前持仓的一致性
 func (tc *TradeCoordinator) validateAction(action TradeAction, currentPosition *Position) error {
@@ -219,42 +347,43 @@ func (tc *TradeCoordinator) validateAction(action TradeAction, currentPosition *
 	return nil
 }
 
-// calculatePositionSize calculates the position size for the trade
-// calculatePositionSize 计算交易的仓位大小
-func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol string, action TradeAction, currentPosition *Position, llmLeverage int, positionSizePercent float64) (float64, error) {
+// calculatePositionSize calculates the position size for the trade and the leverage it is opened with
+// calculatePositionSize 计算交易的仓位大小及开仓使用的杠杆
+func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol string, action TradeAction, currentPosition *Position, llmLeverage int, positionSizePercent float64, hints SizingHints) (float64, int, error) {
 	// For close actions, use the current position size
 	// 平仓动作使用当前持仓大小
 	if action == ActionCloseLong || action == ActionCloseShort {
 		if currentPosition == nil {
-			return 0, fmt.Errorf("无持仓可平")
+			return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("无持仓可平"))
 		}
-		return currentPosition.Size, nil
+		return currentPosition.Size, currentPosition.Leverage, nil
 	}
 
-	// For open actions, LLM MUST provide position size recommendation
-	// 开仓动作必须由 LLM 提供仓位建议
-	if positionSizePercent <= 0 {
-		return 0, fmt.Errorf("❌ LLM 未提供仓位建议（positionSizePercent = %.1f%%），拒绝交易。请确保 LLM 决策中包含'仓位建议: XX%%'字段", positionSizePercent)
+	// For open actions, use the LLM's position size; without one the entry is sized from its stop distance
+	// 开仓动作使用 LLM 的仓位建议；未提供时按止损距离计算仓位
+	riskSized := positionSizePercent <= 0
+	if riskSized && hints.StopLoss <= 0 {
+		return 0, 0, Categorize(storage.ErrorCategoryLLM, fmt.Errorf("❌ LLM 未提供仓位建议（positionSizePercent = %.1f%%）也未提供止损价格，拒绝交易。请确保 LLM 决策中包含'仓位建议: XX%%'或'初始止损: $价格'字段", positionSizePercent))
 	}
 
 	// Validate position size percentage range
 	// 验证仓位百分比范围
 	if positionSizePercent > 100 {
-		return 0, fmt.Errorf("❌ LLM 仓位建议超过 100%% (%.1f%%)，拒绝交易", positionSizePercent)
+		return 0, 0, Categorize(storage.ErrorCategoryLLM, fmt.Errorf("❌ LLM 仓位建议超过 100%% (%.1f%%)，拒绝交易", positionSizePercent))
 	}
 
 	// Get account balance
 	// 获取账户余额
 	balance, err := tc.executor.GetBalance(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("获取账户余额失败: %w", err)
+		return 0, 0, Categorize(storage.ErrorCategoryExchange, fmt.Errorf("获取账户余额失败: %w", err))
 	}
 
 	// Get current price
 	// 获取当前价格
 	currentPrice, err := tc.executor.GetCurrentPrice(ctx, symbol)
 	if err != nil {
-		return 0, fmt.Errorf("获取当前价格失败: %w", err)
+		return 0, 0, Categorize(storage.ErrorCategoryExchange, fmt.Errorf("获取当前价格失败: %w", err))
 	}
 
 	// Use LLM leverage if provided, otherwise use config default
@@ -264,6 +393,37 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 		actualLeverage = tc.config.BinanceLeverage
 	}
 
+	side := "long"
+	if action == ActionSell {
+		side = "short"
+	}
+
+	// Without an LLM size, risk the configured share of equity at the stop and pick the leverage that carries it;
+	// the leverage passed in (the LLM's, possibly lowered by a regime ceiling) is the highest allowed
+	// 没有 LLM 仓位时，按止损触发时承担配置比例的权益风险计算数量，并选择承载该仓位的杠杆；
+	// 传入的杠杆（LLM 建议，可能已被市场状态上限下调）为允许的最高杠杆
+	if riskSized {
+		maxLeverage := tc.config.BinanceLeverageMax
+		if llmLeverage > 0 {
+			maxLeverage = llmLeverage
+		}
+		size, err := RiskBasedSize(side, balance, currentPrice, hints.StopLoss,
+			tc.config.RiskSizingRiskPercent, tc.config.RiskSizingMarginPercent,
+			min(tc.config.BinanceLeverageMin, maxLeverage), maxLeverage)
+		if err != nil {
+			return 0, 0, Categorize(storage.ErrorCategoryLLM, fmt.Errorf("按止损距离计算仓位失败: %w", err))
+		}
+		if size.Leverage != llmLeverage {
+			if err := tc.executor.SetupExchange(ctx, symbol, size.Leverage); err != nil {
+				return 0, 0, Categorize(storage.ErrorCategoryExchange, fmt.Errorf("设置 %dx 杠杆失败: %w", size.Leverage, err))
+			}
+		}
+		tc.logger.Info(fmt.Sprintf("🎯 LLM 未提供仓位，按止损距离计算: %.4f %s，%dx 杠杆，保证金 %.1f%% 权益（%s）",
+			size.Quantity, symbol, size.Leverage, size.MarginPercent, size.Detail))
+		positionSizePercent = size.MarginPercent
+		actualLeverage = size.Leverage
+	}
+
 	// Calculate position size based on percentage and leverage
 	// 根据百分比和杠杆倍数计算仓位大小
 	// Formula: (Balance × Percentage% × Leverage) / Price = Quantity
@@ -273,17 +433,89 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 	rawSize := leveragedFunds / currentPrice
 
 	tc.logger.Info(fmt.Sprintf("💰 账户余额: %.2f USDT", balance))
-	tc.logger.Info(fmt.Sprintf("📊 LLM 建议: %.1f%% 资金 = %.2f USDT (保证金)", positionSizePercent, fundsToUse))
+	tc.logger.Info(fmt.Sprintf("📊 仓位: %.1f%% 资金 = %.2f USDT (保证金)", positionSizePercent, fundsToUse))
 	tc.logger.Info(fmt.Sprintf("⚡ 杠杆倍数: %dx", actualLeverage))
 	tc.logger.Info(fmt.Sprintf("💵 当前价格: $%.2f", currentPrice))
 	tc.logger.Info(fmt.Sprintf("📐 计算数量: %.2f USDT × %d倍 / $%.2f = %.4f %s",
 		fundsToUse, actualLeverage, currentPrice, rawSize, symbol))
 
+	// Clamp the size to the sizing engine's deterministic cap
+	// 用仓位计算引擎的确定性上限限制仓位
+	sizing, capped, err := tc.sizer.Size(SizingRequest{
+		Symbol:   symbol,
+		Side:     side,
+		Equity:   balance,
+		Price:    currentPrice,
+		StopLoss: hints.StopLoss,
+		ATR:      hints.ATR,
+	})
+	if err != nil {
+		return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("仓位计算引擎拒绝开仓: %w", err))
+	}
+	if capped {
+		tc.logger.Info(fmt.Sprintf("🧮 仓位上限（%s）: %.4f %s，止损风险 %.2f%% 权益（%s）",
+			sizing.Mode, sizing.Quantity, symbol, sizing.RiskPercent, sizing.Detail))
+		if rawSize > sizing.Quantity {
+			tc.logger.Warning(fmt.Sprintf("✂️  LLM 建议数量 %.4f 超过上限，削减为 %.4f", rawSize, sizing.Quantity))
+			rawSize = sizing.Quantity
+		}
+	}
+
+	// Keep the portfolio within the total and per-symbol exposure caps
+	// 使组合保持在总敞口和单币敞口上限之内
+	if tc.exposure != nil {
+		equity, open, err := tc.openExposure(ctx, symbol, side)
+		if err != nil {
+			return 0, 0, Categorize(storage.ErrorCategoryExchange, fmt.Errorf("获取组合敞口失败: %w", err))
+		}
+		limited, err := tc.exposure.Cap(ExposureRequest{
+			Symbol:   tc.config.GetBinanceSymbolFor(symbol),
+			Equity:   equity,
+			Price:    currentPrice,
+			Quantity: rawSize,
+			Open:     open,
+		})
+		if err != nil {
+			return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("敞口上限拒绝开仓: %w", err))
+		}
+		if limited.Capped {
+			tc.logger.Warning(fmt.Sprintf("✂️  敞口上限（%s）: 数量 %.4f 削减为 %.4f", limited.Detail, rawSize, limited.Quantity))
+			rawSize = limited.Quantity
+		}
+	}
+
+	// Scheduled deleverage windows scale entries down
+	// 计划降杠杆时段内按比例缩小开仓数量
+	if window, _, active := tc.deleverage.Active(time.Now()); active && tc.deleverage.SizeFactor() < 1 {
+		scaled := rawSize * tc.deleverage.SizeFactor()
+		tc.logger.Warning(fmt.Sprintf("🛡️  降杠杆时段 %s: 数量 %.4f 缩小为 %.4f（%.0f%%）", window.Label, rawSize, scaled, tc.deleverage.SizeFactor()*100))
+		rawSize = scaled
+	}
+
+	// Hard cap on the loss at the stop, applied last so no sizing path can exceed it
+	// 止损亏损硬上限，最后执行以确保任何仓位计算方式都不会超出
+	riskCap, err := CapTradeRisk(side, balance, currentPrice, hints.StopLoss, rawSize, tc.config.MaxTradeRiskPercent)
+	if err != nil {
+		return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("单笔风险上限检查失败: %w", err))
+	}
+	if riskCap.Capped {
+		tc.logger.Warning(fmt.Sprintf("🛑 单笔风险上限 %.2f%%: %s，止损亏损 %.2f%% 权益，数量 %.4f 削减为 %.4f",
+			tc.config.MaxTradeRiskPercent, riskCap.Detail, riskCap.RiskPercent, rawSize, riskCap.Quantity))
+		rawSize = riskCap.Quantity
+	}
+
 	// Adjust quantity to meet symbol's precision and minimum quantity requirements
 	// 调整数量以符合交易对的精度和最小数量要求
 	adjustedSize, err := AdjustQuantityPrecision(symbol, rawSize)
 	if err != nil {
-		return 0, fmt.Errorf("精度调整失败: %w", err)
+		return 0, 0, fmt.Errorf("精度调整失败: %w", err)
+	}
+	if riskCap.Capped && adjustedSize > riskCap.Quantity {
+		// Rounding must not carry a capped quantity back over the cap
+		// 四舍五入不能让受限数量重新超过上限
+		if adjustedSize, err = FloorQuantityPrecision(symbol, riskCap.Quantity); err != nil {
+			return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf("单笔风险上限下数量不足: %w", err))
+		}
 	}
 
 	tc.logger.Info(fmt.Sprintf("原始数量: %.4f → 调整后: %.4f (符合 %s 精度要求)", rawSize, adjustedSize, symbol))
@@ -294,7 +526,7 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 	minNotional := 100.0
 
 	if notionalValue < minNotional {
-		return 0, fmt.Errorf(`
+		return 0, 0, Categorize(storage.ErrorCategoryRisk, fmt.Errorf(`
 ❌ 订单价值不足: $%.2f < $%.2f (币安最小要求)
 
 原因分析：
@@ -315,12 +547,73 @@ func (tc *TradeCoordinator) calculatePositionSize(ctx context.Context, symbol st
 			rawSize, adjustedSize,
 			(minNotional/float64(actualLeverage)/balance)*100,
 			balance, actualLeverage,
-			(minNotional/float64(actualLeverage)/balance)*100)
+			(minNotional/float64(actualLeverage)/balance)*100))
 	}
 
 	tc.logger.Success(fmt.Sprintf("✅ 订单价值: $%.2f ≥ $%.2f (符合要求)", notionalValue, minNotional))
 
-	return adjustedSize, nil
+	return adjustedSize, actualLeverage, nil
+}
+
+// OpenExposure returns the account equity and the open notional the exposure caps are checked against
+// OpenExposure 返回账户权益以及敞口上限所依据的持仓名义价值
+func (tc *TradeCoordinator) OpenExposure(ctx context.Context, symbol, side string) (float64, map[string]float64, error) {
+	return tc.openExposure(ctx, symbol, side)
+}
+
+// openExposure returns the account equity and the open notional of every configured symbol
+// openExposure 返回账户权益及所有配置交易对的持仓名义价值
+// A position in symbol on the opposite side is left out, since the entry reverses it
+// 与本次开仓方向相反的同币持仓不计入，因为开仓会将其反转
+func (tc *TradeCoordinator) openExposure(ctx context.Context, symbol, side string) (float64, map[string]float64, error) {
+	account, err := tc.executor.GetAccountInfo(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get account info: %w", err)
+	}
+	equity, err := parseFloat(account.TotalMarginBalance)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to parse margin balance: %w", err)
+	}
+
+	open := make(map[string]float64)
+	target := tc.config.GetBinanceSymbolFor(symbol)
+	for _, s := range tc.config.CryptoSymbols {
+		pos, err := tc.executor.GetCurrentPosition(ctx, s)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to get position for %s: %w", s, err)
+		}
+		if pos == nil || pos.Size == 0 {
+			continue
+		}
+		binanceSymbol := tc.config.GetBinanceSymbolFor(s)
+		if binanceSymbol == target && pos.Side != side {
+			continue
+		}
+		open[binanceSymbol] += positionNotional(pos)
+	}
+	return equity, open, nil
+}
+
+// otherOpenPositions returns the configured symbols other than symbol that hold a position
+// otherOpenPositions 返回除 symbol 外持有仓位的配置交易对
+// The symbol itself is left out: an entry there reverses or adds to its position without taking another slot
+// 不计入 symbol 本身：在该交易对开仓只会反转或加仓，不会占用新的名额
+func (tc *TradeCoordinator) otherOpenPositions(ctx context.Context, symbol string) ([]string, error) {
+	target := tc.config.GetBinanceSymbolFor(symbol)
+	var open []string
+	for _, s := range tc.config.CryptoSymbols {
+		if tc.config.GetBinanceSymbolFor(s) == target {
+			continue
+		}
+		pos, err := tc.executor.GetCurrentPosition(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get position for %s: %w", s, err)
+		}
+		if pos != nil && pos.Size > 0 {
+			open = append(open, s)
+		}
+	}
+	return open, nil
 }
 
 // postExecutionVerification verifies the trade was executed correctly
@@ -379,6 +672,9 @@ func (tc *TradeCoordinator) GetExecutionSummary(result *TradeResult) string {
 	summary += fmt.Sprintf("交易对: %s\n", result.Symbol)
 	summary += fmt.Sprintf("动作: %s\n", result.Action)
 	summary += fmt.Sprintf("数量: %.4f\n", result.Amount)
+	if result.Filled > 0 && result.Filled < result.Amount {
+		summary += fmt.Sprintf("实际成交: %.4f（部分成交）\n", result.Filled)
+	}
 	summary += fmt.Sprintf("时间: %s\n", result.Timestamp)
 	summary += fmt.Sprintf("理由: %s\n", result.Reason)
 
@@ -389,6 +685,9 @@ func (tc *TradeCoordinator) GetExecutionSummary(result *TradeResult) string {
 	if result.OrderID != "" {
 		summary += fmt.Sprintf("\n订单ID: %s\n", result.OrderID)
 	}
+	if result.ReferencePrice > 0 {
+		summary += fmt.Sprintf("成交价: $%.2f（下单前标记价 $%.2f，滑点 %+.1f bps）\n", result.Price, result.ReferencePrice, result.SlippageBps)
+	}
 
 	if result.NewPosition != nil {
 		summary += "\n当前持仓:\n"
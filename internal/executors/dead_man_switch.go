@@ -0,0 +1,328 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// minDeadManCheckInterval keeps connectivity probes from eating the request weight budget
+// minDeadManCheckInterval 限制连通性探测频率，避免占用过多请求权重
+const minDeadManCheckInterval = 5 * time.Second
+
+// DeadManSwitch cleans up the account when the bot stops, crashes or loses the exchange
+// DeadManSwitch 在程序停止、崩溃或与交易所失联时清理账户
+//
+// When tripped it cancels the bot's open entry orders on every symbol it manages; exchange-side
+// stop-loss orders are kept because they are what protects a position while the bot is gone.
+// With flatten enabled it closes the positions at market and cancels every order instead.
+// Optionally Binance's countdownCancelAll is armed and renewed by the connectivity probe, so the
+// exchange cancels all orders by itself if the heartbeat stops (this includes stop-loss orders).
+// 触发时撤销所管理交易对上的开仓挂单；交易所止损单会保留，因为程序离线时由它保护持仓。
+// 启用平仓后改为市价平仓并撤销全部挂单。
+// 可选启用币安 countdownCancelAll，由连通性探测续期；心跳停止时交易所自行撤销全部挂单（包括止损单）。
+type DeadManSwitch struct {
+	executor        *BinanceExecutor    // 执行器 / Executor
+	stopLossManager *StopLossManager    // 止损管理器 / Stop-loss manager
+	logger          *logger.ColorLogger // 日志 / Logger
+	symbols         []string            // 交易对 / Trading pairs
+	flatten         bool                // 触发时是否平仓 / Flatten positions when tripped
+	countdown       bool                // 是否启用交易所倒计时撤单 / Arm the exchange countdown
+	timeout         time.Duration       // 失联多久后触发 / Time without contact before tripping
+
+	mu          sync.Mutex // 保护以下字段 / Protects the fields below
+	lastContact time.Time  // 最近一次成功连接交易所的时间 / Last successful exchange contact
+	tripped     bool       // 本次失联是否已触发 / Whether the current outage already tripped
+}
+
+// NewDeadManSwitch creates the switch described by the configuration, or nil when it is disabled
+// NewDeadManSwitch 按配置创建死人开关，未启用时返回 nil
+func NewDeadManSwitch(cfg *config.Config, executor *BinanceExecutor, sm *StopLossManager, log *logger.ColorLogger) *DeadManSwitch {
+	// A paper executor has no orders on the exchange to cancel
+	// 模拟盘在交易所没有需要撤销的订单
+	if !cfg.DeadManSwitchEnabled || cfg.PaperTrading {
+		return nil
+	}
+	return &DeadManSwitch{
+		executor:        executor,
+		stopLossManager: sm,
+		logger:          log,
+		symbols:         cfg.CryptoSymbols,
+		flatten:         cfg.DeadManSwitchFlatten,
+		countdown:       cfg.DeadManSwitchCountdown,
+		timeout:         time.Duration(cfg.DeadManSwitchTimeoutSeconds) * time.Second,
+		lastContact:     time.Now(),
+	}
+}
+
+// Run probes exchange connectivity until ctx is cancelled and trips the switch after a long outage
+// Run 持续探测交易所连通性直到 ctx 取消，长时间失联后触发开关
+func (d *DeadManSwitch) Run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	interval := d.timeout / 3
+	if interval < minDeadManCheckInterval {
+		interval = minDeadManCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	d.check(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.check(ctx)
+		}
+	}
+}
+
+// check runs one connectivity probe and renews the exchange countdown on success
+// check 执行一次连通性探测，成功时续期交易所倒计时
+func (d *DeadManSwitch) check(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	if err := d.executor.client.NewPingService().Do(probeCtx); err == nil {
+		d.mu.Lock()
+		recovered := d.tripped
+		d.lastContact = now
+		d.tripped = false
+		d.mu.Unlock()
+		if recovered {
+			d.logger.Success("✅ 已恢复与交易所的连接，死人开关重新待命")
+		}
+		if d.countdown {
+			d.armCountdown(probeCtx, d.timeout)
+		}
+		return
+	}
+
+	d.mu.Lock()
+	lastContact, tripped := d.lastContact, d.tripped
+	d.mu.Unlock()
+	if tripped || !connectivityLost(lastContact, now, d.timeout) {
+		return
+	}
+
+	reason := fmt.Sprintf("与交易所失联超过 %v", d.timeout)
+	if err := d.Trigger(ctx, reason); err != nil {
+		// Still offline most likely; try again on the next probe
+		// 很可能仍然离线，下次探测时重试
+		d.logger.Warning(fmt.Sprintf("⚠️ 死人开关执行未完成，下次探测时重试: %v", err))
+		return
+	}
+	d.mu.Lock()
+	d.tripped = true
+	d.mu.Unlock()
+}
+
+// connectivityLost reports whether the last exchange contact is older than timeout
+// connectivityLost 判断最近一次连接交易所的时间是否已超过 timeout
+func connectivityLost(lastContact, now time.Time, timeout time.Duration) bool {
+	return timeout > 0 && now.Sub(lastContact) > timeout
+}
+
+// Trigger cancels entry orders, or flattens and cancels everything when flatten is enabled
+// Trigger 撤销开仓挂单；启用平仓时改为平仓并撤销全部挂单
+func (d *DeadManSwitch) Trigger(ctx context.Context, reason string) error {
+	if d == nil {
+		return nil
+	}
+	d.logger.Error(fmt.Sprintf("💀 死人开关触发: %s", reason))
+
+	var errs []error
+	for _, symbol := range d.symbols {
+		binanceSymbol := d.executor.config.GetBinanceSymbolFor(symbol)
+		if !d.executor.ManagesSymbol(binanceSymbol) {
+			continue
+		}
+		if d.flatten {
+			if err := d.flattenSymbol(ctx, binanceSymbol, reason); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", binanceSymbol, err))
+			}
+		}
+		if err := d.cancelOrders(ctx, binanceSymbol); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", binanceSymbol, err))
+		}
+	}
+
+	action := "已撤销开仓挂单，保留止损单"
+	if d.flatten {
+		action = "已平仓并撤销全部挂单"
+	}
+	if err := errors.Join(errs...); err != nil {
+		d.stopLossManager.notifier.Notify(notify.SeverityCritical, "全部交易对", fmt.Sprintf("💀 死人开关触发（%s），执行失败: %v", reason, err))
+		return err
+	}
+	d.logger.Warning(fmt.Sprintf("💀 死人开关执行完成: %s", action))
+	d.stopLossManager.notifier.Notify(notify.SeverityCritical, "全部交易对", fmt.Sprintf("💀 死人开关触发（%s），%s", reason, action))
+	return nil
+}
+
+// Shutdown trips the switch for a graceful stop and disarms the exchange countdown
+// Shutdown 在正常停止时触发开关，并解除交易所倒计时
+//
+// Without disarming, the countdown would later cancel the stop-loss orders that were deliberately kept.
+// 若不解除，倒计时稍后会撤销刻意保留的止损单。
+func (d *DeadManSwitch) Shutdown(ctx context.Context, reason string) {
+	if d == nil {
+		return
+	}
+	if err := d.Trigger(ctx, reason); err != nil {
+		d.logger.Error(fmt.Sprintf("❌ 死人开关执行失败: %v", err))
+	}
+	if d.countdown {
+		d.armCountdown(ctx, 0)
+	}
+}
+
+// flattenSymbol closes the symbol's position at market and releases it from the stop-loss manager
+// flattenSymbol 市价平掉交易对持仓，并从止损管理器中移除
+func (d *DeadManSwitch) flattenSymbol(ctx context.Context, symbol, reason string) error {
+	pos, err := d.executor.GetCurrentPosition(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if pos == nil || pos.Size == 0 {
+		return nil
+	}
+
+	action := ActionCloseLong
+	if pos.Side == "short" {
+		action = ActionCloseShort
+	}
+	closeReason := fmt.Sprintf("死人开关: %s", reason)
+	result := d.executor.ExecuteTrade(ctx, symbol, action, pos.Size, closeReason)
+	if !result.Success {
+		return fmt.Errorf("failed to flatten position: %s", result.Message)
+	}
+	d.logger.Warning(fmt.Sprintf("💀【%s】已市价平仓 %.4f @ %.2f", symbol, result.FilledQuantity(), result.Price))
+
+	if !d.stopLossManager.HasPosition(symbol) {
+		return nil
+	}
+	realizedPnL := (result.Price - pos.EntryPrice) * result.FilledQuantity()
+	if pos.Side == "short" {
+		realizedPnL = -realizedPnL
+	}
+	return d.stopLossManager.ClosePosition(ctx, symbol, result.Price, closeReason, realizedPnL)
+}
+
+// cancelOrders cancels every open order after a flatten, otherwise only the non-protective ones
+// cancelOrders 平仓后撤销全部挂单，否则只撤销非保护性挂单
+func (d *DeadManSwitch) cancelOrders(ctx context.Context, symbol string) error {
+	if d.flatten {
+		return d.executor.withRetry(func() error {
+			return d.executor.client.NewCancelAllOpenOrdersService().Symbol(symbol).Do(ctx)
+		})
+	}
+
+	orders, err := d.executor.client.NewListOpenOrdersService().Symbol(symbol).Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list open orders: %w", err)
+	}
+	stopOrderIDs := map[int64]bool{}
+	if pos := d.stopLossManager.GetPosition(symbol); pos != nil && pos.StopLossOrderID != "" {
+		if id, err := strconv.ParseInt(pos.StopLossOrderID, 10, 64); err == nil {
+			stopOrderIDs[id] = true
+		}
+	}
+	cancelled := 0
+	for _, order := range orders {
+		if isProtectiveOrder(order, stopOrderIDs) {
+			continue
+		}
+		_, err := d.executor.client.NewCancelOrderService().Symbol(symbol).OrderID(order.OrderID).Do(ctx)
+		if err != nil && !isOrderNotFoundError(err) {
+			return fmt.Errorf("failed to cancel order %d: %w", order.OrderID, err)
+		}
+		cancelled++
+	}
+	if cancelled > 0 {
+		d.logger.Warning(fmt.Sprintf("💀【%s】已撤销 %d 个开仓挂单", symbol, cancelled))
+	}
+	return nil
+}
+
+// isProtectiveOrder reports whether an open order guards a position and must survive a trip
+// isProtectiveOrder 判断挂单是否保护持仓、触发时必须保留
+// Hedge-mode stops close by position side and carry no reduceOnly flag, so the order type and the stop-loss
+// manager's own stop order IDs decide, not the flag alone
+// 双向持仓的止损单按持仓方向平仓、不带 reduceOnly 标志，因此按订单类型和止损管理器记录的止损单 ID 判断，而不仅依赖该标志
+func isProtectiveOrder(order *futures.Order, stopOrderIDs map[int64]bool) bool {
+	return triggeredOrderTypes[order.Type] || stopOrderIDs[order.OrderID] || order.ReduceOnly || order.ClosePosition
+}
+
+// armCountdown renews Binance's countdownCancelAll on every managed symbol; a zero countdown disarms it
+// armCountdown 为所管理的交易对续期币安 countdownCancelAll；倒计时为 0 时解除
+func (d *DeadManSwitch) armCountdown(ctx context.Context, countdown time.Duration) {
+	for _, symbol := range d.symbols {
+		binanceSymbol := d.executor.config.GetBinanceSymbolFor(symbol)
+		if !d.executor.ManagesSymbol(binanceSymbol) {
+			continue
+		}
+		if err := d.executor.setCountdownCancelAll(ctx, binanceSymbol, countdown); err != nil {
+			d.logger.Warning(fmt.Sprintf("⚠️【%s】设置交易所倒计时撤单失败: %v", binanceSymbol, err))
+		}
+	}
+}
+
+// setCountdownCancelAll calls POST /fapi/v1/countdownCancelAll, which go-binance does not wrap
+// setCountdownCancelAll 调用 go-binance 未封装的 POST /fapi/v1/countdownCancelAll
+func (e *BinanceExecutor) setCountdownCancelAll(ctx context.Context, symbol string, countdown time.Duration) error {
+	form := url.Values{}
+	form.Set("symbol", symbol)
+	form.Set("countdownTime", fmt.Sprintf("%d", countdown.Milliseconds()))
+	form.Set("timestamp", fmt.Sprintf("%d", time.Now().UnixMilli()-e.client.TimeOffset))
+
+	keyType := e.client.KeyType
+	if keyType == "" {
+		keyType = common.KeyTypeHmac
+	}
+	sign, err := common.SignFunc(keyType)
+	if err != nil {
+		return err
+	}
+	signature, err := sign(e.client.SecretKey, form.Encode())
+	if err != nil {
+		return fmt.Errorf("failed to sign countdown request: %w", err)
+	}
+	// The signature goes last so the signed string stays exactly as sent
+	// 签名放在最后，使被签名的字符串与发送内容完全一致
+	body := form.Encode() + "&signature=" + *signature
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.client.BaseURL+"/fapi/v1/countdownCancelAll", strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-MBX-APIKEY", e.client.APIKey)
+
+	resp, err := e.client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to set countdown: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("countdownCancelAll returned status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package executors
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestConnectivityLost(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		lastContact time.Time
+		timeout     time.Duration
+		want        bool
+	}{
+		{"Recent contact", now.Add(-30 * time.Second), 2 * time.Minute, false},
+		{"Outage beyond timeout", now.Add(-3 * time.Minute), 2 * time.Minute, true},
+		{"Zero timeout never trips", now.Add(-time.Hour), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connectivityLost(tt.lastContact, now, tt.timeout); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsProtectiveOrder(t *testing.T) {
+	stopIDs := map[int64]bool{7: true}
+	tests := []struct {
+		name  string
+		order futures.Order
+		want  bool
+	}{
+		// Hedge mode: protective orders carry a position side instead of reduceOnly
+		// 双向持仓：保护性订单带持仓方向而不是 reduceOnly
+		{"Hedge stop-loss", futures.Order{OrderID: 1, Type: futures.OrderTypeStopMarket, PositionSide: futures.PositionSideTypeLong}, true},
+		{"Hedge take-profit", futures.Order{OrderID: 2, Type: futures.OrderTypeTakeProfitMarket, PositionSide: futures.PositionSideTypeShort}, true},
+		{"Hedge trailing stop", futures.Order{OrderID: 3, Type: futures.OrderTypeTrailingStopMarket, PositionSide: futures.PositionSideTypeLong}, true},
+		{"Hedge limit entry", futures.Order{OrderID: 4, Type: futures.OrderTypeLimit, PositionSide: futures.PositionSideTypeLong}, false},
+		{"Managed stop by ID", futures.Order{OrderID: 7, Type: futures.OrderTypeLimit, PositionSide: futures.PositionSideTypeLong}, true},
+		// One-way mode
+		// 单向持仓
+		{"Reduce-only limit", futures.Order{OrderID: 5, Type: futures.OrderTypeLimit, ReduceOnly: true}, true},
+		{"Limit entry", futures.Order{OrderID: 6, Type: futures.OrderTypeLimit}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isProtectiveOrder(&tt.order, stopIDs); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSetCountdownCancelAllSignsRequest(t *testing.T) {
+	var body, apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/countdownCancelAll" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		body = string(raw)
+		apiKey = r.Header.Get("X-MBX-APIKEY")
+		w.Write([]byte(`{"symbol":"BTCUSDT","countdownTime":"120000"}`))
+	}))
+	defer server.Close()
+
+	client := futures.NewClient("key", "secret")
+	client.BaseURL = server.URL
+	e := &BinanceExecutor{client: client}
+
+	if err := e.setCountdownCancelAll(context.Background(), "BTCUSDT", 2*time.Minute); err != nil {
+		t.Fatalf("setCountdownCancelAll failed: %v", err)
+	}
+	if apiKey != "key" {
+		t.Errorf("Expected API key header, got %q", apiKey)
+	}
+
+	// The signature is the HMAC of everything before it
+	// 签名是其之前全部参数的 HMAC
+	idx := strings.LastIndex(body, "&signature=")
+	if idx < 0 {
+		t.Fatalf("Signature missing from body %q", body)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(body[:idx]))
+	if got, want := body[idx+len("&signature="):], hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Expected signature %s, got %s", want, got)
+	}
+
+	params, _ := url.ParseQuery(body)
+	if params.Get("countdownTime") != "120000" || params.Get("symbol") != "BTCUSDT" {
+		t.Errorf("Unexpected params %v", params)
+	}
+}
@@ -0,0 +1,300 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// deleverageTrigger marks the stop-loss event recorded when a position is reduced for a deleverage window
+// deleverageTrigger 标记持仓因降杠杆时段减仓时记录的止损事件
+const deleverageTrigger = "deleverage"
+
+// deleverageLayout is the layout of a one-off window bound, in UTC
+// deleverageLayout 是一次性时段边界的格式（UTC）
+const deleverageLayout = "2006-01-02T15:04"
+
+const minutesPerWeek = 7 * 24 * 60
+
+var deleverageWeekdays = map[string]int{"mon": 0, "tue": 1, "wed": 2, "thu": 3, "fri": 4, "sat": 5, "sun": 6}
+
+// DeleverageWindow is one period in which leverage and position size are reduced
+// DeleverageWindow 是降低杠杆和仓位的一个时段
+// A weekly window repeats every week (minutes since Monday 00:00 UTC); a dated window happens once
+// 每周时段每周重复（自周一 00:00 UTC 起的分钟数）；带日期的时段只发生一次
+type DeleverageWindow struct {
+	Label      string    // 配置中的原文 / The window as configured
+	weekly     bool      // 是否每周重复 / Whether the window repeats weekly
+	startMin   int       // 每周时段开始 / Start of a weekly window
+	endMin     int       // 每周时段结束 / End of a weekly window
+	start, end time.Time // 一次性时段的起止 / Bounds of a one-off window
+}
+
+// activeSince returns when the occurrence of the window covering now started, or false when now is outside it
+// activeSince 返回覆盖 now 的时段开始时间，now 不在时段内时返回 false
+func (w DeleverageWindow) activeSince(now time.Time) (time.Time, bool) {
+	if !w.weekly {
+		return w.start, !now.Before(w.start) && now.Before(w.end)
+	}
+	now = now.UTC()
+	minute := (int(now.Weekday())+6)%7*24*60 + now.Hour()*60 + now.Minute()
+	elapsed := (minute - w.startMin + minutesPerWeek) % minutesPerWeek
+	length := (w.endMin - w.startMin + minutesPerWeek) % minutesPerWeek
+	if elapsed >= length {
+		return time.Time{}, false
+	}
+	return now.Truncate(time.Minute).Add(-time.Duration(elapsed) * time.Minute), true
+}
+
+// DeleveragePolicy lowers leverage and size during scheduled windows such as weekends and holidays
+// DeleveragePolicy 在周末、节假日等计划时段内降低杠杆和仓位
+// A nil policy (no DELEVERAGE_WINDOWS) is never active
+// policy 为 nil（未配置 DELEVERAGE_WINDOWS）时从不生效
+type DeleveragePolicy struct {
+	windows     []DeleverageWindow
+	maxLeverage int     // 时段内杠杆上限，0 表示不限制 / Leverage ceiling in a window, 0 imposes none
+	sizePercent float64 // 时段内仓位比例（%）/ Position size in a window as % of normal
+}
+
+// NewDeleveragePolicy creates the policy configured by DELEVERAGE_WINDOWS, or nil when no window is configured
+// NewDeleveragePolicy 按 DELEVERAGE_WINDOWS 创建降杠杆策略，未配置时段时返回 nil
+func NewDeleveragePolicy(cfg *config.Config) (*DeleveragePolicy, error) {
+	windows, err := parseDeleverageWindows(cfg.DeleverageWindows)
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+	sizePercent := cfg.DeleverageSizePercent
+	if sizePercent <= 0 || sizePercent > 100 {
+		sizePercent = 100
+	}
+	return &DeleveragePolicy{windows: windows, maxLeverage: cfg.DeleverageMaxLeverage, sizePercent: sizePercent}, nil
+}
+
+// parseDeleverageWindows parses "Fri 20:00/Mon 00:00,2026-12-24T20:00/2026-12-27T00:00" (UTC)
+// parseDeleverageWindows 解析 "Fri 20:00/Mon 00:00,2026-12-24T20:00/2026-12-27T00:00"（UTC）
+func parseDeleverageWindows(value string) ([]DeleverageWindow, error) {
+	var windows []DeleverageWindow
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		bounds := strings.SplitN(entry, "/", 2)
+		if len(bounds) != 2 {
+			return nil, fmt.Errorf("invalid deleverage window %q: expected start/end", entry)
+		}
+		from, to := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+
+		if start, err := time.Parse(deleverageLayout, from); err == nil {
+			end, err := time.Parse(deleverageLayout, to)
+			if err != nil || !end.After(start) {
+				return nil, fmt.Errorf("invalid deleverage window %q: end must be a later %s time", entry, deleverageLayout)
+			}
+			windows = append(windows, DeleverageWindow{Label: entry, start: start, end: end})
+			continue
+		}
+
+		startMin, err := parseWeekMinute(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deleverage window %q: %w", entry, err)
+		}
+		endMin, err := parseWeekMinute(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deleverage window %q: %w", entry, err)
+		}
+		if startMin == endMin {
+			return nil, fmt.Errorf("invalid deleverage window %q: start equals end", entry)
+		}
+		windows = append(windows, DeleverageWindow{Label: entry, weekly: true, startMin: startMin, endMin: endMin})
+	}
+	return windows, nil
+}
+
+// parseWeekMinute parses "Fri 20:00" into minutes since Monday 00:00
+// parseWeekMinute 将 "Fri 20:00" 解析为自周一 00:00 起的分钟数
+func parseWeekMinute(value string) (int, error) {
+	var day string
+	var hour, minute int
+	if n, _ := fmt.Sscanf(value, "%s %d:%d", &day, &hour, &minute); n != 3 {
+		return 0, fmt.Errorf("expected \"Day HH:MM\" or %s, got %q", deleverageLayout, value)
+	}
+	weekday, ok := deleverageWeekdays[strings.ToLower(day)]
+	if !ok || hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid day or time %q", value)
+	}
+	return weekday*24*60 + hour*60 + minute, nil
+}
+
+// Active returns the window covering now and when its current occurrence started
+// Active 返回覆盖 now 的时段及其本次开始时间
+func (p *DeleveragePolicy) Active(now time.Time) (DeleverageWindow, time.Time, bool) {
+	if p == nil {
+		return DeleverageWindow{}, time.Time{}, false
+	}
+	for _, w := range p.windows {
+		if since, ok := w.activeSince(now); ok {
+			return w, since, true
+		}
+	}
+	return DeleverageWindow{}, time.Time{}, false
+}
+
+// CapLeverage lowers an entry's leverage to the window ceiling; 0 (the configured default) is capped too
+// CapLeverage 将开仓杠杆降到时段上限；0（使用配置默认值）同样受限
+func (p *DeleveragePolicy) CapLeverage(leverage, defaultLeverage int) (int, bool) {
+	if p == nil || p.maxLeverage <= 0 {
+		return leverage, false
+	}
+	effective := leverage
+	if effective <= 0 {
+		effective = defaultLeverage
+	}
+	if effective <= p.maxLeverage {
+		return leverage, false
+	}
+	return p.maxLeverage, true
+}
+
+// SizeFactor returns the share of the normal size an entry keeps during a window
+// SizeFactor 返回时段内开仓保留的正常仓位比例
+func (p *DeleveragePolicy) SizeFactor() float64 {
+	if p == nil {
+		return 1
+	}
+	return p.sizePercent / 100
+}
+
+// reductionFactor returns the share of an open position to keep: the size percentage, lowered further so a
+// position opened above the leverage ceiling carries the notional it would have at the ceiling
+// reductionFactor 返回已有持仓保留的比例：按仓位比例，若持仓杠杆高于上限，则进一步降到按上限杠杆应有的名义价值
+func (p *DeleveragePolicy) reductionFactor(leverage int) float64 {
+	factor := p.SizeFactor()
+	if p.maxLeverage > 0 && leverage > p.maxLeverage {
+		factor = math.Min(factor, float64(p.maxLeverage)/float64(leverage))
+	}
+	return factor
+}
+
+// describe summarizes the limits applied during a window
+// describe 概述时段内生效的限制
+func (p *DeleveragePolicy) describe() string {
+	limits := []string{fmt.Sprintf("仓位降至 %.0f%%", p.sizePercent)}
+	if p.maxLeverage > 0 {
+		limits = append([]string{fmt.Sprintf("杠杆上限 %dx", p.maxLeverage)}, limits...)
+	}
+	return strings.Join(limits, "，")
+}
+
+// MonitorDeleverage announces deleverage windows and reduces open positions when one begins, until ctx is cancelled
+// MonitorDeleverage 通知降杠杆时段的开始和结束，并在时段开始时减少已有持仓，直到 ctx 取消
+// Each position is reduced once per window; the stop-loss event recorded with the reduction survives restarts
+// 每个持仓在每个时段内只减仓一次；减仓时记录的止损事件在重启后依然有效
+func (sm *StopLossManager) MonitorDeleverage(ctx context.Context, policy *DeleveragePolicy, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sm.logger.Info(fmt.Sprintf("🛡️ 启动计划降杠杆监控，间隔: %v", interval))
+	var current string
+	for {
+		window, since, active := policy.Active(time.Now())
+		switch {
+		case active && window.Label != current:
+			msg := fmt.Sprintf("🛡️ 进入降杠杆时段 %s：%s", window.Label, policy.describe())
+			sm.logger.Warning(msg)
+			sm.notifier.Notify(notify.SeverityInfo, "全部交易对", msg)
+		case !active && current != "":
+			msg := fmt.Sprintf("✅ 降杠杆时段 %s 已结束，恢复正常杠杆和仓位限制", current)
+			sm.logger.Success(msg)
+			sm.notifier.Notify(notify.SeverityInfo, "全部交易对", msg)
+		}
+		current = window.Label
+		if active {
+			sm.deleveragePositions(ctx, policy, window, since)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deleveragePositions reduces every managed position opened before the window and not yet reduced in it
+// deleveragePositions 减少所有在时段开始前开仓、且本时段尚未减仓的受管理持仓
+func (sm *StopLossManager) deleveragePositions(ctx context.Context, policy *DeleveragePolicy, window DeleverageWindow, since time.Time) {
+	sm.mu.RLock()
+	symbols := make([]string, 0, len(sm.positions))
+	for symbol := range sm.positions {
+		symbols = append(symbols, symbol)
+	}
+	sm.mu.RUnlock()
+
+	for _, symbol := range symbols {
+		reduceCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		sm.deleveragePosition(reduceCtx, policy, window, since, symbol)
+		cancel()
+	}
+}
+
+// deleveragePosition reduces one position for the window, re-placing its stop for the remaining quantity
+// deleveragePosition 为时段减少一个持仓，并按剩余数量重挂止损
+func (sm *StopLossManager) deleveragePosition(ctx context.Context, policy *DeleveragePolicy, window DeleverageWindow, since time.Time, symbol string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, ok := sm.positions[symbol]
+	if !ok || !pos.EntryTime.Before(since) || deleveragedSince(pos, since) {
+		return
+	}
+	keep := policy.reductionFactor(pos.Leverage)
+	if keep >= 1 {
+		return
+	}
+
+	filters, err := sm.executor.GetSymbolFilters(ctx, symbol)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】获取交易规则失败，使用内置精度: %v", symbol, err))
+	}
+	step := quantityStep(symbol, filters)
+	closeQty := math.Floor(pos.Quantity*(1-keep)/step+filterEpsilon) * step
+	reason := fmt.Sprintf("降杠杆时段 %s 减仓 %.4f（保留 %.0f%%）", window.Label, closeQty, keep*100)
+
+	if closeQty < minTradableQuantity(symbol, filters) {
+		sm.logger.Info(fmt.Sprintf("🛡️【%s】减仓数量 %.4f 低于最小下单量，本时段不减仓", symbol, closeQty))
+		sm.recordStopLossEvent(pos, pos.CurrentStopLoss, pos.CurrentStopLoss, "降杠杆时段减仓数量低于最小下单量，未减仓", deleverageTrigger)
+		return
+	}
+
+	transitionPosition(sm.logger, pos, StateReducing, reason)
+	price, err := sm.executor.closeQuantity(ctx, symbol, pos.Side, closeQty, step, reason)
+	if err != nil {
+		finishReduce(sm.logger, pos, 0, "降杠杆减仓失败")
+		sm.logger.Error(fmt.Sprintf("❌【%s】降杠杆减仓失败，下个检查周期重试: %v", symbol, err))
+		sm.notifier.Notify(notify.SeverityCritical, symbol, fmt.Sprintf("🚨 降杠杆时段 %s 减仓失败: %v", window.Label, err))
+		return
+	}
+
+	finishReduce(sm.logger, pos, closeQty, reason)
+	sm.resizeStopLossOrder(ctx, pos, fmt.Sprintf("降杠杆减仓后按新数量 %.4f 重挂止损", pos.Quantity))
+	sm.recordStopLossEvent(pos, pos.CurrentStopLoss, pos.CurrentStopLoss, reason, deleverageTrigger)
+
+	sm.logger.Success(fmt.Sprintf("🛡️【%s】%s @ %.2f，剩余 %.4f", symbol, reason, price, pos.Quantity))
+	sm.notifier.Notify(notify.SeverityInfo, symbol, fmt.Sprintf("🛡️ %s @ %.2f，剩余 %.4f", reason, price, pos.Quantity))
+}
+
+// deleveragedSince reports whether a position was already reduced for the window that started at since
+// deleveragedSince 判断持仓是否已为 since 开始的时段减过仓
+func deleveragedSince(pos *Position, since time.Time) bool {
+	for _, e := range pos.StopLossHistory {
+		if e.Trigger == deleverageTrigger && !e.Time.Before(since) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package executors
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+func TestParseDeleverageWindows(t *testing.T) {
+	windows, err := parseDeleverageWindows("Fri 20:00/Mon 00:00, 2026-12-24T20:00/2026-12-27T00:00")
+	if err != nil {
+		t.Fatalf("Expected windows to parse, got %v", err)
+	}
+	if len(windows) != 2 || !windows[0].weekly || windows[1].weekly {
+		t.Fatalf("Expected one weekly and one dated window, got %+v", windows)
+	}
+	if windows[0].startMin != 4*24*60+20*60 || windows[0].endMin != 0 {
+		t.Errorf("Unexpected weekly bounds: %+v", windows[0])
+	}
+
+	for _, invalid := range []string{"Fri 20:00", "Fri 20:00/Fri 20:00", "Xyz 20:00/Mon 00:00", "Fri 25:00/Mon 00:00", "2026-12-27T00:00/2026-12-24T20:00"} {
+		if _, err := parseDeleverageWindows(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestDeleveragePolicyActive(t *testing.T) {
+	policy, err := NewDeleveragePolicy(&config.Config{
+		DeleverageWindows:     "Fri 20:00/Mon 00:00,2026-12-24T20:00/2026-12-27T00:00",
+		DeleverageMaxLeverage: 3,
+		DeleverageSizePercent: 50,
+	})
+	if err != nil {
+		t.Fatalf("Expected a policy, got %v", err)
+	}
+
+	// 2026-10-16 is a Friday
+	friday := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		now   time.Time
+		want  bool
+		since time.Time
+	}{
+		{"before the weekend", friday.Add(-time.Minute), false, time.Time{}},
+		{"window start", friday, true, friday},
+		{"sunday night", time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), true, friday},
+		{"monday", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false, time.Time{}},
+		{"holiday", time.Date(2026, 12, 25, 12, 0, 0, 0, time.UTC), true, time.Date(2026, 12, 24, 20, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		_, since, active := policy.Active(tt.now)
+		if active != tt.want || !since.Equal(tt.since) {
+			t.Errorf("%s: Active() = %v since %v, want %v since %v", tt.name, active, since, tt.want, tt.since)
+		}
+	}
+
+	if leverage, capped := policy.CapLeverage(10, 5); !capped || leverage != 3 {
+		t.Errorf("Expected 10x to be capped to 3x, got %dx %v", leverage, capped)
+	}
+	if leverage, capped := policy.CapLeverage(0, 5); !capped || leverage != 3 {
+		t.Errorf("Expected the 5x default to be capped to 3x, got %dx %v", leverage, capped)
+	}
+	if leverage, capped := policy.CapLeverage(2, 5); capped || leverage != 2 {
+		t.Errorf("Expected 2x to stay, got %dx %v", leverage, capped)
+	}
+
+	if got := policy.reductionFactor(2); got != 0.5 {
+		t.Errorf("Expected a 2x position to keep 50%%, got %.3f", got)
+	}
+	if got := policy.reductionFactor(10); math.Abs(got-0.3) > 1e-9 {
+		t.Errorf("Expected a 10x position to keep 30%% (3x / 10x), got %.3f", got)
+	}
+
+	var disabled *DeleveragePolicy
+	if _, _, active := disabled.Active(friday); active || disabled.SizeFactor() != 1 {
+		t.Error("Expected a nil policy to never be active")
+	}
+}
+
+func TestDeleveragedSince(t *testing.T) {
+	since := time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)
+	pos := &Position{StopLossHistory: []StopLossEvent{
+		{Time: since.Add(-7 * 24 * time.Hour), Trigger: deleverageTrigger},
+		{Time: since.Add(time.Hour), Trigger: "program"},
+	}}
+	if deleveragedSince(pos, since) {
+		t.Error("Expected last week's reduction not to count for this window")
+	}
+	pos.StopLossHistory = append(pos.StopLossHistory, StopLossEvent{Time: since.Add(time.Minute), Trigger: deleverageTrigger})
+	if !deleveragedSince(pos, since) {
+		t.Error("Expected the reduction in this window to be found")
+	}
+}
@@ -0,0 +1,500 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// entryLadderRegisterGrace is how long a ladder may wait for its position to be registered before it is treated as orphaned
+// entryLadderRegisterGrace 是阶梯等待其持仓注册的最长时间，超过后视为孤立挂单
+const entryLadderRegisterGrace = 2 * time.Minute
+
+// EntryLadderRung is one resting limit order of a DCA entry ladder
+// EntryLadderRung 是 DCA 分批开仓阶梯中的一笔限价挂单
+type EntryLadderRung struct {
+	Price    float64 // 限价 / Limit price
+	Quantity float64 // 数量 / Quantity
+	OrderID  int64   // 订单 ID / Order ID
+	Filled   float64 // 已并入持仓的成交数量 / Quantity already merged into the position
+	AvgPrice float64 // 已并入部分的成交均价 / Average price of the merged quantity
+	Done     bool    // 订单已结束（成交或撤销）/ The order will not change any more
+	Error    string  // 挂单被拒绝的原因 / Why placing the order failed
+}
+
+// EntryLadder is the set of rungs still resting for one position, merged into it as they fill
+// EntryLadder 是某个持仓仍在挂单的各层订单，成交后并入该持仓
+type EntryLadder struct {
+	Symbol    string             // 交易对（币安格式）/ Trading pair in Binance format
+	Side      string             // 持仓方向 long/short / Position side
+	Rungs     []*EntryLadderRung // 挂单各层，不含立即成交的第一层 / Resting rungs, without the first rung that filled at once
+	Rejected  []*EntryLadderRung // 挂单失败的层 / Rungs the exchange rejected
+	CreatedAt time.Time          // 创建时间 / Creation time
+	ExpiresAt time.Time          // 未成交挂单的撤单时间 / When unfilled rungs are cancelled
+}
+
+// entryLadderRegistry holds the active ladders per symbol
+// entryLadderRegistry 保存每个交易对的活跃阶梯
+type entryLadderRegistry struct {
+	ladders   map[string]*EntryLadder
+	monitored bool // 阶梯监控已启动，只有此时才拆分开仓 / The ladder monitor is running, entries are only split then
+	mu        sync.Mutex
+}
+
+// planEntryLadder splits an entry into rungs spaced spacingPercent apart, starting at price and moving away from it
+// planEntryLadder 将开仓拆分为从 price 开始、间距为 spacingPercent 的各层
+//
+// Rung n of a long rests n × spacing below price (a short above it) and gets weights[n] of the total, missing weights
+// counting as 1. Resting rungs are rounded down to the symbol's precision and dropped below its minimum quantity, the
+// first rung takes what is left; when that is not tradable the entry stays whole.
+// 多仓第 n 层挂在 price 下方 n × 间距处（空仓在上方），数量占总量的 weights[n] 份，缺少的权重按 1 计。
+// 挂单层按交易对精度向下取整，低于最小数量的层被舍弃，第一层取剩余数量；第一层不可交易时整笔开仓。
+func planEntryLadder(symbol, side string, price, total float64, levels int, spacingPercent float64, weights []float64) []*EntryLadderRung {
+	whole := []*EntryLadderRung{{Price: price, Quantity: total}}
+	if levels <= 1 || price <= 0 || total <= 0 || spacingPercent <= 0 {
+		return whole
+	}
+
+	weightOf := func(i int) float64 {
+		if i < len(weights) {
+			return weights[i]
+		}
+		return 1
+	}
+	sum := 0.0
+	for i := 0; i < levels; i++ {
+		sum += weightOf(i)
+	}
+
+	direction := 1.0
+	if side == "short" {
+		direction = -1
+	}
+
+	rungs := []*EntryLadderRung{{Price: price}}
+	resting := 0.0
+	for i := 1; i < levels; i++ {
+		rungPrice := price * (1 - direction*float64(i)*spacingPercent/100)
+		if rungPrice <= 0 {
+			break
+		}
+		quantity, ok := floorQuantity(symbol, total*weightOf(i)/sum)
+		if !ok {
+			continue
+		}
+		rungs = append(rungs, &EntryLadderRung{Price: rungPrice, Quantity: quantity})
+		resting += quantity
+	}
+
+	precision, minQty := getSymbolPrecision(symbol)
+	multiplier := math.Pow(10, float64(precision))
+	rungs[0].Quantity = math.Round((total-resting)*multiplier) / multiplier
+	if len(rungs) == 1 || rungs[0].Quantity < minQty {
+		return whole
+	}
+	return rungs
+}
+
+// useEntryLadder reports whether entries are split into a ladder
+// useEntryLadder 判断开仓是否拆分为阶梯
+//
+// Resting rungs fill long after the entry, so a ladder needs MonitorEntryLadders running to merge the fills and
+// resize the stop; one-shot runs and the paper executor, which has no limit orders, always enter whole.
+// 挂单会在开仓很久之后才成交，需要 MonitorEntryLadders 运行来并入成交并调整止损数量；
+// 单次运行和不支持限价单的模拟盘始终整笔开仓。
+func (e *BinanceExecutor) useEntryLadder() bool {
+	if e.config.EntryLadderLevels <= 1 || e.paper != nil {
+		return false
+	}
+	e.ladders.mu.Lock()
+	defer e.ladders.mu.Unlock()
+	return e.ladders.monitored
+}
+
+// placeEntryLadder fills the first rung of an entry at once and rests the other rungs as limit orders
+// placeEntryLadder 第一层立即成交，其余各层以限价单挂出
+//
+// It returns the first rung's fill like placeEntryOrder; the resting rungs are merged into the position later by
+// SyncEntryLadder. Rung prices are rounded to the tick away from the market. A rung that cannot be placed is kept in
+// Rejected so the entry result reports it, the entry keeps what filled.
+// 与 placeEntryOrder 一样返回第一层的成交结果；挂单各层稍后由 SyncEntryLadder 并入持仓。各层限价按最小价格变动向远离市价一侧取整。
+// 无法下单的层记入 Rejected 并在开仓结果中报告，已成交部分保留。
+func (e *BinanceExecutor) placeEntryLadder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	price, err := e.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 获取价格失败，整笔开仓: %v", err))
+		return e.placeImmediateEntryOrder(ctx, symbol, side, positionSide, quantity)
+	}
+
+	positionDirection := "long"
+	if side == futures.SideTypeSell {
+		positionDirection = "short"
+	}
+	rungs := planEntryLadder(binanceSymbol, positionDirection, price, quantity, e.config.EntryLadderLevels, e.config.EntryLadderSpacingPercent, e.config.EntryLadderWeights)
+	if len(rungs) == 1 {
+		e.logger.Info(fmt.Sprintf("ℹ️ 【%s】数量不足以拆分阶梯，整笔开仓", binanceSymbol))
+		return e.placeImmediateEntryOrder(ctx, symbol, side, positionSide, quantity)
+	}
+
+	// Rungs left over from an earlier entry must not add to this one
+	// 之前开仓遗留的挂单不能并入本次开仓
+	e.CancelEntryLadder(ctx, binanceSymbol)
+
+	e.logger.Info(fmt.Sprintf("🪜 DCA 分批开仓: 总数量 %.4f 拆分为 %d 层（间距 %.2f%%），第一层 %.4f 立即成交",
+		quantity, len(rungs), e.config.EntryLadderSpacingPercent, rungs[0].Quantity))
+	orderID, filledQty, avgPrice, err := e.placeImmediateEntryOrder(ctx, symbol, side, positionSide, rungs[0].Quantity)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	ladder := &EntryLadder{
+		Symbol:    binanceSymbol,
+		Side:      positionDirection,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(time.Duration(e.config.EntryLadderTimeoutMinutes) * time.Minute),
+	}
+	tickSize := e.makerTickSize(ctx, symbol)
+	for i, rung := range rungs[1:] {
+		rung.Price = roundMakerPrice(rung.Price, side, tickSize)
+		order, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(side).
+			PositionSide(positionSide).
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(formatTickPrice(rung.Price, tickSize)).
+			Quantity(fmt.Sprintf("%.4f", rung.Quantity)))
+		if err != nil {
+			rung.Error = err.Error()
+			ladder.Rejected = append(ladder.Rejected, rung)
+			e.logger.Warning(fmt.Sprintf("⚠️ 阶梯第 %d 层挂单失败（%.4f @ %s）: %v", i+2, rung.Quantity, formatTickPrice(rung.Price, tickSize), err))
+			continue
+		}
+		rung.OrderID = order.OrderID
+		ladder.Rungs = append(ladder.Rungs, rung)
+		e.logger.Info(fmt.Sprintf("🪜 阶梯第 %d 层已挂单: %.4f @ %s (订单ID: %d)", i+2, rung.Quantity, formatTickPrice(rung.Price, tickSize), rung.OrderID))
+	}
+
+	// The ladder is kept even when every rung was rejected, so the entry result can report them
+	// 即使所有挂单层都被拒绝也保留阶梯，以便开仓结果报告失败的层
+	e.ladders.mu.Lock()
+	e.ladders.ladders[binanceSymbol] = ladder
+	e.ladders.mu.Unlock()
+	return orderID, filledQty, avgPrice, nil
+}
+
+// entryLadderMessage describes a ladder entry for the execution result, including any rejected rungs
+// entryLadderMessage 为执行结果描述阶梯开仓，包括挂单失败的层
+func entryLadderMessage(ladder *EntryLadder, filledQty float64) string {
+	message := fmt.Sprintf("第一层已成交 %.4f，其余 %d 层限价挂单中", filledQty, len(ladder.Rungs))
+	if len(ladder.Rejected) == 0 {
+		return message
+	}
+	rejectedQty := 0.0
+	for _, rung := range ladder.Rejected {
+		rejectedQty += rung.Quantity
+	}
+	return fmt.Sprintf("%s；%d 层挂单失败，共 %.4f 未挂出（%s）", message, len(ladder.Rejected), rejectedQty, ladder.Rejected[0].Error)
+}
+
+// EntryLadder returns the active ladder of a symbol, or nil
+// EntryLadder 返回交易对的活跃阶梯，没有时返回 nil
+func (e *BinanceExecutor) EntryLadder(symbol string) *EntryLadder {
+	e.ladders.mu.Lock()
+	defer e.ladders.mu.Unlock()
+	return e.ladders.ladders[e.config.GetBinanceSymbolFor(symbol)]
+}
+
+// entryLadderSymbols returns the symbols with an active ladder, sorted
+// entryLadderSymbols 返回有活跃阶梯的交易对，已排序
+func (e *BinanceExecutor) entryLadderSymbols() []string {
+	e.ladders.mu.Lock()
+	defer e.ladders.mu.Unlock()
+	symbols := make([]string, 0, len(e.ladders.ladders))
+	for symbol := range e.ladders.ladders {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// isEntryLadderOrder reports whether an order is a resting rung of the symbol's ladder
+// isEntryLadderOrder 判断订单是否为交易对阶梯中的挂单
+func (e *BinanceExecutor) isEntryLadderOrder(symbol string, orderID int64) bool {
+	ladder := e.EntryLadder(symbol)
+	if ladder == nil {
+		return false
+	}
+	for _, rung := range ladder.Rungs {
+		if rung.OrderID == orderID {
+			return true
+		}
+	}
+	return false
+}
+
+// removeEntryLadder forgets a symbol's ladder without touching its orders
+// removeEntryLadder 移除交易对的阶梯记录，不处理其订单
+func (e *BinanceExecutor) removeEntryLadder(binanceSymbol string) {
+	e.ladders.mu.Lock()
+	defer e.ladders.mu.Unlock()
+	delete(e.ladders.ladders, binanceSymbol)
+}
+
+// CancelEntryLadder cancels the unfilled rungs of a symbol's ladder and forgets it; fills are not merged any more
+// CancelEntryLadder 撤销交易对阶梯中未成交的挂单并移除该阶梯；之后的成交不再并入持仓
+func (e *BinanceExecutor) CancelEntryLadder(ctx context.Context, symbol string) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	ladder := e.EntryLadder(binanceSymbol)
+	if ladder == nil {
+		return
+	}
+	e.removeEntryLadder(binanceSymbol)
+
+	cancelled := 0
+	for _, rung := range ladder.Rungs {
+		if rung.Done {
+			continue
+		}
+		if err := e.cancelOrderByID(ctx, binanceSymbol, rung.OrderID); err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️【%s】撤销阶梯挂单 %d 失败，请手动处理: %v", binanceSymbol, rung.OrderID, err))
+			continue
+		}
+		cancelled++
+	}
+	if cancelled > 0 {
+		e.logger.Info(fmt.Sprintf("🪜【%s】已撤销 %d 笔未成交的阶梯挂单", binanceSymbol, cancelled))
+	}
+}
+
+// readEntryLadderRung returns a rung's executed quantity and average price, cancelling it first when cancel is set
+// readEntryLadderRung 返回挂单层的成交数量和均价，cancel 为真时先撤单
+func (e *BinanceExecutor) readEntryLadderRung(ctx context.Context, binanceSymbol string, rung *EntryLadderRung, cancel bool) (executed, avgPrice float64, done bool, err error) {
+	if cancel {
+		executed, avgPrice, err = e.cancelAndReadFill(ctx, binanceSymbol, rung.OrderID)
+		return executed, avgPrice, err == nil, err
+	}
+	order, err := e.getOrder(ctx, binanceSymbol, rung.OrderID)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	executed, _ = parseFloat(order.ExecutedQuantity)
+	avgPrice, _ = parseFloat(order.AvgPrice)
+	return executed, avgPrice, isOrderDone(order.Status), nil
+}
+
+// mergeEntryFill adds a fill to a position, moving its entry price to the volume-weighted average
+// mergeEntryFill 将一笔成交并入持仓，入场价更新为成交量加权均价
+func mergeEntryFill(pos *Position, quantity, price float64) {
+	pos.EntryPrice = vwap(pos.Quantity, pos.EntryPrice, quantity, price)
+	pos.Quantity += quantity
+	pos.Size = pos.Quantity
+}
+
+// SyncEntryLadder merges new rung fills into the symbol's position and cancels the rungs once the ladder expires
+// SyncEntryLadder 将挂单层的新成交并入交易对持仓，阶梯超时后撤销剩余挂单
+//
+// Each fill moves the entry to the volume-weighted average and re-places the stop for the new quantity at the same
+// price. Take-profit percentages apply to the quantity at the time they trigger, so the ladder keeps its targets.
+// A ladder whose position closed, reversed or was never registered is cancelled.
+// 每次成交都将入场价更新为成交量加权均价，并以原止损价按新数量重挂止损单。
+// 分批止盈比例按触发时的持仓数量计算，因此止盈目标保持不变。持仓已平仓、反向或从未注册的阶梯会被撤销。
+func (sm *StopLossManager) SyncEntryLadder(ctx context.Context, symbol string) error {
+	binanceSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	ladder := sm.executor.EntryLadder(binanceSymbol)
+	if ladder == nil {
+		return nil
+	}
+
+	// A ladder whose rungs were all rejected only carried them to the entry result
+	// 所有挂单层都被拒绝的阶梯只用于向开仓结果报告失败的层
+	if len(ladder.Rungs) == 0 {
+		sm.executor.removeEntryLadder(binanceSymbol)
+		return nil
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[binanceSymbol]
+	if !exists || pos.Side != ladder.Side {
+		if exists || time.Since(ladder.CreatedAt) > entryLadderRegisterGrace {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】阶梯对应的 %s 持仓已不存在，撤销剩余挂单", binanceSymbol, ladder.Side))
+			sm.executor.CancelEntryLadder(ctx, binanceSymbol)
+		}
+		return nil
+	}
+
+	expired := time.Now().After(ladder.ExpiresAt)
+	var addedQty, addedCost float64
+	pending := 0
+	for _, rung := range ladder.Rungs {
+		if rung.Done {
+			continue
+		}
+		executed, avgPrice, done, err := sm.executor.readEntryLadderRung(ctx, binanceSymbol, rung, expired)
+		if err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】查询阶梯挂单 %d 失败: %v", binanceSymbol, rung.OrderID, err))
+			pending++
+			continue
+		}
+		if avgPrice <= 0 {
+			avgPrice = rung.Price
+		}
+		if executed > rung.Filled+filterEpsilon {
+			addedQty += executed - rung.Filled
+			addedCost += executed*avgPrice - rung.Filled*rung.AvgPrice
+			rung.Filled, rung.AvgPrice = executed, avgPrice
+		}
+		rung.Done = done
+		if !done {
+			pending++
+		}
+	}
+
+	if addedQty > 0 {
+		oldEntry := pos.EntryPrice
+		mergeEntryFill(pos, addedQty, addedCost/addedQty)
+		sm.logger.Success(fmt.Sprintf("🪜【%s】阶梯挂单成交 %.4f @ %.2f，持仓 %.4f，入场价 %.2f → %.2f",
+			binanceSymbol, addedQty, addedCost/addedQty, pos.Quantity, oldEntry, pos.EntryPrice))
+		sm.notifier.Notify(notify.SeverityInfo, binanceSymbol, fmt.Sprintf("🪜 阶梯加仓 %.4f @ %.2f，持仓 %.4f，均价 %.2f",
+			addedQty, addedCost/addedQty, pos.Quantity, pos.EntryPrice))
+		sm.resizeStopLossOrder(ctx, pos, fmt.Sprintf("阶梯加仓后按新数量 %.4f 重挂止损", pos.Quantity))
+		sm.persistEntryLadderFill(pos)
+	}
+
+	if pending == 0 {
+		sm.executor.removeEntryLadder(binanceSymbol)
+		if expired {
+			sm.logger.Info(fmt.Sprintf("🪜【%s】阶梯已超时，剩余挂单已撤销，最终持仓 %.4f @ %.2f", binanceSymbol, pos.Quantity, pos.EntryPrice))
+		} else {
+			sm.logger.Success(fmt.Sprintf("🪜【%s】阶梯已全部结束，最终持仓 %.4f @ %.2f", binanceSymbol, pos.Quantity, pos.EntryPrice))
+		}
+	}
+	return nil
+}
+
+// resizeStopLossOrder re-places the exchange stop at its current price so it covers the position's new quantity
+// resizeStopLossOrder 以当前止损价重挂交易所止损单，使其覆盖持仓的新数量
+// The caller holds sm.mu; a failed replacement goes to the retry queue like any other stop move
+// 调用方持有 sm.mu；替换失败时与其他止损移动一样进入重试队列
+func (sm *StopLossManager) resizeStopLossOrder(ctx context.Context, pos *Position, reason string) {
+	if pos.StopLossOrderID == "" || !sm.exchangeStop(pos.Symbol) {
+		return
+	}
+	if err := sm.cancelStopLossOrder(ctx, pos); err != nil {
+		sm.logger.Error(fmt.Sprintf("❌【%s】取消旧止损单失败，止损数量与持仓不一致: %v", pos.Symbol, err))
+		sm.enqueueStopLossRetry(pos, pos.CurrentStopLoss, reason, err)
+		return
+	}
+	if err := sm.placeStopLossOrder(ctx, pos, pos.CurrentStopLoss); err != nil {
+		sm.logger.Error(fmt.Sprintf("❌【%s】按新数量重挂止损失败，持仓现在无止损保护！%v", pos.Symbol, err))
+		sm.notifier.Notify(notify.SeverityCritical, pos.Symbol, fmt.Sprintf("🚨 %s失败，持仓无止损保护: %v", reason, err))
+		sm.enqueueStopLossRetry(pos, pos.CurrentStopLoss, reason, err)
+		return
+	}
+	sm.logger.Success(fmt.Sprintf("【%s】止损单已按新数量 %.4f 重挂 @ %.2f (订单ID: %s)", pos.Symbol, pos.Quantity, pos.CurrentStopLoss, pos.StopLossOrderID))
+}
+
+// persistEntryLadderFill saves a position's merged quantity, entry price and stop order to the database
+// persistEntryLadderFill 将持仓合并后的数量、入场价和止损单保存到数据库
+func (sm *StopLossManager) persistEntryLadderFill(pos *Position) {
+	if sm.storage == nil {
+		return
+	}
+	record, err := sm.storage.GetPositionByID(pos.ID)
+	if err != nil || record == nil {
+		return
+	}
+	record.Quantity = pos.Quantity
+	record.EntryPrice = pos.EntryPrice
+	record.StopLossOrderID = pos.StopLossOrderID
+	if err := sm.storage.UpdatePosition(record); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  保存 %s 阶梯加仓结果失败: %v", pos.Symbol, err))
+	}
+}
+
+// MonitorEntryLadders enables entry ladders and keeps merging their fills until ctx is cancelled
+// MonitorEntryLadders 启用分批开仓阶梯，并持续并入其成交直到 ctx 取消
+// With the user data stream on, fills are usually merged as they are pushed; this loop also handles timeouts
+// 启用用户数据流时成交通常在推送时即已并入；此循环同时负责超时撤单
+func (sm *StopLossManager) MonitorEntryLadders(ctx context.Context, interval time.Duration) {
+	sm.executor.ladders.mu.Lock()
+	sm.executor.ladders.monitored = true
+	sm.executor.ladders.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sm.logger.Info(fmt.Sprintf("🪜 启动 DCA 分批开仓监控，间隔: %v", interval))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, symbol := range sm.executor.entryLadderSymbols() {
+				syncCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				if err := sm.SyncEntryLadder(syncCtx, symbol); err != nil {
+					sm.logger.Warning(fmt.Sprintf("⚠️【%s】同步阶梯挂单失败: %v", symbol, err))
+				}
+				cancel()
+			}
+		}
+	}
+}
+
+// entryLadderOrders returns the bot's resting limit orders that open on side, i.e. rungs of a ladder
+// entryLadderOrders 返回本实例在 side 方向开仓的限价挂单，即阶梯的挂单层
+func entryLadderOrders(orders []*futures.Order, side, ownTag string) []*futures.Order {
+	openSide := futures.SideTypeBuy
+	if side == "short" {
+		openSide = futures.SideTypeSell
+	}
+	var rungs []*futures.Order
+	for _, o := range orders {
+		if o.Type != futures.OrderTypeLimit || o.Side != openSide || o.ReduceOnly || o.ClosePosition {
+			continue
+		}
+		if tag, ok := parseClientOrderTag(o.ClientOrderID); !ok || tag != ownTag {
+			continue
+		}
+		rungs = append(rungs, o)
+	}
+	return rungs
+}
+
+// restoreEntryLadder links resting rungs found at startup to the position again, keeping their original timeout
+// restoreEntryLadder 将启动时发现的挂单层重新关联到持仓，沿用其原有的超时时间
+func (e *BinanceExecutor) restoreEntryLadder(binanceSymbol, side string, orders []*futures.Order) {
+	if len(orders) == 0 {
+		return
+	}
+	ladder := &EntryLadder{Symbol: binanceSymbol, Side: side, CreatedAt: time.Now()}
+	var ids []string
+	for _, o := range orders {
+		price, _ := parseFloat(o.Price)
+		quantity, _ := parseFloat(o.OrigQuantity)
+		executed, _ := parseFloat(o.ExecutedQuantity)
+		avgPrice, _ := parseFloat(o.AvgPrice)
+		ladder.Rungs = append(ladder.Rungs, &EntryLadderRung{Price: price, Quantity: quantity, OrderID: o.OrderID, Filled: executed, AvgPrice: avgPrice})
+
+		expires := time.UnixMilli(o.Time).Add(time.Duration(e.config.EntryLadderTimeoutMinutes) * time.Minute)
+		if ladder.ExpiresAt.IsZero() || expires.Before(ladder.ExpiresAt) {
+			ladder.ExpiresAt = expires
+		}
+		ids = append(ids, fmt.Sprintf("%d", o.OrderID))
+	}
+
+	e.ladders.mu.Lock()
+	e.ladders.ladders[binanceSymbol] = ladder
+	e.ladders.mu.Unlock()
+	e.logger.Info(fmt.Sprintf("🪜【%s】已恢复 %d 笔阶梯挂单: %s", binanceSymbol, len(ladder.Rungs), strings.Join(ids, ", ")))
+}
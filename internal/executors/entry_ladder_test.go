@@ -0,0 +1,113 @@
+package executors
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestPlanEntryLadder(t *testing.T) {
+	type rung struct{ price, quantity float64 }
+	tests := []struct {
+		name     string
+		side     string
+		total    float64
+		levels   int
+		weights  []float64
+		expected []rung
+	}{
+		{
+			name:     "Equal rungs below a long",
+			side:     "long",
+			total:    0.3,
+			levels:   3,
+			expected: []rung{{100, 0.1}, {99, 0.1}, {98, 0.1}},
+		},
+		{
+			name:     "Weighted rungs above a short",
+			side:     "short",
+			total:    0.4,
+			levels:   3,
+			weights:  []float64{1, 1, 2},
+			expected: []rung{{100, 0.1}, {101, 0.1}, {102, 0.2}},
+		},
+		{
+			name:     "First rung takes the rounding leftover",
+			side:     "long",
+			total:    0.1,
+			levels:   3,
+			expected: []rung{{100, 0.034}, {99, 0.033}, {98, 0.033}},
+		},
+		{
+			name:     "Untradable rungs keep the entry whole",
+			side:     "long",
+			total:    0.002,
+			levels:   3,
+			expected: []rung{{100, 0.002}},
+		},
+		{
+			name:     "One level disables the ladder",
+			side:     "long",
+			total:    0.3,
+			levels:   1,
+			expected: []rung{{100, 0.3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rungs := planEntryLadder("BTCUSDT", tt.side, 100, tt.total, tt.levels, 1, tt.weights)
+			if len(rungs) != len(tt.expected) {
+				t.Fatalf("Expected %d rungs, got %d", len(tt.expected), len(rungs))
+			}
+			for i, r := range rungs {
+				if math.Abs(r.Price-tt.expected[i].price) > 1e-9 || math.Abs(r.Quantity-tt.expected[i].quantity) > 1e-9 {
+					t.Errorf("Rung %d: expected %.4f @ %.2f, got %.4f @ %.2f", i, tt.expected[i].quantity, tt.expected[i].price, r.Quantity, r.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryLadderMessage(t *testing.T) {
+	ladder := &EntryLadder{Rungs: []*EntryLadderRung{{Price: 99, Quantity: 0.033}}}
+	if got := entryLadderMessage(ladder, 0.034); got != "第一层已成交 0.0340，其余 1 层限价挂单中" {
+		t.Errorf("Unexpected message without rejects: %s", got)
+	}
+
+	ladder.Rejected = []*EntryLadderRung{{Price: 98, Quantity: 0.033, Error: "Price not increased by tick size."}}
+	got := entryLadderMessage(ladder, 0.034)
+	if !strings.Contains(got, "1 层挂单失败，共 0.0330 未挂出") || !strings.Contains(got, "tick size") {
+		t.Errorf("Rejected rung not reported: %s", got)
+	}
+}
+
+func TestMergeEntryFill(t *testing.T) {
+	pos := &Position{Side: "long", EntryPrice: 100, Quantity: 1, Size: 1}
+	mergeEntryFill(pos, 1, 98)
+	if pos.Quantity != 2 || pos.Size != 2 || math.Abs(pos.EntryPrice-99) > 1e-9 {
+		t.Errorf("Expected 2 @ 99 after merging a rung, got %.4f @ %.2f", pos.Quantity, pos.EntryPrice)
+	}
+}
+
+func TestEntryLadderOrders(t *testing.T) {
+	own := newClientOrderID("abcd")
+	orders := []*futures.Order{
+		{OrderID: 1, Type: futures.OrderTypeLimit, Side: futures.SideTypeBuy, ClientOrderID: own},
+		{OrderID: 2, Type: futures.OrderTypeLimit, Side: futures.SideTypeSell, ClientOrderID: own},                  // 平多方向 / Closes a long
+		{OrderID: 3, Type: futures.OrderTypeLimit, Side: futures.SideTypeBuy, ClientOrderID: own, ReduceOnly: true}, // 只减仓 / Reduce-only
+		{OrderID: 4, Type: futures.OrderTypeLimit, Side: futures.SideTypeBuy, ClientOrderID: newClientOrderID("wxyz")},
+		{OrderID: 5, Type: futures.OrderTypeLimit, Side: futures.SideTypeBuy, ClientOrderID: "web_manual"},
+		{OrderID: 6, Type: futures.OrderTypeStopMarket, Side: futures.SideTypeBuy, ClientOrderID: own},
+	}
+
+	rungs := entryLadderOrders(orders, "long", "abcd")
+	if len(rungs) != 1 || rungs[0].OrderID != 1 {
+		t.Errorf("Expected only the bot's own buy limit order as a long rung, got %d orders", len(rungs))
+	}
+	if rungs := entryLadderOrders(orders, "short", "abcd"); len(rungs) != 1 || rungs[0].OrderID != 2 {
+		t.Errorf("Expected the sell limit order as a short rung, got %d orders", len(rungs))
+	}
+}
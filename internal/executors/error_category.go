@@ -0,0 +1,56 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/adshao/go-binance/v2/common"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// CategorizedError tags an error with the category it is counted under on the stats page
+// CategorizedError 为错误标记其在统计页中归入的类别
+type CategorizedError struct {
+	Category string // 错误类别（storage.ErrorCategory*）/ Error category (storage.ErrorCategory*)
+	Err      error  // 原始错误 / Underlying error
+}
+
+func (e *CategorizedError) Error() string { return e.Err.Error() }
+
+func (e *CategorizedError) Unwrap() error { return e.Err }
+
+// Categorize tags err with a category; a nil err stays nil
+// Categorize 为 err 标记类别；err 为 nil 时返回 nil
+func Categorize(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CategorizedError{Category: category, Err: err}
+}
+
+// ErrorCategoryOf returns the category of err, empty when err is nil
+// ErrorCategoryOf 返回 err 的类别，err 为 nil 时返回空
+//
+// An explicit tag wins; otherwise Binance API errors and network failures count as EXCHANGE and anything else as INTERNAL.
+// 显式标记优先；否则币安 API 错误和网络故障归为 EXCHANGE，其余归为 INTERNAL。
+func ErrorCategoryOf(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var categorized *CategorizedError
+	if errors.As(err, &categorized) {
+		return categorized.Category
+	}
+
+	var apiErr *common.APIError
+	var netErr net.Error
+	if errors.As(err, &apiErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return storage.ErrorCategoryExchange
+	}
+	return storage.ErrorCategoryInternal
+}
@@ -0,0 +1,42 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/common"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestErrorCategoryOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		category string
+	}{
+		{name: "No error", err: nil, category: ""},
+		{name: "Tagged risk refusal", err: Categorize(storage.ErrorCategoryRisk, errors.New("开仓已暂停")), category: storage.ErrorCategoryRisk},
+		{name: "Wrapped tag", err: fmt.Errorf("pre-execution check failed: %w", Categorize(storage.ErrorCategoryLLM, errors.New("未提供仓位建议"))), category: storage.ErrorCategoryLLM},
+		{name: "Tag wins over API error", err: Categorize(storage.ErrorCategoryRisk, fmt.Errorf("拒绝: %w", &common.APIError{Code: -2019})), category: storage.ErrorCategoryRisk},
+		{name: "Binance API error", err: fmt.Errorf("下单失败: %w", &common.APIError{Code: -2019, Message: "Margin is insufficient."}), category: storage.ErrorCategoryExchange},
+		{name: "Network error", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, category: storage.ErrorCategoryExchange},
+		{name: "Deadline exceeded", err: context.DeadlineExceeded, category: storage.ErrorCategoryExchange},
+		{name: "Plain error", err: errors.New("精度调整失败"), category: storage.ErrorCategoryInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCategoryOf(tt.err); got != tt.category {
+				t.Errorf("Expected %q, got %q", tt.category, got)
+			}
+		})
+	}
+
+	if Categorize(storage.ErrorCategoryRisk, nil) != nil {
+		t.Error("Categorize should keep a nil error nil")
+	}
+}
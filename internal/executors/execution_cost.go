@@ -0,0 +1,135 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// ExecutionCostTracker persists the slippage and fees of every executed order
+// ExecutionCostTracker 持久化每笔已执行订单的滑点与手续费
+//
+// Slippage is measured against the price the decision was made at, so it includes the delay between analysis and
+// execution as well as the spread and book impact. Tracking is best-effort and never blocks trading; a nil tracker is a no-op.
+// 滑点相对做出决策时的价格计算，因此包含分析到执行之间的延迟以及价差和盘口冲击。跟踪为尽力而为，不阻塞交易；tracker 为 nil 时不做任何事。
+type ExecutionCostTracker struct {
+	executor *BinanceExecutor    // 查询成交明细 / Looks up order fills
+	storage  *storage.Storage    // 数据库 / Database
+	logger   *logger.ColorLogger // 日志 / Logger
+}
+
+// NewExecutionCostTracker creates a tracker; it records nothing when db is nil
+// NewExecutionCostTracker 创建跟踪器；db 为 nil 时不记录
+func NewExecutionCostTracker(executor *BinanceExecutor, db *storage.Storage, log *logger.ColorLogger) *ExecutionCostTracker {
+	return &ExecutionCostTracker{executor: executor, storage: db, logger: log}
+}
+
+// Record computes and stores the execution cost of a successful trade
+// Record 计算并保存一笔成功交易的执行成本
+// decisionPrice is the latest price the trader saw; when it is unknown the pre-order mark price is used instead
+// decisionPrice 是交易员决策时看到的最新价格；未知时改用下单前的标记价格
+func (t *ExecutionCostTracker) Record(ctx context.Context, result *TradeResult, decisionPrice float64) *storage.ExecutionCost {
+	if t == nil || t.storage == nil || result == nil || !result.Success || result.Price <= 0 {
+		return nil
+	}
+
+	fee, makerQty, takerQty, err := t.executor.orderFills(ctx, result)
+	if err != nil {
+		t.logger.Warning(fmt.Sprintf("⚠️ 【%s】获取订单成交明细失败，手续费记为 0: %v", result.Symbol, err))
+	}
+
+	cost := buildExecutionCost(result, decisionPrice, fee, makerQty, takerQty, time.Now())
+	if _, err := t.storage.SaveExecutionCost(cost); err != nil {
+		t.logger.Warning(fmt.Sprintf("⚠️ 【%s】保存执行成本失败: %v", result.Symbol, err))
+		return cost
+	}
+	t.logger.Info(fmt.Sprintf("💸 【%s】执行成本: 滑点 %+.1f bps（%.4f USDT），手续费 %.4f USDT（%s）",
+		result.Symbol, cost.SlippageBps, cost.SlippageCost, cost.Fee, cost.Liquidity))
+	return cost
+}
+
+// buildExecutionCost measures a trade's fill against the decision price
+// buildExecutionCost 以决策价格为基准衡量交易成交
+func buildExecutionCost(result *TradeResult, decisionPrice, fee, makerQty, takerQty float64, at time.Time) *storage.ExecutionCost {
+	if decisionPrice <= 0 {
+		decisionPrice = result.ReferencePrice
+	}
+	if decisionPrice <= 0 {
+		decisionPrice = result.Price
+	}
+
+	side := futures.SideTypeBuy
+	if result.Action == ActionSell || result.Action == ActionCloseLong {
+		side = futures.SideTypeSell
+	}
+
+	cost := &storage.ExecutionCost{
+		Symbol:         result.Symbol,
+		Action:         string(result.Action),
+		OrderID:        result.OrderID,
+		Liquidity:      storage.LiquidityTaker,
+		Quantity:       result.FilledQuantity(),
+		DecisionPrice:  decisionPrice,
+		ReferencePrice: result.ReferencePrice,
+		FillPrice:      result.Price,
+		SlippageBps:    fillSlippageBps(side, decisionPrice, result.Price),
+		Fee:            fee,
+		ExecutedAt:     at,
+	}
+	cost.SlippageCost = cost.SlippageBps / 10000 * decisionPrice * cost.Quantity
+
+	switch {
+	case makerQty > 0 && takerQty > 0:
+		cost.Liquidity = storage.LiquidityMixed
+	case makerQty > 0:
+		cost.Liquidity = storage.LiquidityMaker
+	}
+	return cost
+}
+
+// orderFills returns the commission and the maker/taker quantities of a trade's order
+// orderFills 返回交易订单的手续费以及 maker/taker 成交数量
+// Paper fills are always taker fills charged at the paper fee rate
+// 模拟盘成交一律视为 taker，按模拟盘费率收取手续费
+func (e *BinanceExecutor) orderFills(ctx context.Context, result *TradeResult) (fee, makerQty, takerQty float64, err error) {
+	if e.paper != nil {
+		qty := result.FilledQuantity()
+		return qty * result.Price * e.paper.feeRate, 0, qty, nil
+	}
+
+	orderID, err := strconv.ParseInt(result.OrderID, 10, 64)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid order id %q: %w", result.OrderID, err)
+	}
+
+	binanceSymbol := e.config.GetBinanceSymbolFor(result.Symbol)
+	var trades []*futures.AccountTrade
+	err = e.withRetry(func() error {
+		var err error
+		trades, err = e.client.NewListAccountTradeService().Symbol(binanceSymbol).OrderID(orderID).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to list account trades: %w", err)
+	}
+
+	for _, trade := range trades {
+		if trade.OrderID != orderID {
+			continue
+		}
+		commission, _ := parseFloat(trade.Commission)
+		qty, _ := parseFloat(trade.Quantity)
+		fee += commission
+		if trade.Maker {
+			makerQty += qty
+		} else {
+			takerQty += qty
+		}
+	}
+	return fee, makerQty, takerQty, nil
+}
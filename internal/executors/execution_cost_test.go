@@ -0,0 +1,38 @@
+package executors
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestBuildExecutionCost(t *testing.T) {
+	// A market buy filled 10 bps above the decision price, partly as maker
+	// 市价买入成交价比决策价高 10 个基点，其中部分为 maker 成交
+	buy := &TradeResult{Success: true, Action: ActionBuy, Symbol: "BTCUSDT", OrderID: "42", Amount: 0.2, Filled: 0.2,
+		Price: 50050, ReferencePrice: 50020}
+	cost := buildExecutionCost(buy, 50000, 4, 0.05, 0.15, time.Now())
+	if math.Abs(cost.SlippageBps-10) > 1e-9 || math.Abs(cost.SlippageCost-10) > 1e-9 || cost.Fee != 4 {
+		t.Errorf("Unexpected buy cost: %+v", cost)
+	}
+	if cost.Liquidity != storage.LiquidityMixed || cost.ReferencePrice != 50020 || cost.Quantity != 0.2 {
+		t.Errorf("Unexpected buy details: %+v", cost)
+	}
+
+	// Closing a long sells, so a higher fill is favourable
+	// 平多是卖出，成交价更高为有利
+	sell := &TradeResult{Success: true, Action: ActionCloseLong, Symbol: "ETHUSDT", Amount: 1, Price: 3003}
+	cost = buildExecutionCost(sell, 3000, 0.6, 1, 0, time.Now())
+	if math.Abs(cost.SlippageBps+10) > 1e-9 || cost.Liquidity != storage.LiquidityMaker {
+		t.Errorf("Unexpected sell cost: %+v", cost)
+	}
+
+	// Without a decision price the pre-order mark price is the benchmark
+	// 没有决策价格时以下单前标记价格为基准
+	cost = buildExecutionCost(&TradeResult{Action: ActionSell, Amount: 1, Price: 99, ReferencePrice: 100}, 0, 0, 0, 0, time.Now())
+	if cost.DecisionPrice != 100 || math.Abs(cost.SlippageBps-100) > 1e-9 || cost.Liquidity != storage.LiquidityTaker {
+		t.Errorf("Unexpected fallback cost: %+v", cost)
+	}
+}
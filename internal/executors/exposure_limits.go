@@ -0,0 +1,100 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// ExposureRequest is a proposed entry and the notional already open across the portfolio
+// ExposureRequest 是拟开仓信息及组合中已有的名义价值
+type ExposureRequest struct {
+	Symbol   string             // 交易对（币安格式）/ Trading pair in Binance format
+	Equity   float64            // 账户权益（USDT）/ Account equity in USDT
+	Price    float64            // 当前价格 / Current price
+	Quantity float64            // 拟开仓数量 / Proposed quantity
+	Open     map[string]float64 // 各交易对已有名义价值（币安格式）/ Open notional per Binance symbol
+}
+
+// ExposureResult is the quantity allowed by the exposure caps
+// ExposureResult 是敞口上限允许的开仓数量
+type ExposureResult struct {
+	Quantity float64 // 允许的数量 / Allowed quantity
+	Capped   bool    // 是否被削减 / Whether the quantity was reduced
+	Detail   string  // 计算说明 / How the room was derived
+}
+
+// ExposureLimits caps total and per-symbol notional exposure as a percentage of account equity
+// ExposureLimits 以账户权益百分比限制总名义敞口和单币名义敞口
+// A nil limiter (both caps 0) lets every entry through
+// limiter 为 nil（两个上限均为 0）时不做限制
+type ExposureLimits struct {
+	maxTotalPercent  float64
+	maxSymbolPercent float64
+}
+
+// NewExposureLimits creates the limiter configured by EXPOSURE_MAX_TOTAL_PERCENT and EXPOSURE_MAX_SYMBOL_PERCENT, or nil when both are 0
+// NewExposureLimits 按 EXPOSURE_MAX_TOTAL_PERCENT 和 EXPOSURE_MAX_SYMBOL_PERCENT 创建限制器；两者均为 0 时返回 nil
+func NewExposureLimits(cfg *config.Config) *ExposureLimits {
+	if cfg.ExposureMaxTotalPercent <= 0 && cfg.ExposureMaxSymbolPercent <= 0 {
+		return nil
+	}
+	return &ExposureLimits{
+		maxTotalPercent:  cfg.ExposureMaxTotalPercent,
+		maxSymbolPercent: cfg.ExposureMaxSymbolPercent,
+	}
+}
+
+// Cap downsizes an entry to the room left under the caps; an error means no room is left at all
+// Cap 将开仓数量削减到上限剩余的余量内；返回错误表示已没有余量
+func (l *ExposureLimits) Cap(req ExposureRequest) (ExposureResult, error) {
+	if l == nil {
+		return ExposureResult{Quantity: req.Quantity}, nil
+	}
+	if req.Equity <= 0 || req.Price <= 0 {
+		return ExposureResult{}, fmt.Errorf("invalid exposure inputs: equity %.2f, price %.4f", req.Equity, req.Price)
+	}
+
+	room := math.Inf(1)
+	detail := ""
+	if l.maxTotalPercent > 0 {
+		total := 0.0
+		for _, notional := range req.Open {
+			total += notional
+		}
+		limit := req.Equity * l.maxTotalPercent / 100
+		room = limit - total
+		detail = fmt.Sprintf("总敞口 %.2f / %.2f USDT（%.0f%% 权益）", total, limit, l.maxTotalPercent)
+	}
+	if l.maxSymbolPercent > 0 {
+		open := req.Open[req.Symbol]
+		limit := req.Equity * l.maxSymbolPercent / 100
+		room = math.Min(room, limit-open)
+		if detail != "" {
+			detail += "，"
+		}
+		detail += fmt.Sprintf("%s 敞口 %.2f / %.2f USDT（%.0f%% 权益）", req.Symbol, open, limit, l.maxSymbolPercent)
+	}
+
+	if room <= 0 {
+		return ExposureResult{Detail: detail}, fmt.Errorf("exposure cap reached: %s", detail)
+	}
+	allowed := room / req.Price
+	if req.Quantity <= allowed {
+		return ExposureResult{Quantity: req.Quantity, Detail: detail}, nil
+	}
+	return ExposureResult{Quantity: allowed, Capped: true, Detail: detail}, nil
+}
+
+// positionNotional values a position at its mark price, derived from the entry price and unrealized PnL
+// positionNotional 按标记价格计算持仓名义价值（由入场价和未实现盈亏推算）
+func positionNotional(pos *Position) float64 {
+	if pos == nil {
+		return 0
+	}
+	if pos.Side == "short" {
+		return math.Abs(pos.Size*pos.EntryPrice - pos.UnrealizedPnL)
+	}
+	return math.Abs(pos.Size*pos.EntryPrice + pos.UnrealizedPnL)
+}
@@ -0,0 +1,69 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+func TestExposureLimits(t *testing.T) {
+	cfg := &config.Config{}
+	if limits := NewExposureLimits(cfg); limits != nil {
+		t.Fatal("Expected no limiter without caps")
+	} else if res, err := limits.Cap(ExposureRequest{Quantity: 3}); err != nil || res.Quantity != 3 || res.Capped {
+		t.Errorf("Expected a nil limiter to pass the quantity through, got %+v err=%v", res, err)
+	}
+
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	cfg.ExposureMaxTotalPercent = 300
+	cfg.ExposureMaxSymbolPercent = 100
+	limits := NewExposureLimits(cfg)
+	req := ExposureRequest{
+		Symbol:   "BTCUSDT",
+		Equity:   1000,
+		Price:    100,
+		Quantity: 5,
+		Open:     map[string]float64{"ETHUSDT": 1500, "BTCUSDT": 200},
+	}
+
+	// Within both caps
+	// 两个上限之内
+	if res, err := limits.Cap(req); err != nil || res.Capped || !near(res.Quantity, 5) {
+		t.Errorf("Expected 5 to pass, got %+v err=%v", res, err)
+	}
+
+	// The per-symbol cap leaves 800 USDT
+	// 单币上限剩余 800 USDT
+	big := req
+	big.Quantity = 20
+	if res, err := limits.Cap(big); err != nil || !res.Capped || !near(res.Quantity, 8) {
+		t.Errorf("Expected the symbol cap to downsize to 8, got %+v err=%v", res, err)
+	}
+
+	// The total cap leaves 300 USDT
+	// 总上限剩余 300 USDT
+	crowded := big
+	crowded.Open = map[string]float64{"ETHUSDT": 2500, "BTCUSDT": 200}
+	if res, err := limits.Cap(crowded); err != nil || !res.Capped || !near(res.Quantity, 3) {
+		t.Errorf("Expected the total cap to downsize to 3, got %+v err=%v", res, err)
+	}
+
+	// No room left under the total cap
+	// 总上限已无余量
+	full := req
+	full.Open = map[string]float64{"ETHUSDT": 3000}
+	if _, err := limits.Cap(full); err == nil {
+		t.Error("Expected an entry to be rejected at the total cap")
+	}
+
+	if _, err := limits.Cap(ExposureRequest{Symbol: "BTCUSDT", Price: 100, Quantity: 1}); err == nil {
+		t.Error("Expected an error without equity")
+	}
+
+	short := &Position{Side: "short", Size: 2, EntryPrice: 100, UnrealizedPnL: 20}
+	long := &Position{Side: "long", Size: 2, EntryPrice: 100, UnrealizedPnL: 20}
+	if !near(positionNotional(short), 180) || !near(positionNotional(long), 220) || positionNotional(nil) != 0 {
+		t.Errorf("Unexpected notionals: short %.2f, long %.2f", positionNotional(short), positionNotional(long))
+	}
+}
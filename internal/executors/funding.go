@@ -0,0 +1,162 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// incomeTypeFundingFee is the Binance income type for funding payments
+// incomeTypeFundingFee 是币安资金费用的收益类型
+const incomeTypeFundingFee = "FUNDING_FEE"
+
+// incomeTypeCommission is the Binance income type for trading commissions
+// incomeTypeCommission 是币安交易手续费的收益类型
+const incomeTypeCommission = "COMMISSION"
+
+// incomeHistoryPageSize is the maximum number of records Binance returns per income request
+// incomeHistoryPageSize 是币安每次收益查询返回的最大记录数
+const incomeHistoryPageSize = 1000
+
+// FundingPayment is one funding settlement; positive Amount is income, negative is cost
+// FundingPayment 表示一次资金费结算；Amount 为正表示收入，为负表示支出
+type FundingPayment struct {
+	Time   time.Time // 结算时间 / Settlement time
+	Symbol string    // 交易对 / Trading pair
+	Asset  string    // 结算资产 / Settlement asset
+	Amount float64   // 金额 / Amount
+}
+
+// GetFundingPayments returns funding payments between start and end; an empty symbol means all symbols
+// GetFundingPayments 返回 start 到 end 之间的资金费记录；symbol 为空表示所有交易对
+func (e *BinanceExecutor) GetFundingPayments(ctx context.Context, symbol string, start, end time.Time) ([]FundingPayment, error) {
+	records, err := e.getIncomeHistory(ctx, symbol, incomeTypeFundingFee, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding history: %w", err)
+	}
+
+	payments := make([]FundingPayment, 0, len(records))
+	for _, r := range records {
+		amount, err := parseFloat(r.Income)
+		if err != nil {
+			continue
+		}
+		payments = append(payments, FundingPayment{
+			Time:   time.UnixMilli(r.Time),
+			Symbol: r.Symbol,
+			Asset:  r.Asset,
+			Amount: amount,
+		})
+	}
+
+	return payments, nil
+}
+
+// GetMarkPriceAndFunding returns the current mark price and last funding rate of a symbol
+// GetMarkPriceAndFunding 返回交易对当前的标记价格和最新资金费率
+func (e *BinanceExecutor) GetMarkPriceAndFunding(ctx context.Context, symbol string) (float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	var premiumIndex []*futures.PremiumIndex
+	err := e.withRetry(func() error {
+		var err error
+		premiumIndex, err = e.client.NewPremiumIndexService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get premium index: %w", err)
+	}
+	if len(premiumIndex) == 0 {
+		return 0, 0, fmt.Errorf("no premium index for %s", binanceSymbol)
+	}
+	markPrice, err := parseFloat(premiumIndex[0].MarkPrice)
+	if err != nil || markPrice <= 0 {
+		return 0, 0, fmt.Errorf("invalid mark price for %s: %q", binanceSymbol, premiumIndex[0].MarkPrice)
+	}
+	fundingRate, _ := parseFloat(premiumIndex[0].LastFundingRate)
+	return markPrice, fundingRate, nil
+}
+
+// PositionFees are the commissions and funding charged to one position
+// PositionFees 是单个持仓产生的手续费和资金费
+type PositionFees struct {
+	Commission float64 // 手续费（正数为支出）/ Commission paid (positive is a cost)
+	Funding    float64 // 资金费（正数为收入）/ Net funding received (positive is income)
+}
+
+// GetPositionFees sums the commission and funding income records of a symbol between start and end
+// GetPositionFees 汇总交易对在 start 到 end 之间的手续费和资金费收益记录
+// Only one position per symbol is held at a time, so the window from entry to close covers exactly that position's fees
+// 每个交易对同一时间只持有一个仓位，因此从开仓到平仓的时间窗口恰好覆盖该持仓的费用
+func (e *BinanceExecutor) GetPositionFees(ctx context.Context, symbol string, start, end time.Time) (PositionFees, error) {
+	records, err := e.getIncomeHistory(ctx, symbol, "", start, end)
+	if err != nil {
+		return PositionFees{}, fmt.Errorf("failed to get income history: %w", err)
+	}
+	return sumPositionFees(records), nil
+}
+
+// sumPositionFees adds up commission and funding records, ignoring every other income type
+// sumPositionFees 累加手续费和资金费记录，忽略其他收益类型
+func sumPositionFees(records []*futures.IncomeHistory) PositionFees {
+	var fees PositionFees
+	for _, r := range records {
+		amount, err := parseFloat(r.Income)
+		if err != nil {
+			continue
+		}
+		switch r.IncomeType {
+		case incomeTypeCommission:
+			// Binance reports commission as negative income
+			// 币安将手续费记为负收益
+			fees.Commission -= amount
+		case incomeTypeFundingFee:
+			fees.Funding += amount
+		}
+	}
+	return fees
+}
+
+// getIncomeHistory returns all income records between start and end, paging through Binance's per-request limit
+// getIncomeHistory 返回 start 到 end 之间的所有收益记录，自动翻页绕过币安单次请求上限
+// An empty symbol or incomeType means no filter
+// symbol 或 incomeType 为空表示不过滤
+func (e *BinanceExecutor) getIncomeHistory(ctx context.Context, symbol, incomeType string, start, end time.Time) ([]*futures.IncomeHistory, error) {
+	binanceSymbol := ""
+	if symbol != "" {
+		binanceSymbol = e.config.GetBinanceSymbolFor(symbol)
+	}
+
+	var all []*futures.IncomeHistory
+	cursor := start.UnixMilli()
+	endMs := end.UnixMilli()
+
+	// Page forward by time until a short page is returned
+	// 按时间向后翻页，直到返回不足一页
+	for cursor <= endMs {
+		records, err := e.client.NewGetIncomeHistoryService().
+			Symbol(binanceSymbol).
+			IncomeType(incomeType).
+			StartTime(cursor).
+			EndTime(endMs).
+			Limit(incomeHistoryPageSize).
+			Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range records {
+			all = append(all, r)
+			if r.Time >= cursor {
+				cursor = r.Time + 1
+			}
+		}
+
+		if len(records) < incomeHistoryPageSize {
+			break
+		}
+	}
+
+	return all, nil
+}
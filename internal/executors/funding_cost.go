@@ -0,0 +1,96 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// fundingCostHistorySize is how many settled funding rates the projection beyond the next settlement averages
+// fundingCostHistorySize 是下次结算之后的预测所平均的已结算资金费率条数
+const fundingCostHistorySize = 3
+
+// FundingCostEstimate is the funding a position is expected to pay over a holding horizon
+// FundingCostEstimate 是持仓在持有期内预计支付的资金费
+type FundingCostEstimate struct {
+	Side          string  // 持仓方向 / Position side
+	Predicted     float64 // 下次结算的预测资金费率 / Predicted rate of the next settlement
+	Recent        float64 // 最近已结算资金费率的均值 / Mean of the recently settled rates
+	IntervalHours int     // 结算间隔（小时）/ Funding interval in hours
+	Settlements   int     // 持有期内的结算次数 / Settlements within the horizon
+	CostPercent   float64 // 预计成本占名义价值的比例（%），负数为收入 / Expected cost as % of notional, negative is income
+}
+
+// String describes the estimate for logs and notifications
+// String 描述预估结果，用于日志和通知
+func (f FundingCostEstimate) String() string {
+	return fmt.Sprintf("预测费率 %.4f%%，近期均值 %.4f%%，%d 次结算（每 %dh）预计成本 %.3f%% 名义价值",
+		f.Predicted*100, f.Recent*100, f.Settlements, f.IntervalHours, f.CostPercent)
+}
+
+// EstimateFundingCost projects the funding cost of holding a side for horizon: the predicted rate applies to the next
+// settlement and the recent average to the rest; longs pay positive rates, shorts pay negative ones
+// EstimateFundingCost 预估持有某方向 horizon 时长的资金费成本：下次结算使用预测费率，其余结算使用近期均值；
+// 费率为正时多头支付，为负时空头支付
+func EstimateFundingCost(side string, predicted, recent float64, intervalHours int, horizon time.Duration) FundingCostEstimate {
+	if intervalHours <= 0 {
+		intervalHours = defaultFundingIntervalHours
+	}
+	settlements := int(math.Ceil(horizon.Hours() / float64(intervalHours)))
+	if settlements < 1 {
+		settlements = 1
+	}
+
+	rate := predicted + float64(settlements-1)*recent
+	if side == "short" {
+		rate = -rate
+	}
+	return FundingCostEstimate{
+		Side:          side,
+		Predicted:     predicted,
+		Recent:        recent,
+		IntervalHours: intervalHours,
+		Settlements:   settlements,
+		CostPercent:   rate * 100,
+	}
+}
+
+// FundingCost estimates the funding cost of holding side in symbol for horizon from the exchange's current rates
+// FundingCost 根据交易所当前费率预估在 symbol 上持有 side 方向 horizon 时长的资金费成本
+func (e *BinanceExecutor) FundingCost(ctx context.Context, symbol, side string, horizon time.Duration) (FundingCostEstimate, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	_, predicted, err := e.GetMarkPriceAndFunding(ctx, symbol)
+	if err != nil {
+		return FundingCostEstimate{}, err
+	}
+
+	var history []*futures.FundingRate
+	err = e.withRetry(func() error {
+		var err error
+		history, err = e.client.NewFundingRateService().Symbol(binanceSymbol).Limit(fundingCostHistorySize).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return FundingCostEstimate{}, fmt.Errorf("failed to get funding rate history: %w", err)
+	}
+
+	// Without settled rates the predicted one stands in for the whole horizon
+	// 没有已结算费率时整个持有期都使用预测费率
+	recent, n := 0.0, 0
+	for _, r := range history {
+		if rate, err := parseFloat(r.FundingRate); err == nil {
+			recent += rate
+			n++
+		}
+	}
+	if n > 0 {
+		recent /= float64(n)
+	} else {
+		recent = predicted
+	}
+
+	return EstimateFundingCost(side, predicted, recent, e.fundingIntervalHours(ctx, binanceSymbol), horizon), nil
+}
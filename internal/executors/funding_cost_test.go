@@ -0,0 +1,33 @@
+package executors
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimateFundingCost(t *testing.T) {
+	tests := []struct {
+		name        string
+		side        string
+		predicted   float64
+		recent      float64
+		interval    int
+		horizon     time.Duration
+		settlements int
+		cost        float64
+	}{
+		{"long pays positive funding", "long", 0.0005, 0.0003, 8, 24 * time.Hour, 3, 0.11},
+		{"short receives positive funding", "short", 0.0005, 0.0003, 8, 24 * time.Hour, 3, -0.11},
+		{"short pays negative funding", "short", -0.001, -0.001, 4, 10 * time.Hour, 3, 0.3},
+		{"short horizon counts the next settlement", "long", 0.0001, 0.0005, 8, time.Hour, 1, 0.01},
+		{"unknown interval uses the default", "long", 0.0001, 0.0001, 0, 16 * time.Hour, 2, 0.02},
+	}
+	for _, tt := range tests {
+		got := EstimateFundingCost(tt.side, tt.predicted, tt.recent, tt.interval, tt.horizon)
+		if got.Settlements != tt.settlements || math.Abs(got.CostPercent-tt.cost) > 1e-9 {
+			t.Errorf("%s: got %d settlements costing %.4f%%, want %d costing %.4f%%",
+				tt.name, got.Settlements, got.CostPercent, tt.settlements, tt.cost)
+		}
+	}
+}
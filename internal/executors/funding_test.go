@@ -0,0 +1,27 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestSumPositionFees(t *testing.T) {
+	records := []*futures.IncomeHistory{
+		{IncomeType: "COMMISSION", Income: "-0.25"},
+		{IncomeType: "COMMISSION", Income: "-0.15"},
+		{IncomeType: "FUNDING_FEE", Income: "-0.30"},
+		{IncomeType: "FUNDING_FEE", Income: "0.10"},
+		{IncomeType: "REALIZED_PNL", Income: "12.5"},
+		{IncomeType: "COMMISSION", Income: "bad"},
+	}
+
+	fees := sumPositionFees(records)
+	if math.Abs(fees.Commission-0.4) > 1e-9 {
+		t.Errorf("Expected commission 0.4, got %.4f", fees.Commission)
+	}
+	if math.Abs(fees.Funding-(-0.2)) > 1e-9 {
+		t.Errorf("Expected funding -0.2, got %.4f", fees.Funding)
+	}
+}
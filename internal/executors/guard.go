@@ -0,0 +1,133 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// LeaseAlive reports whether a symbol lease is still being renewed by its holder
+// LeaseAlive 判断交易对租约是否仍由持有者续约
+func LeaseAlive(lease *storage.SymbolLease, now time.Time) bool {
+	return lease != nil && now.Sub(lease.HeartbeatAt) < SymbolLeaseTTL
+}
+
+// GuardSymbol runs one protection pass on a symbol whose main bot is not running
+// GuardSymbol 对主程序未运行的交易对执行一次保护检查
+//
+// It reconciles the managed position with the exchange, confirms the stop-loss order is still working,
+// and re-places it at the current stop if it is gone. If price has already crossed the stop, the
+// position is closed at market instead, since the stop can no longer be placed.
+// 与交易所对账受管持仓，确认止损单仍然有效，止损单丢失时按当前止损价重新下单。
+// 如果价格已越过止损价导致无法下止损单，则直接市价平仓。
+func (sm *StopLossManager) GuardSymbol(ctx context.Context, symbol string) error {
+	if err := sm.ReconcilePosition(ctx, symbol); err != nil {
+		return err
+	}
+	if err := sm.CheckStopLossOrderStatus(ctx, symbol); err != nil {
+		return err
+	}
+
+	pos := sm.GetPosition(symbol)
+	if pos == nil {
+		return nil
+	}
+
+	// A locally monitored stop has no order to restore; the guard enforces it itself
+	// 本地监控的止损没有订单可恢复，由守护进程直接执行
+	if !sm.exchangeStop(pos.Symbol) {
+		currentPrice, err := sm.getCurrentPrice(ctx, pos.Symbol)
+		if err != nil {
+			return err
+		}
+		return sm.UpdatePosition(ctx, pos.Symbol, currentPrice)
+	}
+	if sm.stopLossOrderWorking(ctx, pos) {
+		return nil
+	}
+
+	sm.logger.Warning(fmt.Sprintf("🛡️【%s】止损单缺失，按当前止损价 %.2f 重新下单", pos.Symbol, pos.CurrentStopLoss))
+	err := sm.placeStopLossOrder(ctx, pos, pos.CurrentStopLoss)
+	if err == nil {
+		sm.syncStopLossOrderID(pos)
+		return nil
+	}
+
+	currentPrice, priceErr := sm.getCurrentPrice(ctx, pos.Symbol)
+	if priceErr != nil || !stopBreached(pos.Side, pos.CurrentStopLoss, currentPrice) {
+		return fmt.Errorf("failed to restore stop-loss order: %w", err)
+	}
+	return sm.closeBreachedPosition(ctx, pos, currentPrice)
+}
+
+// stopBreached reports whether price has already moved through the stop
+// stopBreached 判断价格是否已越过止损价
+func stopBreached(side string, stopPrice, currentPrice float64) bool {
+	if stopPrice <= 0 || currentPrice <= 0 {
+		return false
+	}
+	if side == "short" {
+		return currentPrice >= stopPrice
+	}
+	return currentPrice <= stopPrice
+}
+
+// stopLossOrderWorking reports whether the position's stop-loss order is still resting on the exchange
+// stopLossOrderWorking 判断持仓的止损单是否仍挂在交易所
+func (sm *StopLossManager) stopLossOrderWorking(ctx context.Context, pos *Position) bool {
+	if pos.StopLossOrderID == "" {
+		return false
+	}
+	order, err := sm.executor.getOrder(ctx, pos.Symbol, parseInt64(pos.StopLossOrderID))
+	if err != nil {
+		// Unknown state is treated as working so a transient error never stacks a second stop
+		// 状态未知时视为有效，避免临时错误导致重复下止损单
+		return !isOrderNotFoundError(err)
+	}
+	return order.Status == futures.OrderStatusTypeNew || order.Status == futures.OrderStatusTypePartiallyFilled
+}
+
+// syncStopLossOrderID writes a re-placed stop-loss order ID back to the position record
+// syncStopLossOrderID 将重新下的止损单 ID 写回持仓记录
+func (sm *StopLossManager) syncStopLossOrderID(pos *Position) {
+	if sm.storage == nil {
+		return
+	}
+	posRecord, err := sm.storage.GetPositionByID(pos.ID)
+	if err != nil || posRecord == nil {
+		return
+	}
+	posRecord.StopLossOrderID = pos.StopLossOrderID
+	if err := sm.storage.UpdatePosition(posRecord); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】同步止损单 ID 失败: %v", pos.Symbol, err))
+	}
+}
+
+// closeBreachedPosition closes a position at market after price crossed its stop while it was unprotected
+// closeBreachedPosition 在无保护期间价格越过止损价后市价平仓
+func (sm *StopLossManager) closeBreachedPosition(ctx context.Context, pos *Position, currentPrice float64) error {
+	sm.logger.Error(fmt.Sprintf("🚨【%s】价格 %.2f 已越过止损价 %.2f，立即市价平仓", pos.Symbol, currentPrice, pos.CurrentStopLoss))
+
+	action := ActionCloseLong
+	if pos.Side == "short" {
+		action = ActionCloseShort
+	}
+	reason := fmt.Sprintf("守护进程: 止损单缺失且价格已越过止损价 %.2f", pos.CurrentStopLoss)
+	result := sm.executor.ExecuteTrade(ctx, pos.Symbol, action, pos.Quantity, reason)
+	if !result.Success {
+		return fmt.Errorf("failed to close breached position: %s", result.Message)
+	}
+
+	closePrice := result.Price
+	if closePrice <= 0 {
+		closePrice = currentPrice
+	}
+	realizedPnL := (closePrice - pos.EntryPrice) * result.FilledQuantity()
+	if pos.Side == "short" {
+		realizedPnL = -realizedPnL
+	}
+	return sm.ClosePosition(ctx, pos.Symbol, closePrice, reason, realizedPnL)
+}
@@ -0,0 +1,54 @@
+package executors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestLeaseAlive(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		lease *storage.SymbolLease
+		want  bool
+	}{
+		{"No lease", nil, false},
+		{"Fresh heartbeat", &storage.SymbolLease{HeartbeatAt: now.Add(-10 * time.Second)}, true},
+		{"Expired heartbeat", &storage.SymbolLease{HeartbeatAt: now.Add(-SymbolLeaseTTL - time.Second)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LeaseAlive(tt.lease, now); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStopBreached(t *testing.T) {
+	tests := []struct {
+		name  string
+		side  string
+		stop  float64
+		price float64
+		want  bool
+	}{
+		{"Long above stop", "long", 95, 100, false},
+		{"Long through stop", "long", 95, 94, true},
+		{"Short below stop", "short", 105, 100, false},
+		{"Short through stop", "short", 105, 106, true},
+		{"No stop", "long", 0, 100, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stopBreached(tt.side, tt.stop, tt.price); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
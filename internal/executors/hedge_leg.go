@@ -0,0 +1,165 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// hedgeOnReversal reports whether a reversal keeps the open position and opens a hedge leg instead of closing it
+// hedgeOnReversal 返回反向开仓时是否保留原持仓并开对冲腿，而不是先平仓
+// Only hedge mode can hold both sides of a symbol; one-way mode and paper trading always close first
+// 只有双向持仓模式能同时持有多空两个方向；单向持仓和模拟盘始终先平仓
+func (e *BinanceExecutor) hedgeOnReversal() bool {
+	return e.config.HedgeOnReversal && e.paper == nil && e.positionMode == PositionModeHedge
+}
+
+// actionSide returns the position side a trade action opens or closes, empty for HOLD
+// actionSide 返回交易动作开仓或平仓的持仓方向，HOLD 返回空
+func actionSide(action TradeAction) string {
+	switch action {
+	case ActionBuy, ActionCloseLong:
+		return "long"
+	case ActionSell, ActionCloseShort:
+		return "short"
+	}
+	return ""
+}
+
+// PositionFor returns the position a trade action acts on
+// PositionFor 返回交易动作作用的持仓
+// With both hedge legs open it is the leg on the action's side, so BUY sees the existing long and CLOSE_SHORT the short
+// 两条对冲腿都存在时返回动作方向上的那条，使 BUY 看到已有多仓、CLOSE_SHORT 看到空仓
+func (e *BinanceExecutor) PositionFor(ctx context.Context, symbol string, action TradeAction) (*Position, error) {
+	legs, err := e.positionLegs(ctx, symbol)
+	if err != nil || len(legs) == 0 {
+		return nil, err
+	}
+	side := actionSide(action)
+	for _, leg := range legs {
+		if leg.Side == side {
+			return leg, nil
+		}
+	}
+	return legs[0], nil
+}
+
+// GetPositionLeg returns the open leg of a symbol on one side, nil when that side is flat
+// GetPositionLeg 返回交易对某一方向上的持仓腿，该方向无持仓时返回 nil
+func (e *BinanceExecutor) GetPositionLeg(ctx context.Context, symbol, side string) (*Position, error) {
+	legs, err := e.positionLegs(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, leg := range legs {
+		if leg.Side == side {
+			return leg, nil
+		}
+	}
+	return nil, nil
+}
+
+// RegisterHedge registers the hedge leg opened on a reversal, next to the main position of the symbol
+// RegisterHedge 注册反转时开出的对冲腿，与该交易对的主持仓并存
+// The leg keeps its own stop order and database record; it takes over as the main position once that one closes
+// 对冲腿有自己的止损单和数据库记录；主持仓平仓后由它接替为主持仓
+func (sm *StopLossManager) RegisterHedge(pos *Position) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	pos.Symbol = normalizedSymbol
+	pos.HighestPrice = pos.EntryPrice
+	pos.CurrentPrice = pos.EntryPrice
+	pos.StopLossType = "fixed"
+
+	sm.hedges[normalizedSymbol] = pos
+	transitionPosition(sm.logger, pos, StateOpen, "注册对冲腿")
+	sm.logger.Success(fmt.Sprintf("【%s】🛡️ 对冲腿已注册: %s，入场价: %.2f, 初始止损: %.2f",
+		normalizedSymbol, pos.Side, pos.EntryPrice, pos.InitialStopLoss))
+}
+
+// GetHedge returns the hedge leg of a symbol, nil when there is none
+// GetHedge 返回交易对的对冲腿，没有时返回 nil
+func (sm *StopLossManager) GetHedge(symbol string) *Position {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.hedges[sm.config.GetBinanceSymbolFor(symbol)]
+}
+
+// CloseSide closes the leg of a symbol on one side: the hedge leg when it is on that side, else the main position
+// CloseSide 关闭交易对某一方向上的持仓腿：对冲腿在该方向时关闭对冲腿，否则关闭主持仓
+func (sm *StopLossManager) CloseSide(ctx context.Context, symbol, side string, closePrice float64, closeReason string, realizedPnL float64) error {
+	if hedge := sm.GetHedge(symbol); hedge != nil && hedge.Side == side {
+		return sm.closeManaged(ctx, symbol, hedge, closePrice, closeReason, realizedPnL)
+	}
+	return sm.ClosePosition(ctx, symbol, closePrice, closeReason, realizedPnL)
+}
+
+// untrack removes a closed leg from memory; when the main position goes, a remaining hedge leg takes its place
+// untrack 从内存移除已平仓的持仓腿；主持仓移除后，剩余的对冲腿接替为主持仓
+func (sm *StopLossManager) untrack(symbol string, pos *Position) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if hedge, ok := sm.hedges[symbol]; ok && hedge == pos {
+		delete(sm.hedges, symbol)
+		return
+	}
+	delete(sm.positions, symbol)
+	if hedge, ok := sm.hedges[symbol]; ok {
+		sm.positions[symbol] = hedge
+		delete(sm.hedges, symbol)
+		sm.logger.Info(fmt.Sprintf("【%s】🛡️ 主持仓已平仓，%s 对冲腿接替为主持仓", symbol, hedge.Side))
+	}
+}
+
+// exchangeLeg returns the exchange position matching a managed leg; with a hedge open both sides exist, so it is
+// looked up by side
+// exchangeLeg 返回与受管理持仓腿对应的交易所持仓；存在对冲腿时两个方向都有持仓，因此按方向查找
+func (sm *StopLossManager) exchangeLeg(ctx context.Context, symbol, side string) (*Position, error) {
+	if sm.GetHedge(symbol) == nil {
+		return sm.executor.GetCurrentPosition(ctx, symbol)
+	}
+	return sm.executor.GetPositionLeg(ctx, symbol, side)
+}
+
+// checkHedgeStopOrder closes the hedge leg once its stop order has filled or its side is flat on the exchange
+// checkHedgeStopOrder 在对冲腿止损单成交或交易所该方向已无持仓时关闭对冲腿
+func (sm *StopLossManager) checkHedgeStopOrder(ctx context.Context, symbol string) error {
+	hedge := sm.GetHedge(symbol)
+	if hedge == nil || hedge.StopLossOrderID == "" {
+		return nil
+	}
+
+	order, err := sm.executor.getOrder(ctx, symbol, parseInt64(hedge.StopLossOrderID))
+	if err != nil {
+		if !isOrderNotFoundError(err) {
+			return fmt.Errorf("查询对冲腿止损单状态失败: %w", err)
+		}
+		// The stop is gone; the leg is closed only if the exchange agrees
+		// 止损单已不存在；仅当交易所该方向确实无持仓时才关闭对冲腿
+		leg, err := sm.executor.GetPositionLeg(ctx, symbol, hedge.Side)
+		if err != nil || leg != nil {
+			return err
+		}
+		closePrice, err := sm.getCurrentPrice(ctx, symbol)
+		if err != nil || closePrice == 0 {
+			closePrice = hedge.CurrentStopLoss
+		}
+		realizedPnL := (closePrice - hedge.EntryPrice) * hedge.Quantity
+		if hedge.Side == "short" {
+			realizedPnL = -realizedPnL
+		}
+		sm.logger.Warning(fmt.Sprintf("🔔【%s】对冲腿止损单已不存在且无 %s 持仓，清理对冲腿", symbol, hedge.Side))
+		return sm.closeManaged(ctx, symbol, hedge, closePrice, "对冲腿止损触发（币安自动执行）", realizedPnL)
+	}
+
+	if order.Status == futures.OrderStatusTypeFilled {
+		sm.logger.Warning(fmt.Sprintf("🔔【%s】对冲腿止损单已成交，订单ID: %s", symbol, hedge.StopLossOrderID))
+		return sm.closeOnStopLossFill(ctx, symbol, hedge, order.AvgPrice,
+			fmt.Sprintf("对冲腿止损单成交（订单ID: %s）", hedge.StopLossOrderID))
+	}
+	return nil
+}
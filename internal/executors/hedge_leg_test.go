@@ -0,0 +1,38 @@
+package executors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestHedgeLegs(t *testing.T) {
+	cfg := &config.Config{PaperTrading: true, PaperInitialBalance: 10000}
+	log := logger.NewColorLogger(false)
+	sm := NewStopLossManager(cfg, NewBinanceExecutor(cfg, log), log, nil)
+	ctx := context.Background()
+
+	sm.RegisterPosition(&Position{ID: "long-1", Symbol: "BTC/USDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 95, CurrentStopLoss: 95})
+	sm.RegisterHedge(&Position{ID: "short-1", Symbol: "BTC/USDT", Side: "short", EntryPrice: 104, Quantity: 1, InitialStopLoss: 108, CurrentStopLoss: 108})
+
+	// Closing the hedged side removes only the hedge leg
+	// 平对冲方向只移除对冲腿
+	if err := sm.CloseSide(ctx, "BTC/USDT", "short", 102, "test", 2); err != nil {
+		t.Fatalf("CloseSide failed: %v", err)
+	}
+	if sm.GetHedge("BTC/USDT") != nil || sm.GetPosition("BTC/USDT") == nil {
+		t.Fatal("Expected the hedge leg closed and the main position kept")
+	}
+
+	// Closing the main position promotes the remaining hedge leg
+	// 关闭主持仓后剩余的对冲腿接替为主持仓
+	sm.RegisterHedge(&Position{ID: "short-2", Symbol: "BTC/USDT", Side: "short", EntryPrice: 104, Quantity: 1, InitialStopLoss: 108, CurrentStopLoss: 108})
+	if err := sm.CloseSide(ctx, "BTC/USDT", "long", 103, "test", 3); err != nil {
+		t.Fatalf("CloseSide failed: %v", err)
+	}
+	if pos := sm.GetPosition("BTC/USDT"); pos == nil || pos.ID != "short-2" || sm.GetHedge("BTC/USDT") != nil {
+		t.Errorf("Expected the hedge leg to become the main position, got %+v", pos)
+	}
+}
@@ -0,0 +1,138 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// splitIcebergQuantity splits a total quantity into slices of roughly sliceNotional USDT each
+// splitIcebergQuantity 将总数量拆分为每片约 sliceNotional USDT 的分片
+// Slices respect the symbol's quantity precision; a remainder below the minimum quantity is merged into the last slice
+// 分片遵循交易对的数量精度；低于最小数量的余量并入最后一片
+func splitIcebergQuantity(symbol string, total, price, sliceNotional float64) []float64 {
+	if total <= 0 || price <= 0 || sliceNotional <= 0 {
+		return []float64{total}
+	}
+
+	precision, minQty := getSymbolPrecision(symbol)
+	multiplier := math.Pow(10, float64(precision))
+
+	// Round the slice size down so no slice exceeds the visible notional
+	// 分片大小向下取整，确保每片不超过可见名义价值
+	sliceQty := math.Floor(sliceNotional/price*multiplier) / multiplier
+	if sliceQty < minQty {
+		sliceQty = minQty
+	}
+	if sliceQty >= total {
+		return []float64{total}
+	}
+
+	var slices []float64
+	remaining := total
+	for remaining-sliceQty >= minQty {
+		slices = append(slices, sliceQty)
+		remaining = math.Round((remaining-sliceQty)*multiplier) / multiplier
+	}
+
+	// Leftover smaller than a full slice: keep it as its own slice if tradable, otherwise merge it
+	// 不足一片的余量：若可交易则单独成片，否则并入最后一片
+	if remaining >= minQty || len(slices) == 0 {
+		slices = append(slices, remaining)
+	} else if remaining > 0 {
+		slices[len(slices)-1] = math.Round((slices[len(slices)-1]+remaining)*multiplier) / multiplier
+	}
+
+	return slices
+}
+
+// planIcebergSlices returns the slices for an entry, or a single slice when iceberg mode does not apply
+// planIcebergSlices 返回开仓的分片计划；不适用冰山模式时返回单个分片
+func (e *BinanceExecutor) planIcebergSlices(ctx context.Context, symbol string, quantity float64) []float64 {
+	if e.config.IcebergThresholdNotional <= 0 || e.config.IcebergSliceNotional <= 0 {
+		return []float64{quantity}
+	}
+
+	price, err := e.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 获取价格失败，跳过冰山拆单: %v", err))
+		return []float64{quantity}
+	}
+
+	notional := quantity * price
+	if notional <= e.config.IcebergThresholdNotional {
+		return []float64{quantity}
+	}
+
+	return splitIcebergQuantity(e.config.GetBinanceSymbolFor(symbol), quantity, price, e.config.IcebergSliceNotional)
+}
+
+// placeIcebergOrder places the slices one after another so only the current slice is visible in the book
+// placeIcebergOrder 依次下达各分片，订单簿中只暴露当前分片
+// If a slice fails after earlier slices filled, the filled part is kept and the hidden remainder is dropped
+// 若前面的分片已成交而某一片失败，保留已成交部分并放弃剩余隐藏数量
+func (e *BinanceExecutor) placeIcebergOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, slices []float64) (int64, float64, float64, error) {
+	total := 0.0
+	for _, qty := range slices {
+		total += qty
+	}
+	e.logger.Info(fmt.Sprintf("🧊 冰山拆单: 总数量 %.4f 拆分为 %d 片（每片约 %.0f USDT）",
+		total, len(slices), e.config.IcebergSliceNotional))
+
+	var lastOrderID int64
+	var filledQty, filledCost float64
+	for i, qty := range slices {
+		if i > 0 && e.config.IcebergSliceDelaySeconds > 0 {
+			select {
+			case <-ctx.Done():
+				return e.finishIcebergOrder(lastOrderID, filledQty, filledCost, total, ctx.Err())
+			case <-time.After(time.Duration(e.config.IcebergSliceDelaySeconds) * time.Second):
+			}
+		}
+
+		orderID, sliceQty, slicePrice, err := e.placeSingleEntryOrder(ctx, symbol, side, positionSide, qty)
+		if err != nil {
+			e.logger.Warning(fmt.Sprintf("⚠️ 冰山分片 %d/%d 下单失败: %v", i+1, len(slices), err))
+			return e.finishIcebergOrder(lastOrderID, filledQty, filledCost, total, err)
+		}
+
+		// Market fills may not report a price; fall back to the current price for the average
+		// 市价成交可能不返回价格，此时用当前价计算均价
+		if slicePrice == 0 {
+			if price, err := e.GetCurrentPrice(ctx, symbol); err == nil {
+				slicePrice = price
+			}
+		}
+
+		lastOrderID = orderID
+		filledQty += sliceQty
+		filledCost += sliceQty * slicePrice
+		e.logger.Info(fmt.Sprintf("🧊 冰山分片 %d/%d 已成交: %.4f @ %.2f（累计 %.4f / %.4f）",
+			i+1, len(slices), sliceQty, slicePrice, filledQty, total))
+	}
+
+	return e.finishIcebergOrder(lastOrderID, filledQty, filledCost, total, nil)
+}
+
+// finishIcebergOrder aggregates slice fills into one result, keeping partial fills when a later slice failed
+// finishIcebergOrder 将各分片成交汇总为一个结果，后续分片失败时保留部分成交
+func (e *BinanceExecutor) finishIcebergOrder(orderID int64, filledQty, filledCost, total float64, err error) (int64, float64, float64, error) {
+	if filledQty <= 0 {
+		if err == nil {
+			err = fmt.Errorf("iceberg order filled nothing")
+		}
+		return 0, 0, 0, err
+	}
+
+	avgPrice := filledCost / filledQty
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 冰山单部分完成: 已成交 %.4f / %.4f，放弃剩余数量", filledQty, total))
+	} else {
+		e.logger.Success(fmt.Sprintf("🧊 冰山单全部完成: %.4f @ 均价 %.2f", filledQty, avgPrice))
+	}
+
+	return orderID, filledQty, avgPrice, nil
+}
@@ -0,0 +1,64 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitIcebergQuantity(t *testing.T) {
+	tests := []struct {
+		name          string
+		symbol        string
+		total         float64
+		price         float64
+		sliceNotional float64
+		expected      []float64
+	}{
+		{
+			name:          "Even split",
+			symbol:        "BTCUSDT",
+			total:         0.3,
+			price:         50000,
+			sliceNotional: 5000,
+			expected:      []float64{0.1, 0.1, 0.1},
+		},
+		{
+			name:          "Tradable remainder becomes its own slice",
+			symbol:        "BTCUSDT",
+			total:         0.25,
+			price:         50000,
+			sliceNotional: 5000,
+			expected:      []float64{0.1, 0.1, 0.05},
+		},
+		{
+			name:          "Untradable leftover stays with last slice",
+			symbol:        "AVAXUSDT",
+			total:         2.05,
+			price:         20,
+			sliceNotional: 20,
+			expected:      []float64{1, 1.05},
+		},
+		{
+			name:          "Below one slice stays whole",
+			symbol:        "ETHUSDT",
+			total:         0.5,
+			price:         3000,
+			sliceNotional: 5000,
+			expected:      []float64{0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitIcebergQuantity(tt.symbol, tt.total, tt.price, tt.sliceNotional)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected %d slices %v, got %d slices %v", len(tt.expected), tt.expected, len(result), result)
+			}
+			for i := range result {
+				if math.Abs(result[i]-tt.expected[i]) > 1e-9 {
+					t.Errorf("Slice %d: expected %.4f, got %.4f", i, tt.expected[i], result[i])
+				}
+			}
+		})
+	}
+}
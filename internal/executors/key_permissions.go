@@ -0,0 +1,129 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// tradingExpiryWarning is how early an approaching trading-permission expiry is reported
+// tradingExpiryWarning 是交易权限即将过期时提前告警的时间
+const tradingExpiryWarning = 7 * 24 * time.Hour
+
+// KeyPermissionReport is the outcome of the startup API key and account mode check
+// KeyPermissionReport 是启动时 API 密钥与账户模式检查的结果
+type KeyPermissionReport struct {
+	Permissions       *binance.APIKeyPermission // 密钥权限，测试网上为 nil / Key permissions, nil on the testnet
+	DualSidePosition  bool                      // 账户是否为双向持仓模式 / Whether the account is in hedge mode
+	MultiAssetsMargin bool                      // 账户是否开启联合保证金 / Whether multi-assets margin is on
+	Errors            []string                  // 必须修复才能交易的问题 / Problems that must be fixed before trading
+	Warnings          []string                  // 建议修复的问题 / Problems worth fixing
+}
+
+// OK reports whether the check found nothing that blocks trading
+// OK 返回检查是否未发现阻止交易的问题
+func (r *KeyPermissionReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// CheckKeyPermissions verifies the API key permissions and the account modes before any trading starts
+// CheckKeyPermissions 在开始交易前检查 API 密钥权限与账户模式
+//
+// Paper trading returns nil. The testnet has no key restriction endpoint, so there only the account modes are checked.
+// 模拟盘返回 nil；测试网没有密钥权限接口，只检查账户模式。
+func (e *BinanceExecutor) CheckKeyPermissions(ctx context.Context) (*KeyPermissionReport, error) {
+	if e.paper != nil {
+		return nil, nil
+	}
+
+	report := &KeyPermissionReport{}
+	if !e.testMode {
+		// Key restrictions live on the spot API; reuse the futures transport so the proxy and key failover apply
+		// 密钥权限在现货 API 上查询；复用合约客户端的传输层，使代理和密钥切换同样生效
+		spot := binance.NewClient(e.config.BinanceAPIKey, e.config.BinanceAPISecret)
+		spot.HTTPClient = e.client.HTTPClient
+		perm, err := spot.NewGetAPIKeyPermission().Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query API key permissions: %w", err)
+		}
+		report.Permissions = perm
+	}
+
+	mode, err := e.client.NewGetPositionModeService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position mode: %w", err)
+	}
+	report.DualSidePosition = mode.DualSidePosition
+
+	multiAssets, err := e.client.NewGetMultiAssetModeService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query multi-assets mode: %w", err)
+	}
+	report.MultiAssetsMargin = multiAssets.MultiAssetsMargin
+
+	evaluateKeyPermissions(report, e.config, time.Now())
+	return report, nil
+}
+
+// evaluateKeyPermissions fills the report's errors and warnings from the queried permissions and modes
+// evaluateKeyPermissions 根据查询到的权限与模式填写报告中的错误和警告
+func evaluateKeyPermissions(report *KeyPermissionReport, cfg *config.Config, now time.Time) {
+	// Strict mode turns the security recommendations into hard requirements
+	// 严格模式下安全建议变为硬性要求
+	advise := func(msg string) {
+		if cfg.BinanceKeyCheckStrict {
+			report.Errors = append(report.Errors, msg)
+		} else {
+			report.Warnings = append(report.Warnings, msg)
+		}
+	}
+
+	if perm := report.Permissions; perm != nil {
+		if !perm.EnableReading {
+			report.Errors = append(report.Errors, "API 密钥未开启读取权限，无法查询余额和持仓，请在币安 API 管理中开启「允许读取」")
+		}
+		if !perm.EnableFutures {
+			report.Errors = append(report.Errors, "API 密钥未开启合约交易权限，下单会被拒绝，请在币安 API 管理中开启「允许合约」")
+		}
+		if perm.EnableWithdrawals {
+			advise("API 密钥开启了提现权限，密钥泄露时资金可被直接转出，交易机器人不需要此权限，建议关闭")
+		}
+		if !perm.IPRestrict {
+			advise("API 密钥未限制访问 IP，建议在币安 API 管理中绑定运行机器人的服务器 IP")
+		}
+		if perm.TradingAuthorityExpirationTime > 0 {
+			expiry := time.UnixMilli(int64(perm.TradingAuthorityExpirationTime))
+			if !expiry.After(now) {
+				report.Errors = append(report.Errors, fmt.Sprintf("API 密钥的交易权限已于 %s 过期，请绑定 IP 或重新开启交易权限",
+					expiry.Format("2006-01-02 15:04")))
+			} else if expiry.Sub(now) < tradingExpiryWarning {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("API 密钥的交易权限将于 %s 过期，绑定 IP 可避免过期",
+					expiry.Format("2006-01-02 15:04")))
+			}
+		}
+	}
+
+	// Orders follow the account's actual mode, so a mismatched setting is ignored rather than fatal
+	// 下单以账户实际模式为准，配置不一致时会被忽略而不是导致失败
+	accountMode := PositionModeOneWay
+	if report.DualSidePosition {
+		accountMode = PositionModeHedge
+	}
+	if configured := PositionMode(cfg.BinancePositionMode); (configured == PositionModeOneWay || configured == PositionModeHedge) && configured != accountMode {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("BINANCE_POSITION_MODE=%s 与账户实际持仓模式 %s 不一致，将按账户实际模式下单",
+			configured, accountMode))
+	}
+
+	// Binance refuses isolated margin under multi-assets mode (-4168)
+	// 联合保证金模式下币安拒绝切换为逐仓（-4168）
+	if report.MultiAssetsMargin {
+		for _, symbol := range cfg.CryptoSymbols {
+			if MarginType(cfg.MarginTypeFor(symbol)) == MarginTypeIsolated {
+				report.Errors = append(report.Errors, fmt.Sprintf("账户开启了联合保证金模式，无法为 %s 使用逐仓，请关闭联合保证金或改用全仓", symbol))
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package executors
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2"
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+func TestEvaluateKeyPermissions(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	cfg := &config.Config{BinancePositionMode: "auto", CryptoSymbols: []string{"BTC/USDT"}}
+
+	report := &KeyPermissionReport{Permissions: &binance.APIKeyPermission{EnableReading: true, EnableFutures: true, IPRestrict: true}}
+	evaluateKeyPermissions(report, cfg, now)
+	if !report.OK() || len(report.Warnings) != 0 {
+		t.Errorf("Expected a clean report, got errors %v warnings %v", report.Errors, report.Warnings)
+	}
+
+	// Missing futures permission is fatal; withdrawals and no IP restriction only warn
+	// 缺少合约权限为错误；提现权限和未限制 IP 只告警
+	report = &KeyPermissionReport{Permissions: &binance.APIKeyPermission{EnableReading: true, EnableWithdrawals: true}}
+	evaluateKeyPermissions(report, cfg, now)
+	if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "合约") || len(report.Warnings) != 2 {
+		t.Errorf("Expected one futures error and two warnings, got errors %v warnings %v", report.Errors, report.Warnings)
+	}
+
+	// Strict mode turns the recommendations into errors
+	// 严格模式下建议项变为错误
+	strict := *cfg
+	strict.BinanceKeyCheckStrict = true
+	report = &KeyPermissionReport{Permissions: &binance.APIKeyPermission{EnableReading: true, EnableFutures: true, EnableWithdrawals: true}}
+	evaluateKeyPermissions(report, &strict, now)
+	if len(report.Errors) != 2 {
+		t.Errorf("Expected two errors in strict mode, got %v", report.Errors)
+	}
+
+	// An expired trading permission is fatal
+	// 交易权限已过期为错误
+	report = &KeyPermissionReport{Permissions: &binance.APIKeyPermission{EnableReading: true, EnableFutures: true, IPRestrict: true,
+		TradingAuthorityExpirationTime: uint64(now.Add(-time.Hour).UnixMilli())}}
+	evaluateKeyPermissions(report, cfg, now)
+	if report.OK() {
+		t.Error("Expected an expired trading permission to fail")
+	}
+}
+
+func TestEvaluateKeyPermissionsAccountModes(t *testing.T) {
+	cfg := &config.Config{BinancePositionMode: "oneway", BinanceMarginType: "isolated", CryptoSymbols: []string{"BTC/USDT"}}
+
+	// The testnet reports no key permissions; only the account modes are checked
+	// 测试网没有密钥权限信息，只检查账户模式
+	report := &KeyPermissionReport{DualSidePosition: true, MultiAssetsMargin: true}
+	evaluateKeyPermissions(report, cfg, time.Now())
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "BINANCE_POSITION_MODE") {
+		t.Errorf("Expected a position mode warning, got %v", report.Warnings)
+	}
+	if len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "联合保证金") {
+		t.Errorf("Expected an isolated margin error under multi-assets mode, got %v", report.Errors)
+	}
+}
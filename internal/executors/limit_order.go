@@ -0,0 +1,227 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Order execution modes for opening positions
+// 开仓下单方式
+const (
+	OrderExecutionMarket = "market" // 市价开仓 / Market entry
+	OrderExecutionLimit  = "limit"  // 限价 maker 开仓 / Post-only limit entry
+
+	LimitFallbackMarket = "market" // 超时后剩余数量转市价 / Fill the remainder at market after timeout
+	LimitFallbackChase  = "chase"  // 超时后在盘口最优价追单 / Chase the remainder at the top of the book after timeout
+	LimitFallbackCancel = "cancel" // 超时后撤单放弃 / Cancel and give up after timeout
+)
+
+// limitOrderPollInterval is how often the order status is checked while waiting for a fill
+// limitOrderPollInterval 等待成交期间查询订单状态的间隔
+const limitOrderPollInterval = 1 * time.Second
+
+// calculateMakerPrice returns a limit price that rests on the maker side of the mark price
+// calculateMakerPrice 计算位于标记价格 maker 一侧的限价
+// Buys are placed below the mark price and sells above it, offset by offsetPercent (e.g. 0.02 = 0.02%)
+// 买单挂在标记价格下方，卖单挂在上方，偏移量为 offsetPercent（如 0.02 表示 0.02%）
+func calculateMakerPrice(markPrice float64, side futures.SideType, offsetPercent float64) float64 {
+	offset := markPrice * offsetPercent / 100
+	if side == futures.SideTypeBuy {
+		return markPrice - offset
+	}
+	return markPrice + offset
+}
+
+// roundMakerPrice rounds a resting limit price to the tick away from the book: buys down and sells up
+// roundMakerPrice 将挂单限价按最小价格变动向远离盘口的一侧取整：买单向下，卖单向上
+// Rounding towards the book could make a post-only order cross and expire; a zero tick leaves the price unchanged
+// 向盘口一侧取整可能使只做 maker 的订单吃单而过期；最小价格变动为 0 时价格不变
+func roundMakerPrice(price float64, side futures.SideType, tickSize float64) float64 {
+	if tickSize <= 0 {
+		return price
+	}
+	if side == futures.SideTypeBuy {
+		return math.Floor(price/tickSize+filterEpsilon) * tickSize
+	}
+	return math.Ceil(price/tickSize-filterEpsilon) * tickSize
+}
+
+// makerTickSize returns the symbol's tick size, or 0 when the filters cannot be loaded
+// makerTickSize 返回交易对的最小价格变动，无法获取过滤器时返回 0
+func (e *BinanceExecutor) makerTickSize(ctx context.Context, symbol string) float64 {
+	filters, err := e.GetSymbolFilters(ctx, symbol)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 获取 %s 价格精度失败，限价按两位小数提交: %v", symbol, err))
+		return 0
+	}
+	return filters.TickSize
+}
+
+// placeEntryOrder opens a position with the configured execution mode and returns order ID, filled quantity and average fill price
+// placeEntryOrder 按配置的下单方式开仓，返回订单 ID、成交数量和成交均价
+// With ENTRY_LADDER_LEVELS above 1 only the first rung fills here, see placeEntryLadder
+// ENTRY_LADDER_LEVELS 大于 1 时这里只成交第一层，见 placeEntryLadder
+func (e *BinanceExecutor) placeEntryOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	if e.useEntryLadder() {
+		return e.placeEntryLadder(ctx, symbol, side, positionSide, quantity)
+	}
+	return e.placeImmediateEntryOrder(ctx, symbol, side, positionSide, quantity)
+}
+
+// placeImmediateEntryOrder fills an entry now, splitting it into slices first when it is larger than the iceberg threshold
+// placeImmediateEntryOrder 立即完成开仓，超过冰山阈值时先拆分为多个分片
+func (e *BinanceExecutor) placeImmediateEntryOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	if slices := e.planIcebergSlices(ctx, symbol, quantity); len(slices) > 1 {
+		return e.placeIcebergOrder(ctx, symbol, side, positionSide, slices)
+	}
+	return e.placeSingleEntryOrder(ctx, symbol, side, positionSide, quantity)
+}
+
+// placeSingleEntryOrder places one entry order as market or maker limit, depending on configuration
+// placeSingleEntryOrder 按配置以市价或 maker 限价下一笔开仓单
+func (e *BinanceExecutor) placeSingleEntryOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	if strings.ToLower(e.config.OrderExecutionMode) == OrderExecutionLimit {
+		// Symbols without post-only support degrade to market entries
+		// 不支持只做 maker 的交易对降级为市价开仓
+		if !e.Capabilities(symbol).PostOnly {
+			return e.placeMarketOrder(ctx, symbol, side, positionSide, quantity)
+		}
+		return e.placeLimitEntryOrder(ctx, symbol, side, positionSide, quantity)
+	}
+	return e.placeMarketOrder(ctx, symbol, side, positionSide, quantity)
+}
+
+// placeMarketOrder places a market order and returns order ID, filled quantity and average fill price
+// placeMarketOrder 下市价单，返回订单 ID、成交数量和成交均价
+// With MAX_SLIPPAGE_BPS set the order book is checked first, see placeGuardedMarketOrder
+// 设置了 MAX_SLIPPAGE_BPS 时先检查盘口，见 placeGuardedMarketOrder
+func (e *BinanceExecutor) placeMarketOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	if e.config.MaxSlippageBps > 0 {
+		return e.placeGuardedMarketOrder(ctx, symbol, side, positionSide, quantity)
+	}
+	return e.sendMarketOrder(ctx, symbol, side, positionSide, quantity)
+}
+
+// sendMarketOrder sends a market order without any slippage check
+// sendMarketOrder 直接发送市价单，不做滑点检查
+// A market order can expire partially filled when the book is thin; the remainder follows PARTIAL_FILL_POLICY
+// 盘口过薄时市价单可能部分成交后过期，剩余数量按 PARTIAL_FILL_POLICY 处理
+func (e *BinanceExecutor) sendMarketOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	orderID, filledQty, avgPrice, err := e.submitMarketOrder(ctx, symbol, side, positionSide, quantity)
+	if err != nil || filledQty >= quantity-filterEpsilon {
+		return orderID, filledQty, avgPrice, err
+	}
+
+	e.logger.Warning(fmt.Sprintf("⚠️ 市价单部分成交: %.4f / %.4f", filledQty, quantity))
+	remID, remQty, remPrice, err := e.fillRemainder(ctx, symbol, side, positionSide, quantity-filledQty, e.config.PartialFillPolicy)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 剩余数量补单失败，仅保留已成交部分: %v", err))
+	}
+	if remQty <= 0 {
+		if filledQty <= 0 {
+			return 0, 0, 0, fmt.Errorf("market order %d filled nothing", orderID)
+		}
+		return orderID, filledQty, avgPrice, nil
+	}
+	return remID, filledQty + remQty, vwap(filledQty, avgPrice, remQty, remPrice), nil
+}
+
+// placeLimitEntryOrder places a post-only limit order near the mark price and waits for it to fill
+// placeLimitEntryOrder 在标记价格附近挂只做 maker 的限价单并等待成交
+// If the order is not fully filled before the timeout it is cancelled, and the remainder is either sent as a market order or dropped
+// 如果超时前未完全成交则撤单，剩余数量按配置转为市价单或直接放弃
+func (e *BinanceExecutor) placeLimitEntryOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	// Get mark price as the reference for the maker price
+	// 以标记价格作为 maker 限价的参考
+	markPrice, err := e.getMarkPrice(ctx, binanceSymbol)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 获取标记价格失败，改用市价单: %v", err))
+		return e.placeMarketOrder(ctx, symbol, side, positionSide, quantity)
+	}
+
+	tickSize := e.makerTickSize(ctx, symbol)
+	limitPrice := formatTickPrice(roundMakerPrice(calculateMakerPrice(markPrice, side, e.config.LimitOrderOffsetPercent), side, tickSize), tickSize)
+	e.logger.Info(fmt.Sprintf("📝 限价挂单: 标记价 %.2f → 限价 %s (偏移 %.3f%%, 超时 %ds)",
+		markPrice, limitPrice, e.config.LimitOrderOffsetPercent, e.config.LimitOrderTimeoutSeconds))
+
+	// GTX (post-only) guarantees maker fees; Binance rejects it if it would cross the book
+	// GTX（只做 maker）确保 maker 手续费；若会立即吃单则被币安拒绝
+	order, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(side).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeLimit).
+		TimeInForce(futures.TimeInForceTypeGTX).
+		Price(limitPrice).
+		Quantity(fmt.Sprintf("%.4f", quantity)))
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 限价单下单失败: %v", err))
+		if e.isLimitFallbackMarket() {
+			return e.placeMarketOrder(ctx, symbol, side, positionSide, quantity)
+		}
+		return 0, 0, 0, fmt.Errorf("failed to place limit order: %w", err)
+	}
+
+	filledQty, avgPrice, status := e.waitForLimitFill(ctx, binanceSymbol, order.OrderID)
+	if status == futures.OrderStatusTypeFilled {
+		e.logger.Success(fmt.Sprintf("✅ 限价单已全部成交 (maker)，均价: %.2f", avgPrice))
+		return order.OrderID, filledQty, avgPrice, nil
+	}
+
+	// Still resting after the timeout: cancel it and read back the final filled quantity
+	// 超时后仍在挂单：撤单并读取最终成交数量
+	// A post-only order that would cross the book ends up EXPIRED right away and needs no cancel
+	// 会立即吃单的只做 maker 单会直接变为 EXPIRED，无需撤单
+	if !isOrderDone(status) {
+		filledQty, avgPrice, err = e.cancelAndReadFill(ctx, binanceSymbol, order.OrderID)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to cancel limit order %d: %w", order.OrderID, err)
+		}
+		if filledQty >= quantity-filterEpsilon {
+			return order.OrderID, filledQty, avgPrice, nil
+		}
+	}
+
+	e.logger.Warning(fmt.Sprintf("⏱️ 限价单 %ds 内未完全成交，已撤单（状态 %s，已成交 %.4f / %.4f）",
+		e.config.LimitOrderTimeoutSeconds, status, filledQty, quantity))
+
+	// The remainder follows LIMIT_ORDER_FALLBACK: market, chase or cancel
+	// 剩余数量按 LIMIT_ORDER_FALLBACK 处理：市价、追单或放弃
+	remID, remQty, remPrice, err := e.fillRemainder(ctx, symbol, side, positionSide, quantity-filledQty, e.config.LimitOrderFallback)
+	if err != nil {
+		if filledQty > 0 {
+			e.logger.Warning(fmt.Sprintf("⚠️ 剩余数量补单失败，仅保留限价成交部分: %v", err))
+			return order.OrderID, filledQty, avgPrice, nil
+		}
+		return 0, 0, 0, err
+	}
+	if remQty <= 0 {
+		if filledQty <= 0 {
+			return 0, 0, 0, fmt.Errorf("limit order not filled within %ds, cancelled", e.config.LimitOrderTimeoutSeconds)
+		}
+		return order.OrderID, filledQty, avgPrice, nil
+	}
+
+	// Blend the maker fill and the remainder fill into one volume-weighted average price
+	// 将 maker 成交与剩余数量的成交合并为成交量加权均价
+	return remID, filledQty + remQty, vwap(filledQty, avgPrice, remQty, remPrice), nil
+}
+
+// waitForLimitFill polls the order until it is filled, terminated, or the configured timeout elapses
+// waitForLimitFill 轮询订单直到完全成交、终止或达到配置的超时时间
+func (e *BinanceExecutor) waitForLimitFill(ctx context.Context, binanceSymbol string, orderID int64) (float64, float64, futures.OrderStatusType) {
+	return e.waitForOrderFill(ctx, binanceSymbol, orderID, time.Duration(e.config.LimitOrderTimeoutSeconds)*time.Second)
+}
+
+// isLimitFallbackMarket reports whether unfilled limit orders should fall back to market orders
+// isLimitFallbackMarket 判断未成交的限价单是否应回退为市价单
+func (e *BinanceExecutor) isLimitFallbackMarket() bool {
+	return strings.ToLower(e.config.LimitOrderFallback) != LimitFallbackCancel
+}
@@ -0,0 +1,117 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestCalculateMakerPrice(t *testing.T) {
+	tests := []struct {
+		name          string
+		markPrice     float64
+		side          futures.SideType
+		offsetPercent float64
+		expected      float64
+	}{
+		{
+			name:          "Buy rests below mark price",
+			markPrice:     50000,
+			side:          futures.SideTypeBuy,
+			offsetPercent: 0.02,
+			expected:      49990,
+		},
+		{
+			name:          "Sell rests above mark price",
+			markPrice:     50000,
+			side:          futures.SideTypeSell,
+			offsetPercent: 0.02,
+			expected:      50010,
+		},
+		{
+			name:          "Zero offset keeps mark price",
+			markPrice:     3000,
+			side:          futures.SideTypeBuy,
+			offsetPercent: 0,
+			expected:      3000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateMakerPrice(tt.markPrice, tt.side, tt.offsetPercent)
+			if math.Abs(result-tt.expected) > 0.0001 {
+				t.Errorf("Expected %.4f, got %.4f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestRoundMakerPrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		price    float64
+		side     futures.SideType
+		tickSize float64
+		expected float64
+	}{
+		{"Buy rounds down", 2998.2287, futures.SideTypeBuy, 0.01, 2998.22},
+		{"Sell rounds up", 2998.2213, futures.SideTypeSell, 0.01, 2998.23},
+		{"On-tick price is kept", 49990, futures.SideTypeBuy, 0.1, 49990},
+		{"Coarse tick", 0.123456, futures.SideTypeSell, 0.0001, 0.1235},
+		{"Unknown tick leaves price", 3001.237, futures.SideTypeBuy, 0, 3001.237},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := roundMakerPrice(tt.price, tt.side, tt.tickSize)
+			if math.Abs(result-tt.expected) > 1e-9 {
+				t.Errorf("Expected %.6f, got %.6f", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	tests := []struct {
+		name                       string
+		qtyA, priceA, qtyB, priceB float64
+		expected                   float64
+	}{
+		{"Two fills", 1, 100, 3, 104, 103},
+		{"Second fill without price", 2, 100, 1, 0, 100},
+		{"First fill without price", 0, 0, 1, 105, 105},
+		{"Nothing filled", 0, 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vwap(tt.qtyA, tt.priceA, tt.qtyB, tt.priceB); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("vwap = %.4f, want %.4f", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFloorQuantity(t *testing.T) {
+	// 向下取整，避免补单超过剩余数量
+	if got, ok := floorQuantity("BTCUSDT", 0.0049); !ok || math.Abs(got-0.004) > 1e-12 {
+		t.Errorf("floorQuantity = %.6f (ok=%v), want 0.004", got, ok)
+	}
+	if got, ok := floorQuantity("BTCUSDT", 0.003); !ok || math.Abs(got-0.003) > 1e-12 {
+		t.Errorf("floorQuantity = %.6f (ok=%v), want 0.003", got, ok)
+	}
+	if _, ok := floorQuantity("BTCUSDT", 0.0009); ok {
+		t.Error("expected dust below the minimum quantity to be rejected")
+	}
+}
+
+func TestTradeResultFilledQuantity(t *testing.T) {
+	if got := (&TradeResult{Amount: 1, Filled: 0.6}).FilledQuantity(); got != 0.6 {
+		t.Errorf("FilledQuantity = %.4f, want 0.6", got)
+	}
+	if got := (&TradeResult{Amount: 1}).FilledQuantity(); got != 1 {
+		t.Errorf("FilledQuantity = %.4f, want 1 when the fill is unknown", got)
+	}
+}
@@ -0,0 +1,236 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// liquidationCheckInterval is how often mark prices are compared with liquidation prices
+// liquidationCheckInterval 是比较标记价格与强平价的间隔
+const liquidationCheckInterval = 30 * time.Second
+
+// LiquidationGuard alerts when a position's mark price nears its liquidation price and can reduce the position
+// LiquidationGuard 在持仓标记价格接近强平价时告警，并可自动减仓
+type LiquidationGuard struct {
+	stopLossManager *StopLossManager    // 止损管理器 / Stop-loss manager
+	logger          *logger.ColorLogger // 日志 / Logger
+	bufferPercent   float64             // 告警缓冲区（%）/ Alert buffer (%)
+	reducePercent   float64             // 自动减仓比例（%），0 表示只告警 / Auto-reduce percent, 0 only alerts
+
+	mu     sync.Mutex      // 保护 inZone / Protects inZone
+	inZone map[string]bool // 已在缓冲区内并已处理的交易对 / Symbols already inside the buffer and handled
+}
+
+// LiquidationRisk is the liquidation distance of one position
+// LiquidationRisk 是单个持仓的强平距离
+type LiquidationRisk struct {
+	Symbol           string  // 交易对 / Trading pair
+	Side             string  // long/short
+	MarkPrice        float64 // 标记价格 / Mark price
+	LiquidationPrice float64 // 强平价格 / Liquidation price
+	Estimated        bool    // 强平价为本地估算 / Liquidation price was estimated locally
+	DistancePercent  float64 // 标记价格到强平价的距离（%）/ Distance from mark to liquidation (%)
+}
+
+// NewLiquidationGuard creates the guard described by the configuration, or nil when it is disabled
+// NewLiquidationGuard 按配置创建强平监控，未启用时返回 nil
+func NewLiquidationGuard(cfg *config.Config, sm *StopLossManager, log *logger.ColorLogger) *LiquidationGuard {
+	if !cfg.LiquidationMonitorEnabled || cfg.LiquidationBufferPercent <= 0 {
+		return nil
+	}
+	return &LiquidationGuard{
+		stopLossManager: sm,
+		logger:          log,
+		bufferPercent:   cfg.LiquidationBufferPercent,
+		reducePercent:   math.Min(math.Max(cfg.LiquidationAutoReducePercent, 0), 100),
+		inZone:          make(map[string]bool),
+	}
+}
+
+// Run checks every managed position until ctx is cancelled
+// Run 持续检查所有受管持仓直到 ctx 取消
+func (g *LiquidationGuard) Run(ctx context.Context) {
+	if g == nil {
+		return
+	}
+	g.logger.Info(fmt.Sprintf("🛡️ 强平监控已启动: 缓冲区 %.1f%%，自动减仓 %.0f%%", g.bufferPercent, g.reducePercent))
+	ticker := time.NewTicker(liquidationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.Check(ctx)
+		}
+	}
+}
+
+// Check runs one pass over the managed positions
+// Check 对受管持仓执行一次检查
+func (g *LiquidationGuard) Check(ctx context.Context) {
+	sm := g.stopLossManager
+	for _, pos := range sm.GetAllPositions() {
+		risk, err := g.assess(ctx, pos)
+		if err != nil {
+			g.logger.Warning(fmt.Sprintf("⚠️ 【%s】强平距离检查失败: %v", pos.Symbol, err))
+			continue
+		}
+		if risk == nil || risk.DistancePercent > g.bufferPercent {
+			g.mu.Lock()
+			delete(g.inZone, pos.Symbol)
+			g.mu.Unlock()
+			continue
+		}
+
+		// Alert and reduce once per entry into the buffer
+		// 每次进入缓冲区只告警和减仓一次
+		g.mu.Lock()
+		handled := g.inZone[pos.Symbol]
+		g.inZone[pos.Symbol] = true
+		g.mu.Unlock()
+		if handled {
+			continue
+		}
+		g.alert(ctx, pos, risk)
+	}
+}
+
+// assess returns the liquidation distance of a position, or nil when it cannot be liquidated at present
+// assess 返回持仓的强平距离；当前不会被强平时返回 nil
+func (g *LiquidationGuard) assess(ctx context.Context, pos *Position) (*LiquidationRisk, error) {
+	executor := g.stopLossManager.executor
+	current, err := executor.GetCurrentPosition(ctx, pos.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, nil
+	}
+
+	liquidationPrice, estimated := liquidationPriceOf(current)
+	if liquidationPrice <= 0 {
+		return nil, nil
+	}
+	markPrice, _, err := executor.GetMarkPriceAndFunding(ctx, pos.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &LiquidationRisk{
+		Symbol:           pos.Symbol,
+		Side:             current.Side,
+		MarkPrice:        markPrice,
+		LiquidationPrice: liquidationPrice,
+		Estimated:        estimated,
+		DistancePercent:  liquidationDistancePercent(current.Side, markPrice, liquidationPrice),
+	}, nil
+}
+
+// liquidationPriceOf returns the exchange liquidation price, or an estimate from leverage for an isolated position
+// liquidationPriceOf 返回交易所的强平价；逐仓持仓缺少时按杠杆估算
+//
+// A cross position reporting 0 is backed by the whole wallet and is treated as not liquidatable.
+// 全仓持仓返回 0 表示由整个钱包支撑，视为当前不会被强平。
+func liquidationPriceOf(pos *Position) (price float64, estimated bool) {
+	if pos.LiquidationPrice > 0 {
+		return pos.LiquidationPrice, false
+	}
+	if pos.MarginType == MarginTypeCross || pos.Leverage <= 0 || pos.EntryPrice <= 0 {
+		return 0, false
+	}
+	return paperLiquidationPrice(pos.Side, pos.EntryPrice, pos.Leverage), true
+}
+
+// EstimatedLiquidationDistancePercent is how far price can move against a new isolated position of the given leverage
+// before liquidation, in percent, using the same maintenance margin estimate as paper trading
+// EstimatedLiquidationDistancePercent 是给定杠杆的新逐仓持仓在强平前价格还能逆向移动的百分比，维持保证金估算与模拟盘一致
+func EstimatedLiquidationDistancePercent(leverage int) float64 {
+	if leverage <= 0 {
+		return 100
+	}
+	return (1/float64(leverage) - paperMaintenanceMarginRate) * 100
+}
+
+// liquidationDistancePercent is how far the mark price can move against the position before liquidation, in percent
+// liquidationDistancePercent 是标记价格在触发强平前还能逆向移动的百分比
+func liquidationDistancePercent(side string, markPrice, liquidationPrice float64) float64 {
+	if markPrice <= 0 {
+		return 0
+	}
+	if side == "short" {
+		return (liquidationPrice - markPrice) / markPrice * 100
+	}
+	return (markPrice - liquidationPrice) / markPrice * 100
+}
+
+// alert logs and notifies the breach, then reduces the position when auto-reduce is enabled
+// alert 记录并通知进入缓冲区，启用自动减仓时减仓
+func (g *LiquidationGuard) alert(ctx context.Context, pos *Position, risk *LiquidationRisk) {
+	sm := g.stopLossManager
+	source := "交易所"
+	if risk.Estimated {
+		source = "估算"
+	}
+	message := fmt.Sprintf("⚠️ 标记价格 %.4f 距强平价 %.4f（%s）仅 %.2f%%，低于缓冲区 %.1f%%",
+		risk.MarkPrice, risk.LiquidationPrice, source, risk.DistancePercent, g.bufferPercent)
+	g.logger.Error(fmt.Sprintf("🚨【%s】%s", pos.Symbol, message))
+	sm.notifier.Notify(notify.SeverityCritical, pos.Symbol, message)
+
+	if g.reducePercent <= 0 {
+		return
+	}
+	quantity, ok := floorQuantity(sm.config.GetBinanceSymbolFor(pos.Symbol), pos.Quantity*g.reducePercent/100)
+	if !ok {
+		g.logger.Warning(fmt.Sprintf("⚠️ 【%s】减仓数量低于最小下单量，仅告警", pos.Symbol))
+		return
+	}
+	reason := fmt.Sprintf("强平缓冲区自动减仓 %.0f%%（距强平价 %.2f%%）", g.reducePercent, risk.DistancePercent)
+	if err := sm.reducePosition(ctx, pos, quantity, reason); err != nil {
+		g.logger.Error(fmt.Sprintf("❌【%s】自动减仓失败: %v", pos.Symbol, err))
+		sm.notifier.Notify(notify.SeverityCritical, pos.Symbol, fmt.Sprintf("❌ 强平缓冲区自动减仓失败: %v", err))
+	}
+}
+
+// reducePosition closes part of a position at market and re-places its stop for the remaining quantity
+// reducePosition 市价平掉部分持仓，并按剩余数量重新挂止损单
+func (sm *StopLossManager) reducePosition(ctx context.Context, pos *Position, quantity float64, reason string) error {
+	action := ActionCloseLong
+	if pos.Side == "short" {
+		action = ActionCloseShort
+	}
+	transitionPosition(sm.logger, pos, StateReducing, reason)
+	result := sm.executor.ExecuteTrade(ctx, pos.Symbol, action, quantity, reason)
+	if !result.Success {
+		finishReduce(sm.logger, pos, 0, "减仓失败")
+		return fmt.Errorf("减仓失败: %s", result.Message)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	finishReduce(sm.logger, pos, result.FilledQuantity(), reason)
+	sm.logger.Success(fmt.Sprintf("✅【%s】已减仓 %.4f @ %.2f，剩余 %.4f（%s）",
+		pos.Symbol, result.FilledQuantity(), result.Price, pos.Quantity, reason))
+
+	// The resting stop still covers the old quantity; reduce-only keeps it safe, but it is resized for the rest
+	// 挂着的止损单仍是原数量，只减仓属性保证安全，但仍按剩余数量重新下单
+	if pos.StopLossOrderID == "" {
+		return nil
+	}
+	if err := sm.cancelStopLossOrder(ctx, pos); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】撤销原止损单失败，保留原数量止损单: %v", pos.Symbol, err))
+		return nil
+	}
+	if err := sm.placeStopLossOrder(ctx, pos, pos.CurrentStopLoss); err != nil {
+		sm.enqueueStopLossRetry(pos, pos.CurrentStopLoss, "减仓后重挂止损单", err)
+		return fmt.Errorf("减仓后重挂止损单失败: %w", err)
+	}
+	sm.syncStopLossOrderID(pos)
+	return nil
+}
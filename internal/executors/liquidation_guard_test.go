@@ -0,0 +1,52 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestLiquidationPriceOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		pos       *Position
+		price     float64
+		estimated bool
+	}{
+		{"exchange price", &Position{Side: "long", EntryPrice: 100, Leverage: 10, LiquidationPrice: 91}, 91, false},
+		{"isolated estimate", &Position{Side: "long", EntryPrice: 100, Leverage: 10, MarginType: MarginTypeIsolated}, 90.4, true},
+		{"short estimate", &Position{Side: "short", EntryPrice: 100, Leverage: 10}, 109.6, true},
+		{"cross without price", &Position{Side: "long", EntryPrice: 100, Leverage: 10, MarginType: MarginTypeCross}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, estimated := liquidationPriceOf(tt.pos)
+			if math.Abs(price-tt.price) > 1e-9 || estimated != tt.estimated {
+				t.Errorf("liquidationPriceOf() = %.4f/%v, expected %.4f/%v", price, estimated, tt.price, tt.estimated)
+			}
+		})
+	}
+}
+
+func TestLiquidationDistancePercent(t *testing.T) {
+	if d := liquidationDistancePercent("long", 100, 92); math.Abs(d-8) > 1e-9 {
+		t.Errorf("Expected long distance 8%%, got %.4f", d)
+	}
+	if d := liquidationDistancePercent("short", 100, 105); math.Abs(d-5) > 1e-9 {
+		t.Errorf("Expected short distance 5%%, got %.4f", d)
+	}
+}
+
+func TestNewLiquidationGuardDisabled(t *testing.T) {
+	cfg := &config.Config{LiquidationMonitorEnabled: false, LiquidationBufferPercent: 10}
+	if g := NewLiquidationGuard(cfg, nil, logger.NewColorLogger(false)); g != nil {
+		t.Error("Expected no guard when disabled")
+	}
+	cfg.LiquidationMonitorEnabled = true
+	cfg.LiquidationAutoReducePercent = 150
+	if g := NewLiquidationGuard(cfg, nil, logger.NewColorLogger(false)); g == nil || g.reducePercent != 100 {
+		t.Errorf("Expected an enabled guard with auto-reduce capped at 100%%, got %+v", g)
+	}
+}
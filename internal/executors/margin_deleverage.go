@@ -0,0 +1,149 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// marginDeleverageTrigger marks the stop-loss events recorded for reductions made because the margin ratio was high
+// marginDeleverageTrigger 标记因保证金率过高而减仓时记录的止损事件
+const marginDeleverageTrigger = "margin_deleverage"
+
+// MonitorMarginRatio watches the account margin ratio and, while it is above MARGIN_DELEVERAGE_RATIO_PERCENT, reduces the
+// largest losing managed position by MARGIN_DELEVERAGE_STEP_PERCENT on every check, until ctx is cancelled
+// MonitorMarginRatio 监控账户保证金率，高于 MARGIN_DELEVERAGE_RATIO_PERCENT 时每次检查将亏损最大的受管持仓减仓
+// MARGIN_DELEVERAGE_STEP_PERCENT，直到 ctx 取消
+// One step per check leaves time for the margin ratio to reflect the reduction before the next one
+// 每次检查只减仓一步，使保证金率在下一步之前反映减仓结果
+func (sm *StopLossManager) MonitorMarginRatio(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sm.logger.Info(fmt.Sprintf("🛡️ 启动保证金率监控，阈值 %.1f%%，每步减仓 %.0f%%，间隔: %v",
+		sm.config.MarginDeleverageRatioPercent, sm.config.MarginDeleverageStepPercent, interval))
+	alerted := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := sm.executor.AccountSnapshot(ctx)
+		if err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️ 获取账户保证金率失败: %v", err))
+			continue
+		}
+		if snapshot.MarginRatio < sm.config.MarginDeleverageRatioPercent {
+			if alerted {
+				sm.logger.Success(fmt.Sprintf("✅ 保证金率已回落至 %.1f%%", snapshot.MarginRatio))
+				sm.notifier.Notify(notify.SeverityInfo, "账户", fmt.Sprintf("✅ 保证金率已回落至 %.1f%%，停止自动减仓", snapshot.MarginRatio))
+				alerted = false
+			}
+			continue
+		}
+
+		losers := sm.managedLosers(snapshot.Positions)
+		if len(losers) == 0 {
+			if !alerted {
+				msg := fmt.Sprintf("🚨 保证金率 %.1f%% 超过阈值 %.1f%%，但没有亏损的受管持仓可减仓", snapshot.MarginRatio, sm.config.MarginDeleverageRatioPercent)
+				sm.logger.Error(msg)
+				sm.notifier.Notify(notify.SeverityCritical, "账户", msg)
+			}
+			alerted = true
+			continue
+		}
+		alerted = true
+
+		// Positions too small to reduce are skipped for the next largest loser
+		// 无法减仓的小持仓会被跳过，改为减仓下一个亏损最大的持仓
+		reduceCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		for _, p := range losers {
+			if sm.reduceForMargin(reduceCtx, p.Symbol, snapshot.MarginRatio, p.UnrealizedPnL) {
+				break
+			}
+		}
+		cancel()
+	}
+}
+
+// managedLosers returns the losing managed positions, largest loss first
+// managedLosers 返回亏损的受管持仓，亏损最大的在前
+// Positions of other instances or outside the bot's management are left to their owners
+// 其他实例或未受管理的持仓由其所有者处理
+func (sm *StopLossManager) managedLosers(positions []AccountPositionSnapshot) []AccountPositionSnapshot {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var losers []AccountPositionSnapshot
+	for _, p := range positions {
+		if _, ok := sm.positions[p.Symbol]; ok && p.UnrealizedPnL < 0 {
+			losers = append(losers, p)
+		}
+	}
+	sort.SliceStable(losers, func(i, j int) bool { return losers[i].UnrealizedPnL < losers[j].UnrealizedPnL })
+	return losers
+}
+
+// reduceForMargin closes MARGIN_DELEVERAGE_STEP_PERCENT of a position, re-placing its stop for the remaining quantity,
+// and reports whether it was reduced
+// reduceForMargin 平掉持仓的 MARGIN_DELEVERAGE_STEP_PERCENT，按剩余数量重挂止损，并返回是否已减仓
+func (sm *StopLossManager) reduceForMargin(ctx context.Context, symbol string, marginRatio, pnl float64) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, ok := sm.positions[symbol]
+	if !ok {
+		return false
+	}
+
+	filters, err := sm.executor.GetSymbolFilters(ctx, symbol)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】获取交易规则失败，使用内置精度: %v", symbol, err))
+	}
+	step := quantityStep(symbol, filters)
+	closeQty := marginReductionQuantity(pos.Quantity, sm.config.MarginDeleverageStepPercent, step, minTradableQuantity(symbol, filters))
+	if closeQty <= 0 {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】保证金率 %.1f%% 过高，但持仓 %.4f 无法按最小下单量减仓", symbol, marginRatio, pos.Quantity))
+		return false
+	}
+	reason := fmt.Sprintf("保证金率 %.1f%% 超过阈值 %.1f%%，减仓亏损最大的持仓 %.4f（未实现盈亏 %.2f）",
+		marginRatio, sm.config.MarginDeleverageRatioPercent, closeQty, pnl)
+
+	transitionPosition(sm.logger, pos, StateReducing, reason)
+	price, err := sm.executor.closeQuantity(ctx, symbol, pos.Side, closeQty, step, reason)
+	if err != nil {
+		finishReduce(sm.logger, pos, 0, "保证金率减仓失败")
+		sm.logger.Error(fmt.Sprintf("❌【%s】保证金率减仓失败，下个检查周期重试: %v", symbol, err))
+		sm.notifier.Notify(notify.SeverityCritical, symbol, fmt.Sprintf("🚨 保证金率 %.1f%% 过高，减仓失败: %v", marginRatio, err))
+		return false
+	}
+
+	finishReduce(sm.logger, pos, closeQty, reason)
+	sm.resizeStopLossOrder(ctx, pos, fmt.Sprintf("保证金率减仓后按新数量 %.4f 重挂止损", pos.Quantity))
+	sm.recordStopLossEvent(pos, pos.CurrentStopLoss, pos.CurrentStopLoss, reason, marginDeleverageTrigger)
+
+	sm.logger.Warning(fmt.Sprintf("🛡️【%s】%s @ %.2f，剩余 %.4f", symbol, reason, price, pos.Quantity))
+	sm.notifier.Notify(notify.SeverityCritical, symbol, fmt.Sprintf("🛡️ %s @ %.2f，剩余 %.4f", reason, price, pos.Quantity))
+	return true
+}
+
+// marginReductionQuantity rounds stepPercent of quantity down to the lot step, raising it to the minimum order quantity;
+// 0 means the position cannot be reduced without leaving an untradable remainder, which only a full close could clear
+// marginReductionQuantity 将数量的 stepPercent 向下取整到步长，不足最小下单量时提高到最小下单量；
+// 返回 0 表示减仓后剩余数量将无法交易，只能整体平仓
+func marginReductionQuantity(quantity, stepPercent, step, minQty float64) float64 {
+	qty := math.Floor(quantity*stepPercent/100/step+filterEpsilon) * step
+	if qty < minQty {
+		qty = minQty
+	}
+	if quantity-qty < minQty-filterEpsilon {
+		return 0
+	}
+	return qty
+}
@@ -0,0 +1,47 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestMarginReductionQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity float64
+		percent  float64
+		want     float64
+	}{
+		{"quarter rounded to the step", 1.005, 25, 0.251},
+		{"raised to the minimum", 0.010, 25, 0.005},
+		{"remainder below the minimum", 0.008, 25, 0},
+		{"whole position", 1, 100, 0},
+	}
+	for _, tt := range tests {
+		if got := marginReductionQuantity(tt.quantity, tt.percent, 0.001, 0.005); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: got %.4f, want %.4f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestManagedLosers(t *testing.T) {
+	cfg := &config.Config{PaperTrading: true, PaperInitialBalance: 10000}
+	log := logger.NewColorLogger(false)
+	sm := NewStopLossManager(cfg, NewBinanceExecutor(cfg, log), log, nil)
+	sm.RegisterPosition(&Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 90})
+	sm.RegisterPosition(&Position{Symbol: "ETHUSDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 90})
+	sm.RegisterPosition(&Position{Symbol: "SOLUSDT", Side: "short", EntryPrice: 100, Quantity: 1, InitialStopLoss: 110})
+
+	losers := sm.managedLosers([]AccountPositionSnapshot{
+		{Symbol: "BTCUSDT", UnrealizedPnL: -20},
+		{Symbol: "ETHUSDT", UnrealizedPnL: -50},
+		{Symbol: "SOLUSDT", UnrealizedPnL: 30},
+		{Symbol: "XRPUSDT", UnrealizedPnL: -90}, // 未受管 / unmanaged
+	})
+	if len(losers) != 2 || losers[0].Symbol != "ETHUSDT" || losers[1].Symbol != "BTCUSDT" {
+		t.Errorf("Expected ETHUSDT then BTCUSDT, got %+v", losers)
+	}
+}
@@ -0,0 +1,126 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Trailing stop modes
+// 追踪止损方式
+const (
+	TrailingStopModeLocal  = "local"  // 本地计算并重下 STOP_MARKET / Computed locally, STOP_MARKET re-placed
+	TrailingStopModeNative = "native" // 币安原生 TRAILING_STOP_MARKET / Binance native TRAILING_STOP_MARKET
+
+	StopLossTypeNativeTrailing = "native_trailing" // 由交易所追踪的止损 / Stop trailed by the exchange
+)
+
+// Binance callback rate limits for TRAILING_STOP_MARKET (percent, one decimal)
+// 币安 TRAILING_STOP_MARKET 回调比例限制（百分比，一位小数）
+const (
+	minCallbackRate = 0.1
+	maxCallbackRate = 10.0
+)
+
+// calculateCallbackRate converts the ATR trailing distance into a Binance callback rate
+// calculateCallbackRate 将 ATR 追踪距离换算为币安回调比例
+// rate = multiplier × ATR / price × 100, rounded to one decimal and clamped to Binance limits
+// 回调比例 = 倍数 × ATR / 价格 × 100，保留一位小数并限制在币安允许范围内
+func calculateCallbackRate(price, atr, multiplier float64) float64 {
+	if price <= 0 || atr <= 0 || multiplier <= 0 {
+		return minCallbackRate
+	}
+
+	rate := math.Round(multiplier*atr/price*100*10) / 10
+	if rate < minCallbackRate {
+		return minCallbackRate
+	}
+	if rate > maxCallbackRate {
+		return maxCallbackRate
+	}
+	return rate
+}
+
+// useNativeTrailing reports whether a position's trailing stop should be delegated to the exchange
+// useNativeTrailing 判断持仓的追踪止损是否应交给交易所执行
+func (sm *StopLossManager) useNativeTrailing(pos *Position) bool {
+	// The paper executor only simulates plain stop-market orders
+	// 模拟盘只模拟普通的 STOP_MARKET 止损单
+	if strings.ToLower(sm.config.TrailingStopMode) != TrailingStopModeNative || pos.ATR <= 0 || sm.executor.paper != nil {
+		return false
+	}
+	// The local monitor compares against a fixed stop price, which a native trailing order does not expose
+	// 本地监控需要对照固定止损价，原生追踪订单无法提供
+	if sm.localStop(pos.Symbol) {
+		return false
+	}
+	return sm.executor.Capabilities(pos.Symbol).TrailingStop
+}
+
+// placeNativeTrailingStop places a closing TRAILING_STOP_MARKET order for the position, by position side in hedge mode
+// placeNativeTrailingStop 为持仓下平仓的 TRAILING_STOP_MARKET 订单，双向持仓时按持仓方向平仓
+// The order activates immediately and trails the best mark price by the callback rate, so it keeps protecting the position while the bot is down
+// 订单立即激活，并按回调比例跟随最优标记价格，程序停机期间仍能保护持仓
+func (sm *StopLossManager) placeNativeTrailingStop(ctx context.Context, pos *Position) error {
+	currentPrice, err := sm.getCurrentPrice(ctx, pos.Symbol)
+	if err != nil {
+		return fmt.Errorf("获取当前价格失败: %w", err)
+	}
+
+	multiplier := sm.calculator.GetConfig(pos.Symbol).TrailingATRMultiplier
+	callbackRate := calculateCallbackRate(currentPrice, pos.ATR, multiplier)
+
+	orderSide := futures.SideTypeSell
+	if pos.Side == "short" {
+		orderSide = futures.SideTypeBuy
+	}
+
+	binanceSymbol := sm.config.GetBinanceSymbolFor(pos.Symbol)
+	orderService := sm.executor.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(orderSide).
+		Type(futures.OrderTypeTrailingStopMarket).
+		CallbackRate(fmt.Sprintf("%.1f", callbackRate)).
+		Quantity(fmt.Sprintf("%.4f", pos.Quantity)).
+		WorkingType(futures.WorkingTypeMarkPrice)
+
+	// Hedge mode closes by position side and rejects ReduceOnly; one-way mode needs ReduceOnly instead
+	// 双向持仓通过持仓方向平仓且不接受 ReduceOnly；单向持仓需要使用 ReduceOnly
+	if sm.executor.positionMode == PositionModeHedge {
+		positionSide := futures.PositionSideTypeLong
+		if pos.Side == "short" {
+			positionSide = futures.PositionSideTypeShort
+		}
+		orderService = orderService.PositionSide(positionSide)
+	} else {
+		orderService = orderService.ReduceOnly(true)
+	}
+	order, err := sm.executor.createOrder(ctx, binanceSymbol, orderService)
+	if err != nil {
+		return fmt.Errorf("下原生追踪止损单失败: %w", err)
+	}
+
+	// Record the trigger level implied by the current price; the exchange moves it from here on
+	// 记录由当前价推算的触发价，之后由交易所负责移动
+	impliedStop := currentPrice * (1 - callbackRate/100)
+	if pos.Side == "short" {
+		impliedStop = currentPrice * (1 + callbackRate/100)
+	}
+
+	pos.StopLossOrderID = fmt.Sprintf("%d", order.OrderID)
+	pos.StopLossType = StopLossTypeNativeTrailing
+	pos.TrailingDistance = callbackRate
+	pos.CurrentStopLoss = impliedStop
+
+	modeLabel := ""
+	if sm.executor.testMode {
+		modeLabel = "🧪 [测试网] "
+	}
+	sm.logger.Success(fmt.Sprintf("%s【%s】原生追踪止损单已下达: 回调 %.1f%% (ATR=%.2f × %.1f), 当前触发价约 %.2f (订单ID: %s)",
+		modeLabel, pos.Symbol, callbackRate, pos.ATR, multiplier, impliedStop, pos.StopLossOrderID))
+
+	return nil
+}
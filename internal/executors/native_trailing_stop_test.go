@@ -0,0 +1,61 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculateCallbackRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      float64
+		atr        float64
+		multiplier float64
+		expected   float64
+	}{
+		{
+			name:       "ATR distance converted to percent",
+			price:      50000,
+			atr:        500,
+			multiplier: 2.0,
+			expected:   2.0,
+		},
+		{
+			name:       "Rounded to one decimal",
+			price:      3000,
+			atr:        20,
+			multiplier: 2.5,
+			expected:   1.7,
+		},
+		{
+			name:       "Clamped to minimum",
+			price:      50000,
+			atr:        10,
+			multiplier: 2.0,
+			expected:   0.1,
+		},
+		{
+			name:       "Clamped to maximum",
+			price:      1,
+			atr:        0.2,
+			multiplier: 3.0,
+			expected:   10.0,
+		},
+		{
+			name:       "Invalid ATR falls back to minimum",
+			price:      50000,
+			atr:        0,
+			multiplier: 2.0,
+			expected:   0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := calculateCallbackRate(tt.price, tt.atr, tt.multiplier)
+			if math.Abs(result-tt.expected) > 0.0001 {
+				t.Errorf("Expected %.1f, got %.1f", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Binance symbol filter names checked locally
+// 本地校验的币安交易对过滤器名称
+const (
+	FilterPrice        = "PRICE_FILTER"
+	FilterLotSize      = "LOT_SIZE"
+	FilterMarketLot    = "MARKET_LOT_SIZE"
+	FilterPercentPrice = "PERCENT_PRICE"
+	FilterMinNotional  = "MIN_NOTIONAL"
+)
+
+// filterEpsilon absorbs float rounding when checking step and tick multiples
+// filterEpsilon 用于在校验步长和最小价格变动倍数时吸收浮点误差
+const filterEpsilon = 1e-9
+
+// SymbolFilters holds the numeric filter rules Binance publishes for a symbol; zero means the rule is not enforced
+// SymbolFilters 保存币安公布的交易对数值过滤规则；为 0 表示不校验该规则
+type SymbolFilters struct {
+	Symbol         string  // 交易对 / Trading pair
+	MinPrice       float64 // 最低价格 / Minimum price
+	MaxPrice       float64 // 最高价格 / Maximum price
+	TickSize       float64 // 价格最小变动 / Price tick size
+	MinQty         float64 // 最小数量 / Minimum quantity
+	MaxQty         float64 // 最大数量 / Maximum quantity
+	StepSize       float64 // 数量步长 / Quantity step size
+	MarketMinQty   float64 // 市价单最小数量 / Market order minimum quantity
+	MarketMaxQty   float64 // 市价单最大数量 / Market order maximum quantity
+	MarketStepSize float64 // 市价单数量步长 / Market order step size
+	MultiplierUp   float64 // 价格相对标记价上限倍数 / Upper price multiplier of mark price
+	MultiplierDown float64 // 价格相对标记价下限倍数 / Lower price multiplier of mark price
+	MinNotional    float64 // 最小名义价值 / Minimum notional
+}
+
+// OrderIntent describes an order the bot is about to send, formatted exactly as it would be submitted
+// OrderIntent 描述即将发送的订单，数值与实际提交时一致
+type OrderIntent struct {
+	Symbol     string            // 交易对 / Trading pair
+	Side       futures.SideType  // 方向 / Side
+	Type       futures.OrderType // 订单类型 / Order type
+	Quantity   float64           // 数量 / Quantity
+	Price      float64           // 限价（市价单为 0）/ Limit price (0 for market orders)
+	MarkPrice  float64           // 当前标记价格 / Current mark price
+	ReduceOnly bool              // 只减仓 / Reduce-only
+}
+
+// FilterViolation is one filter rule an order would break
+// FilterViolation 表示订单会违反的一条过滤规则
+type FilterViolation struct {
+	Filter string // 过滤器名称 / Filter name
+	Reason string // 拒绝原因 / Rejection reason
+}
+
+func (v FilterViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Filter, v.Reason)
+}
+
+// filtersFromSymbol extracts the numeric filter rules from an exchange info entry
+// filtersFromSymbol 从交易所信息条目中提取数值过滤规则
+func filtersFromSymbol(info futures.Symbol) SymbolFilters {
+	f := SymbolFilters{Symbol: info.Symbol}
+	num := func(s string) float64 {
+		v, _ := parseFloat(s)
+		return v
+	}
+
+	if p := info.PriceFilter(); p != nil {
+		f.MinPrice, f.MaxPrice, f.TickSize = num(p.MinPrice), num(p.MaxPrice), num(p.TickSize)
+	}
+	if l := info.LotSizeFilter(); l != nil {
+		f.MinQty, f.MaxQty, f.StepSize = num(l.MinQuantity), num(l.MaxQuantity), num(l.StepSize)
+	}
+	if m := info.MarketLotSizeFilter(); m != nil {
+		f.MarketMinQty, f.MarketMaxQty, f.MarketStepSize = num(m.MinQuantity), num(m.MaxQuantity), num(m.StepSize)
+	}
+	if pp := info.PercentPriceFilter(); pp != nil {
+		f.MultiplierUp, f.MultiplierDown = num(pp.MultiplierUp), num(pp.MultiplierDown)
+	}
+	if n := info.MinNotionalFilter(); n != nil {
+		f.MinNotional = num(n.Notional)
+	}
+	return f
+}
+
+// isMultiple reports whether (value - base) is a whole number of steps
+// isMultiple 判断 (value - base) 是否为步长的整数倍
+func isMultiple(value, base, step float64) bool {
+	if step <= 0 {
+		return true
+	}
+	n := (value - base) / step
+	return math.Abs(n-math.Round(n)) < filterEpsilon*math.Max(1, math.Abs(n))
+}
+
+// ValidateOrderIntent runs an order through the symbol's filters and returns every rule it would break
+// ValidateOrderIntent 用交易对的过滤规则校验订单，返回其违反的所有规则
+// It is a pure function so tests can exercise orders without touching the exchange
+// 这是纯函数，测试无需连接交易所即可校验订单
+func ValidateOrderIntent(filters SymbolFilters, intent OrderIntent) []FilterViolation {
+	var violations []FilterViolation
+	add := func(filter, format string, args ...interface{}) {
+		violations = append(violations, FilterViolation{Filter: filter, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	isMarket := intent.Type == futures.OrderTypeMarket
+
+	// PRICE_FILTER applies to orders that carry a price
+	// PRICE_FILTER 适用于带价格的订单
+	if !isMarket && intent.Price > 0 {
+		if filters.MinPrice > 0 && intent.Price < filters.MinPrice-filterEpsilon {
+			add(FilterPrice, "价格 %.8g 低于最低价 %.8g", intent.Price, filters.MinPrice)
+		}
+		if filters.MaxPrice > 0 && intent.Price > filters.MaxPrice+filterEpsilon {
+			add(FilterPrice, "价格 %.8g 高于最高价 %.8g", intent.Price, filters.MaxPrice)
+		}
+		if !isMultiple(intent.Price, filters.MinPrice, filters.TickSize) {
+			add(FilterPrice, "价格 %.8g 不是最小变动 %.8g 的整数倍", intent.Price, filters.TickSize)
+		}
+	}
+
+	// LOT_SIZE for limit orders, MARKET_LOT_SIZE for market orders
+	// 限价单校验 LOT_SIZE，市价单校验 MARKET_LOT_SIZE
+	lotFilter, minQty, maxQty, stepSize := FilterLotSize, filters.MinQty, filters.MaxQty, filters.StepSize
+	if isMarket && filters.MarketStepSize > 0 {
+		lotFilter, minQty, maxQty, stepSize = FilterMarketLot, filters.MarketMinQty, filters.MarketMaxQty, filters.MarketStepSize
+	}
+	if intent.Quantity <= 0 {
+		add(lotFilter, "数量 %.8g 必须大于 0", intent.Quantity)
+	} else {
+		if minQty > 0 && intent.Quantity < minQty-filterEpsilon {
+			add(lotFilter, "数量 %.8g 低于最小数量 %.8g", intent.Quantity, minQty)
+		}
+		if maxQty > 0 && intent.Quantity > maxQty+filterEpsilon {
+			add(lotFilter, "数量 %.8g 高于最大数量 %.8g", intent.Quantity, maxQty)
+		}
+		if !isMultiple(intent.Quantity, minQty, stepSize) {
+			add(lotFilter, "数量 %.8g 不是步长 %.8g 的整数倍", intent.Quantity, stepSize)
+		}
+	}
+
+	// PERCENT_PRICE bounds a limit price around the mark price: buys below the cap, sells above the floor
+	// PERCENT_PRICE 限制限价相对标记价格的范围：买单不高于上限，卖单不低于下限
+	if !isMarket && intent.Price > 0 && intent.MarkPrice > 0 {
+		if intent.Side == futures.SideTypeBuy && filters.MultiplierUp > 0 {
+			if limit := intent.MarkPrice * filters.MultiplierUp; intent.Price > limit+filterEpsilon {
+				add(FilterPercentPrice, "买价 %.8g 高于标记价上限 %.8g", intent.Price, limit)
+			}
+		}
+		if intent.Side == futures.SideTypeSell && filters.MultiplierDown > 0 {
+			if limit := intent.MarkPrice * filters.MultiplierDown; intent.Price < limit-filterEpsilon {
+				add(FilterPercentPrice, "卖价 %.8g 低于标记价下限 %.8g", intent.Price, limit)
+			}
+		}
+	}
+
+	// MIN_NOTIONAL does not apply to reduce-only orders; market orders are valued at the mark price
+	// MIN_NOTIONAL 不适用于只减仓订单；市价单按标记价格计算名义价值
+	if filters.MinNotional > 0 && !intent.ReduceOnly {
+		price := intent.Price
+		if isMarket || price <= 0 {
+			price = intent.MarkPrice
+		}
+		if price > 0 {
+			if notional := price * intent.Quantity; notional < filters.MinNotional-filterEpsilon {
+				add(FilterMinNotional, "名义价值 %.4f 低于最小值 %.4f", notional, filters.MinNotional)
+			}
+		}
+	}
+
+	return violations
+}
+
+// GetSymbolFilters returns the filters recorded by ProbeCapabilities, probing the symbol first if needed
+// GetSymbolFilters 返回 ProbeCapabilities 记录的过滤规则，必要时先探测该交易对
+func (e *BinanceExecutor) GetSymbolFilters(ctx context.Context, symbol string) (SymbolFilters, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+
+	e.capabilities.mu.RLock()
+	filters, ok := e.capabilities.filters[binanceSymbol]
+	e.capabilities.mu.RUnlock()
+	if ok {
+		return filters, nil
+	}
+
+	if err := e.ProbeCapabilities(ctx, []string{symbol}); err != nil {
+		return SymbolFilters{}, err
+	}
+
+	e.capabilities.mu.RLock()
+	filters, ok = e.capabilities.filters[binanceSymbol]
+	e.capabilities.mu.RUnlock()
+	if !ok {
+		return SymbolFilters{}, fmt.Errorf("no exchange filters for %s", binanceSymbol)
+	}
+	return filters, nil
+}
+
+// simulateTrade builds the order intents a trade action would send and validates them without placing anything
+// simulateTrade 构建交易动作将要发送的订单并进行校验，不实际下单
+// Used when ORDER_VALIDATION_ONLY is enabled
+// 在启用 ORDER_VALIDATION_ONLY 时使用
+func (e *BinanceExecutor) simulateTrade(ctx context.Context, symbol string, action TradeAction, amount float64, currentPosition *Position) ([]FilterViolation, error) {
+	filters, err := e.GetSymbolFilters(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	var premiumIndex []*futures.PremiumIndex
+	err = e.withRetry(func() error {
+		var err error
+		premiumIndex, err = e.client.NewPremiumIndexService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil || len(premiumIndex) == 0 {
+		return nil, fmt.Errorf("failed to get mark price: %w", err)
+	}
+	markPrice, _ := parseFloat(premiumIndex[0].MarkPrice)
+
+	// Round exactly like the order services do
+	// 与实际下单时的格式化保持一致
+	round := func(v float64, format string) float64 {
+		r, _ := parseFloat(fmt.Sprintf(format, v))
+		return r
+	}
+
+	// closeIntent mirrors executeCloseLong/executeCloseShort, which only use reduce-only in one-way mode
+	// closeIntent 与 executeCloseLong/executeCloseShort 一致，只在单向持仓模式下使用只减仓
+	closeIntent := func(side futures.SideType) OrderIntent {
+		return OrderIntent{Symbol: binanceSymbol, Side: side, Type: futures.OrderTypeMarket,
+			Quantity: round(currentPosition.Size, "%.4f"), MarkPrice: markPrice, ReduceOnly: closeReduceOnly(e.positionMode)}
+	}
+
+	var intents []OrderIntent
+	switch action {
+	case ActionBuy, ActionSell:
+		side, opposite, closeSide := futures.SideTypeBuy, "short", futures.SideTypeBuy
+		if action == ActionSell {
+			side, opposite, closeSide = futures.SideTypeSell, "long", futures.SideTypeSell
+		}
+		// Opening against an existing position closes it first
+		// 反向开仓前会先平掉现有持仓
+		if currentPosition != nil && currentPosition.Side == opposite {
+			intents = append(intents, closeIntent(closeSide))
+		}
+		intent := OrderIntent{Symbol: binanceSymbol, Side: side, Type: futures.OrderTypeMarket, Quantity: round(amount, "%.4f"), MarkPrice: markPrice}
+		if strings.ToLower(e.config.OrderExecutionMode) == OrderExecutionLimit && e.Capabilities(symbol).PostOnly {
+			intent.Type = futures.OrderTypeLimit
+			intent.Price = round(calculateMakerPrice(markPrice, side, e.config.LimitOrderOffsetPercent), "%.2f")
+		}
+		intents = append(intents, intent)
+	case ActionCloseLong:
+		if currentPosition != nil && currentPosition.Side == "long" {
+			intents = append(intents, closeIntent(futures.SideTypeSell))
+		}
+	case ActionCloseShort:
+		if currentPosition != nil && currentPosition.Side == "short" {
+			intents = append(intents, closeIntent(futures.SideTypeBuy))
+		}
+	}
+
+	var violations []FilterViolation
+	for _, intent := range intents {
+		violations = append(violations, ValidateOrderIntent(filters, intent)...)
+	}
+	return violations, nil
+}
+
+// validateOnly reports whether a trade would pass the exchange filters, without placing any order
+// validateOnly 报告交易能否通过交易所过滤规则，不实际下单
+// The result is never marked successful, so callers do not record positions or place stops for an order that was not sent
+// 结果永远不标记为成功，避免调用方为未发送的订单记录持仓或下止损单
+func (e *BinanceExecutor) validateOnly(ctx context.Context, symbol string, action TradeAction, amount float64, currentPosition *Position, result *TradeResult) *TradeResult {
+	violations, err := e.simulateTrade(ctx, symbol, action, amount, currentPosition)
+	if err != nil {
+		result.Message = fmt.Sprintf("仅校验模式：校验失败: %v", err)
+		e.logger.Error(result.Message)
+		return result
+	}
+
+	if len(violations) > 0 {
+		reasons := make([]string, len(violations))
+		for i, v := range violations {
+			reasons[i] = v.String()
+		}
+		result.Message = fmt.Sprintf("仅校验模式：订单会被拒绝 - %s", strings.Join(reasons, "; "))
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】%s", symbol, result.Message))
+		return result
+	}
+
+	result.Message = "仅校验模式：订单通过交易所过滤规则，未实际下单"
+	e.logger.Success(fmt.Sprintf("✅ 【%s】%s", symbol, result.Message))
+	return result
+}
@@ -0,0 +1,109 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestFiltersFromSymbol(t *testing.T) {
+	info := futures.Symbol{
+		Symbol: "BTCUSDT",
+		Filters: []map[string]interface{}{
+			{"filterType": "PRICE_FILTER", "minPrice": "556.80", "maxPrice": "4529764", "tickSize": "0.10"},
+			{"filterType": "LOT_SIZE", "minQty": "0.001", "maxQty": "1000", "stepSize": "0.001"},
+			{"filterType": "MARKET_LOT_SIZE", "minQty": "0.001", "maxQty": "120", "stepSize": "0.001"},
+			{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500", "multiplierDown": "0.9500", "multiplierDecimal": "4"},
+			{"filterType": "MIN_NOTIONAL", "notional": "100"},
+		},
+	}
+
+	f := filtersFromSymbol(info)
+	if f.TickSize != 0.1 || f.StepSize != 0.001 || f.MarketMaxQty != 120 || f.MultiplierUp != 1.05 || f.MinNotional != 100 {
+		t.Errorf("Unexpected filters: %+v", f)
+	}
+}
+
+func TestValidateOrderIntent(t *testing.T) {
+	filters := SymbolFilters{
+		Symbol:         "BTCUSDT",
+		MinPrice:       556.8,
+		MaxPrice:       4529764,
+		TickSize:       0.1,
+		MinQty:         0.001,
+		MaxQty:         1000,
+		StepSize:       0.001,
+		MarketMinQty:   0.001,
+		MarketMaxQty:   120,
+		MarketStepSize: 0.001,
+		MultiplierUp:   1.05,
+		MultiplierDown: 0.95,
+		MinNotional:    100,
+	}
+
+	tests := []struct {
+		name     string
+		intent   OrderIntent
+		expected []string
+	}{
+		{
+			name:     "Valid market order",
+			intent:   OrderIntent{Side: futures.SideTypeBuy, Type: futures.OrderTypeMarket, Quantity: 0.01, MarkPrice: 60000},
+			expected: nil,
+		},
+		{
+			name:     "Valid limit order",
+			intent:   OrderIntent{Side: futures.SideTypeSell, Type: futures.OrderTypeLimit, Quantity: 0.005, Price: 60012.3, MarkPrice: 60000},
+			expected: nil,
+		},
+		{
+			name:     "Price off tick",
+			intent:   OrderIntent{Side: futures.SideTypeBuy, Type: futures.OrderTypeLimit, Quantity: 0.01, Price: 59987.65, MarkPrice: 60000},
+			expected: []string{FilterPrice},
+		},
+		{
+			name:     "Quantity off step and below notional",
+			intent:   OrderIntent{Side: futures.SideTypeBuy, Type: futures.OrderTypeLimit, Quantity: 0.0015, Price: 60000, MarkPrice: 60000},
+			expected: []string{FilterLotSize, FilterMinNotional},
+		},
+		{
+			name:     "Market quantity above market max",
+			intent:   OrderIntent{Side: futures.SideTypeSell, Type: futures.OrderTypeMarket, Quantity: 150, MarkPrice: 60000},
+			expected: []string{FilterMarketLot},
+		},
+		{
+			name:     "Buy above percent price cap",
+			intent:   OrderIntent{Side: futures.SideTypeBuy, Type: futures.OrderTypeLimit, Quantity: 0.01, Price: 64000, MarkPrice: 60000},
+			expected: []string{FilterPercentPrice},
+		},
+		{
+			name:     "Sell below percent price floor",
+			intent:   OrderIntent{Side: futures.SideTypeSell, Type: futures.OrderTypeLimit, Quantity: 0.01, Price: 56000, MarkPrice: 60000},
+			expected: []string{FilterPercentPrice},
+		},
+		{
+			name:     "Reduce-only is exempt from min notional",
+			intent:   OrderIntent{Side: futures.SideTypeSell, Type: futures.OrderTypeMarket, Quantity: 0.001, MarkPrice: 60000, ReduceOnly: true},
+			expected: nil,
+		},
+		{
+			name:     "Zero quantity",
+			intent:   OrderIntent{Side: futures.SideTypeBuy, Type: futures.OrderTypeMarket, Quantity: 0, MarkPrice: 60000},
+			expected: []string{FilterMarketLot, FilterMinNotional},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateOrderIntent(filters, tt.intent)
+			if len(violations) != len(tt.expected) {
+				t.Fatalf("Expected %d violations %v, got %v", len(tt.expected), tt.expected, violations)
+			}
+			for i, v := range violations {
+				if v.Filter != tt.expected[i] {
+					t.Errorf("Expected violation %d to be %s, got %s", i, tt.expected[i], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Pre-flight errors for orders that the exchange would reject or that would not do what the action intends
+// 预检错误：交易所会拒绝的订单，或与交易动作意图不符的订单
+var (
+	ErrPositionModeUnknown   = errors.New("position mode unknown")
+	ErrInvalidOrderQuantity  = errors.New("invalid order quantity")
+	ErrNoPositionToClose     = errors.New("no position to close")
+	ErrReduceOnlyInHedgeMode = errors.New("reduce-only is not accepted in hedge mode")
+	ErrOrderWouldIncrease    = errors.New("closing order would increase the position")
+	ErrCloseExceedsPosition  = errors.New("closing quantity exceeds the position")
+	ErrInsufficientMargin    = errors.New("insufficient available margin")
+)
+
+// PreflightState is the account state an order is checked against
+// PreflightState 是订单预检所依据的账户状态
+type PreflightState struct {
+	Mode            PositionMode // 持仓模式 / Position mode
+	Position        *Position    // 当前持仓，无持仓为 nil / Current position, nil when flat
+	AvailableMargin float64      // 可用保证金，负数表示未知 / Available margin, negative when unknown
+	Leverage        int          // 开仓杠杆，0 表示未知 / Leverage for new positions, 0 when unknown
+	MarkPrice       float64      // 标记价格，0 表示未知 / Mark price, 0 when unknown
+	Hedge           bool         // 反向开仓时保留原持仓对冲 / Reversals keep the opposite position as a hedge
+}
+
+// PreflightOrder is one order as the executor is about to send it
+// PreflightOrder 是执行器即将发送的一笔订单
+type PreflightOrder struct {
+	Side       futures.SideType // 买/卖 / Buy or sell
+	Quantity   float64          // 数量 / Quantity
+	ReduceOnly bool             // 只减仓标志 / Reduce-only flag
+	Closes     bool             // 用于平掉当前持仓 / Meant to close the current position
+}
+
+// closeReduceOnly reports whether closing orders carry the reduce-only flag in a position mode
+// closeReduceOnly 返回在该持仓模式下平仓单是否携带只减仓标志
+// Hedge mode closes by position side and rejects reduce-only; one-way mode needs reduce-only so a close never flips the position
+// 双向持仓通过持仓方向平仓且不接受只减仓；单向持仓需要只减仓，避免平仓时反向开仓
+func closeReduceOnly(mode PositionMode) bool {
+	return mode == PositionModeOneWay
+}
+
+// CheckOrderPreflight checks one order against the position mode, the current position and the available margin
+// CheckOrderPreflight 按持仓模式、当前持仓和可用保证金检查一笔订单
+func CheckOrderPreflight(state PreflightState, order PreflightOrder) error {
+	if state.Mode != PositionModeOneWay && state.Mode != PositionModeHedge {
+		return fmt.Errorf("%w: %q", ErrPositionModeUnknown, state.Mode)
+	}
+	if order.Quantity <= 0 {
+		return fmt.Errorf("%w: %.4f", ErrInvalidOrderQuantity, order.Quantity)
+	}
+	if order.ReduceOnly && state.Mode == PositionModeHedge {
+		return ErrReduceOnlyInHedgeMode
+	}
+
+	if order.ReduceOnly || order.Closes {
+		pos := state.Position
+		if pos == nil || pos.Size <= 0 {
+			return fmt.Errorf("%w: 当前无持仓", ErrNoPositionToClose)
+		}
+		// A sell reduces a long and a buy reduces a short
+		// 卖单减少多仓，买单减少空仓
+		reduces := (order.Side == futures.SideTypeSell && pos.Side == "long") ||
+			(order.Side == futures.SideTypeBuy && pos.Side == "short")
+		if !reduces {
+			return fmt.Errorf("%w: %s 单不能减少 %s 持仓", ErrOrderWouldIncrease, order.Side, pos.Side)
+		}
+		if order.Quantity > pos.Size+filterEpsilon {
+			return fmt.Errorf("%w: %.4f > %.4f", ErrCloseExceedsPosition, order.Quantity, pos.Size)
+		}
+		return nil
+	}
+
+	// Opening orders need initial margin; skip the check when any input is unknown
+	// 开仓单需要初始保证金；任一输入未知时跳过检查
+	if state.AvailableMargin < 0 || state.Leverage <= 0 || state.MarkPrice <= 0 {
+		return nil
+	}
+	required := order.Quantity * state.MarkPrice / float64(state.Leverage)
+	if required > state.AvailableMargin+filterEpsilon {
+		return fmt.Errorf("%w: 需要 %.2f USDT（%.4f × %.2f / %dx），可用 %.2f USDT",
+			ErrInsufficientMargin, required, order.Quantity, state.MarkPrice, state.Leverage, state.AvailableMargin)
+	}
+	return nil
+}
+
+// PreflightTrade checks every order a trade action would send, mirroring the order sequence of ExecuteTrade
+// PreflightTrade 检查交易动作将发送的每一笔订单，与 ExecuteTrade 的下单顺序一致
+// Reversing first closes the opposite position, whose margin is then available for the new one, unless it is kept as a hedge
+// 反手时先平掉反向持仓，释放的保证金可用于新开仓；保留为对冲时除外
+func PreflightTrade(state PreflightState, action TradeAction, amount float64) error {
+	switch action {
+	case ActionCloseLong, ActionCloseShort:
+		side, want := futures.SideTypeSell, "long"
+		if action == ActionCloseShort {
+			side, want = futures.SideTypeBuy, "short"
+		}
+		if state.Position == nil || state.Position.Side != want {
+			current := "无"
+			if state.Position != nil {
+				current = fmt.Sprintf("%s %.4f", state.Position.Side, state.Position.Size)
+			}
+			return fmt.Errorf("%w: %s 需要%s，当前持仓: %s", ErrNoPositionToClose, action, sideLabel(want), current)
+		}
+		return CheckOrderPreflight(state, PreflightOrder{Side: side, Quantity: state.Position.Size, ReduceOnly: closeReduceOnly(state.Mode), Closes: true})
+
+	case ActionBuy, ActionSell:
+		side, closeSide, same, opposite := futures.SideTypeBuy, futures.SideTypeBuy, "long", "short"
+		if action == ActionSell {
+			side, closeSide, same, opposite = futures.SideTypeSell, futures.SideTypeSell, "short", "long"
+		}
+		// Already holding this side: the executor refuses to add, so nothing is sent
+		// 已持有同向仓位：执行器拒绝加仓，不会发送订单
+		if state.Position != nil && state.Position.Side == same {
+			return nil
+		}
+		// A hedge keeps the opposite position, so nothing is closed and no margin is released
+		// 对冲保留反向持仓，不平仓也不释放保证金
+		if state.Position != nil && state.Position.Side == opposite && state.Hedge {
+			state.Position = nil
+		}
+		if state.Position != nil && state.Position.Side == opposite {
+			if err := CheckOrderPreflight(state, PreflightOrder{Side: closeSide, Quantity: state.Position.Size, Closes: true}); err != nil {
+				return err
+			}
+			if state.AvailableMargin >= 0 && state.Position.Leverage > 0 {
+				state.AvailableMargin += state.Position.Size * state.Position.EntryPrice / float64(state.Position.Leverage)
+			}
+			state.Position = nil
+		}
+		return CheckOrderPreflight(state, PreflightOrder{Side: side, Quantity: amount})
+	}
+	return nil
+}
+
+// preflightTrade gathers the account state and checks a trade before any order is sent
+// preflightTrade 收集账户状态，在发送任何订单前检查交易
+// Margin inputs that cannot be fetched are left unknown, so a failed lookup never blocks trading by itself
+// 无法获取的保证金输入保持未知，查询失败本身不会阻止交易
+func (e *BinanceExecutor) preflightTrade(ctx context.Context, symbol string, action TradeAction, amount float64, currentPosition *Position) error {
+	if action == ActionHold {
+		return nil
+	}
+	state := PreflightState{Mode: e.positionMode, Position: currentPosition, AvailableMargin: -1, Hedge: e.hedgeOnReversal()}
+
+	if action == ActionBuy || action == ActionSell {
+		binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+		if balance, err := e.GetBalance(ctx); err == nil {
+			state.AvailableMargin = balance
+		}
+		if markPrice, err := e.getMarkPrice(ctx, binanceSymbol); err == nil {
+			state.MarkPrice = markPrice
+		}
+		if leverage, err := e.symbolLeverage(ctx, binanceSymbol); err == nil {
+			state.Leverage = leverage
+		}
+	}
+	return PreflightTrade(state, action, amount)
+}
+
+// symbolLeverage returns the leverage currently set on a symbol, which applies to the next entry
+// symbolLeverage 返回交易对当前设置的杠杆，即下一次开仓使用的杠杆
+func (e *BinanceExecutor) symbolLeverage(ctx context.Context, binanceSymbol string) (int, error) {
+	var positions []*futures.PositionRisk
+	err := e.withRetry(func() error {
+		var err error
+		positions, err = e.client.NewGetPositionRiskService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get position risk: %w", err)
+	}
+	if len(positions) == 0 {
+		return 0, fmt.Errorf("no position risk for %s", binanceSymbol)
+	}
+	leverage, err := parseInt(positions[0].Leverage)
+	if err != nil || leverage <= 0 {
+		return 0, fmt.Errorf("invalid leverage for %s: %q", binanceSymbol, positions[0].Leverage)
+	}
+	return leverage, nil
+}
@@ -0,0 +1,81 @@
+package executors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestPreflightTrade(t *testing.T) {
+	long := &Position{Side: "long", Size: 0.1, EntryPrice: 50000, Leverage: 10}
+	short := &Position{Side: "short", Size: 0.1, EntryPrice: 50000, Leverage: 10}
+	state := func(mode PositionMode, pos *Position, margin float64) PreflightState {
+		return PreflightState{Mode: mode, Position: pos, AvailableMargin: margin, Leverage: 10, MarkPrice: 50000}
+	}
+
+	tests := []struct {
+		name   string
+		state  PreflightState
+		action TradeAction
+		amount float64
+		want   error
+	}{
+		{"close long without position", state(PositionModeOneWay, nil, -1), ActionCloseLong, 0, ErrNoPositionToClose},
+		{"close long while short", state(PositionModeHedge, short, -1), ActionCloseLong, 0, ErrNoPositionToClose},
+		{"close short while short", state(PositionModeHedge, short, -1), ActionCloseShort, 0, nil},
+		{"close long one-way", state(PositionModeOneWay, long, -1), ActionCloseLong, 0, nil},
+		{"unknown mode", state("", long, -1), ActionCloseLong, 0, ErrPositionModeUnknown},
+		{"zero quantity", state(PositionModeOneWay, nil, 1000), ActionBuy, 0, ErrInvalidOrderQuantity},
+		{"enough margin", state(PositionModeOneWay, nil, 600), ActionBuy, 0.1, nil},
+		{"insufficient margin", state(PositionModeOneWay, nil, 400), ActionSell, 0.1, ErrInsufficientMargin},
+		{"unknown margin skips check", state(PositionModeOneWay, nil, -1), ActionBuy, 10, nil},
+		{"reverse frees margin", state(PositionModeOneWay, short, 100), ActionBuy, 0.1, nil},
+		{"reverse still short of margin", state(PositionModeOneWay, short, 100), ActionBuy, 0.2, ErrInsufficientMargin},
+		{"hedge keeps the opposite margin", PreflightState{Mode: PositionModeHedge, Position: short, AvailableMargin: 100, Leverage: 10, MarkPrice: 50000, Hedge: true}, ActionBuy, 0.1, ErrInsufficientMargin},
+		{"same side is a no-op", state(PositionModeOneWay, long, 0), ActionBuy, 1, nil},
+		{"hold", state("", nil, 0), ActionHold, 0, nil},
+	}
+
+	for _, tt := range tests {
+		err := PreflightTrade(tt.state, tt.action, tt.amount)
+		if tt.want == nil && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestCheckOrderPreflightReduceOnly(t *testing.T) {
+	long := &Position{Side: "long", Size: 0.1}
+
+	tests := []struct {
+		name  string
+		mode  PositionMode
+		pos   *Position
+		order PreflightOrder
+		want  error
+	}{
+		{"reduce-only in hedge mode", PositionModeHedge, long, PreflightOrder{Side: futures.SideTypeSell, Quantity: 0.1, ReduceOnly: true}, ErrReduceOnlyInHedgeMode},
+		{"reduce-only without position", PositionModeOneWay, nil, PreflightOrder{Side: futures.SideTypeSell, Quantity: 0.1, ReduceOnly: true}, ErrNoPositionToClose},
+		{"reduce-only on the same side", PositionModeOneWay, long, PreflightOrder{Side: futures.SideTypeBuy, Quantity: 0.1, ReduceOnly: true}, ErrOrderWouldIncrease},
+		{"reduce-only larger than position", PositionModeOneWay, long, PreflightOrder{Side: futures.SideTypeSell, Quantity: 0.2, ReduceOnly: true}, ErrCloseExceedsPosition},
+		{"valid reduce-only", PositionModeOneWay, long, PreflightOrder{Side: futures.SideTypeSell, Quantity: 0.1, ReduceOnly: true}, nil},
+	}
+
+	for _, tt := range tests {
+		err := CheckOrderPreflight(PreflightState{Mode: tt.mode, Position: tt.pos, AvailableMargin: -1}, tt.order)
+		if tt.want == nil && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if !closeReduceOnly(PositionModeOneWay) || closeReduceOnly(PositionModeHedge) {
+		t.Error("closing orders should use reduce-only in one-way mode only")
+	}
+}
@@ -0,0 +1,527 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// paperMaintenanceMarginRate approximates the maintenance margin rate of Binance's first notional tier
+// paperMaintenanceMarginRate 近似币安第一档名义价值的维持保证金率
+const paperMaintenanceMarginRate = 0.004
+
+// errCodeUnknownOrder is the Binance error code returned for an order that does not exist
+// errCodeUnknownOrder 订单不存在时币安返回的错误码
+const errCodeUnknownOrder = -2011
+
+// paperPosition is a simulated isolated-margin position
+// paperPosition 模拟的逐仓持仓
+type paperPosition struct {
+	side       string  // 方向 long/short / Side
+	size       float64 // 持仓数量 / Quantity held
+	entryPrice float64 // 开仓均价 / Average entry price
+	leverage   int     // 杠杆倍数 / Leverage
+	margin     float64 // 占用保证金 / Margin locked by the position
+}
+
+// paperOrder is a simulated reduce-only STOP_MARKET order
+// paperOrder 模拟的只减仓 STOP_MARKET 订单
+type paperOrder struct {
+	orderID   int64                   // 订单 ID / Order ID
+	symbol    string                  // 交易对（币安格式）/ Symbol in Binance format
+	side      string                  // 所保护持仓的方向 / Side of the position it protects
+	stopPrice float64                 // 触发价 / Trigger price
+	quantity  float64                 // 数量 / Quantity
+	status    futures.OrderStatusType // 订单状态 / Order status
+	avgPrice  float64                 // 成交均价 / Average fill price
+}
+
+// PaperExecutor simulates futures trading against live prices with a virtual USDT balance
+// PaperExecutor 使用虚拟 USDT 余额，按实盘价格模拟合约交易
+//
+// Market orders fill at the last observed price and pay the taker fee. Positions are isolated,
+// so a position whose price crosses its liquidation price loses its whole margin.
+// Resting stop-loss orders trigger when an observed price crosses them.
+// 市价单按最近观察到的价格成交并支付吃单手续费。持仓为逐仓，价格越过强平价时损失全部保证金。
+// 挂着的止损单在观察到的价格越过触发价时成交。
+type PaperExecutor struct {
+	mu          sync.Mutex
+	balance     float64                   // 钱包余额（含已实现盈亏，扣除手续费）/ Wallet balance after realized PnL and fees
+	feeRate     float64                   // 吃单手续费率 / Taker fee rate
+	leverage    int                       // 默认杠杆 / Default leverage
+	leverages   map[string]int            // 按交易对设置的杠杆 / Leverage set per symbol
+	positions   map[string]*paperPosition // 持仓（键为币安格式）/ Positions keyed by Binance symbol
+	orders      map[int64]*paperOrder     // 止损单 / Stop orders
+	lastPrices  map[string]float64        // 最近观察到的价格 / Last observed prices
+	nextOrderID int64                     // 下一个订单 ID / Next order ID
+	totalFees   float64                   // 累计手续费 / Fees paid so far
+	logger      *logger.ColorLogger       // 日志 / Logger
+}
+
+// NewPaperExecutor creates a paper executor holding initialBalance USDT
+// NewPaperExecutor 创建持有 initialBalance USDT 的模拟执行器
+func NewPaperExecutor(initialBalance, feeRate float64, leverage int, log *logger.ColorLogger) *PaperExecutor {
+	if leverage < 1 {
+		leverage = 1
+	}
+	return &PaperExecutor{
+		balance:     initialBalance,
+		feeRate:     feeRate,
+		leverage:    leverage,
+		leverages:   make(map[string]int),
+		positions:   make(map[string]*paperPosition),
+		orders:      make(map[int64]*paperOrder),
+		lastPrices:  make(map[string]float64),
+		nextOrderID: 1,
+		logger:      log,
+	}
+}
+
+// SetLeverage sets the leverage used for new positions on a symbol
+// SetLeverage 设置交易对新开仓使用的杠杆
+func (p *PaperExecutor) SetLeverage(symbol string, leverage int) {
+	if leverage < 1 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.leverages[symbol] = leverage
+}
+
+// Leverage returns the leverage used for new positions on a symbol
+// Leverage 返回交易对新开仓使用的杠杆
+func (p *PaperExecutor) Leverage(symbol string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.leverageLocked(symbol)
+}
+
+// Fill executes action at price, updating the virtual balance and position like the live executor would
+// Fill 按 price 执行交易动作，像实盘执行器一样更新虚拟余额和持仓
+//
+// A buy closes an existing short first and never adds to an existing long; a close with 0 < amount < size closes part of the position.
+// 买入会先平掉已有空仓，且不会在已有多仓上加仓；平仓时 0 < amount < 持仓数量 表示部分平仓。
+func (p *PaperExecutor) Fill(symbol string, action TradeAction, amount, price float64, result *TradeResult) error {
+	if price <= 0 {
+		return fmt.Errorf("no price available for %s", symbol)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastPrices[symbol] = price
+
+	pos := p.positions[symbol]
+	switch action {
+	case ActionBuy, ActionSell:
+		side := "long"
+		if action == ActionSell {
+			side = "short"
+		}
+		if pos != nil && pos.side == side {
+			result.Message = fmt.Sprintf("已有%s，不重复开仓（系统保护：防止意外加仓）", sideLabel(side))
+			return nil
+		}
+		if pos != nil {
+			p.closeLocked(symbol, pos.size, price)
+		}
+		if err := p.openLocked(symbol, side, amount, price); err != nil {
+			return err
+		}
+		result.Filled = amount
+	case ActionCloseLong, ActionCloseShort:
+		side := "long"
+		if action == ActionCloseShort {
+			side = "short"
+		}
+		if pos == nil || pos.side != side {
+			result.Message = fmt.Sprintf("没有%s可平", sideLabel(side))
+			return nil
+		}
+		qty := pos.size
+		if amount > 0 && amount < pos.size {
+			qty = amount
+		}
+		p.closeLocked(symbol, qty, price)
+		result.Filled = qty
+	default:
+		return fmt.Errorf("unsupported paper action: %s", action)
+	}
+
+	result.Success = true
+	result.OrderID = fmt.Sprintf("%d", p.newOrderIDLocked())
+	result.Price = price
+	result.Message = "订单执行成功（模拟盘）"
+	return nil
+}
+
+// openLocked opens a position after checking the margin and fee against the available balance
+// openLocked 检查可用余额足够支付保证金和手续费后开仓
+func (p *PaperExecutor) openLocked(symbol, side string, qty, price float64) error {
+	if qty <= 0 {
+		return fmt.Errorf("invalid quantity %.4f", qty)
+	}
+	leverage := p.leverageLocked(symbol)
+	notional := qty * price
+	margin := notional / float64(leverage)
+	fee := notional * p.feeRate
+	if available := p.availableLocked(); margin+fee > available {
+		return fmt.Errorf("insufficient virtual balance: need %.2f USDT, available %.2f USDT", margin+fee, available)
+	}
+
+	p.chargeFeeLocked(fee)
+	p.positions[symbol] = &paperPosition{
+		side:       side,
+		size:       qty,
+		entryPrice: price,
+		leverage:   leverage,
+		margin:     margin,
+	}
+	return nil
+}
+
+// closeLocked closes qty of a position at price and realizes its PnL minus the fee
+// closeLocked 按 price 平掉 qty 数量的持仓，实现扣除手续费后的盈亏
+func (p *PaperExecutor) closeLocked(symbol string, qty, price float64) float64 {
+	pos := p.positions[symbol]
+	pnl := positionPnL(pos.side, pos.entryPrice, price, qty)
+	p.balance += pnl
+	p.chargeFeeLocked(qty * price * p.feeRate)
+
+	pos.margin -= pos.margin * qty / pos.size
+	pos.size -= qty
+	if pos.size <= filterEpsilon {
+		delete(p.positions, symbol)
+	}
+	return pnl
+}
+
+// chargeFeeLocked deducts a trading fee from the wallet
+// chargeFeeLocked 从钱包扣除交易手续费
+func (p *PaperExecutor) chargeFeeLocked(fee float64) {
+	p.balance -= fee
+	p.totalFees += fee
+}
+
+// OnPrice feeds an observed price, triggering crossed stop orders and liquidating positions past their liquidation price
+// OnPrice 输入观察到的价格，触发被越过的止损单，并强平越过强平价的持仓
+//
+// A nil executor ignores prices, so callers can feed every price they fetch.
+// 执行器为 nil 时忽略价格，调用方可以输入每一次获取到的价格。
+func (p *PaperExecutor) OnPrice(symbol string, price float64) {
+	if p == nil || price <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastPrices[symbol] = price
+
+	for _, order := range p.workingOrdersLocked(symbol) {
+		if !stopBreached(order.side, order.stopPrice, price) {
+			continue
+		}
+		pos := p.positions[symbol]
+		if pos == nil || pos.side != order.side {
+			// A reduce-only order with nothing left to reduce is rejected by the exchange
+			// 没有可减仓位的只减仓订单会被交易所拒绝
+			order.status = futures.OrderStatusTypeExpired
+			continue
+		}
+		qty := math.Min(order.quantity, pos.size)
+		pnl := p.closeLocked(symbol, qty, price)
+		order.status = futures.OrderStatusTypeFilled
+		order.avgPrice = price
+		p.logger.Warning(fmt.Sprintf("📝 [模拟盘]【%s】止损单 %d 触发: %.4f @ %.2f，盈亏 %+.2f USDT",
+			symbol, order.orderID, qty, price, pnl))
+	}
+
+	pos := p.positions[symbol]
+	if pos == nil {
+		return
+	}
+	liquidationPrice := paperLiquidationPrice(pos.side, pos.entryPrice, pos.leverage)
+	if !stopBreached(pos.side, liquidationPrice, price) {
+		return
+	}
+	// The insurance fund keeps what is left of an isolated position's margin, so the whole margin is lost
+	// 逐仓持仓剩余的保证金归保险基金，因此损失全部保证金
+	p.balance -= pos.margin
+	delete(p.positions, symbol)
+	p.logger.Error(fmt.Sprintf("💥 [模拟盘]【%s】%s被强平: 强平价 %.2f, 当前价 %.2f, 损失保证金 %.2f USDT",
+		symbol, sideLabel(pos.side), liquidationPrice, price, pos.margin))
+}
+
+// PlaceStopOrder rests a reduce-only stop order protecting the position on side and returns its order ID
+// PlaceStopOrder 挂一个保护 side 方向持仓的只减仓止损单，返回订单 ID
+func (p *PaperExecutor) PlaceStopOrder(symbol, side string, stopPrice, quantity float64) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	order := &paperOrder{
+		orderID:   p.newOrderIDLocked(),
+		symbol:    symbol,
+		side:      side,
+		stopPrice: stopPrice,
+		quantity:  quantity,
+		status:    futures.OrderStatusTypeNew,
+	}
+	p.orders[order.orderID] = order
+	return order.orderID
+}
+
+// CancelOrder cancels a working stop order; like the exchange it reports an unknown order once the order is no longer working
+// CancelOrder 撤销挂着的止损单；与交易所一致，订单不再挂单时返回订单不存在
+func (p *PaperExecutor) CancelOrder(orderID int64) (*futures.CancelOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	order, ok := p.orders[orderID]
+	if !ok || order.status != futures.OrderStatusTypeNew {
+		return nil, unknownOrderError()
+	}
+	order.status = futures.OrderStatusTypeCanceled
+	return &futures.CancelOrderResponse{
+		OrderID: order.orderID,
+		Symbol:  order.symbol,
+		Status:  order.status,
+	}, nil
+}
+
+// Order returns a stop order in the same shape the exchange would
+// Order 以交易所相同的结构返回止损单
+func (p *PaperExecutor) Order(orderID int64) (*futures.Order, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	order, ok := p.orders[orderID]
+	if !ok {
+		return nil, unknownOrderError()
+	}
+	executed := 0.0
+	if order.status == futures.OrderStatusTypeFilled {
+		executed = order.quantity
+	}
+	side := futures.SideTypeSell
+	if order.side == "short" {
+		side = futures.SideTypeBuy
+	}
+	return &futures.Order{
+		Symbol:           order.symbol,
+		OrderID:          order.orderID,
+		Side:             side,
+		Status:           order.status,
+		Type:             futures.OrderTypeStopMarket,
+		StopPrice:        fmt.Sprintf("%.8f", order.stopPrice),
+		OrigQuantity:     fmt.Sprintf("%.8f", order.quantity),
+		ExecutedQuantity: fmt.Sprintf("%.8f", executed),
+		AvgPrice:         fmt.Sprintf("%.8f", order.avgPrice),
+		ReduceOnly:       true,
+	}, nil
+}
+
+// OpenOrders returns the working stop orders of a symbol in the same shape the exchange would
+// OpenOrders 以交易所相同的结构返回交易对挂着的止损单
+func (p *PaperExecutor) OpenOrders(symbol string) []*futures.Order {
+	p.mu.Lock()
+	working := p.workingOrdersLocked(symbol)
+	p.mu.Unlock()
+
+	orders := make([]*futures.Order, 0, len(working))
+	for _, o := range working {
+		if order, err := p.Order(o.orderID); err == nil {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// Position returns the simulated position of a symbol valued at the last observed price, or nil when flat
+// Position 返回按最近观察价格估值的模拟持仓，无持仓时返回 nil
+func (p *PaperExecutor) Position(symbol string) *Position {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pos := p.positions[symbol]
+	if pos == nil {
+		return nil
+	}
+	positionAmt := pos.size
+	if pos.side == "short" {
+		positionAmt = -pos.size
+	}
+	return &Position{
+		Side:             pos.side,
+		Size:             pos.size,
+		EntryPrice:       pos.entryPrice,
+		UnrealizedPnL:    p.unrealizedLocked(symbol, pos),
+		PositionAmt:      positionAmt,
+		Symbol:           symbol,
+		Leverage:         pos.leverage,
+		LiquidationPrice: paperLiquidationPrice(pos.side, pos.entryPrice, pos.leverage),
+		MarginType:       MarginTypeIsolated,
+	}
+}
+
+// Account returns the virtual balance in the shape of a Binance futures account
+// Account 以币安合约账户的结构返回虚拟余额
+func (p *PaperExecutor) Account() *futures.Account {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var unrealized, usedMargin float64
+	for symbol, pos := range p.positions {
+		unrealized += p.unrealizedLocked(symbol, pos)
+		usedMargin += pos.margin
+	}
+	available := fmt.Sprintf("%.8f", p.availableLocked())
+	return &futures.Account{
+		Assets: []*futures.AccountAsset{{
+			Asset:                 "USDT",
+			WalletBalance:         fmt.Sprintf("%.8f", p.balance),
+			UnrealizedProfit:      fmt.Sprintf("%.8f", unrealized),
+			MarginBalance:         fmt.Sprintf("%.8f", p.balance+unrealized),
+			PositionInitialMargin: fmt.Sprintf("%.8f", usedMargin),
+			InitialMargin:         fmt.Sprintf("%.8f", usedMargin),
+			AvailableBalance:      available,
+			MaxWithdrawAmount:     available,
+			UpdateTime:            time.Now().UnixMilli(),
+		}},
+		CanTrade:              true,
+		TotalWalletBalance:    fmt.Sprintf("%.8f", p.balance),
+		TotalUnrealizedProfit: fmt.Sprintf("%.8f", unrealized),
+		TotalMarginBalance:    fmt.Sprintf("%.8f", p.balance+unrealized),
+		TotalInitialMargin:    fmt.Sprintf("%.8f", usedMargin),
+		AvailableBalance:      available,
+		MaxWithdrawAmount:     available,
+		UpdateTime:            time.Now().UnixMilli(),
+	}
+}
+
+// TotalFees returns the fees paid on simulated fills so far
+// TotalFees 返回模拟成交累计支付的手续费
+func (p *PaperExecutor) TotalFees() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.totalFees
+}
+
+// availableLocked is the balance free for new margin: wallet plus unrealized PnL minus margin in use
+// availableLocked 可用于新保证金的余额：钱包余额加未实现盈亏减去已用保证金
+func (p *PaperExecutor) availableLocked() float64 {
+	available := p.balance
+	for symbol, pos := range p.positions {
+		available += p.unrealizedLocked(symbol, pos) - pos.margin
+	}
+	return math.Max(available, 0)
+}
+
+// unrealizedLocked values a position at the last observed price
+// unrealizedLocked 按最近观察价格计算持仓的未实现盈亏
+func (p *PaperExecutor) unrealizedLocked(symbol string, pos *paperPosition) float64 {
+	price, ok := p.lastPrices[symbol]
+	if !ok {
+		return 0
+	}
+	return positionPnL(pos.side, pos.entryPrice, price, pos.size)
+}
+
+// leverageLocked returns the leverage of a symbol, falling back to the default
+// leverageLocked 返回交易对的杠杆，未设置时使用默认值
+func (p *PaperExecutor) leverageLocked(symbol string) int {
+	if leverage, ok := p.leverages[symbol]; ok {
+		return leverage
+	}
+	return p.leverage
+}
+
+// workingOrdersLocked returns the working stop orders of a symbol in placement order
+// workingOrdersLocked 按下单顺序返回交易对挂着的止损单
+func (p *PaperExecutor) workingOrdersLocked(symbol string) []*paperOrder {
+	var orders []*paperOrder
+	for _, order := range p.orders {
+		if order.symbol == symbol && order.status == futures.OrderStatusTypeNew {
+			orders = append(orders, order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].orderID < orders[j].orderID })
+	return orders
+}
+
+func (p *PaperExecutor) newOrderIDLocked() int64 {
+	id := p.nextOrderID
+	p.nextOrderID++
+	return id
+}
+
+// positionPnL returns the PnL of qty moved from entry to exit on side
+// positionPnL 返回 side 方向 qty 数量从 entry 到 exit 的盈亏
+func positionPnL(side string, entry, exit, qty float64) float64 {
+	if side == "short" {
+		return (entry - exit) * qty
+	}
+	return (exit - entry) * qty
+}
+
+// paperLiquidationPrice is the price at which an isolated position's margin falls to the maintenance margin
+// paperLiquidationPrice 逐仓持仓保证金降至维持保证金时的价格
+func paperLiquidationPrice(side string, entry float64, leverage int) float64 {
+	if side == "short" {
+		return entry * (1 + 1/float64(leverage) - paperMaintenanceMarginRate)
+	}
+	return entry * (1 - 1/float64(leverage) + paperMaintenanceMarginRate)
+}
+
+// sideLabel returns the Chinese name of a position side
+// sideLabel 返回持仓方向的中文名称
+func sideLabel(side string) string {
+	if side == "short" {
+		return "空仓"
+	}
+	return "多仓"
+}
+
+// unknownOrderError mirrors the exchange error for an order that is not working
+// unknownOrderError 模拟交易所对不存在订单返回的错误
+func unknownOrderError() error {
+	return &common.APIError{Code: errCodeUnknownOrder, Message: "Unknown order sent."}
+}
+
+// executePaperTrade fills a trade on the paper executor at the current live price
+// executePaperTrade 按当前实盘价格在模拟执行器上成交
+func (e *BinanceExecutor) executePaperTrade(ctx context.Context, symbol string, action TradeAction, amount float64, result *TradeResult) *TradeResult {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	result.TestMode = true
+
+	e.logger.Header("【模拟盘】 交易执行", '=', 60)
+	e.logger.Info(fmt.Sprintf("动作: %s", action))
+	e.logger.Info(fmt.Sprintf("交易对: %s", symbol))
+	e.logger.Info(fmt.Sprintf("数量: %.4f", amount))
+	e.logger.Info(fmt.Sprintf("理由: %s", result.Reason))
+
+	if action == ActionHold {
+		e.logger.Info("💤 建议观望，不执行交易")
+		result.Success = true
+		result.Message = "观望，不执行交易"
+		return result
+	}
+
+	price, err := e.GetCurrentPrice(ctx, symbol)
+	if err == nil {
+		err = e.paper.Fill(binanceSymbol, action, amount, price, result)
+	}
+	if err != nil {
+		result.Message = fmt.Sprintf("订单执行失败: %v", err)
+		e.logger.Error(result.Message)
+		return result
+	}
+	if !result.Success {
+		e.logger.Warning(fmt.Sprintf("⚠️ %s", result.Message))
+		return result
+	}
+
+	e.logger.Success(fmt.Sprintf("📝 [模拟盘] ✅ 订单执行成功，订单ID: %s, 成交: %.4f @ %.2f, 累计手续费: %.4f USDT",
+		result.OrderID, result.Filled, result.Price, e.paper.TotalFees()))
+	result.NewPosition = e.paper.Position(binanceSymbol)
+	e.tradeHistory = append(e.tradeHistory, *result)
+	return result
+}
@@ -0,0 +1,157 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestPaperExecutorRoundTrip(t *testing.T) {
+	p := NewPaperExecutor(1000, 0.001, 10, logger.NewColorLogger(true))
+
+	result := &TradeResult{}
+	if err := p.Fill("BTCUSDT", ActionBuy, 0.1, 50000, result); err != nil {
+		t.Fatalf("Buy failed: %v", err)
+	}
+	if !result.Success || result.Filled != 0.1 || result.Price != 50000 {
+		t.Fatalf("Unexpected buy result: %+v", result)
+	}
+
+	// Notional 5000 at 10x locks 500 margin and pays a 5 USDT fee
+	// 名义价值 5000，10 倍杠杆占用 500 保证金，手续费 5 USDT
+	pos := p.Position("BTCUSDT")
+	if pos == nil || pos.Side != "long" || pos.Leverage != 10 {
+		t.Fatalf("Unexpected position: %+v", pos)
+	}
+	if balance, _ := parseFloat(p.Account().AvailableBalance); !almostEqual(balance, 495) {
+		t.Errorf("Expected available 495, got %.4f", balance)
+	}
+
+	// A second buy never adds to the long
+	// 再次买入不会加仓
+	again := &TradeResult{}
+	if err := p.Fill("BTCUSDT", ActionBuy, 0.1, 50000, again); err != nil || again.Success {
+		t.Errorf("Expected the second buy to be refused, got %+v (err=%v)", again, err)
+	}
+
+	closeResult := &TradeResult{}
+	if err := p.Fill("BTCUSDT", ActionCloseLong, 0, 51000, closeResult); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if p.Position("BTCUSDT") != nil {
+		t.Error("Expected the position to be closed")
+	}
+
+	// 1000 - 5 (open fee) + 100 (PnL) - 5.1 (close fee)
+	// 1000 - 5（开仓手续费）+ 100（盈亏）- 5.1（平仓手续费）
+	if wallet, _ := parseFloat(p.Account().TotalWalletBalance); !almostEqual(wallet, 1089.9) {
+		t.Errorf("Expected wallet 1089.9, got %.4f", wallet)
+	}
+	if !almostEqual(p.TotalFees(), 10.1) {
+		t.Errorf("Expected fees 10.1, got %.4f", p.TotalFees())
+	}
+}
+
+func TestPaperExecutorPartialCloseAndReverse(t *testing.T) {
+	p := NewPaperExecutor(10000, 0, 5, logger.NewColorLogger(true))
+
+	if err := p.Fill("ETHUSDT", ActionSell, 2, 3000, &TradeResult{}); err != nil {
+		t.Fatalf("Sell failed: %v", err)
+	}
+	if err := p.Fill("ETHUSDT", ActionCloseShort, 0.5, 2900, &TradeResult{}); err != nil {
+		t.Fatalf("Partial close failed: %v", err)
+	}
+	if pos := p.Position("ETHUSDT"); pos == nil || !almostEqual(pos.Size, 1.5) || pos.PositionAmt >= 0 {
+		t.Fatalf("Expected a 1.5 short left, got %+v", pos)
+	}
+
+	// Buying reverses the remaining short into a long
+	// 买入将剩余空仓反手为多仓
+	if err := p.Fill("ETHUSDT", ActionBuy, 1, 2800, &TradeResult{}); err != nil {
+		t.Fatalf("Reverse failed: %v", err)
+	}
+	if pos := p.Position("ETHUSDT"); pos == nil || pos.Side != "long" || !almostEqual(pos.Size, 1) {
+		t.Fatalf("Expected a 1.0 long, got %+v", pos)
+	}
+
+	// 0.5 * 100 + 1.5 * 200 realized on the short
+	// 空仓已实现盈亏 0.5 * 100 + 1.5 * 200
+	if wallet, _ := parseFloat(p.Account().TotalWalletBalance); !almostEqual(wallet, 10350) {
+		t.Errorf("Expected wallet 10350, got %.4f", wallet)
+	}
+}
+
+func TestPaperExecutorInsufficientBalance(t *testing.T) {
+	p := NewPaperExecutor(100, 0.0005, 2, logger.NewColorLogger(true))
+
+	result := &TradeResult{}
+	if err := p.Fill("BTCUSDT", ActionBuy, 1, 50000, result); err == nil {
+		t.Fatal("Expected an insufficient balance error")
+	}
+	if result.Success || p.Position("BTCUSDT") != nil {
+		t.Error("Expected no position after a rejected order")
+	}
+}
+
+func TestPaperExecutorStopOrder(t *testing.T) {
+	p := NewPaperExecutor(1000, 0, 10, logger.NewColorLogger(true))
+	if err := p.Fill("BTCUSDT", ActionBuy, 0.1, 50000, &TradeResult{}); err != nil {
+		t.Fatalf("Buy failed: %v", err)
+	}
+	orderID := p.PlaceStopOrder("BTCUSDT", "long", 49000, 0.1)
+
+	p.OnPrice("BTCUSDT", 49500)
+	if order, _ := p.Order(orderID); order.Status != futures.OrderStatusTypeNew {
+		t.Fatalf("Expected the stop to keep working above its trigger, got %s", order.Status)
+	}
+
+	p.OnPrice("BTCUSDT", 48900)
+	order, err := p.Order(orderID)
+	if err != nil || order.Status != futures.OrderStatusTypeFilled {
+		t.Fatalf("Expected the stop to fill, got %+v (err=%v)", order, err)
+	}
+	if price, _ := parseFloat(order.AvgPrice); !almostEqual(price, 48900) {
+		t.Errorf("Expected fill at 48900, got %.2f", price)
+	}
+	if p.Position("BTCUSDT") != nil {
+		t.Error("Expected the stop to close the position")
+	}
+
+	// A filled order can no longer be cancelled, just like on the exchange
+	// 与交易所一致，已成交订单无法撤销
+	if _, err := p.CancelOrder(orderID); err == nil || !isOrderNotFoundError(err) {
+		t.Errorf("Expected an unknown order error, got %v", err)
+	}
+}
+
+func TestPaperExecutorLiquidation(t *testing.T) {
+	p := NewPaperExecutor(1000, 0, 10, logger.NewColorLogger(true))
+	if err := p.Fill("BTCUSDT", ActionSell, 0.1, 50000, &TradeResult{}); err != nil {
+		t.Fatalf("Sell failed: %v", err)
+	}
+
+	pos := p.Position("BTCUSDT")
+	wantLiquidation := 50000 * (1 + 0.1 - paperMaintenanceMarginRate)
+	if !almostEqual(pos.LiquidationPrice, wantLiquidation) {
+		t.Fatalf("Expected liquidation price %.2f, got %.2f", wantLiquidation, pos.LiquidationPrice)
+	}
+
+	p.OnPrice("BTCUSDT", wantLiquidation-10)
+	if p.Position("BTCUSDT") == nil {
+		t.Fatal("Expected the short to survive below its liquidation price")
+	}
+
+	p.OnPrice("BTCUSDT", wantLiquidation+10)
+	if p.Position("BTCUSDT") != nil {
+		t.Fatal("Expected the short to be liquidated")
+	}
+	if wallet, _ := parseFloat(p.Account().TotalWalletBalance); !almostEqual(wallet, 500) {
+		t.Errorf("Expected the 500 margin to be lost, got wallet %.4f", wallet)
+	}
+}
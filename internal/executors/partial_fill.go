@@ -0,0 +1,248 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Policies for the unfilled remainder of a partially filled entry order
+// 开仓单部分成交后剩余数量的处理策略
+const (
+	RemainderCancel = "cancel" // 放弃剩余数量，保留已成交部分 / Drop the remainder and keep the filled part
+	RemainderChase  = "chase"  // 在盘口最优价反复挂限价单追单 / Re-post limit orders at the top of the book
+	RemainderMarket = "market" // 剩余数量市价成交 / Fill the remainder at market
+)
+
+// chaseDepthLimit is the order book depth fetched to find the touch price when chasing
+// chaseDepthLimit 追单时获取盘口最优价所用的深度档位
+const chaseDepthLimit = 5
+
+// marketOrderFillTimeout bounds how long a market order that was accepted but not yet filled is polled
+// marketOrderFillTimeout 市价单已受理但未成交时的最长轮询时间
+const marketOrderFillTimeout = 5 * time.Second
+
+// isOrderDone reports whether an order will not change any more, whether it filled or not
+// isOrderDone 判断订单是否已不会再变化（无论是否成交）
+func isOrderDone(status futures.OrderStatusType) bool {
+	return status == futures.OrderStatusTypeFilled || isOrderCancelled(status)
+}
+
+// isOrderCancelled reports whether an order ended without filling completely: cancelled, expired or rejected
+// isOrderCancelled 判断订单是否未完全成交就已结束：已撤销、已过期或被拒绝
+func isOrderCancelled(status futures.OrderStatusType) bool {
+	switch status {
+	case futures.OrderStatusTypeCanceled, futures.OrderStatusTypeExpired, futures.OrderStatusTypeRejected:
+		return true
+	}
+	return false
+}
+
+// vwap merges two fills into one volume-weighted average price
+// vwap 将两笔成交合并为成交量加权均价
+func vwap(qtyA, priceA, qtyB, priceB float64) float64 {
+	if qtyA+qtyB <= 0 {
+		return 0
+	}
+	if priceB <= 0 {
+		return priceA
+	}
+	if priceA <= 0 {
+		return priceB
+	}
+	return (qtyA*priceA + qtyB*priceB) / (qtyA + qtyB)
+}
+
+// submitMarketOrder sends one market order and returns what actually filled, without handling any remainder
+// submitMarketOrder 发送一笔市价单并返回实际成交，不处理剩余数量
+func (e *BinanceExecutor) submitMarketOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	// RESULT makes Binance return the fill, so the average price is the real one
+	// RESULT 让币安返回成交结果，成交均价为真实值
+	order, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(side).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeMarket).
+		Quantity(fmt.Sprintf("%.4f", quantity)).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	filledQty, _ := parseFloat(order.ExecutedQuantity)
+	avgPrice, _ := parseFloat(order.AvgPrice)
+	if !isOrderDone(order.Status) {
+		filledQty, avgPrice, _ = e.waitForOrderFill(ctx, binanceSymbol, order.OrderID, marketOrderFillTimeout)
+	}
+	return order.OrderID, filledQty, avgPrice, nil
+}
+
+// waitForOrderFill polls the order until it is filled, terminated, or timeout elapses
+// waitForOrderFill 轮询订单直到完全成交、终止或超时
+func (e *BinanceExecutor) waitForOrderFill(ctx context.Context, binanceSymbol string, orderID int64, timeout time.Duration) (float64, float64, futures.OrderStatusType) {
+	deadline := time.Now().Add(timeout)
+
+	var filledQty, avgPrice float64
+	var status futures.OrderStatusType
+	for {
+		order, err := e.client.NewGetOrderService().
+			Symbol(binanceSymbol).
+			OrderID(orderID).
+			Do(ctx)
+		if err == nil {
+			filledQty, _ = parseFloat(order.ExecutedQuantity)
+			avgPrice, _ = parseFloat(order.AvgPrice)
+			status = order.Status
+			if isOrderDone(status) {
+				return filledQty, avgPrice, status
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return filledQty, avgPrice, status
+		}
+
+		select {
+		case <-ctx.Done():
+			return filledQty, avgPrice, status
+		case <-time.After(limitOrderPollInterval):
+		}
+	}
+}
+
+// cancelAndReadFill cancels a resting order and returns its final filled quantity and average price
+// cancelAndReadFill 撤销挂单并返回其最终成交数量和均价
+// If the cancel fails the order may have filled meanwhile, so its status is read back instead
+// 撤单失败时订单可能已在此期间成交，因此改为回读订单状态
+func (e *BinanceExecutor) cancelAndReadFill(ctx context.Context, binanceSymbol string, orderID int64) (float64, float64, error) {
+	cancelResp, err := e.cancelOrder(ctx, binanceSymbol, orderID)
+	if err != nil {
+		filledQty, avgPrice, status := e.waitForOrderFill(ctx, binanceSymbol, orderID, 0)
+		if isOrderDone(status) {
+			return filledQty, avgPrice, nil
+		}
+		return filledQty, avgPrice, fmt.Errorf("failed to cancel order %d: %w", orderID, err)
+	}
+
+	executed, _ := parseFloat(cancelResp.ExecutedQuantity)
+	cumQuote, _ := parseFloat(cancelResp.CumQuote)
+	avgPrice := 0.0
+	if executed > 0 && cumQuote > 0 {
+		avgPrice = cumQuote / executed
+	}
+	return executed, avgPrice, nil
+}
+
+// floorQuantity rounds a quantity down to the symbol's precision; ok is false when it falls below the minimum quantity
+// floorQuantity 将数量按交易对精度向下取整；低于最小数量时 ok 为 false
+// Rounding down guarantees a remainder order never buys more than was asked for
+// 向下取整保证补单数量不会超过原本要求的数量
+func floorQuantity(symbol string, quantity float64) (float64, bool) {
+	precision, minQty := getSymbolPrecision(symbol)
+	multiplier := math.Pow(10, float64(precision))
+	floored := math.Floor(quantity*multiplier+filterEpsilon) / multiplier
+	return floored, floored >= minQty
+}
+
+// fillRemainder handles the unfilled part of an entry order according to policy and returns what it filled
+// fillRemainder 按策略处理开仓单未成交的剩余数量，返回补充成交的结果
+// Remainders below the symbol's minimum quantity are dust and are always dropped
+// 低于交易对最小数量的剩余视为零头，始终放弃
+func (e *BinanceExecutor) fillRemainder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, remaining float64, policy string) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	remaining, ok := floorQuantity(binanceSymbol, remaining)
+	if !ok {
+		e.logger.Info(fmt.Sprintf("ℹ️ 【%s】剩余数量低于最小下单量，不再补单", binanceSymbol))
+		return 0, 0, 0, nil
+	}
+
+	switch strings.ToLower(policy) {
+	case RemainderCancel:
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】剩余 %.4f 未成交，按策略放弃", binanceSymbol, remaining))
+		return 0, 0, 0, nil
+	case RemainderChase:
+		return e.chaseRemainder(ctx, symbol, side, positionSide, remaining)
+	default:
+		e.logger.Info(fmt.Sprintf("📤 【%s】剩余数量 %.4f 改用市价单成交", binanceSymbol, remaining))
+		return e.submitMarketOrder(ctx, symbol, side, positionSide, remaining)
+	}
+}
+
+// chaseRemainder re-posts a limit order at the best same-side price until the remainder fills or the attempts run out
+// chaseRemainder 在同侧最优价反复挂限价单，直到剩余数量成交或用完尝试次数
+// Each attempt rests for LIMIT_ORDER_TIMEOUT_SECONDS, then is cancelled and re-priced
+// 每次挂单等待 LIMIT_ORDER_TIMEOUT_SECONDS，之后撤单并重新定价
+func (e *BinanceExecutor) chaseRemainder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, remaining float64) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	timeout := time.Duration(e.config.LimitOrderTimeoutSeconds) * time.Second
+
+	var lastOrderID int64
+	var filledQty, filledCost float64
+	var lastErr error
+	for attempt := 1; attempt <= e.config.PartialFillChaseAttempts; attempt++ {
+		qty, ok := floorQuantity(binanceSymbol, remaining-filledQty)
+		if !ok {
+			break
+		}
+
+		depth, err := e.client.NewDepthService().Symbol(binanceSymbol).Limit(chaseDepthLimit).Do(ctx)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to get order book: %w", err)
+			continue
+		}
+		if len(depth.Bids) == 0 || len(depth.Asks) == 0 {
+			lastErr = fmt.Errorf("empty order book for %s", binanceSymbol)
+			continue
+		}
+		touch := depth.Bids[0].Price
+		if side == futures.SideTypeSell {
+			touch = depth.Asks[0].Price
+		}
+
+		e.logger.Info(fmt.Sprintf("🏃 【%s】追单 %d/%d: %.4f @ %s", binanceSymbol, attempt, e.config.PartialFillChaseAttempts, qty, touch))
+		order, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(side).
+			PositionSide(positionSide).
+			Type(futures.OrderTypeLimit).
+			TimeInForce(futures.TimeInForceTypeGTC).
+			Price(touch).
+			Quantity(fmt.Sprintf("%.4f", qty)))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lastOrderID = order.OrderID
+
+		qtyFilled, avgPrice, status := e.waitForOrderFill(ctx, binanceSymbol, order.OrderID, timeout)
+		if !isOrderDone(status) {
+			qtyFilled, avgPrice, err = e.cancelAndReadFill(ctx, binanceSymbol, order.OrderID)
+			if err != nil {
+				// The order may still be resting; stop rather than risk stacking orders
+				// 订单可能仍在挂单，停止追单以免叠加订单
+				lastErr = err
+				break
+			}
+		}
+		if qtyFilled > 0 {
+			filledCost += qtyFilled * avgPrice
+			filledQty += qtyFilled
+		}
+	}
+
+	if filledQty <= 0 {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("remainder not filled after %d chase attempts", e.config.PartialFillChaseAttempts)
+		}
+		return 0, 0, 0, lastErr
+	}
+	if filledQty < remaining {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】追单结束，剩余 %.4f 中成交 %.4f", binanceSymbol, remaining, filledQty))
+	}
+	return lastOrderID, filledQty, filledCost / filledQty, nil
+}
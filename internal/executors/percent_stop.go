@@ -0,0 +1,101 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Percentage trailing defaults: trail 1.5% behind the extreme, moving in 0.5% steps in step mode
+// 百分比追踪默认参数：距极值 1.5% 追踪，阶梯模式下每次移动 0.5%
+const (
+	defaultTrailingPercent     = 1.5
+	defaultTrailingStepPercent = 0.5
+)
+
+// CalculatePercentStop calculates a fixed-percentage trailing stop from the highest price since entry (lowest for shorts)
+// CalculatePercentStop 根据入场以来的最高价（空仓为最低价）计算固定百分比追踪止损价
+//
+// With step set, the stop snaps to a grid of step-sized moves anchored at the entry price, always on the side away from
+// price, so it only moves once the market has advanced a whole step.
+// 启用阶梯时，止损价对齐到以入场价为起点、间隔为阶梯大小的网格上（总是取远离价格的一侧），只有行情推进一整个阶梯后止损才会移动。
+func (calc *TrailingStopCalculator) CalculatePercentStop(symbol string, entryPrice, extremePrice float64, side string, step bool) (float64, error) {
+	if entryPrice <= 0 || extremePrice <= 0 {
+		return 0, fmt.Errorf("invalid entry price %.4f or extreme price %.4f", entryPrice, extremePrice)
+	}
+	config := calc.GetConfig(symbol)
+	trail := config.TrailingPercent
+	if trail <= 0 {
+		trail = defaultTrailingPercent
+	}
+
+	stop := extremePrice * (1 - trail/100)
+	if side == "short" {
+		stop = extremePrice * (1 + trail/100)
+	}
+
+	stepPercent := 0.0
+	if step {
+		stepPercent = config.TrailingStepPercent
+		if stepPercent <= 0 {
+			stepPercent = defaultTrailingStepPercent
+		}
+		// A small epsilon keeps a stop sitting exactly on a grid line from dropping a step through rounding
+		// 微小的容差避免恰好位于网格线上的止损因舍入误差少算一个阶梯
+		steps := (stop/entryPrice - 1) * 100 / stepPercent
+		if side == "short" {
+			steps = math.Ceil(steps - 1e-9)
+		} else {
+			steps = math.Floor(steps + 1e-9)
+		}
+		stop = entryPrice * (1 + steps*stepPercent/100)
+	}
+
+	if calc.logger != nil {
+		priceType := "最高价"
+		if side == "short" {
+			priceType = "最低价"
+		}
+		if step {
+			calc.logger.Info(fmt.Sprintf("【%s】计算阶梯追踪止损: %s=%.2f, 回撤=%.2f%%, 阶梯=%.2f%%, 止损价=%.2f",
+				symbol, priceType, extremePrice, trail, stepPercent, stop))
+		} else {
+			calc.logger.Info(fmt.Sprintf("【%s】计算百分比追踪止损: %s=%.2f, 回撤=%.2f%%, 止损价=%.2f",
+				symbol, priceType, extremePrice, trail, stop))
+		}
+	}
+	return stop, nil
+}
+
+// UpdatePercentStop trails a position's stop a fixed percentage behind its best price, in steps when step is set
+// UpdatePercentStop 以距最优价固定百分比追踪持仓止损，step 为 true 时按阶梯移动
+// Unlike the other formulas it needs neither ATR nor bars, only the extreme the manager already tracks
+// 与其他公式不同，它既不需要 ATR 也不需要 K 线，只使用管理器已跟踪的极值
+func (sm *StopLossManager) UpdatePercentStop(ctx context.Context, symbol string, step bool) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		sm.mu.RUnlock()
+		return nil
+	}
+	side, entryPrice, highestPrice := pos.Side, pos.EntryPrice, pos.HighestPrice
+	currentStopLoss, stopLossType := pos.CurrentStopLoss, pos.StopLossType
+	sm.mu.RUnlock()
+
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
+	newStopLoss, err := sm.calculator.CalculatePercentStop(symbol, entryPrice, highestPrice, side, step)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("【%s】⚠️ 无法计算百分比追踪止损，保持当前止损: %v", symbol, err))
+		return nil
+	}
+	reason := "百分比追踪止损自动调整"
+	if step {
+		reason = "阶梯追踪止损自动调整"
+	}
+	return sm.applyTrailingStop(ctx, symbol, side, currentStopLoss, newStopLoss, reason)
+}
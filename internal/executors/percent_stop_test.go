@@ -0,0 +1,48 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalculatePercentStop(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+
+	stop, err := calc.CalculatePercentStop("BTCUSDT", 100, 110, "long", false)
+	if err != nil || math.Abs(stop-108.35) > 1e-9 {
+		t.Errorf("Expected a long stop 1.5%% below 110 at 108.35, got %.4f (%v)", stop, err)
+	}
+
+	stop, err = calc.CalculatePercentStop("BTCUSDT", 100, 90, "short", false)
+	if err != nil || math.Abs(stop-91.35) > 1e-9 {
+		t.Errorf("Expected a short stop 1.5%% above 90 at 91.35, got %.4f (%v)", stop, err)
+	}
+
+	if _, err := calc.CalculatePercentStop("BTCUSDT", 0, 110, "long", false); err == nil {
+		t.Error("Expected an error without an entry price")
+	}
+}
+
+func TestCalculatePercentStopSteps(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+
+	// 110 × 0.985 = 108.35 is 8.35% above entry, so the stop sits on the 8.0% step
+	// 110 × 0.985 = 108.35 高于入场价 8.35%，止损落在 8.0% 阶梯上
+	stop, err := calc.CalculatePercentStop("BTCUSDT", 100, 110, "long", true)
+	if err != nil || math.Abs(stop-108) > 1e-9 {
+		t.Errorf("Expected a long step stop at 108, got %.4f (%v)", stop, err)
+	}
+
+	// A smaller advance that does not complete the next step leaves the stop where it was
+	// 推进不足一个阶梯时止损保持不变
+	if next, _ := calc.CalculatePercentStop("BTCUSDT", 100, 110.1, "long", true); math.Abs(next-stop) > 1e-9 {
+		t.Errorf("Expected the stop to stay at %.4f until a full step, got %.4f", stop, next)
+	}
+
+	// Shorts snap upward, away from price: 90 × 1.015 = 91.35 → 91.5
+	// 空仓向上对齐（远离价格）：90 × 1.015 = 91.35 → 91.5
+	stop, err = calc.CalculatePercentStop("BTCUSDT", 100, 90, "short", true)
+	if err != nil || math.Abs(stop-91.5) > 1e-9 {
+		t.Errorf("Expected a short step stop at 91.5, got %.4f (%v)", stop, err)
+	}
+}
@@ -0,0 +1,95 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// fundingSyncInterval is how long a synced funding total is reused before the income history is queried again
+// fundingSyncInterval 是已同步的资金费合计在重新查询收益历史前的复用时长
+// Funding settles every few hours, so the position report does not need to query on every cycle
+// 资金费每隔数小时结算一次，因此持仓报告无需每个周期都查询
+const fundingSyncInterval = 10 * time.Minute
+
+// NetUnrealizedPnL returns the unrealized PnL including the funding accumulated while the position is open
+// NetUnrealizedPnL 返回包含持仓期间累计资金费的未实现盈亏
+func (p *Position) NetUnrealizedPnL() float64 {
+	return p.UnrealizedPnL + p.FundingFees
+}
+
+// sumFundingPayments adds up the payments settled at or after the position entry
+// sumFundingPayments 累加开仓之后结算的资金费
+func sumFundingPayments(payments []FundingPayment, entry time.Time) float64 {
+	total := 0.0
+	for _, p := range payments {
+		if p.Time.Before(entry) {
+			continue
+		}
+		total += p.Amount
+	}
+	return total
+}
+
+// SyncFunding refreshes the funding accumulated by a managed position since entry and returns the total
+// SyncFunding 刷新托管持仓自开仓以来累计的资金费并返回合计
+//
+// The total is recomputed from the income history on every sync, so a missed or repeated event never double counts.
+// A sync within fundingSyncInterval of the previous one reuses the stored total unless force is set.
+// 每次同步都从收益历史重新计算合计，因此遗漏或重复的事件不会导致重复计算。
+// 距上次同步不足 fundingSyncInterval 时复用已保存的合计，除非设置了 force。
+func (sm *StopLossManager) SyncFunding(ctx context.Context, symbol string, force bool) (float64, error) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	var entry, lastSync time.Time
+	var total float64
+	if exists {
+		entry, lastSync, total = pos.EntryTime, pos.LastFundingSync, pos.FundingFees
+	}
+	sm.mu.RUnlock()
+
+	if !exists {
+		return 0, nil
+	}
+	// The paper executor has no funding history
+	// 模拟盘执行器没有资金费历史
+	if sm.executor == nil || sm.executor.paper != nil || entry.IsZero() {
+		return total, nil
+	}
+	if !force && !lastSync.IsZero() && time.Since(lastSync) < fundingSyncInterval {
+		return total, nil
+	}
+
+	now := time.Now()
+	payments, err := sm.executor.GetFundingPayments(ctx, normalizedSymbol, entry, now)
+	if err != nil {
+		return total, fmt.Errorf("failed to sync funding for %s: %w", normalizedSymbol, err)
+	}
+	funding := sumFundingPayments(payments, entry)
+
+	sm.mu.Lock()
+	// Skip the update if the position was closed or replaced during the API call
+	// 若持仓在 API 调用期间被关闭或替换则不更新
+	if current, ok := sm.positions[normalizedSymbol]; ok && current == pos {
+		if funding != pos.FundingFees {
+			sm.logger.Info(fmt.Sprintf("【%s】💸 持仓累计资金费: %+.4f → %+.4f USDT", normalizedSymbol, pos.FundingFees, funding))
+		}
+		pos.FundingFees = funding
+		pos.LastFundingSync = now
+	}
+	sm.mu.Unlock()
+
+	return funding, nil
+}
+
+// SyncAllFunding force-syncs the funding of every managed position, e.g. after a funding settlement event
+// SyncAllFunding 强制同步所有托管持仓的资金费，例如在资金费结算推送之后
+func (sm *StopLossManager) SyncAllFunding(ctx context.Context) {
+	for _, pos := range sm.GetAllPositions() {
+		if _, err := sm.SyncFunding(ctx, pos.Symbol, true); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️  同步 %s 资金费失败: %v", pos.Symbol, err))
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package executors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSumFundingPayments(t *testing.T) {
+	entry := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	payments := []FundingPayment{
+		{Time: entry.Add(-8 * time.Hour), Amount: -5},
+		{Time: entry, Amount: -1.5},
+		{Time: entry.Add(8 * time.Hour), Amount: 0.75},
+	}
+
+	// Settlements before entry belong to an earlier position
+	// 开仓之前的结算属于之前的持仓
+	if got := sumFundingPayments(payments, entry); !almostEqual(got, -0.75) {
+		t.Errorf("Expected -0.75, got %.4f", got)
+	}
+}
+
+func TestNetUnrealizedPnL(t *testing.T) {
+	pos := &Position{UnrealizedPnL: 12.5, FundingFees: -2.25}
+	if got := pos.NetUnrealizedPnL(); !almostEqual(got, 10.25) {
+		t.Errorf("Expected 10.25, got %.4f", got)
+	}
+}
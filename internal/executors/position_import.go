@@ -0,0 +1,142 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// importedOpenReason prefixes the open reason of every position handed over by the user
+// importedOpenReason 是用户手动移交的持仓开仓理由的前缀
+const importedOpenReason = "手动导入："
+
+// IsImported reports whether the position was opened by hand and handed over through ImportPosition
+// IsImported 判断持仓是否为手动开仓后通过 ImportPosition 移交管理
+func (p *Position) IsImported() bool {
+	return strings.HasPrefix(p.OpenReason, importedOpenReason)
+}
+
+// ImportRequest describes how a manually opened position is taken over
+// ImportRequest 描述如何接管手动开立的持仓
+type ImportRequest struct {
+	StopLoss   float64 // 初始止损价，0 时由追踪止损计算器按 ATR 计算 / Initial stop, computed from the ATR by the trailing stop calculator when 0
+	EntryPrice float64 // 入场价，0 时使用交易所的持仓均价 / Entry price, the exchange's average entry when 0
+	Note       string  // 附加到开仓理由的说明 / Note appended to the open reason
+}
+
+// ImportPosition takes a position the user opened by hand on the exchange under management: the bot places its own stop,
+// replacing the manual stop orders, and builds the take-profit ladder from the entry and the initial stop
+// ImportPosition 接管用户在交易所手动开立的持仓：机器人下达自己的止损单并替换手动止损单，
+// 并根据入场价和初始止损建立分批止盈
+func (sm *StopLossManager) ImportPosition(ctx context.Context, symbol string, req ImportRequest) (*Position, error) {
+	binanceSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	if !sm.executor.ManagesSymbol(binanceSymbol) {
+		return nil, fmt.Errorf("%s 由其他实例管理", binanceSymbol)
+	}
+	if sm.GetPosition(binanceSymbol) != nil {
+		return nil, fmt.Errorf("%s 已在管理中", binanceSymbol)
+	}
+	if req.StopLoss < 0 || req.EntryPrice < 0 {
+		return nil, fmt.Errorf("止损价和入场价不能为负数")
+	}
+
+	actual, err := sm.executor.GetCurrentPosition(ctx, binanceSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+	if actual == nil {
+		return nil, fmt.Errorf("%s 在交易所没有持仓", binanceSymbol)
+	}
+	if req.EntryPrice > 0 {
+		actual.EntryPrice = req.EntryPrice
+	}
+
+	markPrice, err := sm.executor.GetCurrentPrice(ctx, binanceSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取当前价格失败: %w", err)
+	}
+
+	// The ATR sizes the trailing stop later on, so it is fetched even with an explicit stop
+	// ATR 也用于后续追踪止损，因此即使指定了止损也会获取
+	atr := 0.0
+	if bars, err := dataflows.NewMarketData(sm.config).GetOHLCV(ctx, binanceSymbol, sm.config.CryptoTimeframe, sm.config.CryptoLookbackDays); err == nil {
+		atr = latestATR(bars)
+	} else if req.StopLoss == 0 {
+		return nil, fmt.Errorf("获取 K 线失败: %w", err)
+	}
+
+	stopPrice := req.StopLoss
+	if stopPrice == 0 {
+		if stopPrice, err = adoptionStop(sm.calculator, binanceSymbol, actual.Side, markPrice, atr); err != nil {
+			return nil, fmt.Errorf("无法计算止损: %w", err)
+		}
+	}
+	if err := validateImportStop(actual.Side, stopPrice, markPrice); err != nil {
+		return nil, err
+	}
+
+	orders, err := sm.executor.listOpenOrders(ctx, binanceSymbol)
+	if err != nil {
+		return nil, err
+	}
+	manualStops, manualTakeProfits := protectiveOrders(orders, actual.Side)
+
+	reason := importedOpenReason + "用户移交的手动持仓"
+	if req.Note != "" {
+		reason += "（" + req.Note + "）"
+	}
+	pos := newAdoptedPosition(binanceSymbol, actual, stopPrice, reason)
+	pos.ATR = atr
+	sm.RegisterPosition(pos)
+
+	// Trailing continues from the current price, the entry only anchors the take-profit ladder
+	// 追踪从当前价继续，入场价仅作为分批止盈的基准
+	sm.mu.Lock()
+	pos.CurrentPrice = markPrice
+	pos.HighestPrice = markPrice
+	sm.mu.Unlock()
+
+	if err := sm.placeStopLossOrder(ctx, pos, stopPrice); err != nil {
+		// The manual stops stay in place, so the position is handed back rather than left half managed
+		// 手动止损单仍然保留，因此退回持仓而不是让其处于半管理状态
+		sm.RemovePosition(binanceSymbol)
+		return nil, fmt.Errorf("止损单 %.2f 下单失败: %w", stopPrice, err)
+	}
+	sm.saveAdoptedPosition(pos)
+
+	// The bot's stop replaces the manual ones; manual take-profit orders are left to the user
+	// 机器人的止损单替换手动止损单；手动止盈单由用户自行处理
+	for _, o := range manualStops {
+		if strconv.FormatInt(o.OrderID, 10) == pos.StopLossOrderID {
+			continue
+		}
+		if _, err := sm.executor.cancelOrder(ctx, binanceSymbol, o.OrderID); err != nil && !isOrderNotFoundError(err) {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】撤销手动止损单 %d 失败: %v", binanceSymbol, o.OrderID, err))
+		}
+	}
+	if len(manualTakeProfits) > 0 {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】保留了 %d 个手动止盈单，可能与分批止盈重复", binanceSymbol, len(manualTakeProfits)))
+	}
+
+	sm.logger.Success(fmt.Sprintf("📥【%s】已导入手动持仓 %s %.4f @ %.2f，止损 %.2f（当前价 %.2f，ATR %.2f）",
+		binanceSymbol, pos.Side, pos.Quantity, pos.EntryPrice, stopPrice, markPrice, atr))
+	sm.notifier.Notify(notify.SeverityInfo, binanceSymbol, fmt.Sprintf("📥 已导入手动持仓 %s %.4f @ %.2f，止损 %.2f",
+		pos.Side, pos.Quantity, pos.EntryPrice, stopPrice))
+	return pos, nil
+}
+
+// validateImportStop rejects a stop on the wrong side of the current price, which would close the position at once
+// validateImportStop 拒绝位于当前价错误一侧的止损，否则会立即平仓
+func validateImportStop(side string, stopPrice, markPrice float64) error {
+	if side == "short" && stopPrice <= markPrice {
+		return fmt.Errorf("空仓止损价 %.2f 必须高于当前价 %.2f", stopPrice, markPrice)
+	}
+	if side != "short" && stopPrice >= markPrice {
+		return fmt.Errorf("多仓止损价 %.2f 必须低于当前价 %.2f", stopPrice, markPrice)
+	}
+	return nil
+}
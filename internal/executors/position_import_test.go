@@ -0,0 +1,34 @@
+package executors
+
+import "testing"
+
+func TestValidateImportStop(t *testing.T) {
+	tests := []struct {
+		side      string
+		stop      float64
+		wantError bool
+	}{
+		{"long", 95, false},
+		{"long", 100, true},
+		{"long", 105, true},
+		{"short", 105, false},
+		{"short", 100, true},
+		{"short", 95, true},
+	}
+	for _, tt := range tests {
+		if err := validateImportStop(tt.side, tt.stop, 100); (err != nil) != tt.wantError {
+			t.Errorf("%s stop %.0f at 100: got error %v, want error %v", tt.side, tt.stop, err, tt.wantError)
+		}
+	}
+}
+
+func TestIsImported(t *testing.T) {
+	imported := newAdoptedPosition("BTCUSDT", &Position{Side: "long", Size: 1, EntryPrice: 100}, 95, importedOpenReason+"用户移交的手动持仓")
+	if !imported.IsImported() || imported.IsAdopted() {
+		t.Error("Expected an imported position not to count as adopted at startup")
+	}
+	adopted := newAdoptedPosition("BTCUSDT", &Position{Side: "long", Size: 1, EntryPrice: 100}, 95, adoptedOpenReason+"从交易所止损单接管")
+	if adopted.IsImported() {
+		t.Error("Expected an adopted position not to count as imported")
+	}
+}
@@ -0,0 +1,110 @@
+package executors
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// LifecycleState is the stage of a managed position's life
+// LifecycleState 表示受管理持仓所处的生命周期阶段
+type LifecycleState string
+
+const (
+	StatePendingEntry LifecycleState = "PENDING_ENTRY" // 入场单未成交 / Entry not filled yet
+	StateOpen         LifecycleState = "OPEN"          // 持仓中且受止损保护 / Open and protected by a stop
+	StateReducing     LifecycleState = "REDUCING"      // 部分平仓进行中 / Partial close in flight
+	StateClosing      LifecycleState = "CLOSING"       // 全部平仓进行中 / Full close in flight
+	StateClosed       LifecycleState = "CLOSED"        // 已平仓 / Closed
+)
+
+// lifecycleTransitions lists the states each state may move to
+// lifecycleTransitions 列出每个状态允许转换到的状态
+// CLOSING may fall back to OPEN when the exchange still holds the position after a failed close
+// 平仓失败且交易所仍有持仓时，CLOSING 可以回到 OPEN
+var lifecycleTransitions = map[LifecycleState][]LifecycleState{
+	StatePendingEntry: {StateOpen, StateClosed},
+	StateOpen:         {StateReducing, StateClosing, StateClosed},
+	StateReducing:     {StateOpen, StateClosing, StateClosed},
+	StateClosing:      {StateClosed, StateOpen},
+	StateClosed:       nil,
+}
+
+// Lifecycle returns the position's lifecycle state; a position never registered is still pending entry
+// Lifecycle 返回持仓的生命周期状态；从未注册的持仓视为等待入场
+func (p *Position) Lifecycle() LifecycleState {
+	if p.State == "" {
+		return StatePendingEntry
+	}
+	return p.State
+}
+
+// Transition moves the position to another lifecycle state, refusing moves the state machine does not allow
+// Transition 将持仓转换到另一生命周期状态，拒绝状态机不允许的转换
+// Moving to the current state is a no-op, so repeated calls from overlapping paths are harmless
+// 转换到当前状态不做任何操作，重叠流程的重复调用不会出错
+func (p *Position) Transition(to LifecycleState) error {
+	from := p.Lifecycle()
+	if from == to {
+		return nil
+	}
+	for _, allowed := range lifecycleTransitions[from] {
+		if allowed == to {
+			p.State = to
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal position transition %s -> %s", from, to)
+}
+
+// CheckInvariants returns the invariants the position currently breaks, empty when it is consistent
+// CheckInvariants 返回持仓当前违反的不变量，一致时返回空
+func (p *Position) CheckInvariants() []string {
+	var violations []string
+	if p.Quantity < 0 {
+		violations = append(violations, fmt.Sprintf("持仓数量为负 (%.6f)", p.Quantity))
+	}
+	if p.Size < 0 {
+		violations = append(violations, fmt.Sprintf("持仓大小为负 (%.6f)", p.Size))
+	}
+	if state := p.Lifecycle(); (state == StateOpen || state == StateReducing) && p.CurrentStopLoss <= 0 {
+		violations = append(violations, fmt.Sprintf("%s 状态下没有止损价", state))
+	}
+	return violations
+}
+
+// transitionPosition moves a position to another state and logs an illegal move or a broken invariant
+// transitionPosition 转换持仓状态，并记录非法转换或被破坏的不变量
+// Violations are logged rather than fatal: the position is live on the exchange and must keep being managed
+// 违规只记录不中断：持仓在交易所仍然存在，必须继续管理
+func transitionPosition(log *logger.ColorLogger, pos *Position, to LifecycleState, reason string) bool {
+	from := pos.Lifecycle()
+	if err := pos.Transition(to); err != nil {
+		log.Error(fmt.Sprintf("🚨【%s】持仓状态转换被拒绝 %s -> %s（%s）", pos.Symbol, from, to, reason))
+		return false
+	}
+	assertPositionInvariants(log, pos, reason)
+	return true
+}
+
+// assertPositionInvariants logs every invariant the position breaks
+// assertPositionInvariants 记录持仓违反的所有不变量
+func assertPositionInvariants(log *logger.ColorLogger, pos *Position, reason string) {
+	if violations := pos.CheckInvariants(); len(violations) > 0 {
+		log.Error(fmt.Sprintf("🚨【%s】持仓不变量被破坏（%s，状态 %s）: %s",
+			pos.Symbol, reason, pos.Lifecycle(), strings.Join(violations, "；")))
+	}
+}
+
+// finishReduce takes the filled quantity off a reducing position and returns it to OPEN
+// finishReduce 从减仓中的持仓扣除已成交数量并将其恢复为 OPEN
+// A failed reduction passes filled=0 so the position still leaves REDUCING
+// 减仓失败时传入 filled=0，持仓同样会离开 REDUCING 状态
+func finishReduce(log *logger.ColorLogger, pos *Position, filled float64, reason string) {
+	if filled > 0 {
+		pos.Quantity -= filled
+		pos.Size = pos.Quantity
+	}
+	transitionPosition(log, pos, StateOpen, reason)
+}
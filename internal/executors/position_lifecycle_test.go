@@ -0,0 +1,109 @@
+package executors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestPositionTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    LifecycleState
+		to      LifecycleState
+		wantErr bool
+	}{
+		{"unregistered opens", "", StateOpen, false},
+		{"open reduces", StateOpen, StateReducing, false},
+		{"reducing returns to open", StateReducing, StateOpen, false},
+		{"reducing closes", StateReducing, StateClosing, false},
+		{"closing falls back to open", StateClosing, StateOpen, false},
+		{"same state is a no-op", StateOpen, StateOpen, false},
+		{"pending cannot reduce", StatePendingEntry, StateReducing, true},
+		{"closed cannot reopen", StateClosed, StateOpen, true},
+		{"closed cannot close again", StateClosed, StateClosing, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := &Position{State: tt.from}
+			err := pos.Transition(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transition(%s -> %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+			want := tt.to
+			if tt.wantErr {
+				want = pos.Lifecycle()
+				if pos.State != tt.from {
+					t.Errorf("Expected a refused transition to keep %s, got %s", tt.from, pos.State)
+				}
+			}
+			if pos.Lifecycle() != want {
+				t.Errorf("Expected state %s, got %s", want, pos.Lifecycle())
+			}
+		})
+	}
+}
+
+func TestPositionInvariants(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  Position
+		want int
+	}{
+		{"open with stop", Position{State: StateOpen, Quantity: 1, Size: 1, CurrentStopLoss: 95}, 0},
+		{"pending without stop", Position{Quantity: 1, Size: 1}, 0},
+		{"closed without stop", Position{State: StateClosed}, 0},
+		{"open without stop", Position{State: StateOpen, Quantity: 1, Size: 1}, 1},
+		{"reducing without stop", Position{State: StateReducing, Quantity: 1, Size: 1}, 1},
+		{"negative quantity", Position{State: StateOpen, Quantity: -0.1, Size: -0.1, CurrentStopLoss: 95}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pos.CheckInvariants(); len(got) != tt.want {
+				t.Errorf("Expected %d violations, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPositionLifecycleThroughManager(t *testing.T) {
+	cfg := &config.Config{PaperTrading: true, PaperInitialBalance: 10000}
+	log := logger.NewColorLogger(false)
+	sm := NewStopLossManager(cfg, NewBinanceExecutor(cfg, log), log, nil)
+	ctx := context.Background()
+
+	pos := &Position{ID: "p-1", Symbol: "BTC/USDT", Side: "long", EntryPrice: 100, Quantity: 1, Size: 1, InitialStopLoss: 95, CurrentStopLoss: 95}
+	sm.RegisterPosition(pos)
+	if pos.Lifecycle() != StateOpen {
+		t.Fatalf("Expected a registered position to be OPEN, got %s", pos.Lifecycle())
+	}
+
+	transitionPosition(log, pos, StateReducing, "test")
+	finishReduce(log, pos, 0.4, "test")
+	if pos.Lifecycle() != StateOpen || pos.Quantity != 0.6 || pos.Size != 0.6 {
+		t.Errorf("Expected OPEN with 0.6 left after reducing, got %s with %.4f/%.4f", pos.Lifecycle(), pos.Quantity, pos.Size)
+	}
+
+	if err := sm.ClosePosition(ctx, "BTC/USDT", 102, "test", 1.2); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+	if pos.Lifecycle() != StateClosed {
+		t.Errorf("Expected a closed position to be CLOSED, got %s", pos.Lifecycle())
+	}
+
+	// A leg already closing is not closed a second time
+	// 正在关闭的持仓腿不会被重复关闭
+	closing := &Position{ID: "p-2", Symbol: "ETH/USDT", Side: "long", EntryPrice: 100, Quantity: 1, InitialStopLoss: 95, CurrentStopLoss: 95}
+	sm.RegisterPosition(closing)
+	closing.State = StateClosing
+	if err := sm.ClosePosition(ctx, "ETH/USDT", 102, "test", 2); err != nil {
+		t.Fatalf("ClosePosition failed: %v", err)
+	}
+	if !sm.HasPosition("ETH/USDT") || closing.Lifecycle() != StateClosing {
+		t.Errorf("Expected the closing leg to be left to the close in flight, got %s", closing.Lifecycle())
+	}
+}
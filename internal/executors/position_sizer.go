@@ -0,0 +1,179 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+// Position sizing modes
+// 仓位计算模式
+const (
+	SizingModeLLM              = "llm"               // 直接使用 LLM 建议的仓位 / Use the LLM's proposed size as is
+	SizingModeFixedFractional  = "fixed_fractional"  // 止损触发时亏损固定比例的权益 / Lose a fixed fraction of equity at the stop
+	SizingModeVolatilityTarget = "volatility_target" // 每根 K 线的 ATR 波动对应固定比例的权益 / One bar's ATR move equals a fixed fraction of equity
+	SizingModeKelly            = "kelly"             // 按历史胜率与盈亏比的凯利公式决定风险比例（有上限）/ Risk fraction from the Kelly formula on past trades, capped
+)
+
+// KellyStats are the closed-trade statistics the Kelly mode sizes from
+// KellyStats 是凯利模式所用的已平仓交易统计
+type KellyStats struct {
+	Trades      int     // 样本交易数 / Number of sample trades
+	WinRate     float64 // 胜率 0-1 / Win rate 0-1
+	PayoffRatio float64 // 平均盈利 / 平均亏损 / Average win over average loss
+}
+
+// Fraction returns the full Kelly fraction W − (1 − W) / R; it is 0 or negative when the edge is not positive
+// Fraction 返回完整凯利比例 W − (1 − W) / R；没有正期望时为 0 或负数
+func (k KellyStats) Fraction() float64 {
+	if k.PayoffRatio <= 0 {
+		return 0
+	}
+	return k.WinRate - (1-k.WinRate)/k.PayoffRatio
+}
+
+// SizingRequest is what the sizer needs to know about one entry
+// SizingRequest 是仓位计算所需的单次开仓信息
+type SizingRequest struct {
+	Symbol   string  // 交易对 / Trading pair
+	Side     string  // long/short
+	Equity   float64 // 账户权益（USDT）/ Account equity in USDT
+	Price    float64 // 当前价格 / Current price
+	StopLoss float64 // 计划止损价，0 表示未知 / Planned stop price, 0 if unknown
+	ATR      float64 // 主时间周期 ATR，0 表示未知 / ATR of the primary timeframe, 0 if unknown
+}
+
+// SizingResult is the deterministic quantity cap of one entry
+// SizingResult 是单次开仓的确定性数量上限
+type SizingResult struct {
+	Mode        string  // 实际使用的模式 / Mode actually used
+	RiskPercent float64 // 止损触发时的亏损占权益比例（%）/ Loss at the stop as % of equity
+	Quantity    float64 // 数量上限 / Quantity cap
+	Detail      string  // 计算说明 / How the cap was derived
+}
+
+// PositionSizer turns "risk N% of equity" into a quantity cap that clamps the LLM's proposed size
+// PositionSizer 将"承担权益的 N% 风险"换算为数量上限，用于限制 LLM 建议的仓位
+// A nil sizer (POSITION_SIZING_MODE=llm) imposes no cap
+// sizer 为 nil（POSITION_SIZING_MODE=llm）时不设上限
+type PositionSizer struct {
+	mode             string
+	riskPercent      float64
+	volTargetPercent float64
+	kellyFraction    float64
+	kellyMaxPercent  float64
+	kellyMinTrades   int
+	stats            KellyStats
+}
+
+// NewPositionSizer creates the sizer configured by POSITION_SIZING_MODE, or returns nil in llm mode
+// NewPositionSizer 按 POSITION_SIZING_MODE 创建仓位计算器；llm 模式返回 nil
+func NewPositionSizer(cfg *config.Config) *PositionSizer {
+	switch cfg.PositionSizingMode {
+	case SizingModeFixedFractional, SizingModeVolatilityTarget, SizingModeKelly:
+	default:
+		return nil
+	}
+	return &PositionSizer{
+		mode:             cfg.PositionSizingMode,
+		riskPercent:      cfg.PositionRiskPercent,
+		volTargetPercent: cfg.PositionVolTargetPercent,
+		kellyFraction:    cfg.PositionKellyFraction,
+		kellyMaxPercent:  cfg.PositionKellyMaxPercent,
+		kellyMinTrades:   cfg.PositionKellyMinTrades,
+	}
+}
+
+// Mode returns the configured sizing mode
+// Mode 返回配置的仓位计算模式
+func (s *PositionSizer) Mode() string {
+	if s == nil {
+		return SizingModeLLM
+	}
+	return s.mode
+}
+
+// SetKellyStats updates the trade statistics used by the Kelly mode
+// SetKellyStats 更新凯利模式使用的交易统计
+func (s *PositionSizer) SetKellyStats(stats KellyStats) {
+	if s == nil {
+		return
+	}
+	s.stats = stats
+}
+
+// Size computes the quantity cap of an entry
+// Size 计算开仓的数量上限
+// ok is false when there is no cap (llm mode); an error means the entry must not be taken, e.g. Kelly finds no edge
+// ok 为 false 表示不设上限（llm 模式）；返回错误表示不应开仓，例如凯利公式显示没有正期望
+func (s *PositionSizer) Size(req SizingRequest) (SizingResult, bool, error) {
+	if s == nil {
+		return SizingResult{}, false, nil
+	}
+	if req.Equity <= 0 || req.Price <= 0 {
+		return SizingResult{}, false, fmt.Errorf("invalid sizing inputs: equity %.2f, price %.4f", req.Equity, req.Price)
+	}
+
+	stopDistance, stopDetail := s.stopDistance(req)
+
+	switch s.mode {
+	case SizingModeVolatilityTarget:
+		if req.ATR > 0 {
+			qty := req.Equity * s.volTargetPercent / 100 / req.ATR
+			return SizingResult{
+				Mode:        s.mode,
+				RiskPercent: qty * stopDistance / req.Equity * 100,
+				Quantity:    qty,
+				Detail:      fmt.Sprintf("波动目标 %.2f%% 权益 / ATR %.4f", s.volTargetPercent, req.ATR),
+			}, true, nil
+		}
+		// Without an ATR the volatility cannot be targeted, so the fixed risk applies
+		// 没有 ATR 时无法按波动率计算，改用固定风险比例
+		return s.fixedFractional(req, stopDistance, stopDetail+"，缺少 ATR 改用固定比例"), true, nil
+
+	case SizingModeKelly:
+		if s.stats.Trades < s.kellyMinTrades {
+			return s.fixedFractional(req, stopDistance, fmt.Sprintf("%s，样本 %d 笔不足 %d 笔改用固定比例", stopDetail, s.stats.Trades, s.kellyMinTrades)), true, nil
+		}
+		full := s.stats.Fraction()
+		if full <= 0 {
+			return SizingResult{Mode: s.mode}, true, fmt.Errorf("kelly fraction %.3f is not positive (win rate %.1f%%, payoff %.2f over %d trades)",
+				full, s.stats.WinRate*100, s.stats.PayoffRatio, s.stats.Trades)
+		}
+		riskPercent := math.Min(full*s.kellyFraction*100, s.kellyMaxPercent)
+		return SizingResult{
+			Mode:        s.mode,
+			RiskPercent: riskPercent,
+			Quantity:    req.Equity * riskPercent / 100 / stopDistance,
+			Detail: fmt.Sprintf("凯利 %.3f × %.2f（上限 %.2f%%），胜率 %.1f%%，盈亏比 %.2f，%s",
+				full, s.kellyFraction, s.kellyMaxPercent, s.stats.WinRate*100, s.stats.PayoffRatio, stopDetail),
+		}, true, nil
+	}
+
+	return s.fixedFractional(req, stopDistance, stopDetail), true, nil
+}
+
+// fixedFractional sizes the entry so that hitting the stop loses riskPercent of equity
+// fixedFractional 按止损触发时亏损 riskPercent 权益计算数量
+func (s *PositionSizer) fixedFractional(req SizingRequest, stopDistance float64, detail string) SizingResult {
+	return SizingResult{
+		Mode:        SizingModeFixedFractional,
+		RiskPercent: s.riskPercent,
+		Quantity:    req.Equity * s.riskPercent / 100 / stopDistance,
+		Detail:      fmt.Sprintf("风险 %.2f%% 权益，%s", s.riskPercent, detail),
+	}
+}
+
+// stopDistance returns the price distance to the planned stop, assuming 2.5% when the stop is missing or on the wrong side
+// stopDistance 返回到计划止损的价格距离；止损缺失或方向错误时假设 2.5%
+func (s *PositionSizer) stopDistance(req SizingRequest) (float64, string) {
+	distance := req.Price - req.StopLoss
+	if req.Side == "short" {
+		distance = -distance
+	}
+	if req.StopLoss > 0 && distance > 0 {
+		return distance, fmt.Sprintf("止损 %.4f（距离 %.2f%%）", req.StopLoss, distance/req.Price*100)
+	}
+	return req.Price * DefaultStopLossPercent / 100, fmt.Sprintf("无有效止损，假设距离 %.1f%%", DefaultStopLossPercent)
+}
@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+)
+
+func TestPositionSizer(t *testing.T) {
+	cfg := &config.Config{
+		PositionRiskPercent:      1,
+		PositionVolTargetPercent: 0.5,
+		PositionKellyFraction:    0.5,
+		PositionKellyMaxPercent:  2,
+		PositionKellyMinTrades:   20,
+	}
+
+	cfg.PositionSizingMode = SizingModeLLM
+	if sizer := NewPositionSizer(cfg); sizer != nil {
+		t.Fatal("Expected no sizer in llm mode")
+	} else if _, capped, err := sizer.Size(SizingRequest{Equity: 1000, Price: 100}); capped || err != nil {
+		t.Errorf("Expected a nil sizer to impose no cap, got capped=%v err=%v", capped, err)
+	}
+
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	req := SizingRequest{Symbol: "BTCUSDT", Side: "long", Equity: 10000, Price: 100, StopLoss: 95, ATR: 2}
+
+	// 1% of 10000 at a 5 point stop
+	// 10000 的 1% 对应 5 点止损距离
+	cfg.PositionSizingMode = SizingModeFixedFractional
+	res, capped, err := NewPositionSizer(cfg).Size(req)
+	if err != nil || !capped || !near(res.Quantity, 20) {
+		t.Errorf("Fixed fractional: expected 20, got %+v capped=%v err=%v", res, capped, err)
+	}
+
+	// A stop on the wrong side falls back to a 2.5% distance
+	// 止损方向错误时按 2.5% 距离计算
+	wrongSide := req
+	wrongSide.Side = "short"
+	if res, _, _ := NewPositionSizer(cfg).Size(wrongSide); !near(res.Quantity, 40) {
+		t.Errorf("Expected 40 with the assumed stop, got %.4f", res.Quantity)
+	}
+
+	// 0.5% of 10000 per 2 point ATR
+	// 每 2 点 ATR 对应 10000 的 0.5%
+	cfg.PositionSizingMode = SizingModeVolatilityTarget
+	if res, _, _ := NewPositionSizer(cfg).Size(req); !near(res.Quantity, 25) || !near(res.RiskPercent, 1.25) {
+		t.Errorf("Volatility target: expected 25 at 1.25%% risk, got %+v", res)
+	}
+	noATR := req
+	noATR.ATR = 0
+	if res, _, _ := NewPositionSizer(cfg).Size(noATR); res.Mode != SizingModeFixedFractional || !near(res.Quantity, 20) {
+		t.Errorf("Expected a fixed-fractional fallback without ATR, got %+v", res)
+	}
+
+	cfg.PositionSizingMode = SizingModeKelly
+	sizer := NewPositionSizer(cfg)
+	if res, _, _ := sizer.Size(req); res.Mode != SizingModeFixedFractional {
+		t.Errorf("Expected a fixed-fractional fallback with too few trades, got %+v", res)
+	}
+
+	// Full Kelly 0.55 − 0.45/2 = 0.325, halved to 16.25%, capped at 2%
+	// 完整凯利 0.55 − 0.45/2 = 0.325，减半为 16.25%，上限 2%
+	sizer.SetKellyStats(KellyStats{Trades: 30, WinRate: 0.55, PayoffRatio: 2})
+	if res, _, err := sizer.Size(req); err != nil || !near(res.RiskPercent, 2) || !near(res.Quantity, 40) {
+		t.Errorf("Kelly: expected 40 at 2%% risk, got %+v err=%v", res, err)
+	}
+	sizer.SetKellyStats(KellyStats{Trades: 30, WinRate: 0.3, PayoffRatio: 1.5})
+	if _, _, err := sizer.Size(req); err == nil {
+		t.Error("Expected Kelly to refuse an entry without a positive edge")
+	}
+}
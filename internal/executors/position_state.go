@@ -0,0 +1,165 @@
+package executors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// PositionFromRecord converts a stored position into a position for the stop-loss manager
+// PositionFromRecord 将已保存的持仓转换为止损管理器使用的持仓
+func PositionFromRecord(r *storage.PositionRecord, symbol string) *Position {
+	return &Position{
+		ID:               r.ID,
+		Symbol:           symbol,
+		Side:             r.Side,
+		EntryPrice:       r.EntryPrice,
+		EntryTime:        r.EntryTime,
+		Quantity:         r.Quantity,
+		Size:             r.Quantity,
+		Leverage:         r.Leverage,
+		InitialStopLoss:  r.InitialStopLoss,
+		CurrentStopLoss:  r.CurrentStopLoss,
+		StopLossType:     r.StopLossType,
+		TrailingDistance: r.TrailingDistance,
+		HighestPrice:     r.HighestPrice,
+		CurrentPrice:     r.CurrentPrice,
+		UnrealizedPnL:    r.UnrealizedPnL,
+		OpenReason:       r.OpenReason,
+		ATR:              r.ATR,
+		StopLossOrderID:  r.StopLossOrderID,
+	}
+}
+
+// RestorePosition registers a position loaded from storage and puts back the state registration would reset
+// RestorePosition 注册从存储加载的持仓，并恢复注册时会被重置的状态
+//
+// Registration starts a position fresh: highest/lowest price at entry, a fixed stop and untouched take-profit levels.
+// A restored position keeps its extreme price, stop type, stop history and which take-profit levels already fired.
+// 注册会将持仓视为新开仓：最高/最低价为入场价、固定止损、止盈级别均未执行。
+// 恢复的持仓保留其极值价格、止损类型、止损历史以及已触发的止盈级别。
+func (sm *StopLossManager) RestorePosition(pos *Position) {
+	highest, current, stopType := pos.HighestPrice, pos.CurrentPrice, pos.StopLossType
+
+	var history []StopLossEvent
+	var levels []*storage.TakeProfitLevelRecord
+	if sm.storage != nil {
+		events, err := sm.storage.GetStopLossEvents(pos.ID)
+		if err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】加载止损历史失败: %v", pos.Symbol, err))
+		}
+		for _, e := range events {
+			history = append(history, StopLossEvent{Time: e.Timestamp, OldStop: e.OldStop, NewStop: e.NewStop, Reason: e.Reason, Trigger: e.Trigger})
+		}
+		if levels, err = sm.storage.GetTakeProfitLevels(pos.ID); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】加载止盈级别失败: %v", pos.Symbol, err))
+		}
+	}
+
+	sm.RegisterPosition(pos)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if highest > 0 {
+		pos.HighestPrice = highest
+	}
+	if current > 0 {
+		pos.CurrentPrice = current
+	}
+	if stopType != "" {
+		pos.StopLossType = stopType
+	}
+	pos.StopLossHistory = history
+
+	if len(levels) == 0 {
+		// Levels are stored once they change, so none stored means the fresh levels are still accurate
+		// 级别在变化时才保存，未保存说明新初始化的级别仍然准确
+		return
+	}
+	pos.TakeProfitConfig = takeProfitConfigFromRecords(levels, pos.TakeProfitConfig == nil || pos.TakeProfitConfig.Enabled)
+	executed := 0
+	for _, l := range pos.TakeProfitConfig.Levels {
+		if l.Executed {
+			executed++
+		}
+	}
+	pos.PartialTPExecuted = executed > 0
+	sm.logger.Info(fmt.Sprintf("【%s】已恢复持仓状态: 极值价 %.2f，止损历史 %d 条，止盈级别已执行 %d/%d",
+		pos.Symbol, pos.HighestPrice, len(history), executed, len(pos.TakeProfitConfig.Levels)))
+}
+
+// takeProfitConfigFromRecords rebuilds take-profit levels from their stored state
+// takeProfitConfigFromRecords 根据保存的状态重建分批止盈级别
+func takeProfitConfigFromRecords(records []*storage.TakeProfitLevelRecord, enabled bool) *TakeProfitConfig {
+	cfg := &TakeProfitConfig{Enabled: enabled}
+	for _, r := range records {
+		cfg.Levels = append(cfg.Levels, &TakeProfitLevel{
+			Level:           r.Level,
+			RiskRewardRatio: r.RiskRewardRatio,
+			Percentage:      r.Percentage,
+			TargetPrice:     r.TargetPrice,
+			Executed:        r.Executed,
+			ExecutedTime:    r.ExecutedTime,
+			ExecutedPrice:   r.ExecutedPrice,
+			NewStopLoss:     r.NewStopLoss,
+			OrderID:         r.OrderID,
+		})
+	}
+	return cfg
+}
+
+// takeProfitRecords converts take-profit levels into their stored form
+// takeProfitRecords 将分批止盈级别转换为存储格式
+func takeProfitRecords(positionID string, cfg *TakeProfitConfig) []*storage.TakeProfitLevelRecord {
+	if cfg == nil {
+		return nil
+	}
+	records := make([]*storage.TakeProfitLevelRecord, 0, len(cfg.Levels))
+	for _, l := range cfg.Levels {
+		records = append(records, &storage.TakeProfitLevelRecord{
+			PositionID:      positionID,
+			Level:           l.Level,
+			RiskRewardRatio: l.RiskRewardRatio,
+			Percentage:      l.Percentage,
+			TargetPrice:     l.TargetPrice,
+			Executed:        l.Executed,
+			ExecutedTime:    l.ExecutedTime,
+			ExecutedPrice:   l.ExecutedPrice,
+			NewStopLoss:     l.NewStopLoss,
+			OrderID:         l.OrderID,
+		})
+	}
+	return records
+}
+
+// saveTakeProfitLevels stores the take-profit levels of a position; failures are logged, the levels stay in memory
+// saveTakeProfitLevels 保存持仓的分批止盈级别；失败时仅记录日志，级别仍保留在内存中
+func (sm *StopLossManager) saveTakeProfitLevels(pos *Position) {
+	if sm.storage == nil || pos.ID == "" || pos.TakeProfitConfig == nil {
+		return
+	}
+	if err := sm.storage.SaveTakeProfitLevels(pos.ID, takeProfitRecords(pos.ID, pos.TakeProfitConfig)); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】保存止盈级别失败: %v", pos.Symbol, err))
+	}
+}
+
+// recordStopLossEvent appends a stop change to the position history and stores it
+// recordStopLossEvent 将止损变更追加到持仓历史并保存
+func (sm *StopLossManager) recordStopLossEvent(pos *Position, oldStop, newStop float64, reason, trigger string) {
+	pos.AddStopLossEvent(oldStop, newStop, reason, trigger)
+	if sm.storage == nil || pos.ID == "" {
+		return
+	}
+	event := &storage.StopLossEvent{
+		PositionID: pos.ID,
+		Timestamp:  time.Now(),
+		OldStop:    oldStop,
+		NewStop:    newStop,
+		Reason:     reason,
+		Trigger:    trigger,
+	}
+	if err := sm.storage.SaveStopLossEvent(event); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️ 【%s】保存止损历史失败: %v", pos.Symbol, err))
+	}
+}
@@ -0,0 +1,112 @@
+package executors
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestRestorePosition(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "state.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{PaperTrading: true, PaperInitialBalance: 10000}
+	log := logger.NewColorLogger(false)
+	executor := NewBinanceExecutor(cfg, log)
+
+	record := &storage.PositionRecord{
+		ID:              "BTCUSDT-1",
+		Symbol:          "BTC/USDT",
+		Side:            "long",
+		EntryPrice:      100,
+		EntryTime:       time.Now().Add(-time.Hour),
+		Quantity:        1,
+		InitialStopLoss: 95,
+		CurrentStopLoss: 95,
+		StopLossType:    "fixed",
+	}
+	if err := db.SavePosition(record); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// Fire the first level and move the stop before the "restart"
+	// 在“重启”前触发第一个止盈级别并移动止损
+	before := NewStopLossManager(cfg, executor, log, db)
+	pos := PositionFromRecord(record, "BTCUSDT")
+	before.RegisterPosition(pos)
+	executedAt := time.Now()
+	pos.TakeProfitConfig.Levels[0].Executed = true
+	pos.TakeProfitConfig.Levels[0].ExecutedTime = &executedAt
+	pos.TakeProfitConfig.Levels[0].ExecutedPrice = 105.2
+	pos.TakeProfitConfig.Levels[1].TargetPrice = 111
+	before.saveTakeProfitLevels(pos)
+	before.recordStopLossEvent(pos, 95, 100, "分批止盈后移动止损", "llm")
+
+	record.CurrentStopLoss = 100
+	record.HighestPrice = 108
+	record.CurrentPrice = 107
+	record.StopLossType = StopLossTypeNativeTrailing
+
+	after := NewStopLossManager(cfg, executor, log, db)
+	after.RestorePosition(PositionFromRecord(record, "BTCUSDT"))
+	restored := after.GetPosition("BTC/USDT")
+	if restored == nil {
+		t.Fatal("Expected the position to be registered")
+	}
+	if restored.HighestPrice != 108 || restored.CurrentPrice != 107 || restored.StopLossType != StopLossTypeNativeTrailing {
+		t.Errorf("Expected the extreme price, price and stop type to survive, got %.2f %.2f %s",
+			restored.HighestPrice, restored.CurrentPrice, restored.StopLossType)
+	}
+	if len(restored.StopLossHistory) != 1 || restored.StopLossHistory[0].NewStop != 100 {
+		t.Errorf("Expected the stop history to be reloaded, got %+v", restored.StopLossHistory)
+	}
+
+	levels := restored.TakeProfitConfig.Levels
+	if len(levels) != 3 || !levels[0].Executed || levels[0].ExecutedPrice != 105.2 || levels[0].ExecutedTime == nil {
+		t.Fatalf("Expected level 1 to stay executed, got %+v", levels[0])
+	}
+	if levels[1].Executed || levels[1].TargetPrice != 111 || !restored.PartialTPExecuted {
+		t.Errorf("Expected level 2 pending at the moved target, got %+v", levels[1])
+	}
+
+	// A position without stored levels keeps the freshly initialized ones
+	// 没有保存级别的持仓保留新初始化的级别
+	fresh := &storage.PositionRecord{ID: "ETHUSDT-1", Side: "short", EntryPrice: 100, InitialStopLoss: 110, CurrentStopLoss: 110}
+	after.RestorePosition(PositionFromRecord(fresh, "ETHUSDT"))
+	if p := after.GetPosition("ETHUSDT"); p == nil || p.TakeProfitConfig.Levels[0].TargetPrice != 90 || p.HighestPrice != 100 {
+		t.Errorf("Expected fresh levels for a position without stored state, got %+v", p)
+	}
+}
+
+func TestStopLossHistoryRecordsPlacedMoves(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	pos := &Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 90, Quantity: 1, InitialStopLoss: 85, CurrentStopLoss: 85}
+	sm.RegisterPosition(pos)
+
+	// A stop above the market price fails validation and leaves no history
+	// 高于市场价的止损验证失败，不留下历史
+	if err := sm.UpdateStopLoss(context.Background(), "BTCUSDT", 101, "LLM 建议"); err == nil {
+		t.Fatal("Expected the stop above the market to be rejected")
+	}
+	if len(pos.StopLossHistory) != 0 || pos.CurrentStopLoss != 85 {
+		t.Fatalf("Expected no recorded move, got %+v", pos.StopLossHistory)
+	}
+
+	// A trailing move is recorded as program-triggered once its order is placed
+	// 追踪止损下单成功后以程序触发记录
+	if err := sm.applyTrailingStop(context.Background(), "BTCUSDT", "long", 85, 95, "追踪止损"); err != nil {
+		t.Fatalf("applyTrailingStop failed: %v", err)
+	}
+	if len(pos.StopLossHistory) != 1 || pos.StopLossHistory[0].NewStop != 95 || pos.StopLossHistory[0].Trigger != "program" {
+		t.Errorf("Expected one program-triggered move to 95, got %+v", pos.StopLossHistory)
+	}
+}
@@ -0,0 +1,231 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// Price stream tuning
+// 价格流参数
+const (
+	priceStaleAfter           = 10 * time.Second // 超过该时间未更新的缓存价格视为过期 / Cached prices older than this are stale
+	priceStreamReconnectDelay = 5 * time.Second  // 断线重连间隔 / Delay before reconnecting
+	priceSubscriberBuffer     = 16               // 订阅者默认缓冲 / Default subscriber buffer
+)
+
+// PriceTick is the latest traded price of a symbol
+// PriceTick 是交易对的最新成交价
+type PriceTick struct {
+	Symbol string    // 币安格式交易对 / Binance symbol
+	Price  float64   // 最新成交价 / Last traded price
+	Time   time.Time // 成交时间 / Trade time
+}
+
+// PriceService keeps the latest price of each symbol in memory and fans updates out to subscribers
+// PriceService 在内存中维护各交易对的最新价格，并将更新分发给订阅者
+//
+// Prices arrive from the aggregate trade websocket; when the stream is down or a symbol has no fresh tick,
+// callers fall back to REST and the result is cached too, so every consumer within a tick sees the same price.
+// 价格来自聚合成交 websocket；数据流断开或交易对没有新鲜报价时，调用方回退到 REST，结果同样写入缓存，
+// 因此同一时刻的所有使用方看到的价格一致。
+type PriceService struct {
+	executor *BinanceExecutor                                                                                                                 // 执行器（REST 回退和模拟盘）/ Executor (REST fallback and paper fills)
+	symbols  []string                                                                                                                         // 订阅的交易对（币安格式）/ Streamed symbols (Binance format)
+	logger   *logger.ColorLogger                                                                                                              // 日志 / Logger
+	serve    func(symbols []string, handler futures.WsAggTradeHandler, errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error) // 建立连接（测试可替换）/ Connects the stream (replaceable in tests)
+	now      func() time.Time                                                                                                                 // 判断过期的时钟（测试可替换）/ Clock for staleness (replaceable in tests)
+
+	mu     sync.RWMutex
+	prices map[string]PriceTick   // 最新价格 / Latest prices
+	subs   map[int]chan PriceTick // 订阅者 / Subscribers
+	nextID int                    // 下一个订阅 ID / Next subscription ID
+}
+
+// NewPriceService creates a price service for the given symbols and attaches it to the executor
+// NewPriceService 为指定交易对创建价格服务，并挂载到执行器
+func NewPriceService(executor *BinanceExecutor, symbols []string, log *logger.ColorLogger) *PriceService {
+	binanceSymbols := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		binanceSymbols = append(binanceSymbols, executor.config.GetBinanceSymbolFor(s))
+	}
+	ps := &PriceService{
+		executor: executor,
+		symbols:  binanceSymbols,
+		logger:   log,
+		serve:    futures.WsCombinedAggTradeServe,
+		now:      time.Now,
+		prices:   make(map[string]PriceTick),
+		subs:     make(map[int]chan PriceTick),
+	}
+	executor.prices = ps
+	return ps
+}
+
+// Run keeps the price stream connected until ctx is cancelled
+// Run 保持价格流连接，直到 ctx 被取消
+func (ps *PriceService) Run(ctx context.Context) {
+	for {
+		err := ps.connect(ctx)
+		if ctx.Err() != nil {
+			ps.logger.Info("价格流已停止")
+			return
+		}
+		ps.logger.Warning(fmt.Sprintf("⚠️  价格流中断: %v，%v 后重连（期间使用 REST 获取价格）", err, priceStreamReconnectDelay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(priceStreamReconnectDelay):
+		}
+	}
+}
+
+// connect opens one websocket session and blocks until it ends
+// connect 建立一次 websocket 会话并阻塞直到会话结束
+func (ps *PriceService) connect(ctx context.Context) error {
+	handler := func(event *futures.WsAggTradeEvent) {
+		price, err := parseFloat(event.Price)
+		if err != nil {
+			return
+		}
+		ps.Publish(event.Symbol, price, time.UnixMilli(event.TradeTime))
+	}
+	errHandler := func(err error) {
+		ps.logger.Warning(fmt.Sprintf("⚠️  价格流错误: %v", err))
+	}
+
+	doneC, stopC, err := ps.serve(ps.symbols, handler, errHandler)
+	if err != nil {
+		return fmt.Errorf("failed to connect price stream: %w", err)
+	}
+	ps.logger.Success(fmt.Sprintf("✅ 已连接币安价格流: %v", ps.symbols))
+
+	select {
+	case <-ctx.Done():
+		close(stopC)
+		<-doneC
+		return ctx.Err()
+	case <-doneC:
+		return errors.New("连接已断开")
+	}
+}
+
+// Publish stores a price and delivers it to every subscriber
+// Publish 保存价格并推送给所有订阅者
+// Older ticks never overwrite newer ones, and a slow subscriber loses its oldest queued tick rather than blocking the stream
+// 旧报价不会覆盖新报价；处理缓慢的订阅者会丢弃其队列中最旧的报价，而不会阻塞数据流
+func (ps *PriceService) Publish(symbol string, price float64, at time.Time) {
+	if ps == nil || price <= 0 {
+		return
+	}
+	tick := PriceTick{Symbol: symbol, Price: price, Time: at}
+
+	ps.mu.Lock()
+	if last, ok := ps.prices[symbol]; ok && last.Time.After(at) {
+		ps.mu.Unlock()
+		return
+	}
+	ps.prices[symbol] = tick
+	for _, ch := range ps.subs {
+		select {
+		case ch <- tick:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- tick:
+			default:
+			}
+		}
+	}
+	ps.mu.Unlock()
+
+	ps.executor.paper.OnPrice(symbol, price)
+}
+
+// Latest returns the cached price of a symbol if it is fresh
+// Latest 返回交易对的缓存价格（仅在未过期时）
+func (ps *PriceService) Latest(symbol string) (PriceTick, bool) {
+	if ps == nil {
+		return PriceTick{}, false
+	}
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	tick, ok := ps.prices[symbol]
+	if !ok || ps.now().Sub(tick.Time) > priceStaleAfter {
+		return PriceTick{}, false
+	}
+	return tick, true
+}
+
+// Subscribe registers a subscriber and returns its channel and a function that unsubscribes
+// Subscribe 注册订阅者，返回接收通道和取消订阅函数
+func (ps *PriceService) Subscribe(buffer int) (<-chan PriceTick, func()) {
+	if buffer <= 0 {
+		buffer = priceSubscriberBuffer
+	}
+	ch := make(chan PriceTick, buffer)
+
+	ps.mu.Lock()
+	id := ps.nextID
+	ps.nextID++
+	ps.subs[id] = ch
+	ps.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			ps.mu.Lock()
+			delete(ps.subs, id)
+			ps.mu.Unlock()
+		})
+	}
+}
+
+// cachedPrice returns a fresh cached price when a price service is attached
+// cachedPrice 在挂载了价格服务时返回未过期的缓存价格
+func (e *BinanceExecutor) cachedPrice(binanceSymbol string) (float64, bool) {
+	tick, ok := e.prices.Latest(binanceSymbol)
+	return tick.Price, ok
+}
+
+// LatestPrice returns the fresh cached price of a symbol from the shared price service
+// LatestPrice 从共享价格服务返回交易对未过期的缓存价格
+func (sm *StopLossManager) LatestPrice(symbol string) (float64, bool) {
+	return sm.executor.cachedPrice(sm.config.GetBinanceSymbolFor(symbol))
+}
+
+// FollowPrices keeps CurrentPrice and UnrealizedPnL of managed positions in step with the price service
+// FollowPrices 使托管持仓的 CurrentPrice 和 UnrealizedPnL 与价格服务保持同步
+// Only memory is updated; the highest/lowest price keeps coming from klines so wicks are not missed
+// 只更新内存；最高/最低价仍来自 K 线，以免遗漏影线
+func (sm *StopLossManager) FollowPrices(ctx context.Context, ps *PriceService) {
+	ticks, unsubscribe := ps.Subscribe(0)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tick := <-ticks:
+			sm.mu.Lock()
+			if pos, ok := sm.positions[tick.Symbol]; ok {
+				pos.CurrentPrice = tick.Price
+				if pos.Side == "long" {
+					pos.UnrealizedPnL = (tick.Price - pos.EntryPrice) * pos.Quantity
+				} else {
+					pos.UnrealizedPnL = (pos.EntryPrice - tick.Price) * pos.Quantity
+				}
+			}
+			sm.mu.Unlock()
+		}
+	}
+}
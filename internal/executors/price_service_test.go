@@ -0,0 +1,93 @@
+package executors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func newTestPriceService() (*BinanceExecutor, *PriceService) {
+	e := &BinanceExecutor{config: &config.Config{}}
+	return e, NewPriceService(e, []string{"BTC/USDT"}, logger.NewColorLogger(false))
+}
+
+func TestPriceServiceCache(t *testing.T) {
+	e, ps := newTestPriceService()
+	if ps.symbols[0] != "BTCUSDT" {
+		t.Fatalf("Expected Binance symbols, got %v", ps.symbols)
+	}
+
+	now := time.Now()
+	ps.Publish("BTCUSDT", 50000, now)
+	if price, ok := e.cachedPrice("BTCUSDT"); !ok || price != 50000 {
+		t.Fatalf("Expected cached 50000, got %.2f (%v)", price, ok)
+	}
+
+	// An older tick arriving late does not overwrite a newer one
+	// 延迟到达的旧报价不会覆盖新报价
+	ps.Publish("BTCUSDT", 49000, now.Add(-time.Second))
+	if price, _ := e.cachedPrice("BTCUSDT"); price != 50000 {
+		t.Errorf("Expected the newer price to stay, got %.2f", price)
+	}
+
+	// Stale prices fall back to REST
+	// 过期价格回退到 REST
+	ps.Publish("ETHUSDT", 3000, now.Add(-2*priceStaleAfter))
+	if _, ok := e.cachedPrice("ETHUSDT"); ok {
+		t.Error("Expected a stale price to be ignored")
+	}
+}
+
+func TestPriceServiceSubscribe(t *testing.T) {
+	_, ps := newTestPriceService()
+	ticks, unsubscribe := ps.Subscribe(1)
+
+	// A slow subscriber keeps only the newest tick
+	// 处理缓慢的订阅者只保留最新报价
+	now := time.Now()
+	ps.Publish("BTCUSDT", 50000, now)
+	ps.Publish("BTCUSDT", 50100, now.Add(time.Millisecond))
+	if tick := <-ticks; tick.Price != 50100 {
+		t.Errorf("Expected the newest tick, got %+v", tick)
+	}
+
+	unsubscribe()
+	ps.Publish("BTCUSDT", 50200, now.Add(2*time.Millisecond))
+	select {
+	case tick := <-ticks:
+		t.Errorf("Expected no tick after unsubscribing, got %+v", tick)
+	default:
+	}
+}
+
+func TestPriceServiceStream(t *testing.T) {
+	_, ps := newTestPriceService()
+	ps.serve = func(symbols []string, handler futures.WsAggTradeHandler, errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+		doneC, stopC := make(chan struct{}), make(chan struct{})
+		handler(&futures.WsAggTradeEvent{Symbol: "BTCUSDT", Price: "50500.5", TradeTime: time.Now().UnixMilli()})
+		go func() {
+			<-stopC
+			close(doneC)
+		}()
+		return doneC, stopC, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks, unsubscribe := ps.Subscribe(0)
+	defer unsubscribe()
+
+	go ps.connect(ctx)
+	select {
+	case tick := <-ticks:
+		if tick.Symbol != "BTCUSDT" || tick.Price != 50500.5 {
+			t.Errorf("Unexpected tick %+v", tick)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a tick from the stream")
+	}
+	cancel()
+}
@@ -0,0 +1,55 @@
+package executors
+
+import "fmt"
+
+// ProfitLockStop returns the stop that locks the configured share of the best open profit
+// ProfitLockStop 返回锁定最大浮盈中配置比例所需的止损价
+//
+// tiers maps a trigger profit (% of entry) to the share (%) of the best profit to keep; the highest tier reached by the
+// best price since entry applies. For example "4:50" keeps at least half of the move once the position has been 4% up.
+// tiers 以触发盈利（相对入场价的 %）为键、以保留的最大浮盈比例（%）为值；使用入场以来最优价达到的最高一档。
+// 例如 "4:50" 表示持仓曾盈利 4% 后至少保留一半的浮盈。
+func ProfitLockStop(tiers map[float64]float64, side string, entryPrice, extremePrice float64) (float64, string, bool) {
+	if len(tiers) == 0 || entryPrice <= 0 || extremePrice <= 0 {
+		return 0, "", false
+	}
+
+	move := extremePrice - entryPrice
+	if side == "short" {
+		move = entryPrice - extremePrice
+	}
+	if move <= 0 {
+		return 0, "", false
+	}
+	maxProfitPercent := move / entryPrice * 100
+
+	trigger, lock := 0.0, 0.0
+	for t, l := range tiers {
+		if maxProfitPercent >= t && t > trigger {
+			trigger, lock = t, l
+		}
+	}
+	if trigger == 0 {
+		return 0, "", false
+	}
+
+	locked := move * lock / 100
+	stop := entryPrice + locked
+	if side == "short" {
+		stop = entryPrice - locked
+	}
+	reason := fmt.Sprintf("最大浮盈 %.2f%% ≥ %.2f%%，锁定 %.0f%%", maxProfitPercent, trigger, lock)
+	return stop, reason, true
+}
+
+// profitLockFloor returns the profit-lock floor for a position under the configured tiers
+// profitLockFloor 按配置的阶梯返回持仓的利润锁定底线
+func (sm *StopLossManager) profitLockFloor(pos *Position) (float64, string, bool) {
+	if sm.config == nil || len(sm.config.ProfitLockTiers) == 0 {
+		return 0, "", false
+	}
+	sm.mu.RLock()
+	side, entryPrice, extremePrice := pos.Side, pos.EntryPrice, pos.HighestPrice
+	sm.mu.RUnlock()
+	return ProfitLockStop(sm.config.ProfitLockTiers, side, entryPrice, extremePrice)
+}
@@ -0,0 +1,41 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProfitLockStop(t *testing.T) {
+	tiers := map[float64]float64{4: 50, 8: 70}
+
+	// Below the first tier nothing is locked
+	// 未达到第一档时不锁定
+	if _, _, ok := ProfitLockStop(tiers, "long", 100, 103); ok {
+		t.Error("Expected no lock below the first tier")
+	}
+
+	// Up 6%: the 4% tier keeps half the move
+	// 盈利 6%：4% 档位保留一半浮盈
+	stop, _, ok := ProfitLockStop(tiers, "long", 100, 106)
+	if !ok || math.Abs(stop-103) > 1e-9 {
+		t.Errorf("Expected a long lock at 103, got %.4f (%v)", stop, ok)
+	}
+
+	// Up 10%: the highest reached tier applies
+	// 盈利 10%：使用达到的最高档位
+	stop, _, ok = ProfitLockStop(tiers, "long", 100, 110)
+	if !ok || math.Abs(stop-107) > 1e-9 {
+		t.Errorf("Expected a long lock at 107, got %.4f (%v)", stop, ok)
+	}
+
+	// Shorts lock below entry from the lowest price
+	// 空仓根据最低价在入场价下方锁定
+	stop, _, ok = ProfitLockStop(tiers, "short", 100, 95)
+	if !ok || math.Abs(stop-97.5) > 1e-9 {
+		t.Errorf("Expected a short lock at 97.5, got %.4f (%v)", stop, ok)
+	}
+
+	if _, _, ok := ProfitLockStop(nil, "long", 100, 110); ok {
+		t.Error("Expected no lock without tiers")
+	}
+}
@@ -0,0 +1,71 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// Parabolic SAR defaults (Wilder's 0.02 step capped at 0.2)
+// 抛物线 SAR 默认参数（Wilder 的 0.02 步长，上限 0.2）
+const (
+	defaultPSARStep = 0.02
+	defaultPSARMax  = 0.2
+)
+
+// CalculatePSARStop calculates the parabolic SAR stop of a position from recent bars
+// CalculatePSARStop 根据近期 K 线计算持仓的抛物线 SAR 止损价
+//
+// The SAR accelerates toward price as the trend extends, so it suits strongly trending pairs. When the SAR has flipped
+// to the other side of the position there is no valid stop and an error is returned.
+// SAR 会随趋势延续加速靠近价格，适合强趋势交易对。SAR 已翻转到持仓另一侧时没有有效止损价，返回错误。
+func (calc *TrailingStopCalculator) CalculatePSARStop(symbol string, bars []dataflows.OHLCV, side string) (float64, error) {
+	config := calc.GetConfig(symbol)
+	step, max := config.PSARStep, config.PSARMax
+	if step <= 0 {
+		step = defaultPSARStep
+	}
+	if max <= 0 {
+		max = defaultPSARMax
+	}
+
+	sar, long, ok := dataflows.ParabolicSAR(bars, step, max)
+	if !ok {
+		return 0, fmt.Errorf("need at least 3 bars for parabolic SAR, got %d", len(bars))
+	}
+	if long != (side == "long") {
+		return 0, fmt.Errorf("parabolic SAR %.2f has reversed against the %s position", sar, side)
+	}
+
+	if calc.logger != nil {
+		calc.logger.Info(fmt.Sprintf("【%s】计算抛物线 SAR 止损: 步长=%.2f, 上限=%.2f, 止损价=%.2f", symbol, step, max, sar))
+	}
+	return sar, nil
+}
+
+// UpdatePSARStop trails a position's stop with the parabolic SAR of the given bars
+// UpdatePSARStop 使用给定 K 线的抛物线 SAR 追踪持仓止损
+func (sm *StopLossManager) UpdatePSARStop(ctx context.Context, symbol string, bars []dataflows.OHLCV) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		sm.mu.RUnlock()
+		return nil
+	}
+	side, currentStopLoss, stopLossType := pos.Side, pos.CurrentStopLoss, pos.StopLossType
+	sm.mu.RUnlock()
+
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
+	newStopLoss, err := sm.calculator.CalculatePSARStop(symbol, bars, side)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("【%s】⚠️ 无法计算抛物线 SAR 止损，保持当前止损: %v", symbol, err))
+		return nil
+	}
+	return sm.applyTrailingStop(ctx, symbol, side, currentStopLoss, newStopLoss, "抛物线 SAR 止损自动调整")
+}
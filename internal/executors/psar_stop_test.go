@@ -0,0 +1,27 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+func TestCalculatePSARStop(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	bars := make([]dataflows.OHLCV, 40)
+	for i := range bars {
+		c := 100 + float64(i)
+		bars[i] = dataflows.OHLCV{Open: c, High: c + 1, Low: c - 1, Close: c}
+	}
+
+	stop, err := calc.CalculatePSARStop("BTCUSDT", bars, "long")
+	if err != nil || stop <= 100 || stop >= bars[len(bars)-1].Low {
+		t.Errorf("Expected a long stop below the last low, got %.4f (%v)", stop, err)
+	}
+
+	// A SAR below price is no stop for a short
+	// SAR 位于价格下方时不能作为空仓止损
+	if _, err := calc.CalculatePSARStop("BTCUSDT", bars, "short"); err == nil {
+		t.Error("Expected an error when the SAR is against the position")
+	}
+}
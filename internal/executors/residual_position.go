@@ -0,0 +1,213 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/adshao/go-binance/v2/futures"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// ResidualPosition is an exchange position too small to be closed or added to by a normal order
+// ResidualPosition 是小到无法用普通订单平仓或加仓的交易所持仓
+type ResidualPosition struct {
+	Symbol   string  // 交易对 / Trading pair
+	Side     string  // long/short
+	Quantity float64 // 残余数量 / Residual quantity
+	MinQty   float64 // 最小下单量 / Minimum order quantity
+	Cleared  bool    // 是否已在交易所平掉 / Whether it was closed on the exchange
+}
+
+// minTradableQuantity returns the smallest quantity a market order may carry, from the exchange filters or the built-in table
+// minTradableQuantity 返回市价单允许的最小数量，优先使用交易所过滤规则，否则使用内置表
+func minTradableQuantity(symbol string, filters SymbolFilters) float64 {
+	minQty := filters.MinQty
+	if filters.MarketMinQty > minQty {
+		minQty = filters.MarketMinQty
+	}
+	if minQty <= 0 {
+		_, minQty = getSymbolPrecision(symbol)
+	}
+	return minQty
+}
+
+// isResidualQuantity reports whether a position quantity is non-zero but below the minimum order quantity
+// isResidualQuantity 判断持仓数量是否非零且低于最小下单量
+func isResidualQuantity(quantity, minQty float64) bool {
+	return quantity > filterEpsilon && quantity < minQty-filterEpsilon
+}
+
+// ResidualSweeper finds residual positions left by partial closes and precision rounding, and closes or flags them
+// ResidualSweeper 查找部分平仓和精度取整后遗留的残余持仓，并将其平掉或告警
+//
+// A residual makes the executor treat the symbol as held, so new entries in its direction are refused and the
+// stop-loss manager keeps tracking a quantity no order can touch. The sweep runs before each trading cycle.
+// 残余持仓会使执行器认为该交易对已有持仓，从而拒绝同方向的新开仓，止损管理器也会继续跟踪一个无法下单的数量。
+// 清理在每个交易周期开始前运行。
+type ResidualSweeper struct {
+	executor *BinanceExecutor
+	stopLoss *StopLossManager
+	logger   *logger.ColorLogger
+	flagged  map[string]float64 // 已告警的残余数量，避免每个周期重复通知 / Residuals already flagged, so they are not notified every cycle
+}
+
+// NewResidualSweeper creates a residual sweeper for the positions of a stop-loss manager
+// NewResidualSweeper 为止损管理器的持仓创建残余持仓清理器
+func NewResidualSweeper(sm *StopLossManager, log *logger.ColorLogger) *ResidualSweeper {
+	return &ResidualSweeper{
+		executor: sm.Executor(),
+		stopLoss: sm,
+		logger:   log,
+		flagged:  make(map[string]float64),
+	}
+}
+
+// Sweep checks every symbol for a residual position and returns the residuals found
+// Sweep 检查每个交易对的残余持仓，返回发现的残余持仓
+func (s *ResidualSweeper) Sweep(ctx context.Context, symbols []string) []ResidualPosition {
+	var residuals []ResidualPosition
+	for _, symbol := range symbols {
+		if !s.executor.ManagesSymbol(symbol) {
+			continue
+		}
+		binanceSymbol := s.executor.config.GetBinanceSymbolFor(symbol)
+
+		pos, err := s.executor.GetCurrentPosition(ctx, symbol)
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️ 【%s】残余持仓检查失败: %v", binanceSymbol, err))
+			continue
+		}
+		if pos == nil {
+			delete(s.flagged, binanceSymbol)
+			continue
+		}
+
+		filters, err := s.executor.GetSymbolFilters(ctx, symbol)
+		if err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️ 【%s】获取交易规则失败，使用内置最小下单量: %v", binanceSymbol, err))
+		}
+		minQty := minTradableQuantity(binanceSymbol, filters)
+		if !isResidualQuantity(pos.Size, minQty) {
+			delete(s.flagged, binanceSymbol)
+			continue
+		}
+
+		residual := ResidualPosition{Symbol: binanceSymbol, Side: pos.Side, Quantity: pos.Size, MinQty: minQty}
+		residual.Cleared = s.clear(ctx, symbol, pos, &residual, quantityStep(binanceSymbol, filters))
+		residuals = append(residuals, residual)
+	}
+	return residuals
+}
+
+// quantityStep returns the lot step of a market order, from the exchange filters or the built-in precision table
+// quantityStep 返回市价单的数量步长，优先使用交易所过滤规则，否则使用内置精度表
+func quantityStep(symbol string, filters SymbolFilters) float64 {
+	if filters.MarketStepSize > 0 {
+		return filters.MarketStepSize
+	}
+	if filters.StepSize > 0 {
+		return filters.StepSize
+	}
+	precision, _ := getSymbolPrecision(symbol)
+	return math.Pow(10, -float64(precision))
+}
+
+// formatStepQuantity writes a quantity with exactly as many decimals as the lot step allows
+// formatStepQuantity 按数量步长允许的小数位数输出数量
+func formatStepQuantity(quantity, step float64) string {
+	decimals := 0
+	if step > 0 && step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step) - filterEpsilon))
+	}
+	return strconv.FormatFloat(quantity, 'f', decimals, 64)
+}
+
+// clear closes a residual with a reduce-only market order and retires its local tracking once the close succeeds;
+// a refused close is flagged and the position, stop included, stays managed
+// clear 用只减仓市价单平掉残余持仓，平仓成功后才结束本地跟踪；交易所拒绝时告警，持仓及其止损继续受管理
+func (s *ResidualSweeper) clear(ctx context.Context, symbol string, pos *Position, residual *ResidualPosition, step float64) bool {
+	reason := fmt.Sprintf("残余持仓 %.8f 低于最小下单量 %g", residual.Quantity, residual.MinQty)
+
+	s.logger.Info(fmt.Sprintf("🧹【%s】发现残余%s %.8f（最小下单量 %g），尝试平仓", residual.Symbol, pos.Side, residual.Quantity, residual.MinQty))
+	closePrice, err := s.executor.closeQuantity(ctx, symbol, pos.Side, residual.Quantity, step, reason)
+	if err != nil {
+		if s.flagged[residual.Symbol] != residual.Quantity {
+			s.flagged[residual.Symbol] = residual.Quantity
+			s.logger.Warning(fmt.Sprintf("⚠️ 【%s】残余持仓无法平仓: %v，需手动处理", residual.Symbol, err))
+			s.stopLoss.Notifier().Notify(notify.SeverityCritical, residual.Symbol, fmt.Sprintf("🧹 %s，交易所拒绝平仓（%v），需手动处理", reason, err))
+		}
+		return false
+	}
+	if closePrice == 0 {
+		closePrice = pos.CurrentPrice
+	}
+	s.logger.Success(fmt.Sprintf("🧹【%s】残余持仓已平仓 @ %.4f", residual.Symbol, closePrice))
+	delete(s.flagged, residual.Symbol)
+
+	// The exchange position is gone, so the tracked position and its stop can be retired
+	// 交易所持仓已平掉，本地跟踪的持仓及其止损可以结束
+	if tracked := s.stopLoss.GetPosition(symbol); tracked != nil {
+		pnl := (closePrice - tracked.EntryPrice) * residual.Quantity
+		if tracked.Side == "short" {
+			pnl = -pnl
+		}
+		if err := s.stopLoss.ClosePosition(ctx, symbol, closePrice, reason, pnl); err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️ 【%s】关闭本地残余持仓记录失败: %v", residual.Symbol, err))
+		}
+	}
+	return true
+}
+
+// closeQuantity sends a market order closing part of a position, formatted to the lot step, and returns its average price
+// closeQuantity 下达按数量步长格式化的市价单平掉持仓的指定数量，返回成交均价
+func (e *BinanceExecutor) closeQuantity(ctx context.Context, symbol, side string, quantity, step float64, reason string) (float64, error) {
+	if e.paper != nil {
+		action := ActionCloseLong
+		if side == "short" {
+			action = ActionCloseShort
+		}
+		result := e.ExecuteTrade(ctx, symbol, action, quantity, reason)
+		if !result.Success {
+			return 0, fmt.Errorf("%s", result.Message)
+		}
+		return result.Price, nil
+	}
+
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	orderSide, positionSide := futures.SideTypeSell, futures.PositionSideTypeLong
+	if side == "short" {
+		orderSide, positionSide = futures.SideTypeBuy, futures.PositionSideTypeShort
+	}
+	if e.positionMode == PositionModeOneWay {
+		positionSide = futures.PositionSideTypeBoth
+	}
+
+	service := e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(orderSide).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeMarket).
+		Quantity(formatStepQuantity(quantity, step)).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT)
+	if closeReduceOnly(e.positionMode) {
+		service = service.ReduceOnly(true)
+	}
+
+	order, err := e.createOrder(ctx, binanceSymbol, service)
+	if err != nil {
+		return 0, err
+	}
+	filledQty, _ := parseFloat(order.ExecutedQuantity)
+	price, _ := parseFloat(order.AvgPrice)
+	if !isOrderDone(order.Status) {
+		filledQty, price, _ = e.waitForOrderFill(ctx, binanceSymbol, order.OrderID, marketOrderFillTimeout)
+	}
+	if filledQty <= 0 {
+		return 0, fmt.Errorf("close order %d did not fill (status %s)", order.OrderID, order.Status)
+	}
+	return price, nil
+}
@@ -0,0 +1,82 @@
+package executors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestResidualQuantity(t *testing.T) {
+	filters := SymbolFilters{MinQty: 0.001, MarketMinQty: 0.002}
+	if got := minTradableQuantity("BTCUSDT", filters); got != 0.002 {
+		t.Errorf("Expected the market minimum 0.002, got %g", got)
+	}
+	if got := minTradableQuantity("BTCUSDT", SymbolFilters{}); got <= 0 {
+		t.Errorf("Expected the built-in minimum without filters, got %g", got)
+	}
+
+	cases := []struct {
+		quantity float64
+		residual bool
+	}{
+		{0, false},
+		{0.0005, true},
+		{0.0019999, true},
+		{0.002, false},
+		{0.5, false},
+	}
+	for _, c := range cases {
+		if got := isResidualQuantity(c.quantity, 0.002); got != c.residual {
+			t.Errorf("isResidualQuantity(%g) = %v, want %v", c.quantity, got, c.residual)
+		}
+	}
+}
+
+func TestFormatStepQuantity(t *testing.T) {
+	cases := []struct {
+		quantity, step float64
+		want           string
+	}{
+		{0.0005, 0.0001, "0.0005"},
+		{0.00004, 0.00001, "0.00004"},
+		{0.3, 0.1, "0.3"},
+		{7, 1, "7"},
+	}
+	for _, c := range cases {
+		if got := formatStepQuantity(c.quantity, c.step); got != c.want {
+			t.Errorf("formatStepQuantity(%g, %g) = %q, want %q", c.quantity, c.step, got, c.want)
+		}
+	}
+	if got := quantityStep("BTCUSDT", SymbolFilters{StepSize: 0.001, MarketStepSize: 0.01}); got != 0.01 {
+		t.Errorf("Expected the market step 0.01, got %g", got)
+	}
+	if got := quantityStep("BTCUSDT", SymbolFilters{}); got != 0.001 {
+		t.Errorf("Expected the built-in step 0.001, got %g", got)
+	}
+}
+
+func TestResidualClearKeepsPositionWhenCloseFails(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string), reject: "MARKET"}
+	sm := newBracketTestManager(t, exchange)
+	pos := &Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, Quantity: 0.0005, InitialStopLoss: 95, CurrentStopLoss: 95, StopLossOrderID: "9"}
+	sm.RegisterPosition(pos)
+	sweeper := NewResidualSweeper(sm, logger.NewColorLogger(false))
+	residual := &ResidualPosition{Symbol: "BTCUSDT", Side: "long", Quantity: 0.0005, MinQty: 0.001}
+
+	if sweeper.clear(context.Background(), "BTCUSDT", pos, residual, 0.0001) {
+		t.Fatal("Expected the rejected close to fail")
+	}
+	if sm.GetPosition("BTCUSDT") == nil || len(exchange.cancelled) != 0 {
+		t.Fatalf("Expected the position and its stop kept, cancelled %v", exchange.cancelled)
+	}
+
+	exchange.reject = ""
+	exchange.query = `{"symbol":"BTCUSDT","orderId":1,"status":"FILLED","executedQty":"0.0005","avgPrice":"101"}`
+	if !sweeper.clear(context.Background(), "BTCUSDT", pos, residual, 0.0001) {
+		t.Fatal("Expected the close to succeed")
+	}
+	if sm.GetPosition("BTCUSDT") != nil || len(exchange.cancelled) != 1 || exchange.cancelled[0] != 9 {
+		t.Errorf("Expected the position retired and stop 9 cancelled, cancelled %v", exchange.cancelled)
+	}
+}
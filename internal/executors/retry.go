@@ -0,0 +1,271 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/jpillora/backoff"
+)
+
+// Retry policy for Binance calls
+// 币安调用的重试策略
+const retryMaxAttempts = 3 // 最大重试次数 / Maximum retries
+
+// Retry backoff for Binance calls; variables so tests that inject failures can shorten them
+// 币安调用的重试退避；定义为变量，便于注入故障的测试缩短等待
+var (
+	retryBackoffMin = 2 * time.Second  // 最小退避 / Minimum backoff
+	retryBackoffMax = 10 * time.Second // 最大退避 / Maximum backoff
+)
+
+// errCodeTimestampOutsideWindow is returned when the local clock drifts outside recvWindow
+// errCodeTimestampOutsideWindow 表示本地时钟偏移超出 recvWindow
+const errCodeTimestampOutsideWindow = -1021
+
+// retryableAPICodes are Binance error codes caused by transient exchange or clock conditions
+// retryableAPICodes 是由交易所或时钟的暂时状况引起的币安错误码
+var retryableAPICodes = map[int64]string{
+	-1000: "未知错误",      // UNKNOWN
+	-1001: "连接断开",      // DISCONNECTED
+	-1003: "请求过于频繁",    // TOO_MANY_REQUESTS
+	-1006: "异常响应",      // UNEXPECTED_RESP
+	-1007: "后端超时",      // TIMEOUT
+	-1008: "服务器过载",     // SERVER_BUSY
+	-1021: "时间戳超出接收窗口", // INVALID_TIMESTAMP
+}
+
+// fatalAPICodes are common Binance error codes that will fail again on retry; listed for clearer logs
+// fatalAPICodes 是重试也必然失败的常见币安错误码，仅用于更清晰的日志
+var fatalAPICodes = map[int64]string{
+	-1111: "精度超限",            // BAD_PRECISION
+	-1121: "无效交易对",           // BAD_SYMBOL
+	-2011: "订单不存在",           // UNKNOWN_ORDER
+	-2013: "订单不存在",           // NO_SUCH_ORDER
+	-2015: "API 密钥或权限无效",     // REJECTED_MBX_KEY
+	-2018: "余额不足",            // BALANCE_NOT_SUFFICIENT
+	-2019: "保证金不足",           // MARGIN_NOT_SUFFICIEN
+	-2022: "只减仓单被拒绝",         // REDUCE_ONLY_REJECT
+	-4164: "名义价值低于最小值",       // MIN_NOTIONAL
+	-5022: "只做 maker 单会立即成交", // GTX_ORDER_REJECT
+}
+
+// classifyError decides whether a failed Binance call is worth retrying, and describes why
+// classifyError 判断失败的币安调用是否值得重试，并给出原因
+//
+// Timeouts, dropped connections, 5xx gateway pages and the codes in retryableAPICodes are retryable.
+// Every other API error (insufficient margin, bad precision, ...) is fatal, as is a cancelled context.
+// 超时、连接断开、5xx 网关页面以及 retryableAPICodes 中的错误码可以重试；
+// 其他 API 错误（保证金不足、精度错误等）以及上下文被取消都视为致命错误。
+func classifyError(err error) (retryable bool, reason string) {
+	if err == nil {
+		return false, ""
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false, "上下文已取消"
+	}
+
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		// A 5xx from a gateway returns an HTML page that carries no code
+		// 网关返回的 5xx 是不含错误码的 HTML 页面
+		if !apiErr.IsValid() || apiErr.Code == 0 {
+			return true, "服务端错误"
+		}
+		if reason, ok := retryableAPICodes[apiErr.Code]; ok {
+			return true, reason
+		}
+		if reason, ok := fatalAPICodes[apiErr.Code]; ok {
+			return false, reason
+		}
+		return false, fmt.Sprintf("错误码 %d", apiErr.Code)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true, "请求超时"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return true, "请求超时"
+		}
+		return true, "网络错误"
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, "连接中断"
+	}
+
+	return false, "未知错误"
+}
+
+// isTimestampError reports whether err is Binance's -1021 clock drift error
+// isTimestampError 判断 err 是否为币安 -1021 时钟偏移错误
+func isTimestampError(err error) bool {
+	var apiErr *common.APIError
+	return errors.As(err, &apiErr) && apiErr.Code == errCodeTimestampOutsideWindow
+}
+
+// withRetry executes a function, retrying transient errors with jittered exponential backoff
+// withRetry 执行函数，对暂时性错误按带抖动的指数退避重试
+// Fatal errors are returned immediately; a -1021 clock error resyncs the server time before the next attempt
+// 致命错误立即返回；-1021 时钟错误会在下次尝试前重新同步服务器时间
+func (e *BinanceExecutor) withRetry(fn func() error) error {
+	b := &backoff.Backoff{
+		Min:    retryBackoffMin,
+		Max:    retryBackoffMax,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	for i := 0; ; i++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		retryable, reason := classifyError(err)
+		if !retryable {
+			return err
+		}
+		if i == retryMaxAttempts {
+			return fmt.Errorf("max retries reached: %w", err)
+		}
+
+		if isTimestampError(err) {
+			e.syncServerTime()
+		}
+
+		duration := b.Duration()
+		e.logger.Warning(fmt.Sprintf("操作失败 (尝试 %d/%d, %s): %v，等待 %.1f 秒后重试...",
+			i+1, retryMaxAttempts, reason, err, duration.Seconds()))
+		time.Sleep(duration)
+	}
+}
+
+// syncServerTime updates the client's clock offset from the exchange server time
+// syncServerTime 根据交易所服务器时间更新客户端时钟偏移
+func (e *BinanceExecutor) syncServerTime() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	offset, err := e.client.NewSetServerTimeService().Do(ctx)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️  同步服务器时间失败: %v", err))
+		return
+	}
+	e.logger.Info(fmt.Sprintf("🕒 已同步服务器时间，偏移 %d ms", offset))
+}
+
+// clientOrderSeq makes client order IDs unique within the process
+// clientOrderSeq 保证进程内客户端订单 ID 唯一
+var clientOrderSeq atomic.Uint64
+
+// newClientOrderID returns a client order ID accepted by Binance (at most 36 characters)
+// newClientOrderID 返回币安接受的客户端订单 ID（最多 36 个字符）
+// The instance tag lets other instances on the same account recognise orders they do not own
+// 实例标签让同一账户上的其他实例识别出不属于自己的订单
+func newClientOrderID(tag string) string {
+	return fmt.Sprintf("%s%s_%d_%d", clientOrderPrefix, tag, time.Now().UnixMilli(), clientOrderSeq.Add(1))
+}
+
+// createOrder submits an order with retries, without risking a duplicate fill
+// createOrder 带重试地提交订单，且不会产生重复成交
+//
+// The order is tagged with a client order ID before the first attempt. If an attempt fails ambiguously
+// (e.g. a timeout after the request reached the exchange), the order is looked up by that ID before resending.
+// 首次提交前为订单设置客户端订单 ID。若某次尝试结果不明（如请求已到达交易所后超时），重发前先按该 ID 查询订单。
+func (e *BinanceExecutor) createOrder(ctx context.Context, binanceSymbol string, service *futures.CreateOrderService) (*futures.CreateOrderResponse, error) {
+	clientOrderID := newClientOrderID(e.instanceTag)
+	service = service.NewClientOrderID(clientOrderID)
+
+	var order *futures.CreateOrderResponse
+	attempted := false
+	err := e.withRetry(func() error {
+		if attempted {
+			if existing, err := e.client.NewGetOrderService().
+				Symbol(binanceSymbol).
+				OrigClientOrderID(clientOrderID).
+				Do(ctx); err == nil {
+				order = orderToCreateResponse(existing)
+				return nil
+			}
+		}
+		attempted = true
+
+		var err error
+		order, err = service.Do(ctx)
+		return err
+	})
+	return order, err
+}
+
+// orderToCreateResponse converts a queried order into the shape returned by order creation
+// orderToCreateResponse 将查询到的订单转换为下单接口返回的结构
+func orderToCreateResponse(o *futures.Order) *futures.CreateOrderResponse {
+	return &futures.CreateOrderResponse{
+		Symbol:           o.Symbol,
+		OrderID:          o.OrderID,
+		ClientOrderID:    o.ClientOrderID,
+		Price:            o.Price,
+		OrigQuantity:     o.OrigQuantity,
+		ExecutedQuantity: o.ExecutedQuantity,
+		CumQuote:         o.CumQuote,
+		ReduceOnly:       o.ReduceOnly,
+		Status:           o.Status,
+		StopPrice:        o.StopPrice,
+		TimeInForce:      o.TimeInForce,
+		Type:             o.Type,
+		Side:             o.Side,
+		UpdateTime:       o.UpdateTime,
+		WorkingType:      o.WorkingType,
+		ActivatePrice:    o.ActivatePrice,
+		PriceRate:        o.PriceRate,
+		AvgPrice:         o.AvgPrice,
+		PositionSide:     o.PositionSide,
+		ClosePosition:    o.ClosePosition,
+		PriceProtect:     o.PriceProtect,
+	}
+}
+
+// cancelOrder cancels an order with retries; cancelling is idempotent, so an unknown order is reported as-is
+// cancelOrder 带重试地撤单；撤单是幂等的，订单不存在时原样返回错误
+func (e *BinanceExecutor) cancelOrder(ctx context.Context, binanceSymbol string, orderID int64) (*futures.CancelOrderResponse, error) {
+	if e.paper != nil {
+		return e.paper.CancelOrder(orderID)
+	}
+	var resp *futures.CancelOrderResponse
+	err := e.withRetry(func() error {
+		var err error
+		resp, err = e.client.NewCancelOrderService().
+			Symbol(binanceSymbol).
+			OrderID(orderID).
+			Do(ctx)
+		return err
+	})
+	return resp, err
+}
+
+// getOrder queries an order with retries; in paper mode the simulated order book answers
+// getOrder 带重试地查询订单；模拟盘模式下由模拟订单簿返回
+func (e *BinanceExecutor) getOrder(ctx context.Context, binanceSymbol string, orderID int64) (*futures.Order, error) {
+	if e.paper != nil {
+		return e.paper.Order(orderID)
+	}
+	var order *futures.Order
+	err := e.withRetry(func() error {
+		var err error
+		order, err = e.client.NewGetOrderService().
+			Symbol(binanceSymbol).
+			OrderID(orderID).
+			Do(ctx)
+		return err
+	})
+	return order, err
+}
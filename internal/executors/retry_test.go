@@ -0,0 +1,66 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "Timestamp outside recvWindow", err: &common.APIError{Code: -1021, Message: "Timestamp for this request is outside of the recvWindow."}, retryable: true},
+		{name: "Server busy", err: &common.APIError{Code: -1008, Message: "Server is currently overloaded"}, retryable: true},
+		{name: "Gateway 5xx without code", err: &common.APIError{Response: []byte("<html>502 Bad Gateway</html>")}, retryable: true},
+		{name: "Insufficient margin", err: &common.APIError{Code: -2019, Message: "Margin is insufficient."}, retryable: false},
+		{name: "Bad precision", err: &common.APIError{Code: -1111, Message: "Precision is over the maximum defined for this asset."}, retryable: false},
+		{name: "Unknown API code", err: &common.APIError{Code: -4061, Message: "Order's position side does not match user's setting."}, retryable: false},
+		{name: "Wrapped API error", err: fmt.Errorf("下止损单失败: %w", &common.APIError{Code: -1001}), retryable: true},
+		{name: "Network timeout", err: &net.OpError{Op: "read", Err: timeoutError{}}, retryable: true},
+		{name: "Deadline exceeded", err: context.DeadlineExceeded, retryable: true},
+		{name: "Unexpected EOF", err: io.ErrUnexpectedEOF, retryable: true},
+		{name: "Context cancelled", err: context.Canceled, retryable: false},
+		{name: "Plain error", err: errors.New("invalid quantity"), retryable: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, reason := classifyError(tt.err)
+			if retryable != tt.retryable {
+				t.Errorf("Expected retryable=%v, got %v (%s)", tt.retryable, retryable, reason)
+			}
+			if reason == "" {
+				t.Errorf("Expected a reason")
+			}
+		})
+	}
+}
+
+func TestNewClientOrderID(t *testing.T) {
+	a, b := newClientOrderID("abc123"), newClientOrderID("abc123")
+	if a == b {
+		t.Errorf("Expected unique client order IDs, got %s twice", a)
+	}
+	if len(a) > 36 {
+		t.Errorf("Client order ID %s exceeds 36 characters", a)
+	}
+	if tag, ok := parseClientOrderTag(a); !ok || tag != "abc123" {
+		t.Errorf("Expected tag abc123 in %s, got %q", a, tag)
+	}
+}
+
+// timeoutError is a net.Error that reports a timeout
+// timeoutError 是一个报告超时的 net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultStopLossPercent is the initial stop distance given to entries without a usable stop, and the distance the
+// sizing rules assume for them
+// DefaultStopLossPercent 是没有可用止损的开仓所使用的初始止损距离（%），仓位计算也按该距离假设
+const DefaultStopLossPercent = 2.5
+
+// DefaultInitialStop returns the stop DefaultStopLossPercent away from entry on the losing side
+// DefaultInitialStop 返回位于入场价亏损一侧、距离 DefaultStopLossPercent 的止损价
+func DefaultInitialStop(side string, entry float64) float64 {
+	if side == "short" {
+		return entry * (1 + DefaultStopLossPercent/100)
+	}
+	return entry * (1 - DefaultStopLossPercent/100)
+}
+
+// TradeRiskCap is an entry quantity checked against the per-trade risk cap
+// TradeRiskCap 是经过单笔风险上限检查的开仓数量
+type TradeRiskCap struct {
+	Quantity    float64 // 限制后的数量 / Quantity after the cap
+	RiskPercent float64 // 限制前止损触发时的亏损占权益比例（%）/ Loss at the stop as % of equity before the cap
+	Capped      bool    // 是否削减了数量 / Whether the quantity was cut
+	Detail      string  // 止损说明 / Which stop the risk was measured at
+}
+
+// CapTradeRisk cuts quantity so that hitting stop loses at most maxRiskPercent of equity
+// CapTradeRisk 削减数量，使止损触发时的亏损不超过权益的 maxRiskPercent
+//
+// The cap does not depend on how the quantity was sized, so it holds whatever size and stop the LLM proposed; a stop
+// that is missing or on the wrong side is measured at DefaultStopLossPercent. maxRiskPercent <= 0 disables it.
+// 该上限与数量的计算方式无关，无论 LLM 给出怎样的仓位和止损都同样生效；止损缺失或方向错误时按
+// DefaultStopLossPercent 计算。maxRiskPercent <= 0 表示不启用。
+func CapTradeRisk(side string, equity, entry, stop, quantity, maxRiskPercent float64) (TradeRiskCap, error) {
+	if maxRiskPercent <= 0 {
+		return TradeRiskCap{Quantity: quantity}, nil
+	}
+	if equity <= 0 || entry <= 0 {
+		return TradeRiskCap{}, fmt.Errorf("invalid risk cap inputs: equity %.2f, entry %.4f", equity, entry)
+	}
+	distance := entry - stop
+	if side == "short" {
+		distance = -distance
+	}
+	detail := fmt.Sprintf("止损 %.4f（距离 %.2f%%）", stop, distance/entry*100)
+	if stop <= 0 || distance <= 0 {
+		distance = entry * DefaultStopLossPercent / 100
+		detail = fmt.Sprintf("无有效止损，按默认 %.1f%% 止损", DefaultStopLossPercent)
+	}
+
+	result := TradeRiskCap{Quantity: quantity, RiskPercent: quantity * distance / equity * 100, Detail: detail}
+	if limit := equity * maxRiskPercent / 100 / distance; quantity > limit {
+		result.Quantity = limit
+		result.Capped = true
+	}
+	return result, nil
+}
+
+// FloorQuantityPrecision rounds quantity down to the symbol's precision, so a capped quantity is never rounded past its cap
+// FloorQuantityPrecision 将数量向下取整到交易对精度，使受限数量不会因四舍五入超过上限
+func FloorQuantityPrecision(symbol string, quantity float64) (float64, error) {
+	precision, minQty := getSymbolPrecision(symbol)
+	multiplier := math.Pow(10, float64(precision))
+	floored := math.Floor(quantity*multiplier+1e-9) / multiplier
+	if floored < minQty {
+		return 0, fmt.Errorf("数量 %.4f 低于最小要求 %.4f (交易对: %s)", floored, minQty, symbol)
+	}
+	return floored, nil
+}
@@ -0,0 +1,58 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCapTradeRisk(t *testing.T) {
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	// 100 units with a 5 point stop lose 500, 5% of 10000; a 2% cap allows 40 units
+	// 100 个单位、5 点止损亏损 500，即 10000 的 5%；2% 上限只允许 40 个单位
+	capped, err := CapTradeRisk("long", 10000, 100, 95, 100, 2)
+	if err != nil || !capped.Capped || !near(capped.Quantity, 40) || !near(capped.RiskPercent, 5) {
+		t.Errorf("Expected 100 units cut to 40 from 5%% risk, got %+v err=%v", capped, err)
+	}
+	if short, _ := CapTradeRisk("short", 10000, 100, 105, 100, 2); !short.Capped || !near(short.Quantity, 40) {
+		t.Errorf("Expected the short side to mirror the long, got %+v", short)
+	}
+
+	// Entries within the cap are left alone
+	// 未超出上限的开仓保持不变
+	if within, _ := CapTradeRisk("long", 10000, 100, 95, 30, 2); within.Capped || within.Quantity != 30 || !near(within.RiskPercent, 1.5) {
+		t.Errorf("Expected 30 units at 1.5%% risk to pass, got %+v", within)
+	}
+
+	// A missing or wrong-side stop is measured at the default 2.5% stop
+	// 止损缺失或方向错误时按默认 2.5% 止损计算
+	for _, stop := range []float64{0, 101} {
+		if got, _ := CapTradeRisk("long", 10000, 100, stop, 100, 2); !got.Capped || !near(got.Quantity, 80) {
+			t.Errorf("Expected stop %.0f to allow 80 units at the default stop, got %+v", stop, got)
+		}
+	}
+
+	if off, _ := CapTradeRisk("long", 10000, 100, 99.9, 1000, 0); off.Capped || off.Quantity != 1000 {
+		t.Errorf("Expected a zero cap to disable the check, got %+v", off)
+	}
+	if _, err := CapTradeRisk("long", 0, 100, 95, 10, 2); err == nil {
+		t.Error("Expected zero equity to be rejected")
+	}
+}
+
+func TestDefaultInitialStop(t *testing.T) {
+	if long, short := DefaultInitialStop("long", 100), DefaultInitialStop("short", 100); math.Abs(long-97.5) > 1e-9 || math.Abs(short-102.5) > 1e-9 {
+		t.Errorf("Expected 97.5 and 102.5, got %v and %v", long, short)
+	}
+}
+
+func TestFloorQuantityPrecision(t *testing.T) {
+	// BTC trades in 0.001 steps, so 0.0129 rounds down to 0.012 rather than up to 0.013
+	// BTC 数量步长为 0.001，因此 0.0129 向下取整为 0.012 而不是四舍五入为 0.013
+	if got, err := FloorQuantityPrecision("BTCUSDT", 0.0129); err != nil || got != 0.012 {
+		t.Errorf("Expected 0.012, got %v err=%v", got, err)
+	}
+	if _, err := FloorQuantityPrecision("BTCUSDT", 0.0009); err == nil {
+		t.Error("Expected a quantity below the minimum to be rejected")
+	}
+}
@@ -0,0 +1,61 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+)
+
+// RiskSize is an entry sized from its stop distance, with the leverage that carries it
+// RiskSize 是按止损距离计算的开仓数量及承载该仓位的杠杆
+type RiskSize struct {
+	Quantity      float64 // 开仓数量 / Entry quantity
+	Leverage      int     // 使用的杠杆 / Leverage to use
+	MarginPercent float64 // 保证金占权益比例（%）/ Margin as % of equity
+	RiskPercent   float64 // 止损触发时的亏损占权益比例（%）/ Loss at the stop as % of equity
+	Detail        string  // 计算说明 / How the size was derived
+}
+
+// RiskBasedSize sizes an entry so that hitting stop loses riskPercent of equity, then picks the lowest leverage in
+// [minLeverage, maxLeverage] that keeps the margin within marginPercent of equity
+// RiskBasedSize 按止损触发时亏损 riskPercent 权益计算数量，再在 [minLeverage, maxLeverage] 中选择
+// 使保证金不超过权益 marginPercent 的最低杠杆
+//
+// When even maxLeverage needs more margin than allowed, the quantity is cut to fit and the realized risk is lower.
+// 即使使用 maxLeverage 保证金仍超出上限时削减数量，实际风险随之降低。
+func RiskBasedSize(side string, equity, entry, stop, riskPercent, marginPercent float64, minLeverage, maxLeverage int) (RiskSize, error) {
+	if equity <= 0 || entry <= 0 || riskPercent <= 0 || marginPercent <= 0 {
+		return RiskSize{}, fmt.Errorf("invalid risk sizing inputs: equity %.2f, entry %.4f, risk %.2f%%, margin %.2f%%", equity, entry, riskPercent, marginPercent)
+	}
+	distance := entry - stop
+	if side == "short" {
+		distance = -distance
+	}
+	if stop <= 0 || distance <= 0 {
+		return RiskSize{}, fmt.Errorf("stop %.4f is not on the losing side of a %s entry at %.4f", stop, side, entry)
+	}
+	if minLeverage < 1 {
+		minLeverage = 1
+	}
+	if maxLeverage < minLeverage {
+		maxLeverage = minLeverage
+	}
+
+	quantity := equity * riskPercent / 100 / distance
+	marginBudget := equity * marginPercent / 100
+	leverage := int(math.Ceil(quantity * entry / marginBudget))
+	leverage = max(minLeverage, min(leverage, maxLeverage))
+
+	detail := fmt.Sprintf("风险 %.2f%% 权益，止损 %.4f（距离 %.2f%%）", riskPercent, stop, distance/entry*100)
+	if limit := marginBudget * float64(leverage) / entry; quantity > limit {
+		quantity = limit
+		detail += fmt.Sprintf("，%dx 杠杆下保证金上限 %.0f%% 权益限制了数量", leverage, marginPercent)
+	}
+
+	return RiskSize{
+		Quantity:      quantity,
+		Leverage:      leverage,
+		MarginPercent: quantity * entry / float64(leverage) / equity * 100,
+		RiskPercent:   quantity * distance / equity * 100,
+		Detail:        detail,
+	}, nil
+}
@@ -0,0 +1,39 @@
+package executors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRiskBasedSize(t *testing.T) {
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+
+	// 0.5% of 10000 at a 1 point stop is 50 units, 5000 notional; 2x keeps the margin within 30%
+	// 10000 的 0.5% 对应 1 点止损为 50 个单位、名义价值 5000；2 倍杠杆使保证金不超过 30%
+	size, err := RiskBasedSize("long", 10000, 100, 99, 0.5, 30, 1, 20)
+	if err != nil || !near(size.Quantity, 50) || size.Leverage != 2 || !near(size.MarginPercent, 25) || !near(size.RiskPercent, 0.5) {
+		t.Errorf("Expected 50 units at 2x with 25%% margin, got %+v err=%v", size, err)
+	}
+	if short, _ := RiskBasedSize("short", 10000, 100, 101, 0.5, 30, 1, 20); !near(short.Quantity, 50) || short.Leverage != 2 {
+		t.Errorf("Expected the short side to mirror the long, got %+v", short)
+	}
+
+	// The leverage floor applies even when less would do
+	// 即使更低杠杆足够也不低于最低杠杆
+	if floored, _ := RiskBasedSize("long", 10000, 100, 99, 0.5, 30, 5, 20); floored.Leverage != 5 || !near(floored.MarginPercent, 10) {
+		t.Errorf("Expected the 5x floor with 10%% margin, got %+v", floored)
+	}
+
+	// At the leverage ceiling the margin cap cuts the quantity and the risk with it
+	// 达到杠杆上限时保证金上限削减数量，风险随之降低
+	if capped, _ := RiskBasedSize("long", 10000, 100, 99, 0.5, 30, 1, 1); !near(capped.Quantity, 30) || capped.Leverage != 1 || !near(capped.RiskPercent, 0.3) {
+		t.Errorf("Expected 30 units at 1x with 0.3%% risk, got %+v", capped)
+	}
+
+	if _, err := RiskBasedSize("long", 10000, 100, 101, 0.5, 30, 1, 20); err == nil {
+		t.Error("Expected a stop above a long entry to be rejected")
+	}
+	if _, err := RiskBasedSize("short", 10000, 100, 0, 0.5, 30, 1, 20); err == nil {
+		t.Error("Expected a missing stop to be rejected")
+	}
+}
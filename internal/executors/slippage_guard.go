@@ -0,0 +1,201 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Actions when a market order's expected slippage exceeds MAX_SLIPPAGE_BPS
+// 市价单预计滑点超过 MAX_SLIPPAGE_BPS 时的处理方式
+const (
+	SlippageActionAbort = "abort" // 放弃下单 / Do not send the order
+	SlippageActionLimit = "limit" // 改为以滑点上限价格下 IOC 限价单 / Send an IOC limit order at the slippage cap
+)
+
+// slippageDepthLimit is the number of order book levels fetched to estimate slippage
+// slippageDepthLimit 估算滑点时获取的盘口档位数
+const slippageDepthLimit = 100
+
+// estimateSlippageBps walks the book levels a market order would consume and returns the expected slippage from markPrice in bps
+// estimateSlippageBps 按市价单会吃掉的盘口档位计算成交均价，返回相对标记价格的预计滑点（基点）
+// Buys consume asks and sells consume bids; ok is false when the fetched depth cannot fill the quantity
+// 买单吃卖盘、卖单吃买盘；获取的深度不足以成交全部数量时 ok 为 false
+func estimateSlippageBps(levels []common.PriceLevel, side futures.SideType, quantity, markPrice float64) (bps float64, ok bool) {
+	if quantity <= 0 || markPrice <= 0 {
+		return 0, false
+	}
+
+	remaining := quantity
+	cost := 0.0
+	for _, level := range levels {
+		price, qty, err := level.Parse()
+		if err != nil || qty <= 0 {
+			continue
+		}
+		take := math.Min(qty, remaining)
+		cost += take * price
+		remaining -= take
+		if remaining <= filterEpsilon {
+			return fillSlippageBps(side, markPrice, cost/quantity), true
+		}
+	}
+	return 0, false
+}
+
+// fillSlippageBps returns how far fillPrice is from referencePrice in bps; positive means a worse price than the reference
+// fillSlippageBps 返回成交价相对参考价格的偏离（基点）；正数表示比参考价格更差
+func fillSlippageBps(side futures.SideType, referencePrice, fillPrice float64) float64 {
+	if referencePrice <= 0 || fillPrice <= 0 {
+		return 0
+	}
+	bps := (fillPrice - referencePrice) / referencePrice * 10000
+	if side == futures.SideTypeSell {
+		return -bps
+	}
+	return bps
+}
+
+// slippageCapPrice returns the worst acceptable price for maxBps of slippage, rounded to the tick towards the mark price
+// slippageCapPrice 返回滑点为 maxBps 时可接受的最差价格，按最小价格变动向标记价格一侧取整
+func slippageCapPrice(side futures.SideType, markPrice, maxBps, tickSize float64) float64 {
+	if side == futures.SideTypeBuy {
+		price := markPrice * (1 + maxBps/10000)
+		if tickSize > 0 {
+			price = math.Floor(price/tickSize+filterEpsilon) * tickSize
+		}
+		return price
+	}
+	price := markPrice * (1 - maxBps/10000)
+	if tickSize > 0 {
+		price = math.Ceil(price/tickSize-filterEpsilon) * tickSize
+	}
+	return price
+}
+
+// formatTickPrice formats a price with as many decimals as the tick size, or two when the tick is unknown
+// formatTickPrice 按最小价格变动的小数位数格式化价格，未知时保留两位小数
+func formatTickPrice(price, tickSize float64) string {
+	decimals := 2
+	if tickSize > 0 {
+		decimals = int(math.Max(0, math.Ceil(-math.Log10(tickSize)-filterEpsilon)))
+	}
+	return fmt.Sprintf("%.*f", decimals, price)
+}
+
+// getMarkPrice returns the current mark price of a symbol
+// getMarkPrice 返回交易对当前的标记价格
+func (e *BinanceExecutor) getMarkPrice(ctx context.Context, binanceSymbol string) (float64, error) {
+	var premiumIndex []*futures.PremiumIndex
+	err := e.withRetry(func() error {
+		var err error
+		premiumIndex, err = e.client.NewPremiumIndexService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mark price: %w", err)
+	}
+	if len(premiumIndex) == 0 {
+		return 0, fmt.Errorf("no mark price for %s", binanceSymbol)
+	}
+	markPrice, err := parseFloat(premiumIndex[0].MarkPrice)
+	if err != nil || markPrice <= 0 {
+		return 0, fmt.Errorf("invalid mark price for %s: %q", binanceSymbol, premiumIndex[0].MarkPrice)
+	}
+	return markPrice, nil
+}
+
+// placeGuardedMarketOrder places a market order only if the order book can absorb it within MAX_SLIPPAGE_BPS of the mark price
+// placeGuardedMarketOrder 仅当盘口能在标记价格 MAX_SLIPPAGE_BPS 范围内吃下订单时才下市价单
+// Otherwise the order is aborted, or sent as an IOC limit at the slippage cap so any unfilled part is cancelled instead of walking the book
+// 否则放弃下单，或以滑点上限价格发送 IOC 限价单，未成交部分直接撤销而不是继续扫盘
+func (e *BinanceExecutor) placeGuardedMarketOrder(ctx context.Context, symbol string, side futures.SideType, positionSide futures.PositionSideType, quantity float64) (int64, float64, float64, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	maxBps := e.config.MaxSlippageBps
+
+	// Without market data the guard cannot judge the order; it does not block trading
+	// 缺少行情数据时无法判断滑点，不阻塞交易
+	markPrice, err := e.getMarkPrice(ctx, binanceSymbol)
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】滑点检查跳过: %v", binanceSymbol, err))
+		return e.sendMarketOrder(ctx, symbol, side, positionSide, quantity)
+	}
+	var depth *futures.DepthResponse
+	err = e.withRetry(func() error {
+		var err error
+		depth, err = e.client.NewDepthService().Symbol(binanceSymbol).Limit(slippageDepthLimit).Do(ctx)
+		return err
+	})
+	if err != nil {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】滑点检查跳过，获取盘口失败: %v", binanceSymbol, err))
+		return e.sendMarketOrder(ctx, symbol, side, positionSide, quantity)
+	}
+
+	levels := depth.Asks
+	if side == futures.SideTypeSell {
+		levels = depth.Bids
+	}
+	expected, ok := estimateSlippageBps(levels, side, quantity, markPrice)
+	if ok && expected <= maxBps {
+		e.logger.Info(fmt.Sprintf("📏 【%s】预计滑点 %.1f bps（上限 %.1f bps）", binanceSymbol, expected, maxBps))
+		return e.sendMarketOrder(ctx, symbol, side, positionSide, quantity)
+	}
+
+	if ok {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】预计滑点 %.1f bps 超过上限 %.1f bps（标记价 %.2f）", binanceSymbol, expected, maxBps, markPrice))
+	} else {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】盘口前 %d 档深度不足以成交 %.4f，滑点无法控制在 %.1f bps 内", binanceSymbol, slippageDepthLimit, quantity, maxBps))
+	}
+
+	if strings.ToLower(e.config.SlippageGuardAction) == SlippageActionAbort {
+		if ok {
+			return 0, 0, 0, fmt.Errorf("expected slippage %.1f bps exceeds limit %.1f bps, order aborted", expected, maxBps)
+		}
+		return 0, 0, 0, fmt.Errorf("order book too thin to fill %.4f within %.1f bps, order aborted", quantity, maxBps)
+	}
+
+	tickSize := 0.0
+	if filters, err := e.GetSymbolFilters(ctx, symbol); err == nil {
+		tickSize = filters.TickSize
+	}
+	limitPrice := formatTickPrice(slippageCapPrice(side, markPrice, maxBps, tickSize), tickSize)
+	e.logger.Info(fmt.Sprintf("📝 【%s】改为 IOC 限价单: 限价 %s（标记价 %.2f ± %.1f bps）", binanceSymbol, limitPrice, markPrice, maxBps))
+
+	order, err := e.createOrder(ctx, binanceSymbol, e.client.NewCreateOrderService().
+		Symbol(binanceSymbol).
+		Side(side).
+		PositionSide(positionSide).
+		Type(futures.OrderTypeLimit).
+		TimeInForce(futures.TimeInForceTypeIOC).
+		Price(limitPrice).
+		Quantity(fmt.Sprintf("%.4f", quantity)).
+		NewOrderResponseType(futures.NewOrderRespTypeRESULT))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to place slippage-capped limit order: %w", err)
+	}
+
+	filledQty, _ := parseFloat(order.ExecutedQuantity)
+	avgPrice, _ := parseFloat(order.AvgPrice)
+	if filledQty <= 0 {
+		return 0, 0, 0, fmt.Errorf("slippage-capped limit order at %s did not fill", limitPrice)
+	}
+	if filledQty < quantity {
+		e.logger.Warning(fmt.Sprintf("⚠️ 【%s】IOC 限价单部分成交 %.4f / %.4f，剩余数量已取消", binanceSymbol, filledQty, quantity))
+	}
+	return order.OrderID, filledQty, avgPrice, nil
+}
+
+// recordEntrySlippage stores the entry fill's slippage against the mark price seen before the order was sent
+// recordEntrySlippage 记录开仓成交价相对下单前标记价格的滑点
+func (e *BinanceExecutor) recordEntrySlippage(result *TradeResult, side futures.SideType, referencePrice, fillPrice float64) {
+	if referencePrice <= 0 || fillPrice <= 0 {
+		return
+	}
+	result.ReferencePrice = referencePrice
+	result.SlippageBps = fillSlippageBps(side, referencePrice, fillPrice)
+	e.logger.Info(fmt.Sprintf("📏 实际滑点: %+.1f bps（下单前标记价 %.2f → 成交均价 %.2f）", result.SlippageBps, referencePrice, fillPrice))
+}
@@ -0,0 +1,105 @@
+package executors
+
+import (
+	"math"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestEstimateSlippageBps(t *testing.T) {
+	asks := []common.PriceLevel{
+		{Price: "100.00", Quantity: "1"},
+		{Price: "100.10", Quantity: "1"},
+		{Price: "101.00", Quantity: "2"},
+	}
+	bids := []common.PriceLevel{
+		{Price: "99.90", Quantity: "1"},
+		{Price: "99.00", Quantity: "1"},
+	}
+
+	tests := []struct {
+		name     string
+		levels   []common.PriceLevel
+		side     futures.SideType
+		quantity float64
+		wantBps  float64
+		wantOK   bool
+	}{
+		{
+			name:     "Buy filled by the best ask",
+			levels:   asks,
+			side:     futures.SideTypeBuy,
+			quantity: 1,
+			wantBps:  0,
+			wantOK:   true,
+		},
+		{
+			name:     "Buy walks two levels",
+			levels:   asks,
+			side:     futures.SideTypeBuy,
+			quantity: 2,
+			wantBps:  5, // 均价 100.05
+			wantOK:   true,
+		},
+		{
+			name:     "Sell below mark is adverse",
+			levels:   bids,
+			side:     futures.SideTypeSell,
+			quantity: 2,
+			wantBps:  55, // 均价 99.45
+			wantOK:   true,
+		},
+		{
+			name:     "Book too thin",
+			levels:   bids,
+			side:     futures.SideTypeSell,
+			quantity: 3,
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bps, ok := estimateSlippageBps(tt.levels, tt.side, tt.quantity, 100)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && math.Abs(bps-tt.wantBps) > 1e-6 {
+				t.Errorf("bps = %.4f, want %.4f", bps, tt.wantBps)
+			}
+		})
+	}
+}
+
+func TestFillSlippageBps(t *testing.T) {
+	if got := fillSlippageBps(futures.SideTypeBuy, 100, 100.2); math.Abs(got-20) > 1e-6 {
+		t.Errorf("buy above reference = %.4f, want 20", got)
+	}
+	if got := fillSlippageBps(futures.SideTypeSell, 100, 100.2); math.Abs(got+20) > 1e-6 {
+		t.Errorf("sell above reference = %.4f, want -20", got)
+	}
+	if got := fillSlippageBps(futures.SideTypeBuy, 0, 100); got != 0 {
+		t.Errorf("missing reference = %.4f, want 0", got)
+	}
+}
+
+func TestSlippageCapPrice(t *testing.T) {
+	// 买单向下取整、卖单向上取整，保证不超过滑点上限
+	if got := slippageCapPrice(futures.SideTypeBuy, 50000, 30, 0.1); math.Abs(got-50150) > 1e-6 {
+		t.Errorf("buy cap = %.4f, want 50150", got)
+	}
+	if got := slippageCapPrice(futures.SideTypeBuy, 3001.23, 10, 0.01); got > 3001.23*1.001 {
+		t.Errorf("buy cap %.4f exceeds the limit", got)
+	}
+	if got := slippageCapPrice(futures.SideTypeSell, 3001.23, 10, 0.01); got < 3001.23*0.999 {
+		t.Errorf("sell cap %.4f exceeds the limit", got)
+	}
+	if got := formatTickPrice(2998.2287, 0.01); got != "2998.23" {
+		t.Errorf("formatTickPrice = %s, want 2998.23", got)
+	}
+	if got := formatTickPrice(0.123456, 0.0001); got != "0.1235" {
+		t.Errorf("formatTickPrice = %s, want 0.1235", got)
+	}
+}
@@ -0,0 +1,483 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Soak harness schedule, in simulated time
+// 浸泡测试调度（模拟时间）
+const (
+	soakStep             = 10 * time.Second // 价格步长 / Price step
+	soakMonitorInterval  = time.Minute      // 止损单状态检查与重试队列间隔 / Stop status checks and retry queue
+	soakDecisionInterval = 15 * time.Minute // 决策周期 / Decision cycle
+	soakMaxUnprotected   = 10 * time.Minute // 交易所持仓无止损单的最长时间 / Longest an exchange position may lack a stop
+)
+
+// soakClock is the simulated clock shared by the harness, the retry queue and the price cache
+// soakClock 是浸泡测试、重试队列和价格缓存共用的模拟时钟
+type soakClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *soakClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *soakClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// soakExchange serves the REST endpoints the paper executor still calls and fails them while an outage is injected
+// soakExchange 提供模拟盘仍会调用的 REST 接口，并在注入故障期间返回错误
+type soakExchange struct {
+	mu       sync.Mutex
+	prices   map[string]float64
+	down     bool
+	calls    int
+	failures int
+}
+
+func (x *soakExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.calls++
+	if x.down {
+		x.failures++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"code":-1008,"msg":"Server is currently overloaded with other requests."}`)
+		return
+	}
+	switch r.URL.Path {
+	case "/fapi/v2/ticker/price":
+		symbol := r.URL.Query().Get("symbol")
+		fmt.Fprintf(w, `{"symbol":"%s","price":"%.8f","time":0}`, symbol, x.prices[symbol])
+	case "/fapi/v1/income":
+		fmt.Fprint(w, `[]`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"code":-5000,"msg":"unexpected path %s"}`, r.URL.Path)
+	}
+}
+
+func (x *soakExchange) setPrice(symbol string, price float64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.prices[symbol] = price
+}
+
+func (x *soakExchange) setDown(down bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.down = down
+}
+
+// soakStats counts what happened during a soak run
+// soakStats 统计浸泡测试期间发生的事件
+type soakStats struct {
+	entries, restarts, crashRestarts          int
+	apiOutages, streamDrops, llmTimeouts      int
+	skippedCycles, stopUpdates, failedUpdates int
+	longestUnprotected                        time.Duration
+}
+
+// soakHarness runs the stop-loss loop against the paper executor with injected faults
+// soakHarness 在模拟盘上运行止损循环并注入故障
+type soakHarness struct {
+	t        *testing.T
+	ctx      context.Context
+	rng      *rand.Rand
+	clock    *soakClock
+	exchange *soakExchange
+	cfg      *config.Config
+	log      *logger.ColorLogger
+	db       *storage.Storage
+	executor *BinanceExecutor
+	prices   *PriceService
+	sm       *StopLossManager
+	symbols  []string
+	last     map[string]float64
+
+	apiDownUntil, streamDownUntil, llmDownUntil time.Time
+	botDownUntil                                time.Time // 机器人重启中，直到该时间 / Bot restarting until then
+	unprotectedSince                            map[string]time.Time
+	stats                                       soakStats
+}
+
+func newSoakHarness(t *testing.T, seed int64) *soakHarness {
+	// Retries back off in real time, so shorten them to keep simulated weeks fast
+	// 重试按真实时间退避，缩短等待以便快速模拟数周
+	minBackoff, maxBackoff := retryBackoffMin, retryBackoffMax
+	retryBackoffMin, retryBackoffMax = time.Millisecond, time.Millisecond
+	t.Cleanup(func() { retryBackoffMin, retryBackoffMax = minBackoff, maxBackoff })
+
+	exchange := &soakExchange{prices: make(map[string]float64)}
+	srv := httptest.NewServer(exchange)
+	t.Cleanup(srv.Close)
+
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "soak.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := &config.Config{
+		CryptoSymbols:       []string{"BTC/USDT", "ETH/USDT"},
+		PaperTrading:        true,
+		PaperInitialBalance: 10000,
+		PaperFeeRate:        0.0004,
+		BinanceLeverage:     5,
+	}
+	log := logger.NewColorLogger(false)
+	executor := NewBinanceExecutor(cfg, log)
+	executor.client.BaseURL = srv.URL
+
+	clock := &soakClock{now: time.Now().UTC().Truncate(time.Hour)}
+	prices := NewPriceService(executor, cfg.CryptoSymbols, log)
+	prices.now = clock.Now
+
+	h := &soakHarness{
+		t:                t,
+		ctx:              context.Background(),
+		rng:              rand.New(rand.NewSource(seed)),
+		clock:            clock,
+		exchange:         exchange,
+		cfg:              cfg,
+		log:              log,
+		db:               db,
+		executor:         executor,
+		prices:           prices,
+		symbols:          []string{"BTCUSDT", "ETHUSDT"},
+		last:             map[string]float64{"BTCUSDT": 60000, "ETHUSDT": 3000},
+		unprotectedSince: make(map[string]time.Time),
+	}
+	for symbol, price := range h.last {
+		exchange.setPrice(symbol, price)
+		prices.Publish(symbol, price, clock.Now())
+	}
+	h.sm = h.newStopLossManager()
+	return h
+}
+
+// newStopLossManager starts a stop-loss manager the way main does: restore from the database, then reconcile
+// newStopLossManager 按 main 的方式启动止损管理器：从数据库恢复，然后对账
+func (h *soakHarness) newStopLossManager() *StopLossManager {
+	sm := NewStopLossManager(h.cfg, h.executor, h.log, h.db)
+	sm.taskQueue.now = h.clock.Now
+
+	records, err := h.db.GetActivePositions()
+	if err != nil {
+		h.t.Fatalf("GetActivePositions failed: %v", err)
+	}
+	for _, r := range records {
+		sm.RegisterPosition(&Position{
+			ID:              r.ID,
+			Symbol:          r.Symbol,
+			Side:            r.Side,
+			EntryPrice:      r.EntryPrice,
+			EntryTime:       r.EntryTime,
+			Quantity:        r.Quantity,
+			Leverage:        r.Leverage,
+			InitialStopLoss: r.InitialStopLoss,
+			CurrentStopLoss: r.CurrentStopLoss,
+			StopLossType:    r.StopLossType,
+			StopLossOrderID: r.StopLossOrderID,
+		})
+	}
+	sm.ReconcileOnStartup(h.ctx, h.cfg.CryptoSymbols)
+	return sm
+}
+
+// crash stops the manager; a new one starts against the same exchange and database after 1-5 minutes of downtime
+// crash 停止止损管理器；停机 1-5 分钟后基于同一交易所和数据库启动新的管理器
+func (h *soakHarness) crash(now time.Time) {
+	h.sm.Stop()
+	h.botDownUntil = now.Add(time.Duration(1+h.rng.Intn(5)) * time.Minute)
+	h.stats.restarts++
+}
+
+// injectFaults opens new fault windows at random
+// injectFaults 随机开启新的故障窗口
+func (h *soakHarness) injectFaults(now time.Time) {
+	// On average one API outage an hour, one stream drop every 2 hours, one LLM timeout every 8 hours and one crash a day
+	// 平均每小时一次 API 故障，每 2 小时一次价格流断开，每 8 小时一次 LLM 超时，每天一次崩溃
+	perStep := func(every time.Duration) float64 { return float64(soakStep) / float64(every) }
+
+	if now.After(h.apiDownUntil) && h.rng.Float64() < perStep(time.Hour) {
+		h.apiDownUntil = now.Add(time.Duration(30+h.rng.Intn(150)) * time.Second)
+		h.stats.apiOutages++
+	}
+	if now.After(h.streamDownUntil) && h.rng.Float64() < perStep(2*time.Hour) {
+		h.streamDownUntil = now.Add(time.Duration(5+h.rng.Intn(40)) * time.Minute)
+		h.stats.streamDrops++
+	}
+	if now.After(h.llmDownUntil) && h.rng.Float64() < perStep(8*time.Hour) {
+		h.llmDownUntil = now.Add(time.Duration(15+h.rng.Intn(45)) * time.Minute)
+		h.stats.llmTimeouts++
+	}
+	h.exchange.setDown(now.Before(h.apiDownUntil))
+	if h.sm.ctx.Err() == nil && h.rng.Float64() < perStep(24*time.Hour) {
+		h.crash(now)
+	}
+}
+
+// movePrices advances every symbol by one step of a random walk with occasional gaps
+// movePrices 以带偶发跳空的随机游走推进每个交易对一步
+func (h *soakHarness) movePrices(now time.Time) {
+	for _, symbol := range h.symbols {
+		change := h.rng.NormFloat64() * 0.0005
+		if h.rng.Float64() < 0.0005 {
+			change += (h.rng.Float64()*2 - 1) * 0.03
+		}
+		price := h.last[symbol] * math.Exp(change)
+		h.last[symbol] = price
+
+		// The exchange sees every price; the bot only sees the stream while it is connected
+		// 交易所能看到每个价格；机器人只在价格流连接时收到推送
+		h.exchange.setPrice(symbol, price)
+		h.executor.paper.OnPrice(symbol, price)
+		if now.After(h.streamDownUntil) {
+			h.prices.Publish(symbol, price, now)
+		}
+	}
+}
+
+// decide runs one decision cycle: open new positions and trail the stops of open ones
+// decide 执行一个决策周期：开新仓并追踪已有持仓的止损
+func (h *soakHarness) decide(now time.Time) {
+	if now.Before(h.llmDownUntil) {
+		// An LLM timeout skips the whole cycle, so only the resting stops protect the positions
+		// LLM 超时跳过整个周期，持仓只由挂着的止损单保护
+		h.stats.skippedCycles++
+		return
+	}
+
+	for _, symbol := range h.symbols {
+		price := h.last[symbol]
+		if pos := h.sm.GetPosition(symbol); pos != nil {
+			newStop := price * 0.975
+			if pos.Side == "short" {
+				newStop = price * 1.025
+			}
+			if (pos.Side == "long" && newStop > pos.CurrentStopLoss) || (pos.Side == "short" && newStop < pos.CurrentStopLoss) {
+				h.stats.stopUpdates++
+				if err := h.sm.UpdateStopLoss(h.ctx, symbol, newStop, "浸泡测试追踪"); err != nil {
+					h.stats.failedUpdates++
+				}
+			}
+			continue
+		}
+		if h.executor.paper.Position(symbol) != nil || h.rng.Float64() > 0.3 {
+			continue
+		}
+		h.enter(now, symbol, price)
+	}
+}
+
+// enter opens a position the way the trading loop does: fill, register, save, then place the initial stop
+// enter 按交易循环的方式开仓：成交、注册、保存，然后下初始止损单
+func (h *soakHarness) enter(now time.Time, symbol string, price float64) {
+	action, side, stop := ActionBuy, "long", price*0.975
+	if h.rng.Intn(2) == 0 {
+		action, side, stop = ActionSell, "short", price*1.025
+	}
+	result := h.executor.ExecuteTrade(h.ctx, symbol, action, math.Round(1000/price*1e4)/1e4, "浸泡测试开仓")
+	if !result.Success {
+		return
+	}
+	h.stats.entries++
+
+	pos := &Position{
+		ID:              fmt.Sprintf("%s-%d", symbol, now.Unix()),
+		Symbol:          symbol,
+		Side:            side,
+		EntryPrice:      result.Price,
+		EntryTime:       now,
+		Quantity:        result.FilledQuantity(),
+		Leverage:        h.cfg.BinanceLeverage,
+		InitialStopLoss: stop,
+		CurrentStopLoss: stop,
+		StopLossType:    "fixed",
+	}
+	h.sm.RegisterPosition(pos)
+	if err := h.db.SavePosition(&storage.PositionRecord{
+		ID:              pos.ID,
+		Symbol:          pos.Symbol,
+		Side:            pos.Side,
+		EntryPrice:      pos.EntryPrice,
+		EntryTime:       pos.EntryTime,
+		Quantity:        pos.Quantity,
+		Leverage:        pos.Leverage,
+		InitialStopLoss: pos.InitialStopLoss,
+		CurrentStopLoss: pos.CurrentStopLoss,
+		StopLossType:    pos.StopLossType,
+		HighestPrice:    pos.EntryPrice,
+		CurrentPrice:    pos.EntryPrice,
+	}); err != nil {
+		h.t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	// Now and then the process dies before the initial stop is placed
+	// 偶尔进程在下初始止损单之前崩溃
+	if h.rng.Float64() < 0.05 {
+		h.stats.crashRestarts++
+		h.crash(now)
+		return
+	}
+	_ = h.sm.PlaceInitialStopLoss(h.ctx, pos)
+}
+
+// monitor checks the stop orders of managed positions and runs due retries
+// monitor 检查托管持仓的止损单状态并执行到期的重试任务
+func (h *soakHarness) monitor() {
+	for _, symbol := range h.symbols {
+		if h.sm.HasPosition(symbol) {
+			_ = h.sm.CheckStopLossOrderStatus(h.ctx, symbol)
+		}
+	}
+	h.sm.ProcessPendingTasks(h.ctx)
+}
+
+// checkInvariants fails the run when an exchange position has gone too long without a working stop or has several
+// checkInvariants 在交易所持仓长时间没有有效止损单或有多个止损单时使测试失败
+func (h *soakHarness) checkInvariants(now time.Time) {
+	for _, symbol := range h.symbols {
+		pos := h.executor.paper.Position(symbol)
+		stops := h.executor.paper.OpenOrders(symbol)
+		if len(stops) > 1 {
+			h.t.Fatalf("%s: %s has %d working stops", now.Format(time.RFC3339), symbol, len(stops))
+		}
+		if pos == nil || (len(stops) == 1 && soakStopCovers(stops[0], pos)) {
+			delete(h.unprotectedSince, symbol)
+			continue
+		}
+
+		since, ok := h.unprotectedSince[symbol]
+		if !ok {
+			h.unprotectedSince[symbol] = now
+			continue
+		}
+		gap := now.Sub(since)
+		if gap > h.stats.longestUnprotected {
+			h.stats.longestUnprotected = gap
+		}
+		if gap > soakMaxUnprotected {
+			h.t.Fatalf("%s: %s %s position %.4f unprotected since %s (%s)",
+				now.Format(time.RFC3339), symbol, pos.Side, pos.Size, since.Format(time.RFC3339), gap)
+		}
+	}
+}
+
+// soakStopCovers reports whether a stop order closes the whole position
+// soakStopCovers 判断止损单是否覆盖整个持仓
+func soakStopCovers(order *futures.Order, pos *Position) bool {
+	qty, _ := strconv.ParseFloat(order.OrigQuantity, 64)
+	closeSide := futures.SideTypeSell
+	if pos.Side == "short" {
+		closeSide = futures.SideTypeBuy
+	}
+	return order.Side == closeSide && qty >= pos.Size-1e-9
+}
+
+// run simulates duration of trading, one price step at a time
+// run 按价格步长模拟 duration 时长的交易
+func (h *soakHarness) run(duration time.Duration) {
+	end := h.clock.Now().Add(duration)
+	for step := 0; h.clock.Now().Before(end); step++ {
+		h.clock.Advance(soakStep)
+		now := h.clock.Now()
+
+		h.injectFaults(now)
+		h.movePrices(now)
+		h.checkInvariants(now)
+
+		// While the bot is down only the exchange runs
+		// 机器人停机期间只有交易所在运行
+		if h.sm.ctx.Err() != nil {
+			if now.Before(h.botDownUntil) {
+				continue
+			}
+			h.sm = h.newStopLossManager()
+		}
+		if step%int(soakMonitorInterval/soakStep) == 0 {
+			h.monitor()
+		}
+		if h.sm.ctx.Err() == nil && step%int(soakDecisionInterval/soakStep) == 0 {
+			h.decide(now)
+		}
+	}
+}
+
+// settle lifts every fault and lets the bot catch up with the exchange
+// settle 解除所有故障，让机器人与交易所状态一致
+func (h *soakHarness) settle() {
+	h.apiDownUntil, h.streamDownUntil, h.llmDownUntil = time.Time{}, time.Time{}, time.Time{}
+	h.exchange.setDown(false)
+	if h.sm.ctx.Err() != nil {
+		h.sm = h.newStopLossManager()
+	}
+	for i := 0; i < 30; i++ {
+		h.clock.Advance(soakMonitorInterval)
+		h.movePrices(h.clock.Now())
+		h.monitor()
+	}
+}
+
+// TestSoakStopLossLoop runs the stop-loss loop for simulated days with API errors, stream drops, LLM timeouts and restarts
+// TestSoakStopLossLoop 在 API 错误、价格流断开、LLM 超时和重启的情况下模拟运行止损循环数天
+// SOAK_DAYS sets the simulated length (default 2) and SOAK_SEED the random seed
+// SOAK_DAYS 设置模拟天数（默认 2），SOAK_SEED 设置随机种子
+func TestSoakStopLossLoop(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过浸泡测试（-short）")
+	}
+	days, seed := 2, int64(1)
+	if v, err := strconv.Atoi(os.Getenv("SOAK_DAYS")); err == nil && v > 0 {
+		days = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("SOAK_SEED"), 10, 64); err == nil {
+		seed = v
+	}
+
+	h := newSoakHarness(t, seed)
+	h.run(time.Duration(days) * 24 * time.Hour)
+	h.settle()
+
+	// Once the faults are gone the bot and the exchange agree on every position
+	// 故障解除后，机器人与交易所的持仓一致
+	for _, symbol := range h.symbols {
+		actual := h.executor.paper.Position(symbol)
+		managed := h.sm.GetPosition(symbol)
+		if (actual == nil) != (managed == nil) {
+			t.Errorf("%s: exchange position %+v but managed position %+v", symbol, actual, managed)
+		}
+	}
+
+	s := h.stats
+	t.Logf("%d 天（种子 %d）: 开仓 %d, 止损更新 %d（失败 %d）, 重启 %d（开仓中崩溃 %d）, API 故障 %d, 价格流断开 %d, LLM 超时 %d（跳过周期 %d）, 最长无保护 %s, REST 调用 %d（失败 %d）",
+		days, seed, s.entries, s.stopUpdates, s.failedUpdates, s.restarts, s.crashRestarts,
+		s.apiOutages, s.streamDrops, s.llmTimeouts, s.skippedCycles, s.longestUnprotected, h.exchange.calls, h.exchange.failures)
+	if s.entries == 0 || s.restarts == 0 || s.apiOutages == 0 {
+		t.Errorf("Expected entries, restarts and API outages during the run, got %+v", s)
+	}
+}
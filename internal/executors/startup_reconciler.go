@@ -0,0 +1,428 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/notify"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// adoptedOpenReason prefixes the open reason of every position taken over by startup reconciliation
+// adoptedOpenReason 是启动对账接管的持仓开仓理由的前缀
+const adoptedOpenReason = "启动对账："
+
+// IsAdopted reports whether the position was taken over from the exchange by startup reconciliation
+// IsAdopted 判断持仓是否由启动对账从交易所接管
+func (p *Position) IsAdopted() bool {
+	return strings.HasPrefix(p.OpenReason, adoptedOpenReason)
+}
+
+// StartupReconcileReport summarizes how the exchange state was merged into the stop-loss manager at startup
+// StartupReconcileReport 汇总启动时交易所状态与止损管理器的合并结果
+type StartupReconcileReport struct {
+	Restored    []string // 已确认或重新关联止损单的受管持仓 / Managed positions whose stop order was confirmed or re-linked
+	Reprotected []string // 交易所无止损单、已重新下单的受管持仓 / Managed positions whose missing stop was placed again
+	Closed      []string // 重启期间已平仓的受管持仓 / Managed positions closed while the bot was down
+	Adopted     []string // 接管的未知持仓（沿用交易所止损单或重新计算止损）/ Unknown positions adopted, behind their exchange stop or a freshly computed one
+	Unmanaged   []string // 无止损保护的未知持仓（仅告警）/ Unknown unprotected positions (flagged only)
+	Brackets    []string // 重建的括号单 / Rebuilt brackets
+	Failed      []string // 对账失败的交易对 / Symbols that could not be reconciled
+}
+
+// protectiveOrders splits a symbol's open orders into the stop and take-profit orders that close a position on side
+// protectiveOrders 将交易对的未成交订单拆分为平掉 side 方向持仓的止损单和止盈单
+func protectiveOrders(orders []*futures.Order, side string) (stops, takeProfits []*futures.Order) {
+	closeSide := futures.SideTypeSell
+	if side == "short" {
+		closeSide = futures.SideTypeBuy
+	}
+	for _, o := range orders {
+		if o.Side != closeSide || !(o.ReduceOnly || o.ClosePosition) {
+			continue
+		}
+		switch o.Type {
+		case futures.OrderTypeStopMarket, futures.OrderTypeStop, futures.OrderTypeTrailingStopMarket:
+			stops = append(stops, o)
+		case futures.OrderTypeTakeProfitMarket, futures.OrderTypeTakeProfit:
+			takeProfits = append(takeProfits, o)
+		}
+	}
+	return stops, takeProfits
+}
+
+// listOpenOrders returns the open orders of a symbol; the paper executor keeps no orders across process restarts
+// listOpenOrders 返回交易对的未成交订单；模拟盘的订单不会跨进程重启保留
+func (e *BinanceExecutor) listOpenOrders(ctx context.Context, binanceSymbol string) ([]*futures.Order, error) {
+	if e.paper != nil {
+		return e.paper.OpenOrders(binanceSymbol), nil
+	}
+	var orders []*futures.Order
+	err := e.withRetry(func() error {
+		var err error
+		orders, err = e.client.NewListOpenOrdersService().Symbol(binanceSymbol).Do(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+	return orders, nil
+}
+
+// restoreBracket links a resting stop and take-profit pair again so SyncBracket cancels the survivor
+// restoreBracket 重新关联挂单中的止损单和止盈单，使 SyncBracket 能撤销剩余的一条腿
+func (e *BinanceExecutor) restoreBracket(binanceSymbol, side string, quantity float64, stop, takeProfit *futures.Order) {
+	stopPrice, _ := strconv.ParseFloat(stop.StopPrice, 64)
+	tpPrice, _ := strconv.ParseFloat(takeProfit.StopPrice, 64)
+
+	e.brackets.mu.Lock()
+	defer e.brackets.mu.Unlock()
+	e.brackets.brackets[binanceSymbol] = &BracketOrder{
+		Symbol:            binanceSymbol,
+		Side:              side,
+		Quantity:          quantity,
+		StopLossPrice:     stopPrice,
+		TakeProfitPrice:   tpPrice,
+		StopLossOrderID:   stop.OrderID,
+		TakeProfitOrderID: takeProfit.OrderID,
+		Status:            BracketStatusActive,
+		CreatedAt:         time.UnixMilli(stop.Time),
+	}
+}
+
+// ReconcileOnStartup merges the exchange's positions and open orders into the positions restored from the database
+// ReconcileOnStartup 将交易所的持仓和未成交订单与从数据库恢复的持仓合并
+//
+// Call it after the database positions are registered. Positions closed while the bot was down are closed locally,
+// stop orders are re-linked or placed again, and unknown positions are adopted: behind their existing stop, or with a
+// fresh stop from the trailing stop calculator when STARTUP_ADOPT_POSITIONS is on. Otherwise they are flagged for the user.
+// 应在注册数据库持仓之后调用。停机期间已平仓的持仓在本地关闭，止损单重新关联或重新下达；
+// 未知持仓会被接管：沿用已有止损单，或在启用 STARTUP_ADOPT_POSITIONS 时由追踪止损计算器计算新止损，否则告警提示用户。
+func (sm *StopLossManager) ReconcileOnStartup(ctx context.Context, symbols []string) *StartupReconcileReport {
+	report := &StartupReconcileReport{}
+	for _, symbol := range symbols {
+		binanceSymbol := sm.config.GetBinanceSymbolFor(symbol)
+		if err := sm.reconcileSymbolOnStartup(ctx, binanceSymbol, report); err != nil {
+			sm.logger.Error(fmt.Sprintf("❌【%s】启动对账失败: %v", binanceSymbol, err))
+			report.Failed = append(report.Failed, binanceSymbol)
+		}
+	}
+
+	sm.logger.Info(fmt.Sprintf("🔄 启动对账完成: 恢复 %d, 重新保护 %d, 已平仓 %d, 接管 %d, 未受管 %d, 括号单 %d, 失败 %d",
+		len(report.Restored), len(report.Reprotected), len(report.Closed), len(report.Adopted),
+		len(report.Unmanaged), len(report.Brackets), len(report.Failed)))
+	return report
+}
+
+// reconcileSymbolOnStartup reconciles one symbol, see ReconcileOnStartup
+// reconcileSymbolOnStartup 对单个交易对进行启动对账，参见 ReconcileOnStartup
+func (sm *StopLossManager) reconcileSymbolOnStartup(ctx context.Context, binanceSymbol string, report *StartupReconcileReport) error {
+	actual, err := sm.executor.GetCurrentPosition(ctx, binanceSymbol)
+	if err != nil {
+		return fmt.Errorf("failed to get position: %w", err)
+	}
+	orders, err := sm.executor.listOpenOrders(ctx, binanceSymbol)
+	if err != nil {
+		return err
+	}
+	managed := sm.GetPosition(binanceSymbol)
+
+	if actual == nil {
+		if managed != nil {
+			if err := sm.reconcilePosition(ctx, binanceSymbol, 0, "停机期间已平仓（启动对账）"); err != nil {
+				return err
+			}
+			report.Closed = append(report.Closed, binanceSymbol)
+		}
+		sm.cancelOrphanProtectiveOrders(ctx, binanceSymbol, orders)
+		sm.cancelOrphanEntryLadder(ctx, binanceSymbol, orders)
+		return nil
+	}
+
+	stops, takeProfits := protectiveOrders(orders, actual.Side)
+	if len(stops) == 1 && len(takeProfits) == 1 {
+		sm.executor.restoreBracket(binanceSymbol, actual.Side, actual.Size, stops[0], takeProfits[0])
+		report.Brackets = append(report.Brackets, binanceSymbol)
+		sm.logger.Info(fmt.Sprintf("🔗【%s】已重建括号单: 止损 %d / 止盈 %d", binanceSymbol, stops[0].OrderID, takeProfits[0].OrderID))
+	}
+
+	if managed == nil {
+		return sm.adoptOnStartup(ctx, binanceSymbol, actual, stops, report)
+	}
+
+	// Side and quantity follow the exchange
+	// 方向和数量以交易所为准
+	if err := sm.reconcilePosition(ctx, binanceSymbol, 0, ""); err != nil {
+		return err
+	}
+	sm.executor.restoreEntryLadder(binanceSymbol, actual.Side, entryLadderOrders(orders, actual.Side, sm.executor.instanceTag))
+
+	if stop := findOrder(stops, managed.StopLossOrderID); stop != nil {
+		report.Restored = append(report.Restored, binanceSymbol)
+		sm.logger.Success(fmt.Sprintf("✅【%s】止损单 %s 仍在挂单，持仓已恢复", binanceSymbol, managed.StopLossOrderID))
+		return nil
+	}
+
+	if len(stops) > 0 {
+		// The stop was replaced while the database still had the old ID, e.g. a crash mid-update
+		// 止损单已被替换但数据库仍是旧 ID，例如更新中途崩溃
+		sm.mu.Lock()
+		managed.StopLossOrderID = strconv.FormatInt(stops[0].OrderID, 10)
+		if price, _ := strconv.ParseFloat(stops[0].StopPrice, 64); price > 0 {
+			managed.CurrentStopLoss = price
+		}
+		if stops[0].Type == futures.OrderTypeTrailingStopMarket {
+			managed.StopLossType = StopLossTypeNativeTrailing
+		}
+		sm.mu.Unlock()
+		sm.persistStopLossOrder(managed)
+		report.Restored = append(report.Restored, binanceSymbol)
+		sm.logger.Warning(fmt.Sprintf("🔗【%s】已重新关联交易所止损单 %s @ %.2f", binanceSymbol, managed.StopLossOrderID, managed.CurrentStopLoss))
+		return nil
+	}
+
+	if managed.CurrentStopLoss <= 0 {
+		sm.flagUnprotected(binanceSymbol, "交易所无止损单，本地也没有止损价", report)
+		return nil
+	}
+	if err := sm.placeStopLossOrder(ctx, managed, managed.CurrentStopLoss); err != nil {
+		sm.flagUnprotected(binanceSymbol, fmt.Sprintf("交易所无止损单，重新下单失败: %v", err), report)
+		return nil
+	}
+	sm.persistStopLossOrder(managed)
+	report.Reprotected = append(report.Reprotected, binanceSymbol)
+	sm.notifier.Notify(notify.SeverityCritical, binanceSymbol, fmt.Sprintf("🛡️ 启动对账发现止损单缺失，已按 %.2f 重新下单", managed.CurrentStopLoss))
+	return nil
+}
+
+// adoptOnStartup takes over an unknown position, keeping its exchange stop or computing a fresh one, and flags it otherwise
+// adoptOnStartup 接管未知持仓：沿用交易所止损单或重新计算止损，无法接管时告警
+func (sm *StopLossManager) adoptOnStartup(ctx context.Context, binanceSymbol string, actual *Position, stops []*futures.Order, report *StartupReconcileReport) error {
+	if len(stops) == 0 {
+		if !sm.config.StartupAdoptPositions {
+			sm.flagUnprotected(binanceSymbol, fmt.Sprintf("发现未受管理的 %s 持仓 %.4f @ %.2f，且没有止损单", actual.Side, actual.Size, actual.EntryPrice), report)
+			return nil
+		}
+		sm.adoptUnprotected(ctx, binanceSymbol, actual, report)
+		return nil
+	}
+
+	stopPrice, _ := strconv.ParseFloat(stops[0].StopPrice, 64)
+	pos := newAdoptedPosition(binanceSymbol, actual, stopPrice, adoptedOpenReason+"从交易所止损单接管")
+	pos.StopLossOrderID = strconv.FormatInt(stops[0].OrderID, 10)
+	if stops[0].Type == futures.OrderTypeTrailingStopMarket {
+		pos.StopLossType = StopLossTypeNativeTrailing
+	}
+	sm.RegisterPosition(pos)
+	sm.saveAdoptedPosition(pos)
+
+	report.Adopted = append(report.Adopted, binanceSymbol)
+	sm.logger.Warning(fmt.Sprintf("🔄【%s】已接管未知持仓 %s %.4f @ %.2f（止损单 %s @ %.2f）",
+		binanceSymbol, pos.Side, pos.Quantity, pos.EntryPrice, pos.StopLossOrderID, stopPrice))
+	sm.notifier.Notify(notify.SeverityCritical, binanceSymbol, fmt.Sprintf("🔄 启动对账接管了未知持仓 %s %.4f @ %.2f，止损 %.2f",
+		pos.Side, pos.Quantity, pos.EntryPrice, stopPrice))
+	return nil
+}
+
+// adoptUnprotected takes over an unknown position without a stop, asking the trailing stop calculator for a fresh stop
+// adoptUnprotected 接管没有止损单的未知持仓，由追踪止损计算器计算新止损
+func (sm *StopLossManager) adoptUnprotected(ctx context.Context, binanceSymbol string, actual *Position, report *StartupReconcileReport) {
+	found := fmt.Sprintf("发现未受管理的 %s 持仓 %.4f @ %.2f，且没有止损单", actual.Side, actual.Size, actual.EntryPrice)
+
+	markPrice, err := sm.executor.GetCurrentPrice(ctx, binanceSymbol)
+	if err != nil {
+		sm.flagUnprotected(binanceSymbol, fmt.Sprintf("%s，获取当前价格失败: %v", found, err), report)
+		return
+	}
+	bars, err := dataflows.NewMarketData(sm.config).GetOHLCV(ctx, binanceSymbol, sm.config.CryptoTimeframe, sm.config.CryptoLookbackDays)
+	if err != nil {
+		sm.flagUnprotected(binanceSymbol, fmt.Sprintf("%s，获取 K 线失败: %v", found, err), report)
+		return
+	}
+	atr := latestATR(bars)
+	stopPrice, err := adoptionStop(sm.calculator, binanceSymbol, actual.Side, markPrice, atr)
+	if err != nil {
+		sm.flagUnprotected(binanceSymbol, fmt.Sprintf("%s，无法计算止损: %v", found, err), report)
+		return
+	}
+
+	pos := newAdoptedPosition(binanceSymbol, actual, stopPrice, adoptedOpenReason+"接管无止损持仓，止损由追踪止损计算器重新计算")
+	pos.ATR = atr
+	sm.RegisterPosition(pos)
+
+	// Trailing continues from the current price, not from the entry the bot never saw
+	// 追踪从当前价继续，而不是从机器人未参与的入场价开始
+	sm.mu.Lock()
+	pos.CurrentPrice = markPrice
+	pos.HighestPrice = markPrice
+	sm.mu.Unlock()
+
+	placeErr := sm.placeStopLossOrder(ctx, pos, stopPrice)
+	sm.saveAdoptedPosition(pos)
+	if placeErr != nil {
+		sm.enqueueStopLossRetry(pos, stopPrice, "接管持仓止损下单失败重试", placeErr)
+		sm.flagUnprotected(binanceSymbol, fmt.Sprintf("已接管 %s 持仓 %.4f @ %.2f，但止损单 %.2f 下单失败: %v",
+			pos.Side, pos.Quantity, pos.EntryPrice, stopPrice, placeErr), report)
+		return
+	}
+
+	report.Adopted = append(report.Adopted, binanceSymbol)
+	sm.logger.Warning(fmt.Sprintf("🔄【%s】已接管无止损持仓 %s %.4f @ %.2f，新止损 %.2f（当前价 %.2f，ATR %.2f）",
+		binanceSymbol, pos.Side, pos.Quantity, pos.EntryPrice, stopPrice, markPrice, atr))
+	sm.notifier.Notify(notify.SeverityCritical, binanceSymbol, fmt.Sprintf("🔄 启动对账接管了无止损持仓 %s %.4f @ %.2f，已下达止损 %.2f",
+		pos.Side, pos.Quantity, pos.EntryPrice, stopPrice))
+}
+
+// adoptionStop asks the trailing stop calculator for a stop trailing the current price, as if it were the best price so far
+// adoptionStop 让追踪止损计算器以当前价作为迄今最优价计算追踪止损
+func adoptionStop(calc *TrailingStopCalculator, symbol, side string, markPrice, atr float64) (float64, error) {
+	if markPrice <= 0 {
+		return 0, fmt.Errorf("当前价格无效: %.4f", markPrice)
+	}
+	if atr <= 0 || math.IsNaN(atr) {
+		return 0, fmt.Errorf("ATR 不可用")
+	}
+	stop := calc.CalculateTrailingStop(symbol, markPrice, atr, side)
+	if stop <= 0 {
+		return 0, fmt.Errorf("计算出的止损价 %.4f 无效", stop)
+	}
+	return stop, nil
+}
+
+// latestATR returns the last ATR(14) of the bars, 0 when there are too few
+// latestATR 返回 K 线最新的 ATR(14)，K 线不足时返回 0
+func latestATR(bars []dataflows.OHLCV) float64 {
+	if len(bars) == 0 {
+		return 0
+	}
+	atr := dataflows.CalculateIndicators(bars).ATR_14
+	if n := len(atr); n > 0 && !math.IsNaN(atr[n-1]) {
+		return atr[n-1]
+	}
+	return 0
+}
+
+// newAdoptedPosition builds the managed position for an exchange position the bot did not open
+// newAdoptedPosition 为非机器人开立的交易所持仓构建受管持仓
+func newAdoptedPosition(binanceSymbol string, actual *Position, stopPrice float64, reason string) *Position {
+	return &Position{
+		ID:              fmt.Sprintf("%s-%d", binanceSymbol, time.Now().Unix()),
+		Symbol:          binanceSymbol,
+		Side:            actual.Side,
+		EntryPrice:      actual.EntryPrice,
+		EntryTime:       time.Now(),
+		Quantity:        actual.Size,
+		Size:            actual.Size,
+		Leverage:        actual.Leverage,
+		InitialStopLoss: stopPrice,
+		CurrentStopLoss: stopPrice,
+		OpenReason:      reason,
+	}
+}
+
+// saveAdoptedPosition stores an adopted position so it survives the next restart and shows in the web UI
+// saveAdoptedPosition 保存接管的持仓，使其在下次重启后仍被管理并显示在网页中
+func (sm *StopLossManager) saveAdoptedPosition(pos *Position) {
+	if sm.storage == nil {
+		return
+	}
+	record := &storage.PositionRecord{
+		ID:              pos.ID,
+		Symbol:          pos.Symbol,
+		Side:            pos.Side,
+		EntryPrice:      pos.EntryPrice,
+		EntryTime:       pos.EntryTime,
+		Quantity:        pos.Quantity,
+		Leverage:        pos.Leverage,
+		InitialStopLoss: pos.InitialStopLoss,
+		CurrentStopLoss: pos.CurrentStopLoss,
+		StopLossType:    pos.StopLossType,
+		HighestPrice:    pos.HighestPrice,
+		CurrentPrice:    pos.CurrentPrice,
+		OpenReason:      pos.OpenReason,
+		ATR:             pos.ATR,
+		StopLossOrderID: pos.StopLossOrderID,
+	}
+	if err := sm.storage.SavePosition(record); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】保存接管的持仓失败: %v", pos.Symbol, err))
+	}
+}
+
+// flagUnprotected reports a position the bot cannot protect on its own
+// flagUnprotected 报告机器人无法自行保护的持仓
+func (sm *StopLossManager) flagUnprotected(binanceSymbol, reason string, report *StartupReconcileReport) {
+	report.Unmanaged = append(report.Unmanaged, binanceSymbol)
+	sm.logger.Error(fmt.Sprintf("🚨【%s】%s，请人工处理", binanceSymbol, reason))
+	sm.notifier.Notify(notify.SeverityCritical, binanceSymbol, fmt.Sprintf("🚨 %s，请人工处理", reason))
+}
+
+// cancelOrphanProtectiveOrders cancels reduce-only orders left behind by a position that no longer exists
+// cancelOrphanProtectiveOrders 撤销持仓已不存在时遗留的只减仓挂单
+func (sm *StopLossManager) cancelOrphanProtectiveOrders(ctx context.Context, binanceSymbol string, orders []*futures.Order) {
+	var cancelled []string
+	for _, o := range orders {
+		if !(o.ReduceOnly || o.ClosePosition) {
+			continue
+		}
+		if _, err := sm.executor.cancelOrder(ctx, binanceSymbol, o.OrderID); err != nil && !isOrderNotFoundError(err) {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】撤销遗留订单 %d 失败: %v", binanceSymbol, o.OrderID, err))
+			continue
+		}
+		cancelled = append(cancelled, fmt.Sprintf("%s %d", o.Type, o.OrderID))
+	}
+	if len(cancelled) > 0 {
+		sm.logger.Warning(fmt.Sprintf("🧹【%s】持仓已不存在，已撤销遗留订单: %s", binanceSymbol, strings.Join(cancelled, ", ")))
+	}
+}
+
+// cancelOrphanEntryLadder cancels the bot's resting ladder rungs of a symbol that no longer has a position
+// cancelOrphanEntryLadder 撤销已无持仓的交易对上本实例遗留的阶梯挂单
+func (sm *StopLossManager) cancelOrphanEntryLadder(ctx context.Context, binanceSymbol string, orders []*futures.Order) {
+	rungs := append(entryLadderOrders(orders, "long", sm.executor.instanceTag), entryLadderOrders(orders, "short", sm.executor.instanceTag)...)
+	var cancelled []string
+	for _, o := range rungs {
+		if err := sm.executor.cancelOrderByID(ctx, binanceSymbol, o.OrderID); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】撤销遗留阶梯挂单 %d 失败: %v", binanceSymbol, o.OrderID, err))
+			continue
+		}
+		cancelled = append(cancelled, fmt.Sprintf("%d", o.OrderID))
+	}
+	if len(cancelled) > 0 {
+		sm.logger.Warning(fmt.Sprintf("🧹【%s】持仓已不存在，已撤销遗留阶梯挂单: %s", binanceSymbol, strings.Join(cancelled, ", ")))
+	}
+}
+
+// persistStopLossOrder saves the stop price and order ID of a position to the database
+// persistStopLossOrder 将持仓的止损价和止损单 ID 保存到数据库
+func (sm *StopLossManager) persistStopLossOrder(pos *Position) {
+	if sm.storage == nil {
+		return
+	}
+	record, err := sm.storage.GetPositionByID(pos.ID)
+	if err != nil || record == nil {
+		return
+	}
+	record.CurrentStopLoss = pos.CurrentStopLoss
+	record.StopLossOrderID = pos.StopLossOrderID
+	record.StopLossType = pos.StopLossType
+	if err := sm.storage.UpdatePosition(record); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】同步止损单到数据库失败: %v", pos.Symbol, err))
+	}
+}
+
+// findOrder returns the order whose ID matches id, or nil
+// findOrder 返回 ID 与 id 匹配的订单，没有时返回 nil
+func findOrder(orders []*futures.Order, id string) *futures.Order {
+	for _, o := range orders {
+		if strconv.FormatInt(o.OrderID, 10) == id {
+			return o
+		}
+	}
+	return nil
+}
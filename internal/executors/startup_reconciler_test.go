@@ -0,0 +1,96 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestProtectiveOrders(t *testing.T) {
+	orders := []*futures.Order{
+		{OrderID: 1, Side: futures.SideTypeSell, Type: futures.OrderTypeStopMarket, ReduceOnly: true, StopPrice: "49000"},
+		{OrderID: 2, Side: futures.SideTypeSell, Type: futures.OrderTypeTakeProfitMarket, ClosePosition: true, StopPrice: "55000"},
+		{OrderID: 3, Side: futures.SideTypeBuy, Type: futures.OrderTypeStopMarket, ReduceOnly: true, StopPrice: "51000"},
+		{OrderID: 4, Side: futures.SideTypeSell, Type: futures.OrderTypeLimit, Price: "52000"},
+		{OrderID: 5, Side: futures.SideTypeSell, Type: futures.OrderTypeTrailingStopMarket, ReduceOnly: true},
+		{OrderID: 6, Side: futures.SideTypeSell, Type: futures.OrderTypeStopMarket, StopPrice: "48000"},
+	}
+
+	stops, takeProfits := protectiveOrders(orders, "long")
+	if len(stops) != 2 || stops[0].OrderID != 1 || stops[1].OrderID != 5 {
+		t.Errorf("Expected stops 1 and 5 for a long, got %+v", stops)
+	}
+	if len(takeProfits) != 1 || takeProfits[0].OrderID != 2 {
+		t.Errorf("Expected take-profit 2 for a long, got %+v", takeProfits)
+	}
+
+	// Only buy-side reduce-only orders close a short
+	// 只有买方向的只减仓单会平掉空仓
+	stops, takeProfits = protectiveOrders(orders, "short")
+	if len(stops) != 1 || stops[0].OrderID != 3 || len(takeProfits) != 0 {
+		t.Errorf("Expected only stop 3 for a short, got stops %+v, take-profits %+v", stops, takeProfits)
+	}
+}
+
+func TestFindOrder(t *testing.T) {
+	orders := []*futures.Order{{OrderID: 10}, {OrderID: 20}}
+	if o := findOrder(orders, "20"); o == nil || o.OrderID != 20 {
+		t.Errorf("Expected order 20, got %+v", o)
+	}
+	if o := findOrder(orders, ""); o != nil {
+		t.Errorf("Expected no match for an empty ID, got %+v", o)
+	}
+}
+
+func TestRestoreBracket(t *testing.T) {
+	e := &BinanceExecutor{brackets: &bracketRegistry{brackets: make(map[string]*BracketOrder)}}
+	stop := &futures.Order{OrderID: 1, StopPrice: "49000", Time: 1700000000000}
+	tp := &futures.Order{OrderID: 2, StopPrice: "55000"}
+
+	e.restoreBracket("BTCUSDT", "long", 0.1, stop, tp)
+
+	bracket := e.brackets.brackets["BTCUSDT"]
+	if bracket == nil || bracket.Status != BracketStatusActive {
+		t.Fatalf("Expected an active bracket, got %+v", bracket)
+	}
+	if bracket.StopLossOrderID != 1 || bracket.TakeProfitOrderID != 2 || bracket.StopLossPrice != 49000 || bracket.TakeProfitPrice != 55000 {
+		t.Errorf("Unexpected bracket %+v", bracket)
+	}
+}
+
+func TestAdoptionStop(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	multiplier := calc.GetConfig("BTCUSDT").TrailingATRMultiplier
+
+	// The stop trails the current price, so an adopted position in a loss is not stopped out at once
+	// 止损从当前价追踪，因此亏损中的接管持仓不会被立即止损
+	stop, err := adoptionStop(calc, "BTCUSDT", "long", 50000, 500)
+	if err != nil || stop != 50000-multiplier*500 {
+		t.Errorf("Expected long stop %.2f, got %.2f (%v)", 50000-multiplier*500, stop, err)
+	}
+	stop, err = adoptionStop(calc, "BTCUSDT", "short", 50000, 500)
+	if err != nil || stop != 50000+multiplier*500 {
+		t.Errorf("Expected short stop %.2f, got %.2f (%v)", 50000+multiplier*500, stop, err)
+	}
+
+	if _, err := adoptionStop(calc, "BTCUSDT", "long", 50000, 0); err == nil {
+		t.Error("Expected an error without ATR")
+	}
+	if _, err := adoptionStop(calc, "BTCUSDT", "long", 0, 500); err == nil {
+		t.Error("Expected an error without a current price")
+	}
+	if _, err := adoptionStop(calc, "BTCUSDT", "long", 100, 500); err == nil {
+		t.Error("Expected an error when the stop would fall below zero")
+	}
+}
+
+func TestIsAdopted(t *testing.T) {
+	actual := &Position{Side: "long", Size: 0.01, EntryPrice: 50000, Leverage: 5}
+	pos := newAdoptedPosition("BTCUSDT", actual, 49000, adoptedOpenReason+"接管无止损持仓")
+	if !pos.IsAdopted() || pos.Quantity != 0.01 || pos.InitialStopLoss != 49000 || pos.CurrentStopLoss != 49000 {
+		t.Errorf("Unexpected adopted position: %+v", pos)
+	}
+	if (&Position{OpenReason: "LLM 决策开多"}).IsAdopted() {
+		t.Error("A position opened by the bot must not count as adopted")
+	}
+}
@@ -0,0 +1,32 @@
+package executors
+
+// Stop monitoring modes (STOP_MONITORING_MODE)
+// 止损监控方式（STOP_MONITORING_MODE）
+const (
+	StopMonitoringExchange = "exchange" // 止损单挂在交易所 / Stop rests on the exchange as a STOP_MARKET order
+	StopMonitoringLocal    = "local"    // 仅由本地监控执行 / Enforced by the local monitor only
+	StopMonitoringBoth     = "both"     // 交易所止损单 + 本地监控 / Exchange stop plus local monitor
+)
+
+// exchangeStop reports whether a symbol's stop should rest on the exchange
+// exchangeStop 判断交易对的止损是否应挂在交易所
+func (sm *StopLossManager) exchangeStop(symbol string) bool {
+	return sm.config.StopMonitoringModeFor(symbol) != StopMonitoringLocal
+}
+
+// localStop reports whether the local monitor enforces a symbol's stop
+// localStop 判断交易对的止损是否由本地监控执行
+func (sm *StopLossManager) localStop(symbol string) bool {
+	return sm.config.StopMonitoringModeFor(symbol) != StopMonitoringExchange
+}
+
+// UsesLocalStopMonitoring reports whether any configured symbol needs the local stop monitor running
+// UsesLocalStopMonitoring 判断是否有交易对需要运行本地止损监控
+func (sm *StopLossManager) UsesLocalStopMonitoring() bool {
+	for _, symbol := range sm.config.CryptoSymbols {
+		if sm.localStop(symbol) {
+			return true
+		}
+	}
+	return false
+}
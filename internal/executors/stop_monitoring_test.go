@@ -0,0 +1,38 @@
+package executors
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLocalStopPlacesNoExchangeOrder(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	sm.config.StopMonitoringModes = map[string]string{"BTCUSDT": StopMonitoringLocal}
+	pos := newBracketTestPosition()
+
+	if err := sm.PlaceInitialStopLoss(context.Background(), pos); err != nil {
+		t.Fatalf("PlaceInitialStopLoss failed: %v", err)
+	}
+	if pos.StopLossOrderID != "" || len(exchange.placed) != 0 {
+		t.Errorf("Expected no exchange orders for a local stop, got stop %q placed %v", pos.StopLossOrderID, exchange.placed)
+	}
+	if !sm.UsesLocalStopMonitoring() {
+		t.Error("Expected the local monitor to be needed")
+	}
+}
+
+func TestExecuteStopLossCancelsExchangeStopFirst(t *testing.T) {
+	exchange := &bracketExchange{placed: make(map[int64]string)}
+	sm := newBracketTestManager(t, exchange)
+	sm.config.StopMonitoringMode = StopMonitoringBoth
+	pos := newBracketTestPosition()
+	pos.StopLossOrderID = "5"
+
+	// The fake exchange cannot fill the close, but the resting stop must already be gone
+	// 模拟交易所无法成交平仓单，但挂着的止损单必须已被撤销
+	_ = sm.executeStopLoss(context.Background(), pos)
+	if len(exchange.cancelled) != 1 || exchange.cancelled[0] != 5 || pos.StopLossOrderID != "" {
+		t.Errorf("Expected stop order 5 cancelled before closing, got cancelled %v stop %q", exchange.cancelled, pos.StopLossOrderID)
+	}
+}
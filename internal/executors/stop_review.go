@@ -0,0 +1,135 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/notify"
+)
+
+// maxLLMSuggestions bounds the review notes kept on a position
+// maxLLMSuggestions 限制每个持仓保留的复查记录数量
+const maxLLMSuggestions = 20
+
+// validateTakeProfitTarget checks a new target for the next pending take-profit level
+// validateTakeProfitTarget 校验下一个待执行止盈级别的新目标价
+//
+// The target must sit on the profitable side of both the current price and the entry, and must not pass the level after it.
+// 目标价必须位于当前价和入场价的盈利一侧，且不得越过其后的止盈级别。
+func validateTakeProfitTarget(pos *Position, currentPrice, target float64) (*TakeProfitLevel, error) {
+	if pos.TakeProfitConfig == nil || !pos.TakeProfitConfig.Enabled {
+		return nil, fmt.Errorf("持仓未启用分批止盈")
+	}
+
+	var next, after *TakeProfitLevel
+	for _, level := range pos.TakeProfitConfig.Levels {
+		if level.Executed {
+			continue
+		}
+		if next == nil {
+			next = level
+		} else {
+			after = level
+			break
+		}
+	}
+	if next == nil {
+		return nil, fmt.Errorf("所有止盈级别均已执行")
+	}
+
+	if pos.Side == "long" {
+		if target <= currentPrice || target <= pos.EntryPrice {
+			return nil, fmt.Errorf("多仓止盈 %.4f 必须高于当前价 %.4f 和入场价 %.4f", target, currentPrice, pos.EntryPrice)
+		}
+		if after != nil && target >= after.TargetPrice {
+			return nil, fmt.Errorf("止盈 %.4f 越过了级别 %d 的目标价 %.4f", target, after.Level, after.TargetPrice)
+		}
+	} else {
+		if target >= currentPrice || target >= pos.EntryPrice {
+			return nil, fmt.Errorf("空仓止盈 %.4f 必须低于当前价 %.4f 和入场价 %.4f", target, currentPrice, pos.EntryPrice)
+		}
+		if after != nil && target <= after.TargetPrice {
+			return nil, fmt.Errorf("止盈 %.4f 越过了级别 %d 的目标价 %.4f", target, after.Level, after.TargetPrice)
+		}
+	}
+	return next, nil
+}
+
+// ReviewStopLoss reads a position's current stop under the manager lock and reports whether moving it to newStop
+// clears the update threshold UpdateStopLoss applies; exists is false when the symbol has no position
+// ReviewStopLoss 在管理器锁内读取持仓的当前止损，并判断移到 newStop 是否达到 UpdateStopLoss 的更新阈值；无持仓时 exists 为 false
+func (sm *StopLossManager) ReviewStopLoss(symbol string, newStop float64) (current float64, significant, exists bool) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		return 0, false, false
+	}
+	_, _, below := sm.belowUpdateThreshold(normalizedSymbol, pos.CurrentStopLoss, newStop)
+	return pos.CurrentStopLoss, !below, true
+}
+
+// UpdateTakeProfitTarget moves the next pending take-profit level of a position (called by the quick LLM stop review)
+// UpdateTakeProfitTarget 调整持仓下一个待执行止盈级别的目标价（由快速 LLM 止损复查调用）
+func (sm *StopLossManager) UpdateTakeProfitTarget(ctx context.Context, symbol string, target float64, reason string) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	if !sm.executor.ManagesSymbol(symbol) {
+		return fmt.Errorf("【%s】由其他实例管理，跳过止盈更新", normalizedSymbol)
+	}
+
+	currentPrice, err := sm.executor.GetCurrentPrice(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("获取当前价格失败: %w", err)
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		return fmt.Errorf("持仓 %s 不存在", symbol)
+	}
+
+	level, err := validateTakeProfitTarget(pos, currentPrice, target)
+	if err != nil {
+		return err
+	}
+
+	// A resting bracket take-profit sits at the old target, so it is cancelled and the level monitored locally
+	// 挂在交易所的括号单止盈仍在旧目标价，撤销后该级别改为本地监控
+	if err := sm.releaseTakeProfitOrder(ctx, pos, level); err != nil {
+		return err
+	}
+
+	oldTarget := level.TargetPrice
+	level.TargetPrice = target
+	sm.saveTakeProfitLevels(pos)
+	sm.logger.Success(fmt.Sprintf("【%s】✅ LLM 止盈级别 %d 已更新: %.2f → %.2f (%s)",
+		pos.Symbol, level.Level, oldTarget, target, reason))
+	sm.notifier.Notify(notify.SeverityInfo, pos.Symbol, fmt.Sprintf("止盈级别 %d: %.2f → %.2f（%s）",
+		level.Level, oldTarget, target, reason))
+	return nil
+}
+
+// RecordLLMReview stamps a position with the time and note of its latest LLM review
+// RecordLLMReview 记录持仓最近一次 LLM 复查的时间和结论
+func (sm *StopLossManager) RecordLLMReview(symbol, note string) {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		return
+	}
+	pos.LastLLMReview = time.Now()
+	pos.LLMSuggestions = append(pos.LLMSuggestions, note)
+	if len(pos.LLMSuggestions) > maxLLMSuggestions {
+		pos.LLMSuggestions = pos.LLMSuggestions[len(pos.LLMSuggestions)-maxLLMSuggestions:]
+	}
+}
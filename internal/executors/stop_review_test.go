@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestValidateTakeProfitTarget(t *testing.T) {
+	newPos := func(side string) *Position {
+		return &Position{
+			Symbol:     "BTCUSDT",
+			Side:       side,
+			EntryPrice: 100,
+			TakeProfitConfig: &TakeProfitConfig{
+				Enabled: true,
+				Levels: []*TakeProfitLevel{
+					{Level: 1, TargetPrice: 105, Executed: true},
+					{Level: 2, TargetPrice: 110},
+					{Level: 3, TargetPrice: 115},
+				},
+			},
+		}
+	}
+
+	long := newPos("long")
+	if level, err := validateTakeProfitTarget(long, 106, 112); err != nil || level.Level != 2 {
+		t.Errorf("Expected level 2 to accept 112, got %+v err=%v", level, err)
+	}
+	for _, target := range []float64{105, 99, 116} {
+		if _, err := validateTakeProfitTarget(long, 106, target); err == nil {
+			t.Errorf("Expected long target %.0f to be rejected", target)
+		}
+	}
+
+	short := newPos("short")
+	short.TakeProfitConfig.Levels[1].TargetPrice = 90
+	short.TakeProfitConfig.Levels[2].TargetPrice = 85
+	if _, err := validateTakeProfitTarget(short, 95, 88); err != nil {
+		t.Errorf("Expected short target 88 to be accepted, got %v", err)
+	}
+	if _, err := validateTakeProfitTarget(short, 95, 96); err == nil {
+		t.Error("Expected a short target above the price to be rejected")
+	}
+
+	for _, level := range long.TakeProfitConfig.Levels {
+		level.Executed = true
+	}
+	if _, err := validateTakeProfitTarget(long, 106, 112); err == nil {
+		t.Error("Expected an error once every level has executed")
+	}
+	if _, err := validateTakeProfitTarget(&Position{Side: "long"}, 106, 112); err == nil {
+		t.Error("Expected an error without take-profit levels")
+	}
+}
+
+func TestReviewStopLoss(t *testing.T) {
+	cfg := &config.Config{PaperTrading: true, PaperInitialBalance: 10000}
+	log := logger.NewColorLogger(false)
+	sm := NewStopLossManager(cfg, NewBinanceExecutor(cfg, log), log, nil)
+	sm.RegisterPosition(&Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 110, Quantity: 1, InitialStopLoss: 100, CurrentStopLoss: 100})
+
+	if current, significant, exists := sm.ReviewStopLoss("BTC/USDT", 100.1); !exists || current != 100 || significant {
+		t.Errorf("Expected a 0.1%% move to be below the threshold, got %.2f %v %v", current, significant, exists)
+	}
+	if _, significant, _ := sm.ReviewStopLoss("BTCUSDT", 102); !significant {
+		t.Error("Expected a 2% move to clear the threshold")
+	}
+	if _, _, exists := sm.ReviewStopLoss("ETHUSDT", 102); exists {
+		t.Error("Expected no position for ETHUSDT")
+	}
+}
@@ -0,0 +1,57 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// buildStopSlippage measures a stop-loss fill against the stop's trigger price
+// buildStopSlippage 以止损触发价为基准衡量止损成交
+func buildStopSlippage(pos *Position, timeframe, orderType string, fillPrice, quantity float64, at time.Time) *storage.StopSlippage {
+	side := futures.SideTypeSell
+	if pos.Side == "short" {
+		side = futures.SideTypeBuy
+	}
+
+	slip := &storage.StopSlippage{
+		PositionID:   pos.ID,
+		Symbol:       pos.Symbol,
+		Timeframe:    timeframe,
+		Side:         pos.Side,
+		OrderType:    orderType,
+		TriggerPrice: pos.CurrentStopLoss,
+		FillPrice:    fillPrice,
+		Quantity:     quantity,
+		SlippageBps:  fillSlippageBps(side, pos.CurrentStopLoss, fillPrice),
+		ExecutedAt:   at,
+	}
+	if pos.EntryPrice > 0 {
+		slip.StopDistancePercent = math.Abs(pos.EntryPrice-pos.CurrentStopLoss) / pos.EntryPrice * 100
+	}
+	slip.SlippageCost = slip.SlippageBps / 10000 * pos.CurrentStopLoss * quantity
+	return slip
+}
+
+// recordStopSlippage stores how far a stop-loss fill landed from its trigger price
+// recordStopSlippage 保存止损成交价与触发价的偏离
+//
+// Native trailing stops are skipped: the exchange moves their trigger and never reports where it fired, so there is
+// nothing to measure the fill against. Recording is best-effort and never blocks the close.
+// 原生追踪止损不记录：其触发价由交易所移动且不会回报实际触发位置，无法衡量成交偏离。记录为尽力而为，不阻塞平仓。
+func (sm *StopLossManager) recordStopSlippage(pos *Position, orderType string, fillPrice, quantity float64) {
+	if sm.storage == nil || pos.StopLossType == StopLossTypeNativeTrailing || pos.CurrentStopLoss <= 0 || fillPrice <= 0 || quantity <= 0 {
+		return
+	}
+
+	slip := buildStopSlippage(pos, sm.config.CryptoTimeframe, orderType, fillPrice, quantity, time.Now())
+	if _, err := sm.storage.SaveStopSlippage(slip); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】保存止损滑点失败: %v", pos.Symbol, err))
+		return
+	}
+	sm.logger.Info(fmt.Sprintf("📉【%s】止损滑点: 触发价 %.4f，成交价 %.4f，%+.1f bps（%.4f USDT，%s）",
+		pos.Symbol, slip.TriggerPrice, slip.FillPrice, slip.SlippageBps, slip.SlippageCost, orderType))
+}
@@ -0,0 +1,31 @@
+package executors
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestBuildStopSlippage(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A long stop at 98 filling at 97.9 sells 10 bps worse than the trigger
+	// 多仓止损 98 在 97.9 成交，比触发价差 10 bps 卖出
+	long := &Position{ID: "p1", Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, CurrentStopLoss: 98}
+	slip := buildStopSlippage(long, "1h", storage.StopOrderTypeStopMarket, 97.902, 2, at)
+	if math.Abs(slip.SlippageBps-10) > 1e-6 || math.Abs(slip.SlippageCost-0.196) > 1e-6 || math.Abs(slip.StopDistancePercent-2) > 1e-9 {
+		t.Errorf("Unexpected long slippage: %+v", slip)
+	}
+	if slip.Timeframe != "1h" || slip.OrderType != storage.StopOrderTypeStopMarket || slip.TriggerPrice != 98 {
+		t.Errorf("Unexpected long record: %+v", slip)
+	}
+
+	// A short stop filling below its trigger is favourable
+	// 空仓止损低于触发价成交为有利滑点
+	short := &Position{ID: "p2", Symbol: "ETHUSDT", Side: "short", EntryPrice: 100, CurrentStopLoss: 101}
+	if slip := buildStopSlippage(short, "1h", storage.StopOrderTypeLocal, 100.899, 1, at); slip.SlippageBps >= 0 {
+		t.Errorf("Expected favourable short slippage, got %+v", slip)
+	}
+}
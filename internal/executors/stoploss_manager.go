@@ -2,6 +2,7 @@ package executors
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/adshao/go-binance/v2/futures"
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
@@ -40,12 +43,15 @@ import (
 //     无重复执行风险
 type StopLossManager struct {
 	positions        map[string]*Position    // symbol -> Position
+	hedges           map[string]*Position    // symbol -> 反转时保留的对冲腿 / Hedge leg kept on a reversal
 	executor         *BinanceExecutor        // 执行器 / Executor
 	config           *config.Config          // 配置 / Config
 	logger           *logger.ColorLogger     // 日志 / Logger
 	storage          *storage.Storage        // 数据库 / Database
 	calculator       *TrailingStopCalculator // 追踪止损计算器 / Trailing stop calculator
 	takeProfitMgr    *TakeProfitManager      // 分批止盈管理器 / Take-profit manager
+	taskQueue        *TaskQueue              // 失败动作重试队列 / Retry queue for failed actions
+	notifier         *notify.Notifier        // 事件通知，可为 nil / Event notifications, may be nil
 	mu               sync.RWMutex            // 读写锁 / RW mutex
 	ctx              context.Context         // 上下文 / Context
 	cancel           context.CancelFunc      // 取消函数 / Cancel function
@@ -55,17 +61,50 @@ type StopLossManager struct {
 // NewStopLossManager 创建新的止损管理器
 func NewStopLossManager(cfg *config.Config, executor *BinanceExecutor, log *logger.ColorLogger, db *storage.Storage) *StopLossManager {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &StopLossManager{
+	sm := &StopLossManager{
 		positions:     make(map[string]*Position),
+		hedges:        make(map[string]*Position),
 		executor:      executor,
 		config:        cfg,
 		logger:        log,
 		storage:       db,
 		calculator:    NewTrailingStopCalculator(log),         // 初始化追踪止损计算器 / Initialize trailing stop calculator
 		takeProfitMgr: NewTakeProfitManager(cfg, executor, log, db), // 初始化分批止盈管理器 / Initialize take-profit manager
+		taskQueue:     NewTaskQueue(db, log),                   // 初始化重试队列 / Initialize retry queue
 		ctx:           ctx,
 		cancel:        cancel,
 	}
+	sm.taskQueue.RegisterHandler(TaskReplaceStopLoss, sm.handleReplaceStopLossTask)
+	sm.calculator.SetFormulas(cfg.TrailingStopFormula, cfg.TrailingStopFormulas)
+	if cfg.TrailingThresholdAutoTune {
+		sm.calculator.EnableThresholdTuning(cfg.TrailingThresholdMin, cfg.TrailingThresholdMax, cfg.TrailingThresholdWindow)
+	}
+	if cfg.ATRScalingEnabled {
+		sm.calculator.EnableATRScaling(cfg.ATRScalingMinScale, cfg.ATRScalingMaxScale)
+	}
+	return sm
+}
+
+// SetNotifier enables notifications for stop-loss and take-profit events
+// SetNotifier 启用止损和止盈事件的通知
+func (sm *StopLossManager) SetNotifier(n *notify.Notifier) {
+	sm.notifier = n
+	sm.takeProfitMgr.notifier = n
+	if sm.executor != nil && sm.executor.keys != nil {
+		sm.executor.keys.setNotifier(n)
+	}
+}
+
+// Notifier returns the notifier set by SetNotifier, or nil
+// Notifier 返回通过 SetNotifier 设置的通知器，未设置时为 nil
+func (sm *StopLossManager) Notifier() *notify.Notifier {
+	return sm.notifier
+}
+
+// Executor returns the executor the manager trades through, sharing its caches with other callers
+// Executor 返回管理器使用的执行器，供其他调用方共享其缓存
+func (sm *StopLossManager) Executor() *BinanceExecutor {
+	return sm.executor
 }
 
 // RegisterPosition registers a new position for stop-loss management
@@ -83,13 +122,18 @@ func (sm *StopLossManager) RegisterPosition(pos *Position) {
 
 	pos.HighestPrice = pos.EntryPrice // 初始化最高价/最低价 / Initialize highest/lowest
 	pos.CurrentPrice = pos.EntryPrice
-	pos.StopLossType = "fixed" // LLM 驱动的固定止损 / LLM-driven fixed stop
+	// Positions restored with an exchange-side trailing stop keep it; everything else starts as a fixed stop
+	// 恢复的持仓若已有交易所追踪止损则保留，其余均从固定止损开始
+	if pos.StopLossType != StopLossTypeNativeTrailing {
+		pos.StopLossType = "fixed" // LLM 驱动的固定止损 / LLM-driven fixed stop
+	}
 
 	// Initialize take-profit levels
 	// 初始化分批止盈级别
 	sm.takeProfitMgr.InitializeTakeProfitLevels(pos)
 
 	sm.positions[normalizedSymbol] = pos
+	transitionPosition(sm.logger, pos, StateOpen, "注册持仓")
 	sm.logger.Success(fmt.Sprintf("【%s】持仓已注册，入场价: %.2f, 初始止损: %.2f, 当前止损: %.2f",
 		normalizedSymbol, pos.EntryPrice, pos.InitialStopLoss, pos.CurrentStopLoss))
 }
@@ -137,6 +181,31 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 		sm.logger.Warning(fmt.Sprintf("⚠️  %s 持仓不存在，无需关闭", symbol))
 		return nil
 	}
+	return sm.closeManaged(ctx, symbol, pos, closePrice, closeReason, realizedPnL)
+}
+
+// closeManaged closes one managed leg, the main position or a hedge leg, and records the result
+// closeManaged 关闭一条受管理的持仓腿（主持仓或对冲腿）并记录结果
+func (sm *StopLossManager) closeManaged(ctx context.Context, symbol string, pos *Position, closePrice float64, closeReason string, realizedPnL float64) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	// Another path may have closed the leg in the meantime
+	// 持仓腿可能已被其他流程关闭
+	sm.mu.Lock()
+	tracked := sm.positions[normalizedSymbol] == pos || sm.hedges[normalizedSymbol] == pos
+	closing := pos.Lifecycle() == StateClosing
+	if tracked && !closing {
+		transitionPosition(sm.logger, pos, StateClosing, closeReason)
+	}
+	sm.mu.Unlock()
+	if !tracked {
+		sm.logger.Warning(fmt.Sprintf("⚠️  %s 持仓已不在管理中，无需关闭", symbol))
+		return nil
+	}
+	if closing {
+		sm.logger.Warning(fmt.Sprintf("⚠️  %s 持仓正在关闭中，跳过重复关闭", symbol))
+		return nil
+	}
 
 	sm.logger.Info(fmt.Sprintf("【%s】正在关闭持仓...", symbol))
 
@@ -149,12 +218,13 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 			sm.logger.Success(fmt.Sprintf("✅ %s 止损单已取消", symbol))
 		}
 	}
+	sm.cancelTakeProfitOrders(ctx, pos)
+	sm.executor.CancelEntryLadder(ctx, normalizedSymbol)
 
 	// Step 2: Remove from memory
 	// 步骤 2：从内存移除
-	sm.mu.Lock()
-	delete(sm.positions, normalizedSymbol)
-	sm.mu.Unlock()
+	sm.untrack(normalizedSymbol, pos)
+	transitionPosition(sm.logger, pos, StateClosed, closeReason)
 	sm.logger.Info(fmt.Sprintf("✅ %s 已从止损管理器移除", symbol))
 
 	// Step 3: Update database status with retry
@@ -175,6 +245,23 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 			posRecord.CloseReason = closeReason
 			posRecord.RealizedPnL = realizedPnL
 
+			// Record commissions and funding so the stored result is net PnL
+			// 记录手续费和资金费，使保存的结果为净盈亏
+			if sm.executor != nil {
+				fees, err := sm.executor.GetPositionFees(ctx, normalizedSymbol, posRecord.EntryTime, now)
+				if err != nil {
+					// Fall back to the funding accumulated while the position was open
+					// 回退为持仓期间累计的资金费
+					posRecord.FundingFee = pos.FundingFees
+					sm.logger.Warning(fmt.Sprintf("⚠️  获取 %s 手续费和资金费失败: %v（净盈亏仅计入已同步的资金费）", symbol, err))
+				} else {
+					posRecord.Commission = fees.Commission
+					posRecord.FundingFee = fees.Funding
+				}
+			}
+			sm.logger.Info(fmt.Sprintf("💰【%s】毛盈亏 %+.2f，手续费 -%.4f，资金费 %+.4f，净盈亏 %+.2f USDT",
+				symbol, posRecord.RealizedPnL, posRecord.Commission, posRecord.FundingFee, posRecord.NetPnL()))
+
 			// Retry database update up to 3 times
 			// 重试数据库更新最多 3 次
 			for i := 0; i < 3; i++ {
@@ -190,6 +277,8 @@ func (sm *StopLossManager) ClosePosition(ctx context.Context, symbol string, clo
 				sm.logger.Success(fmt.Sprintf("✅ %s 数据库状态已更新为已关闭", symbol))
 				break
 			}
+			sm.notifier.Notify(notify.SeverityCritical, symbol, fmt.Sprintf("🔔 持仓已平仓 @ %.2f，净盈亏 %+.2f USDT（%s）",
+				closePrice, posRecord.NetPnL(), closeReason))
 		}
 	}
 
@@ -215,12 +304,33 @@ func (sm *StopLossManager) PlaceInitialStopLoss(ctx context.Context, pos *Positi
 		return fmt.Errorf("初始止损距离超出合理范围，拒绝开仓")
 	}
 
+	// In native mode the exchange trails the stop; fall back to a fixed stop if that fails
+	// 原生模式下由交易所追踪止损；失败时回退为固定止损
+	placed := false
+	if sm.useNativeTrailing(pos) {
+		if err := sm.placeNativeTrailingStop(ctx, pos); err != nil {
+			sm.logger.Warning(fmt.Sprintf("⚠️【%s】原生追踪止损下单失败，回退为固定止损: %v", pos.Symbol, err))
+		} else {
+			placed = true
+		}
+	}
+
 	// Try to place stop-loss order
 	// 尝试下止损单
-	err := sm.placeStopLossOrder(ctx, pos, pos.InitialStopLoss)
+	// Bracket entry places the first take-profit together with the stop
+	// 括号单开仓时止盈单与止损单一起下达
+	var err error
+	if !placed {
+		if sm.useBracketEntry(pos) {
+			err = sm.placeBracketEntry(ctx, pos)
+		} else {
+			err = sm.placeStopLossOrder(ctx, pos, pos.InitialStopLoss)
+		}
+	}
 	if err != nil {
 		sm.logger.Error(fmt.Sprintf("❌ 下初始止损单失败: %v", err))
 		sm.logger.Warning(fmt.Sprintf("⚠️  持仓 %s 已注册但无止损保护，建议立即移除或手动下单", pos.Symbol))
+		sm.enqueueStopLossRetry(pos, pos.InitialStopLoss, "初始止损下单失败重试", err)
 		return fmt.Errorf("下初始止损单失败，持仓无保护: %w", err)
 	}
 
@@ -230,6 +340,9 @@ func (sm *StopLossManager) PlaceInitialStopLoss(ctx context.Context, pos *Positi
 		posRecord, err := sm.storage.GetPositionByID(pos.ID)
 		if err == nil && posRecord != nil {
 			posRecord.StopLossOrderID = pos.StopLossOrderID
+			posRecord.StopLossType = pos.StopLossType
+			posRecord.CurrentStopLoss = pos.CurrentStopLoss
+			posRecord.TrailingDistance = pos.TrailingDistance
 			// Retry database update up to 3 times
 			// 重试数据库更新最多 3 次
 			for i := 0; i < 3; i++ {
@@ -304,10 +417,20 @@ func (sm *StopLossManager) validateStopLossPrice(ctx context.Context, symbol str
 // UpdateStopLoss updates stop-loss price for a position (called by LLM every 15 minutes)
 // UpdateStopLoss 更新持仓的止损价格（每 15 分钟由 LLM 调用）
 func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, newStopLoss float64, reason string) error {
+	return sm.moveStopLoss(ctx, symbol, newStopLoss, reason, "llm")
+}
+
+// moveStopLoss replaces a position's stop order and records the move under trigger ("llm" or "program") once it is placed
+// moveStopLoss 替换持仓的止损单，下单成功后以 trigger（"llm" 或 "program"）记录此次移动
+func (sm *StopLossManager) moveStopLoss(ctx context.Context, symbol string, newStopLoss float64, reason, trigger string) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
 
+	if !sm.executor.ManagesSymbol(symbol) {
+		return fmt.Errorf("【%s】由其他实例管理，跳过止损更新", normalizedSymbol)
+	}
+
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
@@ -333,18 +456,13 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 
 	// Check if change is significant enough (threshold from trailing stop calculator config)
 	// 检查变化是否足够大（阈值从追踪止损计算器配置读取）
-	changePercent := math.Abs((newStopLoss-oldStop)/oldStop) * 100
-	threshold := sm.calculator.GetConfig(normalizedSymbol).UpdateThreshold
-	if changePercent < threshold {
+	changePercent, threshold, below := sm.belowUpdateThreshold(normalizedSymbol, oldStop, newStopLoss)
+	if below {
 		sm.logger.Info(fmt.Sprintf("【%s】💡 止损价格变化较小 (%.2f → %.2f, 变化 %.2f%% < 阈值 %.1f%%)，跳过更新以避免频繁调整",
 			pos.Symbol, oldStop, newStopLoss, changePercent, threshold))
 		return nil
 	}
 
-	// Record history
-	// 记录历史
-	pos.AddStopLossEvent(oldStop, newStopLoss, reason, "llm")
-
 	// CRITICAL FIX: Validate new stop-loss price BEFORE cancelling old order
 	// 关键修复：在取消旧订单之前先验证新止损价格
 	// This prevents leaving the position unprotected if validation fails
@@ -366,6 +484,7 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 	if pos.StopLossOrderID != "" {
 		if err := sm.cancelStopLossOrder(ctx, pos); err != nil {
 			sm.logger.Error(fmt.Sprintf("❌ 取消旧止损单失败: %v", err))
+			sm.enqueueStopLossRetry(pos, newStopLoss, reason, err)
 			return fmt.Errorf("无法取消旧止损单（订单ID: %s）: %w", pos.StopLossOrderID, err)
 		}
 	}
@@ -374,16 +493,25 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 	// 下新的止损单
 	if err := sm.placeStopLossOrder(ctx, pos, newStopLoss); err != nil {
 		sm.logger.Error(fmt.Sprintf("❌【%s】下新止损单失败: %v，持仓现在无止损保护！", pos.Symbol, err))
+		sm.notifier.Notify(notify.SeverityCritical, pos.Symbol, fmt.Sprintf("🚨 下新止损单失败，持仓无止损保护: %v", err))
+		sm.enqueueStopLossRetry(pos, newStopLoss, reason, err)
 		return fmt.Errorf("下止损单失败（旧单已取消）: %w", err)
 	}
 
 	pos.CurrentStopLoss = newStopLoss
+
+	// Record history only for a stop that is actually on the exchange
+	// 只记录已真正挂到交易所的止损
+	sm.recordStopLossEvent(pos, oldStop, newStopLoss, reason, trigger)
+
 	modeLabel := ""
 	if sm.executor.testMode {
 		modeLabel = "🧪 [测试网] "
 	}
-	sm.logger.Success(fmt.Sprintf("%s【%s】✅ LLM 止损已更新: %.2f → %.2f (%s)",
-		modeLabel, pos.Symbol, oldStop, newStopLoss, reason))
+	sm.logger.Success(fmt.Sprintf("%s【%s】✅ 止损已更新: %.2f → %.2f (%s, %s)",
+		modeLabel, pos.Symbol, oldStop, newStopLoss, reason, trigger))
+	sm.notifier.Notify(notify.SeverityInfo, pos.Symbol, fmt.Sprintf("止损 %.2f → %.2f（%+.2f%%，%s）",
+		oldStop, newStopLoss, (newStopLoss-oldStop)/oldStop*100, reason))
 
 	// Persist to database with retry
 	// 持久化到数据库（带重试）
@@ -412,6 +540,113 @@ func (sm *StopLossManager) UpdateStopLoss(ctx context.Context, symbol string, ne
 	return nil
 }
 
+// belowUpdateThreshold reports whether a stop move is too small to be worth replacing the order
+// belowUpdateThreshold 判断止损移动是否小到不值得替换订单
+func (sm *StopLossManager) belowUpdateThreshold(symbol string, oldStop, newStop float64) (changePercent, threshold float64, below bool) {
+	changePercent = math.Abs((newStop-oldStop)/oldStop) * 100
+	threshold = sm.calculator.GetConfig(symbol).UpdateThreshold
+	return changePercent, threshold, changePercent < threshold
+}
+
+// replaceStopLossPayload is the payload of a TaskReplaceStopLoss task
+// replaceStopLossPayload 是 TaskReplaceStopLoss 任务的参数
+type replaceStopLossPayload struct {
+	PositionID string  `json:"position_id"`
+	StopPrice  float64 `json:"stop_price"`
+	Reason     string  `json:"reason"`
+}
+
+// enqueueStopLossRetry queues a stop-loss placement that failed so it is retried after the cycle
+// enqueueStopLossRetry 将失败的止损下单加入队列，在周期结束后重试
+func (sm *StopLossManager) enqueueStopLossRetry(pos *Position, stopPrice float64, reason string, cause error) {
+	payload := replaceStopLossPayload{
+		PositionID: pos.ID,
+		StopPrice:  stopPrice,
+		Reason:     reason,
+	}
+	if err := sm.taskQueue.Enqueue(TaskReplaceStopLoss, pos.Symbol, payload, cause); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️  止损重试任务入队失败: %v", err))
+	}
+}
+
+// handleReplaceStopLossTask re-places a stop-loss order that previously failed
+// handleReplaceStopLossTask 重新下达之前失败的止损单
+func (sm *StopLossManager) handleReplaceStopLossTask(ctx context.Context, task *storage.PendingTask) error {
+	var payload replaceStopLossPayload
+	if err := json.Unmarshal([]byte(task.Payload), &payload); err != nil {
+		return fmt.Errorf("%w: invalid payload: %v", ErrTaskPermanent, err)
+	}
+
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(task.Symbol)
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// The position may have been closed or replaced in the meantime
+	// 持仓可能已在此期间被平仓或替换
+	pos, exists := sm.positions[normalizedSymbol]
+	if hedge, ok := sm.hedges[normalizedSymbol]; ok && hedge.ID == payload.PositionID {
+		pos, exists = hedge, true
+	}
+	if !exists || pos.ID != payload.PositionID {
+		sm.logger.Info(fmt.Sprintf("【%s】💡 持仓已不存在，放弃止损重试任务", task.Symbol))
+		return nil
+	}
+
+	// A later update may already have placed an equal or better stop
+	// 之后的更新可能已经下达了相同或更优的止损单
+	if pos.StopLossOrderID != "" && !sm.calculator.IsValidUpdate(pos.Side, pos.CurrentStopLoss, payload.StopPrice) {
+		sm.logger.Info(fmt.Sprintf("【%s】💡 当前止损单 %.2f 已不劣于重试价格 %.2f，无需重试",
+			pos.Symbol, pos.CurrentStopLoss, payload.StopPrice))
+		return nil
+	}
+
+	// Price moved through the stop while waiting, retrying cannot succeed
+	// 等待期间价格已穿过止损价，重试不会成功
+	if _, err := sm.validateStopLossPrice(ctx, task.Symbol, pos, payload.StopPrice); err != nil {
+		return fmt.Errorf("%w: %v", ErrTaskPermanent, err)
+	}
+
+	if pos.StopLossOrderID != "" {
+		if err := sm.cancelStopLossOrder(ctx, pos); err != nil {
+			return err
+		}
+	}
+	if err := sm.placeStopLossOrder(ctx, pos, payload.StopPrice); err != nil {
+		return err
+	}
+
+	oldStop := pos.CurrentStopLoss
+	pos.CurrentStopLoss = payload.StopPrice
+	sm.recordStopLossEvent(pos, oldStop, payload.StopPrice, payload.Reason, "retry")
+
+	if sm.storage != nil {
+		posRecord, err := sm.storage.GetPositionByID(pos.ID)
+		if err == nil && posRecord != nil {
+			posRecord.CurrentStopLoss = pos.CurrentStopLoss
+			posRecord.StopLossOrderID = pos.StopLossOrderID
+			if err := sm.storage.UpdatePosition(posRecord); err != nil {
+				sm.logger.Warning(fmt.Sprintf("⚠️  更新数据库止损失败: %v", err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// ProcessPendingTasks runs deferred tasks that are due, e.g. stop-loss orders that failed earlier
+// ProcessPendingTasks 执行已到期的延迟任务，例如之前失败的止损单
+func (sm *StopLossManager) ProcessPendingTasks(ctx context.Context) int {
+	return sm.taskQueue.ProcessDue(ctx)
+}
+
+// RunTaskQueue retries deferred tasks in the background until the manager is stopped
+// RunTaskQueue 在后台重试延迟任务，直到管理器停止
+func (sm *StopLossManager) RunTaskQueue(interval time.Duration) {
+	sm.logger.Info(fmt.Sprintf("📥 启动失败任务重试队列，间隔: %v", interval))
+	sm.taskQueue.Run(sm.ctx, interval)
+}
+
 // AutoUpdateTrailingStop automatically calculates and updates trailing stop
 // AutoUpdateTrailingStop 自动计算并更新追踪止损
 //
@@ -451,9 +686,16 @@ func (sm *StopLossManager) AutoUpdateTrailingStop(ctx context.Context, symbol st
 	side := pos.Side
 	highestPrice := pos.HighestPrice
 	currentStopLoss := pos.CurrentStopLoss
+	stopLossType := pos.StopLossType
 	//entryPrice := pos.EntryPrice
 	sm.mu.RUnlock()
 
+	// The exchange already trails native stops; re-placing them locally would undo that
+	// 原生追踪止损已由交易所跟随，本地重下会破坏它
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
 	// Validate ATR value
 	// 验证 ATR 值
 	if atr <= 0 {
@@ -470,12 +712,26 @@ func (sm *StopLossManager) AutoUpdateTrailingStop(ctx context.Context, symbol st
 		side,
 	)
 
+	priceType := "最低价"
+	if side == "long" {
+		priceType = "最高价"
+	}
+	reason := fmt.Sprintf("追踪止损自动调整（%s=%.2f, ATR=%.2f）",
+		priceType, highestPrice, atr)
+	return sm.applyTrailingStop(ctx, symbol, side, currentStopLoss, newStopLoss, reason)
+}
+
+// applyTrailingStop places a newly calculated trailing stop, respecting the take-profit floor, favorable direction and threshold
+// applyTrailingStop 下达新计算的追踪止损，遵守止盈底线、有利方向和更新阈值
+func (sm *StopLossManager) applyTrailingStop(ctx context.Context, symbol, side string, currentStopLoss, newStopLoss float64, reason string) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
 	// 2. Check take-profit floor (hybrid mode coordination)
 	// 2. 检查止盈底线（混合模式协调）
 	// If any TP level has been executed, ensure trailing stop doesn't go below the TP floor
 	// 如果任何止盈级别已执行，确保追踪止损不低于止盈底线
 	sm.mu.RLock()
-	pos, exists = sm.positions[normalizedSymbol]
+	pos, exists := sm.positions[normalizedSymbol]
 	sm.mu.RUnlock()
 	if exists {
 		minStopLoss, hasFloor := sm.takeProfitMgr.GetMinimumStopLoss(pos)
@@ -500,6 +756,20 @@ func (sm *StopLossManager) AutoUpdateTrailingStop(ctx context.Context, symbol st
 				}
 			}
 		}
+
+		// Profit-lock tiers form a second floor: never give back more than the configured share of the best profit
+		// 利润锁定阶梯构成第二道底线：回吐不超过最大浮盈的配置比例
+		if lockStop, lockReason, ok := sm.profitLockFloor(pos); ok {
+			if side == "long" && newStopLoss < lockStop {
+				sm.logger.Info(fmt.Sprintf("【%s】🔒 追踪止损 (%.2f) 低于利润锁定底线 (%.2f, %s)，使用底线价格",
+					symbol, newStopLoss, lockStop, lockReason))
+				newStopLoss = lockStop
+			} else if side == "short" && newStopLoss > lockStop {
+				sm.logger.Info(fmt.Sprintf("【%s】🔒 追踪止损 (%.2f) 高于利润锁定底线 (%.2f, %s)，使用底线价格",
+					symbol, newStopLoss, lockStop, lockReason))
+				newStopLoss = lockStop
+			}
+		}
 	}
 
 	// 3. Validate stop-loss price is in favorable direction
@@ -510,25 +780,20 @@ func (sm *StopLossManager) AutoUpdateTrailingStop(ctx context.Context, symbol st
 		return nil
 	}
 
-	// 4. Check if change is significant enough (exceeds threshold)
-	// 4. 检查变化是否足够大（超过阈值）
-	if !sm.calculator.ShouldUpdate(symbol, currentStopLoss, newStopLoss) {
+	// 4. Check if change is significant enough (exceeds threshold), feeding the outcome to threshold tuning
+	// 4. 检查变化是否足够大（超过阈值），并将结果反馈给阈值调节
+	shouldUpdate := sm.calculator.ShouldUpdate(symbol, currentStopLoss, newStopLoss)
+	sm.calculator.RecordUpdateOutcome(symbol, shouldUpdate)
+	if !shouldUpdate {
 		changePercent := math.Abs((newStopLoss-currentStopLoss)/currentStopLoss) * 100
 		sm.logger.Info(fmt.Sprintf("【%s】💡 止损价变化较小 (%.2f%%)，跳过更新以避免频繁调整",
 			symbol, changePercent))
 		return nil
 	}
 
-	// 5. Call existing UpdateStopLoss method to update Binance stop order
-	// 5. 调用现有的 UpdateStopLoss 方法更新币安止损单
-	priceType := "最低价"
-	if side == "long" {
-		priceType = "最高价"
-	}
-	reason := fmt.Sprintf("追踪止损自动调整（%s=%.2f, ATR=%.2f）",
-		priceType, highestPrice, atr)
-
-	err := sm.UpdateStopLoss(ctx, symbol, newStopLoss, reason)
+	// 5. Move the Binance stop order as a program-triggered change
+	// 5. 以程序触发的方式移动币安止损单
+	err := sm.moveStopLoss(ctx, symbol, newStopLoss, reason, "program")
 	if err != nil {
 		sm.logger.Error(fmt.Sprintf("【%s】❌ 自动更新追踪止损失败: %v", symbol, err))
 		return fmt.Errorf("自动更新追踪止损失败: %w", err)
@@ -596,26 +861,35 @@ func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, sy
 	// 仅查询最新的 K 线（增量更新）
 	// Use configured trading interval instead of hardcoded value
 	// 使用配置的交易间隔而不是硬编码值
-	klines, err := sm.executor.client.NewKlinesService().
-		Symbol(binanceSymbol).
-		Interval(sm.config.TradingInterval). // 使用配置的交易间隔（与系统运行间隔一致）
-		Limit(1).                            // 只获取最新一根 K 线 / Only fetch the latest kline
-		Do(ctx)
+	interval := dataflows.NativeInterval(sm.config.TradingInterval) // 使用配置的交易间隔，合成周期使用其基础周期 / Configured interval, or its base when aggregated
 
-	if err != nil {
-		return fmt.Errorf("获取 K 线数据失败: %w", err)
-	}
+	var klineHigh, klineLow, currentPrice float64
+	if candle, ok := dataflows.LatestStreamedCandle(binanceSymbol, interval); ok {
+		// The kline stream already holds the forming candle
+		// K 线流中已有当前未收盘的 K 线
+		klineHigh, klineLow, currentPrice = candle.High, candle.Low, candle.Close
+	} else {
+		klines, err := sm.executor.client.NewKlinesService().
+			Symbol(binanceSymbol).
+			Interval(interval).
+			Limit(1). // 只获取最新一根 K 线 / Only fetch the latest kline
+			Do(ctx)
 
-	if len(klines) == 0 {
-		return fmt.Errorf("未获取到 K 线数据")
-	}
+		if err != nil {
+			return fmt.Errorf("获取 K 线数据失败: %w", err)
+		}
 
-	// Parse latest kline data
-	// 解析最新 K 线数据
-	latestKline := klines[0]
-	klineHigh, _ := parseFloat(latestKline.High)
-	klineLow, _ := parseFloat(latestKline.Low)
-	currentPrice, _ := parseFloat(latestKline.Close)
+		if len(klines) == 0 {
+			return fmt.Errorf("未获取到 K 线数据")
+		}
+
+		// Parse latest kline data
+		// 解析最新 K 线数据
+		latestKline := klines[0]
+		klineHigh, _ = parseFloat(latestKline.High)
+		klineLow, _ = parseFloat(latestKline.Low)
+		currentPrice, _ = parseFloat(latestKline.Close)
+	}
 
 	// Incrementally update highest/lowest price
 	// 增量更新最高/最低价
@@ -718,6 +992,14 @@ func (sm *StopLossManager) UpdatePositionPriceFromKlines(ctx context.Context, sy
 // the stop-loss automatically, and the system needs to sync this change.
 // 这对于服务器端止损策略至关重要，因为币安会自动执行止损，系统需要同步这个变化。
 func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string) error {
+	return sm.reconcilePosition(ctx, symbol, 0, "")
+}
+
+// reconcilePosition is ReconcilePosition with an optional known fill price and close reason
+// reconcilePosition 是可传入已知成交价和平仓原因的 ReconcilePosition
+// A zero closePrice falls back to the current market price; an empty reason assumes the stop-loss fired
+// closePrice 为 0 时回退到当前市场价；reason 为空时视为止损触发
+func (sm *StopLossManager) reconcilePosition(ctx context.Context, symbol string, closePrice float64, reason string) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
@@ -740,7 +1022,7 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 
 	// Get actual position from Binance
 	// 从币安获取实际持仓
-	actualPos, err := sm.executor.GetCurrentPosition(ctx, symbol)
+	actualPos, err := sm.exchangeLeg(ctx, symbol, posSide)
 	if err != nil {
 		sm.logger.Warning(fmt.Sprintf("⚠️  对账失败（无法获取 %s 币安持仓）: %v", symbol, err))
 		return err
@@ -753,12 +1035,14 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 		sm.logger.Info(fmt.Sprintf("   持仓详情: %s %.4f @ $%.2f, 止损价: $%.2f",
 			posSide, posQuantity, posEntryPrice, posCurrentStopLoss))
 
-		// Get current market price as close price
-		// 获取当前市场价格作为平仓价格
-		closePrice, err := sm.getCurrentPrice(ctx, symbol)
-		if err != nil || closePrice == 0 {
-			sm.logger.Warning(fmt.Sprintf("⚠️  无法获取平仓价格，使用止损价: %.2f", posCurrentStopLoss))
-			closePrice = posCurrentStopLoss
+		// Get current market price as close price unless the fill price is known
+		// 未知成交价时获取当前市场价格作为平仓价格
+		if closePrice == 0 {
+			closePrice, err = sm.getCurrentPrice(ctx, symbol)
+			if err != nil || closePrice == 0 {
+				sm.logger.Warning(fmt.Sprintf("⚠️  无法获取平仓价格，使用止损价: %.2f", posCurrentStopLoss))
+				closePrice = posCurrentStopLoss
+			}
 		}
 
 		// Calculate realized PnL
@@ -772,13 +1056,15 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 
 		// Close position (removes from memory and updates database)
 		// 关闭持仓（从内存移除并更新数据库）
-		reason := "止损单触发（币安自动执行）"
+		if reason == "" {
+			reason = "止损单触发（币安自动执行）"
+		}
 		if err := sm.ClosePosition(ctx, symbol, closePrice, reason, realizedPnL); err != nil {
 			sm.logger.Warning(fmt.Sprintf("⚠️  清理已止损持仓失败: %v", err))
 			return err
 		}
 
-		sm.logger.Success(fmt.Sprintf("✅【%s】已清理止损后的持仓数据（盈亏: %+.2f USDT）", symbol, realizedPnL))
+		sm.logger.Success(fmt.Sprintf("✅【%s】已清理止损后的持仓数据（毛盈亏: %+.2f USDT）", symbol, realizedPnL))
 		return nil
 	}
 
@@ -814,6 +1100,7 @@ func (sm *StopLossManager) ReconcilePosition(ctx context.Context, symbol string)
 			symbol, actualPos.Size, managedPos.Quantity))
 		managedPos.Quantity = actualPos.Size
 		managedPos.Size = actualPos.Size
+		assertPositionInvariants(sm.logger, managedPos, "同步币安持仓数量")
 	}
 
 	return nil
@@ -834,6 +1121,12 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 	// 标准化符号以匹配内部存储格式
 	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
 
+	// The hedge leg has its own stop order
+	// 对冲腿有自己的止损单
+	if err := sm.checkHedgeStopOrder(ctx, normalizedSymbol); err != nil {
+		sm.logger.Warning(fmt.Sprintf("⚠️【%s】检查对冲腿止损单失败: %v", symbol, err))
+	}
+
 	sm.mu.RLock()
 	pos, exists := sm.positions[normalizedSymbol]
 	sm.mu.RUnlock()
@@ -846,10 +1139,7 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 
 	// Query order status from Binance
 	// 从币安查询订单状态
-	order, err := sm.executor.client.NewGetOrderService().
-		Symbol(binanceSymbol).
-		OrderID(parseInt64(pos.StopLossOrderID)).
-		Do(ctx)
+	order, err := sm.executor.getOrder(ctx, binanceSymbol, parseInt64(pos.StopLossOrderID))
 
 	if err != nil {
 		// Check if order not found (likely executed or cancelled)
@@ -858,12 +1148,7 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 		// 注意：币安 Go SDK 不提供类型化错误，所以使用字符串匹配
 		// Common error messages: "Unknown order", "Order does not exist", "-2011"
 		// 常见错误消息："Unknown order"、"Order does not exist"、"-2011"
-		errMsg := err.Error()
-		isOrderNotFound := strings.Contains(errMsg, "Unknown order") ||
-			strings.Contains(errMsg, "Order does not exist") ||
-			strings.Contains(errMsg, "-2011") // Binance error code for unknown order
-
-		if isOrderNotFound {
+		if isOrderNotFoundError(err) {
 			sm.logger.Warning(fmt.Sprintf("🔔【%s】止损单已不存在（可能已执行），订单ID: %s", symbol, pos.StopLossOrderID))
 			// Trigger reconciliation to clean up
 			// 触发对账以清理持仓
@@ -878,27 +1163,8 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 		sm.logger.Warning(fmt.Sprintf("🔔【%s】止损单已成交，订单ID: %s, 状态: %s",
 			symbol, pos.StopLossOrderID, order.Status))
 
-		// Get executed price from order
-		// 从订单获取成交价格
-		closePrice, err := parseFloat(order.AvgPrice)
-		if err != nil || closePrice == 0 {
-			sm.logger.Warning(fmt.Sprintf("⚠️  无法解析成交价格，使用止损价: %.2f", pos.CurrentStopLoss))
-			closePrice = pos.CurrentStopLoss
-		}
-
-		// Calculate realized PnL
-		// 计算已实现盈亏
-		var realizedPnL float64
-		if pos.Side == "long" {
-			realizedPnL = (closePrice - pos.EntryPrice) * pos.Quantity
-		} else {
-			realizedPnL = (pos.EntryPrice - closePrice) * pos.Quantity
-		}
-
-		// Close position
-		// 关闭持仓
 		reason := fmt.Sprintf("止损单成交（订单ID: %s）", pos.StopLossOrderID)
-		return sm.ClosePosition(ctx, symbol, closePrice, reason, realizedPnL)
+		return sm.closeOnStopLossFill(ctx, symbol, pos, order.AvgPrice, reason)
 	}
 
 	// Order still active
@@ -907,13 +1173,36 @@ func (sm *StopLossManager) CheckStopLossOrderStatus(ctx context.Context, symbol
 	return nil
 }
 
+// closeOnStopLossFill closes a position whose stop-loss order filled at avgPrice
+// closeOnStopLossFill 关闭止损单已按 avgPrice 成交的持仓
+func (sm *StopLossManager) closeOnStopLossFill(ctx context.Context, symbol string, pos *Position, avgPrice, reason string) error {
+	// Get executed price from order
+	// 从订单获取成交价格
+	closePrice, err := parseFloat(avgPrice)
+	if err != nil || closePrice == 0 {
+		sm.logger.Warning(fmt.Sprintf("⚠️  无法解析成交价格，使用止损价: %.2f", pos.CurrentStopLoss))
+		closePrice = pos.CurrentStopLoss
+	} else {
+		sm.recordStopSlippage(pos, storage.StopOrderTypeStopMarket, closePrice, pos.Quantity)
+	}
+
+	// Calculate realized PnL
+	// 计算已实现盈亏
+	var realizedPnL float64
+	if pos.Side == "long" {
+		realizedPnL = (closePrice - pos.EntryPrice) * pos.Quantity
+	} else {
+		realizedPnL = (pos.EntryPrice - closePrice) * pos.Quantity
+	}
+
+	return sm.closeManaged(ctx, symbol, pos, closePrice, reason, realizedPnL)
+}
+
 // UpdatePosition updates position price and checks if stop-loss should trigger
 // UpdatePosition 更新持仓价格并检查是否应触发止损
 //
-// DEPRECATED: This method is part of the deprecated local monitoring system.
-// 已弃用：此方法是已弃用的本地监控系统的一部分。
-// Use Binance server-side STOP_MARKET orders instead.
-// 请使用币安服务器端 STOP_MARKET 订单。
+// Only symbols with STOP_MONITORING_MODE local or both are checked; exchange stops trigger on Binance.
+// 只检查 STOP_MONITORING_MODE 为 local 或 both 的交易对；exchange 模式的止损由币安触发。
 func (sm *StopLossManager) UpdatePosition(ctx context.Context, symbol string, currentPrice float64) error {
 	// Normalize symbol to match internal storage format
 	// 标准化符号以匹配内部存储格式
@@ -926,6 +1215,9 @@ func (sm *StopLossManager) UpdatePosition(ctx context.Context, symbol string, cu
 		return nil // 无持仓 / No position
 	}
 	sm.mu.Unlock()
+	if !sm.localStop(normalizedSymbol) {
+		return nil
+	}
 
 	// Update price
 	// 更新价格
@@ -945,6 +1237,19 @@ func (sm *StopLossManager) UpdatePosition(ctx context.Context, symbol string, cu
 // placeStopLossOrder places a stop-loss order on Binance
 // placeStopLossOrder 在币安下止损单
 func (sm *StopLossManager) placeStopLossOrder(ctx context.Context, pos *Position, stopPrice float64) error {
+	// Never place a stop on a symbol another instance manages, or the two would replace each other's orders
+	// 不在其他实例管理的交易对上下止损单，否则两个实例会互相替换订单
+	if !sm.executor.ManagesSymbol(pos.Symbol) {
+		return fmt.Errorf("【%s】由其他实例管理", pos.Symbol)
+	}
+
+	// A locally monitored stop only lives in pos.CurrentStopLoss, which the caller updates
+	// 本地监控的止损只保存在 pos.CurrentStopLoss 中，由调用方更新
+	if !sm.exchangeStop(pos.Symbol) {
+		sm.logger.Info(fmt.Sprintf("【%s】止损 %.2f 由本地监控执行，不在交易所挂单", pos.Symbol, stopPrice))
+		return nil
+	}
+
 	// Get current market price for validation
 	// 获取当前市场价格用于验证
 	currentPrice, err := sm.getCurrentPrice(ctx, pos.Symbol)
@@ -991,28 +1296,52 @@ func (sm *StopLossManager) placeStopLossOrder(ctx context.Context, pos *Position
 	// WorkingType 说明 / WorkingType explanation:
 	// - CONTRACT_PRICE: 使用最新成交价触发 / Trigger using last price
 	// - MARK_PRICE: 使用标记价格触发（推荐，防止插针）/ Trigger using mark price (recommended, prevents wicks)
-	order, err := sm.executor.client.NewCreateOrderService().
-		Symbol(binanceSymbol).
-		Side(orderSide).
-		Type(futures.OrderTypeStopMarket).         // 使用 STOP_MARKET / Use STOP_MARKET
-		StopPrice(fmt.Sprintf("%.2f", stopPrice)). // 触发价格 / Trigger price
-		Quantity(fmt.Sprintf("%.4f", pos.Quantity)).
-		WorkingType(futures.WorkingTypeMarkPrice). // ⚠️ 关键：必须指定 workingType / CRITICAL: Must specify workingType
-		ReduceOnly(true).                          // 只平仓不开仓 / Close only
-		Do(ctx)
+	modeLabel := ""
+	if sm.executor.paper != nil {
+		// The paper executor rests the stop in its own book and triggers it on observed prices
+		// 模拟盘将止损单挂在自己的订单簿中，按观察到的价格触发
+		pos.StopLossOrderID = fmt.Sprintf("%d", sm.executor.paper.PlaceStopOrder(binanceSymbol, pos.Side, stopPrice, pos.Quantity))
+		modeLabel = "📝 [模拟盘] "
+	} else {
+		orderService := sm.executor.client.NewCreateOrderService().
+			Symbol(binanceSymbol).
+			Side(orderSide).
+			Type(futures.OrderTypeStopMarket).         // 使用 STOP_MARKET / Use STOP_MARKET
+			StopPrice(fmt.Sprintf("%.2f", stopPrice)). // 触发价格 / Trigger price
+			Quantity(fmt.Sprintf("%.4f", pos.Quantity)).
+			WorkingType(futures.WorkingTypeMarkPrice) // ⚠️ 关键：必须指定 workingType / CRITICAL: Must specify workingType
+
+		// Hedge mode closes by position side, so each leg gets its own stop; one-way mode needs ReduceOnly instead
+		// 双向持仓通过持仓方向平仓，使每条腿有各自的止损；单向持仓需要使用 ReduceOnly（只平仓不开仓）
+		if sm.executor.positionMode == PositionModeHedge {
+			positionSide := futures.PositionSideTypeLong
+			if pos.Side == "short" {
+				positionSide = futures.PositionSideTypeShort
+			}
+			orderService = orderService.PositionSide(positionSide)
+		} else {
+			orderService = orderService.ReduceOnly(true)
+		}
+		order, err := sm.executor.createOrder(ctx, binanceSymbol, orderService)
 
-	if err != nil {
-		return fmt.Errorf("下止损单失败: %w", err)
+		if err != nil {
+			return fmt.Errorf("下止损单失败: %w", err)
+		}
+		pos.StopLossOrderID = fmt.Sprintf("%d", order.OrderID)
 	}
-
-	pos.StopLossOrderID = fmt.Sprintf("%d", order.OrderID)
-	modeLabel := ""
 	if sm.executor.testMode {
 		modeLabel = "🧪 [测试网] "
 	}
 	sm.logger.Success(fmt.Sprintf("%s【%s】止损单已下达: %.2f (订单ID: %s, 当前价: %.2f)",
 		modeLabel, pos.Symbol, stopPrice, pos.StopLossOrderID, currentPrice))
 
+	// A fixed stop replacing a native trailing stop (e.g. take-profit floor) hands trailing back to the local loop
+	// 固定止损替换原生追踪止损时（如止盈底线），追踪交还给本地逻辑
+	if pos.StopLossType == StopLossTypeNativeTrailing {
+		pos.StopLossType = "fixed"
+		sm.logger.Info(fmt.Sprintf("【%s】原生追踪止损已替换为固定止损，后续改由本地追踪", pos.Symbol))
+	}
+
 	return nil
 }
 
@@ -1036,10 +1365,7 @@ func (sm *StopLossManager) cancelStopLossOrder(ctx context.Context, pos *Positio
 	sm.logger.Info(fmt.Sprintf("%s【%s】正在取消止损单: OrderID=%s, Symbol=%s",
 		modeLabel, pos.Symbol, pos.StopLossOrderID, binanceSymbol))
 
-	_, err := sm.executor.client.NewCancelOrderService().
-		Symbol(binanceSymbol).
-		OrderID(parseInt64(pos.StopLossOrderID)).
-		Do(ctx)
+	_, err := sm.executor.cancelOrder(ctx, binanceSymbol, parseInt64(pos.StopLossOrderID))
 
 	if err != nil {
 		// Provide detailed error context
@@ -1057,13 +1383,24 @@ func (sm *StopLossManager) cancelStopLossOrder(ctx context.Context, pos *Positio
 // executeStopLoss executes stop-loss (close position)
 // executeStopLoss 执行止损（平仓）
 //
-// DEPRECATED: This method is part of the deprecated local monitoring system.
-// 已弃用：此方法是已弃用的本地监控系统的一部分。
-// Binance STOP_MARKET orders handle stop-loss execution automatically.
-// 币安 STOP_MARKET 订单会自动处理止损执行。
+// In both mode the exchange stop is cancelled before closing, so the two never both close the position and
+// no stop order is left behind. If the exchange stop is already gone it has most likely fired, and the
+// position is reconciled instead of closed a second time.
+// both 模式下先撤销交易所止损单再平仓，避免两者重复平仓或遗留孤立止损单。若交易所止损单已不存在，
+// 说明它很可能已经触发，此时对账持仓而不是再次平仓。
 func (sm *StopLossManager) executeStopLoss(ctx context.Context, pos *Position) error {
 	sm.logger.Warning(fmt.Sprintf("【%s】🛑 执行止损平仓", pos.Symbol))
 
+	if pos.StopLossOrderID != "" {
+		if err := sm.cancelStopLossOrder(ctx, pos); err != nil {
+			if isOrderNotFoundError(err) {
+				sm.logger.Warning(fmt.Sprintf("🔔【%s】交易所止损单已不存在（可能已触发），改为对账", pos.Symbol))
+				return sm.ReconcilePosition(ctx, pos.Symbol)
+			}
+			return fmt.Errorf("撤销交易所止损单失败，暂不本地平仓: %w", err)
+		}
+	}
+
 	// Close position via market order
 	// 通过市价单平仓
 	action := ActionCloseLong
@@ -1071,39 +1408,36 @@ func (sm *StopLossManager) executeStopLoss(ctx context.Context, pos *Position) e
 		action = ActionCloseShort
 	}
 
-	result := sm.executor.ExecuteTrade(ctx, pos.Symbol, action, pos.Quantity, "触发止损")
-
-	if result.Success {
-		sm.logger.Success(fmt.Sprintf("【%s】止损平仓成功，盈亏: %.2f%%",
-			pos.Symbol, pos.GetUnrealizedPnL()*100))
-		sm.RemovePosition(pos.Symbol)
-	} else {
+	reason := fmt.Sprintf("本地监控触发止损 %.2f", pos.CurrentStopLoss)
+	result := sm.executor.ExecuteTrade(ctx, pos.Symbol, action, pos.Quantity, reason)
+	if !result.Success {
+		// The next monitor tick retries; in both mode the exchange stop is gone until then
+		// 下一次监控会重试；both 模式下在此之前交易所止损单已撤销
 		sm.logger.Error(fmt.Sprintf("【%s】止损平仓失败: %s", pos.Symbol, result.Message))
 		return fmt.Errorf("止损平仓失败: %s", result.Message)
 	}
 
-	return nil
+	closePrice := result.Price
+	if closePrice <= 0 {
+		closePrice = pos.CurrentPrice
+	}
+	realizedPnL := (closePrice - pos.EntryPrice) * result.FilledQuantity()
+	if pos.Side == "short" {
+		realizedPnL = -realizedPnL
+	}
+	sm.recordStopSlippage(pos, storage.StopOrderTypeLocal, result.Price, result.FilledQuantity())
+	sm.logger.Success(fmt.Sprintf("【%s】止损平仓成功 @ %.2f，盈亏: %+.2f USDT", pos.Symbol, closePrice, realizedPnL))
+	return sm.ClosePosition(ctx, pos.Symbol, closePrice, reason, realizedPnL)
 }
 
-// MonitorPositions monitors all positions in real-time (every 10 seconds)
-// MonitorPositions 实时监控所有持仓（每 10 秒）
-//
-// DEPRECATED: This method is deprecated and should NOT be used with fixed stop-loss strategy.
-// 已弃用：此方法已弃用，不应与固定止损策略一起使用。
-//
-// Reason: With Binance server-side STOP_MARKET orders, local monitoring is redundant and can cause issues:
-// 原因：使用币安服务器端 STOP_MARKET 订单时，本地监控是多余的，可能导致问题：
-//  1. Duplicate execution: Both Binance and local monitoring may try to close the position
-//     重复执行：币安和本地监控可能都尝试平仓
-//  2. API overhead: Polling price every 10 seconds for all positions
-//     API 开销：每 10 秒为所有持仓轮询价格
-//  3. Slower than Binance: 10s polling vs millisecond server-side trigger
-//     比币安慢：10 秒轮询 vs 毫秒级服务器端触发
-//  4. Reliability: Depends on local program uptime and network stability
-//     可靠性：依赖本地程序运行和网络稳定性
+// MonitorPositions enforces local stops every interval for symbols monitored locally
+// MonitorPositions 每隔 interval 为本地监控的交易对执行止损检查
 //
-// For fixed stop-loss strategy, rely entirely on Binance STOP_MARKET orders placed via PlaceInitialStopLoss().
-// 对于固定止损策略，完全依赖通过 PlaceInitialStopLoss() 下达的币安 STOP_MARKET 订单。
+// Exchange stops (STOP_MONITORING_MODE=exchange) trigger on Binance within milliseconds and keep working
+// while the bot is down, so they are skipped here. A local stop depends on this loop running and on the
+// polling interval, which is the trade-off for not exposing the stop price on the order book.
+// 交易所止损（STOP_MONITORING_MODE=exchange）由币安毫秒级触发，程序停机时依然有效，因此这里跳过。
+// 本地止损依赖此循环运行并受轮询间隔影响，代价是换取不在订单簿上暴露止损价。
 func (sm *StopLossManager) MonitorPositions(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -1120,7 +1454,9 @@ func (sm *StopLossManager) MonitorPositions(interval time.Duration) {
 			sm.mu.RLock()
 			positions := make([]*Position, 0, len(sm.positions))
 			for _, pos := range sm.positions {
-				positions = append(positions, pos)
+				if sm.localStop(pos.Symbol) {
+					positions = append(positions, pos)
+				}
 			}
 			sm.mu.RUnlock()
 
@@ -1147,10 +1483,18 @@ func (sm *StopLossManager) MonitorPositions(interval time.Duration) {
 // getCurrentPrice 从币安获取当前价格
 func (sm *StopLossManager) getCurrentPrice(ctx context.Context, symbol string) (float64, error) {
 	binanceSymbol := sm.config.GetBinanceSymbolFor(symbol)
+	if price, ok := sm.executor.cachedPrice(binanceSymbol); ok {
+		return price, nil
+	}
 
-	prices, err := sm.executor.client.NewListPricesService().
-		Symbol(binanceSymbol).
-		Do(ctx)
+	var prices []*futures.SymbolPrice
+	err := sm.executor.withRetry(func() error {
+		var err error
+		prices, err = sm.executor.client.NewListPricesService().
+			Symbol(binanceSymbol).
+			Do(ctx)
+		return err
+	})
 
 	if err != nil {
 		return 0, fmt.Errorf("获取价格失败: %w", err)
@@ -1164,6 +1508,7 @@ func (sm *StopLossManager) getCurrentPrice(ctx context.Context, symbol string) (
 	if err != nil {
 		return 0, fmt.Errorf("解析价格失败: %w", err)
 	}
+	sm.executor.observePrice(binanceSymbol, price)
 
 	return price, nil
 }
@@ -1225,6 +1570,10 @@ func (sm *StopLossManager) MonitorPartialTakeProfit(ctx context.Context, symbol
 		return nil
 	}
 
+	// Store which levels fired, so a restart does not execute them again
+	// 保存已触发的级别，避免重启后再次执行
+	sm.saveTakeProfitLevels(pos)
+
 	// TP was executed, need to update stop-loss to the new floor
 	// 止盈已执行，需要将止损更新到新底线
 	sm.mu.RLock()
@@ -1245,7 +1594,7 @@ func (sm *StopLossManager) MonitorPartialTakeProfit(ctx context.Context, symbol
 		// Update stop-loss to the new floor
 		// 更新止损到新底线
 		reason := fmt.Sprintf("分批止盈后移动止损（级别 %d 已执行）", executedCount)
-		err := sm.UpdateStopLoss(ctx, symbol, minStopLoss, reason)
+		err := sm.moveStopLoss(ctx, symbol, minStopLoss, reason, "program")
 		if err != nil {
 			sm.logger.Warning(fmt.Sprintf("⚠️  更新止损失败: %v", err))
 			return fmt.Errorf("更新止损失败: %w", err)
@@ -1332,6 +1681,10 @@ func (sm *StopLossManager) MonitorPartialTakeProfitRealtime(interval time.Durati
 				}
 
 				if executedCount > 0 {
+					// Store which levels fired, so a restart does not execute them again
+					// 保存已触发的级别，避免重启后再次执行
+					sm.saveTakeProfitLevels(pos)
+
 					// TP was executed, update stop-loss to the new floor
 					// 止盈已执行，需要将止损更新到新底线
 					sm.mu.RLock()
@@ -1356,7 +1709,7 @@ func (sm *StopLossManager) MonitorPartialTakeProfitRealtime(interval time.Durati
 						// 更新止损到新底线
 						reason := fmt.Sprintf("分批止盈后移动止损（级别 %d 已执行）", executedCount)
 						ctx, cancel = context.WithTimeout(sm.ctx, 30*time.Second)
-						err := sm.UpdateStopLoss(ctx, pos.Symbol, minStopLoss, reason)
+						err := sm.moveStopLoss(ctx, pos.Symbol, minStopLoss, reason, "program")
 						cancel()
 						if err != nil {
 							sm.logger.Warning(fmt.Sprintf("⚠️  更新止损失败: %v", err))
@@ -1374,6 +1727,15 @@ func (sm *StopLossManager) Stop() {
 	sm.cancel()
 }
 
+// isOrderNotFoundError reports whether an order query failed because the order no longer exists
+// isOrderNotFoundError 判断订单查询失败是否因为订单已不存在
+func isOrderNotFoundError(err error) bool {
+	errMsg := err.Error()
+	return strings.Contains(errMsg, "Unknown order") ||
+		strings.Contains(errMsg, "Order does not exist") ||
+		strings.Contains(errMsg, "-2011") // Binance error code for unknown order
+}
+
 // Helper function to parse int64
 // 辅助函数：解析 int64
 func parseInt64(s string) int64 {
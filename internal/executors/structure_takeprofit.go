@@ -0,0 +1,106 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// Take-profit ladder modes
+// 分批止盈阶梯模式
+const (
+	TakeProfitModeRMultiple = "r_multiple" // 在 1R/2R/3R 止盈 / Take profit at 1R, 2R and 3R
+	TakeProfitModeStructure = "structure"  // 在检测到的结构位止盈 / Take profit at detected structure levels
+)
+
+// structureLadderPercentages are the close fractions by number of structural targets, matching 30/30/40 at three
+// structureLadderPercentages 是按结构位目标数量划分的平仓比例，三个目标时与 30/30/40 一致
+var structureLadderPercentages = [][]float64{
+	1: {1.0},
+	2: {0.5, 0.5},
+	3: {0.30, 0.30, 0.40},
+}
+
+// structureMergeR is how close two levels may sit, in R, before the farther one is dropped as a duplicate
+// structureMergeR 是两个结构位之间的最小间距（R 倍数），更近时较远的一个视为重复而舍弃
+const structureMergeR = 0.25
+
+// UpdateStructureLevels stores the latest structure levels of a symbol for the next entry's ladder
+// UpdateStructureLevels 保存交易对最新的结构位，供下次开仓设置止盈阶梯
+func (tm *TakeProfitManager) UpdateStructureLevels(symbol string, levels []dataflows.StructureLevel) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.structure[tm.config.GetBinanceSymbolFor(symbol)] = levels
+}
+
+// structureTakeProfitLevels builds the ladder from the symbol's structure levels, or returns nil when none qualify
+// structureTakeProfitLevels 根据交易对的结构位构建止盈阶梯，没有合适的结构位时返回 nil
+//
+// Stops step up as in the R-multiple ladder: breakeven after the first target, then the previous target.
+// 止损的上移方式与 R 倍数阶梯一致：第一个目标后移至保本，之后移至上一个目标价。
+func (tm *TakeProfitManager) structureTakeProfitLevels(pos *Position, riskDistance float64) []*TakeProfitLevel {
+	tm.mu.RLock()
+	levels := tm.structure[tm.config.GetBinanceSymbolFor(pos.Symbol)]
+	tm.mu.RUnlock()
+
+	targets := structureTargets(levels, pos.Side, pos.EntryPrice, riskDistance, tm.config.TakeProfitStructureMinR)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	ladder := make([]*TakeProfitLevel, len(targets))
+	for i, target := range targets {
+		ladder[i] = &TakeProfitLevel{
+			Level:           i + 1,
+			RiskRewardRatio: math.Abs(target.Price-pos.EntryPrice) / riskDistance,
+			Percentage:      structureLadderPercentages[len(targets)][i],
+			TargetPrice:     target.Price,
+			NewStopLoss:     pos.EntryPrice,
+		}
+		if i > 0 {
+			ladder[i].NewStopLoss = ladder[i-1].TargetPrice
+		}
+	}
+	return ladder
+}
+
+// structureTargets picks up to three levels beyond the entry in the profit direction, nearest first, at least minR away
+// structureTargets 在盈利方向上选取入场价之外、距离至少 minR 的最多三个结构位，由近及远
+func structureTargets(levels []dataflows.StructureLevel, side string, entry, riskDistance, minR float64) []dataflows.StructureLevel {
+	if riskDistance <= 0 {
+		return nil
+	}
+	direction := 1.0
+	if side == "short" {
+		direction = -1
+	}
+
+	var targets []dataflows.StructureLevel
+	lastDistance := math.Inf(-1)
+	for i := range levels {
+		// Walk outward from the entry: ascending for a long, descending for a short
+		// 从入场价向外遍历：多仓按价格升序，空仓按价格降序
+		level := levels[i]
+		if side == "short" {
+			level = levels[len(levels)-1-i]
+		}
+		distance := (level.Price - entry) * direction
+		if distance < minR*riskDistance || distance-lastDistance < structureMergeR*riskDistance {
+			continue
+		}
+		targets = append(targets, level)
+		lastDistance = distance
+		if len(targets) == len(structureLadderPercentages)-1 {
+			break
+		}
+	}
+	return targets
+}
+
+// UpdateStructureLevels refreshes the structure levels a symbol's next structure-mode ladder is placed at
+// UpdateStructureLevels 刷新交易对下次结构位止盈阶梯所用的结构位
+func (sm *StopLossManager) UpdateStructureLevels(symbol string, levels []dataflows.StructureLevel) {
+	sm.takeProfitMgr.UpdateStructureLevels(symbol, levels)
+	sm.logger.Debug(fmt.Sprintf("【%s】📍 结构位已更新: %d 个", symbol, len(levels)))
+}
@@ -0,0 +1,50 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+func TestStructureTakeProfitLevels(t *testing.T) {
+	cfg := &config.Config{TakeProfitModes: map[string]string{"BTCUSDT": TakeProfitModeStructure}, TakeProfitStructureMinR: 1}
+	tm := NewTakeProfitManager(cfg, nil, logger.NewColorLogger(false), nil)
+	prices := []float64{88, 94, 98, 103, 106, 107, 112, 120, 130}
+	levels := make([]dataflows.StructureLevel, len(prices))
+	for i, p := range prices {
+		levels[i] = dataflows.StructureLevel{Price: p, Kind: dataflows.StructureSwingHigh}
+	}
+	tm.UpdateStructureLevels("BTC/USDT", levels)
+
+	// R is 5: 103 is under 1R and 107 sits within 0.25R of 106, so the nearest three are 106, 112 and 120
+	// R 为 5：103 不足 1R，107 与 106 相距不足 0.25R，因此最近的三个目标为 106、112 和 120
+	long := &Position{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, InitialStopLoss: 95}
+	tm.InitializeTakeProfitLevels(long)
+	got := long.TakeProfitConfig.Levels
+	if len(got) != 3 || got[0].TargetPrice != 106 || got[1].TargetPrice != 112 || got[2].TargetPrice != 120 {
+		t.Fatalf("Expected targets 106/112/120, got %+v", got)
+	}
+	if got[0].NewStopLoss != 100 || got[2].NewStopLoss != 112 || got[2].Percentage != 0.40 || got[1].RiskRewardRatio != 2.4 {
+		t.Errorf("Unexpected ladder %+v %+v %+v", got[0], got[1], got[2])
+	}
+
+	// Only two levels lie at least 1R below a short, so each closes half
+	// 空仓下方只有两个结构位达到 1R，因此各平一半
+	short := &Position{Symbol: "BTCUSDT", Side: "short", EntryPrice: 100, InitialStopLoss: 105}
+	tm.InitializeTakeProfitLevels(short)
+	got = short.TakeProfitConfig.Levels
+	if len(got) != 2 || got[0].TargetPrice != 94 || got[1].TargetPrice != 88 || got[0].Percentage != 0.5 {
+		t.Fatalf("Expected short targets 94/88 at 50%% each, got %+v", got)
+	}
+
+	// Without levels the R-multiple ladder applies
+	// 没有结构位时使用 R 倍数阶梯
+	eth := &Position{Symbol: "ETHUSDT", Side: "long", EntryPrice: 100, InitialStopLoss: 95}
+	cfg.TakeProfitModes["ETHUSDT"] = TakeProfitModeStructure
+	tm.InitializeTakeProfitLevels(eth)
+	if got := eth.TakeProfitConfig.Levels; len(got) != 3 || got[0].TargetPrice != 105 || got[2].TargetPrice != 115 {
+		t.Errorf("Expected the 1R/2R/3R fallback, got %+v", got)
+	}
+}
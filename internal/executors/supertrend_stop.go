@@ -0,0 +1,71 @@
+package executors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+)
+
+// SuperTrend defaults (Olivier Seban's 10 bars and 3 ATR)
+// SuperTrend 默认参数（Olivier Seban 的 10 根 K 线与 3 倍 ATR）
+const (
+	defaultSuperTrendPeriod     = 10
+	defaultSuperTrendMultiplier = 3.0
+)
+
+// CalculateSuperTrendStop calculates the SuperTrend stop of a position from recent bars
+// CalculateSuperTrendStop 根据近期 K 线计算持仓的 SuperTrend 止损价
+//
+// The SuperTrend band only tightens while the trend holds, so it makes a steady ratchet for trending pairs. When the
+// line has flipped to the other side of the position there is no valid stop and an error is returned.
+// SuperTrend 轨道在趋势延续时只收紧不放宽，适合作为趋势交易对的棘轮止损。该线已翻转到持仓另一侧时没有有效止损价，返回错误。
+func (calc *TrailingStopCalculator) CalculateSuperTrendStop(symbol string, bars []dataflows.OHLCV, side string) (float64, error) {
+	config := calc.GetConfig(symbol)
+	period, multiplier := config.SuperTrendPeriod, config.SuperTrendMultiplier
+	if period <= 0 {
+		period = defaultSuperTrendPeriod
+	}
+	if multiplier <= 0 {
+		multiplier = defaultSuperTrendMultiplier
+	}
+
+	line, long, ok := dataflows.SuperTrend(bars, period, multiplier)
+	if !ok {
+		return 0, fmt.Errorf("need at least %d bars for SuperTrend(%d), got %d", period+2, period, len(bars))
+	}
+	if long != (side == "long") {
+		return 0, fmt.Errorf("SuperTrend %.2f has flipped against the %s position", line, side)
+	}
+
+	if calc.logger != nil {
+		calc.logger.Info(fmt.Sprintf("【%s】计算 SuperTrend 止损: 周期=%d, 倍数=%.1f, 止损价=%.2f", symbol, period, multiplier, line))
+	}
+	return line, nil
+}
+
+// UpdateSuperTrendStop trails a position's stop with the SuperTrend line of the given bars
+// UpdateSuperTrendStop 使用给定 K 线的 SuperTrend 线追踪持仓止损
+func (sm *StopLossManager) UpdateSuperTrendStop(ctx context.Context, symbol string, bars []dataflows.OHLCV) error {
+	normalizedSymbol := sm.config.GetBinanceSymbolFor(symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[normalizedSymbol]
+	if !exists {
+		sm.mu.RUnlock()
+		return nil
+	}
+	side, currentStopLoss, stopLossType := pos.Side, pos.CurrentStopLoss, pos.StopLossType
+	sm.mu.RUnlock()
+
+	if stopLossType == StopLossTypeNativeTrailing {
+		return nil
+	}
+
+	newStopLoss, err := sm.calculator.CalculateSuperTrendStop(symbol, bars, side)
+	if err != nil {
+		sm.logger.Warning(fmt.Sprintf("【%s】⚠️ 无法计算 SuperTrend 止损，保持当前止损: %v", symbol, err))
+		return nil
+	}
+	return sm.applyTrailingStop(ctx, symbol, side, currentStopLoss, newStopLoss, "SuperTrend 止损自动调整")
+}
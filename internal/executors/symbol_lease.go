@@ -0,0 +1,231 @@
+package executors
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Symbol lease timing
+// 交易对租约时间参数
+const (
+	SymbolLeaseTTL       = 90 * time.Second // 心跳超过该时间未更新视为实例已退出 / A holder silent for this long is considered gone
+	symbolLeaseHeartbeat = 30 * time.Second // 续约间隔 / Renewal interval
+)
+
+// clientOrderPrefix starts every client order ID the bot generates
+// clientOrderPrefix 是机器人生成的所有客户端订单 ID 的前缀
+const clientOrderPrefix = "ctb_"
+
+// instanceTag derives a short, stable tag from an instance ID for embedding in client order IDs
+// instanceTag 由实例标识派生出简短且稳定的标签，用于嵌入客户端订单 ID
+func instanceTag(instanceID string) string {
+	sum := sha256.Sum256([]byte(instanceID))
+	return hex.EncodeToString(sum[:3])
+}
+
+// resolveInstanceID returns the configured instance ID, or the hostname when none is set
+// resolveInstanceID 返回配置的实例标识，未配置时使用主机名
+func resolveInstanceID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return "default"
+}
+
+// parseClientOrderTag extracts the instance tag from a client order ID generated by newClientOrderID
+// parseClientOrderTag 从 newClientOrderID 生成的客户端订单 ID 中提取实例标签
+func parseClientOrderTag(clientOrderID string) (string, bool) {
+	if !strings.HasPrefix(clientOrderID, clientOrderPrefix) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(clientOrderID, clientOrderPrefix), "_")
+	if len(parts) != 3 {
+		return "", false
+	}
+	return parts[0], true
+}
+
+// foreignInstanceOrders lists open orders on a symbol that were placed by a different bot instance
+// foreignInstanceOrders 列出交易对上由其他机器人实例下的未成交订单
+// This catches a second instance on another host, which does not share the lease database
+// 用于发现运行在其他主机、不共享租约数据库的第二个实例
+func (e *BinanceExecutor) foreignInstanceOrders(ctx context.Context, symbol string) ([]string, error) {
+	binanceSymbol := e.config.GetBinanceSymbolFor(symbol)
+	orders, err := e.client.NewListOpenOrdersService().Symbol(binanceSymbol).Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open orders: %w", err)
+	}
+
+	var foreign []string
+	for _, o := range orders {
+		if tag, ok := parseClientOrderTag(o.ClientOrderID); ok && tag != e.instanceTag {
+			foreign = append(foreign, o.ClientOrderID)
+		}
+	}
+	return foreign, nil
+}
+
+// SymbolLeaseManager makes sure only one running instance manages each symbol of an account
+// SymbolLeaseManager 确保同一账户的每个交易对只由一个运行中的实例管理
+//
+// Leases live in the database and are renewed by a heartbeat; a crashed instance's leases expire after SymbolLeaseTTL.
+// 租约保存在数据库中并通过心跳续约；崩溃实例的租约在 SymbolLeaseTTL 后过期。
+type SymbolLeaseManager struct {
+	storage  *storage.Storage    // 数据库 / Database
+	executor *BinanceExecutor    // 执行器 / Executor
+	logger   *logger.ColorLogger // 日志 / Logger
+	owner    string              // 本进程令牌 / This process's token
+	hostname string              // 主机名 / Hostname
+	held     map[string]bool     // 已持有的交易对 / Symbols currently held
+	mu       sync.RWMutex
+}
+
+// NewSymbolLeaseManager creates a lease manager and attaches it to the executor
+// NewSymbolLeaseManager 创建租约管理器并关联到执行器
+func NewSymbolLeaseManager(db *storage.Storage, executor *BinanceExecutor, log *logger.ColorLogger) *SymbolLeaseManager {
+	token := make([]byte, 8)
+	rand.Read(token)
+	hostname, _ := os.Hostname()
+
+	m := &SymbolLeaseManager{
+		storage:  db,
+		executor: executor,
+		logger:   log,
+		owner:    hex.EncodeToString(token),
+		hostname: hostname,
+		held:     make(map[string]bool),
+	}
+	executor.leases = m
+	return m
+}
+
+// Acquire takes the lease on each symbol and returns the symbols this instance may manage
+// Acquire 获取每个交易对的租约，返回本实例可以管理的交易对
+func (m *SymbolLeaseManager) Acquire(ctx context.Context, symbols []string) []string {
+	var granted []string
+	for _, symbol := range symbols {
+		binanceSymbol := m.executor.config.GetBinanceSymbolFor(symbol)
+
+		holder, err := m.renew(binanceSymbol)
+		if err != nil {
+			m.logger.Error(fmt.Sprintf("❌【%s】获取交易对租约失败: %v", binanceSymbol, err))
+			continue
+		}
+		if holder.Owner != m.owner {
+			m.logger.Error(fmt.Sprintf("❌【%s】已由另一个实例管理（主机 %s, PID %d, 最近心跳 %s），本实例跳过该交易对",
+				binanceSymbol, holder.Hostname, holder.PID, holder.HeartbeatAt.Format("15:04:05")))
+			continue
+		}
+
+		// Another host does not share our database, but its orders carry its instance tag
+		// 其他主机不共享数据库，但其订单带有实例标签
+		if foreign, err := m.executor.foreignInstanceOrders(ctx, symbol); err != nil {
+			m.logger.Warning(fmt.Sprintf("⚠️ 【%s】无法检查其他实例的订单: %v", binanceSymbol, err))
+		} else if len(foreign) > 0 {
+			m.logger.Error(fmt.Sprintf("❌【%s】发现其他实例的挂单 %v，本实例跳过该交易对；如为旧实例遗留，请手动撤单或设置相同的 BOT_INSTANCE_ID",
+				binanceSymbol, foreign))
+			m.storage.ReleaseSymbolLease(binanceSymbol, m.owner)
+			continue
+		}
+
+		m.mu.Lock()
+		m.held[binanceSymbol] = true
+		m.mu.Unlock()
+		granted = append(granted, symbol)
+	}
+	return granted
+}
+
+// Holds reports whether this instance currently manages the symbol
+// Holds 返回本实例当前是否管理该交易对
+func (m *SymbolLeaseManager) Holds(symbol string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.held[m.executor.config.GetBinanceSymbolFor(symbol)]
+}
+
+// Run renews held leases until ctx is cancelled, then releases them
+// Run 持续续约已持有的租约直到 ctx 被取消，然后释放
+func (m *SymbolLeaseManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(symbolLeaseHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.Release()
+			return
+		case <-ticker.C:
+			m.heartbeat()
+		}
+	}
+}
+
+// heartbeat renews every held lease and stops managing symbols whose lease was taken over
+// heartbeat 续约所有已持有的租约，租约被接管的交易对停止管理
+func (m *SymbolLeaseManager) heartbeat() {
+	m.mu.RLock()
+	symbols := make([]string, 0, len(m.held))
+	for symbol := range m.held {
+		symbols = append(symbols, symbol)
+	}
+	m.mu.RUnlock()
+
+	for _, symbol := range symbols {
+		holder, err := m.renew(symbol)
+		if err != nil {
+			m.logger.Warning(fmt.Sprintf("⚠️ 【%s】租约续约失败: %v", symbol, err))
+			continue
+		}
+		if holder.Owner != m.owner {
+			m.mu.Lock()
+			delete(m.held, symbol)
+			m.mu.Unlock()
+			m.logger.Error(fmt.Sprintf("🚨【%s】租约已被另一个实例接管（主机 %s, PID %d），本实例停止管理该交易对",
+				symbol, holder.Hostname, holder.PID))
+		}
+	}
+}
+
+// Release drops all leases held by this instance, letting another instance take over immediately
+// Release 释放本实例持有的所有租约，使其他实例可以立即接管
+func (m *SymbolLeaseManager) Release() {
+	if err := m.storage.ReleaseSymbolLeases(m.owner); err != nil {
+		m.logger.Warning(fmt.Sprintf("⚠️  释放交易对租约失败: %v", err))
+	}
+	m.mu.Lock()
+	m.held = make(map[string]bool)
+	m.mu.Unlock()
+}
+
+func (m *SymbolLeaseManager) renew(binanceSymbol string) (*storage.SymbolLease, error) {
+	return m.storage.AcquireSymbolLease(&storage.SymbolLease{
+		Symbol:     binanceSymbol,
+		Owner:      m.owner,
+		InstanceID: m.executor.instanceID,
+		Hostname:   m.hostname,
+		PID:        os.Getpid(),
+	}, SymbolLeaseTTL)
+}
+
+// ManagesSymbol reports whether this executor may act on the symbol; always true without a lease manager
+// ManagesSymbol 返回本执行器是否可以操作该交易对；未启用租约管理时始终为 true
+func (e *BinanceExecutor) ManagesSymbol(symbol string) bool {
+	if e.leases == nil {
+		return true
+	}
+	return e.leases.Holds(symbol)
+}
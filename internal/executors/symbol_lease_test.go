@@ -0,0 +1,49 @@
+package executors
+
+import (
+	"testing"
+)
+
+func TestInstanceTag(t *testing.T) {
+	a := instanceTag("bot-a")
+	if a != instanceTag("bot-a") {
+		t.Error("Expected the same tag for the same instance ID")
+	}
+	if a == instanceTag("bot-b") {
+		t.Error("Expected different tags for different instance IDs")
+	}
+	if len(a) != 6 {
+		t.Errorf("Expected a 6-character tag, got %q", a)
+	}
+}
+
+func TestParseClientOrderTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		wantTag string
+		wantOK  bool
+	}{
+		{name: "Tagged order", id: "ctb_a1b2c3_1700000000000_42", wantTag: "a1b2c3", wantOK: true},
+		{name: "Untagged legacy order", id: "ctb_1700000000000_42", wantOK: false},
+		{name: "Manual order", id: "web_abc123", wantOK: false},
+		{name: "Empty", id: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, ok := parseClientOrderTag(tt.id)
+			if ok != tt.wantOK || tag != tt.wantTag {
+				t.Errorf("parseClientOrderTag(%q) = (%q, %v), want (%q, %v)", tt.id, tag, ok, tt.wantTag, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestManagesSymbolWithoutLeases(t *testing.T) {
+	// 未启用租约管理时（如 Web 接口创建的执行器），所有交易对都可以操作
+	e := &BinanceExecutor{}
+	if !e.ManagesSymbol("BTC/USDT") {
+		t.Error("Expected every symbol to be managed without a lease manager")
+	}
+}
@@ -0,0 +1,151 @@
+package executors
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// SymbolPauseRegistry pauses new entries on individual symbols, e.g. ahead of a token unlock
+// SymbolPauseRegistry 暂停单个交易对的新开仓，例如在代币解锁之前
+//
+// Pauses live in the database so the web API and the trading loop see the same state across restarts.
+// A pause with a resume time is lifted the first time it is read after that time.
+// 暂停保存在数据库中，Web API 与交易循环在重启后看到一致的状态。
+// 设置了恢复时间的暂停在到期后首次读取时自动解除。
+type SymbolPauseRegistry struct {
+	storage *storage.Storage    // 数据库 / Database
+	config  *config.Config      // 配置（交易对格式转换）/ Config (symbol normalization)
+	logger  *logger.ColorLogger // 日志 / Logger
+	now     func() time.Time    // 时钟（测试可替换）/ Clock, replaceable in tests
+}
+
+// NewSymbolPauseRegistry creates a pause registry backed by the database
+// NewSymbolPauseRegistry 创建基于数据库的暂停登记表
+func NewSymbolPauseRegistry(db *storage.Storage, cfg *config.Config, log *logger.ColorLogger) *SymbolPauseRegistry {
+	return &SymbolPauseRegistry{storage: db, config: cfg, logger: log, now: time.Now}
+}
+
+// Pause stops new entries on a symbol until Resume is called or resumeAt passes; a nil resumeAt pauses indefinitely
+// Pause 暂停交易对的新开仓，直到调用 Resume 或到达 resumeAt；resumeAt 为 nil 时无限期暂停
+func (r *SymbolPauseRegistry) Pause(symbol, reason string, resumeAt *time.Time) (*storage.SymbolPause, error) {
+	now := r.now()
+	if resumeAt != nil && !resumeAt.After(now) {
+		return nil, fmt.Errorf("resume time %s is not in the future", resumeAt.Format(time.RFC3339))
+	}
+	pause := &storage.SymbolPause{
+		Symbol:   r.config.GetBinanceSymbolFor(symbol),
+		Reason:   reason,
+		PausedAt: now,
+		ResumeAt: resumeAt,
+	}
+	if err := r.storage.SaveSymbolPause(pause); err != nil {
+		return nil, err
+	}
+
+	until := "手动恢复"
+	if resumeAt != nil {
+		until = resumeAt.In(r.config.Location()).Format("2006-01-02 15:04")
+	}
+	r.logger.Warning(fmt.Sprintf("【%s】⏸️  已暂停开仓（%s），恢复: %s", pause.Symbol, reason, until))
+	return pause, nil
+}
+
+// Resume lifts the pause on a symbol
+// Resume 解除交易对的暂停
+func (r *SymbolPauseRegistry) Resume(symbol string) error {
+	binanceSymbol := r.config.GetBinanceSymbolFor(symbol)
+	if err := r.storage.DeleteSymbolPause(binanceSymbol); err != nil {
+		return err
+	}
+	r.logger.Success(fmt.Sprintf("【%s】▶️  已恢复交易", binanceSymbol))
+	return nil
+}
+
+// List returns the active pauses, lifting any whose resume time has passed
+// List 返回生效中的暂停，并解除已到恢复时间的暂停
+func (r *SymbolPauseRegistry) List() ([]*storage.SymbolPause, error) {
+	pauses, err := r.storage.GetSymbolPauses()
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now()
+	active := make([]*storage.SymbolPause, 0, len(pauses))
+	for _, p := range pauses {
+		if p.ResumeAt != nil && !p.ResumeAt.After(now) {
+			if err := r.storage.DeleteSymbolPause(p.Symbol); err != nil {
+				r.logger.Warning(fmt.Sprintf("【%s】⚠️  自动恢复失败: %v", p.Symbol, err))
+				active = append(active, p)
+				continue
+			}
+			r.logger.Success(fmt.Sprintf("【%s】▶️  已到预定时间，自动恢复交易", p.Symbol))
+			continue
+		}
+		active = append(active, p)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Symbol < active[j].Symbol })
+	return active, nil
+}
+
+// Active returns the pause on a symbol, or nil when the symbol may trade
+// Active 返回交易对的暂停信息，可以交易时返回 nil
+func (r *SymbolPauseRegistry) Active(symbol string) (*storage.SymbolPause, error) {
+	pauses, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	binanceSymbol := r.config.GetBinanceSymbolFor(symbol)
+	for _, p := range pauses {
+		if p.Symbol == binanceSymbol {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetSymbolPauses attaches a pause registry so the executor can refuse entries on paused symbols
+// SetSymbolPauses 关联暂停登记表，使执行器拒绝已暂停交易对的开仓
+func (e *BinanceExecutor) SetSymbolPauses(pauses *SymbolPauseRegistry) {
+	e.pauses = pauses
+}
+
+// SymbolPaused reports whether new entries on the symbol are paused; always false without a registry
+// SymbolPaused 返回交易对的新开仓是否已暂停；未关联登记表时始终为 false
+// A registry read error counts as paused, so a broken database cannot let entries through
+// 读取登记表出错时视为已暂停，避免数据库故障导致放行开仓
+func (e *BinanceExecutor) SymbolPaused(symbol string) (bool, string) {
+	if e.pauses == nil {
+		return false, ""
+	}
+	pause, err := e.pauses.Active(symbol)
+	if err != nil {
+		return true, fmt.Sprintf("无法读取暂停状态: %v", err)
+	}
+	if pause == nil {
+		return false, ""
+	}
+	if pause.ResumeAt != nil {
+		return true, fmt.Sprintf("%s（%s 恢复）", pause.Reason, pause.ResumeAt.In(e.pauses.config.Location()).Format("2006-01-02 15:04"))
+	}
+	return true, pause.Reason
+}
+
+// FilterPausedSymbols splits symbols into those to analyze this cycle and those skipped for a pause
+// FilterPausedSymbols 将交易对分为本轮需要分析的和因暂停而跳过的
+// A paused symbol with an open position is still analyzed so the position can be managed and closed
+// 已暂停但仍有持仓的交易对继续分析，以便管理和平仓
+func (e *BinanceExecutor) FilterPausedSymbols(symbols []string, hasPosition func(symbol string) bool) (active, skipped []string) {
+	for _, symbol := range symbols {
+		if paused, _ := e.SymbolPaused(symbol); paused && (hasPosition == nil || !hasPosition(symbol)) {
+			skipped = append(skipped, symbol)
+			continue
+		}
+		active = append(active, symbol)
+	}
+	return active, skipped
+}
@@ -0,0 +1,73 @@
+package executors
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestSymbolPauseRegistry(t *testing.T) {
+	db, err := storage.NewStorage(filepath.Join(t.TempDir(), "pauses.db"))
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	registry := NewSymbolPauseRegistry(db, &config.Config{}, logger.NewColorLogger(false))
+	registry.now = func() time.Time { return now }
+	executor := &BinanceExecutor{pauses: registry}
+
+	if _, err := registry.Pause("BTC/USDT", "late", &now); err == nil {
+		t.Error("Expected a resume time that is not in the future to be rejected")
+	}
+
+	resumeAt := now.Add(24 * time.Hour)
+	if _, err := registry.Pause("SOL/USDT", "token unlock", &resumeAt); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if _, err := registry.Pause("ETHUSDT", "manual", nil); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	if paused, reason := executor.SymbolPaused("SOLUSDT"); !paused || reason == "" {
+		t.Errorf("Expected SOLUSDT to be paused with a reason, got %v %q", paused, reason)
+	}
+	if paused, _ := executor.SymbolPaused("BTC/USDT"); paused {
+		t.Error("Expected BTC/USDT to trade")
+	}
+
+	// A paused symbol with an open position is still analyzed
+	// 已暂停但有持仓的交易对仍参与分析
+	active, skipped := executor.FilterPausedSymbols([]string{"BTC/USDT", "ETH/USDT", "SOL/USDT"}, func(symbol string) bool {
+		return symbol == "ETH/USDT"
+	})
+	if len(active) != 2 || active[1] != "ETH/USDT" || len(skipped) != 1 || skipped[0] != "SOL/USDT" {
+		t.Errorf("Unexpected split: active=%v skipped=%v", active, skipped)
+	}
+
+	// Passing the resume time lifts the pause and removes it from storage
+	// 到达恢复时间后暂停解除并从数据库删除
+	now = resumeAt
+	if paused, _ := executor.SymbolPaused("SOL/USDT"); paused {
+		t.Error("Expected SOL/USDT to resume at its scheduled time")
+	}
+	stored, err := db.GetSymbolPauses()
+	if err != nil || len(stored) != 1 || stored[0].Symbol != "ETHUSDT" || stored[0].ResumeAt != nil {
+		t.Fatalf("Expected only the manual ETHUSDT pause to remain, got %+v (%v)", stored, err)
+	}
+
+	if err := registry.Resume("ETH/USDT"); err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if pauses, _ := registry.List(); len(pauses) != 0 {
+		t.Errorf("Expected no pauses after resume, got %+v", pauses)
+	}
+	if paused, _ := (&BinanceExecutor{}).SymbolPaused("ETHUSDT"); paused {
+		t.Error("Expected an executor without a registry never to report a pause")
+	}
+}
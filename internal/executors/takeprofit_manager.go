@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/notify"
 	"github.com/oak/crypto-trading-bot/internal/storage"
 )
 
@@ -23,6 +25,7 @@ type TakeProfitLevel struct {
 	ExecutedTime   *time.Time // 执行时间 / Execution time
 	ExecutedPrice  float64 // 实际执行价格 / Actual execution price
 	NewStopLoss    float64 // 执行后新止损价 / New stop-loss after execution
+	OrderID        int64   // 交易所挂着的止盈单 ID（括号单开仓），0 表示本地监控 / Resting exchange take-profit order ID (bracket entry), 0 means monitored locally
 }
 
 // TakeProfitConfig represents the configuration for partial take-profit
@@ -50,7 +53,10 @@ type TakeProfitManager struct {
 	config   *config.Config
 	logger   *logger.ColorLogger
 	storage  *storage.Storage
+	notifier *notify.Notifier // 事件通知，可为 nil / Event notifications, may be nil
 	mu       sync.RWMutex
+
+	structure map[string][]dataflows.StructureLevel // 交易对 → 最近检测到的结构位 / Symbol → latest detected structure levels
 }
 
 // NewTakeProfitManager creates a new TakeProfitManager
@@ -61,6 +67,8 @@ func NewTakeProfitManager(cfg *config.Config, executor *BinanceExecutor, log *lo
 		config:   cfg,
 		logger:   log,
 		storage:  db,
+
+		structure: make(map[string][]dataflows.StructureLevel),
 	}
 }
 
@@ -84,6 +92,21 @@ func (tm *TakeProfitManager) InitializeTakeProfitLevels(pos *Position) {
 	// 计算风险距离（入场价到初始止损的距离）
 	riskDistance := math.Abs(pos.EntryPrice - pos.InitialStopLoss)
 
+	// Symbols in structure mode take profit at the detected levels, falling back to R multiples when none qualify
+	// 结构位模式的交易对在检测到的结构位止盈，没有合适的结构位时回退到 R 倍数
+	if tm.config.TakeProfitModeFor(pos.Symbol) == TakeProfitModeStructure {
+		if levels := tm.structureTakeProfitLevels(pos, riskDistance); levels != nil {
+			pos.TakeProfitConfig = &TakeProfitConfig{Enabled: true, Levels: levels}
+			tm.logger.Success(fmt.Sprintf("【%s】结构位分批止盈已初始化 (风险距离: %.2f)", pos.Symbol, riskDistance))
+			for _, level := range levels {
+				tm.logger.Info(fmt.Sprintf("  级别 %d: %.0f%% @ $%.2f (%.1fR) → 止损移至 $%.2f",
+					level.Level, level.Percentage*100, level.TargetPrice, level.RiskRewardRatio, level.NewStopLoss))
+			}
+			return
+		}
+		tm.logger.Warning(fmt.Sprintf("【%s】⚠️  没有距入场至少 %.1fR 的结构位，止盈回退到 1R/2R/3R", pos.Symbol, tm.config.TakeProfitStructureMinR))
+	}
+
 	// Default configuration: 3 levels
 	// 默认配置：3个级别
 	// Level 1: 30% at 1R (risk-reward ratio 1:1)
@@ -201,43 +224,70 @@ func (tm *TakeProfitManager) MonitorAndExecute(ctx context.Context, pos *Positio
 			continue
 		}
 
-		// Check if target price is reached
-		// 检查是否达到目标价格
-		targetReached := false
-		if pos.Side == "long" {
-			targetReached = currentPrice >= level.TargetPrice
-		} else {
-			targetReached = currentPrice <= level.TargetPrice
-		}
+		var closePrice, closeQuantity float64
+		filledOnExchange := false
 
-		if !targetReached {
-			// Target not reached, skip to next level
-			// 目标未达到，跳过到下一级
-			continue
+		// A bracket entry level rests on the exchange; only its fill is picked up here
+		// 括号单开仓的级别挂在交易所，这里只同步其成交
+		if level.OrderID != 0 {
+			price, quantity, resting, err := tm.syncTakeProfitOrder(ctx, pos, level)
+			if err != nil {
+				tm.logger.Warning(fmt.Sprintf("⚠️【%s】查询止盈级别 %d 订单失败: %v", pos.Symbol, level.Level, err))
+				continue
+			}
+			if resting {
+				continue
+			}
+			if quantity > 0 {
+				closePrice, closeQuantity = price, quantity
+				filledOnExchange = true
+				tm.logger.Info(fmt.Sprintf("【%s】🎯 止盈级别 %d 已由交易所成交 @ $%.2f",
+					pos.Symbol, level.Level, closePrice))
+			}
 		}
 
-		// Execute partial close
-		// 执行部分平仓
-		tm.logger.Info(fmt.Sprintf("【%s】🎯 触发止盈级别 %d: 当前价 $%.2f >= 目标价 $%.2f",
-			pos.Symbol, level.Level, currentPrice, level.TargetPrice))
+		if !filledOnExchange {
+			// Check if target price is reached
+			// 检查是否达到目标价格
+			targetReached := false
+			if pos.Side == "long" {
+				targetReached = currentPrice >= level.TargetPrice
+			} else {
+				targetReached = currentPrice <= level.TargetPrice
+			}
+
+			if !targetReached {
+				// Target not reached, skip to next level
+				// 目标未达到，跳过到下一级
+				continue
+			}
 
-		// Calculate close quantity
-		// 计算平仓数量
-		closeQuantity := pos.Quantity * level.Percentage
+			// Execute partial close
+			// 执行部分平仓
+			tm.logger.Info(fmt.Sprintf("【%s】🎯 触发止盈级别 %d: 当前价 $%.2f >= 目标价 $%.2f",
+				pos.Symbol, level.Level, currentPrice, level.TargetPrice))
 
-		// Execute close order
-		// 执行平仓订单
-		action := ActionCloseLong
-		if pos.Side == "short" {
-			action = ActionCloseShort
-		}
+			// Calculate close quantity
+			// 计算平仓数量
+			closeQuantity = pos.Quantity * level.Percentage
 
-		result := tm.executor.ExecuteTrade(ctx, pos.Symbol, action, closeQuantity,
-			fmt.Sprintf("分批止盈级别%d (%.1fR)", level.Level, level.RiskRewardRatio))
+			// Execute close order
+			// 执行平仓订单
+			action := ActionCloseLong
+			if pos.Side == "short" {
+				action = ActionCloseShort
+			}
 
-		if !result.Success {
-			tm.logger.Error(fmt.Sprintf("❌ 执行止盈失败: %s", result.Message))
-			return executedCount, fmt.Errorf("执行止盈失败: %s", result.Message)
+			reason := fmt.Sprintf("分批止盈级别%d (%.1fR)", level.Level, level.RiskRewardRatio)
+			transitionPosition(tm.logger, pos, StateReducing, reason)
+			result := tm.executor.ExecuteTrade(ctx, pos.Symbol, action, closeQuantity, reason)
+
+			if !result.Success {
+				finishReduce(tm.logger, pos, 0, "止盈失败")
+				tm.logger.Error(fmt.Sprintf("❌ 执行止盈失败: %s", result.Message))
+				return executedCount, fmt.Errorf("执行止盈失败: %s", result.Message)
+			}
+			closePrice = result.Price
 		}
 
 		// Mark level as executed
@@ -245,11 +295,11 @@ func (tm *TakeProfitManager) MonitorAndExecute(ctx context.Context, pos *Positio
 		now := time.Now()
 		level.Executed = true
 		level.ExecutedTime = &now
-		level.ExecutedPrice = result.Price
+		level.ExecutedPrice = closePrice
 
 		// Update position quantity
 		// 更新持仓数量
-		pos.Quantity -= closeQuantity
+		finishReduce(tm.logger, pos, closeQuantity, fmt.Sprintf("止盈级别 %d 已执行", level.Level))
 
 		executedCount++
 
@@ -257,13 +307,15 @@ func (tm *TakeProfitManager) MonitorAndExecute(ctx context.Context, pos *Positio
 		// 计算此次部分平仓的已实现盈亏
 		var partialPnL float64
 		if pos.Side == "long" {
-			partialPnL = (result.Price - pos.EntryPrice) * closeQuantity
+			partialPnL = (closePrice - pos.EntryPrice) * closeQuantity
 		} else {
-			partialPnL = (pos.EntryPrice - result.Price) * closeQuantity
+			partialPnL = (pos.EntryPrice - closePrice) * closeQuantity
 		}
 
 		tm.logger.Success(fmt.Sprintf("✅【%s】止盈级别 %d 已执行: 平仓 %.4f (%.0f%%) @ $%.2f, 盈亏: %+.2f USDT",
-			pos.Symbol, level.Level, closeQuantity, level.Percentage*100, result.Price, partialPnL))
+			pos.Symbol, level.Level, closeQuantity, level.Percentage*100, closePrice, partialPnL))
+		tm.notifier.Notify(notify.SeverityInfo, pos.Symbol, fmt.Sprintf("🎯 止盈级别 %d 触发: 平仓 %.0f%% @ %.2f，盈亏 %+.2f USDT",
+			level.Level, level.Percentage*100, closePrice, partialPnL))
 
 		// Check if this was the last level (close entire position)
 		// 检查是否是最后一个级别（关闭整个持仓）
@@ -0,0 +1,199 @@
+package executors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Deferred task types
+// 延迟任务类型
+const (
+	TaskReplaceStopLoss = "replace_stop_loss" // 重新下止损单 / Re-place a stop-loss order
+)
+
+// Task queue defaults
+// 任务队列默认参数
+const (
+	taskDefaultMaxAttempts = 8                // 最大尝试次数 / Max attempts before giving up
+	taskBackoffMin         = 5 * time.Second  // 最小退避 / Minimum backoff
+	taskBackoffMax         = 5 * time.Minute  // 最大退避 / Maximum backoff
+	taskBatchSize          = 20               // 每轮最多处理任务数 / Max tasks per round
+	taskDefaultInterval    = 10 * time.Second // 默认轮询间隔 / Default polling interval
+)
+
+// ErrTaskPermanent marks a task failure that retrying cannot fix
+// ErrTaskPermanent 表示重试无法解决的任务失败
+var ErrTaskPermanent = errors.New("permanent task failure")
+
+// TaskHandler executes one deferred task; returning nil marks it done
+// TaskHandler 执行一个延迟任务；返回 nil 表示完成
+type TaskHandler func(ctx context.Context, task *storage.PendingTask) error
+
+// TaskQueue persists actions that failed transiently and retries them with exponential backoff
+// TaskQueue 持久化因临时错误失败的动作，并按指数退避重试
+type TaskQueue struct {
+	storage  *storage.Storage       // 数据库 / Database
+	logger   *logger.ColorLogger    // 日志 / Logger
+	handlers map[string]TaskHandler // 任务类型 -> 处理函数 / Task type -> handler
+	backoff  *backoff.Backoff       // 退避策略 / Backoff policy
+	now      func() time.Time       // 时钟（测试可替换）/ Clock (replaceable in tests)
+	mu       sync.Mutex             // 保证同一时间只有一轮处理 / Ensures one processing round at a time
+}
+
+// NewTaskQueue creates a new TaskQueue
+// NewTaskQueue 创建新的任务队列
+func NewTaskQueue(db *storage.Storage, log *logger.ColorLogger) *TaskQueue {
+	return &TaskQueue{
+		storage:  db,
+		logger:   log,
+		handlers: make(map[string]TaskHandler),
+		backoff: &backoff.Backoff{
+			Min:    taskBackoffMin,
+			Max:    taskBackoffMax,
+			Factor: 2,
+			Jitter: true,
+		},
+		now: time.Now,
+	}
+}
+
+// RegisterHandler registers the handler for a task type
+// RegisterHandler 注册任务类型的处理函数
+func (q *TaskQueue) RegisterHandler(taskType string, handler TaskHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[taskType] = handler
+}
+
+// Enqueue persists a task so it is retried after the current cycle instead of being dropped
+// Enqueue 持久化任务，使其在当前周期之后重试而不是被丢弃
+func (q *TaskQueue) Enqueue(taskType, symbol string, payload interface{}, cause error) error {
+	if q.storage == nil {
+		return fmt.Errorf("task queue has no storage, cannot enqueue %s", taskType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode task payload: %w", err)
+	}
+
+	task := &storage.PendingTask{
+		TaskType:    taskType,
+		Symbol:      symbol,
+		Payload:     string(data),
+		MaxAttempts: taskDefaultMaxAttempts,
+		NextRunAt:   q.now().Add(q.backoff.ForAttempt(0)),
+	}
+	if cause != nil {
+		task.LastError = cause.Error()
+	}
+
+	if _, err := q.storage.SavePendingTask(task); err != nil {
+		return err
+	}
+
+	q.logger.Warning(fmt.Sprintf("【%s】📥 任务 %s 已加入重试队列（ID: %d），%s 后重试",
+		symbol, taskType, task.ID, task.NextRunAt.Sub(q.now()).Round(time.Second)))
+	return nil
+}
+
+// ProcessDue runs all tasks whose next run time has passed and returns how many succeeded
+// ProcessDue 执行所有已到期的任务，返回成功数量
+func (q *TaskQueue) ProcessDue(ctx context.Context) int {
+	if q.storage == nil {
+		return 0
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tasks, err := q.storage.GetDuePendingTasks(q.now(), taskBatchSize)
+	if err != nil {
+		q.logger.Warning(fmt.Sprintf("⚠️  读取重试队列失败: %v", err))
+		return 0
+	}
+
+	succeeded := 0
+	for _, task := range tasks {
+		if q.runTask(ctx, task) {
+			succeeded++
+		}
+	}
+
+	return succeeded
+}
+
+// runTask executes a single task and reschedules or finalizes it
+// runTask 执行单个任务，并重新调度或结束它
+func (q *TaskQueue) runTask(ctx context.Context, task *storage.PendingTask) bool {
+	handler, ok := q.handlers[task.TaskType]
+	if !ok {
+		task.Status = storage.TaskStatusFailed
+		task.LastError = fmt.Sprintf("no handler registered for task type %s", task.TaskType)
+		q.saveTask(task)
+		q.logger.Error(fmt.Sprintf("❌ 未知任务类型 %s（ID: %d），已放弃", task.TaskType, task.ID))
+		return false
+	}
+
+	task.Attempts++
+	err := handler(ctx, task)
+	if err == nil {
+		task.Status = storage.TaskStatusDone
+		task.LastError = ""
+		q.saveTask(task)
+		q.logger.Success(fmt.Sprintf("【%s】✅ 重试任务 %s 成功（第 %d 次尝试）", task.Symbol, task.TaskType, task.Attempts))
+		return true
+	}
+
+	task.LastError = err.Error()
+	if errors.Is(err, ErrTaskPermanent) || (task.MaxAttempts > 0 && task.Attempts >= task.MaxAttempts) {
+		task.Status = storage.TaskStatusFailed
+		q.saveTask(task)
+		q.logger.Error(fmt.Sprintf("【%s】❌ 重试任务 %s 最终失败（共 %d 次）: %v，需要人工处理",
+			task.Symbol, task.TaskType, task.Attempts, err))
+		return false
+	}
+
+	delay := q.backoff.ForAttempt(float64(task.Attempts))
+	task.NextRunAt = q.now().Add(delay)
+	q.saveTask(task)
+	q.logger.Warning(fmt.Sprintf("【%s】⚠️ 重试任务 %s 失败（第 %d/%d 次）: %v，%s 后再试",
+		task.Symbol, task.TaskType, task.Attempts, task.MaxAttempts, err, delay.Round(time.Second)))
+	return false
+}
+
+// saveTask persists task state, logging instead of failing on database errors
+// saveTask 持久化任务状态，数据库错误时仅记录日志
+func (q *TaskQueue) saveTask(task *storage.PendingTask) {
+	if err := q.storage.UpdatePendingTask(task); err != nil {
+		q.logger.Warning(fmt.Sprintf("⚠️  更新重试任务状态失败（ID: %d）: %v", task.ID, err))
+	}
+}
+
+// Run processes due tasks periodically until ctx is cancelled
+// Run 周期性处理到期任务，直到 ctx 被取消
+func (q *TaskQueue) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = taskDefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			q.ProcessDue(ctx)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package executors
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Placed-ratio bands of the threshold tuner
+// 阈值调节器的下单比例区间
+const (
+	churnPlacedRatioHigh = 0.6  // 超过该比例视为频繁撤单重下，提高阈值 / Above this the stop churns, raise the threshold
+	churnPlacedRatioLow  = 0.2  // 低于该比例视为止损滞后，降低阈值 / Below this the stop lags, lower the threshold
+	thresholdRaiseFactor = 1.25 // 每次提高的倍数 / Factor applied when raising
+	thresholdLowerFactor = 0.8  // 每次降低的倍数 / Factor applied when lowering
+)
+
+// churnStats counts trailing stop updates for one symbol within the current window
+// churnStats 统计单个交易对当前窗口内的追踪止损更新
+type churnStats struct {
+	generated int     // 朝有利方向的候选更新次数 / Candidate updates in the favorable direction
+	placed    int     // 超过阈值、实际下单的次数 / Candidates above the threshold that were placed
+	threshold float64 // 当前生效的阈值（%），0 表示尚未调节 / Threshold in effect (%), 0 until first tuned
+}
+
+// ThresholdAdjustment describes one change of a symbol's update threshold
+// ThresholdAdjustment 描述一次交易对更新阈值的调整
+type ThresholdAdjustment struct {
+	Symbol       string  // 交易对 / Trading pair
+	Generated    int     // 窗口内候选更新次数 / Candidate updates in the window
+	Placed       int     // 窗口内实际下单次数 / Orders placed in the window
+	OldThreshold float64 // 调整前阈值（%）/ Threshold before (%)
+	NewThreshold float64 // 调整后阈值（%）/ Threshold after (%)
+}
+
+// thresholdTuner adapts UpdateThreshold per symbol from the ratio of placed to generated trailing updates
+// thresholdTuner 根据追踪止损实际下单与候选更新的比例，按交易对调节 UpdateThreshold
+//
+// Placing most candidates means small moves keep cancelling and re-placing the stop, so the threshold rises;
+// placing few means the stop falls behind the price, so it drops. It always stays within [min, max].
+// 大部分候选都下单说明小幅波动在不断撤单重下，因此提高阈值；很少下单说明止损落后于价格，因此降低阈值。阈值始终限制在 [min, max] 内。
+type thresholdTuner struct {
+	min    float64                // 阈值下限（%）/ Lower bound (%)
+	max    float64                // 阈值上限（%）/ Upper bound (%)
+	window int                    // 每多少次候选评估一次 / Candidates per evaluation
+	stats  map[string]*churnStats // 每个交易对的统计 / Per-symbol statistics
+	mu     sync.Mutex
+}
+
+// newThresholdTuner creates a tuner; a non-positive window falls back to 10 candidates
+// newThresholdTuner 创建调节器；窗口不为正时使用 10 次候选
+func newThresholdTuner(min, max float64, window int) *thresholdTuner {
+	if window <= 0 {
+		window = 10
+	}
+	if max < min {
+		min, max = max, min
+	}
+	return &thresholdTuner{min: min, max: max, window: window, stats: make(map[string]*churnStats)}
+}
+
+// threshold returns the tuned threshold of a symbol, or base until the symbol has been tuned
+// threshold 返回交易对调节后的阈值，尚未调节时返回 base
+func (t *thresholdTuner) threshold(symbol string, base float64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.stats[symbol]; ok && s.threshold > 0 {
+		return s.threshold
+	}
+	return base
+}
+
+// record counts one candidate update and returns the adjustment made when it closes a window
+// record 记录一次候选更新，若恰好结束一个窗口则返回所做的调整
+func (t *thresholdTuner) record(symbol string, base float64, placed bool) *ThresholdAdjustment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.stats[symbol]
+	if !ok {
+		s = &churnStats{}
+		t.stats[symbol] = s
+	}
+	s.generated++
+	if placed {
+		s.placed++
+	}
+	if s.generated < t.window {
+		return nil
+	}
+
+	current := s.threshold
+	if current <= 0 {
+		current = base
+	}
+	ratio := float64(s.placed) / float64(s.generated)
+	next := current
+	switch {
+	case ratio > churnPlacedRatioHigh:
+		next = current * thresholdRaiseFactor
+	case ratio < churnPlacedRatioLow:
+		next = current * thresholdLowerFactor
+	}
+	next = math.Max(t.min, math.Min(t.max, next))
+
+	adj := &ThresholdAdjustment{Symbol: symbol, Generated: s.generated, Placed: s.placed, OldThreshold: current, NewThreshold: next}
+	s.generated, s.placed, s.threshold = 0, 0, next
+	return adj
+}
+
+// EnableThresholdTuning turns on per-symbol auto-tuning of UpdateThreshold within [min, max]
+// EnableThresholdTuning 启用按交易对在 [min, max] 内自动调节 UpdateThreshold
+func (calc *TrailingStopCalculator) EnableThresholdTuning(min, max float64, window int) {
+	calc.tuner = newThresholdTuner(min, max, window)
+}
+
+// RecordUpdateOutcome records whether a favorable trailing update was placed or suppressed by the threshold
+// RecordUpdateOutcome 记录一次朝有利方向的追踪更新是实际下单还是被阈值拦截
+func (calc *TrailingStopCalculator) RecordUpdateOutcome(symbol string, placed bool) {
+	if calc.tuner == nil {
+		return
+	}
+	normalizedSymbol := normalizeCalculatorSymbol(symbol)
+	adj := calc.tuner.record(normalizedSymbol, calc.baseConfig(normalizedSymbol).UpdateThreshold, placed)
+	if adj == nil || calc.logger == nil {
+		return
+	}
+
+	if adj.NewThreshold == adj.OldThreshold {
+		calc.logger.Info(fmt.Sprintf("【%s】🎛️ 追踪止损阈值保持 %.2f%%（最近 %d 次候选更新中下单 %d 次）",
+			adj.Symbol, adj.NewThreshold, adj.Generated, adj.Placed))
+		return
+	}
+	reason := "止损单变更过于频繁"
+	if adj.NewThreshold < adj.OldThreshold {
+		reason = "止损跟随滞后"
+	}
+	calc.logger.Info(fmt.Sprintf("【%s】🎛️ 追踪止损阈值自动调节: %.2f%% → %.2f%%（最近 %d 次候选更新中下单 %d 次，%s）",
+		adj.Symbol, adj.OldThreshold, adj.NewThreshold, adj.Generated, adj.Placed, reason))
+}
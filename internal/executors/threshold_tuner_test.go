@@ -0,0 +1,72 @@
+package executors
+
+import "testing"
+
+func TestThresholdTunerRaisesOnChurn(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	calc.EnableThresholdTuning(0.1, 1.0, 4)
+
+	// Every candidate placed: the stop churns, so the threshold rises from the configured 0.3%
+	// 每次候选都下单：止损频繁变更，阈值从配置的 0.3% 提高
+	for i := 0; i < 4; i++ {
+		calc.RecordUpdateOutcome("BTC/USDT", true)
+	}
+	if got := calc.GetConfig("BTCUSDT").UpdateThreshold; !almostEqual(got, 0.375) {
+		t.Fatalf("Expected 0.375%%, got %.4f%%", got)
+	}
+
+	// Repeated churn is capped at the upper bound
+	// 持续频繁变更时被上限截断
+	for i := 0; i < 40; i++ {
+		calc.RecordUpdateOutcome("BTCUSDT", true)
+	}
+	if got := calc.GetConfig("BTCUSDT").UpdateThreshold; !almostEqual(got, 1.0) {
+		t.Errorf("Expected the 1.0%% cap, got %.4f%%", got)
+	}
+
+	// Other symbols keep their configured threshold
+	// 其他交易对保持配置的阈值
+	if got := calc.GetConfig("ETHUSDT").UpdateThreshold; !almostEqual(got, 0.3) {
+		t.Errorf("Expected ETHUSDT to stay at 0.3%%, got %.4f%%", got)
+	}
+}
+
+func TestThresholdTunerLowersOnLag(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	calc.EnableThresholdTuning(0.25, 1.0, 5)
+
+	for i := 0; i < 5; i++ {
+		calc.RecordUpdateOutcome("SOLUSDT", false)
+	}
+	if got := calc.GetConfig("SOLUSDT").UpdateThreshold; !almostEqual(got, 0.25) {
+		t.Errorf("Expected 0.3%% × 0.8 clamped to the 0.25%% floor, got %.4f%%", got)
+	}
+}
+
+func TestThresholdTunerHoldsInBand(t *testing.T) {
+	tuner := newThresholdTuner(0.1, 1.0, 5)
+
+	// 2 of 5 placed is inside the band
+	// 5 次中下单 2 次处于区间内
+	outcomes := []bool{true, false, true, false, false}
+	var adj *ThresholdAdjustment
+	for i, placed := range outcomes {
+		adj = tuner.record("BNBUSDT", 0.3, placed)
+		if i < len(outcomes)-1 && adj != nil {
+			t.Fatalf("Expected no adjustment before the window closes, got %+v", adj)
+		}
+	}
+	if adj == nil || adj.Placed != 2 || adj.Generated != 5 || !almostEqual(adj.NewThreshold, 0.3) {
+		t.Errorf("Expected the threshold to hold at 0.3%%, got %+v", adj)
+	}
+}
+
+func TestThresholdTunerDisabled(t *testing.T) {
+	calc := NewTrailingStopCalculator(nil)
+	for i := 0; i < 20; i++ {
+		calc.RecordUpdateOutcome("BTCUSDT", true)
+	}
+	if got := calc.GetConfig("BTCUSDT").UpdateThreshold; !almostEqual(got, 0.3) {
+		t.Errorf("Expected the fixed 0.3%% threshold, got %.4f%%", got)
+	}
+}
@@ -0,0 +1,97 @@
+package executors
+
+import (
+	"fmt"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// IntentTracker persists each stage a trade intent reaches, so a decision that never became a position shows where it stopped
+// IntentTracker 持久化交易意图经过的每个阶段，使未形成持仓的决策可以看到停在哪一步
+//
+// Tracking is best-effort: storage errors are logged and never block trading. A nil tracker or a nil intent is a no-op.
+// 跟踪为尽力而为：数据库错误只记录日志，不阻塞交易。tracker 或 intent 为 nil 时不做任何事。
+type IntentTracker struct {
+	storage *storage.Storage    // 数据库 / Database
+	logger  *logger.ColorLogger // 日志 / Logger
+}
+
+// NewIntentTracker creates a tracker; it records nothing when db is nil
+// NewIntentTracker 创建跟踪器；db 为 nil 时不记录
+func NewIntentTracker(db *storage.Storage, log *logger.ColorLogger) *IntentTracker {
+	return &IntentTracker{storage: db, logger: log}
+}
+
+// Begin records a new intent in the decided stage
+// Begin 以"决策已生成"阶段记录一个新的交易意图
+func (t *IntentTracker) Begin(batchID, symbol string, action TradeAction, reason string) *storage.TradeIntent {
+	if t == nil || t.storage == nil {
+		return nil
+	}
+	intent := &storage.TradeIntent{
+		BatchID: batchID,
+		Symbol:  symbol,
+		Action:  string(action),
+		Reason:  reason,
+		Status:  storage.IntentDecided,
+	}
+	if _, err := t.storage.SaveTradeIntent(intent); err != nil {
+		t.logger.Warning(fmt.Sprintf("⚠️ 【%s】保存交易意图失败: %v", symbol, err))
+		return nil
+	}
+	return intent
+}
+
+// Advance moves the intent to status with an explanation
+// Advance 将交易意图推进到 status 并附带说明
+func (t *IntentTracker) Advance(intent *storage.TradeIntent, status, detail string) {
+	if t == nil || t.storage == nil || intent == nil {
+		return
+	}
+	intent.Status = status
+	intent.Detail = detail
+	if err := t.storage.UpdateTradeIntent(intent); err != nil {
+		t.logger.Warning(fmt.Sprintf("⚠️ 【%s】更新交易意图状态 %s 失败: %v", intent.Symbol, status, err))
+	}
+}
+
+// Fail moves the intent to a failed status and tags it with the error category of the failure
+// Fail 将交易意图推进到失败状态，并标记失败的错误类别
+func (t *IntentTracker) Fail(intent *storage.TradeIntent, status, category, detail string) {
+	if intent == nil {
+		return
+	}
+	intent.ErrorCategory = category
+	t.Advance(intent, status, detail)
+}
+
+// RecordExecution moves the intent to filled or order_failed according to the trade result
+// RecordExecution 根据交易结果将意图推进到已成交或下单失败
+func (t *IntentTracker) RecordExecution(intent *storage.TradeIntent, result *TradeResult) {
+	if intent == nil || result == nil {
+		return
+	}
+	if !result.Success {
+		t.Fail(intent, storage.IntentOrderFailed, storage.ErrorCategoryExchange, result.Message)
+		return
+	}
+	intent.OrderID = result.OrderID
+	intent.FilledQty = result.FilledQuantity()
+	intent.FillPrice = result.Price
+	t.Advance(intent, storage.IntentFilled, fmt.Sprintf("成交 %.4f @ %.2f", intent.FilledQty, intent.FillPrice))
+}
+
+// RecordPosition moves the intent to its final stage once the resulting position is registered
+// RecordPosition 在持仓注册后将意图推进到最终阶段
+func (t *IntentTracker) RecordPosition(intent *storage.TradeIntent, positionID string, stopErr error) {
+	if intent == nil {
+		return
+	}
+	intent.PositionID = positionID
+	if stopErr != nil {
+		t.Fail(intent, storage.IntentUnprotected, storage.ErrorCategoryExchange, fmt.Sprintf("止损单失败: %v", stopErr))
+		return
+	}
+	t.Advance(intent, storage.IntentPositionOpened, "持仓已建立，止损单已下达")
+}
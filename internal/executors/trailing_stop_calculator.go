@@ -26,6 +26,26 @@ type TrailingStopConfig struct {
 	UpdateThreshold float64 // Update threshold in percentage, default 1.0 / 更新阈值（百分比），默认 1.0
 	MinStopDistance float64 // Minimum stop distance in percentage, default 1.5 / 最小止损距离（百分比），默认 1.5
 	MaxStopDistance float64 // Maximum stop distance in percentage, default 8.0 / 最大止损距离（百分比），默认 8.0
+
+	// Chandelier exit parameters (TRAILING_STOP_FORMULA=chandelier)
+	// 吊灯止损参数（TRAILING_STOP_FORMULA=chandelier）
+	ChandelierPeriod     int     // Bars for the highest high and ATR, 0 uses 22 / 最高价与 ATR 的 K 线数，0 表示使用 22
+	ChandelierMultiplier float64 // ATR multiplier, 0 uses 3.0 / ATR 倍数，0 表示使用 3.0
+
+	// Parabolic SAR parameters (TRAILING_STOP_FORMULA=psar)
+	// 抛物线 SAR 参数（TRAILING_STOP_FORMULA=psar）
+	PSARStep float64 // Acceleration factor step, 0 uses 0.02 / 加速因子步长，0 表示使用 0.02
+	PSARMax  float64 // Acceleration factor cap, 0 uses 0.2 / 加速因子上限，0 表示使用 0.2
+
+	// SuperTrend parameters (TRAILING_STOP_FORMULA=supertrend)
+	// SuperTrend 参数（TRAILING_STOP_FORMULA=supertrend）
+	SuperTrendPeriod     int     // ATR period, 0 uses 10 / ATR 周期，0 表示使用 10
+	SuperTrendMultiplier float64 // ATR multiplier, 0 uses 3.0 / ATR 倍数，0 表示使用 3.0
+
+	// Percentage trailing parameters (TRAILING_STOP_FORMULA=percent or step)
+	// 百分比追踪参数（TRAILING_STOP_FORMULA=percent 或 step）
+	TrailingPercent     float64 // Distance behind the highest/lowest price in %, 0 uses 1.5 / 距最高/最低价的回撤（%），0 表示使用 1.5
+	TrailingStepPercent float64 // Step size in % for the step formula, 0 uses 0.5 / 阶梯公式的阶梯大小（%），0 表示使用 0.5
 }
 
 // TrailingStopCalculator calculates trailing stop prices locally
@@ -53,6 +73,11 @@ type TrailingStopConfig struct {
 type TrailingStopCalculator struct {
 	configs map[string]TrailingStopConfig // Symbol-specific configs / 币种特定配置
 	logger  *logger.ColorLogger           // Logger / 日志记录器
+	tuner   *thresholdTuner               // UpdateThreshold auto-tuning, nil when disabled / 更新阈值自动调节，未启用时为 nil
+	scaler  *atrScaler                    // ATR multiplier scaling by volatility, nil when disabled / 按波动率缩放 ATR 倍数，未启用时为 nil
+
+	formula  string            // Default trailing formula, empty means atr / 默认追踪公式，为空表示 atr
+	formulas map[string]string // Per-symbol trailing formulas / 按交易对的追踪公式
 }
 
 // NewTrailingStopCalculator creates a new trailing stop calculator
@@ -174,24 +199,48 @@ func getDefaultConfigs() map[string]TrailingStopConfig {
 
 // GetConfig returns configuration for a specific symbol
 // GetConfig 返回指定币种的配置
+//
+// When threshold tuning is enabled, UpdateThreshold is the tuned value of the symbol; when ATR scaling is enabled,
+// the initial and trailing ATR multipliers are scaled by the symbol's last measured factor
+// 启用阈值调节时，UpdateThreshold 为该交易对调节后的值；启用 ATR 缩放时，初始与追踪 ATR 倍数按该交易对最近测得的系数缩放
 func (calc *TrailingStopCalculator) GetConfig(symbol string) TrailingStopConfig {
-	// Normalize symbol (remove slash)
-	// 标准化符号（去除斜杠）
-	normalizedSymbol := strings.ReplaceAll(symbol, "/", "")
-	normalizedSymbol = strings.ToUpper(normalizedSymbol)
+	normalizedSymbol := normalizeCalculatorSymbol(symbol)
+
+	config, exists := calc.configs[normalizedSymbol]
+	if !exists {
+		// Return default config if symbol not found
+		// 如果未找到币种配置，返回默认配置
+		if calc.logger != nil {
+			calc.logger.Warning(fmt.Sprintf("⚠️  未找到 %s 的追踪止损配置，使用默认参数", symbol))
+		}
+		config = calc.configs["DEFAULT"]
+	}
 
-	if config, exists := calc.configs[normalizedSymbol]; exists {
-		return config
+	if calc.tuner != nil {
+		config.UpdateThreshold = calc.tuner.threshold(normalizedSymbol, config.UpdateThreshold)
 	}
+	if scale := calc.scaler.scale(normalizedSymbol); scale != 1 {
+		config.InitialATRMultiplier *= scale
+		config.TrailingATRMultiplier *= scale
+	}
+	return config
+}
 
-	// Return default config if symbol not found
-	// 如果未找到币种配置，返回默认配置
-	if calc.logger != nil {
-		calc.logger.Warning(fmt.Sprintf("⚠️  未找到 %s 的追踪止损配置，使用默认参数", symbol))
+// baseConfig returns the configured parameters of a symbol without tuning or logging
+// baseConfig 返回交易对配置的参数，不经过调节也不打印日志
+func (calc *TrailingStopCalculator) baseConfig(normalizedSymbol string) TrailingStopConfig {
+	if config, exists := calc.configs[normalizedSymbol]; exists {
+		return config
 	}
 	return calc.configs["DEFAULT"]
 }
 
+// normalizeCalculatorSymbol removes the slash and upper-cases a symbol (BTC/usdt → BTCUSDT)
+// normalizeCalculatorSymbol 去除斜杠并转为大写（BTC/usdt → BTCUSDT）
+func normalizeCalculatorSymbol(symbol string) string {
+	return strings.ToUpper(strings.ReplaceAll(symbol, "/", ""))
+}
+
 // CalculateInitialStop calculates initial stop-loss price when opening a position
 // CalculateInitialStop 计算开仓时的初始止损价格
 //
@@ -0,0 +1,326 @@
+package executors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// User data stream timing
+// 用户数据流时间参数
+const (
+	listenKeyKeepalive       = 30 * time.Minute // listenKey 60 分钟过期，每 30 分钟续期 / listenKey expires after 60 minutes, renew every 30
+	userStreamReconnectDelay = 5 * time.Second  // 断线重连间隔 / Delay before reconnecting
+	userStreamBuffer         = 256              // 待处理事件缓冲 / Pending event buffer
+)
+
+// exchangeCloseOrderPrefixes are client order ID prefixes Binance uses for orders it places itself
+// exchangeCloseOrderPrefixes 是币安自行下单时使用的客户端订单 ID 前缀
+var exchangeCloseOrderPrefixes = map[string]string{
+	"autoclose-":    "强制平仓", // Liquidation
+	"adl_autoclose": "自动减仓", // Auto-deleveraging
+}
+
+// triggeredOrderTypes are order types the exchange fires on its own once the trigger price is hit
+// triggeredOrderTypes 是到达触发价后由交易所自动执行的订单类型
+var triggeredOrderTypes = map[futures.OrderType]bool{
+	futures.OrderTypeStopMarket:         true,
+	futures.OrderTypeTakeProfitMarket:   true,
+	futures.OrderTypeStop:               true,
+	futures.OrderTypeTakeProfit:         true,
+	futures.OrderTypeTrailingStopMarket: true,
+}
+
+// orderUpdateAction is what the stop-loss manager should do with an ORDER_TRADE_UPDATE event
+// orderUpdateAction 是止损管理器对 ORDER_TRADE_UPDATE 事件应采取的动作
+type orderUpdateAction int
+
+const (
+	orderUpdateIgnore     orderUpdateAction = iota // 无需处理 / Nothing to do
+	orderUpdateStopFilled                          // 止损单完全成交 / The stop-loss order filled
+	orderUpdateReconcile                           // 持仓被外部成交改变，需要对账 / A fill outside the bot changed the position
+)
+
+// classifyOrderUpdate decides how an order event affects a managed position, and describes the cause
+// classifyOrderUpdate 判断订单事件如何影响受管持仓，并给出原因
+//
+// Fills of the bot's own market/limit orders are ignored: the code that placed them already updates state.
+// Everything the exchange executes by itself (stop-loss, take-profit, liquidation, ADL) and fills of
+// orders placed elsewhere (manual, another instance) change the position behind the bot's back.
+// 机器人自己下的市价/限价单成交会被忽略，下单代码已经更新了状态。
+// 交易所自动执行的订单（止损、止盈、强平、自动减仓）以及其他来源（手动、其他实例）的订单成交会在机器人不知情时改变持仓。
+func classifyOrderUpdate(u *futures.WsOrderTradeUpdate, stopLossOrderID, ownTag string) (orderUpdateAction, string) {
+	if u.ExecutionType != futures.OrderExecutionTypeTrade {
+		return orderUpdateIgnore, ""
+	}
+
+	orderID := strconv.FormatInt(u.ID, 10)
+	if stopLossOrderID != "" && orderID == stopLossOrderID {
+		if u.Status == futures.OrderStatusTypeFilled {
+			return orderUpdateStopFilled, fmt.Sprintf("止损单成交（订单ID: %s，实时推送）", orderID)
+		}
+		return orderUpdateReconcile, fmt.Sprintf("止损单部分成交（订单ID: %s）", orderID)
+	}
+
+	for prefix, label := range exchangeCloseOrderPrefixes {
+		if strings.HasPrefix(u.ClientOrderID, prefix) {
+			return orderUpdateReconcile, fmt.Sprintf("%s（订单ID: %s）", label, orderID)
+		}
+	}
+
+	if triggeredOrderTypes[u.OriginalType] {
+		return orderUpdateReconcile, fmt.Sprintf("条件单 %s 成交（订单ID: %s）", u.OriginalType, orderID)
+	}
+
+	if tag, ok := parseClientOrderTag(u.ClientOrderID); ok && tag == ownTag {
+		return orderUpdateIgnore, ""
+	}
+
+	return orderUpdateReconcile, fmt.Sprintf("外部订单成交（订单ID: %s）", orderID)
+}
+
+// HandleOrderTradeUpdate applies a pushed ORDER_TRADE_UPDATE event to the managed position on its symbol
+// HandleOrderTradeUpdate 将推送的 ORDER_TRADE_UPDATE 事件应用到对应交易对的受管持仓
+func (sm *StopLossManager) HandleOrderTradeUpdate(ctx context.Context, u *futures.WsOrderTradeUpdate) error {
+	symbol := sm.config.GetBinanceSymbolFor(u.Symbol)
+
+	sm.mu.RLock()
+	pos, exists := sm.positions[symbol]
+	stopLossOrderID := ""
+	if exists {
+		stopLossOrderID = pos.StopLossOrderID
+	}
+	sm.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+
+	// Rung fills of an entry ladder add to the position instead of changing it behind the bot's back
+	// 分批开仓阶梯的挂单成交是对持仓的加仓，而不是外部改变持仓
+	if u.ExecutionType == futures.OrderExecutionTypeTrade && sm.executor.isEntryLadderOrder(symbol, u.ID) {
+		return sm.SyncEntryLadder(ctx, symbol)
+	}
+
+	action, reason := classifyOrderUpdate(u, stopLossOrderID, sm.executor.instanceTag)
+	switch action {
+	case orderUpdateStopFilled:
+		sm.logger.Warning(fmt.Sprintf("🔔【%s】%s，成交均价: %s", symbol, reason, u.AveragePrice))
+		return sm.closeOnStopLossFill(ctx, symbol, pos, u.AveragePrice, reason)
+
+	case orderUpdateReconcile:
+		sm.logger.Warning(fmt.Sprintf("🔔【%s】%s，状态: %s，正在对账", symbol, reason, u.Status))
+		closePrice := 0.0
+		if u.Status == futures.OrderStatusTypeFilled {
+			closePrice, _ = parseFloat(u.AveragePrice)
+		}
+		return sm.reconcilePosition(ctx, symbol, closePrice, reason)
+	}
+	return nil
+}
+
+// UserDataStream pushes order fills and position changes from Binance to the stop-loss manager in real time
+// UserDataStream 将币安的订单成交和持仓变化实时推送给止损管理器
+//
+// Per-cycle polling (ReconcilePosition, CheckStopLossOrderStatus) stays in place as a safety net;
+// after every (re)connect all managed positions are reconciled to cover events missed while disconnected.
+// 每个周期的轮询（ReconcilePosition、CheckStopLossOrderStatus）仍作为兜底保留；
+// 每次（重新）连接后都会对账所有受管持仓，以覆盖断线期间错过的事件。
+type UserDataStream struct {
+	executor *BinanceExecutor                                                                                                                 // 执行器 / Executor
+	stopLoss *StopLossManager                                                                                                                 // 止损管理器 / Stop-loss manager
+	logger   *logger.ColorLogger                                                                                                              // 日志 / Logger
+	events   chan *futures.WsUserDataEvent                                                                                                    // 待处理事件 / Pending events
+	resync   chan struct{}                                                                                                                    // 需要全量对账的信号 / Full reconciliation request
+	serve    func(listenKey string, handler futures.WsUserDataHandler, errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error) // 建立连接（测试可替换）/ Connects the stream (replaceable in tests)
+}
+
+// NewUserDataStream creates a user data stream feeding the given stop-loss manager
+// NewUserDataStream 创建向指定止损管理器推送事件的用户数据流
+func NewUserDataStream(executor *BinanceExecutor, sm *StopLossManager, log *logger.ColorLogger) *UserDataStream {
+	return &UserDataStream{
+		executor: executor,
+		stopLoss: sm,
+		logger:   log,
+		events:   make(chan *futures.WsUserDataEvent, userStreamBuffer),
+		resync:   make(chan struct{}, 1),
+		serve:    futures.WsUserDataServe,
+	}
+}
+
+// Run keeps the stream connected and dispatches its events until ctx is cancelled
+// Run 保持数据流连接并分发事件，直到 ctx 被取消
+func (s *UserDataStream) Run(ctx context.Context) {
+	go s.process(ctx)
+
+	for {
+		err := s.connect(ctx)
+		if ctx.Err() != nil {
+			s.logger.Info("用户数据流已停止")
+			return
+		}
+		s.logger.Warning(fmt.Sprintf("⚠️  用户数据流中断: %v，%v 后重连", err, userStreamReconnectDelay))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(userStreamReconnectDelay):
+		}
+	}
+}
+
+// connect opens one websocket session and blocks until it ends
+// connect 建立一次 websocket 会话并阻塞直到会话结束
+func (s *UserDataStream) connect(ctx context.Context) error {
+	listenKey, err := s.executor.client.NewStartUserStreamService().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start user stream: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		s.executor.client.NewCloseUserStreamService().ListenKey(listenKey).Do(closeCtx)
+	}()
+
+	expired := make(chan struct{}, 1)
+	handler := func(event *futures.WsUserDataEvent) {
+		if event.Event == futures.UserDataEventTypeListenKeyExpired {
+			select {
+			case expired <- struct{}{}:
+			default:
+			}
+			return
+		}
+		select {
+		case s.events <- event:
+		default:
+			// The processor is stuck behind slow API calls; drop the event and reconcile everything instead
+			// 处理协程被慢速 API 调用阻塞；丢弃事件并改为全量对账
+			s.requestResync()
+		}
+	}
+	errHandler := func(err error) {
+		s.logger.Warning(fmt.Sprintf("⚠️  用户数据流错误: %v", err))
+	}
+
+	doneC, stopC, err := s.serve(listenKey, handler, errHandler)
+	if err != nil {
+		return fmt.Errorf("failed to connect user stream: %w", err)
+	}
+	s.logger.Success("✅ 已连接币安用户数据流，止损/强平/持仓变化将实时推送")
+	s.requestResync()
+
+	keepalive := time.NewTicker(listenKeyKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			close(stopC)
+			<-doneC
+			return ctx.Err()
+		case <-doneC:
+			return errors.New("连接已断开")
+		case <-expired:
+			close(stopC)
+			<-doneC
+			return errors.New("listenKey 已过期")
+		case <-keepalive.C:
+			if err := s.executor.client.NewKeepaliveUserStreamService().ListenKey(listenKey).Do(ctx); err != nil {
+				s.logger.Warning(fmt.Sprintf("⚠️  listenKey 续期失败: %v", err))
+			}
+		}
+	}
+}
+
+func (s *UserDataStream) requestResync() {
+	select {
+	case s.resync <- struct{}{}:
+	default:
+	}
+}
+
+// process handles events one at a time, so fills on the same symbol are applied in order
+// process 逐个处理事件，保证同一交易对的成交按顺序应用
+func (s *UserDataStream) process(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.resync:
+			s.reconcileAll(ctx)
+		case event := <-s.events:
+			s.handle(ctx, event)
+		}
+	}
+}
+
+// reconcileAll reconciles every managed position against the exchange
+// reconcileAll 将所有受管持仓与交易所对账
+func (s *UserDataStream) reconcileAll(ctx context.Context) {
+	for _, pos := range s.stopLoss.GetAllPositions() {
+		if err := s.stopLoss.ReconcilePosition(ctx, pos.Symbol); err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  对账 %s 失败: %v", pos.Symbol, err))
+		}
+	}
+}
+
+func (s *UserDataStream) handle(ctx context.Context, event *futures.WsUserDataEvent) {
+	switch event.Event {
+	case futures.UserDataEventTypeOrderTradeUpdate:
+		u := &event.OrderTradeUpdate
+		if err := s.stopLoss.HandleOrderTradeUpdate(ctx, u); err != nil {
+			s.logger.Warning(fmt.Sprintf("⚠️  处理 %s 订单推送失败: %v", u.Symbol, err))
+		}
+		// A filled bracket leg must cancel its sibling right away rather than on the next poll
+		// 括号单的一条腿成交后应立即撤销另一条，而不是等下一次轮询
+		if u.Status == futures.OrderStatusTypeFilled && triggeredOrderTypes[u.OriginalType] {
+			if _, err := s.executor.SyncBracket(ctx, u.Symbol); err != nil {
+				s.logger.Warning(fmt.Sprintf("⚠️  同步 %s 括号单失败: %v", u.Symbol, err))
+			}
+		}
+
+	case futures.UserDataEventTypeAccountUpdate:
+		// Order fills arrive as ORDER_TRADE_UPDATE and funding does not change size; anything else
+		// (insurance clear, adjustment, margin type change, ...) may have changed a position
+		// 订单成交通过 ORDER_TRADE_UPDATE 推送，资金费不改变持仓数量；其他原因（保险基金清算、调整、保证金模式变更等）可能改变了持仓
+		reason := event.AccountUpdate.Reason
+		if reason == futures.UserDataEventReasonTypeFundingFee {
+			// Pick up the settlement in each position's accumulated funding
+			// 将本次结算计入各持仓的累计资金费
+			s.stopLoss.SyncAllFunding(ctx)
+			return
+		}
+		if reason == futures.UserDataEventReasonTypeOrder {
+			return
+		}
+		for _, p := range event.AccountUpdate.Positions {
+			if !s.stopLoss.HasPosition(p.Symbol) {
+				continue
+			}
+			s.logger.Warning(fmt.Sprintf("🔔【%s】账户变动（%s），持仓数量: %s，正在对账", p.Symbol, reason, p.Amount))
+			if err := s.stopLoss.reconcilePosition(ctx, p.Symbol, 0, fmt.Sprintf("账户变动（%s）", reason)); err != nil {
+				s.logger.Warning(fmt.Sprintf("⚠️  对账 %s 失败: %v", p.Symbol, err))
+			}
+		}
+
+	case futures.UserDataEventTypeMarginCall:
+		for _, p := range event.MarginCallPositions {
+			s.logger.Error(fmt.Sprintf("🚨【%s】追加保证金通知！持仓: %s %s，维持保证金: %s，未实现盈亏: %s",
+				p.Symbol, p.Side, p.Amount, p.MaintenanceMarginRequired, p.UnrealizedPnL))
+		}
+
+	case futures.UserDataEventTypeConditionalOrderTriggerReject:
+		r := event.ConditionalOrderTriggerReject
+		if pos := s.stopLoss.GetPosition(r.Symbol); pos != nil && pos.StopLossOrderID == strconv.FormatInt(r.OrderId, 10) {
+			s.logger.Error(fmt.Sprintf("🚨【%s】止损单触发后被拒绝（订单ID: %d, 原因: %s），持仓当前无保护！",
+				r.Symbol, r.OrderId, r.RejectReason))
+		}
+	}
+}
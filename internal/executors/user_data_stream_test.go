@@ -0,0 +1,85 @@
+package executors
+
+import (
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestClassifyOrderUpdate(t *testing.T) {
+	const ownTag = "abc123"
+	const stopID = "1001"
+
+	tests := []struct {
+		name   string
+		update futures.WsOrderTradeUpdate
+		want   orderUpdateAction
+	}{
+		{
+			name: "Stop-loss filled",
+			update: futures.WsOrderTradeUpdate{ID: 1001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeStopMarket, ClientOrderID: "ctb_abc123_1_1"},
+			want: orderUpdateStopFilled,
+		},
+		{
+			name: "Stop-loss partially filled",
+			update: futures.WsOrderTradeUpdate{ID: 1001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypePartiallyFilled, OriginalType: futures.OrderTypeStopMarket},
+			want: orderUpdateReconcile,
+		},
+		{
+			name: "Stop-loss placed (not a trade)",
+			update: futures.WsOrderTradeUpdate{ID: 1001, ExecutionType: futures.OrderExecutionTypeNew,
+				Status: futures.OrderStatusTypeNew, OriginalType: futures.OrderTypeStopMarket},
+			want: orderUpdateIgnore,
+		},
+		{
+			name: "Liquidation",
+			update: futures.WsOrderTradeUpdate{ID: 2001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeLimit, ClientOrderID: "autoclose-1700000000000"},
+			want: orderUpdateReconcile,
+		},
+		{
+			name: "Auto-deleveraging",
+			update: futures.WsOrderTradeUpdate{ID: 2002, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeLimit, ClientOrderID: "adl_autoclose"},
+			want: orderUpdateReconcile,
+		},
+		{
+			name: "Own bracket take-profit leg",
+			update: futures.WsOrderTradeUpdate{ID: 3001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeTakeProfitMarket, ClientOrderID: "ctb_abc123_1_2"},
+			want: orderUpdateReconcile,
+		},
+		{
+			name: "Own market close",
+			update: futures.WsOrderTradeUpdate{ID: 4001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeMarket, ClientOrderID: "ctb_abc123_1_3"},
+			want: orderUpdateIgnore,
+		},
+		{
+			name: "Other instance market order",
+			update: futures.WsOrderTradeUpdate{ID: 4002, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeMarket, ClientOrderID: "ctb_ffffff_1_3"},
+			want: orderUpdateReconcile,
+		},
+		{
+			name: "Manual order from the app",
+			update: futures.WsOrderTradeUpdate{ID: 5001, ExecutionType: futures.OrderExecutionTypeTrade,
+				Status: futures.OrderStatusTypeFilled, OriginalType: futures.OrderTypeMarket, ClientOrderID: "web_AbCdEf"},
+			want: orderUpdateReconcile,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := classifyOrderUpdate(&tt.update, stopID, ownTag)
+			if got != tt.want {
+				t.Errorf("classifyOrderUpdate() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if got != orderUpdateIgnore && reason == "" {
+				t.Error("Expected a reason for an actionable update")
+			}
+		})
+	}
+}
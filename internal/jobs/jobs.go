@@ -0,0 +1,328 @@
+// Package jobs runs long operations such as historical replays in the background of the web process
+// Package jobs 在 Web 进程后台运行历史回放等耗时操作
+//
+// A submitted job gets an ID right away and runs on its own goroutine, at most MaxConcurrent at a time, so neither
+// the HTTP request that started it nor the trading loop waits for it. Jobs report progress while running and can be
+// cancelled through their context. Jobs live in memory only; the most recent finished ones are kept for inspection.
+// 提交的任务立即获得 ID 并在独立协程中运行，同时最多运行 MaxConcurrent 个，发起任务的 HTTP 请求和交易循环都不需要等待。
+// 任务运行时上报进度，并可通过其 context 取消。任务仅保存在内存中，保留最近完成的若干个以供查询。
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// Status is the stage of a job
+// Status 表示任务所处的阶段
+type Status string
+
+const (
+	StatusQueued    Status = "queued"    // 等待空闲运行槽位 / Waiting for a free slot
+	StatusRunning   Status = "running"   // 运行中 / Running
+	StatusSucceeded Status = "succeeded" // 已完成 / Finished successfully
+	StatusFailed    Status = "failed"    // 运行出错 / Finished with an error
+	StatusCancelled Status = "cancelled" // 已取消 / Cancelled
+)
+
+// Finished reports whether the job has stopped for good
+// Finished 判断任务是否已经结束
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// keepFinished is how many finished jobs stay queryable
+// keepFinished 是保留以供查询的已结束任务数量
+const keepFinished = 50
+
+var (
+	ErrUnknownKind = errors.New("unknown job kind")
+	ErrNotFound    = errors.New("job not found")
+	ErrFinished    = errors.New("job already finished")
+)
+
+// Reporter records a running job's progress; total <= 0 means the amount of work is not known yet
+// Reporter 记录运行中任务的进度；total <= 0 表示工作总量尚未知
+type Reporter func(done, total int, message string)
+
+// Runner does the work of one job kind; params come from the request and the result is returned to the caller as JSON
+// Runner 执行一种任务的工作；params 来自请求，结果以 JSON 返回给调用方
+// Runners must return promptly once ctx is cancelled
+// ctx 被取消后 Runner 必须尽快返回
+type Runner func(ctx context.Context, params map[string]string, report Reporter) (any, error)
+
+// Kind describes a registered job kind
+// Kind 描述已注册的任务类型
+type Kind struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	run         Runner
+}
+
+// Job is a snapshot of one job
+// Job 是单个任务的快照
+type Job struct {
+	ID          int64             `json:"id"`
+	Kind        string            `json:"kind"`
+	Params      map[string]string `json:"params,omitempty"`
+	RequestedBy string            `json:"requested_by"`
+	Status      Status            `json:"status"`
+	Done        int               `json:"done"`
+	Total       int               `json:"total"`
+	Message     string            `json:"message,omitempty"`
+	Result      any               `json:"result,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	FinishedAt  *time.Time        `json:"finished_at,omitempty"`
+}
+
+// Percent returns the completed share of the job's work, 0 when the total is unknown
+// Percent 返回任务已完成的比例（百分比），总量未知时返回 0
+func (j Job) Percent() float64 {
+	if j.Status == StatusSucceeded {
+		return 100
+	}
+	if j.Total <= 0 {
+		return 0
+	}
+	return float64(j.Done) / float64(j.Total) * 100
+}
+
+// job is a job with the means to cancel it
+// job 是带有取消手段的任务
+type job struct {
+	Job
+	cancel context.CancelFunc
+}
+
+// Manager queues, runs and tracks jobs
+// Manager 负责任务的排队、运行与跟踪
+type Manager struct {
+	mu     sync.Mutex
+	kinds  map[string]Kind
+	jobs   map[int64]*job
+	nextID int64
+	slots  chan struct{} // 运行槽位 / Run slots
+	ctx    context.Context
+	stop   context.CancelFunc
+	logger *logger.ColorLogger
+}
+
+// NewManager creates a manager that runs at most maxConcurrent jobs at a time
+// NewManager 创建同时最多运行 maxConcurrent 个任务的管理器
+func NewManager(maxConcurrent int, log *logger.ColorLogger) *Manager {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Manager{
+		kinds:  make(map[string]Kind),
+		jobs:   make(map[int64]*job),
+		slots:  make(chan struct{}, maxConcurrent),
+		ctx:    ctx,
+		stop:   stop,
+		logger: log,
+	}
+}
+
+// Register makes a job kind available for submission
+// Register 注册可提交的任务类型
+func (m *Manager) Register(name, description string, run Runner) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kinds[name] = Kind{Name: name, Description: description, run: run}
+}
+
+// Kinds returns the registered job kinds sorted by name
+// Kinds 返回按名称排序的已注册任务类型
+func (m *Manager) Kinds() []Kind {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kinds := make([]Kind, 0, len(m.kinds))
+	for _, k := range m.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Name < kinds[j].Name })
+	return kinds
+}
+
+// Submit queues a job of a registered kind and returns it without waiting for it to start
+// Submit 将已注册类型的任务加入队列并立即返回，不等待任务开始
+func (m *Manager) Submit(kind string, params map[string]string, requestedBy string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.kinds[kind]
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+
+	m.nextID++
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &job{
+		Job: Job{
+			ID:          m.nextID,
+			Kind:        kind,
+			Params:      params,
+			RequestedBy: requestedBy,
+			Status:      StatusQueued,
+			CreatedAt:   time.Now(),
+		},
+		cancel: cancel,
+	}
+	m.jobs[j.ID] = j
+	m.prune()
+
+	go m.run(ctx, j, k.run)
+	return j.Job, nil
+}
+
+// run waits for a free slot and runs the job, recording its outcome
+// run 等待空闲槽位后运行任务并记录结果
+func (m *Manager) run(ctx context.Context, j *job, run Runner) {
+	defer j.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.finish(j, nil, ctx.Err())
+		return
+	}
+
+	m.mu.Lock()
+	if ctx.Err() != nil {
+		m.mu.Unlock()
+		m.finish(j, nil, ctx.Err())
+		return
+	}
+	now := time.Now()
+	j.Status = StatusRunning
+	j.StartedAt = &now
+	m.mu.Unlock()
+	m.logger.Info(fmt.Sprintf("🧰 后台任务 #%d（%s）开始运行", j.ID, j.Kind))
+
+	var (
+		result any
+		err    error
+	)
+	func() {
+		// A panicking job must not take the trading process down with it
+		// 任务崩溃不能连带交易进程退出
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panicked: %v", r)
+			}
+		}()
+		result, err = run(ctx, j.Params, func(done, total int, message string) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			j.Done, j.Total, j.Message = done, total, message
+		})
+	}()
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	m.finish(j, result, err)
+}
+
+// finish records a job's outcome; an error caused by cancellation marks it cancelled rather than failed
+// finish 记录任务结果；由取消引起的错误标记为已取消而不是失败
+func (m *Manager) finish(j *job, result any, err error) {
+	m.mu.Lock()
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Result = result
+	switch {
+	case err == nil:
+		j.Status = StatusSucceeded
+	case errors.Is(err, context.Canceled):
+		j.Status = StatusCancelled
+	default:
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	}
+	snapshot := j.Job
+	m.mu.Unlock()
+
+	switch snapshot.Status {
+	case StatusSucceeded:
+		m.logger.Success(fmt.Sprintf("✅ 后台任务 #%d（%s）已完成", snapshot.ID, snapshot.Kind))
+	case StatusCancelled:
+		m.logger.Warning(fmt.Sprintf("⏹️ 后台任务 #%d（%s）已取消", snapshot.ID, snapshot.Kind))
+	default:
+		m.logger.Error(fmt.Sprintf("❌ 后台任务 #%d（%s）失败: %s", snapshot.ID, snapshot.Kind, snapshot.Error))
+	}
+}
+
+// Get returns a snapshot of one job
+// Get 返回单个任务的快照
+func (m *Manager) Get(id int64) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return j.Job, nil
+}
+
+// List returns snapshots of all kept jobs, newest first
+// List 返回所有保留任务的快照，最新的在前
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		list = append(list, j.Job)
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].ID > list[k].ID })
+	return list
+}
+
+// Cancel stops a queued or running job; the job is marked cancelled once its runner returns
+// Cancel 停止排队中或运行中的任务；任务在 Runner 返回后标记为已取消
+func (m *Manager) Cancel(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if j.Status.Finished() {
+		return ErrFinished
+	}
+	j.cancel()
+	return nil
+}
+
+// Shutdown cancels every queued and running job
+// Shutdown 取消所有排队中和运行中的任务
+func (m *Manager) Shutdown() {
+	m.stop()
+}
+
+// prune drops the oldest finished jobs beyond keepFinished; callers hold m.mu
+// prune 删除超出 keepFinished 的最早已结束任务；调用方需持有 m.mu
+func (m *Manager) prune() {
+	var finished []int64
+	for id, j := range m.jobs {
+		if j.Status.Finished() {
+			finished = append(finished, id)
+		}
+	}
+	if len(finished) <= keepFinished {
+		return
+	}
+	sort.Slice(finished, func(i, k int) bool { return finished[i] < finished[k] })
+	for _, id := range finished[:len(finished)-keepFinished] {
+		delete(m.jobs, id)
+	}
+}
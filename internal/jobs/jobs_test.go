@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// waitFor polls a job until it has finished
+// waitFor 轮询任务直到其结束
+func waitFor(t *testing.T, m *Manager, id int64) Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		j, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%d) failed: %v", id, err)
+		}
+		if j.Status.Finished() {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Job %d did not finish in time", id)
+	return Job{}
+}
+
+func TestManagerRunsJobs(t *testing.T) {
+	m := NewManager(1, logger.NewColorLogger(false))
+	defer m.Shutdown()
+	m.Register("echo", "returns its params", func(ctx context.Context, params map[string]string, report Reporter) (any, error) {
+		report(1, 2, "half way")
+		return params["value"], nil
+	})
+	m.Register("broken", "always fails", func(ctx context.Context, params map[string]string, report Reporter) (any, error) {
+		return nil, errors.New("boom")
+	})
+
+	if _, err := m.Submit("missing", nil, "admin"); !errors.Is(err, ErrUnknownKind) {
+		t.Fatalf("Expected ErrUnknownKind, got %v", err)
+	}
+
+	job, err := m.Submit("echo", map[string]string{"value": "ok"}, "admin")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if job.Status != StatusQueued || job.ID == 0 {
+		t.Fatalf("Expected a queued job with an ID, got %+v", job)
+	}
+	done := waitFor(t, m, job.ID)
+	if done.Status != StatusSucceeded || done.Result != "ok" || done.Percent() != 100 {
+		t.Errorf("Expected a succeeded job with result ok, got %+v", done)
+	}
+	if done.StartedAt == nil || done.FinishedAt == nil {
+		t.Errorf("Expected start and finish times to be recorded, got %+v", done)
+	}
+
+	failed, _ := m.Submit("broken", nil, "admin")
+	if j := waitFor(t, m, failed.ID); j.Status != StatusFailed || j.Error != "boom" {
+		t.Errorf("Expected a failed job with error boom, got %+v", j)
+	}
+	if err := m.Cancel(failed.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("Expected ErrFinished when cancelling a finished job, got %v", err)
+	}
+
+	if list := m.List(); len(list) != 2 || list[0].ID != failed.ID {
+		t.Errorf("Expected both jobs listed newest first, got %+v", list)
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	m := NewManager(1, logger.NewColorLogger(false))
+	defer m.Shutdown()
+	started := make(chan struct{}, 2)
+	m.Register("block", "runs until cancelled", func(ctx context.Context, params map[string]string, report Reporter) (any, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	running, _ := m.Submit("block", nil, "admin")
+	<-started
+
+	// A single slot keeps the second job queued behind the first
+	// 只有一个运行槽位时，第二个任务排在第一个之后
+	queued, _ := m.Submit("block", nil, "admin")
+	if j, _ := m.Get(queued.ID); j.Status != StatusQueued {
+		t.Fatalf("Expected the second job to wait for the slot, got %s", j.Status)
+	}
+
+	if err := m.Cancel(queued.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if j := waitFor(t, m, queued.ID); j.Status != StatusCancelled || j.StartedAt != nil {
+		t.Errorf("Expected the queued job cancelled before starting, got %+v", j)
+	}
+
+	if err := m.Cancel(running.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if j := waitFor(t, m, running.ID); j.Status != StatusCancelled {
+		t.Errorf("Expected the running job cancelled, got %s", j.Status)
+	}
+
+	if err := m.Cancel(999); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
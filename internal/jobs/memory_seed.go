@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// KindMemorySeed replays historical klines into situation memory
+// KindMemorySeed 将历史 K 线回放为情境记忆
+const KindMemorySeed = "memory-seed"
+
+// SeedSource marks situations produced by replaying historical klines
+// SeedSource 标记由历史 K 线回放生成的情境
+const SeedSource = "seed"
+
+// defaultSeedDays is how much history a seed replays when no days are given
+// defaultSeedDays 是未指定天数时回放的历史天数
+const defaultSeedDays = 180
+
+// SeedResult is the outcome of seeding one symbol
+// SeedResult 是单个交易对的回放结果
+type SeedResult struct {
+	Symbol   string `json:"symbol"`
+	Klines   int    `json:"klines"`
+	Setups   int    `json:"setups"`
+	Inserted int    `json:"inserted"`
+	Skipped  int    `json:"skipped"` // 已存在的情境 / Situations already stored
+	Error    string `json:"error,omitempty"`
+}
+
+// SeedMemory scans days of history of every configured symbol for setups, simulates a trade on each and stores the
+// outcomes as situation memory
+// SeedMemory 扫描每个配置交易对 days 天的历史形态，逐个模拟交易并将结果保存为情境记忆
+//
+// Seeding is idempotent: situations already stored are skipped. A symbol whose klines cannot be fetched is reported
+// in its result and the others still run; a failed save stops the seed.
+// 回放是幂等的，已存在的情境会被跳过。无法获取 K 线的交易对在其结果中报告，其余交易对继续运行；保存失败则终止回放。
+func SeedMemory(ctx context.Context, db *storage.Storage, cfg *config.Config, days int, report Reporter) ([]SeedResult, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("days must be positive, got %d", days)
+	}
+
+	marketData := dataflows.NewMarketData(cfg)
+	timeframe := cfg.CryptoTimeframe
+	end := time.Now()
+	start := end.AddDate(0, 0, -days)
+
+	results := make([]SeedResult, 0, len(cfg.CryptoSymbols))
+	for i, symbol := range cfg.CryptoSymbols {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		binanceSymbol := cfg.GetBinanceSymbolFor(symbol)
+		report(i, len(cfg.CryptoSymbols), fmt.Sprintf("%s %s (%s ~ %s)", binanceSymbol, timeframe, start.Format("2006-01-02"), end.Format("2006-01-02")))
+
+		result := SeedResult{Symbol: binanceSymbol}
+		data, err := marketData.GetOHLCVRange(ctx, binanceSymbol, timeframe, start, end)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			result.Error = fmt.Sprintf("failed to fetch klines: %v", err)
+			results = append(results, result)
+			continue
+		}
+		result.Klines = len(data)
+
+		setups := dataflows.ScanSetups(data, dataflows.DefaultSetupLookback)
+		result.Setups = len(setups)
+		for _, setup := range setups {
+			outcome, ok := dataflows.SimulateOutcome(data, setup, dataflows.DefaultSetupHoldBars)
+			if !ok {
+				continue
+			}
+
+			features, _ := json.Marshal(setup.Features)
+			saved, err := db.SaveSituationMemory(&storage.SituationMemory{
+				Symbol:        binanceSymbol,
+				Timeframe:     timeframe,
+				SituationTime: data[setup.Index].Timestamp,
+				Setup:         setup.Type,
+				Direction:     setup.Direction,
+				Features:      string(features),
+				Situation: fmt.Sprintf("%s: %s", dataflows.SetupTypeLabel(setup.Type, setup.Direction),
+					dataflows.DescribeSetupFeatures(setup.Features)),
+				Outcome:   outcome.Result,
+				ReturnPct: outcome.ReturnPct,
+				RMultiple: outcome.RMultiple,
+				BarsHeld:  outcome.BarsHeld,
+				Source:    SeedSource,
+			})
+			if err != nil {
+				return results, fmt.Errorf("failed to save situation: %w", err)
+			}
+			if saved {
+				result.Inserted++
+			} else {
+				result.Skipped++
+			}
+		}
+		results = append(results, result)
+	}
+	report(len(cfg.CryptoSymbols), len(cfg.CryptoSymbols), "done")
+	return results, nil
+}
+
+// MemorySeed returns the runner of memory-seed jobs; the optional days parameter sets how much history is replayed
+// MemorySeed 返回 memory-seed 任务的 Runner；可选参数 days 指定回放的历史天数
+func MemorySeed(cfg *config.Config, db *storage.Storage) Runner {
+	return func(ctx context.Context, params map[string]string, report Reporter) (any, error) {
+		days := defaultSeedDays
+		if v := params["days"]; v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid days %q", v)
+			}
+			days = n
+		}
+		return SeedMemory(ctx, db, cfg, days, report)
+	}
+}
@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// Severity decides whether a notification is sent at once or may wait for the digest
+// Severity 决定通知是立即发送还是可以等待汇总
+type Severity int
+
+const (
+	SeverityInfo     Severity = iota // 低优先级（止损微调、止盈级别触发），可合并 / Low severity (stop nudged, TP level hit), may be batched
+	SeverityCritical                 // 关键事件（平仓、持仓无保护），始终立即发送 / Critical (position closed, unprotected), always sent at once
+)
+
+// sendTimeout bounds a single delivery so a slow chat API never holds up trading
+// sendTimeout 限制单次发送时长，避免慢速接口拖住交易
+const sendTimeout = 10 * time.Second
+
+// Sender delivers a text message to a chat
+// Sender 将文本消息发送到聊天
+type Sender interface {
+	Send(ctx context.Context, text string) error
+}
+
+// TelegramSender sends messages through the Telegram Bot API
+// TelegramSender 通过 Telegram Bot API 发送消息
+type TelegramSender struct {
+	token  string       // Bot Token / Bot token
+	chatID string       // 接收消息的聊天 ID / Chat receiving the messages
+	client *http.Client // HTTP 客户端 / HTTP client
+}
+
+// NewTelegramSender creates a Telegram sender
+// NewTelegramSender 创建 Telegram 发送器
+func NewTelegramSender(token, chatID string) *TelegramSender {
+	return &TelegramSender{
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: sendTimeout},
+	}
+}
+
+// Send posts text to the configured chat
+// Send 将文本发送到配置的聊天
+func (t *TelegramSender) Send(ctx context.Context, text string) error {
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
+	form := url.Values{"chat_id": {t.chatID}, "text": {text}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telegram message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Notifier sends trading notifications, batching low-severity ones into a periodic digest
+// Notifier 发送交易通知，将低优先级通知合并为定期汇总
+//
+// With a zero digest interval every notification is sent at once. A nil Notifier is a no-op.
+// 汇总间隔为 0 时每条通知都立即发送。Notifier 为 nil 时不做任何事。
+type Notifier struct {
+	sender         Sender              // 消息发送器 / Message sender
+	digestInterval time.Duration       // 汇总间隔，0 表示不汇总 / Digest interval, 0 disables batching
+	logger         *logger.ColorLogger // 日志 / Logger
+
+	mu       sync.Mutex     // 保护 pending / Protects pending
+	pending  []string       // 等待汇总的通知 / Notifications waiting for the digest
+	inflight sync.WaitGroup // 正在发送的即时通知 / Immediate notifications being sent
+}
+
+// NewNotifier creates a notifier; it returns nil when sender is nil so callers need no checks
+// NewNotifier 创建通知器；sender 为 nil 时返回 nil，调用方无需判断
+func NewNotifier(sender Sender, digestInterval time.Duration, log *logger.ColorLogger) *Notifier {
+	if sender == nil {
+		return nil
+	}
+	return &Notifier{
+		sender:         sender,
+		digestInterval: digestInterval,
+		logger:         log,
+	}
+}
+
+// NewFromConfig creates the notifier described by the configuration, or nil when Telegram is not configured
+// NewFromConfig 按配置创建通知器，未配置 Telegram 时返回 nil
+func NewFromConfig(cfg *config.Config, log *logger.ColorLogger) *Notifier {
+	if cfg.TelegramBotToken == "" || cfg.TelegramChatID == "" {
+		return nil
+	}
+	return NewNotifier(NewTelegramSender(cfg.TelegramBotToken, cfg.TelegramChatID),
+		time.Duration(cfg.NotifyDigestMinutes)*time.Minute, log)
+}
+
+// Notify sends a critical notification at once, and queues an info notification for the digest
+// Notify 立即发送关键通知，将普通通知放入汇总队列
+func (n *Notifier) Notify(severity Severity, symbol, message string) {
+	if n == nil {
+		return
+	}
+	text := fmt.Sprintf("【%s】%s", symbol, message)
+	if severity == SeverityCritical || n.digestInterval <= 0 {
+		n.inflight.Add(1)
+		go func() {
+			defer n.inflight.Done()
+			n.send(text)
+		}()
+		return
+	}
+
+	n.mu.Lock()
+	n.pending = append(n.pending, fmt.Sprintf("%s %s", time.Now().Format("15:04"), text))
+	n.mu.Unlock()
+}
+
+// Pending returns the number of notifications waiting for the digest
+// Pending 返回等待汇总的通知数量
+func (n *Notifier) Pending() int {
+	if n == nil {
+		return 0
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.pending)
+}
+
+// Flush sends the queued notifications as one digest message
+// Flush 将排队的通知合并为一条汇总消息发送
+func (n *Notifier) Flush() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	items := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	if len(items) == 0 {
+		return
+	}
+	n.send(formatDigest(items))
+}
+
+// Run flushes the digest every interval until ctx is cancelled
+// Run 每个间隔发送一次汇总，直到 ctx 取消
+func (n *Notifier) Run(ctx context.Context) {
+	if n == nil || n.digestInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(n.digestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n.Flush()
+		}
+	}
+}
+
+// Close sends what is left in the digest and waits for immediate notifications still in flight
+// Close 发送剩余的汇总通知，并等待正在发送的即时通知完成
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	n.Flush()
+	n.inflight.Wait()
+}
+
+// send delivers text and logs a failure; notifications never interrupt trading
+// send 发送文本并记录失败；通知失败不影响交易
+func (n *Notifier) send(text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := n.sender.Send(ctx, text); err != nil {
+		n.logger.Warning(fmt.Sprintf("⚠️ 发送通知失败: %v", err))
+	}
+}
+
+// formatDigest joins queued notifications into a single message
+// formatDigest 将排队的通知拼接为一条消息
+func formatDigest(items []string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📋 交易通知汇总（%d 条）\n", len(items)))
+	for _, item := range items {
+		b.WriteString("• ")
+		b.WriteString(item)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
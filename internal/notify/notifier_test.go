@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/logger"
+)
+
+// recordingSender keeps every message it is asked to send
+// recordingSender 记录所有待发送的消息
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recordingSender) Send(ctx context.Context, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, text)
+	return nil
+}
+
+func (r *recordingSender) sent() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...)
+}
+
+func TestNotifierDigest(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewNotifier(sender, time.Hour, logger.NewColorLogger(false))
+
+	n.Notify(SeverityInfo, "BTCUSDT", "止损 100.00 → 100.30")
+	n.Notify(SeverityInfo, "ETHUSDT", "止盈级别 1 触发")
+	n.Notify(SeverityCritical, "SOLUSDT", "持仓已平仓")
+	n.inflight.Wait()
+
+	// Only the critical event goes out before the digest
+	// 汇总前只发送关键事件
+	messages := sender.sent()
+	if len(messages) != 1 || !strings.Contains(messages[0], "【SOLUSDT】") {
+		t.Fatalf("expected only the critical notification, got %v", messages)
+	}
+	if n.Pending() != 2 {
+		t.Fatalf("expected 2 pending notifications, got %d", n.Pending())
+	}
+
+	n.Close()
+	messages = sender.sent()
+	if len(messages) != 2 {
+		t.Fatalf("expected the digest as the second message, got %v", messages)
+	}
+	digest := messages[1]
+	if !strings.Contains(digest, "2 条") || !strings.Contains(digest, "【BTCUSDT】") || !strings.Contains(digest, "【ETHUSDT】") {
+		t.Errorf("digest missing entries: %s", digest)
+	}
+	if n.Pending() != 0 {
+		t.Errorf("expected the queue to be empty after Close, got %d", n.Pending())
+	}
+}
+
+func TestNotifierWithoutDigest(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewNotifier(sender, 0, logger.NewColorLogger(false))
+
+	n.Notify(SeverityInfo, "BTCUSDT", "止损 100.00 → 100.30")
+	n.Close()
+
+	if messages := sender.sent(); len(messages) != 1 {
+		t.Fatalf("expected the info notification to be sent at once, got %v", messages)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	n.Notify(SeverityCritical, "BTCUSDT", "ignored")
+	n.Close()
+	if n.Pending() != 0 {
+		t.Error("nil notifier should have nothing pending")
+	}
+}
@@ -0,0 +1,150 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+const (
+	defaultAttributionHalfLife = 30 * 24 * time.Hour // 默认权重半衰期 / Default weight half-life
+	attributionMaxLag          = 6 * time.Hour       // 开仓最迟在决策会话之后多久 / Latest an entry may follow its decision session
+)
+
+// AttributionGroup is the time-weighted outcome of trades decided with an analyst in one status
+// AttributionGroup 是分析师处于某一状态时所决策交易的时间加权结果
+type AttributionGroup struct {
+	Trades  int     `json:"trades"`   // 交易数 / Number of trades
+	Weight  float64 `json:"weight"`   // 时间衰减后的权重合计 / Sum of time-decayed weights
+	MeanR   float64 `json:"mean_r"`   // 加权平均 R / Weighted mean R
+	WinRate float64 `json:"win_rate"` // 加权胜率 / Weighted win rate
+
+	sumR float64 // 加权 R 合计 / Weighted sum of R
+	wins float64 // 加权盈利笔数 / Weighted count of winners
+}
+
+// add counts one trade with its R-multiple and weight
+// add 计入一笔交易的 R 倍数和权重
+func (g *AttributionGroup) add(r, weight float64) {
+	g.Trades++
+	g.Weight += weight
+	g.sumR += r * weight
+	if r > 0 {
+		g.wins += weight
+	}
+}
+
+// finish turns the weighted sums into means
+// finish 将加权合计换算为均值
+func (g *AttributionGroup) finish() {
+	if g.Weight > 0 {
+		g.MeanR = g.sumR / g.Weight
+		g.WinRate = g.wins / g.Weight
+	}
+}
+
+// AnalystAttribution compares the trades decided with an analyst's full report against those decided without it
+// AnalystAttribution 比较分析师报告完整时与缺失时所决策交易的结果
+type AnalystAttribution struct {
+	Analyst  string           `json:"analyst"`        // 分析师 / Analyst
+	Present  AttributionGroup `json:"present"`        // 报告完整 / Report complete
+	Degraded AttributionGroup `json:"degraded"`       // 报告降级 / Report degraded
+	Absent   AttributionGroup `json:"absent"`         // 未运行 / Did not run
+	Edge     *float64         `json:"edge,omitempty"` // 完整时的加权平均 R 减去降级与缺失合计的加权平均 R，任一侧无交易时为空 / Weighted mean R when present minus that of degraded and absent combined, empty when either side has no trades
+}
+
+// AnalystAttributionReport is the per-analyst attribution of closed trades, recent trades weighing more
+// AnalystAttributionReport 是已平仓交易按分析师的归因统计，近期交易权重更高
+type AnalystAttributionReport struct {
+	HalfLifeDays float64              `json:"half_life_days"` // 权重半衰期（天）/ Weight half-life in days
+	Trades       int                  `json:"trades"`         // 已关联决策会话的交易数 / Trades matched to their decision session
+	Unmatched    int                  `json:"unmatched"`      // 找不到决策会话、缺少分析师状态或无法计算 R 的交易数 / Trades without a session, analyst status or R-multiple
+	Analysts     []AnalystAttribution `json:"analysts"`       // 按分析师名称排序 / Sorted by analyst name
+}
+
+// CalculateAnalystAttribution attributes the R-multiples of closed trades to the analyst statuses of their entry sessions
+// CalculateAnalystAttribution 将已平仓交易的 R 倍数归因到其开仓决策会话的分析师状态
+//
+// A trade belongs to the latest BUY/SELL session of the same symbol and side created at most attributionMaxLag before
+// its entry; its weight halves every halfLife since it closed (halfLife <= 0 uses 30 days).
+// 交易归属于同一交易对、同一方向、在开仓前 attributionMaxLag 内创建的最新 BUY/SELL 会话；
+// 其权重自平仓起每经过 halfLife 减半（halfLife <= 0 时使用 30 天）。
+func CalculateAnalystAttribution(trades []*storage.PositionRecord, sessions []*storage.TradingSession, asOf time.Time, halfLife time.Duration) *AnalystAttributionReport {
+	if halfLife <= 0 {
+		halfLife = defaultAttributionHalfLife
+	}
+	report := &AnalystAttributionReport{HalfLifeDays: halfLife.Hours() / 24, Analysts: []AnalystAttribution{}}
+
+	byAnalyst := make(map[string]*AnalystAttribution)
+	for _, p := range trades {
+		if !p.Closed || p.CloseTime == nil {
+			continue
+		}
+		r, _, ok := TradeRMultiple(p)
+		session := entrySession(p, sessions)
+		if !ok || session == nil || len(session.AnalystStatus) == 0 {
+			report.Unmatched++
+			continue
+		}
+		report.Trades++
+
+		weight := math.Pow(0.5, math.Max(asOf.Sub(*p.CloseTime).Hours(), 0)/halfLife.Hours())
+		for analyst, status := range session.AnalystStatus {
+			a := byAnalyst[analyst]
+			if a == nil {
+				a = &AnalystAttribution{Analyst: analyst}
+				byAnalyst[analyst] = a
+			}
+			switch status {
+			case storage.AnalystPresent:
+				a.Present.add(r, weight)
+			case storage.AnalystDegraded:
+				a.Degraded.add(r, weight)
+			default:
+				a.Absent.add(r, weight)
+			}
+		}
+	}
+
+	for _, a := range byAnalyst {
+		withoutWeight := a.Degraded.Weight + a.Absent.Weight
+		if a.Present.Weight > 0 && withoutWeight > 0 {
+			edge := a.Present.sumR/a.Present.Weight - (a.Degraded.sumR+a.Absent.sumR)/withoutWeight
+			a.Edge = &edge
+		}
+		a.Present.finish()
+		a.Degraded.finish()
+		a.Absent.finish()
+		report.Analysts = append(report.Analysts, *a)
+	}
+	sort.Slice(report.Analysts, func(i, j int) bool { return report.Analysts[i].Analyst < report.Analysts[j].Analyst })
+	return report
+}
+
+// entrySession returns the latest session that decided a position's entry, or nil
+// entrySession 返回决定该持仓开仓的最新会话，找不到时返回 nil
+func entrySession(p *storage.PositionRecord, sessions []*storage.TradingSession) *storage.TradingSession {
+	action := "BUY"
+	if p.Side == "short" {
+		action = "SELL"
+	}
+	symbol := strings.ReplaceAll(p.Symbol, "/", "")
+
+	var latest *storage.TradingSession
+	for _, s := range sessions {
+		if s.Action != action || strings.ReplaceAll(s.Symbol, "/", "") != symbol {
+			continue
+		}
+		lag := p.EntryTime.Sub(s.CreatedAt)
+		if lag < 0 || lag > attributionMaxLag {
+			continue
+		}
+		if latest == nil || s.CreatedAt.After(latest.CreatedAt) {
+			latest = s
+		}
+	}
+	return latest
+}
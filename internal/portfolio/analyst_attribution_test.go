@@ -0,0 +1,64 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestCalculateAnalystAttribution(t *testing.T) {
+	asOf := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	trade := func(id, symbol, side string, entry time.Time, r float64, closedAgo time.Duration) *storage.PositionRecord {
+		closeTime := asOf.Add(-closedAgo)
+		stop := 95.0
+		if side == "short" {
+			stop = 105
+		}
+		return &storage.PositionRecord{ID: id, Symbol: symbol, Side: side, EntryPrice: 100, EntryTime: entry, InitialStopLoss: stop,
+			Quantity: 1, RealizedPnL: r * 5, Closed: true, CloseTime: &closeTime}
+	}
+	session := func(symbol, action string, at time.Time, sentiment string) *storage.TradingSession {
+		return &storage.TradingSession{Symbol: symbol, Action: action, CreatedAt: at,
+			AnalystStatus: map[string]string{"market": storage.AnalystPresent, "sentiment": sentiment}}
+	}
+
+	t1 := asOf.Add(-40 * 24 * time.Hour)
+	t2 := asOf.Add(-20 * 24 * time.Hour)
+	t3 := asOf.Add(-10 * 24 * time.Hour)
+	sessions := []*storage.TradingSession{
+		session("BTC/USDT", "BUY", t1.Add(-10*time.Minute), storage.AnalystPresent),
+		session("BTC/USDT", "BUY", t1.Add(-3*time.Hour), storage.AnalystAbsent), // 更早的会话被更晚的取代 / Superseded by the later one
+		session("ETH/USDT", "SELL", t2.Add(-5*time.Minute), storage.AnalystDegraded),
+		session("SOL/USDT", "BUY", t3.Add(-5*time.Minute), storage.AnalystAbsent),
+	}
+	trades := []*storage.PositionRecord{
+		trade("a", "BTCUSDT", "long", t1, 2, 0),                           // 权重 1 / Weight 1
+		trade("b", "ETHUSDT", "short", t2, -1, 30*24*time.Hour),           // 权重 0.5 / Weight 0.5
+		trade("c", "SOLUSDT", "long", t3, 1, 30*24*time.Hour),             // 权重 0.5 / Weight 0.5
+		trade("d", "SOLUSDT", "short", t3, 1, 0),                          // 没有 SELL 会话 / No SELL session
+		trade("e", "BTCUSDT", "long", t1.Add(24*time.Hour), 1, time.Hour), // 会话超过 6 小时 / Session more than 6h earlier
+	}
+
+	report := CalculateAnalystAttribution(trades, sessions, asOf, 30*24*time.Hour)
+	if report.Trades != 3 || report.Unmatched != 2 || len(report.Analysts) != 2 {
+		t.Fatalf("Expected 3 matched and 2 unmatched trades over 2 analysts, got %+v", report)
+	}
+
+	market, sentiment := report.Analysts[0], report.Analysts[1]
+	if market.Analyst != "market" || market.Present.Trades != 3 || market.Edge != nil {
+		t.Errorf("Expected market present on every trade and no edge, got %+v", market)
+	}
+	// Present: R 2 at weight 1; without: R -1 and 1 at weight 0.5 each
+	// 完整：R 2 权重 1；缺失：R -1 与 1 各权重 0.5
+	if sentiment.Present.Trades != 1 || sentiment.Degraded.Trades != 1 || sentiment.Absent.Trades != 1 {
+		t.Fatalf("Unexpected sentiment groups: %+v", sentiment)
+	}
+	if math.Abs(sentiment.Absent.Weight-0.5) > 1e-9 || sentiment.Absent.WinRate != 1 {
+		t.Errorf("Expected a half-weight winner when absent, got %+v", sentiment.Absent)
+	}
+	if sentiment.Edge == nil || math.Abs(*sentiment.Edge-2) > 1e-9 {
+		t.Errorf("Expected a sentiment edge of 2R, got %v", sentiment.Edge)
+	}
+}
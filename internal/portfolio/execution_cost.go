@@ -0,0 +1,131 @@
+package portfolio
+
+import (
+	"sort"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// ExecutionCostOrder is the execution cost of one order
+// ExecutionCostOrder 是一笔订单的执行成本
+type ExecutionCostOrder struct {
+	Symbol        string    `json:"symbol"`         // 交易对 / Trading pair
+	Action        string    `json:"action"`         // 交易动作 / Trade action
+	OrderID       string    `json:"order_id"`       // 订单 ID / Order ID
+	Liquidity     string    `json:"liquidity"`      // maker/taker/mixed
+	Quantity      float64   `json:"quantity"`       // 成交数量 / Filled quantity
+	DecisionPrice float64   `json:"decision_price"` // 决策时价格 / Price when the decision was made
+	FillPrice     float64   `json:"fill_price"`     // 成交均价 / Average fill price
+	SlippageBps   float64   `json:"slippage_bps"`   // 滑点（基点，正数为不利）/ Slippage in bps (positive is adverse)
+	SlippageCost  float64   `json:"slippage_cost"`  // 滑点成本（USDT）/ Slippage cost in USDT
+	Fee           float64   `json:"fee"`            // 手续费（USDT）/ Fee in USDT
+	ExecutedAt    time.Time `json:"executed_at"`    // 成交时间 / Execution time
+}
+
+// ExecutionCostGroup aggregates the execution costs of a group of orders
+// ExecutionCostGroup 汇总一组订单的执行成本
+// Bps figures are notional-weighted so large orders count in proportion to what they cost
+// 基点数据按名义价值加权，大单按其实际成本占比计入
+type ExecutionCostGroup struct {
+	Key             string  `json:"key"`                // 分组键（maker/taker/mixed 或交易对）/ Group key (maker/taker/mixed or symbol)
+	Orders          int     `json:"orders"`             // 订单数 / Number of orders
+	Notional        float64 `json:"notional"`           // 成交名义价值（USDT）/ Filled notional in USDT
+	SlippageCost    float64 `json:"slippage_cost"`      // 滑点成本（USDT）/ Slippage cost in USDT
+	Fees            float64 `json:"fees"`               // 手续费（USDT）/ Fees in USDT
+	TotalCost       float64 `json:"total_cost"`         // 滑点 + 手续费（USDT）/ Slippage plus fees in USDT
+	AvgSlippageBps  float64 `json:"avg_slippage_bps"`   // 平均滑点（基点）/ Average slippage in bps
+	AvgFeeBps       float64 `json:"avg_fee_bps"`        // 平均手续费（基点）/ Average fee in bps
+	AvgTotalCostBps float64 `json:"avg_total_cost_bps"` // 平均总成本（基点）/ Average total cost in bps
+	WorstSlippage   float64 `json:"worst_slippage_bps"` // 最差单笔滑点（基点）/ Worst single-order slippage in bps
+}
+
+// ExecutionCostReport is the execution cost summary served by the analytics endpoint
+// ExecutionCostReport 是分析接口返回的执行成本汇总
+type ExecutionCostReport struct {
+	Total       ExecutionCostGroup   `json:"total"`        // 全部订单 / All orders
+	ByLiquidity []ExecutionCostGroup `json:"by_liquidity"` // 按 maker/taker 分组 / By maker/taker
+	BySymbol    []ExecutionCostGroup `json:"by_symbol"`    // 按交易对分组 / By symbol
+	Orders      []ExecutionCostOrder `json:"orders"`       // 逐笔明细（按成交时间）/ Per-order details by execution time
+}
+
+// add accumulates one order into the group
+// add 将一笔订单累加到分组
+func (g *ExecutionCostGroup) add(c *storage.ExecutionCost) {
+	if g.Orders == 0 || c.SlippageBps > g.WorstSlippage {
+		g.WorstSlippage = c.SlippageBps
+	}
+	g.Orders++
+	g.Notional += c.FillPrice * c.Quantity
+	g.SlippageCost += c.SlippageCost
+	g.Fees += c.Fee
+	g.TotalCost = g.SlippageCost + g.Fees
+}
+
+// finish derives the bps averages from the accumulated totals
+// finish 根据累计值计算基点均值
+func (g *ExecutionCostGroup) finish() {
+	if g.Notional <= 0 {
+		return
+	}
+	g.AvgSlippageBps = g.SlippageCost / g.Notional * 10000
+	g.AvgFeeBps = g.Fees / g.Notional * 10000
+	g.AvgTotalCostBps = g.TotalCost / g.Notional * 10000
+}
+
+// CalculateExecutionCosts aggregates order execution costs in total, by maker/taker liquidity and by symbol
+// CalculateExecutionCosts 按总计、maker/taker 流动性和交易对汇总订单执行成本
+// Comparing the taker and maker groups shows how much market execution costs over resting limit orders
+// 对比 taker 与 maker 分组即可看出市价成交相对挂单成交多付出的成本
+func CalculateExecutionCosts(costs []*storage.ExecutionCost) *ExecutionCostReport {
+	report := &ExecutionCostReport{
+		Total:       ExecutionCostGroup{Key: "total"},
+		ByLiquidity: []ExecutionCostGroup{},
+		BySymbol:    []ExecutionCostGroup{},
+		Orders:      make([]ExecutionCostOrder, 0, len(costs)),
+	}
+
+	byLiquidity := make(map[string]*ExecutionCostGroup)
+	bySymbol := make(map[string]*ExecutionCostGroup)
+	group := func(groups map[string]*ExecutionCostGroup, key string) *ExecutionCostGroup {
+		g, ok := groups[key]
+		if !ok {
+			g = &ExecutionCostGroup{Key: key}
+			groups[key] = g
+		}
+		return g
+	}
+
+	for _, c := range costs {
+		report.Total.add(c)
+		group(byLiquidity, c.Liquidity).add(c)
+		group(bySymbol, c.Symbol).add(c)
+		report.Orders = append(report.Orders, ExecutionCostOrder{
+			Symbol:        c.Symbol,
+			Action:        c.Action,
+			OrderID:       c.OrderID,
+			Liquidity:     c.Liquidity,
+			Quantity:      c.Quantity,
+			DecisionPrice: c.DecisionPrice,
+			FillPrice:     c.FillPrice,
+			SlippageBps:   c.SlippageBps,
+			SlippageCost:  c.SlippageCost,
+			Fee:           c.Fee,
+			ExecutedAt:    c.ExecutedAt,
+		})
+	}
+
+	report.Total.finish()
+	collect := func(groups map[string]*ExecutionCostGroup) []ExecutionCostGroup {
+		out := make([]ExecutionCostGroup, 0, len(groups))
+		for _, g := range groups {
+			g.finish()
+			out = append(out, *g)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+		return out
+	}
+	report.ByLiquidity = collect(byLiquidity)
+	report.BySymbol = collect(bySymbol)
+	return report
+}
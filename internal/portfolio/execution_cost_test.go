@@ -0,0 +1,40 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestCalculateExecutionCosts(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	costs := []*storage.ExecutionCost{
+		{Symbol: "BTCUSDT", Liquidity: storage.LiquidityTaker, Quantity: 0.1, FillPrice: 50000, SlippageCost: 5, Fee: 2, SlippageBps: 10, ExecutedAt: at},
+		{Symbol: "ETHUSDT", Liquidity: storage.LiquidityTaker, Quantity: 5, FillPrice: 3000, SlippageCost: 4.5, Fee: 6, SlippageBps: 3, ExecutedAt: at},
+		{Symbol: "BTCUSDT", Liquidity: storage.LiquidityMaker, Quantity: 0.2, FillPrice: 50000, SlippageCost: -2, Fee: 2, SlippageBps: -2, ExecutedAt: at},
+	}
+
+	report := CalculateExecutionCosts(costs)
+	if report.Total.Orders != 3 || report.Total.Notional != 30000 || math.Abs(report.Total.TotalCost-17.5) > 1e-9 {
+		t.Errorf("Unexpected totals: %+v", report.Total)
+	}
+	if len(report.ByLiquidity) != 2 || len(report.BySymbol) != 2 || len(report.Orders) != 3 {
+		t.Fatalf("Unexpected groups: %+v / %+v", report.ByLiquidity, report.BySymbol)
+	}
+
+	// Taker orders: 20000 notional, 9.5 slippage + 8 fees; maker: 10000 notional, -2 slippage + 2 fees
+	// Taker 订单：名义价值 20000，滑点 9.5 + 手续费 8；maker：名义价值 10000，滑点 -2 + 手续费 2
+	maker, taker := report.ByLiquidity[0], report.ByLiquidity[1]
+	if maker.Key != storage.LiquidityMaker || math.Abs(maker.AvgTotalCostBps) > 1e-9 || maker.WorstSlippage != -2 {
+		t.Errorf("Unexpected maker group: %+v", maker)
+	}
+	if taker.Key != storage.LiquidityTaker || math.Abs(taker.AvgSlippageBps-4.75) > 1e-9 || math.Abs(taker.AvgFeeBps-4) > 1e-9 || taker.WorstSlippage != 10 {
+		t.Errorf("Unexpected taker group: %+v", taker)
+	}
+
+	if empty := CalculateExecutionCosts(nil); empty.Total.Orders != 0 || empty.Orders == nil || empty.ByLiquidity == nil {
+		t.Errorf("Expected empty slices for no orders, got %+v", empty)
+	}
+}
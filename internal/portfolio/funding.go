@@ -0,0 +1,137 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// SymbolFunding is the funding paid and received on one symbol next to its trading PnL
+// SymbolFunding 是单个交易对的资金费收支及其交易盈亏
+type SymbolFunding struct {
+	Symbol     string  `json:"symbol"`      // 交易对 / Trading pair
+	Paid       float64 `json:"paid"`        // 支付的资金费（正数）/ Funding paid (positive)
+	Received   float64 `json:"received"`    // 收到的资金费 / Funding received
+	Net        float64 `json:"net"`         // 资金费净收入（负为成本）/ Net funding (negative is cost)
+	Payments   int     `json:"payments"`    // 资金费结算次数 / Number of funding settlements
+	TradingPnL float64 `json:"trading_pnl"` // 扣除手续费后的交易已实现盈亏 / Realized trading PnL after commissions
+	Dominant   bool    `json:"dominant"`    // 资金费绝对值超过交易盈亏 / Funding outweighs trading PnL
+}
+
+// FundingBySymbol groups funding payments per symbol, largest net funding first
+// FundingBySymbol 按交易对汇总资金费，净额绝对值大的在前
+//
+// Symbols that only traded are included too, so a symbol's funding can be read against its trading PnL.
+// 只有交易没有资金费的交易对也会列出，以便对照资金费与交易盈亏。
+func FundingBySymbol(trades []*storage.PositionRecord, funding []executors.FundingPayment) []SymbolFunding {
+	bySymbol := make(map[string]*SymbolFunding)
+	entry := func(symbol string) *SymbolFunding {
+		// Trades are stored as BTC/USDT and funding arrives as BTCUSDT
+		// 交易记录为 BTC/USDT 格式，资金费为 BTCUSDT 格式
+		symbol = strings.ReplaceAll(symbol, "/", "")
+		if bySymbol[symbol] == nil {
+			bySymbol[symbol] = &SymbolFunding{Symbol: symbol}
+		}
+		return bySymbol[symbol]
+	}
+
+	for _, payment := range funding {
+		f := entry(payment.Symbol)
+		f.Payments++
+		f.Net += payment.Amount
+		if payment.Amount < 0 {
+			f.Paid -= payment.Amount
+		} else {
+			f.Received += payment.Amount
+		}
+	}
+	for _, trade := range trades {
+		entry(trade.Symbol).TradingPnL += trade.RealizedPnL - trade.Commission
+	}
+
+	out := make([]SymbolFunding, 0, len(bySymbol))
+	for _, f := range bySymbol {
+		f.Dominant = f.Payments > 0 && math.Abs(f.Net) > math.Abs(f.TradingPnL)
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if math.Abs(out[i].Net) != math.Abs(out[j].Net) {
+			return math.Abs(out[i].Net) > math.Abs(out[j].Net)
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// DailySummary is the end-of-day report of realized trading PnL and funding
+// DailySummary 是每日结束时的交易已实现盈亏与资金费报告
+type DailySummary struct {
+	Date         time.Time       `json:"date"`          // 统计日（TIMEZONE 时区零点）/ Day covered, midnight in TIMEZONE
+	ClosedTrades int             `json:"closed_trades"` // 平仓笔数 / Closed trades
+	TradingPnL   float64         `json:"trading_pnl"`   // 扣除手续费后的交易已实现盈亏 / Realized trading PnL after commissions
+	FundingPnL   float64         `json:"funding_pnl"`   // 资金费净收入 / Net funding income
+	NetPnL       float64         `json:"net_pnl"`       // 交易盈亏 + 资金费 / Trading PnL plus funding
+	Funding      []SymbolFunding `json:"funding"`       // 按交易对的资金费明细 / Funding per symbol
+}
+
+// BuildDailySummary summarizes the trades closed and funding settled on the day starting at dayStart
+// BuildDailySummary 汇总从 dayStart 开始的一天内平仓的交易和结算的资金费
+//
+// Records outside [dayStart, dayStart+1 day) are ignored, so callers may pass a wider query result.
+// [dayStart, dayStart+1 天) 之外的记录会被忽略，调用方可以直接传入范围更大的查询结果。
+func BuildDailySummary(dayStart time.Time, trades []*storage.PositionRecord, funding []executors.FundingPayment) *DailySummary {
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	inDay := func(t time.Time) bool {
+		return !t.Before(dayStart) && t.Before(dayEnd)
+	}
+
+	var dayTrades []*storage.PositionRecord
+	for _, trade := range trades {
+		if trade.CloseTime != nil && inDay(*trade.CloseTime) {
+			dayTrades = append(dayTrades, trade)
+		}
+	}
+	var dayFunding []executors.FundingPayment
+	for _, payment := range funding {
+		if inDay(payment.Time) {
+			dayFunding = append(dayFunding, payment)
+		}
+	}
+
+	summary := &DailySummary{
+		Date:         dayStart,
+		ClosedTrades: len(dayTrades),
+		Funding:      FundingBySymbol(dayTrades, dayFunding),
+	}
+	for _, f := range summary.Funding {
+		summary.TradingPnL += f.TradingPnL
+		summary.FundingPnL += f.Net
+	}
+	summary.NetPnL = summary.TradingPnL + summary.FundingPnL
+	return summary
+}
+
+// Format renders the summary as a notification message
+// Format 将汇总渲染为通知消息
+func (s *DailySummary) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📅 %s 每日汇总\n", s.Date.Format("2006-01-02"))
+	fmt.Fprintf(&b, "平仓 %d 笔，交易盈亏 %+.2f USDT，资金费 %+.2f USDT，合计 %+.2f USDT",
+		s.ClosedTrades, s.TradingPnL, s.FundingPnL, s.NetPnL)
+	for _, f := range s.Funding {
+		if f.Payments == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n  %s: 资金费 %+.2f（收 %.2f / 付 %.2f，%d 次），交易盈亏 %+.2f",
+			f.Symbol, f.Net, f.Received, f.Paid, f.Payments, f.TradingPnL)
+		if f.Dominant {
+			b.WriteString(" ⚠️ 资金费超过交易盈亏")
+		}
+	}
+	return b.String()
+}
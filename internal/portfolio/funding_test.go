@@ -0,0 +1,66 @@
+package portfolio
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestFundingBySymbol(t *testing.T) {
+	trades := []*storage.PositionRecord{
+		{Symbol: "BTC/USDT", RealizedPnL: 5, Commission: 1},
+		{Symbol: "ETH/USDT", RealizedPnL: 50},
+	}
+	funding := []executors.FundingPayment{
+		{Symbol: "BTCUSDT", Amount: -8},
+		{Symbol: "BTCUSDT", Amount: 3},
+		{Symbol: "ETHUSDT", Amount: 2},
+	}
+
+	result := FundingBySymbol(trades, funding)
+	if len(result) != 2 || result[0].Symbol != "BTCUSDT" {
+		t.Fatalf("Expected BTCUSDT first of two symbols, got %+v", result)
+	}
+	btc := result[0]
+	if btc.Paid != 8 || btc.Received != 3 || btc.Net != -5 || btc.Payments != 2 || btc.TradingPnL != 4 {
+		t.Errorf("Unexpected BTCUSDT funding: %+v", btc)
+	}
+	if !btc.Dominant {
+		t.Error("Expected BTCUSDT funding to outweigh its trading PnL")
+	}
+	if result[1].Dominant {
+		t.Errorf("Expected ETHUSDT trading PnL to outweigh funding: %+v", result[1])
+	}
+}
+
+func TestBuildDailySummary(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	inside := day.Add(5 * time.Hour)
+	before := day.Add(-time.Hour)
+
+	trades := []*storage.PositionRecord{
+		{Symbol: "BTC/USDT", RealizedPnL: 10, CloseTime: &inside},
+		{Symbol: "BTC/USDT", RealizedPnL: 99, CloseTime: &before},
+	}
+	funding := []executors.FundingPayment{
+		{Time: day.Add(8 * time.Hour), Symbol: "SOLUSDT", Amount: -12},
+		{Time: day.Add(24 * time.Hour), Symbol: "SOLUSDT", Amount: -50},
+	}
+
+	summary := BuildDailySummary(day, trades, funding)
+	if summary.ClosedTrades != 1 || summary.TradingPnL != 10 || summary.FundingPnL != -12 {
+		t.Fatalf("Expected only the day's records, got %+v", summary)
+	}
+	if math.Abs(summary.NetPnL+2) > 1e-9 {
+		t.Errorf("Expected net PnL -2, got %.4f", summary.NetPnL)
+	}
+
+	text := summary.Format()
+	if !strings.Contains(text, "SOLUSDT") || !strings.Contains(text, "资金费超过交易盈亏") {
+		t.Errorf("Expected the SOLUSDT funding flagged, got %s", text)
+	}
+}
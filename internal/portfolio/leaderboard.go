@@ -0,0 +1,203 @@
+package portfolio
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// LeaderboardFormat identifies the export format for leaderboard and bot-tracking sites
+// LeaderboardFormat 标识面向排行榜和机器人追踪网站的导出格式
+const LeaderboardFormat = "bot-performance"
+
+// LeaderboardFormatVersion is bumped whenever a field changes meaning
+// LeaderboardFormatVersion 在字段含义变化时递增
+const LeaderboardFormatVersion = 1
+
+// LeaderboardDay is one day of the return series
+// LeaderboardDay 是收益序列中的一天
+type LeaderboardDay struct {
+	Date   string  `json:"date"`   // TIMEZONE 下的日期（YYYY-MM-DD）/ Date in TIMEZONE (YYYY-MM-DD)
+	Return float64 `json:"return"` // 当日收益率（0.01 = 1%）/ Daily return (0.01 = 1%)
+	Equity float64 `json:"equity"` // 当日收盘权益 / Equity at the end of the day
+}
+
+// LeaderboardPosition is one open or closed position in the export
+// LeaderboardPosition 是导出中的一个持仓（持仓中或已平仓）
+type LeaderboardPosition struct {
+	Symbol      string     `json:"symbol"`                // 交易对 / Trading pair
+	Side        string     `json:"side"`                  // long/short
+	Quantity    float64    `json:"quantity"`              // 数量 / Quantity
+	Leverage    int        `json:"leverage"`              // 杠杆倍数 / Leverage
+	EntryPrice  float64    `json:"entry_price"`           // 入场价格 / Entry price
+	EntryTime   time.Time  `json:"entry_time"`            // 入场时间 / Entry time
+	Status      string     `json:"status"`                // open/closed
+	ClosePrice  float64    `json:"close_price,omitempty"` // 平仓价格 / Close price
+	CloseTime   *time.Time `json:"close_time,omitempty"`  // 平仓时间 / Close time
+	RealizedPnL float64    `json:"realized_pnl"`          // 毛已实现盈亏 / Gross realized PnL
+	Commission  float64    `json:"commission"`            // 手续费 / Commission paid
+	FundingFee  float64    `json:"funding_fee"`           // 资金费净收入 / Net funding received
+	NetPnL      float64    `json:"net_pnl"`               // 净盈亏 / Net PnL
+}
+
+// LeaderboardExport is the signed performance document shared with leaderboards
+// LeaderboardExport 是与排行榜共享的已签名绩效文档
+//
+// The signature covers the compact JSON encoding of the document with the signature field omitted,
+// so anyone holding the published public key can verify that the results were not edited.
+// 签名覆盖省略 signature 字段后的紧凑 JSON 编码，持有公开公钥的任何人都可以验证成绩未被篡改。
+type LeaderboardExport struct {
+	Format       string                `json:"format"`              // 导出格式 / Export format
+	Version      int                   `json:"version"`             // 格式版本 / Format version
+	Bot          string                `json:"bot"`                 // 机器人标识 / Bot identifier
+	GeneratedAt  time.Time             `json:"generated_at"`        // 生成时间 / Generation time
+	Summary      *PerformanceReport    `json:"summary"`             // 绩效汇总 / Performance summary
+	DailyReturns []LeaderboardDay      `json:"daily_returns"`       // 日收益序列 / Daily return series
+	Positions    []LeaderboardPosition `json:"positions"`           // 窗口内的持仓 / Positions in the window
+	PublicKey    string                `json:"public_key"`          // Ed25519 公钥（base64）/ Ed25519 public key (base64)
+	Signature    string                `json:"signature,omitempty"` // Ed25519 签名（base64）/ Ed25519 signature (base64)
+}
+
+// BuildLeaderboardExport assembles an unsigned export from the same inputs as CalculatePerformance
+// BuildLeaderboardExport 使用与 CalculatePerformance 相同的输入生成未签名的导出
+func BuildLeaderboardExport(bot string, closed, open []*storage.PositionRecord, funding []executors.FundingPayment, startEquity float64, start, end time.Time, annualRiskFree float64) *LeaderboardExport {
+	export := &LeaderboardExport{
+		Format:       LeaderboardFormat,
+		Version:      LeaderboardFormatVersion,
+		Bot:          bot,
+		GeneratedAt:  time.Now().UTC(),
+		Summary:      CalculatePerformance(closed, funding, startEquity, start, end, annualRiskFree),
+		DailyReturns: []LeaderboardDay{},
+		Positions:    make([]LeaderboardPosition, 0, len(closed)+len(open)),
+	}
+
+	day := truncateDay(start, start.Location())
+	equity := startEquity
+	for _, r := range DailyReturns(closed, funding, startEquity, start, end) {
+		equity *= 1 + r
+		export.DailyReturns = append(export.DailyReturns, LeaderboardDay{Date: day.Format("2006-01-02"), Return: r, Equity: equity})
+		day = day.AddDate(0, 0, 1)
+	}
+
+	for _, p := range closed {
+		export.Positions = append(export.Positions, leaderboardPosition(p, "closed"))
+	}
+	for _, p := range open {
+		export.Positions = append(export.Positions, leaderboardPosition(p, "open"))
+	}
+	return export
+}
+
+// leaderboardPosition converts a stored position; open positions report only fees settled so far
+// leaderboardPosition 转换数据库中的持仓；持仓中的仓位只报告目前已结算的费用
+func leaderboardPosition(p *storage.PositionRecord, status string) LeaderboardPosition {
+	return LeaderboardPosition{
+		Symbol:      p.Symbol,
+		Side:        p.Side,
+		Quantity:    p.Quantity,
+		Leverage:    p.Leverage,
+		EntryPrice:  p.EntryPrice,
+		EntryTime:   p.EntryTime.UTC(),
+		Status:      status,
+		ClosePrice:  p.ClosePrice,
+		CloseTime:   p.CloseTime,
+		RealizedPnL: p.RealizedPnL,
+		Commission:  p.Commission,
+		FundingFee:  p.FundingFee,
+		NetPnL:      p.NetPnL(),
+	}
+}
+
+// signedPayload returns the bytes covered by the signature
+// signedPayload 返回签名覆盖的字节
+func (x *LeaderboardExport) signedPayload() ([]byte, error) {
+	unsigned := *x
+	unsigned.Signature = ""
+	return json.Marshal(&unsigned)
+}
+
+// Sign fills in the public key and signature using the bot's key
+// Sign 使用机器人的私钥填写公钥和签名
+func (x *LeaderboardExport) Sign(key ed25519.PrivateKey) error {
+	x.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	payload, err := x.signedPayload()
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	x.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+	return nil
+}
+
+// Verify checks the signature against the embedded public key
+// Verify 使用文档内的公钥校验签名
+// Callers should also compare PublicKey with the key the bot published, otherwise a re-signed copy would pass
+// 调用方还应将 PublicKey 与机器人公开的公钥比对，否则重新签名的副本也能通过校验
+func (x *LeaderboardExport) Verify() error {
+	publicKey, err := base64.StdEncoding.DecodeString(x.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(x.Signature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+	payload, err := x.signedPayload()
+	if err != nil {
+		return fmt.Errorf("failed to encode export: %w", err)
+	}
+	if !ed25519.Verify(publicKey, payload, signature) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// LoadOrCreateSigningKey reads the bot's Ed25519 key from a PKCS#8 PEM file, generating it on first use
+// LoadOrCreateSigningKey 从 PKCS#8 PEM 文件读取机器人的 Ed25519 私钥，首次使用时自动生成
+func LoadOrCreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode signing key %s: no PEM block", path)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return key, nil
+}
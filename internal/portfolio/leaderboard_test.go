@@ -0,0 +1,79 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestBuildLeaderboardExport(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * 24 * time.Hour)
+	closeTime := start.Add(10 * time.Hour)
+
+	closed := []*storage.PositionRecord{{Symbol: "BTCUSDT", Side: "long", RealizedPnL: 100, Commission: 2, CloseTime: &closeTime}}
+	open := []*storage.PositionRecord{{Symbol: "ETHUSDT", Side: "short", EntryTime: start.Add(30 * time.Hour)}}
+	funding := []executors.FundingPayment{{Time: start.Add(32 * time.Hour), Amount: -9.8}}
+
+	export := BuildLeaderboardExport("bot-1", closed, open, funding, 1000, start, end, 0)
+	if len(export.DailyReturns) != 3 {
+		t.Fatalf("Expected 3 days, got %d", len(export.DailyReturns))
+	}
+	if day := export.DailyReturns[0]; day.Date != "2025-01-01" || math.Abs(day.Equity-1098) > 1e-9 {
+		t.Errorf("Unexpected first day %+v", day)
+	}
+	if day := export.DailyReturns[1]; day.Date != "2025-01-02" || math.Abs(day.Equity-1088.2) > 1e-9 {
+		t.Errorf("Unexpected second day %+v", day)
+	}
+	if len(export.Positions) != 2 || export.Positions[0].Status != "closed" || export.Positions[1].Status != "open" {
+		t.Errorf("Unexpected positions %+v", export.Positions)
+	}
+	if export.Positions[0].NetPnL != 98 {
+		t.Errorf("Expected net PnL 98, got %.2f", export.Positions[0].NetPnL)
+	}
+}
+
+func TestLeaderboardExportSignature(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "keys", "leaderboard.pem")
+	key, err := LoadOrCreateSigningKey(keyPath)
+	if err != nil {
+		t.Fatalf("Failed to create key: %v", err)
+	}
+
+	// The key is generated once and reused afterwards
+	// 私钥只生成一次，之后重复使用
+	reloaded, err := LoadOrCreateSigningKey(keyPath)
+	if err != nil || !reloaded.Equal(key) {
+		t.Fatalf("Expected the saved key to be reloaded, err %v", err)
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	export := BuildLeaderboardExport("bot-1", nil, nil, nil, 1000, start, start.Add(24*time.Hour), 0.04)
+	if err := export.Sign(key); err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+
+	// A shared copy still verifies after a JSON round trip
+	// 分享的副本经过 JSON 往返后仍可验证
+	data, err := json.Marshal(export)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	var shared LeaderboardExport
+	if err := json.Unmarshal(data, &shared); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if err := shared.Verify(); err != nil {
+		t.Fatalf("Expected a valid signature, got %v", err)
+	}
+
+	shared.Summary.EndEquity *= 2
+	if err := shared.Verify(); err == nil {
+		t.Error("Expected an edited export to fail verification")
+	}
+}
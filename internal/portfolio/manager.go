@@ -136,6 +136,7 @@ func (pm *PortfolioManager) GetPortfolioSummary() string {
 			summary += fmt.Sprintf("  方向: %s\n", posInfo.Position.Side)
 			summary += fmt.Sprintf("  数量: %.4f\n", posInfo.Position.Size)
 			summary += fmt.Sprintf("  入场价: $%.2f\n", posInfo.Position.EntryPrice)
+			summary += fmt.Sprintf("  杠杆: %dx %s\n", posInfo.Position.Leverage, posInfo.Position.MarginType.Label())
 			summary += fmt.Sprintf("  未实现盈亏: %+.2f USDT\n\n", posInfo.Position.UnrealizedPnL)
 			totalPnL += posInfo.Position.UnrealizedPnL
 		}
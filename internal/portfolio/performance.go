@@ -0,0 +1,207 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// periodsPerYear annualizes daily ratios; crypto futures trade every day of the year
+// periodsPerYear 用于年化日度指标；加密货币合约全年每天交易
+const periodsPerYear = 365
+
+// PerformanceReport summarizes risk-adjusted performance over a window
+// PerformanceReport 汇总某个时间窗口内的风险调整后绩效
+type PerformanceReport struct {
+	Start          time.Time `json:"start"`           // 窗口开始 / Window start
+	End            time.Time `json:"end"`             // 窗口结束 / Window end
+	StartEquity    float64   `json:"start_equity"`    // 期初权益 / Starting equity
+	EndEquity      float64   `json:"end_equity"`      // 期末权益 / Ending equity
+	TradingPnL     float64   `json:"trading_pnl"`     // 扣除手续费后的交易已实现盈亏 / Realized trading PnL after commissions
+	CommissionPaid float64   `json:"commission_paid"` // 已平仓交易的手续费 / Commission paid on closed trades
+	FundingPnL     float64   `json:"funding_pnl"`     // 资金费净收入（负为成本）/ Net funding income (negative is cost)
+	TotalReturn    float64   `json:"total_return"`    // 总收益率 / Total return
+	RiskFreeRate   float64   `json:"risk_free_rate"`  // 年化无风险利率 / Annual risk-free rate
+	AnnualReturn   float64   `json:"annual_return"`   // 年化平均日收益 / Annualized mean daily return
+	AnnualVol      float64   `json:"annual_vol"`      // 年化波动率 / Annualized volatility
+	SharpeRatio    float64   `json:"sharpe_ratio"`    // 夏普比率 / Sharpe ratio
+	SortinoRatio   float64   `json:"sortino_ratio"`   // 索提诺比率 / Sortino ratio
+	Days           int       `json:"days"`            // 样本天数 / Number of daily samples
+	ClosedTrades   int       `json:"closed_trades"`   // 平仓笔数 / Closed trades
+	FundingRecords int       `json:"funding_records"` // 资金费记录数 / Funding records
+
+	FundingBySymbol []SymbolFunding `json:"funding_by_symbol"` // 按交易对的资金费明细 / Funding per symbol
+}
+
+// DailyReturns builds a daily return series from realized trade PnL and funding payments
+// DailyReturns 由交易已实现盈亏和资金费构建日收益序列
+//
+// Every calendar day in [start, end] produces one return, including flat days, so volatility is not
+// overstated by skipping quiet periods. Equity compounds from startEquity.
+// [start, end] 中每个自然日都产生一个收益率（包括无交易日），避免跳过平静期而高估波动率。权益从 startEquity 开始复利累积。
+// Days roll over at midnight in start's location, so callers pass start in the TIMEZONE zone.
+// 日切发生在 start 所在时区的午夜，因此调用方应以 TIMEZONE 时区传入 start。
+func DailyReturns(trades []*storage.PositionRecord, funding []executors.FundingPayment, startEquity float64, start, end time.Time) []float64 {
+	if startEquity <= 0 || !end.After(start) {
+		return nil
+	}
+
+	loc := start.Location()
+	start = truncateDay(start, loc)
+	days := daysBetween(start, truncateDay(end, loc)) + 1
+	pnl := make([]float64, days)
+
+	dayIndex := func(t time.Time) int {
+		return daysBetween(start, truncateDay(t, loc))
+	}
+	for _, trade := range trades {
+		if trade.CloseTime == nil {
+			continue
+		}
+		if i := dayIndex(*trade.CloseTime); i >= 0 && i < days {
+			pnl[i] += trade.RealizedPnL - trade.Commission
+		}
+	}
+	for _, payment := range funding {
+		if i := dayIndex(payment.Time); i >= 0 && i < days {
+			pnl[i] += payment.Amount
+		}
+	}
+
+	returns := make([]float64, days)
+	equity := startEquity
+	for i, dayPnL := range pnl {
+		if equity <= 0 {
+			return returns[:i]
+		}
+		returns[i] = dayPnL / equity
+		equity += dayPnL
+	}
+	return returns
+}
+
+// dailyRiskFreeRate converts an annual rate (e.g. 0.04 = 4%) into the equivalent daily rate
+// dailyRiskFreeRate 将年化利率（如 0.04 表示 4%）换算为等效日利率
+func dailyRiskFreeRate(annualRate float64) float64 {
+	return math.Pow(1+annualRate, 1.0/periodsPerYear) - 1
+}
+
+// SharpeRatio returns the annualized Sharpe ratio of daily returns in excess of the risk-free rate
+// SharpeRatio 返回日收益相对无风险利率的年化夏普比率
+func SharpeRatio(returns []float64, annualRiskFree float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+
+	rf := dailyRiskFreeRate(annualRiskFree)
+	mean := 0.0
+	for _, r := range returns {
+		mean += r - rf
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		d := r - rf - mean
+		variance += d * d
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+	return mean / std * math.Sqrt(periodsPerYear)
+}
+
+// SortinoRatio returns the annualized Sortino ratio, penalizing only returns below the risk-free rate
+// SortinoRatio 返回年化索提诺比率，只惩罚低于无风险利率的收益
+func SortinoRatio(returns []float64, annualRiskFree float64) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+
+	rf := dailyRiskFreeRate(annualRiskFree)
+	mean := 0.0
+	downside := 0.0
+	for _, r := range returns {
+		excess := r - rf
+		mean += excess
+		if excess < 0 {
+			downside += excess * excess
+		}
+	}
+	mean /= float64(len(returns))
+
+	downsideDev := math.Sqrt(downside / float64(len(returns)))
+	if downsideDev == 0 {
+		return 0
+	}
+	return mean / downsideDev * math.Sqrt(periodsPerYear)
+}
+
+// CalculatePerformance builds a performance report including funding carry and the risk-free rate
+// CalculatePerformance 生成包含资金费收益和无风险利率的绩效报告
+func CalculatePerformance(trades []*storage.PositionRecord, funding []executors.FundingPayment, startEquity float64, start, end time.Time, annualRiskFree float64) *PerformanceReport {
+	report := &PerformanceReport{
+		Start:          start,
+		End:            end,
+		StartEquity:    startEquity,
+		RiskFreeRate:   annualRiskFree,
+		ClosedTrades:   len(trades),
+		FundingRecords: len(funding),
+	}
+
+	// Funding is taken from the payment list rather than each trade's FundingFee so it is not counted twice
+	// 资金费取自资金费记录而非每笔交易的 FundingFee，避免重复计算
+	for _, trade := range trades {
+		report.TradingPnL += trade.RealizedPnL - trade.Commission
+		report.CommissionPaid += trade.Commission
+	}
+	for _, payment := range funding {
+		report.FundingPnL += payment.Amount
+	}
+	report.FundingBySymbol = FundingBySymbol(trades, funding)
+	report.EndEquity = startEquity + report.TradingPnL + report.FundingPnL
+	if startEquity > 0 {
+		report.TotalReturn = (report.EndEquity - startEquity) / startEquity
+	}
+
+	returns := DailyReturns(trades, funding, startEquity, start, end)
+	report.Days = len(returns)
+	if len(returns) == 0 {
+		return report
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	report.AnnualReturn = mean * periodsPerYear
+
+	if len(returns) > 1 {
+		variance := 0.0
+		for _, r := range returns {
+			variance += (r - mean) * (r - mean)
+		}
+		report.AnnualVol = math.Sqrt(variance/float64(len(returns)-1)) * math.Sqrt(periodsPerYear)
+	}
+
+	report.SharpeRatio = SharpeRatio(returns, annualRiskFree)
+	report.SortinoRatio = SortinoRatio(returns, annualRiskFree)
+	return report
+}
+
+// truncateDay returns midnight of t's day in loc, so days roll over at the user's midnight
+// truncateDay 返回 t 所在日在 loc 时区的零点，使日切发生在用户所在时区的午夜
+func truncateDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// daysBetween counts the calendar days between two midnights; rounding absorbs 23h and 25h daylight saving days
+// daysBetween 计算两个零点之间的自然日数；四舍五入以兼容夏令时的 23 小时和 25 小时日
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,105 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestDailyReturnsIncludesFunding(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * 24 * time.Hour)
+	closeTime := start.Add(10 * time.Hour)
+
+	trades := []*storage.PositionRecord{{RealizedPnL: 100, CloseTime: &closeTime}}
+	funding := []executors.FundingPayment{
+		{Time: start.Add(32 * time.Hour), Amount: -22},
+	}
+
+	returns := DailyReturns(trades, funding, 1000, start, end)
+	expected := []float64{0.1, -0.02, 0}
+	if len(returns) != len(expected) {
+		t.Fatalf("Expected %d returns, got %d", len(expected), len(returns))
+	}
+	for i := range expected {
+		if math.Abs(returns[i]-expected[i]) > 1e-9 {
+			t.Errorf("Day %d: expected %.4f, got %.4f", i, expected[i], returns[i])
+		}
+	}
+}
+
+func TestDailyReturnsRollOverInStartZone(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("Zone data unavailable: %v", err)
+	}
+
+	// 20:00 UTC on Jan 1 is already Jan 2 in Shanghai
+	// UTC 1 月 1 日 20:00 在上海已是 1 月 2 日
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, shanghai)
+	closeTime := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	trades := []*storage.PositionRecord{{RealizedPnL: 50, CloseTime: &closeTime}}
+
+	returns := DailyReturns(trades, nil, 1000, start, start.Add(48*time.Hour))
+	if len(returns) != 3 || returns[0] != 0 || math.Abs(returns[1]-0.05) > 1e-9 {
+		t.Errorf("Expected the trade on the second local day, got %v", returns)
+	}
+}
+
+func TestSharpeAndSortinoRiskFree(t *testing.T) {
+	tests := []struct {
+		name      string
+		returns   []float64
+		riskFree  float64
+		wantLower bool // 扣除无风险利率后比率应更低 / Ratio should drop after the risk-free deduction
+	}{
+		{
+			name:      "Positive returns lose ratio to risk-free rate",
+			returns:   []float64{0.01, -0.005, 0.008, -0.002, 0.004},
+			riskFree:  0.05,
+			wantLower: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sharpe := SharpeRatio(tt.returns, 0)
+			sharpeRF := SharpeRatio(tt.returns, tt.riskFree)
+			sortino := SortinoRatio(tt.returns, 0)
+			sortinoRF := SortinoRatio(tt.returns, tt.riskFree)
+
+			if sharpe <= 0 || sortino <= 0 {
+				t.Fatalf("Expected positive ratios, got sharpe=%.4f sortino=%.4f", sharpe, sortino)
+			}
+			if tt.wantLower && (sharpeRF >= sharpe || sortinoRF >= sortino) {
+				t.Errorf("Expected lower ratios with risk-free rate: sharpe %.4f -> %.4f, sortino %.4f -> %.4f",
+					sharpe, sharpeRF, sortino, sortinoRF)
+			}
+		})
+	}
+}
+
+func TestCalculatePerformanceFundingCarry(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(9 * 24 * time.Hour)
+
+	// 无交易，仅靠资金费收入 / No trades, funding income only
+	var funding []executors.FundingPayment
+	for i := 0; i < 10; i++ {
+		funding = append(funding, executors.FundingPayment{Time: start.Add(time.Duration(i) * 24 * time.Hour), Amount: 1 + float64(i%2)})
+	}
+
+	report := CalculatePerformance(nil, funding, 1000, start, end, 0)
+	if math.Abs(report.FundingPnL-15) > 1e-9 {
+		t.Errorf("Expected funding PnL 15, got %.4f", report.FundingPnL)
+	}
+	if math.Abs(report.EndEquity-1015) > 1e-9 {
+		t.Errorf("Expected end equity 1015, got %.4f", report.EndEquity)
+	}
+	if report.Days != 10 || report.SharpeRatio <= 0 {
+		t.Errorf("Expected 10 days and positive Sharpe, got days=%d sharpe=%.4f", report.Days, report.SharpeRatio)
+	}
+}
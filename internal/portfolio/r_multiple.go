@@ -0,0 +1,218 @@
+package portfolio
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// Histogram range; R-multiples outside it are counted in the outermost bucket so one outlier cannot stretch the chart
+// 直方图范围；超出范围的 R 倍数计入最外侧的桶，避免单个异常值拉长图表
+const (
+	rMultipleMinEdge      = -5.0 // 最低桶下限 / Lower edge of the lowest bucket
+	rMultipleMaxEdge      = 10.0 // 最高桶上限 / Upper edge of the highest bucket
+	defaultRMultipleWidth = 0.5  // 默认桶宽（R）/ Default bucket width in R
+)
+
+// RMultipleTrade is one closed trade measured in units of its initial risk
+// RMultipleTrade 是以初始风险为单位衡量的一笔已平仓交易
+type RMultipleTrade struct {
+	PositionID  string    `json:"position_id"`  // 持仓 ID / Position ID
+	Symbol      string    `json:"symbol"`       // 交易对 / Trading pair
+	Side        string    `json:"side"`         // long/short
+	CloseTime   time.Time `json:"close_time"`   // 平仓时间 / Close time
+	CloseReason string    `json:"close_reason"` // 平仓原因 / Close reason
+	Risk        float64   `json:"risk"`         // 初始风险（USDT）/ Initial risk (USDT)
+	NetPnL      float64   `json:"net_pnl"`      // 净盈亏 / Net PnL
+	R           float64   `json:"r"`            // R 倍数 / R-multiple
+}
+
+// RMultipleBucket counts the trades whose R-multiple falls in [From, To)
+// RMultipleBucket 统计 R 倍数落在 [From, To) 内的交易数
+type RMultipleBucket struct {
+	From  float64 `json:"from"`  // 下限（含）/ Lower edge (inclusive)
+	To    float64 `json:"to"`    // 上限（不含）/ Upper edge (exclusive)
+	Count int     `json:"count"` // 交易数 / Number of trades
+}
+
+// RMultipleDistribution is the histogram and summary of realized R-multiples
+// RMultipleDistribution 是已实现 R 倍数的直方图和汇总
+type RMultipleDistribution struct {
+	Trades       int               `json:"trades"`        // 有初始止损的交易数 / Trades with an initial stop
+	Skipped      int               `json:"skipped"`       // 无有效初始止损而跳过的交易数 / Trades skipped for lacking a valid initial stop
+	BucketWidth  float64           `json:"bucket_width"`  // 桶宽（R）/ Bucket width in R
+	Buckets      []RMultipleBucket `json:"buckets"`       // 直方图 / Histogram
+	Expectancy   float64           `json:"expectancy"`    // 期望值：平均 R / Expectancy: mean R
+	MedianR      float64           `json:"median_r"`      // R 中位数 / Median R
+	StdDevR      float64           `json:"stddev_r"`      // R 标准差 / Standard deviation of R
+	SQN          float64           `json:"sqn"`           // 系统质量数：√n × 期望 / 标准差 / System quality number: √n × expectancy / stddev
+	WinRate      float64           `json:"win_rate"`      // 胜率 / Win rate
+	AvgWinR      float64           `json:"avg_win_r"`     // 盈利交易平均 R / Mean R of winners
+	AvgLossR     float64           `json:"avg_loss_r"`    // 亏损交易平均 R / Mean R of losers
+	BestR        float64           `json:"best_r"`        // 最大 R / Best R
+	WorstR       float64           `json:"worst_r"`       // 最小 R / Worst R
+	TradeDetails []RMultipleTrade  `json:"trade_details"` // 逐笔明细（按平仓时间）/ Per-trade details by close time
+}
+
+// TradeRMultiple returns a closed trade's net PnL divided by the risk to its initial stop
+// TradeRMultiple 返回已平仓交易的净盈亏除以到初始止损的风险
+// Trades without an initial stop on the losing side of entry have no defined risk and report false
+// 没有位于入场价亏损一侧的初始止损的交易没有定义风险，返回 false
+func TradeRMultiple(p *storage.PositionRecord) (r, risk float64, ok bool) {
+	risk, ok = initialRisk(p.Side, p.EntryPrice, p.InitialStopLoss, p.Quantity)
+	if !ok {
+		return 0, 0, false
+	}
+	return p.NetPnL() / risk, risk, true
+}
+
+// OpenRMultiple returns an open position's unrealized PnL divided by the risk to its initial stop
+// OpenRMultiple 返回持仓中仓位的未实现盈亏除以到初始止损的风险
+// Like TradeRMultiple it reports false when the initial stop does not define a risk
+// 与 TradeRMultiple 相同，初始止损无法定义风险时返回 false
+func OpenRMultiple(side string, entryPrice, initialStop, quantity, unrealizedPnL float64) (float64, bool) {
+	risk, ok := initialRisk(side, entryPrice, initialStop, quantity)
+	if !ok {
+		return 0, false
+	}
+	return unrealizedPnL / risk, true
+}
+
+// initialRisk returns the loss at the initial stop, which must sit on the losing side of entry
+// initialRisk 返回打到初始止损时的亏损，初始止损必须位于入场价亏损一侧
+func initialRisk(side string, entryPrice, initialStop, quantity float64) (float64, bool) {
+	if initialStop <= 0 || entryPrice <= 0 || quantity <= 0 {
+		return 0, false
+	}
+	distance := entryPrice - initialStop
+	if side == "short" {
+		distance = -distance
+	}
+	if distance <= 0 {
+		return 0, false
+	}
+	return distance * quantity, true
+}
+
+// CalculateRMultiples builds the R-multiple histogram and expectancy of closed trades
+// CalculateRMultiples 计算已平仓交易的 R 倍数直方图和期望值
+// bucketWidth <= 0 uses 0.5R
+// bucketWidth <= 0 时使用 0.5R
+func CalculateRMultiples(trades []*storage.PositionRecord, bucketWidth float64) *RMultipleDistribution {
+	if bucketWidth <= 0 {
+		bucketWidth = defaultRMultipleWidth
+	}
+	dist := &RMultipleDistribution{BucketWidth: bucketWidth, Buckets: []RMultipleBucket{}, TradeDetails: []RMultipleTrade{}}
+
+	var rs []float64
+	for _, p := range trades {
+		if !p.Closed || p.CloseTime == nil {
+			continue
+		}
+		r, risk, ok := TradeRMultiple(p)
+		if !ok {
+			dist.Skipped++
+			continue
+		}
+		rs = append(rs, r)
+		dist.TradeDetails = append(dist.TradeDetails, RMultipleTrade{
+			PositionID:  p.ID,
+			Symbol:      p.Symbol,
+			Side:        p.Side,
+			CloseTime:   *p.CloseTime,
+			CloseReason: p.CloseReason,
+			Risk:        risk,
+			NetPnL:      p.NetPnL(),
+			R:           r,
+		})
+	}
+	sort.Slice(dist.TradeDetails, func(i, j int) bool { return dist.TradeDetails[i].CloseTime.Before(dist.TradeDetails[j].CloseTime) })
+
+	dist.Trades = len(rs)
+	if len(rs) == 0 {
+		return dist
+	}
+
+	var sum, winSum, lossSum float64
+	var wins, losses int
+	for _, r := range rs {
+		sum += r
+		if r > 0 {
+			wins++
+			winSum += r
+		} else if r < 0 {
+			losses++
+			lossSum += r
+		}
+	}
+	n := float64(len(rs))
+	dist.Expectancy = sum / n
+	dist.WinRate = float64(wins) / n
+	if wins > 0 {
+		dist.AvgWinR = winSum / float64(wins)
+	}
+	if losses > 0 {
+		dist.AvgLossR = lossSum / float64(losses)
+	}
+	if len(rs) > 1 {
+		var ss float64
+		for _, r := range rs {
+			ss += (r - dist.Expectancy) * (r - dist.Expectancy)
+		}
+		dist.StdDevR = math.Sqrt(ss / (n - 1))
+		if dist.StdDevR > 0 {
+			dist.SQN = math.Sqrt(n) * dist.Expectancy / dist.StdDevR
+		}
+	}
+
+	sorted := append([]float64(nil), rs...)
+	sort.Float64s(sorted)
+	dist.WorstR, dist.BestR = sorted[0], sorted[len(sorted)-1]
+	if mid := len(sorted) / 2; len(sorted)%2 == 1 {
+		dist.MedianR = sorted[mid]
+	} else {
+		dist.MedianR = (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	// Buckets span the observed range, clamped to [rMultipleMinEdge, rMultipleMaxEdge]
+	// 桶覆盖实际出现的范围，并限制在 [rMultipleMinEdge, rMultipleMaxEdge] 内
+	bucketIndex := func(r float64) int {
+		return int(math.Floor(r / bucketWidth))
+	}
+	lo := bucketIndex(math.Max(dist.WorstR, rMultipleMinEdge))
+	hi := bucketIndex(math.Min(dist.BestR, rMultipleMaxEdge-bucketWidth/2))
+	for i := lo; i <= hi; i++ {
+		dist.Buckets = append(dist.Buckets, RMultipleBucket{From: float64(i) * bucketWidth, To: float64(i+1) * bucketWidth})
+	}
+	for _, r := range rs {
+		i := bucketIndex(r) - lo
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(dist.Buckets) {
+			i = len(dist.Buckets) - 1
+		}
+		dist.Buckets[i].Count++
+	}
+	return dist
+}
+
+// KellyLookbackDays is how far back closed trades feed the Kelly sizing statistics
+// KellyLookbackDays 是计算凯利仓位统计时回看已平仓交易的天数
+const KellyLookbackDays = 90
+
+// CalculateKellyStats derives the Kelly sizing statistics from closed trades measured in R
+// CalculateKellyStats 以 R 倍数衡量已平仓交易，得出凯利仓位统计
+// The payoff ratio is the mean winning R over the mean losing R; without losers it is the mean winning R
+// 盈亏比为盈利交易平均 R 除以亏损交易平均 R 的绝对值；没有亏损交易时取盈利交易平均 R
+func CalculateKellyStats(trades []*storage.PositionRecord) executors.KellyStats {
+	dist := CalculateRMultiples(trades, 0)
+	stats := executors.KellyStats{Trades: dist.Trades, WinRate: dist.WinRate, PayoffRatio: dist.AvgWinR}
+	if dist.AvgLossR < 0 {
+		stats.PayoffRatio = dist.AvgWinR / -dist.AvgLossR
+	}
+	return stats
+}
@@ -0,0 +1,107 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestTradeRMultiple(t *testing.T) {
+	tests := []struct {
+		name   string
+		record storage.PositionRecord
+		want   float64
+		ok     bool
+	}{
+		{"long winner", storage.PositionRecord{Side: "long", EntryPrice: 100, InitialStopLoss: 95, Quantity: 2, RealizedPnL: 20}, 2, true},
+		{"short loser after fees", storage.PositionRecord{Side: "short", EntryPrice: 100, InitialStopLoss: 110, Quantity: 1, RealizedPnL: -10, Commission: 1}, -1.1, true},
+		{"funding counts", storage.PositionRecord{Side: "long", EntryPrice: 100, InitialStopLoss: 90, Quantity: 1, RealizedPnL: 5, FundingFee: 5}, 1, true},
+		{"no stop", storage.PositionRecord{Side: "long", EntryPrice: 100, Quantity: 1, RealizedPnL: 5}, 0, false},
+		{"stop on the wrong side", storage.PositionRecord{Side: "short", EntryPrice: 100, InitialStopLoss: 95, Quantity: 1}, 0, false},
+	}
+	for _, tt := range tests {
+		r, _, ok := TradeRMultiple(&tt.record)
+		if ok != tt.ok || math.Abs(r-tt.want) > 1e-9 {
+			t.Errorf("%s: expected %.2f/%v, got %.2f/%v", tt.name, tt.want, tt.ok, r, ok)
+		}
+	}
+}
+
+func TestOpenRMultiple(t *testing.T) {
+	if r, ok := OpenRMultiple("long", 100, 95, 2, 5); !ok || r != 0.5 {
+		t.Errorf("Expected a long up 5 on 10 risk to be 0.5R, got %.2f/%v", r, ok)
+	}
+	if r, ok := OpenRMultiple("short", 100, 104, 1, -6); !ok || r != -1.5 {
+		t.Errorf("Expected a short down 6 on 4 risk to be -1.5R, got %.2f/%v", r, ok)
+	}
+	if _, ok := OpenRMultiple("long", 100, 0, 1, 5); ok {
+		t.Error("Expected no R without an initial stop")
+	}
+}
+
+func TestCalculateRMultiples(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(i int, pnl, stop float64) *storage.PositionRecord {
+		closeTime := base.Add(time.Duration(i) * time.Hour)
+		return &storage.PositionRecord{ID: string(rune('a' + i)), Side: "long", EntryPrice: 100, InitialStopLoss: stop, Quantity: 1,
+			RealizedPnL: pnl, Closed: true, CloseTime: &closeTime}
+	}
+	// R-multiples: -1, -1, 2, 3 and an outlier of 40; one trade has no stop
+	// R 倍数：-1、-1、2、3 和异常值 40；另有一笔交易没有止损
+	trades := []*storage.PositionRecord{
+		trade(4, 400, 90), trade(0, -10, 90), trade(1, -10, 90), trade(2, 20, 90), trade(3, 30, 90), trade(5, 10, 0),
+	}
+
+	dist := CalculateRMultiples(trades, 1)
+	if dist.Trades != 5 || dist.Skipped != 1 {
+		t.Fatalf("Expected 5 trades and 1 skipped, got %d/%d", dist.Trades, dist.Skipped)
+	}
+	if math.Abs(dist.Expectancy-8.6) > 1e-9 || dist.MedianR != 2 || dist.WinRate != 0.6 {
+		t.Errorf("Unexpected summary: %+v", dist)
+	}
+	if dist.AvgLossR != -1 || math.Abs(dist.AvgWinR-15) > 1e-9 || dist.BestR != 40 || dist.WorstR != -1 {
+		t.Errorf("Unexpected win/loss stats: %+v", dist)
+	}
+	if dist.TradeDetails[0].PositionID != "a" || dist.TradeDetails[4].R != 40 {
+		t.Errorf("Expected details sorted by close time, got %+v", dist.TradeDetails)
+	}
+
+	// Buckets run from -1R to the +10R edge, with the outlier in the last bucket
+	// 桶从 -1R 到 +10R 上限，异常值计入最后一个桶
+	if len(dist.Buckets) != 11 || dist.Buckets[0].From != -1 || dist.Buckets[10].To != 10 {
+		t.Fatalf("Unexpected bucket range: %+v", dist.Buckets)
+	}
+	counts := map[float64]int{}
+	total := 0
+	for _, b := range dist.Buckets {
+		counts[b.From] = b.Count
+		total += b.Count
+	}
+	if total != 5 || counts[-1] != 2 || counts[2] != 1 || counts[3] != 1 || counts[9] != 1 {
+		t.Errorf("Unexpected bucket counts: %+v", dist.Buckets)
+	}
+
+	if empty := CalculateRMultiples(nil, 0); empty.Trades != 0 || empty.BucketWidth != 0.5 || len(empty.Buckets) != 0 {
+		t.Errorf("Unexpected empty distribution: %+v", empty)
+	}
+}
+
+func TestCalculateKellyStats(t *testing.T) {
+	closeTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	trade := func(pnl float64) *storage.PositionRecord {
+		return &storage.PositionRecord{Side: "long", EntryPrice: 100, InitialStopLoss: 90, Quantity: 1,
+			RealizedPnL: pnl, Closed: true, CloseTime: &closeTime}
+	}
+
+	// R-multiples 2, 3, -1 and -1: win rate 50%, payoff 2.5 / 1
+	// R 倍数 2、3、-1、-1：胜率 50%，盈亏比 2.5 / 1
+	stats := CalculateKellyStats([]*storage.PositionRecord{trade(20), trade(30), trade(-10), trade(-10)})
+	if stats.Trades != 4 || math.Abs(stats.WinRate-0.5) > 1e-9 || math.Abs(stats.PayoffRatio-2.5) > 1e-9 {
+		t.Errorf("Expected 4 trades, 50%% win rate and payoff 2.5, got %+v", stats)
+	}
+	if f := stats.Fraction(); math.Abs(f-0.3) > 1e-9 {
+		t.Errorf("Expected a Kelly fraction of 0.3, got %.4f", f)
+	}
+}
@@ -0,0 +1,98 @@
+package portfolio
+
+import (
+	"sort"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+// StopSlippageGroup aggregates the slippage of a group of stop-loss fills
+// StopSlippageGroup 汇总一组止损成交的滑点
+// Bps figures are notional-weighted like the execution costs, so large stops count in proportion to what they cost
+// 基点数据与执行成本一样按名义价值加权，大额止损按其实际成本占比计入
+type StopSlippageGroup struct {
+	Key                    string  `json:"key"`                       // 分组键（交易对/周期或执行方式）/ Group key (symbol/timeframe or order type)
+	Stops                  int     `json:"stops"`                     // 止损次数 / Number of stop fills
+	Notional               float64 `json:"notional"`                  // 按触发价计的名义价值（USDT）/ Notional at the trigger price in USDT
+	SlippageCost           float64 `json:"slippage_cost"`             // 滑点成本（USDT）/ Slippage cost in USDT
+	AvgSlippageBps         float64 `json:"avg_slippage_bps"`          // 平均滑点（基点）/ Average slippage in bps
+	WorstSlippageBps       float64 `json:"worst_slippage_bps"`        // 最差单次滑点（基点）/ Worst single-stop slippage in bps
+	AvgStopDistancePercent float64 `json:"avg_stop_distance_percent"` // 平均止损距离（%）/ Average stop distance (%)
+}
+
+// StopSlippageReport is the stop-loss slippage summary served by the analytics endpoint
+// StopSlippageReport 是分析接口返回的止损滑点汇总
+type StopSlippageReport struct {
+	Total             StopSlippageGroup   `json:"total"`                // 全部止损 / All stops
+	BySymbol          []StopSlippageGroup `json:"by_symbol"`            // 按交易对/周期分组 / By symbol and timeframe
+	ByOrderType       []StopSlippageGroup `json:"by_order_type"`        // 按执行方式分组 / By order type
+	SlippageToStopPct float64             `json:"slippage_to_stop_pct"` // 平均滑点占平均止损距离的比例（%）/ Average slippage as % of the average stop distance
+}
+
+// add accumulates one stop fill into the group
+// add 将一次止损成交累加到分组
+func (g *StopSlippageGroup) add(s *storage.StopSlippage) {
+	if g.Stops == 0 || s.SlippageBps > g.WorstSlippageBps {
+		g.WorstSlippageBps = s.SlippageBps
+	}
+	g.AvgStopDistancePercent = (g.AvgStopDistancePercent*float64(g.Stops) + s.StopDistancePercent) / float64(g.Stops+1)
+	g.Stops++
+	g.Notional += s.TriggerPrice * s.Quantity
+	g.SlippageCost += s.SlippageCost
+}
+
+// finish derives the bps average from the accumulated totals
+// finish 根据累计值计算基点均值
+func (g *StopSlippageGroup) finish() {
+	if g.Notional > 0 {
+		g.AvgSlippageBps = g.SlippageCost / g.Notional * 10000
+	}
+}
+
+// CalculateStopSlippage aggregates stop-loss slippage in total, by symbol and timeframe, and by order type
+// CalculateStopSlippage 按总计、交易对/周期和执行方式汇总止损滑点
+// A stop whose slippage is a large share of its distance is too tight for how the symbol fills on a stop
+// 滑点占止损距离比例较大时，说明该交易对的止损设置过紧（MinStopDistance 偏小）
+func CalculateStopSlippage(slips []*storage.StopSlippage) *StopSlippageReport {
+	report := &StopSlippageReport{
+		Total:       StopSlippageGroup{Key: "total"},
+		BySymbol:    []StopSlippageGroup{},
+		ByOrderType: []StopSlippageGroup{},
+	}
+
+	bySymbol := make(map[string]*StopSlippageGroup)
+	byOrderType := make(map[string]*StopSlippageGroup)
+	group := func(groups map[string]*StopSlippageGroup, key string) *StopSlippageGroup {
+		g, ok := groups[key]
+		if !ok {
+			g = &StopSlippageGroup{Key: key}
+			groups[key] = g
+		}
+		return g
+	}
+
+	for _, s := range slips {
+		report.Total.add(s)
+		group(bySymbol, s.Symbol+"/"+s.Timeframe).add(s)
+		group(byOrderType, s.OrderType).add(s)
+	}
+
+	report.Total.finish()
+	if report.Total.AvgStopDistancePercent > 0 {
+		// bps / 100 is percent, so the ratio of the two is in %
+		// bps / 100 即百分比，两者之比以 % 表示
+		report.SlippageToStopPct = report.Total.AvgSlippageBps / 100 / report.Total.AvgStopDistancePercent * 100
+	}
+	collect := func(groups map[string]*StopSlippageGroup) []StopSlippageGroup {
+		out := make([]StopSlippageGroup, 0, len(groups))
+		for _, g := range groups {
+			g.finish()
+			out = append(out, *g)
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+		return out
+	}
+	report.BySymbol = collect(bySymbol)
+	report.ByOrderType = collect(byOrderType)
+	return report
+}
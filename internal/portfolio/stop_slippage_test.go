@@ -0,0 +1,39 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/storage"
+)
+
+func TestCalculateStopSlippage(t *testing.T) {
+	slips := []*storage.StopSlippage{
+		{Symbol: "BTCUSDT", Timeframe: "1h", OrderType: storage.StopOrderTypeStopMarket, StopDistancePercent: 2, TriggerPrice: 50000, Quantity: 0.1, SlippageBps: 10, SlippageCost: 5},
+		{Symbol: "BTCUSDT", Timeframe: "1h", OrderType: storage.StopOrderTypeLocal, StopDistancePercent: 1, TriggerPrice: 50000, Quantity: 0.1, SlippageBps: 30, SlippageCost: 15},
+		{Symbol: "ETHUSDT", Timeframe: "1h", OrderType: storage.StopOrderTypeStopMarket, StopDistancePercent: 3, TriggerPrice: 2000, Quantity: 5, SlippageBps: -5, SlippageCost: -5},
+	}
+
+	report := CalculateStopSlippage(slips)
+	// 20000 notional, 15 USDT of slippage → 7.5 bps over an average stop distance of 2%
+	// 名义价值 20000，滑点 15 USDT → 7.5 bps，平均止损距离 2%
+	if report.Total.Stops != 3 || report.Total.Notional != 20000 || math.Abs(report.Total.AvgSlippageBps-7.5) > 1e-9 ||
+		report.Total.WorstSlippageBps != 30 || math.Abs(report.Total.AvgStopDistancePercent-2) > 1e-9 {
+		t.Errorf("Unexpected totals: %+v", report.Total)
+	}
+	if math.Abs(report.SlippageToStopPct-3.75) > 1e-9 {
+		t.Errorf("Expected slippage to be 3.75%% of the stop distance, got %.4f", report.SlippageToStopPct)
+	}
+
+	if len(report.BySymbol) != 2 || report.BySymbol[0].Key != "BTCUSDT/1h" || math.Abs(report.BySymbol[0].AvgSlippageBps-20) > 1e-9 {
+		t.Errorf("Unexpected symbol groups: %+v", report.BySymbol)
+	}
+	local, market := report.ByOrderType[0], report.ByOrderType[1]
+	if local.Key != storage.StopOrderTypeLocal || local.Stops != 1 || market.Key != storage.StopOrderTypeStopMarket || market.Stops != 2 {
+		t.Errorf("Unexpected order type groups: %+v", report.ByOrderType)
+	}
+
+	if empty := CalculateStopSlippage(nil); empty.Total.Stops != 0 || empty.BySymbol == nil || empty.ByOrderType == nil {
+		t.Errorf("Expected empty slices for no stops, got %+v", empty)
+	}
+}
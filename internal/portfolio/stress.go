@@ -0,0 +1,227 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+// varLookbackDays is how many daily returns the historical VaR replays
+// varLookbackDays 是历史模拟 VaR 回放的日收益天数
+const varLookbackDays = 60
+
+// varConfidence is the confidence level of the reported VaR
+// varConfidence 是报告的 VaR 置信水平
+const varConfidence = 0.95
+
+// StressScenario is a predefined price shock applied to the open positions
+// StressScenario 是施加在当前持仓上的预设价格冲击
+type StressScenario struct {
+	Name    string  `json:"name"`     // 场景名称 / Scenario name
+	BTCMove float64 `json:"btc_move"` // BTC 价格变动（%）/ BTC price move (%)
+	AltMove float64 `json:"alt_move"` // 其他币种价格变动（%）/ Price move of every other coin (%)
+	Adverse bool    `json:"adverse"`  // 每个持仓都按变动幅度朝不利方向移动（相关性→1，对冲失效）/ Every position moves against itself by the move size (correlations → 1, hedges fail)
+}
+
+// DefaultStressScenarios are the shocks shown on the dashboard and in the daily summary
+// DefaultStressScenarios 是仪表盘和每日汇总中展示的冲击场景
+var DefaultStressScenarios = []StressScenario{
+	{Name: "BTC −10%", BTCMove: -10},
+	{Name: "山寨币 −20%", AltMove: -20},
+	{Name: "相关性→1（BTC 10% / 山寨币 20% 全部逆向）", BTCMove: 10, AltMove: 20, Adverse: true},
+}
+
+// PositionShock is the hypothetical result of a scenario on one position
+// PositionShock 是场景对单个持仓的假设结果
+type PositionShock struct {
+	Symbol string  `json:"symbol"` // 交易对 / Trading pair
+	Side   string  `json:"side"`   // long/short
+	Move   float64 `json:"move"`   // 价格变动（%）/ Price move (%)
+	PnL    float64 `json:"pnl"`    // 假设盈亏 / Hypothetical PnL
+}
+
+// ScenarioResult is the hypothetical PnL and margin impact of one scenario
+// ScenarioResult 是单个场景的假设盈亏和保证金影响
+type ScenarioResult struct {
+	Scenario      StressScenario  `json:"scenario"`       // 场景 / Scenario
+	PnL           float64         `json:"pnl"`            // 假设盈亏合计 / Total hypothetical PnL
+	PnLPercent    float64         `json:"pnl_percent"`    // 占当前保证金余额的比例（%）/ As % of the current margin balance
+	MarginBalance float64         `json:"margin_balance"` // 冲击后的保证金余额 / Margin balance after the shock
+	MarginRatio   float64         `json:"margin_ratio"`   // 冲击后的保证金率（%）/ Margin ratio after the shock (%)
+	Liquidation   bool            `json:"liquidation"`    // 冲击后保证金率达到 100%（强平）/ The shock takes the margin ratio to 100% (liquidation)
+	Positions     []PositionShock `json:"positions"`      // 按持仓的明细 / Per-position breakdown
+}
+
+// StressReport is the portfolio VaR and the result of every stress scenario
+// StressReport 是组合 VaR 以及每个压力场景的结果
+type StressReport struct {
+	At            time.Time        `json:"at"`             // 计算时间 / When it was computed
+	MarginBalance float64          `json:"margin_balance"` // 当前保证金余额 / Current margin balance
+	MarginRatio   float64          `json:"margin_ratio"`   // 当前保证金率（%）/ Current margin ratio (%)
+	VaR           float64          `json:"var"`            // 1 日 95% 历史模拟 VaR（正数为亏损），0 表示不可用 / 1-day 95% historical VaR (positive is a loss), 0 when unavailable
+	VaRDays       int              `json:"var_days"`       // VaR 使用的日收益天数 / Daily returns the VaR used
+	Scenarios     []ScenarioResult `json:"scenarios"`      // 场景结果 / Scenario results
+}
+
+// isBTC reports whether a Binance symbol trades bitcoin
+// isBTC 判断币安交易对是否为比特币
+func isBTC(symbol string) bool {
+	return strings.HasPrefix(strings.ToUpper(symbol), "BTC")
+}
+
+// shockMove returns the price move of a scenario for one position, in %
+// shockMove 返回场景对单个持仓的价格变动（%）
+func shockMove(s StressScenario, symbol, side string) float64 {
+	move := s.AltMove
+	if isBTC(symbol) {
+		move = s.BTCMove
+	}
+	if !s.Adverse {
+		return move
+	}
+	if side == "short" {
+		return math.Abs(move)
+	}
+	return -math.Abs(move)
+}
+
+// RunStressTest applies each scenario to the snapshot's positions
+// RunStressTest 将每个场景施加到快照中的持仓上
+//
+// Maintenance margin is assumed to scale with notional, so the margin ratio after a shock is the scaled maintenance
+// margin over the margin balance plus the hypothetical PnL.
+// 假设维持保证金与名义价值成正比，因此冲击后的保证金率为按比例调整的维持保证金除以（保证金余额 + 假设盈亏）。
+func RunStressTest(snapshot *executors.AccountSnapshot, scenarios []StressScenario) []ScenarioResult {
+	var notional float64
+	for _, p := range snapshot.Positions {
+		notional += p.Notional
+	}
+
+	results := make([]ScenarioResult, 0, len(scenarios))
+	for _, s := range scenarios {
+		r := ScenarioResult{Scenario: s, Positions: []PositionShock{}}
+		var shocked float64
+		for _, p := range snapshot.Positions {
+			move := shockMove(s, p.Symbol, p.Side)
+			pnl := p.Notional * move / 100
+			if p.Side == "short" {
+				pnl = -pnl
+			}
+			r.PnL += pnl
+			shocked += p.Notional * (1 + move/100)
+			r.Positions = append(r.Positions, PositionShock{Symbol: p.Symbol, Side: p.Side, Move: move, PnL: pnl})
+		}
+
+		r.MarginBalance = snapshot.MarginBalance + r.PnL
+		if snapshot.MarginBalance > 0 {
+			r.PnLPercent = r.PnL / snapshot.MarginBalance * 100
+		}
+		maint := snapshot.MaintMargin
+		if notional > 0 {
+			maint *= shocked / notional
+		}
+		if r.MarginBalance > 0 {
+			r.MarginRatio = maint / r.MarginBalance * 100
+		}
+		r.Liquidation = r.MarginBalance <= 0 || r.MarginRatio >= 100
+		results = append(results, r)
+	}
+	return results
+}
+
+// HistoricalVaR replays the daily returns of each symbol on today's positions and returns the loss exceeded on only
+// 1−confidence of the days, with the number of days replayed; 0 when fewer than 10 days line up
+// HistoricalVaR 将各交易对的日收益回放到当前持仓上，返回仅在 1−confidence 的交易日中被超过的亏损以及回放天数；
+// 对齐的天数少于 10 天时返回 0
+func HistoricalVaR(positions []executors.AccountPositionSnapshot, closes map[string][]float64, confidence float64) (float64, int) {
+	days := math.MaxInt
+	for _, p := range positions {
+		n := len(closes[p.Symbol]) - 1
+		if n < days {
+			days = n
+		}
+	}
+	if len(positions) == 0 || days < 10 {
+		return 0, 0
+	}
+
+	// Closes are aligned on their most recent day
+	// 收盘价按最近一天对齐
+	pnls := make([]float64, days)
+	for _, p := range positions {
+		c := closes[p.Symbol]
+		c = c[len(c)-days-1:]
+		sign := 1.0
+		if p.Side == "short" {
+			sign = -1
+		}
+		for t := 1; t <= days; t++ {
+			if c[t-1] > 0 {
+				pnls[t-1] += sign * p.Notional * (c[t]/c[t-1] - 1)
+			}
+		}
+	}
+
+	// The VaR is the mildest loss inside the 1−confidence tail, e.g. the 3rd worst of 60 days at 95%
+	// VaR 取 1−confidence 尾部中亏损最小的一天，例如 95% 置信度下 60 天中第 3 差的一天
+	sort.Float64s(pnls)
+	idx := int(math.Ceil((1-confidence)*float64(days)-1e-9)) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return math.Max(0, -pnls[idx]), days
+}
+
+// BuildStressReport runs the default scenarios on the snapshot and the historical VaR on daily closes fetched for it
+// BuildStressReport 对快照运行默认场景，并获取日线收盘价计算历史模拟 VaR
+// Without klines the report still carries the scenarios, with VaR 0
+// 获取不到 K 线时报告仍包含场景结果，VaR 为 0
+func BuildStressReport(ctx context.Context, cfg *config.Config, snapshot *executors.AccountSnapshot) *StressReport {
+	report := &StressReport{
+		At:            time.Now(),
+		MarginBalance: snapshot.MarginBalance,
+		MarginRatio:   snapshot.MarginRatio,
+		Scenarios:     RunStressTest(snapshot, DefaultStressScenarios),
+	}
+	if len(snapshot.Positions) == 0 {
+		return report
+	}
+
+	market := dataflows.NewMarketData(cfg)
+	closes := make(map[string][]float64, len(snapshot.Positions))
+	for _, p := range snapshot.Positions {
+		bars, err := market.GetOHLCV(ctx, p.Symbol, "1d", varLookbackDays+1)
+		if err != nil {
+			return report
+		}
+		for _, b := range bars {
+			closes[p.Symbol] = append(closes[p.Symbol], b.Close)
+		}
+	}
+	report.VaR, report.VaRDays = HistoricalVaR(snapshot.Positions, closes, varConfidence)
+	return report
+}
+
+// Format renders the report as a notification message
+// Format 将报告渲染为通知消息
+func (r *StressReport) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧪 组合压力测试（保证金余额 %.2f USDT，保证金率 %.1f%%）", r.MarginBalance, r.MarginRatio)
+	if r.VaRDays > 0 {
+		fmt.Fprintf(&b, "\n  1 日 %.0f%% VaR: %.2f USDT（%d 天历史模拟）", varConfidence*100, r.VaR, r.VaRDays)
+	}
+	for _, s := range r.Scenarios {
+		fmt.Fprintf(&b, "\n  %s: 盈亏 %+.2f USDT（%+.1f%%），保证金率 %.1f%%", s.Scenario.Name, s.PnL, s.PnLPercent, s.MarginRatio)
+		if s.Liquidation {
+			b.WriteString(" 🚨 强平")
+		}
+	}
+	return b.String()
+}
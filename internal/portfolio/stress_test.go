@@ -0,0 +1,90 @@
+package portfolio
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/oak/crypto-trading-bot/internal/executors"
+)
+
+func TestRunStressTest(t *testing.T) {
+	snapshot := &executors.AccountSnapshot{
+		MarginBalance: 1000,
+		MaintMargin:   100,
+		Positions: []executors.AccountPositionSnapshot{
+			{Symbol: "BTCUSDT", Side: "long", Notional: 2000},
+			{Symbol: "ETHUSDT", Side: "short", Notional: 1000},
+		},
+	}
+
+	results := RunStressTest(snapshot, DefaultStressScenarios)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 scenarios, got %d", len(results))
+	}
+
+	// BTC −10%: the long loses 200, the short is untouched
+	btc := results[0]
+	if math.Abs(btc.PnL+200) > 1e-9 || math.Abs(btc.PnLPercent+20) > 1e-9 {
+		t.Errorf("Expected −200 (−20%%) for BTC −10%%, got %.2f (%.2f%%)", btc.PnL, btc.PnLPercent)
+	}
+	// Maintenance margin scales with notional 2800/3000, the balance drops to 800
+	if want := 100 * 2800.0 / 3000 / 800 * 100; math.Abs(btc.MarginRatio-want) > 1e-9 {
+		t.Errorf("Expected margin ratio %.2f%%, got %.2f%%", want, btc.MarginRatio)
+	}
+
+	// Alts −20%: the ETH short gains 200
+	if alts := results[1]; math.Abs(alts.PnL-200) > 1e-9 {
+		t.Errorf("Expected +200 for alts −20%%, got %.2f", alts.PnL)
+	}
+
+	// Correlations → 1: the long falls 10% and the short rises 20% against it
+	adverse := results[2]
+	if math.Abs(adverse.PnL+400) > 1e-9 || adverse.Positions[1].Move != 20 {
+		t.Errorf("Expected −400 with the short moving +20%%, got %.2f %+v", adverse.PnL, adverse.Positions)
+	}
+
+	snapshot.MarginBalance = 300
+	if liquidated := RunStressTest(snapshot, DefaultStressScenarios)[2]; !liquidated.Liquidation {
+		t.Errorf("Expected a 400 loss on a 300 balance to liquidate, got %+v", liquidated)
+	}
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	// 20 daily returns: 19 days of +1% and one day of −5%
+	closes := []float64{100}
+	for i := 0; i < 20; i++ {
+		move := 1.01
+		if i == 7 {
+			move = 0.95
+		}
+		closes = append(closes, closes[len(closes)-1]*move)
+	}
+	positions := []executors.AccountPositionSnapshot{{Symbol: "BTCUSDT", Side: "long", Notional: 1000}}
+
+	v, days := HistoricalVaR(positions, map[string][]float64{"BTCUSDT": closes}, 0.95)
+	if days != 20 || math.Abs(v-50) > 1e-9 {
+		t.Errorf("Expected the −5%% day to set a VaR of 50 over 20 days, got %.2f over %d", v, days)
+	}
+
+	positions[0].Side = "short"
+	if v, _ := HistoricalVaR(positions, map[string][]float64{"BTCUSDT": closes}, 0.95); math.Abs(v-10) > 1e-9 {
+		t.Errorf("Expected a short to risk the +1%% days (10), got %.2f", v)
+	}
+	if _, days := HistoricalVaR(positions, map[string][]float64{"BTCUSDT": closes[:5]}, 0.95); days != 0 {
+		t.Errorf("Expected no VaR with too few days, got %d days", days)
+	}
+}
+
+func TestStressReportFormat(t *testing.T) {
+	report := &StressReport{MarginBalance: 1000, VaR: 50, VaRDays: 60, Scenarios: []ScenarioResult{
+		{Scenario: DefaultStressScenarios[0], PnL: -200, PnLPercent: -20, MarginRatio: 12},
+		{Scenario: DefaultStressScenarios[2], PnL: -1200, PnLPercent: -120, Liquidation: true},
+	}}
+	text := report.Format()
+	for _, want := range []string{"VaR: 50.00 USDT", "BTC −10%: 盈亏 -200.00 USDT", "🚨 强平"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected %q in:\n%s", want, text)
+		}
+	}
+}
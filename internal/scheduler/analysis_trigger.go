@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// analysisQueueSize bounds the on-demand runs waiting for the trading loop
+// analysisQueueSize 限制等待交易循环处理的即时分析数量
+const analysisQueueSize = 16
+
+// Analysis trigger errors
+// 即时分析触发错误
+var (
+	ErrAnalysisInProgress  = errors.New("analysis already queued or running for this symbol")
+	ErrAnalysisRateLimited = errors.New("analysis requested too recently for this symbol")
+	ErrAnalysisQueueFull   = errors.New("analysis queue is full")
+)
+
+// AnalysisJob is an on-demand analysis waiting for the trading loop
+// AnalysisJob 是等待交易循环处理的即时分析
+type AnalysisJob struct {
+	RequestID   int64     // 分析请求 ID / Analysis request ID
+	Symbol      string    // 交易对 / Trading pair
+	RequestedBy string    // 发起请求的用户 / User who requested the run
+	RequestedAt time.Time // 请求时间 / When the run was requested
+}
+
+// AnalysisTrigger queues on-demand analyses for the trading loop, one at a time per symbol and at most once per cooldown
+// AnalysisTrigger 为交易循环排队即时分析，每个交易对同一时间只有一个，且冷却期内最多一次
+// The trading loop runs the jobs, so they never overlap a scheduled cycle
+// 由交易循环执行这些任务，因此不会与定时周期重叠
+type AnalysisTrigger struct {
+	mu       sync.Mutex
+	cooldown time.Duration
+	jobs     chan AnalysisJob
+	active   map[string]bool      // 已排队或运行中的交易对 / Symbols queued or running
+	last     map[string]time.Time // 每个交易对最近一次请求时间 / Last request time per symbol
+	now      func() time.Time
+}
+
+// NewAnalysisTrigger creates a trigger that accepts one request per symbol per cooldown
+// NewAnalysisTrigger 创建触发器，每个交易对在冷却期内只接受一次请求
+func NewAnalysisTrigger(cooldown time.Duration) *AnalysisTrigger {
+	return &AnalysisTrigger{
+		cooldown: cooldown,
+		jobs:     make(chan AnalysisJob, analysisQueueSize),
+		active:   make(map[string]bool),
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Reserve claims a symbol for a new request, or reports why it cannot run now
+// Reserve 为新请求占用交易对，不能立即运行时返回原因
+// A successful reservation must be followed by Enqueue or Release
+// 占用成功后必须调用 Enqueue 或 Release
+func (t *AnalysisTrigger) Reserve(symbol string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active[symbol] {
+		return ErrAnalysisInProgress
+	}
+	if last, ok := t.last[symbol]; ok {
+		if wait := t.cooldown - t.now().Sub(last); wait > 0 {
+			return fmt.Errorf("%w, retry in %s", ErrAnalysisRateLimited, wait.Round(time.Second))
+		}
+	}
+	if len(t.jobs) >= cap(t.jobs) {
+		return ErrAnalysisQueueFull
+	}
+	t.active[symbol] = true
+	t.last[symbol] = t.now()
+	return nil
+}
+
+// Enqueue hands a reserved symbol's job to the trading loop
+// Enqueue 将已占用交易对的任务交给交易循环
+func (t *AnalysisTrigger) Enqueue(job AnalysisJob) error {
+	select {
+	case t.jobs <- job:
+		return nil
+	default:
+		t.Release(job.Symbol)
+		return ErrAnalysisQueueFull
+	}
+}
+
+// Release frees a symbol once its job has finished or could not be queued
+// Release 在任务结束或无法排队时释放交易对
+func (t *AnalysisTrigger) Release(symbol string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.active, symbol)
+}
+
+// Jobs returns the channel the trading loop receives queued analyses from
+// Jobs 返回交易循环接收排队分析任务的通道
+func (t *AnalysisTrigger) Jobs() <-chan AnalysisJob {
+	return t.jobs
+}
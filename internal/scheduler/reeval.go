@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReevalTriggers are the conditions that bring the re-analysis of a held symbol forward
+// ReevalTriggers 是让持仓 HOLD 的交易对提前重新分析的条件
+type ReevalTriggers struct {
+	PriceMovePercent     float64 // 价格相对 HOLD 时变动该百分比即触发，0 表示不启用 / Price move from the HOLD in %, 0 disables
+	StopProximityPercent float64 // 价格距离止损不足该百分比即触发，0 表示不启用 / Distance to the stop in %, 0 disables
+	FundingFlip          bool    // 资金费率正负翻转即触发 / Funding rate changes sign
+}
+
+// Enabled reports whether any trigger is configured
+// Enabled 判断是否配置了任一触发条件
+func (t ReevalTriggers) Enabled() bool {
+	return t.PriceMovePercent > 0 || t.StopProximityPercent > 0 || t.FundingFlip
+}
+
+// HoldSnapshot is the market state of a symbol when the trader decided to HOLD its position
+// HoldSnapshot 是交易员决定继续持有时交易对的市场状态
+type HoldSnapshot struct {
+	Symbol      string    // 交易对 / Trading pair
+	Price       float64   // HOLD 时的价格 / Price at the HOLD
+	FundingRate float64   // HOLD 时的资金费率 / Funding rate at the HOLD
+	HeldAt      time.Time // HOLD 时间 / When the HOLD was decided
+}
+
+// Evaluate checks the current price, stop and funding rate against a HOLD and returns the reason of the first trigger that fires
+// Evaluate 用当前价格、止损和资金费率检查 HOLD，返回第一个触发条件的原因
+// A stopLoss of 0 skips the stop trigger, a fundingRate of 0 on either side skips the funding trigger
+// stopLoss 为 0 时跳过止损条件，任一侧资金费率为 0 时跳过资金费率条件
+func (t ReevalTriggers) Evaluate(hold HoldSnapshot, price, stopLoss, fundingRate float64) (string, bool) {
+	if price <= 0 {
+		return "", false
+	}
+	if t.PriceMovePercent > 0 && hold.Price > 0 {
+		if move := (price - hold.Price) / hold.Price * 100; math.Abs(move) >= t.PriceMovePercent {
+			return fmt.Sprintf("价格较 HOLD 时变动 %+.2f%%（%.4f → %.4f）", move, hold.Price, price), true
+		}
+	}
+	if t.StopProximityPercent > 0 && stopLoss > 0 {
+		if distance := math.Abs(price-stopLoss) / price * 100; distance <= t.StopProximityPercent {
+			return fmt.Sprintf("价格 %.4f 距止损 %.4f 仅 %.2f%%", price, stopLoss, distance), true
+		}
+	}
+	if t.FundingFlip && hold.FundingRate != 0 && fundingRate != 0 && (hold.FundingRate > 0) != (fundingRate > 0) {
+		return fmt.Sprintf("资金费率翻转（%.4f%% → %.4f%%）", hold.FundingRate*100, fundingRate*100), true
+	}
+	return "", false
+}
+
+// ReevalWatcher holds the armed HOLD snapshots and fires each at most once
+// ReevalWatcher 保存已布防的 HOLD 快照，每个快照最多触发一次
+// A nil watcher is a no-op, so callers do not need to check whether the feature is enabled
+// watcher 为 nil 时不做任何事，调用方无需检查功能是否启用
+type ReevalWatcher struct {
+	mu       sync.Mutex
+	triggers ReevalTriggers
+	holds    map[string]HoldSnapshot
+}
+
+// NewReevalWatcher creates a watcher, or returns nil when no trigger is configured
+// NewReevalWatcher 创建监视器；未配置任何触发条件时返回 nil
+func NewReevalWatcher(triggers ReevalTriggers) *ReevalWatcher {
+	if !triggers.Enabled() {
+		return nil
+	}
+	return &ReevalWatcher{triggers: triggers, holds: make(map[string]HoldSnapshot)}
+}
+
+// Arm starts watching a held symbol, replacing any earlier snapshot of it
+// Arm 开始监视持有的交易对，覆盖其之前的快照
+func (w *ReevalWatcher) Arm(hold HoldSnapshot) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.holds[hold.Symbol] = hold
+}
+
+// Disarm stops watching a symbol
+// Disarm 停止监视交易对
+func (w *ReevalWatcher) Disarm(symbol string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.holds, symbol)
+}
+
+// Armed returns the armed snapshots sorted by symbol
+// Armed 返回已布防的快照，按交易对排序
+func (w *ReevalWatcher) Armed() []HoldSnapshot {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	holds := make([]HoldSnapshot, 0, len(w.holds))
+	for _, hold := range w.holds {
+		holds = append(holds, hold)
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].Symbol < holds[j].Symbol })
+	return holds
+}
+
+// Check evaluates an armed symbol and disarms it when a trigger fires
+// Check 检查已布防的交易对，触发后解除布防
+func (w *ReevalWatcher) Check(symbol string, price, stopLoss, fundingRate float64) (string, bool) {
+	if w == nil {
+		return "", false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	hold, ok := w.holds[symbol]
+	if !ok {
+		return "", false
+	}
+	reason, fired := w.triggers.Evaluate(hold, price, stopLoss, fundingRate)
+	if fired {
+		delete(w.holds, symbol)
+	}
+	return reason, fired
+}
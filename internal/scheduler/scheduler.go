@@ -2,6 +2,8 @@ package scheduler
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -12,6 +14,36 @@ type TradingScheduler struct {
 	mu        sync.RWMutex // Protects timeframe and minutes / 保护 timeframe 和 minutes
 	timeframe string
 	minutes   int
+
+	// Adaptive interval: the configured interval is the base, volatility moves the effective one between the bounds
+	// 自适应间隔：配置的间隔为基准，波动率使实际间隔在上下限之间切换
+	base         string // 基准间隔 / Base interval
+	minTimeframe string // 高波动时的最短间隔，为空表示未启用 / Shortest interval in high volatility, empty when disabled
+	maxTimeframe string // 低波动时的最长间隔 / Longest interval in low volatility
+
+	lastCycle time.Time      // 最近一次已处理周期的开始时间 / Start of the last processed cycle
+	location  *time.Location // 返回时间所用的显示时区 / Display zone of returned times
+}
+
+// VolatilityLevel classifies market volatility for adaptive scheduling
+// VolatilityLevel 表示用于自适应调度的市场波动等级
+type VolatilityLevel int
+
+const (
+	VolatilityNormal VolatilityLevel = iota // 正常波动，使用基准间隔 / Normal, use the base interval
+	VolatilityHigh                          // 高波动，收紧到最短间隔 / High, tighten to the shortest interval
+	VolatilityLow                           // 低波动，放宽到最长间隔 / Low, relax to the longest interval
+)
+
+func (l VolatilityLevel) String() string {
+	switch l {
+	case VolatilityHigh:
+		return "高波动"
+	case VolatilityLow:
+		return "低波动"
+	default:
+		return "正常波动"
+	}
 }
 
 // Timeframe minute mappings
@@ -25,13 +57,44 @@ var timeframeMinutes = map[string]int{
 	"2h":  120,
 	"4h":  240,
 	"6h":  360,
+	"8h":  480,
 	"12h": 720,
 	"1d":  1440,
 }
 
+// timeframeToMinutes returns the length of a timeframe in minutes
+// timeframeToMinutes 返回时间周期的分钟数
+// Besides the exchange intervals it accepts any "<n>m" or "<n>h" that divides a day (e.g. 90m), so periods stay aligned
+// to the day like exchange klines; the market data layer aggregates such timeframes from lower intervals.
+// 除交易所周期外，还接受任何能整除一天的 "<n>m" 或 "<n>h"（如 90m），使周期与交易所 K 线一样按天对齐；这类周期由行情层用更短周期合成。
+func timeframeToMinutes(timeframe string) (int, bool) {
+	if minutes, ok := timeframeMinutes[timeframe]; ok {
+		return minutes, true
+	}
+	if len(timeframe) < 2 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(timeframe[:len(timeframe)-1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	minutes := n
+	switch timeframe[len(timeframe)-1] {
+	case 'm':
+	case 'h':
+		minutes = n * 60
+	default:
+		return 0, false
+	}
+	if minutes > 1440 || 1440%minutes != 0 {
+		return 0, false
+	}
+	return minutes, true
+}
+
 // NewTradingScheduler creates a new trading scheduler
 func NewTradingScheduler(timeframe string) (*TradingScheduler, error) {
-	minutes, ok := timeframeMinutes[timeframe]
+	minutes, ok := timeframeToMinutes(timeframe)
 	if !ok {
 		return nil, fmt.Errorf("unsupported timeframe: %s", timeframe)
 	}
@@ -39,17 +102,48 @@ func NewTradingScheduler(timeframe string) (*TradingScheduler, error) {
 	return &TradingScheduler{
 		timeframe: timeframe,
 		minutes:   minutes,
+		base:      timeframe,
+		location:  time.Local,
 	}, nil
 }
 
+// TimeframeDuration returns the length of a supported timeframe
+// TimeframeDuration 返回支持的时间周期长度
+func TimeframeDuration(timeframe string) (time.Duration, error) {
+	minutes, ok := timeframeToMinutes(timeframe)
+	if !ok {
+		return 0, fmt.Errorf("unsupported timeframe: %s", timeframe)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+// ClassifyVolatility turns volatility percentiles (0-100) into a level
+// ClassifyVolatility 将波动率百分位（0-100）转换为波动等级
+// Either measure reaching highPercentile is a spike; the market is quiet only when both are at or below lowPercentile
+// 任一指标达到 highPercentile 即视为波动放大；两者都不高于 lowPercentile 才视为平静
+func ClassifyVolatility(atrPercentile, realizedPercentile, highPercentile, lowPercentile float64) VolatilityLevel {
+	switch {
+	case atrPercentile >= highPercentile || realizedPercentile >= highPercentile:
+		return VolatilityHigh
+	case atrPercentile <= lowPercentile && realizedPercentile <= lowPercentile:
+		return VolatilityLow
+	default:
+		return VolatilityNormal
+	}
+}
+
 // GetNextTimeframeTime returns the next K-line period start time
 // GetNextTimeframeTime 返回下一个 K 线周期开始时间
+//
+// Periods are aligned to UTC like exchange klines, so a display timezone with a non-hour offset does not
+// shift the schedule; the result is returned in the display timezone set by SetLocation.
+// 周期与交易所 K 线一样按 UTC 对齐，因此非整点偏移的显示时区不会使调度错位；结果以 SetLocation 设置的显示时区返回。
 func (s *TradingScheduler) GetNextTimeframeTime() time.Time {
 	s.mu.RLock()
-	minutes := s.minutes
+	minutes, loc := s.minutes, s.location
 	s.mu.RUnlock()
 
-	now := time.Now()
+	now := time.Now().UTC()
 
 	// Calculate current minute of the day
 	// 计算当天的当前分钟数
@@ -64,13 +158,13 @@ func (s *TradingScheduler) GetNextTimeframeTime() time.Time {
 	if nextPeriod >= 1440 { // 24 hours = 1440 minutes
 		nextDay := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
 		nextPeriodMinutes := nextPeriod - 1440
-		return nextDay.Add(time.Duration(nextPeriodMinutes) * time.Minute)
+		return nextDay.Add(time.Duration(nextPeriodMinutes) * time.Minute).In(loc)
 	}
 
 	// Same day
 	// 同一天
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	return today.Add(time.Duration(nextPeriod) * time.Minute)
+	return today.Add(time.Duration(nextPeriod) * time.Minute).In(loc)
 }
 
 // WaitForNextTimeframe waits until the next K-line period starts
@@ -120,7 +214,7 @@ func (s *TradingScheduler) IsOnTimeframe() bool {
 	minutes := s.minutes
 	s.mu.RUnlock()
 
-	now := time.Now()
+	now := time.Now().UTC()
 	currentMinute := now.Hour()*60 + now.Minute()
 
 	// Check if on period boundary (allow 60 second tolerance)
@@ -128,23 +222,87 @@ func (s *TradingScheduler) IsOnTimeframe() bool {
 	return currentMinute%minutes == 0 && now.Second() < 60
 }
 
-// GetAlignedIntervals returns all aligned time points in a day
-// GetAlignedIntervals 返回一天内所有对齐的时间点
+// CycleStart returns the start of the K-line period containing t, aligned to UTC like GetNextTimeframeTime
+// CycleStart 返回 t 所在 K 线周期的开始时间，与 GetNextTimeframeTime 一样按 UTC 对齐
+func (s *TradingScheduler) CycleStart(t time.Time) time.Time {
+	s.mu.RLock()
+	minutes, loc := s.minutes, s.location
+	s.mu.RUnlock()
+
+	u := t.UTC()
+	midnight := time.Date(u.Year(), u.Month(), u.Day(), 0, 0, 0, 0, time.UTC)
+	period := (u.Hour()*60 + u.Minute()) / minutes * minutes
+	return midnight.Add(time.Duration(period) * time.Minute).In(loc)
+}
+
+// MarkProcessed records a cycle as already processed, e.g. the cycle of the last saved session after a restart
+// MarkProcessed 将周期记录为已处理，例如重启后最近一次保存会话所在的周期
+func (s *TradingScheduler) MarkProcessed(cycle time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cycle.After(s.lastCycle) {
+		s.lastCycle = cycle
+	}
+}
+
+// ClaimCycle decides whether the cycle containing now should run, and claims it so it runs only once
+// ClaimCycle 判断 now 所在周期是否应当运行，并占用该周期使其只运行一次
+//
+// Without a processed cycle the scheduler waits for a period boundary, as IsOnTimeframe does. After that, a cycle
+// that has not run yet is claimed even past its boundary, so a late tick (sleep, a slow analysis) catches up.
+// Cycles missed in between are only counted: just the latest cycle runs, never a backlog of stale ones.
+// 尚无已处理周期时，与 IsOnTimeframe 一样等待周期边界。此后，尚未运行的周期即使已过边界也会被占用，
+// 使延迟的触发（休眠、分析耗时过长）得以补跑。期间错过的周期只计数：只运行最新周期，不会积压补跑过期周期。
+func (s *TradingScheduler) ClaimCycle(now time.Time) (cycle time.Time, missed int, ok bool) {
+	cycle = s.CycleStart(now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastCycle.IsZero() {
+		if !now.Before(cycle.Add(time.Minute)) {
+			return cycle, 0, false
+		}
+	} else {
+		if !cycle.After(s.lastCycle) {
+			return cycle, 0, false
+		}
+		interval := time.Duration(s.minutes) * time.Minute
+		if n := int(cycle.Sub(s.lastCycle)/interval) - 1; n > 0 {
+			missed = n
+		}
+	}
+	s.lastCycle = cycle
+	return cycle, missed, true
+}
+
+// IsCurrentCycle reports whether cycle is still the running period at now; decisions from an older cycle are stale
+// IsCurrentCycle 返回 cycle 在 now 时是否仍是当前周期；更早周期的决策已过期
+func (s *TradingScheduler) IsCurrentCycle(cycle, now time.Time) bool {
+	return s.CycleStart(now).Equal(cycle)
+}
+
+// GetAlignedIntervals returns all aligned time points in a day as display clock times
+// GetAlignedIntervals 以显示时区的时钟时间返回一天内所有对齐的时间点
 func (s *TradingScheduler) GetAlignedIntervals() []string {
 	s.mu.RLock()
-	minutes := s.minutes
+	minutes, loc := s.minutes, s.location
 	s.mu.RUnlock()
 
 	intervals := []string{}
 	totalMinutes := 0
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
 
 	for totalMinutes < 1440 { // 24 hours
-		hour := totalMinutes / 60
-		minute := totalMinutes % 60
-		intervals = append(intervals, fmt.Sprintf("%02d:%02d", hour, minute))
+		point := midnight.Add(time.Duration(totalMinutes) * time.Minute).In(loc)
+		intervals = append(intervals, fmt.Sprintf("%02d:%02d", point.Hour(), point.Minute()))
 		totalMinutes += minutes
 	}
 
+	// Start the list at the earliest display clock time
+	// 从最早的显示时钟时间开始排列
+	sort.Strings(intervals)
 	return intervals
 }
 
@@ -169,7 +327,7 @@ func (s *TradingScheduler) GetMinutes() int {
 func (s *TradingScheduler) UpdateTimeframe(newTimeframe string) error {
 	// Validate timeframe
 	// 验证时间周期
-	minutes, ok := timeframeMinutes[newTimeframe]
+	minutes, ok := timeframeToMinutes(newTimeframe)
 	if !ok {
 		return fmt.Errorf("unsupported timeframe: %s", newTimeframe)
 	}
@@ -181,6 +339,78 @@ func (s *TradingScheduler) UpdateTimeframe(newTimeframe string) error {
 
 	s.timeframe = newTimeframe
 	s.minutes = minutes
+	s.base = newTimeframe
 
 	return nil
 }
+
+// GetBaseTimeframe returns the configured interval that adaptive scheduling starts from
+// GetBaseTimeframe 返回自适应调度所基于的配置间隔
+func (s *TradingScheduler) GetBaseTimeframe() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.base
+}
+
+// SetLocation sets the display zone of the times the scheduler returns; periods stay aligned to UTC
+// SetLocation 设置调度器返回时间所用的显示时区；周期仍按 UTC 对齐
+func (s *TradingScheduler) SetLocation(loc *time.Location) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.location = loc
+}
+
+// SetAdaptiveBounds enables adaptive intervals between minTimeframe and maxTimeframe
+// SetAdaptiveBounds 启用自适应间隔，范围为 minTimeframe 到 maxTimeframe
+func (s *TradingScheduler) SetAdaptiveBounds(minTimeframe, maxTimeframe string) error {
+	minMinutes, ok := timeframeToMinutes(minTimeframe)
+	if !ok {
+		return fmt.Errorf("unsupported timeframe: %s", minTimeframe)
+	}
+	maxMinutes, ok := timeframeToMinutes(maxTimeframe)
+	if !ok {
+		return fmt.Errorf("unsupported timeframe: %s", maxTimeframe)
+	}
+	if minMinutes > maxMinutes {
+		return fmt.Errorf("adaptive interval minimum %s is longer than maximum %s", minTimeframe, maxTimeframe)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minTimeframe = minTimeframe
+	s.maxTimeframe = maxTimeframe
+	return nil
+}
+
+// Adapt switches the effective interval for the volatility level and reports whether it changed
+// Adapt 根据波动等级切换实际间隔，并返回是否发生变化
+// The interval only ever moves towards a bound: a base already shorter than the minimum is not lengthened in high volatility
+// 间隔只会向边界方向调整：基准已短于最短间隔时，高波动下也不会被拉长
+func (s *TradingScheduler) Adapt(level VolatilityLevel) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.minTimeframe == "" {
+		return s.timeframe, false
+	}
+
+	target := s.base
+	baseMinutes, _ := timeframeToMinutes(s.base)
+	switch level {
+	case VolatilityHigh:
+		if minMinutes, _ := timeframeToMinutes(s.minTimeframe); minMinutes < baseMinutes {
+			target = s.minTimeframe
+		}
+	case VolatilityLow:
+		if maxMinutes, _ := timeframeToMinutes(s.maxTimeframe); maxMinutes > baseMinutes {
+			target = s.maxTimeframe
+		}
+	}
+
+	if target == s.timeframe {
+		return s.timeframe, false
+	}
+	s.timeframe = target
+	s.minutes, _ = timeframeToMinutes(target)
+	return target, true
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"errors"
 	"testing"
 	"time"
 )
@@ -17,6 +18,10 @@ func TestNewTradingScheduler(t *testing.T) {
 		{"1h", false, 60},
 		{"4h", false, 240},
 		{"1d", false, 1440},
+		{"8h", false, 480},
+		{"90m", false, 90},
+		{"7m", true, 0},
+		{"0m", true, 0},
 		{"invalid", true, 0},
 	}
 
@@ -49,7 +54,8 @@ func TestGetNextTimeframeTime(t *testing.T) {
 		t.Fatalf("NewTradingScheduler failed: %v", err)
 	}
 
-	next := scheduler.GetNextTimeframeTime()
+	// 周期按 UTC 对齐
+	next := scheduler.GetNextTimeframeTime().UTC()
 
 	// 下一个时间应该是整点
 	if next.Minute() != 0 || next.Second() != 0 {
@@ -151,7 +157,7 @@ func TestTimeframeAlignment(t *testing.T) {
 				t.Fatalf("NewTradingScheduler failed: %v", err)
 			}
 
-			next := scheduler.GetNextTimeframeTime()
+			next := scheduler.GetNextTimeframeTime().UTC()
 
 			// 验证分钟对齐
 			isAligned := false
@@ -169,3 +175,247 @@ func TestTimeframeAlignment(t *testing.T) {
 		})
 	}
 }
+
+func TestNextTimeframeAlignsToUTC(t *testing.T) {
+	// A display timezone with a half-hour offset must not shift the schedule
+	// 半小时偏移的显示时区不应使调度错位
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("Zone data unavailable: %v", err)
+	}
+	scheduler, err := NewTradingScheduler("1h")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+	scheduler.SetLocation(kolkata)
+
+	next := scheduler.GetNextTimeframeTime()
+	if next.Location() != kolkata {
+		t.Errorf("Expected the display timezone, got %s", next.Location())
+	}
+	if next.Minute() != 30 || next.UTC().Minute() != 0 {
+		t.Errorf("Expected :30 local and :00 UTC, got %s", next.Format(time.RFC3339))
+	}
+
+	if intervals := scheduler.GetAlignedIntervals(); len(intervals) != 24 || intervals[0] != "00:30" {
+		t.Errorf("Expected 24 local points starting at 00:30, got %v", intervals)
+	}
+}
+
+func TestClassifyVolatility(t *testing.T) {
+	tests := []struct {
+		name     string
+		atr      float64
+		realized float64
+		expected VolatilityLevel
+	}{
+		{"ATR spike", 90, 50, VolatilityHigh},
+		{"Realized volatility spike", 40, 85, VolatilityHigh},
+		{"Both quiet", 10, 15, VolatilityLow},
+		{"Only one quiet", 10, 50, VolatilityNormal},
+		{"Middle", 50, 50, VolatilityNormal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyVolatility(tt.atr, tt.realized, 80, 20); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestAdapt(t *testing.T) {
+	scheduler, err := NewTradingScheduler("1h")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+
+	// 未设置上下限时不调整
+	if _, changed := scheduler.Adapt(VolatilityHigh); changed {
+		t.Error("Adapt should do nothing without bounds")
+	}
+
+	if err := scheduler.SetAdaptiveBounds("4h", "15m"); err == nil {
+		t.Error("Expected error for minimum longer than maximum")
+	}
+	if err := scheduler.SetAdaptiveBounds("15m", "4h"); err != nil {
+		t.Fatalf("SetAdaptiveBounds failed: %v", err)
+	}
+
+	steps := []struct {
+		level    VolatilityLevel
+		expected string
+		changed  bool
+	}{
+		{VolatilityHigh, "15m", true},
+		{VolatilityHigh, "15m", false},
+		{VolatilityLow, "4h", true},
+		{VolatilityNormal, "1h", true},
+	}
+	for i, step := range steps {
+		got, changed := scheduler.Adapt(step.level)
+		if got != step.expected || changed != step.changed {
+			t.Errorf("Step %d (%s): expected %s changed=%v, got %s changed=%v", i, step.level, step.expected, step.changed, got, changed)
+		}
+		if scheduler.GetMinutes() != timeframeMinutes[step.expected] {
+			t.Errorf("Step %d: minutes not updated, got %d", i, scheduler.GetMinutes())
+		}
+	}
+
+	// 手动修改的间隔成为新的基准
+	if err := scheduler.UpdateTimeframe("30m"); err != nil {
+		t.Fatalf("UpdateTimeframe failed: %v", err)
+	}
+	scheduler.Adapt(VolatilityHigh)
+	if got, _ := scheduler.Adapt(VolatilityNormal); got != "30m" || scheduler.GetBaseTimeframe() != "30m" {
+		t.Errorf("Expected base 30m after manual update, got %s (base %s)", got, scheduler.GetBaseTimeframe())
+	}
+}
+
+func TestClaimCycle(t *testing.T) {
+	scheduler, err := NewTradingScheduler("15m")
+	if err != nil {
+		t.Fatalf("NewTradingScheduler failed: %v", err)
+	}
+	at := func(hour, min, sec int) time.Time {
+		return time.Date(2025, 1, 1, hour, min, sec, 0, time.UTC)
+	}
+
+	// 首次运行只在周期边界开始
+	if _, _, ok := scheduler.ClaimCycle(at(10, 7, 0)); ok {
+		t.Error("Expected the first cycle to wait for a boundary")
+	}
+	cycle, missed, ok := scheduler.ClaimCycle(at(10, 15, 20))
+	if !ok || missed != 0 || !cycle.Equal(at(10, 15, 0)) {
+		t.Fatalf("Expected to claim 10:15, got %v missed=%d ok=%v", cycle.UTC(), missed, ok)
+	}
+
+	// 同一周期只运行一次
+	if _, _, ok := scheduler.ClaimCycle(at(10, 15, 50)); ok {
+		t.Error("Expected a second tick in the same cycle not to run")
+	}
+	if !scheduler.IsCurrentCycle(cycle, at(10, 29, 59)) || scheduler.IsCurrentCycle(cycle, at(10, 30, 0)) {
+		t.Error("Expected 10:15 to be current until 10:30")
+	}
+
+	// 停机后只补跑最新周期，错过的周期仅计数
+	cycle, missed, ok = scheduler.ClaimCycle(at(11, 8, 0))
+	if !ok || missed != 2 || !cycle.Equal(at(11, 0, 0)) {
+		t.Errorf("Expected to catch up on 11:00 with 2 missed cycles, got %v missed=%d ok=%v", cycle.UTC(), missed, ok)
+	}
+
+	// 重启后已处理的周期不再运行
+	restarted, _ := NewTradingScheduler("15m")
+	restarted.MarkProcessed(restarted.CycleStart(at(11, 1, 0)))
+	if _, _, ok := restarted.ClaimCycle(at(11, 0, 30)); ok {
+		t.Error("Expected a cycle marked processed not to run again")
+	}
+	if _, missed, ok := restarted.ClaimCycle(at(11, 15, 10)); !ok || missed != 0 {
+		t.Errorf("Expected the next cycle to run normally, got missed=%d ok=%v", missed, ok)
+	}
+}
+
+func TestReevalTriggersEvaluate(t *testing.T) {
+	triggers := ReevalTriggers{PriceMovePercent: 2, StopProximityPercent: 0.5, FundingFlip: true}
+	hold := HoldSnapshot{Symbol: "BTC/USDT", Price: 100, FundingRate: 0.0001}
+
+	tests := []struct {
+		name        string
+		price       float64
+		stopLoss    float64
+		fundingRate float64
+		fired       bool
+	}{
+		{"quiet", 101, 95, 0.0001, false},
+		{"price up", 102, 95, 0.0001, true},
+		{"price down", 97.9, 95, 0.0001, true},
+		{"near stop", 99, 98.6, 0.0001, true},
+		{"no stop", 99, 0, 0.0001, false},
+		{"funding flip", 100, 95, -0.0001, true},
+		{"funding unknown", 100, 95, 0, false},
+		{"no price", 0, 95, -0.0001, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, fired := triggers.Evaluate(hold, tt.price, tt.stopLoss, tt.fundingRate)
+			if fired != tt.fired {
+				t.Errorf("Evaluate fired = %v (%q), want %v", fired, reason, tt.fired)
+			}
+			if fired && reason == "" {
+				t.Error("Expected a reason when a trigger fires")
+			}
+		})
+	}
+}
+
+func TestReevalWatcher(t *testing.T) {
+	if w := NewReevalWatcher(ReevalTriggers{}); w != nil {
+		t.Fatal("Expected nil watcher without triggers")
+	}
+	var nilWatcher *ReevalWatcher
+	nilWatcher.Arm(HoldSnapshot{Symbol: "BTC/USDT", Price: 100})
+	if _, fired := nilWatcher.Check("BTC/USDT", 200, 0, 0); fired {
+		t.Error("Nil watcher should never fire")
+	}
+
+	w := NewReevalWatcher(ReevalTriggers{PriceMovePercent: 1})
+	w.Arm(HoldSnapshot{Symbol: "ETH/USDT", Price: 100})
+	w.Arm(HoldSnapshot{Symbol: "BTC/USDT", Price: 100})
+	if armed := w.Armed(); len(armed) != 2 || armed[0].Symbol != "BTC/USDT" {
+		t.Fatalf("Expected 2 armed symbols sorted by symbol, got %+v", armed)
+	}
+
+	if _, fired := w.Check("BTC/USDT", 100.5, 0, 0); fired {
+		t.Error("Expected no trigger below the price move threshold")
+	}
+	if _, fired := w.Check("BTC/USDT", 101, 0, 0); !fired {
+		t.Error("Expected price move trigger to fire")
+	}
+	if _, fired := w.Check("BTC/USDT", 110, 0, 0); fired {
+		t.Error("Expected symbol to be disarmed after firing")
+	}
+
+	w.Disarm("ETH/USDT")
+	if armed := w.Armed(); len(armed) != 0 {
+		t.Errorf("Expected no armed symbols, got %+v", armed)
+	}
+}
+
+func TestAnalysisTrigger(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	trigger := NewAnalysisTrigger(5 * time.Minute)
+	trigger.now = func() time.Time { return now }
+
+	if err := trigger.Reserve("BTC/USDT"); err != nil {
+		t.Fatalf("Reserve failed: %v", err)
+	}
+	if err := trigger.Enqueue(AnalysisJob{RequestID: 1, Symbol: "BTC/USDT"}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	// 排队中的交易对拒绝新请求，其他交易对不受影响
+	if err := trigger.Reserve("BTC/USDT"); !errors.Is(err, ErrAnalysisInProgress) {
+		t.Errorf("Expected ErrAnalysisInProgress, got %v", err)
+	}
+	if err := trigger.Reserve("ETH/USDT"); err != nil {
+		t.Errorf("Expected another symbol to be accepted, got %v", err)
+	}
+	trigger.Release("ETH/USDT")
+
+	job := <-trigger.Jobs()
+	if job.RequestID != 1 {
+		t.Errorf("Expected request 1, got %+v", job)
+	}
+	trigger.Release(job.Symbol)
+
+	// 完成后仍受冷却时间限制
+	if err := trigger.Reserve("BTC/USDT"); !errors.Is(err, ErrAnalysisRateLimited) {
+		t.Errorf("Expected ErrAnalysisRateLimited within the cooldown, got %v", err)
+	}
+	now = now.Add(5 * time.Minute)
+	if err := trigger.Reserve("BTC/USDT"); err != nil {
+		t.Errorf("Expected the request to be accepted after the cooldown, got %v", err)
+	}
+}
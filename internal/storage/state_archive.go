@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StateArchiveVersion is the format version written into exported archives
+// StateArchiveVersion 是写入导出归档的格式版本
+const StateArchiveVersion = 1
+
+// stateArchiveMagic prefixes every archive so foreign files are rejected early
+// stateArchiveMagic 作为每个归档的前缀，用于尽早拒绝非本程序的文件
+const stateArchiveMagic = "CTBSTATE"
+
+// Archive key derivation parameters
+// 归档密钥派生参数
+const (
+	stateArchiveSaltSize   = 16     // 盐长度 / Salt size
+	stateArchiveKeySize    = 32     // AES-256 密钥长度 / AES-256 key size
+	stateArchiveIterations = 600000 // PBKDF2 迭代次数 / PBKDF2 iterations
+)
+
+// stateTables lists the tables that make up the bot state, in restore order
+// stateTables 列出构成机器人状态的表，按恢复顺序排列
+// positions must come before stoploss_events and take_profit_levels because of the foreign keys
+// positions 必须在 stoploss_events 和 take_profit_levels 之前，因为存在外键
+// symbol_leases is left out: a lease names the exporting host's process, and restoring it would block the new host
+// until it expired. Leases in archives exported before this are ignored on import
+// symbol_leases 不在其中：租约属于导出主机上的进程，恢复后会阻塞新主机直到租约过期。之前导出的归档中的租约在导入时被忽略
+var stateTables = []string{
+	"trading_sessions",
+	"positions",
+	"stoploss_events",
+	"take_profit_levels",
+	"balance_history",
+	"pending_tasks",
+	"trade_intents",
+	"trade_intent_events",
+	"symbol_pauses",
+	"situation_memories",
+	"execution_costs",
+	"analysis_requests",
+	"trading_lessons",
+	"stop_slippages",
+	"strategy_reviews",
+	"trader_configs",
+	"shadow_decisions",
+}
+
+// StateSnapshot is the complete bot state moved between hosts
+// StateSnapshot 是在主机之间迁移的完整机器人状态
+type StateSnapshot struct {
+	Version    int                                 `json:"version"`     // 格式版本 / Format version
+	ExportedAt time.Time                           `json:"exported_at"` // 导出时间 / Export time
+	Hostname   string                              `json:"hostname"`    // 导出主机 / Source host
+	Config     string                              `json:"config"`      // .env 文件内容 / .env file content
+	Tables     map[string][]map[string]interface{} `json:"tables"`      // 表名 -> 行 / Table name -> rows
+}
+
+// ExportState dumps every state table into a snapshot
+// ExportState 将所有状态表导出为快照
+func (s *Storage) ExportState() (*StateSnapshot, error) {
+	snapshot := &StateSnapshot{
+		Version:    StateArchiveVersion,
+		ExportedAt: time.Now(),
+		Tables:     make(map[string][]map[string]interface{}, len(stateTables)),
+	}
+
+	for _, table := range stateTables {
+		rows, err := s.exportTable(table)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Tables[table] = rows
+	}
+
+	return snapshot, nil
+}
+
+// exportTable reads all rows of a table as column -> value maps
+// exportTable 以列名 -> 值的形式读取表中所有行
+func (s *Storage) exportTable(table string) ([]map[string]interface{}, error) {
+	rows, err := s.db.Query("SELECT * FROM " + table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row of %s: %w", table, err)
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			switch v := values[i].(type) {
+			case []byte:
+				row[column] = string(v)
+			case time.Time:
+				row[column] = v.Format(time.RFC3339Nano)
+			default:
+				row[column] = v
+			}
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// ImportState replaces all state tables with the snapshot contents in a single transaction
+// ImportState 在单个事务中用快照内容替换所有状态表
+func (s *Storage) ImportState(snapshot *StateSnapshot) error {
+	if snapshot.Version > StateArchiveVersion {
+		return fmt.Errorf("unsupported state archive version %d (max %d)", snapshot.Version, StateArchiveVersion)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Clear in reverse order so child rows go before their parents
+	// 按相反顺序清空，保证子表先于父表
+	for i := len(stateTables) - 1; i >= 0; i-- {
+		if _, err := tx.Exec("DELETE FROM " + stateTables[i]); err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", stateTables[i], err)
+		}
+	}
+
+	for _, table := range stateTables {
+		for _, row := range snapshot.Tables[table] {
+			columns := make([]string, 0, len(row))
+			placeholders := make([]string, 0, len(row))
+			args := make([]interface{}, 0, len(row))
+			for column, value := range row {
+				columns = append(columns, column)
+				placeholders = append(placeholders, "?")
+				args = append(args, normalizeImportValue(value))
+			}
+
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+				table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("failed to import row into %s: %w", table, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit import: %w", err)
+	}
+	return nil
+}
+
+// normalizeImportValue converts JSON-decoded numbers back into integer or float values for SQLite
+// normalizeImportValue 将 JSON 解码后的数字还原为 SQLite 使用的整数或浮点数
+func normalizeImportValue(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := strconv.ParseInt(string(number), 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(string(number), 64); err == nil {
+		return f
+	}
+	return string(number)
+}
+
+// WriteStateArchive writes the snapshot as gzip-compressed JSON encrypted with AES-256-GCM
+// WriteStateArchive 将快照写为经 gzip 压缩、AES-256-GCM 加密的 JSON
+// Layout: magic | version byte | salt | nonce | ciphertext; the key is derived from the passphrase with PBKDF2-SHA256
+// 格式：魔数 | 版本字节 | 盐 | nonce | 密文；密钥由口令经 PBKDF2-SHA256 派生
+func WriteStateArchive(w io.Writer, snapshot *StateSnapshot, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("state archive passphrase is empty")
+	}
+
+	var plain bytes.Buffer
+	zw := gzip.NewWriter(&plain)
+	if err := json.NewEncoder(zw).Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to encode state snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress state snapshot: %w", err)
+	}
+
+	salt := make([]byte, stateArchiveSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	gcm, err := newStateArchiveCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := append([]byte(stateArchiveMagic), byte(StateArchiveVersion))
+	ciphertext := gcm.Seal(nil, nonce, plain.Bytes(), header)
+
+	for _, part := range [][]byte{header, salt, nonce, ciphertext} {
+		if _, err := w.Write(part); err != nil {
+			return fmt.Errorf("failed to write state archive: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadStateArchive decrypts and decodes an archive written by WriteStateArchive
+// ReadStateArchive 解密并解码由 WriteStateArchive 写出的归档
+func ReadStateArchive(r io.Reader, passphrase string) (*StateSnapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state archive: %w", err)
+	}
+
+	headerSize := len(stateArchiveMagic) + 1
+	if len(data) < headerSize+stateArchiveSaltSize || string(data[:len(stateArchiveMagic)]) != stateArchiveMagic {
+		return nil, fmt.Errorf("not a state archive")
+	}
+	if version := int(data[len(stateArchiveMagic)]); version > StateArchiveVersion {
+		return nil, fmt.Errorf("unsupported state archive version %d (max %d)", version, StateArchiveVersion)
+	}
+
+	header := data[:headerSize]
+	salt := data[headerSize : headerSize+stateArchiveSaltSize]
+	gcm, err := newStateArchiveCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := data[headerSize+stateArchiveSaltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, fmt.Errorf("state archive is truncated")
+	}
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt state archive (wrong passphrase or corrupted file): %w", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(plain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress state archive: %w", err)
+	}
+	defer zr.Close()
+
+	decoder := json.NewDecoder(zr)
+	decoder.UseNumber()
+	var snapshot StateSnapshot
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode state snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// newStateArchiveCipher derives the archive key from the passphrase and returns an AES-GCM cipher
+// newStateArchiveCipher 由口令派生归档密钥并返回 AES-GCM 加密器
+func newStateArchiveCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, stateArchiveIterations, stateArchiveKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive archive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return gcm, nil
+}
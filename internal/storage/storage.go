@@ -3,6 +3,8 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -22,8 +24,24 @@ type TradingSession struct {
 	PositionInfo    string
 	Decision        string // 该交易对的专属决策 / Symbol-specific decision
 	FullDecision    string // LLM 原始完整决策（包含所有交易对）/ Full LLM decision (all symbols)
+	Action          string // 该交易对的决策动作（BUY/SELL/HOLD...）/ Decided action for the symbol (BUY/SELL/HOLD...)
 	Executed        bool
 	ExecutionResult string
+	ErrorCategory   string // 失败类别，空表示未失败 / Failure category, empty when nothing failed
+
+	AnalystStatus map[string]string // 分析师 → 报告状态（present/degraded/absent）/ Analyst → report status (present, degraded or absent)
+}
+
+// SessionQuery filters and pages trading sessions, newest first
+// SessionQuery 按条件筛选并分页查询交易会话，按时间倒序
+type SessionQuery struct {
+	Symbol   string    // 交易对，BTC/USDT 与 BTCUSDT 等价 / Trading pair, BTC/USDT and BTCUSDT are equivalent
+	Action   string    // 决策动作 / Decided action
+	Executed *bool     // 是否已执行，nil 表示不限 / Execution outcome, nil for any
+	From     time.Time // 起始时间（含），零值表示不限 / Inclusive start, zero for unbounded
+	To       time.Time // 结束时间（不含），零值表示不限 / Exclusive end, zero for unbounded
+	Cursor   int64     // 只返回 ID 小于该值的会话，0 表示从最新开始 / Only sessions with an ID below this, 0 starts at the newest
+	Limit    int       // 每页数量 / Page size
 }
 
 // PositionRecord represents an active trading position
@@ -51,6 +69,14 @@ type PositionRecord struct {
 	ClosePrice       float64
 	CloseReason      string
 	RealizedPnL      float64
+	Commission       float64 // 累计手续费（正数为支出）/ Total commission paid (positive is a cost)
+	FundingFee       float64 // 累计资金费（正数为收入）/ Net funding received (positive is income)
+}
+
+// NetPnL returns the realized PnL after commissions and funding
+// NetPnL 返回扣除手续费并计入资金费后的已实现净盈亏
+func (p *PositionRecord) NetPnL() float64 {
+	return p.RealizedPnL - p.Commission + p.FundingFee
 }
 
 // StopLossEvent represents a stop-loss change event
@@ -65,6 +91,21 @@ type StopLossEvent struct {
 	Trigger    string
 }
 
+// TakeProfitLevelRecord is the persisted state of one partial take-profit level
+// TakeProfitLevelRecord 是单个分批止盈级别的持久化状态
+type TakeProfitLevelRecord struct {
+	PositionID      string
+	Level           int
+	RiskRewardRatio float64
+	Percentage      float64
+	TargetPrice     float64
+	Executed        bool
+	ExecutedTime    *time.Time
+	ExecutedPrice   float64
+	NewStopLoss     float64
+	OrderID         int64
+}
+
 // BalanceHistory represents account balance at a point in time
 // BalanceHistory 表示某个时间点的账户余额
 type BalanceHistory struct {
@@ -76,6 +117,278 @@ type BalanceHistory struct {
 	Positions        int
 }
 
+// PendingTask represents a deferred action that is retried with backoff until it succeeds
+// PendingTask 表示一个延迟执行的动作，按退避策略重试直到成功
+type PendingTask struct {
+	ID          int64
+	TaskType    string // 任务类型（如 replace_stop_loss）/ Task type (e.g. replace_stop_loss)
+	Symbol      string
+	Payload     string // JSON 参数 / JSON-encoded parameters
+	Status      string // pending/done/failed
+	Attempts    int    // 已尝试次数 / Attempts made so far
+	MaxAttempts int    // 最大尝试次数 / Maximum attempts before giving up
+	NextRunAt   time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SymbolLease records which running bot process manages a symbol
+// SymbolLease 记录由哪个运行中的机器人进程管理某个交易对
+type SymbolLease struct {
+	Symbol      string
+	Owner       string // 持有者进程令牌（每次启动随机生成）/ Owner process token (random per run)
+	InstanceID  string // 实例标识 / Instance identifier
+	Hostname    string
+	PID         int
+	AcquiredAt  time.Time
+	HeartbeatAt time.Time // 最近一次续约时间 / Last renewal time
+}
+
+// SymbolPause stops new entries on one symbol until it is resumed, optionally at a scheduled time
+// SymbolPause 暂停某个交易对的新开仓，直到手动恢复或到达预定的恢复时间
+type SymbolPause struct {
+	Symbol   string     // 币安格式交易对 / Binance symbol
+	Reason   string     // 暂停原因（如代币解锁）/ Why trading is paused (e.g. a token unlock)
+	PausedAt time.Time  // 暂停时间 / When the pause started
+	ResumeAt *time.Time // 自动恢复时间，nil 表示需手动恢复 / Scheduled resume, nil until resumed manually
+}
+
+// Execution liquidity of an order, derived from the maker flag of its fills
+// 订单的成交流动性类型，由各笔成交的 maker 标志得出
+const (
+	LiquidityMaker = "maker" // 全部以 maker 成交 / Filled entirely as maker
+	LiquidityTaker = "taker" // 全部以 taker 成交 / Filled entirely as taker
+	LiquidityMixed = "mixed" // 部分 maker 部分 taker / Partly maker, partly taker
+)
+
+// ExecutionCost is the slippage and fees paid by one executed order
+// ExecutionCost 是一笔已执行订单付出的滑点与手续费
+type ExecutionCost struct {
+	ID             int64
+	Symbol         string    // 交易对 / Trading pair
+	Action         string    // 交易动作 / Trade action
+	OrderID        string    // 订单 ID / Order ID
+	Liquidity      string    // maker/taker/mixed
+	Quantity       float64   // 成交数量 / Filled quantity
+	DecisionPrice  float64   // 决策时价格 / Price when the decision was made
+	ReferencePrice float64   // 下单前标记价格，0 表示未记录 / Mark price before the order, 0 when not recorded
+	FillPrice      float64   // 成交均价 / Average fill price
+	SlippageBps    float64   // 相对决策价的滑点（基点，正数为不利）/ Slippage against the decision price in bps (positive is adverse)
+	SlippageCost   float64   // 滑点成本（USDT，正数为支出）/ Slippage cost in USDT (positive is a cost)
+	Fee            float64   // 手续费（USDT）/ Fee paid in USDT
+	ExecutedAt     time.Time // 成交时间 / Execution time
+}
+
+// How a stop-loss was executed
+// 止损的执行方式
+const (
+	StopOrderTypeStopMarket = "STOP_MARKET" // 交易所止损市价单 / Exchange stop market order
+	StopOrderTypeLocal      = "LOCAL"       // 本地监控触发后市价平仓 / Market close after the local monitor triggered
+)
+
+// StopSlippage is how far a stop-loss fill landed from its trigger price
+// StopSlippage 是止损成交价与触发价的偏离
+type StopSlippage struct {
+	ID                  int64
+	PositionID          string    // 持仓 ID / Position ID
+	Symbol              string    // 交易对 / Trading pair
+	Timeframe           string    // 交易时间周期 / Trading timeframe
+	Side                string    // 持仓方向 / Position side
+	OrderType           string    // 止损执行方式 / How the stop executed (STOP_MARKET/LOCAL)
+	StopDistancePercent float64   // 止损距入场价的比例（%）/ Stop distance from entry (%)
+	TriggerPrice        float64   // 止损触发价 / Stop trigger price
+	FillPrice           float64   // 成交均价 / Average fill price
+	Quantity            float64   // 成交数量 / Filled quantity
+	SlippageBps         float64   // 相对触发价的滑点（基点，正数为不利）/ Slippage against the trigger in bps (positive is adverse)
+	SlippageCost        float64   // 滑点成本（USDT，正数为支出）/ Slippage cost in USDT (positive is a cost)
+	ExecutedAt          time.Time // 成交时间 / Execution time
+}
+
+// Analysis request statuses
+// 分析请求状态
+const (
+	AnalysisRequestQueued  = "queued"  // 已排队 / Waiting for the trading loop
+	AnalysisRequestRunning = "running" // 运行中 / Pipeline running
+	AnalysisRequestDone    = "done"    // 已完成 / Pipeline finished
+	AnalysisRequestFailed  = "failed"  // 失败 / Pipeline failed
+)
+
+// AnalysisRequest is an on-demand pipeline run requested from the web UI
+// AnalysisRequest 是从 Web 界面发起的即时分析请求
+type AnalysisRequest struct {
+	ID          int64
+	Symbol      string     // 交易对 / Trading pair
+	RequestedBy string     // 发起请求的用户 / User who requested the run
+	Status      string     // queued/running/done/failed
+	BatchID     string     // 运行产生的会话批次 ID / Batch ID of the sessions the run produced
+	Error       string     // 失败原因 / Failure reason
+	RequestedAt time.Time  // 请求时间 / When the run was requested
+	StartedAt   *time.Time // 开始时间 / When the run started
+	CompletedAt *time.Time // 结束时间 / When the run finished
+}
+
+// TradingLesson is a user-curated trading rule always shown to the trader
+// TradingLesson 是用户维护的交易规则，始终提供给交易员
+type TradingLesson struct {
+	ID        int64
+	Symbol    string    // 适用交易对（币安格式），空表示全部 / Symbol it applies to (Binance format), empty for all
+	Lesson    string    // 规则内容 / Rule text
+	Enabled   bool      // 是否启用 / Whether it is included in the prompt
+	CreatedBy string    // 创建者 / Who added it
+	CreatedAt time.Time // 创建时间 / Creation time
+	UpdatedAt time.Time // 更新时间 / Last update time
+}
+
+// StrategyReview is a periodic review of the strategy written by the deep model
+// StrategyReview 是深度模型生成的定期策略复盘
+type StrategyReview struct {
+	ID          int64
+	PeriodStart time.Time // 复盘区间开始 / Start of the reviewed period
+	PeriodEnd   time.Time // 复盘区间结束 / End of the reviewed period
+	Model       string    // 生成复盘的模型 / Model that wrote the review
+	Trades      int       // 区间内平仓笔数 / Trades closed in the period
+	NetPnL      float64   // 区间内净盈亏（USDT）/ Net PnL of the period in USDT
+	Report      string    // 结构化复盘 JSON / Structured review as JSON
+	CreatedAt   time.Time // 生成时间 / When the review was written
+}
+
+// Trader config statuses
+// 交易员配置状态
+const (
+	TraderConfigLive    = "live"    // 正在驱动实盘决策 / Drives live decisions
+	TraderConfigShadow  = "shadow"  // 影子运行中，只记录不执行 / Running in shadow, recorded but not executed
+	TraderConfigReady   = "ready"   // 影子运行完成，等待切换 / Shadow run finished, waiting for promotion
+	TraderConfigRetired = "retired" // 曾经在实盘使用，已被替换 / Was live, since replaced
+)
+
+// TraderConfig is a version of the trader's model and system prompt, identified by their fingerprint
+// TraderConfig 是交易员模型和系统提示词的一个版本，以二者的指纹标识
+type TraderConfig struct {
+	ID           int64
+	Fingerprint  string     // 模型与提示词的哈希 / Hash of the model and prompt
+	Model        string     // 交易员模型 / Trader model
+	Prompt       string     // 系统提示词全文 / Full system prompt
+	Status       string     // live / shadow / ready / retired
+	ShadowCycles int        // 已完成的影子运行轮数 / Shadow cycles completed
+	CreatedAt    time.Time  // 首次发现时间 / When the version was first seen
+	PromotedAt   *time.Time // 切换为实盘的时间 / When it went live
+}
+
+// ShadowDecision compares the live and shadow decision of one symbol in one shadow cycle
+// ShadowDecision 对比某轮影子运行中单个交易对的实盘决策与影子决策
+type ShadowDecision struct {
+	ID               int64
+	Fingerprint      string    // 影子配置指纹 / Fingerprint of the shadow config
+	Cycle            int       // 第几轮影子运行 / Shadow cycle number, from 1
+	Symbol           string    // 交易对 / Symbol
+	LiveAction       string    // 实盘决策动作 / Live action
+	ShadowAction     string    // 影子决策动作 / Shadow action
+	LiveConfidence   float64   // 实盘置信度 / Live confidence
+	ShadowConfidence float64   // 影子置信度 / Shadow confidence
+	LiveLeverage     int       // 实盘杠杆 / Live leverage
+	ShadowLeverage   int       // 影子杠杆 / Shadow leverage
+	CreatedAt        time.Time // 记录时间 / When it was recorded
+}
+
+// SituationMemory is a labeled market situation that the decision prompt can recall
+// SituationMemory 是带结果标签的市场情境，可在决策 Prompt 中被召回
+type SituationMemory struct {
+	ID            int64
+	Symbol        string
+	Timeframe     string
+	SituationTime time.Time // 情境出现的 K 线时间 / Bar time of the situation
+	Setup         string    // 形态类型 / Setup type
+	Direction     string    // long 或 short / long or short
+	Features      string    // 特征 JSON / Features as JSON
+	Situation     string    // 情境描述 / Situation description
+	Outcome       string    // win / loss / timeout
+	ReturnPct     float64   // 收益率（%）/ Return (%)
+	RMultiple     float64   // 以风险 R 计的收益 / Return in multiples of risk
+	BarsHeld      int       // 持有 K 线数 / Bars held
+	Source        string    // 来源：seed（历史回放）/ Source: seed (historical replay)
+	CreatedAt     time.Time
+}
+
+// Pending task statuses
+// 延迟任务状态
+const (
+	TaskStatusPending = "pending" // 等待执行 / Waiting to run
+	TaskStatusDone    = "done"    // 已完成 / Completed
+	TaskStatusFailed  = "failed"  // 已放弃 / Given up
+)
+
+// TradeIntent follows one actionable decision through risk checks and execution to the resulting position
+// TradeIntent 跟踪一条可执行决策从风控检查、下单到形成持仓的全过程
+type TradeIntent struct {
+	ID            int64
+	BatchID       string  // 所属批次 / Batch the decision came from
+	Symbol        string  // 交易对 / Trading pair
+	Action        string  // 决策动作 / Decided action
+	Reason        string  // 决策理由 / Decision reason
+	Status        string  // 当前阶段 / Current stage
+	Detail        string  // 最近一次状态变化的说明 / Explanation of the latest transition
+	OrderID       string  // 订单 ID / Order ID
+	PositionID    string  // 形成的持仓 ID / Resulting position ID
+	FilledQty     float64 // 成交数量 / Filled quantity
+	FillPrice     float64 // 成交均价 / Average fill price
+	ErrorCategory string  // 失败类别，空表示未失败 / Failure category, empty when nothing failed
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// TradeIntentEvent is one persisted status transition of a trade intent
+// TradeIntentEvent 是交易意图的一次状态变化记录
+type TradeIntentEvent struct {
+	ID        int64
+	IntentID  int64
+	Status    string
+	Detail    string
+	Timestamp time.Time
+}
+
+// Trade intent statuses, in lifecycle order
+// 交易意图状态（按生命周期顺序）
+const (
+	IntentDecided        = "decided"         // 决策已生成 / Decision made
+	IntentRejected       = "rejected"        // 风控或验证拒绝 / Rejected by validation or risk checks
+	IntentApproved       = "approved"        // 通过风控检查 / Passed validation and risk checks
+	IntentOrderFailed    = "order_failed"    // 下单失败或未成交 / Order failed or did not fill
+	IntentFilled         = "filled"          // 订单已成交 / Order filled
+	IntentPositionOpened = "position_opened" // 持仓已建立并受止损保护 / Position opened with stop-loss protection
+	IntentUnprotected    = "unprotected"     // 持仓已建立但止损单失败 / Position opened but stop-loss placement failed
+)
+
+// Error categories of failed sessions and trade intents, so a failure can be traced to its source at a glance
+// 失败会话和交易意图的错误类别，便于一眼看出失败来源
+const (
+	ErrorCategoryData     = "DATA"     // 行情数据获取或校验失败（代理、数据源）/ Market data fetch or validation failed (proxy, data source)
+	ErrorCategoryLLM      = "LLM"      // 模型调用失败或输出无法使用 / Model call failed or its output was unusable
+	ErrorCategoryExchange = "EXCHANGE" // 交易所接口报错或拒单 / Exchange API error or order rejection
+	ErrorCategoryRisk     = "RISK"     // 被风控或决策验证拒绝 / Refused by risk controls or decision validation
+	ErrorCategoryInternal = "INTERNAL" // 程序内部错误 / Internal error
+)
+
+// ErrorCategories lists every error category in display order
+// ErrorCategories 按展示顺序列出所有错误类别
+var ErrorCategories = []string{ErrorCategoryData, ErrorCategoryLLM, ErrorCategoryExchange, ErrorCategoryRisk, ErrorCategoryInternal}
+
+// Analyst report statuses recorded with each session, so outcomes can be attributed to the analysts behind a decision
+// 每个会话记录的分析师报告状态，用于将结果归因到决策背后的分析师
+const (
+	AnalystPresent  = "present"  // 报告完整 / Report complete
+	AnalystDegraded = "degraded" // 已运行但部分或全部数据获取失败 / Ran, but some or all of its data failed to load
+	AnalystAbsent   = "absent"   // 未运行（未启用或未选择）/ Did not run (disabled or not selected)
+)
+
+// ErrorCategoryCount is the number of failed sessions and trade intents in one error category
+// ErrorCategoryCount 是某个错误类别下失败的会话数和交易意图数
+type ErrorCategoryCount struct {
+	Category string `json:"category"` // 错误类别 / Error category
+	Sessions int    `json:"sessions"` // 失败会话数 / Failed sessions
+	Orders   int    `json:"orders"`   // 失败交易意图数 / Failed trade intents
+}
+
 // BatchSession represents a batch of trading sessions (all symbols from one execution)
 // BatchSession 表示一批交易会话（一次运行中所有交易对的会话）
 type BatchSession struct {
@@ -128,9 +441,12 @@ func (s *Storage) initSchema() error {
 		position_info TEXT,
 		decision TEXT,
 		full_decision TEXT,
+		action TEXT,
 		leverage INTEGER,
 		executed BOOLEAN DEFAULT 0,
-		execution_result TEXT
+		execution_result TEXT,
+		error_category TEXT,
+		analyst_status TEXT
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_symbol_created_at ON trading_sessions(symbol, created_at DESC);
@@ -159,7 +475,9 @@ func (s *Storage) initSchema() error {
 		close_time DATETIME,
 		close_price REAL,
 		close_reason TEXT,
-		realized_pnl REAL
+		realized_pnl REAL,
+		commission REAL DEFAULT 0,
+		funding_fee REAL DEFAULT 0
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_positions_symbol ON positions(symbol);
@@ -178,6 +496,21 @@ func (s *Storage) initSchema() error {
 
 	CREATE INDEX IF NOT EXISTS idx_stoploss_position ON stoploss_events(position_id, timestamp DESC);
 
+	CREATE TABLE IF NOT EXISTS take_profit_levels (
+		position_id TEXT NOT NULL,
+		level INTEGER NOT NULL,
+		risk_reward_ratio REAL NOT NULL,
+		percentage REAL NOT NULL,
+		target_price REAL NOT NULL,
+		executed BOOLEAN NOT NULL DEFAULT 0,
+		executed_time DATETIME,
+		executed_price REAL NOT NULL DEFAULT 0,
+		new_stop_loss REAL NOT NULL DEFAULT 0,
+		order_id INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (position_id, level),
+		FOREIGN KEY (position_id) REFERENCES positions(id)
+	);
+
 	CREATE TABLE IF NOT EXISTS balance_history (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		timestamp DATETIME NOT NULL,
@@ -188,6 +521,189 @@ func (s *Storage) initSchema() error {
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_balance_timestamp ON balance_history(timestamp DESC);
+
+	CREATE TABLE IF NOT EXISTS pending_tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_type TEXT NOT NULL,
+		symbol TEXT,
+		payload TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL DEFAULT 0,
+		next_run_at DATETIME NOT NULL,
+		last_error TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_pending_tasks_due ON pending_tasks(status, next_run_at);
+
+	CREATE TABLE IF NOT EXISTS trade_intents (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch_id TEXT,
+		symbol TEXT NOT NULL,
+		action TEXT NOT NULL,
+		reason TEXT,
+		status TEXT NOT NULL,
+		detail TEXT,
+		order_id TEXT,
+		position_id TEXT,
+		filled_qty REAL DEFAULT 0,
+		fill_price REAL DEFAULT 0,
+		error_category TEXT,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_trade_intents_created ON trade_intents(created_at DESC);
+
+	CREATE TABLE IF NOT EXISTS trade_intent_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		intent_id INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		detail TEXT,
+		timestamp DATETIME NOT NULL,
+		FOREIGN KEY (intent_id) REFERENCES trade_intents(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_trade_intent_events_intent ON trade_intent_events(intent_id, timestamp);
+
+	CREATE TABLE IF NOT EXISTS symbol_leases (
+		symbol TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		instance_id TEXT,
+		hostname TEXT,
+		pid INTEGER,
+		acquired_at DATETIME NOT NULL,
+		heartbeat_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS symbol_pauses (
+		symbol TEXT PRIMARY KEY,
+		reason TEXT,
+		paused_at DATETIME NOT NULL,
+		resume_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS situation_memories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		timeframe TEXT NOT NULL,
+		situation_time DATETIME NOT NULL,
+		setup TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		features TEXT NOT NULL,
+		situation TEXT,
+		outcome TEXT NOT NULL,
+		return_pct REAL,
+		r_multiple REAL,
+		bars_held INTEGER,
+		source TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(symbol, timeframe, situation_time, setup, direction)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_situation_memories_symbol ON situation_memories(symbol, timeframe);
+
+	CREATE TABLE IF NOT EXISTS execution_costs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		action TEXT NOT NULL,
+		order_id TEXT,
+		liquidity TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		decision_price REAL NOT NULL,
+		reference_price REAL,
+		fill_price REAL NOT NULL,
+		slippage_bps REAL NOT NULL,
+		slippage_cost REAL NOT NULL,
+		fee REAL NOT NULL,
+		executed_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_execution_costs_executed_at ON execution_costs(executed_at);
+
+	CREATE TABLE IF NOT EXISTS stop_slippages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		position_id TEXT NOT NULL,
+		symbol TEXT NOT NULL,
+		timeframe TEXT NOT NULL,
+		side TEXT NOT NULL,
+		order_type TEXT NOT NULL,
+		stop_distance_percent REAL NOT NULL,
+		trigger_price REAL NOT NULL,
+		fill_price REAL NOT NULL,
+		quantity REAL NOT NULL,
+		slippage_bps REAL NOT NULL,
+		slippage_cost REAL NOT NULL,
+		executed_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_stop_slippages_executed_at ON stop_slippages(executed_at);
+
+	CREATE TABLE IF NOT EXISTS analysis_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL,
+		requested_by TEXT NOT NULL,
+		status TEXT NOT NULL,
+		batch_id TEXT,
+		error TEXT,
+		requested_at DATETIME NOT NULL,
+		started_at DATETIME,
+		completed_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_analysis_requests_symbol ON analysis_requests(symbol, requested_at);
+
+	CREATE TABLE IF NOT EXISTS trading_lessons (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		symbol TEXT NOT NULL DEFAULT '',
+		lesson TEXT NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS strategy_reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		period_start DATETIME NOT NULL,
+		period_end DATETIME NOT NULL,
+		model TEXT NOT NULL,
+		trades INTEGER NOT NULL,
+		net_pnl REAL NOT NULL,
+		report TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_strategy_reviews_period_end ON strategy_reviews(period_end DESC);
+
+	CREATE TABLE IF NOT EXISTS trader_configs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		fingerprint TEXT NOT NULL UNIQUE,
+		model TEXT NOT NULL,
+		prompt TEXT NOT NULL,
+		status TEXT NOT NULL,
+		shadow_cycles INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		promoted_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS shadow_decisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		fingerprint TEXT NOT NULL,
+		cycle INTEGER NOT NULL,
+		symbol TEXT NOT NULL,
+		live_action TEXT NOT NULL,
+		shadow_action TEXT NOT NULL,
+		live_confidence REAL NOT NULL,
+		shadow_confidence REAL NOT NULL,
+		live_leverage INTEGER NOT NULL,
+		shadow_leverage INTEGER NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_shadow_decisions_fingerprint ON shadow_decisions(fingerprint, cycle);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -206,6 +722,27 @@ func (s *Storage) initSchema() error {
 	// 忽略错误，因为字段可能已经存在
 	s.db.Exec(migrationSQL)
 
+	// SQLite stops a multi-statement Exec at the first error, so later columns are added one by one
+	// SQLite 多语句执行遇到第一个错误即停止，因此后续字段逐条添加
+	for _, stmt := range []string{
+		"ALTER TABLE positions ADD COLUMN commission REAL DEFAULT 0",
+		"ALTER TABLE positions ADD COLUMN funding_fee REAL DEFAULT 0",
+		"ALTER TABLE trading_sessions ADD COLUMN action TEXT",
+		"ALTER TABLE trading_sessions ADD COLUMN error_category TEXT",
+		"ALTER TABLE trade_intents ADD COLUMN error_category TEXT",
+		"ALTER TABLE take_profit_levels ADD COLUMN order_id INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE trading_sessions ADD COLUMN analyst_status TEXT",
+	} {
+		s.db.Exec(stmt)
+	}
+
+	// Backfill the action of older sessions from their formatted decision text
+	// 从格式化的决策文本中回填旧会话的决策动作
+	for _, action := range []string{"BUY", "SELL", "HOLD", "CLOSE_LONG", "CLOSE_SHORT"} {
+		s.db.Exec(`UPDATE trading_sessions SET action = ? WHERE action IS NULL AND decision LIKE ?`,
+			action, "%**交易方向**: "+action+"%")
+	}
+
 	return nil
 }
 
@@ -215,8 +752,9 @@ func (s *Storage) SaveSession(session *TradingSession) (int64, error) {
 	INSERT INTO trading_sessions (
 		batch_id, symbol, timeframe, created_at,
 		market_report, crypto_report, sentiment_report,
-		position_info, decision, full_decision, executed, execution_result
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		position_info, decision, full_decision, action, executed, execution_result, error_category,
+		analyst_status
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(
@@ -231,8 +769,11 @@ func (s *Storage) SaveSession(session *TradingSession) (int64, error) {
 		session.PositionInfo,
 		session.Decision,
 		session.FullDecision,
+		session.Action,
 		session.Executed,
 		session.ExecutionResult,
+		session.ErrorCategory,
+		formatAnalystStatus(session.AnalystStatus),
 	)
 
 	if err != nil {
@@ -291,13 +832,99 @@ func (s *Storage) GetLatestSessions(limit int) ([]*TradingSession, error) {
 	return sessions, rows.Err()
 }
 
+// QuerySessions returns one page of sessions matching q and the cursor of the next page (0 when there is none)
+// QuerySessions 返回符合 q 的一页会话以及下一页的游标（没有下一页时为 0）
+func (s *Storage) QuerySessions(q SessionQuery) ([]*TradingSession, int64, error) {
+	var conditions []string
+	var args []interface{}
+	if q.Symbol != "" {
+		conditions = append(conditions, "REPLACE(symbol, '/', '') = ?")
+		args = append(args, strings.ToUpper(strings.ReplaceAll(q.Symbol, "/", "")))
+	}
+	if q.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, strings.ToUpper(q.Action))
+	}
+	if q.Executed != nil {
+		conditions = append(conditions, "executed = ?")
+		args = append(args, *q.Executed)
+	}
+	// created_at is stored as local time text, so bounds are compared in local time too
+	// created_at 以本地时间文本存储，因此边界也按本地时间比较
+	if !q.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, q.From.Local())
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, q.To.Local())
+	}
+	if q.Cursor > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, q.Cursor)
+	}
+
+	query := `
+	SELECT id, batch_id, symbol, timeframe, created_at,
+		   market_report, crypto_report, sentiment_report,
+		   position_info, decision, full_decision, COALESCE(action, ''), executed, execution_result
+	FROM trading_sessions`
+	if len(conditions) > 0 {
+		query += "\n\tWHERE " + strings.Join(conditions, " AND ")
+	}
+	// IDs grow with creation time, so ordering by ID keeps the cursor stable
+	// ID 随创建时间递增，按 ID 排序使游标保持稳定
+	query += "\n\tORDER BY id DESC\n\tLIMIT ?"
+	args = append(args, q.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*TradingSession
+	for rows.Next() {
+		session := &TradingSession{}
+		err := rows.Scan(
+			&session.ID,
+			&session.BatchID,
+			&session.Symbol,
+			&session.Timeframe,
+			&session.CreatedAt,
+			&session.MarketReport,
+			&session.CryptoReport,
+			&session.SentimentReport,
+			&session.PositionInfo,
+			&session.Decision,
+			&session.FullDecision,
+			&session.Action,
+			&session.Executed,
+			&session.ExecutionResult,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var nextCursor int64
+	if q.Limit > 0 && len(sessions) == q.Limit {
+		nextCursor = sessions[len(sessions)-1].ID
+	}
+	return sessions, nextCursor, nil
+}
+
 // GetSessionByID retrieves a session by its ID
 // GetSessionByID 根据 ID 获取会话
 func (s *Storage) GetSessionByID(id int64) (*TradingSession, error) {
 	query := `
 	SELECT id, batch_id, symbol, timeframe, created_at,
 		   market_report, crypto_report, sentiment_report,
-		   position_info, decision, full_decision, executed, execution_result
+		   position_info, decision, full_decision, COALESCE(action, ''), executed, execution_result
 	FROM trading_sessions
 	WHERE id = ?
 	`
@@ -315,6 +942,7 @@ func (s *Storage) GetSessionByID(id int64) (*TradingSession, error) {
 		&session.PositionInfo,
 		&session.Decision,
 		&session.FullDecision,
+		&session.Action,
 		&session.Executed,
 		&session.ExecutionResult,
 	)
@@ -500,9 +1128,118 @@ func (s *Storage) GetSessionStats(symbol string) (map[string]interface{}, error)
 		stats["execution_rate"] = float64(executedCount) / float64(totalSessions) * 100
 	}
 
+	errorCounts, err := s.GetErrorCategoryCounts(symbol)
+	if err != nil {
+		return nil, err
+	}
+	stats["error_categories"] = errorCounts
+
 	return stats, nil
 }
 
+// GetEntrySessions retrieves the BUY/SELL sessions created at or after since, oldest first
+// GetEntrySessions 获取 since 之后创建的 BUY/SELL 会话，按创建时间升序
+// Only the fields needed to attribute outcomes are loaded
+// 只加载结果归因所需的字段
+func (s *Storage) GetEntrySessions(since time.Time) ([]*TradingSession, error) {
+	query := `
+	SELECT id, symbol, created_at, action, COALESCE(analyst_status, '')
+	FROM trading_sessions
+	WHERE action IN ('BUY', 'SELL') AND created_at >= ?
+	ORDER BY created_at ASC
+	`
+
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query entry sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*TradingSession
+	for rows.Next() {
+		session := &TradingSession{}
+		var status string
+		if err := rows.Scan(&session.ID, &session.Symbol, &session.CreatedAt, &session.Action, &status); err != nil {
+			return nil, fmt.Errorf("failed to scan entry session: %w", err)
+		}
+		session.AnalystStatus = parseAnalystStatus(status)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// formatAnalystStatus encodes analyst statuses as "crypto:present,sentiment:absent", sorted by analyst
+// formatAnalystStatus 将分析师状态编码为 "crypto:present,sentiment:absent"，按分析师排序
+func formatAnalystStatus(status map[string]string) string {
+	parts := make([]string, 0, len(status))
+	for analyst, s := range status {
+		parts = append(parts, analyst+":"+s)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+// parseAnalystStatus decodes the format written by formatAnalystStatus; sessions saved before it return nil
+// parseAnalystStatus 解码 formatAnalystStatus 写入的格式；此前保存的会话返回 nil
+func parseAnalystStatus(value string) map[string]string {
+	if value == "" {
+		return nil
+	}
+	status := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		if analyst, s, ok := strings.Cut(entry, ":"); ok {
+			status[analyst] = s
+		}
+	}
+	return status
+}
+
+// GetErrorCategoryCounts counts the failed sessions and trade intents of a symbol per error category, in display order
+// An empty symbol counts every symbol
+// GetErrorCategoryCounts 按错误类别统计某个交易对失败的会话数和交易意图数，按展示顺序返回；symbol 为空时统计所有交易对
+func (s *Storage) GetErrorCategoryCounts(symbol string) ([]ErrorCategoryCount, error) {
+	counts := make([]ErrorCategoryCount, len(ErrorCategories))
+	index := make(map[string]int, len(ErrorCategories))
+	for i, category := range ErrorCategories {
+		counts[i].Category = category
+		index[category] = i
+	}
+
+	for _, table := range []string{"trading_sessions", "trade_intents"} {
+		rows, err := s.db.Query(`
+		SELECT error_category, COUNT(*) FROM `+table+`
+		WHERE (? = '' OR REPLACE(symbol, '/', '') = REPLACE(?, '/', '')) AND error_category IS NOT NULL AND error_category != ''
+		GROUP BY error_category
+		`, symbol, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count error categories: %w", err)
+		}
+		for rows.Next() {
+			var category string
+			var n int
+			if err := rows.Scan(&category, &n); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan error category count: %w", err)
+			}
+			i, ok := index[category]
+			if !ok {
+				continue
+			}
+			if table == "trading_sessions" {
+				counts[i].Sessions = n
+			} else {
+				counts[i].Orders = n
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count error categories: %w", err)
+		}
+	}
+	return counts, nil
+}
+
 // UpdateExecutionResult updates the execution result for a session
 func (s *Storage) UpdateExecutionResult(sessionID int64, executed bool, result string) error {
 	query := `
@@ -542,6 +1279,26 @@ func (s *Storage) UpdateLatestSessionExecution(symbol string, timeframe string,
 	return nil
 }
 
+// UpdateLatestSessionErrorCategory tags the latest session of a symbol with the category of its failure
+// UpdateLatestSessionErrorCategory 为某个交易对最新的会话标记失败类别
+func (s *Storage) UpdateLatestSessionErrorCategory(symbol string, timeframe string, category string) error {
+	query := `
+	UPDATE trading_sessions
+	SET error_category = ?
+	WHERE id = (
+		SELECT id FROM trading_sessions
+		WHERE symbol = ? AND timeframe = ?
+		ORDER BY created_at DESC
+		LIMIT 1
+	)
+	`
+
+	if _, err := s.db.Exec(query, category, symbol, timeframe); err != nil {
+		return fmt.Errorf("failed to update latest session error category: %w", err)
+	}
+	return nil
+}
+
 // SaveBalanceHistory saves account balance snapshot to history
 // SaveBalanceHistory 保存账户余额快照到历史记录
 func (s *Storage) SaveBalanceHistory(balance *BalanceHistory) error {
@@ -654,7 +1411,9 @@ func (s *Storage) UpdatePosition(pos *PositionRecord) error {
 		close_time = ?,
 		close_price = ?,
 		close_reason = ?,
-		realized_pnl = ?
+		realized_pnl = ?,
+		commission = ?,
+		funding_fee = ?
 	WHERE id = ?
 	`
 
@@ -664,6 +1423,7 @@ func (s *Storage) UpdatePosition(pos *PositionRecord) error {
 		pos.HighestPrice, pos.CurrentPrice, pos.UnrealizedPnL,
 		pos.StopLossOrderID,
 		pos.Closed, pos.CloseTime, pos.ClosePrice, pos.CloseReason, pos.RealizedPnL,
+		pos.Commission, pos.FundingFee,
 		pos.ID,
 	)
 
@@ -682,7 +1442,7 @@ func (s *Storage) GetActivePositions() ([]*PositionRecord, error) {
 		   initial_stop_loss, current_stop_loss, stop_loss_type,
 		   trailing_distance, highest_price, current_price,
 		   unrealized_pnl, open_reason, atr, stop_loss_order_id, closed,
-		   close_time, close_price, close_reason, realized_pnl
+		   close_time, close_price, close_reason, realized_pnl, commission, funding_fee
 	FROM positions
 	WHERE closed = 0
 	ORDER BY entry_time DESC
@@ -697,7 +1457,7 @@ func (s *Storage) GetActivePositions() ([]*PositionRecord, error) {
 	var positions []*PositionRecord
 	for rows.Next() {
 		pos := &PositionRecord{}
-		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL sql.NullFloat64
+		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL, commission, fundingFee sql.NullFloat64
 		var closeTime sql.NullTime
 		var closeReason, stopLossOrderID sql.NullString
 
@@ -706,7 +1466,7 @@ func (s *Storage) GetActivePositions() ([]*PositionRecord, error) {
 			&pos.InitialStopLoss, &pos.CurrentStopLoss, &pos.StopLossType,
 			&trailingDistance, &pos.HighestPrice, &pos.CurrentPrice,
 			&unrealizedPnL, &pos.OpenReason, &atr, &stopLossOrderID, &pos.Closed,
-			&closeTime, &closePrice, &closeReason, &realizedPnL,
+			&closeTime, &closePrice, &closeReason, &realizedPnL, &commission, &fundingFee,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan position: %w", err)
@@ -738,6 +1498,8 @@ func (s *Storage) GetActivePositions() ([]*PositionRecord, error) {
 		if realizedPnL.Valid {
 			pos.RealizedPnL = realizedPnL.Float64
 		}
+		pos.Commission = commission.Float64
+		pos.FundingFee = fundingFee.Float64
 
 		positions = append(positions, pos)
 	}
@@ -745,31 +1507,36 @@ func (s *Storage) GetActivePositions() ([]*PositionRecord, error) {
 	return positions, rows.Err()
 }
 
-// GetPositionsBySymbol retrieves positions for a specific symbol
-// GetPositionsBySymbol 获取特定交易对的持仓
-func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error) {
+// GetClosedPositions retrieves positions closed at or after since, oldest first
+// GetClosedPositions 获取在 since 之后平仓的持仓，按平仓时间升序
+func (s *Storage) GetClosedPositions(since time.Time) ([]*PositionRecord, error) {
 	query := `
 	SELECT id, symbol, side, entry_price, entry_time, quantity, leverage,
 		   initial_stop_loss, current_stop_loss, stop_loss_type,
 		   trailing_distance, highest_price, current_price,
 		   unrealized_pnl, open_reason, atr, stop_loss_order_id, closed,
-		   close_time, close_price, close_reason, realized_pnl
+		   close_time, close_price, close_reason, realized_pnl, commission, funding_fee
 	FROM positions
-	WHERE symbol = ?
-	ORDER BY entry_time DESC
-	LIMIT 20
+	WHERE closed = 1 AND close_time >= ?
+	ORDER BY close_time ASC
 	`
 
-	rows, err := s.db.Query(query, symbol)
+	rows, err := s.db.Query(query, since)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query positions: %w", err)
+		return nil, fmt.Errorf("failed to query closed positions: %w", err)
 	}
 	defer rows.Close()
 
+	return scanPositionRecords(rows)
+}
+
+// scanPositionRecords scans position rows selected with the standard column list
+// scanPositionRecords 扫描按标准列顺序查询出的持仓行
+func scanPositionRecords(rows *sql.Rows) ([]*PositionRecord, error) {
 	var positions []*PositionRecord
 	for rows.Next() {
 		pos := &PositionRecord{}
-		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL sql.NullFloat64
+		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL, commission, fundingFee sql.NullFloat64
 		var closeTime sql.NullTime
 		var closeReason, stopLossOrderID sql.NullString
 
@@ -778,22 +1545,80 @@ func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error)
 			&pos.InitialStopLoss, &pos.CurrentStopLoss, &pos.StopLossType,
 			&trailingDistance, &pos.HighestPrice, &pos.CurrentPrice,
 			&unrealizedPnL, &pos.OpenReason, &atr, &stopLossOrderID, &pos.Closed,
-			&closeTime, &closePrice, &closeReason, &realizedPnL,
+			&closeTime, &closePrice, &closeReason, &realizedPnL, &commission, &fundingFee,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan position: %w", err)
 		}
 
-		// Handle NULL values
-		// 处理 NULL 值
-		if trailingDistance.Valid {
-			pos.TrailingDistance = trailingDistance.Float64
-		}
-		if unrealizedPnL.Valid {
-			pos.UnrealizedPnL = unrealizedPnL.Float64
-		}
-		if atr.Valid {
-			pos.ATR = atr.Float64
+		pos.TrailingDistance = trailingDistance.Float64
+		pos.UnrealizedPnL = unrealizedPnL.Float64
+		pos.ATR = atr.Float64
+		pos.StopLossOrderID = stopLossOrderID.String
+		if closeTime.Valid {
+			pos.CloseTime = &closeTime.Time
+		}
+		pos.ClosePrice = closePrice.Float64
+		pos.CloseReason = closeReason.String
+		pos.RealizedPnL = realizedPnL.Float64
+		pos.Commission = commission.Float64
+		pos.FundingFee = fundingFee.Float64
+
+		positions = append(positions, pos)
+	}
+
+	return positions, rows.Err()
+}
+
+// GetPositionsBySymbol retrieves positions for a specific symbol
+// GetPositionsBySymbol 获取特定交易对的持仓
+func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error) {
+	query := `
+	SELECT id, symbol, side, entry_price, entry_time, quantity, leverage,
+		   initial_stop_loss, current_stop_loss, stop_loss_type,
+		   trailing_distance, highest_price, current_price,
+		   unrealized_pnl, open_reason, atr, stop_loss_order_id, closed,
+		   close_time, close_price, close_reason, realized_pnl, commission, funding_fee
+	FROM positions
+	WHERE symbol = ?
+	ORDER BY entry_time DESC
+	LIMIT 20
+	`
+
+	rows, err := s.db.Query(query, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []*PositionRecord
+	for rows.Next() {
+		pos := &PositionRecord{}
+		var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL, commission, fundingFee sql.NullFloat64
+		var closeTime sql.NullTime
+		var closeReason, stopLossOrderID sql.NullString
+
+		err := rows.Scan(
+			&pos.ID, &pos.Symbol, &pos.Side, &pos.EntryPrice, &pos.EntryTime, &pos.Quantity, &pos.Leverage,
+			&pos.InitialStopLoss, &pos.CurrentStopLoss, &pos.StopLossType,
+			&trailingDistance, &pos.HighestPrice, &pos.CurrentPrice,
+			&unrealizedPnL, &pos.OpenReason, &atr, &stopLossOrderID, &pos.Closed,
+			&closeTime, &closePrice, &closeReason, &realizedPnL, &commission, &fundingFee,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position: %w", err)
+		}
+
+		// Handle NULL values
+		// 处理 NULL 值
+		if trailingDistance.Valid {
+			pos.TrailingDistance = trailingDistance.Float64
+		}
+		if unrealizedPnL.Valid {
+			pos.UnrealizedPnL = unrealizedPnL.Float64
+		}
+		if atr.Valid {
+			pos.ATR = atr.Float64
 		}
 		if stopLossOrderID.Valid {
 			pos.StopLossOrderID = stopLossOrderID.String
@@ -810,6 +1635,8 @@ func (s *Storage) GetPositionsBySymbol(symbol string) ([]*PositionRecord, error)
 		if realizedPnL.Valid {
 			pos.RealizedPnL = realizedPnL.Float64
 		}
+		pos.Commission = commission.Float64
+		pos.FundingFee = fundingFee.Float64
 
 		positions = append(positions, pos)
 	}
@@ -825,7 +1652,7 @@ func (s *Storage) GetPositionByID(positionID string) (*PositionRecord, error) {
 		   initial_stop_loss, current_stop_loss, stop_loss_type,
 		   trailing_distance, highest_price, current_price,
 		   unrealized_pnl, open_reason, atr, stop_loss_order_id, closed,
-		   close_time, close_price, close_reason, realized_pnl
+		   close_time, close_price, close_reason, realized_pnl, commission, funding_fee
 	FROM positions
 	WHERE id = ?
 	LIMIT 1
@@ -834,7 +1661,7 @@ func (s *Storage) GetPositionByID(positionID string) (*PositionRecord, error) {
 	row := s.db.QueryRow(query, positionID)
 
 	pos := &PositionRecord{}
-	var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL sql.NullFloat64
+	var trailingDistance, unrealizedPnL, atr, closePrice, realizedPnL, commission, fundingFee sql.NullFloat64
 	var closeTime sql.NullTime
 	var closeReason, stopLossOrderID sql.NullString
 
@@ -843,7 +1670,7 @@ func (s *Storage) GetPositionByID(positionID string) (*PositionRecord, error) {
 		&pos.InitialStopLoss, &pos.CurrentStopLoss, &pos.StopLossType,
 		&trailingDistance, &pos.HighestPrice, &pos.CurrentPrice,
 		&unrealizedPnL, &pos.OpenReason, &atr, &stopLossOrderID, &pos.Closed,
-		&closeTime, &closePrice, &closeReason, &realizedPnL,
+		&closeTime, &closePrice, &closeReason, &realizedPnL, &commission, &fundingFee,
 	)
 
 	if err == sql.ErrNoRows {
@@ -879,6 +1706,8 @@ func (s *Storage) GetPositionByID(positionID string) (*PositionRecord, error) {
 	if realizedPnL.Valid {
 		pos.RealizedPnL = realizedPnL.Float64
 	}
+	pos.Commission = commission.Float64
+	pos.FundingFee = fundingFee.Float64
 
 	return pos, nil
 }
@@ -937,6 +1766,1009 @@ func (s *Storage) GetStopLossEvents(positionID string) ([]*StopLossEvent, error)
 	return events, rows.Err()
 }
 
+// SaveTakeProfitLevels replaces the stored take-profit levels of a position
+// SaveTakeProfitLevels 替换持仓已保存的分批止盈级别
+func (s *Storage) SaveTakeProfitLevels(positionID string, levels []*TakeProfitLevelRecord) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM take_profit_levels WHERE position_id = ?`, positionID); err != nil {
+		return fmt.Errorf("failed to clear take-profit levels: %w", err)
+	}
+	for _, l := range levels {
+		_, err := tx.Exec(`
+		INSERT INTO take_profit_levels (
+			position_id, level, risk_reward_ratio, percentage, target_price,
+			executed, executed_time, executed_price, new_stop_loss, order_id
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			positionID, l.Level, l.RiskRewardRatio, l.Percentage, l.TargetPrice,
+			l.Executed, l.ExecutedTime, l.ExecutedPrice, l.NewStopLoss, l.OrderID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save take-profit level %d: %w", l.Level, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit take-profit levels: %w", err)
+	}
+	return nil
+}
+
+// GetTakeProfitLevels retrieves the stored take-profit levels of a position, in level order
+// GetTakeProfitLevels 按级别顺序获取持仓已保存的分批止盈级别
+func (s *Storage) GetTakeProfitLevels(positionID string) ([]*TakeProfitLevelRecord, error) {
+	rows, err := s.db.Query(`
+	SELECT position_id, level, risk_reward_ratio, percentage, target_price,
+		executed, executed_time, executed_price, new_stop_loss, order_id
+	FROM take_profit_levels
+	WHERE position_id = ?
+	ORDER BY level ASC
+	`, positionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query take-profit levels: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []*TakeProfitLevelRecord
+	for rows.Next() {
+		l := &TakeProfitLevelRecord{}
+		var executedTime sql.NullTime
+		err := rows.Scan(
+			&l.PositionID, &l.Level, &l.RiskRewardRatio, &l.Percentage, &l.TargetPrice,
+			&l.Executed, &executedTime, &l.ExecutedPrice, &l.NewStopLoss, &l.OrderID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan take-profit level: %w", err)
+		}
+		if executedTime.Valid {
+			l.ExecutedTime = &executedTime.Time
+		}
+		levels = append(levels, l)
+	}
+
+	return levels, rows.Err()
+}
+
+// SavePendingTask inserts a new deferred task and returns its ID
+// SavePendingTask 插入新的延迟任务并返回其 ID
+func (s *Storage) SavePendingTask(task *PendingTask) (int64, error) {
+	query := `
+	INSERT INTO pending_tasks (
+		task_type, symbol, payload, status, attempts, max_attempts,
+		next_run_at, last_error, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	if task.Status == "" {
+		task.Status = TaskStatusPending
+	}
+	if task.NextRunAt.IsZero() {
+		task.NextRunAt = now
+	}
+
+	result, err := s.db.Exec(
+		query,
+		task.TaskType, task.Symbol, task.Payload, task.Status, task.Attempts, task.MaxAttempts,
+		task.NextRunAt, task.LastError, now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save pending task: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pending task ID: %w", err)
+	}
+	task.ID = id
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	return id, nil
+}
+
+// GetDuePendingTasks retrieves pending tasks whose next run time has passed
+// GetDuePendingTasks 获取已到执行时间的待处理任务
+func (s *Storage) GetDuePendingTasks(now time.Time, limit int) ([]*PendingTask, error) {
+	query := `
+	SELECT id, task_type, symbol, payload, status, attempts, max_attempts,
+		next_run_at, last_error, created_at, updated_at
+	FROM pending_tasks
+	WHERE status = ? AND next_run_at <= ?
+	ORDER BY next_run_at ASC
+	LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, TaskStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPendingTasks(rows)
+}
+
+// GetPendingTasks retrieves tasks that are still waiting to be retried
+// GetPendingTasks 获取仍在等待重试的任务
+func (s *Storage) GetPendingTasks() ([]*PendingTask, error) {
+	query := `
+	SELECT id, task_type, symbol, payload, status, attempts, max_attempts,
+		next_run_at, last_error, created_at, updated_at
+	FROM pending_tasks
+	WHERE status = ?
+	ORDER BY next_run_at ASC
+	`
+
+	rows, err := s.db.Query(query, TaskStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending tasks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPendingTasks(rows)
+}
+
+// UpdatePendingTask updates status, attempt count and schedule of a task
+// UpdatePendingTask 更新任务的状态、尝试次数和下次执行时间
+func (s *Storage) UpdatePendingTask(task *PendingTask) error {
+	query := `
+	UPDATE pending_tasks SET
+		status = ?,
+		attempts = ?,
+		next_run_at = ?,
+		last_error = ?,
+		updated_at = ?
+	WHERE id = ?
+	`
+
+	task.UpdatedAt = time.Now()
+	_, err := s.db.Exec(
+		query,
+		task.Status, task.Attempts, task.NextRunAt, task.LastError, task.UpdatedAt, task.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update pending task: %w", err)
+	}
+
+	return nil
+}
+
+// scanPendingTasks scans pending task rows
+// scanPendingTasks 扫描延迟任务查询结果
+func scanPendingTasks(rows *sql.Rows) ([]*PendingTask, error) {
+	var tasks []*PendingTask
+	for rows.Next() {
+		task := &PendingTask{}
+		var symbol, payload, lastError sql.NullString
+		err := rows.Scan(
+			&task.ID, &task.TaskType, &symbol, &payload, &task.Status, &task.Attempts, &task.MaxAttempts,
+			&task.NextRunAt, &lastError, &task.CreatedAt, &task.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pending task: %w", err)
+		}
+		task.Symbol = symbol.String
+		task.Payload = payload.String
+		task.LastError = lastError.String
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// SaveTradeIntent inserts a new trade intent together with its first status event
+// SaveTradeIntent 插入新的交易意图及其第一条状态事件
+func (s *Storage) SaveTradeIntent(intent *TradeIntent) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`
+	INSERT INTO trade_intents (
+		batch_id, symbol, action, reason, status, detail, order_id, position_id,
+		filled_qty, fill_price, error_category, created_at, updated_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		intent.BatchID, intent.Symbol, intent.Action, intent.Reason, intent.Status, intent.Detail,
+		intent.OrderID, intent.PositionID, intent.FilledQty, intent.FillPrice, intent.ErrorCategory, now, now,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save trade intent: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trade intent ID: %w", err)
+	}
+	if err := insertTradeIntentEvent(tx, id, intent.Status, intent.Detail, now); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit trade intent: %w", err)
+	}
+
+	intent.ID = id
+	intent.CreatedAt = now
+	intent.UpdatedAt = now
+	return id, nil
+}
+
+// UpdateTradeIntent persists the intent's current fields and records its status as a new event
+// UpdateTradeIntent 保存交易意图的当前字段，并将其状态记录为一条新事件
+func (s *Storage) UpdateTradeIntent(intent *TradeIntent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	intent.UpdatedAt = time.Now()
+	_, err = tx.Exec(`
+	UPDATE trade_intents SET
+		status = ?,
+		detail = ?,
+		order_id = ?,
+		position_id = ?,
+		filled_qty = ?,
+		fill_price = ?,
+		error_category = ?,
+		updated_at = ?
+	WHERE id = ?
+	`,
+		intent.Status, intent.Detail, intent.OrderID, intent.PositionID,
+		intent.FilledQty, intent.FillPrice, intent.ErrorCategory, intent.UpdatedAt, intent.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update trade intent: %w", err)
+	}
+	if err := insertTradeIntentEvent(tx, intent.ID, intent.Status, intent.Detail, intent.UpdatedAt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trade intent: %w", err)
+	}
+	return nil
+}
+
+func insertTradeIntentEvent(tx *sql.Tx, intentID int64, status, detail string, at time.Time) error {
+	_, err := tx.Exec(`
+	INSERT INTO trade_intent_events (intent_id, status, detail, timestamp) VALUES (?, ?, ?, ?)
+	`, intentID, status, detail, at)
+	if err != nil {
+		return fmt.Errorf("failed to save trade intent event: %w", err)
+	}
+	return nil
+}
+
+// GetTradeIntents retrieves the most recent trade intents, newest first
+// GetTradeIntents 获取最近的交易意图，按时间倒序
+func (s *Storage) GetTradeIntents(limit int) ([]*TradeIntent, error) {
+	query := `
+	SELECT id, batch_id, symbol, action, reason, status, detail, order_id, position_id,
+		filled_qty, fill_price, error_category, created_at, updated_at
+	FROM trade_intents
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trade intents: %w", err)
+	}
+	defer rows.Close()
+
+	var intents []*TradeIntent
+	for rows.Next() {
+		intent := &TradeIntent{}
+		var batchID, reason, detail, orderID, positionID, errorCategory sql.NullString
+		err := rows.Scan(
+			&intent.ID, &batchID, &intent.Symbol, &intent.Action, &reason, &intent.Status, &detail,
+			&orderID, &positionID, &intent.FilledQty, &intent.FillPrice, &errorCategory, &intent.CreatedAt, &intent.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trade intent: %w", err)
+		}
+		intent.BatchID = batchID.String
+		intent.Reason = reason.String
+		intent.Detail = detail.String
+		intent.OrderID = orderID.String
+		intent.PositionID = positionID.String
+		intent.ErrorCategory = errorCategory.String
+		intents = append(intents, intent)
+	}
+
+	return intents, rows.Err()
+}
+
+// GetTradeIntentEvents retrieves the status transitions of a trade intent in order
+// GetTradeIntentEvents 按顺序获取交易意图的状态变化记录
+func (s *Storage) GetTradeIntentEvents(intentID int64) ([]*TradeIntentEvent, error) {
+	query := `
+	SELECT id, intent_id, status, detail, timestamp
+	FROM trade_intent_events
+	WHERE intent_id = ?
+	ORDER BY timestamp ASC, id ASC
+	`
+
+	rows, err := s.db.Query(query, intentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trade intent events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*TradeIntentEvent
+	for rows.Next() {
+		event := &TradeIntentEvent{}
+		var detail sql.NullString
+		if err := rows.Scan(&event.ID, &event.IntentID, &event.Status, &detail, &event.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan trade intent event: %w", err)
+		}
+		event.Detail = detail.String
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// AcquireSymbolLease takes or renews the lease on a symbol and returns the current holder
+// AcquireSymbolLease 获取或续约交易对租约，并返回当前持有者
+// The lease is granted when it is free, already held by the same owner, or its heartbeat is older than ttl.
+// The caller holds the lease if the returned Owner equals lease.Owner.
+// 租约空闲、已由同一持有者持有、或心跳超过 ttl 未更新时授予；返回的 Owner 等于 lease.Owner 即表示获取成功。
+func (s *Storage) AcquireSymbolLease(lease *SymbolLease, ttl time.Duration) (*SymbolLease, error) {
+	// A single upsert keeps the check-and-set atomic across processes sharing the database
+	// 单条 upsert 语句保证多个进程共享数据库时检查与写入的原子性
+	query := `
+	INSERT INTO symbol_leases (symbol, owner, instance_id, hostname, pid, acquired_at, heartbeat_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(symbol) DO UPDATE SET
+		owner = excluded.owner,
+		instance_id = excluded.instance_id,
+		hostname = excluded.hostname,
+		pid = excluded.pid,
+		acquired_at = CASE WHEN symbol_leases.owner = excluded.owner THEN symbol_leases.acquired_at ELSE excluded.acquired_at END,
+		heartbeat_at = excluded.heartbeat_at
+	WHERE symbol_leases.owner = excluded.owner OR symbol_leases.heartbeat_at < ?
+	`
+
+	now := time.Now()
+	_, err := s.db.Exec(
+		query,
+		lease.Symbol, lease.Owner, lease.InstanceID, lease.Hostname, lease.PID, now, now,
+		now.Add(-ttl),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire symbol lease: %w", err)
+	}
+
+	return s.GetSymbolLease(lease.Symbol)
+}
+
+// GetSymbolLease returns the lease on a symbol, or nil if nobody holds it
+// GetSymbolLease 返回交易对的租约，无人持有时返回 nil
+func (s *Storage) GetSymbolLease(symbol string) (*SymbolLease, error) {
+	query := `
+	SELECT symbol, owner, instance_id, hostname, pid, acquired_at, heartbeat_at
+	FROM symbol_leases
+	WHERE symbol = ?
+	`
+
+	lease := &SymbolLease{}
+	var instanceID, hostname sql.NullString
+	var pid sql.NullInt64
+	err := s.db.QueryRow(query, symbol).Scan(
+		&lease.Symbol, &lease.Owner, &instanceID, &hostname, &pid, &lease.AcquiredAt, &lease.HeartbeatAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get symbol lease: %w", err)
+	}
+	lease.InstanceID = instanceID.String
+	lease.Hostname = hostname.String
+	lease.PID = int(pid.Int64)
+
+	return lease, nil
+}
+
+// ReleaseSymbolLease drops the lease on one symbol if it is held by owner
+// ReleaseSymbolLease 释放某个交易对的租约（仅当由 owner 持有时）
+func (s *Storage) ReleaseSymbolLease(symbol, owner string) error {
+	if _, err := s.db.Exec(`DELETE FROM symbol_leases WHERE symbol = ? AND owner = ?`, symbol, owner); err != nil {
+		return fmt.Errorf("failed to release symbol lease: %w", err)
+	}
+	return nil
+}
+
+// ReleaseSymbolLeases drops every lease held by an owner
+// ReleaseSymbolLeases 释放某个持有者的所有租约
+func (s *Storage) ReleaseSymbolLeases(owner string) error {
+	if _, err := s.db.Exec(`DELETE FROM symbol_leases WHERE owner = ?`, owner); err != nil {
+		return fmt.Errorf("failed to release symbol leases: %w", err)
+	}
+	return nil
+}
+
+// SaveSymbolPause pauses a symbol, replacing any existing pause on it
+// SaveSymbolPause 暂停交易对，覆盖该交易对已有的暂停
+func (s *Storage) SaveSymbolPause(pause *SymbolPause) error {
+	query := `
+	INSERT INTO symbol_pauses (symbol, reason, paused_at, resume_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(symbol) DO UPDATE SET
+		reason = excluded.reason,
+		paused_at = excluded.paused_at,
+		resume_at = excluded.resume_at
+	`
+	if _, err := s.db.Exec(query, pause.Symbol, pause.Reason, pause.PausedAt, pause.ResumeAt); err != nil {
+		return fmt.Errorf("failed to save symbol pause: %w", err)
+	}
+	return nil
+}
+
+// DeleteSymbolPause resumes a symbol; resuming a symbol that is not paused is not an error
+// DeleteSymbolPause 恢复交易对；恢复未暂停的交易对不视为错误
+func (s *Storage) DeleteSymbolPause(symbol string) error {
+	if _, err := s.db.Exec(`DELETE FROM symbol_pauses WHERE symbol = ?`, symbol); err != nil {
+		return fmt.Errorf("failed to delete symbol pause: %w", err)
+	}
+	return nil
+}
+
+// GetSymbolPauses returns every stored pause, including those whose resume time has passed
+// GetSymbolPauses 返回所有已保存的暂停，包括已过恢复时间的暂停
+func (s *Storage) GetSymbolPauses() ([]*SymbolPause, error) {
+	rows, err := s.db.Query(`SELECT symbol, reason, paused_at, resume_at FROM symbol_pauses ORDER BY symbol`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query symbol pauses: %w", err)
+	}
+	defer rows.Close()
+
+	var pauses []*SymbolPause
+	for rows.Next() {
+		pause := &SymbolPause{}
+		var reason sql.NullString
+		var resumeAt sql.NullTime
+		if err := rows.Scan(&pause.Symbol, &reason, &pause.PausedAt, &resumeAt); err != nil {
+			return nil, fmt.Errorf("failed to scan symbol pause: %w", err)
+		}
+		pause.Reason = reason.String
+		if resumeAt.Valid {
+			t := resumeAt.Time
+			pause.ResumeAt = &t
+		}
+		pauses = append(pauses, pause)
+	}
+	return pauses, rows.Err()
+}
+
+// SaveExecutionCost stores the execution cost of one order
+// SaveExecutionCost 保存一笔订单的执行成本
+func (s *Storage) SaveExecutionCost(cost *ExecutionCost) (int64, error) {
+	query := `
+	INSERT INTO execution_costs (
+		symbol, action, order_id, liquidity, quantity, decision_price, reference_price,
+		fill_price, slippage_bps, slippage_cost, fee, executed_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, cost.Symbol, cost.Action, cost.OrderID, cost.Liquidity, cost.Quantity,
+		cost.DecisionPrice, cost.ReferencePrice, cost.FillPrice, cost.SlippageBps, cost.SlippageCost, cost.Fee, cost.ExecutedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save execution cost: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetExecutionCosts returns the execution costs of orders executed since the given time, oldest first
+// GetExecutionCosts 返回指定时间之后执行的订单成本，按时间升序
+func (s *Storage) GetExecutionCosts(since time.Time) ([]*ExecutionCost, error) {
+	query := `
+	SELECT id, symbol, action, order_id, liquidity, quantity, decision_price, reference_price,
+		fill_price, slippage_bps, slippage_cost, fee, executed_at
+	FROM execution_costs
+	WHERE executed_at >= ?
+	ORDER BY executed_at ASC
+	`
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query execution costs: %w", err)
+	}
+	defer rows.Close()
+
+	var costs []*ExecutionCost
+	for rows.Next() {
+		cost := &ExecutionCost{}
+		var orderID sql.NullString
+		var referencePrice sql.NullFloat64
+		if err := rows.Scan(&cost.ID, &cost.Symbol, &cost.Action, &orderID, &cost.Liquidity, &cost.Quantity,
+			&cost.DecisionPrice, &referencePrice, &cost.FillPrice, &cost.SlippageBps, &cost.SlippageCost,
+			&cost.Fee, &cost.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan execution cost: %w", err)
+		}
+		cost.OrderID = orderID.String
+		cost.ReferencePrice = referencePrice.Float64
+		costs = append(costs, cost)
+	}
+	return costs, rows.Err()
+}
+
+// SaveStopSlippage stores the slippage of one stop-loss fill
+// SaveStopSlippage 保存一次止损成交的滑点
+func (s *Storage) SaveStopSlippage(slip *StopSlippage) (int64, error) {
+	query := `
+	INSERT INTO stop_slippages (
+		position_id, symbol, timeframe, side, order_type, stop_distance_percent,
+		trigger_price, fill_price, quantity, slippage_bps, slippage_cost, executed_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, slip.PositionID, slip.Symbol, slip.Timeframe, slip.Side, slip.OrderType, slip.StopDistancePercent,
+		slip.TriggerPrice, slip.FillPrice, slip.Quantity, slip.SlippageBps, slip.SlippageCost, slip.ExecutedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save stop slippage: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetStopSlippages returns the stop-loss fills executed since the given time, oldest first
+// GetStopSlippages 返回指定时间之后的止损成交滑点，按时间升序
+func (s *Storage) GetStopSlippages(since time.Time) ([]*StopSlippage, error) {
+	query := `
+	SELECT id, position_id, symbol, timeframe, side, order_type, stop_distance_percent,
+		trigger_price, fill_price, quantity, slippage_bps, slippage_cost, executed_at
+	FROM stop_slippages
+	WHERE executed_at >= ?
+	ORDER BY executed_at ASC
+	`
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stop slippages: %w", err)
+	}
+	defer rows.Close()
+
+	var slips []*StopSlippage
+	for rows.Next() {
+		slip := &StopSlippage{}
+		if err := rows.Scan(&slip.ID, &slip.PositionID, &slip.Symbol, &slip.Timeframe, &slip.Side, &slip.OrderType,
+			&slip.StopDistancePercent, &slip.TriggerPrice, &slip.FillPrice, &slip.Quantity, &slip.SlippageBps,
+			&slip.SlippageCost, &slip.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stop slippage: %w", err)
+		}
+		slips = append(slips, slip)
+	}
+	return slips, rows.Err()
+}
+
+// SaveAnalysisRequest stores a new on-demand analysis request
+// SaveAnalysisRequest 保存新的即时分析请求
+func (s *Storage) SaveAnalysisRequest(req *AnalysisRequest) (int64, error) {
+	query := `
+	INSERT INTO analysis_requests (symbol, requested_by, status, requested_at)
+	VALUES (?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, req.Symbol, req.RequestedBy, req.Status, req.RequestedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save analysis request: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// UpdateAnalysisRequest moves a request to a new status, stamping the start or completion time
+// UpdateAnalysisRequest 更新请求状态，并记录开始或结束时间
+func (s *Storage) UpdateAnalysisRequest(id int64, status, batchID, errMsg string) error {
+	now := time.Now()
+	var query string
+	var args []interface{}
+	switch status {
+	case AnalysisRequestRunning:
+		query = `UPDATE analysis_requests SET status = ?, started_at = ? WHERE id = ?`
+		args = []interface{}{status, now, id}
+	default:
+		query = `UPDATE analysis_requests SET status = ?, batch_id = ?, error = ?, completed_at = ? WHERE id = ?`
+		args = []interface{}{status, batchID, errMsg, now, id}
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to update analysis request: %w", err)
+	}
+	return nil
+}
+
+// GetAnalysisRequest returns an analysis request by ID, or nil when it does not exist
+// GetAnalysisRequest 根据 ID 返回分析请求，不存在时返回 nil
+func (s *Storage) GetAnalysisRequest(id int64) (*AnalysisRequest, error) {
+	query := `
+	SELECT id, symbol, requested_by, status, batch_id, error, requested_at, started_at, completed_at
+	FROM analysis_requests
+	WHERE id = ?
+	`
+	req := &AnalysisRequest{}
+	var batchID, errMsg sql.NullString
+	var startedAt, completedAt sql.NullTime
+	err := s.db.QueryRow(query, id).Scan(&req.ID, &req.Symbol, &req.RequestedBy, &req.Status, &batchID, &errMsg,
+		&req.RequestedAt, &startedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analysis request: %w", err)
+	}
+	req.BatchID, req.Error = batchID.String, errMsg.String
+	if startedAt.Valid {
+		req.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		req.CompletedAt = &completedAt.Time
+	}
+	return req, nil
+}
+
+// SaveLesson stores a new trading lesson
+// SaveLesson 保存新的交易规则
+func (s *Storage) SaveLesson(lesson *TradingLesson) (int64, error) {
+	now := time.Now()
+	query := `
+	INSERT INTO trading_lessons (symbol, lesson, enabled, created_by, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, lesson.Symbol, lesson.Lesson, lesson.Enabled, lesson.CreatedBy, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save lesson: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// UpdateLesson rewrites a lesson's symbol, text and enabled flag
+// UpdateLesson 更新规则的交易对、内容和启用状态
+func (s *Storage) UpdateLesson(lesson *TradingLesson) error {
+	query := `UPDATE trading_lessons SET symbol = ?, lesson = ?, enabled = ?, updated_at = ? WHERE id = ?`
+	result, err := s.db.Exec(query, lesson.Symbol, lesson.Lesson, lesson.Enabled, time.Now(), lesson.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update lesson: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("lesson %d not found", lesson.ID)
+	}
+	return nil
+}
+
+// DeleteLesson removes a lesson
+// DeleteLesson 删除规则
+func (s *Storage) DeleteLesson(id int64) error {
+	result, err := s.db.Exec(`DELETE FROM trading_lessons WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete lesson: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("lesson %d not found", id)
+	}
+	return nil
+}
+
+// GetLesson returns a lesson by ID, or nil when it does not exist
+// GetLesson 根据 ID 返回规则，不存在时返回 nil
+func (s *Storage) GetLesson(id int64) (*TradingLesson, error) {
+	lessons, err := s.queryLessons(`WHERE id = ?`, id)
+	if err != nil || len(lessons) == 0 {
+		return nil, err
+	}
+	return lessons[0], nil
+}
+
+// GetLessons returns the lessons in the order they were added, optionally only the enabled ones
+// GetLessons 按添加顺序返回规则，可只返回已启用的规则
+func (s *Storage) GetLessons(enabledOnly bool) ([]*TradingLesson, error) {
+	if enabledOnly {
+		return s.queryLessons(`WHERE enabled = 1`)
+	}
+	return s.queryLessons(``)
+}
+
+// queryLessons selects lessons matching the given WHERE clause, oldest first
+// queryLessons 查询符合 WHERE 条件的规则，按创建顺序排列
+func (s *Storage) queryLessons(where string, args ...interface{}) ([]*TradingLesson, error) {
+	query := `
+	SELECT id, symbol, lesson, enabled, created_by, created_at, updated_at
+	FROM trading_lessons ` + where + `
+	ORDER BY id ASC
+	`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lessons: %w", err)
+	}
+	defer rows.Close()
+
+	var lessons []*TradingLesson
+	for rows.Next() {
+		l := &TradingLesson{}
+		if err := rows.Scan(&l.ID, &l.Symbol, &l.Lesson, &l.Enabled, &l.CreatedBy, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lesson: %w", err)
+		}
+		lessons = append(lessons, l)
+	}
+	return lessons, rows.Err()
+}
+
+// SaveStrategyReview stores a strategy review
+// SaveStrategyReview 保存策略复盘
+func (s *Storage) SaveStrategyReview(review *StrategyReview) (int64, error) {
+	query := `
+	INSERT INTO strategy_reviews (period_start, period_end, model, trades, net_pnl, report, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, review.PeriodStart, review.PeriodEnd, review.Model, review.Trades, review.NetPnL,
+		review.Report, review.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save strategy review: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetStrategyReviews returns the latest strategy reviews, newest period first
+// GetStrategyReviews 返回最近的策略复盘，按复盘区间倒序
+func (s *Storage) GetStrategyReviews(limit int) ([]*StrategyReview, error) {
+	query := `
+	SELECT id, period_start, period_end, model, trades, net_pnl, report, created_at
+	FROM strategy_reviews
+	ORDER BY period_end DESC, id DESC
+	LIMIT ?
+	`
+	rows, err := s.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query strategy reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []*StrategyReview
+	for rows.Next() {
+		r := &StrategyReview{}
+		if err := rows.Scan(&r.ID, &r.PeriodStart, &r.PeriodEnd, &r.Model, &r.Trades, &r.NetPnL, &r.Report, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan strategy review: %w", err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// SaveTraderConfig stores a trader config version; a version already stored is left untouched
+// SaveTraderConfig 保存交易员配置版本；已存在的版本保持不变
+func (s *Storage) SaveTraderConfig(tc *TraderConfig) error {
+	query := `
+	INSERT OR IGNORE INTO trader_configs (fingerprint, model, prompt, status, shadow_cycles, created_at, promoted_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := s.db.Exec(query, tc.Fingerprint, tc.Model, tc.Prompt, tc.Status, tc.ShadowCycles, tc.CreatedAt, tc.PromotedAt); err != nil {
+		return fmt.Errorf("failed to save trader config: %w", err)
+	}
+	return nil
+}
+
+// GetTraderConfig returns a trader config by fingerprint, or nil when it does not exist
+// GetTraderConfig 根据指纹返回交易员配置，不存在时返回 nil
+func (s *Storage) GetTraderConfig(fingerprint string) (*TraderConfig, error) {
+	configs, err := s.queryTraderConfigs(`WHERE fingerprint = ?`, fingerprint)
+	if err != nil || len(configs) == 0 {
+		return nil, err
+	}
+	return configs[0], nil
+}
+
+// GetLiveTraderConfig returns the trader config driving live decisions, or nil before one was recorded
+// GetLiveTraderConfig 返回驱动实盘决策的交易员配置，尚未记录时返回 nil
+func (s *Storage) GetLiveTraderConfig() (*TraderConfig, error) {
+	configs, err := s.queryTraderConfigs(`WHERE status = ?`, TraderConfigLive)
+	if err != nil || len(configs) == 0 {
+		return nil, err
+	}
+	return configs[0], nil
+}
+
+// GetTraderConfigs returns all trader config versions, newest first
+// GetTraderConfigs 返回所有交易员配置版本，最新的在前
+func (s *Storage) GetTraderConfigs() ([]*TraderConfig, error) {
+	return s.queryTraderConfigs(``)
+}
+
+// queryTraderConfigs selects trader configs matching the given WHERE clause, newest first
+// queryTraderConfigs 查询符合 WHERE 条件的交易员配置，最新的在前
+func (s *Storage) queryTraderConfigs(where string, args ...interface{}) ([]*TraderConfig, error) {
+	query := `
+	SELECT id, fingerprint, model, prompt, status, shadow_cycles, created_at, promoted_at
+	FROM trader_configs ` + where + `
+	ORDER BY id DESC
+	`
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trader configs: %w", err)
+	}
+	defer rows.Close()
+
+	var configs []*TraderConfig
+	for rows.Next() {
+		tc := &TraderConfig{}
+		var promotedAt sql.NullTime
+		if err := rows.Scan(&tc.ID, &tc.Fingerprint, &tc.Model, &tc.Prompt, &tc.Status, &tc.ShadowCycles, &tc.CreatedAt, &promotedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trader config: %w", err)
+		}
+		if promotedAt.Valid {
+			tc.PromotedAt = &promotedAt.Time
+		}
+		configs = append(configs, tc)
+	}
+	return configs, rows.Err()
+}
+
+// FinishShadowCycle counts a completed shadow cycle of a config and returns the new count
+// FinishShadowCycle 为配置累计一轮已完成的影子运行，并返回新的轮数
+func (s *Storage) FinishShadowCycle(fingerprint string) (int, error) {
+	if _, err := s.db.Exec(`UPDATE trader_configs SET shadow_cycles = shadow_cycles + 1 WHERE fingerprint = ?`, fingerprint); err != nil {
+		return 0, fmt.Errorf("failed to count shadow cycle: %w", err)
+	}
+	var cycles int
+	if err := s.db.QueryRow(`SELECT shadow_cycles FROM trader_configs WHERE fingerprint = ?`, fingerprint).Scan(&cycles); err != nil {
+		return 0, fmt.Errorf("failed to read shadow cycles: %w", err)
+	}
+	return cycles, nil
+}
+
+// SetTraderConfigStatus changes the status of a config that is not live
+// SetTraderConfigStatus 修改非实盘配置的状态
+func (s *Storage) SetTraderConfigStatus(fingerprint, status string) error {
+	result, err := s.db.Exec(`UPDATE trader_configs SET status = ? WHERE fingerprint = ? AND status != ?`, status, fingerprint, TraderConfigLive)
+	if err != nil {
+		return fmt.Errorf("failed to update trader config: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("trader config %s not found or live", fingerprint)
+	}
+	return nil
+}
+
+// PromoteTraderConfig makes a config the live one and retires the config it replaces
+// PromoteTraderConfig 将配置切换为实盘配置，并停用被替换的配置
+func (s *Storage) PromoteTraderConfig(fingerprint string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE trader_configs SET status = ? WHERE status = ?`, TraderConfigRetired, TraderConfigLive); err != nil {
+		return fmt.Errorf("failed to retire live trader config: %w", err)
+	}
+	result, err := tx.Exec(`UPDATE trader_configs SET status = ?, promoted_at = ? WHERE fingerprint = ?`, TraderConfigLive, time.Now(), fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to promote trader config: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("trader config %s not found", fingerprint)
+	}
+	return tx.Commit()
+}
+
+// SaveShadowDecision stores the live and shadow decision of one symbol in a shadow cycle
+// SaveShadowDecision 保存某轮影子运行中单个交易对的实盘决策与影子决策
+func (s *Storage) SaveShadowDecision(d *ShadowDecision) (int64, error) {
+	query := `
+	INSERT INTO shadow_decisions (
+		fingerprint, cycle, symbol, live_action, shadow_action,
+		live_confidence, shadow_confidence, live_leverage, shadow_leverage, created_at
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, d.Fingerprint, d.Cycle, d.Symbol, d.LiveAction, d.ShadowAction,
+		d.LiveConfidence, d.ShadowConfidence, d.LiveLeverage, d.ShadowLeverage, d.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save shadow decision: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// GetShadowDecisions returns the shadow decisions recorded for a config, oldest cycle first
+// GetShadowDecisions 返回某个配置的影子决策记录，按轮次先后排列
+func (s *Storage) GetShadowDecisions(fingerprint string) ([]*ShadowDecision, error) {
+	query := `
+	SELECT id, fingerprint, cycle, symbol, live_action, shadow_action,
+		live_confidence, shadow_confidence, live_leverage, shadow_leverage, created_at
+	FROM shadow_decisions
+	WHERE fingerprint = ?
+	ORDER BY cycle ASC, id ASC
+	`
+	rows, err := s.db.Query(query, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shadow decisions: %w", err)
+	}
+	defer rows.Close()
+
+	var decisions []*ShadowDecision
+	for rows.Next() {
+		d := &ShadowDecision{}
+		if err := rows.Scan(&d.ID, &d.Fingerprint, &d.Cycle, &d.Symbol, &d.LiveAction, &d.ShadowAction,
+			&d.LiveConfidence, &d.ShadowConfidence, &d.LiveLeverage, &d.ShadowLeverage, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shadow decision: %w", err)
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions, rows.Err()
+}
+
+// SaveSituationMemory stores a labeled situation; an existing situation at the same bar is left untouched
+// SaveSituationMemory 保存带标签的情境；同一根 K 线上已存在的情境保持不变
+// It reports whether a new row was inserted, so re-running a seed is idempotent
+// 返回是否插入了新记录，因此重复播种是幂等的
+func (s *Storage) SaveSituationMemory(m *SituationMemory) (bool, error) {
+	query := `
+	INSERT OR IGNORE INTO situation_memories (
+		symbol, timeframe, situation_time, setup, direction, features, situation,
+		outcome, return_pct, r_multiple, bars_held, source
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := s.db.Exec(
+		query,
+		m.Symbol, m.Timeframe, m.SituationTime, m.Setup, m.Direction, m.Features, m.Situation,
+		m.Outcome, m.ReturnPct, m.RMultiple, m.BarsHeld, m.Source,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to save situation memory: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	m.ID, _ = result.LastInsertId()
+	return true, nil
+}
+
+// GetSituationMemories returns all situations recorded for a symbol and timeframe
+// GetSituationMemories 返回某个交易对和时间周期的全部情境
+func (s *Storage) GetSituationMemories(symbol, timeframe string) ([]*SituationMemory, error) {
+	query := `
+	SELECT id, symbol, timeframe, situation_time, setup, direction, features, situation,
+		outcome, return_pct, r_multiple, bars_held, source, created_at
+	FROM situation_memories
+	WHERE symbol = ? AND timeframe = ?
+	ORDER BY situation_time
+	`
+
+	rows, err := s.db.Query(query, symbol, timeframe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query situation memories: %w", err)
+	}
+	defer rows.Close()
+
+	var memories []*SituationMemory
+	for rows.Next() {
+		m := &SituationMemory{}
+		var situation sql.NullString
+		var returnPct, rMultiple sql.NullFloat64
+		var barsHeld sql.NullInt64
+		if err := rows.Scan(
+			&m.ID, &m.Symbol, &m.Timeframe, &m.SituationTime, &m.Setup, &m.Direction, &m.Features, &situation,
+			&m.Outcome, &returnPct, &rMultiple, &barsHeld, &m.Source, &m.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan situation memory: %w", err)
+		}
+		m.Situation = situation.String
+		m.ReturnPct = returnPct.Float64
+		m.RMultiple = rMultiple.Float64
+		m.BarsHeld = int(barsHeld.Int64)
+		memories = append(memories, m)
+	}
+
+	return memories, rows.Err()
+}
+
 // GetTotalSessionCount retrieves the total number of trading sessions
 // GetTotalSessionCount 获取交易会话总数
 func (s *Storage) GetTotalSessionCount() (int, error) {
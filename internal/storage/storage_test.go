@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -77,6 +80,89 @@ func TestSaveAndGetSession(t *testing.T) {
 	}
 }
 
+func TestQuerySessions(t *testing.T) {
+	tmpDB := "./test_query_sessions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 五个会话，间隔一天：BTC 买入/持有交替，最后一个为已执行的 ETH 卖出
+	// Five sessions a day apart: BTC alternating BUY/HOLD, the last one an executed ETH SELL
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+	fixtures := []struct {
+		symbol   string
+		action   string
+		executed bool
+	}{
+		{"BTC/USDT", "BUY", true},
+		{"BTC/USDT", "HOLD", false},
+		{"BTC/USDT", "BUY", false},
+		{"BTC/USDT", "HOLD", false},
+		{"ETH/USDT", "SELL", true},
+	}
+	for i, f := range fixtures {
+		if _, err := db.SaveSession(&TradingSession{
+			Symbol:    f.symbol,
+			Timeframe: "1h",
+			CreatedAt: base.AddDate(0, 0, i),
+			Action:    f.action,
+			Executed:  f.executed,
+		}); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	executed := true
+	tests := []struct {
+		name  string
+		query SessionQuery
+		want  int
+	}{
+		{"All", SessionQuery{Limit: 10}, 5},
+		{"Symbol in Binance format", SessionQuery{Symbol: "BTCUSDT", Limit: 10}, 4},
+		{"Action is case-insensitive", SessionQuery{Action: "buy", Limit: 10}, 2},
+		{"Executed only", SessionQuery{Executed: &executed, Limit: 10}, 2},
+		{"Date range", SessionQuery{From: base.AddDate(0, 0, 1), To: base.AddDate(0, 0, 3), Limit: 10}, 2},
+		{"Combined", SessionQuery{Symbol: "BTC/USDT", Action: "HOLD", From: base.AddDate(0, 0, 2), Limit: 10}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, _, err := db.QuerySessions(tt.query)
+			if err != nil {
+				t.Fatalf("QuerySessions failed: %v", err)
+			}
+			if len(sessions) != tt.want {
+				t.Errorf("Expected %d sessions, got %d", tt.want, len(sessions))
+			}
+		})
+	}
+
+	// 按游标翻页，每页两条，直到没有下一页
+	// Page through two at a time until there is no next page
+	var seen []string
+	cursor := int64(0)
+	for page := 0; page < 5; page++ {
+		sessions, next, err := db.QuerySessions(SessionQuery{Symbol: "BTC/USDT", Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("QuerySessions failed: %v", err)
+		}
+		for _, s := range sessions {
+			seen = append(seen, s.Action)
+		}
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	if got := strings.Join(seen, ","); got != "HOLD,BUY,HOLD,BUY" {
+		t.Errorf("Expected newest-first pages HOLD,BUY,HOLD,BUY, got %s", got)
+	}
+}
+
 func TestGetSessionsBySymbol(t *testing.T) {
 	tmpDB := "./test_trading_symbol.db"
 	defer os.Remove(tmpDB)
@@ -228,3 +314,932 @@ func TestUpdateExecutionResult(t *testing.T) {
 			executionResult, updated.ExecutionResult)
 	}
 }
+
+func TestPendingTaskLifecycle(t *testing.T) {
+	tmpDB := "./test_pending_tasks.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 一个已到期任务，一个未来任务
+	due := &PendingTask{
+		TaskType:    "replace_stop_loss",
+		Symbol:      "BTCUSDT",
+		Payload:     `{"stop_price":49000}`,
+		MaxAttempts: 5,
+		NextRunAt:   time.Now().Add(-time.Minute),
+	}
+	if _, err := db.SavePendingTask(due); err != nil {
+		t.Fatalf("SavePendingTask failed: %v", err)
+	}
+	later := &PendingTask{
+		TaskType:    "replace_stop_loss",
+		Symbol:      "ETHUSDT",
+		MaxAttempts: 5,
+		NextRunAt:   time.Now().Add(time.Hour),
+	}
+	if _, err := db.SavePendingTask(later); err != nil {
+		t.Fatalf("SavePendingTask failed: %v", err)
+	}
+
+	tasks, err := db.GetDuePendingTasks(time.Now(), 10)
+	if err != nil {
+		t.Fatalf("GetDuePendingTasks failed: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Symbol != "BTCUSDT" {
+		t.Fatalf("Expected only the due BTCUSDT task, got: %+v", tasks)
+	}
+	if tasks[0].Payload != due.Payload || tasks[0].Status != TaskStatusPending {
+		t.Errorf("Task mismatch: %+v", tasks[0])
+	}
+
+	// 标记完成后不再出现在待处理列表
+	tasks[0].Status = TaskStatusDone
+	tasks[0].Attempts = 1
+	if err := db.UpdatePendingTask(tasks[0]); err != nil {
+		t.Fatalf("UpdatePendingTask failed: %v", err)
+	}
+
+	pending, err := db.GetPendingTasks()
+	if err != nil {
+		t.Fatalf("GetPendingTasks failed: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Symbol != "ETHUSDT" {
+		t.Errorf("Expected only ETHUSDT pending, got: %+v", pending)
+	}
+}
+
+func TestStateArchiveRoundTrip(t *testing.T) {
+	srcDB := "./test_state_src.db"
+	dstDB := "./test_state_dst.db"
+	defer os.Remove(srcDB)
+	defer os.Remove(dstDB)
+
+	src, err := NewStorage(srcDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer src.Close()
+
+	// 源库：一个持仓、一条止损事件、一个会话
+	pos := &PositionRecord{
+		ID:              "pos-1",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      50000.5,
+		EntryTime:       time.Now().Add(-time.Hour),
+		Quantity:        0.012,
+		Leverage:        10,
+		InitialStopLoss: 49000,
+		CurrentStopLoss: 49500,
+		StopLossType:    "trailing",
+		HighestPrice:    51000,
+		CurrentPrice:    50800,
+		StopLossOrderID: "123456",
+	}
+	if err := src.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+	if err := src.SaveStopLossEvent(&StopLossEvent{PositionID: "pos-1", Timestamp: time.Now(), OldStop: 49000, NewStop: 49500, Reason: "trail", Trigger: "program"}); err != nil {
+		t.Fatalf("SaveStopLossEvent failed: %v", err)
+	}
+	if _, err := src.SaveSession(&TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: time.Now(), Decision: "HOLD"}); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	snapshot, err := src.ExportState()
+	if err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	snapshot.Config = "BINANCE_LEVERAGE=10\n"
+
+	var buf bytes.Buffer
+	if err := WriteStateArchive(&buf, snapshot, "secret"); err != nil {
+		t.Fatalf("WriteStateArchive failed: %v", err)
+	}
+	archive := buf.Bytes()
+
+	// 错误口令必须解密失败
+	if _, err := ReadStateArchive(bytes.NewReader(archive), "wrong"); err == nil {
+		t.Fatal("Expected decryption to fail with wrong passphrase")
+	}
+
+	restored, err := ReadStateArchive(bytes.NewReader(archive), "secret")
+	if err != nil {
+		t.Fatalf("ReadStateArchive failed: %v", err)
+	}
+	if restored.Config != snapshot.Config {
+		t.Errorf("Config mismatch: %q", restored.Config)
+	}
+
+	dst, err := NewStorage(dstDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer dst.Close()
+
+	if err := dst.ImportState(restored); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	got, err := dst.GetPositionByID("pos-1")
+	if err != nil {
+		t.Fatalf("GetPositionByID failed: %v", err)
+	}
+	if got.EntryPrice != pos.EntryPrice || got.Quantity != pos.Quantity || got.CurrentStopLoss != pos.CurrentStopLoss ||
+		got.StopLossOrderID != pos.StopLossOrderID || got.Leverage != pos.Leverage {
+		t.Errorf("Position mismatch: %+v", got)
+	}
+	if got.EntryTime.Unix() != pos.EntryTime.Unix() {
+		t.Errorf("Entry time mismatch: %v vs %v", got.EntryTime, pos.EntryTime)
+	}
+
+	events, err := dst.GetStopLossEvents("pos-1")
+	if err != nil || len(events) != 1 || events[0].NewStop != 49500 {
+		t.Errorf("Stop-loss events not restored: %+v, err: %v", events, err)
+	}
+
+	sessions, err := dst.GetLatestSessions(10)
+	if err != nil || len(sessions) != 1 || sessions[0].Decision != "HOLD" {
+		t.Errorf("Sessions not restored: %d, err: %v", len(sessions), err)
+	}
+}
+
+func TestStateArchiveRuntimeTables(t *testing.T) {
+	srcDB := "./test_state_runtime_src.db"
+	dstDB := "./test_state_runtime_dst.db"
+	defer os.Remove(srcDB)
+	defer os.Remove(dstDB)
+
+	src, err := NewStorage(srcDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer src.Close()
+
+	// 源库：一条情境记忆、一个暂停、一个租约、一条止损滑点、一次策略复盘、一个影子配置及其决策
+	barTime := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	resumeAt := time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)
+	if _, err := src.SaveSituationMemory(&SituationMemory{Symbol: "BTCUSDT", Timeframe: "1h", SituationTime: barTime, Setup: "breakout",
+		Direction: "long", Features: `{"rsi":62}`, Outcome: "win", RMultiple: 2, BarsHeld: 7, Source: "seed"}); err != nil {
+		t.Fatalf("SaveSituationMemory failed: %v", err)
+	}
+	if err := src.SaveSymbolPause(&SymbolPause{Symbol: "ETHUSDT", Reason: "unlock", PausedAt: barTime, ResumeAt: &resumeAt}); err != nil {
+		t.Fatalf("SaveSymbolPause failed: %v", err)
+	}
+	if _, err := src.AcquireSymbolLease(&SymbolLease{Symbol: "BTCUSDT", Owner: "proc-a", InstanceID: "host-a", Hostname: "host-a", PID: 100}, time.Minute); err != nil {
+		t.Fatalf("AcquireSymbolLease failed: %v", err)
+	}
+	if _, err := src.SaveStopSlippage(&StopSlippage{PositionID: "pos-1", Symbol: "BTCUSDT", Timeframe: "1h", Side: "long", OrderType: "STOP_MARKET",
+		StopDistancePercent: 2, TriggerPrice: 49000, FillPrice: 48990, Quantity: 0.01, SlippageBps: 2.04, SlippageCost: 0.1, ExecutedAt: barTime}); err != nil {
+		t.Fatalf("SaveStopSlippage failed: %v", err)
+	}
+	if _, err := src.SaveStrategyReview(&StrategyReview{PeriodStart: barTime.Add(-7 * 24 * time.Hour), PeriodEnd: barTime, Model: "gpt-4o",
+		Trades: 12, NetPnL: 84.5, Report: `{"summary":"trend entries worked"}`, CreatedAt: barTime}); err != nil {
+		t.Fatalf("SaveStrategyReview failed: %v", err)
+	}
+	if err := src.SaveTraderConfig(&TraderConfig{Fingerprint: "fp-shadow", Model: "gpt-4o", Prompt: "prompt v2", Status: TraderConfigShadow,
+		ShadowCycles: 3, CreatedAt: barTime}); err != nil {
+		t.Fatalf("SaveTraderConfig failed: %v", err)
+	}
+	if _, err := src.SaveShadowDecision(&ShadowDecision{Fingerprint: "fp-shadow", Cycle: 3, Symbol: "BTCUSDT", LiveAction: "BUY", ShadowAction: "HOLD",
+		LiveConfidence: 0.8, ShadowConfidence: 0.6, LiveLeverage: 10, ShadowLeverage: 5, CreatedAt: barTime}); err != nil {
+		t.Fatalf("SaveShadowDecision failed: %v", err)
+	}
+
+	snapshot, err := src.ExportState()
+	if err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteStateArchive(&buf, snapshot, "secret"); err != nil {
+		t.Fatalf("WriteStateArchive failed: %v", err)
+	}
+	restored, err := ReadStateArchive(&buf, "secret")
+	if err != nil {
+		t.Fatalf("ReadStateArchive failed: %v", err)
+	}
+
+	dst, err := NewStorage(dstDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer dst.Close()
+	if err := dst.ImportState(restored); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	memories, err := dst.GetSituationMemories("BTCUSDT", "1h")
+	if err != nil || len(memories) != 1 || memories[0].BarsHeld != 7 || !memories[0].SituationTime.Equal(barTime) {
+		t.Errorf("Situation memories not restored: %+v, err: %v", memories, err)
+	}
+
+	pauses, err := dst.GetSymbolPauses()
+	if err != nil || len(pauses) != 1 || pauses[0].Reason != "unlock" || pauses[0].ResumeAt == nil || !pauses[0].ResumeAt.Equal(resumeAt) {
+		t.Errorf("Symbol pauses not restored: %+v, err: %v", pauses, err)
+	}
+
+	slippages, err := dst.GetStopSlippages(barTime.Add(-time.Hour))
+	if err != nil || len(slippages) != 1 || slippages[0].FillPrice != 48990 || !slippages[0].ExecutedAt.Equal(barTime) {
+		t.Errorf("Stop slippages not restored: %+v, err: %v", slippages, err)
+	}
+
+	reviews, err := dst.GetStrategyReviews(10)
+	if err != nil || len(reviews) != 1 || reviews[0].Trades != 12 || reviews[0].NetPnL != 84.5 || !reviews[0].PeriodEnd.Equal(barTime) ||
+		reviews[0].Report != `{"summary":"trend entries worked"}` {
+		t.Errorf("Strategy reviews not restored: %+v, err: %v", reviews, err)
+	}
+
+	traderConfig, err := dst.GetTraderConfig("fp-shadow")
+	if err != nil || traderConfig == nil || traderConfig.Status != TraderConfigShadow || traderConfig.ShadowCycles != 3 || traderConfig.Prompt != "prompt v2" {
+		t.Errorf("Trader config not restored: %+v, err: %v", traderConfig, err)
+	}
+
+	shadowDecisions, err := dst.GetShadowDecisions("fp-shadow")
+	if err != nil || len(shadowDecisions) != 1 || shadowDecisions[0].ShadowAction != "HOLD" || shadowDecisions[0].ShadowLeverage != 5 {
+		t.Errorf("Shadow decisions not restored: %+v, err: %v", shadowDecisions, err)
+	}
+
+	// The lease belongs to the source host's process and must not block the destination
+	// 租约属于源主机上的进程，不能阻塞目标主机
+	if _, ok := snapshot.Tables["symbol_leases"]; ok {
+		t.Error("Symbol leases should not be exported")
+	}
+	lease, err := dst.GetSymbolLease("BTCUSDT")
+	if err != nil || lease != nil {
+		t.Errorf("Symbol lease should not be restored: %+v, err: %v", lease, err)
+	}
+}
+
+func TestGetClosedPositions(t *testing.T) {
+	tmpDB := "./test_closed_positions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	// 一笔早于窗口平仓，一笔在窗口内平仓，一笔仍持有
+	now := time.Now()
+	for i, closeAge := range []time.Duration{48 * time.Hour, time.Hour, 0} {
+		pos := &PositionRecord{
+			ID:              fmt.Sprintf("pos-%d", i),
+			Symbol:          "BTCUSDT",
+			Side:            "long",
+			EntryPrice:      50000,
+			EntryTime:       now.Add(-72 * time.Hour),
+			Quantity:        0.01,
+			Leverage:        10,
+			InitialStopLoss: 49000,
+			CurrentStopLoss: 49000,
+			StopLossType:    "fixed",
+			HighestPrice:    50000,
+			CurrentPrice:    50000,
+		}
+		if err := db.SavePosition(pos); err != nil {
+			t.Fatalf("SavePosition failed: %v", err)
+		}
+		if closeAge > 0 {
+			closeTime := now.Add(-closeAge)
+			pos.Closed = true
+			pos.CloseTime = &closeTime
+			pos.RealizedPnL = float64(i + 1)
+			if err := db.UpdatePosition(pos); err != nil {
+				t.Fatalf("UpdatePosition failed: %v", err)
+			}
+		}
+	}
+
+	closed, err := db.GetClosedPositions(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("GetClosedPositions failed: %v", err)
+	}
+	if len(closed) != 1 || closed[0].ID != "pos-1" || closed[0].RealizedPnL != 2 || closed[0].CloseTime == nil {
+		t.Errorf("Expected only pos-1 closed in window, got: %+v", closed)
+	}
+}
+
+func TestPositionFeesPersisted(t *testing.T) {
+	tmpDB := "./test_position_fees.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	pos := &PositionRecord{
+		ID:              "pos-fees",
+		Symbol:          "BTCUSDT",
+		Side:            "long",
+		EntryPrice:      50000,
+		EntryTime:       now.Add(-time.Hour),
+		Quantity:        0.01,
+		Leverage:        10,
+		InitialStopLoss: 49000,
+		CurrentStopLoss: 49000,
+		StopLossType:    "fixed",
+		HighestPrice:    50000,
+		CurrentPrice:    50000,
+	}
+	if err := db.SavePosition(pos); err != nil {
+		t.Fatalf("SavePosition failed: %v", err)
+	}
+
+	pos.Closed = true
+	pos.CloseTime = &now
+	pos.RealizedPnL = 10
+	pos.Commission = 0.4
+	pos.FundingFee = -0.1
+	if err := db.UpdatePosition(pos); err != nil {
+		t.Fatalf("UpdatePosition failed: %v", err)
+	}
+
+	got, err := db.GetPositionByID("pos-fees")
+	if err != nil || got == nil {
+		t.Fatalf("GetPositionByID failed: %v", err)
+	}
+	if got.Commission != 0.4 || got.FundingFee != -0.1 {
+		t.Errorf("Expected commission 0.4 and funding -0.1, got %.4f and %.4f", got.Commission, got.FundingFee)
+	}
+	if net := got.NetPnL(); net < 9.5-1e-9 || net > 9.5+1e-9 {
+		t.Errorf("Expected net PnL 9.5, got %.4f", net)
+	}
+}
+
+func TestTradeIntentLifecycle(t *testing.T) {
+	tmpDB := "./test_trade_intents.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	defer db.Close()
+
+	intent := &TradeIntent{
+		BatchID: "batch-1",
+		Symbol:  "BTCUSDT",
+		Action:  "BUY",
+		Reason:  "breakout",
+		Status:  IntentDecided,
+	}
+	if _, err := db.SaveTradeIntent(intent); err != nil {
+		t.Fatalf("SaveTradeIntent failed: %v", err)
+	}
+
+	for _, status := range []string{IntentApproved, IntentFilled, IntentPositionOpened} {
+		intent.Status = status
+		intent.Detail = "stage " + status
+		if status == IntentFilled {
+			intent.OrderID = "123"
+			intent.FilledQty = 0.01
+			intent.FillPrice = 50000
+		}
+		if err := db.UpdateTradeIntent(intent); err != nil {
+			t.Fatalf("UpdateTradeIntent(%s) failed: %v", status, err)
+		}
+	}
+
+	intents, err := db.GetTradeIntents(10)
+	if err != nil {
+		t.Fatalf("GetTradeIntents failed: %v", err)
+	}
+	if len(intents) != 1 {
+		t.Fatalf("Expected 1 intent, got %d", len(intents))
+	}
+	got := intents[0]
+	if got.Status != IntentPositionOpened || got.OrderID != "123" || got.FillPrice != 50000 {
+		t.Errorf("Unexpected intent: %+v", got)
+	}
+
+	events, err := db.GetTradeIntentEvents(intent.ID)
+	if err != nil {
+		t.Fatalf("GetTradeIntentEvents failed: %v", err)
+	}
+	want := []string{IntentDecided, IntentApproved, IntentFilled, IntentPositionOpened}
+	if len(events) != len(want) {
+		t.Fatalf("Expected %d events, got %d", len(want), len(events))
+	}
+	for i, status := range want {
+		if events[i].Status != status {
+			t.Errorf("Event %d: expected %s, got %s", i, status, events[i].Status)
+		}
+	}
+}
+
+func TestSymbolLease(t *testing.T) {
+	tmpDB := "./test_symbol_lease.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	first := &SymbolLease{Symbol: "BTCUSDT", Owner: "proc-a", InstanceID: "host-a", Hostname: "host-a", PID: 100}
+	second := &SymbolLease{Symbol: "BTCUSDT", Owner: "proc-b", InstanceID: "host-a", Hostname: "host-a", PID: 200}
+
+	// 第一个进程获取租约
+	holder, err := db.AcquireSymbolLease(first, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireSymbolLease failed: %v", err)
+	}
+	if holder.Owner != "proc-a" || holder.PID != 100 {
+		t.Fatalf("Expected proc-a to hold the lease, got %+v", holder)
+	}
+
+	// 第二个进程在租约有效期内被拒绝
+	holder, err = db.AcquireSymbolLease(second, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireSymbolLease failed: %v", err)
+	}
+	if holder.Owner != "proc-a" {
+		t.Errorf("Expected proc-b to be rejected, holder is %s", holder.Owner)
+	}
+
+	// 同一进程续约保留原获取时间
+	acquiredAt := holder.AcquiredAt
+	holder, _ = db.AcquireSymbolLease(first, time.Minute)
+	if holder.Owner != "proc-a" || !holder.AcquiredAt.Equal(acquiredAt) {
+		t.Errorf("Expected renewal to keep acquired_at, got %+v", holder)
+	}
+
+	// 心跳过期后第二个进程接管
+	time.Sleep(10 * time.Millisecond)
+	holder, _ = db.AcquireSymbolLease(second, time.Millisecond)
+	if holder.Owner != "proc-b" {
+		t.Errorf("Expected proc-b to take over the expired lease, holder is %s", holder.Owner)
+	}
+
+	// 释放后租约消失
+	if err := db.ReleaseSymbolLeases("proc-b"); err != nil {
+		t.Fatalf("ReleaseSymbolLeases failed: %v", err)
+	}
+	if holder, _ := db.GetSymbolLease("BTCUSDT"); holder != nil {
+		t.Errorf("Expected no lease after release, got %+v", holder)
+	}
+}
+
+func TestSituationMemory(t *testing.T) {
+	tmpDB := "./test_situation_memory.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	barTime := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	m := &SituationMemory{
+		Symbol:        "BTCUSDT",
+		Timeframe:     "1h",
+		SituationTime: barTime,
+		Setup:         "breakout",
+		Direction:     "long",
+		Features:      `{"rsi":62}`,
+		Situation:     "区间突破（做多）",
+		Outcome:       "win",
+		ReturnPct:     3.2,
+		RMultiple:     2,
+		BarsHeld:      7,
+		Source:        "seed",
+	}
+
+	inserted, err := db.SaveSituationMemory(m)
+	if err != nil {
+		t.Fatalf("SaveSituationMemory failed: %v", err)
+	}
+	if !inserted || m.ID == 0 {
+		t.Fatalf("Expected a new row, got inserted=%v id=%d", inserted, m.ID)
+	}
+
+	// 重复播种同一根 K 线的同一形态不会产生重复记录
+	dup := *m
+	dup.ID = 0
+	dup.Outcome = "loss"
+	inserted, err = db.SaveSituationMemory(&dup)
+	if err != nil {
+		t.Fatalf("SaveSituationMemory (duplicate) failed: %v", err)
+	}
+	if inserted {
+		t.Error("Expected duplicate situation to be ignored")
+	}
+
+	// 其他时间周期的记录不会被查询到
+	other := *m
+	other.Timeframe = "4h"
+	if _, err := db.SaveSituationMemory(&other); err != nil {
+		t.Fatalf("SaveSituationMemory (other timeframe) failed: %v", err)
+	}
+
+	memories, err := db.GetSituationMemories("BTCUSDT", "1h")
+	if err != nil {
+		t.Fatalf("GetSituationMemories failed: %v", err)
+	}
+	if len(memories) != 1 {
+		t.Fatalf("Expected 1 memory, got %d", len(memories))
+	}
+	got := memories[0]
+	if got.Outcome != "win" || got.BarsHeld != 7 || got.RMultiple != 2 || !got.SituationTime.Equal(barTime) {
+		t.Errorf("Unexpected memory: %+v", got)
+	}
+}
+
+func TestExecutionCosts(t *testing.T) {
+	tmpDB := "./test_execution_costs.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	old := &ExecutionCost{Symbol: "BTCUSDT", Action: "BUY", OrderID: "1", Liquidity: LiquidityTaker, Quantity: 0.1,
+		DecisionPrice: 50000, FillPrice: 50025, SlippageBps: 5, SlippageCost: 2.5, Fee: 2, ExecutedAt: now.Add(-48 * time.Hour)}
+	recent := &ExecutionCost{Symbol: "ETHUSDT", Action: "CLOSE_LONG", Liquidity: LiquidityMaker, Quantity: 1,
+		DecisionPrice: 3000, ReferencePrice: 3001, FillPrice: 3003, SlippageBps: -10, SlippageCost: -3, Fee: 0.6, ExecutedAt: now}
+	for _, cost := range []*ExecutionCost{old, recent} {
+		if _, err := db.SaveExecutionCost(cost); err != nil {
+			t.Fatalf("SaveExecutionCost failed: %v", err)
+		}
+	}
+
+	// 只返回时间窗口内的记录
+	costs, err := db.GetExecutionCosts(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetExecutionCosts failed: %v", err)
+	}
+	if len(costs) != 1 {
+		t.Fatalf("Expected 1 recent execution cost, got %d", len(costs))
+	}
+	got := costs[0]
+	if got.Symbol != "ETHUSDT" || got.OrderID != "" || got.ReferencePrice != 3001 || got.SlippageBps != -10 || got.Fee != 0.6 {
+		t.Errorf("Unexpected execution cost: %+v", got)
+	}
+}
+
+func TestAnalysisRequests(t *testing.T) {
+	tmpDB := "./test_analysis_requests.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	id, err := db.SaveAnalysisRequest(&AnalysisRequest{Symbol: "BTCUSDT", RequestedBy: "admin", Status: AnalysisRequestQueued, RequestedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("SaveAnalysisRequest failed: %v", err)
+	}
+
+	req, err := db.GetAnalysisRequest(id)
+	if err != nil || req == nil {
+		t.Fatalf("GetAnalysisRequest failed: %v", err)
+	}
+	if req.Status != AnalysisRequestQueued || req.RequestedBy != "admin" || req.StartedAt != nil || req.BatchID != "" {
+		t.Errorf("Unexpected queued request: %+v", req)
+	}
+
+	if err := db.UpdateAnalysisRequest(id, AnalysisRequestRunning, "", ""); err != nil {
+		t.Fatalf("UpdateAnalysisRequest failed: %v", err)
+	}
+	if err := db.UpdateAnalysisRequest(id, AnalysisRequestDone, "batch-1", ""); err != nil {
+		t.Fatalf("UpdateAnalysisRequest failed: %v", err)
+	}
+	req, _ = db.GetAnalysisRequest(id)
+	if req.Status != AnalysisRequestDone || req.BatchID != "batch-1" || req.StartedAt == nil || req.CompletedAt == nil {
+		t.Errorf("Unexpected finished request: %+v", req)
+	}
+
+	// 不存在的请求返回 nil
+	if missing, err := db.GetAnalysisRequest(id + 1); err != nil || missing != nil {
+		t.Errorf("Expected nil for a missing request, got %+v (%v)", missing, err)
+	}
+}
+
+func TestTradingLessons(t *testing.T) {
+	tmpDB := "./test_trading_lessons.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	globalID, err := db.SaveLesson(&TradingLesson{Lesson: "Never add to a losing position", Enabled: true, CreatedBy: "admin"})
+	if err != nil {
+		t.Fatalf("SaveLesson failed: %v", err)
+	}
+	symbolID, err := db.SaveLesson(&TradingLesson{Symbol: "SOLUSDT", Lesson: "Skip entries during the US open", Enabled: true, CreatedBy: "cli"})
+	if err != nil {
+		t.Fatalf("SaveLesson failed: %v", err)
+	}
+
+	// 停用后不再出现在启用列表中
+	lesson, err := db.GetLesson(symbolID)
+	if err != nil || lesson == nil || lesson.Symbol != "SOLUSDT" {
+		t.Fatalf("GetLesson failed: %+v (%v)", lesson, err)
+	}
+	lesson.Enabled = false
+	if err := db.UpdateLesson(lesson); err != nil {
+		t.Fatalf("UpdateLesson failed: %v", err)
+	}
+	enabled, err := db.GetLessons(true)
+	if err != nil || len(enabled) != 1 || enabled[0].ID != globalID {
+		t.Fatalf("Expected only the global lesson enabled, got %+v (%v)", enabled, err)
+	}
+	all, _ := db.GetLessons(false)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 lessons, got %d", len(all))
+	}
+
+	if err := db.DeleteLesson(globalID); err != nil {
+		t.Fatalf("DeleteLesson failed: %v", err)
+	}
+	if err := db.DeleteLesson(globalID); err == nil {
+		t.Error("Expected deleting a missing lesson to fail")
+	}
+	if missing, err := db.GetLesson(globalID); err != nil || missing != nil {
+		t.Errorf("Expected nil for a deleted lesson, got %+v (%v)", missing, err)
+	}
+}
+
+func TestStrategyReviews(t *testing.T) {
+	tmpDB := "./test_strategy_reviews.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	weekEnd := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	for i := 2; i >= 0; i-- {
+		end := weekEnd.AddDate(0, 0, -7*i)
+		if _, err := db.SaveStrategyReview(&StrategyReview{
+			PeriodStart: end.AddDate(0, 0, -7),
+			PeriodEnd:   end,
+			Model:       "deepseek-reasoner",
+			Trades:      10 + i,
+			NetPnL:      12.5,
+			Report:      `{"summary":"ok"}`,
+			CreatedAt:   end,
+		}); err != nil {
+			t.Fatalf("SaveStrategyReview failed: %v", err)
+		}
+	}
+
+	// Newest period first, bounded by the limit
+	// 最新区间在前，受 limit 限制
+	reviews, err := db.GetStrategyReviews(2)
+	if err != nil || len(reviews) != 2 {
+		t.Fatalf("Expected 2 reviews, got %d (%v)", len(reviews), err)
+	}
+	if !reviews[0].PeriodEnd.Equal(weekEnd) || reviews[0].Trades != 10 || reviews[0].Report != `{"summary":"ok"}` {
+		t.Errorf("Expected the latest week first, got %+v", reviews[0])
+	}
+	if !reviews[1].PeriodEnd.Equal(weekEnd.AddDate(0, 0, -7)) {
+		t.Errorf("Expected the previous week second, got %v", reviews[1].PeriodEnd)
+	}
+}
+
+func TestTraderConfigs(t *testing.T) {
+	tmpDB := "./test_trader_configs.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	if err := db.SaveTraderConfig(&TraderConfig{Fingerprint: "old", Model: "gpt-4o-mini", Prompt: "v1", Status: TraderConfigLive, CreatedAt: now}); err != nil {
+		t.Fatalf("SaveTraderConfig failed: %v", err)
+	}
+	if err := db.SaveTraderConfig(&TraderConfig{Fingerprint: "new", Model: "gpt-4o", Prompt: "v2", Status: TraderConfigShadow, CreatedAt: now}); err != nil {
+		t.Fatalf("SaveTraderConfig failed: %v", err)
+	}
+	// Saving a known version again keeps the stored one
+	// 再次保存已知版本时保留已存储的版本
+	if err := db.SaveTraderConfig(&TraderConfig{Fingerprint: "new", Model: "other", Prompt: "v3", Status: TraderConfigLive, CreatedAt: now}); err != nil {
+		t.Fatalf("SaveTraderConfig failed: %v", err)
+	}
+	if tc, _ := db.GetTraderConfig("new"); tc == nil || tc.Model != "gpt-4o" || tc.Status != TraderConfigShadow {
+		t.Fatalf("Expected the first save of new to be kept, got %+v", tc)
+	}
+
+	for i := 1; i <= 2; i++ {
+		cycles, err := db.FinishShadowCycle("new")
+		if err != nil || cycles != i {
+			t.Fatalf("Expected %d shadow cycles, got %d (%v)", i, cycles, err)
+		}
+	}
+	for _, sym := range []string{"BTCUSDT", "ETHUSDT"} {
+		if _, err := db.SaveShadowDecision(&ShadowDecision{Fingerprint: "new", Cycle: 1, Symbol: sym, LiveAction: "HOLD", ShadowAction: "BUY",
+			LiveConfidence: 0.5, ShadowConfidence: 0.8, LiveLeverage: 5, ShadowLeverage: 10, CreatedAt: now}); err != nil {
+			t.Fatalf("SaveShadowDecision failed: %v", err)
+		}
+	}
+	if decisions, err := db.GetShadowDecisions("new"); err != nil || len(decisions) != 2 || decisions[1].ShadowAction != "BUY" {
+		t.Fatalf("Expected 2 shadow decisions, got %+v (%v)", decisions, err)
+	}
+
+	if err := db.SetTraderConfigStatus("old", TraderConfigReady); err == nil {
+		t.Error("Expected the live config status to be protected")
+	}
+	if err := db.SetTraderConfigStatus("new", TraderConfigReady); err != nil {
+		t.Fatalf("SetTraderConfigStatus failed: %v", err)
+	}
+
+	if err := db.PromoteTraderConfig("new"); err != nil {
+		t.Fatalf("PromoteTraderConfig failed: %v", err)
+	}
+	live, err := db.GetLiveTraderConfig()
+	if err != nil || live == nil || live.Fingerprint != "new" || live.PromotedAt == nil {
+		t.Fatalf("Expected new to be live, got %+v (%v)", live, err)
+	}
+	if old, _ := db.GetTraderConfig("old"); old.Status != TraderConfigRetired {
+		t.Errorf("Expected the replaced config to be retired, got %s", old.Status)
+	}
+	if err := db.PromoteTraderConfig("missing"); err == nil {
+		t.Error("Expected promoting an unknown config to fail")
+	}
+	if live, _ := db.GetLiveTraderConfig(); live == nil || live.Fingerprint != "new" {
+		t.Errorf("Expected a failed promotion to leave the live config alone, got %+v", live)
+	}
+	if configs, _ := db.GetTraderConfigs(); len(configs) != 2 || configs[0].Fingerprint != "new" {
+		t.Errorf("Expected both configs newest first, got %+v", configs)
+	}
+}
+
+func TestTakeProfitLevels(t *testing.T) {
+	tmpDB := "./test_take_profit_levels.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	executedAt := time.Now().Truncate(time.Second)
+	levels := []*TakeProfitLevelRecord{
+		{Level: 1, RiskRewardRatio: 1, Percentage: 0.3, TargetPrice: 105, Executed: true, ExecutedTime: &executedAt, ExecutedPrice: 105.1, NewStopLoss: 100},
+		{Level: 2, RiskRewardRatio: 2, Percentage: 0.3, TargetPrice: 110, NewStopLoss: 105, OrderID: 42},
+	}
+	if err := db.SaveTakeProfitLevels("pos-1", levels); err != nil {
+		t.Fatalf("SaveTakeProfitLevels failed: %v", err)
+	}
+
+	// Saving again replaces the stored levels
+	// 再次保存会替换已保存的级别
+	levels[1].TargetPrice = 112
+	if err := db.SaveTakeProfitLevels("pos-1", levels); err != nil {
+		t.Fatalf("SaveTakeProfitLevels failed: %v", err)
+	}
+
+	got, err := db.GetTakeProfitLevels("pos-1")
+	if err != nil || len(got) != 2 {
+		t.Fatalf("Expected 2 levels, got %d (%v)", len(got), err)
+	}
+	if !got[0].Executed || got[0].ExecutedTime == nil || !got[0].ExecutedTime.Equal(executedAt) || got[0].ExecutedPrice != 105.1 {
+		t.Errorf("Unexpected level 1: %+v", got[0])
+	}
+	if got[1].Executed || got[1].ExecutedTime != nil || got[1].TargetPrice != 112 || got[1].OrderID != 42 {
+		t.Errorf("Unexpected level 2: %+v", got[1])
+	}
+
+	if none, err := db.GetTakeProfitLevels("pos-2"); err != nil || len(none) != 0 {
+		t.Errorf("Expected no levels for another position, got %d (%v)", len(none), err)
+	}
+}
+
+func TestErrorCategoryCounts(t *testing.T) {
+	tmpDB := "./test_error_categories.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	base := time.Now().Add(-time.Hour)
+	for i, category := range []string{ErrorCategoryLLM, "", ErrorCategoryData} {
+		session := &TradingSession{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: base.Add(time.Duration(i) * time.Minute), ErrorCategory: category}
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+	if _, err := db.SaveSession(&TradingSession{Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: base, ErrorCategory: ErrorCategoryLLM}); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	// The latest session is re-tagged when its execution fails
+	// 最新会话在执行失败时重新标记类别
+	if err := db.UpdateLatestSessionErrorCategory("BTC/USDT", "1h", ErrorCategoryExchange); err != nil {
+		t.Fatalf("UpdateLatestSessionErrorCategory failed: %v", err)
+	}
+
+	intent := &TradeIntent{Symbol: "BTC/USDT", Action: "BUY", Status: IntentDecided}
+	if _, err := db.SaveTradeIntent(intent); err != nil {
+		t.Fatalf("SaveTradeIntent failed: %v", err)
+	}
+	intent.Status = IntentRejected
+	intent.ErrorCategory = ErrorCategoryRisk
+	if err := db.UpdateTradeIntent(intent); err != nil {
+		t.Fatalf("UpdateTradeIntent failed: %v", err)
+	}
+
+	intents, err := db.GetTradeIntents(10)
+	if err != nil || len(intents) != 1 || intents[0].ErrorCategory != ErrorCategoryRisk {
+		t.Fatalf("Expected the intent to keep its RISK category, got %+v (%v)", intents, err)
+	}
+
+	stats, err := db.GetSessionStats("BTC/USDT")
+	if err != nil {
+		t.Fatalf("GetSessionStats failed: %v", err)
+	}
+	counts, ok := stats["error_categories"].([]ErrorCategoryCount)
+	if !ok || len(counts) != len(ErrorCategories) {
+		t.Fatalf("Expected a count for every category, got %v", stats["error_categories"])
+	}
+	want := map[string][2]int{
+		ErrorCategoryData:     {0, 0},
+		ErrorCategoryLLM:      {1, 0},
+		ErrorCategoryExchange: {1, 0},
+		ErrorCategoryRisk:     {0, 1},
+		ErrorCategoryInternal: {0, 0},
+	}
+	for i, c := range counts {
+		if c.Category != ErrorCategories[i] {
+			t.Errorf("Expected category %s at %d, got %s", ErrorCategories[i], i, c.Category)
+		}
+		if got := [2]int{c.Sessions, c.Orders}; got != want[c.Category] {
+			t.Errorf("%s: expected sessions/orders %v, got %v", c.Category, want[c.Category], got)
+		}
+	}
+
+	all, err := db.GetErrorCategoryCounts("")
+	if err != nil {
+		t.Fatalf("GetErrorCategoryCounts failed: %v", err)
+	}
+	if all[1].Category != ErrorCategoryLLM || all[1].Sessions != 2 {
+		t.Errorf("Expected 2 LLM sessions across all symbols, got %+v", all[1])
+	}
+}
+
+func TestGetEntrySessions(t *testing.T) {
+	tmpDB := "./test_entry_sessions.db"
+	defer os.Remove(tmpDB)
+
+	db, err := NewStorage(tmpDB)
+	if err != nil {
+		t.Fatalf("NewStorage failed: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	sessions := []*TradingSession{
+		{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now.Add(-48 * time.Hour), Action: "BUY"},
+		{Symbol: "BTC/USDT", Timeframe: "1h", CreatedAt: now, Action: "HOLD"},
+		{Symbol: "ETH/USDT", Timeframe: "1h", CreatedAt: now, Action: "SELL",
+			AnalystStatus: map[string]string{"market": AnalystPresent, "sentiment": AnalystDegraded, "funding": AnalystAbsent}},
+	}
+	for _, session := range sessions {
+		if _, err := db.SaveSession(session); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	// Only entries inside the window, with their analyst statuses
+	// 只返回时间窗口内的开仓会话及其分析师状态
+	got, err := db.GetEntrySessions(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetEntrySessions failed: %v", err)
+	}
+	if len(got) != 1 || got[0].Symbol != "ETH/USDT" || got[0].Action != "SELL" {
+		t.Fatalf("Expected the ETH/USDT SELL session, got %+v", got)
+	}
+	status := got[0].AnalystStatus
+	if len(status) != 3 || status["market"] != AnalystPresent || status["sentiment"] != AnalystDegraded || status["funding"] != AnalystAbsent {
+		t.Errorf("Unexpected analyst status: %v", status)
+	}
+}
@@ -3,9 +3,14 @@ package web
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"math"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -13,8 +18,12 @@ import (
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/oak/crypto-trading-bot/internal/agents"
+	"github.com/oak/crypto-trading-bot/internal/capture"
 	"github.com/oak/crypto-trading-bot/internal/config"
+	"github.com/oak/crypto-trading-bot/internal/dataflows"
 	"github.com/oak/crypto-trading-bot/internal/executors"
+	"github.com/oak/crypto-trading-bot/internal/jobs"
 	"github.com/oak/crypto-trading-bot/internal/logger"
 	"github.com/oak/crypto-trading-bot/internal/portfolio"
 	"github.com/oak/crypto-trading-bot/internal/scheduler"
@@ -29,7 +38,9 @@ type Server struct {
 	storage         *storage.Storage
 	stopLossManager *executors.StopLossManager
 	scheduler       *scheduler.TradingScheduler
-	sessionManager  *SessionManager // Session 管理器 / Session manager
+	sessionManager  *SessionManager            // Session 管理器 / Session manager
+	analysisTrigger *scheduler.AnalysisTrigger // 即时分析队列，nil 表示未启用 / On-demand analysis queue, nil when unavailable
+	jobs            *jobs.Manager              // 后台任务管理器，nil 表示未启用 / Background job manager, nil when unavailable
 	hertz           *server.Hertz
 }
 
@@ -53,6 +64,18 @@ func NewServer(cfg *config.Config, log *logger.ColorLogger, db *storage.Storage,
 	return s
 }
 
+// SetAnalysisTrigger connects the on-demand analysis endpoint to the trading loop
+// SetAnalysisTrigger 将即时分析接口连接到交易循环
+func (s *Server) SetAnalysisTrigger(trigger *scheduler.AnalysisTrigger) {
+	s.analysisTrigger = trigger
+}
+
+// SetJobManager connects the background job endpoints to a job manager
+// SetJobManager 将后台任务接口连接到任务管理器
+func (s *Server) SetJobManager(manager *jobs.Manager) {
+	s.jobs = manager
+}
+
 // setupRoutes configures all HTTP routes
 // setupRoutes 配置所有 HTTP 路由
 func (s *Server) setupRoutes() {
@@ -83,6 +106,67 @@ func (s *Server) setupRoutes() {
 		protected.GET("/api/symbols", s.handleSymbols)
 		protected.GET("/api/balance/history", s.handleBalanceHistory)
 		protected.GET("/api/balance/current", s.handleCurrentBalance)
+		protected.GET("/api/account/snapshot", s.handleAccountSnapshot)
+		protected.GET("/api/risk/stress", s.handleStressTest)
+		protected.GET("/api/performance", s.handlePerformance)
+		protected.GET("/api/performance/r-multiples", s.handleRMultiples)
+		protected.GET("/api/performance/execution-costs", s.handleExecutionCosts)
+		protected.GET("/api/performance/stop-slippage", s.handleStopSlippage)
+		protected.GET("/api/performance/analyst-attribution", s.handleAnalystAttribution)
+		protected.GET("/api/leaderboard/export", s.handleLeaderboardExport)
+		protected.GET("/api/metrics/executor", s.handleExecutorMetrics)
+		protected.GET("/api/metrics/llm", s.handleLLMMetrics)
+		protected.GET("/api/intents", s.handleTradeIntents)
+		protected.GET("/api/intents/:id/events", s.handleTradeIntentEvents)
+
+		// Hand manually opened positions over to the stop and take-profit machinery
+		// 将手动开立的持仓移交给止损和止盈管理
+		protected.POST("/api/positions/:symbol/import", s.handleImportPosition)
+
+		// Per-symbol trading pauses
+		// 按交易对暂停交易
+		protected.GET("/api/pauses", s.handleSymbolPauses)
+		protected.POST("/api/pauses/:symbol", s.handlePauseSymbol)
+		protected.DELETE("/api/pauses/:symbol", s.handleResumeSymbol)
+
+		// Per-symbol debug capture
+		// 按交易对调试采集
+		protected.GET("/api/debug-capture", s.handleDebugCaptures)
+		protected.POST("/api/debug-capture/:symbol", s.handleArmDebugCapture)
+		protected.DELETE("/api/debug-capture/:symbol", s.handleDisarmDebugCapture)
+
+		// On-demand analysis
+		// 即时分析
+		protected.POST("/api/v1/analyze/:symbol", s.handleAnalyzeSymbol)
+		protected.GET("/api/v1/analyze/requests/:id", s.handleAnalysisRequest)
+
+		// Compact status for mobile widgets and chat bots
+		// 供手机小组件和聊天机器人使用的精简状态
+		protected.GET("/api/v1/summary", s.handleSummary)
+
+		// Background jobs for long operations
+		// 耗时操作的后台任务
+		protected.GET("/api/jobs", s.handleJobs)
+		protected.POST("/api/jobs", s.handleSubmitJob)
+		protected.GET("/api/jobs/:id", s.handleJob)
+		protected.DELETE("/api/jobs/:id", s.handleCancelJob)
+
+		// User-maintained trading rules
+		// 用户维护的交易规则
+		protected.GET("/api/lessons", s.handleLessons)
+		protected.POST("/api/lessons", s.handleCreateLesson)
+		protected.PUT("/api/lessons/:id", s.handleUpdateLesson)
+		protected.DELETE("/api/lessons/:id", s.handleDeleteLesson)
+
+		// Weekly strategy reviews written by the deep model
+		// 深度模型生成的策略周复盘
+		protected.GET("/api/strategy-reviews", s.handleStrategyReviews)
+
+		// Trader model and prompt versions, with shadow runs of changes
+		// 交易员模型和提示词版本，变更先影子运行
+		protected.GET("/api/trader-configs", s.handleTraderConfigs)
+		protected.GET("/api/trader-configs/:fingerprint", s.handleTraderConfig)
+		protected.POST("/api/trader-configs/:fingerprint/promote", s.handlePromoteTraderConfig)
 
 		// Configuration management
 		// 配置管理
@@ -131,6 +215,14 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 	// 获取活跃持仓
 	positions, _ := s.storage.GetActivePositions()
 
+	// Count failures per error category across all symbols
+	// 按错误类别统计所有交易对的失败次数
+	errorCounts, err := s.storage.GetErrorCategoryCounts("")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
 	// Create template with custom functions
 	// 创建带自定义函数的模板
 	funcMap := template.FuncMap{
@@ -138,9 +230,11 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 			return a * b
 		},
 		"extractAction": extractActionFromDecision,
+		"inZone":        s.inZone,
 	}
 	tmpl := template.Must(template.New("index.html").Funcs(funcMap).ParseFiles("internal/web/templates/index.html"))
 
+	nextTradeTime := s.scheduler.GetNextTimeframeTime().In(s.config.Location())
 	data := map[string]interface{}{
 		"Symbols":         s.config.CryptoSymbols,
 		"KlineTimeframe":  s.config.CryptoTimeframe, // K线数据间隔 / K-line data interval
@@ -149,8 +243,11 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 		"Sessions":        sessions,
 		"Batches":         batches, // ✅ Add batches for batch-based display
 		"Positions":       positions,
-		"CurrentTime":     time.Now().Format("2006-01-02 15:04:05"),
-		"NextTradeTime":   s.scheduler.GetNextTimeframeTime().Format("2006-01-02 15:04:05"),
+		"ErrorCounts":     errorCounts,
+		"CurrentTime":     time.Now().In(s.config.Location()).Format("2006-01-02 15:04:05"),
+		"NextTradeTime":   nextTradeTime.Format("2006-01-02 15:04:05"),
+		"NextTradeISO":    nextTradeTime.Format(time.RFC3339), // 带时区偏移，供浏览器倒计时解析 / With offset for the browser countdown
+		"TimeZone":        s.config.Timezone,
 		"LLMEnabled":      s.config.APIKey != "" && s.config.APIKey != "your_openai_key",
 		"TestMode":        s.config.BinanceTestMode,
 		"AutoExecute":     s.config.AutoExecute,
@@ -170,24 +267,87 @@ func (s *Server) handleIndex(ctx context.Context, c *app.RequestContext) {
 	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
 }
 
-// handleSessions returns JSON list of sessions
+// maxSessionPageSize caps the page size of /api/sessions
+// maxSessionPageSize 限制 /api/sessions 的每页数量
+const maxSessionPageSize = 200
+
+// handleSessions returns one page of sessions filtered by symbol, action, outcome and date range
+// handleSessions 按交易对、决策动作、执行结果和日期范围筛选并分页返回会话
+//
+// Query parameters: symbol, action, outcome (executed/pending), from, to (YYYY-MM-DD or RFC3339,
+// a date "to" includes that whole day), cursor (next_cursor of the previous page) and limit.
+// 查询参数：symbol、action、outcome（executed/pending）、from、to（YYYY-MM-DD 或 RFC3339，
+// 日期形式的 to 包含当天全天）、cursor（上一页返回的 next_cursor）和 limit。
 func (s *Server) handleSessions(ctx context.Context, c *app.RequestContext) {
-	limit := c.DefaultQuery("limit", "20")
-	var limitInt int
-	fmt.Sscanf(limit, "%d", &limitInt)
+	query := storage.SessionQuery{
+		Symbol: c.DefaultQuery("symbol", ""),
+		Action: c.DefaultQuery("action", ""),
+	}
+
+	fmt.Sscanf(c.DefaultQuery("limit", "20"), "%d", &query.Limit)
+	if query.Limit <= 0 || query.Limit > maxSessionPageSize {
+		query.Limit = maxSessionPageSize
+	}
+	fmt.Sscanf(c.DefaultQuery("cursor", "0"), "%d", &query.Cursor)
+
+	switch outcome := c.DefaultQuery("outcome", ""); outcome {
+	case "":
+	case "executed", "pending":
+		executed := outcome == "executed"
+		query.Executed = &executed
+	default:
+		c.JSON(http.StatusBadRequest, utils.H{"error": fmt.Sprintf("invalid outcome %q, expected executed or pending", outcome)})
+		return
+	}
+
+	var err error
+	if query.From, err = parseSessionTime(c.DefaultQuery("from", ""), false, s.config.Location()); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	if query.To, err = parseSessionTime(c.DefaultQuery("to", ""), true, s.config.Location()); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
 
-	sessions, err := s.storage.GetLatestSessions(limitInt)
+	sessions, nextCursor, err := s.storage.QuerySessions(query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, utils.H{
-		"sessions": sessions,
-		"count":    len(sessions),
+		"sessions":    sessions,
+		"count":       len(sessions),
+		"next_cursor": nextCursor,
 	})
 }
 
+// inZone converts a time to TIMEZONE for display in templates
+// inZone 将时间转换到 TIMEZONE 时区，供模板显示
+func (s *Server) inZone(t time.Time) time.Time {
+	return t.In(s.config.Location())
+}
+
+// parseSessionTime parses a date (in loc) or RFC3339 time; a bare date used as an end bound moves to the next midnight
+// parseSessionTime 解析日期（按 loc 时区）或 RFC3339 时间；作为结束时间的纯日期会移到次日零点
+func parseSessionTime(value string, endOfDay bool, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
 // handleSessionDetail returns details of a specific session
 // handleSessionDetail 返回特定会话的详细信息
 func (s *Server) handleSessionDetail(ctx context.Context, c *app.RequestContext) {
@@ -212,6 +372,7 @@ func (s *Server) handleSessionDetail(ctx context.Context, c *app.RequestContext)
 	// 创建带自定义函数的模板
 	funcMap := template.FuncMap{
 		"extractAction": extractActionFromDecision,
+		"inZone":        s.inZone,
 	}
 	tmpl := template.Must(template.New("session_detail.html").Funcs(funcMap).ParseFiles("internal/web/templates/session_detail.html"))
 
@@ -251,6 +412,19 @@ func (s *Server) handleStats(ctx context.Context, c *app.RequestContext) {
 		return
 	}
 
+	// Stop-loss slippage of the last 30 days, for tuning the stop distance
+	// 最近 30 天的止损滑点，用于调整止损距离
+	binanceSymbol := s.config.GetBinanceSymbolFor(symbol)
+	if slips, err := s.storage.GetStopSlippages(time.Now().AddDate(0, 0, -30)); err == nil {
+		var symbolSlips []*storage.StopSlippage
+		for _, slip := range slips {
+			if slip.Symbol == binanceSymbol {
+				symbolSlips = append(symbolSlips, slip)
+			}
+		}
+		stats["stop_slippage"] = portfolio.CalculateStopSlippage(symbolSlips)
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
 
@@ -323,10 +497,17 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 		EntryPrice       float64 `json:"entry_price"`
 		CurrentPrice     float64 `json:"current_price"`
 		UnrealizedPnL    float64 `json:"unrealized_pnl"`
-		ROE              float64 `json:"roe"` // Return on Equity percentage
+		Commission       float64 `json:"commission"`  // Commission paid since entry / 开仓以来的手续费
+		FundingFee       float64 `json:"funding_fee"` // Net funding since entry / 开仓以来的资金费
+		NetPnL           float64 `json:"net_pnl"`     // Unrealized PnL after fees / 扣除费用后的未实现盈亏
+		ROE              float64 `json:"roe"`         // Return on Equity percentage
 		Leverage         int     `json:"leverage"`
+		MarginType       string  `json:"margin_type"` // cross/isolated / 全仓/逐仓
 		LiquidationPrice float64 `json:"liquidation_price"`
 		CurrentStopLoss  float64 `json:"current_stop_loss"` // Current stop-loss price / 当前止损价格
+		Managed          bool    `json:"managed"`           // 是否由止损管理器管理 / Whether the stop-loss manager tracks it
+		Adopted          bool    `json:"adopted"`           // 是否为启动时接管的持仓 / Whether it was adopted from the exchange at startup
+		Imported         bool    `json:"imported"`          // 是否为手动导入的持仓 / Whether it was opened by hand and imported
 	}
 
 	var positions []PositionResponse
@@ -362,14 +543,31 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 			if pos.CurrentPrice > 0 {
 				currentPrice = pos.CurrentPrice
 			}
+			// Prefer the shared price cache so the page matches what the stop-loss manager sees
+			// 优先使用共享价格缓存，使页面与止损管理器看到的价格一致
+			if s.stopLossManager != nil {
+				if price, ok := s.stopLossManager.LatestPrice(symbol); ok {
+					currentPrice = price
+				}
+			}
 
 			// Get current stop-loss price from stop-loss manager
 			// 从止损管理器获取当前止损价格
+			// Fees are only known for managed positions, whose entry time bounds the income query
+			// 仅受管持仓可统计费用，其开仓时间限定收益查询范围
 			currentStopLoss := 0.0
+			managed, adopted, imported := false, false, false
+			var fees executors.PositionFees
 			if s.stopLossManager != nil {
 				managedPos := s.stopLossManager.GetPosition(symbol)
 				if managedPos != nil {
 					currentStopLoss = managedPos.CurrentStopLoss
+					managed, adopted, imported = true, managedPos.IsAdopted(), managedPos.IsImported()
+					if f, err := executor.GetPositionFees(ctx, symbol, managedPos.EntryTime, time.Now()); err == nil {
+						fees = f
+					} else {
+						s.logger.Warning(fmt.Sprintf("获取 %s 手续费和资金费失败: %v", symbol, err))
+					}
 				}
 			}
 
@@ -380,10 +578,17 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 				EntryPrice:       pos.EntryPrice,
 				CurrentPrice:     currentPrice,
 				UnrealizedPnL:    pos.UnrealizedPnL,
+				Commission:       fees.Commission,
+				FundingFee:       fees.Funding,
+				NetPnL:           pos.UnrealizedPnL - fees.Commission + fees.Funding,
 				ROE:              roe,
 				Leverage:         pos.Leverage,
+				MarginType:       string(pos.MarginType),
 				LiquidationPrice: pos.LiquidationPrice,
 				CurrentStopLoss:  currentStopLoss,
+				Managed:          managed,
+				Adopted:          adopted,
+				Imported:         imported,
 			})
 		}
 	}
@@ -396,6 +601,52 @@ func (s *Server) handleLivePositions(ctx context.Context, c *app.RequestContext)
 	})
 }
 
+// handleImportPosition takes a manually opened exchange position under management, with an explicit stop_loss or one
+// computed from the ATR, and an optional entry_price overriding the exchange's average entry
+// handleImportPosition 接管交易所上手动开立的持仓，可指定 stop_loss（否则按 ATR 计算）和覆盖交易所持仓均价的 entry_price
+func (s *Server) handleImportPosition(ctx context.Context, c *app.RequestContext) {
+	if s.stopLossManager == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Stop-loss manager is not running"})
+		return
+	}
+	var req struct {
+		StopLoss   float64 `json:"stop_loss"`
+		EntryPrice float64 `json:"entry_price"`
+		Note       string  `json:"note"`
+	}
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	symbol := s.configuredSymbol(c.Param("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("%s is not managed by this instance", s.config.GetBinanceSymbolFor(c.Param("symbol")))})
+		return
+	}
+	pos, err := s.stopLossManager.ImportPosition(ctx, symbol, executors.ImportRequest{
+		StopLoss:   req.StopLoss,
+		EntryPrice: req.EntryPrice,
+		Note:       req.Note,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{
+		"status":       "success",
+		"symbol":       pos.Symbol,
+		"side":         pos.Side,
+		"quantity":     pos.Quantity,
+		"entry_price":  pos.EntryPrice,
+		"stop_loss":    pos.CurrentStopLoss,
+		"stop_order":   pos.StopLossOrderID,
+		"take_profits": pos.TakeProfitConfig,
+	})
+}
+
 // handleSymbols returns all configured trading symbols
 // handleSymbols 返回所有配置的交易对
 func (s *Server) handleSymbols(ctx context.Context, c *app.RequestContext) {
@@ -566,129 +817,1340 @@ func (s *Server) handleCurrentBalance(ctx context.Context, c *app.RequestContext
 	c.JSON(http.StatusOK, response)
 }
 
-// handleTradeHistory renders the full trade history page with pagination
-// handleTradeHistory 渲染带分页的完整交易历史页面
-func (s *Server) handleTradeHistory(ctx context.Context, c *app.RequestContext) {
-	// Get pagination parameters
-	// 获取分页参数
-	page := 1
-	pageSize := 50 // Default page size / 默认每页大小
+// handleAccountSnapshot returns the wallet breakdown and today's realized PnL
+// handleAccountSnapshot 返回钱包明细和今日已实现盈亏
+// The account comes from the shared executor's short-lived cache and prices from the live price cache
+// 账户数据来自共享执行器的短期缓存，价格来自实时价格缓存
+func (s *Server) handleAccountSnapshot(ctx context.Context, c *app.RequestContext) {
+	snapshot, err := s.accountExecutor().AccountSnapshot(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": fmt.Sprintf("获取账户快照失败: %v", err)})
+		return
+	}
 
-	if pageStr := c.Query("page"); pageStr != "" {
-		fmt.Sscanf(pageStr, "%d", &page)
-		if page < 1 {
-			page = 1
+	snapshot.TodayRealizedPnL, err = s.todayRealizedPnL()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// accountExecutor returns the stop-loss manager's executor, whose account snapshot cache is shared, or a new one
+// accountExecutor 返回止损管理器的执行器（共享账户快照缓存），不可用时新建一个
+func (s *Server) accountExecutor() *executors.BinanceExecutor {
+	if s.stopLossManager != nil {
+		if executor := s.stopLossManager.Executor(); executor != nil {
+			return executor
 		}
 	}
+	return executors.NewBinanceExecutor(s.config, s.logger)
+}
 
-	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
-		fmt.Sscanf(pageSizeStr, "%d", &pageSize)
-		// Limit page size to valid options
-		// 限制每页大小为有效选项
-		if pageSize != 20 && pageSize != 50 && pageSize != 100 {
-			pageSize = 50
+// todayRealizedPnL returns the net PnL of positions closed since midnight
+// todayRealizedPnL 返回今日零点以来已平仓持仓的净盈亏
+func (s *Server) todayRealizedPnL() (float64, error) {
+	// Days roll over at midnight in TIMEZONE
+	// 日切按 TIMEZONE 的午夜计算
+	now := time.Now().In(s.config.Location())
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	closed, err := s.storage.GetClosedPositions(startOfDay)
+	if err != nil {
+		return 0, err
+	}
+	var pnl float64
+	for _, p := range closed {
+		pnl += p.NetPnL()
+	}
+	return pnl, nil
+}
+
+// summaryStaleCycles is how many trading intervals may pass without a decision before the bot is reported stale
+// summaryStaleCycles 是允许无决策的交易间隔数，超过后报告机器人停滞
+const summaryStaleCycles = 2
+
+// summaryPosition is one open position in the summary
+// summaryPosition 是摘要中的一个持仓
+type summaryPosition struct {
+	Symbol        string   `json:"symbol"`              // 交易对 / Trading pair
+	Side          string   `json:"side"`                // long/short
+	Size          float64  `json:"size"`                // 持仓数量 / Quantity
+	EntryPrice    float64  `json:"entry_price"`         // 开仓均价 / Entry price
+	Price         float64  `json:"price"`               // 当前价格 / Current price
+	StopLoss      float64  `json:"stop_loss,omitempty"` // 当前止损价格 / Current stop-loss
+	UnrealizedPnL float64  `json:"unrealized_pnl"`      // 未实现盈亏 / Unrealized PnL
+	R             *float64 `json:"r"`                   // 以初始风险计的浮动 R，无初始止损时为 null / Open R on the initial risk, null without an initial stop
+}
+
+// summaryDecision is the latest decision on a symbol
+// summaryDecision 是某交易对的最近一次决策
+type summaryDecision struct {
+	Symbol   string    `json:"symbol"`   // 交易对 / Trading pair
+	Action   string    `json:"action"`   // 决策动作 / Decided action
+	Executed bool      `json:"executed"` // 是否已执行 / Whether it was executed
+	At       time.Time `json:"at"`       // 决策时间 / Decision time
+}
+
+// summaryHealth tells whether the bot is trading normally
+// summaryHealth 表示机器人是否正常交易
+type summaryHealth struct {
+	Status        string     `json:"status"`                  // ok/degraded
+	LastCycleAt   *time.Time `json:"last_cycle_at,omitempty"` // 最近一次决策时间 / Time of the latest decision
+	PausedSymbols []string   `json:"paused_symbols"`          // 已暂停新开仓的交易对 / Symbols whose entries are paused
+	Issues        []string   `json:"issues"`                  // 异常说明，正常时为空 / What is wrong, empty when healthy
+}
+
+// summaryResponse is the compact status served to mobile widgets and chat-bot status commands
+// summaryResponse 是提供给手机小组件和聊天机器人状态命令的精简状态
+type summaryResponse struct {
+	Time          time.Time         `json:"time"`           // 生成时间 / When the summary was built
+	Equity        *float64          `json:"equity"`         // 权益（含未实现盈亏），交易所不可用时为 null / Equity including unrealized PnL, null when the exchange is unreachable
+	TodayPnL      float64           `json:"today_pnl"`      // 今日已实现净盈亏 / Net PnL realized today
+	UnrealizedPnL float64           `json:"unrealized_pnl"` // 未实现盈亏合计 / Total unrealized PnL
+	Positions     []summaryPosition `json:"positions"`      // 持仓 / Open positions
+	Decisions     []summaryDecision `json:"decisions"`      // 各交易对最近一次决策 / Latest decision per symbol
+	Health        summaryHealth     `json:"health"`         // 运行状态 / Bot health
+}
+
+// handleSummary returns equity, today's PnL, open positions with their R, the latest decision per symbol and the
+// bot health in one small response
+// handleSummary 在一个精简响应中返回权益、今日盈亏、持仓及其 R、各交易对最近决策和机器人运行状态
+// A failing part is reported under health instead of failing the request, so widgets always have something to show
+// 某部分获取失败时记入 health 而不是让请求失败，使小组件始终有内容可显示
+func (s *Server) handleSummary(ctx context.Context, c *app.RequestContext) {
+	resp := summaryResponse{
+		Time:      time.Now().In(s.config.Location()),
+		Positions: []summaryPosition{},
+		Decisions: []summaryDecision{},
+		Health:    summaryHealth{Status: "ok", PausedSymbols: []string{}, Issues: []string{}},
+	}
+	issue := func(format string, args ...interface{}) {
+		resp.Health.Issues = append(resp.Health.Issues, fmt.Sprintf(format, args...))
+	}
+
+	if snapshot, err := s.accountExecutor().AccountSnapshot(ctx); err != nil {
+		issue("exchange: %v", err)
+	} else {
+		equity := roundCents(snapshot.MarginBalance)
+		resp.Equity = &equity
+		resp.UnrealizedPnL = roundCents(snapshot.UnrealizedPnL)
+		for _, p := range snapshot.Positions {
+			pos := summaryPosition{
+				Symbol:        p.Symbol,
+				Side:          p.Side,
+				Size:          p.Size,
+				EntryPrice:    p.EntryPrice,
+				Price:         p.Price,
+				UnrealizedPnL: roundCents(p.UnrealizedPnL),
+			}
+			var managed *executors.Position
+			if s.stopLossManager != nil {
+				managed = s.stopLossManager.GetPosition(p.Symbol)
+			}
+			if managed == nil {
+				issue("%s: position has no managed stop-loss", p.Symbol)
+			} else {
+				pos.StopLoss = managed.CurrentStopLoss
+				if r, ok := portfolio.OpenRMultiple(p.Side, p.EntryPrice, managed.InitialStopLoss, p.Size, p.UnrealizedPnL); ok {
+					r = roundCents(r)
+					pos.R = &r
+				}
+			}
+			resp.Positions = append(resp.Positions, pos)
 		}
 	}
 
-	// Calculate offset
-	// 计算偏移量
-	offset := (page - 1) * pageSize
+	if pnl, err := s.todayRealizedPnL(); err != nil {
+		issue("storage: %v", err)
+	} else {
+		resp.TodayPnL = roundCents(pnl)
+	}
 
-	// Get total batch count
-	// 获取总批次数
-	totalCount, err := s.storage.GetTotalBatchCount()
+	var lastCycle time.Time
+	for _, symbol := range s.config.CryptoSymbols {
+		sessions, _, err := s.storage.QuerySessions(storage.SessionQuery{Symbol: symbol, Limit: 1})
+		if err != nil {
+			issue("storage: %v", err)
+			break
+		}
+		if len(sessions) == 0 {
+			continue
+		}
+		session := sessions[0]
+		action := session.Action
+		if action == "" {
+			action = extractActionFromDecision(session.Decision)
+		}
+		resp.Decisions = append(resp.Decisions, summaryDecision{
+			Symbol:   s.config.GetBinanceSymbolFor(symbol),
+			Action:   action,
+			Executed: session.Executed,
+			At:       session.CreatedAt,
+		})
+		if session.CreatedAt.After(lastCycle) {
+			lastCycle = session.CreatedAt
+		}
+	}
+	if !lastCycle.IsZero() {
+		resp.Health.LastCycleAt = &lastCycle
+		if s.scheduler != nil {
+			interval := time.Duration(s.scheduler.GetMinutes()) * time.Minute
+			if interval > 0 && time.Since(lastCycle) > summaryStaleCycles*interval {
+				issue("no decision since %s", lastCycle.Format(time.RFC3339))
+			}
+		}
+	}
+
+	if s.stopLossManager == nil {
+		issue("stop-loss manager is not running")
+	}
+	if pauses, err := executors.NewSymbolPauseRegistry(s.storage, s.config, s.logger).List(); err != nil {
+		issue("storage: %v", err)
+	} else {
+		for _, p := range pauses {
+			resp.Health.PausedSymbols = append(resp.Health.PausedSymbols, p.Symbol)
+		}
+	}
+
+	if len(resp.Health.Issues) > 0 {
+		resp.Health.Status = "degraded"
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// roundCents rounds to two decimals so the summary stays short
+// roundCents 保留两位小数，使摘要保持精简
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// performanceInputs is the data a performance window is computed from
+// performanceInputs 是计算某个绩效窗口所需的数据
+type performanceInputs struct {
+	start       time.Time
+	end         time.Time
+	startEquity float64
+	trades      []*storage.PositionRecord
+	funding     []executors.FundingPayment
+}
+
+// loadPerformanceInputs reads the ?days= window (default 30) and collects balances, closed trades and funding
+// loadPerformanceInputs 读取 ?days= 窗口（默认 30 天）并收集余额、已平仓交易和资金费
+// On failure it returns the HTTP status to respond with
+// 失败时返回应响应的 HTTP 状态码
+func (s *Server) loadPerformanceInputs(ctx context.Context, c *app.RequestContext) (*performanceInputs, int, error) {
+	days := 30 // Default to last 30 days / 默认最近 30 天
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	if days < 1 {
+		days = 1
+	}
+
+	// The window is in TIMEZONE so daily returns roll over at the user's midnight
+	// 窗口使用 TIMEZONE 时区，使日收益在用户所在时区的午夜切换
+	in := &performanceInputs{end: time.Now().In(s.config.Location())}
+	in.start = in.end.AddDate(0, 0, -days)
+
+	// Starting equity comes from the first balance snapshot in the window
+	// 期初权益取窗口内第一条余额快照
+	history, err := s.storage.GetBalanceHistory(days * 24)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if len(history) == 0 {
+		return nil, http.StatusNotFound, fmt.Errorf("暂无余额历史，无法计算绩效")
+	}
+	in.startEquity = history[0].TotalBalance + history[0].UnrealizedPnL
+	if history[0].Timestamp.After(in.start) {
+		in.start = history[0].Timestamp.In(s.config.Location())
+	}
+
+	in.trades, err = s.storage.GetClosedPositions(in.start)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// Funding is optional: without it the report still covers trading PnL
+	// 资金费为可选项：获取失败时报告仍包含交易盈亏
+	executor := executors.NewBinanceExecutor(s.config, s.logger)
+	in.funding, err = executor.GetFundingPayments(ctx, "", in.start, in.end)
+	if err != nil {
+		s.logger.Warning(fmt.Sprintf("⚠️  获取资金费记录失败，绩效中不含资金费: %v", err))
+	}
+	return in, http.StatusOK, nil
+}
+
+// handleStressTest returns the portfolio VaR and the hypothetical PnL and margin impact of the stress scenarios
+// handleStressTest 返回组合 VaR 以及各压力场景的假设盈亏和保证金影响
+func (s *Server) handleStressTest(ctx context.Context, c *app.RequestContext) {
+	snapshot, err := s.accountExecutor().AccountSnapshot(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": fmt.Sprintf("获取账户快照失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, portfolio.BuildStressReport(ctx, s.config, snapshot))
+}
+
+// handlePerformance returns Sharpe/Sortino and related metrics including funding carry
+// handlePerformance 返回包含资金费收益的夏普/索提诺等绩效指标
+func (s *Server) handlePerformance(ctx context.Context, c *app.RequestContext) {
+	in, status, err := s.loadPerformanceInputs(ctx, c)
+	if err != nil {
+		c.JSON(status, utils.H{"error": err.Error()})
+		return
+	}
+
+	report := portfolio.CalculatePerformance(in.trades, in.funding, in.startEquity, in.start, in.end, s.config.RiskFreeRate/100)
+	c.JSON(http.StatusOK, report)
+}
+
+// handleRMultiples returns the R-multiple histogram and expectancy of trades closed in the last ?days= (default 90)
+// handleRMultiples 返回最近 ?days= 天（默认 90）已平仓交易的 R 倍数直方图和期望值
+// ?width= sets the bucket width in R (default 0.5)
+// ?width= 设置桶宽（R，默认 0.5）
+func (s *Server) handleRMultiples(ctx context.Context, c *app.RequestContext) {
+	days := 90
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	if days < 1 {
+		days = 1
+	}
+	var width float64
+	if w := c.Query("width"); w != "" {
+		fmt.Sscanf(w, "%f", &width)
+	}
+
+	trades, err := s.storage.GetClosedPositions(time.Now().AddDate(0, 0, -days))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, portfolio.CalculateRMultiples(trades, width))
+}
 
-	// Calculate total pages
-	// 计算总页数
-	totalPages := (totalCount + pageSize - 1) / pageSize
+// handleExecutionCosts returns the slippage and fee statistics of orders executed in the last ?days= (default 30)
+// handleExecutionCosts 返回最近 ?days= 天（默认 30）已执行订单的滑点与手续费统计
+func (s *Server) handleExecutionCosts(ctx context.Context, c *app.RequestContext) {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	if days < 1 {
+		days = 1
+	}
 
-	// Get batches with pagination
-	// 获取分页的批次
-	batches, err := s.storage.GetBatchesWithPagination(offset, pageSize)
+	costs, err := s.storage.GetExecutionCosts(time.Now().AddDate(0, 0, -days))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, portfolio.CalculateExecutionCosts(costs))
+}
 
-	// Create template with custom functions
-	// 创建带自定义函数的模板
-	funcMap := template.FuncMap{
-		"extractAction": extractActionFromDecision,
-		"add": func(a, b int) int {
-			return a + b
-		},
-		"sub": func(a, b int) int {
-			return a - b
-		},
-		"iterate": func(count int) []int {
-			result := make([]int, count)
-			for i := 0; i < count; i++ {
-				result[i] = i
-			}
-			return result
-		},
+// handleStopSlippage returns the slippage of stop-loss fills in the last ?days= (default 30), by symbol and order type
+// handleStopSlippage 返回最近 ?days= 天（默认 30）止损成交的滑点，按交易对和执行方式分组
+func (s *Server) handleStopSlippage(ctx context.Context, c *app.RequestContext) {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	if days < 1 {
+		days = 1
 	}
-	tmpl := template.Must(template.New("trade_history.html").Funcs(funcMap).ParseFiles("internal/web/templates/trade_history.html"))
 
-	data := map[string]interface{}{
-		"Batches":     batches,
-		"CurrentPage": page,
-		"PageSize":    pageSize,
-		"TotalCount":  totalCount,
-		"TotalPages":  totalPages,
-		"HasPrev":     page > 1,
-		"HasNext":     page < totalPages,
+	slips, err := s.storage.GetStopSlippages(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, portfolio.CalculateStopSlippage(slips))
+}
 
-	// Execute template and render
-	// 执行模板并渲染
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+// handleAnalystAttribution attributes trades closed in the last ?days= (default 90) to the analyst reports behind them
+// handleAnalystAttribution 将最近 ?days= 天（默认 90）已平仓交易归因到其决策所依据的分析师报告
+// ?half_life_days= sets how fast older trades lose weight (default 30)
+// ?half_life_days= 设置旧交易权重衰减的速度（默认 30）
+func (s *Server) handleAnalystAttribution(ctx context.Context, c *app.RequestContext) {
+	days := 90
+	if d := c.Query("days"); d != "" {
+		fmt.Sscanf(d, "%d", &days)
+	}
+	if days < 1 {
+		days = 1
+	}
+	var halfLifeDays float64
+	if h := c.Query("half_life_days"); h != "" {
+		fmt.Sscanf(h, "%f", &halfLifeDays)
+	}
+
+	trades, err := s.storage.GetClosedPositions(time.Now().AddDate(0, 0, -days))
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
 		return
 	}
 
-	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	// Trades closed in the window may have been opened before it, so sessions reach back to the earliest entry
+	// 窗口内平仓的交易可能在窗口之前开仓，因此会话从最早的开仓时间开始查询
+	since := time.Now().AddDate(0, 0, -days)
+	for _, t := range trades {
+		if t.EntryTime.Before(since) {
+			since = t.EntryTime
+		}
+	}
+	sessions, err := s.storage.GetEntrySessions(since.Add(-24 * time.Hour))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, portfolio.CalculateAnalystAttribution(trades, sessions, time.Now(), time.Duration(halfLifeDays*24*float64(time.Hour))))
 }
 
-// handleGetConfig returns the current trading interval configuration
-// handleGetConfig 返回当前的交易间隔配置
-func (s *Server) handleGetConfig(ctx context.Context, c *app.RequestContext) {
-	response := map[string]interface{}{
-		"trading_interval": s.scheduler.GetTimeframe(),
-		"available_intervals": []string{
-			"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "12h", "1d",
-		},
+// handleExecutorMetrics returns the latency and error stats of exchange calls and the current request weight usage
+// handleExecutorMetrics 返回交易所调用的耗时和错误统计，以及当前请求权重使用情况
+func (s *Server) handleExecutorMetrics(ctx context.Context, c *app.RequestContext) {
+	resp := utils.H{
+		"calls": executors.SharedCallMetrics().Snapshot(),
 	}
-	c.JSON(http.StatusOK, response)
+	if limiter := dataflows.SharedWeightLimiter(s.config); limiter != nil {
+		used, max := limiter.Usage()
+		resp["weight_used"] = used
+		resp["weight_limit"] = max
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
-// handleUpdateConfig updates the trading interval temporarily (in memory only)
-// handleUpdateConfig 临时更新交易间隔（仅在内存中）
-func (s *Server) handleUpdateConfig(ctx context.Context, c *app.RequestContext) {
-	// Parse request body
-	// 解析请求体
-	var req struct {
-		TradingInterval string `json:"trading_interval"`
+// handleLLMMetrics returns the last rate limit quota reported for each LLM model
+// handleLLMMetrics 返回每个 LLM 模型最近一次报告的限流配额
+func (s *Server) handleLLMMetrics(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, utils.H{
+		"models":                agents.SharedLLMQuota(s.config).Snapshot(),
+		"min_remaining_percent": s.config.LLMQuotaMinRemainingPercent,
+		"fallback_model":        s.config.LLMFallbackModel,
+	})
+}
+
+// handleSymbolPauses lists the symbols whose new entries are paused
+// handleSymbolPauses 列出已暂停新开仓的交易对
+func (s *Server) handleSymbolPauses(ctx context.Context, c *app.RequestContext) {
+	pauses, err := executors.NewSymbolPauseRegistry(s.storage, s.config, s.logger).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, utils.H{"pauses": pauses})
+}
 
+// handlePauseSymbol pauses new entries on a symbol, resuming at resume_at (RFC3339) or after duration when given
+// handlePauseSymbol 暂停交易对的新开仓，提供 resume_at（RFC3339）或 duration 时按时自动恢复
+func (s *Server) handlePauseSymbol(ctx context.Context, c *app.RequestContext) {
+	var req struct {
+		Reason   string `json:"reason"`
+		ResumeAt string `json:"resume_at"`
+		Duration string `json:"duration"`
+	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
 		return
 	}
+	if req.ResumeAt != "" && req.Duration != "" {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Use either resume_at or duration, not both"})
+		return
+	}
 
-	// Validate trading interval
-	// 验证交易间隔
-	validIntervals := map[string]bool{
-		"1m": true, "3m": true, "5m": true, "15m": true, "30m": true,
-		"1h": true, "2h": true, "4h": true, "6h": true, "12h": true, "1d": true,
+	var resumeAt *time.Time
+	if req.ResumeAt != "" {
+		t, err := time.Parse(time.RFC3339, req.ResumeAt)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid resume_at, expected RFC3339"})
+			return
+		}
+		resumeAt = &t
+	}
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid duration"})
+			return
+		}
+		t := time.Now().Add(d)
+		resumeAt = &t
+	}
+	if req.Reason == "" {
+		req.Reason = "手动暂停"
+	}
+
+	pause, err := executors.NewSymbolPauseRegistry(s.storage, s.config, s.logger).Pause(c.Param("symbol"), req.Reason, resumeAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"status": "success", "pause": pause})
+}
+
+// handleResumeSymbol lifts the pause on a symbol
+// handleResumeSymbol 解除交易对的暂停
+func (s *Server) handleResumeSymbol(ctx context.Context, c *app.RequestContext) {
+	symbol := c.Param("symbol")
+	if err := executors.NewSymbolPauseRegistry(s.storage, s.config, s.logger).Resume(symbol); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utils.H{"status": "success", "symbol": s.config.GetBinanceSymbolFor(symbol)})
+}
+
+// handleDebugCaptures lists the symbols armed for debug capture and their remaining cycles
+// handleDebugCaptures 列出已开启调试采集的交易对及其剩余周期数
+func (s *Server) handleDebugCaptures(ctx context.Context, c *app.RequestContext) {
+	c.JSON(http.StatusOK, utils.H{
+		"captures":  capture.Shared(s.config).Armed(),
+		"directory": filepath.Join(s.config.ResultsDir, "debug_capture"),
+	})
+}
+
+// handleArmDebugCapture captures the next cycles of a symbol, DEBUG_CAPTURE_CYCLES when cycles is not given
+// handleArmDebugCapture 为交易对开启接下来若干个周期的采集，未提供 cycles 时使用 DEBUG_CAPTURE_CYCLES
+func (s *Server) handleArmDebugCapture(ctx context.Context, c *app.RequestContext) {
+	var req struct {
+		Cycles int `json:"cycles"`
+	}
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	if req.Cycles < 0 {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "cycles must be positive"})
+		return
+	}
+	if req.Cycles == 0 {
+		req.Cycles = s.config.DebugCaptureCycles
+	}
+
+	symbol := s.configuredSymbol(c.Param("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("%s is not managed by this instance", s.config.GetBinanceSymbolFor(c.Param("symbol")))})
+		return
+	}
+	capture.Shared(s.config).Arm(symbol, req.Cycles)
+	s.logger.Info(fmt.Sprintf("🗂️  【%s】已开启调试采集，接下来 %d 个周期", symbol, req.Cycles))
+	c.JSON(http.StatusOK, utils.H{"status": "success", "symbol": s.config.GetBinanceSymbolFor(symbol), "cycles": req.Cycles})
+}
+
+// handleDisarmDebugCapture stops capturing a symbol
+// handleDisarmDebugCapture 关闭交易对的调试采集
+func (s *Server) handleDisarmDebugCapture(ctx context.Context, c *app.RequestContext) {
+	symbol := s.configuredSymbol(c.Param("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("%s is not managed by this instance", s.config.GetBinanceSymbolFor(c.Param("symbol")))})
+		return
+	}
+	capture.Shared(s.config).Arm(symbol, 0)
+	c.JSON(http.StatusOK, utils.H{"status": "success", "symbol": s.config.GetBinanceSymbolFor(symbol)})
+}
+
+// configuredSymbol returns the configured trading pair matching a path parameter such as BTCUSDT, or ""
+// configuredSymbol 返回与路径参数（如 BTCUSDT）匹配的已配置交易对，未匹配时返回 ""
+func (s *Server) configuredSymbol(param string) string {
+	binanceSymbol := s.config.GetBinanceSymbolFor(param)
+	for _, symbol := range s.config.CryptoSymbols {
+		if s.config.GetBinanceSymbolFor(symbol) == binanceSymbol {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// analysisRequestResponse is an on-demand analysis request as returned by the API
+// analysisRequestResponse 是 API 返回的即时分析请求
+type analysisRequestResponse struct {
+	ID          int64      `json:"id"`
+	Symbol      string     `json:"symbol"`
+	RequestedBy string     `json:"requested_by"`
+	Status      string     `json:"status"`
+	BatchID     string     `json:"batch_id"`
+	Error       string     `json:"error,omitempty"`
+	RequestedAt time.Time  `json:"requested_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// handleAnalyzeSymbol queues an immediate pipeline run for one symbol on behalf of the logged-in operator
+// handleAnalyzeSymbol 代表已登录的操作员为单个交易对排队立即运行分析流程
+// The web login is the bot's only account, so it carries the operator role
+// Web 登录账户是机器人唯一的账户，因此具有操作员权限
+func (s *Server) handleAnalyzeSymbol(ctx context.Context, c *app.RequestContext) {
+	if s.analysisTrigger == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "On-demand analysis is not available"})
+		return
+	}
+
+	symbol := s.configuredSymbol(c.Param("symbol"))
+	if symbol == "" {
+		c.JSON(http.StatusNotFound, utils.H{"error": fmt.Sprintf("%s is not managed by this instance", s.config.GetBinanceSymbolFor(c.Param("symbol")))})
+		return
+	}
+
+	if err := s.analysisTrigger.Reserve(symbol); err != nil {
+		status := http.StatusServiceUnavailable
+		switch {
+		case errors.Is(err, scheduler.ErrAnalysisInProgress):
+			status = http.StatusConflict
+		case errors.Is(err, scheduler.ErrAnalysisRateLimited):
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, utils.H{"error": err.Error(), "symbol": symbol})
+		return
+	}
+
+	username := c.GetString("username")
+	job := scheduler.AnalysisJob{Symbol: symbol, RequestedBy: username, RequestedAt: time.Now()}
+	requestID, err := s.storage.SaveAnalysisRequest(&storage.AnalysisRequest{
+		Symbol:      symbol,
+		RequestedBy: username,
+		Status:      storage.AnalysisRequestQueued,
+		RequestedAt: job.RequestedAt,
+	})
+	if err != nil {
+		s.analysisTrigger.Release(symbol)
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	job.RequestID = requestID
+
+	if err := s.analysisTrigger.Enqueue(job); err != nil {
+		_ = s.storage.UpdateAnalysisRequest(requestID, storage.AnalysisRequestFailed, "", err.Error())
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": err.Error(), "symbol": symbol})
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("🔎 【%s】%s 请求即时分析（请求 #%d）", symbol, username, requestID))
+	c.JSON(http.StatusAccepted, utils.H{"status": storage.AnalysisRequestQueued, "request_id": requestID, "symbol": symbol})
+}
+
+// handleAnalysisRequest returns the status of an on-demand analysis and the batch of sessions it produced
+// handleAnalysisRequest 返回即时分析的状态及其生成的会话批次
+func (s *Server) handleAnalysisRequest(ctx context.Context, c *app.RequestContext) {
+	var requestID int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &requestID); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid request id"})
+		return
+	}
+
+	req, err := s.storage.GetAnalysisRequest(requestID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if req == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "analysis request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, analysisRequestResponse{
+		ID:          req.ID,
+		Symbol:      req.Symbol,
+		RequestedBy: req.RequestedBy,
+		Status:      req.Status,
+		BatchID:     req.BatchID,
+		Error:       req.Error,
+		RequestedAt: req.RequestedAt,
+		StartedAt:   req.StartedAt,
+		CompletedAt: req.CompletedAt,
+	})
+}
+
+// jobRequest is the body of a job submission
+// jobRequest 是提交任务的请求体
+type jobRequest struct {
+	Kind   string            `json:"kind"`
+	Params map[string]string `json:"params"`
+}
+
+// jobResponse is a job as returned by the API
+// jobResponse 是 API 返回的任务
+type jobResponse struct {
+	jobs.Job
+	Percent float64 `json:"percent"`
+}
+
+// jobFromParam looks up the job named by the :id path parameter, writing the error response when there is none
+// jobFromParam 查找路径参数 :id 指定的任务，不存在时写入错误响应
+func (s *Server) jobFromParam(c *app.RequestContext) (int64, bool) {
+	if s.jobs == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Background jobs are not available"})
+		return 0, false
+	}
+	var id int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid job id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// handleJobs lists the kept jobs, newest first, together with the kinds that can be submitted
+// handleJobs 列出保留的任务（最新的在前）以及可提交的任务类型
+func (s *Server) handleJobs(ctx context.Context, c *app.RequestContext) {
+	if s.jobs == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Background jobs are not available"})
+		return
+	}
+	list := s.jobs.List()
+	resp := make([]jobResponse, 0, len(list))
+	for _, j := range list {
+		resp = append(resp, jobResponse{Job: j, Percent: j.Percent()})
+	}
+	c.JSON(http.StatusOK, utils.H{"jobs": resp, "kinds": s.jobs.Kinds()})
+}
+
+// handleSubmitJob starts a background job and returns its ID without waiting for it
+// handleSubmitJob 启动后台任务并立即返回其 ID，不等待任务完成
+func (s *Server) handleSubmitJob(ctx context.Context, c *app.RequestContext) {
+	if s.jobs == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.H{"error": "Background jobs are not available"})
+		return
+	}
+	var req jobRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
+	}
+
+	username := c.GetString("username")
+	job, err := s.jobs.Submit(strings.TrimSpace(req.Kind), req.Params, username)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error(), "kinds": s.jobs.Kinds()})
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("🧰 %s 提交后台任务 #%d（%s）", username, job.ID, job.Kind))
+	c.JSON(http.StatusAccepted, utils.H{"status": job.Status, "job_id": job.ID, "job": jobResponse{Job: job, Percent: job.Percent()}})
+}
+
+// handleJob returns the status, progress and, once finished, the result of a job
+// handleJob 返回任务的状态、进度以及结束后的结果
+func (s *Server) handleJob(ctx context.Context, c *app.RequestContext) {
+	id, ok := s.jobFromParam(c)
+	if !ok {
+		return
+	}
+	job, err := s.jobs.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, jobResponse{Job: job, Percent: job.Percent()})
+}
+
+// handleCancelJob cancels a queued or running job; it shows as cancelled once the job has stopped
+// handleCancelJob 取消排队中或运行中的任务；任务停止后状态变为已取消
+func (s *Server) handleCancelJob(ctx context.Context, c *app.RequestContext) {
+	id, ok := s.jobFromParam(c)
+	if !ok {
+		return
+	}
+	switch err := s.jobs.Cancel(id); {
+	case errors.Is(err, jobs.ErrNotFound):
+		c.JSON(http.StatusNotFound, utils.H{"error": err.Error()})
+	case errors.Is(err, jobs.ErrFinished):
+		c.JSON(http.StatusConflict, utils.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+	default:
+		s.logger.Info(fmt.Sprintf("⏹️ %s 取消后台任务 #%d", c.GetString("username"), id))
+		c.JSON(http.StatusAccepted, utils.H{"status": "cancelling", "job_id": id})
+	}
+}
+
+// lessonResponse is a trading lesson as returned by the API
+// lessonResponse 是 API 返回的交易规则
+type lessonResponse struct {
+	ID        int64     `json:"id"`
+	Symbol    string    `json:"symbol"`
+	Lesson    string    `json:"lesson"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// lessonRequest is the body of lesson create and update calls; omitted fields keep their current value on update
+// lessonRequest 是创建和更新规则的请求体；更新时省略的字段保持原值
+type lessonRequest struct {
+	Symbol  *string `json:"symbol"`
+	Lesson  *string `json:"lesson"`
+	Enabled *bool   `json:"enabled"`
+}
+
+// newLessonResponse converts a stored lesson to its API form
+// newLessonResponse 将存储的规则转换为 API 格式
+func newLessonResponse(l *storage.TradingLesson) lessonResponse {
+	return lessonResponse{
+		ID:        l.ID,
+		Symbol:    l.Symbol,
+		Lesson:    l.Lesson,
+		Enabled:   l.Enabled,
+		CreatedBy: l.CreatedBy,
+		CreatedAt: l.CreatedAt,
+		UpdatedAt: l.UpdatedAt,
+	}
+}
+
+// applyLessonRequest copies the fields set in req onto lesson, normalizing the symbol to Binance format
+// applyLessonRequest 将 req 中设置的字段写入 lesson，交易对转换为币安格式
+func (s *Server) applyLessonRequest(lesson *storage.TradingLesson, req lessonRequest) error {
+	if req.Symbol != nil {
+		lesson.Symbol = strings.ToUpper(s.config.GetBinanceSymbolFor(strings.TrimSpace(*req.Symbol)))
+	}
+	if req.Lesson != nil {
+		lesson.Lesson = strings.TrimSpace(*req.Lesson)
+	}
+	if req.Enabled != nil {
+		lesson.Enabled = *req.Enabled
+	}
+	if lesson.Lesson == "" {
+		return errors.New("lesson must not be empty")
+	}
+	return nil
+}
+
+// handleLessons lists all trading lessons, disabled ones included
+// handleLessons 列出所有交易规则（包括已停用的）
+func (s *Server) handleLessons(ctx context.Context, c *app.RequestContext) {
+	lessons, err := s.storage.GetLessons(false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	resp := make([]lessonResponse, 0, len(lessons))
+	for _, l := range lessons {
+		resp = append(resp, newLessonResponse(l))
+	}
+	c.JSON(http.StatusOK, utils.H{"lessons": resp})
+}
+
+// handleCreateLesson adds a trading lesson; it is enabled unless the body says otherwise
+// handleCreateLesson 添加交易规则；除非请求体另行指定，否则默认启用
+func (s *Server) handleCreateLesson(ctx context.Context, c *app.RequestContext) {
+	var req lessonRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
+	}
+
+	lesson := &storage.TradingLesson{Enabled: true, CreatedBy: c.GetString("username")}
+	if err := s.applyLessonRequest(lesson, req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	id, err := s.storage.SaveLesson(lesson)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	saved, err := s.storage.GetLesson(id)
+	if err != nil || saved == nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": fmt.Sprintf("failed to reload lesson %d: %v", id, err)})
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("📘 %s 添加交易规则 #%d", lesson.CreatedBy, id))
+	c.JSON(http.StatusCreated, utils.H{"status": "success", "lesson": newLessonResponse(saved)})
+}
+
+// handleUpdateLesson edits the symbol, text or enabled flag of a lesson
+// handleUpdateLesson 修改规则的交易对、内容或启用状态
+func (s *Server) handleUpdateLesson(ctx context.Context, c *app.RequestContext) {
+	var id int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid lesson id"})
+		return
+	}
+	var req lessonRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
+	}
+
+	lesson, err := s.storage.GetLesson(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if lesson == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "lesson not found"})
+		return
+	}
+	if err := s.applyLessonRequest(lesson, req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": err.Error()})
+		return
+	}
+	if err := s.storage.UpdateLesson(lesson); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if updated, err := s.storage.GetLesson(id); err == nil && updated != nil {
+		lesson = updated
+	}
+	c.JSON(http.StatusOK, utils.H{"status": "success", "lesson": newLessonResponse(lesson)})
+}
+
+// handleDeleteLesson removes a lesson
+// handleDeleteLesson 删除规则
+func (s *Server) handleDeleteLesson(ctx context.Context, c *app.RequestContext) {
+	var id int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid lesson id"})
+		return
+	}
+	lesson, err := s.storage.GetLesson(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if lesson == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "lesson not found"})
+		return
+	}
+	if err := s.storage.DeleteLesson(id); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("📘 %s 删除交易规则 #%d", c.GetString("username"), id))
+	c.JSON(http.StatusOK, utils.H{"status": "success", "id": id})
+}
+
+// strategyReviewResponse is the JSON shape of a strategy review, with the stored report embedded as JSON
+// strategyReviewResponse 是策略复盘的 JSON 结构，保存的复盘内容以 JSON 嵌入
+type strategyReviewResponse struct {
+	ID          int64           `json:"id"`
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"`
+	Model       string          `json:"model"`
+	Trades      int             `json:"trades"`
+	NetPnL      float64         `json:"net_pnl"`
+	Report      json.RawMessage `json:"report"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// handleStrategyReviews lists the latest ?limit= (default 10) strategy reviews, newest first
+// handleStrategyReviews 列出最近 ?limit= 条（默认 10）策略复盘，最新的在前
+func (s *Server) handleStrategyReviews(ctx context.Context, c *app.RequestContext) {
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	reviews, err := s.storage.GetStrategyReviews(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	resp := make([]strategyReviewResponse, 0, len(reviews))
+	for _, r := range reviews {
+		resp = append(resp, strategyReviewResponse{
+			ID:          r.ID,
+			PeriodStart: r.PeriodStart,
+			PeriodEnd:   r.PeriodEnd,
+			Model:       r.Model,
+			Trades:      r.Trades,
+			NetPnL:      r.NetPnL,
+			Report:      json.RawMessage(r.Report),
+			CreatedAt:   r.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, utils.H{"reviews": resp})
+}
+
+// traderConfigResponse is a trader config version as returned by the API, with the diff report of its shadow run
+// traderConfigResponse 是 API 返回的交易员配置版本，附带其影子运行的差异报告
+type traderConfigResponse struct {
+	Fingerprint  string                   `json:"fingerprint"`
+	Model        string                   `json:"model"`
+	Prompt       string                   `json:"prompt"`
+	Status       string                   `json:"status"`
+	ShadowCycles int                      `json:"shadow_cycles"`
+	CreatedAt    time.Time                `json:"created_at"`
+	PromotedAt   *time.Time               `json:"promoted_at,omitempty"`
+	Report       *agents.ShadowDiffReport `json:"report,omitempty"`
+}
+
+// shadowDecisionResponse is one symbol's live and shadow decision in a shadow cycle
+// shadowDecisionResponse 是某轮影子运行中单个交易对的实盘决策与影子决策
+type shadowDecisionResponse struct {
+	Cycle            int       `json:"cycle"`
+	Symbol           string    `json:"symbol"`
+	LiveAction       string    `json:"live_action"`
+	ShadowAction     string    `json:"shadow_action"`
+	LiveConfidence   float64   `json:"live_confidence"`
+	ShadowConfidence float64   `json:"shadow_confidence"`
+	LiveLeverage     int       `json:"live_leverage"`
+	ShadowLeverage   int       `json:"shadow_leverage"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// newTraderConfigResponse converts a stored trader config to its API form, summarizing its shadow decisions
+// newTraderConfigResponse 将存储的交易员配置转换为 API 格式，并汇总其影子决策
+func (s *Server) newTraderConfigResponse(tc *storage.TraderConfig) (traderConfigResponse, []*storage.ShadowDecision, error) {
+	resp := traderConfigResponse{
+		Fingerprint:  tc.Fingerprint,
+		Model:        tc.Model,
+		Prompt:       tc.Prompt,
+		Status:       tc.Status,
+		ShadowCycles: tc.ShadowCycles,
+		CreatedAt:    tc.CreatedAt,
+		PromotedAt:   tc.PromotedAt,
+	}
+	decisions, err := s.storage.GetShadowDecisions(tc.Fingerprint)
+	if err != nil {
+		return resp, nil, err
+	}
+	if len(decisions) > 0 {
+		report := agents.BuildShadowDiffReport(decisions)
+		resp.Report = &report
+	}
+	return resp, decisions, nil
+}
+
+// handleTraderConfigs lists the trader config versions, newest first
+// handleTraderConfigs 列出交易员配置版本，最新的在前
+func (s *Server) handleTraderConfigs(ctx context.Context, c *app.RequestContext) {
+	configs, err := s.storage.GetTraderConfigs()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	resp := make([]traderConfigResponse, 0, len(configs))
+	for _, tc := range configs {
+		r, _, err := s.newTraderConfigResponse(tc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+			return
+		}
+		resp = append(resp, r)
+	}
+	c.JSON(http.StatusOK, utils.H{"shadow_cycles": s.config.ShadowCycles, "configs": resp})
+}
+
+// handleTraderConfig returns a trader config version with every shadow decision it was compared on
+// handleTraderConfig 返回交易员配置版本及其参与对比的全部影子决策
+func (s *Server) handleTraderConfig(ctx context.Context, c *app.RequestContext) {
+	tc, err := s.storage.GetTraderConfig(c.Param("fingerprint"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if tc == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "trader config not found"})
+		return
+	}
+	resp, decisions, err := s.newTraderConfigResponse(tc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	rows := make([]shadowDecisionResponse, 0, len(decisions))
+	for _, d := range decisions {
+		rows = append(rows, shadowDecisionResponse{
+			Cycle:            d.Cycle,
+			Symbol:           d.Symbol,
+			LiveAction:       d.LiveAction,
+			ShadowAction:     d.ShadowAction,
+			LiveConfidence:   d.LiveConfidence,
+			ShadowConfidence: d.ShadowConfidence,
+			LiveLeverage:     d.LiveLeverage,
+			ShadowLeverage:   d.ShadowLeverage,
+			CreatedAt:        d.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, utils.H{"config": resp, "decisions": rows})
+}
+
+// handlePromoteTraderConfig switches live execution to a trader config version, retiring the live one
+// handlePromoteTraderConfig 将实盘切换为指定的交易员配置版本，并停用当前实盘配置
+//
+// The trader only uses a promoted version while it is also the configured one; otherwise the configured model and
+// prompt start a new shadow run on the next cycle.
+// 只有被切换的版本同时也是当前配置时交易员才会使用它；否则下一轮会对当前配置的模型和提示词重新开始影子运行。
+func (s *Server) handlePromoteTraderConfig(ctx context.Context, c *app.RequestContext) {
+	fingerprint := c.Param("fingerprint")
+	tc, err := s.storage.GetTraderConfig(fingerprint)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+	if tc == nil {
+		c.JSON(http.StatusNotFound, utils.H{"error": "trader config not found"})
+		return
+	}
+	if tc.Status == storage.TraderConfigLive {
+		c.JSON(http.StatusConflict, utils.H{"error": "trader config is already live"})
+		return
+	}
+	if err := s.storage.PromoteTraderConfig(fingerprint); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	s.logger.Success(fmt.Sprintf("🔁 %s 将交易员配置 %s（%s，影子运行 %d 轮）切换为实盘", c.GetString("username"), fingerprint, tc.Model, tc.ShadowCycles))
+	c.JSON(http.StatusOK, utils.H{"status": "success", "fingerprint": fingerprint})
+}
+
+// handleLeaderboardExport returns the performance window as a signed leaderboard document
+// handleLeaderboardExport 以已签名的排行榜文档形式返回绩效窗口
+func (s *Server) handleLeaderboardExport(ctx context.Context, c *app.RequestContext) {
+	in, status, err := s.loadPerformanceInputs(ctx, c)
+	if err != nil {
+		c.JSON(status, utils.H{"error": err.Error()})
+		return
+	}
+
+	open, err := s.storage.GetActivePositions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	key, err := portfolio.LoadOrCreateSigningKey(s.config.LeaderboardKeyPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	bot := s.config.BotInstanceID
+	if bot == "" {
+		bot, _ = os.Hostname()
+	}
+	export := portfolio.BuildLeaderboardExport(bot, in.trades, open, in.funding, in.startEquity, in.start, in.end, s.config.RiskFreeRate/100)
+	if err := export.Sign(key); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=leaderboard-%s.json", in.end.UTC().Format("20060102")))
+	c.JSON(http.StatusOK, export)
+}
+
+// intentResponse is a trade intent as returned by the API
+// intentResponse 是 API 返回的交易意图
+type intentResponse struct {
+	ID            int64     `json:"id"`
+	BatchID       string    `json:"batch_id"`
+	Symbol        string    `json:"symbol"`
+	Action        string    `json:"action"`
+	Reason        string    `json:"reason"`
+	Status        string    `json:"status"`
+	Detail        string    `json:"detail"`
+	OrderID       string    `json:"order_id"`
+	PositionID    string    `json:"position_id"`
+	FilledQty     float64   `json:"filled_qty"`
+	FillPrice     float64   `json:"fill_price"`
+	ErrorCategory string    `json:"error_category"` // 失败类别，空表示未失败 / Failure category, empty when nothing failed
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// handleTradeIntents returns recent trade intents with the stage each one reached
+// handleTradeIntents 返回最近的交易意图及其到达的阶段
+func (s *Server) handleTradeIntents(ctx context.Context, c *app.RequestContext) {
+	limit := 50 // Default limit / 默认条数
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	intents, err := s.storage.GetTradeIntents(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	response := make([]intentResponse, 0, len(intents))
+	for _, i := range intents {
+		response = append(response, intentResponse{
+			ID:            i.ID,
+			BatchID:       i.BatchID,
+			Symbol:        i.Symbol,
+			Action:        i.Action,
+			Reason:        i.Reason,
+			Status:        i.Status,
+			Detail:        i.Detail,
+			OrderID:       i.OrderID,
+			PositionID:    i.PositionID,
+			FilledQty:     i.FilledQty,
+			FillPrice:     i.FillPrice,
+			ErrorCategory: i.ErrorCategory,
+			CreatedAt:     i.CreatedAt,
+			UpdatedAt:     i.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"intents": response,
+		"count":   len(response),
+	})
+}
+
+// handleTradeIntentEvents returns every status transition of one trade intent
+// handleTradeIntentEvents 返回单个交易意图的全部状态变化
+func (s *Server) handleTradeIntentEvents(ctx context.Context, c *app.RequestContext) {
+	var intentID int64
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &intentID); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "invalid intent id"})
+		return
+	}
+
+	events, err := s.storage.GetTradeIntentEvents(intentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	type eventResponse struct {
+		Status    string    `json:"status"`
+		Detail    string    `json:"detail"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+	response := make([]eventResponse, 0, len(events))
+	for _, e := range events {
+		response = append(response, eventResponse{Status: e.Status, Detail: e.Detail, Timestamp: e.Timestamp})
+	}
+
+	c.JSON(http.StatusOK, utils.H{
+		"intent_id": intentID,
+		"events":    response,
+	})
+}
+
+// handleTradeHistory renders the full trade history page with pagination
+// handleTradeHistory 渲染带分页的完整交易历史页面
+func (s *Server) handleTradeHistory(ctx context.Context, c *app.RequestContext) {
+	// Get pagination parameters
+	// 获取分页参数
+	page := 1
+	pageSize := 50 // Default page size / 默认每页大小
+
+	if pageStr := c.Query("page"); pageStr != "" {
+		fmt.Sscanf(pageStr, "%d", &page)
+		if page < 1 {
+			page = 1
+		}
+	}
+
+	if pageSizeStr := c.Query("page_size"); pageSizeStr != "" {
+		fmt.Sscanf(pageSizeStr, "%d", &pageSize)
+		// Limit page size to valid options
+		// 限制每页大小为有效选项
+		if pageSize != 20 && pageSize != 50 && pageSize != 100 {
+			pageSize = 50
+		}
+	}
+
+	// Calculate offset
+	// 计算偏移量
+	offset := (page - 1) * pageSize
+
+	// Get total batch count
+	// 获取总批次数
+	totalCount, err := s.storage.GetTotalBatchCount()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	// Calculate total pages
+	// 计算总页数
+	totalPages := (totalCount + pageSize - 1) / pageSize
+
+	// Get batches with pagination
+	// 获取分页的批次
+	batches, err := s.storage.GetBatchesWithPagination(offset, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	// Create template with custom functions
+	// 创建带自定义函数的模板
+	funcMap := template.FuncMap{
+		"extractAction": extractActionFromDecision,
+		"inZone":        s.inZone,
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"sub": func(a, b int) int {
+			return a - b
+		},
+		"iterate": func(count int) []int {
+			result := make([]int, count)
+			for i := 0; i < count; i++ {
+				result[i] = i
+			}
+			return result
+		},
+	}
+	tmpl := template.Must(template.New("trade_history.html").Funcs(funcMap).ParseFiles("internal/web/templates/trade_history.html"))
+
+	data := map[string]interface{}{
+		"Batches":     batches,
+		"CurrentPage": page,
+		"PageSize":    pageSize,
+		"TotalCount":  totalCount,
+		"TotalPages":  totalPages,
+		"HasPrev":     page > 1,
+		"HasNext":     page < totalPages,
+	}
+
+	// Execute template and render
+	// 执行模板并渲染
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		c.JSON(http.StatusInternalServerError, utils.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// handleGetConfig returns the current trading interval configuration
+// handleGetConfig 返回当前的交易间隔配置
+func (s *Server) handleGetConfig(ctx context.Context, c *app.RequestContext) {
+	response := map[string]interface{}{
+		"trading_interval": s.scheduler.GetTimeframe(),
+		"available_intervals": []string{
+			"1m", "3m", "5m", "15m", "30m", "1h", "2h", "4h", "6h", "8h", "12h", "1d",
+		},
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleUpdateConfig updates the trading interval temporarily (in memory only)
+// handleUpdateConfig 临时更新交易间隔（仅在内存中）
+func (s *Server) handleUpdateConfig(ctx context.Context, c *app.RequestContext) {
+	// Parse request body
+	// 解析请求体
+	var req struct {
+		TradingInterval string `json:"trading_interval"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid request body"})
+		return
 	}
 
-	if !validIntervals[req.TradingInterval] {
+	// Validate trading interval; custom intervals that divide a day (e.g. 90m) are aggregated from lower ones
+	// 验证交易间隔；能整除一天的自定义间隔（如 90m）由更短周期合成
+	if _, err := scheduler.TimeframeDuration(req.TradingInterval); err != nil {
 		c.JSON(http.StatusBadRequest, utils.H{"error": "Invalid trading interval"})
 		return
 	}